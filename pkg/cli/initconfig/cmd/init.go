@@ -179,7 +179,44 @@ func createOrUpdateMongodbIndex(ctx context.Context) {
 		commonrepo.NewProjectManagementColl(),
 		commonrepo.NewImageTagsCollColl(),
 		commonrepo.NewLLMIntegrationColl(),
+		commonrepo.NewLLMUsageColl(),
+		commonrepo.NewLLMBudgetColl(),
 		commonrepo.NewReleasePlanColl(),
+		commonrepo.NewWorkflowScheduleColl(),
+		commonrepo.NewWorkflowTaskArtifactColl(),
+		commonrepo.NewArtifactRetentionPolicyColl(),
+		commonrepo.NewCoverageRecordColl(),
+		commonrepo.NewImagePushTriggerColl(),
+		commonrepo.NewImagePushEventColl(),
+		commonrepo.NewEnvPromotionColl(),
+		commonrepo.NewIstioRoutingRevisionColl(),
+		commonrepo.NewDNSIntegrationColl(),
+		commonrepo.NewEnvDNSRecordColl(),
+		commonrepo.NewEnvTrafficMirrorColl(),
+		commonrepo.NewEnvServiceAutoscalerColl(),
+		commonrepo.NewEnvWorkloadPolicyColl(),
+		commonrepo.NewRegistryCredentialRotationColl(),
+		commonrepo.NewRegistryRetentionRuleColl(),
+		commonrepo.NewArtifactPromotionColl(),
+		commonrepo.NewJobFailureRecordColl(),
+		commonrepo.NewJobCheckpointColl(),
+		commonrepo.NewJobPodTemplateColl(),
+		commonrepo.NewParameterStoreColl(),
+		commonrepo.NewParameterStoreHistoryColl(),
+		commonrepo.NewNotificationPreferenceColl(),
+		commonrepo.NewChatOpsUserBindingColl(),
+		commonrepo.NewStatusBadgeColl(),
+		commonrepo.NewProjectQuotaColl(),
+		commonrepo.NewJobUsageRecordColl(),
+		commonrepo.NewDeployFreezeWindowColl(),
+		commonrepo.NewEnvHealthScoreColl(),
+		commonrepo.NewAlertReceiverColl(),
+		commonrepo.NewEnvAlertColl(),
+		commonrepo.NewJobKubeAccessGrantColl(),
+		commonrepo.NewEnvDebugSnapshotColl(),
+		commonrepo.NewPodExecSessionColl(),
+		commonrepo.NewPortForwardSessionColl(),
+		commonrepo.NewImageSigningKeyColl(),
 		commonrepo.NewReleasePlanLogColl(),
 		commonrepo.NewEnvServiceVersionColl(),
 		commonrepo.NewLabelColl(),
@@ -201,6 +238,7 @@ func createOrUpdateMongodbIndex(ctx context.Context) {
 
 		systemrepo.NewAnnouncementColl(),
 		systemrepo.NewOperationLogColl(),
+		systemrepo.NewAuditLogColl(),
 		modeMongodb.NewCollaborationModeColl(),
 		modeMongodb.NewCollaborationInstanceColl(),
 
@@ -209,9 +247,13 @@ func createOrUpdateMongodbIndex(ctx context.Context) {
 
 		// user related db index
 		userdb.NewUserSettingColl(),
+		userdb.NewServiceAccountTokenColl(),
+		userdb.NewIdPGroupRoleMappingColl(),
+		userdb.NewIdPGroupRoleSyncStateColl(),
 
 		// env AI analysis related db index
 		ai.NewEnvAIAnalysisColl(),
+		ai.NewEnvAnalysisIssueColl(),
 
 		// project group related db index
 		commonrepo.NewProjectGroupColl(),
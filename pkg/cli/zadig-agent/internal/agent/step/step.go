@@ -104,6 +104,11 @@ func RunStep(ctx context.Context, jobCtx *jobctl.JobContext, step *commonmodels.
 		if err != nil {
 			return err
 		}
+	case "coverage_report":
+		stepInstance, err = testing.NewCoverageReportStep(step.Spec, dirs, envs, secretEnvs, logger)
+		if err != nil {
+			return err
+		}
 	case "sonar_check":
 		stepInstance, err = scanning.NewSonarCheckStep(step.Spec, dirs, envs, secretEnvs, logger)
 		if err != nil {
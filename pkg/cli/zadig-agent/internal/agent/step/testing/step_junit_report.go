@@ -33,6 +33,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/cli/zadig-agent/helper/log"
 	"github.com/koderover/zadig/v2/pkg/cli/zadig-agent/internal/common/types"
 	"github.com/koderover/zadig/v2/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/v2/pkg/tool/allure"
 	"github.com/koderover/zadig/v2/pkg/tool/s3"
 	"github.com/koderover/zadig/v2/pkg/types/step"
 	"github.com/koderover/zadig/v2/pkg/util"
@@ -75,6 +76,14 @@ func (s *JunitReportStep) Run(ctx context.Context) error {
 	s.spec.ReportDir = util.ReplaceEnvWithValue(s.spec.ReportDir, envMap)
 
 	reportDir := filepath.Join(s.workspace, s.spec.ReportDir)
+	if s.spec.ReportType == step.TestReportTypeAllure {
+		s.Logger.Infof("Start convert allure results.")
+		allureReportDir, err := convertAllureResults(reportDir, s.spec.DestDir)
+		if err != nil {
+			return fmt.Errorf("failed to convert allure results: %s", err)
+		}
+		reportDir = allureReportDir
+	}
 	results, err := mergeGinkgoTestResults(s.spec.FileName, reportDir, s.spec.DestDir, time.Now(), s.Logger)
 	if err != nil {
 		return fmt.Errorf("failed to merge test result: %s", err)
@@ -239,3 +248,24 @@ func replaceTestSuiteTag(inputXML, replaceStr, newStr string) string {
 	}
 	return outputXML
 }
+
+// convertAllureResults converts the allure results into a junit report in a new directory,
+// so that it can be merged as the junit reports
+func convertAllureResults(resultDir, destDir string) (string, error) {
+	results, err := allure.ReadResults(resultDir)
+	if err != nil {
+		return "", err
+	}
+	content, err := allure.ToJunitXML("allure", results)
+	if err != nil {
+		return "", err
+	}
+	reportDir := filepath.Join(destDir, "allure-junit")
+	if err := os.MkdirAll(reportDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(reportDir, "allure.xml"), content, 0644); err != nil {
+		return "", err
+	}
+	return reportDir, nil
+}
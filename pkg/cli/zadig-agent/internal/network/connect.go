@@ -141,6 +141,7 @@ type HeartbeatParameters struct {
 	DiskSpace     uint64 `json:"disk_space"`
 	FreeDiskSpace uint64 `json:"free_disk_space"`
 	Hostname      string `json:"hostname"`
+	AgentVersion  string `json:"agent_version"`
 }
 
 type HeartbeatServerRequest struct {
@@ -160,6 +161,7 @@ type HeartbeatServerResponse struct {
 	VmName                 string `json:"vm_name"`
 	Description            string `json:"description"`
 	ZadigVersion           string `json:"zadig_version"`
+	AgentDownloadURL       string `json:"agent_download_url"`
 }
 
 func Heartbeat(config *AgentConfig, parameters *HeartbeatParameters) (*HeartbeatServerResponse, error) {
@@ -116,6 +116,7 @@ func Heartbeat(agentCtl *agent.AgentController, errChan chan error, successChan
 	if err != nil {
 		panic(fmt.Errorf("failed to convert platform parameters to register agent parameters: %v", err))
 	}
+	params.AgentVersion = agentconfig.BuildAgentVersion
 
 	config := &network.AgentConfig{
 		Token: agentconfig.GetAgentToken(),
@@ -127,13 +128,13 @@ func Heartbeat(agentCtl *agent.AgentController, errChan chan error, successChan
 		errChan <- err
 		return
 	}
-	if resp.NeedUpdateAgentVersion {
-		err = updater.UpdateAgent(agentCtl, resp.AgentVersion)
-		if err != nil {
-			log.Errorf("failed to update agent: %v", err)
-			errChan <- err
-			return
-		}
+	if resp.NeedUpdateAgentVersion && resp.AgentVersion != agentconfig.BuildAgentVersion {
+		// the upgrade waits for the running jobs to finish, keep the heartbeat going meanwhile
+		go func() {
+			if err := updater.UpdateAgent(agentCtl, resp.AgentVersion, resp.AgentDownloadURL); err != nil {
+				log.Errorf("failed to update agent: %v", err)
+			}
+		}()
 	}
 
 	if resp.NeedOffline {
@@ -18,14 +18,40 @@ package updater
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/koderover/zadig/v2/pkg/cli/zadig-agent/config"
 	"github.com/koderover/zadig/v2/pkg/cli/zadig-agent/helper/log"
 	"github.com/koderover/zadig/v2/pkg/cli/zadig-agent/internal/agent"
+	httpclient "github.com/koderover/zadig/v2/pkg/cli/zadig-agent/util/client"
+	tartool "github.com/koderover/zadig/v2/pkg/tool/tar"
 )
 
-func UpdateAgent(agentCtl *agent.AgentController, version string) error {
+// updating guards against the upgrade being triggered by the following heartbeats
+var updating int32
+
+func UpdateAgent(agentCtl *agent.AgentController, version, downloadURL string) error {
+	if downloadURL == "" {
+		return fmt.Errorf("no download url of zadig-agent %s", version)
+	}
+	if !atomic.CompareAndSwapInt32(&updating, 0, 1) {
+		return nil
+	}
+	log.Infof("start to update agent to version %s", version)
+
+	// download the new binary before stopping the agent, the agent keeps working if the download fails
+	binary, err := downloadAgent(version, downloadURL)
+	if err != nil {
+		atomic.StoreInt32(&updating, 0)
+		return fmt.Errorf("failed to download zadig-agent %s: %v", version, err)
+	}
+	defer os.RemoveAll(filepath.Dir(binary))
+
 	// stop agent polling job from zadig
 	agentCtl.StopPollingJob()
 
@@ -41,19 +67,95 @@ func UpdateAgent(agentCtl *agent.AgentController, version string) error {
 	agentCtl.StopRunJob()
 
 	// update agent
-	// 分四个步骤：1. 配置文件更新 2. 二进制文件更新 3. 启动新的agent 4. 删除旧的agent
-	// 1. 配置文件更新
-	err := UpdateAgentConfig(version)
+	// 分三个步骤：1. 二进制文件更新 2. 配置文件更新 3. 启动新的agent
+	// 1. 二进制文件更新
+	exe, err := replaceBinary(binary)
 	if err != nil {
-		log.Errorf("failed to update agent config: %v", err)
+		log.Errorf("failed to replace agent binary: %v", err)
 		return err
 	}
 
-	// 2. 二进制文件更新
+	// 2. 配置文件更新
+	err = UpdateAgentConfig(version)
+	if err != nil {
+		log.Errorf("failed to update agent config: %v", err)
+		return err
+	}
 
+	// 3. 启动新的agent
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		log.Errorf("failed to start the new agent: %v", err)
+		return err
+	}
+	log.Infof("agent is updated to version %s, exit the old agent", version)
+	os.Exit(0)
 	return nil
 }
 
+func downloadAgent(version, downloadURL string) (string, error) {
+	dir, err := os.MkdirTemp("", "zadig-agent-update")
+	if err != nil {
+		return "", err
+	}
+
+	tarFile := filepath.Join(dir, "zadig-agent.tar.gz")
+	if err := httpclient.Download(downloadURL, tarFile); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	if err := tartool.Untar(tarFile, dir, true); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	name := fmt.Sprintf("zadig-agent-%s-%s-v%s", runtime.GOOS, runtime.GOARCH, version)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	binary := filepath.Join(dir, name)
+	if _, err := os.Stat(binary); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("binary %s not found in the package: %v", name, err)
+	}
+	return binary, nil
+}
+
+// replaceBinary replaces the running executable with the new binary, the old one is kept with .old suffix
+func replaceBinary(binary string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(binary)
+	if err != nil {
+		return "", err
+	}
+	newFile := exe + ".new"
+	if err := os.WriteFile(newFile, data, 0755); err != nil {
+		return "", err
+	}
+
+	oldFile := exe + ".old"
+	_ = os.Remove(oldFile)
+	if err := os.Rename(exe, oldFile); err != nil {
+		return "", err
+	}
+	if err := os.Rename(newFile, exe); err != nil {
+		// roll back to the old binary
+		_ = os.Rename(oldFile, exe)
+		return "", err
+	}
+	return exe, nil
+}
+
 func UpdateAgentConfig(newVersion string) error {
 	// get old agent config
 	oldConfig, err := config.GetAgentConfig()
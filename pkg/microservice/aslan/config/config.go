@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -28,6 +29,42 @@ import (
 	"github.com/koderover/zadig/v2/pkg/setting"
 )
 
+// 本地服务/Chart缓存保留天数，默认7天
+func LocalCacheMaxAgeDays() int {
+	maxAge, err := strconv.Atoi(viper.GetString(setting.ENVLocalCacheMaxAgeDays))
+	if err != nil || maxAge <= 0 {
+		return 7
+	}
+	return maxAge
+}
+
+// 本地服务/Chart缓存总大小上限，默认10G
+func LocalCacheMaxSizeMB() int64 {
+	maxSize, err := strconv.ParseInt(viper.GetString(setting.ENVLocalCacheMaxSizeMB), 10, 64)
+	if err != nil || maxSize <= 0 {
+		return 10240
+	}
+	return maxSize
+}
+
+// 环境变更分布式锁的等待超时时间，默认60秒
+func EnvLockTimeout() time.Duration {
+	timeout, err := strconv.Atoi(viper.GetString(setting.ENVEnvLockTimeout))
+	if err != nil || timeout <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// 环境变更分布式锁的过期时间，防止持有锁的实例异常退出后锁无法释放，默认300秒
+func EnvLockExpiry() time.Duration {
+	expiry, err := strconv.Atoi(viper.GetString(setting.ENVEnvLockExpiry))
+	if err != nil || expiry <= 0 {
+		return 300 * time.Second
+	}
+	return time.Duration(expiry) * time.Second
+}
+
 func DefaultIngressClass() string {
 	return viper.GetString(setting.ENVDefaultIngressClass)
 }
@@ -209,6 +209,7 @@ const (
 	StepDistributeImage   StepType = "distribute_image"
 	StepDebugBefore       StepType = "debug_before"
 	StepDebugAfter        StepType = "debug_after"
+	StepCoverageReport    StepType = "coverage_report"
 )
 
 type JobType string
@@ -253,9 +254,15 @@ const (
 	JobGuanceyunCheck       JobType = "guanceyun-check"
 	JobGrafana              JobType = "grafana"
 	JobBlueKing             JobType = "blueking"
+	JobGitHubActions        JobType = "github-actions"
 	JobApproval             JobType = "approval"
 	JobNotification         JobType = "notification"
 	JobSAEDeploy            JobType = "sae-deploy"
+	JobTerraform            JobType = "terraform"
+	JobEnvAnalysis          JobType = "env-analysis"
+	JobCanaryAnalysis       JobType = "canary-analysis"
+	JobFeatureFlag          JobType = "feature-flag"
+	JobSubWorkflow          JobType = "sub-workflow"
 )
 
 const (
@@ -274,8 +281,9 @@ const (
 type ObservabilityType string
 
 const (
-	ObservabilityTypeGrafana   ObservabilityType = "grafana"
-	ObservabilityTypeGuanceyun ObservabilityType = "guanceyun"
+	ObservabilityTypeGrafana    ObservabilityType = "grafana"
+	ObservabilityTypeGuanceyun  ObservabilityType = "guanceyun"
+	ObservabilityTypePrometheus ObservabilityType = "prometheus"
 )
 
 type ApprovalType string
@@ -287,6 +295,22 @@ const (
 	WorkWXApproval   ApprovalType = "workwx"
 )
 
+type TerraformBinary string
+
+const (
+	TerraformBinaryTerraform TerraformBinary = "terraform"
+	TerraformBinaryOpenTofu  TerraformBinary = "tofu"
+)
+
+type TerraformAction string
+
+const (
+	// TerraformActionPlan only runs plan and uploads the plan file
+	TerraformActionPlan TerraformAction = "plan"
+	// TerraformActionApply runs plan, waits for approval if configured, then applies the uploaded plan file
+	TerraformActionApply TerraformAction = "apply"
+)
+
 type SAEUpdateStrategy string
 
 const (
@@ -311,6 +335,17 @@ const (
 	ApprovalStatusDone     ApprovalStatus = "done"
 )
 
+// ApprovalChannel is where a native approval is resolved from, recorded for audit
+type ApprovalChannel string
+
+const (
+	ApprovalChannelZadig    ApprovalChannel = "zadig"
+	ApprovalChannelOpenAPI  ApprovalChannel = "openapi"
+	ApprovalChannelLark     ApprovalChannel = "lark"
+	ApprovalChannelDingTalk ApprovalChannel = "dingtalk"
+	ApprovalChannelCallback ApprovalChannel = "callback"
+)
+
 type DeploySourceType string
 
 const (
@@ -386,6 +421,14 @@ const (
 	DeployConfig DeployContent = "config"
 )
 
+type SmokeProbeType string
+
+const (
+	SmokeProbeHTTP SmokeProbeType = "http"
+	SmokeProbeGRPC SmokeProbeType = "grpc"
+	SmokeProbeTCP  SmokeProbeType = "tcp"
+)
+
 type StageType string
 
 const (
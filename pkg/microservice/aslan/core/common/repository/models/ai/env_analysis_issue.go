@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnvAnalysisIssue is the back-link of the issue created in the issue tracker for a finding of the env analysis,
+// the findings with the same fingerprint in the env share the issue
+type EnvAnalysisIssue struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
+	ProjectName string             `bson:"project_name"   json:"project_name"`
+	EnvName     string             `bson:"env_name"       json:"env_name"`
+	Production  bool               `bson:"production"     json:"production"`
+	Fingerprint string             `bson:"fingerprint"    json:"fingerprint"`
+	Tracker     string             `bson:"tracker"        json:"tracker"`
+	AnalysisID  string             `bson:"analysis_id"    json:"analysis_id"`
+	IssueKey    string             `bson:"issue_key"      json:"issue_key"`
+	IssueURL    string             `bson:"issue_url"      json:"issue_url"`
+	CreatedBy   string             `bson:"created_by"     json:"created_by"`
+	CreateTime  int64              `bson:"create_time"    json:"create_time"`
+}
+
+func (EnvAnalysisIssue) TableName() string {
+	return "env_analysis_issue"
+}
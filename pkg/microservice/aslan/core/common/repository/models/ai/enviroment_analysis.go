@@ -32,8 +32,25 @@ type EnvAIAnalysis struct {
 	Updated     int64              `bson:"updated"                      json:"updated"`
 	TriggerName string             `bson:"trigger_name"                 json:"trigger_name"`
 	CreatedBy   string             `bson:"created_by"                   json:"created_by"`
+
+	// ServiceNames and Workloads are the scope of the analysis, empty if the whole namespace is analyzed
+	ServiceNames []string `bson:"service_names,omitempty" json:"service_names,omitempty"`
+	Workloads    []string `bson:"workloads,omitempty"     json:"workloads,omitempty"`
+
+	Findings []*EnvAnalysisFinding `bson:"findings,omitempty" json:"findings,omitempty"`
 }
 
 func (EnvAIAnalysis) TableName() string {
 	return "env_ai_analysis"
 }
+
+// EnvAnalysisFinding is a problematic object found by the analysis, the fingerprint identifies the same problem of
+// the same object across analyses
+type EnvAnalysisFinding struct {
+	Fingerprint  string   `bson:"fingerprint"   json:"fingerprint"`
+	Kind         string   `bson:"kind"          json:"kind"`
+	Name         string   `bson:"name"          json:"name"`
+	ParentObject string   `bson:"parent_object" json:"parent_object"`
+	Errors       []string `bson:"errors"        json:"errors"`
+	Details      string   `bson:"details"       json:"details"`
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EnvAlertStatusFiring   = "firing"
+	EnvAlertStatusResolved = "resolved"
+)
+
+// AlertReceiver receives the webhooks of the Prometheus Alertmanager for a project, the alerts are mapped to the envs
+// of the project by the namespace label and to the services of the env by the service labels
+type AlertReceiver struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name"          json:"name"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	Enabled     bool               `bson:"enabled"       json:"enabled"`
+	// Token is required in the Authorization header or token query of the webhook, it's generated on creation if
+	// not specified and masked in the responses
+	Token string `bson:"token"         json:"token"`
+	// ServiceLabels are the alert labels holding the service name, tried in order. The default labels are used if empty.
+	ServiceLabels []string            `bson:"service_labels" json:"service_labels"`
+	Remediations  []*AlertRemediation `bson:"remediations"   json:"remediations"`
+
+	CreatedBy  string `bson:"created_by"  json:"created_by"`
+	CreateTime int64  `bson:"create_time" json:"create_time"`
+	UpdatedBy  string `bson:"updated_by"  json:"updated_by"`
+	UpdateTime int64  `bson:"update_time" json:"update_time"`
+}
+
+func (AlertReceiver) TableName() string {
+	return "alert_receiver"
+}
+
+// AlertRemediation runs a workflow when a matched alert starts firing. The params named alert_name, env_name and
+// service_name of the workflow are set to those of the alert.
+type AlertRemediation struct {
+	// AlertName, Severity and EnvNames are the matchers of the alert, the empty ones match all
+	AlertName    string      `bson:"alert_name"    json:"alert_name"`
+	Severity     string      `bson:"severity"      json:"severity"`
+	EnvNames     []string    `bson:"env_names"     json:"env_names"`
+	WorkflowName string      `bson:"workflow_name" json:"workflow_name"`
+	WorkflowArg  *WorkflowV4 `bson:"workflow_arg"  json:"workflow_arg"`
+	// Cooldown is the minimum seconds between two remediations of the same alert
+	Cooldown int64 `bson:"cooldown"      json:"cooldown"`
+}
+
+// EnvAlert is an alert of the Alertmanager mapped to an env, identified by the fingerprint of the alert
+type EnvAlert struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
+	ReceiverID   string             `bson:"receiver_id"    json:"receiver_id"`
+	ProjectName  string             `bson:"project_name"   json:"project_name"`
+	EnvName      string             `bson:"env_name"       json:"env_name"`
+	Production   bool               `bson:"production"     json:"production"`
+	ServiceName  string             `bson:"service_name"   json:"service_name"`
+	Fingerprint  string             `bson:"fingerprint"    json:"fingerprint"`
+	AlertName    string             `bson:"alert_name"     json:"alert_name"`
+	Severity     string             `bson:"severity"       json:"severity"`
+	Status       string             `bson:"status"         json:"status"`
+	Labels       map[string]string  `bson:"labels"         json:"labels"`
+	Annotations  map[string]string  `bson:"annotations"    json:"annotations"`
+	GeneratorURL string             `bson:"generator_url"  json:"generator_url"`
+	StartsAt     int64              `bson:"starts_at"      json:"starts_at"`
+	EndsAt       int64              `bson:"ends_at"        json:"ends_at"`
+
+	RemediationWorkflow string `bson:"remediation_workflow" json:"remediation_workflow"`
+	RemediationTaskID   int64  `bson:"remediation_task_id"  json:"remediation_task_id"`
+	RemediationTime     int64  `bson:"remediation_time"     json:"remediation_time"`
+	RemediationError    string `bson:"remediation_error"    json:"remediation_error"`
+
+	UpdateTime int64 `bson:"update_time"    json:"update_time"`
+}
+
+func (EnvAlert) TableName() string {
+	return "env_alert"
+}
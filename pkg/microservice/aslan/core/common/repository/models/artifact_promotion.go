@@ -0,0 +1,52 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ArtifactPromotionStageBuild      = "build"
+	ArtifactPromotionStageDistribute = "distribute"
+	ArtifactPromotionStageDeploy     = "deploy"
+)
+
+// ArtifactPromotion records a hop of the image in the delivery, the image is built, distributed to the other
+// registries and deployed to the envs by the workflow jobs. The hops of the same image share the image digest.
+type ArtifactPromotion struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"          json:"id"`
+	Stage       string             `bson:"stage"                  json:"stage"`
+	ImageDigest string             `bson:"image_digest"           json:"image_digest"`
+	Image       string             `bson:"image"                  json:"image"`
+	// SourceImage is the image the distributed image is copied from
+	SourceImage   string `bson:"source_image,omitempty" json:"source_image,omitempty"`
+	ProjectName   string `bson:"project_name"           json:"project_name"`
+	WorkflowName  string `bson:"workflow_name"          json:"workflow_name"`
+	TaskID        int64  `bson:"task_id"                json:"task_id"`
+	JobName       string `bson:"job_name"               json:"job_name"`
+	ServiceName   string `bson:"service_name"           json:"service_name"`
+	ServiceModule string `bson:"service_module"         json:"service_module"`
+	EnvName       string `bson:"env_name,omitempty"     json:"env_name,omitempty"`
+	Production    bool   `bson:"production,omitempty"   json:"production,omitempty"`
+	CreatedBy     string `bson:"created_by"             json:"created_by"`
+	CreateTime    int64  `bson:"create_time"            json:"create_time"`
+}
+
+func (ArtifactPromotion) TableName() string {
+	return "artifact_promotion"
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ArtifactRetentionPolicy controls how long the workflow task artifacts of a project are kept
+type ArtifactRetentionPolicy struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"       json:"id"`
+	ProjectName string             `bson:"project_name"        json:"project_name"`
+	// RetentionDays is the days artifacts are kept after uploaded, 0 means forever
+	RetentionDays int `bson:"retention_days"      json:"retention_days"`
+	// MaxTotalSizeMB is the storage quota of the project in MiB, the oldest artifacts are removed
+	// when exceeded, 0 means unlimited
+	MaxTotalSizeMB int64  `bson:"max_total_size_mb"   json:"max_total_size_mb"`
+	UpdatedBy      string `bson:"updated_by"          json:"updated_by"`
+	UpdateTime     int64  `bson:"update_time"         json:"update_time"`
+}
+
+func (ArtifactRetentionPolicy) TableName() string {
+	return "artifact_retention_policy"
+}
@@ -94,6 +94,8 @@ type PreBuild struct {
 	StrategyID    string `bson:"strategy_id"                     json:"strategy_id"`
 	// UseHostDockerDaemon determines is dockerDaemon on host node is used in pod
 	UseHostDockerDaemon bool `bson:"use_host_docker_daemon" json:"use_host_docker_daemon"`
+	// PodTemplate is the name of the project level pod template used by the build
+	PodTemplate string `bson:"pod_template,omitempty" json:"pod_template,omitempty"`
 
 	CustomAnnotations []*util.KeyValue `bson:"custom_annotations" json:"custom_annotations" yaml:"custom_annotations"`
 	CustomLabels      []*util.KeyValue `bson:"custom_labels"      json:"custom_labels"      yaml:"custom_labels"`
@@ -125,6 +127,7 @@ type PostBuild struct {
 	ObjectStorageUpload *ObjectStorageUpload `bson:"object_storage_upload"  json:"object_storage_upload"`
 	FileArchive         *FileArchive         `bson:"file_archive,omitempty" json:"file_archive,omitempty"`
 	Scripts             string               `bson:"scripts"                json:"scripts"`
+	CoverageReport      *CoverageReport      `bson:"coverage_report,omitempty" json:"coverage_report,omitempty"`
 }
 
 type FileArchive struct {
@@ -150,6 +153,8 @@ type DockerBuild struct {
 	TemplateID string `bson:"template_id"            json:"template_id"`
 	// TemplateName is the name of the template dockerfile
 	TemplateName string `bson:"template_name"        json:"template_name"`
+	// RemoteCache is the remote layer cache used by buildkit to speed up the image build
+	RemoteCache *types.DockerRemoteCache `bson:"remote_cache,omitempty" json:"remote_cache,omitempty"`
 }
 
 type JenkinsBuild struct {
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChatOpsUserBinding maps a user of an IM app to a zadig user, the chatops commands are authorized as the zadig user
+type ChatOpsUserBinding struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	// IMAppID is the id of the IM app in zadig, the chat user ids are unique only in the app
+	IMAppID    string `bson:"im_app_id"    json:"im_app_id"`
+	IMAppType  string `bson:"im_app_type"  json:"im_app_type"`
+	ChatUserID string `bson:"chat_user_id" json:"chat_user_id"`
+	UserID     string `bson:"user_id"      json:"user_id"`
+	UserName   string `bson:"user_name"    json:"user_name"`
+	CreateTime int64  `bson:"create_time"  json:"create_time"`
+}
+
+func (ChatOpsUserBinding) TableName() string {
+	return "chatops_user_binding"
+}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/v2/pkg/setting"
+)
 
 type ConfigurationManagement struct {
 	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
@@ -50,3 +54,19 @@ type NacosAuthConfig struct {
 	UserName string `json:"user_name" bson:"user_name"`
 	Password string `json:"password" bson:"password"`
 }
+
+// FeatureFlagConfig is the config of launchdarkly, unleash or flagsmith
+type FeatureFlagConfig struct {
+	Type          string `json:"type"`
+	ServerAddress string `json:"server_address"`
+	*FeatureFlagAuthConfig
+}
+
+type FeatureFlagAuthConfig struct {
+	// Token is the api access token of launchdarkly, the admin api token of unleash or the organisation api key of flagsmith
+	Token string `json:"token" bson:"token"`
+}
+
+func IsFeatureFlagType(_type string) bool {
+	return _type == setting.SourceFromLaunchDarkly || _type == setting.SourceFromUnleash || _type == setting.SourceFromFlagsmith
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CoverageReport configures the coverage report published by build and test jobs
+type CoverageReport struct {
+	Enabled bool `bson:"enabled"                 json:"enabled"`
+	// Format is lcov or cobertura
+	Format string `bson:"format"                  json:"format"`
+	// ReportPath is the report file relative to the workspace
+	ReportPath string `bson:"report_path"             json:"report_path"`
+	// RegressionThreshold is the max allowed drop of line coverage in percentage points, 0 means no check
+	RegressionThreshold float64 `bson:"regression_threshold"    json:"regression_threshold"`
+}
+
+// CoverageRecord is the coverage of a service module or a test at a commit
+type CoverageRecord struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"      json:"id"`
+	ProjectName   string             `bson:"project_name"       json:"project_name"`
+	WorkflowName  string             `bson:"workflow_name"      json:"workflow_name"`
+	JobName       string             `bson:"job_name"           json:"job_name"`
+	JobTaskName   string             `bson:"job_task_name"      json:"job_task_name"`
+	TaskID        int64              `bson:"task_id"            json:"task_id"`
+	ServiceName   string             `bson:"service_name"       json:"service_name"`
+	ServiceModule string             `bson:"service_module"     json:"service_module"`
+	TestName      string             `bson:"test_name"          json:"test_name"`
+	RepoName      string             `bson:"repo_name"          json:"repo_name"`
+	Branch        string             `bson:"branch"             json:"branch"`
+	CommitID      string             `bson:"commit_id"          json:"commit_id"`
+	PR            int                `bson:"pr"                 json:"pr"`
+	Format        string             `bson:"format"             json:"format"`
+
+	LinesCovered    int64   `bson:"lines_covered"      json:"lines_covered"`
+	LinesValid      int64   `bson:"lines_valid"        json:"lines_valid"`
+	BranchesCovered int64   `bson:"branches_covered"   json:"branches_covered"`
+	BranchesValid   int64   `bson:"branches_valid"     json:"branches_valid"`
+	LineRate        float64 `bson:"line_rate"          json:"line_rate"`
+	BranchRate      float64 `bson:"branch_rate"        json:"branch_rate"`
+	// BaselineLineRate is the line coverage of the target branch when the record is generated
+	BaselineLineRate float64 `bson:"baseline_line_rate" json:"baseline_line_rate"`
+	HasBaseline      bool    `bson:"has_baseline"       json:"has_baseline"`
+	CreateTime       int64   `bson:"create_time"        json:"create_time"`
+}
+
+func (CoverageRecord) TableName() string {
+	return "coverage_record"
+}
@@ -36,6 +36,9 @@ type CustomWorkflowTestReport struct {
 	ErrorCaseNum     int                `bson:"error_case_num"`
 	TestTime         float64            `bson:"test_time"`
 	TestCases        []TestCase         `bson:"test_cases"`
+	// ReportType is the format of the original test results, junit or allure
+	ReportType string `bson:"report_type"`
+	CreateTime int64  `bson:"create_time"`
 }
 
 func (CustomWorkflowTestReport) TableName() string {
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeployFreezeWindow is a period of the system wide deployment freeze, the production deploy jobs and the production
+// env updates during the freeze require an override reason
+type DeployFreezeWindow struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
+	Name        string             `bson:"name"           json:"name"`
+	Description string             `bson:"description"    json:"description"`
+	StartTime   int64              `bson:"start_time"     json:"start_time"`
+	EndTime     int64              `bson:"end_time"       json:"end_time"`
+	CreatedBy   string             `bson:"created_by"     json:"created_by"`
+	UpdatedBy   string             `bson:"updated_by"     json:"updated_by"`
+	CreateTime  int64              `bson:"create_time"    json:"create_time"`
+	UpdateTime  int64              `bson:"update_time"    json:"update_time"`
+}
+
+func (DeployFreezeWindow) TableName() string {
+	return "deploy_freeze_window"
+}
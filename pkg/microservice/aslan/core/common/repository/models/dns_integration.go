@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/v2/pkg/tool/dns"
+)
+
+type DNSIntegration struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"     json:"id"`
+	Name     string             `bson:"name"              json:"name"`
+	Provider dns.Provider       `bson:"provider"          json:"provider"`
+	// Zone is the hosted zone ID for route53, the managed zone name for clouddns and the domain name for alidns
+	Zone            string             `bson:"zone"              json:"zone"`
+	AccessKeyID     string             `bson:"access_key_id"     json:"access_key_id"`
+	AccessKeySecret string             `bson:"access_key_secret" json:"access_key_secret"`
+	Region          string             `bson:"region"            json:"region"`
+	GCPProjectID    string             `bson:"gcp_project_id"    json:"gcp_project_id"`
+	Credentials     string             `bson:"credentials"       json:"credentials"`
+	Rules           []*DNSHostnameRule `bson:"rules"             json:"rules"`
+	UpdatedBy       string             `bson:"updated_by"        json:"updated_by"`
+	UpdateTime      int64              `bson:"update_time"       json:"update_time"`
+}
+
+// DNSHostnameRule defines the hostnames of the services in the envs of the project, the template is rendered with
+// ProjectName, EnvName, Namespace and ServiceName, e.g. `{{.ServiceName}}-{{.EnvName}}.example.com`
+type DNSHostnameRule struct {
+	ProjectName      string `bson:"project_name"      json:"project_name"`
+	HostnameTemplate string `bson:"hostname_template" json:"hostname_template"`
+	RecordType       string `bson:"record_type"       json:"record_type"`
+	// Target is the value of the records, usually the address of the ingress load balancer
+	Target string `bson:"target"            json:"target"`
+	TTL    int64  `bson:"ttl"               json:"ttl"`
+}
+
+func (DNSIntegration) TableName() string {
+	return "dns_integration"
+}
+
+// EnvDNSRecord is the dns record created for the service of the env, it is removed when the env is deleted
+type EnvDNSRecord struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
+	ProjectName   string             `bson:"project_name"   json:"project_name"`
+	EnvName       string             `bson:"env_name"       json:"env_name"`
+	Production    bool               `bson:"production"     json:"production"`
+	ServiceName   string             `bson:"service_name"   json:"service_name"`
+	Hostname      string             `bson:"hostname"       json:"hostname"`
+	RecordType    string             `bson:"record_type"    json:"record_type"`
+	Target        string             `bson:"target"         json:"target"`
+	IntegrationID string             `bson:"integration_id" json:"integration_id"`
+	UpdateTime    int64              `bson:"update_time"    json:"update_time"`
+}
+
+func (EnvDNSRecord) TableName() string {
+	return "env_dns_record"
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnvDebugSnapshot is a diagnostic bundle of the services in the env, the bundle is archived in the object storage
+type EnvDebugSnapshot struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
+	ProjectName  string             `bson:"project_name"   json:"project_name"`
+	EnvName      string             `bson:"env_name"       json:"env_name"`
+	Production   bool               `bson:"production"     json:"production"`
+	ServiceNames []string           `bson:"service_names"  json:"service_names"`
+	Status       string             `bson:"status"         json:"status"`
+	Error        string             `bson:"error"          json:"error"`
+	StorageID    string             `bson:"storage_id"     json:"storage_id"`
+	ObjectKey    string             `bson:"object_key"     json:"object_key"`
+	Size         int64              `bson:"size"           json:"size"`
+	CreatedBy    string             `bson:"created_by"     json:"created_by"`
+	CreateTime   int64              `bson:"create_time"    json:"create_time"`
+	FinishTime   int64              `bson:"finish_time"    json:"finish_time"`
+}
+
+func (EnvDebugSnapshot) TableName() string {
+	return "env_debug_snapshot"
+}
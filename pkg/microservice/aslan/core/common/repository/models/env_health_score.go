@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnvHealthScore is a health sample of an env, the score ranges from 0 to 100 and is computed from the readiness of
+// the workloads, the container restarts, the failed probes and the findings of the latest AI analysis
+type EnvHealthScore struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
+	ProjectName string             `bson:"project_name"   json:"project_name"`
+	EnvName     string             `bson:"env_name"       json:"env_name"`
+	Production  bool               `bson:"production"     json:"production"`
+	Score       int                `bson:"score"          json:"score"`
+	// RollingScore is the average score of the samples in the last hour including this one
+	RollingScore int `bson:"rolling_score"  json:"rolling_score"`
+	ReadyPods    int `bson:"ready_pods"     json:"ready_pods"`
+	TotalPods    int `bson:"total_pods"     json:"total_pods"`
+	// Restarts and FailedProbes are counted since the previous sample
+	Restarts     int   `bson:"restarts"       json:"restarts"`
+	FailedProbes int   `bson:"failed_probes"  json:"failed_probes"`
+	Findings     int   `bson:"findings"       json:"findings"`
+	CreateTime   int64 `bson:"create_time"    json:"create_time"`
+}
+
+func (EnvHealthScore) TableName() string {
+	return "env_health_score"
+}
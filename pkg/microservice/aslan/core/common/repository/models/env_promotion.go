@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EnvPromotionStatusWaitingApproval = "waiting_approval"
+	EnvPromotionStatusRejected        = "rejected"
+	EnvPromotionStatusRunning         = "running"
+	EnvPromotionStatusSuccess         = "success"
+	EnvPromotionStatusFailed          = "failed"
+	EnvPromotionStatusRollingBack     = "rolling_back"
+	EnvPromotionStatusRolledBack      = "rolled_back"
+)
+
+// EnvPromotion applies the service versions currently running in the source env to the target env
+type EnvPromotion struct {
+	ID               primitive.ObjectID     `bson:"_id,omitempty"     json:"id"`
+	ProjectName      string                 `bson:"project_name"      json:"project_name"`
+	SourceEnv        string                 `bson:"source_env"        json:"source_env"`
+	SourceProduction bool                   `bson:"source_production" json:"source_production"`
+	TargetEnv        string                 `bson:"target_env"        json:"target_env"`
+	TargetProduction bool                   `bson:"target_production" json:"target_production"`
+	Services         []*EnvPromotionService `bson:"services"          json:"services"`
+	// Approvers are the usernames allowed to approve the promotion, the promotion is applied directly if empty
+	Approvers  []string `bson:"approvers"   json:"approvers"`
+	ApprovedBy string   `bson:"approved_by" json:"approved_by"`
+	Status     string   `bson:"status"      json:"status"`
+	Error      string   `bson:"error"       json:"error"`
+	CreatedBy  string   `bson:"created_by"  json:"created_by"`
+	CreateTime int64    `bson:"create_time" json:"create_time"`
+	UpdateTime int64    `bson:"update_time" json:"update_time"`
+}
+
+// EnvPromotionService is the image diff of a service container between the source env and the target env
+type EnvPromotionService struct {
+	ServiceName   string `bson:"service_name"   json:"service_name"`
+	ServiceModule string `bson:"service_module" json:"service_module"`
+	// SourceRevision is the latest env service version of the service in the source env
+	SourceRevision int64  `bson:"source_revision" json:"source_revision"`
+	SourceImage    string `bson:"source_image"    json:"source_image"`
+	// TargetImage is the image in the target env before the promotion, it is used to rollback
+	TargetImage string `bson:"target_image" json:"target_image"`
+	Status      string `bson:"status"       json:"status"`
+	Error       string `bson:"error"        json:"error"`
+}
+
+func (EnvPromotion) TableName() string {
+	return "env_promotion"
+}
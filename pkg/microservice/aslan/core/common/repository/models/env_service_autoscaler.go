@@ -0,0 +1,61 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnvServiceAutoscaler is the autoscaling definition of a workload of the service in the env, it is rendered as
+// a HorizontalPodAutoscaler or a KEDA ScaledObject targeting the workload
+type EnvServiceAutoscaler struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	EnvName     string             `bson:"env_name"      json:"env_name"`
+	Production  bool               `bson:"production"    json:"production"`
+	ServiceName string             `bson:"service_name"  json:"service_name"`
+	// Type is hpa or keda
+	Type        string `bson:"type"         json:"type"`
+	TargetKind  string `bson:"target_kind"  json:"target_kind"`
+	TargetName  string `bson:"target_name"  json:"target_name"`
+	MinReplicas int32  `bson:"min_replicas" json:"min_replicas"`
+	MaxReplicas int32  `bson:"max_replicas" json:"max_replicas"`
+	// Metrics are the resource utilization targets, used by both hpa and keda
+	Metrics []*AutoscalerResourceMetric `bson:"metrics" json:"metrics"`
+	// Triggers are the KEDA scalers besides the resource metrics, only used by keda
+	Triggers   []*AutoscalerKEDATrigger `bson:"triggers"    json:"triggers"`
+	CreatedBy  string                   `bson:"created_by"  json:"created_by"`
+	UpdatedBy  string                   `bson:"updated_by"  json:"updated_by"`
+	CreateTime int64                    `bson:"create_time" json:"create_time"`
+	UpdateTime int64                    `bson:"update_time" json:"update_time"`
+}
+
+type AutoscalerResourceMetric struct {
+	// Resource is cpu or memory
+	Resource string `bson:"resource" json:"resource"`
+	// TargetUtilization is the percentage of the requests of the containers
+	TargetUtilization int32 `bson:"target_utilization" json:"target_utilization"`
+}
+
+type AutoscalerKEDATrigger struct {
+	Type     string            `bson:"type"     json:"type"`
+	Metadata map[string]string `bson:"metadata" json:"metadata"`
+}
+
+func (EnvServiceAutoscaler) TableName() string {
+	return "env_service_autoscaler"
+}
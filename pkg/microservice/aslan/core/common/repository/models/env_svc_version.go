@@ -40,6 +40,8 @@ type EnvServiceVersion struct {
 	CreateBy        string                          `bson:"create_by"                 json:"create_by"`
 	CreateTime      int64                           `bson:"create_time"               json:"create_time"`
 	UpdateTime      int64                           `bson:"update_time"               json:"update_time"`
+	// RevisionDiff is the diff between the applied manifests and the manifests of the new service revision
+	RevisionDiff string `bson:"revision_diff,omitempty" json:"revision_diff,omitempty"`
 }
 
 func (EnvServiceVersion) TableName() string {
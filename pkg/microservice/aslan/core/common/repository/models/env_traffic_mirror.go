@@ -0,0 +1,42 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnvTrafficMirror mirrors a percentage of the traffic of the service in the base env to the same service in the sub env
+type EnvTrafficMirror struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"           json:"id"`
+	ProjectName string             `bson:"project_name"            json:"project_name"`
+	BaseEnv     string             `bson:"base_env"                json:"base_env"`
+	SubEnv      string             `bson:"sub_env"                 json:"sub_env"`
+	ServiceName string             `bson:"service_name"            json:"service_name"`
+	Percentage  float64            `bson:"percentage"              json:"percentage"`
+	Mode        string             `bson:"mode"                    json:"mode"`
+	// VirtualServiceName is the VirtualService carrying the mirror rule in the base env namespace, it is deleted
+	// with the mirror if it is created for the mirror
+	VirtualServiceName    string `bson:"virtual_service_name"    json:"virtual_service_name"`
+	VirtualServiceCreated bool   `bson:"virtual_service_created" json:"virtual_service_created"`
+	CreatedBy             string `bson:"created_by"              json:"created_by"`
+	CreateTime            int64  `bson:"create_time"             json:"create_time"`
+}
+
+func (EnvTrafficMirror) TableName() string {
+	return "env_traffic_mirror"
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnvWorkloadPolicy is the platform standards of the project injected into the rendered workloads of its production
+// envs, so that they don't rely on every service template or chart author
+type EnvWorkloadPolicy struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	// Rules are matched in order, the first rule matching the type of the service is applied
+	Rules      []*WorkloadPolicyRule `bson:"rules"       json:"rules"`
+	UpdatedBy  string                `bson:"updated_by"  json:"updated_by"`
+	UpdateTime int64                 `bson:"update_time" json:"update_time"`
+}
+
+type WorkloadPolicyRule struct {
+	// ServiceTypes are the deploy types of the services the rule applies to, like k8s, helm or helm_chart,
+	// all the services match if empty
+	ServiceTypes []string `bson:"service_types" json:"service_types"`
+	// PriorityClassName is set to the pods of the workloads which don't specify one
+	PriorityClassName string             `bson:"priority_class_name" json:"priority_class_name"`
+	PDB               *WorkloadPDBPolicy `bson:"pdb"                 json:"pdb"`
+}
+
+// WorkloadPDBPolicy generates a PodDisruptionBudget for each multi-replica workload which is not covered by one in
+// the service, only one of MinAvailable and MaxUnavailable should be set, in the form of a number or a percentage
+type WorkloadPDBPolicy struct {
+	Enabled        bool   `bson:"enabled"         json:"enabled"`
+	MinAvailable   string `bson:"min_available"   json:"min_available"`
+	MaxUnavailable string `bson:"max_unavailable" json:"max_unavailable"`
+}
+
+func (EnvWorkloadPolicy) TableName() string {
+	return "env_workload_policy"
+}
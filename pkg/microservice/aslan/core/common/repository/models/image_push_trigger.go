@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ImagePushActionUpdateEnv   = "update_env"
+	ImagePushActionRunWorkflow = "run_workflow"
+
+	ImagePushEventStatusProcessing = "processing"
+	ImagePushEventStatusSuccess    = "success"
+	ImagePushEventStatusFailed     = "failed"
+)
+
+// ImagePushTrigger updates the service image in environments or runs a workflow when a matched image is pushed to the registry
+type ImagePushTrigger struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name"          json:"name"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	Enabled     bool               `bson:"enabled"       json:"enabled"`
+	// Source is the type of the registry notification, harbor/acr/ecr
+	Source string `bson:"source"        json:"source"`
+	// Token is required in the Authorization header or token query of the notification, it's generated on creation
+	// if not specified and masked in the responses
+	Token string `bson:"token"         json:"token"`
+	// RegistryHost is prepended to the repo to get the full image, e.g. harbor.example.com
+	RegistryHost string `bson:"registry_host" json:"registry_host"`
+	ImageRepo    string `bson:"image_repo"    json:"image_repo"`
+	TagRegex     string `bson:"tag_regex"     json:"tag_regex"`
+	Action       string `bson:"action"        json:"action"`
+
+	// for update_env action
+	EnvNames      []string `bson:"env_names"      json:"env_names"`
+	Production    bool     `bson:"production"     json:"production"`
+	ServiceName   string   `bson:"service_name"   json:"service_name"`
+	ServiceModule string   `bson:"service_module" json:"service_module"`
+
+	// for run_workflow action, the full image is passed by the ImageParam of the workflow
+	WorkflowName string      `bson:"workflow_name" json:"workflow_name"`
+	WorkflowArg  *WorkflowV4 `bson:"workflow_arg"  json:"workflow_arg"`
+	ImageParam   string      `bson:"image_param"   json:"image_param"`
+
+	CreatedBy  string `bson:"created_by"  json:"created_by"`
+	CreateTime int64  `bson:"create_time" json:"create_time"`
+	UpdatedBy  string `bson:"updated_by"  json:"updated_by"`
+	UpdateTime int64  `bson:"update_time" json:"update_time"`
+}
+
+func (ImagePushTrigger) TableName() string {
+	return "image_push_trigger"
+}
+
+// ImagePushEvent records the handling of an image push, an image is handled at most once by a trigger
+type ImagePushEvent struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TriggerID   string             `bson:"trigger_id"    json:"trigger_id"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	Repo        string             `bson:"repo"          json:"repo"`
+	Tag         string             `bson:"tag"           json:"tag"`
+	Digest      string             `bson:"digest"        json:"digest"`
+	Image       string             `bson:"image"         json:"image"`
+	Action      string             `bson:"action"        json:"action"`
+	Status      string             `bson:"status"        json:"status"`
+	Message     string             `bson:"message"       json:"message"`
+	TaskID      int64              `bson:"task_id"       json:"task_id"`
+	CreateTime  int64              `bson:"create_time"   json:"create_time"`
+	UpdateTime  int64              `bson:"update_time"   json:"update_time"`
+}
+
+func (ImagePushEvent) TableName() string {
+	return "image_push_event"
+}
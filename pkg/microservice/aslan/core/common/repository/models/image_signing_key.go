@@ -0,0 +1,47 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImageSigningKey is used to sign the images with cosign when distributing and to verify them before deploying
+type ImageSigningKey struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name string             `bson:"name"          json:"name"`
+	// Mode is key or keyless
+	Mode string `bson:"mode" json:"mode"`
+
+	// for key mode
+	PrivateKey string `bson:"private_key" json:"private_key"`
+	PublicKey  string `bson:"public_key"  json:"public_key"`
+	Password   string `bson:"password"    json:"password"`
+
+	// for keyless mode
+	IdentityToken         string `bson:"identity_token"          json:"identity_token"`
+	CertificateIdentity   string `bson:"certificate_identity"    json:"certificate_identity"`
+	CertificateOIDCIssuer string `bson:"certificate_oidc_issuer" json:"certificate_oidc_issuer"`
+
+	TlogDisabled bool   `bson:"tlog_disabled" json:"tlog_disabled"`
+	UpdateBy     string `bson:"update_by"     json:"update_by"`
+	UpdateTime   int64  `bson:"update_time"   json:"update_time"`
+}
+
+func (ImageSigningKey) TableName() string {
+	return "image_signing_key"
+}
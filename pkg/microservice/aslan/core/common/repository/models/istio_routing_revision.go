@@ -0,0 +1,42 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IstioRoutingRevision records the content of an istio routing object of the env every time it is changed through zadig,
+// the object can be rolled back to any of the revisions
+type IstioRoutingRevision struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	EnvName     string             `bson:"env_name"      json:"env_name"`
+	Production  bool               `bson:"production"    json:"production"`
+	Kind        string             `bson:"kind"          json:"kind"`
+	Name        string             `bson:"name"          json:"name"`
+	Revision    int64              `bson:"revision"      json:"revision"`
+	Yaml        string             `bson:"yaml"          json:"yaml"`
+	// Deleted marks the revision recorded when the object is deleted, Yaml is the content before deletion
+	Deleted    bool   `bson:"deleted"     json:"deleted"`
+	CreatedBy  string `bson:"created_by"  json:"created_by"`
+	CreateTime int64  `bson:"create_time" json:"create_time"`
+}
+
+func (IstioRoutingRevision) TableName() string {
+	return "istio_routing_revision"
+}
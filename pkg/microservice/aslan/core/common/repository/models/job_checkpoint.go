@@ -0,0 +1,38 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/v2/pkg/types/job"
+)
+
+// JobCheckpointRecord keeps the latest progress of a pod running a long-running test job,
+// a job which has been rerun has one record for each pod.
+type JobCheckpointRecord struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty"   json:"id"`
+	WorkflowName      string             `bson:"workflow_name"   json:"workflow_name"`
+	TaskID            int64              `bson:"task_id"         json:"task_id"`
+	JobName           string             `bson:"job_name"        json:"job_name"`
+	job.JobCheckpoint `bson:",inline"     json:",inline"`
+	CreateTime        int64 `bson:"create_time"     json:"create_time"`
+}
+
+func (JobCheckpointRecord) TableName() string {
+	return "job_checkpoint"
+}
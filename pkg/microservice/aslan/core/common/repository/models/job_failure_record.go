@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	JobFailureCategoryPodEvicted   = "pod_evicted"
+	JobFailureCategoryImagePull    = "image_pull"
+	JobFailureCategoryNodeNotReady = "node_not_ready"
+	// JobFailureCategoryJob is the failure of the job itself, like a failed script or test
+	JobFailureCategoryJob = "job"
+)
+
+// JobFailureRecord records a failed run of the workflow job, the records are used to find the flaky jobs
+type JobFailureRecord struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"        json:"id"`
+	ProjectName  string             `bson:"project_name"         json:"project_name"`
+	WorkflowName string             `bson:"workflow_name"        json:"workflow_name"`
+	TaskID       int64              `bson:"task_id"              json:"task_id"`
+	JobName      string             `bson:"job_name"             json:"job_name"`
+	JobType      string             `bson:"job_type"             json:"job_type"`
+	Category     string             `bson:"category"             json:"category"`
+	Error        string             `bson:"error"                json:"error"`
+	ClusterID    string             `bson:"cluster_id,omitempty" json:"cluster_id,omitempty"`
+	// Recovered is true if the job passed after it was requeued
+	Recovered  bool  `bson:"recovered"            json:"recovered"`
+	CreateTime int64 `bson:"create_time"          json:"create_time"`
+}
+
+func (JobFailureRecord) TableName() string {
+	return "job_failure_record"
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobKubeAccessGrant audits the namespace scoped service account granted to a job to access the env
+type JobKubeAccessGrant struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"   json:"id"`
+	ProjectName    string             `bson:"project_name"    json:"project_name"`
+	WorkflowName   string             `bson:"workflow_name"   json:"workflow_name"`
+	TaskID         int64              `bson:"task_id"         json:"task_id"`
+	JobName        string             `bson:"job_name"        json:"job_name"`
+	EnvName        string             `bson:"env_name"        json:"env_name"`
+	Production     bool               `bson:"production"      json:"production"`
+	ClusterID      string             `bson:"cluster_id"      json:"cluster_id"`
+	Namespace      string             `bson:"namespace"       json:"namespace"`
+	ServiceAccount string             `bson:"service_account" json:"service_account"`
+	ReadOnly       bool               `bson:"read_only"       json:"read_only"`
+	TaskCreator    string             `bson:"task_creator"    json:"task_creator"`
+	ExpireTime     int64              `bson:"expire_time"     json:"expire_time"`
+	// RevokeTime is 0 if the service account has not been deleted, the token then expires at ExpireTime
+	RevokeTime int64 `bson:"revoke_time"     json:"revoke_time"`
+	CreateTime int64 `bson:"create_time"     json:"create_time"`
+}
+
+func (JobKubeAccessGrant) TableName() string {
+	return "job_kube_access_grant"
+}
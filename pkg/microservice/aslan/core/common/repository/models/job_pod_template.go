@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobPodTemplate is a reusable execution environment of the project, build, test and freestyle jobs refer to it by name
+// instead of repeating the low-level pod fields in every job.
+type JobPodTemplate struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"    json:"id,omitempty"`
+	ProjectName  string             `bson:"project_name"     json:"project_name"`
+	Name         string             `bson:"name"             json:"name"`
+	Description  string             `bson:"description"      json:"description"`
+	NodeSelector map[string]string  `bson:"node_selector"    json:"node_selector"`
+	// Tolerations, SecurityContext and Sidecars are kept in yaml like the schedule strategies of the clusters,
+	// they are a list of tolerations, a pod security context and a list of containers respectively.
+	Tolerations     string `bson:"tolerations"      json:"tolerations"`
+	SecurityContext string `bson:"security_context" json:"security_context"`
+	Sidecars        string `bson:"sidecars"         json:"sidecars"`
+	CreatedBy       string `bson:"created_by"       json:"created_by"`
+	CreateTime      int64  `bson:"create_time"      json:"create_time"`
+	UpdatedBy       string `bson:"updated_by"       json:"updated_by"`
+	UpdateTime      int64  `bson:"update_time"      json:"update_time"`
+}
+
+func (JobPodTemplate) TableName() string {
+	return "job_pod_template"
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobUsageRecord records the execution time of a workflow job running in the job pod, the records are used to
+// account the usage of the build clusters by project
+type JobUsageRecord struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"        json:"id"`
+	ProjectName  string             `bson:"project_name"         json:"project_name"`
+	WorkflowName string             `bson:"workflow_name"        json:"workflow_name"`
+	TaskID       int64              `bson:"task_id"              json:"task_id"`
+	JobName      string             `bson:"job_name"             json:"job_name"`
+	JobType      string             `bson:"job_type"             json:"job_type"`
+	ClusterID    string             `bson:"cluster_id"           json:"cluster_id"`
+	Status       string             `bson:"status"               json:"status"`
+	// Month is the month the job started in, in the format of 2006-01
+	Month     string `bson:"month"                json:"month"`
+	StartTime int64  `bson:"start_time"           json:"start_time"`
+	EndTime   int64  `bson:"end_time"             json:"end_time"`
+	// Duration is the execution time of the job in seconds
+	Duration   int64 `bson:"duration"             json:"duration"`
+	CreateTime int64 `bson:"create_time"          json:"create_time"`
+}
+
+func (JobUsageRecord) TableName() string {
+	return "job_usage_record"
+}
@@ -78,6 +78,10 @@ type AdvancedConfig struct {
 	ScheduleStrategy  []*ScheduleStrategy        `json:"schedule_strategy"        bson:"schedule_strategy"`
 	EnableIRSA        bool                       `json:"enable_irsa"              bson:"enable_irsa"`
 	IRSARoleARM       string                     `json:"irsa_role_arn"            bson:"irsa_role_arn"`
+	KubeAPIQPS        float32                    `json:"kube_api_qps"             bson:"kube_api_qps"`
+	KubeAPIBurst      int                        `json:"kube_api_burst"           bson:"kube_api_burst"`
+	CertIssuer        string                     `json:"cert_issuer"              bson:"cert_issuer"`
+	CertIssuerKind    string                     `json:"cert_issuer_kind"         bson:"cert_issuer_kind"`
 }
 
 type ScheduleStrategy struct {
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LLMBudget is the monthly llm cost budget of a project, the receivers are notified once a month when the cost of
+// the month reaches the alert threshold or the budget
+type LLMBudget struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"       json:"id"`
+	ProjectName     string             `bson:"project_name"        json:"project_name"`
+	MonthlyBudget   float64            `bson:"monthly_budget"      json:"monthly_budget"`
+	AlertPercentage int                `bson:"alert_percentage"    json:"alert_percentage"`
+	Receivers       []string           `bson:"receivers"           json:"receivers"`
+	AlertedMonth    string             `bson:"alerted_month"       json:"alerted_month"`
+	AlertedPercent  int                `bson:"alerted_percent"     json:"alerted_percent"`
+	UpdatedBy       string             `bson:"updated_by"          json:"updated_by"`
+	UpdateTime      int64              `bson:"update_time"         json:"update_time"`
+}
+
+func (LLMBudget) TableName() string {
+	return "llm_budget"
+}
@@ -32,6 +32,15 @@ type LLMIntegration struct {
 	IsDefault    bool               `bson:"is_default"     json:"is_default"`
 	UpdatedBy    string             `bson:"updated_by"     json:"updated_by"`
 	UpdateTime   int64              `bson:"update_time"    json:"update_time"`
+
+	Name string `bson:"name"       json:"name"`
+	// Features are the AI features using this integration instead of the default one, see aiservice.LLMFeature
+	Features []string `bson:"features"   json:"features"`
+	// RateLimit is the max requests per minute sent to the provider, 0 means no limit
+	RateLimit int `bson:"rate_limit" json:"rate_limit"`
+	// PromptTokenPrice and CompletionTokenPrice are the prices per 1k tokens used to calculate the cost of the usage
+	PromptTokenPrice     float64 `bson:"prompt_token_price"     json:"prompt_token_price"`
+	CompletionTokenPrice float64 `bson:"completion_token_price" json:"completion_token_price"`
 }
 
 func (llm LLMIntegration) TableName() string {
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LLMUsage is the token usage of a single llm request made by an AI feature
+type LLMUsage struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty"      json:"id"`
+	ProjectName      string             `bson:"project_name"       json:"project_name"`
+	Feature          string             `bson:"feature"            json:"feature"`
+	IntegrationID    string             `bson:"integration_id"     json:"integration_id"`
+	Provider         string             `bson:"provider"           json:"provider"`
+	Model            string             `bson:"model"              json:"model"`
+	PromptTokens     int                `bson:"prompt_tokens"      json:"prompt_tokens"`
+	CompletionTokens int                `bson:"completion_tokens"  json:"completion_tokens"`
+	Cost             float64            `bson:"cost"               json:"cost"`
+	CreateTime       int64              `bson:"create_time"        json:"create_time"`
+}
+
+func (LLMUsage) TableName() string {
+	return "llm_usage"
+}
+
+// LLMUsageStat is the aggregated token usage of a project and a feature
+type LLMUsageStat struct {
+	ProjectName      string  `bson:"project_name"       json:"project_name"`
+	Feature          string  `bson:"feature"            json:"feature"`
+	Requests         int     `bson:"requests"           json:"requests"`
+	PromptTokens     int     `bson:"prompt_tokens"      json:"prompt_tokens"`
+	CompletionTokens int     `bson:"completion_tokens"  json:"completion_tokens"`
+	Cost             float64 `bson:"cost"               json:"cost"`
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp NotificationChannel = "in_app"
+	NotificationChannelMail  NotificationChannel = "mail"
+)
+
+type NotificationEventType string
+
+const (
+	NotificationEventWorkflow NotificationEventType = "workflow"
+	NotificationEventEnv      NotificationEventType = "env"
+	NotificationEventSystem   NotificationEventType = "system"
+)
+
+// NotificationPreference is the notification settings of a user, the empty channels or events mean all of them are accepted
+type NotificationPreference struct {
+	ID            primitive.ObjectID      `bson:"_id,omitempty"   json:"id,omitempty"`
+	UserID        string                  `bson:"user_id"         json:"user_id"`
+	UserName      string                  `bson:"user_name"       json:"user_name"`
+	Channels      []NotificationChannel   `bson:"channels"        json:"channels"`
+	Events        []NotificationEventType `bson:"events"          json:"events"`
+	MutedProjects []string                `bson:"muted_projects"  json:"muted_projects"`
+	QuietHours    *QuietHours             `bson:"quiet_hours"     json:"quiet_hours"`
+	UpdateTime    int64                   `bson:"update_time"     json:"update_time"`
+}
+
+// QuietHours is a daily time range in which the notifications are not pushed to the user, the in-app messages are still kept.
+// Start and End are in the form of HH:mm, the range crosses midnight if End is before Start.
+type QuietHours struct {
+	Enabled  bool   `bson:"enabled"   json:"enabled"`
+	Start    string `bson:"start"     json:"start"`
+	End      string `bson:"end"       json:"end"`
+	Timezone string `bson:"timezone"  json:"timezone"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preference"
+}
@@ -62,6 +62,9 @@ type MessageCtx struct {
 	ReqID   string `bson:"req_id"                json:"req_id"`
 	Title   string `bson:"title"                 json:"title"`   // 消息标题
 	Content string `bson:"content"               json:"content"` // 消息内容
+	// ProjectName and Event are used to check the notification preference of the receiver
+	ProjectName string                `bson:"project_name,omitempty" json:"project_name,omitempty"`
+	Event       NotificationEventType `bson:"event,omitempty"        json:"event,omitempty"`
 }
 
 func (Notify) TableName() string {
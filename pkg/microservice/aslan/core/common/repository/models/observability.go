@@ -29,7 +29,7 @@ type Observability struct {
 	Host string                   `json:"host" bson:"host" yaml:"host"`
 	// ConsoleHost is used for guanceyun console, Host is guanceyun OpenApi Addr
 	ConsoleHost string `json:"console_host" bson:"console_host" yaml:"console_host"`
-	// ApiKey is used for guanceyun
+	// ApiKey is used for guanceyun, and as the bearer token for prometheus
 	ApiKey string `json:"api_key" bson:"api_key" yaml:"api_key"`
 
 	GrafanaToken string `json:"grafana_token" bson:"grafana_token" yaml:"grafana_token"`
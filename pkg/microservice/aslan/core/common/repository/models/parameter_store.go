@@ -0,0 +1,76 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ParameterValueType string
+
+const (
+	ParameterValueTypeString ParameterValueType = "string"
+	ParameterValueTypeNumber ParameterValueType = "number"
+	ParameterValueTypeBool   ParameterValueType = "bool"
+	ParameterValueTypeText   ParameterValueType = "text"
+)
+
+const (
+	ParameterActionCreate = "create"
+	ParameterActionUpdate = "update"
+	ParameterActionDelete = "delete"
+)
+
+// ParameterStoreItem is a key/value shared by the workflows and envs, it is referenced by `{{.store.KEY}}`.
+// The system level parameters have an empty project name, the project level parameters override them with the same key.
+type ParameterStoreItem struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"  json:"id,omitempty"`
+	ProjectName string             `bson:"project_name"   json:"project_name"`
+	Key         string             `bson:"key"            json:"key"`
+	Type        ParameterValueType `bson:"type"           json:"type"`
+	Value       string             `bson:"value"          json:"value"`
+	IsSecret    bool               `bson:"is_secret"      json:"is_secret"`
+	Description string             `bson:"description"    json:"description"`
+	// Usages are the workflows and envs resolved the parameter, in the form of `workflow:project/name` or `env:project/name`
+	Usages       []string `bson:"usages"         json:"usages"`
+	LastUsedTime int64    `bson:"last_used_time" json:"last_used_time"`
+	CreatedBy    string   `bson:"created_by"     json:"created_by"`
+	CreateTime   int64    `bson:"create_time"    json:"create_time"`
+	UpdatedBy    string   `bson:"updated_by"     json:"updated_by"`
+	UpdateTime   int64    `bson:"update_time"    json:"update_time"`
+}
+
+func (ParameterStoreItem) TableName() string {
+	return "parameter_store"
+}
+
+// ParameterStoreHistory is a change of the parameter, the values of the secret parameters are not recorded
+type ParameterStoreHistory struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	Key         string             `bson:"key"           json:"key"`
+	Action      string             `bson:"action"        json:"action"`
+	Type        ParameterValueType `bson:"type"          json:"type"`
+	Value       string             `bson:"value"         json:"value"`
+	IsSecret    bool               `bson:"is_secret"     json:"is_secret"`
+	Operator    string             `bson:"operator"      json:"operator"`
+	CreateTime  int64              `bson:"create_time"   json:"create_time"`
+}
+
+func (ParameterStoreHistory) TableName() string {
+	return "parameter_store_history"
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PodExecSession is the audit record of a terminal session into a pod container of an env
+type PodExecSession struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
+	ProjectName   string             `bson:"project_name"   json:"project_name"`
+	EnvName       string             `bson:"env_name"       json:"env_name"`
+	Production    bool               `bson:"production"     json:"production"`
+	ClusterID     string             `bson:"cluster_id"     json:"cluster_id"`
+	Namespace     string             `bson:"namespace"      json:"namespace"`
+	PodName       string             `bson:"pod_name"       json:"pod_name"`
+	ContainerName string             `bson:"container_name" json:"container_name"`
+	// DebugImage is the image of the ephemeral debug container, empty if the session execs into an existing container
+	DebugImage string `bson:"debug_image"    json:"debug_image"`
+	UserName   string `bson:"user_name"      json:"user_name"`
+	StartTime  int64  `bson:"start_time"     json:"start_time"`
+	EndTime    int64  `bson:"end_time"       json:"end_time"`
+	Error      string `bson:"error"          json:"error"`
+	// Input and Output are the recorded stdin and stdout of the session, they are truncated if too large
+	Input     string `bson:"input"          json:"input,omitempty"`
+	Output    string `bson:"output"         json:"output,omitempty"`
+	Truncated bool   `bson:"truncated"      json:"truncated"`
+}
+
+func (PodExecSession) TableName() string {
+	return "pod_exec_session"
+}
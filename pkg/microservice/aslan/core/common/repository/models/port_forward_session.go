@@ -0,0 +1,52 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PortForwardStatusActive  = "active"
+	PortForwardStatusClosed  = "closed"
+	PortForwardStatusExpired = "expired"
+)
+
+// PortForwardSession is a temporary tunnel from the developer's machine to a port of a pod in an env
+type PortForwardSession struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	EnvName     string             `bson:"env_name"      json:"env_name"`
+	Production  bool               `bson:"production"    json:"production"`
+	ClusterID   string             `bson:"cluster_id"    json:"cluster_id"`
+	Namespace   string             `bson:"namespace"     json:"namespace"`
+	ServiceName string             `bson:"service_name"  json:"service_name"`
+	PodName     string             `bson:"pod_name"      json:"pod_name"`
+	Port        int                `bson:"port"          json:"port"`
+	UserName    string             `bson:"user_name"     json:"user_name"`
+	Status      string             `bson:"status"        json:"status"`
+	CreateTime  int64              `bson:"create_time"   json:"create_time"`
+	ExpireTime  int64              `bson:"expire_time"   json:"expire_time"`
+	CloseTime   int64              `bson:"close_time"    json:"close_time"`
+	Connections int64              `bson:"connections"   json:"connections"`
+	BytesIn     int64              `bson:"bytes_in"      json:"bytes_in"`
+	BytesOut    int64              `bson:"bytes_out"     json:"bytes_out"`
+}
+
+func (PortForwardSession) TableName() string {
+	return "port_forward_session"
+}
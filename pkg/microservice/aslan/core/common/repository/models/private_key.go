@@ -76,6 +76,8 @@ type VMAgent struct {
 	AgentVersion      string `bson:"agent_version"        json:"agent_version"`
 	ZadigVersion      string `bson:"zadig_version"        json:"zadig_version"`
 	LastHeartbeatTime int64  `bson:"last_heartbeat_time"  json:"last_heartbeat_time"`
+	// UpgradeVersion is the version the agent is upgrading to, the agent upgrades itself when NeedUpdate is set
+	UpgradeVersion string `bson:"upgrade_version"      json:"upgrade_version"`
 }
 
 func (PrivateKey) TableName() string {
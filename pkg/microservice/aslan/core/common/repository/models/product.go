@@ -18,6 +18,7 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -71,6 +72,7 @@ type Product struct {
 	// New Since v.1.18.0, env configs
 	AnalysisConfig      *AnalysisConfig       `bson:"analysis_config"      json:"analysis_config"`
 	NotificationConfigs []*NotificationConfig `bson:"notification_configs" json:"notification_configs"`
+	HealthConfig        *EnvHealthConfig      `bson:"health_config"        json:"health_config"`
 
 	// New Since v1.19.0, env sleep configs
 	PreSleepStatus map[string]int `bson:"pre_sleep_status" json:"pre_sleep_status"`
@@ -88,6 +90,154 @@ type Product struct {
 	// For production environment
 	Production bool   `json:"production" bson:"production"`
 	Alias      string `json:"alias" bson:"alias"`
+
+	// ImageSigningKeyID is used to verify the signature of the images before rolling out to the production environment
+	ImageSigningKeyID string `json:"image_signing_key_id,omitempty" bson:"image_signing_key_id,omitempty"`
+
+	// ExternalVariable fetches the key/values from the external system like CMDB when rendering the services of the env
+	ExternalVariable *ExternalVariableConfig `json:"external_variable,omitempty" bson:"external_variable,omitempty"`
+
+	// HelmPostRenderer patches the rendered manifests of the helm releases before they are applied
+	HelmPostRenderer *HelmPostRenderer `json:"helm_post_renderer,omitempty" bson:"helm_post_renderer,omitempty"`
+
+	// ResourceVersion is increased on every change of the env, it is used for the optimistic concurrency control of the env updates
+	ResourceVersion int64 `json:"resource_version" bson:"resource_version"`
+
+	// AutoUpgrade upgrades the services of the non-production env when new revisions of the service templates are created
+	AutoUpgrade *EnvAutoUpgradePolicy `json:"auto_upgrade,omitempty" bson:"auto_upgrade,omitempty"`
+}
+
+type EnvAutoUpgradePolicy struct {
+	Enabled bool `json:"enabled" bson:"enabled"`
+	// AllServices upgrades all the services deployed in the env, otherwise only the Services are upgraded
+	AllServices bool     `json:"all_services" bson:"all_services"`
+	Services    []string `json:"services"     bson:"services"`
+	// MaintenanceWindow defers the upgrades to the window if set
+	MaintenanceWindow *MaintenanceWindow `json:"maintenance_window,omitempty" bson:"maintenance_window,omitempty"`
+	// FailedRevisions are the service revisions failed to upgrade and rolled back,
+	// they are not retried until newer revisions are created
+	FailedRevisions map[string]int64 `json:"failed_revisions,omitempty" bson:"failed_revisions,omitempty"`
+}
+
+// MaintenanceWindow is the daily time range in the local time of aslan, it crosses midnight if EndTime is earlier than StartTime
+type MaintenanceWindow struct {
+	// Weekdays are the days the window starts on, 0 is Sunday, empty means every day
+	Weekdays  []int  `json:"weekdays"   bson:"weekdays"`
+	StartTime string `json:"start_time" bson:"start_time"` // HH:MM
+	EndTime   string `json:"end_time"   bson:"end_time"`   // HH:MM
+}
+
+func (w *MaintenanceWindow) Validate() error {
+	start, err := time.Parse("15:04", w.StartTime)
+	if err != nil {
+		return fmt.Errorf("invalid start time %s, should be HH:MM", w.StartTime)
+	}
+	end, err := time.Parse("15:04", w.EndTime)
+	if err != nil {
+		return fmt.Errorf("invalid end time %s, should be HH:MM", w.EndTime)
+	}
+	if start.Equal(end) {
+		return fmt.Errorf("start time and end time should not be the same")
+	}
+	for _, day := range w.Weekdays {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid weekday %d, should be 0-6", day)
+		}
+	}
+	return nil
+}
+
+// Contains tells if the time is in the window, the window is considered closed if it is invalid
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	start, err := time.Parse("15:04", w.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", w.EndTime)
+	if err != nil {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	startMinute, endMinute := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+
+	// the day the window containing t starts on
+	day := t.Weekday()
+	if startMinute < endMinute {
+		if minute < startMinute || minute >= endMinute {
+			return false
+		}
+	} else {
+		if minute >= endMinute && minute < startMinute {
+			return false
+		}
+		if minute < endMinute {
+			day = t.AddDate(0, 0, -1).Weekday()
+		}
+	}
+
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, weekday := range w.Weekdays {
+		if time.Weekday(weekday) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// HelmPostRenderer is the kustomize patch set applied to the helm releases of the env, so the platform-mandated
+// sidecars and labels can be injected into the third-party charts without forking them
+type HelmPostRenderer struct {
+	Enabled bool `json:"enabled" bson:"enabled"`
+	// Services are the service names of zadig services or the release names of chart services the post renderer
+	// applies to, empty means all the releases in the env
+	Services          []string                 `json:"services"           bson:"services"`
+	CommonLabels      map[string]string        `json:"common_labels"      bson:"common_labels"`
+	CommonAnnotations map[string]string        `json:"common_annotations" bson:"common_annotations"`
+	Patches           []*HelmPostRendererPatch `json:"patches"            bson:"patches"`
+}
+
+// HelmPostRendererPatch is a strategic merge patch or a json 6902 patch applied to the resources matching the target,
+// the kind of the target is required
+type HelmPostRendererPatch struct {
+	Patch  string                  `json:"patch"            bson:"patch"`
+	Target *HelmPostRendererTarget `json:"target,omitempty" bson:"target,omitempty"`
+}
+
+type HelmPostRendererTarget struct {
+	Group              string `json:"group"               bson:"group"`
+	Version            string `json:"version"             bson:"version"`
+	Kind               string `json:"kind"                bson:"kind"`
+	Name               string `json:"name"                bson:"name"`
+	Namespace          string `json:"namespace"           bson:"namespace"`
+	LabelSelector      string `json:"label_selector"      bson:"label_selector"`
+	AnnotationSelector string `json:"annotation_selector" bson:"annotation_selector"`
+}
+
+// ExternalVariableConfig configures the provider of the external key/values, the fetched values are exposed in the
+// template context of the services under the Key, e.g. {{.cmdb.region}}
+type ExternalVariableConfig struct {
+	Enabled  bool   `json:"enabled"   bson:"enabled"`
+	Provider string `json:"provider"  bson:"provider"`
+	Key      string `json:"key"       bson:"key"`
+	// CacheTTL is the seconds the fetched values are cached, 0 means the default ttl
+	CacheTTL int64 `json:"cache_ttl" bson:"cache_ttl"`
+	// FallbackValues is the yaml used when the fetch fails and there are no cached values
+	FallbackValues string                      `json:"fallback_values" bson:"fallback_values"`
+	HTTP           *ExternalVariableHTTPConfig `json:"http,omitempty"  bson:"http,omitempty"`
+}
+
+type ExternalVariableHTTPConfig struct {
+	URL string `json:"url"       bson:"url"`
+	// AuthType is one of none, basic and bearer
+	AuthType string            `json:"auth_type" bson:"auth_type"`
+	Username string            `json:"username"  bson:"username"`
+	Password string            `json:"password"  bson:"password"`
+	Token    string            `json:"token"     bson:"token"`
+	Headers  map[string]string `json:"headers"   bson:"headers"`
+	// Timeout is the request timeout in seconds
+	Timeout int `json:"timeout"   bson:"timeout"`
 }
 
 type NotificationEvent string
@@ -127,8 +277,22 @@ const (
 	ResourceTypeNetworkPolicy ResourceType = "NetworkPolicy"
 )
 
+// EnvHealthConfig is the alerting thresholds of the env health score
+type EnvHealthConfig struct {
+	// AlertThreshold is the rolling score below which the env updater is alerted, 0 disables the alert
+	AlertThreshold int `bson:"alert_threshold" json:"alert_threshold"`
+	// SLOThreshold is the score at or above which a sample is counted as healthy, 0 means the default threshold
+	SLOThreshold int `bson:"slo_threshold"   json:"slo_threshold"`
+	// SLOTarget is the target percentage of the healthy samples, 0 means the default target
+	SLOTarget float64 `bson:"slo_target"      json:"slo_target"`
+}
+
 type AnalysisConfig struct {
 	ResourceTypes []ResourceType `bson:"resource_types" json:"resource_types"`
+	// ServiceNames and Workloads scope the analysis to the resources of the services and workloads, the workloads are
+	// in the form of Kind/name. The whole namespace is analyzed if neither is set.
+	ServiceNames []string `bson:"service_names,omitempty" json:"service_names,omitempty"`
+	Workloads    []string `bson:"workloads,omitempty"     json:"workloads,omitempty"`
 }
 
 type CreateUpdateCommonEnvCfgArgs struct {
@@ -174,6 +338,8 @@ type ProductService struct {
 	Resources   []*ServiceResource            `bson:"resources,omitempty"        json:"resources,omitempty"`
 	UpdateTime  int64                         `bson:"update_time"                json:"update_time"`
 	Render      *templatemodels.ServiceRender `bson:"render"                     json:"render,omitempty"` // New since 1.9.0 used to replace service renders in render_set
+	// GitRef is the branch or tag the service is pinned to, the service is updated by the webhook of the ref
+	GitRef string `bson:"git_ref,omitempty"          json:"git_ref,omitempty"`
 
 	EnvConfigs     []*EnvConfig                    `bson:"-"                          json:"env_configs,omitempty"`
 	RenderedYaml   string                          `bson:"rendered_yaml,omitempty"    json:"rendered_yaml,omitempty"`
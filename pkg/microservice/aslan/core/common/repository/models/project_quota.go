@@ -0,0 +1,39 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProjectQuota limits the resources a project can consume, a zero value of the limits means unlimited
+type ProjectQuota struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"                   json:"id"`
+	ProjectName string             `bson:"project_name"                    json:"project_name"`
+	// MaxEnvs is the max number of environments, including the production environments
+	MaxEnvs int `bson:"max_envs"                        json:"max_envs"`
+	// MaxConcurrentWorkflowTasks is the max number of workflow tasks running or queued at the same time
+	MaxConcurrentWorkflowTasks int `bson:"max_concurrent_workflow_tasks"   json:"max_concurrent_workflow_tasks"`
+	// MaxBuildMinutesPerMonth is the max duration of the build, test and scanning jobs in a natural month
+	MaxBuildMinutesPerMonth int64  `bson:"max_build_minutes_per_month"     json:"max_build_minutes_per_month"`
+	UpdatedBy               string `bson:"updated_by"                      json:"updated_by"`
+	UpdateTime              int64  `bson:"update_time"                     json:"update_time"`
+}
+
+func (ProjectQuota) TableName() string {
+	return "project_quota"
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RegistryCredentialRotation records the propagation of the rotated credential of a registry to the pull secrets
+// of the envs using the registry
+type RegistryCredentialRotation struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RegistryID string             `bson:"registry_id"   json:"registry_id"`
+	// Status is running or finished, the rotation is finished even if some of the envs failed
+	Status     string                               `bson:"status"      json:"status"`
+	Total      int                                  `bson:"total"       json:"total"`
+	Succeeded  int                                  `bson:"succeeded"   json:"succeeded"`
+	Failures   []*RegistryCredentialRotationFailure `bson:"failures"    json:"failures"`
+	CreatedBy  string                               `bson:"created_by"  json:"created_by"`
+	CreateTime int64                                `bson:"create_time" json:"create_time"`
+	FinishTime int64                                `bson:"finish_time" json:"finish_time"`
+}
+
+type RegistryCredentialRotationFailure struct {
+	ProjectName string `bson:"project_name" json:"project_name"`
+	EnvName     string `bson:"env_name"     json:"env_name"`
+	Production  bool   `bson:"production"   json:"production"`
+	Namespace   string `bson:"namespace"    json:"namespace"`
+	Error       string `bson:"error"        json:"error"`
+}
+
+func (RegistryCredentialRotation) TableName() string {
+	return "registry_credential_rotation"
+}
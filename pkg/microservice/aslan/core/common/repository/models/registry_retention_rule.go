@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RegistryRetentionRule cleans up the old tags built by zadig in the repositories of the registry used by the project,
+// the tags deployed in the envs of the project are always kept
+type RegistryRetentionRule struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	RegistryID  string             `bson:"registry_id"   json:"registry_id"`
+	// Repos are the repositories the rule applies to, the images of the services of the project are used if empty
+	Repos []string `bson:"repos" json:"repos"`
+	// KeepLatest is the number of the latest tags kept in each repository
+	KeepLatest int `bson:"keep_latest" json:"keep_latest"`
+	// KeepDays keeps the tags pushed in the days, 0 means the tags are not kept by the age
+	KeepDays int `bson:"keep_days" json:"keep_days"`
+	// Enabled runs the cleanup daily, the rule can be run manually anyway
+	Enabled     bool   `bson:"enabled"       json:"enabled"`
+	UpdatedBy   string `bson:"updated_by"    json:"updated_by"`
+	UpdateTime  int64  `bson:"update_time"   json:"update_time"`
+	LastRunTime int64  `bson:"last_run_time" json:"last_run_time"`
+}
+
+func (RegistryRetentionRule) TableName() string {
+	return "registry_retention_rule"
+}
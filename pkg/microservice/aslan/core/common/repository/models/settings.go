@@ -19,14 +19,16 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type SystemSetting struct {
-	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	WorkflowConcurrency int64              `bson:"workflow_concurrency" json:"workflow_concurrency"`
-	BuildConcurrency    int64              `bson:"build_concurrency" json:"build_concurrency"`
-	DefaultLogin        string             `bson:"default_login" json:"default_login"`
-	Theme               *Theme             `bson:"theme" json:"theme"`
-	Security            *SecuritySettings  `bson:"security" json:"security"`
-	Privacy             *PrivacySettings   `bson:"privacy"  json:"privacy"`
-	UpdateTime          int64              `bson:"update_time" json:"update_time"`
+	ID                  primitive.ObjectID    `bson:"_id,omitempty" json:"id,omitempty"`
+	WorkflowConcurrency int64                 `bson:"workflow_concurrency" json:"workflow_concurrency"`
+	BuildConcurrency    int64                 `bson:"build_concurrency" json:"build_concurrency"`
+	DefaultLogin        string                `bson:"default_login" json:"default_login"`
+	Theme               *Theme                `bson:"theme" json:"theme"`
+	Security            *SecuritySettings     `bson:"security" json:"security"`
+	Privacy             *PrivacySettings      `bson:"privacy"  json:"privacy"`
+	CredentialScan      *CredentialScanPolicy `bson:"credential_scan" json:"credential_scan"`
+	AuditLogExport      *AuditLogExportPolicy `bson:"audit_log_export" json:"audit_log_export"`
+	UpdateTime          int64                 `bson:"update_time" json:"update_time"`
 }
 
 type Theme struct {
@@ -65,6 +67,25 @@ type SecuritySettings struct {
 	TokenExpirationTime int64 `json:"token_expiration_time" bson:"token_expiration_time"`
 }
 
+// CredentialScanPolicy controls the scanning of the plaintext credentials in workflows and environment configurations
+type CredentialScanPolicy struct {
+	Enabled bool `json:"enabled" bson:"enabled"`
+	// BlockOnSave rejects the saving of the configuration when credentials are found, otherwise only warnings are logged
+	BlockOnSave bool `json:"block_on_save" bson:"block_on_save"`
+}
+
+// AuditLogExportPolicy controls the forwarding of the audit logs to the SIEM systems
+type AuditLogExportPolicy struct {
+	SyslogEnabled bool `json:"syslog_enabled" bson:"syslog_enabled"`
+	// SyslogNetwork is either udp or tcp
+	SyslogNetwork  string `json:"syslog_network" bson:"syslog_network"`
+	SyslogAddress  string `json:"syslog_address" bson:"syslog_address"`
+	WebhookEnabled bool   `json:"webhook_enabled" bson:"webhook_enabled"`
+	WebhookURL     string `json:"webhook_url" bson:"webhook_url"`
+	// WebhookSecret signs the webhook body with HMAC-SHA256 in the X-Zadig-Signature header if set
+	WebhookSecret string `json:"webhook_secret" bson:"webhook_secret"`
+}
+
 type PrivacySettings struct {
 	ImprovementPlan bool `json:"improvement_plan" bson:"improvement_plan"`
 }
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type StatusBadgeType string
+
+const (
+	StatusBadgeTypeWorkflow StatusBadgeType = "workflow"
+	StatusBadgeTypeEnv      StatusBadgeType = "env"
+)
+
+// StatusBadge grants the access to the status badge of a workflow or an environment by the token without login
+type StatusBadge struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Token       string             `bson:"token"         json:"token"`
+	ProjectName string             `bson:"project_name"  json:"project_name"`
+	Type        StatusBadgeType    `bson:"type"          json:"type"`
+	// Name is the workflow name or the env name
+	Name string `bson:"name"          json:"name"`
+	// Label is the text in the left side of the badge, the workflow display name or the env name is used if empty
+	Label      string `bson:"label"         json:"label"`
+	CreatedBy  string `bson:"created_by"    json:"created_by"`
+	CreateTime int64  `bson:"create_time"   json:"create_time"`
+}
+
+func (StatusBadge) TableName() string {
+	return "status_badge"
+}
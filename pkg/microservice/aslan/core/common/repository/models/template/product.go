@@ -77,10 +77,73 @@ type Product struct {
 	GlobalVariables            []*commontypes.ServiceVariableKV `bson:"global_variables,omitempty"          json:"global_variables,omitempty"`                       // New since 1.18.0 used to store global variables for test services
 	ProductionGlobalVariables  []*commontypes.ServiceVariableKV `bson:"production_global_variables,omitempty"          json:"production_global_variables,omitempty"` // New since 1.18.0 used to store global variables for production services
 	Public                     bool                             `bson:"public,omitempty"                    json:"public"`
+	LogPolicy                  *LogPolicy                       `bson:"log_policy,omitempty"                json:"log_policy,omitempty"`
+	Inheritance                *ProjectInheritance              `bson:"inheritance,omitempty"               json:"inheritance,omitempty"`
+	HelmDefaultValues          string                           `bson:"helm_default_values,omitempty"       json:"helm_default_values,omitempty"` // project level default values of helm envs, overridden by env default values
+	HelmLockedValuesKeys       []*HelmLockedValuesKey           `bson:"helm_locked_values_keys,omitempty"   json:"helm_locked_values_keys,omitempty"`
+	// ExportedServices is the services which can be referenced by the envs of other projects
+	ExportedServices  []string            `bson:"exported_services,omitempty"   json:"exported_services,omitempty"`
+	SharedServiceRefs []*SharedServiceRef `bson:"shared_service_refs,omitempty" json:"shared_service_refs,omitempty"`
+	// DashboardLinks are the url templates of the dashboards of the services, e.g. grafana dashboards
+	DashboardLinks []*DashboardLink `bson:"dashboard_links,omitempty" json:"dashboard_links,omitempty"`
 	// created after 1.8.0, used to create default project admins
 	Admins []string `bson:"-" json:"admins"`
 }
 
+// LogPolicy limits the logs pushed to a single log stream of the project
+type LogPolicy struct {
+	// RateLimit is the maximum lines per second, the exceeded lines are skipped. 0 means unlimited.
+	RateLimit int `bson:"rate_limit" json:"rate_limit"`
+	// MaxLines is the maximum lines retained, the lines between the head and the tail are truncated. 0 means unlimited.
+	MaxLines int `bson:"max_lines"  json:"max_lines"`
+	// TailLines is the lines at the end of the log preserved when the log is truncated.
+	TailLines int `bson:"tail_lines" json:"tail_lines"`
+}
+
+// DashboardLink is the url template of a dashboard, the variables $project, $env, $namespace, $service and
+// $workload are replaced with those of the service in the env
+type DashboardLink struct {
+	Name string `bson:"name" json:"name"`
+	URL  string `bson:"url"  json:"url"`
+}
+
+// ProjectInheritance describes the base project a project inherits services, builds and global variables from.
+// The overridden items are maintained by the project itself and are never changed by the sync.
+type ProjectInheritance struct {
+	BaseProject         string   `bson:"base_project"         json:"base_project"`
+	OverriddenServices  []string `bson:"overridden_services"  json:"overridden_services"`
+	OverriddenBuilds    []string `bson:"overridden_builds"    json:"overridden_builds"`
+	OverriddenVariables []string `bson:"overridden_variables" json:"overridden_variables"`
+	LastSyncTime        int64    `bson:"last_sync_time"       json:"last_sync_time"`
+	LastSyncBy          string   `bson:"last_sync_by"         json:"last_sync_by"`
+}
+
+// HelmLockedValuesKey is a values key of helm services which can only be changed by the users with the override permission.
+// A locked key also locks all the keys under it, e.g. `resources.limits` locks `resources.limits.cpu`.
+type HelmLockedValuesKey struct {
+	Key string `bson:"key"             json:"key"`
+	// ProductionOnly means the key is only locked in production envs
+	ProductionOnly bool `bson:"production_only" json:"production_only"`
+}
+
+// SharedServiceRef is a read-only reference to a service exported by another project.
+// The referenced service is deployed into the envs of the project with the template of its owner project.
+type SharedServiceRef struct {
+	ProjectName string `bson:"project_name" json:"project_name"`
+	ServiceName string `bson:"service_name" json:"service_name"`
+	// Revision pins the template revision of the service, 0 means the latest revision
+	Revision int64 `bson:"revision"     json:"revision"`
+}
+
+func (p *Product) GetSharedServiceRef(serviceName string) *SharedServiceRef {
+	for _, ref := range p.SharedServiceRefs {
+		if ref.ServiceName == serviceName {
+			return ref
+		}
+	}
+	return nil
+}
+
 type ServiceInfo struct {
 	Name  string `bson:"name"  json:"name"`
 	Owner string `bson:"owner" json:"owner"`
@@ -150,6 +213,36 @@ type ServiceRender struct {
 
 	// OverrideYaml will be used in both helm and k8s projects
 	OverrideYaml *CustomYaml `bson:"override_yaml,omitempty"   json:"override_yaml,omitempty"`
+
+	// SchedulingHints are injected into the pods of the rendered workloads when the service is applied
+	SchedulingHints *SchedulingHints `bson:"scheduling_hints,omitempty"   json:"scheduling_hints,omitempty"`
+}
+
+// SchedulingHints target the pods of the service to the nodes of some OS/arch without changing the service template,
+// Tolerations and Affinity are in the yaml form of the k8s pod spec fields
+type SchedulingHints struct {
+	NodeSelector map[string]string `bson:"node_selector,omitempty"   json:"node_selector,omitempty"`
+	Tolerations  string            `bson:"tolerations,omitempty"     json:"tolerations,omitempty"`
+	Affinity     string            `bson:"affinity,omitempty"        json:"affinity,omitempty"`
+}
+
+func (h *SchedulingHints) IsEmpty() bool {
+	return h == nil || (len(h.NodeSelector) == 0 && h.Tolerations == "" && h.Affinity == "")
+}
+
+func (rc *ServiceRender) GetSchedulingHints() *SchedulingHints {
+	if rc == nil {
+		return nil
+	}
+	return rc.SchedulingHints
+}
+
+// InheritSchedulingHints keeps the scheduling hints of the previous render of the service when the render is replaced
+func (rc *ServiceRender) InheritSchedulingHints(prev *ServiceRender) {
+	if rc == nil || prev == nil || rc.SchedulingHints != nil {
+		return
+	}
+	rc.SchedulingHints = prev.SchedulingHints
 }
 
 func (rc *ServiceRender) DeployedFromZadig() bool {
@@ -292,6 +385,14 @@ func (p *Product) IsHostProduct() bool {
 	return p.ProductFeature != nil && p.ProductFeature.BasicFacility == setting.BasicFacilityK8S && p.ProductFeature.CreateEnvType == setting.SourceFromExternal
 }
 
+// GetLogPolicy returns the log policy of the project, the logs are not limited if it is not configured
+func (p *Product) GetLogPolicy() *LogPolicy {
+	if p.LogPolicy != nil {
+		return p.LogPolicy
+	}
+	return &LogPolicy{}
+}
+
 func (r *RenderKV) SetAlias() {
 	r.Alias = "{{." + r.Key + "}}"
 }
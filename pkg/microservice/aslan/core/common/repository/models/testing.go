@@ -42,10 +42,14 @@ type Testing struct {
 	UpdateBy       string              `bson:"update_by"                json:"update_by"`
 	// Junit 测试报告
 	TestResultPath string `bson:"test_result_path"         json:"test_result_path"`
+	// 测试结果格式 junit/allure，为空时按 junit 处理
+	TestResultType string `bson:"test_result_type"         json:"test_result_type"`
 	// html 测试报告
 	TestReportPath string `bson:"test_report_path"         json:"test_report_path"`
 	Threshold      int    `bson:"threshold"                json:"threshold"`
 	TestType       string `bson:"test_type"                json:"test_type"`
+	// 覆盖率报告
+	CoverageReport *CoverageReport `bson:"coverage_report,omitempty" json:"coverage_report,omitempty"`
 
 	// TODO: Deprecated.
 	Caches []string `bson:"caches"                   json:"caches"`
@@ -71,6 +75,14 @@ type Testing struct {
 	// New since V1.10.0. Only to tell the webpage should the advanced settings be displayed
 	AdvancedSettingsModified bool      `bson:"advanced_setting_modified" json:"advanced_setting_modified"`
 	Outputs                  []*Output `bson:"outputs"                   json:"outputs"`
+	// SoakTest enables the progress checkpoints for long-running tests
+	SoakTest *SoakTestSetting `bson:"soak_test,omitempty"       json:"soak_test,omitempty"`
+}
+
+type SoakTestSetting struct {
+	Enabled bool `bson:"enabled"             json:"enabled"`
+	// CheckpointInterval is the interval in seconds to report the progress, 60 by default
+	CheckpointInterval int64 `bson:"checkpoint_interval" json:"checkpoint_interval"`
 }
 
 type TestingHookCtrl struct {
@@ -107,6 +119,8 @@ type PreTest struct {
 	ClusterSource    string `bson:"cluster_source"         json:"cluster_source"`
 	StrategyID       string `bson:"strategy_id"            json:"strategy_id"`
 	ConcurrencyLimit int    `bson:"concurrency_limit"      json:"concurrency_limit"`
+	// PodTemplate is the name of the project level pod template used by the test
+	PodTemplate string `bson:"pod_template,omitempty" json:"pod_template,omitempty"`
 	// TODO: Deprecated.
 	Namespace string `bson:"namespace"              json:"namespace"`
 
@@ -45,6 +45,10 @@ type VMJob struct {
 	JobCtx         string             `bson:"job_ctx"                json:"job_ctx"`
 	LogFile        string             `bson:"log_file"               json:"log_file"`
 	Outputs        []*job.JobOutput   `bson:"outputs"                json:"outputs"`
+	// RequeueCount is the times the job is requeued because the agent died when running it
+	RequeueCount int `bson:"requeue_count"          json:"-"`
+	// LogChunks is the number of log chunks uploaded to the object storage when the job is running
+	LogChunks int `bson:"log_chunks"             json:"-"`
 }
 
 type ReportJobParameters struct {
@@ -26,6 +26,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/setting"
 	"github.com/koderover/zadig/v2/pkg/tool/blueking"
 	"github.com/koderover/zadig/v2/pkg/types"
+	"github.com/koderover/zadig/v2/pkg/types/job"
 )
 
 type WorkflowTask struct {
@@ -62,8 +63,14 @@ type WorkflowTask struct {
 	Hash                string                        `bson:"hash"                      json:"hash"`
 	ApprovalTicketID    string                        `bson:"approval_ticket_id"        json:"approval_ticket_id"`
 	ApprovalID          string                        `bson:"approval_id"               json:"approval_id"`
+	Tags                []string                      `bson:"tags,omitempty"            json:"tags,omitempty"`
 }
 
+const (
+	// WorkflowTaskTagInfraRetried marks the task whose jobs are requeued by the infra retry policy
+	WorkflowTaskTagInfraRetried = "infra-retried"
+)
+
 func (WorkflowTask) TableName() string {
 	return "workflow_task"
 }
@@ -80,6 +87,8 @@ type StageTask struct {
 	EndTime    int64         `bson:"end_time"        json:"end_time,omitempty"`
 	Parallel   bool          `bson:"parallel"        json:"parallel,omitempty"`
 	ManualExec *ManualExec   `bson:"manual_exec"     json:"manual_exec,omitempty"`
+	Dynamic    *DynamicStage `bson:"dynamic,omitempty" json:"dynamic,omitempty"`
+	OnFailure  bool          `bson:"on_failure"      json:"on_failure"`
 	Jobs       []*JobTask    `bson:"jobs"            json:"jobs,omitempty"`
 	Error      string        `bson:"error"           json:"error"`
 }
@@ -120,6 +129,20 @@ type JobTask struct {
 
 	RetryCount int  `bson:"retry_count" json:"retry_count" yaml:"retry_count"`
 	Reverted   bool `bson:"reverted"    json:"reverted"    yaml:"reverted"`
+
+	// Diagnosis is given by the llm when the job failed
+	Diagnosis *JobDiagnosis `bson:"diagnosis,omitempty" json:"diagnosis,omitempty" yaml:"diagnosis,omitempty"`
+	// InfraRetryCount is the times the job is requeued for infrastructure errors, InfraFailureReason is the last error category
+	InfraRetryCount    int    `bson:"infra_retry_count,omitempty"    json:"infra_retry_count,omitempty"    yaml:"infra_retry_count,omitempty"`
+	InfraFailureReason string `bson:"infra_failure_reason,omitempty" json:"infra_failure_reason,omitempty" yaml:"infra_failure_reason,omitempty"`
+	// Checkpoint is the progress of the long-running test job, it is merged from all the pods when the job is done
+	Checkpoint *job.JobCheckpoint `bson:"checkpoint,omitempty" json:"checkpoint,omitempty" yaml:"checkpoint,omitempty"`
+}
+
+type JobDiagnosis struct {
+	ProbableCause string `bson:"probable_cause" json:"probable_cause" yaml:"probable_cause"`
+	SuggestedFix  string `bson:"suggested_fix"  json:"suggested_fix"  yaml:"suggested_fix"`
+	CreateTime    int64  `bson:"create_time"    json:"create_time"    yaml:"create_time"`
 }
 
 type TaskJobInfo struct {
@@ -223,6 +246,10 @@ type JobTaskDeploySpec struct {
 	Image         string `bson:"image"                            json:"image"                               yaml:"-"`
 	// for revert
 	OriginRevision int64 `bson:"origin_revision"                   json:"origin_revision"                      yaml:"origin_revision"`
+	// verify the image signature before deploying to the production environment
+	ImageSigningKeyID string `bson:"image_signing_key_id,omitempty"   json:"image_signing_key_id,omitempty"       yaml:"image_signing_key_id,omitempty"`
+	// probes run after the deployment is ready
+	SmokeCheck *SmokeCheck `bson:"smoke_check,omitempty"            json:"smoke_check,omitempty"                yaml:"smoke_check,omitempty"`
 }
 
 type JobTaskDeployRevertSpec struct {
@@ -264,7 +291,9 @@ type JobTaskHelmDeploySpec struct {
 	IsProduction bool   `bson:"is_production" yaml:"is_production" json:"is_production"`
 	YamlContent  string `bson:"yaml_content"                     json:"yaml_content"                        yaml:"yaml_content"`
 	// UserSuppliedValue added since 1.18, the values that users gives.
-	UserSuppliedValue  string                   `bson:"user_supplied_value" json:"user_supplied_value" yaml:"user_supplied_value"`
+	UserSuppliedValue string `bson:"user_supplied_value" json:"user_supplied_value" yaml:"user_supplied_value"`
+	// ValuesKVs are merged into the override kvs of the service in env
+	ValuesKVs          []*DeployValuesKV        `bson:"values_kvs,omitempty"             json:"values_kvs,omitempty"                yaml:"values_kvs,omitempty"`
 	UpdateConfig       bool                     `bson:"update_config"                    json:"update_config"                       yaml:"update_config"`
 	SkipCheckRunStatus bool                     `bson:"skip_check_run_status"            json:"skip_check_run_status"               yaml:"skip_check_run_status"`
 	ImageAndModules    []*ImageAndServiceModule `bson:"image_and_service_modules"        json:"image_and_service_modules"           yaml:"image_and_service_modules"`
@@ -273,6 +302,12 @@ type JobTaskHelmDeploySpec struct {
 	Timeout            int                      `bson:"timeout"                          json:"timeout"                             yaml:"timeout"`
 	ReplaceResources   []Resource               `bson:"replace_resources"                json:"replace_resources"                   yaml:"replace_resources"`
 	OriginRevision     int64                    `bson:"origin_revision"                  json:"origin_revision"                     yaml:"origin_revision"`
+	// verify the image signature before deploying to the production environment
+	ImageSigningKeyID string `bson:"image_signing_key_id,omitempty"   json:"image_signing_key_id,omitempty"      yaml:"image_signing_key_id,omitempty"`
+	// HookDiagnosis is the status, logs and events of the failed helm hooks
+	HookDiagnosis string `bson:"hook_diagnosis,omitempty"         json:"hook_diagnosis,omitempty"            yaml:"hook_diagnosis,omitempty"`
+	// probes run after the release is ready
+	SmokeCheck *SmokeCheck `bson:"smoke_check,omitempty"            json:"smoke_check,omitempty"               yaml:"smoke_check,omitempty"`
 }
 
 func (j *JobTaskHelmDeploySpec) GetDeployImages() []string {
@@ -474,6 +509,10 @@ type JobTaskJiraSpec struct {
 	IssueType    string     `bson:"issue_type"  json:"issue_type"  yaml:"issue_type"`
 	Issues       []*IssueID `bson:"issues" json:"issues" yaml:"issues"`
 	TargetStatus string     `bson:"target_status" json:"target_status" yaml:"target_status"`
+	// IssueSource, EnvTransitions and Comment are the same as JiraJobSpec
+	IssueSource    string               `bson:"issue_source"    json:"issue_source"    yaml:"issue_source"`
+	EnvTransitions []*JiraEnvTransition `bson:"env_transitions" json:"env_transitions" yaml:"env_transitions"`
+	Comment        string               `bson:"comment"         json:"comment"         yaml:"comment"`
 }
 
 type JobTaskNacosSpec struct {
@@ -521,9 +560,10 @@ type ApolloKV struct {
 }
 
 type JobTaskJenkinsSpec struct {
-	ID   string                `bson:"id" json:"id" yaml:"id"`
-	Host string                `bson:"host" json:"host" yaml:"host"`
-	Job  JobTaskJenkinsJobInfo `bson:"job" json:"job" yaml:"job"`
+	ID            string                `bson:"id" json:"id" yaml:"id"`
+	Host          string                `bson:"host" json:"host" yaml:"host"`
+	Job           JobTaskJenkinsJobInfo `bson:"job" json:"job" yaml:"job"`
+	PullArtifacts bool                  `bson:"pull_artifacts" json:"pull_artifacts" yaml:"pull_artifacts"`
 }
 
 type JobTaskJenkinsJobInfo struct {
@@ -531,6 +571,10 @@ type JobTaskJenkinsJobInfo struct {
 	JobID      int                    `bson:"job_id" json:"job_id" yaml:"job_id"`
 	JobOutput  string                 `bson:"job_output" json:"job_output" yaml:"job_output"`
 	Parameters []*JenkinsJobParameter `bson:"parameters" json:"parameters" yaml:"parameters"`
+	// Result is the raw result of the jenkins build, e.g. SUCCESS, UNSTABLE, FAILURE, ABORTED, NOT_BUILT
+	Result string `bson:"result" json:"result" yaml:"result"`
+	// Artifacts is the number of the artifacts pulled from the jenkins build
+	Artifacts int `bson:"artifacts" json:"artifacts" yaml:"artifacts"`
 }
 
 type JobTaskBlueKingSpec struct {
@@ -548,6 +592,22 @@ type JobTaskBlueKingSpec struct {
 	InstanceName string `bson:"instance_name"       json:"instance_name"       yaml:"instance_name"`
 }
 
+type JobTaskGitHubActionsSpec struct {
+	// Input Parameters
+	CodeHostID   int                   `bson:"codehost_id"   json:"codehost_id"   yaml:"codehost_id"`
+	RepoOwner    string                `bson:"repo_owner"    json:"repo_owner"    yaml:"repo_owner"`
+	RepoName     string                `bson:"repo_name"     json:"repo_name"     yaml:"repo_name"`
+	WorkflowFile string                `bson:"workflow_file" json:"workflow_file" yaml:"workflow_file"`
+	Ref          string                `bson:"ref"           json:"ref"           yaml:"ref"`
+	Inputs       []*GitHubActionsInput `bson:"inputs"        json:"inputs"        yaml:"inputs"`
+
+	// task data
+	RunID      int64  `bson:"run_id"        json:"run_id"        yaml:"run_id"`
+	RunURL     string `bson:"run_url"       json:"run_url"       yaml:"run_url"`
+	RunStatus  string `bson:"run_status"    json:"run_status"    yaml:"run_status"`
+	Conclusion string `bson:"conclusion"    json:"conclusion"    yaml:"conclusion"`
+}
+
 type JobTaskApprovalSpec struct {
 	Timeout          int64               `bson:"timeout"                     yaml:"timeout"                       json:"timeout"`
 	Type             config.ApprovalType `bson:"type"                        yaml:"type"                          json:"type"`
@@ -575,6 +635,18 @@ type WorkflowTriggerEvent struct {
 	ProjectName         string        `bson:"project_name" json:"project_name" yaml:"project_name"`
 }
 
+type JobTaskSubWorkflowSpec struct {
+	ProjectName         string   `bson:"project_name"          json:"project_name"          yaml:"project_name"`
+	WorkflowName        string   `bson:"workflow_name"         json:"workflow_name"         yaml:"workflow_name"`
+	WorkflowDisplayName string   `bson:"workflow_display_name" json:"workflow_display_name" yaml:"workflow_display_name"`
+	Params              []*Param `bson:"params"                json:"params"                yaml:"params"`
+	Async               bool     `bson:"async"                 json:"async"                 yaml:"async"`
+	// TaskID, TaskStatus and TaskURL are the task of the sub workflow created by the job
+	TaskID     int64         `bson:"task_id"               json:"task_id"               yaml:"task_id"`
+	TaskStatus config.Status `bson:"task_status"           json:"task_status"           yaml:"task_status"`
+	TaskURL    string        `bson:"task_url"              json:"task_url"              yaml:"task_url"`
+}
+
 type JobTaskOfflineServiceSpec struct {
 	EnvType       config.EnvType                `bson:"env_type" json:"env_type" yaml:"env_type"`
 	EnvName       string                        `bson:"env_name" json:"env_name" yaml:"env_name"`
@@ -588,6 +660,83 @@ type JobTaskOfflineServiceEvent struct {
 	Error       string        `bson:"error" json:"error" yaml:"error"`
 }
 
+type JobTaskEnvAnalysisSpec struct {
+	EnvName        string   `bson:"env_name"            json:"env_name"            yaml:"env_name"`
+	Production     bool     `bson:"production"          json:"production"          yaml:"production"`
+	ResourceTypes  []string `bson:"resource_types"      json:"resource_types"      yaml:"resource_types"`
+	ServiceNames   []string `bson:"service_names"       json:"service_names"       yaml:"service_names"`
+	Workloads      []string `bson:"workloads"           json:"workloads"           yaml:"workloads"`
+	FailOnProblems bool     `bson:"fail_on_problems"    json:"fail_on_problems"    yaml:"fail_on_problems"`
+	// Result is the findings of the analysis attached to the task
+	Result   string `bson:"result"              json:"result"              yaml:"result"`
+	Problems int    `bson:"problems"            json:"problems"            yaml:"problems"`
+}
+
+type JobTaskFeatureFlagSpec struct {
+	FeatureFlagID string `bson:"feature_flag_id"        json:"feature_flag_id"        yaml:"feature_flag_id"`
+	// Type is launchdarkly, unleash or flagsmith
+	Type       string               `bson:"type"                   json:"type"                   yaml:"type"`
+	ProjectKey string               `bson:"project_key"            json:"project_key"            yaml:"project_key"`
+	EnvName    string               `bson:"env_name"               json:"env_name"               yaml:"env_name"`
+	FlagEnv    string               `bson:"flag_env"               json:"flag_env"               yaml:"flag_env"`
+	Flags      []*FeatureFlagChange `bson:"flags"                  json:"flags"                  yaml:"flags"`
+}
+
+// FeatureFlagChange records the change of a flag so that it can be rolled back when the workflow fails
+type FeatureFlagChange struct {
+	Key           string `bson:"key"                    json:"key"                    yaml:"key"`
+	Enabled       bool   `bson:"enabled"                json:"enabled"                yaml:"enabled"`
+	OriginEnabled bool   `bson:"origin_enabled"         json:"origin_enabled"         yaml:"origin_enabled"`
+	Changed       bool   `bson:"changed"                json:"changed"                yaml:"changed"`
+	RolledBack    bool   `bson:"rolled_back"            json:"rolled_back"            yaml:"rolled_back"`
+	Error         string `bson:"error,omitempty"        json:"error,omitempty"        yaml:"error,omitempty"`
+}
+
+const (
+	CanaryAnalysisResultPromoted   = "promoted"
+	CanaryAnalysisResultRolledBack = "rolled_back"
+)
+
+type JobTaskCanaryAnalysisSpec struct {
+	ObservabilityID string                  `bson:"observability_id"       json:"observability_id"       yaml:"observability_id"`
+	Duration        int64                   `bson:"duration"               json:"duration"               yaml:"duration"`
+	Interval        int64                   `bson:"interval"               json:"interval"               yaml:"interval"`
+	FailureLimit    int                     `bson:"failure_limit"          json:"failure_limit"          yaml:"failure_limit"`
+	Metrics         []*CanaryAnalysisMetric `bson:"metrics"                json:"metrics"                yaml:"metrics"`
+	// DeployType is the type of the deploy job that the canary comes from
+	DeployType     config.JobType `bson:"deploy_type"            json:"deploy_type"            yaml:"deploy_type"`
+	ClusterID      string         `bson:"cluster_id"             json:"cluster_id"             yaml:"cluster_id"`
+	Namespace      string         `bson:"namespace"              json:"namespace"              yaml:"namespace"`
+	K8sServiceName string         `bson:"k8s_service_name"       json:"k8s_service_name"       yaml:"k8s_service_name"`
+	// only used by k8s canary deploy
+	WorkloadName  string `bson:"workload_name"          json:"workload_name"          yaml:"workload_name"`
+	ContainerName string `bson:"container_name"         json:"container_name"         yaml:"container_name"`
+	Image         string `bson:"image"                  json:"image"                  yaml:"image"`
+	// only used by blue-green deploy, the blue deployment runs the new version
+	Env              string                    `bson:"env"                    json:"env"                    yaml:"env"`
+	BlueGreenService *BlueGreenDeployV2Service `bson:"blue_green_service"     json:"blue_green_service"     yaml:"blue_green_service"`
+	// Result is promoted or rolled_back after the analysis finishes
+	Result    string                    `bson:"result"                 json:"result"                 yaml:"result"`
+	Snapshots []*CanaryAnalysisSnapshot `bson:"snapshots"              json:"snapshots"              yaml:"snapshots"`
+	Events    *Events                   `bson:"events"                 json:"events"                 yaml:"events"`
+}
+
+// CanaryAnalysisSnapshot is the metrics of both variants at a sample time
+type CanaryAnalysisSnapshot struct {
+	Time    int64                   `bson:"time"                   json:"time"                   yaml:"time"`
+	Passed  bool                    `bson:"passed"                 json:"passed"                 yaml:"passed"`
+	Metrics []*CanaryMetricSnapshot `bson:"metrics"                json:"metrics"                yaml:"metrics"`
+}
+
+type CanaryMetricSnapshot struct {
+	Name   string  `bson:"name"                   json:"name"                   yaml:"name"`
+	Canary float64 `bson:"canary"                 json:"canary"                 yaml:"canary"`
+	Stable float64 `bson:"stable"                 json:"stable"                 yaml:"stable"`
+	Passed bool    `bson:"passed"                 json:"passed"                 yaml:"passed"`
+	// Error is set if the metric can't be queried, the metric is not judged then
+	Error string `bson:"error,omitempty"        json:"error,omitempty"        yaml:"error,omitempty"`
+}
+
 type JobTaskGrafanaSpec struct {
 	ID   string `bson:"id" json:"id" yaml:"id"`
 	Name string `bson:"name" json:"name" yaml:"name"`
@@ -745,4 +894,10 @@ type WorkflowTaskCtx struct {
 	GlobalContextEach           func(f func(k, v string) bool)
 	ClusterIDAdd                func(clusterID string)
 	StartTime                   time.Time
+	// FailedJobDiagnosis is true if the failed jobs are diagnosed by the llm
+	FailedJobDiagnosis bool
+	InfraRetryPolicy   *InfraRetryPolicy
+	TaskTagAdd         func(tag string)
+	// WorkflowStatusGet returns the status of the workflow task, it's final when the jobs are cleaned
+	WorkflowStatusGet func() config.Status
 }
@@ -34,6 +34,8 @@ type WorkflowQueue struct {
 	TaskRevoker         string                        `bson:"task_revoker,omitempty"                     json:"task_revoker,omitempty"`
 	CreateTime          int64                         `bson:"create_time"                                json:"create_time,omitempty"`
 	Type                config.CustomWorkflowTaskType `bson:"type"                                       json:"type,omitempty"`
+	// Priority is set by the admin, the waiting tasks with higher priority are scheduled first
+	Priority int `bson:"priority"                                   json:"priority"`
 }
 
 func (WorkflowQueue) TableName() string {
@@ -0,0 +1,51 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkflowSchedule is a timezone aware cron trigger of a custom workflow,
+// the workflow args are frozen when the schedule is saved
+type WorkflowSchedule struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"    json:"id"`
+	Name         string             `bson:"name"             json:"name"`
+	ProjectName  string             `bson:"project_name"     json:"project_name"`
+	WorkflowName string             `bson:"workflow_name"    json:"workflow_name"`
+	// Cron is a standard 5-field cron expression
+	Cron string `bson:"cron"             json:"cron"`
+	// Timezone is an IANA timezone name, server local timezone is used if empty
+	Timezone      string      `bson:"timezone"         json:"timezone"`
+	Enabled       bool        `bson:"enabled"          json:"enabled"`
+	SkipIfRunning bool        `bson:"skip_if_running"  json:"skip_if_running"`
+	WorkflowArgs  *WorkflowV4 `bson:"workflow_args"    json:"workflow_args"`
+
+	NextRunTime    int64  `bson:"next_run_time"    json:"next_run_time"`
+	LastRunTime    int64  `bson:"last_run_time"    json:"last_run_time"`
+	LastTaskID     int64  `bson:"last_task_id"     json:"last_task_id"`
+	LastRunStatus  string `bson:"last_run_status"  json:"last_run_status"`
+	LastRunMessage string `bson:"last_run_message" json:"last_run_message"`
+
+	CreatedBy  string `bson:"created_by"       json:"created_by"`
+	CreateTime int64  `bson:"create_time"      json:"create_time"`
+	UpdatedBy  string `bson:"updated_by"       json:"updated_by"`
+	UpdateTime int64  `bson:"update_time"      json:"update_time"`
+}
+
+func (WorkflowSchedule) TableName() string {
+	return "workflow_schedule"
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkflowTaskArtifact is a file uploaded to object storage by the archive steps of a workflow task job
+type WorkflowTaskArtifact struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"     json:"id"`
+	ProjectName    string             `bson:"project_name"      json:"project_name"`
+	WorkflowName   string             `bson:"workflow_name"     json:"workflow_name"`
+	TaskID         int64              `bson:"task_id"           json:"task_id"`
+	JobName        string             `bson:"job_name"          json:"job_name"`
+	JobDisplayName string             `bson:"job_display_name"  json:"job_display_name"`
+	Name           string             `bson:"name"              json:"name"`
+	StorageID      string             `bson:"storage_id"        json:"storage_id"`
+	ObjectKey      string             `bson:"object_key"        json:"object_key"`
+	// Size of the artifact in bytes
+	Size       int64 `bson:"size"              json:"size"`
+	CreateTime int64 `bson:"create_time"       json:"create_time"`
+	// ExpireTime is 0 if the artifact never expires
+	ExpireTime int64 `bson:"expire_time"       json:"expire_time"`
+}
+
+func (WorkflowTaskArtifact) TableName() string {
+	return "workflow_task_artifact"
+}
@@ -37,6 +37,30 @@ type WorkflowV4Template struct {
 	BuildIn          bool                     `bson:"build_in"            yaml:"build_in"           json:"build_in"`
 	ShareStorages    []*ShareStorage          `bson:"share_storages"      yaml:"share_storages"     json:"share_storages"`
 	ConcurrencyLimit int                      `bson:"concurrency_limit"   yaml:"concurrency_limit"  json:"concurrency_limit"`
+	// the fields below are set for the templates imported from the bundles, the version increases when a new version of
+	// the bundle is imported and the workflows instantiated from the older versions are notified
+	Version        int                      `bson:"version,omitempty"         yaml:"version,omitempty"         json:"version,omitempty"`
+	TemplateParams []*WorkflowTemplateParam `bson:"template_params,omitempty" yaml:"template_params,omitempty" json:"template_params,omitempty"`
+	Documentation  string                   `bson:"documentation,omitempty"   yaml:"documentation,omitempty"   json:"documentation,omitempty"`
+	// Content is the workflow yaml of the bundle with the parameter placeholders like {{.param}}, the stages of the
+	// template are rendered with the default values of the parameters
+	Content string `bson:"content,omitempty"         yaml:"content,omitempty"         json:"content,omitempty"`
+}
+
+const (
+	WorkflowTemplateParamTypeString = "string"
+	WorkflowTemplateParamTypeNumber = "number"
+	WorkflowTemplateParamTypeBool   = "bool"
+	WorkflowTemplateParamTypeEnum   = "enum"
+)
+
+type WorkflowTemplateParam struct {
+	Name        string   `bson:"name"              yaml:"name"              json:"name"`
+	Type        string   `bson:"type"              yaml:"type"              json:"type"`
+	Default     string   `bson:"default"           yaml:"default"           json:"default"`
+	Options     []string `bson:"options,omitempty" yaml:"options,omitempty" json:"options,omitempty"`
+	Required    bool     `bson:"required"          yaml:"required"          json:"required"`
+	Description string   `bson:"description"       yaml:"description"       json:"description"`
 }
 
 func (WorkflowV4Template) TableName() string {
@@ -70,6 +70,69 @@ type WorkflowV4 struct {
 	ConcurrencyLimit     int          `bson:"concurrency_limit"      yaml:"concurrency_limit"      json:"concurrency_limit"`
 	CustomField          *CustomField `bson:"custom_field"           yaml:"-"                      json:"custom_field"`
 	EnableApprovalTicket bool         `bson:"enable_approval_ticket" yaml:"enable_approval_ticket" json:"enable_approval_ticket"`
+	// CommitStatusReport reports the status of each stage to the commit which triggers the task
+	CommitStatusReport *CommitStatusReport `bson:"commit_status_report" yaml:"commit_status_report" json:"commit_status_report"`
+	// FailedJobDiagnosis asks the llm to diagnose the log of the failed jobs, the diagnosis is attached to the job
+	FailedJobDiagnosis bool `bson:"failed_job_diagnosis" yaml:"failed_job_diagnosis" json:"failed_job_diagnosis"`
+	// Template is the workflow template the workflow is instantiated from
+	Template *WorkflowTemplateRef `bson:"template,omitempty" yaml:"-" json:"template,omitempty"`
+	// CodeSource is set if the definition of the workflow lives in a git repo, the workflow is synced from the repo
+	// and can not be edited directly
+	CodeSource *WorkflowCodeSource `bson:"code_source,omitempty" yaml:"-" json:"code_source,omitempty"`
+	// InfraRetryPolicy requeues the jobs failed by infrastructure errors
+	InfraRetryPolicy *InfraRetryPolicy `bson:"infra_retry_policy,omitempty" yaml:"infra_retry_policy,omitempty" json:"infra_retry_policy,omitempty"`
+}
+
+type CommitStatusReport struct {
+	Enabled bool `bson:"enabled" yaml:"enabled" json:"enabled"`
+}
+
+// InfraRetryPolicy requeues the jobs failed by infrastructure errors like pod eviction, image pull errors and not ready
+// nodes, the jobs are moved to the fallback targets in order. Failures of the job itself are handled by the error policy.
+type InfraRetryPolicy struct {
+	Enabled         bool                `bson:"enabled"          yaml:"enabled"          json:"enabled"`
+	MaximumRetry    int                 `bson:"maximum_retry"    yaml:"maximum_retry"    json:"maximum_retry"`
+	FallbackTargets []*InfraRetryTarget `bson:"fallback_targets" yaml:"fallback_targets" json:"fallback_targets"`
+}
+
+// InfraRetryTarget is a cluster and the scheduling strategy of its node pool
+type InfraRetryTarget struct {
+	ClusterID  string `bson:"cluster_id"  yaml:"cluster_id"  json:"cluster_id"`
+	StrategyID string `bson:"strategy_id" yaml:"strategy_id" json:"strategy_id"`
+}
+
+const (
+	// WorkflowCodeSyncModeReadOnly rejects the edits from zadig
+	WorkflowCodeSyncModeReadOnly = "read_only"
+	// WorkflowCodeSyncModePullRequest exports the edits from zadig back to the repo as a pull request
+	WorkflowCodeSyncModePullRequest = "pull_request"
+)
+
+type WorkflowCodeSource struct {
+	CodehostID     int    `bson:"codehost_id"      json:"codehost_id"`
+	RepoOwner      string `bson:"repo_owner"       json:"repo_owner"`
+	RepoNamespace  string `bson:"repo_namespace"   json:"repo_namespace"`
+	RepoName       string `bson:"repo_name"        json:"repo_name"`
+	Branch         string `bson:"branch"           json:"branch"`
+	Path           string `bson:"path"             json:"path"`
+	Mode           string `bson:"mode"             json:"mode"`
+	LastSyncCommit string `bson:"last_sync_commit" json:"last_sync_commit"`
+	LastSyncTime   int64  `bson:"last_sync_time"   json:"last_sync_time"`
+	SyncError      string `bson:"sync_error"       json:"sync_error"`
+}
+
+func (s *WorkflowCodeSource) GetRepoNamespace() string {
+	if s.RepoNamespace != "" {
+		return s.RepoNamespace
+	}
+	return s.RepoOwner
+}
+
+type WorkflowTemplateRef struct {
+	ID      string            `bson:"id"      json:"id"`
+	Name    string            `bson:"name"    json:"name"`
+	Version int               `bson:"version" json:"version"`
+	Params  map[string]string `bson:"params"  json:"params"`
 }
 
 func (w *WorkflowV4) UpdateHash() {
@@ -78,7 +141,7 @@ func (w *WorkflowV4) UpdateHash() {
 
 func (w *WorkflowV4) CalculateHash() [md5.Size]byte {
 	fieldList := make(map[string]interface{})
-	ignoringFieldList := []string{"CreatedBy", "CreateTime", "UpdatedBy", "UpdateTime", "Description", "Hash", "DisplayName", "HookCtls", "JiraHookCtls", "MeegoHookCtls", "GeneralHookCtls", "ConcurrencyLimit", "ShareStorages", "NotifyCtls"}
+	ignoringFieldList := []string{"CreatedBy", "CreateTime", "UpdatedBy", "UpdateTime", "Description", "Hash", "DisplayName", "HookCtls", "JiraHookCtls", "MeegoHookCtls", "GeneralHookCtls", "ConcurrencyLimit", "ShareStorages", "NotifyCtls", "CommitStatusReport", "FailedJobDiagnosis", "Template", "CodeSource", "InfraRetryPolicy"}
 	ignoringFields := sets.NewString(ignoringFieldList...)
 
 	val := reflect.ValueOf(*w)
@@ -113,6 +176,19 @@ type WorkflowStage struct {
 	Approval   *Approval   `bson:"approval"           yaml:"approval"          json:"approval"`
 	ManualExec *ManualExec `bson:"manual_exec"        yaml:"manual_exec"       json:"manual_exec"`
 	Jobs       []*Job      `bson:"jobs"               yaml:"jobs"              json:"jobs"`
+	// Dynamic expands the stage at runtime by the json list output of a job in previous stages
+	Dynamic *DynamicStage `bson:"dynamic,omitempty"  yaml:"dynamic,omitempty" json:"dynamic,omitempty"`
+	// OnFailure marks the stage as a failure handler, it is skipped unless the task fails or is cancelled
+	OnFailure bool `bson:"on_failure"         yaml:"on_failure"        json:"on_failure"`
+}
+
+// DynamicStage jobs of services not in the list are skipped, and jobs using {{.stage.item}} are cloned for every item in the list
+type DynamicStage struct {
+	Enabled bool `bson:"enabled"            yaml:"enabled"            json:"enabled"`
+	// Source is the job output variable containing the json list, e.g. {{.job.detect.output.SERVICES}}
+	Source string `bson:"source"             yaml:"source"             json:"source"`
+	// Expanded marks the stage task has been expanded, to avoid expanding again when the task is restarted
+	Expanded bool `bson:"expanded,omitempty" yaml:"-"                  json:"expanded,omitempty"`
 }
 
 type ManualExec struct {
@@ -145,6 +221,9 @@ type NativeApproval struct {
 	RejectOrApprove   config.ApprovalStatus `bson:"reject_or_approve"           yaml:"-"                          json:"reject_or_approve"`
 	// InstanceCode: native approval instance code, save for working after restart aslan
 	InstanceCode string `bson:"instance_code"               yaml:"instance_code"              json:"instance_code"`
+	// CallbackSecret if set, the approval can be resolved by REST callback signed with the secret, it's encrypted when
+	// the workflow is saved and masked in the workflow responses
+	CallbackSecret string `bson:"callback_secret,omitempty"   yaml:"callback_secret,omitempty"  json:"callback_secret,omitempty"`
 }
 
 type DingTalkApproval struct {
@@ -244,6 +323,8 @@ type User struct {
 	RejectOrApprove config.ApprovalStatus `bson:"reject_or_approve,omitempty" yaml:"-"                          json:"reject_or_approve,omitempty"`
 	Comment         string                `bson:"comment,omitempty"           yaml:"-"                          json:"comment,omitempty"`
 	OperationTime   int64                 `bson:"operation_time,omitempty"    yaml:"-"                          json:"operation_time,omitempty"`
+	// ApproveChannel records where the user approved or rejected from
+	ApproveChannel config.ApprovalChannel `bson:"approve_channel,omitempty"   yaml:"-"                          json:"approve_channel,omitempty"`
 }
 
 type Job struct {
@@ -336,6 +417,14 @@ type ZadigBuildJobSpec struct {
 	DefaultServiceAndBuilds []*ServiceAndBuild      `bson:"default_service_and_builds"     yaml:"default_service_and_builds"         json:"default_service_and_builds"`
 	ServiceAndBuilds        []*ServiceAndBuild      `bson:"service_and_builds"     yaml:"service_and_builds"         json:"service_and_builds"`
 	ServiceAndBuildsOptions []*ServiceAndBuild      `bson:"-"                      yaml:"service_and_builds_options" json:"service_and_builds_options"`
+	// Matrix expands every service build into one job task per combination of the axis values
+	Matrix []*BuildMatrixAxis `bson:"matrix,omitempty"       yaml:"matrix,omitempty"            json:"matrix,omitempty"`
+}
+
+// BuildMatrixAxis is one dimension of build matrix, the value is passed to build as env Key
+type BuildMatrixAxis struct {
+	Key    string   `bson:"key"    yaml:"key"    json:"key"`
+	Values []string `bson:"values" yaml:"values" json:"values"`
 }
 
 type ServiceAndBuild struct {
@@ -348,6 +437,16 @@ type ServiceAndBuild struct {
 	KeyVals          []*KeyVal           `bson:"key_vals"            yaml:"key_vals"             json:"key_vals"`
 	Repos            []*types.Repository `bson:"repos"               yaml:"repos"                json:"repos"`
 	ShareStorageInfo *ShareStorageInfo   `bson:"share_storage_info"  yaml:"share_storage_info"   json:"share_storage_info"`
+	// MatrixOutputs are the image and package of every matrix cell when the build is expanded by matrix,
+	// Image and Package refer to those of the first cell
+	MatrixOutputs []*BuildMatrixOutput `bson:"matrix_outputs,omitempty" yaml:"-" json:"matrix_outputs,omitempty"`
+}
+
+// BuildMatrixOutput is the image and package built by a matrix cell
+type BuildMatrixOutput struct {
+	Cell    string `bson:"cell"    json:"cell"`
+	Image   string `bson:"image"   json:"image"`
+	Package string `bson:"package" json:"package"`
 }
 
 func (i *ServiceAndBuild) GetKey() string {
@@ -373,6 +472,64 @@ type ZadigDeployJobSpec struct {
 	Services      []*DeployServiceInfo `bson:"services"             yaml:"services"             json:"services"`
 	// TODO: Deprecated in 2.3.0, this field is now used for saving the default service module info for deployment.
 	ServiceAndImages []*ServiceAndImage `bson:"service_and_images" yaml:"service_and_images" json:"service_and_images"`
+	// ImageSigningKeyID is used to verify the signature of the images before deploying to the production environment,
+	// the image signing policy of the environment is used if it's empty
+	ImageSigningKeyID string `bson:"image_signing_key_id,omitempty" yaml:"image_signing_key_id,omitempty" json:"image_signing_key_id,omitempty"`
+	// SmokeCheck verifies the deployed services with probes after they are ready
+	SmokeCheck *SmokeCheck `bson:"smoke_check,omitempty" yaml:"smoke_check,omitempty" json:"smoke_check,omitempty"`
+	// MatrixCell selects the cell whose image is used when the quoted build job is expanded by matrix, the first cell is used if it's empty
+	MatrixCell string `bson:"matrix_cell,omitempty" yaml:"matrix_cell,omitempty" json:"matrix_cell,omitempty"`
+}
+
+// SmokeCheck is the post-deploy verification of a deploy job, the probes are sent from within the target cluster
+// and the job fails if any of them doesn't pass within the window.
+type SmokeCheck struct {
+	Enabled bool `bson:"enabled"           yaml:"enabled"           json:"enabled"`
+	// Window is the time in seconds within which all the probes must pass, 300 by default
+	Window int           `bson:"window"            yaml:"window"            json:"window"`
+	Probes []*SmokeProbe `bson:"probes"            yaml:"probes"            json:"probes"`
+}
+
+type SmokeProbe struct {
+	Name string                `bson:"name"              yaml:"name"              json:"name"`
+	Type config.SmokeProbeType `bson:"type"              yaml:"type"              json:"type"`
+	// ServiceName is the zadig service whose deployment is verified by the probe
+	ServiceName string `bson:"service_name"      yaml:"service_name"      json:"service_name"`
+	// K8sService is the kubernetes service in the env namespace that the probe is sent to, the same as ServiceName if empty
+	K8sService string `bson:"k8s_service"       yaml:"k8s_service"       json:"k8s_service"`
+	Port       int    `bson:"port"              yaml:"port"              json:"port"`
+	// Path and Method are only used by http probes
+	Path   string `bson:"path"              yaml:"path"              json:"path"`
+	Method string `bson:"method"            yaml:"method"            json:"method"`
+	// ExpectedStatus is the expected http status codes, any 2xx is accepted if empty
+	ExpectedStatus []int `bson:"expected_status"   yaml:"expected_status"   json:"expected_status"`
+	// GRPCService is the service name sent in the grpc health check request, empty for the overall health
+	GRPCService string `bson:"grpc_service"      yaml:"grpc_service"      json:"grpc_service"`
+	// Timeout is the timeout in seconds of each attempt
+	Timeout int `bson:"timeout"           yaml:"timeout"           json:"timeout"`
+	Retries int `bson:"retries"           yaml:"retries"           json:"retries"`
+	// Interval is the time in seconds between attempts
+	Interval int `bson:"interval"          yaml:"interval"          json:"interval"`
+}
+
+// GetServiceProbes returns the enabled probes of the given service.
+func (s *SmokeCheck) GetServiceProbes(serviceName string) *SmokeCheck {
+	if s == nil || !s.Enabled {
+		return nil
+	}
+	resp := &SmokeCheck{
+		Enabled: true,
+		Window:  s.Window,
+	}
+	for _, probe := range s.Probes {
+		if probe.ServiceName == serviceName {
+			resp.Probes = append(resp.Probes, probe)
+		}
+	}
+	if len(resp.Probes) == 0 {
+		return nil
+	}
+	return resp
 }
 
 type ServiceAndVMDeploy struct {
@@ -401,6 +558,8 @@ type ZadigVMDeployJobSpec struct {
 	JobName string `bson:"job_name"             yaml:"job_name"             json:"job_name"`
 	// save the origin quoted job name
 	OriginJobName string `bson:"origin_job_name"      yaml:"origin_job_name"      json:"origin_job_name"`
+	// MatrixCell selects the cell whose package is used when the quoted build job is expanded by matrix, the first cell is used if it's empty
+	MatrixCell string `bson:"matrix_cell,omitempty" yaml:"matrix_cell,omitempty" json:"matrix_cell,omitempty"`
 }
 
 type ZadigHelmChartDeployJobSpec struct {
@@ -454,11 +613,18 @@ type DeployServiceInfo struct {
 	AutoSync     bool                `bson:"-"                                yaml:"auto_sync"                           json:"auto_sync"`
 	Deployed     bool                `bson:"-"                                yaml:"deployed"                            json:"deployed"`
 	Modules      []*DeployModuleInfo `bson:"modules"                          yaml:"modules"                             json:"modules"`
+	// ValuesKVs sets the keys of the helm values during deployment, e.g. replicaCount, they are saved into the env as override kvs
+	ValuesKVs []*DeployValuesKV `bson:"values_kvs,omitempty"             yaml:"values_kvs,omitempty"                json:"values_kvs,omitempty"`
 	// Deprecated since 1.18
 	KeyVals       []*ServiceKeyVal `bson:"key_vals"            yaml:"key_vals"         json:"key_vals"`
 	LatestKeyVals []*ServiceKeyVal `bson:"latest_key_vals"     yaml:"latest_key_vals"  json:"latest_key_vals"`
 }
 
+type DeployValuesKV struct {
+	Key   string      `bson:"key"                 yaml:"key"              json:"key"`
+	Value interface{} `bson:"value"               yaml:"value"            json:"value"`
+}
+
 type DeployModuleInfo struct {
 	ServiceModule string `bson:"service_module"      yaml:"service_module"   json:"service_module"`
 	Image         string `bson:"image"               yaml:"image"            json:"image"`
@@ -513,6 +679,11 @@ type ZadigDistributeImageJobSpec struct {
 
 	CustomAnnotations []*util.KeyValue `bson:"custom_annotations" json:"custom_annotations" yaml:"custom_annotations"`
 	CustomLabels      []*util.KeyValue `bson:"custom_labels"      json:"custom_labels"      yaml:"custom_labels"`
+
+	// ImageSigningKeyID signs the target images with the key after distributed if not empty
+	ImageSigningKeyID string `bson:"image_signing_key_id" json:"image_signing_key_id" yaml:"image_signing_key_id"`
+	// MatrixCell selects the cell whose image is used when the quoted build job is expanded by matrix, the first cell is used if it's empty
+	MatrixCell string `bson:"matrix_cell,omitempty" yaml:"matrix_cell,omitempty" json:"matrix_cell,omitempty"`
 }
 
 type DistributeTarget struct {
@@ -806,6 +977,24 @@ type JiraJobSpec struct {
 	Issues       []*IssueID `bson:"issues" json:"issues" yaml:"issues"`
 	TargetStatus string     `bson:"target_status" json:"target_status" yaml:"target_status"`
 	Source       string     `bson:"source" json:"source" yaml:"source"`
+	// IssueSource: "" means the issues are selected, "commit" means the issue keys are extracted from the commit messages of the task
+	IssueSource string `bson:"issue_source" json:"issue_source" yaml:"issue_source"`
+	// EnvTransitions overrides the target status by the type of the env deployed in the task
+	EnvTransitions []*JiraEnvTransition `bson:"env_transitions" json:"env_transitions" yaml:"env_transitions"`
+	// Comment is added to the issues if not empty
+	Comment string `bson:"comment" json:"comment" yaml:"comment"`
+}
+
+const (
+	JiraIssueSourceCommit = "commit"
+
+	JiraEnvTypeTesting    = "testing"
+	JiraEnvTypeProduction = "production"
+)
+
+type JiraEnvTransition struct {
+	EnvType      string `bson:"env_type"      json:"env_type"      yaml:"env_type"`
+	TargetStatus string `bson:"target_status" json:"target_status" yaml:"target_status"`
 }
 
 type IstioJobSpec struct {
@@ -933,6 +1122,8 @@ type GuanceyunMonitor struct {
 type JenkinsJobSpec struct {
 	ID   string            `bson:"id" json:"id" yaml:"id"`
 	Jobs []*JenkinsJobInfo `bson:"jobs" json:"jobs" yaml:"jobs"`
+	// PullArtifacts pulls the archived artifacts of the jenkins builds into the zadig artifact store
+	PullArtifacts bool `bson:"pull_artifacts" json:"pull_artifacts" yaml:"pull_artifacts"`
 }
 
 type BlueKingJobSpec struct {
@@ -946,6 +1137,22 @@ type BlueKingJobSpec struct {
 	Parameters []*blueking.GlobalVariable `bson:"parameters" json:"parameters" yaml:"parameters"`
 }
 
+type GitHubActionsJobSpec struct {
+	CodeHostID int    `bson:"codehost_id"   json:"codehost_id"   yaml:"codehost_id"`
+	RepoOwner  string `bson:"repo_owner"    json:"repo_owner"    yaml:"repo_owner"`
+	RepoName   string `bson:"repo_name"     json:"repo_name"     yaml:"repo_name"`
+	// WorkflowFile is the file name of the github actions workflow, e.g. deploy.yml
+	WorkflowFile string `bson:"workflow_file" json:"workflow_file" yaml:"workflow_file"`
+	// Ref is the branch or tag to run the workflow on
+	Ref    string                `bson:"ref"           json:"ref"           yaml:"ref"`
+	Inputs []*GitHubActionsInput `bson:"inputs"        json:"inputs"        yaml:"inputs"`
+}
+
+type GitHubActionsInput struct {
+	Key   string `bson:"key"   json:"key"   yaml:"key"`
+	Value string `bson:"value" json:"value" yaml:"value"`
+}
+
 type ApprovalJobSpec struct {
 	Timeout          int64                   `bson:"timeout"                     yaml:"timeout"                       json:"timeout"`
 	Type             config.ApprovalType     `bson:"type"                        yaml:"type"                          json:"type"`
@@ -1264,6 +1471,15 @@ type WorkflowTriggerJobSpec struct {
 	SourceService          []*ServiceNameAndModule          `bson:"source_service" json:"source_service" yaml:"source_service"`
 }
 
+type SubWorkflowJobSpec struct {
+	ProjectName  string `bson:"project_name"        json:"project_name"        yaml:"project_name"`
+	WorkflowName string `bson:"workflow_name"       json:"workflow_name"       yaml:"workflow_name"`
+	// Params overrides the params of the sub workflow by name, the values can reference the params of the workflow and the outputs of the previous jobs
+	Params []*Param `bson:"params"              json:"params"              yaml:"params"`
+	// Async triggers the sub workflow without waiting for it to finish
+	Async bool `bson:"async"               json:"async"               yaml:"async"`
+}
+
 type ServiceNameAndModule struct {
 	ServiceName   string `bson:"service_name" json:"service_name" yaml:"service_name"`
 	ServiceModule string `bson:"service_module" json:"service_module" yaml:"service_module"`
@@ -1284,6 +1500,104 @@ type OfflineServiceJobSpec struct {
 	Services []string       `bson:"services" json:"services" yaml:"services"`
 }
 
+type TerraformJobSpec struct {
+	// Binary is the cli used to run the job, terraform or tofu
+	Binary config.TerraformBinary `bson:"binary"              json:"binary"              yaml:"binary"`
+	// Version is the tool version installed before running, the tool must be registered in the system tool list
+	Version    string                 `bson:"version"             json:"version"             yaml:"version"`
+	Action     config.TerraformAction `bson:"action"              json:"action"              yaml:"action"`
+	Properties *JobProperties         `bson:"properties"          json:"properties"          yaml:"properties"`
+	Repos      []*types.Repository    `bson:"repos"               json:"repos"               yaml:"repos"`
+	// WorkDir is the directory of the terraform files, relative to the workspace
+	WorkDir string            `bson:"work_dir"            json:"work_dir"            yaml:"work_dir"`
+	Backend *TerraformBackend `bson:"backend"             json:"backend"             yaml:"backend"`
+	// Variables are injected as TF_VAR_<key>, workflow params can be referred by {{.workflow.params.xxx}}
+	Variables     []*KeyVal               `bson:"variables"           json:"variables"           yaml:"variables"`
+	ApplyApproval *TerraformApplyApproval `bson:"apply_approval"      json:"apply_approval"      yaml:"apply_approval"`
+}
+
+type EnvAnalysisJobSpec struct {
+	EnvName    string `bson:"env_name"            json:"env_name"            yaml:"env_name"`
+	Production bool   `bson:"production"          json:"production"          yaml:"production"`
+	// ResourceTypes are the analyzers to run, the resource types in the analysis config of the env are used if empty
+	ResourceTypes []string `bson:"resource_types"      json:"resource_types"      yaml:"resource_types"`
+	// ServiceNames and Workloads in the form of Kind/name scope the analysis, the whole namespace is analyzed if neither is set
+	ServiceNames []string `bson:"service_names"       json:"service_names"       yaml:"service_names"`
+	Workloads    []string `bson:"workloads"           json:"workloads"           yaml:"workloads"`
+	// FailOnProblems fails the job if any problem is found
+	FailOnProblems bool `bson:"fail_on_problems"    json:"fail_on_problems"    yaml:"fail_on_problems"`
+}
+
+type FeatureFlagJobSpec struct {
+	// FeatureFlagID is the id of the launchdarkly, unleash or flagsmith configuration management
+	FeatureFlagID string `bson:"feature_flag_id"     json:"feature_flag_id"     yaml:"feature_flag_id"`
+	// ProjectKey is the project of launchdarkly or unleash, not used by flagsmith
+	ProjectKey string `bson:"project_key"         json:"project_key"         yaml:"project_key"`
+	// EnvName is the zadig env, the flags are toggled in the feature flag environment mapped to it
+	EnvName     string                   `bson:"env_name"            json:"env_name"            yaml:"env_name"`
+	EnvMappings []*FeatureFlagEnvMapping `bson:"env_mappings"        json:"env_mappings"        yaml:"env_mappings"`
+	Flags       []*FeatureFlagToggle     `bson:"flags"               json:"flags"               yaml:"flags"`
+}
+
+type FeatureFlagEnvMapping struct {
+	EnvName string `bson:"env_name"            json:"env_name"            yaml:"env_name"`
+	// FlagEnv is the environment key in the feature flag system, it's the environment api key for flagsmith
+	FlagEnv string `bson:"flag_env"            json:"flag_env"            yaml:"flag_env"`
+}
+
+type FeatureFlagToggle struct {
+	Key     string `bson:"key"                 json:"key"                 yaml:"key"`
+	Enabled bool   `bson:"enabled"             json:"enabled"             yaml:"enabled"`
+}
+
+type CanaryAnalysisJobSpec struct {
+	// FromJob is the k8s canary deploy or blue-green deploy job whose new version is analyzed
+	FromJob string `bson:"from_job"            json:"from_job"            yaml:"from_job"`
+	// ObservabilityID is the prometheus integration that the metrics are queried from
+	ObservabilityID string `bson:"observability_id"    json:"observability_id"    yaml:"observability_id"`
+	// Duration is the analysis time in minutes
+	Duration int64 `bson:"duration"            json:"duration"            yaml:"duration"`
+	// Interval is the time in seconds between the samples of the metrics
+	Interval int64 `bson:"interval"            json:"interval"            yaml:"interval"`
+	// FailureLimit is the number of failed samples tolerated, the new version is rolled back once it's exceeded
+	FailureLimit int                     `bson:"failure_limit"       json:"failure_limit"       yaml:"failure_limit"`
+	Metrics      []*CanaryAnalysisMetric `bson:"metrics"             json:"metrics"             yaml:"metrics"`
+}
+
+const (
+	CanaryMetricThresholdAbsolute = "absolute"
+	CanaryMetricThresholdRelative = "relative"
+)
+
+type CanaryAnalysisMetric struct {
+	Name string `bson:"name"                json:"name"                yaml:"name"`
+	// Query is the PromQL of the metric like error rate or latency, $namespace, $workload and $pod_regex are rendered
+	// with the canary and the stable variant respectively
+	Query string `bson:"query"               json:"query"               yaml:"query"`
+	// ThresholdType is absolute if the canary value must not be greater than the threshold, or relative if the canary
+	// value must not be greater than the stable value by the threshold in percentage
+	ThresholdType string  `bson:"threshold_type"      json:"threshold_type"      yaml:"threshold_type"`
+	Threshold     float64 `bson:"threshold"           json:"threshold"           yaml:"threshold"`
+}
+
+type TerraformBackend struct {
+	// Type is the backend type declared in the terraform files, like s3, oss, consul or http
+	Type string `bson:"type"                json:"type"                yaml:"type"`
+	// Configs are passed to terraform init by -backend-config=key=value
+	Configs []*KeyVal `bson:"configs"             json:"configs"             yaml:"configs"`
+}
+
+type TerraformApplyApproval struct {
+	Enabled          bool                `bson:"enabled"             json:"enabled"                       yaml:"enabled"`
+	Timeout          int64               `bson:"timeout"             json:"timeout"                       yaml:"timeout"`
+	Type             config.ApprovalType `bson:"type"                json:"type"                          yaml:"type"`
+	Description      string              `bson:"description"         json:"description"                   yaml:"description"`
+	NativeApproval   *NativeApproval     `bson:"native_approval"     json:"native_approval,omitempty"     yaml:"native_approval,omitempty"`
+	LarkApproval     *LarkApproval       `bson:"lark_approval"       json:"lark_approval,omitempty"       yaml:"lark_approval,omitempty"`
+	DingTalkApproval *DingTalkApproval   `bson:"dingtalk_approval"   json:"dingtalk_approval,omitempty"   yaml:"dingtalk_approval,omitempty"`
+	WorkWXApproval   *WorkWXApproval     `bson:"workwx_approval"     json:"workwx_approval,omitempty"     yaml:"workwx_approval,omitempty"`
+}
+
 type JobProperties struct {
 	Timeout         int64               `bson:"timeout"                json:"timeout"               yaml:"timeout"`
 	ResourceRequest setting.Request     `bson:"res_req"                json:"res_req"               yaml:"res_req"`
@@ -1312,6 +1626,12 @@ type JobProperties struct {
 	ShareStorageInfo    *ShareStorageInfo    `bson:"share_storage_info"     json:"share_storage_info"    yaml:"share_storage_info"`
 	ShareStorageDetails []*StorageDetail     `bson:"share_storage_details"  json:"share_storage_details" yaml:"-"`
 	UseHostDockerDaemon bool                 `bson:"use_host_docker_daemon,omitempty" json:"use_host_docker_daemon,omitempty" yaml:"use_host_docker_daemon"`
+	// EnvKubeAccess mounts a kubeconfig of the env namespace to the job
+	EnvKubeAccess *EnvKubeAccess `bson:"env_kube_access,omitempty" json:"env_kube_access,omitempty" yaml:"env_kube_access,omitempty"`
+	// PodTemplate is the name of the project level pod template applied to the job pod
+	PodTemplate string `bson:"pod_template,omitempty" json:"pod_template,omitempty" yaml:"pod_template,omitempty"`
+	// CheckpointInterval is the interval in seconds for long-running tests to report the progress, 0 means disabled
+	CheckpointInterval int64 `bson:"checkpoint_interval,omitempty" json:"checkpoint_interval,omitempty" yaml:"checkpoint_interval,omitempty"`
 	// for VM deploy to get service name to save
 	ServiceName string `bson:"service_name" json:"service_name" yaml:"service_name"`
 
@@ -1319,6 +1639,15 @@ type JobProperties struct {
 	CustomLabels      []*util.KeyValue `bson:"custom_labels"      json:"custom_labels"      yaml:"custom_labels"`
 }
 
+// EnvKubeAccess grants the job a short-lived service account token scoped to the namespace of the env,
+// the token is revoked when the job ends.
+type EnvKubeAccess struct {
+	Enabled    bool   `bson:"enabled"    json:"enabled"    yaml:"enabled"`
+	EnvName    string `bson:"env_name"   json:"env_name"   yaml:"env_name"`
+	Production bool   `bson:"production" json:"production" yaml:"production"`
+	ReadOnly   bool   `bson:"read_only"  json:"read_only"  yaml:"read_only"`
+}
+
 func (j *JobProperties) DeepCopyEnvs() []*KeyVal {
 	envs := make([]*KeyVal, 0)
 
@@ -1397,6 +1726,36 @@ type GeneralHook struct {
 	Enabled     bool        `bson:"enabled" json:"enabled"`
 	Description string      `bson:"description" json:"description"`
 	WorkflowArg *WorkflowV4 `bson:"workflow_arg" json:"workflow_arg"`
+	// Secret is used to verify the hmac-sha256 signature of the payload, no verification if empty
+	Secret string `bson:"secret" json:"secret"`
+	// SignatureHeader is the request header carrying the signature, default is X-Zadig-Signature
+	SignatureHeader string `bson:"signature_header" json:"signature_header"`
+	// Filters must all be matched by the payload to trigger the workflow
+	Filters []*GeneralHookFilter `bson:"filters" json:"filters"`
+	// ParamMappings renders the workflow params from the payload
+	ParamMappings []*GeneralHookParamMapping `bson:"param_mappings" json:"param_mappings"`
+}
+
+type GeneralHookFilterOperator string
+
+const (
+	GeneralHookFilterOperatorEqual    GeneralHookFilterOperator = "eq"
+	GeneralHookFilterOperatorNotEqual GeneralHookFilterOperator = "neq"
+	GeneralHookFilterOperatorRegex    GeneralHookFilterOperator = "regex"
+	GeneralHookFilterOperatorExists   GeneralHookFilterOperator = "exists"
+)
+
+type GeneralHookFilter struct {
+	JSONPath string                    `bson:"json_path" json:"json_path"`
+	Operator GeneralHookFilterOperator `bson:"operator"  json:"operator"`
+	Value    string                    `bson:"value"     json:"value"`
+}
+
+type GeneralHookParamMapping struct {
+	ParamName string `bson:"param_name" json:"param_name"`
+	JSONPath  string `bson:"json_path"  json:"json_path"`
+	// Required fails the trigger if the value is not found in the payload
+	Required bool `bson:"required"   json:"required"`
 }
 
 type Param struct {
@@ -1416,6 +1775,16 @@ type Param struct {
 type ShareStorage struct {
 	Name string `bson:"name"             json:"name"             yaml:"name"`
 	Path string `bson:"path"             json:"path"             yaml:"path"`
+	// Scope is task by default, workflow scoped storage is kept between tasks, the tasks of a workflow using it run one at a time
+	Scope types.ShareStorageScope `bson:"scope,omitempty"          json:"scope,omitempty"          yaml:"scope,omitempty"`
+	// SizeLimit unit is MiB, workflow scoped storage exceeding the limit is cleared after the task is done, 0 means no limit
+	SizeLimit int64 `bson:"size_limit,omitempty"     json:"size_limit,omitempty"     yaml:"size_limit,omitempty"`
+	// RetentionDays files in workflow scoped storage not modified for the days are removed after the task is done, 0 means never
+	RetentionDays int `bson:"retention_days,omitempty" json:"retention_days,omitempty" yaml:"retention_days,omitempty"`
+}
+
+func (s *ShareStorage) IsWorkflowScoped() bool {
+	return s.Scope == types.ShareStorageScopeWorkflow
 }
 
 type ShareStorageInfo struct {
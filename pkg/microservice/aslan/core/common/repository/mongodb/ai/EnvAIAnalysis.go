@@ -21,6 +21,7 @@ import (
 	"errors"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
@@ -112,6 +113,23 @@ func (c *EnvAIAnalysisColl) Create(args *ai.EnvAIAnalysis) error {
 		return errors.New("nil Workflow args")
 	}
 
-	_, err := c.InsertOne(context.TODO(), args)
-	return err
+	res, err := c.InsertOne(context.TODO(), args)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		args.ID = id
+	}
+	return nil
+}
+
+func (c *EnvAIAnalysisColl) GetByID(id string) (*ai.EnvAIAnalysis, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(ai.EnvAIAnalysis)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
 }
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/ai"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type EnvAnalysisIssueColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvAnalysisIssueColl() *EnvAnalysisIssueColl {
+	name := ai.EnvAnalysisIssue{}.TableName()
+	return &EnvAnalysisIssueColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *EnvAnalysisIssueColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvAnalysisIssueColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "production", Value: 1},
+			bson.E{Key: "fingerprint", Value: 1},
+			bson.E{Key: "tracker", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *EnvAnalysisIssueColl) Create(obj *ai.EnvAnalysisIssue) error {
+	obj.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *EnvAnalysisIssueColl) GetByFingerprint(projectName, envName string, production bool, fingerprint, tracker string) (*ai.EnvAnalysisIssue, error) {
+	query := bson.M{
+		"project_name": projectName,
+		"env_name":     envName,
+		"production":   production,
+		"fingerprint":  fingerprint,
+		"tracker":      tracker,
+	}
+
+	resp := new(ai.EnvAnalysisIssue)
+	err := c.FindOne(context.TODO(), query).Decode(resp)
+	return resp, err
+}
+
+func (c *EnvAnalysisIssueColl) List(projectName, envName string, production bool) ([]*ai.EnvAnalysisIssue, error) {
+	query := bson.M{
+		"project_name": projectName,
+		"env_name":     envName,
+		"production":   production,
+	}
+
+	resp := make([]*ai.EnvAnalysisIssue, 0)
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
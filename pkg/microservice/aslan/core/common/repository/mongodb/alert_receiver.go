@@ -0,0 +1,250 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type AlertReceiverColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewAlertReceiverColl() *AlertReceiverColl {
+	name := models.AlertReceiver{}.TableName()
+	return &AlertReceiverColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *AlertReceiverColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *AlertReceiverColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *AlertReceiverColl) Create(obj *models.AlertReceiver) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+func (c *AlertReceiverColl) Update(obj *models.AlertReceiver) error {
+	obj.UpdateTime = time.Now().Unix()
+	query := bson.M{"_id": obj.ID}
+	change := bson.M{"$set": obj}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *AlertReceiverColl) GetByID(idStr string) (*models.AlertReceiver, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(models.AlertReceiver)
+	err = c.FindOne(context.TODO(), bson.M{"_id": id}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *AlertReceiverColl) List(projectName string) ([]*models.AlertReceiver, error) {
+	resp := make([]*models.AlertReceiver, 0)
+	query := bson.M{}
+	if projectName != "" {
+		query["project_name"] = projectName
+	}
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *AlertReceiverColl) DeleteByID(idStr string) error {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+type EnvAlertListOption struct {
+	ProjectName string
+	EnvName     string
+	Production  bool
+	ServiceName string
+	Status      string
+}
+
+type EnvAlertColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvAlertColl() *EnvAlertColl {
+	name := models.EnvAlert{}.TableName()
+	return &EnvAlertColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvAlertColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvAlertColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "receiver_id", Value: 1},
+				bson.E{Key: "fingerprint", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "env_name", Value: 1},
+				bson.E{Key: "production", Value: 1},
+				bson.E{Key: "status", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *EnvAlertColl) Get(receiverID, fingerprint string) (*models.EnvAlert, error) {
+	resp := new(models.EnvAlert)
+	err := c.FindOne(context.TODO(), bson.M{"receiver_id": receiverID, "fingerprint": fingerprint}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Upsert creates or updates the alert by the receiver and the fingerprint
+func (c *EnvAlertColl) Upsert(obj *models.EnvAlert) error {
+	obj.UpdateTime = time.Now().Unix()
+	query := bson.M{"receiver_id": obj.ReceiverID, "fingerprint": obj.Fingerprint}
+	change := bson.M{"$set": bson.M{
+		"project_name":  obj.ProjectName,
+		"env_name":      obj.EnvName,
+		"production":    obj.Production,
+		"service_name":  obj.ServiceName,
+		"alert_name":    obj.AlertName,
+		"severity":      obj.Severity,
+		"status":        obj.Status,
+		"labels":        obj.Labels,
+		"annotations":   obj.Annotations,
+		"generator_url": obj.GeneratorURL,
+		"starts_at":     obj.StartsAt,
+		"ends_at":       obj.EndsAt,
+		"update_time":   obj.UpdateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *EnvAlertColl) UpdateRemediation(obj *models.EnvAlert) error {
+	query := bson.M{"receiver_id": obj.ReceiverID, "fingerprint": obj.Fingerprint}
+	change := bson.M{"$set": bson.M{
+		"remediation_workflow": obj.RemediationWorkflow,
+		"remediation_task_id":  obj.RemediationTaskID,
+		"remediation_time":     obj.RemediationTime,
+		"remediation_error":    obj.RemediationError,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *EnvAlertColl) List(opt *EnvAlertListOption) ([]*models.EnvAlert, error) {
+	query := bson.M{
+		"project_name": opt.ProjectName,
+		"env_name":     opt.EnvName,
+		"production":   opt.Production,
+	}
+	if opt.ServiceName != "" {
+		query["service_name"] = opt.ServiceName
+	}
+	if opt.Status != "" {
+		query["status"] = opt.Status
+	}
+
+	resp := make([]*models.EnvAlert, 0)
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "starts_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *EnvAlertColl) DeleteByReceiver(receiverID string) error {
+	_, err := c.DeleteMany(context.TODO(), bson.M{"receiver_id": receiverID})
+	return err
+}
+
+// DeleteResolvedBefore deletes the alerts resolved before the given time
+func (c *EnvAlertColl) DeleteResolvedBefore(endsAt int64) error {
+	_, err := c.DeleteMany(context.TODO(), bson.M{"status": models.EnvAlertStatusResolved, "ends_at": bson.M{"$lt": endsAt}})
+	return err
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type ArtifactPromotionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewArtifactPromotionColl() *ArtifactPromotionColl {
+	name := models.ArtifactPromotion{}.TableName()
+	return &ArtifactPromotionColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ArtifactPromotionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ArtifactPromotionColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "image_digest", Value: 1}, bson.E{Key: "create_time", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys:    bson.D{bson.E{Key: "image", Value: 1}, bson.E{Key: "create_time", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *ArtifactPromotionColl) Create(obj *models.ArtifactPromotion) error {
+	obj.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	obj.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetDigestByImage returns the digest of the image in the latest promotion, it is empty if the image is unknown
+func (c *ArtifactPromotionColl) GetDigestByImage(image string) (string, error) {
+	resp := new(models.ArtifactPromotion)
+	query := bson.M{"image": image, "image_digest": bson.M{"$ne": ""}}
+	opts := options.FindOne().SetSort(bson.D{{"create_time", -1}})
+	err := c.FindOne(context.TODO(), query, opts).Decode(resp)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return resp.ImageDigest, nil
+}
+
+func (c *ArtifactPromotionColl) ListByDigest(digest string) ([]*models.ArtifactPromotion, error) {
+	return c.list(bson.M{"image_digest": digest})
+}
+
+func (c *ArtifactPromotionColl) ListByImages(images []string) ([]*models.ArtifactPromotion, error) {
+	return c.list(bson.M{"image": bson.M{"$in": images}})
+}
+
+func (c *ArtifactPromotionColl) list(query bson.M) ([]*models.ArtifactPromotion, error) {
+	resp := make([]*models.ArtifactPromotion, 0)
+	opts := options.Find().SetSort(bson.D{{"create_time", 1}})
+	cursor, err := c.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
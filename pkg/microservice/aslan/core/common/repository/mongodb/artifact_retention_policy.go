@@ -0,0 +1,94 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type ArtifactRetentionPolicyColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewArtifactRetentionPolicyColl() *ArtifactRetentionPolicyColl {
+	name := models.ArtifactRetentionPolicy{}.TableName()
+	return &ArtifactRetentionPolicyColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ArtifactRetentionPolicyColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ArtifactRetentionPolicyColl) EnsureIndex(ctx context.Context) error {
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, index)
+
+	return err
+}
+
+func (c *ArtifactRetentionPolicyColl) Find(projectName string) (*models.ArtifactRetentionPolicy, error) {
+	resp := new(models.ArtifactRetentionPolicy)
+	err := c.FindOne(context.TODO(), bson.M{"project_name": projectName}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ArtifactRetentionPolicyColl) List() ([]*models.ArtifactRetentionPolicy, error) {
+	resp := make([]*models.ArtifactRetentionPolicy, 0)
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ArtifactRetentionPolicyColl) Upsert(obj *models.ArtifactRetentionPolicy) error {
+	query := bson.M{"project_name": obj.ProjectName}
+	obj.UpdateTime = time.Now().Unix()
+	change := bson.M{"$set": bson.M{
+		"retention_days":    obj.RetentionDays,
+		"max_total_size_mb": obj.MaxTotalSizeMB,
+		"updated_by":        obj.UpdatedBy,
+		"update_time":       obj.UpdateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
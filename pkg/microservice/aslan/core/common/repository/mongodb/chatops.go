@@ -0,0 +1,110 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type ChatOpsUserBindingColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewChatOpsUserBindingColl() *ChatOpsUserBindingColl {
+	name := models.ChatOpsUserBinding{}.TableName()
+	return &ChatOpsUserBindingColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ChatOpsUserBindingColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ChatOpsUserBindingColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "im_app_id", Value: 1}, bson.E{Key: "chat_user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "user_id", Value: 1}},
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+// Upsert binds the chat user to the zadig user, the previous binding of the chat user is replaced
+func (c *ChatOpsUserBindingColl) Upsert(obj *models.ChatOpsUserBinding) error {
+	obj.CreateTime = time.Now().Unix()
+	query := bson.M{"im_app_id": obj.IMAppID, "chat_user_id": obj.ChatUserID}
+	change := bson.M{"$set": bson.M{
+		"im_app_type": obj.IMAppType,
+		"user_id":     obj.UserID,
+		"user_name":   obj.UserName,
+		"create_time": obj.CreateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *ChatOpsUserBindingColl) Find(imAppID, chatUserID string) (*models.ChatOpsUserBinding, error) {
+	resp := new(models.ChatOpsUserBinding)
+	err := c.FindOne(context.TODO(), bson.M{"im_app_id": imAppID, "chat_user_id": chatUserID}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ChatOpsUserBindingColl) ListByUserID(userID string) ([]*models.ChatOpsUserBinding, error) {
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.ChatOpsUserBinding, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Delete only deletes the binding of the user
+func (c *ChatOpsUserBindingColl) Delete(userID, idString string) error {
+	id, err := primitive.ObjectIDFromHex(idString)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": id, "user_id": userID})
+	return err
+}
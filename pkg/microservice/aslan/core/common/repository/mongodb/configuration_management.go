@@ -165,3 +165,23 @@ func (c *ConfigurationManagementColl) DeleteByID(ctx context.Context, idString s
 	_, err = c.DeleteOne(ctx, query)
 	return err
 }
+
+func (c *ConfigurationManagementColl) GetFeatureFlagByID(ctx context.Context, idString string) (*models.FeatureFlagConfig, error) {
+	info, err := c.GetByID(ctx, idString)
+	if err != nil {
+		return nil, err
+	}
+	if !models.IsFeatureFlagType(info.Type) {
+		return nil, errors.Errorf("unexpected feature flag config type %s", info.Type)
+	}
+	featureFlag := &models.FeatureFlagAuthConfig{}
+	err = models.IToi(info.AuthConfig, featureFlag)
+	if err != nil {
+		return nil, errors.Wrap(err, "IToi")
+	}
+	return &models.FeatureFlagConfig{
+		Type:                  info.Type,
+		ServerAddress:         info.ServerAddress,
+		FeatureFlagAuthConfig: featureFlag,
+	}, nil
+}
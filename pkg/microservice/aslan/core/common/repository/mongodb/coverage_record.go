@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type CoverageRecordListOption struct {
+	ProjectName   string
+	WorkflowName  string
+	TaskID        int64
+	ServiceName   string
+	ServiceModule string
+	TestName      string
+	Branch        string
+	// ExcludePR excludes the records generated by pull requests, which are not merged into the branch yet
+	ExcludePR bool
+	Limit     int64
+}
+
+type CoverageRecordColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewCoverageRecordColl() *CoverageRecordColl {
+	name := models.CoverageRecord{}.TableName()
+	return &CoverageRecordColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *CoverageRecordColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *CoverageRecordColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "service_name", Value: 1},
+				bson.E{Key: "service_module", Value: 1},
+				bson.E{Key: "test_name", Value: 1},
+				bson.E{Key: "branch", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "workflow_name", Value: 1},
+				bson.E{Key: "task_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *CoverageRecordColl) Create(obj *models.CoverageRecord) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	obj.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+// List lists the coverage records sorted by create time in descending order
+func (c *CoverageRecordColl) List(opt *CoverageRecordListOption) ([]*models.CoverageRecord, error) {
+	resp := make([]*models.CoverageRecord, 0)
+	query := bson.M{}
+	if opt.ProjectName != "" {
+		query["project_name"] = opt.ProjectName
+	}
+	if opt.WorkflowName != "" {
+		query["workflow_name"] = opt.WorkflowName
+	}
+	if opt.TaskID > 0 {
+		query["task_id"] = opt.TaskID
+	}
+	if opt.ServiceName != "" {
+		query["service_name"] = opt.ServiceName
+	}
+	if opt.ServiceModule != "" {
+		query["service_module"] = opt.ServiceModule
+	}
+	if opt.TestName != "" {
+		query["test_name"] = opt.TestName
+	}
+	if opt.Branch != "" {
+		query["branch"] = opt.Branch
+	}
+	if opt.ExcludePR {
+		query["pr"] = 0
+	}
+
+	findOpt := options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}})
+	if opt.Limit > 0 {
+		findOpt.SetLimit(opt.Limit)
+	}
+	cursor, err := c.Find(context.TODO(), query, findOpt)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
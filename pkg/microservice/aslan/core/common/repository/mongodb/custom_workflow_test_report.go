@@ -19,6 +19,7 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"sort"
 	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -91,3 +92,38 @@ func (c *CustomWorkflowTestReportColl) ListByWorkflow(workflowName, jobName stri
 	err = cursor.All(context.TODO(), &resp)
 	return resp, err
 }
+
+// ListRecentByJob lists the test reports of the latest taskLimit tasks of the workflow job
+func (c *CustomWorkflowTestReportColl) ListRecentByJob(workflowName, jobName string, taskLimit int) ([]*models.CustomWorkflowTestReport, error) {
+	resp := make([]*models.CustomWorkflowTestReport, 0)
+	query := bson.M{
+		"workflow_name": workflowName,
+		"job_name":      strings.ToLower(jobName),
+	}
+
+	ids, err := c.Collection.Distinct(context.TODO(), "task_id", query)
+	if err != nil {
+		return nil, err
+	}
+	taskIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if taskID, ok := id.(int64); ok {
+			taskIDs = append(taskIDs, taskID)
+		}
+	}
+	sort.Slice(taskIDs, func(i, j int) bool { return taskIDs[i] > taskIDs[j] })
+	if taskLimit > 0 && len(taskIDs) > taskLimit {
+		taskIDs = taskIDs[:taskLimit]
+	}
+	if len(taskIDs) == 0 {
+		return resp, nil
+	}
+	query["task_id"] = bson.M{"$in": taskIDs}
+
+	cursor, err := c.Collection.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "task_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
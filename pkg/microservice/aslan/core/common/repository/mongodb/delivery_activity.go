@@ -143,3 +143,21 @@ func (c *DeliveryActivityColl) InsertWithID(id string, args *models.DeliveryActi
 	_, err = c.Collection.InsertOne(context.TODO(), args)
 	return err
 }
+
+func (c *DeliveryActivityColl) ListByArtifactIDs(artifactIDs []primitive.ObjectID) ([]*models.DeliveryActivity, error) {
+	resp := make([]*models.DeliveryActivity, 0)
+	if len(artifactIDs) == 0 {
+		return resp, nil
+	}
+	query := bson.M{"artifact_id": bson.M{"$in": artifactIDs}}
+	opt := options.Find().SetSort(bson.D{{Key: "created_time", Value: 1}})
+	cursor, err := c.Collection.Find(context.TODO(), query, opt)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -238,3 +239,23 @@ func (c *DeliveryArtifactColl) ListTars(args *DeliveryArtifactArgs) ([]*models.D
 	}
 	return resp, nil
 }
+
+// ListImagesBuiltBetween lists the image artifacts of the image repo created in (startTime, endTime], in ascending order of created time
+func (c *DeliveryArtifactColl) ListImagesBuiltBetween(imageRepo string, startTime, endTime int64) ([]*models.DeliveryArtifact, error) {
+	resp := make([]*models.DeliveryArtifact, 0)
+	query := bson.M{
+		"type":         "image",
+		"image":        bson.M{"$regex": "^" + regexp.QuoteMeta(imageRepo) + ":"},
+		"created_time": bson.M{"$gt": startTime, "$lte": endTime},
+	}
+	opt := options.Find().SetSort(bson.D{{Key: "created_time", Value: 1}})
+	cursor, err := c.Collection.Find(context.TODO(), query, opt)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
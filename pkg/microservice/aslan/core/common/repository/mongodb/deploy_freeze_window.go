@@ -0,0 +1,139 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type DeployFreezeWindowColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewDeployFreezeWindowColl() *DeployFreezeWindowColl {
+	name := models.DeployFreezeWindow{}.TableName()
+	return &DeployFreezeWindowColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *DeployFreezeWindowColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *DeployFreezeWindowColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "start_time", Value: 1}, bson.E{Key: "end_time", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *DeployFreezeWindowColl) Create(obj *models.DeployFreezeWindow) error {
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = obj.CreateTime
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	obj.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (c *DeployFreezeWindowColl) Update(idString string, obj *models.DeployFreezeWindow) error {
+	id, err := primitive.ObjectIDFromHex(idString)
+	if err != nil {
+		return err
+	}
+	obj.UpdateTime = time.Now().Unix()
+	change := bson.M{"$set": bson.M{
+		"name":        obj.Name,
+		"description": obj.Description,
+		"start_time":  obj.StartTime,
+		"end_time":    obj.EndTime,
+		"updated_by":  obj.UpdatedBy,
+		"update_time": obj.UpdateTime,
+	}}
+	res, err := c.UpdateOne(context.TODO(), bson.M{"_id": id}, change)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (c *DeployFreezeWindowColl) Delete(idString string) error {
+	id, err := primitive.ObjectIDFromHex(idString)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+// List lists the freeze windows ending after the given time, all the windows are listed if the time is 0
+func (c *DeployFreezeWindowColl) List(endAfter int64) ([]*models.DeployFreezeWindow, error) {
+	query := bson.M{}
+	if endAfter > 0 {
+		query["end_time"] = bson.M{"$gte": endAfter}
+	}
+	opts := options.Find().SetSort(bson.D{{"start_time", 1}})
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.DeployFreezeWindow, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListActive lists the freeze windows covering the given time
+func (c *DeployFreezeWindowColl) ListActive(now int64) ([]*models.DeployFreezeWindow, error) {
+	query := bson.M{"start_time": bson.M{"$lte": now}, "end_time": bson.M{"$gte": now}}
+	cursor, err := c.Collection.Find(context.TODO(), query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.DeployFreezeWindow, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type EnvDebugSnapshotColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvDebugSnapshotColl() *EnvDebugSnapshotColl {
+	name := models.EnvDebugSnapshot{}.TableName()
+	return &EnvDebugSnapshotColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvDebugSnapshotColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvDebugSnapshotColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "production", Value: 1},
+			bson.E{Key: "create_time", Value: -1},
+		},
+		Options: options.Index().SetUnique(false),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *EnvDebugSnapshotColl) Create(obj *models.EnvDebugSnapshot) error {
+	obj.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *EnvDebugSnapshotColl) Update(obj *models.EnvDebugSnapshot) error {
+	_, err := c.UpdateOne(context.TODO(), bson.M{"_id": obj.ID}, bson.M{"$set": obj})
+	return err
+}
+
+func (c *EnvDebugSnapshotColl) GetByID(id string) (*models.EnvDebugSnapshot, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.EnvDebugSnapshot)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+func (c *EnvDebugSnapshotColl) List(projectName, envName string, production bool) ([]*models.EnvDebugSnapshot, error) {
+	query := bson.M{
+		"project_name": projectName,
+		"env_name":     envName,
+		"production":   production,
+	}
+
+	resp := make([]*models.EnvDebugSnapshot, 0)
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type EnvDNSRecordColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvDNSRecordColl() *EnvDNSRecordColl {
+	name := models.EnvDNSRecord{}.TableName()
+	return &EnvDNSRecordColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvDNSRecordColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvDNSRecordColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "env_name", Value: 1},
+			},
+		},
+		{
+			Keys:    bson.D{bson.E{Key: "hostname", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+// Upsert saves the record by its hostname
+func (c *EnvDNSRecordColl) Upsert(args *models.EnvDNSRecord) error {
+	args.UpdateTime = time.Now().Unix()
+	_, err := c.UpdateOne(context.TODO(),
+		bson.M{"hostname": args.Hostname},
+		bson.M{"$set": bson.M{
+			"project_name":   args.ProjectName,
+			"env_name":       args.EnvName,
+			"production":     args.Production,
+			"service_name":   args.ServiceName,
+			"record_type":    args.RecordType,
+			"target":         args.Target,
+			"integration_id": args.IntegrationID,
+			"update_time":    args.UpdateTime,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (c *EnvDNSRecordColl) List(projectName, envName string) ([]*models.EnvDNSRecord, error) {
+	query := bson.M{"project_name": projectName}
+	if envName != "" {
+		query["env_name"] = envName
+	}
+
+	resp := make([]*models.EnvDNSRecord, 0)
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "hostname", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *EnvDNSRecordColl) DeleteByHostname(hostname string) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"hostname": hostname})
+	return err
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type EnvHealthScoreListOption struct {
+	ProjectName string
+	EnvName     string
+	Production  bool
+	StartTime   int64
+	EndTime     int64
+}
+
+type EnvHealthScoreColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvHealthScoreColl() *EnvHealthScoreColl {
+	name := models.EnvHealthScore{}.TableName()
+	return &EnvHealthScoreColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvHealthScoreColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvHealthScoreColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "env_name", Value: 1},
+				bson.E{Key: "production", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys:    bson.M{"create_time": 1},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *EnvHealthScoreColl) Create(obj *models.EnvHealthScore) error {
+	obj.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+// List lists the samples of the env in the time range sorted by the create time
+func (c *EnvHealthScoreColl) List(opt *EnvHealthScoreListOption) ([]*models.EnvHealthScore, error) {
+	query := bson.M{
+		"project_name": opt.ProjectName,
+		"env_name":     opt.EnvName,
+		"production":   opt.Production,
+	}
+	timeQuery := bson.M{}
+	if opt.StartTime > 0 {
+		timeQuery["$gte"] = opt.StartTime
+	}
+	if opt.EndTime > 0 {
+		timeQuery["$lte"] = opt.EndTime
+	}
+	if len(timeQuery) > 0 {
+		query["create_time"] = timeQuery
+	}
+
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{"create_time", 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.EnvHealthScore, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetLatest returns the latest sample of the env
+func (c *EnvHealthScoreColl) GetLatest(projectName, envName string, production bool) (*models.EnvHealthScore, error) {
+	query := bson.M{
+		"project_name": projectName,
+		"env_name":     envName,
+		"production":   production,
+	}
+
+	resp := new(models.EnvHealthScore)
+	err := c.FindOne(context.TODO(), query, options.FindOne().SetSort(bson.D{{"create_time", -1}})).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteBefore deletes the samples created before the given time
+func (c *EnvHealthScoreColl) DeleteBefore(createTime int64) error {
+	_, err := c.DeleteMany(context.TODO(), bson.M{"create_time": bson.M{"$lt": createTime}})
+	return err
+}
+
+// DeleteByEnv deletes the samples of the env
+func (c *EnvHealthScoreColl) DeleteByEnv(projectName, envName string, production bool) error {
+	query := bson.M{
+		"project_name": projectName,
+		"env_name":     envName,
+		"production":   production,
+	}
+	_, err := c.DeleteMany(context.TODO(), query)
+	return err
+}
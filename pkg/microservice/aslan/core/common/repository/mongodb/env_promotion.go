@@ -0,0 +1,148 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type EnvPromotionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvPromotionColl() *EnvPromotionColl {
+	name := models.EnvPromotion{}.TableName()
+	return &EnvPromotionColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvPromotionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvPromotionColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "create_time", Value: -1},
+		},
+		Options: options.Index().SetUnique(false),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *EnvPromotionColl) Create(obj *models.EnvPromotion) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *EnvPromotionColl) Update(obj *models.EnvPromotion) error {
+	obj.UpdateTime = time.Now().Unix()
+	query := bson.M{"_id": obj.ID}
+	change := bson.M{"$set": obj}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+// UpdateStatusFrom sets the status only if the current status is fromStatus, false is returned if the status has been changed by others
+func (c *EnvPromotionColl) UpdateStatusFrom(id primitive.ObjectID, fromStatus, toStatus string) (bool, error) {
+	query := bson.M{"_id": id, "status": fromStatus}
+	change := bson.M{"$set": bson.M{
+		"status":      toStatus,
+		"update_time": time.Now().Unix(),
+	}}
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (c *EnvPromotionColl) GetByID(idStr string) (*models.EnvPromotion, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(models.EnvPromotion)
+	err = c.FindOne(context.TODO(), bson.M{"_id": id}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type ListEnvPromotionOption struct {
+	ProjectName string
+	TargetEnv   string
+	PageNum     int64
+	PageSize    int64
+}
+
+func (c *EnvPromotionColl) List(opt *ListEnvPromotionOption) ([]*models.EnvPromotion, int64, error) {
+	resp := make([]*models.EnvPromotion, 0)
+	query := bson.M{"project_name": opt.ProjectName}
+	if opt.TargetEnv != "" {
+		query["target_env"] = opt.TargetEnv
+	}
+	count, err := c.CountDocuments(context.TODO(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOpt := options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		findOpt.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Find(context.TODO(), query, findOpt)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, count, nil
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type EnvServiceAutoscalerColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvServiceAutoscalerColl() *EnvServiceAutoscalerColl {
+	name := models.EnvServiceAutoscaler{}.TableName()
+	return &EnvServiceAutoscalerColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvServiceAutoscalerColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvServiceAutoscalerColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "service_name", Value: 1},
+			bson.E{Key: "target_kind", Value: 1},
+			bson.E{Key: "target_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *EnvServiceAutoscalerColl) Create(obj *models.EnvServiceAutoscaler) error {
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = obj.CreateTime
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *EnvServiceAutoscalerColl) Update(obj *models.EnvServiceAutoscaler) error {
+	obj.UpdateTime = time.Now().Unix()
+	_, err := c.ReplaceOne(context.TODO(), bson.M{"_id": obj.ID}, obj)
+	return err
+}
+
+func (c *EnvServiceAutoscalerColl) GetByID(id string) (*models.EnvServiceAutoscaler, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.EnvServiceAutoscaler)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+// GetByTarget returns the autoscaler of the workload, nil is returned if there is none
+func (c *EnvServiceAutoscalerColl) GetByTarget(projectName, envName, serviceName, kind, name string) (*models.EnvServiceAutoscaler, error) {
+	resp := new(models.EnvServiceAutoscaler)
+	err := c.FindOne(context.TODO(), bson.M{
+		"project_name": projectName,
+		"env_name":     envName,
+		"service_name": serviceName,
+		"target_kind":  kind,
+		"target_name":  name,
+	}).Decode(resp)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return resp, err
+}
+
+func (c *EnvServiceAutoscalerColl) ListByService(projectName, envName, serviceName string) ([]*models.EnvServiceAutoscaler, error) {
+	resp := make([]*models.EnvServiceAutoscaler, 0)
+	query := bson.M{"project_name": projectName, "env_name": envName, "service_name": serviceName}
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *EnvServiceAutoscalerColl) Delete(id primitive.ObjectID) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+func (c *EnvServiceAutoscalerColl) DeleteByEnv(projectName, envName string) error {
+	_, err := c.DeleteMany(context.TODO(), bson.M{"project_name": projectName, "env_name": envName})
+	return err
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type EnvTrafficMirrorColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvTrafficMirrorColl() *EnvTrafficMirrorColl {
+	name := models.EnvTrafficMirror{}.TableName()
+	return &EnvTrafficMirrorColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvTrafficMirrorColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvTrafficMirrorColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "base_env", Value: 1},
+			bson.E{Key: "service_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *EnvTrafficMirrorColl) Create(obj *models.EnvTrafficMirror) error {
+	obj.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *EnvTrafficMirrorColl) GetByID(id string) (*models.EnvTrafficMirror, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.EnvTrafficMirror)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+// ListByBaseEnv lists the mirrors from the services of the base env
+func (c *EnvTrafficMirrorColl) ListByBaseEnv(projectName, baseEnv string) ([]*models.EnvTrafficMirror, error) {
+	return c.list(bson.M{"project_name": projectName, "base_env": baseEnv})
+}
+
+// ListByEnv lists the mirrors from or to the env
+func (c *EnvTrafficMirrorColl) ListByEnv(projectName, envName string) ([]*models.EnvTrafficMirror, error) {
+	return c.list(bson.M{
+		"project_name": projectName,
+		"$or": bson.A{
+			bson.M{"base_env": envName},
+			bson.M{"sub_env": envName},
+		},
+	})
+}
+
+func (c *EnvTrafficMirrorColl) list(query bson.M) ([]*models.EnvTrafficMirror, error) {
+	resp := make([]*models.EnvTrafficMirror, 0)
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *EnvTrafficMirrorColl) Delete(id primitive.ObjectID) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
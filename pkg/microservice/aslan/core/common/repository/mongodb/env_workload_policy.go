@@ -0,0 +1,82 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type EnvWorkloadPolicyColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvWorkloadPolicyColl() *EnvWorkloadPolicyColl {
+	name := models.EnvWorkloadPolicy{}.TableName()
+	return &EnvWorkloadPolicyColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EnvWorkloadPolicyColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvWorkloadPolicyColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+// GetByProject returns the workload policy of the project, nil is returned if there is none
+func (c *EnvWorkloadPolicyColl) GetByProject(projectName string) (*models.EnvWorkloadPolicy, error) {
+	resp := new(models.EnvWorkloadPolicy)
+	err := c.FindOne(context.TODO(), bson.M{"project_name": projectName}).Decode(resp)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return resp, err
+}
+
+func (c *EnvWorkloadPolicyColl) Upsert(obj *models.EnvWorkloadPolicy) error {
+	query := bson.M{"project_name": obj.ProjectName}
+	obj.UpdateTime = time.Now().Unix()
+	change := bson.M{"$set": bson.M{
+		"rules":       obj.Rules,
+		"updated_by":  obj.UpdatedBy,
+		"update_time": obj.UpdateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
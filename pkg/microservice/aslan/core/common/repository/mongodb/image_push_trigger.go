@@ -0,0 +1,221 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type ImagePushTriggerColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewImagePushTriggerColl() *ImagePushTriggerColl {
+	name := models.ImagePushTrigger{}.TableName()
+	return &ImagePushTriggerColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ImagePushTriggerColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ImagePushTriggerColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *ImagePushTriggerColl) Create(obj *models.ImagePushTrigger) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+func (c *ImagePushTriggerColl) Update(obj *models.ImagePushTrigger) error {
+	obj.UpdateTime = time.Now().Unix()
+	query := bson.M{"_id": obj.ID}
+	change := bson.M{"$set": obj}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ImagePushTriggerColl) GetByID(idStr string) (*models.ImagePushTrigger, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(models.ImagePushTrigger)
+	err = c.FindOne(context.TODO(), bson.M{"_id": id}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ImagePushTriggerColl) List(projectName string) ([]*models.ImagePushTrigger, error) {
+	resp := make([]*models.ImagePushTrigger, 0)
+	query := bson.M{}
+	if projectName != "" {
+		query["project_name"] = projectName
+	}
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ImagePushTriggerColl) DeleteByID(idStr string) error {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+type ImagePushEventColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewImagePushEventColl() *ImagePushEventColl {
+	name := models.ImagePushEvent{}.TableName()
+	return &ImagePushEventColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ImagePushEventColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ImagePushEventColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			// an image is handled at most once by a trigger
+			Keys: bson.D{
+				bson.E{Key: "trigger_id", Value: 1},
+				bson.E{Key: "repo", Value: 1},
+				bson.E{Key: "tag", Value: 1},
+				bson.E{Key: "digest", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "trigger_id", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+// Create inserts the event, a duplicate key error is returned if the image has been handled by the trigger
+func (c *ImagePushEventColl) Create(obj *models.ImagePushEvent) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *ImagePushEventColl) UpdateResult(obj *models.ImagePushEvent) error {
+	query := bson.M{"_id": obj.ID}
+	change := bson.M{"$set": bson.M{
+		"status":      obj.Status,
+		"message":     obj.Message,
+		"task_id":     obj.TaskID,
+		"update_time": time.Now().Unix(),
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ImagePushEventColl) ListByTrigger(triggerID string, pageNum, pageSize int64) ([]*models.ImagePushEvent, int64, error) {
+	resp := make([]*models.ImagePushEvent, 0)
+	query := bson.M{"trigger_id": triggerID}
+	count, err := c.CountDocuments(context.TODO(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opt := options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}})
+	if pageNum > 0 && pageSize > 0 {
+		opt.SetSkip((pageNum - 1) * pageSize).SetLimit(pageSize)
+	}
+	cursor, err := c.Find(context.TODO(), query, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, count, nil
+}
+
+func (c *ImagePushEventColl) DeleteByTrigger(triggerID string) error {
+	_, err := c.DeleteMany(context.TODO(), bson.M{"trigger_id": triggerID})
+	return err
+}
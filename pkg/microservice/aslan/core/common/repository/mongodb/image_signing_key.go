@@ -0,0 +1,116 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type ImageSigningKeyColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewImageSigningKeyColl() *ImageSigningKeyColl {
+	name := models.ImageSigningKey{}.TableName()
+	return &ImageSigningKeyColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ImageSigningKeyColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ImageSigningKeyColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys:    bson.D{bson.E{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *ImageSigningKeyColl) Create(obj *models.ImageSigningKey) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	obj.UpdateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+func (c *ImageSigningKeyColl) Update(idStr string, obj *models.ImageSigningKey) error {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return err
+	}
+	obj.ID = id
+	obj.UpdateTime = time.Now().Unix()
+	_, err = c.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": obj})
+	return err
+}
+
+func (c *ImageSigningKeyColl) GetByID(idStr string) (*models.ImageSigningKey, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(models.ImageSigningKey)
+	err = c.FindOne(context.TODO(), bson.M{"_id": id}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ImageSigningKeyColl) List() ([]*models.ImageSigningKey, error) {
+	resp := make([]*models.ImageSigningKey, 0)
+	cursor, err := c.Find(context.TODO(), bson.M{}, options.Find().SetSort(bson.D{{Key: "update_time", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ImageSigningKeyColl) DeleteByID(idStr string) error {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type IstioRoutingRevisionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewIstioRoutingRevisionColl() *IstioRoutingRevisionColl {
+	name := models.IstioRoutingRevision{}.TableName()
+	return &IstioRoutingRevisionColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *IstioRoutingRevisionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *IstioRoutingRevisionColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "production", Value: 1},
+			bson.E{Key: "kind", Value: 1},
+			bson.E{Key: "name", Value: 1},
+			bson.E{Key: "revision", Value: -1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+type IstioRoutingRevisionOption struct {
+	ProjectName string
+	EnvName     string
+	Production  bool
+	Kind        string
+	Name        string
+}
+
+func (opt *IstioRoutingRevisionOption) query() bson.M {
+	return bson.M{
+		"project_name": opt.ProjectName,
+		"env_name":     opt.EnvName,
+		"production":   opt.Production,
+		"kind":         opt.Kind,
+		"name":         opt.Name,
+	}
+}
+
+// Create saves the object as the next revision
+func (c *IstioRoutingRevisionColl) Create(obj *models.IstioRoutingRevision) error {
+	latest, err := c.GetLatestRevision(&IstioRoutingRevisionOption{
+		ProjectName: obj.ProjectName,
+		EnvName:     obj.EnvName,
+		Production:  obj.Production,
+		Kind:        obj.Kind,
+		Name:        obj.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	obj.ID = primitive.NilObjectID
+	obj.Revision = latest + 1
+	obj.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *IstioRoutingRevisionColl) GetLatestRevision(opt *IstioRoutingRevisionOption) (int64, error) {
+	resp := new(models.IstioRoutingRevision)
+	findOpt := options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}})
+	err := c.FindOne(context.TODO(), opt.query(), findOpt).Decode(resp)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return resp.Revision, nil
+}
+
+func (c *IstioRoutingRevisionColl) GetByRevision(opt *IstioRoutingRevisionOption, revision int64) (*models.IstioRoutingRevision, error) {
+	query := opt.query()
+	query["revision"] = revision
+	resp := new(models.IstioRoutingRevision)
+	err := c.FindOne(context.TODO(), query).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *IstioRoutingRevisionColl) List(opt *IstioRoutingRevisionOption) ([]*models.IstioRoutingRevision, error) {
+	resp := make([]*models.IstioRoutingRevision, 0)
+	findOpt := options.Find().SetSort(bson.D{{Key: "revision", Value: -1}})
+	cursor, err := c.Find(context.TODO(), opt.query(), findOpt)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type JobCheckpointColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewJobCheckpointColl() *JobCheckpointColl {
+	name := models.JobCheckpointRecord{}.TableName()
+	return &JobCheckpointColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *JobCheckpointColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *JobCheckpointColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "workflow_name", Value: 1},
+				bson.E{Key: "task_id", Value: 1},
+				bson.E{Key: "job_name", Value: 1},
+				bson.E{Key: "run_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+// Upsert saves the latest checkpoint of the pod
+func (c *JobCheckpointColl) Upsert(obj *models.JobCheckpointRecord) error {
+	query := bson.M{"workflow_name": obj.WorkflowName, "task_id": obj.TaskID, "job_name": obj.JobName, "run_id": obj.RunID}
+	change := bson.M{
+		"$set": bson.M{
+			"cases_total":  obj.CasesTotal,
+			"cases_run":    obj.CasesRun,
+			"cases_passed": obj.CasesPassed,
+			"cases_failed": obj.CasesFailed,
+			"message":      obj.Message,
+			"update_time":  obj.UpdateTime,
+		},
+		"$setOnInsert": bson.M{"create_time": time.Now().Unix()},
+	}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *JobCheckpointColl) ListByJob(workflowName string, taskID int64, jobName string) ([]*models.JobCheckpointRecord, error) {
+	query := bson.M{"workflow_name": workflowName, "task_id": taskID, "job_name": jobName}
+	opts := options.Find().SetSort(bson.D{{"create_time", 1}})
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.JobCheckpointRecord, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type JobFailureRecordColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewJobFailureRecordColl() *JobFailureRecordColl {
+	name := models.JobFailureRecord{}.TableName()
+	return &JobFailureRecordColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *JobFailureRecordColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *JobFailureRecordColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "workflow_name", Value: 1}, bson.E{Key: "create_time", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *JobFailureRecordColl) Create(obj *models.JobFailureRecord) error {
+	obj.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	obj.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// MarkRecovered marks the failures of the job in the task as recovered
+func (c *JobFailureRecordColl) MarkRecovered(workflowName string, taskID int64, jobName string) error {
+	query := bson.M{"workflow_name": workflowName, "task_id": taskID, "job_name": jobName}
+	_, err := c.UpdateMany(context.TODO(), query, bson.M{"$set": bson.M{"recovered": true}})
+	return err
+}
+
+func (c *JobFailureRecordColl) ListByWorkflow(workflowName string, since int64) ([]*models.JobFailureRecord, error) {
+	resp := make([]*models.JobFailureRecord, 0)
+	query := bson.M{"workflow_name": workflowName, "create_time": bson.M{"$gte": since}}
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}})
+	cursor, err := c.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type JobKubeAccessGrantColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewJobKubeAccessGrantColl() *JobKubeAccessGrantColl {
+	name := models.JobKubeAccessGrant{}.TableName()
+	return &JobKubeAccessGrantColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *JobKubeAccessGrantColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *JobKubeAccessGrantColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "project_name", Value: 1}, bson.E{Key: "create_time", Value: -1}},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *JobKubeAccessGrantColl) Create(obj *models.JobKubeAccessGrant) error {
+	obj.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	obj.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (c *JobKubeAccessGrantColl) MarkRevoked(id primitive.ObjectID) error {
+	_, err := c.UpdateByID(context.TODO(), id, bson.M{"$set": bson.M{"revoke_time": time.Now().Unix()}})
+	return err
+}
+
+type JobKubeAccessGrantListOption struct {
+	ProjectName  string
+	WorkflowName string
+	EnvName      string
+	PageNum      int64
+	PageSize     int64
+}
+
+func (c *JobKubeAccessGrantColl) List(opt *JobKubeAccessGrantListOption) ([]*models.JobKubeAccessGrant, int64, error) {
+	query := bson.M{"project_name": opt.ProjectName}
+	if opt.WorkflowName != "" {
+		query["workflow_name"] = opt.WorkflowName
+	}
+	if opt.EnvName != "" {
+		query["env_name"] = opt.EnvName
+	}
+
+	count, err := c.CountDocuments(context.TODO(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		opts.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]*models.JobKubeAccessGrant, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp, count, nil
+}
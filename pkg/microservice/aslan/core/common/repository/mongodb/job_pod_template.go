@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type JobPodTemplateColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewJobPodTemplateColl() *JobPodTemplateColl {
+	name := models.JobPodTemplate{}.TableName()
+	return &JobPodTemplateColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *JobPodTemplateColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *JobPodTemplateColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "project_name", Value: 1}, bson.E{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *JobPodTemplateColl) Create(obj *models.JobPodTemplate) error {
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = obj.CreateTime
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	obj.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (c *JobPodTemplateColl) Update(projectName, name string, obj *models.JobPodTemplate) error {
+	query := bson.M{"project_name": projectName, "name": name}
+	change := bson.M{"$set": bson.M{
+		"description":      obj.Description,
+		"node_selector":    obj.NodeSelector,
+		"tolerations":      obj.Tolerations,
+		"security_context": obj.SecurityContext,
+		"sidecars":         obj.Sidecars,
+		"updated_by":       obj.UpdatedBy,
+		"update_time":      time.Now().Unix(),
+	}}
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (c *JobPodTemplateColl) Get(projectName, name string) (*models.JobPodTemplate, error) {
+	resp := new(models.JobPodTemplate)
+	query := bson.M{"project_name": projectName, "name": name}
+	err := c.FindOne(context.TODO(), query).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *JobPodTemplateColl) List(projectName string) ([]*models.JobPodTemplate, error) {
+	query := bson.M{"project_name": projectName}
+	opts := options.Find().SetSort(bson.D{{"name", 1}})
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.JobPodTemplate, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *JobPodTemplateColl) Delete(projectName, name string) error {
+	query := bson.M{"project_name": projectName, "name": name}
+	_, err := c.DeleteOne(context.TODO(), query)
+	return err
+}
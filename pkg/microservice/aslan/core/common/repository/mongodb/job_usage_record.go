@@ -0,0 +1,145 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type JobUsageQueryOption struct {
+	ProjectNames []string
+	ClusterID    string
+	JobTypes     []string
+	// StartMonth and EndMonth are both inclusive, in the format of 2006-01
+	StartMonth string
+	EndMonth   string
+}
+
+type JobUsageStat struct {
+	Month       string `bson:"month"        json:"month"`
+	ProjectName string `bson:"project_name" json:"project_name"`
+	ClusterID   string `bson:"cluster_id"   json:"cluster_id"`
+	JobCount    int64  `bson:"job_count"    json:"job_count"`
+	// Duration is the total execution time of the jobs in seconds
+	Duration int64 `bson:"duration"     json:"duration"`
+}
+
+type JobUsageRecordColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewJobUsageRecordColl() *JobUsageRecordColl {
+	name := models.JobUsageRecord{}.TableName()
+	return &JobUsageRecordColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *JobUsageRecordColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *JobUsageRecordColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "month", Value: 1}, bson.E{Key: "project_name", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys:    bson.D{bson.E{Key: "workflow_name", Value: 1}, bson.E{Key: "task_id", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *JobUsageRecordColl) Create(obj *models.JobUsageRecord) error {
+	obj.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+// GetUsage aggregates the execution time of the jobs by month, project and cluster
+func (c *JobUsageRecordColl) GetUsage(opt *JobUsageQueryOption) ([]*JobUsageStat, error) {
+	match := bson.M{}
+	if len(opt.ProjectNames) > 0 {
+		match["project_name"] = bson.M{"$in": opt.ProjectNames}
+	}
+	if opt.ClusterID != "" {
+		match["cluster_id"] = opt.ClusterID
+	}
+	if len(opt.JobTypes) > 0 {
+		match["job_type"] = bson.M{"$in": opt.JobTypes}
+	}
+	monthQuery := bson.M{}
+	if opt.StartMonth != "" {
+		monthQuery["$gte"] = opt.StartMonth
+	}
+	if opt.EndMonth != "" {
+		monthQuery["$lte"] = opt.EndMonth
+	}
+	if len(monthQuery) > 0 {
+		match["month"] = monthQuery
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"month":        "$month",
+				"project_name": "$project_name",
+				"cluster_id":   "$cluster_id",
+			},
+			"job_count": bson.M{"$sum": 1},
+			"duration":  bson.M{"$sum": "$duration"},
+		}},
+		{"$project": bson.M{
+			"_id":          0,
+			"month":        "$_id.month",
+			"project_name": "$_id.project_name",
+			"cluster_id":   "$_id.cluster_id",
+			"job_count":    1,
+			"duration":     1,
+		}},
+		{"$sort": bson.D{{"month", 1}, {"project_name", 1}, {"cluster_id", 1}}},
+	}
+
+	cursor, err := c.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*JobUsageStat, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
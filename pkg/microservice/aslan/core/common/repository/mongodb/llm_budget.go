@@ -0,0 +1,111 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type LLMBudgetColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewLLMBudgetColl() *LLMBudgetColl {
+	name := models.LLMBudget{}.TableName()
+	return &LLMBudgetColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *LLMBudgetColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *LLMBudgetColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys:    bson.D{bson.E{Key: "project_name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+// Upsert sets the budget and the receivers of the project, the alert state is kept
+func (c *LLMBudgetColl) Upsert(obj *models.LLMBudget) error {
+	query := bson.M{"project_name": obj.ProjectName}
+	change := bson.M{"$set": bson.M{
+		"monthly_budget":   obj.MonthlyBudget,
+		"alert_percentage": obj.AlertPercentage,
+		"receivers":        obj.Receivers,
+		"updated_by":       obj.UpdatedBy,
+		"update_time":      time.Now().Unix(),
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *LLMBudgetColl) GetByProject(projectName string) (*models.LLMBudget, error) {
+	resp := new(models.LLMBudget)
+	err := c.FindOne(context.TODO(), bson.M{"project_name": projectName}).Decode(resp)
+	return resp, err
+}
+
+func (c *LLMBudgetColl) List() ([]*models.LLMBudget, error) {
+	resp := make([]*models.LLMBudget, 0)
+	cursor, err := c.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *LLMBudgetColl) Delete(projectName string) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"project_name": projectName})
+	return err
+}
+
+// UpdateAlerted records the alerted percentage of the month, it returns false if the percentage of the month has
+// been alerted already so that concurrent requests do not send the same alert twice
+func (c *LLMBudgetColl) UpdateAlerted(projectName, month string, percent int) (bool, error) {
+	query := bson.M{
+		"project_name": projectName,
+		"$or": []bson.M{
+			{"alerted_month": bson.M{"$ne": month}},
+			{"alerted_percent": bson.M{"$lt": percent}},
+		},
+	}
+	change := bson.M{"$set": bson.M{"alerted_month": month, "alerted_percent": percent}}
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
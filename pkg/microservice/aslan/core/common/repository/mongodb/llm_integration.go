@@ -66,6 +66,33 @@ func (c *LLMIntegrationColl) FindDefault(ctx context.Context) (*models.LLMIntegr
 	return llmProvider, err
 }
 
+func (c *LLMIntegrationColl) FindByFeature(ctx context.Context, feature string) (*models.LLMIntegration, error) {
+	llmProvider := new(models.LLMIntegration)
+	query := bson.M{"features": feature}
+	err := c.FindOne(ctx, query).Decode(llmProvider)
+	return llmProvider, err
+}
+
+// UnsetDefault unsets the default flag of all the integrations except the given one
+func (c *LLMIntegrationColl) UnsetDefault(ctx context.Context, exceptID primitive.ObjectID) error {
+	query := bson.M{"_id": bson.M{"$ne": exceptID}, "is_default": true}
+	change := bson.M{"$set": bson.M{"is_default": false}}
+	_, err := c.UpdateMany(ctx, query, change)
+	return err
+}
+
+// PullFeatures removes the features from all the integrations except the given one, so that each feature is
+// served by one integration only
+func (c *LLMIntegrationColl) PullFeatures(ctx context.Context, exceptID primitive.ObjectID, features []string) error {
+	if len(features) == 0 {
+		return nil
+	}
+	query := bson.M{"_id": bson.M{"$ne": exceptID}}
+	change := bson.M{"$pull": bson.M{"features": bson.M{"$in": features}}}
+	_, err := c.UpdateMany(ctx, query, change)
+	return err
+}
+
 func (c *LLMIntegrationColl) FindByID(ctx context.Context, id string) (*models.LLMIntegration, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -112,8 +139,12 @@ func (c *LLMIntegrationColl) Create(ctx context.Context, args *models.LLMIntegra
 	}
 
 	args.UpdateTime = time.Now().Unix()
-	_, err := c.InsertOne(ctx, args)
-	return err
+	res, err := c.InsertOne(ctx, args)
+	if err != nil {
+		return err
+	}
+	args.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
 }
 
 func (c *LLMIntegrationColl) FindAll(ctx context.Context) ([]*models.LLMIntegration, error) {
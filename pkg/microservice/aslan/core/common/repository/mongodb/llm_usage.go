@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type LLMUsageColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewLLMUsageColl() *LLMUsageColl {
+	name := models.LLMUsage{}.TableName()
+	return &LLMUsageColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *LLMUsageColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *LLMUsageColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "create_time", Value: -1},
+		},
+		Options: options.Index().SetUnique(false),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *LLMUsageColl) Create(obj *models.LLMUsage) error {
+	obj.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+// Stat aggregates the usage created in [startTime, endTime) by project and feature, all the projects are included if
+// the projectName is empty
+func (c *LLMUsageColl) Stat(projectName string, startTime, endTime int64) ([]*models.LLMUsageStat, error) {
+	match := bson.M{
+		"create_time": bson.M{"$gte": startTime, "$lt": endTime},
+	}
+	if projectName != "" {
+		match["project_name"] = projectName
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":               bson.M{"project_name": "$project_name", "feature": "$feature"},
+			"requests":          bson.M{"$sum": 1},
+			"prompt_tokens":     bson.M{"$sum": "$prompt_tokens"},
+			"completion_tokens": bson.M{"$sum": "$completion_tokens"},
+			"cost":              bson.M{"$sum": "$cost"},
+		}},
+		{"$project": bson.M{
+			"_id":               0,
+			"project_name":      "$_id.project_name",
+			"feature":           "$_id.feature",
+			"requests":          1,
+			"prompt_tokens":     1,
+			"completion_tokens": 1,
+			"cost":              1,
+		}},
+		{"$sort": bson.D{{Key: "project_name", Value: 1}, {Key: "feature", Value: 1}}},
+	}
+
+	cursor, err := c.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*models.LLMUsageStat, 0)
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+// SumCost returns the total cost of the project since the startTime
+func (c *LLMUsageColl) SumCost(projectName string, startTime int64) (float64, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"project_name": projectName, "create_time": bson.M{"$gte": startTime}}},
+		{"$group": bson.M{"_id": nil, "cost": bson.M{"$sum": "$cost"}}},
+	}
+
+	cursor, err := c.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return 0, err
+	}
+	resp := make([]struct {
+		Cost float64 `bson:"cost"`
+	}, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return 0, err
+	}
+	if len(resp) == 0 {
+		return 0, nil
+	}
+	return resp[0].Cost, nil
+}
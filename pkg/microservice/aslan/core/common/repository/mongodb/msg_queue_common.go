@@ -89,6 +89,18 @@ func (c *MsgQueueCommonColl) DeleteByQueueType(_type string) error {
 	return err
 }
 
+// UpdatePayload replaces the payload of the message only if it is still oldPayload, so that only one of the
+// concurrent writers wins. It returns false if the message has been changed or removed.
+func (c *MsgQueueCommonColl) UpdatePayload(id primitive.ObjectID, oldPayload, newPayload string) (bool, error) {
+	query := bson.M{"_id": id, "payload": oldPayload}
+	change := bson.M{"$set": bson.M{"payload": newPayload}}
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
 func (c *MsgQueueCommonColl) Create(args *msg_queue.MsgQueueCommon) error {
 	_, err := c.InsertOne(context.TODO(), args)
 	return err
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type NotificationPreferenceColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewNotificationPreferenceColl() *NotificationPreferenceColl {
+	name := models.NotificationPreference{}.TableName()
+	return &NotificationPreferenceColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *NotificationPreferenceColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *NotificationPreferenceColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "user_name", Value: 1}},
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *NotificationPreferenceColl) FindByUserID(userID string) (*models.NotificationPreference, error) {
+	resp := new(models.NotificationPreference)
+	err := c.FindOne(context.TODO(), bson.M{"user_id": userID}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FindByUserName is used by the notifications whose receivers are identified by the user names
+func (c *NotificationPreferenceColl) FindByUserName(userName string) (*models.NotificationPreference, error) {
+	resp := new(models.NotificationPreference)
+	err := c.FindOne(context.TODO(), bson.M{"user_name": userName}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *NotificationPreferenceColl) Upsert(obj *models.NotificationPreference) error {
+	obj.UpdateTime = time.Now().Unix()
+	change := bson.M{"$set": bson.M{
+		"user_name":      obj.UserName,
+		"channels":       obj.Channels,
+		"events":         obj.Events,
+		"muted_projects": obj.MutedProjects,
+		"quiet_hours":    obj.QuietHours,
+		"update_time":    obj.UpdateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), bson.M{"user_id": obj.UserID}, change, options.Update().SetUpsert(true))
+	return err
+}
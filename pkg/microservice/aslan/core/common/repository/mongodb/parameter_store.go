@@ -0,0 +1,196 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type ParameterStoreColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewParameterStoreColl() *ParameterStoreColl {
+	name := models.ParameterStoreItem{}.TableName()
+	return &ParameterStoreColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ParameterStoreColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ParameterStoreColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "project_name", Value: 1}, bson.E{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *ParameterStoreColl) Create(obj *models.ParameterStoreItem) error {
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = obj.CreateTime
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	obj.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (c *ParameterStoreColl) Update(projectName, key string, obj *models.ParameterStoreItem) error {
+	query := bson.M{"project_name": projectName, "key": key}
+	change := bson.M{"$set": bson.M{
+		"type":        obj.Type,
+		"value":       obj.Value,
+		"is_secret":   obj.IsSecret,
+		"description": obj.Description,
+		"updated_by":  obj.UpdatedBy,
+		"update_time": time.Now().Unix(),
+	}}
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (c *ParameterStoreColl) Get(projectName, key string) (*models.ParameterStoreItem, error) {
+	resp := new(models.ParameterStoreItem)
+	query := bson.M{"project_name": projectName, "key": key}
+	err := c.FindOne(context.TODO(), query).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// List lists the parameters of the projects, the system level parameters are listed with an empty project name
+func (c *ParameterStoreColl) List(projectNames []string, keys []string) ([]*models.ParameterStoreItem, error) {
+	query := bson.M{"project_name": bson.M{"$in": projectNames}}
+	if len(keys) > 0 {
+		query["key"] = bson.M{"$in": keys}
+	}
+	opts := options.Find().SetSort(bson.D{{"key", 1}})
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.ParameterStoreItem, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RecordUsage adds the usage to the parameters and refreshes the last used time
+func (c *ParameterStoreColl) RecordUsage(ids []primitive.ObjectID, usage string) error {
+	query := bson.M{"_id": bson.M{"$in": ids}}
+	change := bson.M{
+		"$set":      bson.M{"last_used_time": time.Now().Unix()},
+		"$addToSet": bson.M{"usages": usage},
+	}
+	_, err := c.UpdateMany(context.TODO(), query, change)
+	return err
+}
+
+func (c *ParameterStoreColl) Delete(projectName, key string) error {
+	query := bson.M{"project_name": projectName, "key": key}
+	_, err := c.DeleteOne(context.TODO(), query)
+	return err
+}
+
+type ParameterStoreHistoryColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewParameterStoreHistoryColl() *ParameterStoreHistoryColl {
+	name := models.ParameterStoreHistory{}.TableName()
+	return &ParameterStoreHistoryColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ParameterStoreHistoryColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ParameterStoreHistoryColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{bson.E{Key: "project_name", Value: 1}, bson.E{Key: "key", Value: 1}, bson.E{Key: "create_time", Value: -1}},
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *ParameterStoreHistoryColl) Create(obj *models.ParameterStoreHistory) error {
+	obj.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+func (c *ParameterStoreHistoryColl) List(projectName, key string, pageNum, pageSize int64) ([]*models.ParameterStoreHistory, int64, error) {
+	query := bson.M{"project_name": projectName, "key": key}
+	count, err := c.CountDocuments(context.TODO(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}})
+	if pageNum > 0 && pageSize > 0 {
+		opts.SetSkip((pageNum - 1) * pageSize).SetLimit(pageSize)
+	}
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]*models.ParameterStoreHistory, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp, count, nil
+}
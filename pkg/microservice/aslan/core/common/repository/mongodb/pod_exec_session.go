@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type PodExecSessionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewPodExecSessionColl() *PodExecSessionColl {
+	name := models.PodExecSession{}.TableName()
+	return &PodExecSessionColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *PodExecSessionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *PodExecSessionColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "start_time", Value: -1},
+		},
+		Options: options.Index().SetUnique(false),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *PodExecSessionColl) Create(obj *models.PodExecSession) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *PodExecSessionColl) GetByID(idStr string) (*models.PodExecSession, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(models.PodExecSession)
+	err = c.FindOne(context.TODO(), bson.M{"_id": id}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type ListPodExecSessionOption struct {
+	ProjectName string
+	EnvName     string
+	UserName    string
+	PageNum     int64
+	PageSize    int64
+}
+
+// List lists the sessions without the recorded input and output
+func (c *PodExecSessionColl) List(opt *ListPodExecSessionOption) ([]*models.PodExecSession, int64, error) {
+	resp := make([]*models.PodExecSession, 0)
+	query := bson.M{"project_name": opt.ProjectName}
+	if opt.EnvName != "" {
+		query["env_name"] = opt.EnvName
+	}
+	if opt.UserName != "" {
+		query["user_name"] = opt.UserName
+	}
+	count, err := c.CountDocuments(context.TODO(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOpt := options.Find().
+		SetSort(bson.D{{Key: "start_time", Value: -1}}).
+		SetProjection(bson.M{"input": 0, "output": 0})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		findOpt.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Find(context.TODO(), query, findOpt)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, count, nil
+}
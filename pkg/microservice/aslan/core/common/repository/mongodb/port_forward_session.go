@@ -0,0 +1,170 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type PortForwardSessionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewPortForwardSessionColl() *PortForwardSessionColl {
+	name := models.PortForwardSession{}.TableName()
+	return &PortForwardSessionColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *PortForwardSessionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *PortForwardSessionColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "env_name", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "user_name", Value: 1},
+				bson.E{Key: "status", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *PortForwardSessionColl) Create(obj *models.PortForwardSession) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *PortForwardSessionColl) GetByID(idStr string) (*models.PortForwardSession, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(models.PortForwardSession)
+	err = c.FindOne(context.TODO(), bson.M{"_id": id}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CountActiveByUser counts the active sessions of the user which are not expired
+func (c *PortForwardSessionColl) CountActiveByUser(userName string) (int64, error) {
+	query := bson.M{
+		"user_name":   userName,
+		"status":      models.PortForwardStatusActive,
+		"expire_time": bson.M{"$gt": time.Now().Unix()},
+	}
+	return c.CountDocuments(context.TODO(), query)
+}
+
+// UpdateStatus changes the status of an active session
+func (c *PortForwardSessionColl) UpdateStatus(id primitive.ObjectID, status string) error {
+	query := bson.M{"_id": id, "status": models.PortForwardStatusActive}
+	change := bson.M{"$set": bson.M{
+		"status":     status,
+		"close_time": time.Now().Unix(),
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+// IncStats records a finished connection of the session and the bytes transferred by it
+func (c *PortForwardSessionColl) IncStats(id primitive.ObjectID, bytesIn, bytesOut int64) error {
+	change := bson.M{"$inc": bson.M{
+		"connections": 1,
+		"bytes_in":    bytesIn,
+		"bytes_out":   bytesOut,
+	}}
+	_, err := c.UpdateOne(context.TODO(), bson.M{"_id": id}, change)
+	return err
+}
+
+type ListPortForwardSessionOption struct {
+	ProjectName string
+	EnvName     string
+	UserName    string
+	PageNum     int64
+	PageSize    int64
+}
+
+func (c *PortForwardSessionColl) List(opt *ListPortForwardSessionOption) ([]*models.PortForwardSession, int64, error) {
+	resp := make([]*models.PortForwardSession, 0)
+	query := bson.M{"project_name": opt.ProjectName}
+	if opt.EnvName != "" {
+		query["env_name"] = opt.EnvName
+	}
+	if opt.UserName != "" {
+		query["user_name"] = opt.UserName
+	}
+	count, err := c.CountDocuments(context.TODO(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOpt := options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		findOpt.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Find(context.TODO(), query, findOpt)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, count, nil
+}
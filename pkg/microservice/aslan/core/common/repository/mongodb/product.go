@@ -372,7 +372,7 @@ func (c *ProductColl) UpdateRegistry(envName, productName, registryId string) er
 	query := bson.M{"env_name": envName, "product_name": productName}
 	change := bson.M{"$set": bson.M{
 		"registry_id": registryId,
-	}}
+	}, "$inc": bson.M{"resource_version": 1}}
 
 	ctx := context.TODO()
 	if c.Session != nil {
@@ -395,7 +395,7 @@ func (c *ProductColl) UpdateGlobalVariable(args *models.Product) error {
 		"update_time":      time.Now().Unix(),
 		"global_variables": args.GlobalVariables,
 	}
-	change := bson.M{"$set": changePayload}
+	change := bson.M{"$set": changePayload, "$inc": bson.M{"resource_version": 1}}
 	_, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, change)
 	return err
 }
@@ -424,7 +424,7 @@ func (c *ProductColl) Update(args *models.Product) error {
 	if args.PreSleepStatus != nil {
 		changePayload["pre_sleep_status"] = args.PreSleepStatus
 	}
-	change := bson.M{"$set": changePayload}
+	change := bson.M{"$set": changePayload, "$inc": bson.M{"resource_version": 1}}
 	_, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, change)
 	return err
 }
@@ -456,7 +456,7 @@ func (c *ProductColl) UpdateServicesGroup(productName, envName string, groupInde
 		serviceGroup:  group,
 	}
 
-	_, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, bson.M{"$set": change})
+	_, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, bson.M{"$set": change, "$inc": bson.M{"resource_version": 1}})
 
 	return err
 }
@@ -473,7 +473,7 @@ func (c *ProductColl) UpdateAllServices(productName, envName string, services []
 		"services":    services,
 	}
 
-	_, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, bson.M{"$set": change})
+	_, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, bson.M{"$set": change, "$inc": bson.M{"resource_version": 1}})
 
 	return err
 }
@@ -493,6 +493,7 @@ func (c *ProductColl) UpdateOneService(productName, envName string, groupIndex,
 			servicePath:   service,
 			"update_time": time.Now().Unix(),
 		},
+		"$inc": bson.M{"resource_version": 1},
 	}
 
 	result, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, change)
@@ -521,6 +522,7 @@ func (c *ProductColl) AddOneService(productName, envName string, groupIndex, ser
 			servicePath:   service,
 			"update_time": time.Now().Unix(),
 		},
+		"$inc": bson.M{"resource_version": 1},
 	}
 
 	result, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, change)
@@ -546,7 +548,7 @@ func (c *ProductColl) UpdateDeployStrategyAndGlobalVariable(envName, productName
 		"service_deploy_strategy": deployStrategy,
 	}
 
-	_, err := c.UpdateOne(context.TODO(), query, bson.M{"$set": change})
+	_, err := c.UpdateOne(context.TODO(), query, bson.M{"$set": change, "$inc": bson.M{"resource_version": 1}})
 
 	return err
 }
@@ -561,7 +563,7 @@ func (c *ProductColl) UpdateDeployStrategy(envName, productName string, deploySt
 		"service_deploy_strategy": deployStrategy,
 	}
 
-	_, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, bson.M{"$set": change})
+	_, err := c.UpdateOne(mongotool.SessionContext(context.TODO(), c.Session), query, bson.M{"$set": change, "$inc": bson.M{"resource_version": 1}})
 
 	return err
 }
@@ -573,7 +575,7 @@ func (c *ProductColl) UpdateProductVariables(product *models.Product) error {
 		"default_values":   product.DefaultValues,
 		"yaml_data":        product.YamlData,
 		"global_variables": product.GlobalVariables,
-	}}
+	}, "$inc": bson.M{"resource_version": 1}}
 	_, err := c.UpdateOne(context.TODO(), query, change)
 	return err
 }
@@ -600,12 +602,70 @@ func (c *ProductColl) UpdateProductAlias(envName, productName, alias string) err
 	return err
 }
 
+func (c *ProductColl) UpdateImageSigningKey(envName, productName, keyID string) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"update_time":          time.Now().Unix(),
+		"image_signing_key_id": keyID,
+	}, "$inc": bson.M{"resource_version": 1}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+func (c *ProductColl) UpdateExternalVariable(envName, productName string, externalVariable *models.ExternalVariableConfig) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"update_time":       time.Now().Unix(),
+		"external_variable": externalVariable,
+	}, "$inc": bson.M{"resource_version": 1}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+func (c *ProductColl) UpdateHelmPostRenderer(envName, productName string, postRenderer *models.HelmPostRenderer) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"update_time":        time.Now().Unix(),
+		"helm_post_renderer": postRenderer,
+	}, "$inc": bson.M{"resource_version": 1}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+func (c *ProductColl) UpdateAutoUpgrade(envName, productName string, autoUpgrade *models.EnvAutoUpgradePolicy) error {
+	query := bson.M{"env_name": envName, "product_name": productName, "production": false}
+	change := bson.M{"$set": bson.M{
+		"auto_upgrade": autoUpgrade,
+	}, "$inc": bson.M{"resource_version": 1}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+// ListAutoUpgradeEnvs lists the non-production envs with the auto upgrade of services enabled
+func (c *ProductColl) ListAutoUpgradeEnvs() ([]*models.Product, error) {
+	query := bson.M{"auto_upgrade.enabled": true, "production": false}
+	cursor, err := c.Collection.Find(context.TODO(), query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.Product, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (c *ProductColl) UpdateIsPublic(envName, productName string, isPublic bool) error {
 	query := bson.M{"env_name": envName, "product_name": productName}
 	change := bson.M{"$set": bson.M{
 		"update_time": time.Now().Unix(),
 		"is_public":   isPublic,
-	}}
+	}, "$inc": bson.M{"resource_version": 1}}
 	_, err := c.UpdateOne(context.TODO(), query, change)
 
 	return err
@@ -616,7 +676,7 @@ func (c *ProductColl) UpdateIstioGrayscale(envName, productName string, istioGra
 	change := bson.M{"$set": bson.M{
 		"update_time":     time.Now().Unix(),
 		"istio_grayscale": istioGrayscale,
-	}}
+	}, "$inc": bson.M{"resource_version": 1}}
 	_, err := c.UpdateOne(context.TODO(), query, change)
 
 	return err
@@ -741,14 +801,15 @@ func (c *ProductColl) ListEnvByNamespace(clusterID, namespace string) ([]*models
 	return resp, nil
 }
 
-func (c *ProductColl) UpdateConfigs(envName, productName string, analysisConfig *models.AnalysisConfig, notificationConfigs []*models.NotificationConfig) error {
+func (c *ProductColl) UpdateConfigs(envName, productName string, analysisConfig *models.AnalysisConfig, notificationConfigs []*models.NotificationConfig, healthConfig *models.EnvHealthConfig) error {
 	query := bson.M{"env_name": envName, "product_name": productName}
 
 	change := bson.M{"$set": bson.M{
 		"analysis_config":      analysisConfig,
 		"notification_configs": notificationConfigs,
+		"health_config":        healthConfig,
 		"update_time":          time.Now().Unix(),
-	}}
+	}, "$inc": bson.M{"resource_version": 1}}
 	_, err := c.UpdateOne(context.TODO(), query, change)
 
 	return err
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type ProjectQuotaColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewProjectQuotaColl() *ProjectQuotaColl {
+	name := models.ProjectQuota{}.TableName()
+	return &ProjectQuotaColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ProjectQuotaColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ProjectQuotaColl) EnsureIndex(ctx context.Context) error {
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, index)
+
+	return err
+}
+
+func (c *ProjectQuotaColl) Find(projectName string) (*models.ProjectQuota, error) {
+	resp := new(models.ProjectQuota)
+	err := c.FindOne(context.TODO(), bson.M{"project_name": projectName}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ProjectQuotaColl) List() ([]*models.ProjectQuota, error) {
+	resp := make([]*models.ProjectQuota, 0)
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ProjectQuotaColl) Upsert(obj *models.ProjectQuota) error {
+	query := bson.M{"project_name": obj.ProjectName}
+	obj.UpdateTime = time.Now().Unix()
+	change := bson.M{"$set": bson.M{
+		"max_envs":                      obj.MaxEnvs,
+		"max_concurrent_workflow_tasks": obj.MaxConcurrentWorkflowTasks,
+		"max_build_minutes_per_month":   obj.MaxBuildMinutesPerMonth,
+		"updated_by":                    obj.UpdatedBy,
+		"update_time":                   obj.UpdateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *ProjectQuotaColl) Delete(projectName string) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"project_name": projectName})
+	return err
+}
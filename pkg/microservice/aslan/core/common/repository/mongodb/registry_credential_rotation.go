@@ -0,0 +1,112 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type RegistryCredentialRotationColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewRegistryCredentialRotationColl() *RegistryCredentialRotationColl {
+	name := models.RegistryCredentialRotation{}.TableName()
+	return &RegistryCredentialRotationColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *RegistryCredentialRotationColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *RegistryCredentialRotationColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "registry_id", Value: 1},
+			bson.E{Key: "create_time", Value: -1},
+		},
+		Options: options.Index().SetUnique(false),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *RegistryCredentialRotationColl) Create(obj *models.RegistryCredentialRotation) error {
+	obj.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		obj.ID = id
+	}
+	return nil
+}
+
+func (c *RegistryCredentialRotationColl) GetByID(id string) (*models.RegistryCredentialRotation, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.RegistryCredentialRotation)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+// ListByRegistry lists the latest rotations of the registry
+func (c *RegistryCredentialRotationColl) ListByRegistry(registryID string, limit int64) ([]*models.RegistryCredentialRotation, error) {
+	resp := make([]*models.RegistryCredentialRotation, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}}).SetLimit(limit)
+	cursor, err := c.Find(context.TODO(), bson.M{"registry_id": registryID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *RegistryCredentialRotationColl) IncSucceeded(id primitive.ObjectID) error {
+	_, err := c.UpdateByID(context.TODO(), id, bson.M{"$inc": bson.M{"succeeded": 1}})
+	return err
+}
+
+func (c *RegistryCredentialRotationColl) AddFailure(id primitive.ObjectID, failure *models.RegistryCredentialRotationFailure) error {
+	_, err := c.UpdateByID(context.TODO(), id, bson.M{"$push": bson.M{"failures": failure}})
+	return err
+}
+
+func (c *RegistryCredentialRotationColl) Finish(id primitive.ObjectID, status string) error {
+	_, err := c.UpdateByID(context.TODO(), id, bson.M{"$set": bson.M{"status": status, "finish_time": time.Now().Unix()}})
+	return err
+}
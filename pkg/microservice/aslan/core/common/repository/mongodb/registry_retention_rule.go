@@ -0,0 +1,111 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type RegistryRetentionRuleColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewRegistryRetentionRuleColl() *RegistryRetentionRuleColl {
+	name := models.RegistryRetentionRule{}.TableName()
+	return &RegistryRetentionRuleColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *RegistryRetentionRuleColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *RegistryRetentionRuleColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "registry_id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *RegistryRetentionRuleColl) Upsert(obj *models.RegistryRetentionRule) error {
+	query := bson.M{"project_name": obj.ProjectName, "registry_id": obj.RegistryID}
+	obj.UpdateTime = time.Now().Unix()
+	change := bson.M{"$set": bson.M{
+		"repos":       obj.Repos,
+		"keep_latest": obj.KeepLatest,
+		"keep_days":   obj.KeepDays,
+		"enabled":     obj.Enabled,
+		"updated_by":  obj.UpdatedBy,
+		"update_time": obj.UpdateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *RegistryRetentionRuleColl) GetByRegistry(projectName, registryID string) (*models.RegistryRetentionRule, error) {
+	resp := new(models.RegistryRetentionRule)
+	err := c.FindOne(context.TODO(), bson.M{"project_name": projectName, "registry_id": registryID}).Decode(resp)
+	return resp, err
+}
+
+func (c *RegistryRetentionRuleColl) ListByProject(projectName string) ([]*models.RegistryRetentionRule, error) {
+	return c.list(bson.M{"project_name": projectName})
+}
+
+func (c *RegistryRetentionRuleColl) ListEnabled() ([]*models.RegistryRetentionRule, error) {
+	return c.list(bson.M{"enabled": true})
+}
+
+func (c *RegistryRetentionRuleColl) list(query bson.M) ([]*models.RegistryRetentionRule, error) {
+	resp := make([]*models.RegistryRetentionRule, 0)
+	cursor, err := c.Find(context.TODO(), query)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *RegistryRetentionRuleColl) UpdateLastRunTime(projectName, registryID string) error {
+	query := bson.M{"project_name": projectName, "registry_id": registryID}
+	_, err := c.UpdateOne(context.TODO(), query, bson.M{"$set": bson.M{"last_run_time": time.Now().Unix()}})
+	return err
+}
+
+func (c *RegistryRetentionRuleColl) Delete(projectName, registryID string) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"project_name": projectName, "registry_id": registryID})
+	return err
+}
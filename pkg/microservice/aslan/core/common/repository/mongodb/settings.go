@@ -100,6 +100,26 @@ func (c *SystemSettingColl) UpdatePrivacySetting(improvementPlan bool) error {
 	return err
 }
 
+func (c *SystemSettingColl) UpdateCredentialScanPolicy(policy *models.CredentialScanPolicy) error {
+	id, _ := primitive.ObjectIDFromHex(setting.LocalClusterID)
+	change := bson.M{"$set": bson.M{
+		"credential_scan": policy,
+	}}
+	query := bson.M{"_id": id}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *SystemSettingColl) UpdateAuditLogExportPolicy(policy *models.AuditLogExportPolicy) error {
+	id, _ := primitive.ObjectIDFromHex(setting.LocalClusterID)
+	change := bson.M{"$set": bson.M{
+		"audit_log_export": policy,
+	}}
+	query := bson.M{"_id": id}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
 func (c *SystemSettingColl) InitSystemSettings() error {
 	_, err := c.Get()
 	// if we didn't find anything
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type StatusBadgeColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewStatusBadgeColl() *StatusBadgeColl {
+	name := models.StatusBadge{}.TableName()
+	return &StatusBadgeColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *StatusBadgeColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *StatusBadgeColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "token", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "project_name", Value: 1}, bson.E{Key: "type", Value: 1}, bson.E{Key: "name", Value: 1}},
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *StatusBadgeColl) Create(obj *models.StatusBadge) error {
+	obj.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+func (c *StatusBadgeColl) FindByToken(token string) (*models.StatusBadge, error) {
+	resp := new(models.StatusBadge)
+	err := c.FindOne(context.TODO(), bson.M{"token": token}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *StatusBadgeColl) List(projectName string) ([]*models.StatusBadge, error) {
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}})
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{"project_name": projectName}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.StatusBadge, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *StatusBadgeColl) Delete(projectName, idString string) error {
+	id, err := primitive.ObjectIDFromHex(idString)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": id, "project_name": projectName})
+	return err
+}
@@ -316,8 +316,10 @@ func (c *ProductColl) List() ([]*template.Product, error) {
 type ProductListOpt struct {
 	IsOpensource          string
 	ContainSharedServices []*template.ServiceInfo
-	BasicFacility         string
-	DeployType            string
+	// ReferencedService lists the projects referencing the service exported by another project, in format of `project/service`
+	ReferencedService string
+	BasicFacility     string
+	DeployType        string
 }
 
 // ListWithOption ...
@@ -331,6 +333,11 @@ func (c *ProductColl) ListWithOption(opt *ProductListOpt) ([]*template.Product,
 	if len(opt.ContainSharedServices) > 0 {
 		query["shared_services"] = bson.M{"$in": opt.ContainSharedServices}
 	}
+	if opt.ReferencedService != "" {
+		if projectName, serviceName, ok := strings.Cut(opt.ReferencedService, "/"); ok {
+			query["shared_service_refs"] = bson.M{"$elemMatch": bson.M{"project_name": projectName, "service_name": serviceName}}
+		}
+	}
 	if opt.BasicFacility != "" {
 		query["product_feature.basic_facility"] = opt.BasicFacility
 	}
@@ -561,6 +568,76 @@ func (c *ProductColl) UpdateGlobalVars(productName string, serviceVars []*types.
 	return err
 }
 
+func (c *ProductColl) UpdateLogPolicy(productName string, policy *template.LogPolicy) error {
+	query := bson.M{"product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"log_policy": policy,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ProductColl) UpdateInheritance(productName string, inheritance *template.ProjectInheritance) error {
+	query := bson.M{"product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"inheritance": inheritance,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ProductColl) UpdateHelmDefaultValues(productName, defaultValues string) error {
+	query := bson.M{"product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"helm_default_values": defaultValues,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ProductColl) UpdateHelmLockedValuesKeys(productName string, keys []*template.HelmLockedValuesKey) error {
+	query := bson.M{"product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"helm_locked_values_keys": keys,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ProductColl) UpdateDashboardLinks(productName string, links []*template.DashboardLink) error {
+	query := bson.M{"product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"dashboard_links": links,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ProductColl) UpdateExportedServices(productName string, services []string) error {
+	query := bson.M{"product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"exported_services": services,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ProductColl) UpdateSharedServiceRefs(productName string, refs []*template.SharedServiceRef) error {
+	query := bson.M{"product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"shared_service_refs": refs,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
 func (c *ProductColl) Delete(productName string) error {
 	query := bson.M{"product_name": productName}
 
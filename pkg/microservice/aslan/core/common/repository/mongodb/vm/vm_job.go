@@ -98,6 +98,25 @@ func (c *VMJobColl) Update(idString string, obj *vm.VMJob) error {
 	return err
 }
 
+// IncLogChunks increases the log chunk number of the job and returns the new one, which is the sequence of the next chunk
+func (c *VMJobColl) IncLogChunks(idString string) (int, error) {
+	id, err := primitive.ObjectIDFromHex(idString)
+	if err != nil {
+		return 0, err
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	res := c.FindOneAndUpdate(context.TODO(), bson.M{"_id": id}, bson.M{"$inc": bson.M{"log_chunks": 1}}, opts)
+	if res.Err() != nil {
+		return 0, res.Err()
+	}
+	job := new(vm.VMJob)
+	if err := res.Decode(job); err != nil {
+		return 0, err
+	}
+	return job.LogChunks, nil
+}
+
 func (e *VMJobColl) UpdateStatus(idString string, status string) error {
 	if status == "" {
 		return nil
@@ -173,6 +192,26 @@ func (c *VMJobColl) FindOldestByTags(labels []string) (*vm.VMJob, error) {
 	return res, err
 }
 
+// ListUnfinishedByVMID lists the jobs that have been taken by the vm but not finished
+func (c *VMJobColl) ListUnfinishedByVMID(vmID string) ([]*vm.VMJob, error) {
+	query := bson.M{
+		"vm_id": vmID,
+		"status": bson.M{"$in": []string{
+			string(config.StatusPrepare),
+			string(config.StatusDistributed),
+			string(config.StatusRunning),
+		}},
+	}
+
+	res := make([]*vm.VMJob, 0)
+	cursor, err := c.Collection.Find(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.Background(), &res)
+	return res, err
+}
+
 type VMJobOpts struct {
 	Names  []string
 	Status string
@@ -77,7 +77,7 @@ func (c *WorkflowQueueColl) List(opt *ListWorfklowQueueOption) ([]*models.Workfl
 
 	var resp []*models.WorkflowQueue
 	ctx := context.Background()
-	opts := options.Find().SetSort(bson.D{{"create_time", 1}})
+	opts := options.Find().SetSort(bson.D{{"priority", -1}, {"create_time", 1}})
 	cursor, err := c.Collection.Find(ctx, query, opts)
 	if err != nil {
 		return nil, err
@@ -124,3 +124,18 @@ func (c *WorkflowQueueColl) Update(args *models.WorkflowQueue) error {
 	_, err := c.UpdateOne(context.TODO(), query, change)
 	return err
 }
+
+// UpdatePriority sets the priority of the task waiting in the queue
+func (c *WorkflowQueueColl) UpdatePriority(workflowName string, taskID int64, priority int) error {
+	query := bson.M{"task_id": taskID, "workflow_name": workflowName, "status": config.StatusWaiting}
+	change := bson.M{"$set": bson.M{"priority": priority}}
+
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type WorkflowScheduleListOption struct {
+	ProjectName  string
+	WorkflowName string
+}
+
+type WorkflowScheduleColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewWorkflowScheduleColl() *WorkflowScheduleColl {
+	name := models.WorkflowSchedule{}.TableName()
+	return &WorkflowScheduleColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *WorkflowScheduleColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *WorkflowScheduleColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "workflow_name", Value: 1},
+				bson.E{Key: "name", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "enabled", Value: 1},
+				bson.E{Key: "next_run_time", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *WorkflowScheduleColl) Create(obj *models.WorkflowSchedule) error {
+	if obj == nil {
+		return fmt.Errorf("nil object")
+	}
+	obj.ID = primitive.NilObjectID
+	obj.CreateTime = time.Now().Unix()
+	obj.UpdateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), obj)
+	return err
+}
+
+func (c *WorkflowScheduleColl) Update(obj *models.WorkflowSchedule) error {
+	query := bson.M{"_id": obj.ID}
+	change := bson.M{"$set": obj}
+	obj.UpdateTime = time.Now().Unix()
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+// UpdateRunResult records the result of a scheduled run and moves the schedule to the next run time
+func (c *WorkflowScheduleColl) UpdateRunResult(obj *models.WorkflowSchedule) error {
+	query := bson.M{"_id": obj.ID}
+	change := bson.M{"$set": bson.M{
+		"next_run_time":    obj.NextRunTime,
+		"last_run_time":    obj.LastRunTime,
+		"last_task_id":     obj.LastTaskID,
+		"last_run_status":  obj.LastRunStatus,
+		"last_run_message": obj.LastRunMessage,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *WorkflowScheduleColl) GetByID(idStr string) (*models.WorkflowSchedule, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(models.WorkflowSchedule)
+	err = c.FindOne(context.TODO(), bson.M{"_id": id}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *WorkflowScheduleColl) List(opt *WorkflowScheduleListOption) ([]*models.WorkflowSchedule, error) {
+	resp := make([]*models.WorkflowSchedule, 0)
+	query := bson.M{}
+	if opt.ProjectName != "" {
+		query["project_name"] = opt.ProjectName
+	}
+	if opt.WorkflowName != "" {
+		query["workflow_name"] = opt.WorkflowName
+	}
+
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListDue lists the enabled schedules whose next run time has come
+func (c *WorkflowScheduleColl) ListDue(now int64) ([]*models.WorkflowSchedule, error) {
+	resp := make([]*models.WorkflowSchedule, 0)
+	query := bson.M{
+		"enabled":       true,
+		"next_run_time": bson.M{"$lte": now},
+	}
+
+	cursor, err := c.Find(context.TODO(), query)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *WorkflowScheduleColl) DeleteByID(idStr string) error {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+func (c *WorkflowScheduleColl) DeleteByWorkflowName(workflowName string) error {
+	_, err := c.DeleteMany(context.TODO(), bson.M{"workflow_name": workflowName})
+	return err
+}
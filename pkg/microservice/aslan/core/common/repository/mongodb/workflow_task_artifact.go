@@ -0,0 +1,213 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type WorkflowTaskArtifactListOption struct {
+	ProjectName  string
+	WorkflowName string
+	TaskID       int64
+	JobName      string
+}
+
+type WorkflowTaskArtifactUsage struct {
+	ProjectName string `bson:"project_name" json:"project_name"`
+	TotalSize   int64  `bson:"total_size"   json:"total_size"`
+	Count       int64  `bson:"count"        json:"count"`
+}
+
+type WorkflowTaskArtifactColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewWorkflowTaskArtifactColl() *WorkflowTaskArtifactColl {
+	name := models.WorkflowTaskArtifact{}.TableName()
+	return &WorkflowTaskArtifactColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *WorkflowTaskArtifactColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *WorkflowTaskArtifactColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "workflow_name", Value: 1},
+				bson.E{Key: "task_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "create_time", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "expire_time", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *WorkflowTaskArtifactColl) BulkCreate(objs []*models.WorkflowTaskArtifact) error {
+	if len(objs) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(objs))
+	for _, obj := range objs {
+		obj.ID = primitive.NilObjectID
+		obj.CreateTime = time.Now().Unix()
+		docs = append(docs, obj)
+	}
+	_, err := c.InsertMany(context.TODO(), docs)
+	return err
+}
+
+func (c *WorkflowTaskArtifactColl) GetByID(idStr string) (*models.WorkflowTaskArtifact, error) {
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(models.WorkflowTaskArtifact)
+	err = c.FindOne(context.TODO(), bson.M{"_id": id}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *WorkflowTaskArtifactColl) List(opt *WorkflowTaskArtifactListOption) ([]*models.WorkflowTaskArtifact, error) {
+	resp := make([]*models.WorkflowTaskArtifact, 0)
+	query := bson.M{}
+	if opt.ProjectName != "" {
+		query["project_name"] = opt.ProjectName
+	}
+	if opt.WorkflowName != "" {
+		query["workflow_name"] = opt.WorkflowName
+	}
+	if opt.TaskID > 0 {
+		query["task_id"] = opt.TaskID
+	}
+	if opt.JobName != "" {
+		query["job_name"] = opt.JobName
+	}
+
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListExpired lists at most limit artifacts expired before the given time
+func (c *WorkflowTaskArtifactColl) ListExpired(now int64, limit int64) ([]*models.WorkflowTaskArtifact, error) {
+	resp := make([]*models.WorkflowTaskArtifact, 0)
+	query := bson.M{"expire_time": bson.M{"$gt": 0, "$lte": now}}
+
+	cursor, err := c.Find(context.TODO(), query, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUsage returns the total artifact size and count of the project, all projects are counted if projectName is empty
+func (c *WorkflowTaskArtifactColl) GetUsage(projectName string) ([]*WorkflowTaskArtifactUsage, error) {
+	pipeline := []bson.M{}
+	if projectName != "" {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"project_name": projectName}})
+	}
+	pipeline = append(pipeline, bson.M{
+		"$group": bson.M{
+			"_id":        "$project_name",
+			"total_size": bson.M{"$sum": "$size"},
+			"count":      bson.M{"$sum": 1},
+		},
+	})
+	pipeline = append(pipeline, bson.M{
+		"$project": bson.M{
+			"_id":          0,
+			"project_name": "$_id",
+			"total_size":   1,
+			"count":        1,
+		},
+	})
+
+	cursor, err := c.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*WorkflowTaskArtifactUsage, 0)
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *WorkflowTaskArtifactColl) DeleteByIDs(ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := c.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+// SetExpireTimeByProject resets the expire time of the project artifacts, the retentionDays of 0 means never expire
+func (c *WorkflowTaskArtifactColl) SetExpireTimeByProject(projectName string, retentionDays int) error {
+	var pipeline []bson.M
+	if retentionDays <= 0 {
+		pipeline = []bson.M{{"$set": bson.M{"expire_time": 0}}}
+	} else {
+		pipeline = []bson.M{{"$set": bson.M{"expire_time": bson.M{"$add": bson.A{"$create_time", int64(retentionDays) * 24 * 3600}}}}}
+	}
+	_, err := c.UpdateMany(context.TODO(), bson.M{"project_name": projectName}, pipeline)
+	return err
+}
@@ -203,6 +203,15 @@ func (c *WorkflowTaskv4Coll) InCompletedTasks() ([]*models.WorkflowTask, error)
 	return ret, nil
 }
 
+func (c *WorkflowTaskv4Coll) CountInCompletedTasksByProject(projectName string) (int64, error) {
+	query := bson.M{
+		"status":       bson.M{"$in": config.InCompletedStatus()},
+		"is_deleted":   false,
+		"project_name": projectName,
+	}
+	return c.CountDocuments(context.TODO(), query)
+}
+
 func (c *WorkflowTaskv4Coll) Find(workflowName string, taskID int64) (*models.WorkflowTask, error) {
 	resp := new(models.WorkflowTask)
 	query := bson.M{"workflow_name": workflowName, "task_id": taskID}
@@ -119,6 +119,40 @@ func (c *WorkflowV4Coll) ListByWorkflows(opt ListWorkflowV4Opt) ([]*models.Workf
 	return res, nil
 }
 
+func (c *WorkflowV4Coll) ListByTemplate(templateID string) ([]*models.WorkflowV4, error) {
+	resp := make([]*models.WorkflowV4, 0)
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{"template.id": templateID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *WorkflowV4Coll) ListWithCodeSource() ([]*models.WorkflowV4, error) {
+	resp := make([]*models.WorkflowV4, 0)
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{"code_source": bson.M{"$ne": nil}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateCodeSource sets the code source of the workflow, the code source is removed if it is nil
+func (c *WorkflowV4Coll) UpdateCodeSource(name string, source *models.WorkflowCodeSource) error {
+	change := bson.M{"$unset": bson.M{"code_source": ""}}
+	if source != nil {
+		change = bson.M{"$set": bson.M{"code_source": source}}
+	}
+	_, err := c.UpdateOne(context.TODO(), bson.M{"name": name}, change)
+	return err
+}
+
 func (c *WorkflowV4Coll) ListByProjectNames(projects []string) ([]*models.WorkflowV4, error) {
 	resp := make([]*models.WorkflowV4, 0)
 	query := bson.M{}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	aimodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/ai"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/analysis"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+)
+
+type EnvAnalysisResult struct {
+	Result   string
+	Problems int
+	Findings []*aimodels.EnvAnalysisFinding
+}
+
+// GetEnvAnalysisScope returns the objects in the form of Kind/name that the analysis of the env is scoped to,
+// nil is returned if neither services nor workloads are given, which means the whole namespace is analyzed
+func GetEnvAnalysisScope(env *models.Product, serviceNames, workloads []string) (map[string]bool, error) {
+	if len(serviceNames) == 0 && len(workloads) == 0 {
+		return nil, nil
+	}
+
+	scope := make(map[string]bool)
+	for _, workload := range workloads {
+		if !strings.Contains(workload, "/") {
+			return nil, fmt.Errorf("invalid workload %s, should be in the form of Kind/name", workload)
+		}
+		scope[workload] = true
+	}
+	if len(serviceNames) == 0 {
+		return scope, nil
+	}
+
+	svcMap := env.GetServiceMap()
+	for _, svc := range env.GetChartServiceMap() {
+		svcMap[svc.ServiceName] = svc
+	}
+	helmServices := make(map[string]bool)
+	for _, name := range serviceNames {
+		svc, ok := svcMap[name]
+		if !ok {
+			return nil, fmt.Errorf("service %s not found in env %s", name, env.EnvName)
+		}
+		if svc.Type != setting.HelmDeployType && svc.Type != setting.HelmChartDeployType {
+			for _, res := range svc.Resources {
+				scope[res.Kind+"/"+res.Name] = true
+			}
+			continue
+		}
+		helmServices[name] = true
+	}
+	if len(helmServices) == 0 {
+		return scope, nil
+	}
+
+	releaseToService, err := commonutil.GetReleaseNameToServiceNameMap(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build release-service map, err: %w", err)
+	}
+	releaseNames := make(map[string]bool)
+	for release, svcName := range releaseToService {
+		if helmServices[svcName] {
+			releaseNames[release] = true
+		}
+	}
+
+	// the resources of the helm services are not recorded, the workloads are found by the release annotation
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kube client, err: %w", err)
+	}
+	deployments, err := getter.ListDeployments(env.Namespace, labels.Everything(), kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments, err: %w", err)
+	}
+	for _, deployment := range deployments {
+		if releaseNames[deployment.Annotations[setting.HelmReleaseNameAnnotation]] {
+			scope[setting.Deployment+"/"+deployment.Name] = true
+		}
+	}
+	statefulSets, err := getter.ListStatefulSets(env.Namespace, labels.Everything(), kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets, err: %w", err)
+	}
+	for _, sts := range statefulSets {
+		if releaseNames[sts.Annotations[setting.HelmReleaseNameAnnotation]] {
+			scope[setting.StatefulSet+"/"+sts.Name] = true
+		}
+	}
+	return scope, nil
+}
+
+// AnalyzeEnv runs the AI analysis on the resources of the env namespace with the analyzers of the filters, the results
+// are limited to the objects in the scope if the scope is not nil
+func AnalyzeEnv(ctx context.Context, env *models.Product, filters []string, scope map[string]bool) (*EnvAnalysisResult, error) {
+	llmClient, err := GetFeatureLLMClient(ctx, LLMFeatureEnvAnalysis, env.ProductName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get llm client, err: %w", err)
+	}
+
+	analysiser, err := analysis.NewAnalysis(
+		ctx, env.ClusterID,
+		llmClient,
+		filters, env.Namespace,
+		false, // noCache bool
+		true,  // explain bool
+		10,    // maxConcurrency int
+		false, // withDoc bool
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analysiser, err: %w", err)
+	}
+
+	analysiser.RunAnalysis(filters)
+	if scope != nil {
+		analysiser.ScopeResults(scope)
+	}
+	err = analysiser.GetAIResults(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analysis result, err: %w", err)
+	}
+
+	output, err := analysiser.PrintOutput("text")
+	if err != nil {
+		return nil, fmt.Errorf("failed to print analysis result, err: %w", err)
+	}
+
+	resp := &EnvAnalysisResult{Result: string(output)}
+	for _, result := range analysiser.Results {
+		resp.Problems += len(result.Error)
+		resp.Findings = append(resp.Findings, newEnvAnalysisFinding(env, result))
+	}
+	return resp, nil
+}
+
+func newEnvAnalysisFinding(env *models.Product, result analysis.Result) *aimodels.EnvAnalysisFinding {
+	finding := &aimodels.EnvAnalysisFinding{
+		Kind:         result.Kind,
+		Name:         result.Name,
+		ParentObject: result.ParentObject,
+		Details:      result.Details,
+	}
+	for _, failure := range result.Error {
+		finding.Errors = append(finding.Errors, failure.Text)
+	}
+	finding.Fingerprint = envAnalysisFindingFingerprint(env, finding)
+	return finding
+}
+
+// envAnalysisFindingFingerprint identifies the problem by the env, the owner of the object and the errors, the name of
+// the object is replaced by its owner in the errors so that the same problem of recreated pods has the same fingerprint
+func envAnalysisFindingFingerprint(env *models.Product, finding *aimodels.EnvAnalysisFinding) string {
+	object := finding.Kind + "/" + finding.Name
+	if finding.ParentObject != "" {
+		object = finding.ParentObject
+	}
+
+	errs := make([]string, 0, len(finding.Errors))
+	for _, errText := range finding.Errors {
+		errs = append(errs, strings.ReplaceAll(errText, path.Base(finding.Name), object))
+	}
+	sort.Strings(errs)
+
+	hash := sha256.Sum256([]byte(strings.Join(append([]string{env.ProductName, env.EnvName, object}, errs...), "\n")))
+	return hex.EncodeToString(hash[:8])
+}
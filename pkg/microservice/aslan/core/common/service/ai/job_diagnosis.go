@@ -0,0 +1,137 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	s3service "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/s3"
+	"github.com/koderover/zadig/v2/pkg/tool/llm"
+	s3tool "github.com/koderover/zadig/v2/pkg/tool/s3"
+)
+
+const (
+	failedJobLogTailLines = 200
+
+	failedJobDiagnosisPrompt = `你是一个资深devops开发专家，我会提供一个执行失败的工作流任务的元信息，以及用三重引号分割的该任务的最后 %d 行日志。
+请根据这些信息分析任务失败最可能的原因，并给出具体的修复建议。请直接以 JSON 格式回答，不要输出其他内容，格式如下：
+{"probable_cause": "失败最可能的原因", "suggested_fix": "修复建议"}
+`
+)
+
+// FailedJobInfo is the metadata of the failed job sent to the llm together with its log
+type FailedJobInfo struct {
+	ProjectName  string
+	WorkflowName string
+	TaskID       int64
+	Job          *models.JobTask
+}
+
+// DiagnoseFailedJob sends the tail of the log of the failed job with the job metadata to the llm and returns
+// the diagnosis, the error of the job is used if the job has no log
+func DiagnoseFailedJob(ctx context.Context, info *FailedJobInfo) (*models.JobDiagnosis, error) {
+	client, err := GetFeatureLLMClient(ctx, LLMFeatureJobDiagnosis, info.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	jobLog, err := getJobLogFromS3(info.WorkflowName, info.Job.Name, info.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log of job %s, err: %w", info.Job.Name, err)
+	}
+
+	prompt := fmt.Sprintf(failedJobDiagnosisPrompt, failedJobLogTailLines)
+	prompt += fmt.Sprintf("项目: %s\n工作流: %s\n任务ID: %d\n任务名称: %s\n任务类型: %s\n任务状态: %s\n错误信息: %s\n",
+		info.ProjectName, info.WorkflowName, info.TaskID, info.Job.DisplayName, info.Job.JobType, info.Job.Status, info.Job.Error)
+	prompt += fmt.Sprintf("任务日志: \"\"\"%s\"\"\"", tailLines(jobLog, failedJobLogTailLines))
+
+	options := []llm.ParamOption{llm.WithTemperature(0.3)}
+	if client.GetModel() != "" {
+		options = append(options, llm.WithModel(client.GetModel()))
+	}
+	answer, err := client.GetCompletion(ctx, prompt, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer from %s, err: %w", client.GetName(), err)
+	}
+
+	diagnosis := parseJobDiagnosis(answer)
+	diagnosis.CreateTime = time.Now().Unix()
+	return diagnosis, nil
+}
+
+// parseJobDiagnosis parses the json answer of the llm, the whole answer is taken as the cause if it is not in json
+func parseJobDiagnosis(answer string) *models.JobDiagnosis {
+	resp := &models.JobDiagnosis{}
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start >= 0 && end > start {
+		if err := json.Unmarshal([]byte(answer[start:end+1]), resp); err == nil && resp.ProbableCause != "" {
+			return resp
+		}
+	}
+	return &models.JobDiagnosis{ProbableCause: strings.TrimSpace(answer)}
+}
+
+func tailLines(content string, num int) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > num {
+		lines = lines[len(lines)-num:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// getJobLogFromS3 returns the log of the job saved to the default object storage when the job finished, an empty
+// string is returned if the job has no log
+func getJobLogFromS3(workflowName, jobName string, taskID int64) (string, error) {
+	storage, err := s3service.FindDefaultS3()
+	if err != nil {
+		return "", err
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, storage.Provider)
+	if err != nil {
+		return "", err
+	}
+
+	if storage.Subfolder != "" {
+		storage.Subfolder = fmt.Sprintf("%s/%s/%d/%s", storage.Subfolder, strings.ToLower(workflowName), taskID, "log")
+	} else {
+		storage.Subfolder = fmt.Sprintf("%s/%d/%s", strings.ToLower(workflowName), taskID, "log")
+	}
+	fileName := strings.Replace(strings.ToLower(jobName), "_", "-", -1) + ".log"
+	obj, err := client.GetFile(storage.Bucket, storage.GetObjectPath(fileName), &s3tool.DownloadOption{
+		IgnoreNotExistError: true,
+		RetryNum:            3,
+	})
+	if err != nil {
+		return "", err
+	}
+	if obj == nil {
+		return "", nil
+	}
+	defer obj.Body.Close()
+
+	content, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/llm"
+)
+
+// LLMFeature is the AI feature using the llm, each feature can be served by its own llm integration
+type LLMFeature string
+
+const (
+	LLMFeatureEnvAnalysis      LLMFeature = "env_analysis"
+	LLMFeatureJobDiagnosis     LLMFeature = "job_diagnosis"
+	LLMFeatureBuildLogAnalysis LLMFeature = "build_log_analysis"
+	LLMFeatureStatAnalysis     LLMFeature = "stat_analysis"
+)
+
+// GetFeatureLLMClient returns the client of the llm integration selected for the feature, the default integration is
+// used if no integration is selected. The requests of the client are rate limited and their token usage is recorded
+// for the project, the projectName can be empty for the features not belonging to a project.
+func GetFeatureLLMClient(ctx context.Context, feature LLMFeature, projectName string) (llm.ILLM, error) {
+	llmIntegration, err := commonrepo.NewLLMIntegrationColl().FindByFeature(ctx, string(feature))
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("failed to find the llm integration for feature %s, err: %w", feature, err)
+		}
+		llmIntegration, err = commonrepo.NewLLMIntegrationColl().FindDefault(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find default llm integration, err: %w", err)
+		}
+	}
+
+	client, err := NewLLMClient(llmIntegration)
+	if err != nil {
+		return nil, err
+	}
+	return newMeteredLLMClient(client, llmIntegration, feature, projectName), nil
+}
+
+func GetLLMClient(ctx context.Context, name string) (llm.ILLM, error) {
+	llmIntegration, err := commonrepo.NewLLMIntegrationColl().FindByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the llm integration for %s, err: %w", name, err)
+	}
+
+	return NewLLMClient(llmIntegration)
+}
+
+func GetDefaultLLMClient(ctx context.Context) (llm.ILLM, error) {
+	llmIntegration, err := commonrepo.NewLLMIntegrationColl().FindDefault(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find default llm integration, err: %w", err)
+	}
+
+	return NewLLMClient(llmIntegration)
+}
+
+func NewLLMClient(llmIntegration *models.LLMIntegration) (llm.ILLM, error) {
+	llmConfig := llm.LLMConfig{
+		ProviderName: llmIntegration.ProviderName,
+		Token:        llmIntegration.Token,
+		BaseURL:      llmIntegration.BaseURL,
+		Model:        llmIntegration.Model,
+	}
+	if llmIntegration.EnableProxy {
+		llmConfig.Proxy = config.ProxyHTTPSAddr()
+	}
+
+	llmClient, err := llm.NewClient(llmConfig.ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create the llm client for %s: %w", llmConfig.ProviderName, err)
+	}
+
+	err = llmClient.Configure(llmConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Could not configure the llm client for %s: %w", llmConfig.ProviderName, err)
+	}
+
+	return llmClient, nil
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/juju/ratelimit"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notify"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	"github.com/koderover/zadig/v2/pkg/tool/llm"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const defaultLLMBudgetAlertPercentage = 80
+
+var (
+	llmRateLimitersMu sync.Mutex
+	llmRateLimiters   = map[string]*llmRateLimiter{}
+)
+
+type llmRateLimiter struct {
+	rate   int
+	bucket *ratelimit.Bucket
+}
+
+// meteredLLMClient limits the request rate of the llm integration and records the token usage of each request for
+// the project and the feature
+type meteredLLMClient struct {
+	llm.ILLM
+
+	integration *models.LLMIntegration
+	feature     LLMFeature
+	projectName string
+}
+
+func newMeteredLLMClient(client llm.ILLM, integration *models.LLMIntegration, feature LLMFeature, projectName string) llm.ILLM {
+	return &meteredLLMClient{
+		ILLM:        client,
+		integration: integration,
+		feature:     feature,
+		projectName: projectName,
+	}
+}
+
+func (c *meteredLLMClient) GetCompletion(ctx context.Context, prompt string, options ...llm.ParamOption) (string, error) {
+	if err := c.waitRateLimit(ctx); err != nil {
+		return "", err
+	}
+
+	usage := &llm.Usage{}
+	answer, err := c.ILLM.GetCompletion(ctx, prompt, append(options, llm.WithUsage(usage))...)
+	if err != nil {
+		return "", err
+	}
+
+	// some openai compatible servers do not return the usage, estimate it with the tokenizer of the default model
+	if usage.TotalTokens == 0 {
+		usage.PromptTokens, _ = llm.NumTokensFromPrompt(prompt, "")
+		usage.CompletionTokens, _ = llm.NumTokensFromPrompt(answer, "")
+	}
+	c.recordUsage(usage)
+	return answer, nil
+}
+
+func (c *meteredLLMClient) Parse(ctx context.Context, prompt string, cache cache.ICache, options ...llm.ParamOption) (string, error) {
+	if err := c.waitRateLimit(ctx); err != nil {
+		return "", err
+	}
+
+	// the usage is left empty if the answer is loaded from the cache
+	usage := &llm.Usage{}
+	answer, err := c.ILLM.Parse(ctx, prompt, cache, append(options, llm.WithUsage(usage))...)
+	if err != nil {
+		return "", err
+	}
+
+	if usage.TotalTokens > 0 {
+		c.recordUsage(usage)
+	}
+	return answer, nil
+}
+
+func (c *meteredLLMClient) waitRateLimit(ctx context.Context) error {
+	if c.integration.RateLimit <= 0 {
+		return nil
+	}
+
+	llmRateLimitersMu.Lock()
+	limiter, ok := llmRateLimiters[c.integration.ID.Hex()]
+	if !ok || limiter.rate != c.integration.RateLimit {
+		limiter = &llmRateLimiter{
+			rate:   c.integration.RateLimit,
+			bucket: ratelimit.NewBucketWithRate(float64(c.integration.RateLimit)/60, int64(c.integration.RateLimit)),
+		}
+		llmRateLimiters[c.integration.ID.Hex()] = limiter
+	}
+	llmRateLimitersMu.Unlock()
+
+	wait := limiter.bucket.Take(1)
+	if wait == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for the rate limit of llm integration %s, err: %w", c.integration.Name, ctx.Err())
+	}
+}
+
+func (c *meteredLLMClient) recordUsage(usage *llm.Usage) {
+	record := &models.LLMUsage{
+		ProjectName:      c.projectName,
+		Feature:          string(c.feature),
+		IntegrationID:    c.integration.ID.Hex(),
+		Provider:         string(c.integration.ProviderName),
+		Model:            c.GetModel(),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost: float64(usage.PromptTokens)/1000*c.integration.PromptTokenPrice +
+			float64(usage.CompletionTokens)/1000*c.integration.CompletionTokenPrice,
+	}
+	if err := commonrepo.NewLLMUsageColl().Create(record); err != nil {
+		log.Errorf("failed to record llm usage of project %s, feature %s, err: %v", c.projectName, c.feature, err)
+		return
+	}
+
+	if c.projectName != "" && record.Cost > 0 {
+		go checkLLMBudget(c.projectName)
+	}
+}
+
+// checkLLMBudget notifies the receivers of the project budget when the cost of the current month reaches the alert
+// percentage or the whole budget, each level is alerted once a month
+func checkLLMBudget(projectName string) {
+	budget, err := commonrepo.NewLLMBudgetColl().GetByProject(projectName)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Errorf("failed to find llm budget of project %s, err: %v", projectName, err)
+		}
+		return
+	}
+	if budget.MonthlyBudget <= 0 {
+		return
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	cost, err := commonrepo.NewLLMUsageColl().SumCost(projectName, monthStart.Unix())
+	if err != nil {
+		log.Errorf("failed to sum llm cost of project %s, err: %v", projectName, err)
+		return
+	}
+
+	alertPercentage := budget.AlertPercentage
+	if alertPercentage <= 0 || alertPercentage > 100 {
+		alertPercentage = defaultLLMBudgetAlertPercentage
+	}
+	percent := int(cost * 100 / budget.MonthlyBudget)
+	level := 0
+	if percent >= 100 {
+		level = 100
+	} else if percent >= alertPercentage {
+		level = alertPercentage
+	}
+	if level == 0 {
+		return
+	}
+
+	month := monthStart.Format("2006-01")
+	alert, err := commonrepo.NewLLMBudgetColl().UpdateAlerted(projectName, month, level)
+	if err != nil {
+		log.Errorf("failed to update llm budget alert of project %s, err: %v", projectName, err)
+		return
+	}
+	if !alert {
+		return
+	}
+
+	title := fmt.Sprintf("项目 %s 的 AI 用量已达到月度预算的 %d%%", projectName, percent)
+	content := fmt.Sprintf("月份: %s\n已用费用: %.2f\n月度预算: %.2f", month, cost, budget.MonthlyBudget)
+	for _, receiver := range budget.Receivers {
+		notify.SendMessage(receiver, title, content, "", log.SugaredLogger())
+	}
+}
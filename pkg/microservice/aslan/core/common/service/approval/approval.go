@@ -40,6 +40,11 @@ func approveKey(instanceID string) string {
 	return fmt.Sprintf("native-approve-%s", instanceID)
 }
 
+// GenWorkflowApproveKey returns the key of native approval in workflow job
+func GenWorkflowApproveKey(workflowName, jobName string, taskID int64) string {
+	return fmt.Sprintf("%s-%s-%d", workflowName, jobName, taskID)
+}
+
 func approveLockKey(instanceID string) string {
 	return fmt.Sprintf("native-approve-lock-%s", instanceID)
 }
@@ -73,7 +78,7 @@ func (c *GlobalApproveManager) DeleteApproval(key string) {
 	cache.NewRedisCache(config2.RedisCommonCacheTokenDB()).Delete(approveKey(key))
 }
 
-func (c *GlobalApproveManager) DoApproval(key, userName, userID, comment string, approve bool) (*commonmodels.NativeApproval, error) {
+func (c *GlobalApproveManager) DoApproval(key, userName, userID, comment string, approve bool, channel config.ApprovalChannel) (*commonmodels.NativeApproval, error) {
 	redisMutex := cache.NewRedisLock(approveLockKey(key))
 	redisMutex.Lock()
 	defer redisMutex.Unlock()
@@ -93,6 +98,7 @@ func (c *GlobalApproveManager) DoApproval(key, userName, userID, comment string,
 		}
 		user.Comment = comment
 		user.OperationTime = time.Now().Unix()
+		user.ApproveChannel = channel
 		if approve {
 			user.RejectOrApprove = config.ApprovalStatusApprove
 			meetUser = true
@@ -107,10 +113,33 @@ func (c *GlobalApproveManager) DoApproval(key, userName, userID, comment string,
 		return nil, fmt.Errorf("user %s has no authority to Approve", userName)
 	}
 
+	log.Infof("approval %s is resolved by %s(%s) from %s, approve: %v", key, userName, userID, channel, approve)
 	c.SetApproval(key, approvalData)
 	return approvalData, nil
 }
 
+// FindApproveUser returns the first pending approver matching the given function, it's used to map users of external channels to zadig users
+func (c *GlobalApproveManager) FindApproveUser(key string, match func(user *commonmodels.User) (bool, error)) (*commonmodels.User, error) {
+	approvalData, ok := c.GetApproval(key)
+	if !ok {
+		return nil, fmt.Errorf("not found approval")
+	}
+	for _, user := range approvalData.ApproveUsers {
+		if user.RejectOrApprove != "" {
+			continue
+		}
+		matched, err := match(user)
+		if err != nil {
+			log.Warnf("failed to match approve user %s, err: %s", user.UserName, err)
+			continue
+		}
+		if matched {
+			return user, nil
+		}
+	}
+	return nil, fmt.Errorf("no pending approver matched")
+}
+
 // first return value is whether the approval is approved, second return value is whether the approval is rejected
 func (c *GlobalApproveManager) IsApproval(key string) (bool, bool, *commonmodels.NativeApproval, error) {
 	approval, ok := c.GetApproval(key)
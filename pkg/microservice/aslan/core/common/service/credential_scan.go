@@ -0,0 +1,63 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// CheckCredentials scans the configuration for plaintext credentials according to the system credential scan policy.
+// When the policy blocks on save, an error describing the findings is returned, otherwise the findings are only logged.
+func CheckCredentials(source string, obj interface{}, logger *zap.SugaredLogger) error {
+	systemSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		logger.Warnf("failed to get system settings, skip credential scan, error: %s", err)
+		return nil
+	}
+	policy := systemSetting.CredentialScan
+	if policy == nil || !policy.Enabled {
+		return nil
+	}
+
+	findings, err := util.ScanCredentialsInObject(obj)
+	if err != nil {
+		logger.Errorf("failed to scan credentials in %s, error: %s", source, err)
+		return e.ErrScanCredential.AddErr(err)
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	details := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		details = append(details, fmt.Sprintf("%s(%s): %s", finding.Location, finding.Rule, finding.Masked))
+	}
+	msg := fmt.Sprintf("found %d plaintext credentials in %s: %s", len(findings), source, strings.Join(details, "; "))
+	if !policy.BlockOnSave {
+		logger.Warn(msg)
+		return nil
+	}
+	logger.Error(msg)
+	return e.ErrCredentialDetected.AddDesc(msg)
+}
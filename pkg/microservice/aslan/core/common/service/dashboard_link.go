@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	"github.com/koderover/zadig/v2/pkg/setting"
+)
+
+const (
+	DashboardLinkSourceProject    = "project"
+	DashboardLinkSourceAnnotation = "annotation"
+)
+
+type DashboardLinkResp struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Source is project if the link is rendered from the project templates, or annotation if from the workload annotations
+	Source string `json:"source"`
+}
+
+// ValidateDashboardLinks checks the names of the links are unique and the urls are http urls
+func ValidateDashboardLinks(links []*templatemodels.DashboardLink) error {
+	names := make(map[string]bool)
+	for _, link := range links {
+		link.Name = strings.TrimSpace(link.Name)
+		if link.Name == "" {
+			return fmt.Errorf("name of the dashboard link can not be empty")
+		}
+		if names[link.Name] {
+			return fmt.Errorf("duplicated dashboard link: %s", link.Name)
+		}
+		names[link.Name] = true
+		if err := validateDashboardURL(link.URL); err != nil {
+			return fmt.Errorf("invalid url of dashboard link %s: %w", link.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateDashboardURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("only http and https urls are supported")
+	}
+	return nil
+}
+
+// RenderServiceDashboardLinks renders the dashboard links of the service in the env. The links in the annotations of the
+// workloads with the prefix setting.DashboardLinkAnnotationPrefix override the project links of the same name.
+func RenderServiceDashboardLinks(links []*templatemodels.DashboardLink, env *commonmodels.Product, serviceName string, workloads []*Workload) []*DashboardLinkResp {
+	workloadName := ""
+	if len(workloads) > 0 {
+		workloadName = workloads[0].Name
+	}
+	// the longer variables are replaced first in case one is the prefix of another
+	replacer := strings.NewReplacer(
+		"$namespace", env.Namespace,
+		"$workload", workloadName,
+		"$project", env.ProductName,
+		"$service", serviceName,
+		"$env", env.EnvName,
+	)
+
+	resp := make([]*DashboardLinkResp, 0)
+	index := make(map[string]int)
+	for _, link := range links {
+		index[link.Name] = len(resp)
+		resp = append(resp, &DashboardLinkResp{
+			Name:   link.Name,
+			URL:    replacer.Replace(link.URL),
+			Source: DashboardLinkSourceProject,
+		})
+	}
+
+	for _, workload := range workloads {
+		keys := make([]string, 0)
+		for key := range workload.Annotation {
+			if strings.HasPrefix(key, setting.DashboardLinkAnnotationPrefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			name := strings.TrimPrefix(key, setting.DashboardLinkAnnotationPrefix)
+			link := &DashboardLinkResp{
+				Name:   name,
+				URL:    replacer.Replace(workload.Annotation[key]),
+				Source: DashboardLinkSourceAnnotation,
+			}
+			if name == "" || validateDashboardURL(link.URL) != nil {
+				continue
+			}
+			if i, ok := index[name]; ok {
+				resp[i] = link
+				continue
+			}
+			index[name] = len(resp)
+			resp = append(resp, link)
+		}
+	}
+	return resp
+}
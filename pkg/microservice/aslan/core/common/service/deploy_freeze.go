@@ -0,0 +1,117 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// ListDeployFreezeWindows lists the freeze windows, the finished ones are omitted unless all is true
+func ListDeployFreezeWindows(all bool, logger *zap.SugaredLogger) ([]*commonmodels.DeployFreezeWindow, error) {
+	var endAfter int64
+	if !all {
+		endAfter = time.Now().Unix()
+	}
+	windows, err := commonrepo.NewDeployFreezeWindowColl().List(endAfter)
+	if err != nil {
+		logger.Errorf("list deploy freeze windows error: %v", err)
+		return nil, e.ErrListDeployFreezeWindow.AddErr(err)
+	}
+	return windows, nil
+}
+
+// ListActiveDeployFreezeWindows lists the freeze windows in effect now
+func ListActiveDeployFreezeWindows(logger *zap.SugaredLogger) ([]*commonmodels.DeployFreezeWindow, error) {
+	windows, err := commonrepo.NewDeployFreezeWindowColl().ListActive(time.Now().Unix())
+	if err != nil {
+		logger.Errorf("list active deploy freeze windows error: %v", err)
+		return nil, e.ErrListDeployFreezeWindow.AddErr(err)
+	}
+	return windows, nil
+}
+
+func CreateDeployFreezeWindow(userName string, window *commonmodels.DeployFreezeWindow, logger *zap.SugaredLogger) error {
+	if err := validateDeployFreezeWindow(window); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	window.CreatedBy = userName
+	window.UpdatedBy = userName
+	if err := commonrepo.NewDeployFreezeWindowColl().Create(window); err != nil {
+		logger.Errorf("create deploy freeze window %s error: %v", window.Name, err)
+		return e.ErrCreateDeployFreezeWindow.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateDeployFreezeWindow(id, userName string, window *commonmodels.DeployFreezeWindow, logger *zap.SugaredLogger) error {
+	if err := validateDeployFreezeWindow(window); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	window.UpdatedBy = userName
+	if err := commonrepo.NewDeployFreezeWindowColl().Update(id, window); err != nil {
+		logger.Errorf("update deploy freeze window %s error: %v", id, err)
+		return e.ErrUpdateDeployFreezeWindow.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteDeployFreezeWindow(id string, logger *zap.SugaredLogger) error {
+	if err := commonrepo.NewDeployFreezeWindowColl().Delete(id); err != nil {
+		logger.Errorf("delete deploy freeze window %s error: %v", id, err)
+		return e.ErrDeleteDeployFreezeWindow.AddErr(err)
+	}
+	return nil
+}
+
+func validateDeployFreezeWindow(window *commonmodels.DeployFreezeWindow) error {
+	if strings.TrimSpace(window.Name) == "" {
+		return fmt.Errorf("name can not be empty")
+	}
+	if window.StartTime <= 0 || window.EndTime <= window.StartTime {
+		return fmt.Errorf("end time must be after start time")
+	}
+	return nil
+}
+
+// CheckDeployFreeze rejects the production deployments during the deployment freeze unless an override reason is
+// given. The active freeze window is returned so that the caller can record the override.
+func CheckDeployFreeze(overrideReason string) (*commonmodels.DeployFreezeWindow, error) {
+	windows, err := commonrepo.NewDeployFreezeWindowColl().ListActive(time.Now().Unix())
+	if err != nil {
+		return nil, e.ErrListDeployFreezeWindow.AddErr(err)
+	}
+	if len(windows) == 0 {
+		return nil, nil
+	}
+
+	window := windows[0]
+	if strings.TrimSpace(overrideReason) == "" {
+		return window, e.ErrDeployFrozen.AddDesc(fmt.Sprintf("production deployments are frozen by %s from %s to %s, an override reason is required",
+			window.Name,
+			time.Unix(window.StartTime, 0).Format("2006-01-02 15:04"),
+			time.Unix(window.EndTime, 0).Format("2006-01-02 15:04")))
+	}
+	return window, nil
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dingtalk
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	approvalservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/approval"
+	userclient "github.com/koderover/zadig/v2/pkg/shared/client/user"
+)
+
+const (
+	CardParamApproveKey = "approve_key"
+	CardParamApprove    = "approve"
+)
+
+// handleCardCallback resolves native approval by the buttons of dingtalk interactive card,
+// the card should carry approve_key and approve in its private params,
+// the dingtalk operator is mapped to zadig approver by mobile
+func handleCardCallback(imAppID, data string, log *zap.SugaredLogger) error {
+	staffID := gjson.Get(data, "userId").String()
+	params := gjson.Get(gjson.Get(data, "content").String(), "cardPrivateData.params")
+	approveKey := params.Get(CardParamApproveKey).String()
+	approve := params.Get(CardParamApprove).String() == "true"
+	if staffID == "" || approveKey == "" {
+		log.Infof("card callback without approve key, ignored")
+		return nil
+	}
+
+	client, err := GetDingTalkClientByIMAppID(imAppID)
+	if err != nil {
+		return errors.Wrap(err, "get dingtalk client error")
+	}
+
+	user, err := approvalservice.GlobalApproveMap.FindApproveUser(approveKey, func(user *commonmodels.User) (bool, error) {
+		userInfo, err := userclient.New().GetUserByID(user.UserID)
+		if err != nil {
+			return false, err
+		}
+		if userInfo.Phone == "" {
+			return false, nil
+		}
+		resp, err := client.GetUserIDByMobile(userInfo.Phone)
+		if err != nil {
+			return false, err
+		}
+		return resp.UserID == staffID, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "dingtalk user %s is not approver of %s", staffID, approveKey)
+	}
+
+	_, err = approvalservice.GlobalApproveMap.DoApproval(approveKey, user.UserName, user.UserID, "", approve, config.ApprovalChannelDingTalk)
+	return err
+}
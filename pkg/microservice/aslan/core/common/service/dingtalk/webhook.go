@@ -34,6 +34,7 @@ import (
 const (
 	EventTaskChange     = "bpms_task_change"
 	EventInstanceChange = "bpms_instance_change"
+	EventCardCallback   = "card_callback"
 )
 
 type UserApprovalResult struct {
@@ -153,6 +154,10 @@ func EventHandler(appKey string, body []byte, signature, ts, nonce string) (*Eve
 		SetUserApprovalResult(event.ProcessInstanceID, event.StaffID, event.Result, event.Remark, event.FinishTime)
 		log.Infof("dingtalk event type: %s instanceID: %s userID: %s result: %s remark: %s",
 			eventType, event.ProcessInstanceID, event.StaffID, event.Result, event.Remark)
+	case EventCardCallback:
+		if err := handleCardCallback(info.ID.Hex(), data, log); err != nil {
+			log.Errorf("handle card callback error: %v", err)
+		}
 	}
 
 	msg, err := d.GetEncryptMsg("success")
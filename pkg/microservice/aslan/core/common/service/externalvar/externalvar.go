@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalvar
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/koderover/zadig/v2/pkg/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const (
+	DefaultKey = "cmdb"
+
+	defaultCacheTTL = 300
+)
+
+type cachedValues struct {
+	Values    map[string]interface{} `json:"values"`
+	FetchTime int64                  `json:"fetch_time"`
+}
+
+func cacheKey(env *commonmodels.Product) string {
+	return fmt.Sprintf("external_variable:%s:%s", env.ProductName, env.EnvName)
+}
+
+// Validate checks the external variable config of the env
+func Validate(externalVariable *commonmodels.ExternalVariableConfig) error {
+	if externalVariable == nil || !externalVariable.Enabled {
+		return nil
+	}
+	if externalVariable.CacheTTL < 0 {
+		return fmt.Errorf("cache ttl can not be negative")
+	}
+	if err := yaml.Unmarshal([]byte(externalVariable.FallbackValues), &map[string]interface{}{}); err != nil {
+		return fmt.Errorf("invalid fallback values, err: %w", err)
+	}
+	_, err := newProvider(externalVariable)
+	return err
+}
+
+// Fetch fetches the key/values of the env from the provider without the cache
+func Fetch(env *commonmodels.Product) (map[string]interface{}, error) {
+	if env.ExternalVariable == nil || !env.ExternalVariable.Enabled {
+		return nil, nil
+	}
+	provider, err := newProvider(env.ExternalVariable)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Fetch(env)
+}
+
+// GetVariableYaml returns the external key/values of the env as a variable yaml used in the template context.
+// The values are cached for the ttl, the expired cached values and then the fallback values are used if the fetch fails.
+func GetVariableYaml(env *commonmodels.Product) (string, error) {
+	externalVariable := env.ExternalVariable
+	if externalVariable == nil || !externalVariable.Enabled {
+		return "", nil
+	}
+
+	ttl := externalVariable.CacheTTL
+	if ttl == 0 {
+		ttl = defaultCacheTTL
+	}
+
+	redisCache := cache.NewRedisCache(config.RedisCommonCacheTokenDB())
+	cached := &cachedValues{}
+	cachedStr, err := redisCache.GetString(cacheKey(env))
+	if err != nil || json.Unmarshal([]byte(cachedStr), cached) != nil {
+		cached = nil
+	}
+
+	var values map[string]interface{}
+	if cached != nil && time.Now().Unix()-cached.FetchTime < ttl {
+		values = cached.Values
+	} else {
+		values, err = Fetch(env)
+		if err == nil {
+			bs, _ := json.Marshal(&cachedValues{Values: values, FetchTime: time.Now().Unix()})
+			// the expired values are kept as the fallback of the failed fetches
+			if err := redisCache.Write(cacheKey(env), string(bs), 0); err != nil {
+				log.Warnf("failed to cache external variables of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+			}
+		} else {
+			log.Warnf("failed to fetch external variables of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+			switch {
+			case cached != nil:
+				values = cached.Values
+			case externalVariable.FallbackValues != "":
+				values = make(map[string]interface{})
+				if err := yaml.Unmarshal([]byte(externalVariable.FallbackValues), &values); err != nil {
+					return "", fmt.Errorf("failed to parse fallback external variables, err: %w", err)
+				}
+			default:
+				return "", fmt.Errorf("failed to fetch external variables, err: %w", err)
+			}
+		}
+	}
+
+	key := externalVariable.Key
+	if key == "" {
+		key = DefaultKey
+	}
+	bs, err := yaml.Marshal(map[string]interface{}{key: values})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal external variables, err: %w", err)
+	}
+	return string(bs), nil
+}
+
+// ClearCache removes the cached values of the env so the next render fetches the latest values
+func ClearCache(env *commonmodels.Product) error {
+	return cache.NewRedisCache(config.RedisCommonCacheTokenDB()).Delete(cacheKey(env))
+}
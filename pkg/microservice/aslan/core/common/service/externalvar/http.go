@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalvar
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/tool/httpclient"
+)
+
+const (
+	HTTPAuthTypeNone   = "none"
+	HTTPAuthTypeBasic  = "basic"
+	HTTPAuthTypeBearer = "bearer"
+
+	defaultHTTPTimeout = 10
+)
+
+// httpProvider fetches the key/values with a GET request, the project, env, namespace and cluster of the env
+// are passed as query params and a json object is expected in the response body
+type httpProvider struct {
+	config *commonmodels.ExternalVariableHTTPConfig
+}
+
+func newHTTPProvider(config *commonmodels.ExternalVariableConfig) (Provider, error) {
+	if config.HTTP == nil || config.HTTP.URL == "" {
+		return nil, fmt.Errorf("url of the http provider is not set")
+	}
+	switch config.HTTP.AuthType {
+	case "", HTTPAuthTypeNone, HTTPAuthTypeBasic, HTTPAuthTypeBearer:
+	default:
+		return nil, fmt.Errorf("unsupported auth type: %s", config.HTTP.AuthType)
+	}
+	return &httpProvider{config: config.HTTP}, nil
+}
+
+func (p *httpProvider) Fetch(env *commonmodels.Product) (map[string]interface{}, error) {
+	timeout := p.config.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	clientOptions := []httpclient.ClientFunc{
+		func(c *httpclient.Client) {
+			c.SetTimeout(time.Duration(timeout) * time.Second)
+		},
+	}
+	switch p.config.AuthType {
+	case HTTPAuthTypeBasic:
+		clientOptions = append(clientOptions, httpclient.SetBasicAuth(p.config.Username, p.config.Password))
+	case HTTPAuthTypeBearer:
+		clientOptions = append(clientOptions, httpclient.SetAuthToken(p.config.Token))
+	}
+
+	res, err := httpclient.New(clientOptions...).Get(p.config.URL,
+		httpclient.SetHeaders(p.config.Headers),
+		httpclient.SetQueryParams(map[string]string{
+			"project":    env.ProductName,
+			"env_name":   env.EnvName,
+			"namespace":  env.Namespace,
+			"cluster_id": env.ClusterID,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s, err: %w", p.config.URL, err)
+	}
+
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(res.Body(), &values); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of %s as json object, err: %w", p.config.URL, err)
+	}
+	return values, nil
+}
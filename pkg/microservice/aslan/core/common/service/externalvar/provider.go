@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalvar
+
+import (
+	"fmt"
+	"sync"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+)
+
+const (
+	ProviderHTTP = "http"
+)
+
+// Provider fetches the key/values of the env from the external system
+type Provider interface {
+	Fetch(env *commonmodels.Product) (map[string]interface{}, error)
+}
+
+// ProviderFactory creates the provider from the external variable config of the env
+type ProviderFactory func(config *commonmodels.ExternalVariableConfig) (Provider, error)
+
+var (
+	providerFactories = map[string]ProviderFactory{
+		ProviderHTTP: newHTTPProvider,
+	}
+	providerLock sync.RWMutex
+)
+
+// RegisterProvider registers a provider factory, the existing one with the same name is replaced
+func RegisterProvider(name string, factory ProviderFactory) {
+	providerLock.Lock()
+	defer providerLock.Unlock()
+	providerFactories[name] = factory
+}
+
+func newProvider(config *commonmodels.ExternalVariableConfig) (Provider, error) {
+	providerLock.RLock()
+	factory, ok := providerFactories[config.Provider]
+	providerLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported external variable provider: %s", config.Provider)
+	}
+	return factory(config)
+}
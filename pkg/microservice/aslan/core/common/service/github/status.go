@@ -38,6 +38,8 @@ type StatusOptions struct {
 	Ref         string
 	State       string
 	Description string
+	// Context overrides the default context which is zadig/<display name>
+	Context string
 
 	AslanURL    string
 	PipeName    string
@@ -49,6 +51,9 @@ type StatusOptions struct {
 
 func (c *Client) UpdateCheckStatus(opt *StatusOptions) error {
 	sc := setting.ProductName + "/" + opt.DisplayName
+	if opt.Context != "" {
+		sc = opt.Context
+	}
 	_, err := c.CreateStatus(
 		context.TODO(), opt.Owner, opt.Repo, opt.Ref,
 		&github.RepoStatus{
@@ -18,12 +18,14 @@ package helm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"path"
 	"strings"
 	"time"
 
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/yaml"
@@ -42,7 +44,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/tool/crypto"
 	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
-	"github.com/koderover/zadig/v2/pkg/tool/mongo"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
 	"github.com/koderover/zadig/v2/pkg/util/converter"
 	"github.com/pkg/errors"
 )
@@ -118,224 +120,209 @@ func CopyAndUploadService(projectName, serviceName, currentChartPath string, cop
 
 // Update Service and ServiceDeployStrategy for a single service in environment
 func UpdateServiceInEnv(product *commonmodels.Product, productSvc *commonmodels.ProductService, user string) error {
-	session := mongo.Session()
-	defer session.EndSession(context.TODO())
-
-	err := mongo.StartTransaction(session)
-	if err != nil {
-		return err
-	}
-
-	product.LintServices()
-	err = commonutil.CreateEnvServiceVersion(product, productSvc, user, session, log.SugaredLogger())
-	if err != nil {
-		log.Errorf("failed to create helm service version, err: %v", err)
-	}
-
 	envLock := cache.NewRedisLock(fmt.Sprintf("%s:%s:%s", UpdateHelmEnvLockKey, product.ProductName, product.EnvName))
 	envLock.Lock()
 	defer envLock.Unlock()
 
-	productColl := commonrepo.NewProductCollWithSession(session)
-	newProductInfo, err := productColl.Find(&commonrepo.ProductFindOptions{Name: product.ProductName, EnvName: product.EnvName})
-	if err != nil {
-		mongo.AbortTransaction(session)
-		return errors.Wrapf(err, "failed to find product %s", product.ProductName)
-	}
+	return mongotool.WithTransaction(context.TODO(), "UpdateServiceInEnv", func(session mongo.Session) error {
+		product.LintServices()
+		err := commonutil.CreateEnvServiceVersion(product, productSvc, user, session, log.SugaredLogger())
+		if err != nil {
+			log.Errorf("failed to create helm service version, err: %v", err)
+		}
 
-	newProductInfo.LintServices()
-	productSvcMap := newProductInfo.GetServiceMap()
-	productChartSvcMap := newProductInfo.GetChartServiceMap()
-	if productSvc.FromZadig() {
-		productSvcMap[productSvc.ServiceName] = productSvc
-		productSvcMap[productSvc.ServiceName].UpdateTime = time.Now().Unix()
-		delete(productChartSvcMap, productSvc.ReleaseName)
-	} else {
-		productChartSvcMap[productSvc.ReleaseName] = productSvc
-		productChartSvcMap[productSvc.ReleaseName].UpdateTime = time.Now().Unix()
-		for _, svc := range productSvcMap {
-			if svc.ReleaseName == productSvc.ReleaseName {
-				delete(productSvcMap, svc.ServiceName)
-				break
+		productColl := commonrepo.NewProductCollWithSession(session)
+		newProductInfo, err := productColl.Find(&commonrepo.ProductFindOptions{Name: product.ProductName, EnvName: product.EnvName})
+		if err != nil {
+			return errors.Wrapf(err, "failed to find product %s", product.ProductName)
+		}
+
+		newProductInfo.LintServices()
+		productSvcMap := newProductInfo.GetServiceMap()
+		productChartSvcMap := newProductInfo.GetChartServiceMap()
+		if productSvc.FromZadig() {
+			productSvcMap[productSvc.ServiceName] = productSvc
+			productSvcMap[productSvc.ServiceName].UpdateTime = time.Now().Unix()
+			delete(productChartSvcMap, productSvc.ReleaseName)
+		} else {
+			productChartSvcMap[productSvc.ReleaseName] = productSvc
+			productChartSvcMap[productSvc.ReleaseName].UpdateTime = time.Now().Unix()
+			for _, svc := range productSvcMap {
+				if svc.ReleaseName == productSvc.ReleaseName {
+					delete(productSvcMap, svc.ServiceName)
+					break
+				}
 			}
 		}
-	}
 
-	templateProduct, err := template.NewProductCollWithSess(session).Find(product.ProductName)
-	if err != nil {
-		mongo.AbortTransaction(session)
-		return errors.Wrapf(err, "failed to find template product %s", product.ProductName)
-	}
+		templateProduct, err := template.NewProductCollWithSess(session).Find(product.ProductName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find template product %s", product.ProductName)
+		}
 
-	newProductInfo.Services = [][]*commonmodels.ProductService{}
-	serviceOrchestration := templateProduct.Services
-	if product.Production {
-		serviceOrchestration = templateProduct.ProductionServices
-	}
+		newProductInfo.Services = [][]*commonmodels.ProductService{}
+		serviceOrchestration := templateProduct.Services
+		if product.Production {
+			serviceOrchestration = templateProduct.ProductionServices
+		}
 
-	for i, svcGroup := range serviceOrchestration {
-		// init slice
-		if len(newProductInfo.Services) >= i {
+		for i, svcGroup := range serviceOrchestration {
+			// init slice
+			if len(newProductInfo.Services) >= i {
+				newProductInfo.Services = append(newProductInfo.Services, []*commonmodels.ProductService{})
+			}
+
+			// set services in order
+			for _, svc := range svcGroup {
+				// if svc exists in productSvcMap
+				if productSvcMap[svc] != nil {
+					newProductInfo.Services[i] = append(newProductInfo.Services[i], productSvcMap[svc])
+					delete(productSvcMap, svc)
+				}
+			}
+		}
+		if len(newProductInfo.Services) == 0 {
 			newProductInfo.Services = append(newProductInfo.Services, []*commonmodels.ProductService{})
 		}
-
-		// set services in order
-		for _, svc := range svcGroup {
-			// if svc exists in productSvcMap
-			if productSvcMap[svc] != nil {
-				newProductInfo.Services[i] = append(newProductInfo.Services[i], productSvcMap[svc])
+		// append services shared from other projects to the last group, they are not in the service orchestration
+		for _, service := range productSvcMap {
+			if service.ProductName != "" && service.ProductName != product.ProductName {
+				newProductInfo.Services[len(newProductInfo.Services)-1] = append(newProductInfo.Services[len(newProductInfo.Services)-1], service)
 			}
 		}
-	}
-	// append chart services to the last group
-	for _, service := range productChartSvcMap {
-		newProductInfo.Services[len(newProductInfo.Services)-1] = append(newProductInfo.Services[len(newProductInfo.Services)-1], service)
-	}
-
-	if productSvc.DeployStrategy == setting.ServiceDeployStrategyDeploy {
-		if productSvc.FromZadig() {
-			newProductInfo.ServiceDeployStrategy = commonutil.SetServiceDeployStrategyDepoly(newProductInfo.ServiceDeployStrategy, productSvc.ServiceName)
-		} else {
-			newProductInfo.ServiceDeployStrategy = commonutil.SetChartServiceDeployStrategyDepoly(newProductInfo.ServiceDeployStrategy, productSvc.ReleaseName)
+		// append chart services to the last group
+		for _, service := range productChartSvcMap {
+			newProductInfo.Services[len(newProductInfo.Services)-1] = append(newProductInfo.Services[len(newProductInfo.Services)-1], service)
 		}
-	} else if productSvc.DeployStrategy == setting.ServiceDeployStrategyImport {
-		if productSvc.FromZadig() {
-			newProductInfo.ServiceDeployStrategy = commonutil.SetServiceDeployStrategyImport(newProductInfo.ServiceDeployStrategy, productSvc.ServiceName)
-		} else {
-			newProductInfo.ServiceDeployStrategy = commonutil.SetChartServiceDeployStrategyImport(newProductInfo.ServiceDeployStrategy, productSvc.ReleaseName)
+
+		if productSvc.DeployStrategy == setting.ServiceDeployStrategyDeploy {
+			if productSvc.FromZadig() {
+				newProductInfo.ServiceDeployStrategy = commonutil.SetServiceDeployStrategyDepoly(newProductInfo.ServiceDeployStrategy, productSvc.ServiceName)
+			} else {
+				newProductInfo.ServiceDeployStrategy = commonutil.SetChartServiceDeployStrategyDepoly(newProductInfo.ServiceDeployStrategy, productSvc.ReleaseName)
+			}
+		} else if productSvc.DeployStrategy == setting.ServiceDeployStrategyImport {
+			if productSvc.FromZadig() {
+				newProductInfo.ServiceDeployStrategy = commonutil.SetServiceDeployStrategyImport(newProductInfo.ServiceDeployStrategy, productSvc.ServiceName)
+			} else {
+				newProductInfo.ServiceDeployStrategy = commonutil.SetChartServiceDeployStrategyImport(newProductInfo.ServiceDeployStrategy, productSvc.ReleaseName)
+			}
 		}
-	}
 
-	if err = productColl.Update(newProductInfo); err != nil {
-		log.Errorf("update product %s error: %s", newProductInfo.ProductName, err.Error())
-		mongo.AbortTransaction(session)
-		return fmt.Errorf("failed to update product info, name %s", newProductInfo.ProductName)
-	}
+		if err = productColl.Update(newProductInfo); err != nil {
+			log.Errorf("update product %s error: %s", newProductInfo.ProductName, err.Error())
+			return fmt.Errorf("failed to update product info, name %s, err: %w", newProductInfo.ProductName, err)
+		}
 
-	return mongo.CommitTransaction(session)
+		return nil
+	})
 }
 
 // Update all services in environment
 func UpdateAllServicesInEnv(productName, envName string, services [][]*models.ProductService, production bool) error {
-	session := mongo.Session()
-	defer session.EndSession(context.TODO())
-
-	err := mongo.StartTransaction(session)
-	if err != nil {
-		return err
-	}
-
-	productColl := commonrepo.NewProductCollWithSession(session)
-
 	envLock := cache.NewRedisLock(fmt.Sprintf("%s:%s:%s", UpdateHelmEnvLockKey, productName, envName))
 	envLock.Lock()
 	defer envLock.Unlock()
 
-	templateProduct, err := template.NewProductCollWithSess(session).Find(productName)
-	if err != nil {
-		mongo.AbortTransaction(session)
-		return errors.Wrapf(err, "failed to find template product %s", productName)
-	}
+	return mongotool.WithTransaction(context.TODO(), "UpdateAllServicesInEnv", func(session mongo.Session) error {
+		productColl := commonrepo.NewProductCollWithSession(session)
 
-	serviceOrchestration := templateProduct.Services
-	if production {
-		serviceOrchestration = templateProduct.ProductionServices
-	}
+		templateProduct, err := template.NewProductCollWithSess(session).Find(productName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find template product %s", productName)
+		}
 
-	dummyEnv := &commonmodels.Product{
-		Services: services,
-	}
-	dummyEnv.LintServices()
-	productSvcMap := dummyEnv.GetServiceMap()
-	productChartSvcMap := dummyEnv.GetChartServiceMap()
-
-	newServices := [][]*commonmodels.ProductService{}
-	for i, svcGroup := range serviceOrchestration {
-		// init slice
-		if len(newServices) >= i {
-			newServices = append(newServices, []*commonmodels.ProductService{})
-		}
-
-		// set services in order
-		for _, svc := range svcGroup {
-			// if svc exists in productSvcMap
-			if productSvcMap[svc] != nil {
-				newServices[i] = append(newServices[i], productSvcMap[svc])
+		serviceOrchestration := templateProduct.Services
+		if production {
+			serviceOrchestration = templateProduct.ProductionServices
+		}
+
+		dummyEnv := &commonmodels.Product{
+			Services: services,
+		}
+		dummyEnv.LintServices()
+		productSvcMap := dummyEnv.GetServiceMap()
+		productChartSvcMap := dummyEnv.GetChartServiceMap()
+
+		newServices := [][]*commonmodels.ProductService{}
+		for i, svcGroup := range serviceOrchestration {
+			// init slice
+			if len(newServices) >= i {
+				newServices = append(newServices, []*commonmodels.ProductService{})
+			}
+
+			// set services in order
+			for _, svc := range svcGroup {
+				// if svc exists in productSvcMap
+				if productSvcMap[svc] != nil {
+					newServices[i] = append(newServices[i], productSvcMap[svc])
+				}
 			}
 		}
-	}
-	// append chart services to the last group
-	for _, service := range productChartSvcMap {
-		newServices[len(newServices)-1] = append(newServices[len(newServices)-1], service)
-	}
+		// append chart services to the last group
+		for _, service := range productChartSvcMap {
+			newServices[len(newServices)-1] = append(newServices[len(newServices)-1], service)
+		}
 
-	if err = productColl.UpdateAllServices(productName, envName, newServices); err != nil {
-		err = fmt.Errorf("failed to update %s/%s product services, err %s", productName, envName, err)
-		mongo.AbortTransaction(session)
-		log.Error(err)
-		return err
-	}
+		if err = productColl.UpdateAllServices(productName, envName, newServices); err != nil {
+			err = fmt.Errorf("failed to update %s/%s product services, err %w", productName, envName, err)
+			log.Error(err)
+			return err
+		}
 
-	return mongo.CommitTransaction(session)
+		return nil
+	})
 }
 
 // Update a services group in environment
 func UpdateServicesGroupInEnv(productName, envName string, index int, group []*models.ProductService, production bool) error {
-	session := mongo.Session()
-	defer session.EndSession(context.TODO())
-
-	err := mongo.StartTransaction(session)
-	if err != nil {
-		return err
-	}
-
-	productColl := commonrepo.NewProductCollWithSession(session)
-
 	envLock := cache.NewRedisLock(fmt.Sprintf("%s:%s:%s", UpdateHelmEnvLockKey, productName, envName))
 	envLock.Lock()
 	defer envLock.Unlock()
 
-	templateProduct, err := template.NewProductCollWithSess(session).Find(productName)
-	if err != nil {
-		mongo.AbortTransaction(session)
-		return errors.Wrapf(err, "failed to find template product %s", productName)
-	}
+	return mongotool.WithTransaction(context.TODO(), "UpdateServicesGroupInEnv", func(session mongo.Session) error {
+		productColl := commonrepo.NewProductCollWithSession(session)
 
-	serviceOrchestration := templateProduct.Services
-	if production {
-		serviceOrchestration = templateProduct.ProductionServices
-	}
+		templateProduct, err := template.NewProductCollWithSess(session).Find(productName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find template product %s", productName)
+		}
 
-	dummyEnv := &commonmodels.Product{
-		Services: [][]*commonmodels.ProductService{group},
-	}
-	dummyEnv.LintServices()
-	productSvcMap := dummyEnv.GetServiceMap()
-	productChartSvcMap := dummyEnv.GetChartServiceMap()
-
-	newGroup := []*commonmodels.ProductService{}
-	for _, svcGroup := range serviceOrchestration {
-		// set services in order
-		for _, svc := range svcGroup {
-			// if svc exists in productSvcMap
-			if productSvcMap[svc] != nil {
-				newGroup = append(newGroup, productSvcMap[svc])
+		serviceOrchestration := templateProduct.Services
+		if production {
+			serviceOrchestration = templateProduct.ProductionServices
+		}
+
+		dummyEnv := &commonmodels.Product{
+			Services: [][]*commonmodels.ProductService{group},
+		}
+		dummyEnv.LintServices()
+		productSvcMap := dummyEnv.GetServiceMap()
+		productChartSvcMap := dummyEnv.GetChartServiceMap()
+
+		newGroup := []*commonmodels.ProductService{}
+		for _, svcGroup := range serviceOrchestration {
+			// set services in order
+			for _, svc := range svcGroup {
+				// if svc exists in productSvcMap
+				if productSvcMap[svc] != nil {
+					newGroup = append(newGroup, productSvcMap[svc])
+				}
 			}
 		}
-	}
-	// append chart services to the last group
-	for _, service := range productChartSvcMap {
-		newGroup = append(newGroup, service)
-	}
+		// append chart services to the last group
+		for _, service := range productChartSvcMap {
+			newGroup = append(newGroup, service)
+		}
 
-	if err = productColl.UpdateServicesGroup(productName, envName, index, newGroup); err != nil {
-		err = fmt.Errorf("failed to update %s/%s product services, err %s", productName, envName, err)
-		mongo.AbortTransaction(session)
-		log.Error(err)
-		return err
-	}
+		if err = productColl.UpdateServicesGroup(productName, envName, index, newGroup); err != nil {
+			err = fmt.Errorf("failed to update %s/%s product services, err %w", productName, envName, err)
+			log.Error(err)
+			return err
+		}
 
-	return mongo.CommitTransaction(session)
+		return nil
+	})
 }
 
 type HelmDeployService struct {
@@ -432,8 +419,8 @@ func (s *HelmDeployService) GenMergedValues(productSvc *commonmodels.ProductServ
 	}
 	productSvc.GetServiceRender().SetOverrideYaml(replacedEnvValuesYaml)
 
-	// 3. merge override values and kvs into values yaml
-	finalValuesYaml, err := helmtool.MergeOverrideValues("", defaultValues, replacedEnvValuesYaml, overrideKVs, nil)
+	// 3. merge project default values, env default values, override values and kvs into values yaml
+	finalValuesYaml, err := helmtool.MergeOverrideValues(getProjectHelmDefaultValues(productSvc.ProductName), defaultValues, replacedEnvValuesYaml, overrideKVs, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to merge override values, err: %s", err)
 	}
@@ -471,6 +458,42 @@ func (s *HelmDeployService) GenMergedValues(productSvc *commonmodels.ProductServ
 	return finalValuesYaml, nil
 }
 
+// MergeOverrideKVs sets the keys into the json-encoded override kvs of the env service, the existing keys are overwritten
+func (s *HelmDeployService) MergeOverrideKVs(overrideKVs string, kvs []*helmtool.KV) (string, error) {
+	if len(kvs) == 0 {
+		return overrideKVs, nil
+	}
+
+	merged := make([]*helmtool.KV, 0)
+	if overrideKVs != "" {
+		if err := json.Unmarshal([]byte(overrideKVs), &merged); err != nil {
+			return "", fmt.Errorf("failed to unmarshal override kvs, err: %s", err)
+		}
+	}
+
+	indexes := make(map[string]int)
+	for i, kv := range merged {
+		indexes[kv.Key] = i
+	}
+	for _, kv := range kvs {
+		if kv.Key == "" {
+			return "", fmt.Errorf("the key of values can not be empty")
+		}
+		if i, ok := indexes[kv.Key]; ok {
+			merged[i].Value = kv.Value
+			continue
+		}
+		indexes[kv.Key] = len(merged)
+		merged = append(merged, kv)
+	}
+
+	bs, err := json.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal override kvs, err: %s", err)
+	}
+	return string(bs), nil
+}
+
 func (s *HelmDeployService) GeneFullValues(serviceValuesYaml, envValuesYaml string) (string, error) {
 	finalValuesYaml, err := helmtool.MergeOverrideValues(serviceValuesYaml, "", envValuesYaml, "", nil)
 	if err != nil {
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"fmt"
+	"sort"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+// values layers of helm services, ordered by precedence from low to high
+const (
+	ValuesLayerChartDefaults   = "chart_defaults"
+	ValuesLayerProjectDefaults = "project_defaults"
+	ValuesLayerEnvDefaults     = "env_defaults"
+	ValuesLayerServiceOverride = "service_override"
+	ValuesLayerOverrideValues  = "override_values"
+	ValuesLayerOneOffOverride  = "one_off_override"
+)
+
+type ValuesLayer struct {
+	Name   string `json:"name"`
+	Values string `json:"values"`
+}
+
+// ValuesKeySource records the value of a flattened key in the final values and the layer it comes from
+type ValuesKeySource struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	Layer string      `json:"layer"`
+	// OverriddenLayers are the lower layers which also set the key
+	OverriddenLayers []string `json:"overridden_layers,omitempty"`
+}
+
+func getProjectHelmDefaultValues(projectName string) string {
+	if projectName == "" {
+		return ""
+	}
+	project, err := template.NewProductColl().Find(projectName)
+	if err != nil {
+		log.Warnf("failed to find project %s for helm default values, err: %s", projectName, err)
+		return ""
+	}
+	return project.HelmDefaultValues
+}
+
+// GenValuesLayers returns the values layers of the helm service in the env ordered by precedence from low to high,
+// the one-off override values only take effect in a single deployment and are not stored in the env
+func GenValuesLayers(env *commonmodels.Product, prodSvc *commonmodels.ProductService, chartValues, oneOffOverride string) []*ValuesLayer {
+	render := prodSvc.GetServiceRender()
+	return []*ValuesLayer{
+		{Name: ValuesLayerChartDefaults, Values: chartValues},
+		{Name: ValuesLayerProjectDefaults, Values: getProjectHelmDefaultValues(env.ProductName)},
+		{Name: ValuesLayerEnvDefaults, Values: env.DefaultValues},
+		{Name: ValuesLayerServiceOverride, Values: render.GetOverrideYaml()},
+		{Name: ValuesLayerOverrideValues, Values: render.OverrideValues},
+		{Name: ValuesLayerOneOffOverride, Values: oneOffOverride},
+	}
+}
+
+// ExplainValuesLayers resolves the layer each flattened key of the final values comes from
+func ExplainValuesLayers(layers []*ValuesLayer) ([]*ValuesKeySource, error) {
+	sources := make(map[string]*ValuesKeySource)
+	keys := make([]string, 0)
+	for _, layer := range layers {
+		flatValues, err := flattenValuesLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse values of layer %s, err: %w", layer.Name, err)
+		}
+		for key, value := range flatValues {
+			source, ok := sources[key]
+			if !ok {
+				source = &ValuesKeySource{Key: key}
+				sources[key] = source
+				keys = append(keys, key)
+			} else {
+				source.OverriddenLayers = append(source.OverriddenLayers, source.Layer)
+			}
+			source.Value = value
+			source.Layer = layer.Name
+		}
+	}
+
+	sort.Strings(keys)
+	ret := make([]*ValuesKeySource, 0, len(keys))
+	for _, key := range keys {
+		ret = append(ret, sources[key])
+	}
+	return ret, nil
+}
+
+func flattenValuesLayer(layer *ValuesLayer) (map[string]interface{}, error) {
+	valuesYaml := layer.Values
+	// override values are stored as kv list in json format
+	if layer.Name == ValuesLayerOverrideValues && valuesYaml != "" {
+		var err error
+		valuesYaml, err = helmtool.MergeOverrideValues("", "", "", valuesYaml, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return flattenValuesYaml(valuesYaml)
+}
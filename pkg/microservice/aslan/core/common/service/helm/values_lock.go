@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
+	"github.com/koderover/zadig/v2/pkg/util/converter"
+)
+
+// CheckLockedValuesKeys rejects the change of the service values if any locked key of the project is changed,
+// curValues and newValues are the values yaml set to the service in the env
+func CheckLockedValuesKeys(projectName, serviceName string, production bool, curValues, newValues string) error {
+	project, err := template.NewProductColl().Find(projectName)
+	if err != nil {
+		return fmt.Errorf("failed to find project %s, err: %w", projectName, err)
+	}
+
+	lockedKeys := make([]string, 0)
+	for _, lockedKey := range project.HelmLockedValuesKeys {
+		if lockedKey.ProductionOnly && !production {
+			continue
+		}
+		lockedKeys = append(lockedKeys, lockedKey.Key)
+	}
+	if len(lockedKeys) == 0 {
+		return nil
+	}
+
+	changedKeys, err := getChangedLockedKeys(lockedKeys, curValues, newValues)
+	if err != nil {
+		return err
+	}
+	if len(changedKeys) > 0 {
+		return e.ErrForbidden.AddDesc(fmt.Sprintf("locked values keys of service %s can not be changed: %s", serviceName, strings.Join(changedKeys, ", ")))
+	}
+	return nil
+}
+
+// MergeServiceOverrideValues merges the override yaml and the override kvs of the service into a values yaml
+func MergeServiceOverrideValues(overrideYaml, overrideKVs string) (string, error) {
+	return helmtool.MergeOverrideValues("", "", overrideYaml, overrideKVs, nil)
+}
+
+func getChangedLockedKeys(lockedKeys []string, curValues, newValues string) ([]string, error) {
+	curFlatValues, err := flattenValuesYaml(curValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current values, err: %w", err)
+	}
+	newFlatValues, err := flattenValuesYaml(newValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new values, err: %w", err)
+	}
+
+	changedKeys := make(map[string]struct{})
+	checkKey := func(key string) {
+		for _, lockedKey := range lockedKeys {
+			if !isKeyLocked(lockedKey, key) {
+				continue
+			}
+			curValue, curOK := curFlatValues[key]
+			newValue, newOK := newFlatValues[key]
+			if curOK != newOK || fmt.Sprintf("%v", curValue) != fmt.Sprintf("%v", newValue) {
+				changedKeys[lockedKey] = struct{}{}
+			}
+		}
+	}
+	for key := range curFlatValues {
+		checkKey(key)
+	}
+	for key := range newFlatValues {
+		checkKey(key)
+	}
+
+	ret := make([]string, 0, len(changedKeys))
+	for key := range changedKeys {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+func isKeyLocked(lockedKey, key string) bool {
+	return key == lockedKey || strings.HasPrefix(key, lockedKey+".") || strings.HasPrefix(key, lockedKey+"[")
+}
+
+func flattenValuesYaml(valuesYaml string) (map[string]interface{}, error) {
+	valuesMap := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(valuesYaml), &valuesMap); err != nil {
+		return nil, err
+	}
+	return converter.Flatten(valuesMap)
+}
@@ -43,6 +43,11 @@ const (
 	LarkReceiverTypeEmail = "email"
 )
 
+const (
+	LarkCardValueApproveKey = "approve_key"
+	LarkCardValueApprove    = "approve"
+)
+
 const (
 	LarkMessageTypeCard = "interactive"
 	LarkMessageTypeText = "text"
@@ -84,10 +89,11 @@ type Field struct {
 }
 
 type Action struct {
-	Tag  string   `json:"tag"`
-	Text TextElem `json:"text"`
-	Type string   `json:"type"`
-	URL  string   `json:"url"`
+	Tag   string            `json:"tag"`
+	Text  TextElem          `json:"text"`
+	Type  string            `json:"type"`
+	URL   string            `json:"url,omitempty"`
+	Value map[string]string `json:"value,omitempty"`
 }
 
 type ZhCn struct {
@@ -188,6 +194,33 @@ func (lc *LarkCard) AddI18NElementsZhcnAction(content, url string) {
 	lc.I18NElements.ZhCn = append(lc.I18NElements.ZhCn, zhcnElem)
 }
 
+// AddI18NElementsZhcnApproveActions adds approve and reject buttons of native approval, the click is sent to the
+// card callback of lark app and resolved by the approve key
+func (lc *LarkCard) AddI18NElementsZhcnApproveActions(jobName, approveKey string) {
+	if lc.I18NElements == nil {
+		lc.I18NElements = &I18NElements{
+			ZhCn: make([]*ZhCn, 0),
+		}
+	}
+	approve := &Action{
+		Tag:   feishuTagButton,
+		Text:  TextElem{Content: "通过 " + jobName, Tag: feiShuTagText},
+		Type:  "primary",
+		Value: map[string]string{LarkCardValueApproveKey: approveKey, LarkCardValueApprove: "true"},
+	}
+	reject := &Action{
+		Tag:   feishuTagButton,
+		Text:  TextElem{Content: "拒绝 " + jobName, Tag: feiShuTagText},
+		Type:  "danger",
+		Value: map[string]string{LarkCardValueApproveKey: approveKey, LarkCardValueApprove: "false"},
+	}
+	zhcnElem := &ZhCn{
+		Actions: []*Action{approve, reject},
+		Tag:     feishuTagAction,
+	}
+	lc.I18NElements.ZhCn = append(lc.I18NElements.ZhCn, zhcnElem)
+}
+
 func (w *Service) sendFeishuMessage(uri string, lcMsg *LarkCard) error {
 	message := LarkCardReq{
 		MsgType: feishuCardType,
@@ -234,3 +267,17 @@ func getColorTemplateWithStatus(status config.Status) string {
 	}
 	return feishuHeaderTemplateRed
 }
+
+// NewLarkStatusCard builds a card colored by the status, each content is a markdown field and a button is added if url is not empty
+func NewLarkStatusCard(status config.Status, title string, contents []string, actionText, url string) *LarkCard {
+	lc := NewLarkCard()
+	lc.SetConfig(true)
+	lc.SetHeader(getColorTemplateWithStatus(status), title, feiShuTagText)
+	for i, content := range contents {
+		lc.AddI18NElementsZhcnFeild(content, i == 0)
+	}
+	if url != "" {
+		lc.AddI18NElementsZhcnAction(actionText, url)
+	}
+	return lc
+}
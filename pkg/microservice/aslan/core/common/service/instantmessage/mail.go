@@ -20,6 +20,7 @@ import (
 	_ "embed"
 
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notifypref"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
 	"github.com/koderover/zadig/v2/pkg/shared/client/systemconfig"
 	"github.com/koderover/zadig/v2/pkg/shared/client/user"
@@ -27,7 +28,8 @@ import (
 	"github.com/koderover/zadig/v2/pkg/tool/mail"
 )
 
-func (w *Service) sendMailMessage(title, content string, users []*models.User) error {
+// sendMailMessage sends the mail of the workflow notification, the users muted it by the notification preference are skipped
+func (w *Service) sendMailMessage(title, content, projectName string, users []*models.User) error {
 	if len(users) == 0 {
 		return nil
 	}
@@ -48,6 +50,9 @@ func (w *Service) sendMailMessage(title, content string, users []*models.User) e
 			}
 		}
 
+		if !notifypref.AllowedByUserID(u.UserID, models.NotificationChannelMail, models.NotificationEventWorkflow, projectName) {
+			continue
+		}
 		if info.Email == "" {
 			log.Warnf("sendMailMessage user %s email is empty", info.Name)
 			continue
@@ -36,6 +36,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	approvalservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/approval"
 	larkservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/lark"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/webhooknotify"
 	"github.com/koderover/zadig/v2/pkg/setting"
@@ -381,6 +382,21 @@ func (w *Service) getApproveNotificationContent(notify *models.NotifyCtl, task *
 	}
 	workflowDetailURL, _ = getWorkflowTaskTplExec(workflowDetailURL, workflowNotification)
 	lc.AddI18NElementsZhcnAction(buttonContent, workflowDetailURL)
+	// only messages sent by lark app can call back to zadig when the buttons are clicked
+	if notify.WebHookType == setting.NotifyWebhookTypeFeishuApp || notify.WebHookType == setting.NotifyWebHookTypeFeishuPerson {
+		for _, stage := range task.Stages {
+			for _, job := range stage.Jobs {
+				if job.JobType != string(config.JobApproval) || job.Status != config.StatusWaitingApprove {
+					continue
+				}
+				spec := &models.JobTaskApprovalSpec{}
+				if err := models.IToi(job.Spec, spec); err != nil || spec.Type != config.NativeApproval {
+					continue
+				}
+				lc.AddI18NElementsZhcnApproveActions(job.DisplayName, approvalservice.GenWorkflowApproveKey(task.WorkflowName, job.Name, task.TaskID))
+			}
+		}
+	}
 	return "", "", lc, nil, nil
 }
 
@@ -616,6 +632,14 @@ func (w *Service) getNotificationContent(notify *models.NotifyCtl, task *models.
 				}
 				workflowNotifyJob.Spec = workflowNotifyJobTaskSpec
 			}
+			if job.Diagnosis != nil {
+				jobTplcontent += "{{if eq .WebHookType \"dingding\"}}##### {{end}}**AI 诊断**：{{.Job.Diagnosis.ProbableCause}}  \n"
+				mailJobTplcontent += "AI 诊断：{{.Job.Diagnosis.ProbableCause}} \n"
+				if job.Diagnosis.SuggestedFix != "" {
+					jobTplcontent += "{{if eq .WebHookType \"dingding\"}}##### {{end}}**修复建议**：{{.Job.Diagnosis.SuggestedFix}}  \n"
+					mailJobTplcontent += "修复建议：{{.Job.Diagnosis.SuggestedFix}} \n"
+				}
+			}
 			jobNotifaication := &jobTaskNotification{
 				Job:         job,
 				WebHookType: notify.WebHookType,
@@ -939,7 +963,11 @@ func (w *Service) sendNotification(title, content string, notify *models.NotifyC
 			return err
 		}
 	case setting.NotifyWebHookTypeMail:
-		if err := w.sendMailMessage(title, content, notify.MailNotificationConfig.TargetUsers); err != nil {
+		projectName := ""
+		if webhookNotify != nil {
+			projectName = webhookNotify.ProjectName
+		}
+		if err := w.sendMailMessage(title, content, projectName, notify.MailNotificationConfig.TargetUsers); err != nil {
 			return err
 		}
 	case setting.NotifyWebHookTypeWebook:
@@ -502,6 +502,11 @@ func CreateOrPatchResource(applyParam *ResourceApplyParam, log *zap.SugaredLogge
 				continue
 			}
 
+			// the certificates are optional, failing to request them does not fail the deployment
+			if certErr := EnsureIngressCertificates(productInfo.ClusterID, namespace, u, labels, kubeClient); certErr != nil {
+				log.Warnf("Failed to request certificates of ingress %s: %v", u.GetName(), certErr)
+			}
+
 		case setting.Service:
 			u.SetNamespace(namespace)
 			u.SetLabels(MergeLabels(labels, u.GetLabels()))
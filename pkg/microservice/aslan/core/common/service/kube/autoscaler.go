@@ -0,0 +1,168 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+)
+
+const (
+	AutoscalerTypeHPA  = "hpa"
+	AutoscalerTypeKEDA = "keda"
+
+	// kedaHPANamePrefix is the prefix of the HPA created by KEDA for the ScaledObject
+	kedaHPANamePrefix = "keda-hpa-"
+)
+
+// AutoscalerHPAName returns the name of the HPA scaling the target of the autoscaler, which is the HPA rendered by
+// zadig or the one managed by KEDA for the ScaledObject
+func AutoscalerHPAName(autoscaler *commonmodels.EnvServiceAutoscaler) string {
+	if autoscaler.Type == AutoscalerTypeKEDA {
+		return kedaHPANamePrefix + autoscaler.TargetName
+	}
+	return autoscaler.TargetName
+}
+
+// BuildAutoscalerObject builds the HorizontalPodAutoscaler or the KEDA ScaledObject of the autoscaler, the object is
+// named after the target workload and labeled as a resource of the service in the env
+func BuildAutoscalerObject(env *commonmodels.Product, autoscaler *commonmodels.EnvServiceAutoscaler) (*unstructured.Unstructured, error) {
+	labels := GetPredefinedClusterLabels(env.ProductName, autoscaler.ServiceName, env.EnvName)
+
+	switch autoscaler.Type {
+	case AutoscalerTypeHPA:
+		minReplicas := autoscaler.MinReplicas
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: autoscalingv2.SchemeGroupVersion.String(),
+				Kind:       "HorizontalPodAutoscaler",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      autoscaler.TargetName,
+				Namespace: env.Namespace,
+				Labels:    labels,
+			},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+					APIVersion: "apps/v1",
+					Kind:       autoscaler.TargetKind,
+					Name:       autoscaler.TargetName,
+				},
+				MinReplicas: &minReplicas,
+				MaxReplicas: autoscaler.MaxReplicas,
+			},
+		}
+		for _, metric := range autoscaler.Metrics {
+			utilization := metric.TargetUtilization
+			hpa.Spec.Metrics = append(hpa.Spec.Metrics, autoscalingv2.MetricSpec{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name: corev1.ResourceName(metric.Resource),
+					Target: autoscalingv2.MetricTarget{
+						Type:               autoscalingv2.UtilizationMetricType,
+						AverageUtilization: &utilization,
+					},
+				},
+			})
+		}
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(hpa)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert HPA %s, err: %w", hpa.Name, err)
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		// status is not part of the desired state
+		unstructured.RemoveNestedField(u.Object, "status")
+		unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+		return u, nil
+	case AutoscalerTypeKEDA:
+		triggers := make([]interface{}, 0, len(autoscaler.Metrics)+len(autoscaler.Triggers))
+		for _, metric := range autoscaler.Metrics {
+			triggers = append(triggers, map[string]interface{}{
+				"type":       metric.Resource,
+				"metricType": string(autoscalingv2.UtilizationMetricType),
+				"metadata": map[string]interface{}{
+					"value": fmt.Sprintf("%d", metric.TargetUtilization),
+				},
+			})
+		}
+		for _, trigger := range autoscaler.Triggers {
+			metadata := make(map[string]interface{}, len(trigger.Metadata))
+			for k, v := range trigger.Metadata {
+				metadata[k] = v
+			}
+			triggers = append(triggers, map[string]interface{}{
+				"type":     trigger.Type,
+				"metadata": metadata,
+			})
+		}
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "keda.sh/v1alpha1",
+			"kind":       "ScaledObject",
+			"spec": map[string]interface{}{
+				"scaleTargetRef": map[string]interface{}{
+					"apiVersion": "apps/v1",
+					"kind":       autoscaler.TargetKind,
+					"name":       autoscaler.TargetName,
+				},
+				"minReplicaCount": int64(autoscaler.MinReplicas),
+				"maxReplicaCount": int64(autoscaler.MaxReplicas),
+				"triggers":        triggers,
+			},
+		}}
+		u.SetName(autoscaler.TargetName)
+		u.SetNamespace(env.Namespace)
+		u.SetLabels(labels)
+		return u, nil
+	default:
+		return nil, fmt.Errorf("unsupported autoscaler type %s", autoscaler.Type)
+	}
+}
+
+// RenderServiceAutoscalers renders the autoscalers of the service in the env, they are appended to the rendered yaml
+// of the service so that they are deployed and removed together with the service
+func RenderServiceAutoscalers(env *commonmodels.Product, serviceName string) (string, error) {
+	autoscalers, err := commonrepo.NewEnvServiceAutoscalerColl().ListByService(env.ProductName, env.EnvName, serviceName)
+	if err != nil {
+		return "", fmt.Errorf("failed to list autoscalers of service %s, err: %w", serviceName, err)
+	}
+
+	manifests := make([]string, 0, len(autoscalers))
+	for _, autoscaler := range autoscalers {
+		u, err := BuildAutoscalerObject(env, autoscaler)
+		if err != nil {
+			return "", err
+		}
+		// the namespace is set by the deployment of the service
+		unstructured.RemoveNestedField(u.Object, "metadata", "namespace")
+		manifest, err := yaml.Marshal(u.Object)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal autoscaler of %s/%s, err: %w", autoscaler.TargetKind, autoscaler.TargetName, err)
+		}
+		manifests = append(manifests, string(manifest))
+	}
+	return strings.Join(manifests, "---\n"), nil
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/updater"
+	zadigtypes "github.com/koderover/zadig/v2/pkg/types"
+)
+
+const (
+	CertIssuerKindClusterIssuer = "ClusterIssuer"
+	CertIssuerKindIssuer        = "Issuer"
+
+	// CertExpiryWarningPeriod is how long before the expiration the certificate is reported as expiring
+	CertExpiryWarningPeriod = 14 * 24 * time.Hour
+)
+
+var CertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+type CertificateStatus struct {
+	Name        string   `json:"name"`
+	SecretName  string   `json:"secret_name"`
+	DNSNames    []string `json:"dns_names"`
+	ServiceName string   `json:"service_name"`
+	Ready       bool     `json:"ready"`
+	Message     string   `json:"message"`
+	NotAfter    int64    `json:"not_after"`
+	RenewalTime int64    `json:"renewal_time"`
+	// Warning is set when the certificate is not ready or will expire soon
+	Warning string `json:"warning"`
+}
+
+// EnsureIngressCertificates requests the certificates of the TLS hosts of the ingress from the issuer configured for the
+// cluster, nothing is done if no issuer is configured
+func EnsureIngressCertificates(clusterID, namespace string, ingress *unstructured.Unstructured, ls map[string]string, kubeClient client.Client) error {
+	tls, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "tls")
+	hosts := make(map[string]string)
+	for _, t := range tls {
+		entry, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		secretName, _ := entry["secretName"].(string)
+		entryHosts, _, _ := unstructured.NestedStringSlice(entry, "hosts")
+		for _, host := range entryHosts {
+			if secretName == "" {
+				hosts[host] = CertificateSecretName(host)
+			} else {
+				hosts[host] = secretName
+			}
+		}
+	}
+	return EnsureCertificates(clusterID, namespace, hosts, ls, kubeClient)
+}
+
+// EnsureCertificates creates a certificate for each host, the certificate is stored in the secret the host is mapped to.
+// The hosts sharing the same secret are requested in one certificate.
+func EnsureCertificates(clusterID, namespace string, hosts map[string]string, ls map[string]string, kubeClient client.Client) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+	cluster, err := commonrepo.NewK8SClusterColl().Get(clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to find cluster %s, err: %w", clusterID, err)
+	}
+	if cluster.AdvancedConfig == nil || cluster.AdvancedConfig.CertIssuer == "" {
+		return nil
+	}
+	issuerKind := cluster.AdvancedConfig.CertIssuerKind
+	if issuerKind == "" {
+		issuerKind = CertIssuerKindClusterIssuer
+	}
+
+	secretHosts := make(map[string][]interface{})
+	for host, secretName := range hosts {
+		secretHosts[secretName] = append(secretHosts[secretName], host)
+	}
+	for secretName, dnsNames := range secretHosts {
+		cert := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"secretName": secretName,
+				"dnsNames":   dnsNames,
+				"issuerRef": map[string]interface{}{
+					"name":  cluster.AdvancedConfig.CertIssuer,
+					"kind":  issuerKind,
+					"group": CertificateGVK.Group,
+				},
+			},
+		}}
+		cert.SetGroupVersionKind(CertificateGVK)
+		cert.SetNamespace(namespace)
+		cert.SetName(secretName)
+		cert.SetLabels(MergeLabels(ls, map[string]string{zadigtypes.ZadigLabelKeyGlobalOwner: zadigtypes.Zadig}))
+
+		err := updater.CreateOrPatchUnstructured(cert, kubeClient)
+		if meta.IsNoMatchError(err) {
+			return fmt.Errorf("cert-manager is not installed in cluster %s", cluster.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply certificate %s, err: %w", secretName, err)
+		}
+	}
+	return nil
+}
+
+// ListCertificateStatus lists the certificates created by zadig in the namespace
+func ListCertificateStatus(namespace string, selector labels.Selector, kubeClient client.Client) ([]*CertificateStatus, error) {
+	certs, err := getter.ListUnstructuredResourceInCache(namespace, selector, nil, CertificateGVK, kubeClient)
+	if meta.IsNoMatchError(err) {
+		return []*CertificateStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	resp := make([]*CertificateStatus, 0, len(certs))
+	for _, cert := range certs {
+		if cert.GetLabels()[zadigtypes.ZadigLabelKeyGlobalOwner] != zadigtypes.Zadig {
+			continue
+		}
+
+		status := &CertificateStatus{
+			Name:        cert.GetName(),
+			ServiceName: cert.GetLabels()[setting.ServiceLabel],
+		}
+		status.SecretName, _, _ = unstructured.NestedString(cert.Object, "spec", "secretName")
+		status.DNSNames, _, _ = unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+		conditions, _, _ := unstructured.NestedSlice(cert.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok || condition["type"] != "Ready" {
+				continue
+			}
+			status.Ready = condition["status"] == "True"
+			status.Message, _ = condition["message"].(string)
+		}
+		if notAfter, found, _ := unstructured.NestedString(cert.Object, "status", "notAfter"); found {
+			if t, err := time.Parse(time.RFC3339, notAfter); err == nil {
+				status.NotAfter = t.Unix()
+			}
+		}
+		if renewalTime, found, _ := unstructured.NestedString(cert.Object, "status", "renewalTime"); found {
+			if t, err := time.Parse(time.RFC3339, renewalTime); err == nil {
+				status.RenewalTime = t.Unix()
+			}
+		}
+
+		switch {
+		case !status.Ready:
+			status.Warning = fmt.Sprintf("certificate is not ready: %s", status.Message)
+		case status.NotAfter > 0 && time.Unix(status.NotAfter, 0).Before(now.Add(CertExpiryWarningPeriod)):
+			status.Warning = fmt.Sprintf("certificate expires at %s", time.Unix(status.NotAfter, 0).Format(time.RFC3339))
+		}
+		resp = append(resp, status)
+	}
+	return resp, nil
+}
+
+// CertificateSecretName returns the default secret name of the certificate of the host
+func CertificateSecretName(host string) string {
+	name := strings.ReplaceAll(strings.TrimPrefix(host, "*."), ".", "-")
+	if strings.HasPrefix(host, "*.") {
+		name = "wildcard-" + name
+	}
+	return name + "-tls"
+}
@@ -32,6 +32,7 @@ import (
 	helmclient "github.com/mittwald/go-helm-client"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
 	versionedclient "istio.io/client-go/pkg/clientset/versioned"
@@ -59,6 +60,21 @@ import (
 	"github.com/koderover/zadig/v2/pkg/util/fs"
 )
 
+// HelmHookError is the install or upgrade error of a release whose hooks failed or didn't finish,
+// the diagnosis contains the status, logs and events of the hooks
+type HelmHookError struct {
+	Err       error
+	Diagnosis string
+}
+
+func (e *HelmHookError) Error() string {
+	return fmt.Sprintf("%s\nhelm hooks diagnosis:\n%s", e.Err, e.Diagnosis)
+}
+
+func (e *HelmHookError) Unwrap() error {
+	return e.Err
+}
+
 type IntervalExecutorHandler func(data *ReleaseInstallParam, isRetry bool, log *zap.SugaredLogger) error
 type DeploySvcFilter func(svc *commonmodels.ProductService) bool
 
@@ -74,6 +90,7 @@ type ReleaseInstallParam struct {
 	Timeout        int
 	DryRun         bool
 	Production     bool
+	PostRenderer   postrender.PostRenderer
 }
 
 func InstallOrUpgradeHelmChartWithValues(param *ReleaseInstallParam, isRetry bool, helmClient *helmtool.HelmClient) error {
@@ -132,13 +149,21 @@ func InstallOrUpgradeHelmChartWithValues(param *ReleaseInstallParam, isRetry boo
 		return fmt.Errorf("failed to clone helm client: %s", err)
 	}
 
+	var opts *helmclient.GenericHelmOptions
+	if param.PostRenderer != nil {
+		opts = &helmclient.GenericHelmOptions{PostRenderer: param.PostRenderer}
+	}
+
 	var release *release.Release
-	release, err = helmClient.InstallOrUpgradeChart(ctx, chartSpec, nil)
+	release, err = helmClient.InstallOrUpgradeChart(ctx, chartSpec, opts)
 	if err != nil {
 		err = errors.WithMessagef(
 			err,
 			"failed to install or upgrade helm chart %s/%s",
 			namespace, serviceObj.ServiceName)
+		if diagnosis := helmClient.DiagnoseHookFailure(ctx, namespace, param.ReleaseName, release); diagnosis != "" {
+			err = &HelmHookError{Err: err, Diagnosis: diagnosis}
+		}
 	} else {
 		err = EnsureZadigServiceByManifest(ctx, param.ProductName, param.Namespace, release.Manifest)
 		if err != nil {
@@ -334,7 +359,7 @@ func DeleteHelmReleaseFromEnv(userName, requestID string, productInfo *commonmod
 		// send err message to user
 		if len(errList) > 0 {
 			title := fmt.Sprintf("[%s] 的 [%s] 环境服务删除失败", productInfo.ProductName, productInfo.EnvName)
-			notify.SendErrorMessage(userName, title, requestID, errors.New(strings.Join(errList, "\n")), log)
+			notify.SendEnvErrorMessage(userName, productInfo.ProductName, title, requestID, errors.New(strings.Join(errList, "\n")), log)
 		}
 
 		if productInfo.ShareEnv.Enable && !productInfo.ShareEnv.IsBase {
@@ -451,7 +476,7 @@ func DeleteHelmServiceFromEnv(userName, requestID string, productInfo *commonmod
 		// send err message to user
 		if len(errList) > 0 {
 			title := fmt.Sprintf("[%s] 的 [%s] 环境服务删除失败", productInfo.ProductName, productInfo.EnvName)
-			notify.SendErrorMessage(userName, title, requestID, errors.New(strings.Join(errList, "\n")), log)
+			notify.SendEnvErrorMessage(userName, productInfo.ProductName, title, requestID, errors.New(strings.Join(errList, "\n")), log)
 		}
 
 		if productInfo.ShareEnv.Enable && !productInfo.ShareEnv.IsBase {
@@ -626,6 +651,10 @@ func DeploySingleHelmRelease(product *commonmodels.Product, productSvc *commonmo
 	if !productSvc.FromZadig() {
 		param.IsChartInstall = true
 	}
+	param.PostRenderer, err = GenHelmPostRenderer(product, productSvc)
+	if err != nil {
+		return err
+	}
 
 	ensureUpgrade := func() error {
 		hrs, errHistory := helmClient.ListReleaseHistory(param.ReleaseName, 10)
@@ -791,6 +820,7 @@ func findRenderChartFromList(svc *commonmodels.ProductService, renderCharts []*t
 
 func BuildInstallParam(defaultValues string, productInfo *commonmodels.Product, renderChart *templatemodels.ServiceRender, productSvc *commonmodels.ProductService) (*ReleaseInstallParam, error) {
 	productName, namespace, envName := productInfo.ProductName, productInfo.Namespace, productInfo.EnvName
+	renderChart.InheritSchedulingHints(productSvc.Render)
 	productSvc.Render = renderChart
 
 	ret := &ReleaseInstallParam{
@@ -837,6 +867,73 @@ func BuildInstallParam(defaultValues string, productInfo *commonmodels.Product,
 
 	ret.MergedValues = finalValues
 	ret.Production = productInfo.Production
+	ret.PostRenderer, err = GenHelmPostRenderer(productInfo, productSvc)
+	if err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// GenHelmPostRenderer returns the post renderer of the env and the workload policy of the project applied to the
+// release of the service, nil is returned if neither applies
+func GenHelmPostRenderer(env *commonmodels.Product, productSvc *commonmodels.ProductService) (postrender.PostRenderer, error) {
+	kustomizeRenderer, err := genKustomizePostRenderer(env, productSvc)
+	if err != nil {
+		return nil, err
+	}
+
+	var policyRenderer postrender.PostRenderer
+	rule, err := GetWorkloadPolicyRule(env, productSvc.Type)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		policyRenderer = NewWorkloadPolicyPostRenderer(rule)
+	}
+	return helmtool.NewChainedPostRenderer(kustomizeRenderer, policyRenderer, NewSchedulingHintsPostRenderer(productSvc.Render.GetSchedulingHints())), nil
+}
+
+// genKustomizePostRenderer returns the post renderer of the env, nil is returned if the post renderer is disabled
+// or the service is not selected
+func genKustomizePostRenderer(env *commonmodels.Product, productSvc *commonmodels.ProductService) (postrender.PostRenderer, error) {
+	postRenderer := env.HelmPostRenderer
+	if postRenderer == nil || !postRenderer.Enabled {
+		return nil, nil
+	}
+
+	if len(postRenderer.Services) > 0 {
+		name := productSvc.ServiceName
+		if !productSvc.FromZadig() {
+			name = productSvc.ReleaseName
+		}
+		if !sets.NewString(postRenderer.Services...).Has(name) {
+			return nil, nil
+		}
+	}
+
+	patchSet := &helmtool.KustomizePatchSet{
+		CommonLabels:      postRenderer.CommonLabels,
+		CommonAnnotations: postRenderer.CommonAnnotations,
+	}
+	for _, patch := range postRenderer.Patches {
+		kustomizePatch := &helmtool.KustomizePatch{Patch: patch.Patch}
+		if patch.Target != nil {
+			kustomizePatch.Target = &helmtool.KustomizePatchTarget{
+				Group:              patch.Target.Group,
+				Version:            patch.Target.Version,
+				Kind:               patch.Target.Kind,
+				Name:               patch.Target.Name,
+				Namespace:          patch.Target.Namespace,
+				LabelSelector:      patch.Target.LabelSelector,
+				AnnotationSelector: patch.Target.AnnotationSelector,
+			}
+		}
+		patchSet.Patches = append(patchSet.Patches, kustomizePatch)
+	}
+	ret, err := helmtool.NewKustomizePostRenderer(patchSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create helm post renderer of env %s, err: %w", env.EnvName, err)
+	}
 	return ret, nil
 }
 
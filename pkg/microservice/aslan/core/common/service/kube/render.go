@@ -38,6 +38,8 @@ import (
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
 	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/externalvar"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/paramstore"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/repository"
 	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
 	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
@@ -563,7 +565,12 @@ func GenerateRenderedYaml(option *GeneSvcYamlOption) (string, int, []*WorkloadRe
 
 	serviceRender.OverrideYaml.YamlContent = mergedYaml
 
-	fullRenderedYaml, err := RenderServiceYaml(latestSvcTemplate.Yaml, option.ProductName, option.ServiceName, serviceRender)
+	externalVariableYaml, err := externalvar.GetVariableYaml(productInfo)
+	if err != nil {
+		return "", 0, nil, errors.Wrapf(err, "failed to get external variables of env %s", productInfo.EnvName)
+	}
+
+	fullRenderedYaml, err := RenderServiceYaml(latestSvcTemplate.Yaml, option.ProductName, option.ServiceName, serviceRender, externalVariableYaml)
 	if err != nil {
 		return "", 0, nil, err
 	}
@@ -575,17 +582,51 @@ func GenerateRenderedYaml(option *GeneSvcYamlOption) (string, int, []*WorkloadRe
 
 	mergedContainers := mergeContainers(curContainers, latestSvcTemplate.Containers, svcContainersInProduct, option.Containers)
 	fullRenderedYaml, workloadResource, err := ReplaceWorkloadImages(fullRenderedYaml, mergedContainers)
-	return fullRenderedYaml, int(latestSvcTemplate.Revision), workloadResource, err
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	policyRule, err := GetWorkloadPolicyRule(productInfo, setting.K8SDeployType)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	fullRenderedYaml, err = ApplyWorkloadPolicy(fullRenderedYaml, policyRule, GetPredefinedClusterLabels(option.ProductName, option.ServiceName, productInfo.EnvName))
+	if err != nil {
+		return "", 0, nil, err
+	}
+	fullRenderedYaml, err = ApplySchedulingHints(fullRenderedYaml, serviceRender.GetSchedulingHints())
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	autoscalerYaml, err := RenderServiceAutoscalers(productInfo, option.ServiceName)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if autoscalerYaml != "" {
+		fullRenderedYaml = util.JoinYamls([]string{fullRenderedYaml, autoscalerYaml})
+	}
+	return fullRenderedYaml, int(latestSvcTemplate.Revision), workloadResource, nil
 }
 
-func RenderServiceYaml(originYaml, productName, serviceName string, svcRender *template.ServiceRender) (string, error) {
-	if svcRender == nil {
+// RenderServiceYaml renders the service yaml with the service variables, the external variable yamls are merged
+// before the service variables so the service variables take precedence
+func RenderServiceYaml(originYaml, productName, serviceName string, svcRender *template.ServiceRender, externalVariableYamls ...string) (string, error) {
+	variableYamls := make([]string, 0)
+	for _, externalVariableYaml := range externalVariableYamls {
+		if externalVariableYaml != "" {
+			variableYamls = append(variableYamls, externalVariableYaml)
+		}
+	}
+	if svcRender == nil && len(variableYamls) == 0 {
 		originYaml = strings.ReplaceAll(originYaml, setting.TemplateVariableProduct, productName)
 		originYaml = strings.ReplaceAll(originYaml, setting.TemplateVariableService, serviceName)
 		return originYaml, nil
 	}
-	variableYaml := svcRender.GetSafeVariable()
-	return commonutil.RenderK8sSvcYamlStrict(originYaml, productName, serviceName, variableYaml)
+	if svcRender != nil {
+		variableYamls = append(variableYamls, svcRender.GetSafeVariable())
+	}
+	return commonutil.RenderK8sSvcYamlStrict(originYaml, productName, serviceName, variableYamls...)
 }
 
 // RenderEnvService renders service with particular revision and service vars in environment
@@ -605,13 +646,37 @@ func RenderEnvService(prod *commonmodels.Product, serviceRender *template.Servic
 }
 
 func RenderEnvServiceWithTempl(prod *commonmodels.Product, serviceRender *template.ServiceRender, service *commonmodels.ProductService, svcTmpl *commonmodels.Service) (yaml string, err error) {
+	externalVariableYaml, err := externalvar.GetVariableYaml(prod)
+	if err != nil {
+		log.Errorf("failed to get external variables of env %s/%s, err: %s", prod.ProductName, prod.EnvName, err)
+		return "", err
+	}
+
+	originYaml, err := paramstore.RenderContent(prod.ProductName, svcTmpl.Yaml, paramstore.EnvUsage(prod.ProductName, prod.EnvName))
+	if err != nil {
+		log.Errorf("failed to resolve parameters of service %s in env %s/%s, err: %s", svcTmpl.ServiceName, prod.ProductName, prod.EnvName, err)
+		return "", err
+	}
+
 	// Note only the keys in TemplateService.ServiceVar can work
-	parsedYaml, err := RenderServiceYaml(svcTmpl.Yaml, prod.ProductName, svcTmpl.ServiceName, serviceRender)
+	parsedYaml, err := RenderServiceYaml(originYaml, prod.ProductName, svcTmpl.ServiceName, serviceRender, externalVariableYaml)
 	if err != nil {
 		log.Errorf("failed to render service yaml, err: %s", err)
 		return "", err
 	}
 	parsedYaml = ParseSysKeys(prod.Namespace, prod.EnvName, prod.ProductName, service.ServiceName, parsedYaml)
 	parsedYaml, _, err = ReplaceWorkloadImages(parsedYaml, service.Containers)
-	return parsedYaml, err
+	if err != nil {
+		return "", err
+	}
+
+	policyRule, err := GetWorkloadPolicyRule(prod, setting.K8SDeployType)
+	if err != nil {
+		return "", err
+	}
+	parsedYaml, err = ApplyWorkloadPolicy(parsedYaml, policyRule, GetPredefinedClusterLabels(prod.ProductName, service.ServiceName, prod.EnvName))
+	if err != nil {
+		return "", err
+	}
+	return ApplySchedulingHints(parsedYaml, serviceRender.GetSchedulingHints())
 }
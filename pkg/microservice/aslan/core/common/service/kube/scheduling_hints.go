@@ -0,0 +1,198 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/postrender"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	templatemodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+type parsedSchedulingHints struct {
+	nodeSelector map[string]interface{}
+	tolerations  []interface{}
+	affinity     map[string]interface{}
+}
+
+// ValidateSchedulingHints checks the tolerations and affinity of the hints are valid k8s pod spec fields
+func ValidateSchedulingHints(hints *templatemodels.SchedulingHints) error {
+	_, err := parseSchedulingHints(hints)
+	return err
+}
+
+func parseSchedulingHints(hints *templatemodels.SchedulingHints) (*parsedSchedulingHints, error) {
+	ret := &parsedSchedulingHints{}
+	if len(hints.NodeSelector) > 0 {
+		ret.nodeSelector = make(map[string]interface{}, len(hints.NodeSelector))
+		for k, v := range hints.NodeSelector {
+			if k == "" {
+				return nil, fmt.Errorf("empty node selector key")
+			}
+			ret.nodeSelector[k] = v
+		}
+	}
+
+	if strings.TrimSpace(hints.Tolerations) != "" {
+		tolerations := make([]corev1.Toleration, 0)
+		if err := yaml.UnmarshalStrict([]byte(hints.Tolerations), &tolerations); err != nil {
+			return nil, fmt.Errorf("invalid tolerations, err: %w", err)
+		}
+		for i := range tolerations {
+			toleration, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&tolerations[i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid toleration %s, err: %w", tolerations[i].Key, err)
+			}
+			ret.tolerations = append(ret.tolerations, toleration)
+		}
+	}
+
+	if strings.TrimSpace(hints.Affinity) != "" {
+		affinity := &corev1.Affinity{}
+		if err := yaml.UnmarshalStrict([]byte(hints.Affinity), affinity); err != nil {
+			return nil, fmt.Errorf("invalid affinity, err: %w", err)
+		}
+		var err error
+		ret.affinity, err = runtime.DefaultUnstructuredConverter.ToUnstructured(affinity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid affinity, err: %w", err)
+		}
+	}
+	return ret, nil
+}
+
+// ApplySchedulingHints injects the scheduling hints into the pod templates of the workloads in the manifest, the node
+// selector is merged with the one of the workload, the tolerations are appended if not present and each of the
+// node/pod/podAnti affinities in the hints replaces the one of the workload, the documents not changed are kept as they are
+func ApplySchedulingHints(manifest string, hints *templatemodels.SchedulingHints) (string, error) {
+	if hints.IsEmpty() {
+		return manifest, nil
+	}
+	parsed, err := parseSchedulingHints(hints)
+	if err != nil {
+		return "", err
+	}
+
+	docs := util.SplitYaml(manifest)
+	ret := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		u, err := parseManifestObject(doc)
+		if err != nil || u == nil {
+			ret = append(ret, doc)
+			continue
+		}
+
+		var podSpecFields []string
+		switch u.GetKind() {
+		case setting.Deployment, setting.StatefulSet, setting.DaemonSet, setting.Job:
+			podSpecFields = []string{"spec", "template", "spec"}
+		case setting.CronJob:
+			podSpecFields = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+		default:
+			ret = append(ret, doc)
+			continue
+		}
+
+		podSpec, found, err := unstructured.NestedMap(u.Object, podSpecFields...)
+		if err != nil || !found {
+			ret = append(ret, doc)
+			continue
+		}
+		parsed.applyToPodSpec(podSpec)
+		if err := unstructured.SetNestedMap(u.Object, podSpec, podSpecFields...); err != nil {
+			return "", fmt.Errorf("failed to set scheduling hints of %s %s, err: %w", u.GetKind(), u.GetName(), err)
+		}
+		out, err := yaml.Marshal(u.Object)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal %s %s, err: %w", u.GetKind(), u.GetName(), err)
+		}
+		ret = append(ret, strings.TrimSuffix(string(out), "\n"))
+	}
+	return util.JoinYamls(ret), nil
+}
+
+func (h *parsedSchedulingHints) applyToPodSpec(podSpec map[string]interface{}) {
+	if len(h.nodeSelector) > 0 {
+		nodeSelector, _ := podSpec["nodeSelector"].(map[string]interface{})
+		if nodeSelector == nil {
+			nodeSelector = make(map[string]interface{}, len(h.nodeSelector))
+		}
+		for k, v := range h.nodeSelector {
+			nodeSelector[k] = v
+		}
+		podSpec["nodeSelector"] = nodeSelector
+	}
+
+	if len(h.tolerations) > 0 {
+		tolerations, _ := podSpec["tolerations"].([]interface{})
+		for _, toleration := range h.tolerations {
+			exists := false
+			for _, current := range tolerations {
+				if reflect.DeepEqual(current, toleration) {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				tolerations = append(tolerations, toleration)
+			}
+		}
+		podSpec["tolerations"] = tolerations
+	}
+
+	if len(h.affinity) > 0 {
+		affinity, _ := podSpec["affinity"].(map[string]interface{})
+		if affinity == nil {
+			affinity = make(map[string]interface{}, len(h.affinity))
+		}
+		for k, v := range h.affinity {
+			affinity[k] = v
+		}
+		podSpec["affinity"] = affinity
+	}
+}
+
+type schedulingHintsPostRenderer struct {
+	hints *templatemodels.SchedulingHints
+}
+
+// NewSchedulingHintsPostRenderer returns the helm post renderer injecting the scheduling hints into the release,
+// nil is returned if there is no hint
+func NewSchedulingHintsPostRenderer(hints *templatemodels.SchedulingHints) postrender.PostRenderer {
+	if hints.IsEmpty() {
+		return nil
+	}
+	return &schedulingHintsPostRenderer{hints: hints}
+}
+
+func (r *schedulingHintsPostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	out, err := ApplySchedulingHints(renderedManifests.String(), r.hints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply scheduling hints, err: %w", err)
+	}
+	return bytes.NewBufferString(out), nil
+}
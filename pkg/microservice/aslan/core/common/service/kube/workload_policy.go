@@ -0,0 +1,223 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/postrender"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+// GetWorkloadPolicyRule returns the workload policy rule of the project applied to the service of the type in the env,
+// the policies only apply to production envs and nil is returned if no rule matches
+func GetWorkloadPolicyRule(env *commonmodels.Product, serviceType string) (*commonmodels.WorkloadPolicyRule, error) {
+	if !env.Production {
+		return nil, nil
+	}
+	policy, err := commonrepo.NewEnvWorkloadPolicyColl().GetByProject(env.ProductName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find workload policy of project %s, err: %w", env.ProductName, err)
+	}
+	if policy == nil {
+		return nil, nil
+	}
+	for _, rule := range policy.Rules {
+		if len(rule.ServiceTypes) == 0 || sets.NewString(rule.ServiceTypes...).Has(serviceType) {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// ApplyWorkloadPolicy sets the priority class of the pods of the workloads in the manifest and generates the
+// PodDisruptionBudgets of the multi-replica workloads which are not covered by the PDBs in the manifest, the
+// documents not changed are kept as they are
+func ApplyWorkloadPolicy(manifest string, rule *commonmodels.WorkloadPolicyRule, pdbLabels map[string]string) (string, error) {
+	if rule == nil || (rule.PriorityClassName == "" && (rule.PDB == nil || !rule.PDB.Enabled)) {
+		return manifest, nil
+	}
+
+	docs := util.SplitYaml(manifest)
+	objs := make([]*unstructured.Unstructured, len(docs))
+	pdbSelectors := make([]labels.Selector, 0)
+	for i, doc := range docs {
+		u, err := parseManifestObject(doc)
+		if err != nil || u == nil {
+			continue
+		}
+		objs[i] = u
+		if u.GetKind() != "PodDisruptionBudget" {
+			continue
+		}
+		selector, err := nestedLabelSelector(u.Object, "spec", "selector")
+		if err != nil {
+			return "", fmt.Errorf("invalid selector of PodDisruptionBudget %s, err: %w", u.GetName(), err)
+		}
+		pdbSelectors = append(pdbSelectors, selector)
+	}
+
+	ret := make([]string, 0, len(docs))
+	for i, doc := range docs {
+		u := objs[i]
+		if u == nil || (u.GetKind() != setting.Deployment && u.GetKind() != setting.StatefulSet) {
+			ret = append(ret, doc)
+			continue
+		}
+
+		if rule.PriorityClassName != "" {
+			current, _, _ := unstructured.NestedString(u.Object, "spec", "template", "spec", "priorityClassName")
+			if current == "" {
+				if err := unstructured.SetNestedField(u.Object, rule.PriorityClassName, "spec", "template", "spec", "priorityClassName"); err != nil {
+					return "", fmt.Errorf("failed to set priority class of %s %s, err: %w", u.GetKind(), u.GetName(), err)
+				}
+				out, err := yaml.Marshal(u.Object)
+				if err != nil {
+					return "", fmt.Errorf("failed to marshal %s %s, err: %w", u.GetKind(), u.GetName(), err)
+				}
+				doc = strings.TrimSuffix(string(out), "\n")
+			}
+		}
+		ret = append(ret, doc)
+
+		if rule.PDB == nil || !rule.PDB.Enabled {
+			continue
+		}
+		pdb, err := buildWorkloadPDB(u, rule.PDB, pdbSelectors, pdbLabels)
+		if err != nil {
+			return "", err
+		}
+		if pdb != "" {
+			ret = append(ret, pdb)
+		}
+	}
+	return util.JoinYamls(ret), nil
+}
+
+// buildWorkloadPDB returns the PDB of the workload, a PDB on a single replica workload blocks the node drains so it
+// is only generated for the workloads with more than one replica
+func buildWorkloadPDB(u *unstructured.Unstructured, policy *commonmodels.WorkloadPDBPolicy, pdbSelectors []labels.Selector, pdbLabels map[string]string) (string, error) {
+	replicas, found, _ := unstructured.NestedInt64(u.Object, "spec", "replicas")
+	if !found || replicas < 2 {
+		return "", nil
+	}
+	podLabels, _, _ := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "labels")
+	for _, selector := range pdbSelectors {
+		if !selector.Empty() && selector.Matches(labels.Set(podLabels)) {
+			return "", nil
+		}
+	}
+	selector, found, _ := unstructured.NestedMap(u.Object, "spec", "selector")
+	if !found {
+		return "", nil
+	}
+
+	spec := map[string]interface{}{"selector": selector}
+	switch {
+	case policy.MinAvailable != "":
+		spec["minAvailable"] = intOrPercent(policy.MinAvailable)
+	case policy.MaxUnavailable != "":
+		spec["maxUnavailable"] = intOrPercent(policy.MaxUnavailable)
+	default:
+		spec["maxUnavailable"] = int64(1)
+	}
+	metadata := map[string]interface{}{"name": u.GetName()}
+	if len(pdbLabels) > 0 {
+		pdbLabelMap := make(map[string]interface{}, len(pdbLabels))
+		for k, v := range pdbLabels {
+			pdbLabelMap[k] = v
+		}
+		metadata["labels"] = pdbLabelMap
+	}
+	out, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "policy/v1",
+		"kind":       "PodDisruptionBudget",
+		"metadata":   metadata,
+		"spec":       spec,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal PodDisruptionBudget of %s %s, err: %w", u.GetKind(), u.GetName(), err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func intOrPercent(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	return value
+}
+
+func parseManifestObject(doc string) (*unstructured.Unstructured, error) {
+	if strings.TrimSpace(doc) == "" {
+		return nil, nil
+	}
+	jsonBytes, err := yaml.YAMLToJSON([]byte(doc))
+	if err != nil {
+		return nil, err
+	}
+	if string(jsonBytes) == "null" {
+		return nil, nil
+	}
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(jsonBytes); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func nestedLabelSelector(obj map[string]interface{}, fields ...string) (labels.Selector, error) {
+	selectorMap, found, err := unstructured.NestedMap(obj, fields...)
+	if err != nil || !found {
+		return labels.Nothing(), err
+	}
+	labelSelector := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorMap, labelSelector); err != nil {
+		return nil, err
+	}
+	return metav1.LabelSelectorAsSelector(labelSelector)
+}
+
+type workloadPolicyPostRenderer struct {
+	rule *commonmodels.WorkloadPolicyRule
+}
+
+// NewWorkloadPolicyPostRenderer returns the helm post renderer applying the workload policy rule to the release
+func NewWorkloadPolicyPostRenderer(rule *commonmodels.WorkloadPolicyRule) postrender.PostRenderer {
+	return &workloadPolicyPostRenderer{rule: rule}
+}
+
+func (r *workloadPolicyPostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	out, err := ApplyWorkloadPolicy(renderedManifests.String(), r.rule, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply workload policy, err: %w", err)
+	}
+	return bytes.NewBufferString(out), nil
+}
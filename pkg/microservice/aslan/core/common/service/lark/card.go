@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lark
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	approvalservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/approval"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	userclient "github.com/koderover/zadig/v2/pkg/shared/client/user"
+	"github.com/koderover/zadig/v2/pkg/tool/lark"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+const (
+	EventTypeCardActionTrigger = "card.action.trigger"
+	EventTypeMessageReceive    = "im.message.receive_v1"
+
+	cardToastSuccess = "success"
+	cardToastError   = "error"
+)
+
+// handleCardAction resolves native approval by the approve buttons in lark card,
+// the lark operator is mapped to zadig approver by mobile
+func handleCardAction(imAppID, raw string) *EventHandlerResponse {
+	openID := gjson.Get(raw, "event.operator.open_id").String()
+	approveKey := gjson.Get(raw, "event.action.value.approve_key").String()
+	approve := gjson.Get(raw, "event.action.value.approve").String() == "true"
+	if openID == "" || approveKey == "" {
+		log.Infof("LarkEventHandler: card action without approve key, ignored")
+		return nil
+	}
+
+	client, err := GetLarkClientByIMAppID(imAppID)
+	if err != nil {
+		log.Errorf("get lark client failed: %v", err)
+		return cardToast(cardToastError, "获取飞书应用失败")
+	}
+
+	user, err := approvalservice.GlobalApproveMap.FindApproveUser(approveKey, func(user *commonmodels.User) (bool, error) {
+		userInfo, err := userclient.New().GetUserByID(user.UserID)
+		if err != nil {
+			return false, err
+		}
+		if userInfo.Phone == "" {
+			return false, nil
+		}
+		larkUser, err := client.GetUserIDByEmailOrMobile(lark.QueryTypeMobile, userInfo.Phone, setting.LarkUserOpenID)
+		if err != nil {
+			return false, err
+		}
+		return util.GetStringFromPointer(larkUser.UserId) == openID, nil
+	})
+	if err != nil {
+		log.Warnf("lark user %s is not approver of %s: %v", openID, approveKey, err)
+		return cardToast(cardToastError, "您不是该审批的审批人或已审批")
+	}
+
+	_, err = approvalservice.GlobalApproveMap.DoApproval(approveKey, user.UserName, user.UserID, "", approve, config.ApprovalChannelLark)
+	if err != nil {
+		log.Errorf("do approval %s from lark failed: %v", approveKey, err)
+		return cardToast(cardToastError, fmt.Sprintf("审批失败: %s", err))
+	}
+	return cardToast(cardToastSuccess, "审批成功")
+}
+
+func cardToast(toastType, content string) *EventHandlerResponse {
+	return &EventHandlerResponse{
+		Toast: &CardToast{
+			Type:    toastType,
+			Content: content,
+		},
+	}
+}
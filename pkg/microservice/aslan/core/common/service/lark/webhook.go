@@ -71,7 +71,23 @@ type ApprovalTaskEvent struct {
 }
 
 type EventHandlerResponse struct {
-	Challenge string `json:"challenge"`
+	Challenge string     `json:"challenge,omitempty"`
+	Toast     *CardToast `json:"toast,omitempty"`
+	// Message is the message sent to the bot, it is handled as a chatops command by the caller
+	Message *MessageEvent `json:"-"`
+}
+
+// MessageEvent is a text message sent to the bot in a chat or a private chat
+type MessageEvent struct {
+	IMAppID string
+	OpenID  string
+	ChatID  string
+	Text    string
+}
+
+type CardToast struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
 }
 
 func EventHandler(appID, sign, ts, nonce, body string) (*EventHandlerResponse, error) {
@@ -106,6 +122,14 @@ func EventHandler(appID, sign, ts, nonce, body string) (*EventHandlerResponse, e
 		return nil, errors.New("check sign failed")
 	}
 
+	// card action callback uses the 2.0 event schema
+	if gjson.Get(raw, "header.event_type").String() == EventTypeCardActionTrigger {
+		return handleCardAction(larkAppInfoID, raw), nil
+	}
+	if gjson.Get(raw, "header.event_type").String() == EventTypeMessageReceive {
+		return &EventHandlerResponse{Message: parseMessageEvent(larkAppInfoID, raw)}, nil
+	}
+
 	callback := &CallbackData{}
 	err = json.Unmarshal([]byte(raw), callback)
 	if err != nil {
@@ -187,3 +211,21 @@ func larkCalculateSignature(timestamp, nonce, encryptKey, bodystring string) str
 	sig := fmt.Sprintf("%x", bs)
 	return sig
 }
+
+// parseMessageEvent returns nil if the message is not a text message, the mentions of the bot are removed from the text
+func parseMessageEvent(imAppID, raw string) *MessageEvent {
+	if gjson.Get(raw, "event.message.message_type").String() != "text" {
+		log.Infof("LarkEventHandler: non-text message received, ignored")
+		return nil
+	}
+	text := gjson.Get(gjson.Get(raw, "event.message.content").String(), "text").String()
+	for _, mention := range gjson.Get(raw, "event.message.mentions").Array() {
+		text = strings.ReplaceAll(text, mention.Get("key").String(), "")
+	}
+	return &MessageEvent{
+		IMAppID: imAppID,
+		OpenID:  gjson.Get(raw, "event.sender.sender_id.open_id").String(),
+		ChatID:  gjson.Get(raw, "event.message.chat_id").String(),
+		Text:    strings.TrimSpace(text),
+	}
+}
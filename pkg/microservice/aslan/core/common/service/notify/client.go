@@ -31,6 +31,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/base"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notifypref"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/scmnotify"
 	"github.com/koderover/zadig/v2/pkg/setting"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
@@ -97,6 +98,13 @@ func (c *client) CreateNotify(sender string, nf *models.Notify) error {
 		if err = json.Unmarshal(b, &content); err != nil {
 			return fmt.Errorf("[%s] convert message error: %v", sender, err)
 		}
+		event := content.Event
+		if event == "" {
+			event = models.NotificationEventSystem
+		}
+		if !notifypref.AllowedByUserName(sender, models.NotificationChannelInApp, event, content.ProjectName) {
+			return nil
+		}
 
 		nf.Content = content
 	case config.WorkflowTaskStatus:
@@ -107,6 +115,9 @@ func (c *client) CreateNotify(sender string, nf *models.Notify) error {
 		if err = json.Unmarshal(b, &content); err != nil {
 			return fmt.Errorf("[%s] convert workflowtaskstatus error: %v", sender, err)
 		}
+		if !notifypref.AllowedByUserName(sender, models.NotificationChannelInApp, models.NotificationEventWorkflow, content.ProductName) {
+			return nil
+		}
 		nf.Content = content
 	default:
 		return fmt.Errorf("notify type not found")
@@ -29,19 +29,50 @@ import (
 )
 
 func SendErrorMessage(sender, title, requestID string, err error, log *zap.SugaredLogger) {
+	sendErrorMessage(sender, "", models.NotificationEventSystem, title, requestID, err, log)
+}
+
+// SendEnvErrorMessage sends the error of an env, it can be muted by the notification preference of the receiver
+func SendEnvErrorMessage(sender, projectName, title, requestID string, err error, log *zap.SugaredLogger) {
+	sendErrorMessage(sender, projectName, models.NotificationEventEnv, title, requestID, err, log)
+}
+
+func sendErrorMessage(sender, projectName string, event models.NotificationEventType, title, requestID string, err error, log *zap.SugaredLogger) {
 	content := fmt.Sprintf("错误信息: %s", err)
-	SendMessage(sender, title, content, requestID, log)
+	// the errors are usually fired in batches, e.g. one per failed service of an env, so they are sent as digests
+	defaultAggregator.add(sender, &models.MessageCtx{
+		ReqID:       requestID,
+		Title:       title,
+		ProjectName: projectName,
+		Event:       event,
+	}, content, log)
 }
 
 func SendMessage(sender, title, content, requestID string, log *zap.SugaredLogger) {
+	sendMessage(sender, &models.MessageCtx{
+		ReqID:   requestID,
+		Title:   title,
+		Content: content,
+		Event:   models.NotificationEventSystem,
+	}, log)
+}
+
+// SendEnvMessage sends a message of an env, it can be muted by the notification preference of the receiver
+func SendEnvMessage(sender, projectName, title, content, requestID string, log *zap.SugaredLogger) {
+	sendMessage(sender, &models.MessageCtx{
+		ReqID:       requestID,
+		Title:       title,
+		Content:     content,
+		ProjectName: projectName,
+		Event:       models.NotificationEventEnv,
+	}, log)
+}
+
+func sendMessage(sender string, message *models.MessageCtx, log *zap.SugaredLogger) {
 	nf := &models.Notify{
-		Type:     config.Message,
-		Receiver: sender,
-		Content: &models.MessageCtx{
-			ReqID:   requestID,
-			Title:   title,
-			Content: content,
-		},
+		Type:       config.Message,
+		Receiver:   sender,
+		Content:    message,
 		CreateTime: time.Now().Unix(),
 		IsRead:     false,
 	}
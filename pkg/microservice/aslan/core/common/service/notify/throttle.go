@@ -0,0 +1,137 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+)
+
+const (
+	// MessageAggregationWindow is how long the messages with the same receiver and title are collected into one digest
+	MessageAggregationWindow = 30 * time.Second
+	// MessageRateLimit is the max number of messages sent to a receiver in MessageRateLimitPeriod,
+	// the messages over the limit are delayed and merged into a digest
+	MessageRateLimit       = 10
+	MessageRateLimitPeriod = time.Minute
+	// maxDigestItems limits the size of a digest, the rest are only counted
+	maxDigestItems = 20
+)
+
+var defaultAggregator = newMessageAggregator(MessageAggregationWindow, MessageRateLimit, MessageRateLimitPeriod)
+
+type pendingMessage struct {
+	receiver string
+	message  *models.MessageCtx
+	contents []string
+	log      *zap.SugaredLogger
+}
+
+// messageAggregator merges the messages fired in a short time, e.g. one message per failed service when
+// updating an env, into a digest, and limits the rate of the messages sent to a receiver.
+// The state is kept in memory, the pending messages are lost if aslan restarts.
+type messageAggregator struct {
+	mu        sync.Mutex
+	window    time.Duration
+	limit     int
+	period    time.Duration
+	pending   map[string]*pendingMessage
+	sentTimes map[string][]time.Time
+	send      func(receiver string, message *models.MessageCtx, log *zap.SugaredLogger)
+}
+
+func newMessageAggregator(window time.Duration, limit int, period time.Duration) *messageAggregator {
+	return &messageAggregator{
+		window:    window,
+		limit:     limit,
+		period:    period,
+		pending:   make(map[string]*pendingMessage),
+		sentTimes: make(map[string][]time.Time),
+		send:      sendMessage,
+	}
+}
+
+// add collects the content of the message, the contents of the messages with the same receiver and title are merged
+func (a *messageAggregator) add(receiver string, message *models.MessageCtx, content string, log *zap.SugaredLogger) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := fmt.Sprintf("%s/%s", receiver, message.Title)
+	if msg, ok := a.pending[key]; ok {
+		msg.contents = append(msg.contents, content)
+		return
+	}
+	a.pending[key] = &pendingMessage{
+		receiver: receiver,
+		message:  message,
+		contents: []string{content},
+		log:      log,
+	}
+	time.AfterFunc(a.window, func() { a.flush(key) })
+}
+
+func (a *messageAggregator) flush(key string) {
+	a.mu.Lock()
+	msg, ok := a.pending[key]
+	if !ok {
+		a.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	sentTimes := make([]time.Time, 0, len(a.sentTimes[msg.receiver]))
+	for _, t := range a.sentTimes[msg.receiver] {
+		if now.Sub(t) < a.period {
+			sentTimes = append(sentTimes, t)
+		}
+	}
+	if len(sentTimes) >= a.limit {
+		// delay the message until the oldest one is out of the period, more messages are merged in the meantime
+		a.sentTimes[msg.receiver] = sentTimes
+		time.AfterFunc(a.period-now.Sub(sentTimes[0]), func() { a.flush(key) })
+		a.mu.Unlock()
+		return
+	}
+	a.sentTimes[msg.receiver] = append(sentTimes, now)
+	delete(a.pending, key)
+	a.mu.Unlock()
+
+	msg.message.Title, msg.message.Content = digestMessage(msg)
+	a.send(msg.receiver, msg.message, msg.log)
+}
+
+func digestMessage(msg *pendingMessage) (string, string) {
+	if len(msg.contents) == 1 {
+		return msg.message.Title, msg.contents[0]
+	}
+
+	lines := []string{fmt.Sprintf("共 %d 条消息:", len(msg.contents))}
+	for i, content := range msg.contents {
+		if i >= maxDigestItems {
+			lines = append(lines, fmt.Sprintf("... 其余 %d 条消息已省略", len(msg.contents)-maxDigestItems))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, content))
+	}
+	return fmt.Sprintf("%s (%d)", msg.message.Title, len(msg.contents)), strings.Join(lines, "\n")
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notifypref
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const quietHoursLayout = "15:04"
+
+// AllowedByUserID checks if the notification can be sent to the user, it is allowed if the user has no preference
+func AllowedByUserID(userID string, channel commonmodels.NotificationChannel, event commonmodels.NotificationEventType, projectName string) bool {
+	pref, err := commonrepo.NewNotificationPreferenceColl().FindByUserID(userID)
+	return allowed(pref, err, channel, event, projectName)
+}
+
+// AllowedByUserName is the same as AllowedByUserID, for the notifications whose receivers are identified by the user names
+func AllowedByUserName(userName string, channel commonmodels.NotificationChannel, event commonmodels.NotificationEventType, projectName string) bool {
+	pref, err := commonrepo.NewNotificationPreferenceColl().FindByUserName(userName)
+	return allowed(pref, err, channel, event, projectName)
+}
+
+func allowed(pref *commonmodels.NotificationPreference, err error, channel commonmodels.NotificationChannel, event commonmodels.NotificationEventType, projectName string) bool {
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Warnf("failed to find notification preference, the notification is sent by default, err: %s", err)
+		}
+		return true
+	}
+	return Allowed(pref, channel, event, projectName, time.Now())
+}
+
+// Allowed checks the notification against the preference, the quiet hours only mute the channels pushing to the user
+func Allowed(pref *commonmodels.NotificationPreference, channel commonmodels.NotificationChannel, event commonmodels.NotificationEventType, projectName string, now time.Time) bool {
+	if pref == nil {
+		return true
+	}
+	if len(pref.Channels) > 0 && !containsChannel(pref.Channels, channel) {
+		return false
+	}
+	if len(pref.Events) > 0 && !containsEvent(pref.Events, event) {
+		return false
+	}
+	if projectName != "" {
+		for _, project := range pref.MutedProjects {
+			if project == projectName {
+				return false
+			}
+		}
+	}
+	if channel != commonmodels.NotificationChannelInApp && InQuietHours(pref.QuietHours, now) {
+		return false
+	}
+	return true
+}
+
+// InQuietHours checks if the time is in the quiet hours, invalid quiet hours are ignored
+func InQuietHours(quietHours *commonmodels.QuietHours, now time.Time) bool {
+	if quietHours == nil || !quietHours.Enabled {
+		return false
+	}
+	if err := ValidateQuietHours(quietHours); err != nil {
+		return false
+	}
+
+	if quietHours.Timezone != "" {
+		loc, _ := time.LoadLocation(quietHours.Timezone)
+		now = now.In(loc)
+	}
+	start, _ := time.Parse(quietHoursLayout, quietHours.Start)
+	end, _ := time.Parse(quietHoursLayout, quietHours.End)
+	minutes := now.Hour()*60 + now.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+	if startMinutes <= endMinutes {
+		return minutes >= startMinutes && minutes < endMinutes
+	}
+	return minutes >= startMinutes || minutes < endMinutes
+}
+
+func ValidateQuietHours(quietHours *commonmodels.QuietHours) error {
+	if quietHours == nil || !quietHours.Enabled {
+		return nil
+	}
+	if _, err := time.Parse(quietHoursLayout, quietHours.Start); err != nil {
+		return fmt.Errorf("invalid start time %q of quiet hours, it should be HH:mm", quietHours.Start)
+	}
+	if _, err := time.Parse(quietHoursLayout, quietHours.End); err != nil {
+		return fmt.Errorf("invalid end time %q of quiet hours, it should be HH:mm", quietHours.End)
+	}
+	if quietHours.Timezone != "" {
+		if _, err := time.LoadLocation(quietHours.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q of quiet hours", quietHours.Timezone)
+		}
+	}
+	return nil
+}
+
+func containsChannel(channels []commonmodels.NotificationChannel, channel commonmodels.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEvent(events []commonmodels.NotificationEventType, event commonmodels.NotificationEventType) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package paramstore
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const (
+	// VariableKey is the key of the parameters in the template context, they are referenced by `{{.store.KEY}}`
+	VariableKey = "store"
+)
+
+var (
+	KeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+	refRegex = regexp.MustCompile(`\{\{\.store\.([A-Za-z_][A-Za-z0-9_]{0,63})\}\}`)
+)
+
+func WorkflowUsage(projectName, workflowName string) string {
+	return fmt.Sprintf("workflow:%s/%s", projectName, workflowName)
+}
+
+func EnvUsage(projectName, envName string) string {
+	return fmt.Sprintf("env:%s/%s", projectName, envName)
+}
+
+// ReferencedKeys returns the keys of the parameters referenced in the content
+func ReferencedKeys(content string) []string {
+	keys := sets.NewString()
+	for _, match := range refRegex.FindAllStringSubmatch(content, -1) {
+		keys.Insert(match[1])
+	}
+	return keys.List()
+}
+
+// Resolve finds the parameters of the keys for the project, the project level parameters override the system level ones.
+// The usage is recorded on the resolved parameters, all the keys must be found.
+func Resolve(projectName string, keys []string, usage string) (map[string]*commonmodels.ParameterStoreItem, error) {
+	resp := make(map[string]*commonmodels.ParameterStoreItem)
+	if len(keys) == 0 {
+		return resp, nil
+	}
+
+	items, err := commonrepo.NewParameterStoreColl().List([]string{"", projectName}, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list parameters, err: %w", err)
+	}
+	for _, item := range items {
+		if cur, ok := resp[item.Key]; ok && cur.ProjectName != "" {
+			continue
+		}
+		resp[item.Key] = item
+	}
+
+	missing := make([]string, 0)
+	ids := make([]primitive.ObjectID, 0, len(resp))
+	for _, key := range keys {
+		item, ok := resp[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		ids = append(ids, item.ID)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("parameters %s not found in the parameter store", strings.Join(missing, ","))
+	}
+
+	if usage != "" {
+		if err := commonrepo.NewParameterStoreColl().RecordUsage(ids, usage); err != nil {
+			log.Warnf("failed to record usage %s of parameters %v, err: %s", usage, keys, err)
+		}
+	}
+	return resp, nil
+}
+
+// RenderContent replaces the parameters referenced in the content with their values. The references are replaced
+// before the content is rendered with the other variables, so the values in the store always take effect.
+func RenderContent(projectName, content, usage string) (string, error) {
+	keys := ReferencedKeys(content)
+	if len(keys) == 0 {
+		return content, nil
+	}
+
+	items, err := Resolve(projectName, keys, usage)
+	if err != nil {
+		return "", err
+	}
+	return refRegex.ReplaceAllStringFunc(content, func(ref string) string {
+		return items[refRegex.FindStringSubmatch(ref)[1]].Value
+	}), nil
+}
+
+// TypedValue converts the value of the parameter by its type
+func TypedValue(item *commonmodels.ParameterStoreItem) (interface{}, error) {
+	switch item.Type {
+	case commonmodels.ParameterValueTypeNumber:
+		if v, err := strconv.ParseInt(item.Value, 10, 64); err == nil {
+			return v, nil
+		}
+		v, err := strconv.ParseFloat(item.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value of parameter %s is not a number", item.Key)
+		}
+		return v, nil
+	case commonmodels.ParameterValueTypeBool:
+		v, err := strconv.ParseBool(item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("value of parameter %s is not a bool", item.Key)
+		}
+		return v, nil
+	default:
+		return item.Value, nil
+	}
+}
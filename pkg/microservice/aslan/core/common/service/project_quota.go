@@ -0,0 +1,245 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// BuildMinutesJobTypes are the job types running in the job pods, their durations are counted as build minutes
+var BuildMinutesJobTypes = []string{
+	string(config.JobZadigBuild),
+	string(config.JobFreestyle),
+	string(config.JobZadigTesting),
+	string(config.JobZadigScanning),
+}
+
+type ProjectQuotaUsage struct {
+	ProjectName                string `json:"project_name"`
+	MaxEnvs                    int    `json:"max_envs"`
+	Envs                       int    `json:"envs"`
+	MaxConcurrentWorkflowTasks int    `json:"max_concurrent_workflow_tasks"`
+	ConcurrentWorkflowTasks    int64  `json:"concurrent_workflow_tasks"`
+	MaxBuildMinutesPerMonth    int64  `json:"max_build_minutes_per_month"`
+	BuildMinutes               int64  `json:"build_minutes"`
+}
+
+func GetProjectQuota(projectName string) (*commonmodels.ProjectQuota, error) {
+	quota, err := commonrepo.NewProjectQuotaColl().Find(projectName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &commonmodels.ProjectQuota{ProjectName: projectName}, nil
+		}
+		return nil, e.ErrGetProjectQuota.AddErr(err)
+	}
+	return quota, nil
+}
+
+func UpdateProjectQuota(projectName, userName string, quota *commonmodels.ProjectQuota, logger *zap.SugaredLogger) error {
+	if quota.MaxEnvs < 0 || quota.MaxConcurrentWorkflowTasks < 0 || quota.MaxBuildMinutesPerMonth < 0 {
+		return e.ErrInvalidParam.AddDesc("quota can not be negative")
+	}
+	if _, err := templaterepo.NewProductColl().Find(projectName); err != nil {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("project %s not found", projectName))
+	}
+	quota.ProjectName = projectName
+	quota.UpdatedBy = userName
+	if err := commonrepo.NewProjectQuotaColl().Upsert(quota); err != nil {
+		logger.Errorf("update quota of project %s error: %v", projectName, err)
+		return e.ErrUpdateProjectQuota.AddErr(err)
+	}
+	return nil
+}
+
+// GetProjectQuotaUsage returns the quota and the current usage of the project
+func GetProjectQuotaUsage(projectName string, logger *zap.SugaredLogger) (*ProjectQuotaUsage, error) {
+	quota, err := GetProjectQuota(projectName)
+	if err != nil {
+		return nil, err
+	}
+	envs, err := commonrepo.NewProductColl().Count(projectName)
+	if err != nil {
+		logger.Errorf("count envs of project %s error: %v", projectName, err)
+		return nil, e.ErrGetProjectQuota.AddErr(err)
+	}
+	tasks, err := commonrepo.NewworkflowTaskv4Coll().CountInCompletedTasksByProject(projectName)
+	if err != nil {
+		logger.Errorf("count incompleted workflow tasks of project %s error: %v", projectName, err)
+		return nil, e.ErrGetProjectQuota.AddErr(err)
+	}
+	minutes, err := getProjectBuildMinutes(projectName)
+	if err != nil {
+		logger.Errorf("get build minutes of project %s error: %v", projectName, err)
+		return nil, e.ErrGetProjectQuota.AddErr(err)
+	}
+	return &ProjectQuotaUsage{
+		ProjectName:                projectName,
+		MaxEnvs:                    quota.MaxEnvs,
+		Envs:                       envs,
+		MaxConcurrentWorkflowTasks: quota.MaxConcurrentWorkflowTasks,
+		ConcurrentWorkflowTasks:    tasks,
+		MaxBuildMinutesPerMonth:    quota.MaxBuildMinutesPerMonth,
+		BuildMinutes:               minutes,
+	}, nil
+}
+
+// ListProjectQuotaUsage returns the quotas and the current usages of all projects
+func ListProjectQuotaUsage(logger *zap.SugaredLogger) ([]*ProjectQuotaUsage, error) {
+	projects, err := templaterepo.NewProductColl().ListAllName()
+	if err != nil {
+		logger.Errorf("list projects error: %v", err)
+		return nil, e.ErrListProjectQuotaUsage.AddErr(err)
+	}
+	quotas, err := commonrepo.NewProjectQuotaColl().List()
+	if err != nil {
+		logger.Errorf("list project quotas error: %v", err)
+		return nil, e.ErrListProjectQuotaUsage.AddErr(err)
+	}
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{ExcludeStatus: []string{setting.ProductStatusDeleting}})
+	if err != nil {
+		logger.Errorf("list envs error: %v", err)
+		return nil, e.ErrListProjectQuotaUsage.AddErr(err)
+	}
+	tasks, err := commonrepo.NewworkflowTaskv4Coll().InCompletedTasks()
+	if err != nil {
+		logger.Errorf("list incompleted workflow tasks error: %v", err)
+		return nil, e.ErrListProjectQuotaUsage.AddErr(err)
+	}
+	month := time.Now().Format("2006-01")
+	jobUsages, err := commonrepo.NewJobUsageRecordColl().GetUsage(&commonrepo.JobUsageQueryOption{
+		JobTypes:   BuildMinutesJobTypes,
+		StartMonth: month,
+		EndMonth:   month,
+	})
+	if err != nil {
+		logger.Errorf("get job usages error: %v", err)
+		return nil, e.ErrListProjectQuotaUsage.AddErr(err)
+	}
+
+	usageMap := make(map[string]*ProjectQuotaUsage)
+	resp := make([]*ProjectQuotaUsage, 0, len(projects))
+	for _, project := range projects {
+		usage := &ProjectQuotaUsage{ProjectName: project}
+		usageMap[project] = usage
+		resp = append(resp, usage)
+	}
+	for _, quota := range quotas {
+		if usage, ok := usageMap[quota.ProjectName]; ok {
+			usage.MaxEnvs = quota.MaxEnvs
+			usage.MaxConcurrentWorkflowTasks = quota.MaxConcurrentWorkflowTasks
+			usage.MaxBuildMinutesPerMonth = quota.MaxBuildMinutesPerMonth
+		}
+	}
+	for _, env := range envs {
+		if usage, ok := usageMap[env.ProductName]; ok {
+			usage.Envs++
+		}
+	}
+	for _, task := range tasks {
+		if usage, ok := usageMap[task.ProjectName]; ok {
+			usage.ConcurrentWorkflowTasks++
+		}
+	}
+	buildSeconds := make(map[string]int64)
+	for _, jobUsage := range jobUsages {
+		buildSeconds[jobUsage.ProjectName] += jobUsage.Duration
+	}
+	for project, seconds := range buildSeconds {
+		if usage, ok := usageMap[project]; ok {
+			usage.BuildMinutes = seconds / 60
+		}
+	}
+	return resp, nil
+}
+
+// CheckProjectEnvQuota returns an error if a new environment can not be created in the project
+func CheckProjectEnvQuota(projectName string) error {
+	quota, err := GetProjectQuota(projectName)
+	if err != nil {
+		return err
+	}
+	if quota.MaxEnvs <= 0 {
+		return nil
+	}
+	count, err := commonrepo.NewProductColl().Count(projectName)
+	if err != nil {
+		return e.ErrGetProjectQuota.AddErr(err)
+	}
+	if count >= quota.MaxEnvs {
+		return e.ErrProjectQuotaExceeded.AddDesc(fmt.Sprintf("project %s already has %d environments, the quota is %d, please delete unused environments or contact the system administrator to raise the quota", projectName, count, quota.MaxEnvs))
+	}
+	return nil
+}
+
+// CheckProjectWorkflowTaskQuota returns an error if a new workflow task can not be triggered in the project
+func CheckProjectWorkflowTaskQuota(projectName string) error {
+	quota, err := GetProjectQuota(projectName)
+	if err != nil {
+		return err
+	}
+
+	if quota.MaxConcurrentWorkflowTasks > 0 {
+		count, err := commonrepo.NewworkflowTaskv4Coll().CountInCompletedTasksByProject(projectName)
+		if err != nil {
+			return e.ErrGetProjectQuota.AddErr(err)
+		}
+		if count >= int64(quota.MaxConcurrentWorkflowTasks) {
+			return e.ErrProjectQuotaExceeded.AddDesc(fmt.Sprintf("project %s already has %d running or queued workflow tasks, the quota is %d, please retry after some tasks finish", projectName, count, quota.MaxConcurrentWorkflowTasks))
+		}
+	}
+
+	if quota.MaxBuildMinutesPerMonth > 0 {
+		minutes, err := getProjectBuildMinutes(projectName)
+		if err != nil {
+			return e.ErrGetProjectQuota.AddErr(err)
+		}
+		if minutes >= quota.MaxBuildMinutesPerMonth {
+			return e.ErrProjectQuotaExceeded.AddDesc(fmt.Sprintf("project %s has used %d build minutes this month, the quota is %d, please contact the system administrator to raise the quota", projectName, minutes, quota.MaxBuildMinutesPerMonth))
+		}
+	}
+	return nil
+}
+
+// getProjectBuildMinutes returns the build minutes used by the project in the current month
+func getProjectBuildMinutes(projectName string) (int64, error) {
+	month := time.Now().Format("2006-01")
+	jobUsages, err := commonrepo.NewJobUsageRecordColl().GetUsage(&commonrepo.JobUsageQueryOption{
+		ProjectNames: []string{projectName},
+		JobTypes:     BuildMinutesJobTypes,
+		StartMonth:   month,
+		EndMonth:     month,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var seconds int64
+	for _, jobUsage := range jobUsages {
+		seconds += jobUsage.Duration
+	}
+	return seconds / 60, nil
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/docker/distribution/registry/client"
+	"github.com/docker/distribution/registry/client/auth"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/tool/harbor"
+)
+
+// TagDetail is the metadata of a tag in the repository, PushedTime is the image creation time for the registries
+// which don't record the push time
+type TagDetail struct {
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
+	Size       int64  `json:"size"`
+	PushedTime int64  `json:"pushed_time"`
+}
+
+// Browser browses the repositories and the tags of the registry and removes the tags, the repository names are
+// relative to the namespace of the endpoint
+type Browser interface {
+	ListRepositories(ep Endpoint, log *zap.SugaredLogger) ([]string, error)
+	ListTags(ep Endpoint, repo string, log *zap.SugaredLogger) ([]*TagDetail, error)
+	DeleteTag(ep Endpoint, repo string, tag *TagDetail, log *zap.SugaredLogger) error
+}
+
+func NewBrowser(provider string, tlsEnabled bool, tlsCert string) Browser {
+	switch provider {
+	case config.RegistryProviderHarbor:
+		return &harborBrowser{insecure: !tlsEnabled}
+	case config.RegistryTypeAWS:
+		return &ecrService{}
+	default:
+		return &v2RegistryService{
+			EnableHTTPS: tlsEnabled,
+			CustomCert:  tlsCert,
+		}
+	}
+}
+
+type harborBrowser struct {
+	insecure bool
+}
+
+func (b *harborBrowser) ListRepositories(ep Endpoint, log *zap.SugaredLogger) ([]string, error) {
+	repos, err := harbor.NewClient(ep.Addr, ep.Ak, ep.Sk, b.insecure).ListRepositories(ep.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		ret = append(ret, strings.TrimPrefix(repo.Name, ep.Namespace+"/"))
+	}
+	return ret, nil
+}
+
+func (b *harborBrowser) ListTags(ep Endpoint, repo string, log *zap.SugaredLogger) ([]*TagDetail, error) {
+	artifacts, err := harbor.NewClient(ep.Addr, ep.Ak, ep.Sk, b.insecure).ListArtifacts(ep.Namespace, repo)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*TagDetail, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		for _, tag := range artifact.Tags {
+			ret = append(ret, &TagDetail{
+				Tag:        tag.Name,
+				Digest:     artifact.Digest,
+				Size:       artifact.Size,
+				PushedTime: tag.PushTime.Unix(),
+			})
+		}
+	}
+	return ret, nil
+}
+
+func (b *harborBrowser) DeleteTag(ep Endpoint, repo string, tag *TagDetail, log *zap.SugaredLogger) error {
+	reference := tag.Digest
+	if reference == "" {
+		reference = tag.Tag
+	}
+	return harbor.NewClient(ep.Addr, ep.Ak, ep.Sk, b.insecure).DeleteTag(ep.Namespace, repo, reference, tag.Tag)
+}
+
+// ListRepositories lists the repositories in the namespace through the catalog api, the account must be permitted
+// to access the catalog
+func (s *v2RegistryService) ListRepositories(ep Endpoint, log *zap.SugaredLogger) ([]string, error) {
+	cli, err := s.createClient(ep, log)
+	if err != nil {
+		return nil, err
+	}
+	reg, err := client.NewRegistry(cli.endpointURL.String(), cli.scopedTransport(auth.RegistryScope{Name: "catalog", Actions: []string{"*"}}))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]string, 0)
+	prefix := ep.Namespace + "/"
+	last := ""
+	for {
+		entries := make([]string, 100)
+		n, err := reg.Repositories(cli.ctx, entries, last)
+		for _, entry := range entries[:n] {
+			if strings.HasPrefix(entry, prefix) {
+				ret = append(ret, strings.TrimPrefix(entry, prefix))
+			}
+		}
+		if err == io.EOF || n == 0 {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the catalog")
+		}
+		last = entries[n-1]
+	}
+}
+
+// ListTags lists the tags with the manifest metadata, the tags whose manifests are not docker schema2 are listed
+// without the metadata
+func (s *v2RegistryService) ListTags(ep Endpoint, repo string, log *zap.SugaredLogger) ([]*TagDetail, error) {
+	cli, err := s.createClient(ep, log)
+	if err != nil {
+		return nil, err
+	}
+	repoName := ep.Namespace + "/" + repo
+	tags, err := cli.listTags(repoName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list tags of %s", repoName)
+	}
+
+	ret := make([]*TagDetail, 0, len(tags))
+	for _, tag := range tags {
+		detail := &TagDetail{Tag: tag}
+		ci, err := cli.getImageInfo(repoName, tag)
+		if err != nil {
+			log.Warnf("failed to get image info of %s:%s, err: %s", repoName, tag, err)
+		} else {
+			detail.Digest = ci.Digest.String()
+			detail.Size = ci.Size
+			if created, err := time.Parse(time.RFC3339Nano, ci.Created); err == nil {
+				detail.PushedTime = created.Unix()
+			}
+		}
+		ret = append(ret, detail)
+	}
+	return ret, nil
+}
+
+// DeleteTag deletes the manifest of the tag, note that the other tags of the same manifest are deleted as well
+// since the registry api only supports deleting the manifests
+func (s *v2RegistryService) DeleteTag(ep Endpoint, repo string, tag *TagDetail, log *zap.SugaredLogger) error {
+	if tag.Digest == "" {
+		return errors.Errorf("digest of tag %s is unknown", tag.Tag)
+	}
+	cli, err := s.createClient(ep, log)
+	if err != nil {
+		return err
+	}
+	repoName := ep.Namespace + "/" + repo
+	repository, err := cli.getRepository(repoName, "pull", "delete")
+	if err != nil {
+		return err
+	}
+	manifestService, err := repository.Manifests(cli.ctx)
+	if err != nil {
+		return err
+	}
+	if err := manifestService.Delete(cli.ctx, digest.Digest(tag.Digest)); err != nil {
+		return errors.Wrapf(err, "failed to delete %s:%s", repoName, tag.Tag)
+	}
+	return nil
+}
+
+func (s *ecrService) ListRepositories(ep Endpoint, log *zap.SugaredLogger) ([]string, error) {
+	svc, err := s.getECRService(ep, log)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0)
+	err = svc.DescribeRepositoriesPagesWithContext(context.TODO(), &ecr.DescribeRepositoriesInput{}, func(output *ecr.DescribeRepositoriesOutput, _ bool) bool {
+		for _, repo := range output.Repositories {
+			ret = append(ret, aws.StringValue(repo.RepositoryName))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe repositories")
+	}
+	return ret, nil
+}
+
+func (s *ecrService) ListTags(ep Endpoint, repo string, log *zap.SugaredLogger) ([]*TagDetail, error) {
+	svc, err := s.getECRService(ep, log)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*TagDetail, 0)
+	input := &ecr.DescribeImagesInput{
+		RepositoryName: aws.String(repo),
+		Filter:         &ecr.DescribeImagesFilter{TagStatus: aws.String(ecr.TagStatusTagged)},
+	}
+	err = svc.DescribeImagesPagesWithContext(context.TODO(), input, func(output *ecr.DescribeImagesOutput, _ bool) bool {
+		for _, image := range output.ImageDetails {
+			for _, tag := range image.ImageTags {
+				detail := &TagDetail{
+					Tag:    aws.StringValue(tag),
+					Digest: aws.StringValue(image.ImageDigest),
+					Size:   aws.Int64Value(image.ImageSizeInBytes),
+				}
+				if image.ImagePushedAt != nil {
+					detail.PushedTime = image.ImagePushedAt.Unix()
+				}
+				ret = append(ret, detail)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe images of %s", repo)
+	}
+	return ret, nil
+}
+
+func (s *ecrService) DeleteTag(ep Endpoint, repo string, tag *TagDetail, log *zap.SugaredLogger) error {
+	svc, err := s.getECRService(ep, log)
+	if err != nil {
+		return err
+	}
+	output, err := svc.BatchDeleteImage(&ecr.BatchDeleteImageInput{
+		RepositoryName: aws.String(repo),
+		ImageIds:       []*ecr.ImageIdentifier{{ImageTag: aws.String(tag.Tag)}},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s:%s", repo, tag.Tag)
+	}
+	if len(output.Failures) > 0 {
+		failure := output.Failures[0]
+		return errors.Errorf("failed to delete %s:%s, %s: %s", repo, tag.Tag, aws.StringValue(failure.FailureCode), aws.StringValue(failure.FailureReason))
+	}
+	return nil
+}
@@ -174,12 +174,31 @@ func (s *v2RegistryService) createClient(ep Endpoint, logger *zap.SugaredLogger)
 	return
 }
 
-func (c *authClient) getRepository(repoName string) (repo distribution.Repository, err error) {
+func (c *authClient) getRepository(repoName string, actions ...string) (repo distribution.Repository, err error) {
 	repoNameRef, err := reference.WithName(repoName)
 	if err != nil {
 		return
 	}
 
+	if len(actions) == 0 {
+		actions = []string{"pull"}
+	}
+	scope := auth.RepositoryScope{
+		Repository: repoName,
+		Actions:    actions,
+		Class:      "",
+	}
+
+	repo, err = client.NewRepository(repoNameRef, c.endpointURL.String(), c.scopedTransport(scope))
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// scopedTransport returns the transport authorized with the basic auth or the token of the scope
+func (c *authClient) scopedTransport(scope auth.Scope) http.RoundTripper {
 	creds := registry.NewStaticCredentialStore(&types.AuthConfig{
 		Username:      c.endpoint.Ak,
 		Password:      c.endpoint.Sk,
@@ -187,12 +206,6 @@ func (c *authClient) getRepository(repoName string) (repo distribution.Repositor
 	})
 
 	basicHandler := auth.NewBasicHandler(creds)
-	scope := auth.RepositoryScope{
-		Repository: repoName,
-		Actions:    []string{"pull"},
-		Class:      "",
-	}
-
 	tokenHandlerOptions := auth.TokenHandlerOptions{
 		Transport:   c.tr,
 		Credentials: creds,
@@ -202,14 +215,7 @@ func (c *authClient) getRepository(repoName string) (repo distribution.Repositor
 
 	tokenHandler := auth.NewTokenHandlerWithOptions(tokenHandlerOptions)
 	modifier := auth.NewAuthorizer(c.cm, tokenHandler, basicHandler)
-	tr := transport.NewTransport(c.tr, modifier)
-
-	repo, err = client.NewRepository(repoNameRef, c.endpointURL.String(), tr)
-	if err != nil {
-		return
-	}
-
-	return
+	return transport.NewTransport(c.tr, modifier)
 }
 
 func (c *authClient) listTags(repoName string) (tags []string, err error) {
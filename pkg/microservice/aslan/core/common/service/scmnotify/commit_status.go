@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package scmnotify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/v2/pkg/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/github"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/shared/client/systemconfig"
+	gitlabtool "github.com/koderover/zadig/v2/pkg/tool/git/gitlab"
+)
+
+// UpdateCommitStatusForWorkflowV4Stage reports the status of the stage to the commit which triggers the task,
+// github and gitlab are supported
+func (s *Service) UpdateCommitStatusForWorkflowV4Stage(task *models.WorkflowTask, stage *models.StageTask, log *zap.SugaredLogger) error {
+	workflowArgs := task.WorkflowArgs
+	if workflowArgs == nil || workflowArgs.CommitStatusReport == nil || !workflowArgs.CommitStatusReport.Enabled {
+		return nil
+	}
+	hook := workflowArgs.HookPayload
+	if hook == nil || hook.CodehostID == 0 || hook.Owner == "" || hook.Repo == "" {
+		return nil
+	}
+	sha := hook.CommitID
+	if sha == "" {
+		sha = hook.Ref
+	}
+	if sha == "" {
+		return nil
+	}
+
+	ch, err := systemconfig.New().GetCodeHost(hook.CodehostID)
+	if err != nil {
+		log.Errorf("Failed to get codeHost, err:%v", err)
+		return err
+	}
+
+	statusContext := fmt.Sprintf("%s/%s/%s", setting.ProductName, getDisplayName(workflowArgs), stage.Name)
+	description := fmt.Sprintf("Stage [%s] is %s.", stage.Name, stageStatusDescription(stage.Status))
+	taskLink := github.GetTaskLink(configbase.SystemAddress(), task.ProjectName, task.WorkflowName, task.WorkflowDisplayName, config.WorkflowTypeV4, task.TaskID)
+
+	switch strings.ToLower(ch.Type) {
+	case setting.SourceFromGithub:
+		gc := github.NewClient(ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+		return gc.UpdateCheckStatus(&github.StatusOptions{
+			Owner:       hook.Owner,
+			Repo:        hook.Repo,
+			Ref:         sha,
+			State:       getGitHubStatusFromStageStatus(stage.Status),
+			Description: description,
+			Context:     statusContext,
+			AslanURL:    configbase.SystemAddress(),
+			PipeName:    task.WorkflowName,
+			DisplayName: task.WorkflowDisplayName,
+			ProductName: task.ProjectName,
+			PipeType:    config.WorkflowTypeV4,
+			TaskID:      task.TaskID,
+		})
+	case setting.SourceFromGitlab:
+		cli, err := gitlabtool.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+		if err != nil {
+			log.Errorf("create gitlab client failed err: %v", err)
+			return err
+		}
+		return cli.SetCommitStatus(hook.Owner, hook.Repo, sha, &gitlab.SetCommitStatusOptions{
+			State:       getGitlabStateFromStageStatus(stage.Status),
+			Name:        gitlab.String(statusContext),
+			TargetURL:   gitlab.String(taskLink),
+			Description: gitlab.String(description),
+		})
+	default:
+		return nil
+	}
+}
+
+func stageStatusDescription(status config.Status) string {
+	if status == "" || status == config.StatusCreated {
+		return "queued"
+	}
+	return string(status)
+}
+
+func getGitHubStatusFromStageStatus(status config.Status) string {
+	switch status {
+	case config.StatusPassed, config.StatusSkipped:
+		return github.StateSuccess
+	case config.StatusFailed, config.StatusTimeout, config.StatusReject:
+		return github.StateFailure
+	case config.StatusCancelled:
+		return github.StateError
+	default:
+		return github.StatePending
+	}
+}
+
+func getGitlabStateFromStageStatus(status config.Status) gitlab.BuildStateValue {
+	switch status {
+	case config.StatusPassed:
+		return gitlab.Success
+	case config.StatusFailed, config.StatusTimeout, config.StatusReject:
+		return gitlab.Failed
+	case config.StatusCancelled:
+		return gitlab.Canceled
+	case config.StatusSkipped:
+		return gitlab.Skipped
+	case config.StatusRunning:
+		return gitlab.Running
+	default:
+		return gitlab.Pending
+	}
+}
@@ -1330,6 +1330,8 @@ type SvcResp struct {
 	ProductName string                       `json:"product_name"`
 	GroupName   string                       `json:"group_name"`
 	Workloads   []*Workload                  `json:"-"`
+	// DashboardLinks are the rendered dashboard links of the service
+	DashboardLinks []*DashboardLinkResp `json:"dashboard_links"`
 }
 
 func GetServiceImpl(serviceName string, serviceTmpl *commonmodels.Service, workLoadType string, env *commonmodels.Product, clientset *kubernetes.Clientset, inf informers.SharedInformerFactory, log *zap.SugaredLogger) (ret *SvcResp, err error) {
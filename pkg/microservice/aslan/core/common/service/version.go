@@ -417,6 +417,7 @@ func RollbackEnvServiceVersion(ctx *internalhandler.Context, projectName, envNam
 				title := fmt.Sprintf("回滚 %s/%s 环境 %s 服务失败", projectName, envName, serviceName)
 				notify.SendErrorMessage(ctx.UserName, title, ctx.RequestID, err, log)
 				done <- false
+				return
 			}
 			done <- true
 		}(rollbackStatus.HelmDeployStatusChan)
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jobcontroller
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	s3tool "github.com/koderover/zadig/v2/pkg/tool/s3"
+	"github.com/koderover/zadig/v2/pkg/types/step"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+// saveJobArtifacts records the files uploaded by the archive steps of the job, so they can be browsed
+// and cleaned up by the retention policy of the project
+func saveJobArtifacts(job *commonmodels.JobTask, jobTaskSpec *commonmodels.JobTaskFreestyleSpec, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	envMap := make(map[string]string)
+	for _, env := range jobTaskSpec.Properties.Envs {
+		envMap[env.Key] = env.Value
+	}
+
+	var expireTime int64
+	policy, err := mongodb.NewArtifactRetentionPolicyColl().Find(workflowCtx.ProjectName)
+	if err == nil && policy.RetentionDays > 0 {
+		expireTime = time.Now().AddDate(0, 0, policy.RetentionDays).Unix()
+	}
+	// tolerate the clock skew between object storage and aslan
+	since := time.Unix(job.StartTime, 0).Add(-time.Minute)
+
+	artifacts := make([]*commonmodels.WorkflowTaskArtifact, 0)
+	for _, stepTask := range jobTaskSpec.Steps {
+		if stepTask.StepType != config.StepArchive {
+			continue
+		}
+		yamlString, err := yaml.Marshal(stepTask.Spec)
+		if err != nil {
+			logger.Errorf("marshal archive spec error: %v", err)
+			continue
+		}
+		archiveSpec := &step.StepArchiveSpec{}
+		if err := yaml.Unmarshal(yamlString, &archiveSpec); err != nil {
+			logger.Errorf("unmarshal archive spec error: %v", err)
+			continue
+		}
+		if archiveSpec.S3 == nil {
+			continue
+		}
+
+		storageID := archiveSpec.ObjectStorageID
+		if storageID == "" {
+			storage, err := mongodb.NewS3StorageColl().FindDefault()
+			if err != nil {
+				logger.Errorf("find default object storage error: %v", err)
+				continue
+			}
+			storageID = storage.ID.Hex()
+		}
+		client, err := s3tool.NewClient(archiveSpec.S3.Endpoint, archiveSpec.S3.Ak, archiveSpec.S3.Sk, archiveSpec.S3.Region, archiveSpec.S3.Insecure, archiveSpec.S3.Provider)
+		if err != nil {
+			logger.Errorf("create s3 client error: %v", err)
+			continue
+		}
+
+		for _, upload := range archiveSpec.UploadDetail {
+			if upload.DestinationPath == "" || upload.FilePath == "" {
+				continue
+			}
+			// the destination is rendered by the job executor in the same way
+			destination := util.ReplaceEnvWithValue(upload.DestinationPath, envMap)
+			if strings.Contains(destination, "$") {
+				logger.Warnf("destination %s of archive step %s can not be resolved, skip recording artifacts", upload.DestinationPath, stepTask.Name)
+				continue
+			}
+			prefix := strings.TrimLeft(path.Join(archiveSpec.S3.Subfolder, destination), "/") + "/"
+
+			objects, err := client.ListObjectsInfo(archiveSpec.S3.Bucket, prefix)
+			if err != nil {
+				logger.Errorf("list artifacts of %s error: %v", prefix, err)
+				continue
+			}
+			for _, object := range objects {
+				if object.LastModified.Before(since) {
+					continue
+				}
+				artifacts = append(artifacts, &commonmodels.WorkflowTaskArtifact{
+					ProjectName:    workflowCtx.ProjectName,
+					WorkflowName:   workflowCtx.WorkflowName,
+					TaskID:         workflowCtx.TaskID,
+					JobName:        job.Name,
+					JobDisplayName: job.DisplayName,
+					Name:           strings.TrimPrefix(object.Key, prefix),
+					StorageID:      storageID,
+					ObjectKey:      object.Key,
+					Size:           object.Size,
+					ExpireTime:     expireTime,
+				})
+			}
+		}
+	}
+
+	if err := mongodb.NewWorkflowTaskArtifactColl().BulkCreate(artifacts); err != nil {
+		logger.Errorf("save artifacts of job %s error: %v", job.Name, err)
+	}
+}
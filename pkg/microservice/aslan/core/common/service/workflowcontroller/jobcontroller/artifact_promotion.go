@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/types/step"
+)
+
+// saveBuildPromotions records the images built and distributed by the steps of the passed job, the digest of the
+// built image comes from the delivery artifact and the distributed image keeps the digest of its source image
+func saveBuildPromotions(job *commonmodels.JobTask, jobTaskSpec *commonmodels.JobTaskFreestyleSpec, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	if job.Status != config.StatusPassed {
+		return
+	}
+	envMap := make(map[string]string)
+	for _, env := range jobTaskSpec.Properties.Envs {
+		envMap[env.Key] = env.Value
+	}
+
+	for _, stepTask := range jobTaskSpec.Steps {
+		switch stepTask.StepType {
+		case config.StepDockerBuild:
+			buildSpec := &step.StepDockerBuildSpec{}
+			if err := convertStepSpec(stepTask.Spec, buildSpec); err != nil {
+				logger.Errorf("failed to parse docker build spec, err: %s", err)
+				continue
+			}
+			var digest string
+			if artifact, err := mongodb.NewDeliveryArtifactColl().Get(&mongodb.DeliveryArtifactArgs{Image: buildSpec.ImageName}); err == nil {
+				digest = artifact.ImageDigest
+			}
+			savePromotion(&commonmodels.ArtifactPromotion{
+				Stage:         commonmodels.ArtifactPromotionStageBuild,
+				ImageDigest:   digest,
+				Image:         buildSpec.ImageName,
+				ServiceName:   envMap["SERVICE_NAME"],
+				ServiceModule: envMap["SERVICE_MODULE"],
+			}, job, workflowCtx, logger)
+		case config.StepDistributeImage:
+			distributeSpec := &step.StepImageDistributeSpec{}
+			if err := convertStepSpec(stepTask.Spec, distributeSpec); err != nil {
+				logger.Errorf("failed to parse image distribute spec, err: %s", err)
+				continue
+			}
+			for _, target := range distributeSpec.DistributeTarget {
+				savePromotion(&commonmodels.ArtifactPromotion{
+					Stage:         commonmodels.ArtifactPromotionStageDistribute,
+					ImageDigest:   getImageDigest(target.SourceImage, logger),
+					Image:         target.TargetImage,
+					SourceImage:   target.SourceImage,
+					ServiceName:   target.ServiceName,
+					ServiceModule: target.ServiceModule,
+				}, job, workflowCtx, logger)
+			}
+		}
+	}
+}
+
+// saveDeployPromotions records the images deployed to the env by the passed deploy job
+func saveDeployPromotions(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, envName string, production bool, serviceName string, images map[string]string, logger *zap.SugaredLogger) {
+	if job.Status != config.StatusPassed {
+		return
+	}
+	for serviceModule, image := range images {
+		savePromotion(&commonmodels.ArtifactPromotion{
+			Stage:         commonmodels.ArtifactPromotionStageDeploy,
+			ImageDigest:   getImageDigest(image, logger),
+			Image:         image,
+			ServiceName:   serviceName,
+			ServiceModule: serviceModule,
+			EnvName:       envName,
+			Production:    production,
+		}, job, workflowCtx, logger)
+	}
+}
+
+func savePromotion(promotion *commonmodels.ArtifactPromotion, job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	if promotion.Image == "" {
+		return
+	}
+	promotion.ProjectName = workflowCtx.ProjectName
+	promotion.WorkflowName = workflowCtx.WorkflowName
+	promotion.TaskID = workflowCtx.TaskID
+	promotion.JobName = job.Name
+	promotion.CreatedBy = workflowCtx.WorkflowTaskCreatorUsername
+	if err := mongodb.NewArtifactPromotionColl().Create(promotion); err != nil {
+		logger.Errorf("failed to save the %s promotion of image %s, err: %s", promotion.Stage, promotion.Image, err)
+	}
+}
+
+func getImageDigest(image string, logger *zap.SugaredLogger) string {
+	digest, err := mongodb.NewArtifactPromotionColl().GetDigestByImage(image)
+	if err != nil {
+		logger.Warnf("failed to get the digest of image %s, err: %s", image, err)
+	}
+	return digest
+}
+
+func convertStepSpec(spec, out interface{}) error {
+	yamlString, err := yaml.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(yamlString, out)
+}
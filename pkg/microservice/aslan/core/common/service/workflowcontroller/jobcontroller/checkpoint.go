@@ -0,0 +1,113 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/types/job"
+)
+
+// LastCheckpointEnv is the env holding the merged progress of the previous pods of the job, the test can use it to
+// skip the cases which have been run.
+const LastCheckpointEnv = "ZADIG_LAST_CHECKPOINT"
+
+// injectLastCheckpoint passes the progress of the pods which have run the job before to the new pod
+func injectLastCheckpoint(jobTask *commonmodels.JobTask, jobTaskSpec *commonmodels.JobTaskFreestyleSpec, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	records, err := mongodb.NewJobCheckpointColl().ListByJob(workflowCtx.WorkflowName, workflowCtx.TaskID, jobTask.Name)
+	if err != nil {
+		logger.Errorf("failed to list checkpoints of job %s, error: %v", jobTask.Name, err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	checkpoints := make([]*job.JobCheckpoint, 0, len(records))
+	for _, record := range records {
+		checkpoints = append(checkpoints, &record.JobCheckpoint)
+	}
+	checkpointBytes, err := json.Marshal(job.MergeJobCheckpoints(checkpoints))
+	if err != nil {
+		logger.Errorf("failed to marshal checkpoint of job %s, error: %v", jobTask.Name, err)
+		return
+	}
+
+	for _, env := range jobTaskSpec.Properties.Envs {
+		if env.Key == LastCheckpointEnv {
+			env.Value = string(checkpointBytes)
+			return
+		}
+	}
+	jobTaskSpec.Properties.Envs = append(jobTaskSpec.Properties.Envs, &commonmodels.KeyVal{
+		Key:   LastCheckpointEnv,
+		Value: string(checkpointBytes),
+	})
+}
+
+// updateJobCheckpoint sets the checkpoint reported by the job pod to the job task
+func updateJobCheckpoint(data string, jobTask *commonmodels.JobTask, ack func(), logger *zap.SugaredLogger) {
+	checkpoint := &job.JobCheckpoint{}
+	if err := json.Unmarshal([]byte(data), checkpoint); err != nil {
+		logger.Warnf("invalid checkpoint of job %s: %v", jobTask.Name, err)
+		return
+	}
+	if jobTask.Checkpoint != nil && *jobTask.Checkpoint == *checkpoint {
+		return
+	}
+	jobTask.Checkpoint = checkpoint
+	ack()
+}
+
+// saveJobCheckpoint keeps the checkpoint of the running pod in db, so that it survives the restarts of the pod and aslan
+func saveJobCheckpoint(jobTask *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	if jobTask.Checkpoint == nil || jobTask.Checkpoint.RunID == "" {
+		return
+	}
+	err := mongodb.NewJobCheckpointColl().Upsert(&commonmodels.JobCheckpointRecord{
+		WorkflowName:  workflowCtx.WorkflowName,
+		TaskID:        workflowCtx.TaskID,
+		JobName:       jobTask.Name,
+		JobCheckpoint: *jobTask.Checkpoint,
+	})
+	if err != nil {
+		logger.Errorf("failed to save checkpoint of job %s, error: %v", jobTask.Name, err)
+	}
+}
+
+// mergeJobCheckpoint replaces the checkpoint of the last pod with the merged results of all the pods which have run the job
+func mergeJobCheckpoint(jobTask *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	saveJobCheckpoint(jobTask, workflowCtx, logger)
+
+	records, err := mongodb.NewJobCheckpointColl().ListByJob(workflowCtx.WorkflowName, workflowCtx.TaskID, jobTask.Name)
+	if err != nil {
+		logger.Errorf("failed to list checkpoints of job %s, error: %v", jobTask.Name, err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+	checkpoints := make([]*job.JobCheckpoint, 0, len(records))
+	for _, record := range records {
+		checkpoints = append(checkpoints, &record.JobCheckpoint)
+	}
+	jobTask.Checkpoint = job.MergeJobCheckpoints(checkpoints)
+}
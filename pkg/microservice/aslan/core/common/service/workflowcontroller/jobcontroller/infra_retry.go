@@ -0,0 +1,120 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+)
+
+const infraRetryInterval = 10 * time.Second
+
+// infraFailureKeywords are the lowercase keywords in the job error of each infrastructure failure category
+var infraFailureKeywords = map[string][]string{
+	commonmodels.JobFailureCategoryPodEvicted:   {"evicted", "the node was low on resource"},
+	commonmodels.JobFailureCategoryImagePull:    {"imagepullbackoff", "errimagepull", "failed to pull image", "back-off pulling image"},
+	commonmodels.JobFailureCategoryNodeNotReady: {"nodenotready", "node not ready", "node is not ready", "nodelost", "nodeshutdown"},
+}
+
+// classifyJobFailure returns the infrastructure failure category of the job error, the failure of the job itself
+// is JobFailureCategoryJob
+func classifyJobFailure(errMsg string) string {
+	errMsg = strings.ToLower(errMsg)
+	for _, category := range []string{commonmodels.JobFailureCategoryPodEvicted, commonmodels.JobFailureCategoryImagePull, commonmodels.JobFailureCategoryNodeNotReady} {
+		for _, keyword := range infraFailureKeywords[category] {
+			if strings.Contains(errMsg, keyword) {
+				return category
+			}
+		}
+	}
+	return commonmodels.JobFailureCategoryJob
+}
+
+func recordJobFailure(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, category string, logger *zap.SugaredLogger) {
+	record := &commonmodels.JobFailureRecord{
+		ProjectName:  workflowCtx.ProjectName,
+		WorkflowName: workflowCtx.WorkflowName,
+		TaskID:       workflowCtx.TaskID,
+		JobName:      job.Name,
+		JobType:      job.JobType,
+		Category:     category,
+		Error:        job.Error,
+	}
+	if spec, ok := job.Spec.(*commonmodels.JobTaskFreestyleSpec); ok {
+		record.ClusterID = spec.Properties.ClusterID
+	}
+	if err := mongodb.NewJobFailureRecordColl().Create(record); err != nil {
+		logger.Errorf("failed to record the failure of job %s, err: %s", job.Name, err)
+	}
+}
+
+// infraRetryJob requeues the job failed by infrastructure errors. The k8s jobs are moved to the fallback targets of the
+// policy in turn, the other jobs are retried in place.
+func infraRetryJob(ctx context.Context, job *commonmodels.JobTask, jobCtl JobCtl, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) {
+	policy := workflowCtx.InfraRetryPolicy
+	category := classifyJobFailure(job.Error)
+
+	for category != commonmodels.JobFailureCategoryJob && job.InfraRetryCount < policy.MaximumRetry {
+		job.InfraRetryCount++
+		job.InfraFailureReason = category
+		if spec, ok := job.Spec.(*commonmodels.JobTaskFreestyleSpec); ok && job.Infrastructure != setting.JobVMInfrastructure && len(policy.FallbackTargets) > 0 {
+			target := policy.FallbackTargets[(job.InfraRetryCount-1)%len(policy.FallbackTargets)]
+			spec.Properties.ClusterID = target.ClusterID
+			spec.Properties.StrategyID = target.StrategyID
+		}
+		if workflowCtx.TaskTagAdd != nil {
+			workflowCtx.TaskTagAdd(commonmodels.WorkflowTaskTagInfraRetried)
+		}
+		logger.Infof("requeue job %s for the %s failure, retry count: %d", job.Name, category, job.InfraRetryCount)
+
+		select {
+		case <-ctx.Done():
+			job.Status = config.StatusCancelled
+			job.Error = "controller shutdown, marking job as cancelled."
+			return
+		case <-time.After(infraRetryInterval):
+		}
+
+		job.Status = config.StatusPrepare
+		job.Error = ""
+		job.StartTime = time.Now().Unix()
+		job.K8sJobName = getJobName(workflowCtx.WorkflowName, workflowCtx.TaskID)
+		ack()
+
+		jobCtl.Run(ctx)
+
+		if job.Status == config.StatusPassed {
+			if err := mongodb.NewJobFailureRecordColl().MarkRecovered(workflowCtx.WorkflowName, workflowCtx.TaskID, job.Name); err != nil {
+				logger.Errorf("failed to mark the failures of job %s as recovered, err: %s", job.Name, err)
+			}
+			return
+		}
+		if job.Status != config.StatusFailed && job.Status != config.StatusTimeout {
+			return
+		}
+		category = classifyJobFailure(job.Error)
+		recordJobFailure(job, workflowCtx, category, logger)
+	}
+}
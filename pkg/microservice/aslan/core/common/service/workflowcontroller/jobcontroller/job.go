@@ -31,11 +31,16 @@ import (
 
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	aiservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/ai"
+	"github.com/koderover/zadig/v2/pkg/setting"
 	workflowtool "github.com/koderover/zadig/v2/pkg/tool/workflow"
 	"github.com/koderover/zadig/v2/pkg/util"
 	"github.com/koderover/zadig/v2/pkg/util/rand"
 )
 
+const failedJobDiagnosisTimeout = 2 * time.Minute
+
 type JobCtl interface {
 	Run(ctx context.Context)
 	// do some clean stuff when workflow finished, like collect reports or clean up resources.
@@ -103,12 +108,22 @@ func initJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTas
 		jobCtl = NewSQLJobCtl(job, workflowCtx, ack, logger)
 	case string(config.JobBlueKing):
 		jobCtl = NewBlueKingJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobGitHubActions):
+		jobCtl = NewGitHubActionsJobCtl(job, workflowCtx, ack, logger)
 	case string(config.JobApproval):
 		jobCtl = NewApprovalJobCtl(job, workflowCtx, ack, logger)
 	case string(config.JobNotification):
 		jobCtl = NewNotificationJobCtl(job, workflowCtx, ack, logger)
 	case string(config.JobSAEDeploy):
 		jobCtl = NewSAEDeployJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobEnvAnalysis):
+		jobCtl = NewEnvAnalysisJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobCanaryAnalysis):
+		jobCtl = NewCanaryAnalysisJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobFeatureFlag):
+		jobCtl = NewFeatureFlagJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobSubWorkflow):
+		jobCtl = NewSubWorkflowJobCtl(job, workflowCtx, ack, logger)
 	default:
 		jobCtl = NewFreestyleJobCtl(job, workflowCtx, ack, logger)
 	}
@@ -160,10 +175,23 @@ func runJob(ctx context.Context, job *commonmodels.JobTask, workflowCtx *commonm
 		if err != nil {
 			logger.Errorf("update job info: %s into db error: %v", err)
 		}
+		recordJobUsage(job, workflowCtx, logger)
 	}(&jobCtl)
 
 	jobCtl.Run(ctx)
 
+	if job.Status == config.StatusFailed || job.Status == config.StatusTimeout {
+		recordJobFailure(job, workflowCtx, classifyJobFailure(job.Error), logger)
+		if workflowCtx.InfraRetryPolicy != nil && workflowCtx.InfraRetryPolicy.Enabled {
+			infraRetryJob(ctx, job, jobCtl, workflowCtx, ack, logger)
+		}
+	}
+
+	if (job.Status == config.StatusFailed || job.Status == config.StatusTimeout) && workflowCtx.FailedJobDiagnosis {
+		diagnoseFailedJob(ctx, job, workflowCtx, logger)
+		ack()
+	}
+
 	// if the job is in a failed state, do the error handling policy
 	if (job.Status == config.StatusFailed || job.Status == config.StatusTimeout) && job.ErrorPolicy != nil {
 		switch job.ErrorPolicy.Policy {
@@ -179,6 +207,57 @@ func runJob(ctx context.Context, job *commonmodels.JobTask, workflowCtx *commonm
 	}
 }
 
+// recordJobUsage records the execution time of the job running in the job pod for the usage accounting
+func recordJobUsage(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	if job.Infrastructure == setting.JobVMInfrastructure || job.StartTime == 0 || job.EndTime < job.StartTime {
+		return
+	}
+	var clusterID string
+	switch spec := job.Spec.(type) {
+	case *commonmodels.JobTaskFreestyleSpec:
+		clusterID = spec.Properties.ClusterID
+	case *commonmodels.JobTaskPluginSpec:
+		clusterID = spec.Properties.ClusterID
+	default:
+		return
+	}
+
+	record := &commonmodels.JobUsageRecord{
+		ProjectName:  workflowCtx.ProjectName,
+		WorkflowName: workflowCtx.WorkflowName,
+		TaskID:       workflowCtx.TaskID,
+		JobName:      job.Name,
+		JobType:      job.JobType,
+		ClusterID:    clusterID,
+		Status:       string(job.Status),
+		Month:        time.Unix(job.StartTime, 0).Format("2006-01"),
+		StartTime:    job.StartTime,
+		EndTime:      job.EndTime,
+		Duration:     job.EndTime - job.StartTime,
+	}
+	if err := mongodb.NewJobUsageRecordColl().Create(record); err != nil {
+		logger.Errorf("failed to record the usage of job %s, err: %s", job.Name, err)
+	}
+}
+
+// diagnoseFailedJob attaches the diagnosis of the llm to the failed job, the job is not affected if the diagnosis fails
+func diagnoseFailedJob(ctx context.Context, job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	ctx, cancel := context.WithTimeout(ctx, failedJobDiagnosisTimeout)
+	defer cancel()
+
+	diagnosis, err := aiservice.DiagnoseFailedJob(ctx, &aiservice.FailedJobInfo{
+		ProjectName:  workflowCtx.ProjectName,
+		WorkflowName: workflowCtx.WorkflowName,
+		TaskID:       workflowCtx.TaskID,
+		Job:          job,
+	})
+	if err != nil {
+		logger.Warnf("failed to diagnose job %s, err: %s", job.Name, err)
+		return
+	}
+	job.Diagnosis = diagnosis
+}
+
 func retryJob(ctx context.Context, workflowName string, taskID int64, job *commonmodels.JobTask, jobCtl JobCtl, ack func(), maxRetry int) {
 	retryCount := 1
 
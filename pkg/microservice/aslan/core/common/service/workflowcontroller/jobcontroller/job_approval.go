@@ -111,7 +111,7 @@ func waitForNativeApprove(ctx context.Context, spec *commonmodels.JobTaskApprova
 		timeout = 60
 	}
 
-	approveKey := fmt.Sprintf("%s-%s-%d", workflowName, jobName, taskID)
+	approveKey := approvalservice.GenWorkflowApproveKey(workflowName, jobName, taskID)
 	approvalservice.GlobalApproveMap.SetApproval(approveKey, approval)
 	defer func() {
 		approvalservice.GlobalApproveMap.DeleteApproval(approveKey)
@@ -141,6 +141,7 @@ func waitForNativeApprove(ctx context.Context, spec *commonmodels.JobTaskApprova
 							user.RejectOrApprove = nativeUser.RejectOrApprove
 							user.Comment = nativeUser.Comment
 							user.OperationTime = nativeUser.OperationTime
+							user.ApproveChannel = nativeUser.ApproveChannel
 						}
 					}
 				}
@@ -83,58 +83,7 @@ func (c *BlueGreenReleaseV2JobCtl) Clean(ctx context.Context) {
 		return
 	}
 
-	// ensure delete blue deployment and service
-	err = updater.DeleteDeploymentAndWait(c.namespace, c.jobTaskSpec.Service.BlueDeploymentName, c.kubeClient)
-	if err != nil {
-		c.logger.Warnf("can't delete blue deployment %s, err: %v", c.jobTaskSpec.Service.BlueDeploymentName, err)
-	}
-	err = updater.DeleteService(c.namespace, c.jobTaskSpec.Service.BlueServiceName, c.kubeClient)
-	if err != nil {
-		c.logger.Warnf("can't delete blue service %s, err: %v", c.jobTaskSpec.Service.BlueServiceName, err)
-	}
-
-	// ensure green service and pods not contain release label
-	greenDeployment, found, err := getter.GetDeployment(c.namespace, c.jobTaskSpec.Service.GreenDeploymentName, c.kubeClient)
-	if err != nil || !found {
-		c.logger.Errorf("get green deployment: %s error: %v", c.jobTaskSpec.Service.GreenDeploymentName, err)
-		return
-	}
-	greenService, found, err := getter.GetService(c.namespace, c.jobTaskSpec.Service.GreenServiceName, c.kubeClient)
-	if err != nil || !found {
-		c.logger.Errorf("get green service: %s error: %v", c.jobTaskSpec.Service.GreenServiceName, err)
-		return
-	}
-	if greenService.Spec.Selector == nil {
-		c.logger.Errorf("blue service %s selector is nil", c.jobTaskSpec.Service.GreenServiceName)
-		return
-	}
-	// must remove service selector before remove pods labels
-	if _, ok := greenService.Spec.Selector[config.BlueGreenVersionLabelName]; ok {
-		delete(greenService.Spec.Selector, config.BlueGreenVersionLabelName)
-		if err := updater.CreateOrPatchService(greenService, c.kubeClient); err != nil {
-			c.logger.Errorf("delete origin label for service error: %v", err)
-			return
-		}
-	}
-	pods, err := getter.ListPods(c.namespace, labels.Set(greenDeployment.Spec.Selector.MatchLabels).AsSelector(), c.kubeClient)
-	if err != nil {
-		c.logger.Errorf("list green deployment %s pods error: %v", c.jobTaskSpec.Service.GreenDeploymentName, err)
-		return
-	}
-	for _, pod := range pods {
-		if pod.Labels == nil {
-			continue
-		}
-		if _, ok := pod.Labels[config.BlueGreenVersionLabelName]; ok {
-			removeLabelPatch := fmt.Sprintf(`{"metadata":{"labels":{"%s":null}}}`, config.BlueGreenVersionLabelName)
-			if err := updater.PatchPod(c.namespace, pod.Name, []byte(removeLabelPatch), c.kubeClient); err != nil {
-				c.logger.Errorf("remove origin label to pod error: %v", err)
-				continue
-			}
-		}
-	}
-
-	return
+	restoreBlueGreenV2(c.namespace, c.jobTaskSpec.Service, c.kubeClient, c.logger)
 }
 
 func (c *BlueGreenReleaseV2JobCtl) Run(ctx context.Context) {
@@ -307,3 +256,57 @@ func (c *BlueGreenReleaseV2JobCtl) SaveInfo(ctx context.Context) error {
 		Status:              string(c.job.Status),
 	})
 }
+
+// restoreBlueGreenV2 deletes the blue deployment and service, and removes the version label from the green service and pods.
+func restoreBlueGreenV2(namespace string, service *commonmodels.BlueGreenDeployV2Service, kubeClient crClient.Client, logger *zap.SugaredLogger) {
+	// ensure delete blue deployment and service
+	err := updater.DeleteDeploymentAndWait(namespace, service.BlueDeploymentName, kubeClient)
+	if err != nil {
+		logger.Warnf("can't delete blue deployment %s, err: %v", service.BlueDeploymentName, err)
+	}
+	err = updater.DeleteService(namespace, service.BlueServiceName, kubeClient)
+	if err != nil {
+		logger.Warnf("can't delete blue service %s, err: %v", service.BlueServiceName, err)
+	}
+
+	// ensure green service and pods not contain release label
+	greenDeployment, found, err := getter.GetDeployment(namespace, service.GreenDeploymentName, kubeClient)
+	if err != nil || !found {
+		logger.Errorf("get green deployment: %s error: %v", service.GreenDeploymentName, err)
+		return
+	}
+	greenService, found, err := getter.GetService(namespace, service.GreenServiceName, kubeClient)
+	if err != nil || !found {
+		logger.Errorf("get green service: %s error: %v", service.GreenServiceName, err)
+		return
+	}
+	if greenService.Spec.Selector == nil {
+		logger.Errorf("blue service %s selector is nil", service.GreenServiceName)
+		return
+	}
+	// must remove service selector before remove pods labels
+	if _, ok := greenService.Spec.Selector[config.BlueGreenVersionLabelName]; ok {
+		delete(greenService.Spec.Selector, config.BlueGreenVersionLabelName)
+		if err := updater.CreateOrPatchService(greenService, kubeClient); err != nil {
+			logger.Errorf("delete origin label for service error: %v", err)
+			return
+		}
+	}
+	pods, err := getter.ListPods(namespace, labels.Set(greenDeployment.Spec.Selector.MatchLabels).AsSelector(), kubeClient)
+	if err != nil {
+		logger.Errorf("list green deployment %s pods error: %v", service.GreenDeploymentName, err)
+		return
+	}
+	for _, pod := range pods {
+		if pod.Labels == nil {
+			continue
+		}
+		if _, ok := pod.Labels[config.BlueGreenVersionLabelName]; ok {
+			removeLabelPatch := fmt.Sprintf(`{"metadata":{"labels":{"%s":null}}}`, config.BlueGreenVersionLabelName)
+			if err := updater.PatchPod(namespace, pod.Name, []byte(removeLabelPatch), kubeClient); err != nil {
+				logger.Errorf("remove origin label to pod error: %v", err)
+				continue
+			}
+		}
+	}
+}
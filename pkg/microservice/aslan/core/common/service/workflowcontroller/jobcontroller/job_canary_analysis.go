@@ -0,0 +1,291 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	crClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/v2/pkg/tool/prometheus"
+)
+
+type CanaryAnalysisJobCtl struct {
+	job         *commonmodels.JobTask
+	workflowCtx *commonmodels.WorkflowTaskCtx
+	logger      *zap.SugaredLogger
+	kubeClient  crClient.Client
+	jobTaskSpec *commonmodels.JobTaskCanaryAnalysisSpec
+	ack         func()
+}
+
+func NewCanaryAnalysisJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *CanaryAnalysisJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskCanaryAnalysisSpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	if jobTaskSpec.Events == nil {
+		jobTaskSpec.Events = &commonmodels.Events{}
+	}
+	job.Spec = jobTaskSpec
+	return &CanaryAnalysisJobCtl{
+		job:         job,
+		workflowCtx: workflowCtx,
+		logger:      logger,
+		ack:         ack,
+		jobTaskSpec: jobTaskSpec,
+	}
+}
+
+func (c *CanaryAnalysisJobCtl) Clean(ctx context.Context) {}
+
+func (c *CanaryAnalysisJobCtl) Run(ctx context.Context) {
+	c.job.Status = config.StatusRunning
+	c.ack()
+
+	canaryWorkload, stableWorkload, err := c.prepare()
+	if err != nil {
+		logError(c.job, err.Error(), c.logger)
+		c.jobTaskSpec.Events.Error(err.Error())
+		return
+	}
+
+	info, err := mongodb.NewObservabilityColl().GetByID(context.Background(), c.jobTaskSpec.ObservabilityID)
+	if err != nil {
+		msg := fmt.Sprintf("get observability info error: %v", err)
+		logError(c.job, msg, c.logger)
+		c.jobTaskSpec.Events.Error(msg)
+		return
+	}
+	client := prometheus.NewClient(info.Host, info.ApiKey)
+
+	c.jobTaskSpec.Events.Info(fmt.Sprintf("start analyzing canary %s against stable %s", canaryWorkload, stableWorkload))
+	c.ack()
+
+	timeout := time.After(time.Duration(c.jobTaskSpec.Duration) * time.Minute)
+	ticker := time.NewTicker(time.Duration(c.jobTaskSpec.Interval) * time.Second)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			c.job.Status = config.StatusCancelled
+			return
+		case <-timeout:
+			c.promote(ctx)
+			return
+		case <-ticker.C:
+			snapshot := c.sample(client, canaryWorkload, stableWorkload)
+			c.jobTaskSpec.Snapshots = append(c.jobTaskSpec.Snapshots, snapshot)
+			if !snapshot.Passed {
+				failures++
+			}
+			c.ack()
+
+			if failures > c.jobTaskSpec.FailureLimit {
+				c.rollback(fmt.Sprintf("%d samples failed, exceeding the failure limit %d", failures, c.jobTaskSpec.FailureLimit))
+				return
+			}
+		}
+	}
+}
+
+// prepare initializes the kube client and returns the workloads of the canary and the stable variant.
+func (c *CanaryAnalysisJobCtl) prepare() (string, string, error) {
+	if c.jobTaskSpec.DeployType == config.JobK8sBlueGreenDeploy {
+		if c.jobTaskSpec.BlueGreenService == nil {
+			return "", "", errors.New("blue-green service not found")
+		}
+		env, err := mongodb.NewProductColl().Find(&mongodb.ProductFindOptions{
+			Name:    c.workflowCtx.ProjectName,
+			EnvName: c.jobTaskSpec.Env,
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("find project error: %v", err)
+		}
+		c.jobTaskSpec.Namespace = env.Namespace
+		c.jobTaskSpec.ClusterID = env.ClusterID
+	}
+
+	var err error
+	c.kubeClient, err = clientmanager.NewKubeClientManager().GetControllerRuntimeClient(c.jobTaskSpec.ClusterID)
+	if err != nil {
+		return "", "", fmt.Errorf("can't init k8s client: %v", err)
+	}
+
+	if c.jobTaskSpec.DeployType == config.JobK8sBlueGreenDeploy {
+		return c.jobTaskSpec.BlueGreenService.BlueDeploymentName, c.jobTaskSpec.BlueGreenService.GreenDeploymentName, nil
+	}
+	return c.jobTaskSpec.WorkloadName + CanaryDeploymentSuffix, c.jobTaskSpec.WorkloadName, nil
+}
+
+// sample queries the metrics of both variants, a metric that can't be queried is not judged.
+func (c *CanaryAnalysisJobCtl) sample(client *prometheus.Client, canaryWorkload, stableWorkload string) *commonmodels.CanaryAnalysisSnapshot {
+	snapshot := &commonmodels.CanaryAnalysisSnapshot{
+		Time:   time.Now().Unix(),
+		Passed: true,
+	}
+	for _, metric := range c.jobTaskSpec.Metrics {
+		metricSnapshot := &commonmodels.CanaryMetricSnapshot{
+			Name:   metric.Name,
+			Passed: true,
+		}
+		snapshot.Metrics = append(snapshot.Metrics, metricSnapshot)
+
+		canary, err := c.queryMetric(client, metric.Query, canaryWorkload)
+		if err != nil {
+			metricSnapshot.Error = fmt.Sprintf("query canary error: %s", err)
+			continue
+		}
+		stable, err := c.queryMetric(client, metric.Query, stableWorkload)
+		if err != nil {
+			metricSnapshot.Error = fmt.Sprintf("query stable error: %s", err)
+			continue
+		}
+		metricSnapshot.Canary = canary
+		metricSnapshot.Stable = stable
+
+		switch metric.ThresholdType {
+		case commonmodels.CanaryMetricThresholdAbsolute:
+			metricSnapshot.Passed = canary <= metric.Threshold
+		case commonmodels.CanaryMetricThresholdRelative:
+			metricSnapshot.Passed = canary <= stable*(1+metric.Threshold/100)
+		}
+		if !metricSnapshot.Passed {
+			snapshot.Passed = false
+		}
+	}
+	return snapshot
+}
+
+// queryMetric renders the query with the workload and sums up the samples of the result.
+func (c *CanaryAnalysisJobCtl) queryMetric(client *prometheus.Client, query, workload string) (float64, error) {
+	query = strings.NewReplacer(
+		"$namespace", c.jobTaskSpec.Namespace,
+		"$workload", workload,
+		// the pods of the deployment are named as workload-<replicaset hash>-<pod hash>
+		"$pod_regex", workload+"-[a-z0-9]+-[a-z0-9]+",
+	).Replace(query)
+
+	samples, err := client.Query(query)
+	if err != nil {
+		return 0, err
+	}
+	if len(samples) == 0 {
+		return 0, errors.New("no data")
+	}
+	value := float64(0)
+	for _, sample := range samples {
+		value += sample.Value
+	}
+	return value, nil
+}
+
+// promote releases the new version to the stable variant and removes the canary variant.
+func (c *CanaryAnalysisJobCtl) promote(ctx context.Context) {
+	var stableWorkload string
+	if c.jobTaskSpec.DeployType == config.JobK8sBlueGreenDeploy {
+		service := c.jobTaskSpec.BlueGreenService
+		stableWorkload = service.GreenDeploymentName
+		for _, v := range service.ServiceAndImage {
+			if err := updater.UpdateDeploymentImage(c.jobTaskSpec.Namespace, stableWorkload, v.ServiceModule, v.Image, c.kubeClient); err != nil {
+				c.fail(fmt.Sprintf("can't update deployment %s container %s image %s, err: %v", stableWorkload, v.ServiceModule, v.Image, err))
+				return
+			}
+			if err := commonutil.UpdateProductImage(c.jobTaskSpec.Env, c.workflowCtx.ProjectName, service.ServiceName, map[string]string{v.ServiceModule: v.Image}, c.workflowCtx.WorkflowTaskCreatorUsername, c.logger); err != nil {
+				c.fail(fmt.Sprintf("update product image service %s service module %s image %s error: %v", service.ServiceName, v.ServiceModule, v.Image, err))
+				return
+			}
+		}
+	} else {
+		stableWorkload = c.jobTaskSpec.WorkloadName
+		if err := updater.UpdateDeploymentImage(c.jobTaskSpec.Namespace, stableWorkload, c.jobTaskSpec.ContainerName, c.jobTaskSpec.Image, c.kubeClient); err != nil {
+			c.fail(fmt.Sprintf("update deployment: %s image error: %v", stableWorkload, err))
+			return
+		}
+	}
+	c.jobTaskSpec.Events.Info(fmt.Sprintf("analysis passed, updating deployment: %s image", stableWorkload))
+	c.ack()
+
+	status, err := waitDeploymentReady(ctx, stableWorkload, c.jobTaskSpec.Namespace, setting.DeployTimeout, c.kubeClient, c.logger)
+	if err != nil {
+		c.jobTaskSpec.Events.Error(err.Error())
+		logError(c.job, err.Error(), c.logger)
+		c.job.Status = status
+		return
+	}
+	c.removeCanary()
+
+	c.jobTaskSpec.Result = commonmodels.CanaryAnalysisResultPromoted
+	c.jobTaskSpec.Events.Info(fmt.Sprintf("deployment: %s promoted successfully", stableWorkload))
+	c.job.Status = config.StatusPassed
+}
+
+// rollback removes the canary variant so that the traffic goes back to the stable variant.
+func (c *CanaryAnalysisJobCtl) rollback(reason string) {
+	c.jobTaskSpec.Events.Info(fmt.Sprintf("analysis failed: %s, rolling back", reason))
+	c.ack()
+	c.removeCanary()
+
+	c.jobTaskSpec.Result = commonmodels.CanaryAnalysisResultRolledBack
+	c.fail(fmt.Sprintf("canary analysis failed: %s, the new version is rolled back", reason))
+}
+
+func (c *CanaryAnalysisJobCtl) removeCanary() {
+	if c.jobTaskSpec.DeployType == config.JobK8sBlueGreenDeploy {
+		restoreBlueGreenV2(c.jobTaskSpec.Namespace, c.jobTaskSpec.BlueGreenService, c.kubeClient, c.logger)
+		return
+	}
+	canaryDeploymentName := c.jobTaskSpec.WorkloadName + CanaryDeploymentSuffix
+	if err := updater.DeleteDeploymentAndWaitWithTimeout(c.jobTaskSpec.Namespace, canaryDeploymentName, time.Duration(setting.DeployTimeout)*time.Second, c.kubeClient); err != nil {
+		c.logger.Errorf("delete canary deployment %s error: %v", canaryDeploymentName, err)
+		c.jobTaskSpec.Events.Error(fmt.Sprintf("delete canary deployment %s error: %v", canaryDeploymentName, err))
+		return
+	}
+	c.jobTaskSpec.Events.Info(fmt.Sprintf("canary deployment: %s deleted", canaryDeploymentName))
+}
+
+func (c *CanaryAnalysisJobCtl) fail(msg string) {
+	logError(c.job, msg, c.logger)
+	c.jobTaskSpec.Events.Error(msg)
+}
+
+func (c *CanaryAnalysisJobCtl) SaveInfo(ctx context.Context) error {
+	return mongodb.NewJobInfoColl().Create(context.TODO(), &commonmodels.JobInfo{
+		Type:                c.job.JobType,
+		WorkflowName:        c.workflowCtx.WorkflowName,
+		WorkflowDisplayName: c.workflowCtx.WorkflowDisplayName,
+		TaskID:              c.workflowCtx.TaskID,
+		ProductName:         c.workflowCtx.ProjectName,
+		StartTime:           c.job.StartTime,
+		EndTime:             c.job.EndTime,
+		Duration:            c.job.EndTime - c.job.StartTime,
+		Status:              string(c.job.Status),
+	})
+}
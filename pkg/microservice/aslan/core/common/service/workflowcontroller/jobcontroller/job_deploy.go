@@ -89,6 +89,12 @@ func (c *DeployJobCtl) Clean(ctx context.Context) {}
 func (c *DeployJobCtl) Run(ctx context.Context) {
 	c.job.Status = config.StatusRunning
 	c.ack()
+	unlock, err := lockDeployEnv(c.workflowCtx, c.jobTaskSpec.Env)
+	if err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return
+	}
+	defer unlock()
 	c.preRun()
 	if err := c.run(ctx); err != nil {
 		return
@@ -98,6 +104,22 @@ func (c *DeployJobCtl) Run(ctx context.Context) {
 		return
 	}
 	c.wait(ctx)
+	if c.job.Status != config.StatusPassed {
+		return
+	}
+	if err := runSmokeCheck(ctx, c.jobTaskSpec.ClusterID, c.namespace, c.jobTaskSpec.SmokeCheck, c.logger); err != nil {
+		logError(c.job, err.Error(), c.logger)
+	}
+}
+
+// lockDeployEnv freezes the env during the rollout of the workflow task, the env updates from the other users wait until
+// the deployment finishes. The deploy jobs in the same workflow task share the lock.
+func lockDeployEnv(workflowCtx *commonmodels.WorkflowTaskCtx, envName string) (func(), error) {
+	return commonutil.LockEnvs(workflowCtx.ProjectName, []string{envName}, &commonutil.EnvLockHolder{
+		Type:   commonutil.EnvLockHolderWorkflow,
+		Name:   workflowCtx.WorkflowName,
+		TaskID: workflowCtx.TaskID,
+	})
 }
 
 func (c *DeployJobCtl) preRun() {
@@ -187,6 +209,17 @@ func (c *DeployJobCtl) run(ctx context.Context) error {
 		}
 	}
 
+	if len(containers) > 0 {
+		images := make([]string, 0, len(containers))
+		for _, container := range containers {
+			images = append(images, container.Image)
+		}
+		if err := verifyDeployImages(env, c.jobTaskSpec.ImageSigningKeyID, images, c.logger); err != nil {
+			logError(c.job, err.Error(), c.logger)
+			return err
+		}
+	}
+
 	option := &kube.GeneSvcYamlOption{
 		ProductName:           env.ProductName,
 		EnvName:               c.jobTaskSpec.Env,
@@ -764,9 +797,12 @@ func (c *DeployJobCtl) timeout() int {
 
 func (c *DeployJobCtl) SaveInfo(ctx context.Context) error {
 	modules := make([]string, 0)
+	images := make(map[string]string)
 	for _, module := range c.jobTaskSpec.ServiceAndImages {
 		modules = append(modules, module.ServiceModule)
+		images[module.ServiceModule] = module.Image
 	}
+	saveDeployPromotions(c.job, c.workflowCtx, c.jobTaskSpec.Env, c.jobTaskSpec.Production, c.jobTaskSpec.ServiceName, images, c.logger)
 	moduleList := strings.Join(modules, ",")
 	return commonrepo.NewJobInfoColl().Create(context.TODO(), &commonmodels.JobInfo{
 		Type:                c.job.JobType,
@@ -786,3 +822,18 @@ func (c *DeployJobCtl) SaveInfo(ctx context.Context) error {
 		Production:    c.jobTaskSpec.Production,
 	})
 }
+
+// verifyDeployImages refuses to roll out unsigned or invalidly signed images to the production environment,
+// the signing key of the job takes precedence over the image signing policy of the environment.
+func verifyDeployImages(env *commonmodels.Product, keyID string, images []string, logger *zap.SugaredLogger) error {
+	if !env.Production {
+		return nil
+	}
+	if keyID == "" {
+		keyID = env.ImageSigningKeyID
+	}
+	if keyID == "" {
+		return nil
+	}
+	return commonutil.VerifyImageSignature(keyID, images, logger)
+}
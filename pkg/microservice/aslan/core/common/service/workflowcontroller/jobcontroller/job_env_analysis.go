@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/ai"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	airepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/ai"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	aiservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/ai"
+	"github.com/koderover/zadig/v2/pkg/setting"
+)
+
+type EnvAnalysisJobCtl struct {
+	job         *commonmodels.JobTask
+	workflowCtx *commonmodels.WorkflowTaskCtx
+	logger      *zap.SugaredLogger
+	jobTaskSpec *commonmodels.JobTaskEnvAnalysisSpec
+	ack         func()
+}
+
+func NewEnvAnalysisJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *EnvAnalysisJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskEnvAnalysisSpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	job.Spec = jobTaskSpec
+	return &EnvAnalysisJobCtl{
+		job:         job,
+		workflowCtx: workflowCtx,
+		logger:      logger,
+		ack:         ack,
+		jobTaskSpec: jobTaskSpec,
+	}
+}
+
+func (c *EnvAnalysisJobCtl) Clean(ctx context.Context) {}
+
+func (c *EnvAnalysisJobCtl) Run(ctx context.Context) {
+	c.job.Status = config.StatusRunning
+	c.ack()
+
+	env, err := mongodb.NewProductColl().Find(&mongodb.ProductFindOptions{
+		Name:       c.workflowCtx.ProjectName,
+		EnvName:    c.jobTaskSpec.EnvName,
+		Production: &c.jobTaskSpec.Production,
+	})
+	if err != nil {
+		logError(c.job, fmt.Sprintf("failed to find env %s: %v", c.jobTaskSpec.EnvName, err), c.logger)
+		return
+	}
+
+	filters := c.jobTaskSpec.ResourceTypes
+	if len(filters) == 0 && env.AnalysisConfig != nil {
+		for _, resourceType := range env.AnalysisConfig.ResourceTypes {
+			filters = append(filters, string(resourceType))
+		}
+	}
+
+	record := &ai.EnvAIAnalysis{
+		ProjectName:  env.ProductName,
+		EnvName:      env.EnvName,
+		Production:   env.Production,
+		ServiceNames: c.jobTaskSpec.ServiceNames,
+		Workloads:    c.jobTaskSpec.Workloads,
+		TriggerName:  fmt.Sprintf("%s-%d", c.workflowCtx.WorkflowName, c.workflowCtx.TaskID),
+		CreatedBy:    c.workflowCtx.WorkflowTaskCreatorUsername,
+		StartTime:    time.Now().Unix(),
+	}
+	if project, err := templaterepo.NewProductColl().Find(env.ProductName); err == nil && project.ProductFeature != nil {
+		record.DeployType = project.ProductFeature.DeployType
+	}
+
+	scope, err := aiservice.GetEnvAnalysisScope(env, c.jobTaskSpec.ServiceNames, c.jobTaskSpec.Workloads)
+	if err != nil {
+		logError(c.job, fmt.Sprintf("failed to get analysis scope: %v", err), c.logger)
+		return
+	}
+	result, err := aiservice.AnalyzeEnv(ctx, env, filters, scope)
+	if err != nil {
+		logError(c.job, fmt.Sprintf("failed to analyze env %s: %v", env.EnvName, err), c.logger)
+		return
+	}
+
+	c.jobTaskSpec.Result = result.Result
+	c.jobTaskSpec.Problems = result.Problems
+	c.job.Status = config.StatusPassed
+	if result.Problems > 0 && c.jobTaskSpec.FailOnProblems {
+		c.job.Status = config.StatusFailed
+		c.job.Error = fmt.Sprintf("%d problems found in env %s", result.Problems, env.EnvName)
+	}
+
+	record.Status = setting.AIEnvAnalysisStatusSuccess
+	record.Result = result.Result
+	record.Findings = result.Findings
+	record.EndTime = time.Now().Unix()
+	if err := airepo.NewEnvAIAnalysisColl().Create(record); err != nil {
+		c.logger.Errorf("failed to add env ai analysis result to db, err: %s", err)
+	}
+}
+
+func (c *EnvAnalysisJobCtl) SaveInfo(ctx context.Context) error {
+	return mongodb.NewJobInfoColl().Create(context.TODO(), &commonmodels.JobInfo{
+		Type:                c.job.JobType,
+		WorkflowName:        c.workflowCtx.WorkflowName,
+		WorkflowDisplayName: c.workflowCtx.WorkflowDisplayName,
+		TaskID:              c.workflowCtx.TaskID,
+		ProductName:         c.workflowCtx.ProjectName,
+		StartTime:           c.job.StartTime,
+		EndTime:             c.job.EndTime,
+		Duration:            c.job.EndTime - c.job.StartTime,
+		Status:              string(c.job.Status),
+	})
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/featureflag"
+)
+
+type FeatureFlagJobCtl struct {
+	job         *commonmodels.JobTask
+	workflowCtx *commonmodels.WorkflowTaskCtx
+	logger      *zap.SugaredLogger
+	jobTaskSpec *commonmodels.JobTaskFeatureFlagSpec
+	ack         func()
+}
+
+func NewFeatureFlagJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *FeatureFlagJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskFeatureFlagSpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	job.Spec = jobTaskSpec
+	return &FeatureFlagJobCtl{
+		job:         job,
+		workflowCtx: workflowCtx,
+		logger:      logger,
+		ack:         ack,
+		jobTaskSpec: jobTaskSpec,
+	}
+}
+
+// Clean rolls back the changed flags if the workflow doesn't pass.
+func (c *FeatureFlagJobCtl) Clean(ctx context.Context) {
+	if c.workflowCtx.WorkflowStatusGet == nil {
+		return
+	}
+	switch c.workflowCtx.WorkflowStatusGet() {
+	case config.StatusFailed, config.StatusTimeout, config.StatusCancelled, config.StatusReject:
+	default:
+		return
+	}
+
+	var client featureflag.Client
+	for _, flag := range c.jobTaskSpec.Flags {
+		if !flag.Changed || flag.RolledBack {
+			continue
+		}
+		if client == nil {
+			var err error
+			client, err = c.newClient()
+			if err != nil {
+				c.logger.Errorf("failed to roll back feature flags, err: %s", err)
+				return
+			}
+		}
+		if err := client.SetFlag(c.jobTaskSpec.ProjectKey, c.jobTaskSpec.FlagEnv, flag.Key, flag.OriginEnabled); err != nil {
+			c.logger.Errorf("failed to roll back feature flag %s, err: %s", flag.Key, err)
+			flag.Error = fmt.Sprintf("rollback error: %v", err)
+			continue
+		}
+		flag.RolledBack = true
+	}
+	c.ack()
+}
+
+func (c *FeatureFlagJobCtl) Run(ctx context.Context) {
+	c.job.Status = config.StatusRunning
+	c.ack()
+
+	client, err := c.newClient()
+	if err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return
+	}
+
+	for _, flag := range c.jobTaskSpec.Flags {
+		enabled, err := client.GetFlag(c.jobTaskSpec.ProjectKey, c.jobTaskSpec.FlagEnv, flag.Key)
+		if err != nil {
+			flag.Error = fmt.Sprintf("get flag error: %v", err)
+			logError(c.job, fmt.Sprintf("failed to get feature flag %s, err: %s", flag.Key, err), c.logger)
+			return
+		}
+		flag.OriginEnabled = enabled
+		if enabled == flag.Enabled {
+			continue
+		}
+
+		if err := client.SetFlag(c.jobTaskSpec.ProjectKey, c.jobTaskSpec.FlagEnv, flag.Key, flag.Enabled); err != nil {
+			flag.Error = fmt.Sprintf("set flag error: %v", err)
+			logError(c.job, fmt.Sprintf("failed to set feature flag %s, err: %s", flag.Key, err), c.logger)
+			return
+		}
+		flag.Changed = true
+		c.ack()
+	}
+	c.job.Status = config.StatusPassed
+}
+
+func (c *FeatureFlagJobCtl) newClient() (featureflag.Client, error) {
+	info, err := mongodb.NewConfigurationManagementColl().GetFeatureFlagByID(context.Background(), c.jobTaskSpec.FeatureFlagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flag system info, err: %s", err)
+	}
+	c.jobTaskSpec.Type = info.Type
+	return featureflag.NewClient(info.Type, info.ServerAddress, info.Token)
+}
+
+func (c *FeatureFlagJobCtl) SaveInfo(ctx context.Context) error {
+	return mongodb.NewJobInfoColl().Create(context.TODO(), &commonmodels.JobInfo{
+		Type:                c.job.JobType,
+		WorkflowName:        c.workflowCtx.WorkflowName,
+		WorkflowDisplayName: c.workflowCtx.WorkflowDisplayName,
+		TaskID:              c.workflowCtx.TaskID,
+		ProductName:         c.workflowCtx.ProjectName,
+		StartTime:           c.job.StartTime,
+		EndTime:             c.job.EndTime,
+		Duration:            c.job.EndTime - c.job.StartTime,
+		Status:              string(c.job.Status),
+	})
+}
@@ -58,6 +58,8 @@ type FreestyleJobCtl struct {
 	paths       *string
 	jobTaskSpec *commonmodels.JobTaskFreestyleSpec
 	ack         func()
+	// kubeAccessGrant is the kube access to the env granted to the running job
+	kubeAccessGrant *commonmodels.JobKubeAccessGrant
 }
 
 func NewFreestyleJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *FreestyleJobCtl {
@@ -95,6 +97,7 @@ func (c *FreestyleJobCtl) Run(ctx context.Context) {
 		c.vmComplete(ctx, vmJobID)
 	} else {
 		if err := c.run(ctx); err != nil {
+			c.revokeEnvKubeAccess()
 			return
 		}
 		c.wait(ctx)
@@ -120,6 +123,9 @@ func (c *FreestyleJobCtl) prepare(ctx context.Context) error {
 	if c.jobTaskSpec.Properties.ClusterID == "" {
 		c.jobTaskSpec.Properties.ClusterID = setting.LocalClusterID
 	}
+	if c.jobTaskSpec.Properties.CheckpointInterval > 0 {
+		injectLastCheckpoint(c.job, c.jobTaskSpec, c.workflowCtx, c.logger)
+	}
 	// init step configration.
 	if err := stepcontroller.PrepareSteps(ctx, c.workflowCtx, &c.jobTaskSpec.Properties.Paths, c.job.Key, c.jobTaskSpec.Steps, c.logger); err != nil {
 		logError(c.job, err.Error(), c.logger)
@@ -181,6 +187,16 @@ func (c *FreestyleJobCtl) run(ctx context.Context) error {
 		return errors.New(msg)
 	}
 
+	if isEnvKubeAccessEnabled(c.jobTaskSpec) {
+		grant, err := grantEnvKubeAccess(c.job, c.jobTaskSpec, c.workflowCtx, c.logger)
+		if err != nil {
+			msg := fmt.Sprintf("failed to grant kube access of env %s: %v", c.jobTaskSpec.Properties.EnvKubeAccess.EnvName, err)
+			logError(c.job, msg, c.logger)
+			return errors.New(msg)
+		}
+		c.kubeAccessGrant = grant
+	}
+
 	jobLabel := &JobLabel{
 		JobType: string(c.job.JobType),
 		JobName: c.job.K8sJobName,
@@ -299,7 +315,14 @@ func (c *FreestyleJobCtl) wait(ctx context.Context) {
 	} else {
 		return
 	}
-	c.job.Status, c.job.Error = waitJobEndByCheckingConfigMap(ctx, taskTimeout, c.jobTaskSpec.Properties.Namespace, c.job.K8sJobName, true, c.informer, c.job, c.ack, c.logger)
+	ack := c.ack
+	if c.jobTaskSpec.Properties.CheckpointInterval > 0 {
+		ack = func() {
+			saveJobCheckpoint(c.job, c.workflowCtx, c.logger)
+			c.ack()
+		}
+	}
+	c.job.Status, c.job.Error = waitJobEndByCheckingConfigMap(ctx, taskTimeout, c.jobTaskSpec.Properties.Namespace, c.job.K8sJobName, true, c.informer, c.job, ack, c.logger)
 }
 
 func (c *FreestyleJobCtl) vmJobWait(ctx context.Context, jobID string) {
@@ -350,6 +373,7 @@ func (c *FreestyleJobCtl) complete(ctx context.Context) {
 			if err := ensureDeleteConfigMap(c.jobTaskSpec.Properties.Namespace, jobLabel, c.kubeclient); err != nil {
 				c.logger.Error(err)
 			}
+			c.revokeEnvKubeAccess()
 		}()
 	}()
 
@@ -358,6 +382,9 @@ func (c *FreestyleJobCtl) complete(ctx context.Context) {
 		c.logger.Error(err)
 		c.job.Status, c.job.Error = config.StatusFailed, errors.Wrap(err, "get job outputs").Error()
 	}
+	if c.jobTaskSpec.Properties.CheckpointInterval > 0 {
+		mergeJobCheckpoint(c.job, c.workflowCtx, c.logger)
+	}
 
 	if err := saveContainerLog(c.jobTaskSpec.Properties.Namespace, c.jobTaskSpec.Properties.ClusterID, c.workflowCtx.WorkflowName, c.job.Name, c.workflowCtx.TaskID, jobLabel, c.kubeclient); err != nil {
 		c.logger.Error(err)
@@ -371,6 +398,8 @@ func (c *FreestyleJobCtl) complete(ctx context.Context) {
 		c.job.Error = err.Error()
 		return
 	}
+	saveJobArtifacts(c.job, c.jobTaskSpec, c.workflowCtx, c.logger)
+	saveBuildPromotions(c.job, c.jobTaskSpec, c.workflowCtx, c.logger)
 }
 
 func (c *FreestyleJobCtl) vmComplete(ctx context.Context, jobID string) {
@@ -394,6 +423,8 @@ func (c *FreestyleJobCtl) vmComplete(ctx context.Context, jobID string) {
 		c.job.Error = err.Error()
 		return
 	}
+	saveJobArtifacts(c.job, c.jobTaskSpec, c.workflowCtx, c.logger)
+	saveBuildPromotions(c.job, c.jobTaskSpec, c.workflowCtx, c.logger)
 }
 
 func getVMJobOutputFromJobDB(jobID, jobName string, job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx) error {
@@ -439,6 +470,8 @@ func BuildJobExcutorContext(jobTaskSpec *commonmodels.JobTaskFreestyleSpec, job
 		Steps:         jobTaskSpec.Steps,
 		Paths:         jobTaskSpec.Properties.Paths,
 		ConfigMapName: job.K8sJobName,
+
+		CheckpointInterval: jobTaskSpec.Properties.CheckpointInterval,
 	}
 
 	if job.Infrastructure == setting.JobVMInfrastructure {
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v35/github"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	githubservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/github"
+	s3service "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/s3"
+	"github.com/koderover/zadig/v2/pkg/shared/client/systemconfig"
+	s3tool "github.com/koderover/zadig/v2/pkg/tool/s3"
+)
+
+const (
+	githubActionsRunWaitTimeout  = 2 * time.Minute
+	githubActionsPollingInterval = 5 * time.Second
+)
+
+type GitHubActionsJobCtl struct {
+	job         *commonmodels.JobTask
+	workflowCtx *commonmodels.WorkflowTaskCtx
+	logger      *zap.SugaredLogger
+	jobTaskSpec *commonmodels.JobTaskGitHubActionsSpec
+	ack         func()
+
+	client    *githubservice.Client
+	logs      strings.Builder
+	savedJobs sets.Int64
+}
+
+func NewGitHubActionsJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *GitHubActionsJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskGitHubActionsSpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	job.Spec = jobTaskSpec
+	return &GitHubActionsJobCtl{
+		job:         job,
+		workflowCtx: workflowCtx,
+		logger:      logger,
+		ack:         ack,
+		jobTaskSpec: jobTaskSpec,
+		savedJobs:   sets.NewInt64(),
+	}
+}
+
+func (c *GitHubActionsJobCtl) Clean(ctx context.Context) {}
+
+func (c *GitHubActionsJobCtl) Run(ctx context.Context) {
+	c.job.Status = config.StatusPrepare
+	c.ack()
+
+	codehost, err := systemconfig.New().GetCodeHost(c.jobTaskSpec.CodeHostID)
+	if err != nil {
+		logError(c.job, fmt.Sprintf("failed to find codehost %d, error: %s", c.jobTaskSpec.CodeHostID, err), c.logger)
+		return
+	}
+	c.client = githubservice.NewClient(codehost.AccessToken, config.ProxyHTTPSAddr(), codehost.EnableProxy)
+
+	// the dispatch API does not return the run, so the runs existing before dispatching are excluded when looking for it
+	existingRuns, err := c.client.ListDispatchedWorkflowRuns(ctx, c.jobTaskSpec.RepoOwner, c.jobTaskSpec.RepoName, c.jobTaskSpec.WorkflowFile, c.jobTaskSpec.Ref)
+	if err != nil {
+		logError(c.job, fmt.Sprintf("failed to list runs of github workflow %s, error: %s", c.jobTaskSpec.WorkflowFile, err), c.logger)
+		return
+	}
+	existingRunIDs := sets.NewInt64()
+	for _, run := range existingRuns {
+		existingRunIDs.Insert(run.GetID())
+	}
+
+	inputs := make(map[string]interface{})
+	for _, input := range c.jobTaskSpec.Inputs {
+		inputs[input.Key] = input.Value
+	}
+	if err := c.client.DispatchWorkflow(ctx, c.jobTaskSpec.RepoOwner, c.jobTaskSpec.RepoName, c.jobTaskSpec.WorkflowFile, c.jobTaskSpec.Ref, inputs); err != nil {
+		logError(c.job, fmt.Sprintf("failed to dispatch github workflow %s, error: %s", c.jobTaskSpec.WorkflowFile, err), c.logger)
+		return
+	}
+
+	run, err := c.waitForRun(ctx, existingRunIDs)
+	if err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return
+	}
+	c.jobTaskSpec.RunID = run.GetID()
+	c.jobTaskSpec.RunURL = run.GetHTMLURL()
+	c.jobTaskSpec.RunStatus = run.GetStatus()
+	c.job.Status = config.StatusRunning
+	c.ack()
+
+	for run.GetStatus() != "completed" {
+		select {
+		case <-ctx.Done():
+			if err := c.client.CancelWorkflowRun(context.Background(), c.jobTaskSpec.RepoOwner, c.jobTaskSpec.RepoName, run.GetID()); err != nil {
+				c.logger.Warnf("failed to cancel github workflow run %d, error: %s", run.GetID(), err)
+			}
+			c.job.Status = config.StatusCancelled
+			return
+		case <-time.After(githubActionsPollingInterval):
+		}
+
+		run, err = c.client.GetWorkflowRun(ctx, c.jobTaskSpec.RepoOwner, c.jobTaskSpec.RepoName, c.jobTaskSpec.RunID)
+		if err != nil {
+			logError(c.job, fmt.Sprintf("failed to get github workflow run %d, error: %s", c.jobTaskSpec.RunID, err), c.logger)
+			return
+		}
+		c.jobTaskSpec.RunStatus = run.GetStatus()
+		c.saveLogs(ctx)
+		c.ack()
+	}
+	c.saveLogs(ctx)
+
+	c.jobTaskSpec.Conclusion = run.GetConclusion()
+	c.job.Status = githubConclusionToStatus(run.GetConclusion())
+	if c.job.Status != config.StatusPassed {
+		c.job.Error = fmt.Sprintf("github workflow run %s concluded with %s", c.jobTaskSpec.RunURL, run.GetConclusion())
+	}
+}
+
+func (c *GitHubActionsJobCtl) waitForRun(ctx context.Context, existingRunIDs sets.Int64) (*github.WorkflowRun, error) {
+	timeout := time.After(githubActionsRunWaitTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("job cancelled before the github workflow run was created")
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for the run of github workflow %s to be created", c.jobTaskSpec.WorkflowFile)
+		case <-time.After(githubActionsPollingInterval):
+		}
+
+		runs, err := c.client.ListDispatchedWorkflowRuns(ctx, c.jobTaskSpec.RepoOwner, c.jobTaskSpec.RepoName, c.jobTaskSpec.WorkflowFile, c.jobTaskSpec.Ref)
+		if err != nil {
+			c.logger.Warnf("failed to list runs of github workflow %s, error: %s", c.jobTaskSpec.WorkflowFile, err)
+			continue
+		}
+		// runs are returned from the newest, pick the oldest new one in case other runs are dispatched at the same time
+		for i := len(runs) - 1; i >= 0; i-- {
+			if !existingRunIDs.Has(runs[i].GetID()) {
+				return runs[i], nil
+			}
+		}
+	}
+}
+
+// saveLogs appends the logs of the finished jobs of the run to the job log in the object storage,
+// github only provides the logs of a job after it is completed
+func (c *GitHubActionsJobCtl) saveLogs(ctx context.Context) {
+	jobs, err := c.client.ListWorkflowRunJobs(ctx, c.jobTaskSpec.RepoOwner, c.jobTaskSpec.RepoName, c.jobTaskSpec.RunID)
+	if err != nil {
+		c.logger.Warnf("failed to list jobs of github workflow run %d, error: %s", c.jobTaskSpec.RunID, err)
+		return
+	}
+
+	updated := false
+	for _, job := range jobs {
+		if job.GetStatus() != "completed" || c.savedJobs.Has(job.GetID()) {
+			continue
+		}
+		content, err := c.client.GetWorkflowJobLogs(ctx, c.jobTaskSpec.RepoOwner, c.jobTaskSpec.RepoName, job.GetID())
+		if err != nil {
+			c.logger.Warnf("failed to get logs of github job %s, error: %s", job.GetName(), err)
+			continue
+		}
+		c.logs.WriteString(fmt.Sprintf("========== %s (%s) ==========\n", job.GetName(), job.GetConclusion()))
+		c.logs.WriteString(content)
+		if !strings.HasSuffix(content, "\n") {
+			c.logs.WriteString("\n")
+		}
+		c.savedJobs.Insert(job.GetID())
+		updated = true
+	}
+	if !updated {
+		return
+	}
+
+	storage, err := s3service.FindDefaultS3()
+	if err != nil {
+		c.logger.Errorf("failed to find default object storage, error: %s", err)
+		return
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, storage.Provider)
+	if err != nil {
+		c.logger.Errorf("failed to create s3 client, error: %s", err)
+		return
+	}
+	// the same path as the logs of the other jobs, so it can be read by the log service
+	fileName := strings.Replace(strings.ToLower(c.job.Name), "_", "-", -1) + ".log"
+	objectKey := storage.GetObjectPath(fmt.Sprintf("%s/%d/log/%s", strings.ToLower(c.workflowCtx.WorkflowName), c.workflowCtx.TaskID, fileName))
+	if err := client.UploadContent(storage.Bucket, objectKey, []byte(c.logs.String())); err != nil {
+		c.logger.Errorf("failed to upload logs of github workflow run %d, error: %s", c.jobTaskSpec.RunID, err)
+	}
+}
+
+// githubConclusionToStatus maps the conclusion of a github workflow run to the zadig job status
+func githubConclusionToStatus(conclusion string) config.Status {
+	switch conclusion {
+	case "success", "neutral":
+		return config.StatusPassed
+	case "cancelled":
+		return config.StatusCancelled
+	case "skipped":
+		return config.StatusSkipped
+	case "timed_out":
+		return config.StatusTimeout
+	default:
+		return config.StatusFailed
+	}
+}
+
+func (c *GitHubActionsJobCtl) SaveInfo(ctx context.Context) error {
+	return mongodb.NewJobInfoColl().Create(ctx, &commonmodels.JobInfo{
+		Type:                c.job.JobType,
+		WorkflowName:        c.workflowCtx.WorkflowName,
+		WorkflowDisplayName: c.workflowCtx.WorkflowDisplayName,
+		TaskID:              c.workflowCtx.TaskID,
+		ProductName:         c.workflowCtx.ProjectName,
+		StartTime:           c.job.StartTime,
+		EndTime:             c.job.EndTime,
+		Duration:            c.job.EndTime - c.job.StartTime,
+		Status:              string(c.job.Status),
+	})
+}
@@ -66,6 +66,13 @@ func (c *HelmChartDeployJobCtl) Run(ctx context.Context) {
 	c.job.Status = config.StatusRunning
 	c.ack()
 
+	unlock, err := lockDeployEnv(c.workflowCtx, c.jobTaskSpec.Env)
+	if err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return
+	}
+	defer unlock()
+
 	productInfo, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
 		Name:    c.workflowCtx.ProjectName,
 		EnvName: c.jobTaskSpec.Env,
@@ -34,6 +34,7 @@ import (
 	helmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/helm"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
 	"github.com/koderover/zadig/v2/pkg/setting"
+	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
 	"github.com/koderover/zadig/v2/pkg/types/job"
 )
 
@@ -69,6 +70,13 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 	c.job.Status = config.StatusRunning
 	c.ack()
 
+	unlock, err := lockDeployEnv(c.workflowCtx, c.jobTaskSpec.Env)
+	if err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return
+	}
+	defer unlock()
+
 	// set IMAGE job output
 	for _, svc := range c.jobTaskSpec.ImageAndModules {
 		// helm deploy job key is jobName.serviceName
@@ -90,6 +98,13 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 		return
 	}
 
+	if slices.Contains(c.jobTaskSpec.DeployContents, config.DeployImage) && len(c.jobTaskSpec.ImageAndModules) > 0 {
+		if err := verifyDeployImages(productInfo, c.jobTaskSpec.ImageSigningKeyID, c.jobTaskSpec.GetDeployImages(), c.logger); err != nil {
+			logError(c.job, err.Error(), c.logger)
+			return
+		}
+	}
+
 	c.namespace = productInfo.Namespace
 	c.jobTaskSpec.ClusterID = productInfo.ClusterID
 
@@ -107,6 +122,20 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 	}
 	newEnvService.DeployStrategy = setting.ServiceDeployStrategyDeploy
 
+	if len(c.jobTaskSpec.ValuesKVs) > 0 {
+		kvs := make([]*helmtool.KV, 0, len(c.jobTaskSpec.ValuesKVs))
+		for _, kv := range c.jobTaskSpec.ValuesKVs {
+			kvs = append(kvs, &helmtool.KV{Key: kv.Key, Value: kv.Value})
+		}
+		overrideKVs, err := helmDeploySvc.MergeOverrideKVs(newEnvService.GetServiceRender().OverrideValues, kvs)
+		if err != nil {
+			msg := fmt.Sprintf("failed to set values keys, err: %s", err)
+			logError(c.job, msg, c.logger)
+			return
+		}
+		newEnvService.GetServiceRender().OverrideValues = overrideKVs
+	}
+
 	finalValuesYaml := ""
 	if len(c.jobTaskSpec.DeployContents) == 1 && slices.Contains(c.jobTaskSpec.DeployContents, config.DeployImage) {
 		finalValuesYaml, err = helmDeploySvc.GenMergedValues(newEnvService, productInfo.DefaultValues, c.jobTaskSpec.GetDeployImages())
@@ -160,6 +189,10 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 	done := make(chan bool)
 	go func(chan bool) {
 		if err = kube.DeploySingleHelmRelease(productInfo, newEnvService, tmplSvc, nil, c.jobTaskSpec.Timeout, c.workflowCtx.WorkflowTaskCreatorUsername); err != nil {
+			var hookErr *kube.HelmHookError
+			if errors.As(err, &hookErr) {
+				c.jobTaskSpec.HookDiagnosis = hookErr.Diagnosis
+			}
 			err = errors.WithMessagef(err,
 				"failed to upgrade helm chart %s/%s",
 				c.namespace, c.jobTaskSpec.ServiceName)
@@ -185,6 +218,11 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 		return
 	}
 
+	if err := runSmokeCheck(ctx, c.jobTaskSpec.ClusterID, c.namespace, c.jobTaskSpec.SmokeCheck, c.logger); err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return
+	}
+
 	c.job.Status = config.StatusPassed
 }
 
@@ -197,9 +235,12 @@ func (c *HelmDeployJobCtl) timeout() int {
 
 func (c *HelmDeployJobCtl) SaveInfo(ctx context.Context) error {
 	modules := make([]string, 0)
+	images := make(map[string]string)
 	for _, module := range c.jobTaskSpec.ImageAndModules {
 		modules = append(modules, module.ServiceModule)
+		images[module.ServiceModule] = module.Image
 	}
+	saveDeployPromotions(c.job, c.workflowCtx, c.jobTaskSpec.Env, c.jobTaskSpec.IsProduction, c.jobTaskSpec.ServiceName, images, c.logger)
 	moduleList := strings.Join(modules, ",")
 	return commonrepo.NewJobInfoColl().Create(context.TODO(), &commonmodels.JobInfo{
 		Type:                c.job.JobType,
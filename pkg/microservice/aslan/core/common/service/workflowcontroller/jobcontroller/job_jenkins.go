@@ -21,13 +21,18 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	jenkins "github.com/koderover/gojenkins"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	s3service "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/s3"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
+	s3tool "github.com/koderover/zadig/v2/pkg/tool/s3"
 	"go.uber.org/zap"
 )
 
@@ -84,8 +89,17 @@ func (c *JenkinsJobCtl) Run(ctx context.Context) {
 
 	params := make(map[string]string)
 	for _, parameter := range c.jobTaskSpec.Job.Parameters {
-		fmt.Printf("parameter: %v\n", parameter)
-		params[parameter.Name] = parameter.Value
+		value := parameter.Value
+		// jenkins only accepts "true" or "false" for boolean parameters
+		if parameter.Type == config.ParamTypeBool {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				logError(c.job, fmt.Sprintf("invalid value %q of bool parameter %s", value, parameter.Name), c.logger)
+				return
+			}
+			value = strconv.FormatBool(b)
+		}
+		params[parameter.Name] = value
 	}
 
 	queueid, err := job.InvokeSimple(context.TODO(), params)
@@ -128,16 +142,94 @@ func (c *JenkinsJobCtl) Run(ctx context.Context) {
 		log.Warnf("job jenkins failed to get logs from jenkins job, error: %s", err)
 	}
 	c.jobTaskSpec.Job.JobOutput = consoleOutput.Content
+	c.jobTaskSpec.Job.Result = build.GetResult()
 
-	if !build.IsGood(context.TODO()) {
-		c.job.Status = config.StatusFailed
-		return
+	if c.jobTaskSpec.PullArtifacts {
+		c.pullArtifacts(ctx, build)
 	}
 
-	c.job.Status = config.StatusPassed
+	c.job.Status = jenkinsResultToStatus(build.GetResult())
+	if c.job.Status != config.StatusPassed {
+		c.job.Error = fmt.Sprintf("jenkins build result: %s", build.GetResult())
+	}
 	return
 }
 
+// jenkinsResultToStatus maps the result of a jenkins build to the zadig job status
+func jenkinsResultToStatus(result string) config.Status {
+	switch result {
+	case "SUCCESS":
+		return config.StatusPassed
+	case "UNSTABLE":
+		return config.StatusUnstable
+	case "ABORTED":
+		return config.StatusCancelled
+	case "NOT_BUILT":
+		return config.StatusSkipped
+	default:
+		return config.StatusFailed
+	}
+}
+
+// pullArtifacts uploads the archived artifacts of the jenkins build to the default object storage and records them
+// as the artifacts of the job, failures are logged and do not affect the job status
+func (c *JenkinsJobCtl) pullArtifacts(ctx context.Context, build *jenkins.Build) {
+	artifacts := build.GetArtifacts()
+	if len(artifacts) == 0 {
+		return
+	}
+
+	storage, err := s3service.FindDefaultS3()
+	if err != nil {
+		c.logger.Errorf("failed to find default object storage to pull jenkins artifacts, error: %s", err)
+		return
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, storage.Provider)
+	if err != nil {
+		c.logger.Errorf("failed to create s3 client to pull jenkins artifacts, error: %s", err)
+		return
+	}
+
+	var expireTime int64
+	policy, err := mongodb.NewArtifactRetentionPolicyColl().Find(c.workflowCtx.ProjectName)
+	if err == nil && policy.RetentionDays > 0 {
+		expireTime = time.Now().AddDate(0, 0, policy.RetentionDays).Unix()
+	}
+
+	prefix := fmt.Sprintf("%s/%d/artifact/%s", c.workflowCtx.WorkflowName, c.workflowCtx.TaskID, c.job.Name)
+	records := make([]*commonmodels.WorkflowTaskArtifact, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		name := strings.TrimPrefix(artifact.Path, build.Base+"/artifact/")
+		data, err := artifact.GetData(ctx)
+		if err != nil {
+			c.logger.Errorf("failed to download jenkins artifact %s, error: %s", name, err)
+			continue
+		}
+		objectKey := storage.GetObjectPath(path.Join(prefix, name))
+		if err := client.UploadContent(storage.Bucket, objectKey, data); err != nil {
+			c.logger.Errorf("failed to upload jenkins artifact %s, error: %s", name, err)
+			continue
+		}
+		records = append(records, &commonmodels.WorkflowTaskArtifact{
+			ProjectName:    c.workflowCtx.ProjectName,
+			WorkflowName:   c.workflowCtx.WorkflowName,
+			TaskID:         c.workflowCtx.TaskID,
+			JobName:        c.job.Name,
+			JobDisplayName: c.job.DisplayName,
+			Name:           name,
+			StorageID:      storage.ID.Hex(),
+			ObjectKey:      objectKey,
+			Size:           int64(len(data)),
+			ExpireTime:     expireTime,
+		})
+	}
+
+	if err := mongodb.NewWorkflowTaskArtifactColl().BulkCreate(records); err != nil {
+		c.logger.Errorf("failed to save jenkins artifacts of job %s, error: %s", c.job.Name, err)
+	}
+	c.jobTaskSpec.Job.Artifacts = len(records)
+}
+
 func (c *JenkinsJobCtl) SaveInfo(ctx context.Context) error {
 	return mongodb.NewJobInfoColl().Create(context.TODO(), &commonmodels.JobInfo{
 		Type:                c.job.JobType,
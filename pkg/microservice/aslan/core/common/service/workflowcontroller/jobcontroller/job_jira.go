@@ -19,6 +19,7 @@ package jobcontroller
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"go.uber.org/zap"
 
@@ -26,6 +27,7 @@ import (
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/v2/pkg/tool/jira"
+	"github.com/koderover/zadig/v2/pkg/types/step"
 )
 
 type JiraJobCtl struct {
@@ -62,12 +64,40 @@ func (c *JiraJobCtl) Run(ctx context.Context) {
 		logError(c.job, err.Error(), c.logger)
 		return
 	}
+	if c.jobTaskSpec.IssueSource == commonmodels.JiraIssueSourceCommit {
+		if err := c.setIssuesFromCommits(info.JiraHost); err != nil {
+			logError(c.job, fmt.Sprintf("extract issues from commit messages error: %v", err), c.logger)
+			return
+		}
+		if len(c.jobTaskSpec.Issues) == 0 {
+			c.logger.Infof("no jira issue found in the commit messages of workflow %s task %d", c.workflowCtx.WorkflowName, c.workflowCtx.TaskID)
+			c.job.Status = config.StatusPassed
+			return
+		}
+	}
 	if len(c.jobTaskSpec.Issues) == 0 {
 		logError(c.job, "issues not found in job spec", c.logger)
 		return
 	}
+	targetStatus, err := c.getTargetStatus()
+	if err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return
+	}
 	client := jira.NewJiraClientWithAuthType(info.JiraHost, info.JiraUser, info.JiraToken, info.JiraPersonalAccessToken, info.JiraAuthType)
 	for _, issue := range c.jobTaskSpec.Issues {
+		if c.jobTaskSpec.Comment != "" {
+			if err := client.Issue.AddCommentV2(issue.Key, c.jobTaskSpec.Comment); err != nil {
+				logError(c.job, fmt.Sprintf("Add comment to issue %s error: %v", issue.Key, err), c.logger)
+				issue.Status = string(config.StatusFailed)
+				return
+			}
+		}
+		// only comment on the issue if no target status is configured
+		if targetStatus == "" {
+			issue.Status = string(config.StatusPassed)
+			continue
+		}
 		list, err := client.Issue.GetTransitions(issue.Key)
 		if err != nil {
 			logError(c.job, fmt.Sprintf("GetTransitions issue %s error: %v", issue.Key, err), c.logger)
@@ -76,13 +106,13 @@ func (c *JiraJobCtl) Run(ctx context.Context) {
 		}
 		var id string
 		for _, transition := range list {
-			if transition.To.Name == c.jobTaskSpec.TargetStatus {
+			if transition.To.Name == targetStatus {
 				id = transition.ID
 				break
 			}
 		}
 		if id == "" {
-			logError(c.job, fmt.Sprintf("Issue %s failed to find status %s transition id", issue.Key, c.jobTaskSpec.TargetStatus), c.logger)
+			logError(c.job, fmt.Sprintf("Issue %s failed to find status %s transition id", issue.Key, targetStatus), c.logger)
 			issue.Status = string(config.StatusFailed)
 			return
 		}
@@ -98,6 +128,106 @@ func (c *JiraJobCtl) Run(ctx context.Context) {
 	return
 }
 
+// setIssuesFromCommits extracts the issue keys from the commit messages of the build jobs in the same task
+func (c *JiraJobCtl) setIssuesFromCommits(jiraHost string) error {
+	task, err := mongodb.NewworkflowTaskv4Coll().Find(c.workflowCtx.WorkflowName, c.workflowCtx.TaskID)
+	if err != nil {
+		return fmt.Errorf("find workflow task error: %v", err)
+	}
+
+	messages := make([]string, 0)
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			if job.JobType != string(config.JobZadigBuild) && job.JobType != string(config.JobFreestyle) {
+				continue
+			}
+			jobSpec := &commonmodels.JobTaskFreestyleSpec{}
+			if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+				continue
+			}
+			for _, stepTask := range jobSpec.Steps {
+				if stepTask.StepType != config.StepGit {
+					continue
+				}
+				stepSpec := &step.StepGitSpec{}
+				if err := commonmodels.IToi(stepTask.Spec, stepSpec); err != nil {
+					continue
+				}
+				for _, repo := range stepSpec.Repos {
+					if repo.CommitMessage != "" {
+						messages = append(messages, repo.CommitMessage)
+					}
+				}
+			}
+		}
+	}
+
+	issues := make([]*commonmodels.IssueID, 0)
+	for _, key := range jira.ExtractIssueKeys(strings.Join(messages, "\n")) {
+		if c.jobTaskSpec.ProjectID != "" && !strings.HasPrefix(key, c.jobTaskSpec.ProjectID+"-") {
+			continue
+		}
+		issues = append(issues, &commonmodels.IssueID{
+			Key:  key,
+			Link: fmt.Sprintf("%s/browse/%s", strings.TrimSuffix(jiraHost, "/"), key),
+		})
+	}
+	c.jobTaskSpec.Issues = issues
+	return nil
+}
+
+// getTargetStatus returns the status configured for the type of the env deployed in the same task,
+// production env takes precedence over testing env, and falls back to the target status of the job.
+func (c *JiraJobCtl) getTargetStatus() (string, error) {
+	if len(c.jobTaskSpec.EnvTransitions) == 0 {
+		return c.jobTaskSpec.TargetStatus, nil
+	}
+	task, err := mongodb.NewworkflowTaskv4Coll().Find(c.workflowCtx.WorkflowName, c.workflowCtx.TaskID)
+	if err != nil {
+		return "", fmt.Errorf("find workflow task error: %v", err)
+	}
+
+	deployedEnvTypes := make(map[string]bool)
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			switch job.JobType {
+			case string(config.JobZadigDeploy):
+				jobSpec := &commonmodels.JobTaskDeploySpec{}
+				if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+					continue
+				}
+				if jobSpec.Production {
+					deployedEnvTypes[commonmodels.JiraEnvTypeProduction] = true
+				} else {
+					deployedEnvTypes[commonmodels.JiraEnvTypeTesting] = true
+				}
+			case string(config.JobZadigHelmDeploy):
+				jobSpec := &commonmodels.JobTaskHelmDeploySpec{}
+				if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+					continue
+				}
+				if jobSpec.IsProduction {
+					deployedEnvTypes[commonmodels.JiraEnvTypeProduction] = true
+				} else {
+					deployedEnvTypes[commonmodels.JiraEnvTypeTesting] = true
+				}
+			}
+		}
+	}
+
+	for _, envType := range []string{commonmodels.JiraEnvTypeProduction, commonmodels.JiraEnvTypeTesting} {
+		if !deployedEnvTypes[envType] {
+			continue
+		}
+		for _, transition := range c.jobTaskSpec.EnvTransitions {
+			if transition.EnvType == envType {
+				return transition.TargetStatus, nil
+			}
+		}
+	}
+	return c.jobTaskSpec.TargetStatus, nil
+}
+
 func (c *JiraJobCtl) SaveInfo(ctx context.Context) error {
 	return mongodb.NewJobInfoColl().Create(context.TODO(), &commonmodels.JobInfo{
 		Type:                c.job.JobType,
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+
+	systemconfig "github.com/koderover/zadig/v2/pkg/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/shared/client/aslan"
+)
+
+type SubWorkflowJobCtl struct {
+	job         *commonmodels.JobTask
+	workflowCtx *commonmodels.WorkflowTaskCtx
+	logger      *zap.SugaredLogger
+	jobTaskSpec *commonmodels.JobTaskSubWorkflowSpec
+	ack         func()
+}
+
+func NewSubWorkflowJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *SubWorkflowJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskSubWorkflowSpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	job.Spec = jobTaskSpec
+	return &SubWorkflowJobCtl{
+		job:         job,
+		workflowCtx: workflowCtx,
+		logger:      logger,
+		ack:         ack,
+		jobTaskSpec: jobTaskSpec,
+	}
+}
+
+func (c *SubWorkflowJobCtl) Clean(ctx context.Context) {}
+
+func (c *SubWorkflowJobCtl) Run(ctx context.Context) {
+	c.job.Status = config.StatusRunning
+	c.ack()
+
+	workflow, err := mongodb.NewWorkflowV4Coll().Find(c.jobTaskSpec.WorkflowName)
+	if err != nil {
+		logError(c.job, fmt.Sprintf("find workflow %s err: %v", c.jobTaskSpec.WorkflowName, err), c.logger)
+		return
+	}
+	// the params not overridden by the job keep the default values of the sub workflow
+	paramMap := make(map[string]*commonmodels.Param)
+	for _, param := range c.jobTaskSpec.Params {
+		paramMap[param.Name] = param
+	}
+	for _, param := range workflow.Params {
+		if override, ok := paramMap[param.Name]; ok {
+			param.Value = override.Value
+			param.ChoiceValue = override.ChoiceValue
+			if override.Repo != nil {
+				param.Repo = override.Repo
+			}
+		}
+	}
+	c.jobTaskSpec.WorkflowDisplayName = workflow.DisplayName
+
+	client := aslan.New(systemconfig.AslanServiceAddress())
+	resp, err := client.CreateWorkflowTaskV4(&aslan.CreateWorkflowTaskV4Req{
+		Workflow: workflow,
+		UserName: setting.WorkflowTriggerTaskCreator,
+	})
+	if err != nil {
+		logError(c.job, fmt.Sprintf("create workflow task %s err: %v", workflow.Name, err), c.logger)
+		return
+	}
+	c.jobTaskSpec.TaskID = resp.TaskID
+	c.jobTaskSpec.TaskStatus = config.StatusCreated
+	c.jobTaskSpec.TaskURL = fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d?display_name=%s", systemconfig.SystemAddress(), workflow.Project, workflow.Name, resp.TaskID, url.PathEscape(workflow.DisplayName))
+	c.ack()
+
+	if c.jobTaskSpec.Async {
+		c.job.Status = config.StatusPassed
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the sub workflow is cancelled along with the workflow
+			if err := client.CancelWorkflowTaskV4(setting.WorkflowTriggerTaskCreator, workflow.Name, resp.TaskID); err != nil {
+				c.logger.Errorf("failed to cancel sub workflow task %s-%d, err: %v", workflow.Name, resp.TaskID, err)
+			} else {
+				c.jobTaskSpec.TaskStatus = config.StatusCancelled
+			}
+			c.job.Status = config.StatusCancelled
+			return
+		case <-time.After(time.Second):
+		}
+
+		task, err := mongodb.NewworkflowTaskv4Coll().Find(workflow.Name, resp.TaskID)
+		if err != nil {
+			logError(c.job, fmt.Sprintf("get workflow task %s-%d err: %v", workflow.Name, resp.TaskID, err), c.logger)
+			return
+		}
+		if task.Status != c.jobTaskSpec.TaskStatus {
+			c.jobTaskSpec.TaskStatus = task.Status
+			c.ack()
+		}
+
+		switch task.Status {
+		case config.StatusPassed, config.StatusUnstable:
+			c.job.Status = config.StatusPassed
+			return
+		case config.StatusFailed, config.StatusCancelled, config.StatusReject, config.StatusTimeout:
+			logError(c.job, fmt.Sprintf("sub workflow task %s-%d finished with status %s", workflow.Name, resp.TaskID, task.Status), c.logger)
+			return
+		}
+	}
+}
+
+func (c *SubWorkflowJobCtl) SaveInfo(ctx context.Context) error {
+	return mongodb.NewJobInfoColl().Create(context.TODO(), &commonmodels.JobInfo{
+		Type:                c.job.JobType,
+		WorkflowName:        c.workflowCtx.WorkflowName,
+		WorkflowDisplayName: c.workflowCtx.WorkflowDisplayName,
+		TaskID:              c.workflowCtx.TaskID,
+		ProductName:         c.workflowCtx.ProjectName,
+		StartTime:           c.job.StartTime,
+		EndTime:             c.job.EndTime,
+		Duration:            c.job.EndTime - c.job.StartTime,
+		Status:              string(c.job.Status),
+	})
+}
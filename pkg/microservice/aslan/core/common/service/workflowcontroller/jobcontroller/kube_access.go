@@ -0,0 +1,270 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+)
+
+const (
+	envKubeAccessVolumeName = "env-kubeconfig"
+	envKubeAccessMountPath  = "/zadig/kube"
+	envKubeAccessLabel      = "zadig-job-kube-access"
+	// the minimum expiration of the token allowed by kubernetes
+	minTokenExpirationSeconds = 600
+)
+
+const envKubeConfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- name: zadig
+  cluster:
+    server: https://kubernetes.default.svc
+    certificate-authority: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+contexts:
+- name: zadig
+  context:
+    cluster: zadig
+    namespace: %s
+    user: zadig
+current-context: zadig
+users:
+- name: zadig
+  user:
+    token: %s
+`
+
+func getEnvKubeAccessName(k8sJobName string) string {
+	return fmt.Sprintf("zadig-job-%s", k8sJobName)
+}
+
+func getEnvKubeConfigSecretName(k8sJobName string) string {
+	return fmt.Sprintf("%s-kubeconfig", k8sJobName)
+}
+
+func getEnvKubeAccessRules(readOnly bool) []rbacv1.PolicyRule {
+	verbs := []string{"get", "list", "watch"}
+	podVerbs := []string{"get", "list", "watch"}
+	if !readOnly {
+		verbs = append(verbs, "create", "update", "patch", "delete")
+		// pods can be deleted to restart them, but not created since a pod mounting any secret of the namespace
+		// exposes it
+		podVerbs = append(podVerbs, "delete")
+	}
+	// secrets, rbac resources, pod creation and pods/exec are never granted. The read-only access can't read the secrets
+	// of the namespace, while the write access can: a workload created or patched by the job may mount any secret and
+	// print it to its logs, so the write access is only granted to the users who can edit the env.
+	rules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: podVerbs},
+		{APIGroups: []string{""}, Resources: []string{"services", "configmaps", "endpoints", "events", "persistentvolumeclaims"}, Verbs: verbs},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments", "deployments/scale", "statefulsets", "statefulsets/scale", "daemonsets", "replicasets"}, Verbs: verbs},
+		{APIGroups: []string{"batch"}, Resources: []string{"jobs", "cronjobs"}, Verbs: verbs},
+		{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses"}, Verbs: verbs},
+		{APIGroups: []string{"autoscaling"}, Resources: []string{"horizontalpodautoscalers"}, Verbs: verbs},
+	}
+	if !readOnly {
+		rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/portforward"}, Verbs: []string{"create", "get"}})
+	}
+	return rules
+}
+
+// grantEnvKubeAccess creates a service account with the least privilege in the namespace of the env, and saves a kubeconfig
+// with its short-lived token into a secret in the job namespace, the secret is mounted to the job pod.
+func grantEnvKubeAccess(jobTask *commonmodels.JobTask, jobTaskSpec *commonmodels.JobTaskFreestyleSpec, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) (*commonmodels.JobKubeAccessGrant, error) {
+	access := jobTaskSpec.Properties.EnvKubeAccess
+	production := access.Production
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       workflowCtx.ProjectName,
+		EnvName:    access.EnvName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find env %s, error: %v", access.EnvName, err)
+	}
+	envClusterID := env.ClusterID
+	if envClusterID == "" {
+		envClusterID = setting.LocalClusterID
+	}
+	// the kubeconfig uses the in-cluster address of the apiserver, so the job must run in the cluster of the env
+	if envClusterID != jobTaskSpec.Properties.ClusterID {
+		return nil, fmt.Errorf("env %s is not in the cluster where the job runs", access.EnvName)
+	}
+
+	clientset, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(envClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clientset of cluster %s, error: %v", envClusterID, err)
+	}
+
+	name := getEnvKubeAccessName(jobTask.K8sJobName)
+	objectMeta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: env.Namespace,
+		Labels:    map[string]string{envKubeAccessLabel: jobTask.K8sJobName},
+	}
+	// clean up the resources left by the last run of the job
+	revokeEnvKubeAccessResources(clientset, env.Namespace, jobTaskSpec.Properties.Namespace, jobTask.K8sJobName, logger)
+
+	ctx := context.TODO()
+	if _, err := clientset.CoreV1().ServiceAccounts(env.Namespace).Create(ctx, &corev1.ServiceAccount{ObjectMeta: objectMeta}, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create service account, error: %v", err)
+	}
+	role := &rbacv1.Role{ObjectMeta: objectMeta, Rules: getEnvKubeAccessRules(access.ReadOnly)}
+	if _, err := clientset.RbacV1().Roles(env.Namespace).Create(ctx, role, metav1.CreateOptions{}); err != nil {
+		revokeEnvKubeAccessResources(clientset, env.Namespace, jobTaskSpec.Properties.Namespace, jobTask.K8sJobName, logger)
+		return nil, fmt.Errorf("failed to create role, error: %v", err)
+	}
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: objectMeta,
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: env.Namespace}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+	}
+	if _, err := clientset.RbacV1().RoleBindings(env.Namespace).Create(ctx, roleBinding, metav1.CreateOptions{}); err != nil {
+		revokeEnvKubeAccessResources(clientset, env.Namespace, jobTaskSpec.Properties.Namespace, jobTask.K8sJobName, logger)
+		return nil, fmt.Errorf("failed to create role binding, error: %v", err)
+	}
+
+	// the token lives no longer than the job, it is invalidated earlier when the service account is deleted
+	expirationSeconds := jobTaskSpec.Properties.Timeout * 60
+	if expirationSeconds < minTokenExpirationSeconds {
+		expirationSeconds = minTokenExpirationSeconds
+	}
+	token, err := clientset.CoreV1().ServiceAccounts(env.Namespace).CreateToken(ctx, name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expirationSeconds},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		revokeEnvKubeAccessResources(clientset, env.Namespace, jobTaskSpec.Properties.Namespace, jobTask.K8sJobName, logger)
+		return nil, fmt.Errorf("failed to create token, error: %v", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getEnvKubeConfigSecretName(jobTask.K8sJobName),
+			Namespace: jobTaskSpec.Properties.Namespace,
+			Labels:    map[string]string{envKubeAccessLabel: jobTask.K8sJobName},
+		},
+		StringData: map[string]string{"config": fmt.Sprintf(envKubeConfigTemplate, env.Namespace, token.Status.Token)},
+	}
+	if _, err := clientset.CoreV1().Secrets(jobTaskSpec.Properties.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		revokeEnvKubeAccessResources(clientset, env.Namespace, jobTaskSpec.Properties.Namespace, jobTask.K8sJobName, logger)
+		return nil, fmt.Errorf("failed to create kubeconfig secret, error: %v", err)
+	}
+
+	grant := &commonmodels.JobKubeAccessGrant{
+		ProjectName:    workflowCtx.ProjectName,
+		WorkflowName:   workflowCtx.WorkflowName,
+		TaskID:         workflowCtx.TaskID,
+		JobName:        jobTask.Name,
+		EnvName:        access.EnvName,
+		Production:     access.Production,
+		ClusterID:      envClusterID,
+		Namespace:      env.Namespace,
+		ServiceAccount: name,
+		ReadOnly:       access.ReadOnly,
+		TaskCreator:    workflowCtx.WorkflowTaskCreatorUsername,
+		ExpireTime:     token.Status.ExpirationTimestamp.Unix(),
+	}
+	if err := commonrepo.NewJobKubeAccessGrantColl().Create(grant); err != nil {
+		logger.Errorf("failed to save kube access grant of job %s, error: %v", jobTask.Name, err)
+	}
+	return grant, nil
+}
+
+// revokeEnvKubeAccess deletes the service account so that its token is invalidated immediately
+func revokeEnvKubeAccess(grant *commonmodels.JobKubeAccessGrant, jobTask *commonmodels.JobTask, jobNamespace string, logger *zap.SugaredLogger) {
+	clientset, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(grant.ClusterID)
+	if err != nil {
+		logger.Errorf("failed to get clientset of cluster %s to revoke kube access, error: %v", grant.ClusterID, err)
+		return
+	}
+	revokeEnvKubeAccessResources(clientset, grant.Namespace, jobNamespace, jobTask.K8sJobName, logger)
+
+	if !grant.ID.IsZero() {
+		if err := commonrepo.NewJobKubeAccessGrantColl().MarkRevoked(grant.ID); err != nil {
+			logger.Errorf("failed to mark kube access grant of job %s revoked, error: %v", jobTask.Name, err)
+		}
+	}
+}
+
+func revokeEnvKubeAccessResources(clientset *kubernetes.Clientset, envNamespace, jobNamespace, k8sJobName string, logger *zap.SugaredLogger) {
+	ctx := context.TODO()
+	name := getEnvKubeAccessName(k8sJobName)
+	deleteFuncs := map[string]func() error{
+		"service account": func() error {
+			return clientset.CoreV1().ServiceAccounts(envNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+		"role binding": func() error {
+			return clientset.RbacV1().RoleBindings(envNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+		"role": func() error {
+			return clientset.RbacV1().Roles(envNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+		"kubeconfig secret": func() error {
+			return clientset.CoreV1().Secrets(jobNamespace).Delete(ctx, getEnvKubeConfigSecretName(k8sJobName), metav1.DeleteOptions{})
+		},
+	}
+	for kind, deleteFunc := range deleteFuncs {
+		if err := deleteFunc(); err != nil && !apierrors.IsNotFound(err) {
+			logger.Errorf("failed to delete %s of job %s, error: %v", kind, k8sJobName, err)
+		}
+	}
+}
+
+// setEnvKubeConfig mounts the kubeconfig secret to the job container and points KUBECONFIG to it
+func setEnvKubeConfig(podSpec *corev1.PodSpec, k8sJobName string) {
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: envKubeAccessVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: getEnvKubeConfigSecretName(k8sJobName)},
+		},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      envKubeAccessVolumeName,
+		MountPath: envKubeAccessMountPath,
+		ReadOnly:  true,
+	})
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name:  "KUBECONFIG",
+		Value: envKubeAccessMountPath + "/config",
+	})
+}
+
+func isEnvKubeAccessEnabled(jobTaskSpec *commonmodels.JobTaskFreestyleSpec) bool {
+	return jobTaskSpec.Properties.EnvKubeAccess != nil && jobTaskSpec.Properties.EnvKubeAccess.Enabled &&
+		jobTaskSpec.Properties.EnvKubeAccess.EnvName != ""
+}
+
+func (c *FreestyleJobCtl) revokeEnvKubeAccess() {
+	if c.kubeAccessGrant == nil {
+		return
+	}
+	revokeEnvKubeAccess(c.kubeAccessGrant, c.job, c.jobTaskSpec.Properties.Namespace, c.logger)
+	c.kubeAccessGrant = nil
+}
@@ -459,6 +459,19 @@ EOF`,
 		},
 	}
 
+	if jobTaskSpec.Properties.PodTemplate != "" {
+		podTemplate, err := commonrepo.NewJobPodTemplateColl().Get(workflowCtx.ProjectName, jobTaskSpec.Properties.PodTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find pod template %s, err: %s", jobTaskSpec.Properties.PodTemplate, err)
+		}
+		if err := commonutil.ApplyJobPodTemplate(&job.Spec.Template.Spec, podTemplate); err != nil {
+			return nil, fmt.Errorf("failed to apply pod template %s, err: %s", jobTaskSpec.Properties.PodTemplate, err)
+		}
+	}
+	if isEnvKubeAccessEnabled(jobTaskSpec) {
+		setEnvKubeConfig(&job.Spec.Template.Spec, jobTask.K8sJobName)
+	}
+
 	setJobShareStorages(job, workflowCtx, jobTaskSpec.Properties.ShareStorageDetails, targetCluster)
 
 	if jobTaskSpec.Properties.CacheEnable && jobTaskSpec.Properties.Cache.MediumType == commontypes.NFSMedium {
@@ -487,9 +500,34 @@ EOF`,
 	return job, nil
 }
 
-func BuildCleanJob(jobName, clusterID, workflowName string, taskID int64) (*batchv1.Job, error) {
+// BuildCleanJob builds the job removing the task scoped share storages of the task, the expired files and the oversized
+// caches of the workflow scoped share storages are removed only if evictCache is set, i.e. no other task is using them.
+func BuildCleanJob(jobName, clusterID, workflowName string, taskID int64, shareStorages []*commonmodels.ShareStorage, evictCache bool) (*batchv1.Job, error) {
 	workspace := "/workspace"
-	shareStorageDir := commontypes.GetShareStorageSubPathPrefix(workflowName, taskID)
+	if !isValidShareStoragePathName(workflowName) {
+		return nil, fmt.Errorf("invalid workflow name %s for share storage", workflowName)
+	}
+	// the paths are passed to the shell as positional parameters instead of being put into the script,
+	// so the names of the workflow and the storages are never interpreted by the shell
+	cleanPaths := []string{commontypes.GetShareStorageSubPathPrefix(workflowName, taskID)}
+	cleanCmds := []string{`rm -rf "$1"`}
+	// workflow scoped storages are kept across tasks, only expired files and oversized caches are cleaned
+	for _, storage := range shareStorages {
+		if !evictCache || !storage.IsWorkflowScoped() {
+			continue
+		}
+		if !isValidShareStoragePathName(storage.Name) {
+			return nil, fmt.Errorf("invalid share storage name %s", storage.Name)
+		}
+		cleanPaths = append(cleanPaths, commontypes.GetShareStorageCacheSubPath(workflowName, storage.Name))
+		cacheDir := fmt.Sprintf(`"${%d}"`, len(cleanPaths))
+		if storage.RetentionDays > 0 {
+			cleanCmds = append(cleanCmds, fmt.Sprintf("if [ -d %s ]; then find %s -type f -mtime +%d -delete; fi", cacheDir, cacheDir, storage.RetentionDays))
+		}
+		if storage.SizeLimit > 0 {
+			cleanCmds = append(cleanCmds, fmt.Sprintf(`if [ -d %s ] && [ "$(du -sm %s | cut -f1)" -gt %d ]; then rm -rf %s; fi`, cacheDir, cacheDir, storage.SizeLimit, cacheDir))
+		}
+	}
 	image := strings.ReplaceAll(config.ReaperImage(), "${BuildOS}", "focal")
 	targetCluster, err := service.GetCluster(clusterID, log.SugaredLogger())
 	if err != nil {
@@ -518,7 +556,8 @@ func BuildCleanJob(jobName, clusterID, workflowName string, taskID int64) (*batc
 							Image:           image,
 							WorkingDir:      workspace,
 							Command:         []string{"/bin/sh", "-c"},
-							Args:            []string{fmt.Sprintf("rm -rf %s", shareStorageDir)},
+							// the first argument after the script is $0
+							Args: append([]string{strings.Join(cleanCmds, "; "), "clean"}, cleanPaths...),
 
 							TerminationMessagePolicy: corev1.TerminationMessageReadFile,
 							TerminationMessagePath:   job.JobTerminationFile,
@@ -546,6 +585,11 @@ func BuildCleanJob(jobName, clusterID, workflowName string, taskID int64) (*batc
 	return job, nil
 }
 
+// isValidShareStoragePathName makes sure the name is a single path element, so the cleaned path stays in the share storage
+func isValidShareStoragePathName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
 func setJobShareStorages(job *batchv1.Job, workflowCtx *commonmodels.WorkflowTaskCtx, storageDetails []*commonmodels.StorageDetail, cluster *commonmodels.K8SCluster) {
 	if cluster == nil {
 		return
@@ -919,6 +963,9 @@ func waitJobEndByCheckingConfigMap(ctx context.Context, taskTimeout <-chan time.
 				xl.Errorf(errMsg)
 				return config.StatusFailed, errMsg
 			}
+			if data := cm.Data[commontypes.JobCheckpointKey]; data != "" {
+				updateJobCheckpoint(data, jobTask, ack, xl)
+			}
 			// pod is still running
 			switch {
 			case job.Status.Active != 0:
@@ -934,6 +981,10 @@ func waitJobEndByCheckingConfigMap(ctx context.Context, taskTimeout <-chan time.
 						continue
 					}
 					if ipod.Failed() {
+						// the reason is kept to tell the infrastructure failures like eviction from the job failures
+						if pod.Status.Reason != "" {
+							return config.StatusFailed, fmt.Sprintf("pod %s failed, reason: %s, message: %s", pod.Name, pod.Status.Reason, pod.Status.Message)
+						}
 						return config.StatusFailed, ""
 					}
 					if !ipod.Finished() {
@@ -0,0 +1,307 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/portforward"
+)
+
+const (
+	defaultSmokeCheckWindow   = 300
+	defaultSmokeProbeTimeout  = 5
+	defaultSmokeProbeInterval = 5
+	// a tcp probe passes if the port forward is not refused within the time
+	tcpProbeSettleTime = 2 * time.Second
+)
+
+// runSmokeCheck sends the probes to the services in the namespace through the port forward of their ready pods, so the
+// probes are executed from within the target cluster. It returns an error if any of the probes doesn't pass within the window.
+func runSmokeCheck(ctx context.Context, clusterID, namespace string, smokeCheck *commonmodels.SmokeCheck, logger *zap.SugaredLogger) error {
+	if smokeCheck == nil || !smokeCheck.Enabled || len(smokeCheck.Probes) == 0 {
+		return nil
+	}
+
+	clientSet, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get kube client set, err: %s", err)
+	}
+
+	window := smokeCheck.Window
+	if window == 0 {
+		window = defaultSmokeCheckWindow
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(window)*time.Second)
+	defer cancel()
+
+	for _, probe := range smokeCheck.Probes {
+		if err := runSmokeProbe(ctx, clientSet, clusterID, namespace, probe, logger); err != nil {
+			return fmt.Errorf("smoke probe %s failed: %s", probe.Name, err)
+		}
+		logger.Infof("smoke probe %s of service %s passed", probe.Name, probe.ServiceName)
+	}
+	return nil
+}
+
+// runSmokeProbe retries the probe until it passes, the retries are used up or the window ends.
+func runSmokeProbe(ctx context.Context, clientSet *kubernetes.Clientset, clusterID, namespace string, probe *commonmodels.SmokeProbe, logger *zap.SugaredLogger) error {
+	timeout := probe.Timeout
+	if timeout == 0 {
+		timeout = defaultSmokeProbeTimeout
+	}
+	interval := probe.Interval
+	if interval == 0 {
+		interval = defaultSmokeProbeInterval
+	}
+
+	var lastErr error
+	for attempt := 0; probe.Retries == 0 || attempt <= probe.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("not passed within the window, last error: %s", lastErr)
+			case <-time.After(time.Duration(interval) * time.Second):
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		lastErr = probeOnce(attemptCtx, clientSet, clusterID, namespace, probe, attempt)
+		cancel()
+		if lastErr == nil {
+			return nil
+		}
+		logger.Warnf("attempt %d of smoke probe %s failed: %s", attempt+1, probe.Name, lastErr)
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("not passed within the window, last error: %s", lastErr)
+		}
+	}
+	return fmt.Errorf("not passed after %d retries, last error: %s", probe.Retries, lastErr)
+}
+
+func probeOnce(ctx context.Context, clientSet *kubernetes.Clientset, clusterID, namespace string, probe *commonmodels.SmokeProbe, attempt int) error {
+	k8sService := probe.K8sService
+	if k8sService == "" {
+		k8sService = probe.ServiceName
+	}
+	pod, port, err := getSmokeProbeTarget(ctx, clientSet, namespace, k8sService, probe.Port, attempt)
+	if err != nil {
+		return err
+	}
+
+	req := clientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("portforward")
+	dialer, err := clientmanager.NewKubeClientManager().GetSPDYDialer(clusterID, req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to get port forward dialer, err: %s", err)
+	}
+	dial := func(context.Context) (net.Conn, error) {
+		return portforward.Dial(dialer, port)
+	}
+
+	host := fmt.Sprintf("%s.%s:%d", k8sService, namespace, probe.Port)
+	switch probe.Type {
+	case config.SmokeProbeHTTP:
+		return probeHTTP(ctx, dial, host, probe)
+	case config.SmokeProbeGRPC:
+		return probeGRPC(ctx, dial, host, probe)
+	case config.SmokeProbeTCP:
+		return probeTCP(ctx, dial)
+	default:
+		return fmt.Errorf("unsupported probe type: %s", probe.Type)
+	}
+}
+
+// getSmokeProbeTarget returns a ready pod behind the service and its port that the service port targets,
+// the ready pods are probed in turn across the attempts.
+func getSmokeProbeTarget(ctx context.Context, clientSet *kubernetes.Clientset, namespace, serviceName string, servicePort, attempt int) (string, int, error) {
+	svc, err := clientSet.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get service %s, err: %s", serviceName, err)
+	}
+	var targetPort *intstr.IntOrString
+	for _, port := range svc.Spec.Ports {
+		if int(port.Port) == servicePort {
+			targetPort = &port.TargetPort
+			break
+		}
+	}
+	if targetPort == nil {
+		return "", 0, fmt.Errorf("port %d is not found in service %s", servicePort, serviceName)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return "", 0, fmt.Errorf("service %s has no selector", serviceName)
+	}
+
+	pods, err := clientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list pods of service %s, err: %s", serviceName, err)
+	}
+	readyPods := make([]*corev1.Pod, 0)
+	for i := range pods.Items {
+		if isPodReady(&pods.Items[i]) {
+			readyPods = append(readyPods, &pods.Items[i])
+		}
+	}
+	if len(readyPods) == 0 {
+		return "", 0, fmt.Errorf("no ready pod of service %s", serviceName)
+	}
+	pod := readyPods[attempt%len(readyPods)]
+
+	switch {
+	case targetPort.Type == intstr.String:
+		for _, container := range pod.Spec.Containers {
+			for _, port := range container.Ports {
+				if port.Name == targetPort.StrVal {
+					return pod.Name, int(port.ContainerPort), nil
+				}
+			}
+		}
+		return "", 0, fmt.Errorf("port %s is not found in pod %s", targetPort.StrVal, pod.Name)
+	case targetPort.IntVal == 0:
+		return pod.Name, servicePort, nil
+	default:
+		return pod.Name, int(targetPort.IntVal), nil
+	}
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func probeHTTP(ctx context.Context, dial func(context.Context) (net.Conn, error), host string, probe *commonmodels.SmokeProbe) error {
+	method := probe.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("http://%s%s", host, probe.Path), nil)
+	if err != nil {
+		return fmt.Errorf("invalid request, err: %s", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dial(ctx)
+			},
+			DisableKeepAlives: true,
+		},
+		// the redirected location may not be reachable through the port forward
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if len(probe.ExpectedStatus) == 0 {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}
+	for _, status := range probe.ExpectedStatus {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected status %d, expected %v", resp.StatusCode, probe.ExpectedStatus)
+}
+
+func probeGRPC(ctx context.Context, dial func(context.Context) (net.Conn, error), host string, probe *commonmodels.SmokeProbe) error {
+	conn, err := grpc.DialContext(ctx, host,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return dial(ctx)
+		}),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect, err: %s", err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: probe.GRPCService})
+	if err != nil {
+		return fmt.Errorf("health check failed, err: %s", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("unexpected health status %s", resp.Status)
+	}
+	return nil
+}
+
+func probeTCP(ctx context.Context, dial func(context.Context) (net.Conn, error)) error {
+	conn, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// the port forward reports the refused connection asynchronously, the port is considered open if the connection
+	// is not closed with an error in the settle time
+	deadline := time.Now().Add(tcpProbeSettleTime)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	buf := make([]byte, 1)
+	_, err = conn.Read(buf)
+	if err == nil || errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
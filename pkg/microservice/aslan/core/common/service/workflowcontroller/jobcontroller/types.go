@@ -43,6 +43,8 @@ type JobContext struct {
 	Paths string `yaml:"paths"`
 	// ConfigMapName save the name of the configmap in which the jobContext resides
 	ConfigMapName string `yaml:"config_map_name"`
+	// CheckpointInterval 长时间运行测试的进度上报间隔, 单位秒, 为 0 时不上报
+	CheckpointInterval int64 `yaml:"checkpoint_interval"`
 
 	Steps   []*commonmodels.StepTask `yaml:"steps"`
 	Outputs []string                 `yaml:"outputs"`
@@ -26,6 +26,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/mongo"
 
+	config2 "github.com/koderover/zadig/v2/pkg/config"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
@@ -161,73 +162,39 @@ func WorfklowTaskSender() {
 		}
 		var t *commonmodels.WorkflowQueue
 		for _, task := range waitingTasks {
-			var concurrency int
-			workflow, err := commonrepo.NewWorkflowV4Coll().Find(task.WorkflowName)
+			concurrency, err := getTaskConcurrency(task)
 			if err != nil {
-				log.Errorf("WorkflowV4 Queue: find workflow %s error: %v", task.WorkflowName, err)
-				switch task.Type {
-				case config.WorkflowTaskTypeScanning:
-					segs := strings.Split(task.WorkflowName, "-")
-					if len(segs) != 3 {
-						log.Errorf("invalid scanning workflow name: %s", task.WorkflowName)
-						Remove(task)
-						continue
-					}
-					scanningInfo, err := commonrepo.NewScanningColl().GetByID(segs[2])
-					if err != nil {
-						log.Errorf("failed to find scanning of id: %s, error: %s", segs[2], err)
-						Remove(task)
-						continue
-					}
-					concurrencyNum := -1
-					if scanningInfo.AdvancedSetting != nil {
-						concurrencyNum = scanningInfo.AdvancedSetting.ConcurrencyLimit
-					}
-					if concurrencyNum == 0 {
-						concurrencyNum = -1
-					}
-					concurrency = concurrencyNum
-				case config.WorkflowTaskTypeTesting:
-					testingInfo, err := commonrepo.NewTestingColl().Find(task.WorkflowDisplayName, task.ProjectName)
-					if err != nil {
-						log.Errorf("failed to find test of name: %s in project: %s, error: %s", task.WorkflowDisplayName, task.ProjectName, err)
-						Remove(task)
-						continue
-					}
-					concurrencyNum := -1
-					if testingInfo.PreTest != nil {
-						concurrencyNum = testingInfo.PreTest.ConcurrencyLimit
-					}
-					if concurrencyNum == 0 {
-						concurrencyNum = -1
-					}
-					concurrency = concurrencyNum
-				case config.WorkflowTaskTypeDelivery:
-					concurrency = -1
-				default:
-					log.Errorf("unsupported task type: %s, removing from queue", task.Type)
-					Remove(task)
-					continue
-				}
-			} else {
-				concurrency = workflow.ConcurrencyLimit
+				log.Errorf("WorkflowV4 Queue: %s, removing from queue", err)
+				Remove(task)
+				continue
+			}
+			evicting, err := isShareStorageCacheEvicting(task.WorkflowName)
+			if err != nil {
+				log.Errorf("WorkflowV4 Queue: check share storage cache of workflow %s error: %v", task.WorkflowName, err)
+				continue
+			}
+			if evicting {
+				continue
+			}
+			running, limit, err := getProjectTaskQuota(task.ProjectName)
+			if err != nil {
+				log.Errorf("WorkflowV4 Queue: get task quota of project %s error: %v", task.ProjectName, err)
+				continue
+			}
+			if limit > 0 && running >= limit {
+				continue
 			}
 			// no concurrency limit, run task
 			if concurrency == -1 {
 				t = task
 				break
 			}
-			resp, err := RunningWorkflowTasks(task.WorkflowName)
+			running, err = countRunningWorkflowTasks(task.WorkflowName)
 			if err != nil {
 				log.Errorf("WorkflowV4 Queue: find running workflow %s error: %v", task.WorkflowName, err)
 				continue
 			}
-			resp2, err := WaitForApproveWorkflowTasks(task.WorkflowName)
-			if err != nil {
-				log.Errorf("WorkflowV4 Queue: find waiting approve workflow %s error: %v", task.WorkflowName, err)
-				continue
-			}
-			if len(resp)+len(resp2) < concurrency {
+			if running < concurrency {
 				t = task
 				break
 			}
@@ -246,6 +213,202 @@ func WorfklowTaskSender() {
 	}
 }
 
+// getTaskConcurrency returns the concurrency limit of the workflow, the testing or the scanning of the task, -1 means
+// unlimited. An error is returned if the task should be removed from the queue.
+func getTaskConcurrency(task *commonmodels.WorkflowQueue) (int, error) {
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(task.WorkflowName)
+	if err == nil {
+		// the cache kept across the tasks is used by one task at a time
+		if hasWorkflowScopedShareStorage(workflow.ShareStorages) {
+			return 1, nil
+		}
+		return workflow.ConcurrencyLimit, nil
+	}
+
+	log.Errorf("WorkflowV4 Queue: find workflow %s error: %v", task.WorkflowName, err)
+	switch task.Type {
+	case config.WorkflowTaskTypeScanning:
+		segs := strings.Split(task.WorkflowName, "-")
+		if len(segs) != 3 {
+			return 0, fmt.Errorf("invalid scanning workflow name: %s", task.WorkflowName)
+		}
+		scanningInfo, err := commonrepo.NewScanningColl().GetByID(segs[2])
+		if err != nil {
+			return 0, fmt.Errorf("failed to find scanning of id: %s, error: %s", segs[2], err)
+		}
+		concurrencyNum := -1
+		if scanningInfo.AdvancedSetting != nil {
+			concurrencyNum = scanningInfo.AdvancedSetting.ConcurrencyLimit
+		}
+		if concurrencyNum == 0 {
+			concurrencyNum = -1
+		}
+		return concurrencyNum, nil
+	case config.WorkflowTaskTypeTesting:
+		testingInfo, err := commonrepo.NewTestingColl().Find(task.WorkflowDisplayName, task.ProjectName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to find test of name: %s in project: %s, error: %s", task.WorkflowDisplayName, task.ProjectName, err)
+		}
+		concurrencyNum := -1
+		if testingInfo.PreTest != nil {
+			concurrencyNum = testingInfo.PreTest.ConcurrencyLimit
+		}
+		if concurrencyNum == 0 {
+			concurrencyNum = -1
+		}
+		return concurrencyNum, nil
+	case config.WorkflowTaskTypeDelivery:
+		return -1, nil
+	default:
+		return 0, fmt.Errorf("unsupported task type: %s", task.Type)
+	}
+}
+
+func hasWorkflowScopedShareStorage(storages []*commonmodels.ShareStorage) bool {
+	for _, storage := range storages {
+		if storage.IsWorkflowScoped() {
+			return true
+		}
+	}
+	return false
+}
+
+func shareStorageCacheEvictingKey(workflowName string) string {
+	return fmt.Sprintf("workflow-share-storage-evicting:%s", workflowName)
+}
+
+// isShareStorageCacheEvicting checks if a clean job is evicting the workflow scoped share storages of the workflow,
+// the tasks of the workflow are not started until it's done
+func isShareStorageCacheEvicting(workflowName string) (bool, error) {
+	return cache.NewRedisCache(config2.RedisCommonCacheTokenDB()).Exists(shareStorageCacheEvictingKey(workflowName))
+}
+
+// claimShareStorageCacheEviction marks the workflow scoped share storages of the workflow as being evicted if no other
+// task of the workflow has been started, it's done under the lock of the task sender so that no task is started
+// in the meantime. The returned function removes the mark.
+func claimShareStorageCacheEviction(workflowName string, taskID int64) (func(), bool) {
+	mutex := cache.NewRedisLock("workflow-task-sender")
+	if err := mutex.Lock(); err != nil {
+		log.Errorf("failed to lock the task sender to evict share storage cache of workflow %s, err: %v", workflowName, err)
+		return nil, false
+	}
+	defer mutex.Unlock()
+
+	tasks, err := commonrepo.NewWorkflowQueueColl().List(&commonrepo.ListWorfklowQueueOption{WorkflowName: workflowName})
+	if err != nil {
+		log.Errorf("failed to list queued tasks of workflow %s, err: %v", workflowName, err)
+		return nil, false
+	}
+	for _, task := range tasks {
+		if task.TaskID == taskID {
+			continue
+		}
+		switch task.Status {
+		case config.StatusCreated, config.StatusWaiting, config.StatusBlocked, config.QueueItemPending:
+			// not started yet
+		default:
+			return nil, false
+		}
+	}
+
+	redisCache := cache.NewRedisCache(config2.RedisCommonCacheTokenDB())
+	key := shareStorageCacheEvictingKey(workflowName)
+	// the mark expires after the deadline of the clean job in case aslan exits during the eviction
+	if err := redisCache.Write(key, fmt.Sprintf("%d", taskID), time.Hour); err != nil {
+		log.Errorf("failed to mark share storage cache of workflow %s as evicting, err: %v", workflowName, err)
+		return nil, false
+	}
+	return func() {
+		if err := redisCache.Delete(key); err != nil {
+			log.Errorf("failed to remove the evicting mark of share storage cache of workflow %s, err: %v", workflowName, err)
+		}
+	}, true
+}
+
+// countRunningWorkflowTasks counts the running and the waiting for approval tasks of the workflow
+func countRunningWorkflowTasks(workflowName string) (int, error) {
+	resp, err := RunningWorkflowTasks(workflowName)
+	if err != nil {
+		return 0, err
+	}
+	resp2, err := WaitForApproveWorkflowTasks(workflowName)
+	if err != nil {
+		return 0, err
+	}
+	return len(resp) + len(resp2), nil
+}
+
+// getProjectTaskQuota returns the number of the scheduled tasks of the project and its concurrent workflow task quota,
+// 0 limit means unlimited. The quota is checked on task creation, it's checked again before scheduling in case the
+// quota is lowered afterwards.
+func getProjectTaskQuota(projectName string) (running, limit int, err error) {
+	quota, err := commonrepo.NewProjectQuotaColl().Find(projectName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+	if quota.MaxConcurrentWorkflowTasks <= 0 {
+		return 0, 0, nil
+	}
+	for _, t := range ListTasks() {
+		if t.ProjectName != projectName {
+			continue
+		}
+		if t.Status == config.StatusRunning || t.Status == config.StatusQueued || t.Status == config.StatusWaitingApprove {
+			running++
+		}
+	}
+	return running, quota.MaxConcurrentWorkflowTasks, nil
+}
+
+const (
+	QueueBlockingReasonConcurrencyGroup = "concurrency_group"
+	QueueBlockingReasonQuota            = "quota"
+	QueueBlockingReasonClusterCapacity  = "cluster_capacity"
+	// QueueBlockingReasonQueued means the task is waiting for the tasks ahead of it to be scheduled
+	QueueBlockingReasonQueued = "queued"
+)
+
+type QueueBlockingReason struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// GetWaitingTaskBlockingReasons explains why the waiting task is not scheduled yet, the checks are the same as the
+// ones of the task sender
+func GetWaitingTaskBlockingReasons(task *commonmodels.WorkflowQueue, workflowConcurrency int) []*QueueBlockingReason {
+	reasons := make([]*QueueBlockingReason, 0)
+	if scheduled := len(RunningAndQueuedTasks()); scheduled >= workflowConcurrency {
+		reasons = append(reasons, &QueueBlockingReason{
+			Reason:  QueueBlockingReasonClusterCapacity,
+			Message: fmt.Sprintf("%d tasks are running, the system workflow concurrency is %d", scheduled, workflowConcurrency),
+		})
+	}
+	if running, limit, err := getProjectTaskQuota(task.ProjectName); err == nil && limit > 0 && running >= limit {
+		reasons = append(reasons, &QueueBlockingReason{
+			Reason:  QueueBlockingReasonQuota,
+			Message: fmt.Sprintf("%d tasks of project %s are running, the quota is %d", running, task.ProjectName, limit),
+		})
+	}
+	if concurrency, err := getTaskConcurrency(task); err == nil && concurrency != -1 {
+		if running, err := countRunningWorkflowTasks(task.WorkflowName); err == nil && running >= concurrency {
+			reasons = append(reasons, &QueueBlockingReason{
+				Reason:  QueueBlockingReasonConcurrencyGroup,
+				Message: fmt.Sprintf("%d tasks of %s are running, the concurrency limit is %d", running, task.WorkflowName, concurrency),
+			})
+		}
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, &QueueBlockingReason{
+			Reason:  QueueBlockingReasonQueued,
+			Message: "waiting for the tasks ahead to be scheduled",
+		})
+	}
+	return reasons
+}
+
 func hasAgentAvaiable(workflowConcurrency int) bool {
 	return len(RunningAndQueuedTasks()) < int(workflowConcurrency)
 }
@@ -18,7 +18,7 @@ package workflowcontroller
 
 import (
 	"context"
-	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -48,6 +48,13 @@ func runStage(ctx context.Context, stage *commonmodels.StageTask, workflowCtx *c
 		ack()
 		return
 	}
+	if err := expandDynamicStage(stage, workflowCtx, logger); err != nil {
+		stage.Status = config.StatusFailed
+		stage.Error = err.Error()
+		logger.Errorf("finish stage: %s,status: %s error: %s", stage.Name, stage.Status, stage.Error)
+		ack()
+		return
+	}
 
 	defer func() {
 		updateStageStatus(ctx, stage)
@@ -65,6 +72,10 @@ func runStage(ctx context.Context, stage *commonmodels.StageTask, workflowCtx *c
 
 func RunStages(ctx context.Context, stages []*commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, concurrency int, logger *zap.SugaredLogger, ack func()) {
 	for _, stage := range stages {
+		// failure handler stages are run by RunFailureStages
+		if stage.OnFailure {
+			continue
+		}
 		// should skip passed stage when workflow task be restarted
 		if stage.Status == config.StatusPassed {
 			continue
@@ -76,9 +87,72 @@ func RunStages(ctx context.Context, stages []*commonmodels.StageTask, workflowCt
 	}
 }
 
-func ApproveStage(workflowName, jobName, userName, userID, comment string, taskID int64, approve bool) error {
-	approveKey := fmt.Sprintf("%s-%s-%d", workflowName, jobName, taskID)
-	_, err := approvalservice.GlobalApproveMap.DoApproval(approveKey, userName, userID, comment, approve)
+// RunFailureStages runs the failure handler stages when the task has failed or been cancelled, otherwise they are skipped.
+// The failure context can be used by the jobs as {{.workflow.task.status}}, {{.workflow.task.failed_jobs}} and {{.workflow.task.error}}.
+// The handlers are not bound to the ctx of the task, so that they still run after the task is cancelled.
+func RunFailureStages(stages []*commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, concurrency int, logger *zap.SugaredLogger, ack func()) {
+	var failedStatus config.Status
+	for _, stage := range stages {
+		if stage.OnFailure {
+			continue
+		}
+		// the task will continue after the paused stage is resumed
+		if stage.Status == config.StatusPause {
+			return
+		}
+		if statusStopped(stage.Status) {
+			failedStatus = stage.Status
+			break
+		}
+	}
+
+	if failedStatus == "" {
+		for _, stage := range stages {
+			if stage.OnFailure && stage.Status == "" {
+				stage.Status = config.StatusSkipped
+			}
+		}
+		ack()
+		return
+	}
+
+	failedJobs := []string{}
+	errMsgs := []string{}
+	for _, stage := range stages {
+		if stage.OnFailure {
+			continue
+		}
+		if stage.Error != "" {
+			errMsgs = append(errMsgs, stage.Error)
+		}
+		for _, job := range stage.Jobs {
+			if statusStopped(job.Status) {
+				failedJobs = append(failedJobs, job.Name)
+				if job.Error != "" {
+					errMsgs = append(errMsgs, job.Error)
+				}
+			}
+		}
+	}
+	workflowCtx.GlobalContextSet("{{.workflow.task.status}}", string(failedStatus))
+	workflowCtx.GlobalContextSet("{{.workflow.task.failed_jobs}}", strings.Join(failedJobs, ","))
+	workflowCtx.GlobalContextSet("{{.workflow.task.error}}", strings.Join(errMsgs, "\n"))
+
+	for _, stage := range stages {
+		if !stage.OnFailure || stage.Status == config.StatusPassed {
+			continue
+		}
+		logger.Infof("run failure handler stage: %s, task status: %s", stage.Name, failedStatus)
+		runStage(context.Background(), stage, workflowCtx, concurrency, logger, ack)
+		if statusStopped(stage.Status) {
+			return
+		}
+	}
+}
+
+func ApproveStage(workflowName, jobName, userName, userID, comment string, taskID int64, approve bool, channel config.ApprovalChannel) error {
+	approveKey := approvalservice.GenWorkflowApproveKey(workflowName, jobName, taskID)
+	_, err := approvalservice.GlobalApproveMap.DoApproval(approveKey, userName, userID, comment, approve, channel)
 	return err
 }
 
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflowcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+const (
+	DynamicStageItemPlaceholder = "{{.stage.item}}"
+	maxDynamicStageItems        = 100
+)
+
+var dynamicItemKeyReg = regexp.MustCompile("[^a-zA-Z0-9_-]+")
+
+// expandDynamicStage expands stage jobs by the json list resolved from the dynamic source:
+// jobs containing {{.stage.item}} are cloned for every item, service jobs whose service is not in the list are skipped.
+// an item matches a service job by "service" or "service/module".
+func expandDynamicStage(stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) error {
+	if stage.Dynamic == nil || !stage.Dynamic.Enabled || stage.Dynamic.Expanded {
+		return nil
+	}
+	value, ok := workflowCtx.GlobalContextGet(stage.Dynamic.Source)
+	if !ok {
+		return fmt.Errorf("dynamic stage source %s not found", stage.Dynamic.Source)
+	}
+	items, err := parseDynamicStageItems(value)
+	if err != nil {
+		return fmt.Errorf("failed to parse dynamic stage source %s: %s", stage.Dynamic.Source, err)
+	}
+	logger.Infof("expand dynamic stage %s with items: %v", stage.Name, items)
+
+	itemSet := sets.NewString(items...)
+	jobs := make([]*commonmodels.JobTask, 0, len(stage.Jobs))
+	for _, job := range stage.Jobs {
+		b, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job %s: %s", job.Name, err)
+		}
+		if strings.Contains(string(b), DynamicStageItemPlaceholder) {
+			for i, item := range items {
+				escaped, err := util.JsonEscapeString(item)
+				if err != nil {
+					escaped = item
+				}
+				cloned := &commonmodels.JobTask{}
+				if err := json.Unmarshal([]byte(strings.ReplaceAll(string(b), DynamicStageItemPlaceholder, escaped)), cloned); err != nil {
+					return fmt.Errorf("failed to clone job %s for item %s: %s", job.Name, item, err)
+				}
+				cloned.Name = fmt.Sprintf("%s-%d", job.Name, i)
+				cloned.Key = strings.Join([]string{job.Key, dynamicItemKeyReg.ReplaceAllString(item, "-")}, ".")
+				cloned.DisplayName = fmt.Sprintf("%s-%s", job.DisplayName, item)
+				jobs = append(jobs, cloned)
+			}
+			continue
+		}
+
+		jobInfo := &commonmodels.TaskJobInfo{}
+		if err := commonmodels.IToi(job.JobInfo, jobInfo); err == nil && jobInfo.ServiceName != "" {
+			if !itemSet.Has(jobInfo.ServiceName) && !itemSet.Has(jobInfo.ServiceName+"/"+jobInfo.ServiceModule) {
+				job.Status = config.StatusSkipped
+			}
+		}
+		jobs = append(jobs, job)
+	}
+
+	stage.Jobs = jobs
+	stage.Dynamic.Expanded = true
+	return nil
+}
+
+// parseDynamicStageItems accepts a json list, string items are used as is, other items are used as json strings
+func parseDynamicStageItems(value string) ([]string, error) {
+	list := make([]interface{}, 0)
+	if err := json.Unmarshal([]byte(strings.TrimSpace(value)), &list); err != nil {
+		return nil, err
+	}
+	if len(list) > maxDynamicStageItems {
+		return nil, fmt.Errorf("too many items: %d, the limit is %d", len(list), maxDynamicStageItems)
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			items = append(items, str)
+			continue
+		}
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, string(b))
+	}
+	return items, nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+)
+
+type dynamicJobResult struct {
+	Name        string
+	Key         string
+	DisplayName string
+	Status      config.Status
+	Item        string
+}
+
+func TestExpandDynamicStage(t *testing.T) {
+	itemJob := func() *commonmodels.JobTask {
+		return &commonmodels.JobTask{
+			Name:        "deploy",
+			Key:         "deploy",
+			DisplayName: "deploy",
+			Spec:        map[string]interface{}{"item": DynamicStageItemPlaceholder},
+		}
+	}
+	serviceJob := func(name, service, module string) *commonmodels.JobTask {
+		return &commonmodels.JobTask{
+			Name:        name,
+			Key:         name,
+			DisplayName: name,
+			JobInfo:     map[string]interface{}{"service_name": service, "service_module": module},
+		}
+	}
+	dynamic := func() *commonmodels.DynamicStage {
+		return &commonmodels.DynamicStage{Enabled: true, Source: "{{.job.detect.output.SERVICES}}"}
+	}
+
+	tests := []struct {
+		name    string
+		stage   *commonmodels.StageTask
+		context map[string]string
+		want    []dynamicJobResult
+		wantErr bool
+	}{
+		{
+			name:  "not dynamic stage",
+			stage: &commonmodels.StageTask{Jobs: []*commonmodels.JobTask{itemJob()}},
+			want:  []dynamicJobResult{{Name: "deploy", Key: "deploy", DisplayName: "deploy", Item: DynamicStageItemPlaceholder}},
+		},
+		{
+			name: "expanded stage is not expanded again",
+			stage: &commonmodels.StageTask{
+				Dynamic: &commonmodels.DynamicStage{Enabled: true, Source: "{{.job.detect.output.SERVICES}}", Expanded: true},
+				Jobs:    []*commonmodels.JobTask{itemJob()},
+			},
+			want: []dynamicJobResult{{Name: "deploy", Key: "deploy", DisplayName: "deploy", Item: DynamicStageItemPlaceholder}},
+		},
+		{
+			name:    "source not found",
+			stage:   &commonmodels.StageTask{Dynamic: dynamic(), Jobs: []*commonmodels.JobTask{itemJob()}},
+			context: map[string]string{},
+			wantErr: true,
+		},
+		{
+			name:    "source is not a json list",
+			stage:   &commonmodels.StageTask{Dynamic: dynamic(), Jobs: []*commonmodels.JobTask{itemJob()}},
+			context: map[string]string{"{{.job.detect.output.SERVICES}}": "svc-a,svc-b"},
+			wantErr: true,
+		},
+		{
+			name:    "jobs using the item are cloned for every item",
+			stage:   &commonmodels.StageTask{Dynamic: dynamic(), Jobs: []*commonmodels.JobTask{itemJob()}},
+			context: map[string]string{"{{.job.detect.output.SERVICES}}": `["svc-a", "svc/b"]`},
+			want: []dynamicJobResult{
+				{Name: "deploy-0", Key: "deploy.svc-a", DisplayName: "deploy-svc-a", Item: "svc-a"},
+				{Name: "deploy-1", Key: "deploy.svc-b", DisplayName: "deploy-svc/b", Item: "svc/b"},
+			},
+		},
+		{
+			name:    "non string items are used as json strings",
+			stage:   &commonmodels.StageTask{Dynamic: dynamic(), Jobs: []*commonmodels.JobTask{itemJob()}},
+			context: map[string]string{"{{.job.detect.output.SERVICES}}": `[1, {"name":"svc-a"}]`},
+			want: []dynamicJobResult{
+				{Name: "deploy-0", Key: "deploy.1", DisplayName: "deploy-1", Item: "1"},
+				{Name: "deploy-1", Key: "deploy.-name-svc-a-", DisplayName: `deploy-{"name":"svc-a"}`, Item: `{"name":"svc-a"}`},
+			},
+		},
+		{
+			name:    "empty list clones no jobs",
+			stage:   &commonmodels.StageTask{Dynamic: dynamic(), Jobs: []*commonmodels.JobTask{itemJob()}},
+			context: map[string]string{"{{.job.detect.output.SERVICES}}": `[]`},
+			want:    []dynamicJobResult{},
+		},
+		{
+			name: "service jobs not in the list are skipped",
+			stage: &commonmodels.StageTask{Dynamic: dynamic(), Jobs: []*commonmodels.JobTask{
+				serviceJob("build-a", "svc-a", "module-a"),
+				serviceJob("build-b", "svc-b", "module-b"),
+				serviceJob("build-c", "svc-c", "module-c"),
+				{Name: "notify", Key: "notify", DisplayName: "notify"},
+			}},
+			context: map[string]string{"{{.job.detect.output.SERVICES}}": `["svc-a", "svc-b/module-b", "svc-c/module-x"]`},
+			want: []dynamicJobResult{
+				{Name: "build-a", Key: "build-a", DisplayName: "build-a"},
+				{Name: "build-b", Key: "build-b", DisplayName: "build-b"},
+				{Name: "build-c", Key: "build-c", DisplayName: "build-c", Status: config.StatusSkipped},
+				{Name: "notify", Key: "notify", DisplayName: "notify"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflowCtx := &commonmodels.WorkflowTaskCtx{
+				GlobalContextGet: func(key string) (string, bool) {
+					value, ok := tt.context[key]
+					return value, ok
+				},
+			}
+			err := expandDynamicStage(tt.stage, workflowCtx, zap.NewNop().Sugar())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandDynamicStage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make([]dynamicJobResult, 0, len(tt.stage.Jobs))
+			for _, job := range tt.stage.Jobs {
+				result := dynamicJobResult{Name: job.Name, Key: job.Key, DisplayName: job.DisplayName, Status: job.Status}
+				if spec, ok := job.Spec.(map[string]interface{}); ok {
+					result.Item = fmt.Sprint(spec["item"])
+				}
+				got = append(got, result)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandDynamicStage() jobs = %+v, want %+v", got, tt.want)
+			}
+			if tt.stage.Dynamic != nil && tt.stage.Dynamic.Enabled && !tt.stage.Dynamic.Expanded {
+				t.Errorf("expandDynamicStage() stage is not marked as expanded")
+			}
+		})
+	}
+}
+
+func TestParseDynamicStageItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "string items",
+			value: ` ["svc-a", "svc-b/module-b"] `,
+			want:  []string{"svc-a", "svc-b/module-b"},
+		},
+		{
+			name:  "non string items",
+			value: `[1, true, {"name":"svc-a"}, ["x"]]`,
+			want:  []string{"1", "true", `{"name":"svc-a"}`, `["x"]`},
+		},
+		{
+			name:    "not a list",
+			value:   `{"name":"svc-a"}`,
+			wantErr: true,
+		},
+		{
+			name:    "too many items",
+			value:   "[" + strings.TrimSuffix(strings.Repeat("1,", maxDynamicStageItems+1), ",") + "]",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDynamicStageItems(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDynamicStageItems() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDynamicStageItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stepcontroller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/coverage"
+	s3tool "github.com/koderover/zadig/v2/pkg/tool/s3"
+	"github.com/koderover/zadig/v2/pkg/types/step"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+type coverageReportCtl struct {
+	step               *commonmodels.StepTask
+	coverageReportSpec *step.StepCoverageReportSpec
+	log                *zap.SugaredLogger
+}
+
+func NewCoverageReportCtl(stepTask *commonmodels.StepTask, log *zap.SugaredLogger) (*coverageReportCtl, error) {
+	yamlString, err := yaml.Marshal(stepTask.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("marshal coverage report spec error: %v", err)
+	}
+	coverageReportSpec := &step.StepCoverageReportSpec{}
+	if err := yaml.Unmarshal(yamlString, &coverageReportSpec); err != nil {
+		return nil, fmt.Errorf("unmarshal coverage report spec error: %v", err)
+	}
+	stepTask.Spec = coverageReportSpec
+	return &coverageReportCtl{coverageReportSpec: coverageReportSpec, log: log, step: stepTask}, nil
+}
+
+// PreRun fills the baseline coverage of the target branch, so the job executor can check the regression
+func (s *coverageReportCtl) PreRun(ctx context.Context) error {
+	if s.coverageReportSpec.S3Storage == nil {
+		modelS3, err := commonrepo.NewS3StorageColl().FindDefault()
+		if err != nil {
+			return err
+		}
+		s.coverageReportSpec.S3Storage = modelS3toS3(modelS3)
+	}
+
+	opt := &commonrepo.CoverageRecordListOption{
+		ProjectName:   s.coverageReportSpec.ProjectName,
+		ServiceName:   s.coverageReportSpec.ServiceName,
+		ServiceModule: s.coverageReportSpec.ServiceModule,
+		TestName:      s.coverageReportSpec.TestName,
+		ExcludePR:     true,
+		Limit:         1,
+	}
+	if len(s.coverageReportSpec.Repos) > 0 {
+		opt.Branch = s.coverageReportSpec.Repos[0].Branch
+	}
+	records, err := commonrepo.NewCoverageRecordColl().List(opt)
+	if err != nil {
+		s.log.Warnf("find baseline coverage error: %v", err)
+	} else if len(records) > 0 {
+		s.coverageReportSpec.BaselineLineRate = records[0].LineRate
+		s.coverageReportSpec.HasBaseline = true
+	}
+	s.step.Spec = s.coverageReportSpec
+	return nil
+}
+
+// AfterRun records the coverage uploaded by the job executor
+func (s *coverageReportCtl) AfterRun(ctx context.Context) error {
+	spec := s.coverageReportSpec
+	if spec.S3Storage == nil || spec.S3DestDir == "" || spec.FileName == "" {
+		return nil
+	}
+	client, err := s3tool.NewClient(spec.S3Storage.Endpoint, spec.S3Storage.Ak, spec.S3Storage.Sk, spec.S3Storage.Region, spec.S3Storage.Insecure, spec.S3Storage.Provider)
+	if err != nil {
+		s.log.Errorf("create s3 client error: %v", err)
+		return err
+	}
+	filename, err := util.GenerateTmpFile()
+	if err != nil {
+		s.log.Errorf("GenerateTmpFile err:%v", err)
+		return err
+	}
+	defer os.Remove(filename)
+
+	objectKey := filepath.Join(spec.S3Storage.Subfolder, spec.S3DestDir, spec.FileName)
+	if err := client.Download(spec.S3Storage.Bucket, objectKey, filename); err != nil {
+		s.log.Errorf("download coverage report %s error: %v", objectKey, err)
+		return err
+	}
+	summary, err := coverage.ParseFile(filename, coverage.Format(spec.Format))
+	if err != nil {
+		s.log.Errorf("parse coverage report error: %v", err)
+		return err
+	}
+
+	record := &commonmodels.CoverageRecord{
+		ProjectName:      spec.ProjectName,
+		WorkflowName:     spec.SourceWorkflow,
+		JobName:          spec.SourceJobKey,
+		JobTaskName:      spec.JobTaskName,
+		TaskID:           spec.TaskID,
+		ServiceName:      spec.ServiceName,
+		ServiceModule:    spec.ServiceModule,
+		TestName:         spec.TestName,
+		Format:           spec.Format,
+		LinesCovered:     summary.LinesCovered,
+		LinesValid:       summary.LinesValid,
+		BranchesCovered:  summary.BranchesCovered,
+		BranchesValid:    summary.BranchesValid,
+		LineRate:         summary.LineRate,
+		BranchRate:       summary.BranchRate,
+		BaselineLineRate: spec.BaselineLineRate,
+		HasBaseline:      spec.HasBaseline,
+	}
+	if len(spec.Repos) > 0 {
+		repo := spec.Repos[0]
+		record.RepoName = repo.RepoName
+		record.Branch = repo.Branch
+		record.CommitID = repo.CommitID
+		record.PR = repo.PR
+	}
+	if err := commonrepo.NewCoverageRecordColl().Create(record); err != nil {
+		s.log.Errorf("save coverage record error: %v", err)
+		return err
+	}
+	return nil
+}
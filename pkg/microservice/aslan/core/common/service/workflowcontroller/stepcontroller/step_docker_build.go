@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,6 +33,8 @@ import (
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/registry"
 	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/metrics"
+	"github.com/koderover/zadig/v2/pkg/types/job"
 	"github.com/koderover/zadig/v2/pkg/types/step"
 )
 
@@ -75,6 +78,10 @@ func (s *dockerBuildCtl) PreRun(ctx context.Context) error {
 }
 
 func (s *dockerBuildCtl) AfterRun(ctx context.Context) error {
+	if s.dockerBuildSpec.RemoteCache != nil {
+		s.collectBuildCacheStats()
+	}
+
 	deliveryArtifact := new(commonmodels.DeliveryArtifact)
 	deliveryArtifact.CreatedBy = s.workflowCtx.WorkflowTaskCreatorUsername
 	deliveryArtifact.CreatedTime = time.Now().Unix()
@@ -139,6 +146,24 @@ func (s *dockerBuildCtl) AfterRun(ctx context.Context) error {
 	return nil
 }
 
+func (s *dockerBuildCtl) collectBuildCacheStats() {
+	stats := map[string]int{}
+	for _, key := range []string{setting.BuildJobOutputKeyCacheHits, setting.BuildJobOutputKeyCacheSteps} {
+		value, ok := s.workflowCtx.GlobalContextGet(job.GetJobOutputKey(s.step.JobKey, key))
+		if !ok {
+			s.log.Warnf("build cache output %s of job %s not found", key, s.step.JobName)
+			return
+		}
+		stats[key], _ = strconv.Atoi(value)
+	}
+
+	cache := s.dockerBuildSpec.RemoteCache
+	cache.Hits = stats[setting.BuildJobOutputKeyCacheHits]
+	cache.Steps = stats[setting.BuildJobOutputKeyCacheSteps]
+	s.step.Spec = s.dockerBuildSpec
+	metrics.RegisterBuildCache(s.workflowCtx.ProjectName, cache.Type, cache.Hits, cache.Steps)
+}
+
 func getImageInfo(registryID, imageName, tag string, log *zap.SugaredLogger) (*commonmodels.DeliveryImage, error) {
 	registryInfo, err := mongodb.NewRegistryNamespaceColl().Find(&mongodb.FindRegOps{ID: registryID})
 	if err != nil {
@@ -128,6 +128,10 @@ func (s *junitReportCtl) AfterRun(ctx context.Context) error {
 		_ = commonrepo.NewTestTaskStatColl().Update(testTaskStat)
 	}
 
+	reportType := s.junitReportSpec.ReportType
+	if reportType == "" {
+		reportType = step.TestReportTypeJunit
+	}
 	duration := 0.0
 	for _, cases := range testReport.TestCases {
 		duration += cases.Time
@@ -150,6 +154,8 @@ func (s *junitReportCtl) AfterRun(ctx context.Context) error {
 		ErrorCaseNum:     testReport.Errors,
 		TestTime:         math.Round(duration*1000) / 1000,
 		TestCases:        testReport.TestCases,
+		ReportType:       reportType,
+		CreateTime:       time.Now().Unix(),
 	})
 
 	if err != nil {
@@ -76,6 +76,9 @@ type workflowCtl struct {
 	logger            *zap.SugaredLogger
 	prefix            string
 	ack               func()
+	// reportedStageStatus records the stage status which has been reported to the commit
+	reportedStageStatus map[string]config.Status
+	reportMutex         sync.Mutex
 }
 
 func NewWorkflowController(workflowTask *commonmodels.WorkflowTask, logger *zap.SugaredLogger) *workflowCtl {
@@ -83,6 +86,8 @@ func NewWorkflowController(workflowTask *commonmodels.WorkflowTask, logger *zap.
 		workflowTask: workflowTask,
 		logger:       logger,
 		prefix:       fmt.Sprintf("workflowctl-%s-%d", workflowTask.WorkflowName, workflowTask.TaskID),
+
+		reportedStageStatus: make(map[string]config.Status),
 	}
 	ctl.ack = ctl.updateWorkflowTask
 	return ctl
@@ -245,6 +250,12 @@ func (c *workflowCtl) Run(ctx context.Context, concurrency int) {
 		GlobalContextEach:           c.globalContextEach,
 		ClusterIDAdd:                c.addClusterID,
 		StartTime:                   time.Now(),
+		FailedJobDiagnosis:          c.workflowTask.WorkflowArgs != nil && c.workflowTask.WorkflowArgs.FailedJobDiagnosis,
+		TaskTagAdd:                  c.addTaskTag,
+		WorkflowStatusGet:           c.getWorkflowStatus,
+	}
+	if c.workflowTask.WorkflowArgs != nil {
+		workflowCtx.InfraRetryPolicy = c.workflowTask.WorkflowArgs.InfraRetryPolicy
 	}
 	defer jobcontroller.CleanWorkflowJobs(ctx, c.workflowTask, workflowCtx, c.logger, c.ack)
 	if err := scmnotify.NewService().UpdateWebhookCommentForWorkflowV4(c.workflowTask, c.logger); err != nil {
@@ -253,7 +264,9 @@ func (c *workflowCtl) Run(ctx context.Context, concurrency int) {
 	if err := scmnotify.NewService().UpdateGitCheckForWorkflowV4(c.workflowTask.WorkflowArgs, c.workflowTask.TaskID, c.logger); err != nil {
 		log.Warnf("Failed to update github check status for custom workflow %s, taskID: %d the error is: %s", c.workflowTask.WorkflowName, c.workflowTask.TaskID, err)
 	}
+	c.reportStageCommitStatus()
 	RunStages(ctx, c.workflowTask.Stages, workflowCtx, concurrency, c.logger, c.ack)
+	RunFailureStages(c.workflowTask.Stages, workflowCtx, concurrency, c.logger, c.ack)
 	updateworkflowStatus(c.workflowTask)
 }
 
@@ -562,6 +575,8 @@ func (c *workflowCtl) updateWorkflowTask() {
 	}
 	c.workflowTaskMutex.Unlock()
 
+	c.reportStageCommitStatus()
+
 	if c.workflowTask.Status == config.StatusPassed || c.workflowTask.Status == config.StatusFailed || c.workflowTask.Status == config.StatusTimeout || c.workflowTask.Status == config.StatusCancelled || c.workflowTask.Status == config.StatusReject || c.workflowTask.Status == config.StatusPause {
 		c.logger.Infof("%s:%d:%v task done", c.workflowTask.WorkflowName, c.workflowTask.TaskID, c.workflowTask.Status)
 		if err := instantmessage.NewWeChatClient().SendWorkflowTaskNotifications(c.workflowTask); err != nil {
@@ -584,7 +599,39 @@ func (c *workflowCtl) updateWorkflowTask() {
 	}
 }
 
+// reportStageCommitStatus reports the changed stage status to the commit which triggers the task
+func (c *workflowCtl) reportStageCommitStatus() {
+	if c.workflowTask.WorkflowArgs == nil || c.workflowTask.WorkflowArgs.CommitStatusReport == nil || !c.workflowTask.WorkflowArgs.CommitStatusReport.Enabled {
+		return
+	}
+	c.reportMutex.Lock()
+	defer c.reportMutex.Unlock()
+
+	for _, stage := range c.workflowTask.Stages {
+		if status, ok := c.reportedStageStatus[stage.Name]; ok && status == stage.Status {
+			continue
+		}
+		if err := scmnotify.NewService().UpdateCommitStatusForWorkflowV4Stage(c.workflowTask, stage, c.logger); err != nil {
+			c.logger.Warnf("Failed to update commit status of stage %s for custom workflow %s, taskID: %d the error is: %s", stage.Name, c.workflowTask.WorkflowName, c.workflowTask.TaskID, err)
+			continue
+		}
+		c.reportedStageStatus[stage.Name] = stage.Status
+	}
+}
+
 func (c *workflowCtl) CleanShareStorage() {
+	// the workflow scoped share storages are evicted only if no other task of the workflow is using them
+	evictCache := false
+	if len(c.workflowTask.ClusterIDMap) > 0 && hasWorkflowScopedShareStorage(c.workflowTask.ShareStorages) {
+		var release func()
+		release, evictCache = claimShareStorageCacheEviction(c.workflowTask.WorkflowName, c.workflowTask.TaskID)
+		if evictCache {
+			defer release()
+		} else {
+			c.logger.Infof("share storage cache of workflow %s is in use, skip evicting it", c.workflowTask.WorkflowName)
+		}
+	}
+
 	for clusterID := range c.workflowTask.ClusterIDMap {
 		cleanJobName := fmt.Sprintf("clean-%s", rand.String(8))
 		namespace := setting.AttachedClusterNamespace
@@ -601,7 +648,7 @@ func (c *workflowCtl) CleanShareStorage() {
 			c.logger.Errorf("can't init k8s api reader: %v", err)
 			continue
 		}
-		job, err := jobcontroller.BuildCleanJob(cleanJobName, clusterID, c.workflowTask.WorkflowName, c.workflowTask.TaskID)
+		job, err := jobcontroller.BuildCleanJob(cleanJobName, clusterID, c.workflowTask.WorkflowName, c.workflowTask.TaskID, c.workflowTask.ShareStorages, evictCache)
 		if err != nil {
 			c.logger.Errorf("build clean job error: %v", err)
 			continue
@@ -630,11 +677,28 @@ func (c *workflowCtl) addClusterID(clusterID string) {
 	c.workflowTask.ClusterIDMap[clusterID] = true
 }
 
+func (c *workflowCtl) addTaskTag(tag string) {
+	c.workflowTaskMutex.Lock()
+	defer c.workflowTaskMutex.Unlock()
+	for _, t := range c.workflowTask.Tags {
+		if t == tag {
+			return
+		}
+	}
+	c.workflowTask.Tags = append(c.workflowTask.Tags, tag)
+}
+
 // mongo do not support dot in keys.
 const (
 	split = "@?"
 )
 
+func (c *workflowCtl) getWorkflowStatus() config.Status {
+	c.workflowTaskMutex.RLock()
+	defer c.workflowTaskMutex.RUnlock()
+	return c.workflowTask.Status
+}
+
 func (c *workflowCtl) getGlobalContextAll() map[string]string {
 	c.workflowTaskMutex.RLock()
 	defer c.workflowTaskMutex.RUnlock()
@@ -0,0 +1,213 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	CredentialRuleAWSAccessKey      = "aws_access_key"
+	CredentialRuleAWSSecretKey      = "aws_secret_key"
+	CredentialRuleGithubToken       = "github_token"
+	CredentialRuleGitlabToken       = "gitlab_token"
+	CredentialRuleSlackToken        = "slack_token"
+	CredentialRulePrivateKey        = "private_key"
+	CredentialRuleGenericSecret     = "generic_secret"
+	CredentialRuleSensitiveVariable = "sensitive_variable"
+
+	// values of generic secrets with lower entropy or fewer character classes are most likely placeholders like "changeme"
+	minCredentialEntropy     = 2.5
+	minCredentialCharClasses = 2
+	minCredentialLength      = 8
+)
+
+type credentialRule struct {
+	name  string
+	regex *regexp.Regexp
+	// group is the index of the submatch holding the credential, 0 for the whole match
+	group int
+	// checkEntropy tells if the matched value should be checked by entropy
+	checkEntropy bool
+}
+
+var credentialRules = []*credentialRule{
+	{name: CredentialRuleAWSAccessKey, regex: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: CredentialRuleAWSSecretKey, regex: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?['"\s:=]+([A-Za-z0-9/+=]{40})\b`), group: 1},
+	{name: CredentialRuleGithubToken, regex: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{name: CredentialRuleGitlabToken, regex: regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`)},
+	{name: CredentialRuleSlackToken, regex: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}\b`)},
+	{name: CredentialRulePrivateKey, regex: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: CredentialRuleGenericSecret, regex: regexp.MustCompile(`(?i)\b[a-z0-9_\-]*(?:password|passwd|pwd|secret|token|api[_\-]?key|access[_\-]?key)["']?\s*[:=]\s*["']?([^\s"'$;&|]{8,})`), group: 1, checkEntropy: true},
+}
+
+var sensitiveKeyRegex = regexp.MustCompile(`(?i)(password|passwd|pwd|secret|token|api[_\-]?key|access[_\-]?key|private[_\-]?key)`)
+
+// CredentialFinding is a credential found in the configuration, the value itself is never returned to the users
+type CredentialFinding struct {
+	// ID identifies the value of the credential, the same value found in different places has the same ID
+	ID       string `json:"id"`
+	Rule     string `json:"rule"`
+	Location string `json:"location"`
+	Masked   string `json:"masked"`
+	Value    string `json:"-"`
+}
+
+// ScanCredentials finds the credentials embedded in the text
+func ScanCredentials(location, content string) []*CredentialFinding {
+	resp := make([]*CredentialFinding, 0)
+	// a value matched by multiple rules is reported by the first rule only
+	found := make(map[string]bool)
+	for _, rule := range credentialRules {
+		for _, match := range rule.regex.FindAllStringSubmatch(content, -1) {
+			value := match[rule.group]
+			if found[value] || (rule.checkEntropy && !isLikelyCredential(value)) {
+				continue
+			}
+			found[value] = true
+			resp = append(resp, newCredentialFinding(rule.name, location, value))
+		}
+	}
+	return resp
+}
+
+// ScanCredentialsInObject finds the credentials in all the strings of the object, the values of the objects marked as
+// credential by `is_credential: true` are skipped since they are already protected.
+func ScanCredentialsInObject(obj interface{}) ([]*CredentialFinding, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	resp := make([]*CredentialFinding, 0)
+	walkCredentialObject("", generic, &resp)
+	return resp, nil
+}
+
+func walkCredentialObject(location string, obj interface{}, findings *[]*CredentialFinding) {
+	switch value := obj.(type) {
+	case string:
+		*findings = append(*findings, ScanCredentials(location, value)...)
+	case []interface{}:
+		for i, item := range value {
+			walkCredentialObject(fmt.Sprintf("%s[%d]", location, i), item, findings)
+		}
+	case map[string]interface{}:
+		if isCredential, ok := value["is_credential"].(bool); ok && isCredential {
+			return
+		}
+		// variables like {"key": "DB_PASSWORD", "value": "xxx"}
+		if key, ok := value["key"].(string); ok && sensitiveKeyRegex.MatchString(key) {
+			if v, ok := value["value"].(string); ok && isLikelyCredential(v) {
+				*findings = append(*findings, newCredentialFinding(CredentialRuleSensitiveVariable, joinCredentialLocation(location, "value"), v))
+			}
+		}
+
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// values like {"password": "xxx"}
+			if v, ok := value[key].(string); ok && key != "value" && sensitiveKeyRegex.MatchString(key) && isLikelyCredential(v) {
+				*findings = append(*findings, newCredentialFinding(CredentialRuleSensitiveVariable, joinCredentialLocation(location, key), v))
+				continue
+			}
+			walkCredentialObject(joinCredentialLocation(location, key), value[key], findings)
+		}
+	}
+}
+
+func joinCredentialLocation(location, key string) string {
+	if location == "" {
+		return key
+	}
+	return location + "." + key
+}
+
+func newCredentialFinding(rule, location, value string) *CredentialFinding {
+	sum := sha256.Sum256([]byte(value))
+	return &CredentialFinding{
+		ID:       hex.EncodeToString(sum[:])[:16],
+		Rule:     rule,
+		Location: location,
+		Masked:   MaskCredential(value),
+		Value:    value,
+	}
+}
+
+// MaskCredential keeps the first 4 characters of the credential at most
+func MaskCredential(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", 8)
+}
+
+// isLikelyCredential filters out the references, placeholders and plain words
+func isLikelyCredential(value string) bool {
+	if len(value) < minCredentialLength {
+		return false
+	}
+	if strings.Contains(value, "{{") || strings.HasPrefix(value, "$") || strings.Contains(value, "${") {
+		return false
+	}
+	return shannonEntropy(value) >= minCredentialEntropy && charClasses(value) >= minCredentialCharClasses
+}
+
+func charClasses(value string) int {
+	var lower, upper, digit, other int
+	for _, c := range value {
+		switch {
+		case c >= 'a' && c <= 'z':
+			lower = 1
+		case c >= 'A' && c <= 'Z':
+			upper = 1
+		case c >= '0' && c <= '9':
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
+
+func shannonEntropy(value string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range value {
+		counts[c]++
+	}
+	length := float64(len([]rune(value)))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,278 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonconfig "github.com/koderover/zadig/v2/pkg/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const (
+	EnvLockHolderUser     = "user"
+	EnvLockHolderWorkflow = "workflow"
+	EnvLockHolderSystem   = "system"
+)
+
+// EnvLockHolder describes who is mutating the env, a user updating the env or a workflow task deploying to it
+type EnvLockHolder struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	TaskID     int64  `json:"task_id,omitempty"`
+	AcquiredAt int64  `json:"acquired_at"`
+	// RequestID is set by the steps of a user request which lock the env separately
+	RequestID string `json:"-"`
+}
+
+// owner identifies the holder, the jobs of the same workflow task and the steps of the same request share the lock of the env.
+// The other holders are exclusive even if they are from the same user.
+func (h *EnvLockHolder) owner() string {
+	if h.Type == EnvLockHolderWorkflow {
+		return fmt.Sprintf("%s:%s:%d", h.Type, h.Name, h.TaskID)
+	}
+	if h.RequestID != "" {
+		return fmt.Sprintf("request:%s", h.RequestID)
+	}
+	return ""
+}
+
+type EnvLockStatus struct {
+	Locked  bool             `json:"locked"`
+	Holder  *EnvLockHolder   `json:"holder,omitempty"`
+	Waiting []*EnvLockHolder `json:"waiting"`
+}
+
+type heldEnvLock struct {
+	owner string
+	refs  int
+	lock  *cache.RedisLock
+	stop  chan struct{}
+}
+
+var (
+	heldEnvLocks   = make(map[string]*heldEnvLock)
+	heldEnvLocksMu sync.Mutex
+	// acquiringEnvLocks serializes the acquisitions of the same env in this instance, so that the jobs of
+	// a workflow task can join the lock taken by the first one instead of waiting for it
+	acquiringEnvLocks sync.Map
+)
+
+func envLockKey(projectName, envName string) string {
+	return fmt.Sprintf("env_mutation:%s:%s", projectName, envName)
+}
+
+func envLockHolderKey(projectName, envName string) string {
+	return fmt.Sprintf("env_mutation_holder:%s:%s", projectName, envName)
+}
+
+func envLockWaitingKey(projectName, envName string) string {
+	return fmt.Sprintf("env_mutation_waiting:%s:%s", projectName, envName)
+}
+
+// NewEnvLock returns the distributed lock for the mutations of the env, it is shared by all the aslan replicas
+func NewEnvLock(projectName, envName string) *cache.RedisLock {
+	return cache.NewRedisLockWithTimeout(envLockKey(projectName, envName), config.EnvLockExpiry(), config.EnvLockTimeout())
+}
+
+// LockEnvs acquires the locks of the envs in a fixed order to avoid dead locks, the returned function releases all of them.
+// The locks are kept alive until released, and expire soon after the holder exits unexpectedly.
+func LockEnvs(projectName string, envNames []string, holder *EnvLockHolder) (func(), error) {
+	// List returns the sorted names
+	sortedEnvNames := sets.NewString(envNames...).List()
+
+	releases := make([]func(), 0, len(sortedEnvNames))
+	unlock := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+	for _, envName := range sortedEnvNames {
+		release, err := lockEnv(projectName, envName, holder)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+	return unlock, nil
+}
+
+func lockEnv(projectName, envName string, holder *EnvLockHolder) (func(), error) {
+	key := envLockKey(projectName, envName)
+	acquiring, _ := acquiringEnvLocks.LoadOrStore(key, &sync.Mutex{})
+	acquiring.(*sync.Mutex).Lock()
+	defer acquiring.(*sync.Mutex).Unlock()
+
+	owner := holder.owner()
+	heldEnvLocksMu.Lock()
+	if held, ok := heldEnvLocks[key]; ok && owner != "" && held.owner == owner {
+		held.refs++
+		heldEnvLocksMu.Unlock()
+		return func() { releaseEnvLock(projectName, envName) }, nil
+	}
+	heldEnvLocksMu.Unlock()
+
+	redisCache := cache.NewRedisCache(commonconfig.RedisCommonCacheTokenDB())
+	waiting := &EnvLockHolder{Type: holder.Type, Name: holder.Name, TaskID: holder.TaskID, AcquiredAt: time.Now().Unix()}
+	waitingStr, _ := json.Marshal(waiting)
+	if err := redisCache.AddElementsToSet(envLockWaitingKey(projectName, envName), []string{string(waitingStr)}, config.EnvLockTimeout()*2); err != nil {
+		log.Warnf("failed to record the waiting holder of env %s/%s, err: %s", projectName, envName, err)
+	}
+
+	lock := NewEnvLock(projectName, envName)
+	err := lock.Lock()
+	if err := redisCache.RemoveElementsFromSet(envLockWaitingKey(projectName, envName), []string{string(waitingStr)}); err != nil {
+		log.Warnf("failed to remove the waiting holder of env %s/%s, err: %s", projectName, envName, err)
+	}
+	if err != nil {
+		current, _ := GetEnvLockStatus(projectName, envName)
+		if current != nil && current.Holder != nil {
+			return nil, fmt.Errorf("env %s is being updated by %s %s, please try again later: %w", envName, current.Holder.Type, current.Holder.Name, err)
+		}
+		return nil, fmt.Errorf("env %s is being updated by others, please try again later: %w", envName, err)
+	}
+
+	holderStr, _ := json.Marshal(&EnvLockHolder{Type: holder.Type, Name: holder.Name, TaskID: holder.TaskID, AcquiredAt: time.Now().Unix()})
+	if err := redisCache.Write(envLockHolderKey(projectName, envName), string(holderStr), config.EnvLockExpiry()); err != nil {
+		log.Warnf("failed to record the holder of env %s/%s, err: %s", projectName, envName, err)
+	}
+
+	held := &heldEnvLock{
+		owner: owner,
+		refs:  1,
+		lock:  lock,
+		stop:  make(chan struct{}),
+	}
+	go keepEnvLockAlive(projectName, envName, held, string(holderStr))
+
+	heldEnvLocksMu.Lock()
+	heldEnvLocks[key] = held
+	heldEnvLocksMu.Unlock()
+	return func() { releaseEnvLock(projectName, envName) }, nil
+}
+
+// RetainEnvLock takes another reference of the env lock held by the same owner in this instance, so that a background
+// task started by the holder keeps the env locked after the holder returns. ok is false if the lock is not held by the
+// owner in this instance, the lock of others, e.g. a workflow task deploying to the env, is never shared.
+func RetainEnvLock(projectName, envName string, holder *EnvLockHolder) (release func(), ok bool) {
+	owner := holder.owner()
+	if owner == "" {
+		return nil, false
+	}
+
+	heldEnvLocksMu.Lock()
+	defer heldEnvLocksMu.Unlock()
+
+	held, ok := heldEnvLocks[envLockKey(projectName, envName)]
+	if !ok || held.owner != owner {
+		return nil, false
+	}
+	held.refs++
+	return func() { releaseEnvLock(projectName, envName) }, true
+}
+
+func releaseEnvLock(projectName, envName string) {
+	key := envLockKey(projectName, envName)
+
+	heldEnvLocksMu.Lock()
+	held, ok := heldEnvLocks[key]
+	if !ok {
+		heldEnvLocksMu.Unlock()
+		return
+	}
+	held.refs--
+	if held.refs > 0 {
+		heldEnvLocksMu.Unlock()
+		return
+	}
+	delete(heldEnvLocks, key)
+	heldEnvLocksMu.Unlock()
+
+	close(held.stop)
+	if err := cache.NewRedisCache(commonconfig.RedisCommonCacheTokenDB()).Delete(envLockHolderKey(projectName, envName)); err != nil {
+		log.Warnf("failed to remove the holder of env %s/%s, err: %s", projectName, envName, err)
+	}
+	if err := held.lock.Unlock(); err != nil {
+		log.Warnf("failed to release the lock of env %s/%s, err: %s", projectName, envName, err)
+	}
+}
+
+// keepEnvLockAlive extends the lock periodically, so a long running deployment won't lose it while the lock
+// of a crashed holder still expires in time
+func keepEnvLockAlive(projectName, envName string, held *heldEnvLock, holderStr string) {
+	ticker := time.NewTicker(config.EnvLockExpiry() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-held.stop:
+			return
+		case <-ticker.C:
+			if err := held.lock.Extend(); err != nil {
+				log.Warnf("failed to extend the lock of env %s/%s, err: %s", projectName, envName, err)
+				continue
+			}
+			if err := cache.NewRedisCache(commonconfig.RedisCommonCacheTokenDB()).Write(envLockHolderKey(projectName, envName), holderStr, config.EnvLockExpiry()); err != nil {
+				log.Warnf("failed to refresh the holder of env %s/%s, err: %s", projectName, envName, err)
+			}
+		}
+	}
+}
+
+// GetEnvLockStatus returns who is holding the lock of the env and who are waiting for it
+func GetEnvLockStatus(projectName, envName string) (*EnvLockStatus, error) {
+	redisCache := cache.NewRedisCache(commonconfig.RedisCommonCacheTokenDB())
+	resp := &EnvLockStatus{Waiting: make([]*EnvLockHolder, 0)}
+
+	exists, err := redisCache.Exists(envLockHolderKey(projectName, envName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the holder of env %s, err: %w", envName, err)
+	}
+	if exists {
+		holderStr, err := redisCache.GetString(envLockHolderKey(projectName, envName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the holder of env %s, err: %w", envName, err)
+		}
+		holder := &EnvLockHolder{}
+		if err := json.Unmarshal([]byte(holderStr), holder); err != nil {
+			return nil, fmt.Errorf("failed to decode the holder of env %s, err: %w", envName, err)
+		}
+		resp.Locked = true
+		resp.Holder = holder
+	}
+
+	members, err := redisCache.ListSetMembers(envLockWaitingKey(projectName, envName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the waiting holders of env %s, err: %w", envName, err)
+	}
+	for _, member := range members {
+		waiting := &EnvLockHolder{}
+		if err := json.Unmarshal([]byte(member), waiting); err != nil {
+			continue
+		}
+		resp.Waiting = append(resp.Waiting, waiting)
+	}
+	return resp, nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/cosign"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// VerifyImageSignature verifies the cosign signature of the images with the image signing key,
+// the credential of the registry integrated in zadig is used to pull the signatures.
+func VerifyImageSignature(keyID string, images []string, log *zap.SugaredLogger) error {
+	key, err := mongodb.NewImageSigningKeyColl().GetByID(keyID)
+	if err != nil {
+		return e.ErrVerifyImageSignature.AddErr(fmt.Errorf("failed to find image signing key %s, error: %v", keyID, err))
+	}
+	regs, err := mongodb.NewRegistryNamespaceColl().FindAll(&mongodb.FindRegOps{})
+	if err != nil {
+		return e.ErrVerifyImageSignature.AddErr(err)
+	}
+
+	for _, image := range images {
+		opt := &cosign.VerifyOption{
+			Mode:                  key.Mode,
+			PublicKey:             key.PublicKey,
+			CertificateIdentity:   key.CertificateIdentity,
+			CertificateOIDCIssuer: key.CertificateOIDCIssuer,
+			TlogDisabled:          key.TlogDisabled,
+		}
+		for _, reg := range regs {
+			host := strings.TrimPrefix(strings.TrimPrefix(reg.RegAddr, "https://"), "http://")
+			if strings.HasPrefix(image, host+"/") {
+				if reg, err = DecodeRegistry(reg); err != nil {
+					return e.ErrVerifyImageSignature.AddErr(err)
+				}
+				opt.Registry = &cosign.RegistryAuth{
+					Host:     host,
+					Username: reg.AccessKey,
+					Password: reg.SecretKey,
+				}
+				break
+			}
+		}
+		if err := cosign.Verify(image, opt); err != nil {
+			log.Errorf("failed to verify signature of image %s, error: %v", image, err)
+			return e.ErrVerifyImageSignature.AddDesc(fmt.Sprintf("镜像 %s 未签名或签名无效: %v", image, err))
+		}
+	}
+	return nil
+}
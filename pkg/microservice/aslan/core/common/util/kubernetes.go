@@ -1,8 +1,12 @@
 package util
 
 import (
+	"fmt"
+
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	sigsyaml "sigs.k8s.io/yaml"
 
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/v2/pkg/setting"
@@ -102,3 +106,59 @@ func AddNodeAffinity(clusterConfig *commonmodels.AdvancedConfig, strategyID stri
 		return nil
 	}
 }
+
+// ApplyJobPodTemplate adds the node selector, tolerations, security context and sidecars defined in the
+// pod template to the pod spec of the job, the settings of the job itself are kept if there is a conflict.
+func ApplyJobPodTemplate(podSpec *corev1.PodSpec, podTemplate *commonmodels.JobPodTemplate) error {
+	tolerations := make([]corev1.Toleration, 0)
+	if podTemplate.Tolerations != "" {
+		if err := sigsyaml.Unmarshal([]byte(podTemplate.Tolerations), &tolerations); err != nil {
+			return fmt.Errorf("invalid tolerations: %s", err)
+		}
+	}
+	var securityContext *corev1.PodSecurityContext
+	if podTemplate.SecurityContext != "" {
+		securityContext = &corev1.PodSecurityContext{}
+		if err := sigsyaml.Unmarshal([]byte(podTemplate.SecurityContext), securityContext); err != nil {
+			return fmt.Errorf("invalid security context: %s", err)
+		}
+	}
+	sidecars := make([]corev1.Container, 0)
+	if podTemplate.Sidecars != "" {
+		if err := sigsyaml.Unmarshal([]byte(podTemplate.Sidecars), &sidecars); err != nil {
+			return fmt.Errorf("invalid sidecars: %s", err)
+		}
+	}
+
+	containerNames := sets.NewString()
+	for _, container := range podSpec.InitContainers {
+		containerNames.Insert(container.Name)
+	}
+	for _, container := range podSpec.Containers {
+		containerNames.Insert(container.Name)
+	}
+	for _, sidecar := range sidecars {
+		if sidecar.Name == "" || sidecar.Image == "" {
+			return fmt.Errorf("name and image of the sidecar are required")
+		}
+		if containerNames.Has(sidecar.Name) {
+			return fmt.Errorf("duplicated container name %s", sidecar.Name)
+		}
+		containerNames.Insert(sidecar.Name)
+	}
+
+	for key, value := range podTemplate.NodeSelector {
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = make(map[string]string)
+		}
+		if _, ok := podSpec.NodeSelector[key]; !ok {
+			podSpec.NodeSelector[key] = value
+		}
+	}
+	podSpec.Tolerations = append(podSpec.Tolerations, tolerations...)
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = securityContext
+	}
+	podSpec.Containers = append(podSpec.Containers, sidecars...)
+	return nil
+}
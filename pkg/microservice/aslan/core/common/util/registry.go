@@ -70,6 +70,12 @@ func DecodeRegistry(resp *models.RegistryNamespace) (*models.RegistryNamespace,
 	return resp, nil
 }
 
+// InvalidateAWSRegistryCredential removes the cached token of the registry, it should be called when the
+// access key of the registry is changed
+func InvalidateAWSRegistryCredential(id string) {
+	awsKeyMap.Delete(id)
+}
+
 func GetAWSRegistryCredential(id, ak, sk, region string) (realAK string, realSK string, err error) {
 	// first we try to get ak/sk from our memory cache
 	obj, ok := awsKeyMap.Load(id)
@@ -20,6 +20,11 @@ func GenerateEnvServiceNextRevision(projectName, envName, serviceName string, is
 }
 
 func CreateEnvServiceVersion(env *models.Product, prodSvc *models.ProductService, createBy string, session mongo.Session, log *zap.SugaredLogger) error {
+	return CreateEnvServiceVersionWithDiff(env, prodSvc, createBy, "", session, log)
+}
+
+// CreateEnvServiceVersionWithDiff creates the env service version with the manifests diff of the service revision bump
+func CreateEnvServiceVersionWithDiff(env *models.Product, prodSvc *models.ProductService, createBy, revisionDiff string, session mongo.Session, log *zap.SugaredLogger) error {
 	name := prodSvc.ServiceName
 	isHelmChart := !prodSvc.FromZadig()
 	if isHelmChart {
@@ -34,7 +39,7 @@ func CreateEnvServiceVersion(env *models.Product, prodSvc *models.ProductService
 			return fmt.Errorf("failed to get template service %s/%s/%s revision %d, isProduction %v, error: %v", env.ProductName, env.EnvName, prodSvc.ServiceName, prodSvc.Revision, env.Production, err)
 		}
 
-		releaseName := util.GeneReleaseName(tmplSvc.GetReleaseNaming(), env.ProductName, env.Namespace, env.EnvName, tmplSvc.ServiceName)
+		releaseName := util.GeneReleaseName(tmplSvc.GetReleaseNaming(), tmplSvc.ProductName, env.Namespace, env.EnvName, tmplSvc.ServiceName)
 		prodSvc.ReleaseName = releaseName
 	}
 
@@ -54,6 +59,7 @@ func CreateEnvServiceVersion(env *models.Product, prodSvc *models.ProductService
 		GlobalVariables: env.GlobalVariables,
 		DefaultValues:   env.DefaultValues,
 		YamlData:        env.YamlData,
+		RevisionDiff:    revisionDiff,
 		CreateBy:        createBy,
 	}
 	err = svcVersionColl.Create(version)
@@ -184,3 +184,39 @@ func CreateDeliveryActivities(c *gin.Context) {
 	}
 	ctx.RespErr = deliveryservice.InsertDeliveryActivities(&deliveryActivity, ID, ctx.Logger)
 }
+
+// @Summary Get Artifact Lineage
+// @Description Trace the image digest through the build, distribute and deploy jobs, and list the envs running it right now
+// @Tags 	delivery
+// @Accept 	json
+// @Produce json
+// @Param 	digest	query		string		false	"image digest"
+// @Param 	image	query		string		false	"image, the digest of the image is used if digest is empty"
+// @Success 200 	{object} 	deliveryservice.ArtifactLineage
+// @Router /api/aslan/delivery/artifacts/lineage [get]
+func GetArtifactLineage(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.DeliveryCenter.ViewArtifact {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	digest, image := c.Query("digest"), c.Query("image")
+	if digest == "" && image == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("digest and image can't be both empty")
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = deliveryservice.GetArtifactLineage(digest, image, ctx.Logger)
+}
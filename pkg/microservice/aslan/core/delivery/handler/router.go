@@ -29,6 +29,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		deliveryArtifact.GET("", ListDeliveryArtifacts)
 		deliveryArtifact.GET("/:id", GetDeliveryArtifact)
 		deliveryArtifact.GET("/image", GetDeliveryArtifactIDByImage)
+		deliveryArtifact.GET("/lineage", GetArtifactLineage)
 		deliveryArtifact.POST("/:id/activities", CreateDeliveryActivities)
 	}
 
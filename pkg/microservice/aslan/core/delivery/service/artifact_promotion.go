@@ -0,0 +1,137 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type ArtifactLineage struct {
+	ImageDigest string                            `json:"image_digest"`
+	Images      []string                          `json:"images"`
+	Promotions  []*commonmodels.ArtifactPromotion `json:"promotions"`
+	// Running is where the image is running right now, regardless of how it is deployed
+	Running []*RunningArtifact `json:"running"`
+}
+
+type RunningArtifact struct {
+	ProjectName   string `json:"project_name"`
+	EnvName       string `json:"env_name"`
+	Production    bool   `json:"production"`
+	ServiceName   string `json:"service_name"`
+	ServiceModule string `json:"service_module"`
+	Image         string `json:"image"`
+}
+
+// GetArtifactLineage traces the image from the build, through the distributions to the deployments in the envs by the
+// image digest, the digest of the image is used if the digest is not given
+func GetArtifactLineage(digest, image string, log *zap.SugaredLogger) (*ArtifactLineage, error) {
+	var err error
+	if digest == "" {
+		digest, err = commonrepo.NewArtifactPromotionColl().GetDigestByImage(image)
+		if err != nil {
+			log.Errorf("failed to get the digest of image %s, err: %s", image, err)
+			return nil, e.ErrFindArtifact.AddErr(err)
+		}
+	}
+
+	promotions := make([]*commonmodels.ArtifactPromotion, 0)
+	if digest != "" {
+		promotions, err = commonrepo.NewArtifactPromotionColl().ListByDigest(digest)
+		if err != nil {
+			log.Errorf("failed to list the promotions of digest %s, err: %s", digest, err)
+			return nil, e.ErrFindArtifact.AddErr(err)
+		}
+	}
+	images := sets.NewString()
+	if image != "" {
+		images.Insert(image)
+	}
+	for _, promotion := range promotions {
+		images.Insert(promotion.Image)
+	}
+
+	// the digest is unknown when the image is recorded before it is pushed, they are linked by the image
+	if images.Len() > 0 {
+		imagePromotions, err := commonrepo.NewArtifactPromotionColl().ListByImages(images.List())
+		if err != nil {
+			log.Errorf("failed to list the promotions of images %v, err: %s", images.List(), err)
+			return nil, e.ErrFindArtifact.AddErr(err)
+		}
+		for _, promotion := range imagePromotions {
+			if promotion.ImageDigest == "" {
+				promotions = append(promotions, promotion)
+			}
+		}
+	}
+	sort.SliceStable(promotions, func(i, j int) bool {
+		return promotions[i].CreateTime < promotions[j].CreateTime
+	})
+
+	running, err := listRunningArtifacts(promotions, images)
+	if err != nil {
+		log.Errorf("failed to find the envs running the images %v, err: %s", images.List(), err)
+		return nil, e.ErrFindArtifact.AddErr(err)
+	}
+
+	return &ArtifactLineage{
+		ImageDigest: digest,
+		Images:      images.List(),
+		Promotions:  promotions,
+		Running:     running,
+	}, nil
+}
+
+func listRunningArtifacts(promotions []*commonmodels.ArtifactPromotion, images sets.String) ([]*RunningArtifact, error) {
+	resp := make([]*RunningArtifact, 0)
+	projects := sets.NewString()
+	for _, promotion := range promotions {
+		projects.Insert(promotion.ProjectName)
+	}
+
+	for _, project := range projects.List() {
+		envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{Name: project})
+		if err != nil {
+			return nil, err
+		}
+		for _, env := range envs {
+			for _, svc := range env.GetServiceMap() {
+				for _, container := range svc.Containers {
+					if !images.Has(container.Image) {
+						continue
+					}
+					resp = append(resp, &RunningArtifact{
+						ProjectName:   project,
+						EnvName:       env.EnvName,
+						Production:    env.Production,
+						ServiceName:   svc.ServiceName,
+						ServiceModule: container.Name,
+						Image:         container.Image,
+					})
+				}
+			}
+		}
+	}
+	return resp, nil
+}
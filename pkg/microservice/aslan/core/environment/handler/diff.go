@@ -77,3 +77,64 @@ func ServiceDiff(c *gin.Context) {
 
 	ctx.Resp, ctx.RespErr = service.GetServiceDiff(envName, projectKey, c.Param("serviceName"), production, ctx.Logger)
 }
+
+// @Summary Get Env Template Diff
+// @Description Compare the services and variables of the environment with the latest template of the project
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	productName	path		string							true	"project name"
+// @Param 	envName		path		string							true	"env name"
+// @Param 	production	query		bool							false	"is production env"
+// @Success 200 		{object} 	service.EnvTemplateDiff
+// @Router /api/aslan/environment/diff/products/{productName}/env/{envName} [get]
+func EnvTemplateDiff(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("envName")
+	projectKey := c.Param("productName")
+	production := c.Query("production") == "true"
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if production {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].ProductionEnv.View {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.ProductionEnvActionView)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+
+			err = commonutil.CheckZadigProfessionalLicense()
+			if err != nil {
+				ctx.RespErr = err
+				return
+			}
+		} else {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].Env.View {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.EnvActionView)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetEnvTemplateDiff(projectKey, envName, production, ctx.Logger)
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+)
+
+// @Summary List Env Alerts
+// @Description List the Alertmanager alerts mapped to the environment or to a service of the environment
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Param 	serviceName	query		string							false	"service name"
+// @Param 	all			query		bool							false	"list the resolved alerts as well"
+// @Success 200 		{array} 	commonmodels.EnvAlert
+// @Router /api/aslan/environment/environments/{name}/alerts [get]
+func ListEnvAlerts(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListEnvAlerts(projectKey, envName, c.Query("serviceName"), production, c.Query("all") == "true", ctx.Logger)
+}
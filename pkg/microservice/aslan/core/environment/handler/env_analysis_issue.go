@@ -0,0 +1,103 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Env Analysis Issues
+// @Description List the issues created in the issue trackers for the findings of the environment analysis
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Success 200 		{array} 	ai.EnvAnalysisIssue
+// @Router /api/aslan/environment/environments/{name}/analysis/issues [get]
+func ListEnvAnalysisIssues(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListEnvAnalysisIssues(projectKey, envName, production)
+}
+
+// @Summary Create Env Analysis Issue
+// @Description Create an issue in jira or github for a finding of the environment analysis, the existing issue is returned if the same finding has been exported
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"env name"
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	production	query		bool								true	"is production"
+// @Param 	body 		body 		service.CreateEnvAnalysisIssueArgs 	true 	"body"
+// @Success 200 		{object} 	ai.EnvAnalysisIssue
+// @Router /api/aslan/environment/environments/{name}/analysis/issues [post]
+func CreateEnvAnalysisIssue(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.CreateEnvAnalysisIssueArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if args.AnalysisID == "" || args.Fingerprint == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("analysis_id and fingerprint are required")
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "新增", "环境-巡检问题工单", args.Fingerprint, "", ctx.Logger, envName)
+
+	ctx.Resp, ctx.RespErr = service.CreateEnvAnalysisIssue(projectKey, envName, production, args, ctx.UserName, ctx.Logger)
+}
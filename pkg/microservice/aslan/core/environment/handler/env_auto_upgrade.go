@@ -0,0 +1,95 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Get env auto upgrade policy
+// @Description Get the policy to upgrade the services of the non-production env to the latest template revisions automatically
+// @Tags 	environment
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	name		path		string								true	"env name"
+// @Success 200 		{object} 	commonmodels.EnvAutoUpgradePolicy
+// @Router /api/aslan/environment/environments/{name}/autoUpgrade [get]
+func GetEnvAutoUpgradePolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+
+	if !checkEnvPermission(ctx, projectKey, envName, false, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetEnvAutoUpgradePolicy(projectKey, envName)
+}
+
+// @Summary Update env auto upgrade policy
+// @Description Update the auto upgrade policy of the non-production env, the services failed to upgrade before are retried after the update
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	name		path		string								true	"env name"
+// @Param 	body 		body 		commonmodels.EnvAutoUpgradePolicy 	true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/autoUpgrade [put]
+func UpdateEnvAutoUpgradePolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+
+	args := new(commonmodels.EnvAutoUpgradePolicy)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, false, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "更新", "环境-自动升级策略", envName, "", ctx.Logger, envName)
+
+	ctx.RespErr = service.UpdateEnvAutoUpgradePolicy(projectKey, envName, args, ctx.Logger)
+}
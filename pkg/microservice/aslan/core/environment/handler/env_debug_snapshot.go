@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Env Debug Snapshots
+// @Description List the diagnostic bundles captured in the environment
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Success 200 		{array} 	commonmodels.EnvDebugSnapshot
+// @Router /api/aslan/environment/environments/{name}/snapshots [get]
+func ListEnvDebugSnapshots(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListEnvDebugSnapshots(projectKey, envName, production)
+}
+
+// @Summary Create Env Debug Snapshot
+// @Description Capture the describe output, recent logs, events and rendered manifests of the selected services as a bundle archived in the object storage
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"env name"
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	production	query		bool								true	"is production"
+// @Param 	body 		body 		service.CreateEnvDebugSnapshotArgs 	true 	"body"
+// @Success 200 		{object} 	commonmodels.EnvDebugSnapshot
+// @Router /api/aslan/environment/environments/{name}/snapshots [post]
+func CreateEnvDebugSnapshot(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.CreateEnvDebugSnapshotArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "新增", "环境-诊断快照", envName, "", ctx.Logger, envName)
+
+	ctx.Resp, ctx.RespErr = service.CreateEnvDebugSnapshot(projectKey, envName, production, args, ctx.UserName, ctx.Logger)
+}
+
+// @Summary Download Env Debug Snapshot
+// @Description Download the archived bundle of the diagnostic snapshot
+// @Tags 	environment
+// @Accept 	json
+// @Produce application/gzip
+// @Param 	name		path		string							true	"env name"
+// @Param 	id			path		string							true	"snapshot id"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/snapshots/{id}/download [get]
+func DownloadEnvDebugSnapshot(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	data, fileName, err := service.DownloadEnvDebugSnapshot(projectKey, envName, production, c.Param("id"), ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "application/gzip", data)
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Get Env Health
+// @Description Get the health score of the environment with the trend and the SLO compliance in the time range, the last 24 hours are used if the time range is not given
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Param 	startTime	query		int								false	"start time"
+// @Param 	endTime		query		int								false	"end time"
+// @Success 200 		{object} 	service.EnvHealthResp
+// @Router /api/aslan/environment/environments/{name}/health [get]
+func GetEnvHealth(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	var startTime, endTime int64
+	if c.Query("startTime") != "" {
+		startTime, err = strconv.ParseInt(c.Query("startTime"), 10, 64)
+		if err != nil {
+			ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid startTime")
+			return
+		}
+	}
+	if c.Query("endTime") != "" {
+		endTime, err = strconv.ParseInt(c.Query("endTime"), 10, 64)
+		if err != nil {
+			ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid endTime")
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetEnvHealth(projectKey, envName, production, startTime, endTime, ctx.Logger)
+}
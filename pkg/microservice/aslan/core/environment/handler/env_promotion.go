@@ -0,0 +1,374 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/types"
+)
+
+// checkEnvPermission checks the view or edit permission of the env for the non system admin user
+func checkEnvPermission(ctx *internalhandler.Context, projectKey, envName string, production, edit bool) bool {
+	if ctx.Resources.IsSystemAdmin {
+		return true
+	}
+	authInfo, ok := ctx.Resources.ProjectAuthInfo[projectKey]
+	if !ok {
+		return false
+	}
+	if authInfo.IsProjectAdmin {
+		return true
+	}
+
+	var permitted bool
+	var action string
+	switch {
+	case production && edit:
+		permitted, action = authInfo.ProductionEnv.EditConfig, types.ProductionEnvActionEditConfig
+	case production:
+		permitted, action = authInfo.ProductionEnv.View, types.ProductionEnvActionView
+	case edit:
+		permitted, action = authInfo.Env.EditConfig, types.EnvActionEditConfig
+	default:
+		permitted, action = authInfo.Env.View, types.EnvActionView
+	}
+	if permitted {
+		return true
+	}
+	permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, action)
+	return err == nil && permitted
+}
+
+// @Summary Preview Env Promotion
+// @Description Preview the services whose image in the source env differs from the target env
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	body 		body 		service.EnvPromotionArgs 		true 	"body"
+// @Success 200 		{array} 	commonmodels.EnvPromotionService
+// @Router /api/aslan/environment/promotions/preview [post]
+func PreviewEnvPromotion(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(service.EnvPromotionArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args.ProjectName = c.Query("projectName")
+	if args.ProjectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	if !checkEnvPermission(ctx, args.ProjectName, args.SourceEnv, args.SourceProduction, false) ||
+		!checkEnvPermission(ctx, args.ProjectName, args.TargetEnv, args.TargetProduction, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.PreviewEnvPromotion(args, ctx.Logger)
+}
+
+// @Summary Create Env Promotion
+// @Description Promote the service versions in the source env to the target env
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	body 		body 		service.EnvPromotionArgs 		true 	"body"
+// @Success 200 		{object} 	commonmodels.EnvPromotion
+// @Router /api/aslan/environment/promotions [post]
+func CreateEnvPromotion(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(service.EnvPromotionArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args.ProjectName = c.Query("projectName")
+	if args.ProjectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	if !checkEnvPermission(ctx, args.ProjectName, args.SourceEnv, args.SourceProduction, false) ||
+		!checkEnvPermission(ctx, args.ProjectName, args.TargetEnv, args.TargetProduction, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, args.ProjectName, setting.OperationSceneEnv, "晋级", "环境-服务版本", fmt.Sprintf("源环境: %s, 目标环境: %s", args.SourceEnv, args.TargetEnv), "", ctx.Logger, args.TargetEnv)
+
+	ctx.Resp, ctx.RespErr = service.CreateEnvPromotion(ctx.UserName, args, ctx.Logger)
+}
+
+type listEnvPromotionsQuery struct {
+	ProjectName string `form:"projectName"`
+	TargetEnv   string `form:"targetEnv"`
+	Production  bool   `form:"production"`
+	PageNum     int64  `form:"pageNum"`
+	PageSize    int64  `form:"pageSize"`
+}
+
+type listEnvPromotionsResp struct {
+	Promotions interface{} `json:"promotions"`
+	Total      int64       `json:"total"`
+}
+
+// @Summary List Env Promotions
+// @Description List Env Promotions
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	targetEnv	query		string							false	"target env name"
+// @Param 	production	query		bool							false	"is production target env"
+// @Param 	pageNum		query		int								false	"page num"
+// @Param 	pageSize	query		int								false	"page size"
+// @Success 200 		{object} 	listEnvPromotionsResp
+// @Router /api/aslan/environment/promotions [get]
+func ListEnvPromotions(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	query := new(listEnvPromotionsQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if query.ProjectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	if !checkEnvPermission(ctx, query.ProjectName, query.TargetEnv, query.Production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	promotions, total, err := service.ListEnvPromotions(query.ProjectName, query.TargetEnv, query.PageNum, query.PageSize, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	ctx.Resp = &listEnvPromotionsResp{
+		Promotions: promotions,
+		Total:      total,
+	}
+}
+
+// @Summary Get Env Promotion
+// @Description Get Env Promotion
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	id			path		string							true	"promotion id"
+// @Param 	projectName	query		string							true	"project name"
+// @Success 200 		{object} 	commonmodels.EnvPromotion
+// @Router /api/aslan/environment/promotions/{id} [get]
+func GetEnvPromotion(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	promotion, err := service.GetEnvPromotion(projectKey, c.Param("id"), ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if !checkEnvPermission(ctx, projectKey, promotion.TargetEnv, promotion.TargetProduction, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+	ctx.Resp = promotion
+}
+
+// @Summary Approve Env Promotion
+// @Description Approve or reject the env promotion, only the approvers of the promotion are allowed
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	id			path		string							true	"promotion id"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	approve		query		bool							true	"approve or reject"
+// @Success 200
+// @Router /api/aslan/environment/promotions/{id}/approve [post]
+func ApproveEnvPromotion(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	approve := c.Query("approve") == "true"
+	operation := "驳回"
+	if approve {
+		operation = "通过"
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, operation, "环境-版本晋级", c.Param("id"), "", ctx.Logger)
+
+	ctx.RespErr = service.ApproveEnvPromotion(ctx.UserName, projectKey, c.Param("id"), approve, ctx.Logger)
+}
+
+// @Summary Rollback Env Promotion
+// @Description Restore the images of the target env before the promotion
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	id			path		string							true	"promotion id"
+// @Param 	projectName	query		string							true	"project name"
+// @Success 200
+// @Router /api/aslan/environment/promotions/{id}/rollback [post]
+func RollbackEnvPromotion(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	promotion, err := service.GetEnvPromotion(projectKey, c.Param("id"), ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if !checkEnvPermission(ctx, projectKey, promotion.TargetEnv, promotion.TargetProduction, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "回滚", "环境-版本晋级", fmt.Sprintf("环境: %s, 晋级: %s", promotion.TargetEnv, c.Param("id")), "", ctx.Logger, promotion.TargetEnv)
+
+	ctx.RespErr = service.RollbackEnvPromotion(ctx.UserName, projectKey, c.Param("id"), ctx.Logger)
+}
+
+// @Summary Get Env Changelog
+// @Description Get the commits, PRs and authors of the service images which differ between the two envs
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	format		query		string							false	"markdown to download the changelog as Markdown"
+// @Param 	body 		body 		service.EnvChangelogArgs 		true 	"body"
+// @Success 200 		{object} 	service.EnvChangelog
+// @Router /api/aslan/environment/changelog [post]
+func GetEnvChangelog(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(service.EnvChangelogArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args.ProjectName = c.Query("projectName")
+	if args.ProjectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	if !checkEnvPermission(ctx, args.ProjectName, args.FromEnv, args.FromProduction, false) ||
+		!checkEnvPermission(ctx, args.ProjectName, args.ToEnv, args.ToProduction, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	changelog, err := service.GetEnvChangelog(args, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if c.Query("format") != "markdown" {
+		ctx.Resp = changelog
+		return
+	}
+
+	fileName := fmt.Sprintf("changelog-%s-%s-%s.md", args.ProjectName, args.FromEnv, args.ToEnv)
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(service.RenderEnvChangelogMarkdown(changelog)))
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Get Resource Recommendations
+// @Description Compare the usage of the containers with their requests and limits, and recommend the resources for the services in the environment
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string									true	"env name"
+// @Param 	projectName	query		string									true	"project name"
+// @Param 	production	query		bool									true	"is production"
+// @Param 	body 		body 		service.EnvResourceRecommendationArgs 	true 	"body"
+// @Success 200 		{array} 	service.ServiceResourceRecommendation
+// @Router /api/aslan/environment/environments/{name}/resources/recommendations [post]
+func GetEnvResourceRecommendations(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.EnvResourceRecommendationArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetEnvResourceRecommendations(projectKey, envName, production, args, ctx.Logger)
+}
+
+// @Summary Apply Resource Recommendation
+// @Description Merge the values patch of the recommendation into the override values of the helm service and upgrade the release
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string										true	"env name"
+// @Param 	projectName	query		string										true	"project name"
+// @Param 	production	query		bool										true	"is production"
+// @Param 	body 		body 		service.ApplyResourceRecommendationArgs 	true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/resources/recommendations/apply [post]
+func ApplyResourceRecommendation(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.ApplyResourceRecommendationArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if args.ServiceName == "" || args.ValuesPatch == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("service_name and values_patch are required")
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "更新", "环境-资源配置推荐", fmt.Sprintf("%s:%s", envName, args.ServiceName), args.ValuesPatch, ctx.Logger, envName)
+
+	ctx.RespErr = service.ApplyResourceRecommendation(projectKey, envName, production, args, ctx.UserName, ctx.RequestID, ctx.Logger)
+}
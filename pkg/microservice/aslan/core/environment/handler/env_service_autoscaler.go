@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Service Autoscalers
+// @Description List the autoscalers of the workloads of the service with the current scaling status
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	serviceName	path		string							true	"service name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							false	"is production env"
+// @Success 200 		{array} 	service.ServiceAutoscaler
+// @Router /api/aslan/environment/environments/{name}/services/{serviceName}/autoscalers [get]
+func ListServiceAutoscalers(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	serviceName := c.Param("serviceName")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListServiceAutoscalers(projectKey, envName, serviceName, production, ctx.Logger)
+}
+
+// @Summary Upsert Service Autoscaler
+// @Description Create or update the HPA or KEDA ScaledObject of a workload of the service
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"env name"
+// @Param 	serviceName	path		string								true	"service name"
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	production	query		bool								false	"is production env"
+// @Param 	body 		body 		commonmodels.EnvServiceAutoscaler 	true 	"body"
+// @Success 200 		{object} 	commonmodels.EnvServiceAutoscaler
+// @Router /api/aslan/environment/environments/{name}/services/{serviceName}/autoscalers [put]
+func UpsertServiceAutoscaler(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	serviceName := c.Param("serviceName")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(commonmodels.EnvServiceAutoscaler)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "更新", "环境-服务弹性伸缩", fmt.Sprintf("%s:%s:%s/%s", envName, serviceName, args.TargetKind, args.TargetName), "", ctx.Logger, envName)
+
+	ctx.Resp, ctx.RespErr = service.UpsertServiceAutoscaler(projectKey, envName, serviceName, production, args, ctx.UserName, ctx.Logger)
+}
+
+// @Summary Delete Service Autoscaler
+// @Description Delete the autoscaler of a workload of the service
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	serviceName	path		string							true	"service name"
+// @Param 	id			path		string							true	"autoscaler id"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							false	"is production env"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/services/{serviceName}/autoscalers/{id} [delete]
+func DeleteServiceAutoscaler(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	serviceName := c.Param("serviceName")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+	id := c.Param("id")
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "删除", "环境-服务弹性伸缩", fmt.Sprintf("%s:%s:%s", envName, serviceName, id), "", ctx.Logger, envName)
+
+	ctx.RespErr = service.DeleteServiceAutoscaler(projectKey, envName, serviceName, id, production, ctx.Logger)
+}
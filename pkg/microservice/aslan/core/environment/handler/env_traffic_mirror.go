@@ -0,0 +1,128 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Traffic Mirrors
+// @Description List the traffic mirrors from the services of the base environment to its sub environments
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"base env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Success 200 		{array} 	commonmodels.EnvTrafficMirror
+// @Router /api/aslan/environment/environments/{name}/mirrors [get]
+func ListTrafficMirrors(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+
+	if !checkEnvPermission(ctx, projectKey, envName, false, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListTrafficMirrors(projectKey, envName)
+}
+
+// @Summary Create Traffic Mirror
+// @Description Mirror a percentage of the traffic of a service in the base environment to its counterpart in a sub environment
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"base env name"
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	body 		body 		service.CreateTrafficMirrorArgs 	true 	"body"
+// @Success 200 		{object} 	commonmodels.EnvTrafficMirror
+// @Router /api/aslan/environment/environments/{name}/mirrors [post]
+func CreateTrafficMirror(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+
+	args := new(service.CreateTrafficMirrorArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, false, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "新增", "环境-流量镜像", fmt.Sprintf("%s:%s->%s", envName, args.ServiceName, args.SubEnv), "", ctx.Logger, envName)
+
+	ctx.Resp, ctx.RespErr = service.CreateTrafficMirror(projectKey, envName, args, ctx.UserName, ctx.Logger)
+}
+
+// @Summary Delete Traffic Mirror
+// @Description Stop mirroring the traffic and clean up the mirror rules in the base environment
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"base env name"
+// @Param 	id			path		string							true	"traffic mirror id"
+// @Param 	projectName	query		string							true	"project name"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/mirrors/{id} [delete]
+func DeleteTrafficMirror(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	id := c.Param("id")
+
+	if !checkEnvPermission(ctx, projectKey, envName, false, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "删除", "环境-流量镜像", fmt.Sprintf("%s:%s", envName, id), "", ctx.Logger, envName)
+
+	ctx.RespErr = service.DeleteTrafficMirror(projectKey, envName, id, ctx.Logger)
+}
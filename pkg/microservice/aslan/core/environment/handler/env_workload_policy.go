@@ -0,0 +1,105 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Get Env Workload Policy
+// @Description Get the PodDisruptionBudget and priority class policy injected into the workloads of the production environments
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string							true	"project name"
+// @Success 200 		{object} 	commonmodels.EnvWorkloadPolicy
+// @Router /api/aslan/environment/workload/policy [get]
+func GetEnvWorkloadPolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetEnvWorkloadPolicy(projectKey)
+}
+
+// @Summary Update Env Workload Policy
+// @Description Update the PodDisruptionBudget and priority class policy injected into the workloads of the production environments
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	body 		body 		commonmodels.EnvWorkloadPolicy 		true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/workload/policy [put]
+func UpdateEnvWorkloadPolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	args := new(commonmodels.EnvWorkloadPolicy)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		authInfo, ok := ctx.Resources.ProjectAuthInfo[projectKey]
+		if !ok || !authInfo.IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "更新", "环境-工作负载策略", projectKey, "", ctx.Logger)
+
+	ctx.RespErr = service.UpdateEnvWorkloadPolicy(projectKey, ctx.UserName, args, ctx.Logger)
+}
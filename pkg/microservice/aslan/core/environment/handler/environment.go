@@ -49,6 +49,8 @@ import (
 
 type DeleteProductServicesRequest struct {
 	ServiceNames []string `json:"service_names"`
+	// Strict blocks the deletion if the other services in the env depend on the services to be deleted
+	Strict bool `json:"strict"`
 }
 
 type DeleteProductHelmReleaseRequest struct {
@@ -478,6 +480,16 @@ func UpdateProduct(c *gin.Context) {
 		}
 	}
 
+	if !checkProductionDeployFreeze(c, ctx, c.Query("production") == "true") {
+		return
+	}
+
+	release, ok := claimEnvResourceVersion(c, ctx, projectKey, envName, c.Query("production") == "true")
+	if !ok {
+		return
+	}
+	defer release()
+
 	ctx.RespErr = service.UpdateCVMProduct(envName, projectKey, ctx.UserName, ctx.RequestID, ctx.Logger)
 	if ctx.RespErr != nil {
 		ctx.Logger.Errorf("failed to update product %s %s: %v", envName, projectKey, ctx.RespErr)
@@ -551,6 +563,16 @@ func UpdateProductRegistry(c *gin.Context) {
 		}
 	}
 
+	if !checkProductionDeployFreeze(c, ctx, production) {
+		return
+	}
+
+	release, ok := claimEnvResourceVersion(c, ctx, projectKey, envName, production)
+	if !ok {
+		return
+	}
+	defer release()
+
 	ctx.RespErr = service.UpdateProductRegistry(envName, projectKey, args.RegistryID, production, ctx.Logger)
 	if ctx.RespErr != nil {
 		ctx.Logger.Errorf("failed to update product %s %s: %v", envName, args.RegistryID, ctx.RespErr)
@@ -662,6 +684,177 @@ func UpdateProductAlias(c *gin.Context) {
 	ctx.RespErr = service.UpdateProductAlias(envName, projectKey, arg.Alias, production)
 }
 
+type setEnvImageSigningPolicyReq struct {
+	ImageSigningKeyID string `json:"image_signing_key_id"`
+}
+
+// @Summary Set Env Image Signing Policy
+// @Description Images deployed to the production environment must be signed by the key, an empty key id disables the verification
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	body 		body 		setEnvImageSigningPolicyReq 	true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/imageSigningPolicy [put]
+func SetEnvImageSigningPolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	req := new(setEnvImageSigningPolicyReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, true, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "更新", "环境-镜像签名策略", envName, req.ImageSigningKeyID, ctx.Logger, envName)
+
+	ctx.RespErr = service.SetEnvImageSigningPolicy(projectKey, envName, req.ImageSigningKeyID, ctx.Logger)
+}
+
+// @Summary Get Env External Variable
+// @Description Get the config of the external key/values injected into the template context of the services
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"env name"
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	production	query		bool								false	"is production env"
+// @Success 200 		{object} 	commonmodels.ExternalVariableConfig
+// @Router /api/aslan/environment/environments/{name}/externalVariable [get]
+func GetEnvExternalVariable(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetEnvExternalVariable(projectKey, envName, production)
+}
+
+// @Summary Update Env External Variable
+// @Description Update the config of the external key/values injected into the template context of the services, it takes effect in the next deployment
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"env name"
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	production	query		bool								false	"is production env"
+// @Param 	body 		body 		commonmodels.ExternalVariableConfig true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/externalVariable [put]
+func UpdateEnvExternalVariable(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.ExternalVariableConfig)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "更新", "环境-外部变量", envName, "", ctx.Logger, envName)
+
+	ctx.RespErr = service.UpdateEnvExternalVariable(projectKey, envName, production, args, ctx.Logger)
+}
+
+// @Summary Test Env External Variable
+// @Description Fetch the external key/values with the config without the cache
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"env name"
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	production	query		bool								false	"is production env"
+// @Param 	body 		body 		commonmodels.ExternalVariableConfig true 	"body"
+// @Success 200 		{object} 	map[string]interface{}
+// @Router /api/aslan/environment/environments/{name}/externalVariable/test [post]
+func TestEnvExternalVariable(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.ExternalVariableConfig)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.TestEnvExternalVariable(projectKey, envName, production, args)
+}
+
 func AffectedServices(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -790,6 +983,53 @@ func generalRequestValidate(c *gin.Context) (string, string, error) {
 	return projectName, envName, nil
 }
 
+// claimEnvResourceVersion checks the resource version the caller read the env with, which is passed by the If-Match header
+// or the resourceVersion query, and sets the conflict error to the context if the env has been changed since then.
+// The version is required, the update is rejected if it is missing.
+// The env is locked for the request until the returned function is called.
+func claimEnvResourceVersion(c *gin.Context, ctx *internalhandler.Context, projectKey, envName string, production bool) (func(), bool) {
+	versionStr := strings.Trim(c.GetHeader("If-Match"), "\" ")
+	if versionStr == "" {
+		versionStr = c.Query("resourceVersion")
+	}
+
+	if versionStr == "" {
+		ctx.RespErr = e.ErrPreconditionRequired.AddDesc("the resource version of the env is required, pass it by the If-Match header or the resourceVersion query")
+		return nil, false
+	}
+	expected, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(fmt.Sprintf("invalid resource version: %s", versionStr))
+		return nil, false
+	}
+
+	release, err := service.ClaimEnvResourceVersion(projectKey, envName, production, expected, ctx.UserName, ctx.RequestID, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return nil, false
+	}
+	return release, true
+}
+
+// checkProductionDeployFreeze rejects the production env updates during the deployment freeze unless an override
+// reason is given, the reason is recorded in the audit log
+func checkProductionDeployFreeze(c *gin.Context, ctx *internalhandler.Context, production bool) bool {
+	if !production {
+		return true
+	}
+	reason := internalhandler.GetFreezeOverrideReason(c)
+	window, err := commonservice.CheckDeployFreeze(reason)
+	if err != nil {
+		ctx.RespErr = err
+		return false
+	}
+	if window != nil {
+		ctx.Logger.Infof("user %s overrides the deployment freeze %s, reason: %s", ctx.UserName, window.Name, reason)
+		internalhandler.SetAuditLogFreezeOverrideReason(c, reason)
+	}
+	return true
+}
+
 func UpdateHelmProductDefaultValues(c *gin.Context) {
 	ctx, err := internalhandler.NewContextWithAuthorization(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -866,6 +1106,16 @@ func UpdateHelmProductDefaultValues(c *gin.Context) {
 	}
 
 	arg.DeployType = setting.HelmDeployType
+	if !checkProductionDeployFreeze(c, ctx, production) {
+		return
+	}
+
+	release, ok := claimEnvResourceVersion(c, ctx, projectKey, envName, production)
+	if !ok {
+		return
+	}
+	defer release()
+
 	ctx.RespErr = service.UpdateProductDefaultValues(projectKey, envName, ctx.UserName, ctx.RequestID, arg, production, ctx.Logger)
 }
 
@@ -1004,6 +1254,7 @@ func PreviewGlobalVariables(c *gin.Context) {
 // @Produce json
 // @Param 	projectName	query		string									true	"project name"
 // @Param 	name 		path		string									true	"env name"
+// @Param 	If-Match	header		string							true	"resource version of the env"
 // @Param 	body 		body 		updateK8sProductGlobalVariablesRequest 	true 	"body"
 // @Success 200
 // @Router /api/aslan/environment/environments/{name}/k8s/globalVariables [put]
@@ -1071,6 +1322,16 @@ func UpdateK8sProductGlobalVariables(c *gin.Context) {
 		return
 	}
 
+	if !checkProductionDeployFreeze(c, ctx, production) {
+		return
+	}
+
+	release, ok := claimEnvResourceVersion(c, ctx, projectKey, envName, production)
+	if !ok {
+		return
+	}
+	defer release()
+
 	ctx.RespErr = service.UpdateProductGlobalVariables(projectKey, envName, ctx.UserName, ctx.RequestID, arg.CurrentRevision, arg.GlobalVariables, production, ctx.Logger)
 }
 
@@ -1081,6 +1342,7 @@ func UpdateK8sProductGlobalVariables(c *gin.Context) {
 // @Produce json
 // @Param 	projectName		query		string							true	"project name"
 // @Param 	name			path		string							true	"env name"
+// @Param 	If-Match	header		string							true	"resource version of the env"
 // @Param 	body 			body 		service.EnvRendersetArg 		true 	"body"
 // @Success 200
 // @Router /api/aslan/environment/production/environments/{name}/helm/charts [put]
@@ -1159,7 +1421,18 @@ func UpdateHelmProductCharts(c *gin.Context) {
 		return
 	}
 
-	ctx.RespErr = service.UpdateHelmProductCharts(projectKey, envName, ctx.UserName, ctx.RequestID, production, arg, ctx.Logger)
+	if !checkProductionDeployFreeze(c, ctx, production) {
+		return
+	}
+
+	release, ok := claimEnvResourceVersion(c, ctx, projectKey, envName, production)
+	if !ok {
+		return
+	}
+	defer release()
+
+	canOverrideLockedValues := ctx.Resources.IsSystemAdmin || ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin
+	ctx.RespErr = service.UpdateHelmProductCharts(projectKey, envName, ctx.UserName, ctx.RequestID, production, canOverrideLockedValues, arg, ctx.Logger)
 }
 
 func updateMultiEnvWrapper(c *gin.Context, request *service.UpdateEnvRequest, production bool, ctx *internalhandler.Context) {
@@ -1489,7 +1762,13 @@ func GetEnvironment(c *gin.Context) {
 		}
 	}
 
-	ctx.Resp, ctx.RespErr = service.GetProduct(ctx.UserName, envName, projectKey, ctx.Logger)
+	resp, err := service.GetProduct(ctx.UserName, envName, projectKey, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	c.Header("ETag", fmt.Sprintf("\"%d\"", resp.ResourceVersion))
+	ctx.Resp = resp
 }
 
 func GetEstimatedRenderCharts(c *gin.Context) {
@@ -1714,10 +1993,63 @@ func DeleteProductServices(c *gin.Context) {
 		}
 	}
 
+	analysis, err := service.AnalyzeServiceDeletion(projectKey, envName, args.ServiceNames, production, ctx.Logger)
+	if err != nil {
+		if args.Strict {
+			ctx.RespErr = err
+			return
+		}
+		ctx.Logger.Warnf("failed to analyze the dependencies of services %v in env %s, err: %s", args.ServiceNames, envName, err)
+	} else if len(analysis.Dependencies) > 0 {
+		if args.Strict {
+			ctx.RespErr = e.NewWithExtras(e.ErrDeleteSvcHasDependents, "", map[string]interface{}{"dependencies": analysis.Dependencies})
+			return
+		}
+		ctx.Logger.Warnf("services %v in env %s are depended by other services: %d references", args.ServiceNames, envName, len(analysis.Dependencies))
+	}
+
 	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "删除", "环境的服务", fmt.Sprintf("%s:[%s]", envName, strings.Join(args.ServiceNames, ",")), "", ctx.Logger, envName)
 	ctx.RespErr = service.DeleteProductServices(ctx.UserName, ctx.RequestID, envName, projectKey, args.ServiceNames, production, ctx.Logger)
 }
 
+// @Summary Analyze Service Deletion
+// @Description Find the references from the other services in the environment to the services to be deleted
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName		query		string							true	"project name"
+// @Param 	name			path		string							true	"env name"
+// @Param 	production		query		bool							true	"is production"
+// @Param 	body 			body 		DeleteProductServicesRequest 	true 	"body"
+// @Success 200 			{object} 	service.ServiceDeletionAnalysis
+// @Router /api/aslan/environment/environments/{name}/services/deletion/analysis [post]
+func AnalyzeServiceDeletion(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(DeleteProductServicesRequest)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.AnalyzeServiceDeletion(projectKey, envName, args.ServiceNames, production, ctx.Logger)
+}
+
 // @Summary Delete helm release from envrionment
 // @Description Delete helm release from envrionment
 // @Tags 	environment
@@ -2093,6 +2425,7 @@ func GetEnvConfigs(c *gin.Context) {
 // @Produce json
 // @Param 	name 		path		string							true	"env name"
 // @Param 	projectName	query		string							true	"project name"
+// @Param 	If-Match	header		string							true	"resource version of the env"
 // @Param 	body 		body 		service.EnvConfigsArgs	 		true 	"body"
 // @Success 200
 // @Router /api/aslan/environment/environments/{name}/configs [put]
@@ -2163,6 +2496,16 @@ func UpdateEnvConfigs(c *gin.Context) {
 		return
 	}
 
+	if !checkProductionDeployFreeze(c, ctx, production) {
+		return
+	}
+
+	release, ok := claimEnvResourceVersion(c, ctx, projectKey, envName, production)
+	if !ok {
+		return
+	}
+	defer release()
+
 	ctx.RespErr = service.UpdateEnvConfigs(projectKey, envName, arg, &production, ctx.Logger)
 }
 
@@ -2173,6 +2516,7 @@ func UpdateEnvConfigs(c *gin.Context) {
 // @Produce json
 // @Param 	name 		path		string							true	"env name"
 // @Param 	projectName	query		string							true	"project name"
+// @Param 	body 		body 		service.EnvAnalysisArgs 		false 	"body"
 // @Success 200 		{object}    service.EnvAnalysisRespone
 // @Router /api/aslan/environment/environments/{name}/analysis [post]
 func RunAnalysis(c *gin.Context) {
@@ -2223,7 +2567,15 @@ func RunAnalysis(c *gin.Context) {
 		}
 	}
 
-	ctx.Resp, ctx.RespErr = service.EnvAnalysis(projectKey, envName, &production, c.Query("triggerName"), ctx.UserName, ctx.Logger)
+	args := new(service.EnvAnalysisArgs)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(args); err != nil {
+			ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.EnvAnalysis(projectKey, envName, &production, args, c.Query("triggerName"), ctx.UserName, ctx.Logger)
 }
 
 // @Summary Upsert Env Analysis Cron
@@ -4110,3 +4462,34 @@ func DeleteSAEServiceFromEnv(c *gin.Context) {
 
 	ctx.RespErr = service.DelSAEAppFromEnv(ctx.UserName, projectKey, envName, production, arg, ctx.Logger)
 }
+
+// @Summary Get Environment Lock
+// @Description Get who is holding the lock of the environment and who are waiting for it
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string		true	"env name"
+// @Param 	projectName	query		string		true	"project name"
+// @Param 	production	query		bool		true	"is production"
+// @Success 200 		{object} 	commonutil.EnvLockStatus
+// @Router /api/aslan/environment/environments/{name}/lock [get]
+func GetEnvLockStatus(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetEnvLockStatus(projectKey, envName, production)
+}
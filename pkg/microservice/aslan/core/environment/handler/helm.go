@@ -22,10 +22,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/koderover/zadig/v2/pkg/types"
 
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
 	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
 	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 )
 
 func ListReleases(c *gin.Context) {
@@ -140,6 +143,211 @@ func GetChartValues(c *gin.Context) {
 	}
 }
 
+// @Summary Get helm values layers
+// @Description Get the values layers of the helm service and the layer each key of the final values comes from
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	name		path		string								true	"env name"
+// @Param 	production	query		bool								false	"is production env"
+// @Param 	body 		body 		service.HelmValuesLayersArgs 		true 	"body"
+// @Success 200 		{object} 	service.HelmValuesLayersResp
+// @Router /api/aslan/environment/environments/{name}/helm/values/layers [post]
+func GetHelmValuesLayers(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.HelmValuesLayersArgs)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if production {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].ProductionEnv.View {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.ProductionEnvActionView)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		} else {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].Env.View {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.EnvActionView)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetHelmValuesLayers(projectKey, envName, args, production, ctx.Logger)
+}
+
+// @Summary Get helm post renderer
+// @Description Get the kustomize patch set applied to the helm releases of the env
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	name		path		string								true	"env name"
+// @Param 	production	query		bool								false	"is production env"
+// @Success 200 		{object} 	commonmodels.HelmPostRenderer
+// @Router /api/aslan/environment/environments/{name}/helm/postRenderer [get]
+func GetHelmPostRenderer(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetHelmPostRenderer(projectKey, envName, production)
+}
+
+// @Summary Update helm post renderer
+// @Description Update the kustomize patch set applied to the helm releases of the env, it takes effect in the next install or upgrade of the releases
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	name		path		string								true	"env name"
+// @Param 	production	query		bool								false	"is production env"
+// @Param 	body 		body 		commonmodels.HelmPostRenderer 		true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/helm/postRenderer [put]
+func UpdateHelmPostRenderer(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(commonmodels.HelmPostRenderer)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "更新", "环境-Helm后置渲染", envName, "", ctx.Logger, envName)
+
+	ctx.RespErr = service.UpdateHelmPostRenderer(projectKey, envName, production, args, ctx.Logger)
+}
+
+// @Summary List Helm Release History
+// @Description List revisions of a helm release in the environment with values diff against the previous revision
+// @Tags 	environment
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	releaseName	path		string							true	"release name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Success 200 		{array} 	service.HelmReleaseRevision
+// @Router /api/aslan/environment/environments/{name}/helm/releases/{releaseName}/history [get]
+func ListHelmReleaseHistory(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListHelmReleaseHistory(projectKey, envName, c.Param("releaseName"), production, ctx.Logger)
+}
+
+// @Summary Rollback Helm Release
+// @Description Rollback a single helm release in the environment to the given revision
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"env name"
+// @Param 	releaseName	path		string								true	"release name"
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	production	query		bool								true	"is production"
+// @Param 	body 		body 		service.RollbackHelmReleaseArgs 	true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/helm/releases/{releaseName}/rollback [post]
+func RollbackHelmRelease(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	releaseName := c.Param("releaseName")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.RollbackHelmReleaseArgs)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "回滚", "环境-Helm Release", fmt.Sprintf("%s:%s:%d", envName, releaseName, args.Revision), "", ctx.Logger, envName)
+
+	ctx.RespErr = service.RollbackHelmRelease(ctx.UserName, projectKey, envName, releaseName, args.Revision, production, ctx.Logger)
+}
+
 func GetChartInfos(c *gin.Context) {
 	ctx, err := internalhandler.NewContextWithAuthorization(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -0,0 +1,255 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Istio Routings
+// @Description List the VirtualServices, Gateways and DestinationRules in the environment namespace
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Success 200 		{array} 	service.IstioRoutingObject
+// @Router /api/aslan/environment/environments/{name}/istio/routings [get]
+func ListIstioRoutings(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListIstioRoutings(projectKey, envName, production, ctx.Logger)
+}
+
+// @Summary Preview Istio Routing
+// @Description Render the routing objects from the yaml or the traffic spec without applying them
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Param 	body 		body 		service.IstioRoutingArgs 		true 	"body"
+// @Success 200 		{array} 	service.IstioRoutingObject
+// @Router /api/aslan/environment/environments/{name}/istio/routings/preview [post]
+func PreviewIstioRouting(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.IstioRoutingArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.PreviewIstioRouting(projectKey, envName, production, args, ctx.Logger)
+}
+
+// @Summary Apply Istio Routing
+// @Description Create or update the routing objects rendered from the yaml or the traffic spec
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Param 	body 		body 		service.IstioRoutingArgs 		true 	"body"
+// @Success 200 		{array} 	service.IstioRoutingObject
+// @Router /api/aslan/environment/environments/{name}/istio/routings [put]
+func ApplyIstioRouting(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.IstioRoutingArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "更新", "环境-Istio路由", envName, "", ctx.Logger, envName)
+
+	ctx.Resp, ctx.RespErr = service.ApplyIstioRouting(projectKey, envName, production, args, ctx.UserName, ctx.Logger)
+}
+
+// @Summary Delete Istio Routing
+// @Description Delete the routing object from the environment namespace
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	kind		path		string							true	"kind of the object"
+// @Param 	objectName	path		string							true	"name of the object"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/istio/routings/{kind}/{objectName} [delete]
+func DeleteIstioRouting(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+	kind := c.Param("kind")
+	objectName := c.Param("objectName")
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "删除", "环境-Istio路由", fmt.Sprintf("%s:%s/%s", envName, kind, objectName), "", ctx.Logger, envName)
+
+	ctx.RespErr = service.DeleteIstioRouting(projectKey, envName, production, kind, objectName, ctx.UserName, ctx.Logger)
+}
+
+// @Summary List Istio Routing Revisions
+// @Description List the revisions of the routing object applied through zadig
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	kind		path		string							true	"kind of the object"
+// @Param 	objectName	path		string							true	"name of the object"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Success 200 		{array} 	commonmodels.IstioRoutingRevision
+// @Router /api/aslan/environment/environments/{name}/istio/routings/{kind}/{objectName}/revisions [get]
+func ListIstioRoutingRevisions(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListIstioRoutingRevisions(projectKey, envName, production, c.Param("kind"), c.Param("objectName"))
+}
+
+// @Summary Rollback Istio Routing
+// @Description Apply the content of the given revision to the routing object
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"env name"
+// @Param 	kind		path		string							true	"kind of the object"
+// @Param 	objectName	path		string							true	"name of the object"
+// @Param 	revision	path		int								true	"revision"
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	production	query		bool							true	"is production"
+// @Success 200 		{object} 	service.IstioRoutingObject
+// @Router /api/aslan/environment/environments/{name}/istio/routings/{kind}/{objectName}/revisions/{revision}/rollback [post]
+func RollbackIstioRouting(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	revision, err := strconv.ParseInt(c.Param("revision"), 10, 64)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid revision")
+		return
+	}
+	args := &service.IstioRoutingRollbackArgs{
+		Kind:     c.Param("kind"),
+		Name:     c.Param("objectName"),
+		Revision: revision,
+	}
+
+	if !checkEnvPermission(ctx, projectKey, envName, production, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "回滚", "环境-Istio路由", fmt.Sprintf("%s:%s/%s", envName, args.Kind, args.Name), "", ctx.Logger, envName)
+
+	ctx.Resp, ctx.RespErr = service.RollbackIstioRouting(projectKey, envName, production, args, ctx.UserName, ctx.Logger)
+}
@@ -864,3 +864,86 @@ func OpenAPIListKubeEvents(c *gin.Context) {
 	ctx.Resp = resp
 	return
 }
+
+// @Summary Get Service Event Timeline
+// @Description Aggregate the kubernetes events of all the resources of the service and the zadig deployments into a timeline
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name 			path		string		true	"env name"
+// @Param 	serviceName 	path		string		true	"service name"
+// @Param 	projectName		query		string		true	"project name"
+// @Param 	production		query		bool		false	"is production env"
+// @Param 	severity		query		string		false	"Normal or Warning"
+// @Param 	startTime		query		int			false	"start time"
+// @Param 	endTime			query		int			false	"end time"
+// @Success 200 			{array} 	service.ServiceTimelineEvent
+// @Router /api/aslan/environment/environments/{name}/services/{serviceName}/timeline [get]
+func GetServiceEventTimeline(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(service.ServiceTimelineArgs)
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if args.ProjectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	envName := c.Param("name")
+	if !checkEnvPermission(ctx, args.ProjectName, envName, args.Production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetServiceEventTimeline(envName, c.Param("serviceName"), args, ctx.Logger)
+}
+
+// @Summary Search Env Logs
+// @Description Search the logs of the pods of the selected services in the env, the matched lines are merged and paginated
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	name 			path		string					true	"env name"
+// @Param 	projectName		query		string					true	"project name"
+// @Param 	body 			body 		service.LogSearchArgs 	true 	"body"
+// @Success 200 			{object} 	service.LogSearchResp
+// @Router /api/aslan/environment/environments/{name}/logs/search [post]
+func SearchEnvLogs(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(service.LogSearchArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	envName := c.Param("name")
+	if !checkEnvPermission(ctx, projectKey, envName, args.Production, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.SearchEnvLogs(projectKey, envName, args, ctx.Logger)
+}
@@ -748,7 +748,9 @@ func OpenAPICreateK8sEnv(c *gin.Context) {
 		}
 	}
 
-	ctx.RespErr = service.OpenAPICreateK8sEnv(args, ctx.UserName, ctx.RequestID, ctx.Logger)
+	ctx.Resp, ctx.RespErr = runIdempotentOpenAPIRequest(c, ctx, func() (interface{}, error) {
+		return nil, service.OpenAPICreateK8sEnv(args, ctx.UserName, ctx.RequestID, ctx.Logger)
+	})
 }
 
 func OpenAPIDeleteProductionEnv(c *gin.Context) {
@@ -787,7 +789,9 @@ func OpenAPIDeleteProductionEnv(c *gin.Context) {
 		return
 	}
 
-	ctx.RespErr = service.DeleteProductionProduct(ctx.UserName, envName, projectName, ctx.RequestID, ctx.Logger)
+	ctx.Resp, ctx.RespErr = runIdempotentOpenAPIRequest(c, ctx, func() (interface{}, error) {
+		return nil, service.DeleteProductionProduct(ctx.UserName, envName, projectName, ctx.RequestID, ctx.Logger)
+	})
 }
 
 func OpenAPICreateProductionEnv(c *gin.Context) {
@@ -839,7 +843,9 @@ func OpenAPICreateProductionEnv(c *gin.Context) {
 		return
 	}
 
-	ctx.RespErr = service.OpenAPICreateProductionEnv(args, ctx.UserName, ctx.RequestID, ctx.Logger)
+	ctx.Resp, ctx.RespErr = runIdempotentOpenAPIRequest(c, ctx, func() (interface{}, error) {
+		return nil, service.OpenAPICreateProductionEnv(args, ctx.UserName, ctx.RequestID, ctx.Logger)
+	})
 }
 
 func OpenAPIDeleteEnv(c *gin.Context) {
@@ -877,7 +883,9 @@ func OpenAPIDeleteEnv(c *gin.Context) {
 		}
 	}
 
-	ctx.RespErr = service.DeleteProduct(ctx.UserName, envName, projectName, ctx.RequestID, isDelete, ctx.Logger)
+	ctx.Resp, ctx.RespErr = runIdempotentOpenAPIRequest(c, ctx, func() (interface{}, error) {
+		return nil, service.DeleteProduct(ctx.UserName, envName, projectName, ctx.RequestID, isDelete, ctx.Logger)
+	})
 }
 
 func OpenAPIGetEnvDetail(c *gin.Context) {
@@ -1731,3 +1739,379 @@ func OpenAPISetPortalService(c *gin.Context) {
 	ctx.RespErr = service.SetupPortalService(c, projectKey, envName, serviceName, origReq)
 	return
 }
+
+// @Summary OpenAPI Sleep Environment
+// @Description OpenAPI Sleep Environment, the request is run only once for the same Idempotency-Key header in 24 hours
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string		true	"project key"
+// @Param 	name 			path		string		true	"env name"
+// @Param 	Idempotency-Key	header		string		false	"idempotency key"
+// @Success 200
+// @Router /openapi/environments/{name}/sleep [post]
+func OpenAPISleepEnv(c *gin.Context) {
+	openAPISetEnvSleep(c, false, true)
+}
+
+// @Summary OpenAPI Wake Up Environment
+// @Description OpenAPI Wake Up Environment, the request is run only once for the same Idempotency-Key header in 24 hours
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string		true	"project key"
+// @Param 	name 			path		string		true	"env name"
+// @Param 	Idempotency-Key	header		string		false	"idempotency key"
+// @Success 200
+// @Router /openapi/environments/{name}/wakeup [post]
+func OpenAPIWakeupEnv(c *gin.Context) {
+	openAPISetEnvSleep(c, false, false)
+}
+
+// @Summary OpenAPI Sleep Production Environment
+// @Description OpenAPI Sleep Production Environment, the request is run only once for the same Idempotency-Key header in 24 hours
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string		true	"project key"
+// @Param 	name 			path		string		true	"env name"
+// @Param 	Idempotency-Key	header		string		false	"idempotency key"
+// @Success 200
+// @Router /openapi/environments/production/{name}/sleep [post]
+func OpenAPISleepProductionEnv(c *gin.Context) {
+	openAPISetEnvSleep(c, true, true)
+}
+
+// @Summary OpenAPI Wake Up Production Environment
+// @Description OpenAPI Wake Up Production Environment, the request is run only once for the same Idempotency-Key header in 24 hours
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string		true	"project key"
+// @Param 	name 			path		string		true	"env name"
+// @Param 	Idempotency-Key	header		string		false	"idempotency key"
+// @Success 200
+// @Router /openapi/environments/production/{name}/wakeup [post]
+func OpenAPIWakeupProductionEnv(c *gin.Context) {
+	openAPISetEnvSleep(c, true, false)
+}
+
+func openAPISetEnvSleep(c *gin.Context, production, sleep bool) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectName, envName, err := generalOpenAPIRequestValidate(c)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	method := "唤醒"
+	if sleep {
+		method = "睡眠"
+	}
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationSceneEnv, "(OpenAPI)"+method, "环境", envName, "", ctx.Logger, envName)
+
+	if !checkOpenAPIEnvEditPermission(ctx, projectName, envName, production) {
+		ctx.UnAuthorized = true
+		return
+	}
+	if production {
+		if err := commonutil.CheckZadigProfessionalLicense(); err != nil {
+			ctx.RespErr = err
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = runIdempotentOpenAPIRequest(c, ctx, func() (interface{}, error) {
+		return nil, service.EnvSleep(projectName, envName, sleep, production, ctx.Logger)
+	})
+}
+
+// @Summary OpenAPI Delete Service From Environment
+// @Description OpenAPI Delete a single service from the environment, works for both k8s yaml and helm projects
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string		true	"project key"
+// @Param 	name 			path		string		true	"env name"
+// @Param 	serviceName		path		string		true	"service name"
+// @Param 	Idempotency-Key	header		string		false	"idempotency key"
+// @Success 200
+// @Router /openapi/environments/{name}/services/{serviceName} [delete]
+func OpenAPIDeleteEnvService(c *gin.Context) {
+	openAPIDeleteEnvService(c, false)
+}
+
+// @Summary OpenAPI Delete Service From Production Environment
+// @Description OpenAPI Delete a single service from the production environment, works for both k8s yaml and helm projects
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string		true	"project key"
+// @Param 	name 			path		string		true	"env name"
+// @Param 	serviceName		path		string		true	"service name"
+// @Param 	Idempotency-Key	header		string		false	"idempotency key"
+// @Success 200
+// @Router /openapi/environments/production/{name}/services/{serviceName} [delete]
+func OpenAPIDeleteProductionEnvService(c *gin.Context) {
+	openAPIDeleteEnvService(c, true)
+}
+
+func openAPIDeleteEnvService(c *gin.Context, production bool) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectName, envName, err := generalOpenAPIRequestValidate(c)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	serviceName := c.Param("serviceName")
+	if serviceName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("serviceName is empty")
+		return
+	}
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationSceneEnv, "(OpenAPI)"+"删除", "环境的服务", fmt.Sprintf("%s:[%s]", envName, serviceName), "", ctx.Logger, envName)
+
+	if !checkOpenAPIEnvEditPermission(ctx, projectName, envName, production) {
+		ctx.UnAuthorized = true
+		return
+	}
+	if production {
+		if err := commonutil.CheckZadigProfessionalLicense(); err != nil {
+			ctx.RespErr = err
+			return
+		}
+	} else {
+		svcsInSubEnvs, err := service.CheckServicesDeployedInSubEnvs(c, projectName, envName, []string{serviceName})
+		if err != nil {
+			ctx.RespErr = err
+			return
+		}
+		if len(svcsInSubEnvs) > 0 {
+			data := make(map[string]interface{}, len(svcsInSubEnvs))
+			for k, v := range svcsInSubEnvs {
+				data[k] = v
+			}
+			ctx.RespErr = e.NewWithExtras(e.ErrDeleteSvcHasSvcsInSubEnv, "", data)
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = runIdempotentOpenAPIRequest(c, ctx, func() (interface{}, error) {
+		return nil, service.DeleteProductServices(ctx.UserName, ctx.RequestID, envName, projectName, []string{serviceName}, production, ctx.Logger)
+	})
+}
+
+// @Summary OpenAPI Update Helm Service Values
+// @Description OpenAPI Update the values of a helm service in the environment, serviceName is the release name for the charts deployed directly
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string										true	"project key"
+// @Param 	name 			path		string										true	"env name"
+// @Param 	serviceName		path		string										true	"service name"
+// @Param 	Idempotency-Key	header		string										false	"idempotency key"
+// @Param 	body 			body 		service.OpenAPIUpdateHelmServiceValuesReq 	true 	"body"
+// @Success 200
+// @Router /openapi/environments/{name}/services/{serviceName}/values [put]
+func OpenAPIUpdateHelmServiceValues(c *gin.Context) {
+	openAPIUpdateHelmServiceValues(c, false)
+}
+
+// @Summary OpenAPI Update Production Helm Service Values
+// @Description OpenAPI Update the values of a helm service in the production environment, serviceName is the release name for the charts deployed directly
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string										true	"project key"
+// @Param 	name 			path		string										true	"env name"
+// @Param 	serviceName		path		string										true	"service name"
+// @Param 	Idempotency-Key	header		string										false	"idempotency key"
+// @Param 	body 			body 		service.OpenAPIUpdateHelmServiceValuesReq 	true 	"body"
+// @Success 200
+// @Router /openapi/environments/production/{name}/services/{serviceName}/values [put]
+func OpenAPIUpdateProductionHelmServiceValues(c *gin.Context) {
+	openAPIUpdateHelmServiceValues(c, true)
+}
+
+func openAPIUpdateHelmServiceValues(c *gin.Context, production bool) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectName, envName, err := generalOpenAPIRequestValidate(c)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	serviceName := c.Param("serviceName")
+	if serviceName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("serviceName is empty")
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	req := new(service.OpenAPIUpdateHelmServiceValuesReq)
+	if err = json.Unmarshal(data, req); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationSceneEnv, "(OpenAPI)"+"更新", "环境-服务values", fmt.Sprintf("%s:%s", envName, serviceName), string(data), ctx.Logger, envName)
+
+	if !checkOpenAPIEnvEditPermission(ctx, projectName, envName, production) {
+		ctx.UnAuthorized = true
+		return
+	}
+	if production {
+		if err := commonutil.CheckZadigProfessionalLicense(); err != nil {
+			ctx.RespErr = err
+			return
+		}
+	}
+
+	canOverrideLockedValues := ctx.Resources.IsSystemAdmin || ctx.Resources.ProjectAuthInfo[projectName].IsProjectAdmin
+	ctx.Resp, ctx.RespErr = runIdempotentOpenAPIRequest(c, ctx, func() (interface{}, error) {
+		return nil, service.OpenAPIUpdateHelmServiceValues(projectName, envName, serviceName, ctx.UserName, ctx.RequestID, req, production, canOverrideLockedValues, ctx.Logger)
+	})
+}
+
+// checkOpenAPIEnvEditPermission checks the permission to edit the config of the env, the same as the UI APIs
+func checkOpenAPIEnvEditPermission(ctx *internalhandler.Context, projectName, envName string, production bool) bool {
+	if ctx.Resources.IsSystemAdmin {
+		return true
+	}
+	projectAuthInfo, ok := ctx.Resources.ProjectAuthInfo[projectName]
+	if !ok {
+		return false
+	}
+	if projectAuthInfo.IsProjectAdmin {
+		return true
+	}
+
+	if production {
+		if projectAuthInfo.ProductionEnv.EditConfig {
+			return true
+		}
+		permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectName, types.ResourceTypeEnvironment, envName, types.ProductionEnvActionEditConfig)
+		return err == nil && permitted
+	}
+
+	if projectAuthInfo.Env.EditConfig {
+		return true
+	}
+	permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectName, types.ResourceTypeEnvironment, envName, types.EnvActionEditConfig)
+	return err == nil && permitted
+}
+
+// runIdempotentOpenAPIRequest runs fn only once for the Idempotency-Key header, the retries get the result of the first request
+func runIdempotentOpenAPIRequest(c *gin.Context, ctx *internalhandler.Context, fn func() (interface{}, error)) (interface{}, error) {
+	return service.RunIdempotentOpenAPIRequest(ctx.UserID, c.GetHeader("Idempotency-Key"), c.Request.Method+" "+c.Request.URL.RequestURI(), fn, ctx.Logger)
+}
+
+// @Summary OpenAPI Apply Environment
+// @Description OpenAPI Apply the full desired spec of a k8s yaml environment, the environment is created if it doesn't exist, otherwise
+// @Description the services are created, updated or deleted to match the spec. The change plan is returned and dry_run only returns the plan.
+// @Tags 	OpenAPI
+// @Accept 	json
+// @Produce json
+// @Param 	projectKey		query		string							true	"project key"
+// @Param 	Idempotency-Key	header		string							false	"idempotency key"
+// @Param 	body 			body 		service.OpenAPIApplyEnvArgs 	true 	"body"
+// @Success 200 			{object} 	service.EnvApplyPlan
+// @Router /openapi/environments/apply [post]
+func OpenAPIApplyEnvironment(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args := new(service.OpenAPIApplyEnvArgs)
+	if err = json.Unmarshal(data, args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args.ProjectName = c.Query("projectKey")
+	if err := args.Validate(); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	plan, err := service.PlanEnvironmentApply(args, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+
+	// authorization checks
+	if plan.Action == service.EnvApplyActionCreate {
+		if !ctx.Resources.IsSystemAdmin {
+			projectAuthInfo, ok := ctx.Resources.ProjectAuthInfo[args.ProjectName]
+			if !ok {
+				ctx.UnAuthorized = true
+				return
+			}
+			if !projectAuthInfo.IsProjectAdmin &&
+				!(args.Production && projectAuthInfo.ProductionEnv.Create) &&
+				!(!args.Production && projectAuthInfo.Env.Create) {
+				ctx.UnAuthorized = true
+				return
+			}
+		}
+	} else if !checkOpenAPIEnvEditPermission(ctx, args.ProjectName, args.EnvName, args.Production) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	if args.DryRun || plan.Action == service.EnvApplyActionNone {
+		ctx.Resp = plan
+		return
+	}
+
+	if args.Production {
+		if err := commonutil.CheckZadigProfessionalLicense(); err != nil {
+			ctx.RespErr = err
+			return
+		}
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, args.ProjectName, setting.OperationSceneEnv, "(OpenAPI)"+"应用", "环境配置", args.EnvName, string(data), ctx.Logger, args.EnvName)
+
+	ctx.Resp, ctx.RespErr = runIdempotentOpenAPIRequest(c, ctx, func() (interface{}, error) {
+		if err := service.ApplyEnvironmentPlan(args, plan, ctx.UserName, ctx.RequestID, ctx.Logger); err != nil {
+			return nil, err
+		}
+		return plan, nil
+	})
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Create Port Forward Session
+// @Description Create a temporary port forward session to a service or pod port in the env
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	body 		body 		service.CreatePortForwardArgs 		true 	"body"
+// @Success 200 		{object} 	commonmodels.PortForwardSession
+// @Router /api/aslan/environment/portforward [post]
+func CreatePortForwardSession(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(service.CreatePortForwardArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	projectKey := c.Query("projectName")
+	if projectKey == "" || args.EnvName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName and env_name can't be empty")
+		return
+	}
+
+	if !internalhandler.CheckEnvDebugPermission(ctx, projectKey, args.EnvName, args.Production) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	target := args.PodName
+	if args.ServiceName != "" {
+		target = args.ServiceName
+	}
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "新建", "环境-端口转发", fmt.Sprintf("%s:%d", target, args.Port), "", ctx.Logger, args.EnvName)
+
+	ctx.Resp, ctx.RespErr = service.CreatePortForwardSession(projectKey, ctx.UserName, args, ctx.Logger)
+}
+
+type listPortForwardSessionsQuery struct {
+	ProjectName string `form:"projectName"`
+	EnvName     string `form:"envName"`
+	PageNum     int64  `form:"pageNum"`
+	PageSize    int64  `form:"pageSize"`
+}
+
+type listPortForwardSessionsResp struct {
+	Sessions interface{} `json:"sessions"`
+	Total    int64       `json:"total"`
+}
+
+// @Summary List Port Forward Sessions
+// @Description List the port forward sessions of the project, the non admin user can only see the sessions created by themselves
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string							true	"project name"
+// @Param 	envName		query		string							false	"env name"
+// @Param 	pageNum		query		int								false	"page num"
+// @Param 	pageSize	query		int								false	"page size"
+// @Success 200 		{object} 	listPortForwardSessionsResp
+// @Router /api/aslan/environment/portforward [get]
+func ListPortForwardSessions(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	query := new(listPortForwardSessionsQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if query.ProjectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+
+	opt := &commonrepo.ListPortForwardSessionOption{
+		ProjectName: query.ProjectName,
+		EnvName:     query.EnvName,
+		PageNum:     query.PageNum,
+		PageSize:    query.PageSize,
+	}
+	if !internalhandler.IsProjectAdmin(ctx, query.ProjectName) {
+		if _, ok := ctx.Resources.ProjectAuthInfo[query.ProjectName]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		opt.UserName = ctx.UserName
+	}
+
+	sessions, total, err := service.ListPortForwardSessions(opt, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	ctx.Resp = &listPortForwardSessionsResp{
+		Sessions: sessions,
+		Total:    total,
+	}
+}
+
+// @Summary Close Port Forward Session
+// @Description Close the port forward session, the connections of it are closed too
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	id			path		string		true	"session id"
+// @Success 200
+// @Router /api/aslan/environment/portforward/{id} [delete]
+func ClosePortForwardSession(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	session, err := service.GetPortForwardSession(c.Param("id"))
+	if err != nil {
+		ctx.RespErr = e.ErrClosePortForward.AddErr(err)
+		return
+	}
+	if session.UserName != ctx.UserName && !internalhandler.IsProjectAdmin(ctx, session.ProjectName) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, session.ProjectName, "关闭", "环境-端口转发", fmt.Sprintf("%s:%d", session.PodName, session.Port), "", ctx.Logger)
+
+	ctx.RespErr = service.ClosePortForwardSession(session, ctx.Logger)
+}
+
+// @Summary Connect Port Forward Session
+// @Description Upgrade to websocket and forward the binary messages to the port of the session, only the creator of the session can connect
+// @Tags 	environment
+// @Param 	id			path		string		true	"session id"
+// @Router /api/aslan/environment/portforward/{id}/connect [get]
+func ConnectPortForwardSession(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	session, err := service.GetPortForwardSession(c.Param("id"))
+	if err != nil {
+		ctx.RespErr = e.ErrConnectPortForward.AddErr(err)
+		return
+	}
+	if session.UserName != ctx.UserName {
+		ctx.UnAuthorized = true
+		return
+	}
+	// the permission may be revoked after the session is created
+	if !internalhandler.CheckEnvDebugPermission(ctx, session.ProjectName, session.EnvName, session.Production) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.RespErr = service.ConnectPortForwardSession(c, session, ctx.Logger)
+}
@@ -68,6 +68,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	productDiff := router.Group("diff")
 	{
 		productDiff.GET("/products/:productName/service/:serviceName", ServiceDiff)
+		productDiff.GET("/products/:productName/env/:envName", EnvTemplateDiff)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -132,6 +133,44 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		operations.GET("", GetOperationLogs)
 	}
 
+	// ---------------------------------------------------------------------------------------
+	// 环境版本晋级接口
+	// ---------------------------------------------------------------------------------------
+	promotions := router.Group("promotions")
+	{
+		promotions.POST("/preview", PreviewEnvPromotion)
+		promotions.POST("", CreateEnvPromotion)
+		promotions.GET("", ListEnvPromotions)
+		promotions.GET("/:id", GetEnvPromotion)
+		promotions.POST("/:id/approve", ApproveEnvPromotion)
+		promotions.POST("/:id/rollback", RollbackEnvPromotion)
+	}
+
+	// ---------------------------------------------------------------------------------------
+	// 端口转发接口
+	// ---------------------------------------------------------------------------------------
+	portForward := router.Group("portforward")
+	{
+		portForward.POST("", CreatePortForwardSession)
+		portForward.GET("", ListPortForwardSessions)
+		portForward.DELETE("/:id", ClosePortForwardSession)
+		portForward.GET("/:id/connect", ConnectPortForwardSession)
+	}
+
+	changelog := router.Group("changelog")
+	{
+		changelog.POST("", GetEnvChangelog)
+	}
+
+	// ---------------------------------------------------------------------------------------
+	// 生产环境工作负载策略接口
+	// ---------------------------------------------------------------------------------------
+	workloadPolicy := router.Group("workload/policy")
+	{
+		workloadPolicy.GET("", GetEnvWorkloadPolicy)
+		workloadPolicy.PUT("", UpdateEnvWorkloadPolicy)
+	}
+
 	// ---------------------------------------------------------------------------------------
 	// 产品管理接口(环境)
 	// ---------------------------------------------------------------------------------------
@@ -146,6 +185,10 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		environments.GET("/:name", GetEnvironment)
 		environments.PUT("/:name/envRecycle", UpdateProductRecycleDay)
 		environments.PUT("/:name/alias", UpdateProductAlias)
+		environments.PUT("/:name/imageSigningPolicy", SetEnvImageSigningPolicy)
+		environments.GET("/:name/externalVariable", GetEnvExternalVariable)
+		environments.PUT("/:name/externalVariable", UpdateEnvExternalVariable)
+		environments.POST("/:name/externalVariable/test", TestEnvExternalVariable)
 		environments.POST("/:name/affectedservices", AffectedServices)
 		environments.POST("/:name/estimated-values", EstimatedValues)
 
@@ -161,18 +204,59 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		environments.DELETE("/:name", DeleteProduct)
 		environments.GET("/:name/groups", ListGroups)
 		environments.GET("/:name/workloads", ListWorkloadsInEnv)
+		environments.GET("/:name/orphans", ListOrphanResources)
+		environments.GET("/:name/lock", GetEnvLockStatus)
+		environments.POST("/:name/orphans/clean", CleanOrphanResources)
+		environments.GET("/:name/istio/routings", ListIstioRoutings)
+		environments.PUT("/:name/istio/routings", ApplyIstioRouting)
+		environments.POST("/:name/istio/routings/preview", PreviewIstioRouting)
+		environments.DELETE("/:name/istio/routings/:kind/:objectName", DeleteIstioRouting)
+		environments.GET("/:name/istio/routings/:kind/:objectName/revisions", ListIstioRoutingRevisions)
+		environments.POST("/:name/istio/routings/:kind/:objectName/revisions/:revision/rollback", RollbackIstioRouting)
+		environments.GET("/:name/gateway/routes", ListGatewayRoutes)
+		environments.PUT("/:name/gateway/routes", ApplyGatewayRoutes)
+		environments.POST("/:name/gateway/routes/preview", PreviewGatewayRoutes)
+		environments.DELETE("/:name/gateway/routes/:routeName", DeleteGatewayRoute)
+		environments.GET("/:name/certificates", ListEnvCertificates)
+		environments.GET("/:name/health", GetEnvHealth)
+		environments.GET("/:name/alerts", ListEnvAlerts)
+		environments.GET("/:name/dns/records", ListEnvDNSRecords)
+		environments.POST("/:name/dns/sync", SyncEnvDNSRecords)
+		environments.GET("/:name/mirrors", ListTrafficMirrors)
+		environments.POST("/:name/mirrors", CreateTrafficMirror)
+		environments.DELETE("/:name/mirrors/:id", DeleteTrafficMirror)
+		environments.GET("/:name/snapshots", ListEnvDebugSnapshots)
+		environments.POST("/:name/snapshots", CreateEnvDebugSnapshot)
+		environments.GET("/:name/snapshots/:id/download", DownloadEnvDebugSnapshot)
 
 		environments.GET("/:name/helm/releases", ListReleases)
 		environments.DELETE("/:name/helm/releases", DeleteHelmReleases)
+		environments.GET("/:name/helm/releases/:releaseName/history", ListHelmReleaseHistory)
+		environments.POST("/:name/helm/releases/:releaseName/rollback", RollbackHelmRelease)
 		environments.GET("/:name/helm/values", GetChartValues)
+		environments.GET("/:name/helm/postRenderer", GetHelmPostRenderer)
+		environments.PUT("/:name/helm/postRenderer", UpdateHelmPostRenderer)
+		environments.GET("/:name/autoUpgrade", GetEnvAutoUpgradePolicy)
+		environments.PUT("/:name/autoUpgrade", UpdateEnvAutoUpgradePolicy)
+		environments.POST("/:name/sharedServices/:serviceName", DeploySharedService)
+		environments.POST("/:name/helm/values/layers", GetHelmValuesLayers)
 		environments.GET("/:name/helm/charts", GetChartInfos)
 		environments.GET("/:name/helm/images", GetImageInfos)
 
 		environments.GET("/:name/services", ListSvcsInEnv)
 		environments.PUT("/:name/services", DeleteProductServices)
+		environments.POST("/:name/services/deletion/analysis", AnalyzeServiceDeletion)
 		environments.GET("/:name/services/:serviceName", GetService)
 		environments.PUT("/:name/services/:serviceName", UpdateService)
+		environments.PUT("/:name/services/:serviceName/gitRef", SetServiceGitRef)
+		environments.GET("/:name/services/:serviceName/schedulingHints", GetServiceSchedulingHints)
+		environments.PUT("/:name/services/:serviceName/schedulingHints", UpdateServiceSchedulingHints)
 		environments.GET("/:name/services/:serviceName/yaml", FetchServiceYaml)
+		environments.GET("/:name/services/:serviceName/timeline", GetServiceEventTimeline)
+		environments.GET("/:name/services/:serviceName/autoscalers", ListServiceAutoscalers)
+		environments.PUT("/:name/services/:serviceName/autoscalers", UpsertServiceAutoscaler)
+		environments.DELETE("/:name/services/:serviceName/autoscalers/:id", DeleteServiceAutoscaler)
+		environments.POST("/:name/logs/search", SearchEnvLogs)
 		environments.POST("/:name/services/:serviceName/preview", PreviewService)
 		environments.POST("/:name/services/preview/batch", BatchPreviewServices)
 		environments.POST("/:name/services/:serviceName/restart", RestartService)
@@ -208,6 +292,11 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		environments.GET("/:name/analysis/cron", GetEnvAnalysisCron)
 		environments.PUT("/:name/analysis/cron", UpsertEnvAnalysisCron)
 		environments.GET("/analysis/history", GetEnvAnalysisHistory)
+		environments.GET("/:name/analysis/issues", ListEnvAnalysisIssues)
+		environments.POST("/:name/analysis/issues", CreateEnvAnalysisIssue)
+
+		environments.POST("/:name/resources/recommendations", GetEnvResourceRecommendations)
+		environments.POST("/:name/resources/recommendations/apply", ApplyResourceRecommendation)
 
 		environments.POST("/:name/sleep", EnvSleep)
 		environments.GET("/:name/sleep/cron", GetEnvSleepCron)
@@ -288,6 +377,7 @@ func (*OpenAPIRouter) Inject(router *gin.RouterGroup) {
 		common.GET("/:name", OpenAPIGetEnvDetail)
 		common.PUT("/:name", OpenAPIUpdateEnvBasicInfo)
 
+		common.POST("/apply", OpenAPIApplyEnvironment)
 		common.POST("/scale", OpenAPIScaleWorkloads)
 		common.POST("/service/yaml", OpenAPIApplyYamlService)
 		common.DELETE("/service/yaml", OpenAPIDeleteYamlServiceFromEnv)
@@ -304,6 +394,11 @@ func (*OpenAPIRouter) Inject(router *gin.RouterGroup) {
 
 		common.GET("/:name/services/:serviceName", OpenAPIGetService)
 		common.POST("/:name/service/:serviceName/restart", OpenAPIRestartService)
+		common.DELETE("/:name/services/:serviceName", OpenAPIDeleteEnvService)
+		common.PUT("/:name/services/:serviceName/values", OpenAPIUpdateHelmServiceValues)
+
+		common.POST("/:name/sleep", OpenAPISleepEnv)
+		common.POST("/:name/wakeup", OpenAPIWakeupEnv)
 
 		common.GET("/:name/check/workloads/k8services", OpenAPICheckWorkloadsK8sServices)
 		common.POST("/:name/share/enable", OpenAPIEnableBaseEnv)
@@ -337,6 +432,11 @@ func (*OpenAPIRouter) Inject(router *gin.RouterGroup) {
 
 		production.GET("/:name/services/:serviceName", OpenAPIGetProductionService)
 		production.POST("/:name/service/:serviceName/restart", OpenAPIProductionRestartService)
+		production.DELETE("/:name/services/:serviceName", OpenAPIDeleteProductionEnvService)
+		production.PUT("/:name/services/:serviceName/values", OpenAPIUpdateProductionHelmServiceValues)
+
+		production.POST("/:name/sleep", OpenAPISleepProductionEnv)
+		production.POST("/:name/wakeup", OpenAPIWakeupProductionEnv)
 	}
 
 	kube := router.Group("kube")
@@ -23,6 +23,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/types"
 
 	"github.com/gin-gonic/gin"
+	templatemodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
 	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
 	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
@@ -347,6 +348,7 @@ func BatchPreviewServices(c *gin.Context) {
 // @Param 	projectName		query		string								true	"project name"
 // @Param 	name 			path		string								true	"env name"
 // @Param 	serviceName	 	path		string								true	"service name"
+// @Param 	If-Match	header		string							true	"resource version of the env"
 // @Param 	body 			body 		service.SvcRevision 				true 	"body"
 // @Success 200
 // @Router /api/aslan/environment/environments/{name}/services/{serviceName} [put]
@@ -421,9 +423,212 @@ func UpdateService(c *gin.Context) {
 		UpdateServiceTmpl: svcRev.UpdateServiceTmpl,
 	}
 
+	if !checkProductionDeployFreeze(c, ctx, production) {
+		return
+	}
+
+	release, ok := claimEnvResourceVersion(c, ctx, projectKey, envName, production)
+	if !ok {
+		return
+	}
+	defer release()
+
 	ctx.RespErr = service.UpdateService(args, ctx.Logger)
 }
 
+// @Summary Set service git ref
+// @Description Pin the service of the env to a branch or tag, the service is updated when the service template is synced from the ref
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName		query		string								true	"project name"
+// @Param 	name 			path		string								true	"env name"
+// @Param 	serviceName	 	path		string								true	"service name"
+// @Param 	production		query		bool								false	"is production env"
+// @Param 	body 			body 		service.SetServiceGitRefArgs 		true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/services/{serviceName}/gitRef [put]
+func SetServiceGitRef(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	serviceName := c.Param("serviceName")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(service.SetServiceGitRefArgs)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv,
+		"更新", "环境-服务Git引用", fmt.Sprintf("环境名称:%s,服务名称:%s,引用:%s", envName, serviceName, args.GitRef),
+		"", ctx.Logger, envName)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if production {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].ProductionEnv.EditConfig {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.ProductionEnvActionEditConfig)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		} else {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].Env.EditConfig {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.EnvActionEditConfig)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		}
+	}
+
+	ctx.RespErr = service.SetServiceGitRef(projectKey, envName, serviceName, args.GitRef, production)
+}
+
+// @Summary Get service scheduling hints
+// @Description Get the nodeSelector, tolerations and affinity injected into the workloads of the service in the env
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName		query		string								true	"project name"
+// @Param 	name 			path		string								true	"env name"
+// @Param 	serviceName	 	path		string								true	"service name or release name"
+// @Param 	production		query		bool								false	"is production env"
+// @Success 200 			{object} 	templatemodels.SchedulingHints
+// @Router /api/aslan/environment/environments/{name}/services/{serviceName}/schedulingHints [get]
+func GetServiceSchedulingHints(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	serviceName := c.Param("serviceName")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if production {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].ProductionEnv.View {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.ProductionEnvActionView)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		} else {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].Env.View {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.EnvActionView)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetServiceSchedulingHints(projectKey, envName, serviceName, production)
+}
+
+// @Summary Update service scheduling hints
+// @Description Update the nodeSelector, tolerations and affinity injected into the workloads of the service in the env, they take effect on the following deployments
+// @Tags 	environment
+// @Accept 	json
+// @Produce json
+// @Param 	projectName		query		string								true	"project name"
+// @Param 	name 			path		string								true	"env name"
+// @Param 	serviceName	 	path		string								true	"service name or release name"
+// @Param 	production		query		bool								false	"is production env"
+// @Param 	body 			body 		templatemodels.SchedulingHints 		true 	"body"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/services/{serviceName}/schedulingHints [put]
+func UpdateServiceSchedulingHints(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	serviceName := c.Param("serviceName")
+	projectKey := c.Query("projectName")
+	production := c.Query("production") == "true"
+
+	args := new(templatemodels.SchedulingHints)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv,
+		"更新", "环境-服务调度配置", fmt.Sprintf("环境名称:%s,服务名称:%s", envName, serviceName),
+		"", ctx.Logger, envName)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if production {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].ProductionEnv.EditConfig {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.ProductionEnvActionEditConfig)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		} else {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].Env.EditConfig {
+				permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, types.EnvActionEditConfig)
+				if err != nil || !permitted {
+					ctx.UnAuthorized = true
+					return
+				}
+			}
+		}
+	}
+
+	ctx.RespErr = service.UpdateServiceSchedulingHints(projectKey, envName, serviceName, production, args)
+}
+
 func RestartWorkload(c *gin.Context) {
 	ctx, err := internalhandler.NewContextWithAuthorization(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+)
+
+// @Summary Deploy shared service
+// @Description Deploy the service referenced from another project into the non-production env with the revision pinned by the project
+// @Tags 	environment
+// @Produce json
+// @Param 	projectName	query		string		true	"project name"
+// @Param 	name		path		string		true	"env name"
+// @Param 	serviceName	path		string		true	"service name"
+// @Success 200
+// @Router /api/aslan/environment/environments/{name}/sharedServices/{serviceName} [post]
+func DeploySharedService(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	envName := c.Param("name")
+	serviceName := c.Param("serviceName")
+	projectKey := c.Query("projectName")
+
+	if !checkEnvPermission(ctx, projectKey, envName, false, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectKey, setting.OperationSceneEnv, "部署", "环境-共享服务", fmt.Sprintf("%s:%s", envName, serviceName), "", ctx.Logger, envName)
+
+	ctx.RespErr = service.DeploySharedService(projectKey, envName, serviceName, ctx.UserName, ctx.Logger)
+}
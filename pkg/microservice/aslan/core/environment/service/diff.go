@@ -18,14 +18,20 @@ package service
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/pmezard/go-difflib/difflib"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
 
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/repository"
 	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
 	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/util/converter"
 )
 
 type SvcDiffResult struct {
@@ -36,6 +42,8 @@ type SvcDiffResult struct {
 	Current           TmplYaml `json:"current"`
 	Latest            TmplYaml `json:"latest"`
 	Error             string   `json:"error"`
+	// Diff is the unified diff of the current and latest yaml, only set when the service revision is updated
+	Diff string `json:"diff,omitempty"`
 }
 
 type TmplYaml struct {
@@ -57,8 +65,8 @@ type ConfigTmplData struct {
 func GetServiceDiff(envName, productName, serviceName string, production bool, log *zap.SugaredLogger) (*SvcDiffResult, error) {
 	resp := new(SvcDiffResult)
 	opt := &commonrepo.ProductFindOptions{
-		Name: productName,
-		EnvName: envName,
+		Name:       productName,
+		EnvName:    envName,
 		Production: &production,
 	}
 	productInfo, err := commonrepo.NewProductColl().Find(opt)
@@ -118,3 +126,235 @@ func GetServiceDiff(envName, productName, serviceName string, production bool, l
 	resp.Latest.UpdateBy = newService.CreateBy
 	return resp, nil
 }
+
+// getRevisionBumpDiff returns the diff between the currently applied manifests of the service and the manifests
+// rendered by the new revision, the diff is only used for audit so errors are logged and ignored
+func getRevisionBumpDiff(env *commonmodels.Product, service *commonmodels.ProductService, currentRevision int64, log *zap.SugaredLogger) string {
+	currentYaml, _, err := kube.FetchCurrentAppliedYaml(&kube.GeneSvcYamlOption{
+		ProductName: env.ProductName,
+		EnvName:     env.EnvName,
+		ServiceName: service.ServiceName,
+	})
+	if err != nil {
+		log.Warnf("failed to fetch applied yaml of service %s, err: %s", service.ServiceName, err)
+		return ""
+	}
+
+	latestYaml, err := kube.RenderEnvService(env, service.GetServiceRender(), service)
+	if err != nil {
+		log.Warnf("failed to render yaml of service %s revision %d, err: %s", service.ServiceName, service.Revision, err)
+		return ""
+	}
+
+	return unifiedYamlDiff(currentYaml, latestYaml, revisionLabel(currentRevision), revisionLabel(service.Revision))
+}
+
+func revisionLabel(revision int64) string {
+	return fmt.Sprintf("revision-%d", revision)
+}
+
+func unifiedYamlDiff(currentYaml, latestYaml, currentLabel, latestLabel string) string {
+	if currentYaml == latestYaml {
+		return ""
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(currentYaml),
+		B:        difflib.SplitLines(latestYaml),
+		FromFile: currentLabel,
+		ToFile:   latestLabel,
+		Context:  3,
+	})
+	return diff
+}
+
+type EnvTemplateDiff struct {
+	ProductName string `json:"product_name"`
+	EnvName     string `json:"env_name"`
+	Production  bool   `json:"production"`
+	// MissingServices are the services in the template but not deployed in the env
+	MissingServices []string `json:"missing_services"`
+	// RemovedServices are the services deployed in the env but already removed from the template
+	RemovedServices []string `json:"removed_services"`
+	// Services are the deployed services whose revision or variables differ from the latest template
+	Services []*EnvServiceTemplateDiff `json:"services"`
+	// AddedGlobalVariableKeys are the keys in the template but not in the env
+	AddedGlobalVariableKeys []string `json:"added_global_variable_keys"`
+	// DroppedGlobalVariableKeys are the keys in the env but not in the template, always empty for helm envs
+	DroppedGlobalVariableKeys []string `json:"dropped_global_variable_keys"`
+}
+
+type EnvServiceTemplateDiff struct {
+	ServiceName         string   `json:"service_name"`
+	CurrentRevision     int64    `json:"current_revision"`
+	LatestRevision      int64    `json:"latest_revision"`
+	Outdated            bool     `json:"outdated"`
+	AddedVariableKeys   []string `json:"added_variable_keys"`
+	DroppedVariableKeys []string `json:"dropped_variable_keys"`
+}
+
+// GetEnvTemplateDiff compares the services and variables of the env with the latest template of the project
+func GetEnvTemplateDiff(productName, envName string, production bool, log *zap.SugaredLogger) (*EnvTemplateDiff, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       productName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		log.Errorf("failed to find env %s/%s, error: %s", productName, envName, err)
+		return nil, e.ErrFindProduct.AddErr(err)
+	}
+	templateProduct, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		log.Errorf("failed to find template product %s, error: %s", productName, err)
+		return nil, e.ErrFindProduct.AddErr(err)
+	}
+	latestSvcMap, err := repository.GetMaxRevisionsServicesMap(productName, production)
+	if err != nil {
+		log.Errorf("failed to list latest services of product %s, error: %s", productName, err)
+		return nil, e.ErrListTemplate.AddErr(err)
+	}
+
+	resp := &EnvTemplateDiff{
+		ProductName:               productName,
+		EnvName:                   envName,
+		Production:                production,
+		MissingServices:           make([]string, 0),
+		RemovedServices:           make([]string, 0),
+		Services:                  make([]*EnvServiceTemplateDiff, 0),
+		AddedGlobalVariableKeys:   make([]string, 0),
+		DroppedGlobalVariableKeys: make([]string, 0),
+	}
+
+	templateServices := templateProduct.Services
+	if production {
+		templateServices = templateProduct.ProductionServices
+	}
+	templateSvcSet := sets.NewString()
+	for _, group := range templateServices {
+		templateSvcSet.Insert(group...)
+	}
+
+	envSvcMap := env.GetServiceMap()
+	revisionOption := &commonrepo.SvcRevisionListOption{ProductName: productName}
+	for _, svc := range envSvcMap {
+		// services shared from other projects are not part of the template
+		if svc.ProductName != "" && svc.ProductName != productName {
+			continue
+		}
+		if !templateSvcSet.Has(svc.ServiceName) {
+			resp.RemovedServices = append(resp.RemovedServices, svc.ServiceName)
+			continue
+		}
+		revisionOption.ServiceRevisions = append(revisionOption.ServiceRevisions, &commonrepo.ServiceRevision{
+			ServiceName: svc.ServiceName,
+			Revision:    svc.Revision,
+		})
+	}
+	for _, svcName := range templateSvcSet.List() {
+		if _, ok := envSvcMap[svcName]; !ok {
+			resp.MissingServices = append(resp.MissingServices, svcName)
+		}
+	}
+
+	currentSvcMap := make(map[string]*commonmodels.Service)
+	if len(revisionOption.ServiceRevisions) > 0 {
+		currentServices, err := repository.ListServicesWithSRevision(revisionOption, production)
+		if err != nil {
+			log.Errorf("failed to list current services of env %s/%s, error: %s", productName, envName, err)
+			return nil, e.ErrListTemplate.AddErr(err)
+		}
+		for _, svc := range currentServices {
+			currentSvcMap[svc.ServiceName] = svc
+		}
+	}
+
+	for _, revision := range revisionOption.ServiceRevisions {
+		latestSvc, ok := latestSvcMap[revision.ServiceName]
+		if !ok {
+			continue
+		}
+		envSvc := envSvcMap[revision.ServiceName]
+		svcDiff := &EnvServiceTemplateDiff{
+			ServiceName:     revision.ServiceName,
+			CurrentRevision: envSvc.Revision,
+			LatestRevision:  latestSvc.Revision,
+			Outdated:        envSvc.Revision < latestSvc.Revision,
+		}
+
+		var currentKeys, latestKeys sets.String
+		if templateProduct.IsHelmProduct() {
+			// compare the keys of the chart values between the deployed revision and the latest revision
+			currentKeys, latestKeys = sets.NewString(), helmValuesKeys(latestSvc)
+			if currentSvc, ok := currentSvcMap[revision.ServiceName]; ok {
+				currentKeys = helmValuesKeys(currentSvc)
+			}
+		} else {
+			currentKeys, latestKeys = sets.NewString(), sets.NewString()
+			for _, kv := range envSvc.GetServiceRender().OverrideYaml.RenderVariableKVs {
+				currentKeys.Insert(kv.Key)
+			}
+			for _, kv := range latestSvc.ServiceVariableKVs {
+				latestKeys.Insert(kv.Key)
+			}
+		}
+		svcDiff.AddedVariableKeys = latestKeys.Difference(currentKeys).List()
+		svcDiff.DroppedVariableKeys = currentKeys.Difference(latestKeys).List()
+
+		if svcDiff.Outdated || len(svcDiff.AddedVariableKeys) > 0 || len(svcDiff.DroppedVariableKeys) > 0 {
+			resp.Services = append(resp.Services, svcDiff)
+		}
+	}
+
+	if templateProduct.IsHelmProduct() {
+		// env default values may legally contain more keys than the project default values, so only the added keys are reported
+		templateKeys, err := converter.YamlToFlatMap([]byte(templateProduct.HelmDefaultValues))
+		if err != nil {
+			log.Warnf("failed to parse helm default values of product %s, error: %s", productName, err)
+		}
+		envKeys, err := converter.YamlToFlatMap([]byte(env.DefaultValues))
+		if err != nil {
+			log.Warnf("failed to parse default values of env %s/%s, error: %s", productName, envName, err)
+		}
+		for key := range templateKeys {
+			if _, ok := envKeys[key]; !ok {
+				resp.AddedGlobalVariableKeys = append(resp.AddedGlobalVariableKeys, key)
+			}
+		}
+		sort.Strings(resp.AddedGlobalVariableKeys)
+	} else {
+		templateVariables := templateProduct.GlobalVariables
+		if production {
+			templateVariables = templateProduct.ProductionGlobalVariables
+		}
+		templateKeys, envKeys := sets.NewString(), sets.NewString()
+		for _, kv := range templateVariables {
+			templateKeys.Insert(kv.Key)
+		}
+		for _, kv := range env.GlobalVariables {
+			envKeys.Insert(kv.Key)
+		}
+		resp.AddedGlobalVariableKeys = templateKeys.Difference(envKeys).List()
+		resp.DroppedGlobalVariableKeys = envKeys.Difference(templateKeys).List()
+	}
+
+	sort.Strings(resp.RemovedServices)
+	sort.Slice(resp.Services, func(i, j int) bool {
+		return resp.Services[i].ServiceName < resp.Services[j].ServiceName
+	})
+	return resp, nil
+}
+
+func helmValuesKeys(svc *commonmodels.Service) sets.String {
+	keys := sets.NewString()
+	if svc.HelmChart == nil {
+		return keys
+	}
+	flatMap, err := converter.YamlToFlatMap([]byte(svc.HelmChart.ValuesYaml))
+	if err != nil {
+		return keys
+	}
+	for key := range flatMap {
+		keys.Insert(key)
+	}
+	return keys
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// ListEnvAlerts lists the Alertmanager alerts mapped to the env, only the firing ones are listed unless all is true.
+// The alerts of a service are listed if the service name is given.
+func ListEnvAlerts(projectName, envName, serviceName string, production, all bool, log *zap.SugaredLogger) ([]*commonmodels.EnvAlert, error) {
+	opt := &commonrepo.EnvAlertListOption{
+		ProjectName: projectName,
+		EnvName:     envName,
+		Production:  production,
+		ServiceName: serviceName,
+	}
+	if !all {
+		opt.Status = commonmodels.EnvAlertStatusFiring
+	}
+
+	alerts, err := commonrepo.NewEnvAlertColl().List(opt)
+	if err != nil {
+		log.Errorf("failed to list alerts of env %s/%s, err: %s", projectName, envName, err)
+		return nil, e.ErrListEnvAlerts.AddErr(err)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	gogithub "github.com/google/go-github/v35/github"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/v2/pkg/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/ai"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	airepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/ai"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/github"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/shared/client/systemconfig"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/jira"
+)
+
+const (
+	EnvAnalysisIssueTrackerJira   = "jira"
+	EnvAnalysisIssueTrackerGithub = "github"
+
+	defaultEnvAnalysisJiraIssueType = "Bug"
+)
+
+// CreateEnvAnalysisIssueArgs is the issue tracker to create the issue in, the jira fields are used for jira and the
+// codehost fields are used for github
+type CreateEnvAnalysisIssueArgs struct {
+	AnalysisID  string `json:"analysis_id"`
+	Fingerprint string `json:"fingerprint"`
+	Tracker     string `json:"tracker"`
+
+	JiraID        string `json:"jira_id"`
+	JiraProject   string `json:"jira_project"`
+	JiraIssueType string `json:"jira_issue_type"`
+
+	CodehostID int      `json:"codehost_id"`
+	RepoOwner  string   `json:"repo_owner"`
+	RepoName   string   `json:"repo_name"`
+	Labels     []string `json:"labels"`
+}
+
+func ListEnvAnalysisIssues(projectName, envName string, production bool) ([]*ai.EnvAnalysisIssue, error) {
+	issues, err := airepo.NewEnvAnalysisIssueColl().List(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrListEnvAnalysisIssue.AddErr(err)
+	}
+	return issues, nil
+}
+
+// CreateEnvAnalysisIssue creates an issue in the tracker for the finding of the env analysis, the existing issue is
+// returned if an issue has been created in the tracker for a finding with the same fingerprint in the env
+func CreateEnvAnalysisIssue(projectName, envName string, production bool, args *CreateEnvAnalysisIssueArgs, userName string, log *zap.SugaredLogger) (*ai.EnvAnalysisIssue, error) {
+	existing, err := airepo.NewEnvAnalysisIssueColl().GetByFingerprint(projectName, envName, production, args.Fingerprint, args.Tracker)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, e.ErrCreateEnvAnalysisIssue.AddErr(err)
+	}
+
+	analysis, err := airepo.NewEnvAIAnalysisColl().GetByID(args.AnalysisID)
+	if err != nil {
+		return nil, e.ErrCreateEnvAnalysisIssue.AddErr(fmt.Errorf("failed to find env analysis %s, err: %w", args.AnalysisID, err))
+	}
+	if analysis.ProjectName != projectName || analysis.EnvName != envName || analysis.Production != production {
+		return nil, e.ErrCreateEnvAnalysisIssue.AddDesc(fmt.Sprintf("env analysis %s does not belong to env %s", args.AnalysisID, envName))
+	}
+	var finding *ai.EnvAnalysisFinding
+	for _, f := range analysis.Findings {
+		if f.Fingerprint == args.Fingerprint {
+			finding = f
+			break
+		}
+	}
+	if finding == nil {
+		return nil, e.ErrCreateEnvAnalysisIssue.AddDesc(fmt.Sprintf("finding %s not found in env analysis %s", args.Fingerprint, args.AnalysisID))
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName, Production: &production})
+	if err != nil {
+		return nil, e.ErrCreateEnvAnalysisIssue.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	title := fmt.Sprintf("[Zadig] %s/%s: %s %s", projectName, envName, finding.Kind, finding.Name)
+	body := buildEnvAnalysisIssueBody(projectName, envName, env.Namespace, env.ClusterID, production, analysis, finding)
+
+	issue := &ai.EnvAnalysisIssue{
+		ProjectName: projectName,
+		EnvName:     envName,
+		Production:  production,
+		Fingerprint: args.Fingerprint,
+		Tracker:     args.Tracker,
+		AnalysisID:  args.AnalysisID,
+		CreatedBy:   userName,
+	}
+	switch args.Tracker {
+	case EnvAnalysisIssueTrackerJira:
+		issue.IssueKey, issue.IssueURL, err = createEnvAnalysisJiraIssue(args, title, body)
+	case EnvAnalysisIssueTrackerGithub:
+		issue.IssueKey, issue.IssueURL, err = createEnvAnalysisGithubIssue(args, title, body)
+	default:
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("unsupported issue tracker %s", args.Tracker))
+	}
+	if err != nil {
+		log.Errorf("failed to create %s issue for finding %s of env %s/%s, err: %s", args.Tracker, args.Fingerprint, projectName, envName, err)
+		return nil, e.ErrCreateEnvAnalysisIssue.AddErr(err)
+	}
+
+	if err := airepo.NewEnvAnalysisIssueColl().Create(issue); err != nil {
+		log.Errorf("failed to save the back-link of issue %s, err: %s", issue.IssueURL, err)
+		return nil, e.ErrCreateEnvAnalysisIssue.AddErr(err)
+	}
+	return issue, nil
+}
+
+func buildEnvAnalysisIssueBody(projectName, envName, namespace, clusterID string, production bool, analysis *ai.EnvAIAnalysis, finding *ai.EnvAnalysisFinding) string {
+	envURL := fmt.Sprintf("%s/v1/projects/detail/%s/envs/detail?envName=%s", configbase.SystemAddress(), projectName, envName)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Project: %s\n", projectName))
+	sb.WriteString(fmt.Sprintf("Environment: %s (%s)\n", envName, envURL))
+	sb.WriteString(fmt.Sprintf("Namespace: %s\n", namespace))
+	sb.WriteString(fmt.Sprintf("Cluster: %s\n", clusterID))
+	sb.WriteString(fmt.Sprintf("Production: %t\n", production))
+	sb.WriteString(fmt.Sprintf("Analyzed at: %s\n", time.Unix(analysis.StartTime, 0).Format(time.RFC3339)))
+	sb.WriteString(fmt.Sprintf("Object: %s/%s\n", finding.Kind, finding.Name))
+	if finding.ParentObject != "" {
+		sb.WriteString(fmt.Sprintf("Owner: %s\n", finding.ParentObject))
+	}
+	sb.WriteString("\nErrors:\n")
+	for _, errText := range finding.Errors {
+		sb.WriteString(fmt.Sprintf("- %s\n", errText))
+	}
+	if finding.Details != "" {
+		sb.WriteString(fmt.Sprintf("\nAI analysis:\n%s\n", finding.Details))
+	}
+	sb.WriteString(fmt.Sprintf("\nFingerprint: %s\n", finding.Fingerprint))
+	return sb.String()
+}
+
+func createEnvAnalysisJiraIssue(args *CreateEnvAnalysisIssueArgs, title, body string) (string, string, error) {
+	if args.JiraProject == "" {
+		return "", "", fmt.Errorf("jira project is required")
+	}
+	info, err := commonrepo.NewProjectManagementColl().GetJiraByID(args.JiraID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find jira integration %s, err: %w", args.JiraID, err)
+	}
+
+	issueType := args.JiraIssueType
+	if issueType == "" {
+		issueType = defaultEnvAnalysisJiraIssueType
+	}
+	issue, err := jira.NewJiraClientWithAuthType(info.JiraHost, info.JiraUser, info.JiraToken, info.JiraPersonalAccessToken, info.JiraAuthType).
+		Issue.Create(args.JiraProject, issueType, title, body)
+	if err != nil {
+		return "", "", err
+	}
+	return issue.Key, strings.TrimSuffix(info.JiraHost, "/") + "/browse/" + issue.Key, nil
+}
+
+func createEnvAnalysisGithubIssue(args *CreateEnvAnalysisIssueArgs, title, body string) (string, string, error) {
+	if args.RepoOwner == "" || args.RepoName == "" {
+		return "", "", fmt.Errorf("repo owner and repo name are required")
+	}
+	ch, err := systemconfig.New().GetCodeHost(args.CodehostID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find codehost %d, err: %w", args.CodehostID, err)
+	}
+	if ch.Type != setting.SourceFromGithub {
+		return "", "", fmt.Errorf("codehost %d is not github", args.CodehostID)
+	}
+
+	req := &gogithub.IssueRequest{
+		Title: &title,
+		Body:  &body,
+	}
+	if len(args.Labels) > 0 {
+		req.Labels = &args.Labels
+	}
+	issue, err := github.NewClient(ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy).CreateIssue(context.TODO(), args.RepoOwner, args.RepoName, req)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("#%d", issue.GetNumber()), issue.GetHTMLURL(), nil
+}
@@ -0,0 +1,319 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	helmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/helm"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notify"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/repository"
+	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+const (
+	envAutoUpgradeCheckInterval = time.Minute
+	envAutoUpgradeLockHolder    = "auto-upgrade"
+	// the helm release is considered failed if it is not rolled back in this period
+	envAutoUpgradeRollbackTimeout = 10 * time.Minute
+)
+
+func GetEnvAutoUpgradePolicy(projectName, envName string) (*commonmodels.EnvAutoUpgradePolicy, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: util.GetBoolPointer(false),
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+	if env.AutoUpgrade == nil {
+		return &commonmodels.EnvAutoUpgradePolicy{}, nil
+	}
+	return env.AutoUpgrade, nil
+}
+
+// UpdateEnvAutoUpgradePolicy updates the auto upgrade policy of the non-production env,
+// the services failed to upgrade before are retried after the policy is updated
+func UpdateEnvAutoUpgradePolicy(projectName, envName string, args *commonmodels.EnvAutoUpgradePolicy, log *zap.SugaredLogger) error {
+	project, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return e.ErrGetProduct.AddErr(fmt.Errorf("failed to find project %s, err: %w", projectName, err))
+	}
+	if !project.IsK8sYamlProduct() && !project.IsHelmProduct() {
+		return e.ErrInvalidParam.AddDesc("auto upgrade can only be set for k8s yaml and helm envs")
+	}
+	if _, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: util.GetBoolPointer(false),
+	}); err != nil {
+		return e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	if args.Enabled {
+		if !args.AllServices && len(args.Services) == 0 {
+			return e.ErrInvalidParam.AddDesc("services to upgrade should not be empty")
+		}
+		if args.MaintenanceWindow != nil {
+			if err := args.MaintenanceWindow.Validate(); err != nil {
+				return e.ErrInvalidParam.AddErr(err)
+			}
+		}
+	}
+	args.FailedRevisions = nil
+
+	if err := commonrepo.NewProductColl().UpdateAutoUpgrade(envName, projectName, args); err != nil {
+		log.Errorf("failed to update auto upgrade policy of env %s/%s, err: %s", projectName, envName, err)
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	return nil
+}
+
+// WatchEnvAutoUpgrade upgrades the services of the envs with auto upgrade enabled to the latest template revisions
+func WatchEnvAutoUpgrade() {
+	log := log.SugaredLogger().With("service", "WatchEnvAutoUpgrade")
+	for {
+		time.Sleep(envAutoUpgradeCheckInterval)
+
+		// held until it expires 10s before the next minutely check, so only one replica upgrades envs per tick
+		lock := cache.NewRedisLockWithExpiry("env-auto-upgrade-watch-lock", envAutoUpgradeCheckInterval-10*time.Second)
+		if err := lock.TryLock(); err != nil {
+			continue
+		}
+
+		envs, err := commonrepo.NewProductColl().ListAutoUpgradeEnvs()
+		if err != nil {
+			log.Errorf("failed to list auto upgrade envs, err: %s", err)
+			continue
+		}
+		for _, env := range envs {
+			autoUpgradeEnv(env, time.Now(), log)
+		}
+	}
+}
+
+type envServiceUpgrade struct {
+	service *commonmodels.ProductService
+	latest  *commonmodels.Service
+	// envVersion is the revision of the env service version before the upgrade, 0 if there is no version to roll back to
+	envVersion int64
+}
+
+func autoUpgradeEnv(env *commonmodels.Product, now time.Time, log *zap.SugaredLogger) {
+	policy := env.AutoUpgrade
+	if policy.MaintenanceWindow != nil && !policy.MaintenanceWindow.Contains(now) {
+		return
+	}
+	if env.IsSleeping() {
+		return
+	}
+	switch env.Status {
+	case setting.ProductStatusCreating, setting.ProductStatusUpdating, setting.ProductStatusDeleting:
+		return
+	}
+
+	upgrades, err := listEnvServiceUpgrades(env)
+	if err != nil {
+		log.Errorf("failed to list services to upgrade in env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+		return
+	}
+	if len(upgrades) == 0 {
+		return
+	}
+
+	// the upgrade is deferred to the next round if the env is being updated by others
+	if status, err := commonutil.GetEnvLockStatus(env.ProductName, env.EnvName); err != nil || status.Locked {
+		return
+	}
+	unlock, err := commonutil.LockEnvs(env.ProductName, []string{env.EnvName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderSystem, Name: envAutoUpgradeLockHolder})
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	upgraded := make([]*envServiceUpgrade, 0, len(upgrades))
+	var failed *envServiceUpgrade
+	var upgradeErr error
+	for _, upgrade := range upgrades {
+		upgrade.envVersion, err = commonrepo.NewEnvServiceVersionColl().GetLatestRevision(env.ProductName, env.EnvName, upgrade.service.ServiceName, false, false)
+		if err != nil {
+			log.Warnf("failed to get the env version of service %s in env %s/%s, err: %s", upgrade.service.ServiceName, env.ProductName, env.EnvName, err)
+		}
+
+		log.Infof("auto upgrading service %s in env %s/%s from revision %d to %d", upgrade.service.ServiceName, env.ProductName, env.EnvName, upgrade.service.Revision, upgrade.latest.Revision)
+		if upgradeErr = upgradeEnvService(env, upgrade, log); upgradeErr != nil {
+			failed = upgrade
+			break
+		}
+		upgraded = append(upgraded, upgrade)
+	}
+
+	if failed == nil {
+		lines := make([]string, 0, len(upgraded))
+		for _, upgrade := range upgraded {
+			lines = append(lines, fmt.Sprintf("%s: %d -> %d", upgrade.service.ServiceName, upgrade.service.Revision, upgrade.latest.Revision))
+		}
+		title := fmt.Sprintf("项目 %s 环境 %s 的服务已自动升级", env.ProductName, env.EnvName)
+		notifyEnvAutoUpgrade(env, title, strings.Join(lines, "\n"), log)
+		return
+	}
+
+	// roll back the services upgraded in this round, including the failed one which may be partially applied
+	rollbackErrs := make([]string, 0)
+	for _, upgrade := range append(upgraded, failed) {
+		if upgrade.envVersion == 0 {
+			rollbackErrs = append(rollbackErrs, fmt.Sprintf("%s: no version to roll back to", upgrade.service.ServiceName))
+			continue
+		}
+		if err := rollbackEnvServiceUpgrade(env, upgrade, log); err != nil {
+			rollbackErrs = append(rollbackErrs, fmt.Sprintf("%s: %s", upgrade.service.ServiceName, err))
+		}
+	}
+
+	if policy.FailedRevisions == nil {
+		policy.FailedRevisions = make(map[string]int64)
+	}
+	policy.FailedRevisions[failed.service.ServiceName] = failed.latest.Revision
+	if err := commonrepo.NewProductColl().UpdateAutoUpgrade(env.EnvName, env.ProductName, policy); err != nil {
+		log.Errorf("failed to record the failed revision of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+	}
+
+	content := fmt.Sprintf("服务 %s 升级到版本 %d 失败: %s", failed.service.ServiceName, failed.latest.Revision, upgradeErr)
+	if len(rollbackErrs) > 0 {
+		content += "\n回滚失败:\n" + strings.Join(rollbackErrs, "\n")
+	} else {
+		content += "\n本轮升级的服务已回滚"
+	}
+	title := fmt.Sprintf("项目 %s 环境 %s 的服务自动升级失败", env.ProductName, env.EnvName)
+	notifyEnvAutoUpgrade(env, title, content, log)
+}
+
+// listEnvServiceUpgrades returns the services in the env selected by the policy which have newer template revisions
+func listEnvServiceUpgrades(env *commonmodels.Product) ([]*envServiceUpgrade, error) {
+	latestSvcMap, err := repository.GetMaxRevisionsServicesMap(env.ProductName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := env.AutoUpgrade
+	serviceSet := sets.NewString(policy.Services...)
+	resp := make([]*envServiceUpgrade, 0)
+	for _, svc := range env.GetSvcList() {
+		// services shared from other projects and the releases of charts are not upgraded
+		if !svc.FromZadig() || (svc.ProductName != "" && svc.ProductName != env.ProductName) {
+			continue
+		}
+		if !policy.AllServices && !serviceSet.Has(svc.ServiceName) {
+			continue
+		}
+		latest, ok := latestSvcMap[svc.ServiceName]
+		if !ok || latest.Revision <= svc.Revision {
+			continue
+		}
+		if failedRevision, ok := policy.FailedRevisions[svc.ServiceName]; ok && failedRevision >= latest.Revision {
+			continue
+		}
+		resp = append(resp, &envServiceUpgrade{service: svc, latest: latest})
+	}
+	return resp, nil
+}
+
+func upgradeEnvService(env *commonmodels.Product, upgrade *envServiceUpgrade, log *zap.SugaredLogger) error {
+	svc := upgrade.service
+	switch svc.Type {
+	case setting.K8SDeployType:
+		_, variableKVs, err := commontypes.MergeRenderAndServiceTemplateVariableKVs(svc.GetServiceRender().OverrideYaml.RenderVariableKVs, upgrade.latest.ServiceVariableKVs)
+		if err != nil {
+			return fmt.Errorf("failed to merge the variables of service %s, err: %w", svc.ServiceName, err)
+		}
+		return UpdateService(&SvcOptArgs{
+			EnvName:     env.EnvName,
+			ProductName: env.ProductName,
+			ServiceName: svc.ServiceName,
+			ServiceType: svc.Type,
+			ServiceRev: &SvcRevision{
+				ServiceName: svc.ServiceName,
+				Type:        svc.Type,
+				Containers:  svc.Containers,
+				VariableKVs: variableKVs,
+			},
+			UpdateBy:          setting.SystemUser,
+			UpdateServiceTmpl: true,
+		}, log)
+	case setting.HelmDeployType:
+		newSvc := *svc
+		newSvc.Revision = upgrade.latest.Revision
+		newSvc.DeployStrategy = setting.ServiceDeployStrategyDeploy
+		if err := kube.DeploySingleHelmRelease(env, &newSvc, upgrade.latest, nil, 0, setting.SystemUser); err != nil {
+			return err
+		}
+		return helmservice.UpdateServiceInEnv(env, &newSvc, setting.SystemUser)
+	default:
+		return fmt.Errorf("service type %s is not supported", svc.Type)
+	}
+}
+
+func rollbackEnvServiceUpgrade(env *commonmodels.Product, upgrade *envServiceUpgrade, log *zap.SugaredLogger) error {
+	ctx := &internalhandler.Context{
+		Context:  context.Background(),
+		Logger:   log,
+		UserName: setting.SystemUser,
+	}
+	status, err := commonservice.RollbackEnvServiceVersion(ctx, env.ProductName, env.EnvName, upgrade.service.ServiceName, upgrade.envVersion, false, false, log)
+	if err != nil {
+		return err
+	}
+	if upgrade.service.Type != setting.HelmDeployType {
+		return nil
+	}
+
+	select {
+	case ok := <-status.HelmDeployStatusChan:
+		if !ok {
+			return fmt.Errorf("failed to deploy the helm release")
+		}
+		return nil
+	case <-time.After(envAutoUpgradeRollbackTimeout):
+		return fmt.Errorf("timeout waiting for the helm release to roll back")
+	}
+}
+
+func notifyEnvAutoUpgrade(env *commonmodels.Product, title, content string, log *zap.SugaredLogger) {
+	if env.UpdateBy == "" {
+		return
+	}
+	notify.SendEnvMessage(env.UpdateBy, env.ProductName, title, content, "", log)
+}
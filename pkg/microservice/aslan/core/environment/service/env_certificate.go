@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notify"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const certExpiryCheckInterval = 12 * time.Hour
+
+// ListEnvCertificates lists the certificates requested by zadig for the ingresses and HTTPRoutes of the env
+func ListEnvCertificates(projectName, envName string, production bool, log *zap.SugaredLogger) ([]*kube.CertificateStatus, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, e.ErrListResources.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrListResources.AddErr(fmt.Errorf("failed to get kube client, err: %w", err))
+	}
+
+	resp, err := kube.ListCertificateStatus(env.Namespace, labels.SelectorFromSet(map[string]string{setting.ProductLabel: projectName}), kubeClient)
+	if err != nil {
+		log.Errorf("failed to list certificates of env %s/%s, err: %s", projectName, envName, err)
+		return nil, e.ErrListResources.AddErr(err)
+	}
+	return resp, nil
+}
+
+// WatchCertificateExpiry periodically checks the certificates of the envs in the clusters with an issuer configured,
+// and sends a message to the env updater for the certificates which are not ready or will expire soon
+func WatchCertificateExpiry() {
+	log := log.SugaredLogger().With("service", "WatchCertificateExpiry")
+	for {
+		time.Sleep(certExpiryCheckInterval)
+
+		// kept until it expires one minute before the next 12h check, so expiring certificates are notified once per round
+		lock := cache.NewRedisLockWithExpiry("cert-expiry-watch-lock", certExpiryCheckInterval-time.Minute)
+		if err := lock.TryLock(); err != nil {
+			continue
+		}
+		checkCertificateExpiry(log)
+	}
+}
+
+func checkCertificateExpiry(log *zap.SugaredLogger) {
+	clusters, err := commonrepo.NewK8SClusterColl().FindConnectedClusters()
+	if err != nil {
+		log.Errorf("failed to list clusters, err: %s", err)
+		return
+	}
+
+	for _, cluster := range clusters {
+		if cluster.AdvancedConfig == nil || cluster.AdvancedConfig.CertIssuer == "" {
+			continue
+		}
+		clusterID := cluster.ID.Hex()
+		envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{
+			ClusterID:     clusterID,
+			ExcludeStatus: []string{setting.ProductStatusDeleting},
+		})
+		if err != nil {
+			log.Errorf("failed to list envs in cluster %s, err: %s", cluster.Name, err)
+			continue
+		}
+		kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(clusterID)
+		if err != nil {
+			log.Errorf("failed to get kube client of cluster %s, err: %s", cluster.Name, err)
+			continue
+		}
+
+		for _, env := range envs {
+			certs, err := kube.ListCertificateStatus(env.Namespace, labels.SelectorFromSet(map[string]string{setting.ProductLabel: env.ProductName}), kubeClient)
+			if err != nil {
+				log.Errorf("failed to list certificates of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+				continue
+			}
+
+			warnings := make([]string, 0)
+			for _, cert := range certs {
+				if cert.Warning != "" {
+					warnings = append(warnings, fmt.Sprintf("%s(%s): %s", cert.Name, strings.Join(cert.DNSNames, ","), cert.Warning))
+				}
+			}
+			if len(warnings) == 0 || env.UpdateBy == "" {
+				continue
+			}
+			title := fmt.Sprintf("项目 %s 环境 %s 的证书即将过期或未就绪", env.ProductName, env.EnvName)
+			notify.SendEnvMessage(env.UpdateBy, env.ProductName, title, strings.Join(warnings, "\n"), "", log)
+		}
+	}
+}
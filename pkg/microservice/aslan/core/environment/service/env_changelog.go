@@ -0,0 +1,247 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type EnvChangelogArgs struct {
+	ProjectName    string `json:"project_name"`
+	FromEnv        string `json:"from_env"`
+	FromProduction bool   `json:"from_production"`
+	ToEnv          string `json:"to_env"`
+	ToProduction   bool   `json:"to_production"`
+	// ServiceNames limits the services in the changelog, all the services in both envs are included if empty
+	ServiceNames []string `json:"service_names"`
+}
+
+type EnvChangelog struct {
+	ProjectName string              `json:"project_name"`
+	FromEnv     string              `json:"from_env"`
+	ToEnv       string              `json:"to_env"`
+	Services    []*ServiceChangelog `json:"services"`
+}
+
+// ServiceChangelog is the changes of a service container from the image in the from env to the image in the to env
+type ServiceChangelog struct {
+	ServiceName   string                         `json:"service_name"`
+	ServiceModule string                         `json:"service_module"`
+	FromImage     string                         `json:"from_image"`
+	ToImage       string                         `json:"to_image"`
+	Builds        []*ChangelogBuild              `json:"builds"`
+	Commits       []*commonmodels.ActivityCommit `json:"commits"`
+	PRs           []int                          `json:"prs"`
+	Authors       []string                       `json:"authors"`
+	// Message explains why the changes can't be resolved
+	Message string `json:"message,omitempty"`
+}
+
+type ChangelogBuild struct {
+	Image      string `json:"image"`
+	URL        string `json:"url"`
+	CreatedBy  string `json:"created_by"`
+	CreateTime int64  `json:"create_time"`
+}
+
+// GetEnvChangelog resolves the images which differ between the two envs back to the build tasks and commits.
+// All the builds of the image repo after the build of the from image up to the build of the to image are included.
+func GetEnvChangelog(args *EnvChangelogArgs, log *zap.SugaredLogger) (*EnvChangelog, error) {
+	services, err := PreviewEnvPromotion(&EnvPromotionArgs{
+		ProjectName:      args.ProjectName,
+		SourceEnv:        args.ToEnv,
+		SourceProduction: args.ToProduction,
+		TargetEnv:        args.FromEnv,
+		TargetProduction: args.FromProduction,
+		ServiceNames:     args.ServiceNames,
+	}, log)
+	if err != nil {
+		return nil, e.ErrGetEnvChangelog.AddErr(err)
+	}
+
+	resp := &EnvChangelog{
+		ProjectName: args.ProjectName,
+		FromEnv:     args.FromEnv,
+		ToEnv:       args.ToEnv,
+		Services:    make([]*ServiceChangelog, 0),
+	}
+	for _, svc := range services {
+		changelog, err := getServiceChangelog(svc.ServiceName, svc.ServiceModule, svc.TargetImage, svc.SourceImage)
+		if err != nil {
+			log.Errorf("failed to get changelog of %s/%s, error: %v", svc.ServiceName, svc.ServiceModule, err)
+			return nil, e.ErrGetEnvChangelog.AddErr(err)
+		}
+		resp.Services = append(resp.Services, changelog)
+	}
+	return resp, nil
+}
+
+func getServiceChangelog(serviceName, serviceModule, fromImage, toImage string) (*ServiceChangelog, error) {
+	resp := &ServiceChangelog{
+		ServiceName:   serviceName,
+		ServiceModule: serviceModule,
+		FromImage:     fromImage,
+		ToImage:       toImage,
+		Builds:        make([]*ChangelogBuild, 0),
+		Commits:       make([]*commonmodels.ActivityCommit, 0),
+		PRs:           make([]int, 0),
+		Authors:       make([]string, 0),
+	}
+
+	toArtifact, err := commonrepo.NewDeliveryArtifactColl().Get(&commonrepo.DeliveryArtifactArgs{Image: toImage})
+	if err != nil {
+		resp.Message = fmt.Sprintf("no build record found for image %s", toImage)
+		return resp, nil
+	}
+	// all the builds up to the to image are included if the from image is not built by zadig
+	var startTime int64
+	fromArtifact, err := commonrepo.NewDeliveryArtifactColl().Get(&commonrepo.DeliveryArtifactArgs{Image: fromImage})
+	if err == nil {
+		startTime = fromArtifact.CreatedTime
+	} else {
+		resp.Message = fmt.Sprintf("no build record found for image %s", fromImage)
+	}
+	if startTime >= toArtifact.CreatedTime {
+		resp.Message = fmt.Sprintf("image %s is built before image %s", toImage, fromImage)
+		return resp, nil
+	}
+
+	artifacts, err := commonrepo.NewDeliveryArtifactColl().ListImagesBuiltBetween(imageRepo(toImage), startTime, toArtifact.CreatedTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image artifacts, error: %v", err)
+	}
+	artifactIDs := make([]primitive.ObjectID, 0)
+	artifactImages := make(map[primitive.ObjectID]string)
+	for _, artifact := range artifacts {
+		artifactIDs = append(artifactIDs, artifact.ID)
+		artifactImages[artifact.ID] = artifact.Image
+	}
+	activities, err := commonrepo.NewDeliveryActivityColl().ListByArtifactIDs(artifactIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list build activities, error: %v", err)
+	}
+
+	commitSet := sets.NewString()
+	prSet := sets.NewInt()
+	authorSet := sets.NewString()
+	for _, activity := range activities {
+		if activity.Type != setting.BuildType {
+			continue
+		}
+		resp.Builds = append(resp.Builds, &ChangelogBuild{
+			Image:      artifactImages[activity.ArtifactID],
+			URL:        activity.URL,
+			CreatedBy:  activity.CreatedBy,
+			CreateTime: activity.CreatedTime,
+		})
+		for _, commit := range activity.Commits {
+			key := fmt.Sprintf("%s/%s@%s", commit.RepoOwner, commit.RepoName, commit.CommitID)
+			if commit.CommitID == "" || commitSet.Has(key) {
+				continue
+			}
+			commitSet.Insert(key)
+			resp.Commits = append(resp.Commits, commit)
+
+			prs := commit.PRs
+			if len(prs) == 0 && commit.PR != 0 {
+				prs = []int{commit.PR}
+			}
+			for _, pr := range prs {
+				if !prSet.Has(pr) {
+					prSet.Insert(pr)
+					resp.PRs = append(resp.PRs, pr)
+				}
+			}
+			if commit.AuthorName != "" && !authorSet.Has(commit.AuthorName) {
+				authorSet.Insert(commit.AuthorName)
+				resp.Authors = append(resp.Authors, commit.AuthorName)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// imageRepo returns the image without the tag, e.g. harbor.example.com/zadig/aslan:v1 -> harbor.example.com/zadig/aslan
+func imageRepo(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// RenderEnvChangelogMarkdown renders the changelog as Markdown for the release notes
+func RenderEnvChangelogMarkdown(changelog *EnvChangelog) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s: %s -> %s\n\n", changelog.ProjectName, changelog.FromEnv, changelog.ToEnv))
+	if len(changelog.Services) == 0 {
+		sb.WriteString("No changes.\n")
+		return sb.String()
+	}
+
+	for _, svc := range changelog.Services {
+		sb.WriteString(fmt.Sprintf("## %s/%s\n\n", svc.ServiceName, svc.ServiceModule))
+		sb.WriteString(fmt.Sprintf("- From: `%s`\n", svc.FromImage))
+		sb.WriteString(fmt.Sprintf("- To: `%s`\n", svc.ToImage))
+		if len(svc.Authors) > 0 {
+			sb.WriteString(fmt.Sprintf("- Authors: %s\n", strings.Join(svc.Authors, ", ")))
+		}
+		if len(svc.PRs) > 0 {
+			prs := make([]string, 0, len(svc.PRs))
+			for _, pr := range svc.PRs {
+				prs = append(prs, fmt.Sprintf("#%d", pr))
+			}
+			sb.WriteString(fmt.Sprintf("- PRs: %s\n", strings.Join(prs, ", ")))
+		}
+		if svc.Message != "" {
+			sb.WriteString(fmt.Sprintf("- Note: %s\n", svc.Message))
+		}
+		sb.WriteString("\n")
+
+		if len(svc.Commits) > 0 {
+			sb.WriteString("### Commits\n\n")
+			for _, commit := range svc.Commits {
+				commitID := commit.CommitID
+				if len(commitID) > 8 {
+					commitID = commitID[:8]
+				}
+				message := strings.SplitN(strings.TrimSpace(commit.CommitMessage), "\n", 2)[0]
+				sb.WriteString(fmt.Sprintf("- %s/%s `%s` %s (%s)\n", commit.RepoOwner, commit.RepoName, commitID, message, commit.AuthorName))
+			}
+			sb.WriteString("\n")
+		}
+
+		if len(svc.Builds) > 0 {
+			sb.WriteString("### Builds\n\n")
+			for _, build := range svc.Builds {
+				sb.WriteString(fmt.Sprintf("- `%s` by %s at %s\n", build.Image, build.CreatedBy, time.Unix(build.CreateTime, 0).Format("2006-01-02 15:04:05")))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
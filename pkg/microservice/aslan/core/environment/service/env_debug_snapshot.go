@@ -0,0 +1,331 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubectl/pkg/describe"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	s3service "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/s3"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	s3tool "github.com/koderover/zadig/v2/pkg/tool/s3"
+	fsutil "github.com/koderover/zadig/v2/pkg/util/fs"
+)
+
+const (
+	EnvDebugSnapshotStatusRunning = "running"
+	EnvDebugSnapshotStatusSuccess = "success"
+	EnvDebugSnapshotStatusFailed  = "failed"
+
+	envDebugSnapshotS3Base          = "env-debug-snapshots"
+	defaultEnvDebugSnapshotLogLines = 500
+	envDebugSnapshotTimeout         = 10 * time.Minute
+)
+
+// envDebugSnapshotDescribeKinds are the kinds of the service resources described in the snapshot
+var envDebugSnapshotDescribeKinds = map[string]schema.GroupKind{
+	setting.Deployment:  {Group: "apps", Kind: setting.Deployment},
+	setting.StatefulSet: {Group: "apps", Kind: setting.StatefulSet},
+	setting.ReplicaSet:  {Group: "apps", Kind: setting.ReplicaSet},
+	setting.Pod:         {Kind: setting.Pod},
+	setting.Service:     {Kind: setting.Service},
+	setting.CronJob:     {Group: "batch", Kind: setting.CronJob},
+	setting.Ingress:     {Group: "networking.k8s.io", Kind: setting.Ingress},
+}
+
+type CreateEnvDebugSnapshotArgs struct {
+	ServiceNames []string `json:"service_names"`
+	// LogTailLines is the number of the recent log lines collected from each container, 500 by default
+	LogTailLines int64 `json:"log_tail_lines"`
+}
+
+func ListEnvDebugSnapshots(projectName, envName string, production bool) ([]*commonmodels.EnvDebugSnapshot, error) {
+	resp, err := commonrepo.NewEnvDebugSnapshotColl().List(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrListEnvDebugSnapshot.AddErr(err)
+	}
+	return resp, nil
+}
+
+// CreateEnvDebugSnapshot starts capturing the describe output, recent logs, events and rendered manifests of the
+// selected services, the bundle is archived to the default object storage in background
+func CreateEnvDebugSnapshot(projectName, envName string, production bool, args *CreateEnvDebugSnapshotArgs, userName string, log *zap.SugaredLogger) (*commonmodels.EnvDebugSnapshot, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName, Production: &production})
+	if err != nil {
+		return nil, e.ErrCreateEnvDebugSnapshot.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+	if len(args.ServiceNames) == 0 {
+		return nil, e.ErrCreateEnvDebugSnapshot.AddDesc("no service selected")
+	}
+	svcMap := env.GetServiceMap()
+	for _, svc := range env.GetChartServiceMap() {
+		svcMap[svc.ServiceName] = svc
+	}
+	for _, name := range args.ServiceNames {
+		if _, ok := svcMap[name]; !ok {
+			return nil, e.ErrCreateEnvDebugSnapshot.AddDesc(fmt.Sprintf("service %s not found in env %s", name, envName))
+		}
+	}
+	if args.LogTailLines <= 0 {
+		args.LogTailLines = defaultEnvDebugSnapshotLogLines
+	}
+
+	storage, err := s3service.FindDefaultS3()
+	if err != nil {
+		return nil, e.ErrCreateEnvDebugSnapshot.AddDesc("default object storage is not configured")
+	}
+
+	snapshot := &commonmodels.EnvDebugSnapshot{
+		ProjectName:  projectName,
+		EnvName:      envName,
+		Production:   production,
+		ServiceNames: args.ServiceNames,
+		Status:       EnvDebugSnapshotStatusRunning,
+		StorageID:    storage.ID.Hex(),
+		CreatedBy:    userName,
+	}
+	if err := commonrepo.NewEnvDebugSnapshotColl().Create(snapshot); err != nil {
+		return nil, e.ErrCreateEnvDebugSnapshot.AddErr(err)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), envDebugSnapshotTimeout)
+		defer cancel()
+
+		if err := captureEnvDebugSnapshot(ctx, env, snapshot, storage, args.LogTailLines, log); err != nil {
+			log.Errorf("failed to capture debug snapshot of env %s/%s, err: %s", projectName, envName, err)
+			snapshot.Status = EnvDebugSnapshotStatusFailed
+			snapshot.Error = err.Error()
+		} else {
+			snapshot.Status = EnvDebugSnapshotStatusSuccess
+		}
+		snapshot.FinishTime = time.Now().Unix()
+		if err := commonrepo.NewEnvDebugSnapshotColl().Update(snapshot); err != nil {
+			log.Errorf("failed to update debug snapshot %s, err: %s", snapshot.ID.Hex(), err)
+		}
+	}()
+
+	return snapshot, nil
+}
+
+// DownloadEnvDebugSnapshot returns the archived bundle of the snapshot together with the file name
+func DownloadEnvDebugSnapshot(projectName, envName string, production bool, id string, log *zap.SugaredLogger) ([]byte, string, error) {
+	snapshot, err := commonrepo.NewEnvDebugSnapshotColl().GetByID(id)
+	if err != nil {
+		return nil, "", e.ErrDownloadEnvDebugSnapshot.AddErr(err)
+	}
+	if snapshot.ProjectName != projectName || snapshot.EnvName != envName || snapshot.Production != production {
+		return nil, "", e.ErrDownloadEnvDebugSnapshot.AddDesc(fmt.Sprintf("snapshot %s not found in env %s", id, envName))
+	}
+	if snapshot.Status != EnvDebugSnapshotStatusSuccess {
+		return nil, "", e.ErrDownloadEnvDebugSnapshot.AddDesc(fmt.Sprintf("snapshot %s is %s", id, snapshot.Status))
+	}
+
+	storage, err := s3service.FindS3ById(snapshot.StorageID)
+	if err != nil {
+		return nil, "", e.ErrDownloadEnvDebugSnapshot.AddErr(err)
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, storage.Provider)
+	if err != nil {
+		log.Errorf("failed to create s3 client, err: %s", err)
+		return nil, "", e.ErrDownloadEnvDebugSnapshot.AddErr(err)
+	}
+	obj, err := client.GetFile(storage.Bucket, snapshot.ObjectKey, &s3tool.DownloadOption{RetryNum: 2})
+	if err != nil {
+		log.Errorf("failed to get snapshot %s from s3, err: %s", snapshot.ObjectKey, err)
+		return nil, "", e.ErrDownloadEnvDebugSnapshot.AddErr(err)
+	}
+	defer obj.Body.Close()
+
+	data, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return nil, "", e.ErrDownloadEnvDebugSnapshot.AddErr(err)
+	}
+	return data, filepath.Base(snapshot.ObjectKey), nil
+}
+
+// captureEnvDebugSnapshot writes the diagnostic files of each service into a directory named after the service, and
+// uploads the archive of them to the object storage
+func captureEnvDebugSnapshot(ctx context.Context, env *commonmodels.Product, snapshot *commonmodels.EnvDebugSnapshot, storage *s3service.S3, logTailLines int64, log *zap.SugaredLogger) error {
+	tmpDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	bundleDir := filepath.Join(tmpDir, "bundle")
+	if err := collectEnvDebugFiles(ctx, env, snapshot.ServiceNames, logTailLines, bundleDir, log); err != nil {
+		return err
+	}
+
+	tarball := fmt.Sprintf("%s-%s-%s.tar.gz", env.EnvName, time.Unix(snapshot.CreateTime, 0).Format("20060102150405"), snapshot.ID.Hex())
+	localPath := filepath.Join(tmpDir, tarball)
+	if err := fsutil.Tar(os.DirFS(bundleDir), localPath); err != nil {
+		return fmt.Errorf("failed to archive the snapshot, err: %w", err)
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, storage.Provider)
+	if err != nil {
+		return fmt.Errorf("failed to create s3 client, err: %w", err)
+	}
+	objectKey := filepath.Join(storage.Subfolder, envDebugSnapshotS3Base, env.ProductName, env.EnvName, tarball)
+	if err := client.Upload(storage.Bucket, localPath, objectKey); err != nil {
+		return fmt.Errorf("failed to upload the snapshot to s3, err: %w", err)
+	}
+
+	snapshot.ObjectKey = objectKey
+	snapshot.Size = info.Size()
+	return nil
+}
+
+func collectEnvDebugFiles(ctx context.Context, env *commonmodels.Product, serviceNames []string, logTailLines int64, dir string, log *zap.SugaredLogger) error {
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get kube client, err: %w", err)
+	}
+	clientset, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(env.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get kube clientset, err: %w", err)
+	}
+	restConfig, err := clientmanager.NewKubeClientManager().GetRestConfig(env.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get rest config, err: %w", err)
+	}
+
+	manifests, err := renderedServiceManifests(env, log)
+	if err != nil {
+		// the manifests are helpful but not essential, the rest of the bundle is still collected
+		log.Warnf("failed to render the manifests of env %s, err: %s", env.EnvName, err)
+		manifests = map[string]string{}
+	}
+	events, err := clientset.CoreV1().Events(env.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list events in namespace %s, err: %w", env.Namespace, err)
+	}
+
+	svcMap := env.GetServiceMap()
+	for _, svc := range env.GetChartServiceMap() {
+		svcMap[svc.ServiceName] = svc
+	}
+	for _, name := range serviceNames {
+		svcDir := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Join(svcDir, "logs"), 0755); err != nil {
+			return err
+		}
+
+		if manifest, ok := manifests[name]; ok {
+			if err := os.WriteFile(filepath.Join(svcDir, "manifest.yaml"), []byte(manifest), 0644); err != nil {
+				return err
+			}
+		}
+
+		objectKeys, pods, err := getServiceObjects(env, svcMap[name], kubeClient)
+		if err != nil {
+			return fmt.Errorf("failed to get resources of service %s, err: %w", name, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(svcDir, "describe.txt"), []byte(describeServiceObjects(restConfig, env.Namespace, objectKeys.List())), 0644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(svcDir, "events.txt"), []byte(formatServiceEvents(events.Items, objectKeys.Has)), 0644); err != nil {
+			return err
+		}
+
+		for _, pod := range pods {
+			for _, container := range pod.Spec.Containers {
+				content, err := clientset.CoreV1().Pods(env.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+					Container: container.Name,
+					TailLines: &logTailLines,
+				}).DoRaw(ctx)
+				if err != nil {
+					content = []byte(fmt.Sprintf("failed to get logs: %s\n", err))
+				}
+				logFile := filepath.Join(svcDir, "logs", fmt.Sprintf("%s_%s.log", pod.Name, container.Name))
+				if err := os.WriteFile(logFile, content, 0644); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// describeServiceObjects returns the kubectl describe output of the objects, objects of the kinds without describer
+// are skipped
+func describeServiceObjects(restConfig *rest.Config, namespace string, objectKeys []string) string {
+	sb := &strings.Builder{}
+	for _, key := range objectKeys {
+		kind, name, found := strings.Cut(key, "/")
+		if !found {
+			continue
+		}
+		groupKind, ok := envDebugSnapshotDescribeKinds[kind]
+		if !ok {
+			continue
+		}
+		describer, ok := describe.DescriberFor(groupKind, restConfig)
+		if !ok {
+			continue
+		}
+		output, err := describer.Describe(namespace, name, describe.DescriberSettings{ShowEvents: false, ChunkSize: 500})
+		if err != nil {
+			output = fmt.Sprintf("failed to describe: %s\n", err)
+		}
+		fmt.Fprintf(sb, "=== %s ===\n%s\n", key, output)
+	}
+	return sb.String()
+}
+
+func formatServiceEvents(events []corev1.Event, involved func(string) bool) string {
+	serviceEvents := make([]corev1.Event, 0)
+	for _, event := range events {
+		if involved(involvedObjectKey(event.InvolvedObject.Kind, event.InvolvedObject.Name)) {
+			serviceEvents = append(serviceEvents, event)
+		}
+	}
+	sort.Slice(serviceEvents, func(i, j int) bool {
+		return serviceEvents[i].LastTimestamp.Before(&serviceEvents[j].LastTimestamp)
+	})
+
+	sb := &strings.Builder{}
+	for _, event := range serviceEvents {
+		fmt.Fprintf(sb, "%s\t%s\t%s\t%s/%s\t%s\n", event.LastTimestamp.Format(time.RFC3339), event.Type, event.Reason,
+			event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Message)
+	}
+	return sb.String()
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/dns"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type envDNSHostnameData struct {
+	ProjectName string
+	EnvName     string
+	Namespace   string
+	ServiceName string
+}
+
+// SyncEnvDNSRecords creates the records of the env services following the hostname rules of the project, and removes
+// the records of the services which are no longer in the env
+func SyncEnvDNSRecords(projectName, envName string, production bool, log *zap.SugaredLogger) ([]*commonmodels.EnvDNSRecord, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, e.ErrSyncEnvDNSRecords.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	integrations, err := commonrepo.NewDNSIntegrationColl().ListByProject(projectName)
+	if err != nil {
+		return nil, e.ErrSyncEnvDNSRecords.AddErr(err)
+	}
+
+	ctx := context.TODO()
+	expected := sets.NewString()
+	for _, integration := range integrations {
+		for _, rule := range integration.Rules {
+			if rule.ProjectName != projectName {
+				continue
+			}
+
+			client, err := newDNSClient(integration)
+			if err != nil {
+				return nil, e.ErrSyncEnvDNSRecords.AddErr(err)
+			}
+			tmpl, err := template.New("hostname").Option("missingkey=error").Parse(rule.HostnameTemplate)
+			if err != nil {
+				return nil, e.ErrSyncEnvDNSRecords.AddErr(fmt.Errorf("invalid hostname template, err: %w", err))
+			}
+
+			for serviceName := range env.GetServiceMap() {
+				buf := new(bytes.Buffer)
+				err := tmpl.Execute(buf, &envDNSHostnameData{
+					ProjectName: projectName,
+					EnvName:     envName,
+					Namespace:   env.Namespace,
+					ServiceName: serviceName,
+				})
+				if err != nil {
+					return nil, e.ErrSyncEnvDNSRecords.AddErr(fmt.Errorf("failed to render hostname of service %s, err: %w", serviceName, err))
+				}
+
+				record := &dns.Record{
+					Name:   strings.ToLower(strings.TrimSpace(buf.String())),
+					Type:   rule.RecordType,
+					Target: rule.Target,
+					TTL:    rule.TTL,
+				}
+				if err := client.UpsertRecord(ctx, record); err != nil {
+					log.Errorf("failed to upsert dns record %s of env %s/%s, err: %s", record.Name, projectName, envName, err)
+					return nil, e.ErrSyncEnvDNSRecords.AddErr(err)
+				}
+				err = commonrepo.NewEnvDNSRecordColl().Upsert(&commonmodels.EnvDNSRecord{
+					ProjectName:   projectName,
+					EnvName:       envName,
+					Production:    production,
+					ServiceName:   serviceName,
+					Hostname:      record.Name,
+					RecordType:    rule.RecordType,
+					Target:        rule.Target,
+					IntegrationID: integration.ID.Hex(),
+				})
+				if err != nil {
+					return nil, e.ErrSyncEnvDNSRecords.AddErr(err)
+				}
+				expected.Insert(record.Name)
+			}
+		}
+	}
+
+	records, err := commonrepo.NewEnvDNSRecordColl().List(projectName, envName)
+	if err != nil {
+		return nil, e.ErrSyncEnvDNSRecords.AddErr(err)
+	}
+	resp := make([]*commonmodels.EnvDNSRecord, 0, len(records))
+	for _, record := range records {
+		if expected.Has(record.Hostname) {
+			resp = append(resp, record)
+			continue
+		}
+		if err := deleteEnvDNSRecord(ctx, record); err != nil {
+			log.Errorf("failed to delete dns record %s of env %s/%s, err: %s", record.Hostname, projectName, envName, err)
+			return nil, e.ErrSyncEnvDNSRecords.AddErr(err)
+		}
+	}
+	return resp, nil
+}
+
+func ListEnvDNSRecords(projectName, envName string) ([]*commonmodels.EnvDNSRecord, error) {
+	resp, err := commonrepo.NewEnvDNSRecordColl().List(projectName, envName)
+	if err != nil {
+		return nil, e.ErrListDNSIntegration.AddErr(err)
+	}
+	return resp, nil
+}
+
+// syncEnvDNSRecordsOnCreation is called after the env is created, the failure does not affect the env
+func syncEnvDNSRecordsOnCreation(projectName, envName string, production bool, log *zap.SugaredLogger) {
+	integrations, err := commonrepo.NewDNSIntegrationColl().ListByProject(projectName)
+	if err != nil || len(integrations) == 0 {
+		return
+	}
+	if _, err := SyncEnvDNSRecords(projectName, envName, production, log); err != nil {
+		log.Errorf("failed to create dns records of env %s/%s, err: %s", projectName, envName, err)
+	}
+}
+
+// removeEnvDNSRecords removes all the records of the env when it is deleted
+func removeEnvDNSRecords(projectName, envName string, log *zap.SugaredLogger) {
+	records, err := commonrepo.NewEnvDNSRecordColl().List(projectName, envName)
+	if err != nil {
+		log.Errorf("failed to list dns records of env %s/%s, err: %s", projectName, envName, err)
+		return
+	}
+	for _, record := range records {
+		if err := deleteEnvDNSRecord(context.TODO(), record); err != nil {
+			log.Errorf("failed to delete dns record %s of env %s/%s, err: %s", record.Hostname, projectName, envName, err)
+		}
+	}
+}
+
+func deleteEnvDNSRecord(ctx context.Context, record *commonmodels.EnvDNSRecord) error {
+	integration, err := commonrepo.NewDNSIntegrationColl().GetByID(record.IntegrationID)
+	if err != nil {
+		return fmt.Errorf("failed to find dns integration %s, err: %w", record.IntegrationID, err)
+	}
+	client, err := newDNSClient(integration)
+	if err != nil {
+		return err
+	}
+
+	err = client.DeleteRecord(ctx, &dns.Record{
+		Name:   record.Hostname,
+		Type:   record.RecordType,
+		Target: record.Target,
+	})
+	if err != nil {
+		return err
+	}
+	return commonrepo.NewEnvDNSRecordColl().DeleteByHostname(record.Hostname)
+}
+
+// getEnvURLs returns the urls of the env services with dns records in the project, keyed by env name
+func getEnvURLs(projectName string) map[string][]string {
+	resp := make(map[string][]string)
+	records, err := commonrepo.NewEnvDNSRecordColl().List(projectName, "")
+	if err != nil {
+		return resp
+	}
+	for _, record := range records {
+		resp[record.EnvName] = append(resp[record.EnvName], "http://"+record.Hostname)
+	}
+	return resp
+}
+
+func newDNSClient(integration *commonmodels.DNSIntegration) (dns.IDNSClient, error) {
+	return dns.NewClient(&dns.Config{
+		Provider:        integration.Provider,
+		Zone:            integration.Zone,
+		AccessKeyID:     integration.AccessKeyID,
+		AccessKeySecret: integration.AccessKeySecret,
+		Region:          integration.Region,
+		ProjectID:       integration.GCPProjectID,
+		Credentials:     integration.Credentials,
+	})
+}
@@ -0,0 +1,507 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/updater"
+	zadigtypes "github.com/koderover/zadig/v2/pkg/types"
+)
+
+var (
+	httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+	gatewayGVK   = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "Gateway"}
+)
+
+// GatewayRouteTemplate generates an HTTPRoute for each kubernetes service of the selected env services, the hostname
+// template is rendered with the fields of gatewayRouteTemplateData, e.g. `{{.Service}}-{{.EnvName}}.example.com`.
+type GatewayRouteTemplate struct {
+	GatewayName      string   `json:"gateway_name"`
+	GatewayNamespace string   `json:"gateway_namespace"`
+	SectionName      string   `json:"section_name"`
+	HostnameTemplate string   `json:"hostname_template"`
+	PathPrefix       string   `json:"path_prefix"`
+	ServiceNames     []string `json:"service_names"`
+	// TLS requests a certificate for each hostname from the issuer of the cluster, stored in the secret `<hostname>-tls`
+	TLS bool `json:"tls"`
+}
+
+type gatewayRouteTemplateData struct {
+	ProjectName string
+	EnvName     string
+	Namespace   string
+	ServiceName string
+	Service     string
+}
+
+type GatewayRoute struct {
+	Name           string   `json:"name"`
+	ServiceName    string   `json:"service_name"`
+	Gateways       []string `json:"gateways"`
+	Hostnames      []string `json:"hostnames"`
+	ManagedByZadig bool     `json:"managed_by_zadig"`
+	Yaml           string   `json:"yaml"`
+}
+
+type GatewayRouteConflict struct {
+	Hostname string `json:"hostname"`
+	Path     string `json:"path"`
+	Gateway  string `json:"gateway"`
+	Route    string `json:"route"`
+	// ConflictWith is the route in the format of namespace/name which already serves the hostname and path on the gateway
+	ConflictWith string `json:"conflict_with"`
+}
+
+type GatewayRoutePreview struct {
+	Routes    []*GatewayRoute         `json:"routes"`
+	Conflicts []*GatewayRouteConflict `json:"conflicts"`
+}
+
+// ListGatewayRoutes lists the HTTPRoutes in the env namespace with their effective hostnames
+func ListGatewayRoutes(projectName, envName string, production bool, log *zap.SugaredLogger) ([]*GatewayRoute, error) {
+	env, kubeClient, err := getGatewayRouteEnv(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrListResources.AddErr(err)
+	}
+
+	routes, err := listHTTPRoutes(env.Namespace, kubeClient)
+	if err != nil {
+		log.Errorf("failed to list HTTPRoutes in ns %s, err: %s", env.Namespace, err)
+		return nil, e.ErrListResources.AddErr(err)
+	}
+
+	resp := make([]*GatewayRoute, 0, len(routes))
+	for _, route := range routes {
+		r, err := newGatewayRoute(route)
+		if err != nil {
+			return nil, e.ErrListResources.AddErr(err)
+		}
+		resp = append(resp, r)
+	}
+	return resp, nil
+}
+
+// PreviewGatewayRoutes renders the HTTPRoutes from the template and checks whether they conflict with the routes
+// attached to the same gateways in other namespaces
+func PreviewGatewayRoutes(projectName, envName string, production bool, args *GatewayRouteTemplate, log *zap.SugaredLogger) (*GatewayRoutePreview, error) {
+	env, kubeClient, err := getGatewayRouteEnv(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrListResources.AddErr(err)
+	}
+
+	resp, _, err := previewGatewayRoutes(env, kubeClient, args)
+	if err != nil {
+		log.Errorf("failed to preview HTTPRoutes of env %s/%s, err: %s", projectName, envName, err)
+		return nil, e.ErrListResources.AddErr(err)
+	}
+	return resp, nil
+}
+
+// ApplyGatewayRoutes applies the HTTPRoutes rendered from the template, nothing is applied if any conflict is found
+func ApplyGatewayRoutes(projectName, envName string, production bool, args *GatewayRouteTemplate, log *zap.SugaredLogger) (*GatewayRoutePreview, error) {
+	env, kubeClient, err := getGatewayRouteEnv(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrUpdateResource.AddErr(err)
+	}
+
+	resp, routes, err := previewGatewayRoutes(env, kubeClient, args)
+	if err != nil {
+		return nil, e.ErrUpdateResource.AddErr(err)
+	}
+	if len(resp.Conflicts) > 0 {
+		conflicts := make([]string, 0, len(resp.Conflicts))
+		for _, conflict := range resp.Conflicts {
+			conflicts = append(conflicts, fmt.Sprintf("%s%s on %s is served by %s", conflict.Hostname, conflict.Path, conflict.Gateway, conflict.ConflictWith))
+		}
+		return resp, e.ErrUpdateResource.AddDesc(fmt.Sprintf("HTTPRoutes conflict: %s", strings.Join(conflicts, "; ")))
+	}
+
+	for _, route := range routes {
+		if err := updater.CreateOrPatchUnstructured(route, kubeClient); err != nil {
+			log.Errorf("failed to apply HTTPRoute %s in ns %s, err: %s", route.GetName(), env.Namespace, err)
+			return resp, e.ErrUpdateResource.AddErr(fmt.Errorf("failed to apply HTTPRoute %s, err: %w", route.GetName(), err))
+		}
+		log.Infof("HTTPRoute %s applied in env %s/%s", route.GetName(), projectName, envName)
+
+		if !args.TLS {
+			continue
+		}
+		hosts := make(map[string]string)
+		for _, hostname := range httpRouteHostnames(route) {
+			hosts[hostname] = kube.CertificateSecretName(hostname)
+		}
+		if err := kube.EnsureCertificates(env.ClusterID, env.Namespace, hosts, route.GetLabels(), kubeClient); err != nil {
+			log.Errorf("failed to request certificates of HTTPRoute %s, err: %s", route.GetName(), err)
+			return resp, e.ErrUpdateResource.AddErr(err)
+		}
+	}
+	return resp, nil
+}
+
+func DeleteGatewayRoute(projectName, envName string, production bool, routeName string, log *zap.SugaredLogger) error {
+	env, kubeClient, err := getGatewayRouteEnv(projectName, envName, production)
+	if err != nil {
+		return e.ErrDeleteResource.AddErr(err)
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(httpRouteGVK)
+	route.SetNamespace(env.Namespace)
+	route.SetName(routeName)
+	if err := updater.DeleteUnstructured(route, kubeClient); err != nil {
+		log.Errorf("failed to delete HTTPRoute %s in ns %s, err: %s", routeName, env.Namespace, err)
+		return e.ErrDeleteResource.AddErr(err)
+	}
+	return nil
+}
+
+func getGatewayRouteEnv(projectName, envName string, production bool) (*commonmodels.Product, client.Client, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find env %s in project %s, err: %w", envName, projectName, err)
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get kube client, err: %w", err)
+	}
+	return env, kubeClient, nil
+}
+
+func listHTTPRoutes(ns string, kubeClient client.Client) ([]*unstructured.Unstructured, error) {
+	routes, err := getter.ListUnstructuredResourceInCache(ns, labels.Everything(), nil, httpRouteGVK, kubeClient)
+	if meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("gateway API is not installed in the cluster")
+	}
+	return routes, err
+}
+
+func previewGatewayRoutes(env *commonmodels.Product, kubeClient client.Client, args *GatewayRouteTemplate) (*GatewayRoutePreview, []*unstructured.Unstructured, error) {
+	if args.GatewayName == "" {
+		return nil, nil, fmt.Errorf("gateway name can not be empty")
+	}
+	if args.HostnameTemplate == "" {
+		return nil, nil, fmt.Errorf("hostname template can not be empty")
+	}
+	if args.GatewayNamespace == "" {
+		args.GatewayNamespace = env.Namespace
+	}
+	if args.PathPrefix == "" {
+		args.PathPrefix = "/"
+	}
+	hostnameTmpl, err := template.New("hostname").Option("missingkey=error").Parse(args.HostnameTemplate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid hostname template, err: %w", err)
+	}
+
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(gatewayGVK)
+	found, err := getter.GetResourceInCache(args.GatewayNamespace, args.GatewayName, gateway, kubeClient)
+	if meta.IsNoMatchError(err) {
+		return nil, nil, fmt.Errorf("gateway API is not installed in the cluster")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get gateway %s/%s, err: %w", args.GatewayNamespace, args.GatewayName, err)
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("gateway %s/%s not found", args.GatewayNamespace, args.GatewayName)
+	}
+	listenerHostnames := gatewayListenerHostnames(gateway, args.SectionName)
+
+	services, err := getter.ListServices(env.Namespace, labels.SelectorFromSet(map[string]string{setting.ProductLabel: env.ProductName}), kubeClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list services in ns %s, err: %w", env.Namespace, err)
+	}
+	serviceNameSet := sets.NewString(args.ServiceNames...)
+
+	resp := &GatewayRoutePreview{
+		Routes:    make([]*GatewayRoute, 0),
+		Conflicts: make([]*GatewayRouteConflict, 0),
+	}
+	routes := make([]*unstructured.Unstructured, 0)
+	for _, svc := range services {
+		serviceName := svc.Labels[setting.ServiceLabel]
+		if serviceName == "" || (serviceNameSet.Len() > 0 && !serviceNameSet.Has(serviceName)) || len(svc.Spec.Ports) == 0 {
+			continue
+		}
+
+		buf := new(bytes.Buffer)
+		err := hostnameTmpl.Execute(buf, &gatewayRouteTemplateData{
+			ProjectName: env.ProductName,
+			EnvName:     env.EnvName,
+			Namespace:   env.Namespace,
+			ServiceName: serviceName,
+			Service:     svc.Name,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to render hostname of service %s, err: %w", svc.Name, err)
+		}
+		hostname := strings.TrimSpace(buf.String())
+		if len(listenerHostnames) > 0 && !matchGatewayHostname(listenerHostnames, hostname) {
+			return nil, nil, fmt.Errorf("hostname %s of service %s is not allowed by the listeners of gateway %s/%s", hostname, svc.Name, args.GatewayNamespace, args.GatewayName)
+		}
+
+		route := renderHTTPRoute(env, svc, serviceName, hostname, args)
+		r, err := newGatewayRoute(route)
+		if err != nil {
+			return nil, nil, err
+		}
+		resp.Routes = append(resp.Routes, r)
+		routes = append(routes, route)
+	}
+
+	conflicts, err := checkGatewayRouteConflicts(env.Namespace, kubeClient, routes, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp.Conflicts = conflicts
+	return resp, routes, nil
+}
+
+func renderHTTPRoute(env *commonmodels.Product, svc *corev1.Service, serviceName, hostname string, args *GatewayRouteTemplate) *unstructured.Unstructured {
+	parentRef := map[string]interface{}{
+		"name":      args.GatewayName,
+		"namespace": args.GatewayNamespace,
+	}
+	if args.SectionName != "" {
+		parentRef["sectionName"] = args.SectionName
+	}
+
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{parentRef},
+			"hostnames":  []interface{}{hostname},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"matches": []interface{}{
+						map[string]interface{}{
+							"path": map[string]interface{}{
+								"type":  "PathPrefix",
+								"value": args.PathPrefix,
+							},
+						},
+					},
+					"backendRefs": []interface{}{
+						map[string]interface{}{
+							"name": svc.Name,
+							"port": int64(svc.Spec.Ports[0].Port),
+						},
+					},
+				},
+			},
+		},
+	}}
+	route.SetGroupVersionKind(httpRouteGVK)
+	route.SetName(svc.Name)
+	route.SetNamespace(env.Namespace)
+	route.SetLabels(map[string]string{
+		zadigtypes.ZadigLabelKeyGlobalOwner: zadigtypes.Zadig,
+		setting.ProductLabel:                env.ProductName,
+		setting.EnvNameLabel:                env.EnvName,
+		setting.ServiceLabel:                serviceName,
+	})
+	return route
+}
+
+// checkGatewayRouteConflicts finds the routes of other namespaces, or the routes not to be replaced in the env namespace,
+// which are attached to the same gateway and serve the same hostname and path
+func checkGatewayRouteConflicts(ns string, kubeClient client.Client, routes []*unstructured.Unstructured, args *GatewayRouteTemplate) ([]*GatewayRouteConflict, error) {
+	existedRoutes, err := listHTTPRoutes("", kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list HTTPRoutes, err: %w", err)
+	}
+
+	gatewayKey := fmt.Sprintf("%s/%s", args.GatewayNamespace, args.GatewayName)
+	servedBy := make(map[string]string)
+	for _, existed := range existedRoutes {
+		if existed.GetNamespace() == ns {
+			replaced := false
+			for _, route := range routes {
+				if route.GetName() == existed.GetName() {
+					replaced = true
+					break
+				}
+			}
+			if replaced {
+				continue
+			}
+		}
+		if !sets.NewString(httpRouteGateways(existed)...).Has(gatewayKey) {
+			continue
+		}
+		for _, hostname := range httpRouteHostnames(existed) {
+			for _, path := range httpRoutePaths(existed) {
+				servedBy[hostname+path] = fmt.Sprintf("%s/%s", existed.GetNamespace(), existed.GetName())
+			}
+		}
+	}
+
+	resp := make([]*GatewayRouteConflict, 0)
+	for _, route := range routes {
+		for _, hostname := range httpRouteHostnames(route) {
+			for _, path := range httpRoutePaths(route) {
+				conflictWith, ok := servedBy[hostname+path]
+				if !ok {
+					servedBy[hostname+path] = fmt.Sprintf("%s/%s", ns, route.GetName())
+					continue
+				}
+				resp = append(resp, &GatewayRouteConflict{
+					Hostname:     hostname,
+					Path:         path,
+					Gateway:      gatewayKey,
+					Route:        route.GetName(),
+					ConflictWith: conflictWith,
+				})
+			}
+		}
+	}
+	return resp, nil
+}
+
+func gatewayListenerHostnames(gateway *unstructured.Unstructured, sectionName string) []string {
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	resp := make([]string, 0)
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := listener["name"].(string); sectionName != "" && name != sectionName {
+			continue
+		}
+		hostname, _ := listener["hostname"].(string)
+		if hostname == "" {
+			// a listener without hostname accepts all the hostnames
+			return nil
+		}
+		resp = append(resp, hostname)
+	}
+	return resp
+}
+
+func matchGatewayHostname(listenerHostnames []string, hostname string) bool {
+	for _, listenerHostname := range listenerHostnames {
+		if listenerHostname == hostname {
+			return true
+		}
+		if strings.HasPrefix(listenerHostname, "*.") && strings.HasSuffix(hostname, listenerHostname[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func httpRouteGateways(route *unstructured.Unstructured) []string {
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	resp := make([]string, 0, len(parentRefs))
+	for _, p := range parentRefs {
+		parentRef, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := parentRef["name"].(string)
+		ns, _ := parentRef["namespace"].(string)
+		if ns == "" {
+			ns = route.GetNamespace()
+		}
+		resp = append(resp, fmt.Sprintf("%s/%s", ns, name))
+	}
+	return resp
+}
+
+func httpRouteHostnames(route *unstructured.Unstructured) []string {
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if len(hostnames) == 0 {
+		return []string{"*"}
+	}
+	return hostnames
+}
+
+func httpRoutePaths(route *unstructured.Unstructured) []string {
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	paths := sets.NewString()
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matches, _, _ := unstructured.NestedSlice(rule, "matches")
+		if len(matches) == 0 {
+			paths.Insert("/")
+		}
+		for _, m := range matches {
+			match, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path, found, _ := unstructured.NestedString(match, "path", "value")
+			if !found {
+				path = "/"
+			}
+			paths.Insert(path)
+		}
+	}
+	if paths.Len() == 0 {
+		paths.Insert("/")
+	}
+	return paths.List()
+}
+
+func newGatewayRoute(route *unstructured.Unstructured) (*GatewayRoute, error) {
+	content, err := yaml.Marshal(route.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal HTTPRoute %s, err: %w", route.GetName(), err)
+	}
+
+	gateways := httpRouteGateways(route)
+	sort.Strings(gateways)
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	return &GatewayRoute{
+		Name:           route.GetName(),
+		ServiceName:    route.GetLabels()[setting.ServiceLabel],
+		Gateways:       gateways,
+		Hostnames:      hostnames,
+		ManagedByZadig: route.GetLabels()[zadigtypes.ZadigLabelKeyGlobalOwner] == zadigtypes.Zadig,
+		Yaml:           string(content),
+	}, nil
+}
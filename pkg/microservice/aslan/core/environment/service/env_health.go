@@ -0,0 +1,345 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	airepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/ai"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notify"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const (
+	envHealthCheckInterval = 5 * time.Minute
+	envHealthRollingWindow = time.Hour
+	envHealthRetention     = 30 * 24 * time.Hour
+	// the findings of the AI analyses older than this are not counted
+	envHealthAnalysisMaxAge = 24 * time.Hour
+
+	defaultEnvHealthSLOThreshold = 80
+	defaultEnvHealthSLOTarget    = 99.0
+
+	// the weights of the health score, the readiness takes the rest of the 100 points
+	envHealthRestartWeight     = 20
+	envHealthFailedProbeWeight = 15
+	envHealthFindingWeight     = 15
+	// each restart, failed probe or finding costs this many points until its weight is used up
+	envHealthPenaltyPerEvent = 5
+)
+
+type EnvHealthSLO struct {
+	Threshold int     `json:"threshold"`
+	Target    float64 `json:"target"`
+	// Compliance is the percentage of the samples in the time range with a score at or above the threshold
+	Compliance float64 `json:"compliance"`
+	Met        bool    `json:"met"`
+}
+
+type EnvHealthResp struct {
+	Current *commonmodels.EnvHealthScore   `json:"current"`
+	Samples []*commonmodels.EnvHealthScore `json:"samples"`
+	SLO     *EnvHealthSLO                  `json:"slo"`
+}
+
+// GetEnvHealth returns the latest health score of the env with the samples and the SLO compliance in the time range,
+// the last 24 hours are used if the time range is not given
+func GetEnvHealth(projectName, envName string, production bool, startTime, endTime int64, log *zap.SugaredLogger) (*EnvHealthResp, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, e.ErrGetEnvHealth.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	if endTime == 0 {
+		endTime = time.Now().Unix()
+	}
+	if startTime == 0 {
+		startTime = endTime - int64((24 * time.Hour).Seconds())
+	}
+	if startTime > endTime {
+		return nil, e.ErrInvalidParam.AddDesc("start time must be before end time")
+	}
+
+	samples, err := commonrepo.NewEnvHealthScoreColl().List(&commonrepo.EnvHealthScoreListOption{
+		ProjectName: projectName,
+		EnvName:     envName,
+		Production:  production,
+		StartTime:   startTime,
+		EndTime:     endTime,
+	})
+	if err != nil {
+		log.Errorf("failed to list health scores of env %s/%s, err: %s", projectName, envName, err)
+		return nil, e.ErrGetEnvHealth.AddErr(err)
+	}
+
+	current, err := commonrepo.NewEnvHealthScoreColl().GetLatest(projectName, envName, production)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Errorf("failed to get the latest health score of env %s/%s, err: %s", projectName, envName, err)
+		return nil, e.ErrGetEnvHealth.AddErr(err)
+	}
+
+	return &EnvHealthResp{
+		Current: current,
+		Samples: samples,
+		SLO:     getEnvHealthSLO(env.HealthConfig, samples),
+	}, nil
+}
+
+func getEnvHealthSLO(config *commonmodels.EnvHealthConfig, samples []*commonmodels.EnvHealthScore) *EnvHealthSLO {
+	slo := &EnvHealthSLO{
+		Threshold: defaultEnvHealthSLOThreshold,
+		Target:    defaultEnvHealthSLOTarget,
+	}
+	if config != nil {
+		if config.SLOThreshold > 0 {
+			slo.Threshold = config.SLOThreshold
+		}
+		if config.SLOTarget > 0 {
+			slo.Target = config.SLOTarget
+		}
+	}
+
+	if len(samples) == 0 {
+		slo.Compliance = 100
+		slo.Met = true
+		return slo
+	}
+	healthy := 0
+	for _, sample := range samples {
+		if sample.Score >= slo.Threshold {
+			healthy++
+		}
+	}
+	slo.Compliance = float64(healthy) * 100 / float64(len(samples))
+	slo.Met = slo.Compliance >= slo.Target
+	return slo
+}
+
+func validateEnvHealthConfig(config *commonmodels.EnvHealthConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.AlertThreshold < 0 || config.AlertThreshold > 100 {
+		return fmt.Errorf("alert threshold must be between 0 and 100")
+	}
+	if config.SLOThreshold < 0 || config.SLOThreshold > 100 {
+		return fmt.Errorf("slo threshold must be between 0 and 100")
+	}
+	if config.SLOTarget < 0 || config.SLOTarget > 100 {
+		return fmt.Errorf("slo target must be between 0 and 100")
+	}
+	return nil
+}
+
+// WatchEnvHealth periodically samples the health score of the envs and alerts the env updater when the rolling score
+// drops below the alert threshold of the env
+func WatchEnvHealth() {
+	log := log.SugaredLogger().With("service", "WatchEnvHealth")
+	for {
+		time.Sleep(envHealthCheckInterval)
+
+		// not unlocked: it lapses 30s before the next 5 minute check, so health records are written by one replica per window
+		lock := cache.NewRedisLockWithExpiry("env-health-watch-lock", envHealthCheckInterval-30*time.Second)
+		if err := lock.TryLock(); err != nil {
+			continue
+		}
+		sampleEnvHealth(log)
+
+		if err := commonrepo.NewEnvHealthScoreColl().DeleteBefore(time.Now().Add(-envHealthRetention).Unix()); err != nil {
+			log.Errorf("failed to clean up the expired env health scores, err: %s", err)
+		}
+	}
+}
+
+func sampleEnvHealth(log *zap.SugaredLogger) {
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{
+		ExcludeStatus: []string{setting.ProductStatusDeleting},
+	})
+	if err != nil {
+		log.Errorf("failed to list envs, err: %s", err)
+		return
+	}
+
+	for _, env := range envs {
+		if env.ClusterID == "" || env.Namespace == "" {
+			continue
+		}
+		score, err := computeEnvHealthScore(env)
+		if err != nil {
+			log.Errorf("failed to compute the health score of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+			continue
+		}
+
+		previous, err := commonrepo.NewEnvHealthScoreColl().GetLatest(env.ProductName, env.EnvName, env.Production)
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Errorf("failed to get the latest health score of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+			continue
+		}
+
+		recent, err := commonrepo.NewEnvHealthScoreColl().List(&commonrepo.EnvHealthScoreListOption{
+			ProjectName: env.ProductName,
+			EnvName:     env.EnvName,
+			Production:  env.Production,
+			StartTime:   time.Now().Add(-envHealthRollingWindow).Unix(),
+		})
+		if err != nil {
+			log.Errorf("failed to list the recent health scores of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+			continue
+		}
+		total := score.Score
+		for _, sample := range recent {
+			total += sample.Score
+		}
+		score.RollingScore = total / (len(recent) + 1)
+
+		if err := commonrepo.NewEnvHealthScoreColl().Create(score); err != nil {
+			log.Errorf("failed to save the health score of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+			continue
+		}
+
+		// only alert when the rolling score drops below the threshold to avoid repeated messages
+		if env.HealthConfig == nil || env.HealthConfig.AlertThreshold == 0 || env.UpdateBy == "" {
+			continue
+		}
+		threshold := env.HealthConfig.AlertThreshold
+		if score.RollingScore < threshold && (previous == nil || previous.RollingScore >= threshold) {
+			title := fmt.Sprintf("项目 %s 环境 %s 的健康分低于告警阈值", env.ProductName, env.EnvName)
+			content := fmt.Sprintf("健康分: %d, 告警阈值: %d\n就绪 Pod: %d/%d, 重启次数: %d, 探针失败次数: %d, 巡检问题数: %d",
+				score.RollingScore, threshold, score.ReadyPods, score.TotalPods, score.Restarts, score.FailedProbes, score.Findings)
+			notify.SendEnvMessage(env.UpdateBy, env.ProductName, title, content, "", log)
+		}
+	}
+}
+
+// computeEnvHealthScore samples the workloads of the env, the restarts and failed probes are counted in the last
+// check interval
+func computeEnvHealthScore(env *commonmodels.Product) (*commonmodels.EnvHealthScore, error) {
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kube client, err: %w", err)
+	}
+
+	pods, err := getter.ListPods(env.Namespace, labels.Everything(), kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods, err: %w", err)
+	}
+	events, err := getter.ListEvents(env.Namespace, fields.Everything(), kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events, err: %w", err)
+	}
+
+	since := time.Now().Add(-envHealthCheckInterval)
+	score := &commonmodels.EnvHealthScore{
+		ProjectName: env.ProductName,
+		EnvName:     env.EnvName,
+		Production:  env.Production,
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		score.TotalPods++
+		if isPodReady(pod) {
+			score.ReadyPods++
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.LastTerminationState.Terminated
+			if terminated != nil && terminated.FinishedAt.Time.After(since) {
+				score.Restarts++
+			}
+		}
+	}
+	for _, event := range events {
+		if event.Reason != "Unhealthy" || event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		if event.LastTimestamp.Time.After(since) || (event.Series != nil && event.Series.LastObservedTime.Time.After(since)) {
+			score.FailedProbes++
+		}
+	}
+
+	analyses, _, err := airepo.NewEnvAIAnalysisColl().ListByOptions(airepo.EnvAIAnalysisListOption{
+		ProjectName: env.ProductName,
+		EnvName:     env.EnvName,
+		Production:  env.Production,
+		PageNum:     1,
+		PageSize:    1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the latest AI analysis, err: %w", err)
+	}
+	if len(analyses) > 0 && analyses[0].Production == env.Production && analyses[0].StartTime > time.Now().Add(-envHealthAnalysisMaxAge).Unix() {
+		score.Findings = len(analyses[0].Findings)
+	}
+
+	score.Score = calculateEnvHealthScore(score)
+	return score, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func calculateEnvHealthScore(score *commonmodels.EnvHealthScore) int {
+	readinessWeight := 100 - envHealthRestartWeight - envHealthFailedProbeWeight - envHealthFindingWeight
+	readiness := readinessWeight
+	if score.TotalPods > 0 {
+		readiness = readinessWeight * score.ReadyPods / score.TotalPods
+	}
+
+	penalty := func(count, weight int) int {
+		if count*envHealthPenaltyPerEvent > weight {
+			return weight
+		}
+		return count * envHealthPenaltyPerEvent
+	}
+
+	return readiness +
+		envHealthRestartWeight - penalty(score.Restarts, envHealthRestartWeight) +
+		envHealthFailedProbeWeight - penalty(score.FailedProbes, envHealthFailedProbeWeight) +
+		envHealthFindingWeight - penalty(score.Findings, envHealthFindingWeight)
+}
+
+// removeEnvHealthScores removes the health samples of the env when it is deleted
+func removeEnvHealthScores(projectName, envName string, production bool, log *zap.SugaredLogger) {
+	if err := commonrepo.NewEnvHealthScoreColl().DeleteByEnv(projectName, envName, production); err != nil {
+		log.Errorf("failed to remove health scores of env %s/%s, err: %s", projectName, envName, err)
+	}
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+// orphanResourceKinds are the kinds checked for orphaned resources, the resources of zadig services are labeled
+// with the project and service name when they are applied.
+var orphanResourceKinds = []schema.GroupVersionKind{
+	{Group: "apps", Version: "v1", Kind: setting.Deployment},
+	{Group: "apps", Version: "v1", Kind: setting.StatefulSet},
+	{Group: "batch", Version: "v1", Kind: setting.CronJob},
+	{Group: "", Version: "v1", Kind: setting.Service},
+	{Group: "", Version: "v1", Kind: setting.ConfigMap},
+	{Group: "", Version: "v1", Kind: setting.Secret},
+	{Group: "", Version: "v1", Kind: setting.PersistentVolumeClaim},
+	{Group: "networking.k8s.io", Version: "v1", Kind: setting.Ingress},
+}
+
+type OrphanResource struct {
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	Service      string `json:"service"`
+	CreationTime int64  `json:"creation_time"`
+}
+
+type OrphanResourceRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type CleanOrphanResourcesArgs struct {
+	Resources []*OrphanResourceRef `json:"resources"`
+}
+
+type CleanOrphanResourcesResp struct {
+	Deleted []*OrphanResourceRef `json:"deleted"`
+	// Skipped are the resources not found or not orphaned anymore
+	Skipped []*OrphanResourceRef `json:"skipped"`
+}
+
+// ListOrphanResources lists the resources in the env namespace which carry the zadig labels of the project
+// but belong to none of the services in the envs sharing the namespace, e.g. the leftovers of renamed services.
+func ListOrphanResources(projectName, envName string, production bool, log *zap.SugaredLogger) ([]*OrphanResource, error) {
+	env, orphans, err := listOrphanResources(projectName, envName, production, log)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*OrphanResource, 0, len(orphans))
+	for _, res := range orphans {
+		resp = append(resp, &OrphanResource{
+			Kind:         res.GetKind(),
+			Name:         res.GetName(),
+			Service:      res.GetLabels()[setting.ServiceLabel],
+			CreationTime: res.GetCreationTimestamp().Unix(),
+		})
+	}
+	log.Infof("found %d orphaned resources in env %s/%s", len(resp), projectName, env.EnvName)
+	return resp, nil
+}
+
+// CleanOrphanResources deletes the given resources, each of them is checked again so that only the orphaned ones are deleted
+func CleanOrphanResources(projectName, envName string, production bool, args *CleanOrphanResourcesArgs, log *zap.SugaredLogger) (*CleanOrphanResourcesResp, error) {
+	env, orphans, err := listOrphanResources(projectName, envName, production, log)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanMap := make(map[OrphanResourceRef]*unstructured.Unstructured)
+	for _, res := range orphans {
+		orphanMap[OrphanResourceRef{Kind: res.GetKind(), Name: res.GetName()}] = res
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrDeleteResource.AddErr(fmt.Errorf("failed to get kube client, err: %w", err))
+	}
+
+	resp := &CleanOrphanResourcesResp{
+		Deleted: make([]*OrphanResourceRef, 0),
+		Skipped: make([]*OrphanResourceRef, 0),
+	}
+	for _, ref := range args.Resources {
+		res, ok := orphanMap[*ref]
+		if !ok {
+			resp.Skipped = append(resp.Skipped, ref)
+			continue
+		}
+		if err := updater.DeleteUnstructured(res, kubeClient); err != nil {
+			log.Errorf("failed to delete orphaned resource %s/%s in env %s, err: %s", ref.Kind, ref.Name, envName, err)
+			return resp, e.ErrDeleteResource.AddErr(fmt.Errorf("failed to delete %s/%s, err: %w", ref.Kind, ref.Name, err))
+		}
+		log.Infof("orphaned resource %s/%s in env %s/%s deleted", ref.Kind, ref.Name, projectName, envName)
+		resp.Deleted = append(resp.Deleted, ref)
+	}
+	return resp, nil
+}
+
+func listOrphanResources(projectName, envName string, production bool, log *zap.SugaredLogger) (*commonmodels.Product, []*unstructured.Unstructured, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: util.GetBoolPointer(production),
+	})
+	if err != nil {
+		return nil, nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	// the namespace may be shared by several envs of the project, the services of all of them are in use
+	envs, err := commonrepo.NewProductColl().ListEnvByNamespace(env.ClusterID, env.Namespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list envs in namespace %s, err: %w", env.Namespace, err)
+	}
+	services := sets.NewString()
+	for _, nsEnv := range envs {
+		if nsEnv.ProductName != projectName {
+			continue
+		}
+		for _, svc := range nsEnv.GetSvcList() {
+			services.Insert(svc.ServiceName)
+		}
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get kube client, err: %w", err)
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{setting.ProductLabel: projectName})
+	orphans := make([]*unstructured.Unstructured, 0)
+	for _, gvk := range orphanResourceKinds {
+		resources, err := getter.ListUnstructuredResourceInCache(env.Namespace, selector, nil, gvk, kubeClient)
+		if err != nil {
+			// the kind may not be served by the cluster, e.g. old versions of ingress
+			log.Warnf("failed to list %s in namespace %s, err: %s", gvk.Kind, env.Namespace, err)
+			continue
+		}
+		for _, res := range resources {
+			if !isOrphanResource(res, services) {
+				continue
+			}
+			res.SetGroupVersionKind(gvk)
+			orphans = append(orphans, res)
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].GetKind() != orphans[j].GetKind() {
+			return orphans[i].GetKind() < orphans[j].GetKind()
+		}
+		return orphans[i].GetName() < orphans[j].GetName()
+	})
+	return env, orphans, nil
+}
+
+func isOrphanResource(res client.Object, services sets.String) bool {
+	// resources owned by other resources are removed along with their owners
+	if len(res.GetOwnerReferences()) > 0 {
+		return false
+	}
+	service := res.GetLabels()[setting.ServiceLabel]
+	return service != "" && !services.Has(service)
+}
@@ -0,0 +1,270 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type EnvPromotionArgs struct {
+	ProjectName      string `json:"project_name"`
+	SourceEnv        string `json:"source_env"`
+	SourceProduction bool   `json:"source_production"`
+	TargetEnv        string `json:"target_env"`
+	TargetProduction bool   `json:"target_production"`
+	// ServiceNames limits the promoted services, all the services in both envs are promoted if empty
+	ServiceNames []string `json:"service_names"`
+	Approvers    []string `json:"approvers"`
+}
+
+// PreviewEnvPromotion returns the containers whose image in the source env differs from the target env
+func PreviewEnvPromotion(args *EnvPromotionArgs, log *zap.SugaredLogger) ([]*commonmodels.EnvPromotionService, error) {
+	if args.SourceEnv == args.TargetEnv && args.SourceProduction == args.TargetProduction {
+		return nil, e.ErrPreviewEnvPromotion.AddDesc("源环境和目标环境不能相同")
+	}
+
+	sourceEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       args.ProjectName,
+		EnvName:    args.SourceEnv,
+		Production: &args.SourceProduction,
+	})
+	if err != nil {
+		return nil, e.ErrPreviewEnvPromotion.AddErr(fmt.Errorf("failed to find env %s, error: %v", args.SourceEnv, err))
+	}
+	targetEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       args.ProjectName,
+		EnvName:    args.TargetEnv,
+		Production: &args.TargetProduction,
+	})
+	if err != nil {
+		return nil, e.ErrPreviewEnvPromotion.AddErr(fmt.Errorf("failed to find env %s, error: %v", args.TargetEnv, err))
+	}
+
+	serviceNameSet := sets.NewString(args.ServiceNames...)
+	targetSvcMap := targetEnv.GetServiceMap()
+	resp := make([]*commonmodels.EnvPromotionService, 0)
+	for _, serviceGroup := range sourceEnv.Services {
+		for _, sourceSvc := range serviceGroup {
+			if sourceSvc.Type == setting.HelmChartDeployType {
+				continue
+			}
+			if serviceNameSet.Len() > 0 && !serviceNameSet.Has(sourceSvc.ServiceName) {
+				continue
+			}
+			targetSvc, ok := targetSvcMap[sourceSvc.ServiceName]
+			if !ok {
+				continue
+			}
+
+			revision, err := commonrepo.NewEnvServiceVersionColl().GetLatestRevision(args.ProjectName, args.SourceEnv, sourceSvc.ServiceName, false, args.SourceProduction)
+			if err != nil {
+				log.Warnf("failed to get latest revision of service %s in env %s, error: %v", sourceSvc.ServiceName, args.SourceEnv, err)
+			}
+			for _, sourceContainer := range sourceSvc.Containers {
+				for _, targetContainer := range targetSvc.Containers {
+					if targetContainer.Name != sourceContainer.Name || targetContainer.Image == sourceContainer.Image {
+						continue
+					}
+					resp = append(resp, &commonmodels.EnvPromotionService{
+						ServiceName:    sourceSvc.ServiceName,
+						ServiceModule:  sourceContainer.Name,
+						SourceRevision: revision,
+						SourceImage:    sourceContainer.Image,
+						TargetImage:    targetContainer.Image,
+					})
+				}
+			}
+		}
+	}
+	return resp, nil
+}
+
+// CreateEnvPromotion records the promotion, it is applied directly if no approver is specified
+func CreateEnvPromotion(username string, args *EnvPromotionArgs, log *zap.SugaredLogger) (*commonmodels.EnvPromotion, error) {
+	services, err := PreviewEnvPromotion(args, log)
+	if err != nil {
+		return nil, e.ErrCreateEnvPromotion.AddErr(err)
+	}
+	if len(services) == 0 {
+		return nil, e.ErrCreateEnvPromotion.AddDesc("源环境和目标环境的服务版本一致，无需晋级")
+	}
+
+	promotion := &commonmodels.EnvPromotion{
+		ProjectName:      args.ProjectName,
+		SourceEnv:        args.SourceEnv,
+		SourceProduction: args.SourceProduction,
+		TargetEnv:        args.TargetEnv,
+		TargetProduction: args.TargetProduction,
+		Services:         services,
+		Approvers:        args.Approvers,
+		Status:           commonmodels.EnvPromotionStatusWaitingApproval,
+		CreatedBy:        username,
+	}
+	if len(args.Approvers) == 0 {
+		promotion.Status = commonmodels.EnvPromotionStatusRunning
+	}
+	if err := commonrepo.NewEnvPromotionColl().Create(promotion); err != nil {
+		return nil, e.ErrCreateEnvPromotion.AddErr(err)
+	}
+
+	if promotion.Status == commonmodels.EnvPromotionStatusRunning {
+		go applyEnvPromotion(promotion, username, false, log)
+	}
+	return promotion, nil
+}
+
+func ListEnvPromotions(projectName, targetEnv string, pageNum, pageSize int64, log *zap.SugaredLogger) ([]*commonmodels.EnvPromotion, int64, error) {
+	resp, count, err := commonrepo.NewEnvPromotionColl().List(&commonrepo.ListEnvPromotionOption{
+		ProjectName: projectName,
+		TargetEnv:   targetEnv,
+		PageNum:     pageNum,
+		PageSize:    pageSize,
+	})
+	if err != nil {
+		log.Errorf("failed to list env promotions of project %s, error: %v", projectName, err)
+		return nil, 0, e.ErrListEnvPromotion.AddErr(err)
+	}
+	return resp, count, nil
+}
+
+func GetEnvPromotion(projectName, id string, log *zap.SugaredLogger) (*commonmodels.EnvPromotion, error) {
+	promotion, err := commonrepo.NewEnvPromotionColl().GetByID(id)
+	if err != nil {
+		log.Errorf("failed to get env promotion %s, error: %v", id, err)
+		return nil, e.ErrGetEnvPromotion.AddErr(err)
+	}
+	if promotion.ProjectName != projectName {
+		return nil, e.ErrGetEnvPromotion.AddDesc("晋级记录不属于该项目")
+	}
+	return promotion, nil
+}
+
+// ApproveEnvPromotion applies the promotion if approved, only the approvers of the promotion can approve or reject it
+func ApproveEnvPromotion(username, projectName, id string, approve bool, log *zap.SugaredLogger) error {
+	promotion, err := GetEnvPromotion(projectName, id, log)
+	if err != nil {
+		return e.ErrApproveEnvPromotion.AddErr(err)
+	}
+	if promotion.Status != commonmodels.EnvPromotionStatusWaitingApproval {
+		return e.ErrApproveEnvPromotion.AddDesc("晋级记录不在待审批状态")
+	}
+	if !sets.NewString(promotion.Approvers...).Has(username) {
+		return e.ErrApproveEnvPromotion.AddDesc("当前用户不是该晋级的审批人")
+	}
+
+	toStatus := commonmodels.EnvPromotionStatusRejected
+	if approve {
+		toStatus = commonmodels.EnvPromotionStatusRunning
+	}
+	updated, err := commonrepo.NewEnvPromotionColl().UpdateStatusFrom(promotion.ID, commonmodels.EnvPromotionStatusWaitingApproval, toStatus)
+	if err != nil {
+		return e.ErrApproveEnvPromotion.AddErr(err)
+	}
+	if !updated {
+		return e.ErrApproveEnvPromotion.AddDesc("晋级记录已被其他人审批")
+	}
+	promotion.Status = toStatus
+	promotion.ApprovedBy = username
+	if err := commonrepo.NewEnvPromotionColl().Update(promotion); err != nil {
+		return e.ErrApproveEnvPromotion.AddErr(err)
+	}
+
+	if approve {
+		go applyEnvPromotion(promotion, username, false, log)
+	}
+	return nil
+}
+
+// RollbackEnvPromotion restores the images of the target env before the promotion
+func RollbackEnvPromotion(username, projectName, id string, log *zap.SugaredLogger) error {
+	promotion, err := GetEnvPromotion(projectName, id, log)
+	if err != nil {
+		return e.ErrRollbackEnvPromotion.AddErr(err)
+	}
+	if promotion.Status != commonmodels.EnvPromotionStatusSuccess && promotion.Status != commonmodels.EnvPromotionStatusFailed {
+		return e.ErrRollbackEnvPromotion.AddDesc("只能回滚已完成的晋级")
+	}
+
+	updated, err := commonrepo.NewEnvPromotionColl().UpdateStatusFrom(promotion.ID, promotion.Status, commonmodels.EnvPromotionStatusRollingBack)
+	if err != nil {
+		return e.ErrRollbackEnvPromotion.AddErr(err)
+	}
+	if !updated {
+		return e.ErrRollbackEnvPromotion.AddDesc("晋级记录状态已变化，请刷新后重试")
+	}
+	promotion.Status = commonmodels.EnvPromotionStatusRollingBack
+
+	go applyEnvPromotion(promotion, username, true, log)
+	return nil
+}
+
+// applyEnvPromotion updates the images of the target env to the source images, or to the original target images if rollback
+func applyEnvPromotion(promotion *commonmodels.EnvPromotion, username string, rollback bool, log *zap.SugaredLogger) {
+	failed := false
+	for _, svc := range promotion.Services {
+		image := svc.SourceImage
+		if rollback {
+			// the service is not changed by the promotion
+			if svc.Status != commonmodels.EnvPromotionStatusSuccess {
+				continue
+			}
+			image = svc.TargetImage
+		}
+
+		err := UpdateServiceModuleImage(promotion.ID.Hex(), username, promotion.ProjectName, promotion.TargetEnv, svc.ServiceName, svc.ServiceModule, image, promotion.TargetProduction, log)
+		if err != nil {
+			log.Errorf("failed to update image of %s/%s in env %s to %s, error: %v", svc.ServiceName, svc.ServiceModule, promotion.TargetEnv, image, err)
+			failed = true
+			svc.Error = err.Error()
+			if !rollback {
+				svc.Status = commonmodels.EnvPromotionStatusFailed
+			}
+			continue
+		}
+		svc.Error = ""
+		if rollback {
+			svc.Status = commonmodels.EnvPromotionStatusRolledBack
+		} else {
+			svc.Status = commonmodels.EnvPromotionStatusSuccess
+		}
+	}
+
+	switch {
+	case rollback && failed:
+		promotion.Status = commonmodels.EnvPromotionStatusFailed
+		promotion.Error = "部分服务回滚失败"
+	case rollback:
+		promotion.Status = commonmodels.EnvPromotionStatusRolledBack
+		promotion.Error = ""
+	case failed:
+		promotion.Status = commonmodels.EnvPromotionStatusFailed
+		promotion.Error = "部分服务晋级失败"
+	default:
+		promotion.Status = commonmodels.EnvPromotionStatusSuccess
+	}
+	if err := commonrepo.NewEnvPromotionColl().Update(promotion); err != nil {
+		log.Errorf("failed to update env promotion %s, error: %v", promotion.ID.Hex(), err)
+	}
+}
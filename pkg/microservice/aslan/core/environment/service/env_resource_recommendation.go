@@ -0,0 +1,483 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	aiservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/ai"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/v2/pkg/tool/prometheus"
+	yamlutil "github.com/koderover/zadig/v2/pkg/util/yaml"
+)
+
+const (
+	ResourceRecommendationSourcePrometheus    = "prometheus"
+	ResourceRecommendationSourceMetricsServer = "metrics_server"
+
+	defaultResourceRecommendationWindow   = "7d"
+	defaultResourceRecommendationHeadroom = 20
+	minRecommendedCPUMilli                = 10
+	minRecommendedMemoryMi                = 32
+)
+
+var prometheusDurationRegexp = regexp.MustCompile(`^[0-9]+[smhdwy]$`)
+
+type EnvResourceRecommendationArgs struct {
+	// ServiceNames are the services to recommend for, all the services in the env are included if empty
+	ServiceNames []string `json:"service_names"`
+	// Source is where the usage comes from, prometheus gives the usage over the window while metrics-server only
+	// gives the current usage
+	Source          string `json:"source"`
+	ObservabilityID string `json:"observability_id"`
+	// Window is the prometheus duration of the usage to look back, like 24h or 7d
+	Window string `json:"window"`
+	// Headroom is the percentage added to the observed usage
+	Headroom int `json:"headroom"`
+}
+
+type ServiceResourceRecommendation struct {
+	ServiceName string                            `json:"service_name"`
+	Workloads   []*WorkloadResourceRecommendation `json:"workloads"`
+	// ValuesPatch is the override values setting the recommended resources, only for helm services whose values
+	// expose the resources of the containers
+	ValuesPatch string `json:"values_patch,omitempty"`
+	Applicable  bool   `json:"applicable"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type WorkloadResourceRecommendation struct {
+	Kind       string                             `json:"kind"`
+	Name       string                             `json:"name"`
+	Containers []*ContainerResourceRecommendation `json:"containers"`
+}
+
+type ContainerResourceRecommendation struct {
+	Name                string         `json:"name"`
+	CPUUsage            string         `json:"cpu_usage"`
+	MemoryUsage         string         `json:"memory_usage"`
+	CurrentRequests     *ResourceValue `json:"current_requests"`
+	CurrentLimits       *ResourceValue `json:"current_limits"`
+	RecommendedRequests *ResourceValue `json:"recommended_requests"`
+	RecommendedLimits   *ResourceValue `json:"recommended_limits"`
+	// ValuesPath is the path of the resources of the container in the values, empty if not found
+	ValuesPath string `json:"values_path,omitempty"`
+}
+
+type ResourceValue struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// containerUsage is the observed usage of a container, cpu in cores and memory in bytes
+type containerUsage struct {
+	CPU    float64
+	Memory float64
+}
+
+type containerUsageGetter interface {
+	// getUsage returns the usage of the containers of the workload by the container name
+	getUsage(namespace, kind, name string, selector labels.Selector) (map[string]*containerUsage, error)
+}
+
+type prometheusUsageGetter struct {
+	client *prometheus.Client
+	window string
+}
+
+func (g *prometheusUsageGetter) getUsage(namespace, kind, name string, _ labels.Selector) (map[string]*containerUsage, error) {
+	podRegex := strings.ReplaceAll(name, ".", `\\.`) + "-[a-z0-9]+-[a-z0-9]+"
+	if kind == setting.StatefulSet {
+		podRegex = strings.ReplaceAll(name, ".", `\\.`) + "-[0-9]+"
+	}
+	selector := fmt.Sprintf(`namespace="%s",pod=~"%s",container!="",container!="POD"`, namespace, podRegex)
+
+	ret := make(map[string]*containerUsage)
+	cpuQuery := fmt.Sprintf(`max by (container) (quantile_over_time(0.95, rate(container_cpu_usage_seconds_total{%s}[5m])[%s:5m]))`, selector, g.window)
+	samples, err := g.client.Query(cpuQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cpu usage of %s/%s, err: %w", kind, name, err)
+	}
+	for _, sample := range samples {
+		ret[sample.Metric["container"]] = &containerUsage{CPU: sample.Value}
+	}
+
+	memoryQuery := fmt.Sprintf(`max by (container) (max_over_time(container_memory_working_set_bytes{%s}[%s]))`, selector, g.window)
+	samples, err = g.client.Query(memoryQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memory usage of %s/%s, err: %w", kind, name, err)
+	}
+	for _, sample := range samples {
+		usage, ok := ret[sample.Metric["container"]]
+		if !ok {
+			usage = &containerUsage{}
+			ret[sample.Metric["container"]] = usage
+		}
+		usage.Memory = sample.Value
+	}
+	return ret, nil
+}
+
+type metricsServerUsageGetter struct {
+	clusterID string
+}
+
+func (g *metricsServerUsageGetter) getUsage(namespace, _, _ string, selector labels.Selector) (map[string]*containerUsage, error) {
+	metricsClient, err := clientmanager.NewKubeClientManager().GetKubernetesMetricsClient(g.clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics client, err: %w", err)
+	}
+	podMetricsList, err := metricsClient.PodMetricses(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod metrics, err: %w", err)
+	}
+
+	ret := make(map[string]*containerUsage)
+	for _, podMetrics := range podMetricsList.Items {
+		for _, container := range podMetrics.Containers {
+			usage, ok := ret[container.Name]
+			if !ok {
+				usage = &containerUsage{}
+				ret[container.Name] = usage
+			}
+			usage.CPU = math.Max(usage.CPU, float64(container.Usage.Cpu().MilliValue())/1000)
+			usage.Memory = math.Max(usage.Memory, float64(container.Usage.Memory().Value()))
+		}
+	}
+	return ret, nil
+}
+
+func GetEnvResourceRecommendations(projectName, envName string, production bool, args *EnvResourceRecommendationArgs, log *zap.SugaredLogger) ([]*ServiceResourceRecommendation, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName, Production: &production})
+	if err != nil {
+		return nil, e.ErrGetResourceRecommendation.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	if args.Window == "" {
+		args.Window = defaultResourceRecommendationWindow
+	}
+	if args.Headroom <= 0 {
+		args.Headroom = defaultResourceRecommendationHeadroom
+	}
+
+	var usageGetter containerUsageGetter
+	switch args.Source {
+	case ResourceRecommendationSourcePrometheus:
+		if !prometheusDurationRegexp.MatchString(args.Window) {
+			return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("invalid window %s", args.Window))
+		}
+		observability, err := commonrepo.NewObservabilityColl().GetByID(context.TODO(), args.ObservabilityID)
+		if err != nil {
+			return nil, e.ErrGetResourceRecommendation.AddErr(fmt.Errorf("failed to find observability integration %s, err: %w", args.ObservabilityID, err))
+		}
+		if observability.Type != config.ObservabilityTypePrometheus {
+			return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("observability integration %s is not prometheus", observability.Name))
+		}
+		usageGetter = &prometheusUsageGetter{client: prometheus.NewClient(observability.Host, observability.ApiKey), window: args.Window}
+	case ResourceRecommendationSourceMetricsServer, "":
+		usageGetter = &metricsServerUsageGetter{clusterID: env.ClusterID}
+	default:
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("unsupported usage source %s", args.Source))
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrGetResourceRecommendation.AddErr(fmt.Errorf("failed to get kube client, err: %w", err))
+	}
+
+	serviceNames := args.ServiceNames
+	if len(serviceNames) == 0 {
+		for name := range env.GetServiceMap() {
+			serviceNames = append(serviceNames, name)
+		}
+		for name := range env.GetChartServiceMap() {
+			serviceNames = append(serviceNames, name)
+		}
+		sort.Strings(serviceNames)
+	}
+
+	serviceToRelease := map[string]string{}
+	for _, svc := range env.GetServiceMap() {
+		if svc.Type == setting.HelmDeployType {
+			serviceToRelease, err = commonutil.GetServiceNameToReleaseNameMap(env)
+			if err != nil {
+				return nil, e.ErrGetResourceRecommendation.AddErr(fmt.Errorf("failed to build service-release map, err: %w", err))
+			}
+			break
+		}
+	}
+
+	ret := make([]*ServiceResourceRecommendation, 0, len(serviceNames))
+	for _, serviceName := range serviceNames {
+		recommendation, err := getServiceResourceRecommendation(env, serviceName, serviceToRelease, usageGetter, args.Headroom, kubeClient)
+		if err != nil {
+			log.Errorf("failed to get resource recommendation of service %s, err: %s", serviceName, err)
+			return nil, e.ErrGetResourceRecommendation.AddErr(err)
+		}
+		ret = append(ret, recommendation)
+	}
+	return ret, nil
+}
+
+func getServiceResourceRecommendation(env *commonmodels.Product, serviceName string, serviceToRelease map[string]string, usageGetter containerUsageGetter, headroom int, kubeClient client.Client) (*ServiceResourceRecommendation, error) {
+	ret := &ServiceResourceRecommendation{ServiceName: serviceName}
+
+	scope, err := aiservice.GetEnvAnalysisScope(env, []string{serviceName}, nil)
+	if err != nil {
+		return nil, err
+	}
+	workloadKeys := make([]string, 0, len(scope))
+	for key := range scope {
+		workloadKeys = append(workloadKeys, key)
+	}
+	sort.Strings(workloadKeys)
+
+	for _, key := range workloadKeys {
+		kind, name, _ := strings.Cut(key, "/")
+		var podSpec *corev1.PodSpec
+		var labelSelector *metav1.LabelSelector
+		switch kind {
+		case setting.Deployment:
+			deployment, found, err := getter.GetDeployment(env.Namespace, name, kubeClient)
+			if err != nil || !found {
+				continue
+			}
+			podSpec, labelSelector = &deployment.Spec.Template.Spec, deployment.Spec.Selector
+		case setting.StatefulSet:
+			sts, found, err := getter.GetStatefulSet(env.Namespace, name, kubeClient)
+			if err != nil || !found {
+				continue
+			}
+			podSpec, labelSelector = &sts.Spec.Template.Spec, sts.Spec.Selector
+		default:
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector of %s, err: %w", key, err)
+		}
+		usages, err := usageGetter.getUsage(env.Namespace, kind, name, selector)
+		if err != nil {
+			return nil, err
+		}
+
+		workload := &WorkloadResourceRecommendation{Kind: kind, Name: name}
+		for _, container := range podSpec.Containers {
+			usage, ok := usages[container.Name]
+			if !ok {
+				continue
+			}
+			workload.Containers = append(workload.Containers, recommendContainerResources(container, usage, headroom))
+		}
+		ret.Workloads = append(ret.Workloads, workload)
+	}
+
+	if len(ret.Workloads) == 0 {
+		ret.Reason = "no running workload with usage found"
+		return ret, nil
+	}
+
+	releaseName, isHelm := serviceToRelease[serviceName]
+	if chartSvc, ok := env.GetChartServiceMap()[serviceName]; ok {
+		releaseName, isHelm = chartSvc.ReleaseName, true
+	}
+	if !isHelm {
+		ret.Reason = "the resources of non-helm services are defined in the service yaml, update the service template to apply"
+		return ret, nil
+	}
+
+	helmClient, err := helmtool.NewClientFromNamespace(env.ClusterID, env.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create helm client, err: %w", err)
+	}
+	values, err := helmClient.GetReleaseValues(releaseName, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get values of release %s, err: %w", releaseName, err)
+	}
+	patch, err := buildResourceValuesPatch(ret.Workloads, values)
+	if err != nil {
+		return nil, err
+	}
+	if patch == "" {
+		ret.Reason = "the resources of the containers are not found in the values"
+		return ret, nil
+	}
+	ret.ValuesPatch = patch
+	ret.Applicable = true
+	return ret, nil
+}
+
+// recommendContainerResources sets the requests to the usage with the headroom, the cpu limit to twice the cpu request
+// and the memory limit to 1.5 times the memory request
+func recommendContainerResources(container corev1.Container, usage *containerUsage, headroom int) *ContainerResourceRecommendation {
+	factor := 1 + float64(headroom)/100
+	cpuMilli := int64(math.Ceil(usage.CPU * 1000 * factor))
+	if cpuMilli < minRecommendedCPUMilli {
+		cpuMilli = minRecommendedCPUMilli
+	}
+	memoryMi := int64(math.Ceil(usage.Memory * factor / (1 << 20)))
+	if memoryMi < minRecommendedMemoryMi {
+		memoryMi = minRecommendedMemoryMi
+	}
+
+	return &ContainerResourceRecommendation{
+		Name:        container.Name,
+		CPUUsage:    resource.NewMilliQuantity(int64(math.Ceil(usage.CPU*1000)), resource.DecimalSI).String(),
+		MemoryUsage: fmt.Sprintf("%dMi", int64(math.Ceil(usage.Memory/(1<<20)))),
+		CurrentRequests: &ResourceValue{
+			CPU:    quantityString(container.Resources.Requests, corev1.ResourceCPU),
+			Memory: quantityString(container.Resources.Requests, corev1.ResourceMemory),
+		},
+		CurrentLimits: &ResourceValue{
+			CPU:    quantityString(container.Resources.Limits, corev1.ResourceCPU),
+			Memory: quantityString(container.Resources.Limits, corev1.ResourceMemory),
+		},
+		RecommendedRequests: &ResourceValue{
+			CPU:    fmt.Sprintf("%dm", cpuMilli),
+			Memory: fmt.Sprintf("%dMi", memoryMi),
+		},
+		RecommendedLimits: &ResourceValue{
+			CPU:    fmt.Sprintf("%dm", cpuMilli*2),
+			Memory: fmt.Sprintf("%dMi", memoryMi*3/2),
+		},
+	}
+}
+
+func quantityString(resources corev1.ResourceList, name corev1.ResourceName) string {
+	if q, ok := resources[name]; ok {
+		return q.String()
+	}
+	return ""
+}
+
+// buildResourceValuesPatch finds the resources of the containers in the values, the top level resources is used if
+// the service has only one container, otherwise the resources under the key of the container or the workload name
+func buildResourceValuesPatch(workloads []*WorkloadResourceRecommendation, values map[string]interface{}) (string, error) {
+	containers := make([]*ContainerResourceRecommendation, 0)
+	workloadNames := make(map[*ContainerResourceRecommendation]string)
+	for _, workload := range workloads {
+		for _, container := range workload.Containers {
+			containers = append(containers, container)
+			workloadNames[container] = workload.Name
+		}
+	}
+
+	patch := make(map[string]interface{})
+	for _, container := range containers {
+		var path []string
+		if _, ok := values["resources"].(map[string]interface{}); ok && len(containers) == 1 {
+			path = []string{"resources"}
+		} else {
+			for _, key := range []string{container.Name, workloadNames[container]} {
+				if sub, ok := values[key].(map[string]interface{}); ok {
+					if _, ok := sub["resources"].(map[string]interface{}); ok {
+						path = []string{key, "resources"}
+						break
+					}
+				}
+			}
+		}
+		if len(path) == 0 {
+			continue
+		}
+
+		container.ValuesPath = strings.Join(path, ".")
+		resources := map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": container.RecommendedRequests.CPU, "memory": container.RecommendedRequests.Memory},
+			"limits":   map[string]interface{}{"cpu": container.RecommendedLimits.CPU, "memory": container.RecommendedLimits.Memory},
+		}
+		if len(path) == 1 {
+			patch[path[0]] = resources
+		} else {
+			patch[path[0]] = map[string]interface{}{path[1]: resources}
+		}
+	}
+	if len(patch) == 0 {
+		return "", nil
+	}
+
+	bs, err := yaml.Marshal(patch)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal values patch, err: %w", err)
+	}
+	return string(bs), nil
+}
+
+type ApplyResourceRecommendationArgs struct {
+	ServiceName string `json:"service_name"`
+	ValuesPatch string `json:"values_patch"`
+}
+
+// ApplyResourceRecommendation merges the values patch of the recommendation into the override values of the helm
+// service in the env and upgrades the release through the update path of the env values
+func ApplyResourceRecommendation(projectName, envName string, production bool, args *ApplyResourceRecommendationArgs, userName, requestID string, log *zap.SugaredLogger) error {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName, Production: &production})
+	if err != nil {
+		return e.ErrApplyResourceRecommendation.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	deployType := setting.HelmDeployType
+	svc, ok := env.GetServiceMap()[args.ServiceName]
+	if !ok {
+		svc, ok = env.GetChartServiceMap()[args.ServiceName]
+		deployType = setting.HelmChartDeployType
+	}
+	if !ok || (svc.Type != setting.HelmDeployType && svc.Type != setting.HelmChartDeployType) {
+		return e.ErrApplyResourceRecommendation.AddDesc(fmt.Sprintf("helm service %s not found in env %s", args.ServiceName, envName))
+	}
+
+	render := svc.GetServiceRender()
+	if render.OverrideYaml != nil && (render.OverrideYaml.Source == setting.SourceFromGitRepo || render.OverrideYaml.Source == setting.SourceFromVariableSet) {
+		return e.ErrApplyResourceRecommendation.AddDesc("the override values are synced from an external source, update the source instead")
+	}
+
+	merged, err := yamlutil.Merge([][]byte{[]byte(render.GetOverrideYaml()), []byte(args.ValuesPatch)})
+	if err != nil {
+		return e.ErrApplyResourceRecommendation.AddErr(fmt.Errorf("failed to merge values patch, err: %w", err))
+	}
+
+	chartArg := &commonservice.HelmSvcRenderArg{}
+	chartArg.LoadFromRenderChartModel(render)
+	chartArg.OverrideYaml = string(merged)
+	return UpdateHelmProductCharts(projectName, envName, userName, requestID, production, false, &EnvRendersetArg{
+		DeployType:  deployType,
+		ChartValues: []*commonservice.HelmSvcRenderArg{chartArg},
+	}, log)
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+// ClaimEnvResourceVersion locks the env for the request and makes sure the env has not been changed since the caller read it.
+// The returned function releases the lock, the caller must hold it until the update is written since the write increases
+// the resource version, so the concurrent updates based on the same resource version will fail. The conflict error
+// carries the latest state of the env so that the caller can merge the changes.
+func ClaimEnvResourceVersion(projectName, envName string, production bool, expected int64, userName, requestID string, log *zap.SugaredLogger) (func(), error) {
+	// the update steps of the request join the lock instead of waiting for it
+	unlock, err := commonutil.LockEnvs(projectName, []string{envName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: userName, RequestID: requestID})
+	if err != nil {
+		return nil, e.ErrUpdateEnv.AddErr(err)
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: util.GetBoolPointer(production),
+	})
+	if err != nil {
+		unlock()
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+	// envs created before the resource version is introduced have no such field, which is read as 0
+	if env.ResourceVersion == expected {
+		return unlock, nil
+	}
+	unlock()
+
+	latest, err := buildProductResp(envName, env, log)
+	if err != nil {
+		return nil, err
+	}
+	return nil, e.NewWithExtras(e.ErrConflict,
+		fmt.Sprintf("env %s has been changed by %s, expected resource version: %d, latest: %d", envName, env.UpdateBy, expected, env.ResourceVersion),
+		map[string]interface{}{
+			"resource_version": env.ResourceVersion,
+			"env":              latest,
+		})
+}
+
+// GetEnvLockStatus returns the holder of the env lock, e.g. a workflow task deploying to the env, and the ones waiting for it
+func GetEnvLockStatus(projectName, envName string, production bool) (*commonutil.EnvLockStatus, error) {
+	_, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: util.GetBoolPointer(production),
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+	return commonutil.GetEnvLockStatus(projectName, envName)
+}
@@ -0,0 +1,358 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	aiservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/ai"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/updater"
+)
+
+type ServiceAutoscaler struct {
+	*commonmodels.EnvServiceAutoscaler
+	Status *ServiceAutoscalerStatus `json:"status"`
+}
+
+// ServiceAutoscalerStatus is the scaling status read from the HPA in the cluster, it is nil if the HPA is not found
+type ServiceAutoscalerStatus struct {
+	CurrentReplicas int32                        `json:"current_replicas"`
+	DesiredReplicas int32                        `json:"desired_replicas"`
+	LastScaleTime   int64                        `json:"last_scale_time"`
+	CurrentMetrics  []*AutoscalerCurrentMetric   `json:"current_metrics"`
+	Conditions      []*AutoscalerStatusCondition `json:"conditions"`
+}
+
+type AutoscalerCurrentMetric struct {
+	Name               string `json:"name"`
+	CurrentUtilization int32  `json:"current_utilization"`
+	CurrentValue       string `json:"current_value"`
+}
+
+type AutoscalerStatusCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func ListServiceAutoscalers(projectName, envName, serviceName string, production bool, log *zap.SugaredLogger) ([]*ServiceAutoscaler, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName, Production: &production})
+	if err != nil {
+		return nil, e.ErrListServiceAutoscaler.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+	autoscalers, err := commonrepo.NewEnvServiceAutoscalerColl().ListByService(projectName, envName, serviceName)
+	if err != nil {
+		return nil, e.ErrListServiceAutoscaler.AddErr(err)
+	}
+
+	clientset, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrListServiceAutoscaler.AddErr(fmt.Errorf("failed to get kube clientset, err: %w", err))
+	}
+
+	ret := make([]*ServiceAutoscaler, 0, len(autoscalers))
+	for _, autoscaler := range autoscalers {
+		item := &ServiceAutoscaler{EnvServiceAutoscaler: autoscaler}
+		hpa, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(env.Namespace).Get(context.TODO(), kube.AutoscalerHPAName(autoscaler), metav1.GetOptions{})
+		if err == nil {
+			item.Status = buildAutoscalerStatus(hpa)
+		} else if !apierrors.IsNotFound(err) {
+			log.Warnf("failed to get HPA of %s/%s in env %s, err: %s", autoscaler.TargetKind, autoscaler.TargetName, envName, err)
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
+
+func buildAutoscalerStatus(hpa *autoscalingv2.HorizontalPodAutoscaler) *ServiceAutoscalerStatus {
+	status := &ServiceAutoscalerStatus{
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+	}
+	if hpa.Status.LastScaleTime != nil {
+		status.LastScaleTime = hpa.Status.LastScaleTime.Unix()
+	}
+	for _, metric := range hpa.Status.CurrentMetrics {
+		current := &AutoscalerCurrentMetric{Name: string(metric.Type)}
+		var value autoscalingv2.MetricValueStatus
+		switch {
+		case metric.Resource != nil:
+			current.Name, value = string(metric.Resource.Name), metric.Resource.Current
+		case metric.ContainerResource != nil:
+			current.Name, value = string(metric.ContainerResource.Name), metric.ContainerResource.Current
+		case metric.External != nil:
+			current.Name, value = metric.External.Metric.Name, metric.External.Current
+		case metric.Pods != nil:
+			current.Name, value = metric.Pods.Metric.Name, metric.Pods.Current
+		case metric.Object != nil:
+			current.Name, value = metric.Object.Metric.Name, metric.Object.Current
+		}
+		if value.AverageUtilization != nil {
+			current.CurrentUtilization = *value.AverageUtilization
+		}
+		if value.AverageValue != nil {
+			current.CurrentValue = value.AverageValue.String()
+		} else if value.Value != nil {
+			current.CurrentValue = value.Value.String()
+		}
+		status.CurrentMetrics = append(status.CurrentMetrics, current)
+	}
+	for _, condition := range hpa.Status.Conditions {
+		status.Conditions = append(status.Conditions, &AutoscalerStatusCondition{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+	}
+	return status
+}
+
+// UpsertServiceAutoscaler creates or updates the autoscaler of the workload of the service and applies it to the env
+// immediately, the autoscalers of k8s yaml services are also rendered into the service yaml on the following deployments
+func UpsertServiceAutoscaler(projectName, envName, serviceName string, production bool, args *commonmodels.EnvServiceAutoscaler, userName string, log *zap.SugaredLogger) (*commonmodels.EnvServiceAutoscaler, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName, Production: &production})
+	if err != nil {
+		return nil, e.ErrUpsertServiceAutoscaler.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	if args.Type == "" {
+		args.Type = kube.AutoscalerTypeHPA
+	}
+	if err := validateServiceAutoscaler(env, serviceName, args); err != nil {
+		return nil, e.ErrUpsertServiceAutoscaler.AddDesc(err.Error())
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrUpsertServiceAutoscaler.AddErr(fmt.Errorf("failed to get kube client, err: %w", err))
+	}
+	if err := validateAutoscalerTarget(env, args, kubeClient); err != nil {
+		return nil, e.ErrUpsertServiceAutoscaler.AddDesc(err.Error())
+	}
+
+	coll := commonrepo.NewEnvServiceAutoscalerColl()
+	existing, err := coll.GetByTarget(projectName, envName, serviceName, args.TargetKind, args.TargetName)
+	if err != nil {
+		return nil, e.ErrUpsertServiceAutoscaler.AddErr(err)
+	}
+
+	autoscaler := &commonmodels.EnvServiceAutoscaler{
+		ProjectName: projectName,
+		EnvName:     envName,
+		Production:  production,
+		ServiceName: serviceName,
+		Type:        args.Type,
+		TargetKind:  args.TargetKind,
+		TargetName:  args.TargetName,
+		MinReplicas: args.MinReplicas,
+		MaxReplicas: args.MaxReplicas,
+		Metrics:     args.Metrics,
+		Triggers:    args.Triggers,
+		CreatedBy:   userName,
+		UpdatedBy:   userName,
+	}
+	if existing != nil {
+		autoscaler.ID, autoscaler.CreatedBy, autoscaler.CreateTime = existing.ID, existing.CreatedBy, existing.CreateTime
+	}
+
+	obj, err := kube.BuildAutoscalerObject(env, autoscaler)
+	if err != nil {
+		return nil, e.ErrUpsertServiceAutoscaler.AddErr(err)
+	}
+	// the type is switched, the object of the previous type is removed first since both scale the same workload
+	if existing != nil && existing.Type != autoscaler.Type {
+		if err := deleteAutoscalerObject(env, existing); err != nil {
+			return nil, e.ErrUpsertServiceAutoscaler.AddErr(err)
+		}
+	}
+	if err := updater.CreateOrPatchUnstructured(obj, kubeClient); err != nil {
+		log.Errorf("failed to apply %s %s in env %s, err: %s", obj.GetKind(), obj.GetName(), envName, err)
+		return nil, e.ErrUpsertServiceAutoscaler.AddErr(fmt.Errorf("failed to apply %s %s, err: %w", obj.GetKind(), obj.GetName(), err))
+	}
+
+	if existing != nil {
+		err = coll.Update(autoscaler)
+	} else {
+		err = coll.Create(autoscaler)
+	}
+	if err != nil {
+		return nil, e.ErrUpsertServiceAutoscaler.AddErr(err)
+	}
+	log.Infof("autoscaler of %s/%s in env %s/%s is saved by %s", autoscaler.TargetKind, autoscaler.TargetName, projectName, envName, userName)
+	return autoscaler, nil
+}
+
+func validateServiceAutoscaler(env *commonmodels.Product, serviceName string, args *commonmodels.EnvServiceAutoscaler) error {
+	switch args.Type {
+	case kube.AutoscalerTypeHPA:
+		if args.MinReplicas < 1 {
+			return fmt.Errorf("min replicas must be at least 1")
+		}
+		if len(args.Metrics) == 0 {
+			return fmt.Errorf("at least one metric is required")
+		}
+		if len(args.Triggers) > 0 {
+			return fmt.Errorf("triggers are only supported by keda")
+		}
+	case kube.AutoscalerTypeKEDA:
+		if args.MinReplicas < 0 {
+			return fmt.Errorf("min replicas must not be negative")
+		}
+		if len(args.Metrics) == 0 && len(args.Triggers) == 0 {
+			return fmt.Errorf("at least one metric or trigger is required")
+		}
+		for _, trigger := range args.Triggers {
+			if trigger.Type == "" {
+				return fmt.Errorf("trigger type is required")
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported autoscaler type %s", args.Type)
+	}
+	if args.MaxReplicas < args.MinReplicas || args.MaxReplicas < 1 {
+		return fmt.Errorf("max replicas must be at least 1 and not less than min replicas")
+	}
+
+	resources := make(map[string]bool)
+	for _, metric := range args.Metrics {
+		if metric.Resource != string(corev1.ResourceCPU) && metric.Resource != string(corev1.ResourceMemory) {
+			return fmt.Errorf("unsupported metric resource %s", metric.Resource)
+		}
+		if resources[metric.Resource] {
+			return fmt.Errorf("duplicated metric resource %s", metric.Resource)
+		}
+		resources[metric.Resource] = true
+		if metric.TargetUtilization <= 0 {
+			return fmt.Errorf("target utilization of %s must be positive", metric.Resource)
+		}
+	}
+
+	if args.TargetKind != setting.Deployment && args.TargetKind != setting.StatefulSet {
+		return fmt.Errorf("unsupported target kind %s", args.TargetKind)
+	}
+	scope, err := aiservice.GetEnvAnalysisScope(env, []string{serviceName}, nil)
+	if err != nil {
+		return err
+	}
+	if !scope[args.TargetKind+"/"+args.TargetName] {
+		return fmt.Errorf("%s %s is not a workload of service %s", args.TargetKind, args.TargetName, serviceName)
+	}
+	return nil
+}
+
+// validateAutoscalerTarget checks the workload in the cluster, the utilization of a resource is calculated against
+// the requests so all the containers of the workload must request the resources of the metrics
+func validateAutoscalerTarget(env *commonmodels.Product, args *commonmodels.EnvServiceAutoscaler, kubeClient client.Client) error {
+	var podSpec *corev1.PodSpec
+	switch args.TargetKind {
+	case setting.Deployment:
+		deployment, found, err := getter.GetDeployment(env.Namespace, args.TargetName, kubeClient)
+		if err != nil {
+			return fmt.Errorf("failed to get deployment %s, err: %s", args.TargetName, err)
+		}
+		if !found {
+			return fmt.Errorf("deployment %s not found in env %s", args.TargetName, env.EnvName)
+		}
+		podSpec = &deployment.Spec.Template.Spec
+	case setting.StatefulSet:
+		sts, found, err := getter.GetStatefulSet(env.Namespace, args.TargetName, kubeClient)
+		if err != nil {
+			return fmt.Errorf("failed to get statefulset %s, err: %s", args.TargetName, err)
+		}
+		if !found {
+			return fmt.Errorf("statefulset %s not found in env %s", args.TargetName, env.EnvName)
+		}
+		podSpec = &sts.Spec.Template.Spec
+	}
+
+	for _, metric := range args.Metrics {
+		missing := make([]string, 0)
+		for _, container := range podSpec.Containers {
+			if _, ok := container.Resources.Requests[corev1.ResourceName(metric.Resource)]; !ok {
+				missing = append(missing, container.Name)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%s requests are not set in containers %s of %s %s", metric.Resource, strings.Join(missing, ","), args.TargetKind, args.TargetName)
+		}
+	}
+	return nil
+}
+
+func DeleteServiceAutoscaler(projectName, envName, serviceName, id string, production bool, log *zap.SugaredLogger) error {
+	autoscaler, err := commonrepo.NewEnvServiceAutoscalerColl().GetByID(id)
+	if err != nil {
+		return e.ErrDeleteServiceAutoscaler.AddErr(err)
+	}
+	if autoscaler.ProjectName != projectName || autoscaler.EnvName != envName || autoscaler.ServiceName != serviceName {
+		return e.ErrDeleteServiceAutoscaler.AddDesc(fmt.Sprintf("autoscaler %s not found in service %s", id, serviceName))
+	}
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName, Production: &production})
+	if err != nil {
+		return e.ErrDeleteServiceAutoscaler.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	if err := deleteAutoscalerObject(env, autoscaler); err != nil {
+		log.Errorf("failed to delete autoscaler %s, err: %s", id, err)
+		return e.ErrDeleteServiceAutoscaler.AddErr(err)
+	}
+	if err := commonrepo.NewEnvServiceAutoscalerColl().Delete(autoscaler.ID); err != nil {
+		return e.ErrDeleteServiceAutoscaler.AddErr(err)
+	}
+	return nil
+}
+
+func deleteAutoscalerObject(env *commonmodels.Product, autoscaler *commonmodels.EnvServiceAutoscaler) error {
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get kube client, err: %w", err)
+	}
+	obj, err := kube.BuildAutoscalerObject(env, autoscaler)
+	if err != nil {
+		return err
+	}
+	if err := updater.DeleteUnstructured(obj, kubeClient); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %s, err: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// removeEnvAutoscalers removes the autoscaler definitions of the env when it is deleted, the objects in the cluster
+// are removed with the services
+func removeEnvAutoscalers(projectName, envName string, log *zap.SugaredLogger) {
+	if err := commonrepo.NewEnvServiceAutoscalerColl().DeleteByEnv(projectName, envName); err != nil {
+		log.Errorf("failed to remove autoscalers of env %s/%s, err: %s", projectName, envName, err)
+	}
+}
@@ -0,0 +1,317 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	versionedclient "istio.io/client-go/pkg/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	zadigtypes "github.com/koderover/zadig/v2/pkg/types"
+)
+
+const (
+	TrafficMirrorModeIstio = "istio"
+	TrafficMirrorModeEBPF  = "ebpf"
+
+	trafficMirrorVSNameTemplate = "zadig-mirror-%s"
+)
+
+type CreateTrafficMirrorArgs struct {
+	SubEnv      string  `json:"sub_env"`
+	ServiceName string  `json:"service_name"`
+	Percentage  float64 `json:"percentage"`
+	Mode        string  `json:"mode"`
+}
+
+// trafficMirrorer sets up the mirror in the data plane, the mirrors of the envs are always applied through it so that
+// the other meshes can be supported without changing the API
+type trafficMirrorer interface {
+	apply(ctx context.Context, mirror *commonmodels.EnvTrafficMirror, baseEnv, subEnv *commonmodels.Product) error
+	remove(ctx context.Context, mirror *commonmodels.EnvTrafficMirror, baseEnv, subEnv *commonmodels.Product) error
+}
+
+func getTrafficMirrorer(mode, clusterID string) (trafficMirrorer, error) {
+	switch mode {
+	case TrafficMirrorModeIstio:
+		istioClient, err := clientmanager.NewKubeClientManager().GetIstioClientSet(clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to new istio client: %w", err)
+		}
+		return &istioTrafficMirrorer{istioClient: istioClient}, nil
+	case TrafficMirrorModeEBPF:
+		return nil, fmt.Errorf("ebpf based traffic mirroring is not available in this cluster")
+	default:
+		return nil, fmt.Errorf("unsupported traffic mirror mode %s", mode)
+	}
+}
+
+func ListTrafficMirrors(projectName, baseEnv string) ([]*commonmodels.EnvTrafficMirror, error) {
+	resp, err := commonrepo.NewEnvTrafficMirrorColl().ListByBaseEnv(projectName, baseEnv)
+	if err != nil {
+		return nil, e.ErrListTrafficMirror.AddErr(err)
+	}
+	return resp, nil
+}
+
+// CreateTrafficMirror mirrors the traffic of the service in the base env to its counterpart in the sub env
+func CreateTrafficMirror(projectName, baseEnvName string, args *CreateTrafficMirrorArgs, userName string, log *zap.SugaredLogger) (*commonmodels.EnvTrafficMirror, error) {
+	if args.Percentage <= 0 || args.Percentage > 100 {
+		return nil, e.ErrCreateTrafficMirror.AddDesc("percentage must be in (0, 100]")
+	}
+	if args.Mode == "" {
+		args.Mode = TrafficMirrorModeIstio
+	}
+
+	baseEnv, subEnv, err := getTrafficMirrorEnvs(projectName, baseEnvName, args.SubEnv)
+	if err != nil {
+		return nil, e.ErrCreateTrafficMirror.AddErr(err)
+	}
+	mirrorer, err := getTrafficMirrorer(args.Mode, baseEnv.ClusterID)
+	if err != nil {
+		return nil, e.ErrCreateTrafficMirror.AddErr(err)
+	}
+
+	mirror := &commonmodels.EnvTrafficMirror{
+		ProjectName: projectName,
+		BaseEnv:     baseEnvName,
+		SubEnv:      args.SubEnv,
+		ServiceName: args.ServiceName,
+		Percentage:  args.Percentage,
+		Mode:        args.Mode,
+		CreatedBy:   userName,
+	}
+	if err := mirrorer.apply(context.TODO(), mirror, baseEnv, subEnv); err != nil {
+		log.Errorf("failed to mirror traffic of service %s from env %s to %s, err: %s", args.ServiceName, baseEnvName, args.SubEnv, err)
+		return nil, e.ErrCreateTrafficMirror.AddErr(err)
+	}
+	if err := commonrepo.NewEnvTrafficMirrorColl().Create(mirror); err != nil {
+		if removeErr := mirrorer.remove(context.TODO(), mirror, baseEnv, subEnv); removeErr != nil {
+			log.Errorf("failed to revert the traffic mirror of service %s, err: %s", args.ServiceName, removeErr)
+		}
+		return nil, e.ErrCreateTrafficMirror.AddErr(err)
+	}
+	log.Infof("traffic of service %s in env %s/%s is mirrored to env %s by %s", args.ServiceName, projectName, baseEnvName, args.SubEnv, userName)
+	return mirror, nil
+}
+
+func DeleteTrafficMirror(projectName, baseEnvName, id string, log *zap.SugaredLogger) error {
+	mirror, err := commonrepo.NewEnvTrafficMirrorColl().GetByID(id)
+	if err != nil {
+		return e.ErrDeleteTrafficMirror.AddErr(err)
+	}
+	if mirror.ProjectName != projectName || mirror.BaseEnv != baseEnvName {
+		return e.ErrDeleteTrafficMirror.AddDesc(fmt.Sprintf("traffic mirror %s not found in env %s", id, baseEnvName))
+	}
+
+	if err := removeTrafficMirror(mirror); err != nil {
+		log.Errorf("failed to remove traffic mirror %s, err: %s", id, err)
+		return e.ErrDeleteTrafficMirror.AddErr(err)
+	}
+	return nil
+}
+
+// removeEnvTrafficMirrors removes the mirrors from or to the env when it is deleted
+func removeEnvTrafficMirrors(projectName, envName string, log *zap.SugaredLogger) {
+	mirrors, err := commonrepo.NewEnvTrafficMirrorColl().ListByEnv(projectName, envName)
+	if err != nil {
+		log.Errorf("failed to list traffic mirrors of env %s/%s, err: %s", projectName, envName, err)
+		return
+	}
+	for _, mirror := range mirrors {
+		if err := removeTrafficMirror(mirror); err != nil {
+			log.Errorf("failed to remove traffic mirror of service %s from env %s to %s, err: %s", mirror.ServiceName, mirror.BaseEnv, mirror.SubEnv, err)
+		}
+	}
+}
+
+func removeTrafficMirror(mirror *commonmodels.EnvTrafficMirror) error {
+	baseEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: mirror.ProjectName, EnvName: mirror.BaseEnv})
+	if err != nil {
+		return fmt.Errorf("failed to find base env %s, err: %w", mirror.BaseEnv, err)
+	}
+	subEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: mirror.ProjectName, EnvName: mirror.SubEnv, IgnoreNotFoundErr: true})
+	if err != nil {
+		return fmt.Errorf("failed to find sub env %s, err: %w", mirror.SubEnv, err)
+	}
+
+	mirrorer, err := getTrafficMirrorer(mirror.Mode, baseEnv.ClusterID)
+	if err != nil {
+		return err
+	}
+	if err := mirrorer.remove(context.TODO(), mirror, baseEnv, subEnv); err != nil {
+		return err
+	}
+	return commonrepo.NewEnvTrafficMirrorColl().Delete(mirror.ID)
+}
+
+func getTrafficMirrorEnvs(projectName, baseEnvName, subEnvName string) (*commonmodels.Product, *commonmodels.Product, error) {
+	baseEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: baseEnvName})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find base env %s, err: %w", baseEnvName, err)
+	}
+	subEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: subEnvName})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find sub env %s, err: %w", subEnvName, err)
+	}
+
+	isSubEnv := (subEnv.ShareEnv.Enable && !subEnv.ShareEnv.IsBase && subEnv.ShareEnv.BaseEnv == baseEnvName) ||
+		(subEnv.IstioGrayscale.Enable && !subEnv.IstioGrayscale.IsBase && subEnv.IstioGrayscale.BaseEnv == baseEnvName)
+	if !isSubEnv {
+		return nil, nil, fmt.Errorf("env %s is not a sub env of %s", subEnvName, baseEnvName)
+	}
+	if subEnv.ClusterID != baseEnv.ClusterID {
+		return nil, nil, fmt.Errorf("env %s and %s are not in the same cluster", subEnvName, baseEnvName)
+	}
+	return baseEnv, subEnv, nil
+}
+
+type istioTrafficMirrorer struct {
+	istioClient versionedclient.Interface
+}
+
+// apply sets the mirror on the routes of the VirtualService of the service in the base env, a VirtualService routing
+// all the traffic to the service is created if there is none.
+func (m *istioTrafficMirrorer) apply(ctx context.Context, mirror *commonmodels.EnvTrafficMirror, baseEnv, subEnv *commonmodels.Product) error {
+	vsClient := m.istioClient.NetworkingV1alpha3().VirtualServices(baseEnv.Namespace)
+	vs, err := m.findVirtualService(ctx, baseEnv.Namespace, mirror.ServiceName)
+	if err != nil {
+		return err
+	}
+
+	mirrorDestination := &networkingv1alpha3.Destination{
+		Host: fmt.Sprintf("%s.%s.svc.cluster.local", mirror.ServiceName, subEnv.Namespace),
+	}
+	mirrorPercentage := &networkingv1alpha3.Percent{Value: mirror.Percentage}
+
+	if vs == nil {
+		vs = &v1alpha3.VirtualService{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf(trafficMirrorVSNameTemplate, mirror.ServiceName),
+				Labels: map[string]string{zadigtypes.ZadigLabelKeyGlobalOwner: zadigtypes.Zadig},
+			},
+			Spec: networkingv1alpha3.VirtualService{
+				Hosts: []string{mirror.ServiceName},
+				Http: []*networkingv1alpha3.HTTPRoute{
+					{
+						Route: []*networkingv1alpha3.HTTPRouteDestination{
+							{Destination: &networkingv1alpha3.Destination{Host: mirror.ServiceName}},
+						},
+						Mirror:           mirrorDestination,
+						MirrorPercentage: mirrorPercentage,
+					},
+				},
+			},
+		}
+		if _, err := vsClient.Create(ctx, vs, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create VirtualService %s, err: %w", vs.Name, err)
+		}
+		mirror.VirtualServiceName = vs.Name
+		mirror.VirtualServiceCreated = true
+		return nil
+	}
+
+	// only the traffic served by the base env is mirrored, the routes to the other sub envs are kept unchanged
+	baseHosts := map[string]bool{
+		mirror.ServiceName: true,
+		fmt.Sprintf("%s.%s.svc.cluster.local", mirror.ServiceName, baseEnv.Namespace): true,
+	}
+	mirrored := false
+	for _, route := range vs.Spec.Http {
+		for _, dest := range route.Route {
+			if dest.Destination != nil && baseHosts[dest.Destination.Host] {
+				route.Mirror = mirrorDestination
+				route.MirrorPercentage = mirrorPercentage
+				mirrored = true
+				break
+			}
+		}
+	}
+	if !mirrored {
+		return fmt.Errorf("no route to service %s found in VirtualService %s", mirror.ServiceName, vs.Name)
+	}
+	if _, err := vsClient.Update(ctx, vs, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update VirtualService %s, err: %w", vs.Name, err)
+	}
+	mirror.VirtualServiceName = vs.Name
+	return nil
+}
+
+func (m *istioTrafficMirrorer) remove(ctx context.Context, mirror *commonmodels.EnvTrafficMirror, baseEnv, subEnv *commonmodels.Product) error {
+	vsClient := m.istioClient.NetworkingV1alpha3().VirtualServices(baseEnv.Namespace)
+	if mirror.VirtualServiceCreated {
+		err := vsClient.Delete(ctx, mirror.VirtualServiceName, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete VirtualService %s, err: %w", mirror.VirtualServiceName, err)
+		}
+		return nil
+	}
+
+	vs, err := vsClient.Get(ctx, mirror.VirtualServiceName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get VirtualService %s, err: %w", mirror.VirtualServiceName, err)
+	}
+
+	changed := false
+	for _, route := range vs.Spec.Http {
+		if route.Mirror == nil {
+			continue
+		}
+		// the sub env may have been deleted, the mirror to the service of the sub env namespace is removed anyway
+		if subEnv != nil && route.Mirror.Host != fmt.Sprintf("%s.%s.svc.cluster.local", mirror.ServiceName, subEnv.Namespace) {
+			continue
+		}
+		route.Mirror = nil
+		route.MirrorPercentage = nil
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	if _, err := vsClient.Update(ctx, vs, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update VirtualService %s, err: %w", vs.Name, err)
+	}
+	return nil
+}
+
+func (m *istioTrafficMirrorer) findVirtualService(ctx context.Context, ns, serviceName string) (*v1alpha3.VirtualService, error) {
+	vsList, err := m.istioClient.NetworkingV1alpha3().VirtualServices(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VirtualServices in ns %s, err: %w", ns, err)
+	}
+	for _, vs := range vsList.Items {
+		for _, host := range vs.Spec.Hosts {
+			if host == serviceName || host == fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, ns) {
+				return vs, nil
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,293 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/msg_queue"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notify"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+const (
+	envUpdateOperationK8s          = "k8s"
+	envUpdateOperationCVM          = "cvm"
+	envUpdateOperationHelm         = "helm"
+	envUpdateOperationHelmChart    = "helm_chart"
+	envUpdateOperationHelmVariable = "helm_variable"
+
+	envUpdateTaskHeartbeatInterval = 30 * time.Second
+	// a task is taken over by other aslan instances if its heartbeat is not refreshed in this period
+	envUpdateTaskStaleAfter   = 3 * time.Minute
+	envUpdateTaskScanInterval = time.Minute
+
+	envUpdateInterruptedMsg = "env update was interrupted by the restart of aslan, please update the env again"
+	// holder of the env lock when a resumed update is running
+	envUpdateRecoveryLockHolder = "env-update-recovery"
+)
+
+// envUpdateTask is the persistent state of an async env update, stored in the msg queue.
+// It outlives the goroutine running the update, so the update can be resumed or failed after aslan restarts.
+type envUpdateTask struct {
+	ProjectName string `json:"project_name"`
+	EnvName     string `json:"env_name"`
+	Production  bool   `json:"production"`
+	Operation   string `json:"operation"`
+	UserName    string `json:"user_name"`
+	RequestID   string `json:"request_id"`
+	Owner       string `json:"owner"`
+	StartTime   int64  `json:"start_time"`
+	Heartbeat   int64  `json:"heartbeat"`
+}
+
+// envUpdateResumers are the operations which can be completed from the state saved in db.
+// The updates of other operations are marked as failed once they are interrupted.
+var envUpdateResumers = map[string]func(task *envUpdateTask, log *zap.SugaredLogger) error{
+	envUpdateOperationHelmVariable: resumeHelmEnvDeploy,
+}
+
+func (t *envUpdateTask) encode() (string, error) {
+	bs, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+// runEnvUpdateTask runs the env update in background and writes the result into the env status.
+// The env status must have been set to updating before calling it.
+func runEnvUpdateTask(operation, projectName, envName string, production bool, userName, requestID string, update func() error, log *zap.SugaredLogger) {
+	now := time.Now().Unix()
+	task := &envUpdateTask{
+		ProjectName: projectName,
+		EnvName:     envName,
+		Production:  production,
+		Operation:   operation,
+		UserName:    userName,
+		RequestID:   requestID,
+		Owner:       config.PodName(),
+		StartTime:   now,
+		Heartbeat:   now,
+	}
+	payload, err := task.encode()
+	if err != nil {
+		log.Errorf("failed to encode env update task of %s/%s, err: %s", projectName, envName, err)
+	}
+
+	msg := &msg_queue.MsgQueueCommon{
+		ID:        primitive.NewObjectID(),
+		Payload:   payload,
+		QueueType: setting.TopicEnvUpdate,
+	}
+	persisted := err == nil
+	if persisted {
+		if err := commonrepo.NewMsgQueueCommonColl().Create(msg); err != nil {
+			// the update still runs, it just can not be recovered if aslan restarts
+			log.Errorf("failed to persist env update task of %s/%s, err: %s", projectName, envName, err)
+			persisted = false
+		}
+	}
+
+	// the env lock taken by the caller is released once the caller returns, the task takes its own reference so the
+	// env stays locked until the update is done
+	holder := &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: userName, RequestID: requestID}
+	releaseLock, locked := commonutil.RetainEnvLock(projectName, envName, holder)
+
+	go func() {
+		if persisted {
+			stop := make(chan struct{})
+			defer func() {
+				close(stop)
+				if err := commonrepo.NewMsgQueueCommonColl().Delete(msg.ID); err != nil {
+					log.Errorf("failed to delete env update task of %s/%s, err: %s", projectName, envName, err)
+				}
+			}()
+			go keepEnvUpdateTaskAlive(msg.ID, task, payload, stop, log)
+		}
+
+		if !locked {
+			unlock, err := commonutil.LockEnvs(projectName, []string{envName}, holder)
+			if err != nil {
+				finishEnvUpdate(task, err, log)
+				return
+			}
+			releaseLock = unlock
+		}
+		defer releaseLock()
+
+		finishEnvUpdate(task, update(), log)
+	}()
+}
+
+// ensureEnvRequestID gives the updates not started by a request, e.g. the ones started by cron, an id of their own,
+// so that the nested steps of the update join its env lock instead of waiting for it
+func ensureEnvRequestID(requestID string) string {
+	if requestID == "" {
+		return uuid.NewString()
+	}
+	return requestID
+}
+
+// keepEnvUpdateTaskAlive refreshes the heartbeat of the task until stop is closed or the task is taken over
+func keepEnvUpdateTaskAlive(id primitive.ObjectID, task *envUpdateTask, payload string, stop <-chan struct{}, log *zap.SugaredLogger) {
+	ticker := time.NewTicker(envUpdateTaskHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			task.Heartbeat = time.Now().Unix()
+			newPayload, err := task.encode()
+			if err != nil {
+				log.Errorf("failed to encode env update task of %s/%s, err: %s", task.ProjectName, task.EnvName, err)
+				continue
+			}
+			ok, err := commonrepo.NewMsgQueueCommonColl().UpdatePayload(id, payload, newPayload)
+			if err != nil {
+				log.Warnf("failed to refresh heartbeat of env update task %s/%s, err: %s", task.ProjectName, task.EnvName, err)
+				continue
+			}
+			if !ok {
+				log.Warnf("env update task %s/%s has been taken over by other instance", task.ProjectName, task.EnvName)
+				return
+			}
+			payload = newPayload
+		}
+	}
+}
+
+func finishEnvUpdate(task *envUpdateTask, updateErr error, log *zap.SugaredLogger) {
+	status, errMsg := setting.ProductStatusSuccess, ""
+	if updateErr != nil {
+		status, errMsg = setting.ProductStatusFailed, updateErr.Error()
+		log.Errorf("[%s][P:%s] failed to update product %#v", task.EnvName, task.ProjectName, updateErr)
+		// 发送更新产品失败消息给用户
+		title := fmt.Sprintf("更新 [%s] 的 [%s] 环境失败", task.ProjectName, task.EnvName)
+		notify.SendEnvErrorMessage(task.UserName, task.ProjectName, title, task.RequestID, updateErr, log)
+	}
+	if err := commonrepo.NewProductColl().UpdateStatusAndError(task.EnvName, task.ProjectName, status, errMsg); err != nil {
+		log.Errorf("[%s][%s] Product.Update set product status error: %v", task.EnvName, task.ProjectName, err)
+	}
+}
+
+// WatchInterruptedEnvUpdates takes over the env updates whose owner has stopped refreshing the heartbeat,
+// e.g. the aslan instance running it restarted, and completes or fails them so the envs won't be stuck in updating.
+func WatchInterruptedEnvUpdates() {
+	logger := log.SugaredLogger().With("watcher", "env_update")
+	for {
+		recoverInterruptedEnvUpdates(logger)
+		time.Sleep(envUpdateTaskScanInterval)
+	}
+}
+
+func recoverInterruptedEnvUpdates(log *zap.SugaredLogger) {
+	msgs, err := commonrepo.NewMsgQueueCommonColl().List(&commonrepo.ListMsgQueueCommonOption{QueueType: setting.TopicEnvUpdate})
+	if err != nil {
+		log.Errorf("failed to list env update tasks, err: %s", err)
+		return
+	}
+
+	for _, msg := range msgs {
+		task := &envUpdateTask{}
+		if err := json.Unmarshal([]byte(msg.Payload), task); err != nil {
+			log.Errorf("failed to decode env update task %s, err: %s", msg.ID.Hex(), err)
+			_ = commonrepo.NewMsgQueueCommonColl().Delete(msg.ID)
+			continue
+		}
+		if time.Since(time.Unix(task.Heartbeat, 0)) < envUpdateTaskStaleAfter {
+			continue
+		}
+
+		// claim the task, the heartbeat is refreshed so the other instances will skip it
+		previousOwner := task.Owner
+		task.Owner = config.PodName()
+		task.Heartbeat = time.Now().Unix()
+		payload, err := task.encode()
+		if err != nil {
+			log.Errorf("failed to encode env update task %s, err: %s", msg.ID.Hex(), err)
+			continue
+		}
+		ok, err := commonrepo.NewMsgQueueCommonColl().UpdatePayload(msg.ID, msg.Payload, payload)
+		if err != nil || !ok {
+			continue
+		}
+
+		log.Infof("taking over %s update of env %s/%s from %s", task.Operation, task.ProjectName, task.EnvName, previousOwner)
+		go resumeEnvUpdateTask(msg.ID, task, payload, log)
+	}
+}
+
+func resumeEnvUpdateTask(id primitive.ObjectID, task *envUpdateTask, payload string, log *zap.SugaredLogger) {
+	stop := make(chan struct{})
+	defer func() {
+		close(stop)
+		if err := commonrepo.NewMsgQueueCommonColl().Delete(id); err != nil {
+			log.Errorf("failed to delete env update task of %s/%s, err: %s", task.ProjectName, task.EnvName, err)
+		}
+	}()
+	go keepEnvUpdateTaskAlive(id, task, payload, stop, log)
+
+	resume, ok := envUpdateResumers[task.Operation]
+	if !ok {
+		finishEnvUpdate(task, errors.New(envUpdateInterruptedMsg), log)
+		return
+	}
+
+	unlock, err := commonutil.LockEnvs(task.ProjectName, []string{task.EnvName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderSystem, Name: envUpdateRecoveryLockHolder})
+	if err != nil {
+		finishEnvUpdate(task, err, log)
+		return
+	}
+	defer unlock()
+
+	finishEnvUpdate(task, resume(task, log), log)
+}
+
+// resumeHelmEnvDeploy redeploys all the releases in the env with the values saved in db
+func resumeHelmEnvDeploy(task *envUpdateTask, log *zap.SugaredLogger) error {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       task.ProjectName,
+		EnvName:    task.EnvName,
+		Production: util.GetBoolPointer(task.Production),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find env %s/%s, err: %w", task.ProjectName, task.EnvName, err)
+	}
+
+	helmClient, err := helmtool.NewClientFromNamespace(env.ClusterID, env.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to create helm client for env %s/%s, err: %w", task.ProjectName, task.EnvName, err)
+	}
+	return kube.DeployMultiHelmRelease(env, helmClient, nil, task.UserName, log)
+}
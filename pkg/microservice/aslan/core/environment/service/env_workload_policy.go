@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+var pdbPercentRegexp = regexp.MustCompile(`^[0-9]+%$`)
+
+func GetEnvWorkloadPolicy(projectName string) (*commonmodels.EnvWorkloadPolicy, error) {
+	policy, err := commonrepo.NewEnvWorkloadPolicyColl().GetByProject(projectName)
+	if err != nil {
+		return nil, e.ErrGetEnvWorkloadPolicy.AddErr(err)
+	}
+	if policy == nil {
+		return &commonmodels.EnvWorkloadPolicy{ProjectName: projectName, Rules: make([]*commonmodels.WorkloadPolicyRule, 0)}, nil
+	}
+	return policy, nil
+}
+
+// UpdateEnvWorkloadPolicy saves the workload policy of the project, it takes effect on the following deployments of
+// the services in the production envs
+func UpdateEnvWorkloadPolicy(projectName, userName string, policy *commonmodels.EnvWorkloadPolicy, log *zap.SugaredLogger) error {
+	for _, rule := range policy.Rules {
+		if err := validateWorkloadPolicyRule(rule); err != nil {
+			return e.ErrInvalidParam.AddDesc(err.Error())
+		}
+	}
+
+	policy.ProjectName = projectName
+	policy.UpdatedBy = userName
+	if err := commonrepo.NewEnvWorkloadPolicyColl().Upsert(policy); err != nil {
+		log.Errorf("failed to update workload policy of project %s, err: %s", projectName, err)
+		return e.ErrUpdateEnvWorkloadPolicy.AddErr(err)
+	}
+	return nil
+}
+
+func validateWorkloadPolicyRule(rule *commonmodels.WorkloadPolicyRule) error {
+	for _, serviceType := range rule.ServiceTypes {
+		switch serviceType {
+		case setting.K8SDeployType, setting.HelmDeployType, setting.HelmChartDeployType:
+		default:
+			return fmt.Errorf("unsupported service type %s", serviceType)
+		}
+	}
+	if rule.PriorityClassName != "" {
+		if errs := validation.IsDNS1123Subdomain(rule.PriorityClassName); len(errs) > 0 {
+			return fmt.Errorf("invalid priority class name %s: %s", rule.PriorityClassName, strings.Join(errs, ","))
+		}
+	}
+	if rule.PDB == nil || !rule.PDB.Enabled {
+		return nil
+	}
+	if rule.PDB.MinAvailable != "" && rule.PDB.MaxUnavailable != "" {
+		return fmt.Errorf("only one of min available and max unavailable can be set")
+	}
+	for _, value := range []string{rule.PDB.MinAvailable, rule.PDB.MaxUnavailable} {
+		if value == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(value, 10, 32); err != nil && !pdbPercentRegexp.MatchString(value) {
+			return fmt.Errorf("invalid pdb value %s, should be a number or a percentage", value)
+		}
+	}
+	return nil
+}
@@ -52,6 +52,7 @@ import (
 	airepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/ai"
 	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	aiservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/ai"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/collaboration"
 	helmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/helm"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/imnotify"
@@ -154,6 +155,7 @@ func ListProducts(userID, projectName string, envNames []string, production bool
 	if err != nil {
 		return nil, err
 	}
+	envURLs := getEnvURLs(projectName)
 	for _, env := range envs {
 		if len(env.RegistryID) == 0 {
 			env.RegistryID = defaultRegID
@@ -165,6 +167,7 @@ func ListProducts(userID, projectName string, envNames []string, production bool
 		}
 		res = append(res, &EnvResp{
 			ProjectName:           projectName,
+			URLs:                  envURLs[env.EnvName],
 			Name:                  env.EnvName,
 			IsPublic:              env.IsPublic,
 			IsExisted:             env.IsExisted,
@@ -541,14 +544,11 @@ type UpdateEnv struct {
 }
 
 func UpdateMultipleK8sEnv(args []*UpdateEnv, envNames []string, productName, requestID string, force, production bool, username string, log *zap.SugaredLogger) ([]*EnvStatus, error) {
-	mutexAutoUpdate := cache.NewRedisLock(fmt.Sprintf("update_multiple_product:%s", productName))
-	err := mutexAutoUpdate.Lock()
+	unlock, err := commonutil.LockEnvs(productName, envNames, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: username, RequestID: requestID})
 	if err != nil {
-		return nil, e.ErrUpdateEnv.AddErr(fmt.Errorf("failed to acquire lock, err: %s", err))
+		return nil, e.ErrUpdateEnv.AddErr(err)
 	}
-	defer func() {
-		mutexAutoUpdate.Unlock()
-	}()
+	defer unlock()
 
 	envStatuses := make([]*EnvStatus, 0)
 
@@ -788,6 +788,11 @@ func updateProductImpl(updateRevisionSvcs []string, deployStrategy map[string]st
 						return
 					}
 
+					revisionDiff := ""
+					if curSvc := curEnv.GetServiceMap()[service.ServiceName]; curSvc != nil && curSvc.Revision != service.Revision {
+						revisionDiff = getRevisionBumpDiff(updateProd, service, curSvc.Revision, log)
+					}
+
 					items, errUpsertService := upsertService(
 						updateProd,
 						service,
@@ -800,7 +805,7 @@ func updateProductImpl(updateRevisionSvcs []string, deployStrategy map[string]st
 					}
 					service.Resources = kube.UnstructuredToResources(items)
 
-					err = commonutil.CreateEnvServiceVersion(updateProd, service, user, session, log)
+					err = commonutil.CreateEnvServiceVersionWithDiff(updateProd, service, user, revisionDiff, session, log)
 					if err != nil {
 						log.Errorf("CreateK8SEnvServiceVersion error: %v", err)
 					}
@@ -878,7 +883,7 @@ func updateProductImpl(updateRevisionSvcs []string, deployStrategy map[string]st
 		}
 	}
 
-	return mongotool.CommitTransaction(session)
+	return mongotool.CommitTransactionWithRetry(session, "updateProductImpl")
 }
 
 func UpdateProductRegistry(envName, productName, registryID string, production bool, log *zap.SugaredLogger) (err error) {
@@ -939,6 +944,12 @@ func UpdateMultiCVMProducts(envNames []string, productName, user, requestID stri
 }
 
 func UpdateCVMProduct(envName, productName, user, requestID string, log *zap.SugaredLogger) error {
+	unlock, err := commonutil.LockEnvs(productName, []string{envName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: user, RequestID: requestID})
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	defer unlock()
+
 	opt := &commonrepo.ProductFindOptions{Name: productName, EnvName: envName}
 	exitedProd, err := commonrepo.NewProductColl().Find(opt)
 	if err != nil {
@@ -951,9 +962,16 @@ func UpdateCVMProduct(envName, productName, user, requestID string, log *zap.Sug
 // CreateProduct create a new product with its dependent stacks
 func CreateProduct(user, requestID string, args *ProductCreateArg, log *zap.SugaredLogger) (err error) {
 	log.Infof("[%s][P:%s] CreateProduct", args.EnvName, args.ProductName)
+	if err := commonservice.CheckProjectEnvQuota(args.ProductName); err != nil {
+		return err
+	}
 	creator := getCreatorBySource(args.Source)
 	args.UpdateBy = user
-	return creator.Create(user, requestID, args, log)
+	err = creator.Create(user, requestID, args, log)
+	if err == nil {
+		go syncEnvDNSRecordsOnCreation(args.ProductName, args.EnvName, args.Production, log)
+	}
+	return err
 }
 
 func UpdateProductRecycleDay(envName, productName string, recycleDay int) error {
@@ -979,6 +997,31 @@ func UpdateProductAlias(envName, productName, alias string, production bool) err
 	return nil
 }
 
+// SetEnvImageSigningPolicy sets the key used to verify the images before rolling out to the production environment
+func SetEnvImageSigningPolicy(projectName, envName, keyID string, log *zap.SugaredLogger) error {
+	production := true
+	_, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return e.ErrSetEnvImageSigningPolicy.AddErr(fmt.Errorf("failed to find production env %s, error: %v", envName, err))
+	}
+	if keyID != "" {
+		if _, err := commonrepo.NewImageSigningKeyColl().GetByID(keyID); err != nil {
+			return e.ErrSetEnvImageSigningPolicy.AddErr(fmt.Errorf("failed to find image signing key %s, error: %v", keyID, err))
+		}
+	}
+
+	err = commonrepo.NewProductColl().UpdateImageSigningKey(envName, projectName, keyID)
+	if err != nil {
+		log.Errorf("failed to set image signing policy of env %s/%s, error: %v", projectName, envName, err)
+		return e.ErrSetEnvImageSigningPolicy.AddErr(err)
+	}
+	return nil
+}
+
 func updateHelmProduct(productName, envName, username, requestID string, overrideCharts []*commonservice.HelmSvcRenderArg, deletedServices []string, log *zap.SugaredLogger) error {
 	opt := &commonrepo.ProductFindOptions{Name: productName, EnvName: envName}
 	productResp, err := commonrepo.NewProductColl().Find(opt)
@@ -1129,26 +1172,9 @@ func updateHelmProduct(productName, envName, username, requestID string, overrid
 		return e.ErrUpdateEnv.AddDesc(e.UpdateEnvStatusErrMsg)
 	}
 
-	go func() {
-		errMsg := ""
-		err := updateHelmProductGroup(username, productName, envName, productResp, overrideCharts, deletedSvcRevision, addedReleaseNameSet, filter, log)
-		if err != nil {
-			errMsg = err.Error()
-			log.Errorf("[%s][P:%s] failed to update product %#v", envName, productName, err)
-			// 发送更新产品失败消息给用户
-			title := fmt.Sprintf("更新 [%s] 的 [%s] 环境失败", productName, envName)
-			notify.SendErrorMessage(username, title, requestID, err, log)
-
-			log.Infof("[%s][P:%s] update error to => %s", envName, productName, err)
-			productResp.Status = setting.ProductStatusFailed
-		} else {
-			productResp.Status = setting.ProductStatusSuccess
-		}
-		if err = commonrepo.NewProductColl().UpdateStatusAndError(envName, productName, productResp.Status, errMsg); err != nil {
-			log.Errorf("[%s][%s] Product.Update error: %v", envName, productName, err)
-			return
-		}
-	}()
+	runEnvUpdateTask(envUpdateOperationHelm, productName, envName, productResp.Production, username, requestID, func() error {
+		return updateHelmProductGroup(username, productName, envName, productResp, overrideCharts, deletedSvcRevision, addedReleaseNameSet, filter, log)
+	}, log)
 	return nil
 }
 
@@ -1294,26 +1320,9 @@ func updateHelmChartProduct(productName, envName, username, requestID string, ov
 		return e.ErrUpdateEnv.AddDesc(e.UpdateEnvStatusErrMsg)
 	}
 
-	go func() {
-		errMsg := ""
-		err := updateHelmChartProductGroup(username, productName, envName, productResp, overrideCharts, deletedReleaseRevision, dupSvcNameSet, filter, log)
-		if err != nil {
-			errMsg = err.Error()
-			log.Errorf("[%s][P:%s] failed to update product %#v", envName, productName, err)
-			// 发送更新产品失败消息给用户
-			title := fmt.Sprintf("更新 [%s] 的 [%s] 环境失败", productName, envName)
-			notify.SendErrorMessage(username, title, requestID, err, log)
-
-			log.Infof("[%s][P:%s] update error to => %s", envName, productName, err)
-			productResp.Status = setting.ProductStatusFailed
-		} else {
-			productResp.Status = setting.ProductStatusSuccess
-		}
-		if err = commonrepo.NewProductColl().UpdateStatusAndError(envName, productName, productResp.Status, errMsg); err != nil {
-			log.Errorf("[%s][%s] Product.Update error: %v", envName, productName, err)
-			return
-		}
-	}()
+	runEnvUpdateTask(envUpdateOperationHelmChart, productName, envName, productResp.Production, username, requestID, func() error {
+		return updateHelmChartProductGroup(username, productName, envName, productResp, overrideCharts, deletedReleaseRevision, dupSvcNameSet, filter, log)
+	}, log)
 	return nil
 }
 
@@ -1663,6 +1672,18 @@ func UpdateProductDefaultValues(productName, envName, userName, requestID string
 	if err != nil {
 		return err
 	}
+	defaultValues := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(args.DefaultValues), &defaultValues); err == nil {
+		if err := commonservice.CheckCredentials(fmt.Sprintf("default values of env %s/%s", productName, envName), defaultValues, log); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := commonutil.LockEnvs(productName, []string{envName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: userName, RequestID: requestID})
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	defer unlock()
 
 	product, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
 		Name:       productName,
@@ -1729,11 +1750,18 @@ func UpdateProductDefaultValuesWithRender(product *commonmodels.Product, _ *mode
 	return UpdateProductVariable(product.ProductName, product.EnvName, userName, requestID, updatedSvcList, nil, product.DefaultValues, product.YamlData, log)
 }
 
-func UpdateHelmProductCharts(productName, envName, userName, requestID string, production bool, args *EnvRendersetArg, log *zap.SugaredLogger) error {
+func UpdateHelmProductCharts(productName, envName, userName, requestID string, production, canOverrideLockedValues bool, args *EnvRendersetArg, log *zap.SugaredLogger) error {
 	if len(args.ChartValues) == 0 {
 		return nil
 	}
 
+	requestID = ensureEnvRequestID(requestID)
+	unlock, err := commonutil.LockEnvs(productName, []string{envName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: userName, RequestID: requestID})
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	defer unlock()
+
 	product, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
 		Name:       productName,
 		EnvName:    envName,
@@ -1757,6 +1785,14 @@ func UpdateHelmProductCharts(productName, envName, userName, requestID string, p
 		valuesInRenderset[rc.ServiceName] = rc
 	}
 
+	if !canOverrideLockedValues {
+		for serviceName, arg := range requestValueMap {
+			if err := checkLockedValuesKeys(product, valuesInRenderset[serviceName], arg); err != nil {
+				return err
+			}
+		}
+	}
+
 	updatedRcMap := make(map[string]*templatemodels.ServiceRender)
 	changedCharts := make([]*commonservice.HelmSvcRenderArg, 0)
 
@@ -1809,6 +1845,22 @@ func UpdateHelmProductCharts(productName, envName, userName, requestID string, p
 	}
 }
 
+func checkLockedValuesKeys(product *commonmodels.Product, curRender *templatemodels.ServiceRender, arg *commonservice.HelmSvcRenderArg) error {
+	curValues := ""
+	if curRender != nil {
+		var err error
+		curValues, err = helmservice.MergeServiceOverrideValues(curRender.GetOverrideYaml(), curRender.OverrideValues)
+		if err != nil {
+			return e.ErrUpdateEnv.AddErr(fmt.Errorf("failed to merge current values of service %s, err: %w", arg.ServiceName, err))
+		}
+	}
+	newValues, err := helmservice.MergeServiceOverrideValues(arg.OverrideYaml, arg.ToOverrideValueString())
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(fmt.Errorf("failed to merge values of service %s, err: %w", arg.ServiceName, err))
+	}
+	return helmservice.CheckLockedValuesKeys(product.ProductName, arg.ServiceName, product.Production, curValues, newValues)
+}
+
 func geneYamlData(args *commonservice.ValuesDataArgs) *templatemodels.CustomYaml {
 	if args == nil {
 		return nil
@@ -1840,6 +1892,13 @@ func geneYamlData(args *commonservice.ValuesDataArgs) *templatemodels.CustomYaml
 }
 
 func SyncHelmProductEnvironment(productName, envName, requestID string, log *zap.SugaredLogger) error {
+	requestID = ensureEnvRequestID(requestID)
+	unlock, err := commonutil.LockEnvs(productName, []string{envName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: "cron", RequestID: requestID})
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	defer unlock()
+
 	product, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
 		Name:    productName,
 		EnvName: envName,
@@ -1895,6 +1954,13 @@ func SyncHelmProductEnvironment(productName, envName, requestID string, log *zap
 
 func UpdateProductVariable(productName, envName, username, requestID string, updatedSvcs []*templatemodels.ServiceRender,
 	_ []*commontypes.GlobalVariableKV, defaultValue string, yamlData *templatemodels.CustomYaml, log *zap.SugaredLogger) error {
+	requestID = ensureEnvRequestID(requestID)
+	unlock, err := commonutil.LockEnvs(productName, []string{envName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: username, RequestID: requestID})
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	defer unlock()
+
 	opt := &commonrepo.ProductFindOptions{Name: productName, EnvName: envName}
 	productResp, err := commonrepo.NewProductColl().Find(opt)
 	if err != nil {
@@ -1963,32 +2029,18 @@ func updateHelmProductVariable(productResp *commonmodels.Product, userName, requ
 		return e.ErrUpdateEnv.AddDesc(e.UpdateEnvStatusErrMsg)
 	}
 
-	go func() {
-		err := kube.DeployMultiHelmRelease(productResp, helmClient, nil, userName, log)
-		if err != nil {
-			log.Errorf("error occurred when upgrading services in env: %s/%s, err: %s ", productName, envName, err)
-			// 发送更新产品失败消息给用户
-			title := fmt.Sprintf("更新 [%s] 的 [%s] 环境失败", productName, envName)
-			notify.SendErrorMessage(userName, title, requestID, err, log)
-		}
-		productResp.Status = setting.ProductStatusSuccess
-		if err = commonrepo.NewProductColl().UpdateStatusAndError(envName, productName, productResp.Status, ""); err != nil {
-			log.Errorf("[%s][%s] Product.Update error: %v", envName, productName, err)
-			return
-		}
-	}()
+	runEnvUpdateTask(envUpdateOperationHelmVariable, productName, envName, productResp.Production, userName, requestID, func() error {
+		return kube.DeployMultiHelmRelease(productResp, helmClient, nil, userName, log)
+	}, log)
 	return nil
 }
 
 func UpdateMultipleHelmEnv(requestID, userName string, args *UpdateMultiHelmProductArg, production bool, log *zap.SugaredLogger) ([]*EnvStatus, error) {
-	mutexAutoUpdate := cache.NewRedisLock(fmt.Sprintf("update_multiple_product:%s", args.ProductName))
-	err := mutexAutoUpdate.Lock()
+	unlock, err := commonutil.LockEnvs(args.ProductName, args.EnvNames, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: userName, RequestID: requestID})
 	if err != nil {
-		return nil, e.ErrUpdateEnv.AddErr(fmt.Errorf("failed to acquire lock, err: %s", err))
+		return nil, e.ErrUpdateEnv.AddErr(err)
 	}
-	defer func() {
-		mutexAutoUpdate.Unlock()
-	}()
+	defer unlock()
 
 	envNames, productName := args.EnvNames, args.ProductName
 
@@ -2058,15 +2110,11 @@ func UpdateMultipleHelmEnv(requestID, userName string, args *UpdateMultiHelmProd
 }
 
 func UpdateMultipleHelmChartEnv(requestID, userName string, args *UpdateMultiHelmProductArg, production bool, log *zap.SugaredLogger) ([]*EnvStatus, error) {
-	mutexUpdateMultiHelm := cache.NewRedisLock(fmt.Sprintf("update_multiple_product:%s", args.ProductName))
-
-	err := mutexUpdateMultiHelm.Lock()
+	unlock, err := commonutil.LockEnvs(args.ProductName, args.EnvNames, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: userName, RequestID: requestID})
 	if err != nil {
-		return nil, e.ErrUpdateEnv.AddErr(fmt.Errorf("failed to acquire lock, err: %s", err))
+		return nil, e.ErrUpdateEnv.AddErr(err)
 	}
-	defer func() {
-		mutexUpdateMultiHelm.Unlock()
-	}()
+	defer unlock()
 
 	envNames, productName := args.EnvNames, args.ProductName
 
@@ -2119,6 +2167,15 @@ func UpdateMultipleHelmChartEnv(requestID, userName string, args *UpdateMultiHel
 }
 
 func DeleteProduct(username, envName, productName, requestID string, isDelete bool, log *zap.SugaredLogger) (err error) {
+	defer func() {
+		if err == nil {
+			go removeEnvDNSRecords(productName, envName, log)
+			go removeEnvTrafficMirrors(productName, envName, log)
+			go removeEnvAutoscalers(productName, envName, log)
+			go removeEnvHealthScores(productName, envName, false, log)
+		}
+	}()
+
 	eventStart := time.Now().Unix()
 	productInfo, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
 		Name:       productName,
@@ -2189,12 +2246,12 @@ func DeleteProduct(username, envName, productName, requestID string, isDelete bo
 			defer func() {
 				if errList.ErrorOrNil() != nil {
 					title := fmt.Sprintf("删除项目:[%s] 环境:[%s] 失败!", productName, envName)
-					notify.SendErrorMessage(username, title, requestID, errList.ErrorOrNil(), log)
+					notify.SendEnvErrorMessage(username, productName, title, requestID, errList.ErrorOrNil(), log)
 					_ = commonrepo.NewProductColl().UpdateStatus(envName, productName, setting.ProductStatusUnknown)
 				} else {
 					title := fmt.Sprintf("删除项目:[%s] 环境:[%s] 成功!", productName, envName)
 					content := fmt.Sprintf("namespace:%s", productInfo.Namespace)
-					notify.SendMessage(username, title, content, requestID, log)
+					notify.SendEnvMessage(username, productName, title, content, requestID, log)
 				}
 			}()
 
@@ -2254,12 +2311,12 @@ func DeleteProduct(username, envName, productName, requestID string, isDelete bo
 			defer func() {
 				if err != nil {
 					title := fmt.Sprintf("删除项目:[%s] 环境:[%s] 失败!", productName, envName)
-					notify.SendErrorMessage(username, title, requestID, err, log)
+					notify.SendEnvErrorMessage(username, productName, title, requestID, err, log)
 					_ = commonrepo.NewProductColl().UpdateStatus(envName, productName, setting.ProductStatusUnknown)
 				} else {
 					title := fmt.Sprintf("删除项目:[%s] 环境:[%s] 成功!", productName, envName)
 					content := fmt.Sprintf("namespace:%s", productInfo.Namespace)
-					notify.SendMessage(username, title, content, requestID, log)
+					notify.SendEnvMessage(username, productName, title, content, requestID, log)
 				}
 			}()
 
@@ -2525,7 +2582,7 @@ func createGroups(user, requestID string, args *commonmodels.Product, eventStart
 
 			// 发送创建产品失败消息给用户
 			title := fmt.Sprintf("创建 [%s] 的 [%s] 环境失败:%s", args.ProductName, args.EnvName, errorMsg)
-			notify.SendErrorMessage(user, title, requestID, err, log)
+			notify.SendEnvErrorMessage(user, args.ProductName, title, requestID, err, log)
 		}
 
 		commonservice.LogProductStats(envName, setting.CreateProductEvent, args.ProductName, requestID, eventStart, log)
@@ -2807,7 +2864,7 @@ func installProductHelmCharts(user, requestID string, args *commonmodels.Product
 	defer func() {
 		if err != nil {
 			title := fmt.Sprintf("创建 [%s] 的 [%s] 环境失败", args.ProductName, args.EnvName)
-			notify.SendErrorMessage(user, title, requestID, err, log)
+			notify.SendEnvErrorMessage(user, args.ProductName, title, requestID, err, log)
 		}
 
 		commonservice.LogProductStats(envName, setting.CreateProductEvent, args.ProductName, requestID, eventStart, log)
@@ -3039,6 +3096,7 @@ func mergeRenderSetAndRenderChart(productResp *commonmodels.Product, overrideCha
 				continue
 			}
 			if requestArg, ok := requestChartInfoMap[svc.ReleaseName]; ok && !svc.FromZadig() {
+				requestArg.InheritSchedulingHints(svc.Render)
 				svc.Render = requestArg
 			}
 			updatedGroup = append(updatedGroup, svc)
@@ -3209,6 +3267,16 @@ func PreviewHelmProductGlobalVariables(productName, envName, globalVariable stri
 }
 
 func UpdateProductGlobalVariables(productName, envName, userName, requestID string, currentRevision int64, arg []*commontypes.GlobalVariableKV, production bool, log *zap.SugaredLogger) error {
+	if err := commonservice.CheckCredentials(fmt.Sprintf("global variables of env %s/%s", productName, envName), arg, log); err != nil {
+		return err
+	}
+
+	unlock, err := commonutil.LockEnvs(productName, []string{envName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: userName, RequestID: requestID})
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	defer unlock()
+
 	product, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
 		Name:       productName,
 		EnvName:    envName,
@@ -3374,6 +3442,7 @@ func UpdateProductGlobalVariablesWithRender(templateProduct *templatemodels.Prod
 type EnvConfigsArgs struct {
 	AnalysisConfig      *models.AnalysisConfig       `json:"analysis_config"`
 	NotificationConfigs []*models.NotificationConfig `json:"notification_configs"`
+	HealthConfig        *models.EnvHealthConfig      `json:"health_config"`
 }
 
 func GetEnvConfigs(projectName, envName string, production *bool, logger *zap.SugaredLogger) (*EnvConfigsArgs, error) {
@@ -3396,9 +3465,15 @@ func GetEnvConfigs(projectName, envName string, production *bool, logger *zap.Su
 		notificationConfigs = env.NotificationConfigs
 	}
 
+	healthConfig := &models.EnvHealthConfig{}
+	if env.HealthConfig != nil {
+		healthConfig = env.HealthConfig
+	}
+
 	configs := &EnvConfigsArgs{
 		AnalysisConfig:      analysisConfig,
 		NotificationConfigs: notificationConfigs,
+		HealthConfig:        healthConfig,
 	}
 	return configs, nil
 }
@@ -3421,7 +3496,11 @@ func UpdateEnvConfigs(projectName, envName string, arg *EnvConfigsArgs, producti
 		}
 	}
 
-	err = commonrepo.NewProductColl().UpdateConfigs(envName, projectName, arg.AnalysisConfig, arg.NotificationConfigs)
+	if err := validateEnvHealthConfig(arg.HealthConfig); err != nil {
+		return e.ErrUpdateEnvConfigs.AddErr(err)
+	}
+
+	err = commonrepo.NewProductColl().UpdateConfigs(envName, projectName, arg.AnalysisConfig, arg.NotificationConfigs, arg.HealthConfig)
 	if err != nil {
 		return e.ErrUpdateEnvConfigs.AddErr(fmt.Errorf("failed to update environment %s/%s, err: %w", projectName, envName, err))
 	}
@@ -3439,9 +3518,19 @@ func UpdateProductionEnvConfigs(projectName, envName string, arg *EnvConfigsArgs
 
 type EnvAnalysisRespone struct {
 	Result string `json:"result"`
+
+	ID       string                   `json:"id,omitempty"`
+	Findings []*ai.EnvAnalysisFinding `json:"findings,omitempty"`
 }
 
-func EnvAnalysis(projectName, envName string, production *bool, triggerName string, userName string, logger *zap.SugaredLogger) (*EnvAnalysisRespone, error) {
+// EnvAnalysisArgs scopes the analysis to the services and workloads in the form of Kind/name, the scope in the analysis
+// config of the env is used if neither is set
+type EnvAnalysisArgs struct {
+	ServiceNames []string `json:"service_names"`
+	Workloads    []string `json:"workloads"`
+}
+
+func EnvAnalysis(projectName, envName string, production *bool, args *EnvAnalysisArgs, triggerName string, userName string, logger *zap.SugaredLogger) (*EnvAnalysisRespone, error) {
 	var err error
 	start := time.Now()
 	// get project detail
@@ -3458,6 +3547,7 @@ func EnvAnalysis(projectName, envName string, production *bool, triggerName stri
 		Production:  *production,
 		StartTime:   start.Unix(),
 	}
+	resp := &EnvAnalysisRespone{}
 	defer func() {
 		if err != nil {
 			result.Err = err.Error()
@@ -3469,10 +3559,11 @@ func EnvAnalysis(projectName, envName string, production *bool, triggerName stri
 		err = airepo.NewEnvAIAnalysisColl().Create(result)
 		if err != nil {
 			logger.Errorf("failed to add env ai analysis result to db, err: %s", err)
+		} else {
+			resp.ID = result.ID.Hex()
 		}
 	}()
 
-	resp := &EnvAnalysisRespone{}
 	opt := &commonrepo.ProductFindOptions{
 		EnvName:    envName,
 		Name:       projectName,
@@ -3494,39 +3585,29 @@ func EnvAnalysis(projectName, envName string, production *bool, triggerName stri
 		}
 	}
 
-	ctx := context.TODO()
-	llmClient, err := commonservice.GetDefaultLLMClient(ctx)
-	if err != nil {
-		return resp, e.ErrAnalysisEnvResource.AddErr(fmt.Errorf("failed to get llm client, err: %w", err))
-	}
-
-	analysiser, err := analysis.NewAnalysis(
-		ctx, env.ClusterID,
-		llmClient,
-		filters, env.Namespace,
-		false, // noCache bool
-		true,  // explain bool
-		10,    // maxConcurrency int
-		false, // withDoc bool
-	)
-	if err != nil {
-		return resp, e.ErrAnalysisEnvResource.AddErr(fmt.Errorf("failed to create analysiser, err: %w", err))
+	if args == nil || (len(args.ServiceNames) == 0 && len(args.Workloads) == 0) {
+		args = &EnvAnalysisArgs{}
+		if env.AnalysisConfig != nil {
+			args.ServiceNames = env.AnalysisConfig.ServiceNames
+			args.Workloads = env.AnalysisConfig.Workloads
+		}
 	}
-
-	analysiser.RunAnalysis(filters)
-	err = analysiser.GetAIResults(false)
+	result.ServiceNames = args.ServiceNames
+	result.Workloads = args.Workloads
+	scope, err := aiservice.GetEnvAnalysisScope(env, args.ServiceNames, args.Workloads)
 	if err != nil {
-		return resp, e.ErrAnalysisEnvResource.AddErr(fmt.Errorf("failed to get analysis result, err: %w", err))
+		return resp, e.ErrAnalysisEnvResource.AddErr(err)
 	}
 
-	analysisResult, err := analysiser.PrintOutput("text")
+	analysisOutput, err := aiservice.AnalyzeEnv(context.TODO(), env, filters, scope)
 	if err != nil {
-		return resp, e.ErrAnalysisEnvResource.AddErr(fmt.Errorf("failed to print analysis result, err: %w", err))
+		return resp, e.ErrAnalysisEnvResource.AddErr(err)
 	}
+	analysisResult := analysisOutput.Result
 
 	if triggerName == setting.CronTaskCreator {
 		util.Go(func() {
-			err := EnvAnalysisNotification(projectName, envName, string(analysisResult), env.NotificationConfigs)
+			err := EnvAnalysisNotification(projectName, envName, analysisResult, env.NotificationConfigs)
 			if err != nil {
 				log.Errorf("failed to send notification, err: %w", err)
 			} else {
@@ -3534,9 +3615,11 @@ func EnvAnalysis(projectName, envName string, production *bool, triggerName stri
 			}
 		})
 	}
-	result.Result = string(analysisResult)
+	result.Result = analysisResult
+	result.Findings = analysisOutput.Findings
 
-	resp.Result = string(analysisResult)
+	resp.Result = analysisResult
+	resp.Findings = analysisOutput.Findings
 	return resp, nil
 }
 
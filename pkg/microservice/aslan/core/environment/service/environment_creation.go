@@ -249,6 +249,8 @@ func CreateHelmProduct(productName, userName, requestID string, args []*CreateSi
 		err = createSingleHelmProduct(templateProduct, requestID, userName, arg.RegistryID, arg, templateServiceMap, log)
 		if err != nil {
 			errList = multierror.Append(errList, err)
+		} else {
+			go syncEnvDNSRecordsOnCreation(productName, arg.EnvName, arg.Production, log)
 		}
 	}
 	return errList.ErrorOrNil()
@@ -361,6 +363,8 @@ func CreateYamlProduct(productName, userName, requestID string, args []*CreateSi
 		err = createSingleYamlProduct(templateProduct, requestID, userName, arg, log)
 		if err != nil {
 			errList = multierror.Append(errList, err)
+		} else {
+			go syncEnvDNSRecordsOnCreation(productName, arg.EnvName, arg.Production, log)
 		}
 	}
 	return errList.ErrorOrNil()
@@ -73,6 +73,9 @@ type EnvResp struct {
 	IstioGrayscaleEnable  bool   `json:"istio_grayscale_enable"`
 	IstioGrayscaleIsBase  bool   `json:"istio_grayscale_is_base"`
 	IstioGrayscaleBaseEnv string `json:"istio_grayscale_base_env"`
+
+	// URLs are the service urls with dns records managed by the dns integration
+	URLs []string `json:"urls"`
 }
 
 type SharedNSEnvs struct {
@@ -114,6 +117,7 @@ type ProductResp struct {
 	IstioGrayscaleIsBase  bool                       `json:"istio_grayscale_is_base"`
 	IstioGrayscaleBaseEnv string                     `json:"istio_grayscale_base_env"`
 	YamlData              *templatemodels.CustomYaml `json:"yaml_data,omitempty"` // used for cron service
+	ResourceVersion       int64                      `json:"resource_version"`
 }
 
 type ProductParams struct {
@@ -37,6 +37,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notify"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/repository"
 	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
 	"github.com/koderover/zadig/v2/pkg/setting"
 	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
@@ -272,7 +273,15 @@ func updateK8sSvcInAllEnvs(productName string, templateSvc *commonmodels.Service
 			}
 			return false
 		}
-		err = updateK8sProduct(product, "system", "", []string{svcRender.ServiceName}, filter, []*templatemodels.ServiceRender{svcRender}, nil, false, product.GlobalVariables, log.SugaredLogger())
+		err = func() error {
+			requestID := ensureEnvRequestID("")
+			unlock, err := commonutil.LockEnvs(product.ProductName, []string{product.EnvName}, &commonutil.EnvLockHolder{Type: commonutil.EnvLockHolderUser, Name: "system", RequestID: requestID})
+			if err != nil {
+				return err
+			}
+			defer unlock()
+			return updateK8sProduct(product, "system", requestID, []string{svcRender.ServiceName}, filter, []*templatemodels.ServiceRender{svcRender}, nil, false, product.GlobalVariables, log.SugaredLogger())
+		}()
 		if err != nil {
 			retErr = multierror.Append(retErr, err)
 		}
@@ -416,6 +425,7 @@ func updateK8sProduct(exitedProd *commonmodels.Product, user, requestID string,
 
 	for _, updateProdSvc := range updateProd.GetServiceMap() {
 		if svcRender, ok := updatedSvcMap[updateProdSvc.ServiceName]; ok {
+			svcRender.InheritSchedulingHints(updateProdSvc.Render)
 			updateProdSvc.Render = svcRender
 		}
 	}
@@ -485,24 +495,9 @@ func updateK8sProduct(exitedProd *commonmodels.Product, user, requestID string,
 		return e.ErrUpdateEnv.AddDesc(e.UpdateEnvStatusErrMsg)
 	}
 
-	go func() {
-		productErrMsg := ""
-		err = updateProductImpl(updateRevisionSvc, deployStrategy, exitedProd, updateProd, filter, user, log)
-		if err != nil {
-			log.Errorf("[%s][P:%s] failed to update product %#v", envName, productName, err)
-			// 发送更新产品失败消息给用户
-			title := fmt.Sprintf("更新 [%s] 的 [%s] 环境失败", productName, envName)
-			notify.SendErrorMessage(user, title, requestID, err, log)
-			updateProd.Status = setting.ProductStatusFailed
-			productErrMsg = err.Error()
-		} else {
-			updateProd.Status = setting.ProductStatusSuccess
-		}
-		if err = commonrepo.NewProductColl().UpdateStatusAndError(envName, productName, updateProd.Status, productErrMsg); err != nil {
-			log.Errorf("[%s][%s] Product.Update set product status error: %v", envName, productName, err)
-			return
-		}
-	}()
+	runEnvUpdateTask(envUpdateOperationK8s, productName, envName, exitedProd.Production, user, requestID, func() error {
+		return updateProductImpl(updateRevisionSvc, deployStrategy, exitedProd, updateProd, filter, user, log)
+	}, log)
 
 	return nil
 }
@@ -546,25 +541,9 @@ func updateCVMProduct(exitedProd *commonmodels.Product, user, requestID string,
 		return e.ErrUpdateEnv.AddDesc(e.UpdateEnvStatusErrMsg)
 	}
 
-	go func() {
-		productErrMsg := ""
-		err = updateProductImpl(serviceNames, nil, exitedProd, updateProd, nil, user, log)
-		if err != nil {
-			productErrMsg = err.Error()
-			log.Errorf("[%s][P:%s] failed to update product %#v", envName, productName, err)
-			// 发送更新产品失败消息给用户›
-			title := fmt.Sprintf("更新 [%s] 的 [%s] 环境失败", productName, envName)
-			notify.SendErrorMessage(user, title, requestID, err, log)
-			updateProd.Status = setting.ProductStatusFailed
-			productErrMsg = err.Error()
-		} else {
-			updateProd.Status = setting.ProductStatusSuccess
-		}
-		if err = commonrepo.NewProductColl().UpdateStatusAndError(envName, productName, updateProd.Status, productErrMsg); err != nil {
-			log.Errorf("[%s][%s] Product.Update set product status error: %v", envName, productName, err)
-			return
-		}
-	}()
+	runEnvUpdateTask(envUpdateOperationCVM, productName, envName, exitedProd.Production, user, requestID, func() error {
+		return updateProductImpl(serviceNames, nil, exitedProd, updateProd, nil, user, log)
+	}, log)
 
 	return nil
 }
@@ -580,7 +559,7 @@ func AutoDeployHelmServiceToEnvs(userName, requestID, projectName string, servic
 	go func() {
 		err = updateHelmSvcInAllEnvs(userName, projectName, serviceTemplates)
 		if err != nil {
-			notify.SendErrorMessage(userName, "服务自动部署失败", requestID, err, log)
+			notify.SendEnvErrorMessage(userName, projectName, "服务自动部署失败", requestID, err, log)
 		}
 	}()
 	return nil
@@ -601,7 +580,7 @@ func AutoDeployYamlServiceToEnvs(userName, requestID string, serviceTemplate *co
 	go func() {
 		err = updateK8sSvcInAllEnvs(serviceTemplate.ProductName, serviceTemplate, production)
 		if err != nil {
-			notify.SendErrorMessage(userName, "服务自动部署失败", requestID, err, log)
+			notify.SendEnvErrorMessage(userName, serviceTemplate.ProductName, "服务自动部署失败", requestID, err, log)
 		}
 	}()
 	return nil
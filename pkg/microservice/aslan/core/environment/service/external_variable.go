@@ -0,0 +1,91 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/externalvar"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+func GetEnvExternalVariable(projectName, envName string, production bool) (*commonmodels.ExternalVariableConfig, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+	if env.ExternalVariable == nil {
+		return &commonmodels.ExternalVariableConfig{Provider: externalvar.ProviderHTTP, Key: externalvar.DefaultKey}, nil
+	}
+	return env.ExternalVariable, nil
+}
+
+// UpdateEnvExternalVariable updates the external variable config of the env, the cached values are cleared
+// so the next deployment renders the services with the latest values
+func UpdateEnvExternalVariable(projectName, envName string, production bool, args *commonmodels.ExternalVariableConfig, log *zap.SugaredLogger) error {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+	if err := externalvar.Validate(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+
+	if err := commonrepo.NewProductColl().UpdateExternalVariable(envName, projectName, args); err != nil {
+		log.Errorf("failed to update external variable of env %s/%s, err: %s", projectName, envName, err)
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	if err := externalvar.ClearCache(env); err != nil {
+		log.Warnf("failed to clear cached external variables of env %s/%s, err: %s", projectName, envName, err)
+	}
+	return nil
+}
+
+// TestEnvExternalVariable fetches the values with the config from the provider without the cache
+func TestEnvExternalVariable(projectName, envName string, production bool, args *commonmodels.ExternalVariableConfig) (map[string]interface{}, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+	args.Enabled = true
+	if err := externalvar.Validate(args); err != nil {
+		return nil, e.ErrInvalidParam.AddErr(err)
+	}
+
+	env.ExternalVariable = args
+	values, err := externalvar.Fetch(env)
+	if err != nil {
+		return nil, e.ErrInvalidParam.AddErr(err)
+	}
+	return values, nil
+}
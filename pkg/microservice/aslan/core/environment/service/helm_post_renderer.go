@@ -0,0 +1,92 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+func GetHelmPostRenderer(projectName, envName string, production bool) (*commonmodels.HelmPostRenderer, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+	if env.HelmPostRenderer == nil {
+		return &commonmodels.HelmPostRenderer{}, nil
+	}
+	return env.HelmPostRenderer, nil
+}
+
+// UpdateHelmPostRenderer updates the post renderer of the env, it takes effect in the next install or upgrade of the releases
+func UpdateHelmPostRenderer(projectName, envName string, production bool, args *commonmodels.HelmPostRenderer, log *zap.SugaredLogger) error {
+	project, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return e.ErrGetProduct.AddErr(fmt.Errorf("failed to find project %s, err: %w", projectName, err))
+	}
+	if !project.IsHelmProduct() {
+		return e.ErrInvalidParam.AddDesc("post renderer can only be set for helm envs")
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	if args.Enabled {
+		for _, patch := range args.Patches {
+			// a patch without target fails the releases which don't contain the resource in the patch
+			if patch.Target == nil || patch.Target.Kind == "" {
+				return e.ErrInvalidParam.AddDesc("the kind of the patch target is required")
+			}
+		}
+
+		// validate the patch set with all the releases selected
+		postRenderer := *args
+		postRenderer.Services = nil
+		env.HelmPostRenderer = &postRenderer
+		renderer, err := kube.GenHelmPostRenderer(env, &commonmodels.ProductService{})
+		if err != nil {
+			return e.ErrInvalidParam.AddErr(err)
+		}
+		if _, err := renderer.Run(bytes.NewBuffer(nil)); err != nil {
+			return e.ErrInvalidParam.AddErr(err)
+		}
+	}
+
+	if err := commonrepo.NewProductColl().UpdateHelmPostRenderer(envName, projectName, args); err != nil {
+		log.Errorf("failed to update helm post renderer of env %s/%s, err: %s", projectName, envName, err)
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	return nil
+}
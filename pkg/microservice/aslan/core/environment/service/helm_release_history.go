@@ -0,0 +1,237 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/release"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	helmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/helm"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
+	"github.com/koderover/zadig/v2/pkg/util"
+	"github.com/koderover/zadig/v2/pkg/util/converter"
+)
+
+const helmReleaseHistoryMax = 256
+
+type HelmReleaseRevision struct {
+	Revision     int    `json:"revision"`
+	ChartName    string `json:"chart_name"`
+	ChartVersion string `json:"chart_version"`
+	AppVersion   string `json:"app_version"`
+	Status       string `json:"status"`
+	Description  string `json:"description"`
+	UpdatedAt    int64  `json:"updated_at"`
+	// ValuesDiff is the unified diff of the user supplied values against the previous revision
+	ValuesDiff string `json:"values_diff"`
+}
+
+type RollbackHelmReleaseArgs struct {
+	Revision int `json:"revision"`
+}
+
+type envHelmRelease struct {
+	env        *commonmodels.Product
+	productSvc *commonmodels.ProductService
+	client     *helmtool.HelmClient
+}
+
+// findEnvHelmRelease makes sure the release is managed by the env, releases outside zadig can not be browsed or rolled back
+func findEnvHelmRelease(projectName, envName, releaseName string, production bool) (*envHelmRelease, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: util.GetBoolPointer(production),
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	productSvc := env.GetChartServiceMap()[releaseName]
+	if productSvc == nil {
+		releaseToServiceMap, err := commonutil.GetReleaseNameToServiceNameMap(env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build release-service map, err: %w", err)
+		}
+		if serviceName, ok := releaseToServiceMap[releaseName]; ok {
+			productSvc = env.GetServiceMap()[serviceName]
+		}
+	}
+	if productSvc == nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("release %s is not managed by env %s", releaseName, envName))
+	}
+
+	client, err := helmtool.NewClientFromNamespace(env.ClusterID, env.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init helm client, err: %w", err)
+	}
+
+	return &envHelmRelease{
+		env:        env,
+		productSvc: productSvc,
+		client:     client,
+	}, nil
+}
+
+func releaseValuesYaml(rel *release.Release) (string, error) {
+	if len(rel.Config) == 0 {
+		return "", nil
+	}
+	bs, err := yaml.Marshal(rel.Config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal values of revision %d, err: %w", rel.Version, err)
+	}
+	return string(bs), nil
+}
+
+func ListHelmReleaseHistory(projectName, envName, releaseName string, production bool, log *zap.SugaredLogger) ([]*HelmReleaseRevision, error) {
+	envRelease, err := findEnvHelmRelease(projectName, envName, releaseName, production)
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := envRelease.client.ListReleaseHistory(releaseName, helmReleaseHistoryMax)
+	if err != nil {
+		log.Errorf("failed to list history of release %s in env %s, err: %s", releaseName, envName, err)
+		return nil, fmt.Errorf("failed to list history of release %s, err: %w", releaseName, err)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version < releases[j].Version
+	})
+
+	ret := make([]*HelmReleaseRevision, 0, len(releases))
+	previousValues := ""
+	for i, rel := range releases {
+		valuesYaml, err := releaseValuesYaml(rel)
+		if err != nil {
+			return nil, err
+		}
+
+		revision := &HelmReleaseRevision{
+			Revision: rel.Version,
+		}
+		if rel.Info != nil {
+			revision.Status = rel.Info.Status.String()
+			revision.Description = rel.Info.Description
+			revision.UpdatedAt = rel.Info.LastDeployed.Unix()
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			revision.ChartName = rel.Chart.Metadata.Name
+			revision.ChartVersion = rel.Chart.Metadata.Version
+			revision.AppVersion = rel.Chart.Metadata.AppVersion
+		}
+		if i > 0 {
+			revision.ValuesDiff = unifiedYamlDiff(previousValues, valuesYaml, fmt.Sprintf("revision-%d", releases[i-1].Version), fmt.Sprintf("revision-%d", rel.Version))
+		}
+		previousValues = valuesYaml
+		ret = append(ret, revision)
+	}
+
+	// latest revision first
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret, nil
+}
+
+// RollbackHelmRelease rolls a single release back to the given revision and syncs the env service render with the rolled back values,
+// so that the next deployment from zadig does not silently revert the rollback
+func RollbackHelmRelease(userName, projectName, envName, releaseName string, revision int, production bool, log *zap.SugaredLogger) error {
+	if revision <= 0 {
+		return e.ErrInvalidParam.AddDesc("revision must be greater than 0")
+	}
+
+	envRelease, err := findEnvHelmRelease(projectName, envName, releaseName, production)
+	if err != nil {
+		return err
+	}
+
+	releases, err := envRelease.client.ListReleaseHistory(releaseName, helmReleaseHistoryMax)
+	if err != nil {
+		return fmt.Errorf("failed to list history of release %s, err: %w", releaseName, err)
+	}
+	var target *release.Release
+	for _, rel := range releases {
+		if rel.Version == revision {
+			target = rel
+			break
+		}
+	}
+	if target == nil {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("revision %d of release %s not found", revision, releaseName))
+	}
+	valuesYaml, err := releaseValuesYaml(target)
+	if err != nil {
+		return err
+	}
+
+	if err = envRelease.client.RollbackToRevision(releaseName, revision, 10, 0); err != nil {
+		log.Errorf("failed to rollback release %s in env %s to revision %d, err: %s", releaseName, envName, revision, err)
+		return fmt.Errorf("failed to rollback release %s to revision %d, err: %w", releaseName, revision, err)
+	}
+
+	productSvc := envRelease.productSvc
+	svcRender := productSvc.GetServiceRender()
+	svcRender.OverrideYaml = &templatemodels.CustomYaml{YamlContent: valuesYaml}
+	svcRender.OverrideValues = ""
+	if !productSvc.FromZadig() && target.Chart != nil && target.Chart.Metadata != nil {
+		svcRender.ChartVersion = target.Chart.Metadata.Version
+	}
+	if productSvc.FromZadig() {
+		productSvc.Containers = rollbackContainerImages(productSvc.Containers, target.Config)
+	}
+
+	if err = helmservice.UpdateServiceInEnv(envRelease.env, productSvc, userName); err != nil {
+		log.Errorf("failed to update service render of release %s in env %s after rollback, err: %s", releaseName, envName, err)
+		return fmt.Errorf("release %s rolled back but failed to update env service, err: %w", releaseName, err)
+	}
+	return nil
+}
+
+// rollbackContainerImages resolves the container images recorded by zadig from the rolled back values
+func rollbackContainerImages(containers []*commonmodels.Container, values map[string]interface{}) []*commonmodels.Container {
+	flatValues, err := converter.Flatten(values)
+	if err != nil {
+		return containers
+	}
+	for _, container := range containers {
+		if container.ImagePath == nil {
+			continue
+		}
+		pattern := (&templatemodels.ImageSearchingRule{
+			Repo:      container.ImagePath.Repo,
+			Namespace: container.ImagePath.Namespace,
+			Image:     container.ImagePath.Image,
+			Tag:       container.ImagePath.Tag,
+		}).GetSearchingPattern()
+		image, err := commonutil.GeneImageURI(pattern, flatValues)
+		if err != nil || image == "" {
+			continue
+		}
+		container.Image = image
+	}
+	return containers
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	helmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/helm"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/repository"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type HelmValuesLayersArgs struct {
+	ServiceName string `json:"service_name"`
+	// OneOffOverride is the values yaml applied to a single deployment, it is used to preview the final values only
+	OneOffOverride string `json:"one_off_override"`
+}
+
+type HelmValuesLayersResp struct {
+	Layers []*helmservice.ValuesLayer     `json:"layers"`
+	Keys   []*helmservice.ValuesKeySource `json:"keys"`
+}
+
+// GetHelmValuesLayers returns the values layers of the helm service in the env and the layer each final key comes from
+func GetHelmValuesLayers(projectName, envName string, args *HelmValuesLayersArgs, production bool, log *zap.SugaredLogger) (*HelmValuesLayersResp, error) {
+	if err := yaml.Unmarshal([]byte(args.OneOffOverride), &map[string]interface{}{}); err != nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("invalid one-off override values: %s", err))
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	prodSvc := env.GetServiceMap()[args.ServiceName]
+	if prodSvc == nil || prodSvc.Type != setting.HelmDeployType || !prodSvc.FromZadig() {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("helm service %s not found in env %s", args.ServiceName, envName))
+	}
+
+	tmplSvc, err := repository.QueryTemplateService(&commonrepo.ServiceFindOption{
+		ProductName: projectName,
+		ServiceName: args.ServiceName,
+		Revision:    prodSvc.Revision,
+	}, production)
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(fmt.Errorf("failed to find service %s revision %d, err: %w", args.ServiceName, prodSvc.Revision, err))
+	}
+	chartValues := ""
+	if tmplSvc.HelmChart != nil {
+		chartValues = tmplSvc.HelmChart.ValuesYaml
+	}
+
+	layers := helmservice.GenValuesLayers(env, prodSvc, chartValues, args.OneOffOverride)
+	keys, err := helmservice.ExplainValuesLayers(layers)
+	if err != nil {
+		log.Errorf("failed to explain values layers of %s/%s/%s, err: %s", projectName, envName, args.ServiceName, err)
+		return nil, e.ErrGetRenderSet.AddErr(err)
+	}
+	return &HelmValuesLayersResp{
+		Layers: layers,
+		Keys:   keys,
+	}, nil
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
@@ -33,6 +34,7 @@ import (
 	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
 	"github.com/koderover/zadig/v2/pkg/setting"
 	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
 	"github.com/koderover/zadig/v2/pkg/tool/kube/updater"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
 	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
@@ -86,6 +88,12 @@ func UpdateContainerImage(requestID, username string, args *UpdateContainerImage
 		return e.ErrUpdateConainterImage.AddErr(err)
 	}
 
+	if product.Production && product.ImageSigningKeyID != "" {
+		if err := commonutil.VerifyImageSignature(product.ImageSigningKeyID, []string{args.Image}, log); err != nil {
+			return err
+		}
+	}
+
 	namespace := product.Namespace
 	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(product.ClusterID)
 	if err != nil {
@@ -189,3 +197,76 @@ func UpdateContainerImage(requestID, username string, args *UpdateContainerImage
 	}
 	return nil
 }
+
+// UpdateServiceModuleImage updates the image of the container in the workloads of the service which contain the container
+func UpdateServiceModuleImage(requestID, username, projectName, envName, serviceName, serviceModule, image string, production bool, log *zap.SugaredLogger) error {
+	product, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		EnvName:    envName,
+		Name:       projectName,
+		Production: &production,
+	})
+	if err != nil {
+		return e.ErrUpdateConainterImage.AddErr(err)
+	}
+	prodSvc := product.GetServiceMap()[serviceName]
+	if prodSvc == nil {
+		return e.ErrUpdateConainterImage.AddDesc(fmt.Sprintf("服务 %s 不存在", serviceName))
+	}
+
+	if product.Production && product.ImageSigningKeyID != "" {
+		if err := commonutil.VerifyImageSignature(product.ImageSigningKeyID, []string{image}, log); err != nil {
+			return err
+		}
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(product.ClusterID)
+	if err != nil {
+		return e.ErrUpdateConainterImage.AddErr(err)
+	}
+
+	updated := false
+	for _, res := range prodSvc.Resources {
+		var containers []corev1.Container
+		switch res.Kind {
+		case setting.Deployment:
+			deploy, found, err := getter.GetDeployment(product.Namespace, res.Name, kubeClient)
+			if err != nil || !found {
+				continue
+			}
+			containers = deploy.Spec.Template.Spec.Containers
+		case setting.StatefulSet:
+			sts, found, err := getter.GetStatefulSet(product.Namespace, res.Name, kubeClient)
+			if err != nil || !found {
+				continue
+			}
+			containers = sts.Spec.Template.Spec.Containers
+		default:
+			continue
+		}
+
+		for _, container := range containers {
+			if container.Name != serviceModule {
+				continue
+			}
+			err = UpdateContainerImage(requestID, username, &UpdateContainerImageArgs{
+				Type:          res.Kind,
+				ProductName:   projectName,
+				EnvName:       envName,
+				ServiceName:   serviceName,
+				Name:          res.Name,
+				ContainerName: serviceModule,
+				Image:         image,
+				Production:    production,
+			}, log)
+			if err != nil {
+				return err
+			}
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		return e.ErrUpdateConainterImage.AddDesc(fmt.Sprintf("服务 %s 中未找到容器 %s", serviceName, serviceModule))
+	}
+	return nil
+}
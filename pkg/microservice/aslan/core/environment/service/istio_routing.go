@@ -0,0 +1,643 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	versionedclient "istio.io/client-go/pkg/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	zadigtypes "github.com/koderover/zadig/v2/pkg/types"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+const (
+	IstioRoutingKindVirtualService  = "VirtualService"
+	IstioRoutingKindGateway         = "Gateway"
+	IstioRoutingKindDestinationRule = "DestinationRule"
+
+	istioRoutingDefaultVersionLabel = "version"
+
+	IstioHeaderMatchExact  = "exact"
+	IstioHeaderMatchPrefix = "prefix"
+	IstioHeaderMatchRegex  = "regex"
+)
+
+type IstioRoutingObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// ManagedByZadig indicates the object is created or updated through zadig
+	ManagedByZadig bool   `json:"managed_by_zadig"`
+	Yaml           string `json:"yaml"`
+}
+
+// IstioTrafficSubset routes the traffic to the workloads of the service with the given version label
+type IstioTrafficSubset struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Weight  int32  `json:"weight"`
+}
+
+// IstioHeaderRoute routes the requests with the matched header to the subset, it takes precedence over the weighted routes
+type IstioHeaderRoute struct {
+	Header    string `json:"header"`
+	MatchType string `json:"match_type"`
+	Value     string `json:"value"`
+	Subset    string `json:"subset"`
+}
+
+type IstioTrafficSpec struct {
+	Name string `json:"name"`
+	// Host is the service name in the env namespace
+	Host         string                `json:"host"`
+	Port         uint32                `json:"port"`
+	VersionLabel string                `json:"version_label"`
+	Gateways     []string              `json:"gateways"`
+	Subsets      []*IstioTrafficSubset `json:"subsets"`
+	HeaderRoutes []*IstioHeaderRoute   `json:"header_routes"`
+}
+
+// IstioRoutingArgs describes the routing objects to apply, either the raw yaml of the objects or the traffic spec
+// which is rendered into a VirtualService and a DestinationRule.
+type IstioRoutingArgs struct {
+	Yaml    string            `json:"yaml"`
+	Traffic *IstioTrafficSpec `json:"traffic"`
+}
+
+type IstioRoutingRollbackArgs struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Revision int64  `json:"revision"`
+}
+
+type istioRoutingEnv struct {
+	env         *commonmodels.Product
+	istioClient versionedclient.Interface
+}
+
+func getIstioRoutingEnv(projectName, envName string, production bool) (*istioRoutingEnv, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find env %s in project %s, err: %w", envName, projectName, err)
+	}
+	if env.IsSleeping() {
+		return nil, fmt.Errorf("env %s is sleeping", envName)
+	}
+
+	istioClient, err := clientmanager.NewKubeClientManager().GetIstioClientSet(env.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to new istio client: %w", err)
+	}
+	return &istioRoutingEnv{env: env, istioClient: istioClient}, nil
+}
+
+func (r *istioRoutingEnv) revisionOption(kind, name string) *commonrepo.IstioRoutingRevisionOption {
+	return &commonrepo.IstioRoutingRevisionOption{
+		ProjectName: r.env.ProductName,
+		EnvName:     r.env.EnvName,
+		Production:  r.env.Production,
+		Kind:        kind,
+		Name:        name,
+	}
+}
+
+// ListIstioRoutings lists the VirtualServices, Gateways and DestinationRules in the env namespace
+func ListIstioRoutings(projectName, envName string, production bool, log *zap.SugaredLogger) ([]*IstioRoutingObject, error) {
+	r, err := getIstioRoutingEnv(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrListIstioRouting.AddErr(err)
+	}
+
+	ctx := context.TODO()
+	ns := r.env.Namespace
+	networking := r.istioClient.NetworkingV1alpha3()
+	resp := make([]*IstioRoutingObject, 0)
+
+	vsList, err := networking.VirtualServices(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("failed to list VirtualServices in ns %s, err: %s", ns, err)
+		return nil, e.ErrListIstioRouting.AddErr(err)
+	}
+	for _, vs := range vsList.Items {
+		obj, err := newIstioRoutingObject(IstioRoutingKindVirtualService, vs)
+		if err != nil {
+			return nil, e.ErrListIstioRouting.AddErr(err)
+		}
+		resp = append(resp, obj)
+	}
+
+	gwList, err := networking.Gateways(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("failed to list Gateways in ns %s, err: %s", ns, err)
+		return nil, e.ErrListIstioRouting.AddErr(err)
+	}
+	for _, gw := range gwList.Items {
+		obj, err := newIstioRoutingObject(IstioRoutingKindGateway, gw)
+		if err != nil {
+			return nil, e.ErrListIstioRouting.AddErr(err)
+		}
+		resp = append(resp, obj)
+	}
+
+	drList, err := networking.DestinationRules(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("failed to list DestinationRules in ns %s, err: %s", ns, err)
+		return nil, e.ErrListIstioRouting.AddErr(err)
+	}
+	for _, dr := range drList.Items {
+		obj, err := newIstioRoutingObject(IstioRoutingKindDestinationRule, dr)
+		if err != nil {
+			return nil, e.ErrListIstioRouting.AddErr(err)
+		}
+		resp = append(resp, obj)
+	}
+
+	return resp, nil
+}
+
+// PreviewIstioRouting renders the routing objects which would be applied to the env without changing anything in the cluster
+func PreviewIstioRouting(projectName, envName string, production bool, args *IstioRoutingArgs, log *zap.SugaredLogger) ([]*IstioRoutingObject, error) {
+	r, err := getIstioRoutingEnv(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrApplyIstioRouting.AddErr(err)
+	}
+
+	objs, err := r.renderIstioRouting(args)
+	if err != nil {
+		return nil, e.ErrApplyIstioRouting.AddErr(err)
+	}
+
+	resp := make([]*IstioRoutingObject, 0, len(objs))
+	for _, obj := range objs {
+		o, err := newIstioRoutingObject(istioRoutingKind(obj), obj)
+		if err != nil {
+			return nil, e.ErrApplyIstioRouting.AddErr(err)
+		}
+		resp = append(resp, o)
+	}
+	return resp, nil
+}
+
+// ApplyIstioRouting creates or updates the routing objects in the env namespace, a revision is recorded for each of them
+func ApplyIstioRouting(projectName, envName string, production bool, args *IstioRoutingArgs, userName string, log *zap.SugaredLogger) ([]*IstioRoutingObject, error) {
+	r, err := getIstioRoutingEnv(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrApplyIstioRouting.AddErr(err)
+	}
+
+	objs, err := r.renderIstioRouting(args)
+	if err != nil {
+		return nil, e.ErrApplyIstioRouting.AddErr(err)
+	}
+
+	resp := make([]*IstioRoutingObject, 0, len(objs))
+	for _, obj := range objs {
+		o, err := r.applyIstioRoutingObject(obj, userName)
+		if err != nil {
+			log.Errorf("failed to apply istio routing in env %s/%s, err: %s", projectName, envName, err)
+			return resp, e.ErrApplyIstioRouting.AddErr(err)
+		}
+		log.Infof("istio %s %s applied in env %s/%s by %s", o.Kind, o.Name, projectName, envName, userName)
+		resp = append(resp, o)
+	}
+	return resp, nil
+}
+
+// DeleteIstioRouting deletes the routing object from the env namespace, its content is kept as the last revision so it can be restored by rollback
+func DeleteIstioRouting(projectName, envName string, production bool, kind, name, userName string, log *zap.SugaredLogger) error {
+	r, err := getIstioRoutingEnv(projectName, envName, production)
+	if err != nil {
+		return e.ErrDeleteIstioRouting.AddErr(err)
+	}
+
+	ctx := context.TODO()
+	ns := r.env.Namespace
+	networking := r.istioClient.NetworkingV1alpha3()
+
+	var current interface{}
+	switch kind {
+	case IstioRoutingKindVirtualService:
+		current, err = networking.VirtualServices(ns).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			err = networking.VirtualServices(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		}
+	case IstioRoutingKindGateway:
+		current, err = networking.Gateways(ns).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			err = networking.Gateways(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		}
+	case IstioRoutingKindDestinationRule:
+		current, err = networking.DestinationRules(ns).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			err = networking.DestinationRules(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		}
+	default:
+		return e.ErrDeleteIstioRouting.AddDesc(fmt.Sprintf("unsupported kind %s", kind))
+	}
+	if err != nil {
+		log.Errorf("failed to delete istio %s %s in ns %s, err: %s", kind, name, ns, err)
+		return e.ErrDeleteIstioRouting.AddErr(err)
+	}
+
+	content, err := marshalIstioRoutingObject(kind, current)
+	if err != nil {
+		return e.ErrDeleteIstioRouting.AddErr(err)
+	}
+	err = commonrepo.NewIstioRoutingRevisionColl().Create(&commonmodels.IstioRoutingRevision{
+		ProjectName: projectName,
+		EnvName:     envName,
+		Production:  production,
+		Kind:        kind,
+		Name:        name,
+		Yaml:        content,
+		Deleted:     true,
+		CreatedBy:   userName,
+	})
+	if err != nil {
+		log.Errorf("failed to record the revision of deleted istio %s %s, err: %s", kind, name, err)
+	}
+	return nil
+}
+
+func ListIstioRoutingRevisions(projectName, envName string, production bool, kind, name string) ([]*commonmodels.IstioRoutingRevision, error) {
+	resp, err := commonrepo.NewIstioRoutingRevisionColl().List(&commonrepo.IstioRoutingRevisionOption{
+		ProjectName: projectName,
+		EnvName:     envName,
+		Production:  production,
+		Kind:        kind,
+		Name:        name,
+	})
+	if err != nil {
+		return nil, e.ErrListIstioRouting.AddErr(err)
+	}
+	return resp, nil
+}
+
+// RollbackIstioRouting applies the content of the given revision, the rollback itself is recorded as a new revision
+func RollbackIstioRouting(projectName, envName string, production bool, args *IstioRoutingRollbackArgs, userName string, log *zap.SugaredLogger) (*IstioRoutingObject, error) {
+	r, err := getIstioRoutingEnv(projectName, envName, production)
+	if err != nil {
+		return nil, e.ErrApplyIstioRouting.AddErr(err)
+	}
+
+	revision, err := commonrepo.NewIstioRoutingRevisionColl().GetByRevision(r.revisionOption(args.Kind, args.Name), args.Revision)
+	if err != nil {
+		return nil, e.ErrApplyIstioRouting.AddErr(fmt.Errorf("failed to find revision %d of %s %s, err: %w", args.Revision, args.Kind, args.Name, err))
+	}
+
+	objs, err := r.parseIstioRoutingYaml(revision.Yaml)
+	if err != nil {
+		return nil, e.ErrApplyIstioRouting.AddErr(err)
+	}
+	if len(objs) != 1 {
+		return nil, e.ErrApplyIstioRouting.AddDesc(fmt.Sprintf("invalid content of revision %d", args.Revision))
+	}
+
+	resp, err := r.applyIstioRoutingObject(objs[0], userName)
+	if err != nil {
+		log.Errorf("failed to rollback istio %s %s to revision %d, err: %s", args.Kind, args.Name, args.Revision, err)
+		return nil, e.ErrApplyIstioRouting.AddErr(err)
+	}
+	log.Infof("istio %s %s in env %s/%s rolled back to revision %d by %s", args.Kind, args.Name, projectName, envName, args.Revision, userName)
+	return resp, nil
+}
+
+func (r *istioRoutingEnv) renderIstioRouting(args *IstioRoutingArgs) ([]interface{}, error) {
+	if args.Traffic != nil {
+		return r.renderIstioTraffic(args.Traffic)
+	}
+	if strings.TrimSpace(args.Yaml) == "" {
+		return nil, fmt.Errorf("either yaml or traffic must be specified")
+	}
+	return r.parseIstioRoutingYaml(args.Yaml)
+}
+
+// parseIstioRoutingYaml parses the routing objects in the yaml, the objects are always placed in the env namespace
+func (r *istioRoutingEnv) parseIstioRoutingYaml(content string) ([]interface{}, error) {
+	resp := make([]interface{}, 0)
+	for _, manifest := range util.SplitManifests(content) {
+		typeMeta := new(metav1.TypeMeta)
+		if err := yaml.Unmarshal([]byte(manifest), typeMeta); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal yaml, err: %w", err)
+		}
+
+		var obj interface{}
+		var meta *metav1.ObjectMeta
+		switch typeMeta.Kind {
+		case IstioRoutingKindVirtualService:
+			vs := new(v1alpha3.VirtualService)
+			obj, meta = vs, &vs.ObjectMeta
+		case IstioRoutingKindGateway:
+			gw := new(v1alpha3.Gateway)
+			obj, meta = gw, &gw.ObjectMeta
+		case IstioRoutingKindDestinationRule:
+			dr := new(v1alpha3.DestinationRule)
+			obj, meta = dr, &dr.ObjectMeta
+		default:
+			return nil, fmt.Errorf("unsupported kind %q, only %s, %s and %s are supported", typeMeta.Kind,
+				IstioRoutingKindVirtualService, IstioRoutingKindGateway, IstioRoutingKindDestinationRule)
+		}
+
+		if err := yaml.Unmarshal([]byte(manifest), obj); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s, err: %w", typeMeta.Kind, err)
+		}
+		if meta.Name == "" {
+			return nil, fmt.Errorf("name of %s can not be empty", typeMeta.Kind)
+		}
+		r.setIstioRoutingMeta(meta)
+		resp = append(resp, obj)
+	}
+	if len(resp) == 0 {
+		return nil, fmt.Errorf("no istio routing object found in yaml")
+	}
+	return resp, nil
+}
+
+// renderIstioTraffic renders a DestinationRule with a subset for each version of the service, and a VirtualService routing
+// the requests matching the header rules to the subsets and splitting the rest by the weights
+func (r *istioRoutingEnv) renderIstioTraffic(spec *IstioTrafficSpec) ([]interface{}, error) {
+	if spec.Host == "" {
+		return nil, fmt.Errorf("host can not be empty")
+	}
+	if len(spec.Subsets) == 0 {
+		return nil, fmt.Errorf("at least one subset is required")
+	}
+	name := spec.Name
+	if name == "" {
+		name = spec.Host
+	}
+	versionLabel := spec.VersionLabel
+	if versionLabel == "" {
+		versionLabel = istioRoutingDefaultVersionLabel
+	}
+
+	var port *networkingv1alpha3.PortSelector
+	if spec.Port > 0 {
+		port = &networkingv1alpha3.PortSelector{Number: spec.Port}
+	}
+	destination := func(subset string) *networkingv1alpha3.Destination {
+		return &networkingv1alpha3.Destination{Host: spec.Host, Subset: subset, Port: port}
+	}
+
+	subsets := make([]*networkingv1alpha3.Subset, 0, len(spec.Subsets))
+	subsetSet := make(map[string]bool)
+	weightedRoutes := make([]*networkingv1alpha3.HTTPRouteDestination, 0, len(spec.Subsets))
+	totalWeight := int32(0)
+	for _, subset := range spec.Subsets {
+		if subset.Name == "" || subset.Version == "" {
+			return nil, fmt.Errorf("name and version of subset can not be empty")
+		}
+		if subsetSet[subset.Name] {
+			return nil, fmt.Errorf("duplicated subset %s", subset.Name)
+		}
+		if subset.Weight < 0 {
+			return nil, fmt.Errorf("weight of subset %s can not be negative", subset.Name)
+		}
+		subsetSet[subset.Name] = true
+		totalWeight += subset.Weight
+
+		subsets = append(subsets, &networkingv1alpha3.Subset{
+			Name:   subset.Name,
+			Labels: map[string]string{versionLabel: subset.Version},
+		})
+		weightedRoutes = append(weightedRoutes, &networkingv1alpha3.HTTPRouteDestination{
+			Destination: destination(subset.Name),
+			Weight:      subset.Weight,
+		})
+	}
+	if totalWeight != 100 {
+		return nil, fmt.Errorf("the total weight of subsets must be 100, got %d", totalWeight)
+	}
+
+	httpRoutes := make([]*networkingv1alpha3.HTTPRoute, 0, len(spec.HeaderRoutes)+1)
+	for _, route := range spec.HeaderRoutes {
+		if route.Header == "" {
+			return nil, fmt.Errorf("header of header route can not be empty")
+		}
+		if !subsetSet[route.Subset] {
+			return nil, fmt.Errorf("subset %s of header %s not found", route.Subset, route.Header)
+		}
+
+		match := &networkingv1alpha3.StringMatch{}
+		switch route.MatchType {
+		case IstioHeaderMatchExact, "":
+			match.MatchType = &networkingv1alpha3.StringMatch_Exact{Exact: route.Value}
+		case IstioHeaderMatchPrefix:
+			match.MatchType = &networkingv1alpha3.StringMatch_Prefix{Prefix: route.Value}
+		case IstioHeaderMatchRegex:
+			match.MatchType = &networkingv1alpha3.StringMatch_Regex{Regex: route.Value}
+		default:
+			return nil, fmt.Errorf("unsupported match type %s of header %s", route.MatchType, route.Header)
+		}
+
+		httpRoutes = append(httpRoutes, &networkingv1alpha3.HTTPRoute{
+			Match: []*networkingv1alpha3.HTTPMatchRequest{
+				{Headers: map[string]*networkingv1alpha3.StringMatch{route.Header: match}},
+			},
+			Route: []*networkingv1alpha3.HTTPRouteDestination{
+				{Destination: destination(route.Subset)},
+			},
+		})
+	}
+	httpRoutes = append(httpRoutes, &networkingv1alpha3.HTTPRoute{
+		Route: weightedRoutes,
+	})
+
+	dr := &v1alpha3.DestinationRule{
+		TypeMeta: metav1.TypeMeta{Kind: IstioRoutingKindDestinationRule, APIVersion: v1alpha3.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: networkingv1alpha3.DestinationRule{
+			Host:    spec.Host,
+			Subsets: subsets,
+		},
+	}
+	r.setIstioRoutingMeta(&dr.ObjectMeta)
+
+	vs := &v1alpha3.VirtualService{
+		TypeMeta: metav1.TypeMeta{Kind: IstioRoutingKindVirtualService, APIVersion: v1alpha3.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: networkingv1alpha3.VirtualService{
+			Hosts:    []string{spec.Host},
+			Gateways: spec.Gateways,
+			Http:     httpRoutes,
+		},
+	}
+	r.setIstioRoutingMeta(&vs.ObjectMeta)
+
+	return []interface{}{dr, vs}, nil
+}
+
+func (r *istioRoutingEnv) setIstioRoutingMeta(meta *metav1.ObjectMeta) {
+	meta.Namespace = r.env.Namespace
+	meta.Labels = labels.Merge(meta.Labels, map[string]string{
+		zadigtypes.ZadigLabelKeyGlobalOwner: zadigtypes.Zadig,
+		setting.ProductLabel:                r.env.ProductName,
+		setting.EnvNameLabel:                r.env.EnvName,
+	})
+}
+
+func (r *istioRoutingEnv) applyIstioRoutingObject(obj interface{}, userName string) (*IstioRoutingObject, error) {
+	ctx := context.TODO()
+	ns := r.env.Namespace
+	networking := r.istioClient.NetworkingV1alpha3()
+
+	var err error
+	var applied interface{}
+	switch o := obj.(type) {
+	case *v1alpha3.VirtualService:
+		current, getErr := networking.VirtualServices(ns).Get(ctx, o.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(getErr) {
+			applied, err = networking.VirtualServices(ns).Create(ctx, o, metav1.CreateOptions{})
+		} else if getErr != nil {
+			err = getErr
+		} else {
+			o.ResourceVersion = current.ResourceVersion
+			applied, err = networking.VirtualServices(ns).Update(ctx, o, metav1.UpdateOptions{})
+		}
+	case *v1alpha3.Gateway:
+		current, getErr := networking.Gateways(ns).Get(ctx, o.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(getErr) {
+			applied, err = networking.Gateways(ns).Create(ctx, o, metav1.CreateOptions{})
+		} else if getErr != nil {
+			err = getErr
+		} else {
+			o.ResourceVersion = current.ResourceVersion
+			applied, err = networking.Gateways(ns).Update(ctx, o, metav1.UpdateOptions{})
+		}
+	case *v1alpha3.DestinationRule:
+		current, getErr := networking.DestinationRules(ns).Get(ctx, o.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(getErr) {
+			applied, err = networking.DestinationRules(ns).Create(ctx, o, metav1.CreateOptions{})
+		} else if getErr != nil {
+			err = getErr
+		} else {
+			o.ResourceVersion = current.ResourceVersion
+			applied, err = networking.DestinationRules(ns).Update(ctx, o, metav1.UpdateOptions{})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported istio routing object %T", obj)
+	}
+	kind := istioRoutingKind(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s, err: %w", kind, err)
+	}
+
+	resp, err := newIstioRoutingObject(kind, applied)
+	if err != nil {
+		return nil, err
+	}
+	err = commonrepo.NewIstioRoutingRevisionColl().Create(&commonmodels.IstioRoutingRevision{
+		ProjectName: r.env.ProductName,
+		EnvName:     r.env.EnvName,
+		Production:  r.env.Production,
+		Kind:        kind,
+		Name:        resp.Name,
+		Yaml:        resp.Yaml,
+		CreatedBy:   userName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s %s is applied but failed to record the revision, err: %w", kind, resp.Name, err)
+	}
+	return resp, nil
+}
+
+func istioRoutingKind(obj interface{}) string {
+	switch obj.(type) {
+	case *v1alpha3.VirtualService:
+		return IstioRoutingKindVirtualService
+	case *v1alpha3.Gateway:
+		return IstioRoutingKindGateway
+	case *v1alpha3.DestinationRule:
+		return IstioRoutingKindDestinationRule
+	}
+	return ""
+}
+
+func newIstioRoutingObject(kind string, obj interface{}) (*IstioRoutingObject, error) {
+	content, err := marshalIstioRoutingObject(kind, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta *metav1.ObjectMeta
+	switch o := obj.(type) {
+	case *v1alpha3.VirtualService:
+		meta = &o.ObjectMeta
+	case *v1alpha3.Gateway:
+		meta = &o.ObjectMeta
+	case *v1alpha3.DestinationRule:
+		meta = &o.ObjectMeta
+	default:
+		return nil, fmt.Errorf("unsupported istio routing object %T", obj)
+	}
+
+	return &IstioRoutingObject{
+		Kind:           kind,
+		Name:           meta.Name,
+		ManagedByZadig: meta.Labels[zadigtypes.ZadigLabelKeyGlobalOwner] == zadigtypes.Zadig,
+		Yaml:           content,
+	}, nil
+}
+
+// marshalIstioRoutingObject marshals the object without the fields maintained by the cluster, so that the yaml can be applied again
+func marshalIstioRoutingObject(kind string, obj interface{}) (string, error) {
+	content, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s, err: %w", kind, err)
+	}
+
+	data := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return "", fmt.Errorf("failed to unmarshal %s, err: %w", kind, err)
+	}
+	delete(data, "status")
+	data["kind"] = kind
+	data["apiVersion"] = v1alpha3.SchemeGroupVersion.String()
+	if meta, ok := data["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"resourceVersion", "uid", "generation", "creationTimestamp", "managedFields", "selfLink"} {
+			delete(meta, field)
+		}
+	}
+
+	content, err = yaml.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s, err: %w", kind, err)
+	}
+	return string(content), nil
+}
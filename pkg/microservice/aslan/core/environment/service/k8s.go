@@ -119,6 +119,7 @@ func (k *K8sService) updateService(args *SvcOptArgs) error {
 
 	newProductSvc.Containers = currentProductSvc.Containers
 	newProductSvc.Resources = currentProductSvc.Resources
+	newProductSvc.GitRef = currentProductSvc.GitRef
 
 	if !args.UpdateServiceTmpl {
 		newProductSvc.Revision = currentProductSvc.Revision
@@ -248,7 +249,11 @@ func (k *K8sService) updateService(args *SvcOptArgs) error {
 		return e.ErrUpdateProduct.AddErr(err)
 	}
 
-	if err := commonutil.CreateEnvServiceVersion(prodinfo, newProductSvc, args.UpdateBy, session, k.log); err != nil {
+	revisionDiff := ""
+	if newProductSvc.Revision != currentProductSvc.Revision {
+		revisionDiff = unifiedYamlDiff(previewResult.Current.Yaml, previewResult.Latest.Yaml, revisionLabel(currentProductSvc.Revision), revisionLabel(newProductSvc.Revision))
+	}
+	if err := commonutil.CreateEnvServiceVersionWithDiff(prodinfo, newProductSvc, args.UpdateBy, revisionDiff, session, k.log); err != nil {
 		k.log.Errorf("[%s][%s] Product.CreateEnvServiceVersion for service %s error: %v", args.EnvName, args.ProductName, args.ServiceName, err)
 	}
 
@@ -0,0 +1,294 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+const (
+	logSearchDefaultTailLines = 1000
+	logSearchMaxTailLines     = 5000
+	// the max number of the matched lines kept in memory for a search
+	logSearchMaxMatchedLines = 20000
+	logSearchConcurrency     = 10
+	logSearchTimeout         = 30 * time.Second
+)
+
+type LogSearchArgs struct {
+	Production bool `json:"production"`
+	// ServiceNames limits the search to the pods of the services, all the services of the env are searched if empty
+	ServiceNames []string `json:"service_names"`
+	// LabelSelector filters the pods of the services by labels
+	LabelSelector string `json:"label_selector"`
+	// Keyword is matched as a substring, and Regex as a regular expression, both of them are applied if set
+	Keyword   string `json:"keyword"`
+	Regex     string `json:"regex"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+	// TailLines is the number of the latest lines read from each container
+	TailLines int64 `json:"tail_lines"`
+	PageNum   int   `json:"page_num"`
+	PageSize  int   `json:"page_size"`
+}
+
+type LogSearchLine struct {
+	Time          int64  `json:"time"`
+	ServiceName   string `json:"service_name"`
+	PodName       string `json:"pod_name"`
+	ContainerName string `json:"container_name"`
+	Content       string `json:"content"`
+}
+
+type LogSearchResp struct {
+	Lines []*LogSearchLine `json:"lines"`
+	Total int              `json:"total"`
+	// Truncated is true if too many lines are matched and the earlier ones are dropped
+	Truncated bool `json:"truncated"`
+	// Errors are the containers whose logs can't be read, the results of the other containers are still returned
+	Errors []string `json:"errors"`
+}
+
+type logSearchTarget struct {
+	serviceName string
+	pod         *corev1.Pod
+	container   string
+}
+
+// SearchEnvLogs reads the logs of the containers of the selected services in the env, filters them and returns the
+// matched lines merged from all the containers, sorted by time desc
+func SearchEnvLogs(projectName, envName string, args *LogSearchArgs, log *zap.SugaredLogger) (*LogSearchResp, error) {
+	if args.Keyword == "" && args.Regex == "" {
+		return nil, e.ErrSearchEnvLogs.AddDesc("keyword or regex must be specified")
+	}
+	var re *regexp.Regexp
+	if args.Regex != "" {
+		var err error
+		re, err = regexp.Compile(args.Regex)
+		if err != nil {
+			return nil, e.ErrSearchEnvLogs.AddDesc(fmt.Sprintf("invalid regex: %s", err))
+		}
+	}
+	selector := labels.Everything()
+	if args.LabelSelector != "" {
+		var err error
+		selector, err = labels.Parse(args.LabelSelector)
+		if err != nil {
+			return nil, e.ErrSearchEnvLogs.AddDesc(fmt.Sprintf("invalid label selector: %s", err))
+		}
+	}
+	tailLines := args.TailLines
+	if tailLines <= 0 {
+		tailLines = logSearchDefaultTailLines
+	}
+	if tailLines > logSearchMaxTailLines {
+		tailLines = logSearchMaxTailLines
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &args.Production,
+	})
+	if err != nil {
+		return nil, e.ErrSearchEnvLogs.AddDesc(fmt.Sprintf("failed to find env %s: %s", envName, err))
+	}
+
+	targets, err := getLogSearchTargets(env, args.ServiceNames, selector)
+	if err != nil {
+		log.Errorf("failed to get pods of env %s, error: %s", envName, err)
+		return nil, e.ErrSearchEnvLogs.AddErr(err)
+	}
+	clientset, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrSearchEnvLogs.AddErr(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), logSearchTimeout)
+	defer cancel()
+
+	resp := &LogSearchResp{
+		Lines:  make([]*LogSearchLine, 0),
+		Errors: make([]string, 0),
+	}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, logSearchConcurrency)
+	)
+	for _, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(target *logSearchTarget) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			lines, err := searchContainerLogs(ctx, clientset, env.Namespace, target, tailLines, args, re)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				resp.Errors = append(resp.Errors, fmt.Sprintf("%s/%s: %s", target.pod.Name, target.container, err))
+				return
+			}
+			resp.Lines = append(resp.Lines, lines...)
+		}(target)
+	}
+	wg.Wait()
+
+	sort.SliceStable(resp.Lines, func(i, j int) bool {
+		return resp.Lines[i].Time > resp.Lines[j].Time
+	})
+	if len(resp.Lines) > logSearchMaxMatchedLines {
+		resp.Lines = resp.Lines[:logSearchMaxMatchedLines]
+		resp.Truncated = true
+	}
+	resp.Total = len(resp.Lines)
+
+	if args.PageNum > 0 && args.PageSize > 0 {
+		start := (args.PageNum - 1) * args.PageSize
+		if start > len(resp.Lines) {
+			start = len(resp.Lines)
+		}
+		end := start + args.PageSize
+		if end > len(resp.Lines) {
+			end = len(resp.Lines)
+		}
+		resp.Lines = resp.Lines[start:end]
+	}
+	return resp, nil
+}
+
+func getLogSearchTargets(env *commonmodels.Product, serviceNames []string, selector labels.Selector) ([]*logSearchTarget, error) {
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]*commonmodels.ProductService, 0)
+	if len(serviceNames) == 0 {
+		for _, svc := range env.GetServiceMap() {
+			services = append(services, svc)
+		}
+		for _, svc := range env.GetChartServiceMap() {
+			services = append(services, svc)
+		}
+	} else {
+		svcMap := env.GetServiceMap()
+		for _, svc := range env.GetChartServiceMap() {
+			svcMap[svc.ServiceName] = svc
+		}
+		for _, name := range serviceNames {
+			svc, ok := svcMap[name]
+			if !ok {
+				return nil, fmt.Errorf("service %s not found in env %s", name, env.EnvName)
+			}
+			services = append(services, svc)
+		}
+	}
+
+	resp := make([]*logSearchTarget, 0)
+	for _, svc := range services {
+		_, pods, err := getServiceObjects(env, svc, kubeClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pods of service %s: %s", svc.ServiceName, err)
+		}
+		for _, pod := range pods {
+			if !selector.Matches(labels.Set(pod.Labels)) || pod.Status.Phase == corev1.PodPending {
+				continue
+			}
+			for _, container := range pod.Spec.Containers {
+				resp = append(resp, &logSearchTarget{
+					serviceName: svc.ServiceName,
+					pod:         pod,
+					container:   container.Name,
+				})
+			}
+		}
+	}
+	return resp, nil
+}
+
+func searchContainerLogs(ctx context.Context, clientset *kubernetes.Clientset, namespace string, target *logSearchTarget, tailLines int64, args *LogSearchArgs, re *regexp.Regexp) ([]*LogSearchLine, error) {
+	logOptions := &corev1.PodLogOptions{
+		Container:  target.container,
+		Timestamps: true,
+		TailLines:  &tailLines,
+	}
+	if args.StartTime > 0 {
+		sinceTime := metav1.NewTime(time.Unix(args.StartTime, 0))
+		logOptions.SinceTime = &sinceTime
+	}
+
+	stream, err := clientset.CoreV1().Pods(namespace).GetLogs(target.pod.Name, logOptions).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	resp := make([]*LogSearchLine, 0)
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// each line is prefixed with the RFC3339 timestamp when timestamps is enabled
+		var logTime int64
+		if idx := strings.IndexByte(line, ' '); idx > 0 {
+			if t, err := time.Parse(time.RFC3339Nano, line[:idx]); err == nil {
+				logTime = t.Unix()
+				line = line[idx+1:]
+			}
+		}
+
+		if args.EndTime > 0 && logTime > args.EndTime {
+			break
+		}
+		if args.Keyword != "" && !strings.Contains(line, args.Keyword) {
+			continue
+		}
+		if re != nil && !re.MatchString(line) {
+			continue
+		}
+		resp = append(resp, &LogSearchLine{
+			Time:          logTime,
+			ServiceName:   target.serviceName,
+			PodName:       target.pod.Name,
+			ContainerName: target.container,
+			Content:       line,
+		})
+	}
+	return resp, scanner.Err()
+}
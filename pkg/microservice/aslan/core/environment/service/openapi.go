@@ -27,6 +27,7 @@ import (
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
 	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
 	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
 	"github.com/koderover/zadig/v2/pkg/setting"
 	"github.com/koderover/zadig/v2/pkg/shared/client/systemconfig"
@@ -146,6 +147,37 @@ func OpenAPIRestartService(projectName, envName, serviceName string, production
 	return RestartService(envName, args, production, logger)
 }
 
+// OpenAPIUpdateHelmServiceValues updates the values of a helm service or a chart released in the env by the release name
+func OpenAPIUpdateHelmServiceValues(projectName, envName, serviceName, userName, requestID string, req *OpenAPIUpdateHelmServiceValuesReq, production, canOverrideLockedValues bool, logger *zap.SugaredLogger) error {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(fmt.Errorf("failed to find env %s, err: %w", envName, err))
+	}
+
+	arg := &commonservice.HelmSvcRenderArg{
+		EnvName:     envName,
+		ServiceName: serviceName,
+	}
+	rendersetArg := &EnvRendersetArg{
+		UpdateServiceTmpl: req.UpdateServiceTmpl,
+	}
+	if render, ok := env.GetChartDeployRenderMap()[serviceName]; ok {
+		arg.LoadFromRenderChartModel(render)
+		rendersetArg.DeployType = setting.HelmChartDeployType
+	} else if _, ok := env.GetChartRenderMap()[serviceName]; !ok {
+		return e.ErrUpdateEnv.AddDesc(fmt.Sprintf("service %s not found in env %s", serviceName, envName))
+	}
+	arg.OverrideYaml = req.OverrideYaml
+	arg.OverrideValues = req.OverrideValues
+	rendersetArg.ChartValues = []*commonservice.HelmSvcRenderArg{arg}
+
+	return UpdateHelmProductCharts(projectName, envName, userName, requestID, production, canOverrideLockedValues, rendersetArg, logger)
+}
+
 func OpenAPIGetGlobalVariables(projectName, envName string, production bool, logger *zap.SugaredLogger) ([]*commontypes.GlobalVariableKV, error) {
 	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
 		Name:       projectName,
@@ -0,0 +1,320 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type EnvApplyAction string
+
+const (
+	EnvApplyActionCreate EnvApplyAction = "create"
+	EnvApplyActionUpdate EnvApplyAction = "update"
+	EnvApplyActionDelete EnvApplyAction = "delete"
+	EnvApplyActionNone   EnvApplyAction = "none"
+)
+
+// OpenAPIApplyEnvArgs is the full desired spec of the env, the services not in the spec are deleted from the env
+type OpenAPIApplyEnvArgs struct {
+	OpenAPICreateEnvArgs
+	// DryRun only returns the change plan without applying it
+	DryRun bool `json:"dry_run"`
+}
+
+// EnvApplyPlan is the changes to make the env match the desired spec
+type EnvApplyPlan struct {
+	ProjectName            string                 `json:"project_key"`
+	EnvName                string                 `json:"env_key"`
+	Production             bool                   `json:"production"`
+	Action                 EnvApplyAction         `json:"action"`
+	RegistryChanged        bool                   `json:"registry_changed"`
+	ChangedGlobalVariables []string               `json:"changed_global_variables"`
+	Services               []*EnvApplyServicePlan `json:"services"`
+	DryRun                 bool                   `json:"dry_run"`
+}
+
+type EnvApplyServicePlan struct {
+	ServiceName      string         `json:"service_name"`
+	Action           EnvApplyAction `json:"action"`
+	ChangedVariables []string       `json:"changed_variables,omitempty"`
+}
+
+func (p *EnvApplyPlan) servicesWithAction(action EnvApplyAction) []string {
+	resp := make([]string, 0)
+	for _, svc := range p.Services {
+		if svc.Action == action {
+			resp = append(resp, svc.ServiceName)
+		}
+	}
+	return resp
+}
+
+// PlanEnvironmentApply computes the changes between the env and the desired spec, only k8s yaml projects are supported.
+// The cluster and the namespace of an existing env can't be changed.
+func PlanEnvironmentApply(args *OpenAPIApplyEnvArgs, log *zap.SugaredLogger) (*EnvApplyPlan, error) {
+	project, err := templaterepo.NewProductColl().Find(args.ProjectName)
+	if err != nil {
+		return nil, e.ErrNotFound.AddDesc(fmt.Sprintf("project %s not found", args.ProjectName))
+	}
+	if project.ProductFeature.DeployType != setting.K8SDeployType {
+		return nil, e.ErrInvalidParam.AddDesc("only k8s yaml projects are supported")
+	}
+
+	plan := &EnvApplyPlan{
+		ProjectName:            args.ProjectName,
+		EnvName:                args.EnvName,
+		Production:             args.Production,
+		ChangedGlobalVariables: make([]string, 0),
+		Services:               make([]*EnvApplyServicePlan, 0),
+		DryRun:                 args.DryRun,
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:              args.ProjectName,
+		EnvName:           args.EnvName,
+		IgnoreNotFoundErr: true,
+	})
+	if err != nil {
+		log.Errorf("failed to find env %s/%s, error: %v", args.ProjectName, args.EnvName, err)
+		return nil, e.ErrGetEnv.AddErr(err)
+	}
+
+	if env == nil {
+		plan.Action = EnvApplyActionCreate
+		for _, vb := range args.GlobalVariables {
+			plan.ChangedGlobalVariables = append(plan.ChangedGlobalVariables, vb.Key)
+		}
+		for _, svc := range args.Services {
+			plan.Services = append(plan.Services, &EnvApplyServicePlan{ServiceName: svc.ServiceName, Action: EnvApplyActionCreate})
+		}
+		return plan, nil
+	}
+
+	if env.Production != args.Production {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("env %s exists with production: %v", args.EnvName, env.Production))
+	}
+	if env.ClusterID != args.ClusterID || env.Namespace != args.Namespace {
+		return nil, e.ErrInvalidParam.AddDesc("cluster_id and namespace of an existing env can't be changed, recreate the env instead")
+	}
+	if env.IsSleeping() {
+		return nil, e.ErrUpdateEnv.AddDesc("environment is sleeping")
+	}
+
+	plan.RegistryChanged = env.RegistryID != args.RegistryID
+
+	currentGlobalVariables, _, err := GetGlobalVariables(args.ProjectName, args.EnvName, args.Production, log)
+	if err != nil {
+		return nil, err
+	}
+	currentGlobalVariableMap := make(map[string]interface{})
+	for _, vb := range currentGlobalVariables {
+		currentGlobalVariableMap[vb.Key] = vb.Value
+	}
+	for _, vb := range args.GlobalVariables {
+		current, ok := currentGlobalVariableMap[vb.Key]
+		if !ok || !variableValueEqual(current, vb.Value) {
+			plan.ChangedGlobalVariables = append(plan.ChangedGlobalVariables, vb.Key)
+		}
+	}
+
+	desiredServices := make(map[string]*OpenAPICreateServiceArgs)
+	for _, svc := range args.Services {
+		desiredServices[svc.ServiceName] = svc
+	}
+	currentServices := env.GetServiceMap()
+
+	for _, svc := range args.Services {
+		current, ok := currentServices[svc.ServiceName]
+		if !ok {
+			plan.Services = append(plan.Services, &EnvApplyServicePlan{ServiceName: svc.ServiceName, Action: EnvApplyActionCreate})
+			continue
+		}
+		changed, err := changedServiceVariables(current, svc.VariableKVs)
+		if err != nil {
+			return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("failed to parse the variables of service %s in env, err: %s", svc.ServiceName, err))
+		}
+		action := EnvApplyActionNone
+		if len(changed) > 0 {
+			action = EnvApplyActionUpdate
+		}
+		plan.Services = append(plan.Services, &EnvApplyServicePlan{ServiceName: svc.ServiceName, Action: action, ChangedVariables: changed})
+	}
+
+	deleted := make([]string, 0)
+	for name := range currentServices {
+		if _, ok := desiredServices[name]; !ok {
+			deleted = append(deleted, name)
+		}
+	}
+	sort.Strings(deleted)
+	for _, name := range deleted {
+		plan.Services = append(plan.Services, &EnvApplyServicePlan{ServiceName: name, Action: EnvApplyActionDelete})
+	}
+
+	plan.Action = EnvApplyActionNone
+	if plan.RegistryChanged || len(plan.ChangedGlobalVariables) > 0 {
+		plan.Action = EnvApplyActionUpdate
+	}
+	for _, svc := range plan.Services {
+		if svc.Action != EnvApplyActionNone {
+			plan.Action = EnvApplyActionUpdate
+		}
+	}
+	return plan, nil
+}
+
+// ApplyEnvironmentPlan applies the plan computed by PlanEnvironmentApply, the services are deleted first and then
+// the global variables, the updated and the created services are applied.
+func ApplyEnvironmentPlan(args *OpenAPIApplyEnvArgs, plan *EnvApplyPlan, userName, requestID string, log *zap.SugaredLogger) error {
+	switch plan.Action {
+	case EnvApplyActionNone:
+		return nil
+	case EnvApplyActionCreate:
+		if args.Production {
+			return OpenAPICreateProductionEnv(&args.OpenAPICreateEnvArgs, userName, requestID, log)
+		}
+		return OpenAPICreateK8sEnv(&args.OpenAPICreateEnvArgs, userName, requestID, log)
+	}
+
+	if deleted := plan.servicesWithAction(EnvApplyActionDelete); len(deleted) > 0 {
+		if err := DeleteProductServices(userName, requestID, args.EnvName, args.ProjectName, deleted, args.Production, log); err != nil {
+			return err
+		}
+	}
+
+	if plan.RegistryChanged {
+		if err := UpdateProductRegistry(args.EnvName, args.ProjectName, args.RegistryID, args.Production, log); err != nil {
+			return err
+		}
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       args.ProjectName,
+		EnvName:    args.EnvName,
+		Production: &args.Production,
+	})
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+
+	if len(plan.ChangedGlobalVariables) > 0 {
+		currentGlobalVariables, _, err := GetGlobalVariables(args.ProjectName, args.EnvName, args.Production, log)
+		if err != nil {
+			return err
+		}
+		desired := make(map[string]*commontypes.GlobalVariableKV)
+		for _, vb := range args.GlobalVariables {
+			desired[vb.Key] = vb
+		}
+		// the global variables not in the spec are kept as is
+		for _, vb := range currentGlobalVariables {
+			if d, ok := desired[vb.Key]; ok {
+				vb.Value = d.Value
+				delete(desired, vb.Key)
+			}
+		}
+		for _, vb := range args.GlobalVariables {
+			if _, ok := desired[vb.Key]; ok {
+				currentGlobalVariables = append(currentGlobalVariables, vb)
+			}
+		}
+		if err := UpdateProductGlobalVariables(args.ProjectName, args.EnvName, userName, requestID, env.UpdateTime, currentGlobalVariables, args.Production, log); err != nil {
+			return err
+		}
+	}
+
+	created := plan.servicesWithAction(EnvApplyActionCreate)
+	updated := plan.servicesWithAction(EnvApplyActionUpdate)
+	if len(created)+len(updated) == 0 {
+		return nil
+	}
+
+	isCreated, isUpdated := make(map[string]bool), make(map[string]bool)
+	for _, name := range created {
+		isCreated[name] = true
+	}
+	for _, name := range updated {
+		isUpdated[name] = true
+	}
+	services := make([]*UpdateServiceArg, 0)
+	for _, svc := range args.Services {
+		if !isCreated[svc.ServiceName] && !isUpdated[svc.ServiceName] {
+			continue
+		}
+		if err := setGlobalVariableToServiceVariable(svc.VariableKVs, svc.ServiceName, args.ProjectName, args.EnvName, args.Production, log); err != nil {
+			return e.ErrUpdateEnv.AddErr(err)
+		}
+		variables, err := fillServiceVariableAttribute(svc.VariableKVs, svc.ServiceName, args.ProjectName, env, args.Production, isCreated[svc.ServiceName], log)
+		if err != nil {
+			return e.ErrUpdateEnv.AddErr(err)
+		}
+		services = append(services, &UpdateServiceArg{
+			ServiceName:    svc.ServiceName,
+			DeployStrategy: setting.ServiceDeployStrategyDeploy,
+			VariableKVs:    variables,
+		})
+	}
+
+	_, err = UpdateMultipleK8sEnv([]*UpdateEnv{{EnvName: args.EnvName, Services: services}}, []string{args.EnvName}, args.ProjectName, requestID, false, args.Production, userName, log)
+	return err
+}
+
+// changedServiceVariables returns the keys of the variables which are different from the service in the env,
+// the variables using the global variables follow the global variables so they are not compared.
+func changedServiceVariables(svc *commonmodels.ProductService, variables []*commontypes.RenderVariableKV) ([]string, error) {
+	current := make(map[string]interface{})
+	if content := svc.GetServiceRender().GetSafeVariable(); content != "" {
+		if err := yaml.Unmarshal([]byte(content), &current); err != nil {
+			return nil, err
+		}
+	}
+
+	changed := make([]string, 0)
+	for _, kv := range variables {
+		if kv.UseGlobalVariable {
+			continue
+		}
+		value, ok := current[kv.Key]
+		if !ok || !variableValueEqual(value, kv.Value) {
+			changed = append(changed, kv.Key)
+		}
+	}
+	return changed, nil
+}
+
+// variableValueEqual compares the values decoded from json and yaml, the numbers are float64 in both cases
+func variableValueEqual(a, b interface{}) bool {
+	aBytes, errA := json.Marshal(a)
+	bBytes, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return string(aBytes) == string(bBytes)
+}
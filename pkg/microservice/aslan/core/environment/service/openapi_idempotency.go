@@ -0,0 +1,120 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	commonconfig "github.com/koderover/zadig/v2/pkg/config"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+const (
+	openAPIIdempotencyTTL = 24 * time.Hour
+	// openAPIIdempotencyMaxKeyLength limits the key stored in redis, uuids are expected
+	openAPIIdempotencyMaxKeyLength = 128
+	// openAPIIdempotencyLockExpiry covers the slow env operations so the retries don't run them again
+	openAPIIdempotencyLockExpiry = 5 * time.Minute
+)
+
+// openAPIIdempotencyResult is the result of the first request with the idempotency key, it's returned to the retries
+type openAPIIdempotencyResult struct {
+	Failed  bool            `json:"failed"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Desc    string          `json:"desc"`
+	Resp    json.RawMessage `json:"resp,omitempty"`
+}
+
+// RunIdempotentOpenAPIRequest runs fn once for the idempotency key of the user and the operation, the requests with the
+// same key in 24 hours get the result of the first one. The concurrent requests with the same key wait for the first
+// one to finish. fn runs directly if the key is empty.
+func RunIdempotentOpenAPIRequest(userID, key, operation string, fn func() (interface{}, error), log *zap.SugaredLogger) (interface{}, error) {
+	if key == "" {
+		return fn()
+	}
+	if len(key) > openAPIIdempotencyMaxKeyLength {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("idempotency key should be no longer than %d", openAPIIdempotencyMaxKeyLength))
+	}
+
+	resultKey := fmt.Sprintf("openapi-idempotency-%s-%s-%s", userID, operation, key)
+	lock := cache.NewRedisLockWithTimeout("openapi-idempotency-lock:"+resultKey, openAPIIdempotencyLockExpiry, openAPIIdempotencyLockExpiry)
+	if err := lock.Lock(); err != nil {
+		log.Errorf("failed to lock idempotency key %s, error: %v", key, err)
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	redisCache := cache.NewRedisCache(commonconfig.RedisCommonCacheTokenDB())
+	value, err := redisCache.GetString(resultKey)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Errorf("failed to get the result of idempotency key %s, error: %v", key, err)
+		return nil, err
+	}
+	if err == nil {
+		result := &openAPIIdempotencyResult{}
+		if err := json.Unmarshal([]byte(value), result); err != nil {
+			log.Errorf("failed to unmarshal the result of idempotency key %s, error: %v", key, err)
+			return nil, err
+		}
+		log.Infof("request with idempotency key %s has been processed, the previous result is returned", key)
+		return result.response()
+	}
+
+	resp, respErr := fn()
+
+	result := &openAPIIdempotencyResult{}
+	if respErr != nil {
+		result.Failed = true
+		result.Message = respErr.Error()
+		if httpErr, ok := respErr.(*e.HTTPError); ok {
+			result.Code, result.Message, result.Desc = httpErr.Code(), httpErr.Message(), httpErr.Desc()
+		}
+	} else if resp != nil {
+		result.Resp, err = json.Marshal(resp)
+		if err != nil {
+			log.Warnf("failed to marshal the response of idempotency key %s, error: %v", key, err)
+			return resp, respErr
+		}
+	}
+
+	data, _ := json.Marshal(result)
+	if err := redisCache.Write(resultKey, string(data), openAPIIdempotencyTTL); err != nil {
+		log.Warnf("failed to save the result of idempotency key %s, error: %v", key, err)
+	}
+	return resp, respErr
+}
+
+func (r *openAPIIdempotencyResult) response() (interface{}, error) {
+	if r.Failed {
+		if r.Code != 0 {
+			return nil, e.NewHTTPError(r.Code, r.Message, r.Desc)
+		}
+		return nil, errors.New(r.Message)
+	}
+	if len(r.Resp) == 0 {
+		return nil, nil
+	}
+	return r.Resp, nil
+}
@@ -0,0 +1,327 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/portforward"
+)
+
+const (
+	portForwardDefaultTTL = time.Hour
+	portForwardMaxTTL     = 8 * time.Hour
+	// the max number of the active port forward sessions of a user
+	portForwardMaxSessionsPerUser = 3
+)
+
+type CreatePortForwardArgs struct {
+	EnvName    string `json:"env_name"`
+	Production bool   `json:"production"`
+	// ServiceName is the name of the kubernetes service, and Port is the service port if it is set,
+	// otherwise Port is the container port of the pod
+	ServiceName string `json:"service_name"`
+	PodName     string `json:"pod_name"`
+	Port        int    `json:"port"`
+	TTLMinutes  int    `json:"ttl_minutes"`
+}
+
+// CreatePortForwardSession creates a temporary session to the port of a pod in the env, the developer connects to
+// the session with websocket and each websocket connection is forwarded to the port as a tcp connection.
+func CreatePortForwardSession(projectName, userName string, args *CreatePortForwardArgs, log *zap.SugaredLogger) (*commonmodels.PortForwardSession, error) {
+	if args.Port <= 0 || (args.ServiceName == "" && args.PodName == "") {
+		return nil, e.ErrCreatePortForward.AddDesc("service_name or pod_name and port must be specified")
+	}
+	ttl := portForwardDefaultTTL
+	if args.TTLMinutes > 0 {
+		ttl = time.Duration(args.TTLMinutes) * time.Minute
+	}
+	if ttl > portForwardMaxTTL {
+		return nil, e.ErrCreatePortForward.AddDesc(fmt.Sprintf("ttl can't be longer than %s", portForwardMaxTTL))
+	}
+
+	count, err := commonrepo.NewPortForwardSessionColl().CountActiveByUser(userName)
+	if err != nil {
+		return nil, e.ErrCreatePortForward.AddErr(err)
+	}
+	if count >= portForwardMaxSessionsPerUser {
+		return nil, e.ErrCreatePortForward.AddDesc(fmt.Sprintf("at most %d active port forward sessions are allowed for a user, please close the unused ones", portForwardMaxSessionsPerUser))
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    args.EnvName,
+		Production: &args.Production,
+	})
+	if err != nil {
+		return nil, e.ErrCreatePortForward.AddDesc(fmt.Sprintf("failed to find env %s: %s", args.EnvName, err))
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrCreatePortForward.AddErr(err)
+	}
+
+	podName, port := args.PodName, args.Port
+	if args.ServiceName != "" {
+		podName, port, err = resolveServicePort(kubeClient, env.Namespace, args.ServiceName, args.Port)
+		if err != nil {
+			return nil, e.ErrCreatePortForward.AddErr(err)
+		}
+	} else {
+		pod, found, err := getter.GetPod(env.Namespace, podName, kubeClient)
+		if err != nil || !found {
+			return nil, e.ErrCreatePortForward.AddDesc(fmt.Sprintf("pod %s not found", podName))
+		}
+		if pod.Status.Phase != corev1.PodRunning {
+			return nil, e.ErrCreatePortForward.AddDesc(fmt.Sprintf("pod %s is %s, not running", podName, pod.Status.Phase))
+		}
+	}
+
+	now := time.Now()
+	session := &commonmodels.PortForwardSession{
+		ProjectName: projectName,
+		EnvName:     args.EnvName,
+		Production:  env.Production,
+		ClusterID:   env.ClusterID,
+		Namespace:   env.Namespace,
+		ServiceName: args.ServiceName,
+		PodName:     podName,
+		Port:        port,
+		UserName:    userName,
+		Status:      commonmodels.PortForwardStatusActive,
+		CreateTime:  now.Unix(),
+		ExpireTime:  now.Add(ttl).Unix(),
+	}
+	if err := commonrepo.NewPortForwardSessionColl().Create(session); err != nil {
+		log.Errorf("failed to create port forward session, error: %s", err)
+		return nil, e.ErrCreatePortForward.AddErr(err)
+	}
+	return session, nil
+}
+
+// resolveServicePort picks a running pod of the service and returns the container port the service port targets
+func resolveServicePort(kubeClient client.Client, namespace, serviceName string, servicePort int) (string, int, error) {
+	svc, found, err := getter.GetService(namespace, serviceName, kubeClient)
+	if err != nil || !found {
+		return "", 0, fmt.Errorf("service %s not found", serviceName)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return "", 0, fmt.Errorf("service %s has no selector", serviceName)
+	}
+
+	var svcPort *corev1.ServicePort
+	for i, p := range svc.Spec.Ports {
+		if int(p.Port) == servicePort {
+			svcPort = &svc.Spec.Ports[i]
+			break
+		}
+	}
+	if svcPort == nil {
+		return "", 0, fmt.Errorf("service %s has no port %d", serviceName, servicePort)
+	}
+
+	pods, err := getter.ListPods(namespace, labels.SelectorFromSet(svc.Spec.Selector), kubeClient)
+	if err != nil {
+		return "", 0, err
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		switch {
+		case svcPort.TargetPort.IntValue() > 0:
+			return pod.Name, svcPort.TargetPort.IntValue(), nil
+		case svcPort.TargetPort.StrVal != "":
+			for _, container := range pod.Spec.Containers {
+				for _, p := range container.Ports {
+					if p.Name == svcPort.TargetPort.StrVal {
+						return pod.Name, int(p.ContainerPort), nil
+					}
+				}
+			}
+			return "", 0, fmt.Errorf("pod %s has no port named %s", pod.Name, svcPort.TargetPort.StrVal)
+		default:
+			return pod.Name, servicePort, nil
+		}
+	}
+	return "", 0, fmt.Errorf("no running pod of service %s", serviceName)
+}
+
+func ListPortForwardSessions(opt *commonrepo.ListPortForwardSessionOption, log *zap.SugaredLogger) ([]*commonmodels.PortForwardSession, int64, error) {
+	sessions, total, err := commonrepo.NewPortForwardSessionColl().List(opt)
+	if err != nil {
+		log.Errorf("failed to list port forward sessions, error: %s", err)
+		return nil, 0, e.ErrListPortForward.AddErr(err)
+	}
+	now := time.Now().Unix()
+	for _, session := range sessions {
+		if session.Status == commonmodels.PortForwardStatusActive && session.ExpireTime <= now {
+			session.Status = commonmodels.PortForwardStatusExpired
+		}
+	}
+	return sessions, total, nil
+}
+
+func GetPortForwardSession(id string) (*commonmodels.PortForwardSession, error) {
+	session, err := commonrepo.NewPortForwardSessionColl().GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("port forward session %s not found: %s", id, err)
+	}
+	return session, nil
+}
+
+func ClosePortForwardSession(session *commonmodels.PortForwardSession, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewPortForwardSessionColl().UpdateStatus(session.ID, commonmodels.PortForwardStatusClosed); err != nil {
+		log.Errorf("failed to close port forward session %s, error: %s", session.ID.Hex(), err)
+		return e.ErrClosePortForward.AddErr(err)
+	}
+	return nil
+}
+
+// ConnectPortForwardSession forwards the websocket connection to the port of the session,
+// the connection is closed when the session is closed or expired
+func ConnectPortForwardSession(c *gin.Context, session *commonmodels.PortForwardSession, log *zap.SugaredLogger) error {
+	if session.Status != commonmodels.PortForwardStatusActive {
+		return e.ErrConnectPortForward.AddDesc(fmt.Sprintf("session is %s", session.Status))
+	}
+	if time.Now().Unix() >= session.ExpireTime {
+		_ = commonrepo.NewPortForwardSessionColl().UpdateStatus(session.ID, commonmodels.PortForwardStatusExpired)
+		return e.ErrConnectPortForward.AddDesc("session is expired")
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(session.ClusterID)
+	if err != nil {
+		return e.ErrConnectPortForward.AddErr(err)
+	}
+	req := kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(session.Namespace).
+		Name(session.PodName).
+		SubResource("portforward")
+	dialer, err := clientmanager.NewKubeClientManager().GetSPDYDialer(session.ClusterID, req.URL())
+	if err != nil {
+		return e.ErrConnectPortForward.AddErr(err)
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return e.ErrConnectPortForward.AddErr(err)
+	}
+	defer ws.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go watchPortForwardSession(session, ws, done, log)
+
+	conn := &wsConn{ws: ws}
+	if err := portforward.Forward(dialer, session.Port, conn); err != nil {
+		log.Warnf("port forward of session %s finished with error: %s", session.ID.Hex(), err)
+		_ = ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+	}
+	if err := commonrepo.NewPortForwardSessionColl().IncStats(session.ID, atomic.LoadInt64(&conn.bytesIn), atomic.LoadInt64(&conn.bytesOut)); err != nil {
+		log.Errorf("failed to record port forward session %s, error: %s", session.ID.Hex(), err)
+	}
+	return nil
+}
+
+// watchPortForwardSession closes the connection when the session is closed by the user on any replica, or expired
+func watchPortForwardSession(session *commonmodels.PortForwardSession, ws *websocket.Conn, done chan struct{}, log *zap.SugaredLogger) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if time.Now().Unix() >= session.ExpireTime {
+				_ = commonrepo.NewPortForwardSessionColl().UpdateStatus(session.ID, commonmodels.PortForwardStatusExpired)
+				log.Infof("port forward session %s is expired, close the connection", session.ID.Hex())
+				_ = ws.Close()
+				return
+			}
+			latest, err := commonrepo.NewPortForwardSessionColl().GetByID(session.ID.Hex())
+			if err == nil && latest.Status != commonmodels.PortForwardStatusActive {
+				log.Infof("port forward session %s is %s, close the connection", session.ID.Hex(), latest.Status)
+				_ = ws.Close()
+				return
+			}
+		}
+	}
+}
+
+// wsConn transfers the tcp data with websocket binary messages
+type wsConn struct {
+	ws     *websocket.Conn
+	reader io.Reader
+	// bytesIn is the data sent to the pod and bytesOut is the data received from the pod
+	bytesIn  int64
+	bytesOut int64
+}
+
+func (c *wsConn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			msgType, reader, err := c.ws.NextReader()
+			if err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			if msgType != websocket.BinaryMessage {
+				continue
+			}
+			c.reader = reader
+		}
+
+		n, err := c.reader.Read(p)
+		atomic.AddInt64(&c.bytesIn, int64(n))
+		if err == io.EOF {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	atomic.AddInt64(&c.bytesOut, int64(len(p)))
+	return len(p), nil
+}
@@ -244,6 +244,7 @@ func buildProductResp(envName string, prod *commonmodels.Product, log *zap.Sugar
 		IstioGrayscaleIsBase:  prod.IstioGrayscale.IsBase,
 		IstioGrayscaleBaseEnv: prod.IstioGrayscale.BaseEnv,
 		YamlData:              prod.YamlData,
+		ResourceVersion:       prod.ResourceVersion,
 	}
 
 	serviceMap := prod.GetServiceMap()
@@ -56,6 +56,15 @@ func ListProductionGroups(serviceName, envName, productName string, perPage, pag
 }
 
 func DeleteProductionProduct(username, envName, productName, requestID string, log *zap.SugaredLogger) (err error) {
+	defer func() {
+		if err == nil {
+			go removeEnvDNSRecords(productName, envName, log)
+			go removeEnvTrafficMirrors(productName, envName, log)
+			go removeEnvAutoscalers(productName, envName, log)
+			go removeEnvHealthScores(productName, envName, true, log)
+		}
+	}()
+
 	eventStart := time.Now().Unix()
 	productInfo, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName, Production: util.GetBoolPointer(true)})
 	if err != nil {
@@ -86,12 +95,12 @@ func DeleteProductionProduct(username, envName, productName, requestID string, l
 	if err != nil {
 		log.Errorf("Production product delete error: %v", err)
 		title := fmt.Sprintf("删除项目:[%s] 环境:[%s] 失败!", productName, envName)
-		notify.SendErrorMessage(username, title, requestID, err, log)
+		notify.SendEnvErrorMessage(username, productName, title, requestID, err, log)
 		_ = commonrepo.NewProductColl().UpdateStatus(envName, productName, setting.ProductStatusUnknown)
 	} else {
 		title := fmt.Sprintf("删除项目:[%s] 环境:[%s] 成功!", productName, envName)
 		content := fmt.Sprintf("namespace:%s", productInfo.Namespace)
-		notify.SendMessage(username, title, content, requestID, log)
+		notify.SendEnvMessage(username, productName, title, content, requestID, log)
 	}
 
 	// remove custom labels
@@ -220,6 +220,7 @@ func GetService(envName, productName, serviceName string, production bool, workL
 				ret.Services = append(ret.Services, releaseService.Services...)
 			}
 		}
+		ret.DashboardLinks = commonservice.RenderServiceDashboardLinks(projectInfo.DashboardLinks, env, serviceName, ret.Workloads)
 		ret.Workloads = nil
 		ret.Namespace = env.Namespace
 	} else {
@@ -227,6 +228,7 @@ func GetService(envName, productName, serviceName string, production bool, workL
 		if err != nil {
 			return nil, e.ErrGetService.AddErr(err)
 		}
+		ret.DashboardLinks = commonservice.RenderServiceDashboardLinks(projectInfo.DashboardLinks, env, serviceName, ret.Workloads)
 		ret.Workloads = nil
 		ret.Namespace = env.Namespace
 	}
@@ -422,6 +424,9 @@ func PreviewService(args *PreviewServiceArgs, _ *zap.SugaredLogger) (*SvcDiffRes
 
 	ret.Current.Yaml = curYaml
 	ret.Latest.Yaml = latestYaml
+	if args.UpdateServiceRevision {
+		ret.Diff = unifiedYamlDiff(curYaml, latestYaml, "current", "latest")
+	}
 
 	return ret, nil
 }
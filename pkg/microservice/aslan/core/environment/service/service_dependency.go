@@ -0,0 +1,285 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/v2/pkg/tool/helmclient"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+// ServiceDependency describes a resource of a remaining service which references a resource of a service to be deleted
+type ServiceDependency struct {
+	Service          string `json:"service"`
+	Kind             string `json:"kind"`
+	Name             string `json:"name"`
+	DependsOnService string `json:"depends_on_service"`
+	DependsOnKind    string `json:"depends_on_kind"`
+	DependsOnName    string `json:"depends_on_name"`
+}
+
+type ServiceDeletionAnalysis struct {
+	Dependencies []*ServiceDependency `json:"dependencies"`
+}
+
+const horizontalPodAutoscalerKind = "HorizontalPodAutoscaler"
+
+type resourceRef struct {
+	kind string
+	name string
+}
+
+// AnalyzeServiceDeletion finds the references from the other services in the env to the resources of the services to be deleted,
+// e.g. an ingress routing to a k8s Service or a workload mounting a ConfigMap rendered by a service in serviceNames.
+func AnalyzeServiceDeletion(projectName, envName string, serviceNames []string, production bool, log *zap.SugaredLogger) (*ServiceDeletionAnalysis, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: util.GetBoolPointer(production),
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	manifests, err := renderedServiceManifests(env, log)
+	if err != nil {
+		return nil, err
+	}
+
+	toDelete := make(map[string]bool)
+	for _, name := range serviceNames {
+		toDelete[name] = true
+	}
+
+	owners := make(map[resourceRef]string)
+	for svc, manifest := range manifests {
+		if !toDelete[svc] {
+			continue
+		}
+		resources, _, err := kube.ManifestToUnstructured(manifest)
+		if err != nil {
+			log.Warnf("failed to parse manifest of service %s, err: %s", svc, err)
+		}
+		for _, res := range resources {
+			owners[resourceRef{kind: res.GetKind(), name: res.GetName()}] = svc
+		}
+	}
+
+	resp := &ServiceDeletionAnalysis{Dependencies: make([]*ServiceDependency, 0)}
+	for svc, manifest := range manifests {
+		if toDelete[svc] {
+			continue
+		}
+		resources, _, err := kube.ManifestToUnstructured(manifest)
+		if err != nil {
+			log.Warnf("failed to parse manifest of service %s, err: %s", svc, err)
+		}
+		for _, res := range resources {
+			for _, ref := range resourceReferences(res) {
+				owner, ok := owners[ref]
+				if !ok {
+					continue
+				}
+				resp.Dependencies = append(resp.Dependencies, &ServiceDependency{
+					Service:          svc,
+					Kind:             res.GetKind(),
+					Name:             res.GetName(),
+					DependsOnService: owner,
+					DependsOnKind:    ref.kind,
+					DependsOnName:    ref.name,
+				})
+			}
+		}
+	}
+
+	sort.Slice(resp.Dependencies, func(i, j int) bool {
+		a, b := resp.Dependencies[i], resp.Dependencies[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return resp, nil
+}
+
+// renderedServiceManifests returns the manifests of the services deployed in the env, keyed by service name
+func renderedServiceManifests(env *commonmodels.Product, log *zap.SugaredLogger) (map[string]string, error) {
+	switch getProjectType(env.ProductName) {
+	case setting.K8SDeployType:
+		return renderedK8sServiceManifests(env)
+	case setting.HelmDeployType:
+		return renderedHelmServiceManifests(env, log)
+	default:
+		return map[string]string{}, nil
+	}
+}
+
+func renderedK8sServiceManifests(env *commonmodels.Product) (map[string]string, error) {
+	resp := make(map[string]string)
+	for _, svc := range env.GetSvcList() {
+		if !commonutil.ServiceDeployed(svc.ServiceName, env.ServiceDeployStrategy) {
+			continue
+		}
+		manifest, _, err := kube.FetchCurrentAppliedYaml(&kube.GeneSvcYamlOption{
+			ProductName: env.ProductName,
+			EnvName:     env.EnvName,
+			ServiceName: svc.ServiceName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to render yaml of service %s, err: %w", svc.ServiceName, err)
+		}
+		resp[svc.ServiceName] = manifest
+	}
+	return resp, nil
+}
+
+func renderedHelmServiceManifests(env *commonmodels.Product, log *zap.SugaredLogger) (map[string]string, error) {
+	resp := make(map[string]string)
+	releaseToService, err := commonutil.GetReleaseNameToServiceNameMap(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build release-service map, err: %w", err)
+	}
+	client, err := helmtool.NewClientFromNamespace(env.ClusterID, env.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init helm client, err: %w", err)
+	}
+	for releaseName, serviceName := range releaseToService {
+		release, err := client.GetRelease(releaseName)
+		if err != nil {
+			// the release may have not been installed yet
+			log.Warnf("failed to get release %s in env %s, err: %s", releaseName, env.EnvName, err)
+			continue
+		}
+		resp[serviceName] = release.Manifest
+	}
+	return resp, nil
+}
+
+// resourceReferences returns the resources referenced by name in the given resource
+func resourceReferences(res *unstructured.Unstructured) []resourceRef {
+	var refs []resourceRef
+	add := func(kind, name string) {
+		if name != "" {
+			refs = append(refs, resourceRef{kind: kind, name: name})
+		}
+	}
+
+	switch res.GetKind() {
+	case setting.Ingress:
+		if name, ok, _ := unstructured.NestedString(res.Object, "spec", "defaultBackend", "service", "name"); ok {
+			add(setting.Service, name)
+		}
+		if name, ok, _ := unstructured.NestedString(res.Object, "spec", "backend", "serviceName"); ok {
+			add(setting.Service, name)
+		}
+		rules, _, _ := unstructured.NestedSlice(res.Object, "spec", "rules")
+		for _, rule := range rules {
+			paths, _, _ := unstructured.NestedSlice(asMap(rule), "http", "paths")
+			for _, path := range paths {
+				if name, ok, _ := unstructured.NestedString(asMap(path), "backend", "service", "name"); ok {
+					add(setting.Service, name)
+				}
+				if name, ok, _ := unstructured.NestedString(asMap(path), "backend", "serviceName"); ok {
+					add(setting.Service, name)
+				}
+			}
+		}
+	case horizontalPodAutoscalerKind:
+		kind, _, _ := unstructured.NestedString(res.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(res.Object, "spec", "scaleTargetRef", "name")
+		add(kind, name)
+	default:
+		podSpec := workloadPodSpec(res)
+		if podSpec == nil {
+			break
+		}
+		volumes, _, _ := unstructured.NestedSlice(podSpec, "volumes")
+		for _, volume := range volumes {
+			if name, ok, _ := unstructured.NestedString(asMap(volume), "configMap", "name"); ok {
+				add(setting.ConfigMap, name)
+			}
+			if name, ok, _ := unstructured.NestedString(asMap(volume), "secret", "secretName"); ok {
+				add(setting.Secret, name)
+			}
+			if name, ok, _ := unstructured.NestedString(asMap(volume), "persistentVolumeClaim", "claimName"); ok {
+				add(setting.PersistentVolumeClaim, name)
+			}
+		}
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _, _ := unstructured.NestedSlice(podSpec, field)
+			for _, container := range containers {
+				envFroms, _, _ := unstructured.NestedSlice(asMap(container), "envFrom")
+				for _, envFrom := range envFroms {
+					if name, ok, _ := unstructured.NestedString(asMap(envFrom), "configMapRef", "name"); ok {
+						add(setting.ConfigMap, name)
+					}
+					if name, ok, _ := unstructured.NestedString(asMap(envFrom), "secretRef", "name"); ok {
+						add(setting.Secret, name)
+					}
+				}
+				envs, _, _ := unstructured.NestedSlice(asMap(container), "env")
+				for _, env := range envs {
+					if name, ok, _ := unstructured.NestedString(asMap(env), "valueFrom", "configMapKeyRef", "name"); ok {
+						add(setting.ConfigMap, name)
+					}
+					if name, ok, _ := unstructured.NestedString(asMap(env), "valueFrom", "secretKeyRef", "name"); ok {
+						add(setting.Secret, name)
+					}
+				}
+			}
+		}
+	}
+	return refs
+}
+
+func workloadPodSpec(res *unstructured.Unstructured) map[string]interface{} {
+	var fields []string
+	switch res.GetKind() {
+	case setting.Pod:
+		fields = []string{"spec"}
+	case setting.Deployment, setting.StatefulSet, setting.DaemonSet, setting.Job, setting.CloneSet:
+		fields = []string{"spec", "template", "spec"}
+	case setting.CronJob:
+		fields = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+	podSpec, ok, _ := unstructured.NestedMap(res.Object, fields...)
+	if !ok {
+		return nil
+	}
+	return podSpec
+}
+
+func asMap(obj interface{}) map[string]interface{} {
+	m, _ := obj.(map[string]interface{})
+	return m
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type SetServiceGitRefArgs struct {
+	GitRef string `json:"git_ref"`
+}
+
+// SetServiceGitRef pins the service of the env to a branch or tag, an empty ref unpins the service
+func SetServiceGitRef(projectName, envName, serviceName, gitRef string, production bool) error {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	for i, group := range env.Services {
+		for j, svc := range group {
+			if svc.ServiceName != serviceName {
+				continue
+			}
+			if svc.Type != setting.K8SDeployType {
+				return e.ErrInvalidParam.AddDesc("only k8s yaml services can be pinned to a git ref")
+			}
+			svc.GitRef = gitRef
+			if err := commonrepo.NewProductColl().UpdateOneService(projectName, envName, i, j, svc); err != nil {
+				return e.ErrUpdateService.AddErr(fmt.Errorf("failed to update service %s of env %s, err: %w", serviceName, envName, err))
+			}
+			return nil
+		}
+	}
+	return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s not found in env %s", serviceName, envName))
+}
+
+// UpdateServicesPinnedToRef updates the service in the envs pinned to the git ref to the latest revision of the service template
+func UpdateServicesPinnedToRef(projectName, serviceName, gitRef string, production bool, log *zap.SugaredLogger) error {
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{
+		Name:       projectName,
+		Production: &production,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list envs of project %s, err: %w", projectName, err)
+	}
+
+	errs := &multierror.Error{}
+	for _, env := range envs {
+		svc := env.GetServiceMap()[serviceName]
+		if svc == nil || svc.GitRef != gitRef || svc.Type != setting.K8SDeployType {
+			continue
+		}
+
+		log.Infof("updating service %s of env %s/%s pinned to %s", serviceName, projectName, env.EnvName, gitRef)
+		err := UpdateService(&SvcOptArgs{
+			EnvName:     env.EnvName,
+			ProductName: projectName,
+			ServiceName: serviceName,
+			ServiceType: svc.Type,
+			ServiceRev: &SvcRevision{
+				ServiceName: serviceName,
+				Type:        svc.Type,
+				Containers:  svc.Containers,
+				VariableKVs: svc.GetServiceRender().OverrideYaml.RenderVariableKVs,
+			},
+			UpdateBy:          setting.WebhookTaskCreator,
+			UpdateServiceTmpl: true,
+		}, log)
+		if err != nil {
+			log.Errorf("failed to update service %s of env %s/%s, err: %s", serviceName, projectName, env.EnvName, err)
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
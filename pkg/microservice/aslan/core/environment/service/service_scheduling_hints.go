@@ -0,0 +1,100 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// GetServiceSchedulingHints returns the scheduling hints of the service in the env, the release name is used for the
+// services deployed from charts
+func GetServiceSchedulingHints(projectName, envName, serviceName string, production bool) (*templatemodels.SchedulingHints, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	for _, group := range env.Services {
+		for _, svc := range group {
+			if !isSchedulingHintsTarget(svc, serviceName) {
+				continue
+			}
+			if hints := svc.Render.GetSchedulingHints(); hints != nil {
+				return hints, nil
+			}
+			return &templatemodels.SchedulingHints{NodeSelector: make(map[string]string)}, nil
+		}
+	}
+	return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s not found in env %s", serviceName, envName))
+}
+
+// UpdateServiceSchedulingHints saves the scheduling hints on the render of the service in the env, they are injected
+// into the workloads of the service on the following deployments
+func UpdateServiceSchedulingHints(projectName, envName, serviceName string, production bool, hints *templatemodels.SchedulingHints) error {
+	if err := kube.ValidateSchedulingHints(hints); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: &production,
+	})
+	if err != nil {
+		return e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+
+	for i, group := range env.Services {
+		for j, svc := range group {
+			if !isSchedulingHintsTarget(svc, serviceName) {
+				continue
+			}
+			if svc.Type != setting.K8SDeployType && svc.Type != setting.HelmDeployType && svc.Type != setting.HelmChartDeployType {
+				return e.ErrInvalidParam.AddDesc("only k8s yaml and helm services support scheduling hints")
+			}
+			render := svc.GetServiceRender()
+			if hints.IsEmpty() {
+				render.SchedulingHints = nil
+			} else {
+				render.SchedulingHints = hints
+			}
+			if err := commonrepo.NewProductColl().UpdateOneService(projectName, envName, i, j, svc); err != nil {
+				return e.ErrUpdateService.AddErr(fmt.Errorf("failed to update service %s of env %s, err: %w", serviceName, envName, err))
+			}
+			return nil
+		}
+	}
+	return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s not found in env %s", serviceName, envName))
+}
+
+func isSchedulingHintsTarget(svc *commonmodels.ProductService, serviceName string) bool {
+	if svc.FromZadig() {
+		return svc.ServiceName == serviceName
+	}
+	return svc.ReleaseName == serviceName
+}
@@ -0,0 +1,275 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/kube/getter"
+)
+
+const (
+	TimelineSourceKubernetes = "kubernetes"
+	TimelineSourceZadig      = "zadig"
+)
+
+type ServiceTimelineArgs struct {
+	ProjectName string `form:"projectName"`
+	Production  bool   `form:"production"`
+	// Severity filters the events by the kubernetes event type: Normal or Warning
+	Severity  string `form:"severity"`
+	StartTime int64  `form:"startTime"`
+	EndTime   int64  `form:"endTime"`
+}
+
+type ServiceTimelineEvent struct {
+	Time      int64  `json:"time"`
+	FirstSeen int64  `json:"first_seen"`
+	Source    string `json:"source"`
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message"`
+	Count     int32  `json:"count"`
+	Operator  string `json:"operator,omitempty"`
+}
+
+// GetServiceEventTimeline aggregates the kubernetes events of all the resources of the service and its pods,
+// together with the zadig deployments of the service, into a timeline sorted by time desc
+func GetServiceEventTimeline(envName, serviceName string, args *ServiceTimelineArgs, log *zap.SugaredLogger) ([]*ServiceTimelineEvent, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       args.ProjectName,
+		EnvName:    envName,
+		Production: &args.Production,
+	})
+	if err != nil {
+		return nil, e.ErrGetServiceTimeline.AddDesc(fmt.Sprintf("failed to find env %s: %s", envName, err))
+	}
+
+	prodSvc, ok := env.GetServiceMap()[serviceName]
+	if !ok {
+		for _, svc := range env.GetChartServiceMap() {
+			if svc.ServiceName == serviceName {
+				prodSvc, ok = svc, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, e.ErrGetServiceTimeline.AddDesc(fmt.Sprintf("service %s not found in env %s", serviceName, envName))
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrGetServiceTimeline.AddErr(err)
+	}
+	resources, _, err := getServiceObjects(env, prodSvc, kubeClient)
+	if err != nil {
+		log.Errorf("failed to get resources of service %s in env %s, error: %s", serviceName, envName, err)
+		return nil, e.ErrGetServiceTimeline.AddErr(err)
+	}
+
+	apiReader, err := kube.GetKubeAPIReader(env.ClusterID)
+	if err != nil {
+		return nil, e.ErrGetServiceTimeline.AddErr(err)
+	}
+	// the events are listed once for the namespace and filtered locally, since there may be lots of involved objects
+	events, err := getter.ListEvents(env.Namespace, fields.Everything(), apiReader)
+	if err != nil {
+		log.Errorf("failed to list events in namespace %s, error: %s", env.Namespace, err)
+		return nil, e.ErrGetServiceTimeline.AddErr(err)
+	}
+
+	resp := make([]*ServiceTimelineEvent, 0)
+	for _, evt := range events {
+		if !resources.Has(involvedObjectKey(evt.InvolvedObject.Kind, evt.InvolvedObject.Name)) {
+			continue
+		}
+		lastSeen := evt.LastTimestamp.Unix()
+		if evt.LastTimestamp.IsZero() {
+			lastSeen = evt.EventTime.Unix()
+		}
+		resp = append(resp, &ServiceTimelineEvent{
+			Time:      lastSeen,
+			FirstSeen: evt.FirstTimestamp.Unix(),
+			Source:    TimelineSourceKubernetes,
+			Severity:  evt.Type,
+			Kind:      evt.InvolvedObject.Kind,
+			Name:      evt.InvolvedObject.Name,
+			Reason:    evt.Reason,
+			Message:   evt.Message,
+			Count:     evt.Count,
+		})
+	}
+
+	versions, err := commonrepo.NewEnvServiceVersionColl().ListServiceVersions(env.ProductName, env.EnvName, serviceName, !prodSvc.FromZadig(), env.Production)
+	if err != nil {
+		log.Warnf("failed to list versions of service %s in env %s, error: %s", serviceName, envName, err)
+	}
+	for _, version := range versions {
+		resp = append(resp, &ServiceTimelineEvent{
+			Time:      version.CreateTime,
+			FirstSeen: version.CreateTime,
+			Source:    TimelineSourceZadig,
+			Severity:  corev1.EventTypeNormal,
+			Kind:      "Service",
+			Name:      serviceName,
+			Reason:    "Deployed",
+			Message:   fmt.Sprintf("revision %d of service %s is deployed", version.Revision, serviceName),
+			Count:     1,
+			Operator:  version.CreateBy,
+		})
+	}
+
+	resp = filterTimelineEvents(resp, args)
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].Time > resp[j].Time
+	})
+	return resp, nil
+}
+
+func filterTimelineEvents(events []*ServiceTimelineEvent, args *ServiceTimelineArgs) []*ServiceTimelineEvent {
+	resp := make([]*ServiceTimelineEvent, 0, len(events))
+	for _, evt := range events {
+		if args.Severity != "" && evt.Severity != args.Severity {
+			continue
+		}
+		if args.StartTime > 0 && evt.Time < args.StartTime {
+			continue
+		}
+		if args.EndTime > 0 && evt.Time > args.EndTime {
+			continue
+		}
+		resp = append(resp, evt)
+	}
+	return resp
+}
+
+func involvedObjectKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// getServiceObjects returns the keys of the resources of the service including the replicasets and pods of its workloads,
+// and the pods of its workloads
+func getServiceObjects(env *commonmodels.Product, prodSvc *commonmodels.ProductService, kubeClient client.Client) (sets.String, []*corev1.Pod, error) {
+	resp := sets.NewString()
+	servicePods := make([]*corev1.Pod, 0)
+	namespace := env.Namespace
+
+	selectors := make([]*metav1.LabelSelector, 0)
+	deploymentSelectors := make([]*metav1.LabelSelector, 0)
+	if prodSvc.Type == setting.HelmDeployType || prodSvc.Type == setting.HelmChartDeployType {
+		releaseName := prodSvc.ReleaseName
+		if prodSvc.FromZadig() {
+			releaseNameMap, err := commonutil.GetReleaseNameToServiceNameMap(env)
+			if err != nil {
+				return nil, nil, err
+			}
+			for release, svcName := range releaseNameMap {
+				if svcName == prodSvc.ServiceName {
+					releaseName = release
+					break
+				}
+			}
+		}
+
+		deployments, err := getter.ListDeployments(namespace, labels.Everything(), kubeClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, deployment := range deployments {
+			if deployment.Annotations[setting.HelmReleaseNameAnnotation] == releaseName {
+				resp.Insert(involvedObjectKey(setting.Deployment, deployment.Name))
+				deploymentSelectors = append(deploymentSelectors, deployment.Spec.Selector)
+			}
+		}
+		statefulSets, err := getter.ListStatefulSets(namespace, labels.Everything(), kubeClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, sts := range statefulSets {
+			if sts.Annotations[setting.HelmReleaseNameAnnotation] == releaseName {
+				resp.Insert(involvedObjectKey(setting.StatefulSet, sts.Name))
+				selectors = append(selectors, sts.Spec.Selector)
+			}
+		}
+	} else {
+		for _, res := range prodSvc.Resources {
+			resp.Insert(involvedObjectKey(res.Kind, res.Name))
+			switch res.Kind {
+			case setting.Deployment:
+				deployment, found, err := getter.GetDeployment(namespace, res.Name, kubeClient)
+				if err == nil && found {
+					deploymentSelectors = append(deploymentSelectors, deployment.Spec.Selector)
+				}
+			case setting.StatefulSet:
+				sts, found, err := getter.GetStatefulSet(namespace, res.Name, kubeClient)
+				if err == nil && found {
+					selectors = append(selectors, sts.Spec.Selector)
+				}
+			}
+		}
+	}
+
+	for _, labelSelector := range deploymentSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil {
+			continue
+		}
+		replicaSets, err := getter.ListReplicaSets(namespace, selector, kubeClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, rs := range replicaSets {
+			resp.Insert(involvedObjectKey(setting.ReplicaSet, rs.Name))
+		}
+	}
+	for _, labelSelector := range append(selectors, deploymentSelectors...) {
+		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil {
+			continue
+		}
+		pods, err := getter.ListPods(namespace, selector, kubeClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, pod := range pods {
+			if !resp.Has(involvedObjectKey(setting.Pod, pod.Name)) {
+				resp.Insert(involvedObjectKey(setting.Pod, pod.Name))
+				servicePods = append(servicePods, pod)
+			}
+		}
+	}
+	return resp, servicePods, nil
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	helmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/helm"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/repository"
+	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+// DeploySharedService deploys the service referenced by the project into the non-production env,
+// the service is rendered with the template of its owner project at the revision pinned by the reference.
+func DeploySharedService(projectName, envName, serviceName, userName string, log *zap.SugaredLogger) error {
+	project, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return e.ErrGetProduct.AddErr(fmt.Errorf("failed to find project %s, err: %w", projectName, err))
+	}
+	ref := project.GetSharedServiceRef(serviceName)
+	if ref == nil {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s is not referenced by project %s", serviceName, projectName))
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+		Name:       projectName,
+		EnvName:    envName,
+		Production: util.GetBoolPointer(false),
+	})
+	if err != nil {
+		return e.ErrGetEnv.AddErr(fmt.Errorf("failed to find env %s of project %s, err: %w", envName, projectName, err))
+	}
+	if env.IsSleeping() {
+		return e.ErrUpdateEnv.AddErr(fmt.Errorf("environment is sleeping"))
+	}
+	switch env.Status {
+	case setting.ProductStatusCreating, setting.ProductStatusUpdating, setting.ProductStatusDeleting:
+		return e.ErrUpdateEnv.AddDesc(e.EnvCantUpdatedMsg)
+	}
+
+	prevSvc := env.GetServiceMap()[serviceName]
+	if prevSvc != nil && prevSvc.ProductName != ref.ProjectName {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s of project %s already exists in the env", serviceName, prevSvc.ProductName))
+	}
+
+	svcTmpl, err := repository.QueryTemplateService(&commonrepo.ServiceFindOption{
+		ProductName: ref.ProjectName,
+		ServiceName: ref.ServiceName,
+		Revision:    ref.Revision,
+	}, false)
+	if err != nil {
+		return e.ErrUpdateService.AddErr(fmt.Errorf("failed to find revision %d of service %s/%s, err: %w", ref.Revision, ref.ProjectName, ref.ServiceName, err))
+	}
+
+	newSvc := &commonmodels.ProductService{
+		ServiceName: svcTmpl.ServiceName,
+		ProductName: svcTmpl.ProductName,
+		Type:        svcTmpl.Type,
+		Revision:    svcTmpl.Revision,
+		Containers:  svcTmpl.Containers,
+		Render: &templatemodels.ServiceRender{
+			ServiceName:  svcTmpl.ServiceName,
+			OverrideYaml: &templatemodels.CustomYaml{},
+		},
+		DeployStrategy: setting.ServiceDeployStrategyDeploy,
+	}
+	if prevSvc != nil {
+		curUsedSvc, err := repository.QueryTemplateService(&commonrepo.ServiceFindOption{
+			ServiceName: prevSvc.ServiceName,
+			Revision:    prevSvc.Revision,
+			ProductName: prevSvc.ProductName,
+		}, false)
+		if err != nil {
+			curUsedSvc = nil
+		}
+		newSvc.Containers = kube.CalculateContainer(prevSvc, curUsedSvc, svcTmpl.Containers, env)
+		newSvc.Render = prevSvc.GetServiceRender()
+	}
+
+	switch svcTmpl.Type {
+	case setting.K8SDeployType:
+		return deploySharedK8sService(env, newSvc, prevSvc, svcTmpl, userName, log)
+	case setting.HelmDeployType:
+		if svcTmpl.HelmChart != nil {
+			newSvc.Render.ChartVersion = svcTmpl.HelmChart.Version
+		}
+		newSvc.ReleaseName = util.GeneReleaseName(svcTmpl.GetReleaseNaming(), svcTmpl.ProductName, env.Namespace, env.EnvName, svcTmpl.ServiceName)
+		if err := kube.DeploySingleHelmRelease(env, newSvc, svcTmpl, nil, 0, userName); err != nil {
+			return e.ErrUpdateService.AddErr(err)
+		}
+		if err := helmservice.UpdateServiceInEnv(env, newSvc, userName); err != nil {
+			return e.ErrUpdateEnv.AddErr(err)
+		}
+		return nil
+	default:
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service type %s is not supported", svcTmpl.Type))
+	}
+}
+
+func deploySharedK8sService(env *commonmodels.Product, newSvc, prevSvc *commonmodels.ProductService, svcTmpl *commonmodels.Service, userName string, log *zap.SugaredLogger) error {
+	var err error
+	svcRender := newSvc.GetServiceRender()
+	svcRender.OverrideYaml.YamlContent, svcRender.OverrideYaml.RenderVariableKVs, err = commontypes.MergeRenderAndServiceTemplateVariableKVs(svcRender.OverrideYaml.RenderVariableKVs, svcTmpl.ServiceVariableKVs)
+	if err != nil {
+		return e.ErrUpdateService.AddErr(fmt.Errorf("failed to merge the variables of service %s, err: %w", newSvc.ServiceName, err))
+	}
+
+	kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	istioClient, err := clientmanager.NewKubeClientManager().GetIstioClientSet(env.ClusterID)
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	inf, err := clientmanager.NewKubeClientManager().GetInformer(env.ClusterID, env.Namespace)
+	if err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+
+	items, err := upsertService(env, newSvc, prevSvc, true, inf, kubeClient, istioClient, log)
+	if err != nil {
+		return e.ErrUpdateService.AddErr(err)
+	}
+	newSvc.Resources = kube.UnstructuredToResources(items)
+	newSvc.UpdateTime = time.Now().Unix()
+
+	if prevSvc != nil {
+		for _, group := range env.Services {
+			for i, svc := range group {
+				if svc.ServiceName == newSvc.ServiceName {
+					group[i] = newSvc
+				}
+			}
+		}
+	} else {
+		// services shared from other projects are not in the service orchestration, they are deployed in the last group
+		if len(env.Services) == 0 {
+			env.Services = append(env.Services, []*commonmodels.ProductService{})
+		}
+		env.Services[len(env.Services)-1] = append(env.Services[len(env.Services)-1], newSvc)
+	}
+	env.ServiceDeployStrategy = commonutil.SetServiceDeployStrategyDepoly(env.ServiceDeployStrategy, newSvc.ServiceName)
+
+	return mongotool.WithTransaction(context.TODO(), "DeploySharedService", func(session mongo.Session) error {
+		if err := commonrepo.NewProductCollWithSession(session).Update(env); err != nil {
+			return e.ErrUpdateEnv.AddErr(err)
+		}
+		if err := commonutil.CreateEnvServiceVersion(env, newSvc, userName, session, log); err != nil {
+			log.Errorf("failed to create env service version for service %s in env %s/%s, err: %s", newSvc.ServiceName, env.ProductName, env.EnvName, err)
+		}
+		return nil
+	})
+}
@@ -312,6 +312,14 @@ func (req *OpenAPIDeleteYamlServiceFromEnvReq) Validate() error {
 	return nil
 }
 
+type OpenAPIUpdateHelmServiceValuesReq struct {
+	// OverrideYaml replaces the override yaml of the service in the env
+	OverrideYaml   string                  `json:"override_yaml"`
+	OverrideValues []*commonservice.KVPair `json:"override_values"`
+	// UpdateServiceTmpl updates the service to the latest revision of the service template as well
+	UpdateServiceTmpl bool `json:"update_service_tmpl"`
+}
+
 type OpenAPIEnvCfgBrief struct {
 	Name             string                  `json:"name"`
 	EnvName          string                  `json:"env_name"`
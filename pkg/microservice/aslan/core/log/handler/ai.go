@@ -30,3 +30,26 @@ func AIAnalyzeBuildLog(c *gin.Context) {
 	args.Log = string(data)
 	ctx.Resp, ctx.RespErr = ai.AnalyzeBuildLog(args, c.Query("projectName"), c.Param("workflowName"), c.Param("jobName"), taskID, ctx.Logger)
 }
+
+// @Summary AI Diagnose Workflow Job
+// @Description Diagnose the failed job by the tail of its log and the job metadata with the default llm, the diagnosis is saved to the job if the task has finished
+// @Tags 	log
+// @Accept 	json
+// @Produce json
+// @Param 	workflowName	path		string		true	"workflow name"
+// @Param 	taskID			path		int			true	"task id"
+// @Param 	jobName			path		string		true	"job name"
+// @Success 200 			{object} 	commonmodels.JobDiagnosis
+// @Router /api/logs/log/ai/workflow/{workflowName}/tasks/{taskID}/jobs/{jobName}/diagnosis [post]
+func AIDiagnoseWorkflowJob(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = ai.DiagnoseWorkflowJob(c.Param("workflowName"), c.Param("jobName"), taskID, ctx.Logger)
+}
@@ -19,6 +19,7 @@ package handler
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -39,7 +40,51 @@ func GetWorkflowV4JobContainerLogs(c *gin.Context) {
 		return
 	}
 	// Use all lowercase job names to avoid subdomain errors
-	ctx.Resp, ctx.RespErr = logservice.GetWorkflowV4JobContainerLogs(strings.ToLower(c.Param("workflowName")), c.Param("jobName"), taskID, ctx.Logger)
+	content, err := logservice.GetWorkflowV4JobContainerLogs(strings.ToLower(c.Param("workflowName")), c.Param("jobName"), taskID, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	ctx.Resp = logservice.TruncateLog(c.Query("projectName"), content)
+}
+
+func DownloadWorkflowV4JobLogs(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid task id")
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	// Use all lowercase job names to avoid subdomain errors
+	data, fileName, err := logservice.DownloadWorkflowV4JobLogs(strings.ToLower(c.Param("workflowName")), c.Param("jobName"), taskID, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "application/gzip", data)
+}
+
+func DownloadContainerLogs(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+
+	production, _ := strconv.ParseBool(c.Query("production"))
+	previous, _ := strconv.ParseBool(c.Query("previous"))
+
+	data, fileName, err := logservice.DownloadContainerLogs(c.Param("name"), c.Query("container"), c.Query("envName"), c.Query("projectName"), production, previous, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "application/gzip", data)
 }
 
 func GetContainerLogs(c *gin.Context) {
@@ -67,7 +112,7 @@ func GetContainerLogs(c *gin.Context) {
 	}
 
 	internalhandler.Stream(c, func(ctx1 context.Context, streamChan chan interface{}) {
-		logservice.ContainerLogStream(ctx1, streamChan, envName, productName, podName, containerName, follow, tailLines, ctx.Logger)
+		logservice.ContainerLogStream(ctx1, streamChan, envName, productName, podName, containerName, follow, tailLines, getLogNormalizeOptions(c), ctx.Logger)
 	}, ctx.Logger)
 }
 
@@ -25,6 +25,7 @@ type Router struct{}
 func (*Router) Inject(router *gin.RouterGroup) {
 	{
 		router.GET("/pods/:name", GetContainerLogs)
+		router.GET("/pods/:name/download", DownloadContainerLogs)
 	}
 
 	log := router.Group("log")
@@ -32,7 +33,9 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		log.GET("/testing/:test_name/tasks/:task_id", GetTestingContainerLogs)
 		log.GET("/scanning/:id/task/:scan_id", GetScanningContainerLogs)
 		log.GET("/v4/workflow/:workflowName/tasks/:taskID/jobs/:jobName", GetWorkflowV4JobContainerLogs)
+		log.GET("/v4/workflow/:workflowName/tasks/:taskID/jobs/:jobName/download", DownloadWorkflowV4JobLogs)
 		log.POST("/ai/workflow/:workflowName/tasks/:taskID/jobs/:jobName", AIAnalyzeBuildLog)
+		log.POST("/ai/workflow/:workflowName/tasks/:taskID/jobs/:jobName/diagnosis", AIDiagnoseWorkflowJob)
 	}
 
 	sse := router.Group("sse")
@@ -77,7 +77,7 @@ func GetContainerLogsSSE(c *gin.Context) {
 		}
 
 		internalhandler.Stream(c, func(ctx context.Context, streamChan chan interface{}) {
-			logservice.ContainerLogStream(ctx, streamChan, envName, productName, c.Param("podName"), c.Param("containerName"), true, tails, logger)
+			logservice.ContainerLogStream(ctx, streamChan, envName, productName, c.Param("podName"), c.Param("containerName"), true, tails, getLogNormalizeOptions(c), logger)
 		}, logger)
 	} else {
 		// authorization checks
@@ -99,7 +99,7 @@ func GetContainerLogsSSE(c *gin.Context) {
 		}
 
 		internalhandler.Stream(c, func(ctx context.Context, streamChan chan interface{}) {
-			logservice.ContainerLogStream(ctx, streamChan, envName, productName, c.Param("podName"), c.Param("containerName"), true, tails, logger)
+			logservice.ContainerLogStream(ctx, streamChan, envName, productName, c.Param("podName"), c.Param("containerName"), true, tails, getLogNormalizeOptions(c), logger)
 		}, logger)
 	}
 
@@ -131,6 +131,7 @@ func GetWorkflowJobContainerLogsSSE(c *gin.Context) {
 				SubTask:      jobcontroller.GetJobContainerName(jobName),
 				TaskID:       taskID,
 				TailLines:    tails,
+				Normalize:    getLogNormalizeOptions(c),
 			},
 			ctx.Logger)
 	}, ctx.Logger)
@@ -212,6 +213,7 @@ func GetScanningContainerLogsSSE(c *gin.Context) {
 				SubTask:      jobcontroller.GetJobContainerName(jobName),
 				TaskID:       taskID,
 				TailLines:    tails,
+				Normalize:    getLogNormalizeOptions(c),
 				ClusterID:    clusterId,
 			},
 			ctx.Logger)
@@ -282,6 +284,7 @@ func GetTestingContainerLogsSSE(c *gin.Context) {
 				SubTask:      jobcontroller.GetJobContainerName(jobName),
 				TaskID:       taskID,
 				TailLines:    tails,
+				Normalize:    getLogNormalizeOptions(c),
 			},
 			ctx.Logger)
 	}, ctx.Logger)
@@ -314,6 +317,22 @@ func OpenAPIGetContainerLogsSSE(c *gin.Context) {
 	productName := c.Query("projectKey")
 
 	internalhandler.Stream(c, func(ctx context.Context, streamChan chan interface{}) {
-		logservice.ContainerLogStream(ctx, streamChan, envName, productName, c.Param("podName"), c.Param("containerName"), true, tails, logger)
+		logservice.ContainerLogStream(ctx, streamChan, envName, productName, c.Param("podName"), c.Param("containerName"), true, tails, getLogNormalizeOptions(c), logger)
 	}, logger)
 }
+
+// getLogNormalizeOptions reads the log processing options from the query, the ANSI sequences are kept
+// and the progress updates are collapsed by default.
+func getLogNormalizeOptions(c *gin.Context) *logservice.LogNormalizeOptions {
+	opts := logservice.DefaultLogNormalizeOptions()
+	if ansi, err := strconv.ParseBool(c.Query("ansi")); err == nil {
+		opts.KeepANSI = ansi
+	}
+	if collapse, err := strconv.ParseBool(c.Query("collapseProgress")); err == nil {
+		opts.CollapseProgress = collapse
+	}
+	if timestamps, err := strconv.ParseBool(c.Query("timestamps")); err == nil {
+		opts.Timestamp = timestamps
+	}
+	return opts
+}
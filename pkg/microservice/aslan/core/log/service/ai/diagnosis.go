@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	aiservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/ai"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// DiagnoseWorkflowJob diagnoses the failed job of the workflow task by the llm, the diagnosis is saved to the job if
+// the task has finished
+func DiagnoseWorkflowJob(workflowName, jobName string, taskID int64, logger *zap.SugaredLogger) (*commonmodels.JobDiagnosis, error) {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		return nil, e.ErrDiagnoseJob.AddErr(fmt.Errorf("failed to find task %s-%d, err: %w", workflowName, taskID, err))
+	}
+
+	var job *commonmodels.JobTask
+	for _, stage := range task.Stages {
+		for _, stageJob := range stage.Jobs {
+			if stageJob.Name == jobName {
+				job = stageJob
+			}
+		}
+	}
+	if job == nil {
+		return nil, e.ErrDiagnoseJob.AddDesc(fmt.Sprintf("job %s not found in task %s-%d", jobName, workflowName, taskID))
+	}
+	if job.Status != config.StatusFailed && job.Status != config.StatusTimeout && job.Status != config.StatusUnstable {
+		return nil, e.ErrDiagnoseJob.AddDesc(fmt.Sprintf("job %s is %s, only failed jobs can be diagnosed", jobName, job.Status))
+	}
+
+	diagnosis, err := aiservice.DiagnoseFailedJob(context.Background(), &aiservice.FailedJobInfo{
+		ProjectName:  task.ProjectName,
+		WorkflowName: task.WorkflowName,
+		TaskID:       task.TaskID,
+		Job:          job,
+	})
+	if err != nil {
+		logger.Errorf("failed to diagnose job %s of task %s-%d, err: %s", jobName, workflowName, taskID, err)
+		return nil, e.ErrDiagnoseJob.AddErr(err)
+	}
+
+	// the running task is saved by the workflow controller, the diagnosis would be overwritten
+	if task.Finished() {
+		job.Diagnosis = diagnosis
+		if err := commonrepo.NewworkflowTaskv4Coll().Update(task.ID.Hex(), task); err != nil {
+			logger.Errorf("failed to save the diagnosis of job %s, err: %s", jobName, err)
+		}
+	}
+	return diagnosis, nil
+}
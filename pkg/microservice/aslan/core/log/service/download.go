@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	vmmongodb "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/vm"
+	vmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/vm/service"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// DownloadWorkflowV4JobLogs returns the gzipped full log of a workflow job together with the file name.
+// If the merged log file of a vm job has not been uploaded yet, the uploaded log chunks are used instead.
+func DownloadWorkflowV4JobLogs(workflowName, jobName string, taskID int64, log *zap.SugaredLogger) ([]byte, string, error) {
+	content, err := getContainerLogFromS3(workflowName, jobName, taskID, log)
+	if err != nil {
+		return nil, "", e.ErrDownloadLogs.AddErr(err)
+	}
+
+	if content == "" {
+		vmJob, err := vmmongodb.NewVMJobColl().FindByOpts(vmmongodb.VMJobFindOption{
+			WorkflowName: workflowName,
+			TaskID:       taskID,
+			JobName:      jobName,
+		})
+		if err == nil {
+			content, _, err = vmservice.GetVMJobLogChunks(vmJob, 0)
+			if err != nil {
+				log.Errorf("failed to get log chunks of vm job %s, err: %s", jobName, err)
+				return nil, "", e.ErrDownloadLogs.AddErr(err)
+			}
+		}
+	}
+
+	if content == "" {
+		return nil, "", e.ErrDownloadLogs.AddDesc("log not found, the job may not have finished yet")
+	}
+
+	data, err := gzipContent(strings.NewReader(content))
+	if err != nil {
+		log.Errorf("failed to compress log of job %s, err: %s", jobName, err)
+		return nil, "", e.ErrDownloadLogs.AddErr(err)
+	}
+	return data, fmt.Sprintf("%s-%d-%s.log.gz", workflowName, taskID, jobName), nil
+}
+
+// DownloadContainerLogs returns the gzipped full log of a container in the env together with the file name.
+// If previous is true, the log of the previous terminated container is returned.
+func DownloadContainerLogs(podName, containerName, envName, productName string, production, previous bool, log *zap.SugaredLogger) ([]byte, string, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName, Production: &production})
+	if err != nil {
+		log.Errorf("Failed to find env %s in project %s, err: %s", envName, productName, err)
+		return nil, "", e.ErrDownloadLogs.AddErr(err)
+	}
+	clientset, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(env.ClusterID)
+	if err != nil {
+		log.Errorf("Failed to get kube client, err: %s", err)
+		return nil, "", e.ErrDownloadLogs.AddErr(err)
+	}
+
+	stream, err := clientset.CoreV1().Pods(env.Namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: containerName,
+		Previous:  previous,
+	}).Stream(context.TODO())
+	if err != nil {
+		log.Errorf("Failed to get logs of container %s in pod %s, err: %s", containerName, podName, err)
+		return nil, "", e.ErrDownloadLogs.AddErr(err)
+	}
+	defer stream.Close()
+
+	data, err := gzipContent(stream)
+	if err != nil {
+		log.Errorf("Failed to compress logs of container %s in pod %s, err: %s", containerName, podName, err)
+		return nil, "", e.ErrDownloadLogs.AddErr(err)
+	}
+	return data, fmt.Sprintf("%s-%s.log.gz", podName, containerName), nil
+}
+
+func gzipContent(reader io.Reader) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	if _, err := io.Copy(gw, reader); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+)
+
+// logStreamLimiter applies the log policy of the project to the lines pushed to a log stream.
+// The lines exceeding the rate limit are skipped, and once the max lines is reached, only the last lines
+// are preserved and pushed when the stream ends.
+type logStreamLimiter struct {
+	ctx    context.Context
+	policy *template.LogPolicy
+	out    chan interface{}
+
+	windowStart time.Time
+	windowLines int
+	skipped     int
+
+	lines     int
+	truncated int
+	tail      []string
+	tailStart int
+}
+
+// limitLogStream returns a channel which applies the log policy of the project to the lines sent to it before
+// forwarding them to streamChan. The returned function must be called after all the lines are sent, it pushes
+// the preserved tail lines and waits until everything is forwarded, or the ctx is done since nobody reads streamChan then.
+func limitLogStream(ctx context.Context, projectName string, streamChan chan interface{}) (chan interface{}, func()) {
+	limiter := &logStreamLimiter{
+		ctx:    ctx,
+		policy: getProjectLogPolicy(projectName),
+		out:    streamChan,
+	}
+
+	in := make(chan interface{}, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range in {
+			limiter.push(msg)
+		}
+		limiter.flush()
+	}()
+
+	return in, func() {
+		close(in)
+		<-done
+	}
+}
+
+func (l *logStreamLimiter) push(msg interface{}) {
+	line, ok := msg.(string)
+	if !ok {
+		l.send(msg)
+		return
+	}
+
+	if l.policy.RateLimit > 0 {
+		now := time.Now()
+		if now.Sub(l.windowStart) >= time.Second {
+			l.windowStart = now
+			l.windowLines = 0
+			l.flushSkipped()
+		}
+		if l.windowLines >= l.policy.RateLimit {
+			l.skipped++
+			return
+		}
+		l.windowLines++
+	}
+
+	l.lines++
+	if l.policy.MaxLines <= 0 || l.lines <= l.policy.MaxLines-l.policy.TailLines {
+		l.send(line)
+		return
+	}
+
+	if l.lines == l.policy.MaxLines-l.policy.TailLines+1 {
+		l.send(fmt.Sprintf("[zadig] the log exceeds %d lines and is truncated, the last %d lines will be shown when the log ends\n", l.policy.MaxLines, l.policy.TailLines))
+	}
+	if l.policy.TailLines <= 0 {
+		l.truncated++
+		return
+	}
+	if len(l.tail) < l.policy.TailLines {
+		l.tail = append(l.tail, line)
+		return
+	}
+	l.tail[l.tailStart] = line
+	l.tailStart = (l.tailStart + 1) % l.policy.TailLines
+	l.truncated++
+}
+
+func (l *logStreamLimiter) flushSkipped() {
+	if l.skipped > 0 {
+		l.send(fmt.Sprintf("[zadig] %d lines are skipped due to the log rate limit of %d lines per second\n", l.skipped, l.policy.RateLimit))
+		l.skipped = 0
+	}
+}
+
+func (l *logStreamLimiter) flush() {
+	l.flushSkipped()
+	if l.truncated > 0 {
+		l.send(fmt.Sprintf("[zadig] log truncated, %d lines are omitted\n", l.truncated))
+	}
+	for i := range l.tail {
+		l.send(l.tail[(l.tailStart+i)%len(l.tail)])
+	}
+}
+
+// send forwards the msg unless the ctx is done, the lines are dropped then since the stream is not read anymore
+func (l *logStreamLimiter) send(msg interface{}) {
+	select {
+	case l.out <- msg:
+	case <-l.ctx.Done():
+	}
+}
+
+// TruncateLog preserves the head and tail lines of the log according to the log policy of the project
+func TruncateLog(projectName, content string) string {
+	return truncateLog(getProjectLogPolicy(projectName), content)
+}
+
+func truncateLog(policy *template.LogPolicy, content string) string {
+	if policy.MaxLines <= 0 {
+		return content
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	// the empty element after the last line break is not a line
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= policy.MaxLines {
+		return content
+	}
+
+	headLines := policy.MaxLines - policy.TailLines
+	truncated := len(lines) - policy.MaxLines
+	return strings.Join(lines[:headLines], "") +
+		fmt.Sprintf("[zadig] log truncated, %d lines are omitted\n", truncated) +
+		strings.Join(lines[len(lines)-policy.TailLines:], "")
+}
+
+func getProjectLogPolicy(projectName string) *template.LogPolicy {
+	project, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		project = &template.Product{}
+	}
+	return project.GetLogPolicy()
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+)
+
+func Test_logStreamLimiter(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *template.LogPolicy
+		lines  []interface{}
+		want   []interface{}
+	}{
+		{
+			name:   "unlimited",
+			policy: &template.LogPolicy{},
+			lines:  []interface{}{"a\n", "b\n", "c\n"},
+			want:   []interface{}{"a\n", "b\n", "c\n"},
+		},
+		{
+			name:   "non-string messages are forwarded as is",
+			policy: &template.LogPolicy{RateLimit: 1, MaxLines: 1},
+			lines:  []interface{}{1, "a\n", 2},
+			want:   []interface{}{1, "a\n", 2},
+		},
+		{
+			name:   "lines exceeding the rate limit are skipped",
+			policy: &template.LogPolicy{RateLimit: 2},
+			lines:  []interface{}{"a\n", "b\n", "c\n", "d\n", "e\n"},
+			want: []interface{}{
+				"a\n",
+				"b\n",
+				"[zadig] 3 lines are skipped due to the log rate limit of 2 lines per second\n",
+			},
+		},
+		{
+			name:   "tail lines are pushed when the log ends",
+			policy: &template.LogPolicy{MaxLines: 4, TailLines: 2},
+			lines:  []interface{}{"1\n", "2\n", "3\n", "4\n", "5\n", "6\n"},
+			want: []interface{}{
+				"1\n",
+				"2\n",
+				"[zadig] the log exceeds 4 lines and is truncated, the last 2 lines will be shown when the log ends\n",
+				"[zadig] log truncated, 2 lines are omitted\n",
+				"5\n",
+				"6\n",
+			},
+		},
+		{
+			name:   "tail lines of a log reaching max lines are pushed at the end",
+			policy: &template.LogPolicy{MaxLines: 4, TailLines: 2},
+			lines:  []interface{}{"1\n", "2\n", "3\n", "4\n"},
+			want: []interface{}{
+				"1\n",
+				"2\n",
+				"[zadig] the log exceeds 4 lines and is truncated, the last 2 lines will be shown when the log ends\n",
+				"3\n",
+				"4\n",
+			},
+		},
+		{
+			name:   "no tail lines",
+			policy: &template.LogPolicy{MaxLines: 2},
+			lines:  []interface{}{"1\n", "2\n", "3\n"},
+			want: []interface{}{
+				"1\n",
+				"2\n",
+				"[zadig] the log exceeds 2 lines and is truncated, the last 0 lines will be shown when the log ends\n",
+				"[zadig] log truncated, 1 lines are omitted\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := make(chan interface{}, 100)
+			l := &logStreamLimiter{ctx: context.Background(), policy: tt.policy, out: out}
+			for _, line := range tt.lines {
+				l.push(line)
+			}
+			l.flush()
+			close(out)
+
+			got := make([]interface{}, 0)
+			for msg := range out {
+				got = append(got, msg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("logStreamLimiter got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_logStreamLimiter_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// nobody reads the stream, the limiter must not block once the ctx is done
+	l := &logStreamLimiter{ctx: ctx, policy: &template.LogPolicy{MaxLines: 2, TailLines: 1}, out: make(chan interface{})}
+	for _, line := range []string{"1\n", "2\n", "3\n"} {
+		l.push(line)
+	}
+	l.flush()
+}
+
+func Test_truncateLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *template.LogPolicy
+		content string
+		want    string
+	}{
+		{
+			name:    "unlimited",
+			policy:  &template.LogPolicy{},
+			content: "1\n2\n3\n",
+			want:    "1\n2\n3\n",
+		},
+		{
+			name:    "not exceeding max lines",
+			policy:  &template.LogPolicy{MaxLines: 3, TailLines: 1},
+			content: "1\n2\n3\n",
+			want:    "1\n2\n3\n",
+		},
+		{
+			name:    "head and tail lines are preserved",
+			policy:  &template.LogPolicy{MaxLines: 3, TailLines: 1},
+			content: "1\n2\n3\n4\n5\n",
+			want:    "1\n2\n[zadig] log truncated, 2 lines are omitted\n5\n",
+		},
+		{
+			name:    "last line without line break",
+			policy:  &template.LogPolicy{MaxLines: 2, TailLines: 1},
+			content: "1\n2\n3",
+			want:    "1\n[zadig] log truncated, 1 lines are omitted\n3",
+		},
+		{
+			name:    "no tail lines",
+			policy:  &template.LogPolicy{MaxLines: 2},
+			content: "1\n2\n3\n",
+			want:    "1\n2\n[zadig] log truncated, 1 lines are omitted\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateLog(tt.policy, tt.content); got != tt.want {
+				t.Errorf("truncateLog() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ansiRegexp matches CSI sequences (colors, cursor movements, erase line) and OSC sequences (window title, hyperlinks)
+var ansiRegexp = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+// LogNormalizeOptions controls how the raw log lines are processed before they are pushed to the log stream.
+type LogNormalizeOptions struct {
+	// KeepANSI keeps the ANSI escape sequences such as colors in the lines, otherwise they are stripped.
+	KeepANSI bool
+	// CollapseProgress collapses the carriage-return separated progress updates, e.g. npm and docker progress bars,
+	// into the final state of the line instead of pushing every update separately.
+	CollapseProgress bool
+	// Timestamp prefixes each line with the time it is received by the server in RFC3339 format.
+	Timestamp bool
+}
+
+func DefaultLogNormalizeOptions() *LogNormalizeOptions {
+	return &LogNormalizeOptions{
+		KeepANSI:         true,
+		CollapseProgress: true,
+	}
+}
+
+// Normalize processes a single line read from the log, the trailing line break is kept if there is one.
+func (o *LogNormalizeOptions) Normalize(line string) []string {
+	if o == nil {
+		o = DefaultLogNormalizeOptions()
+	}
+
+	lineBreak := ""
+	if strings.HasSuffix(line, "\n") {
+		lineBreak = "\n"
+		line = strings.TrimSuffix(line, "\n")
+		// lines ending with CRLF are not progress updates
+		line = strings.TrimSuffix(line, "\r")
+	}
+
+	segments := []string{line}
+	if strings.ContainsRune(line, '\r') {
+		segments = strings.Split(line, "\r")
+		if o.CollapseProgress {
+			// only the last non-empty update is what the terminal would finally show
+			last := ""
+			for i := len(segments) - 1; i >= 0; i-- {
+				if segments[i] != "" {
+					last = segments[i]
+					break
+				}
+			}
+			segments = []string{last}
+		} else {
+			for i := range segments[:len(segments)-1] {
+				segments[i] += "\r"
+			}
+		}
+	}
+
+	resp := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		if !o.KeepANSI {
+			segment = ansiRegexp.ReplaceAllString(segment, "")
+		}
+		if o.Timestamp {
+			segment = time.Now().Format(time.RFC3339) + " " + segment
+		}
+		if i == len(segments)-1 {
+			segment += lineBreak
+		}
+		if len(segment) > 0 {
+			resp = append(resp, segment)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestLogNormalizeOptions_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *LogNormalizeOptions
+		line    string
+		want    []string
+	}{
+		{
+			name:    "plain line",
+			options: &LogNormalizeOptions{},
+			line:    "hello\n",
+			want:    []string{"hello\n"},
+		},
+		{
+			name:    "line without line break",
+			options: &LogNormalizeOptions{},
+			line:    "hello",
+			want:    []string{"hello"},
+		},
+		{
+			name:    "crlf is not a progress update",
+			options: &LogNormalizeOptions{},
+			line:    "hello\r\n",
+			want:    []string{"hello\n"},
+		},
+		{
+			name:    "ansi sequences are stripped",
+			options: &LogNormalizeOptions{},
+			line:    "\x1b[31mred\x1b[0m \x1b]0;title\x07text\n",
+			want:    []string{"red text\n"},
+		},
+		{
+			name:    "ansi sequences are kept",
+			options: &LogNormalizeOptions{KeepANSI: true},
+			line:    "\x1b[31mred\x1b[0m\n",
+			want:    []string{"\x1b[31mred\x1b[0m\n"},
+		},
+		{
+			name:    "progress updates are collapsed",
+			options: &LogNormalizeOptions{CollapseProgress: true},
+			line:    "10%\r50%\r100%\r\r\n",
+			want:    []string{"100%\n"},
+		},
+		{
+			name:    "progress updates are pushed separately",
+			options: &LogNormalizeOptions{},
+			line:    "10%\r50%\r100%\n",
+			want:    []string{"10%\r", "50%\r", "100%\n"},
+		},
+		{
+			name:    "empty line is dropped",
+			options: &LogNormalizeOptions{},
+			line:    "",
+			want:    []string{},
+		},
+		{
+			name:    "nil options keep ansi and collapse progress",
+			options: nil,
+			line:    "\x1b[32m10%\r\x1b[32m100%\n",
+			want:    []string{"\x1b[32m100%\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.Normalize(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogNormalizeOptions_Normalize_timestamp(t *testing.T) {
+	got := (&LogNormalizeOptions{Timestamp: true}).Normalize("hello\n")
+	if len(got) != 1 || !regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\S+ hello\n$`).MatchString(got[0]) {
+		t.Errorf("Normalize() = %q, want a line prefixed with the timestamp", got)
+	}
+}
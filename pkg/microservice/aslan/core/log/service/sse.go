@@ -22,7 +22,7 @@ import (
 	"crypto/tls"
 	"io"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -64,6 +64,7 @@ type GetContainerOptions struct {
 	EnvName       string
 	ProductName   string
 	ClusterID     string
+	Normalize     *LogNormalizeOptions
 }
 
 type GetVMJobLogOptions struct {
@@ -72,9 +73,13 @@ type GetVMJobLogOptions struct {
 	WorkflowKey    string
 	TaskID         int64
 	JobName        string
+	Normalize      *LogNormalizeOptions
 }
 
-func ContainerLogStream(ctx context.Context, streamChan chan interface{}, envName, productName, podName, containerName string, follow bool, tailLines int64, log *zap.SugaredLogger) {
+func ContainerLogStream(ctx context.Context, streamChan chan interface{}, envName, productName, podName, containerName string, follow bool, tailLines int64, normalize *LogNormalizeOptions, log *zap.SugaredLogger) {
+	streamChan, flush := limitLogStream(ctx, productName, streamChan)
+	defer flush()
+
 	productInfo, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
 	if err != nil {
 		log.Errorf("kubeCli.GetContainerLogStream error: %v", err)
@@ -85,10 +90,10 @@ func ContainerLogStream(ctx context.Context, streamChan chan interface{}, envNam
 		log.Errorf("failed to find ns and kubeClient: %v", err)
 		return
 	}
-	containerLogStream(ctx, streamChan, productInfo.Namespace, podName, containerName, follow, tailLines, clientset, log)
+	containerLogStream(ctx, streamChan, productInfo.Namespace, podName, containerName, follow, tailLines, normalize, clientset, log)
 }
 
-func containerLogStream(ctx context.Context, streamChan chan interface{}, namespace, podName, containerName string, follow bool, tailLines int64, client *kubernetes.Clientset, log *zap.SugaredLogger) {
+func containerLogStream(ctx context.Context, streamChan chan interface{}, namespace, podName, containerName string, follow bool, tailLines int64, normalize *LogNormalizeOptions, client *kubernetes.Clientset, log *zap.SugaredLogger) {
 	log.Infof("[GetContainerLogsSSE] Get container log of pod %s", podName)
 
 	out, err := containerlog.GetContainerLogStream(ctx, namespace, podName, containerName, follow, tailLines, client)
@@ -113,23 +118,18 @@ func containerLogStream(ctx context.Context, streamChan chan interface{}, namesp
 		default:
 			line, err := buf.ReadString('\n')
 			if err == nil {
-				if strings.ContainsRune(line, '\r') {
-					segments := strings.Split(line, "\r")
-					for _, segment := range segments {
-						segment = segment + string('\r')
-						if len(segment) > 0 {
-							streamChan <- segment
-						}
+				for _, segment := range normalize.Normalize(line) {
+					if !strings.HasSuffix(segment, "\r") {
+						segment = strings.TrimSpace(segment)
 					}
-				} else {
-					line = strings.TrimSpace(line)
-					streamChan <- line
+					streamChan <- segment
 				}
 			}
 			if err == io.EOF {
-				line = strings.TrimSpace(line)
-				if len(line) > 0 {
-					streamChan <- line
+				for _, segment := range normalize.Normalize(line) {
+					if segment = strings.TrimSpace(segment); len(segment) > 0 {
+						streamChan <- segment
+					}
 				}
 				log.Infof("No more input is available, container log stream stopped")
 				return
@@ -171,6 +171,9 @@ func WorkflowTaskV4ContainerLogStream(ctx context.Context, streamChan chan inter
 		log.Errorf("Failed to find workflow %s taskID %s: %v", options.PipelineName, options.TaskID, err)
 		return
 	}
+	streamChan, flush := limitLogStream(ctx, task.ProjectName, streamChan)
+	defer flush()
+
 	var vmJobOptions *GetVMJobLogOptions
 
 	for _, stage := range task.Stages {
@@ -207,6 +210,7 @@ func WorkflowTaskV4ContainerLogStream(ctx context.Context, streamChan chan inter
 						WorkflowKey:    task.WorkflowName,
 						TaskID:         task.TaskID,
 						JobName:        job.Name,
+						Normalize:      options.Normalize,
 					}
 				} else {
 					options.ClusterID = jobSpec.Properties.ClusterID
@@ -218,6 +222,10 @@ func WorkflowTaskV4ContainerLogStream(ctx context.Context, streamChan chan inter
 					return
 				}
 				options.ClusterID = jobSpec.Properties.ClusterID
+			case string(config.JobGitHubActions):
+				// the logs of github actions jobs are uploaded to the object storage by the job controller
+				waitStoredLogAndGetLog(ctx, streamChan, task, job.Name, options.Normalize, log)
+				return
 			default:
 				log.Errorf("get real-time log error, unsupported job type %s", job.JobType)
 				return
@@ -286,6 +294,7 @@ func waitAndGetLog(ctx context.Context, streamChan chan interface{}, selector la
 			pods[0].Name, options.SubTask,
 			true,
 			options.TailLines,
+			options.Normalize,
 			clientSet,
 			log,
 		)
@@ -304,29 +313,30 @@ func waitVmAndGetLog(ctx context.Context, streamChan chan interface{}, options *
 		return
 	}
 
-	if job.LogFile == "" {
-		log.Errorf("vm job log file is empty")
-		return
-	}
-
 	if job.Status != string(config.StatusRunning) {
 		log.Errorf("vm job not running")
 		return
 	}
 
-	out, err := os.OpenFile(job.LogFile, os.O_APPEND|os.O_CREATE|os.O_RDONLY, 0644)
-	if err != nil {
-		log.Errorf("open vm job log file error: %v", err)
-		return
-	}
-	defer func() {
-		err := out.Close()
-		if err != nil {
-			log.Errorf("Failed to close vm job log file, error: %v", err)
+	// the log is read from the chunks in the object storage, so the stream can be served by any aslan replica
+	var (
+		offset  int
+		pending string
+	)
+	readChunks := func() error {
+		content, next, err := vmservice.GetVMJobLogChunks(job, offset)
+		offset = next
+		content = pending + content
+		// the last line may be continued in the next chunk
+		idx := strings.LastIndex(content, "\n")
+		pending = content[idx+1:]
+		if idx >= 0 {
+			if err := ReadFromFileAndWriteToStreamChan(bufio.NewReader(strings.NewReader(content[:idx+1])), streamChan, options.Normalize); err != nil && err != io.EOF {
+				return err
+			}
 		}
-	}()
-
-	buf := bufio.NewReader(out)
+		return err
+	}
 
 	for {
 		select {
@@ -334,19 +344,22 @@ func waitVmAndGetLog(ctx context.Context, streamChan chan interface{}, options *
 			log.Infof("Connection is closed, vm log stream stopped")
 			return
 		default:
-			if !vmservice.VMJobStatus.Exists(job.ID.Hex()) {
-				err := ReadFromFileAndWriteToStreamChan(buf, streamChan)
-				if err != nil && err != io.EOF {
-					log.Errorf("scan vm log stream error: %v", err)
-					return
-				}
-				log.Infof("job cache existed vm job log stream stopped")
+			job, err = vmmongodb.NewVMJobColl().FindByID(job.ID.Hex())
+			if err != nil {
+				log.Errorf("get vm job error: %v", err)
 				return
 			}
 
-			err := ReadFromFileAndWriteToStreamChan(buf, streamChan)
-			if err != nil && err != io.EOF {
-				log.Errorf("scan vm log stream error: %v", err)
+			if err := readChunks(); err != nil {
+				log.Errorf("read vm log chunks error: %v", err)
+				return
+			}
+
+			if job.JobFinished() {
+				for _, segment := range options.Normalize.Normalize(pending) {
+					streamChan <- segment
+				}
+				log.Infof("vm job finished, vm job log stream stopped")
 				return
 			}
 
@@ -355,22 +368,56 @@ func waitVmAndGetLog(ctx context.Context, streamChan chan interface{}, options *
 	}
 }
 
-func ReadFromFileAndWriteToStreamChan(buf *bufio.Reader, streamChan chan interface{}) error {
+// waitStoredLogAndGetLog streams the log of the job uploaded to the object storage by aslan until the job finishes
+func waitStoredLogAndGetLog(ctx context.Context, streamChan chan interface{}, task *commonmodels.WorkflowTask, jobName string, normalize *LogNormalizeOptions, log *zap.SugaredLogger) {
+	offset := 0
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("Connection is closed, job log stream stopped")
+			return
+		default:
+		}
+
+		finished := true
+		latestTask, err := commonrepo.NewworkflowTaskv4Coll().Find(task.WorkflowName, task.TaskID)
+		if err != nil {
+			log.Errorf("Failed to find workflow %s taskID %d: %v", task.WorkflowName, task.TaskID, err)
+			return
+		}
+		for _, stage := range latestTask.Stages {
+			for _, job := range stage.Jobs {
+				if job.Name == jobName {
+					finished = !slices.Contains(config.InCompletedStatus(), job.Status)
+				}
+			}
+		}
+
+		content, err := getContainerLogFromS3(strings.ToLower(task.WorkflowName), strings.ToLower(jobName), task.TaskID, log)
+		if err != nil {
+			return
+		}
+		if idx := strings.LastIndex(content, "\n"); idx >= offset {
+			if err := ReadFromFileAndWriteToStreamChan(bufio.NewReader(strings.NewReader(content[offset:idx+1])), streamChan, normalize); err != nil && err != io.EOF {
+				log.Errorf("read job log error: %v", err)
+				return
+			}
+			offset = idx + 1
+		}
+
+		if finished {
+			return
+		}
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func ReadFromFileAndWriteToStreamChan(buf *bufio.Reader, streamChan chan interface{}, normalize *LogNormalizeOptions) error {
 	for {
 		line, err := buf.ReadString('\n')
 		if err == nil {
-			if strings.ContainsRune(line, '\r') {
-				segments := strings.Split(line, "\r")
-				for _, segment := range segments {
-					segment = segment + string('\r')
-					if len(segment) > 0 {
-						streamChan <- segment
-					}
-				}
-			} else {
-				if len(line) > 0 {
-					streamChan <- line
-				}
+			for _, segment := range normalize.Normalize(line) {
+				streamChan <- segment
 			}
 			continue
 		}
@@ -96,6 +96,10 @@ type AdvancedConfig struct {
 	ScheduleStrategy  []*ScheduleStrategy `json:"schedule_strategy"         bson:"schedule_strategy"`
 	EnableIRSA        bool                `json:"enable_irsa"               bson:"enable_irsa"`
 	IRSARoleARM       string              `json:"irsa_role_arn"             bson:"irsa_role_arn"`
+	KubeAPIQPS        float32             `json:"kube_api_qps"              bson:"kube_api_qps"`
+	KubeAPIBurst      int                 `json:"kube_api_burst"            bson:"kube_api_burst"`
+	CertIssuer        string              `json:"cert_issuer"               bson:"cert_issuer"`
+	CertIssuerKind    string              `json:"cert_issuer_kind"          bson:"cert_issuer_kind"`
 }
 
 type ScheduleStrategy struct {
@@ -252,6 +256,10 @@ func ListClusters(ids []string, projectName string, logger *zap.SugaredLogger) (
 
 			advancedConfig.EnableIRSA = c.AdvancedConfig.EnableIRSA
 			advancedConfig.IRSARoleARM = c.AdvancedConfig.IRSARoleARM
+			advancedConfig.KubeAPIQPS = c.AdvancedConfig.KubeAPIQPS
+			advancedConfig.KubeAPIBurst = c.AdvancedConfig.KubeAPIBurst
+			advancedConfig.CertIssuer = c.AdvancedConfig.CertIssuer
+			advancedConfig.CertIssuerKind = c.AdvancedConfig.CertIssuerKind
 		}
 
 		if c.DindCfg == nil {
@@ -416,6 +424,10 @@ func UpdateCluster(ctx *handler.Context, id string, args *K8SCluster) (*commonmo
 	cluster.AdvancedConfig.ScheduleWorkflow = clusterArgs.AdvancedConfig.ScheduleWorkflow
 	cluster.AdvancedConfig.EnableIRSA = clusterArgs.AdvancedConfig.EnableIRSA
 	cluster.AdvancedConfig.IRSARoleARM = clusterArgs.AdvancedConfig.IRSARoleARM
+	cluster.AdvancedConfig.KubeAPIQPS = clusterArgs.AdvancedConfig.KubeAPIQPS
+	cluster.AdvancedConfig.KubeAPIBurst = clusterArgs.AdvancedConfig.KubeAPIBurst
+	cluster.AdvancedConfig.CertIssuer = clusterArgs.AdvancedConfig.CertIssuer
+	cluster.AdvancedConfig.CertIssuerKind = clusterArgs.AdvancedConfig.CertIssuerKind
 
 	// Delete all projects associated with clusterID
 	hasErr := false
@@ -1655,6 +1667,10 @@ func K8SClusterArgsToModel(args *K8SCluster) (*commonmodels.K8SCluster, error) {
 		advancedConfig.ScheduleWorkflow = args.AdvancedConfig.ScheduleWorkflow
 		advancedConfig.EnableIRSA = args.AdvancedConfig.EnableIRSA
 		advancedConfig.IRSARoleARM = args.AdvancedConfig.IRSARoleARM
+		advancedConfig.KubeAPIQPS = args.AdvancedConfig.KubeAPIQPS
+		advancedConfig.KubeAPIBurst = args.AdvancedConfig.KubeAPIBurst
+		advancedConfig.CertIssuer = args.AdvancedConfig.CertIssuer
+		advancedConfig.CertIssuerKind = args.AdvancedConfig.CertIssuerKind
 
 		advancedConfig.ScheduleStrategy = make([]*commonmodels.ScheduleStrategy, 0)
 		for _, strategy := range args.AdvancedConfig.ScheduleStrategy {
@@ -677,6 +677,417 @@ func UpdateProductionGlobalVariables(c *gin.Context) {
 	ctx.RespErr = projectservice.UpdateGlobalVariables(projectKey, ctx.UserName, args.GlobalVariables, true)
 }
 
+// @Summary Get log policy
+// @Description Get log policy of the project
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Success 200 	{object} 	template.LogPolicy
+// @Router /api/aslan/project/products/{name}/logPolicy [get]
+func GetLogPolicy(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = projectservice.GetLogPolicy(projectKey)
+}
+
+// @Summary Update log policy
+// @Description Update log policy of the project
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Param 	body 	body 		template.LogPolicy 				true 	"body"
+// @Success 200
+// @Router /api/aslan/project/products/{name}/logPolicy [put]
+func UpdateLogPolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	args := new(template.LogPolicy)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid log policy json args")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "更新", "工程管理-项目-日志策略", projectKey, "", ctx.Logger)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = projectservice.UpdateLogPolicy(projectKey, args)
+}
+
+// @Summary Get helm default values
+// @Description Get the project level default values of helm envs
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Success 200 	{object} 	projectservice.HelmDefaultValues
+// @Router /api/aslan/project/products/{name}/helmDefaultValues [get]
+func GetHelmDefaultValues(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = projectservice.GetHelmDefaultValues(projectKey)
+}
+
+// @Summary Update helm default values
+// @Description Update the project level default values of helm envs, the values are overridden by env default values
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Param 	body 	body 		projectservice.HelmDefaultValues 	true 	"body"
+// @Success 200
+// @Router /api/aslan/project/products/{name}/helmDefaultValues [put]
+func UpdateHelmDefaultValues(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	args := new(projectservice.HelmDefaultValues)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid default values json args")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "更新", "工程管理-项目-Helm默认值", projectKey, "", ctx.Logger)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = projectservice.UpdateHelmDefaultValues(projectKey, args)
+}
+
+// @Summary Get dashboard links
+// @Description Get the dashboard url templates of the services of the project
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Success 200 	{array} 	template.DashboardLink
+// @Router /api/aslan/project/products/{name}/dashboardLinks [get]
+func GetDashboardLinks(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = projectservice.GetDashboardLinks(projectKey)
+}
+
+// @Summary Update dashboard links
+// @Description Update the dashboard url templates of the services of the project, the variables $project, $env, $namespace, $service and $workload are rendered in the service details
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Param 	body 	body 		[]template.DashboardLink 		true 	"body"
+// @Success 200
+// @Router /api/aslan/project/products/{name}/dashboardLinks [put]
+func UpdateDashboardLinks(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	args := make([]*template.DashboardLink, 0)
+	if err := c.BindJSON(&args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid dashboard links json args")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "更新", "工程管理-项目-监控面板链接", projectKey, "", ctx.Logger)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = projectservice.UpdateDashboardLinks(projectKey, args)
+}
+
+// @Summary Get helm locked values keys
+// @Description Get the values keys of helm services which can only be changed by the project admins
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Success 200 	{array} 	template.HelmLockedValuesKey
+// @Router /api/aslan/project/products/{name}/helmLockedValuesKeys [get]
+func GetHelmLockedValuesKeys(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = projectservice.GetHelmLockedValuesKeys(projectKey)
+}
+
+// @Summary Update helm locked values keys
+// @Description Update the values keys of helm services which can only be changed by the project admins, the env updates and workflow deploy jobs changing the locked keys are rejected
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Param 	body 	body 		[]template.HelmLockedValuesKey 	true 	"body"
+// @Success 200
+// @Router /api/aslan/project/products/{name}/helmLockedValuesKeys [put]
+func UpdateHelmLockedValuesKeys(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	args := make([]*template.HelmLockedValuesKey, 0)
+	if err := c.BindJSON(&args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid locked values keys json args")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "更新", "工程管理-项目-Helm锁定配置", projectKey, "", ctx.Logger)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = projectservice.UpdateHelmLockedValuesKeys(projectKey, args)
+}
+
+// @Summary Get project inheritance
+// @Description Get the base project and the pending changes of the base project
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Success 200 	{object} 	projectservice.ProjectInheritanceResp
+// @Router /api/aslan/project/products/{name}/inheritance [get]
+func GetProjectInheritance(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = projectservice.GetProjectInheritance(projectKey, ctx.Logger)
+}
+
+// @Summary Update project inheritance
+// @Description Set the base project and the overridden items of the project
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string							true	"project name"
+// @Param 	body 	body 		template.ProjectInheritance 	true 	"body"
+// @Success 200
+// @Router /api/aslan/project/products/{name}/inheritance [put]
+func UpdateProjectInheritance(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	args := new(template.ProjectInheritance)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid inheritance json args")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "更新", "工程管理-项目-继承", projectKey, "", ctx.Logger)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = projectservice.UpdateProjectInheritance(projectKey, args)
+}
+
+// @Summary Sync project inheritance
+// @Description Apply the changes of the base project to the project, all pending changes are applied if no item is specified
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string										true	"project name"
+// @Param 	body 	body 		projectservice.SyncProjectInheritanceArgs 	true 	"body"
+// @Success 200 	{object} 	projectservice.ProjectInheritanceDiff
+// @Router /api/aslan/project/products/{name}/inheritance/sync [post]
+func SyncProjectInheritance(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	args := new(projectservice.SyncProjectInheritanceArgs)
+	if err := c.ShouldBindJSON(args); err != nil && err != io.EOF {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid sync json args")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "同步", "工程管理-项目-继承", projectKey, "", ctx.Logger)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = projectservice.SyncProjectInheritance(projectKey, ctx.UserName, args, ctx.Logger)
+}
+
 // @Summary Get global variable candidates
 // @Description Get global variable candidates
 // @Tags 	project
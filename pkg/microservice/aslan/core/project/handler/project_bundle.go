@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	projectservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/project/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Export Project Bundle
+// @Description Export the configuration of the project as a yaml bundle
+// @Tags 	project
+// @Accept 	json
+// @Produce application/x-yaml
+// @Param 	name	path		string		true	"project name"
+// @Success 200
+// @Router /api/aslan/project/products/{name}/bundle [get]
+func ExportProjectBundle(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	projectKey := c.Param("name")
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok || !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			internalhandler.JSONResponse(c, ctx)
+			return
+		}
+	}
+
+	data, fileName, err := projectservice.ExportProjectBundle(projectKey, ctx.UserName, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "application/x-yaml", data)
+}
+
+// @Summary Import Project Bundle
+// @Description Create the project from the yaml bundle exported by another installation
+// @Tags 	project
+// @Accept 	plain
+// @Produce json
+// @Param 	clusterID	query		string								false	"cluster of the envs"
+// @Param 	registryID	query		string								false	"registry of the envs"
+// @Param 	body 		body 		projectservice.ProjectBundle 		true 	"bundle yaml"
+// @Success 200 		{object} 	projectservice.ProjectBundleImportResult
+// @Router /api/aslan/project/products/import [post]
+func ImportProjectBundle(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.Project.Create {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	args := new(projectservice.ProjectBundleImportArgs)
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	data, err := c.GetRawData()
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	result, err := projectservice.ImportProjectBundle(data, args, ctx.UserName, ctx.UserID, ctx.RequestID, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, result.ProjectName, "导入", "项目管理-项目", result.ProjectName, "", ctx.Logger)
+	ctx.Resp = result
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Get project quota
+// @Description Get the quota and the current usage of the project
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string								true	"project name"
+// @Success 200 	{object} 	commonservice.ProjectQuotaUsage
+// @Router /api/aslan/project/products/{name}/quota [get]
+func GetProjectQuota(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = commonservice.GetProjectQuotaUsage(projectKey, ctx.Logger)
+}
+
+// @Summary Update project quota
+// @Description Update the quota of the project, zero means unlimited, only system admin can update it
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Param 	name	path		string								true	"project name"
+// @Param 	body 	body 		commonmodels.ProjectQuota 			true 	"body"
+// @Success 200
+// @Router /api/aslan/project/products/{name}/quota [put]
+func UpdateProjectQuota(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Param("name")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	args := new(commonmodels.ProjectQuota)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid project quota json args")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "更新", "工程管理-项目-配额", projectKey, "", ctx.Logger)
+
+	// the quota is managed by system admin only, otherwise the project admin can raise the limits by themselves
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.RespErr = commonservice.UpdateProjectQuota(projectKey, ctx.UserName, args, ctx.Logger)
+}
+
+// @Summary List project quota usage
+// @Description List the quotas and the current usages of all projects
+// @Tags 	project
+// @Accept 	json
+// @Produce json
+// @Success 200 	{array} 	commonservice.ProjectQuotaUsage
+// @Router /api/aslan/project/quotas/usage [get]
+func ListProjectQuotaUsage(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = commonservice.ListProjectQuotaUsage(ctx.Logger)
+}
@@ -34,11 +34,13 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		product.GET("/:name/searching-rules", GetCustomMatchRules)
 		product.PUT("/:name/searching-rules", CreateOrUpdateMatchRules)
 		product.POST("", CreateProductTemplate)
+		product.POST("/import", ImportProjectBundle)
 		product.PUT("/:name", UpdateProductTemplate)
 		product.PUT("/:name/:status", UpdateProductTmplStatus)
 		product.PATCH("/:name", UpdateServiceOrchestration)
 		product.PUT("", UpdateProject)
 		product.PUT("/:name/type", TransferProject)
+		product.GET("/:name/bundle", ExportProjectBundle)
 		product.DELETE("/:name", DeleteProductTemplate)
 
 		product.GET("/:name/globalVariables", GetGlobalVariables)
@@ -47,7 +49,28 @@ func (*Router) Inject(router *gin.RouterGroup) {
 
 		product.GET("/:name/productionGlobalVariables", GetProductionGlobalVariables)
 		product.PUT("/:name/productionGlobalVariables", UpdateProductionGlobalVariables)
+		product.GET("/:name/logPolicy", GetLogPolicy)
+		product.PUT("/:name/logPolicy", UpdateLogPolicy)
+		product.GET("/:name/podTemplates", ListJobPodTemplates)
+		product.GET("/:name/podTemplates/:templateName", GetJobPodTemplate)
+		product.POST("/:name/podTemplates", CreateJobPodTemplate)
+		product.PUT("/:name/podTemplates/:templateName", UpdateJobPodTemplate)
+		product.DELETE("/:name/podTemplates/:templateName", DeleteJobPodTemplate)
+		product.GET("/:name/helmDefaultValues", GetHelmDefaultValues)
+		product.PUT("/:name/helmDefaultValues", UpdateHelmDefaultValues)
+		product.GET("/:name/helmLockedValuesKeys", GetHelmLockedValuesKeys)
+		product.PUT("/:name/helmLockedValuesKeys", UpdateHelmLockedValuesKeys)
+		product.GET("/:name/dashboardLinks", GetDashboardLinks)
+		product.PUT("/:name/dashboardLinks", UpdateDashboardLinks)
+		product.GET("/:name/inheritance", GetProjectInheritance)
+		product.PUT("/:name/inheritance", UpdateProjectInheritance)
+		product.POST("/:name/inheritance/sync", SyncProjectInheritance)
+		product.PUT("/:name/exportedServices", UpdateExportedServices)
+		product.GET("/:name/sharedServices", ListSharedServices)
+		product.PUT("/:name/sharedServices", UpdateSharedServiceRefs)
 		product.GET("/:name/productionGlobalVariableCandidates", GetProductionGlobalVariableCandidates)
+		product.GET("/:name/quota", GetProjectQuota)
+		product.PUT("/:name/quota", UpdateProjectQuota)
 	}
 
 	group := router.Group("group")
@@ -83,6 +106,11 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		project.GET("", ListProjects)
 	}
 
+	quota := router.Group("quotas")
+	{
+		quota.GET("/usage", ListProjectQuotaUsage)
+	}
+
 	pms := router.Group("pms")
 	{
 		pms.GET("", ListPMHosts)
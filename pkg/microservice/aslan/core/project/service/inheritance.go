@@ -0,0 +1,411 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
+	svcService "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/service/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+const (
+	InheritanceItemTypeGlobalVariable = "global_variable"
+	InheritanceItemTypeService        = "service"
+	InheritanceItemTypeBuild          = "build"
+
+	InheritanceActionAdd    = "add"
+	InheritanceActionUpdate = "update"
+)
+
+type ProjectInheritanceResp struct {
+	Inheritance *template.ProjectInheritance `json:"inheritance"`
+	Diff        *ProjectInheritanceDiff      `json:"diff"`
+}
+
+type ProjectInheritanceDiff struct {
+	BaseProject string                 `json:"base_project"`
+	Items       []*InheritanceDiffItem `json:"items"`
+}
+
+// InheritanceDiffItem is a single change of the base project not yet applied to the project.
+// Base and Current are the yaml content of the item in the base project and the project.
+type InheritanceDiffItem struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	Base    string `json:"base"`
+	Current string `json:"current"`
+}
+
+func (i *InheritanceDiffItem) Key() string {
+	return fmt.Sprintf("%s/%s", i.Type, i.Name)
+}
+
+type SyncProjectInheritanceArgs struct {
+	// Items is the keys of the diff items to be applied, in format of `type/name`. All items are applied if it is empty.
+	Items []string `json:"items"`
+}
+
+// inheritedBuildName returns the name of the build copied from the base project, build names are unique across projects.
+func inheritedBuildName(projectName, buildName string) string {
+	return fmt.Sprintf("%s-%s", projectName, buildName)
+}
+
+func GetProjectInheritance(projectName string, log *zap.SugaredLogger) (*ProjectInheritanceResp, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return nil, e.ErrGetProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", projectName, err))
+	}
+
+	resp := &ProjectInheritanceResp{Inheritance: productInfo.Inheritance}
+	if productInfo.Inheritance == nil || productInfo.Inheritance.BaseProject == "" {
+		return resp, nil
+	}
+
+	resp.Diff, err = getProjectInheritanceDiff(productInfo, log)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func UpdateProjectInheritance(projectName string, inheritance *template.ProjectInheritance) error {
+	productInfo, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return e.ErrGetProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", projectName, err))
+	}
+
+	if inheritance.BaseProject != "" {
+		if inheritance.BaseProject == projectName {
+			return e.ErrInvalidParam.AddDesc("project can not inherit from itself")
+		}
+
+		baseProject, err := templaterepo.NewProductColl().Find(inheritance.BaseProject)
+		if err != nil {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("base project %s not found", inheritance.BaseProject))
+		}
+		if baseProject.IsK8sYamlProduct() != productInfo.IsK8sYamlProduct() || baseProject.IsHelmProduct() != productInfo.IsHelmProduct() ||
+			baseProject.IsHostProduct() != productInfo.IsHostProduct() {
+			return e.ErrInvalidParam.AddDesc("base project must be of the same type as the project")
+		}
+
+		// walk through the base chain to make sure no cycle is introduced
+		visited := sets.NewString(projectName)
+		for base := baseProject; base.Inheritance != nil && base.Inheritance.BaseProject != ""; {
+			if visited.Has(base.Inheritance.BaseProject) {
+				return e.ErrInvalidParam.AddDesc(fmt.Sprintf("circular inheritance detected at project %s", base.Inheritance.BaseProject))
+			}
+			visited.Insert(base.ProductName)
+			base, err = templaterepo.NewProductColl().Find(base.Inheritance.BaseProject)
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	// the sync record is maintained by the sync only
+	if productInfo.Inheritance != nil && productInfo.Inheritance.BaseProject == inheritance.BaseProject {
+		inheritance.LastSyncTime = productInfo.Inheritance.LastSyncTime
+		inheritance.LastSyncBy = productInfo.Inheritance.LastSyncBy
+	} else {
+		inheritance.LastSyncTime = 0
+		inheritance.LastSyncBy = ""
+	}
+
+	if err := templaterepo.NewProductColl().UpdateInheritance(projectName, inheritance); err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to update inheritance of product: %s, err: %w", projectName, err))
+	}
+	return nil
+}
+
+// SyncProjectInheritance applies the changes of the base project to the project, overridden items are skipped.
+func SyncProjectInheritance(projectName, userName string, args *SyncProjectInheritanceArgs, log *zap.SugaredLogger) (*ProjectInheritanceDiff, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return nil, e.ErrGetProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", projectName, err))
+	}
+	if productInfo.Inheritance == nil || productInfo.Inheritance.BaseProject == "" {
+		return nil, e.ErrInvalidParam.AddDesc("project does not inherit from any project")
+	}
+
+	diff, err := getProjectInheritanceDiff(productInfo, log)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := sets.NewString(args.Items...)
+	applied := &ProjectInheritanceDiff{BaseProject: diff.BaseProject}
+	variables := productInfo.GlobalVariables
+	variablesChanged := false
+	for _, item := range diff.Items {
+		if selected.Len() > 0 && !selected.Has(item.Key()) {
+			continue
+		}
+
+		switch item.Type {
+		case InheritanceItemTypeGlobalVariable:
+			kv := new(commontypes.ServiceVariableKV)
+			if err := yaml.Unmarshal([]byte(item.Base), kv); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal global variable %s, err: %w", item.Name, err)
+			}
+			replaced := false
+			for i, variable := range variables {
+				if variable.Key == kv.Key {
+					variables[i] = kv
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				variables = append(variables, kv)
+			}
+			variablesChanged = true
+		case InheritanceItemTypeService:
+			if err := syncInheritedService(productInfo.Inheritance.BaseProject, projectName, item.Name, userName, log); err != nil {
+				return nil, err
+			}
+		case InheritanceItemTypeBuild:
+			if err := syncInheritedBuild(productInfo.Inheritance.BaseProject, projectName, item.Name, userName); err != nil {
+				return nil, err
+			}
+		}
+		applied.Items = append(applied.Items, item)
+	}
+
+	if variablesChanged {
+		if err := templaterepo.NewProductColl().UpdateGlobalVars(projectName, variables); err != nil {
+			return nil, e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to update global variables of product: %s, err: %w", projectName, err))
+		}
+	}
+
+	productInfo.Inheritance.LastSyncTime = time.Now().Unix()
+	productInfo.Inheritance.LastSyncBy = userName
+	if err := templaterepo.NewProductColl().UpdateInheritance(projectName, productInfo.Inheritance); err != nil {
+		return nil, e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to update inheritance of product: %s, err: %w", projectName, err))
+	}
+
+	log.Infof("project %s synced %d items from base project %s by %s", projectName, len(applied.Items), applied.BaseProject, userName)
+	return applied, nil
+}
+
+func getProjectInheritanceDiff(productInfo *template.Product, log *zap.SugaredLogger) (*ProjectInheritanceDiff, error) {
+	inheritance := productInfo.Inheritance
+	baseProject, err := templaterepo.NewProductColl().Find(inheritance.BaseProject)
+	if err != nil {
+		return nil, e.ErrGetProduct.AddErr(fmt.Errorf("failed to find base product %s, err: %w", inheritance.BaseProject, err))
+	}
+
+	diff := &ProjectInheritanceDiff{
+		BaseProject: inheritance.BaseProject,
+		Items:       make([]*InheritanceDiffItem, 0),
+	}
+
+	// global variables
+	overriddenVariables := sets.NewString(inheritance.OverriddenVariables...)
+	currentVariables := make(map[string]*commontypes.ServiceVariableKV)
+	for _, kv := range productInfo.GlobalVariables {
+		currentVariables[kv.Key] = kv
+	}
+	for _, kv := range baseProject.GlobalVariables {
+		if overriddenVariables.Has(kv.Key) {
+			continue
+		}
+		item, err := newInheritanceDiffItem(InheritanceItemTypeGlobalVariable, kv.Key, kv, currentVariables[kv.Key])
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			diff.Items = append(diff.Items, item)
+		}
+	}
+
+	// services, only the yaml of k8s services is inherited
+	if baseProject.IsK8sYamlProduct() {
+		overriddenServices := sets.NewString(inheritance.OverriddenServices...)
+		baseServices, err := commonrepo.NewServiceColl().ListMaxRevisionsByProduct(baseProject.ProductName)
+		if err != nil {
+			return nil, e.ErrListTemplate.AddErr(fmt.Errorf("failed to list services of base product %s, err: %w", baseProject.ProductName, err))
+		}
+		currentServices, err := commonrepo.NewServiceColl().ListMaxRevisionsByProduct(productInfo.ProductName)
+		if err != nil {
+			return nil, e.ErrListTemplate.AddErr(fmt.Errorf("failed to list services of product %s, err: %w", productInfo.ProductName, err))
+		}
+		currentServiceYaml := make(map[string]string)
+		for _, svc := range currentServices {
+			currentServiceYaml[svc.ServiceName] = svc.Yaml
+		}
+		for _, svc := range baseServices {
+			if overriddenServices.Has(svc.ServiceName) {
+				continue
+			}
+			current, ok := currentServiceYaml[svc.ServiceName]
+			switch {
+			case !ok:
+				diff.Items = append(diff.Items, &InheritanceDiffItem{Type: InheritanceItemTypeService, Name: svc.ServiceName, Action: InheritanceActionAdd, Base: svc.Yaml})
+			case current != svc.Yaml:
+				diff.Items = append(diff.Items, &InheritanceDiffItem{Type: InheritanceItemTypeService, Name: svc.ServiceName, Action: InheritanceActionUpdate, Base: svc.Yaml, Current: current})
+			}
+		}
+	}
+
+	// builds
+	overriddenBuilds := sets.NewString(inheritance.OverriddenBuilds...)
+	baseBuilds, err := commonrepo.NewBuildColl().List(&commonrepo.BuildListOption{ProductName: baseProject.ProductName})
+	if err != nil {
+		return nil, e.ErrListBuildModule.AddErr(fmt.Errorf("failed to list builds of base product %s, err: %w", baseProject.ProductName, err))
+	}
+	currentBuilds, err := commonrepo.NewBuildColl().List(&commonrepo.BuildListOption{ProductName: productInfo.ProductName})
+	if err != nil {
+		return nil, e.ErrListBuildModule.AddErr(fmt.Errorf("failed to list builds of product %s, err: %w", productInfo.ProductName, err))
+	}
+	currentBuildMap := make(map[string]*commonmodels.Build)
+	for _, build := range currentBuilds {
+		currentBuildMap[build.Name] = build
+	}
+	for _, build := range baseBuilds {
+		if overriddenBuilds.Has(build.Name) {
+			continue
+		}
+		var current *commonmodels.Build
+		if currentBuild, ok := currentBuildMap[inheritedBuildName(productInfo.ProductName, build.Name)]; ok {
+			current = normalizeInheritedBuild(currentBuild)
+		}
+		item, err := newInheritanceDiffItem(InheritanceItemTypeBuild, build.Name, normalizeInheritedBuild(build), current)
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			diff.Items = append(diff.Items, item)
+		}
+	}
+
+	return diff, nil
+}
+
+// newInheritanceDiffItem returns nil if the base and current objects have the same content
+func newInheritanceDiffItem(itemType, name string, base, current interface{}) (*InheritanceDiffItem, error) {
+	baseContent, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s %s, err: %w", itemType, name, err)
+	}
+
+	item := &InheritanceDiffItem{
+		Type:   itemType,
+		Name:   name,
+		Action: InheritanceActionAdd,
+		Base:   string(baseContent),
+	}
+	if current == nil {
+		return item, nil
+	}
+
+	currentContent, err := yaml.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s %s, err: %w", itemType, name, err)
+	}
+	if string(currentContent) == item.Base {
+		return nil, nil
+	}
+	item.Action = InheritanceActionUpdate
+	item.Current = string(currentContent)
+	return item, nil
+}
+
+// normalizeInheritedBuild clears the project specific fields of the build so that builds of different projects can be compared
+func normalizeInheritedBuild(build *commonmodels.Build) *commonmodels.Build {
+	data, _ := json.Marshal(build)
+	normalized := new(commonmodels.Build)
+	_ = json.Unmarshal(data, normalized)
+
+	normalized.ID = primitive.NilObjectID
+	normalized.Name = ""
+	normalized.ProductName = ""
+	normalized.UpdateTime = 0
+	normalized.UpdateBy = ""
+	for _, target := range normalized.Targets {
+		target.ProductName = ""
+		target.BuildName = ""
+	}
+	return normalized
+}
+
+func syncInheritedService(baseProject, projectName, serviceName, userName string, log *zap.SugaredLogger) error {
+	baseService, err := commonrepo.NewServiceColl().Find(&commonrepo.ServiceFindOption{
+		ProductName: baseProject,
+		ServiceName: serviceName,
+	})
+	if err != nil {
+		return e.ErrGetService.AddErr(fmt.Errorf("failed to find service %s of base product %s, err: %w", serviceName, baseProject, err))
+	}
+
+	_, err = svcService.CreateServiceTemplate(userName, &commonmodels.Service{
+		ProductName:        projectName,
+		ServiceName:        serviceName,
+		Yaml:               baseService.Yaml,
+		Source:             setting.SourceFromZadig,
+		Type:               setting.K8SDeployType,
+		CreateBy:           userName,
+		VariableYaml:       baseService.VariableYaml,
+		ServiceVariableKVs: baseService.ServiceVariableKVs,
+	}, true, false, log)
+	if err != nil {
+		return fmt.Errorf("failed to sync service %s from base product %s, err: %w", serviceName, baseProject, err)
+	}
+	return nil
+}
+
+func syncInheritedBuild(baseProject, projectName, buildName, userName string) error {
+	baseBuild, err := commonrepo.NewBuildColl().Find(&commonrepo.BuildFindOption{Name: buildName, ProductName: baseProject})
+	if err != nil {
+		return e.ErrGetBuildModule.AddErr(fmt.Errorf("failed to find build %s of base product %s, err: %w", buildName, baseProject, err))
+	}
+
+	build := normalizeInheritedBuild(baseBuild)
+	build.Name = inheritedBuildName(projectName, buildName)
+	build.ProductName = projectName
+	build.UpdateBy = userName
+	for _, target := range build.Targets {
+		target.ProductName = projectName
+		target.BuildName = build.Name
+	}
+
+	if current, err := commonrepo.NewBuildColl().Find(&commonrepo.BuildFindOption{Name: build.Name, ProductName: projectName}); err == nil {
+		build.ID = current.ID
+		build.UpdateTime = time.Now().Unix()
+		err = commonrepo.NewBuildColl().Update(build)
+	} else {
+		err = commonrepo.NewBuildColl().Create(build)
+	}
+	if err != nil {
+		return e.ErrUpdateBuildModule.AddErr(fmt.Errorf("failed to sync build %s from base product %s, err: %w", buildName, baseProject, err))
+	}
+	return nil
+}
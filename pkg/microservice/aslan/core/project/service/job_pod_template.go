@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+func ListJobPodTemplates(projectName string, log *zap.SugaredLogger) ([]*commonmodels.JobPodTemplate, error) {
+	templates, err := commonrepo.NewJobPodTemplateColl().List(projectName)
+	if err != nil {
+		log.Errorf("failed to list pod templates of project %s, err: %s", projectName, err)
+		return nil, e.ErrListPodTemplate.AddErr(err)
+	}
+	return templates, nil
+}
+
+func GetJobPodTemplate(projectName, name string, log *zap.SugaredLogger) (*commonmodels.JobPodTemplate, error) {
+	podTemplate, err := commonrepo.NewJobPodTemplateColl().Get(projectName, name)
+	if err != nil {
+		log.Errorf("failed to get pod template %s of project %s, err: %s", name, projectName, err)
+		return nil, e.ErrGetPodTemplate.AddErr(err)
+	}
+	return podTemplate, nil
+}
+
+func CreateJobPodTemplate(projectName, userName string, args *commonmodels.JobPodTemplate, log *zap.SugaredLogger) error {
+	if err := validateJobPodTemplate(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+
+	args.ID = primitive.NilObjectID
+	args.ProjectName = projectName
+	args.CreatedBy = userName
+	args.UpdatedBy = userName
+	if err := commonrepo.NewJobPodTemplateColl().Create(args); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return e.ErrCreatePodTemplate.AddDesc(fmt.Sprintf("pod template %s already exists", args.Name))
+		}
+		log.Errorf("failed to create pod template %s of project %s, err: %s", args.Name, projectName, err)
+		return e.ErrCreatePodTemplate.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateJobPodTemplate(projectName, name, userName string, args *commonmodels.JobPodTemplate, log *zap.SugaredLogger) error {
+	// the name is referred by the jobs, so it can not be changed
+	args.Name = name
+	if err := validateJobPodTemplate(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+
+	args.UpdatedBy = userName
+	if err := commonrepo.NewJobPodTemplateColl().Update(projectName, name, args); err != nil {
+		log.Errorf("failed to update pod template %s of project %s, err: %s", name, projectName, err)
+		return e.ErrUpdatePodTemplate.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteJobPodTemplate(projectName, name string, log *zap.SugaredLogger) error {
+	usedBy := make([]string, 0)
+	builds, err := commonrepo.NewBuildColl().List(&commonrepo.BuildListOption{ProductName: projectName})
+	if err != nil {
+		log.Errorf("failed to list builds of project %s, err: %s", projectName, err)
+		return e.ErrDeletePodTemplate.AddErr(err)
+	}
+	for _, build := range builds {
+		if build.PreBuild != nil && build.PreBuild.PodTemplate == name {
+			usedBy = append(usedBy, fmt.Sprintf("build %s", build.Name))
+		}
+	}
+	testings, err := commonrepo.NewTestingColl().List(&commonrepo.ListTestOption{ProductName: projectName})
+	if err != nil {
+		log.Errorf("failed to list tests of project %s, err: %s", projectName, err)
+		return e.ErrDeletePodTemplate.AddErr(err)
+	}
+	for _, testing := range testings {
+		if testing.PreTest != nil && testing.PreTest.PodTemplate == name {
+			usedBy = append(usedBy, fmt.Sprintf("test %s", testing.Name))
+		}
+	}
+	if len(usedBy) > 0 {
+		return e.ErrDeletePodTemplate.AddDesc(fmt.Sprintf("pod template %s is used by %s", name, strings.Join(usedBy, ", ")))
+	}
+
+	if err := commonrepo.NewJobPodTemplateColl().Delete(projectName, name); err != nil {
+		log.Errorf("failed to delete pod template %s of project %s, err: %s", name, projectName, err)
+		return e.ErrDeletePodTemplate.AddErr(err)
+	}
+	return nil
+}
+
+func validateJobPodTemplate(podTemplate *commonmodels.JobPodTemplate) error {
+	if podTemplate.Name == "" {
+		return fmt.Errorf("name can not be empty")
+	}
+	// apply the template to an empty pod to make sure the yaml fields can be parsed
+	return commonutil.ApplyJobPodTemplate(&corev1.PodSpec{}, podTemplate)
+}
@@ -727,6 +727,10 @@ func DeleteProductTemplate(userName, productName, requestID string, isDelete boo
 		log.Errorf("DeleteProductTemplate Delete productName %s ProjectClusterRelation err: %s", productName, err)
 	}
 
+	if err = commonrepo.NewProjectQuotaColl().Delete(productName); err != nil {
+		log.Errorf("DeleteProductTemplate Delete productName %s ProjectQuota err: %s", productName, err)
+	}
+
 	err = templaterepo.NewProductColl().Delete(productName)
 	if err != nil {
 		log.Errorf("ProductTmpl.Delete error: %s", err)
@@ -1189,6 +1193,9 @@ func UpdateGlobalVariables(productName, userName string, globalVariables []*comm
 		}
 		keySet.Insert(kv.Key)
 	}
+	if err := commonservice.CheckCredentials(fmt.Sprintf("global variables of project %s", productName), globalVariables, log.SugaredLogger()); err != nil {
+		return err
+	}
 
 	productInfo.UpdateBy = userName
 	if production {
@@ -1205,6 +1212,128 @@ func UpdateGlobalVariables(productName, userName string, globalVariables []*comm
 	return nil
 }
 
+func GetLogPolicy(productName string) (*template.LogPolicy, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find product %s, err: %w", productName, err)
+	}
+	return productInfo.GetLogPolicy(), nil
+}
+
+func UpdateLogPolicy(productName string, policy *template.LogPolicy) error {
+	if policy.RateLimit < 0 || policy.MaxLines < 0 || policy.TailLines < 0 {
+		return e.ErrInvalidParam.AddDesc("log policy values can not be negative")
+	}
+	if policy.MaxLines > 0 && policy.TailLines >= policy.MaxLines {
+		return e.ErrInvalidParam.AddDesc("tail lines must be less than max lines")
+	}
+
+	if err := templaterepo.NewProductColl().UpdateLogPolicy(productName, policy); err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to update log policy of product: %s, err: %w", productName, err))
+	}
+	return nil
+}
+
+type HelmDefaultValues struct {
+	DefaultValues string `json:"default_values"`
+}
+
+func GetHelmDefaultValues(productName string) (*HelmDefaultValues, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find product %s, err: %w", productName, err)
+	}
+	return &HelmDefaultValues{DefaultValues: productInfo.HelmDefaultValues}, nil
+}
+
+// UpdateHelmDefaultValues updates the project level default values of helm envs, it takes effect in the next deployment
+func UpdateHelmDefaultValues(productName string, args *HelmDefaultValues) error {
+	productInfo, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", productName, err))
+	}
+	if !productInfo.IsHelmProduct() {
+		return e.ErrInvalidParam.AddDesc("default values can only be set for helm projects")
+	}
+	defaultValues := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(args.DefaultValues), &defaultValues); err != nil {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("invalid default values: %s", err))
+	}
+	if err := commonservice.CheckCredentials(fmt.Sprintf("helm default values of project %s", productName), defaultValues, log.SugaredLogger()); err != nil {
+		return err
+	}
+
+	if err := templaterepo.NewProductColl().UpdateHelmDefaultValues(productName, args.DefaultValues); err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to update helm default values of product: %s, err: %w", productName, err))
+	}
+	return nil
+}
+
+func GetDashboardLinks(productName string) ([]*template.DashboardLink, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find product %s, err: %w", productName, err)
+	}
+	if productInfo.DashboardLinks == nil {
+		return make([]*template.DashboardLink, 0), nil
+	}
+	return productInfo.DashboardLinks, nil
+}
+
+// UpdateDashboardLinks updates the dashboard url templates of the services of the project
+func UpdateDashboardLinks(productName string, links []*template.DashboardLink) error {
+	if _, err := templaterepo.NewProductColl().Find(productName); err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", productName, err))
+	}
+	if err := commonservice.ValidateDashboardLinks(links); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+
+	if err := templaterepo.NewProductColl().UpdateDashboardLinks(productName, links); err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to update dashboard links of product: %s, err: %w", productName, err))
+	}
+	return nil
+}
+
+func GetHelmLockedValuesKeys(productName string) ([]*template.HelmLockedValuesKey, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find product %s, err: %w", productName, err)
+	}
+	if productInfo.HelmLockedValuesKeys == nil {
+		return make([]*template.HelmLockedValuesKey, 0), nil
+	}
+	return productInfo.HelmLockedValuesKeys, nil
+}
+
+// UpdateHelmLockedValuesKeys updates the values keys of helm services which can only be changed by the project admins
+func UpdateHelmLockedValuesKeys(productName string, keys []*template.HelmLockedValuesKey) error {
+	productInfo, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", productName, err))
+	}
+	if !productInfo.IsHelmProduct() {
+		return e.ErrInvalidParam.AddDesc("locked values keys can only be set for helm projects")
+	}
+
+	keySet := sets.NewString()
+	for _, key := range keys {
+		key.Key = strings.TrimSpace(key.Key)
+		if key.Key == "" {
+			return e.ErrInvalidParam.AddDesc("locked values key can not be empty")
+		}
+		if keySet.Has(key.Key) {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("duplicated locked values key: %s", key.Key))
+		}
+		keySet.Insert(key.Key)
+	}
+
+	if err := templaterepo.NewProductColl().UpdateHelmLockedValuesKeys(productName, keys); err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to update helm locked values keys of product: %s, err: %w", productName, err))
+	}
+	return nil
+}
+
 type GetGlobalVariableCandidatesRespone struct {
 	KeyName        string   `json:"key_name"`
 	RelatedService []string `json:"related_service"`
@@ -0,0 +1,333 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	commontypes "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/types"
+	environmentservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	svcService "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/service/service"
+	workflowservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// ProjectBundleVersion is the version of the bundle format, bundles of other versions can not be imported
+const ProjectBundleVersion = "v1"
+
+const (
+	ProjectBundleItemTypeService           = "service"
+	ProjectBundleItemTypeProductionService = "production_service"
+	ProjectBundleItemTypeBuild             = "build"
+	ProjectBundleItemTypeTest              = "test"
+	ProjectBundleItemTypeWorkflow          = "workflow"
+	ProjectBundleItemTypeEnv               = "env"
+)
+
+// ProjectBundle is the configuration of a project which can be moved between zadig installations,
+// the runtime state like the deployed images and the task history is not included
+type ProjectBundle struct {
+	Version            string                     `json:"version"`
+	ExportTime         int64                      `json:"export_time"`
+	ExportBy           string                     `json:"export_by"`
+	Project            *template.Product          `json:"project"`
+	Services           []*commonmodels.Service    `json:"services"`
+	ProductionServices []*commonmodels.Service    `json:"production_services"`
+	Builds             []*commonmodels.Build      `json:"builds"`
+	Tests              []*commonmodels.Testing    `json:"tests"`
+	Workflows          []*commonmodels.WorkflowV4 `json:"workflows"`
+	Envs               []*ProjectBundleEnv        `json:"envs"`
+}
+
+type ProjectBundleEnv struct {
+	EnvName         string                          `json:"env_name"`
+	Alias           string                          `json:"alias,omitempty"`
+	Production      bool                            `json:"production"`
+	Namespace       string                          `json:"namespace"`
+	ClusterID       string                          `json:"cluster_id"`
+	RegistryID      string                          `json:"registry_id"`
+	GlobalVariables []*commontypes.GlobalVariableKV `json:"global_variables,omitempty"`
+	Services        [][]*ProjectBundleEnvService    `json:"services"`
+}
+
+type ProjectBundleEnvService struct {
+	ServiceName string                          `json:"service_name"`
+	VariableKVs []*commontypes.RenderVariableKV `json:"variable_kvs,omitempty"`
+}
+
+type ProjectBundleImportArgs struct {
+	// ClusterID and RegistryID replace the ones of the envs in the bundle since they are different between installations
+	ClusterID  string `json:"cluster_id"  form:"clusterID"`
+	RegistryID string `json:"registry_id" form:"registryID"`
+}
+
+type ProjectBundleImportResult struct {
+	ProjectName string               `json:"project_name"`
+	Imported    []*ProjectBundleItem `json:"imported"`
+	Skipped     []*ProjectBundleItem `json:"skipped"`
+}
+
+type ProjectBundleItem struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ExportProjectBundle returns the yaml bundle of the project and the file name of it
+func ExportProjectBundle(projectName, userName string, log *zap.SugaredLogger) ([]byte, string, error) {
+	project, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return nil, "", e.ErrExportProject.AddErr(fmt.Errorf("failed to find project %s, err: %w", projectName, err))
+	}
+
+	bundle := &ProjectBundle{
+		Version:    ProjectBundleVersion,
+		ExportTime: time.Now().Unix(),
+		ExportBy:   userName,
+		Project:    project,
+		Envs:       make([]*ProjectBundleEnv, 0),
+	}
+
+	if bundle.Services, err = commonrepo.NewServiceColl().ListMaxRevisionsByProduct(projectName); err != nil {
+		return nil, "", e.ErrExportProject.AddErr(fmt.Errorf("failed to list services, err: %w", err))
+	}
+	if bundle.ProductionServices, err = commonrepo.NewProductionServiceColl().ListMaxRevisionsByProduct(projectName); err != nil {
+		return nil, "", e.ErrExportProject.AddErr(fmt.Errorf("failed to list production services, err: %w", err))
+	}
+	if bundle.Builds, err = commonrepo.NewBuildColl().List(&commonrepo.BuildListOption{ProductName: projectName}); err != nil {
+		return nil, "", e.ErrExportProject.AddErr(fmt.Errorf("failed to list builds, err: %w", err))
+	}
+	if bundle.Tests, err = commonrepo.NewTestingColl().List(&commonrepo.ListTestOption{ProductName: projectName}); err != nil {
+		return nil, "", e.ErrExportProject.AddErr(fmt.Errorf("failed to list tests, err: %w", err))
+	}
+	if bundle.Workflows, _, err = commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{ProjectName: projectName}, 0, 0); err != nil {
+		return nil, "", e.ErrExportProject.AddErr(fmt.Errorf("failed to list workflows, err: %w", err))
+	}
+	for _, workflow := range bundle.Workflows {
+		workflow.ID = primitive.NilObjectID
+		// the code source and the template refer to the objects of this installation
+		workflow.CodeSource = nil
+		workflow.Template = nil
+	}
+	for _, build := range bundle.Builds {
+		build.ID = primitive.NilObjectID
+	}
+	for _, test := range bundle.Tests {
+		test.ID = primitive.NilObjectID
+	}
+
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{Name: projectName})
+	if err != nil {
+		return nil, "", e.ErrExportProject.AddErr(fmt.Errorf("failed to list envs, err: %w", err))
+	}
+	for _, env := range envs {
+		bundle.Envs = append(bundle.Envs, newProjectBundleEnv(env))
+	}
+
+	content, err := yaml.Marshal(bundle)
+	if err != nil {
+		return nil, "", e.ErrExportProject.AddErr(err)
+	}
+	log.Infof("project %s is exported by %s", projectName, userName)
+	return content, fmt.Sprintf("%s-%s.yaml", projectName, time.Now().Format("20060102150405")), nil
+}
+
+func newProjectBundleEnv(env *commonmodels.Product) *ProjectBundleEnv {
+	bundleEnv := &ProjectBundleEnv{
+		EnvName:         env.EnvName,
+		Alias:           env.Alias,
+		Production:      env.Production,
+		Namespace:       env.Namespace,
+		ClusterID:       env.ClusterID,
+		RegistryID:      env.RegistryID,
+		GlobalVariables: env.GlobalVariables,
+		Services:        make([][]*ProjectBundleEnvService, 0),
+	}
+	for _, group := range env.Services {
+		services := make([]*ProjectBundleEnvService, 0)
+		for _, svc := range group {
+			bundleSvc := &ProjectBundleEnvService{ServiceName: svc.ServiceName}
+			if svc.Render != nil && svc.Render.OverrideYaml != nil {
+				bundleSvc.VariableKVs = svc.Render.OverrideYaml.RenderVariableKVs
+			}
+			services = append(services, bundleSvc)
+		}
+		bundleEnv.Services = append(bundleEnv.Services, services)
+	}
+	return bundleEnv
+}
+
+// ImportProjectBundle creates the project from the bundle. The objects which can not be moved between installations,
+// like the helm charts stored in zadig and the envs of non-yaml projects, are skipped and listed in the result.
+func ImportProjectBundle(content []byte, args *ProjectBundleImportArgs, userName, userID, requestID string, log *zap.SugaredLogger) (*ProjectBundleImportResult, error) {
+	bundle := new(ProjectBundle)
+	if err := yaml.Unmarshal(content, bundle); err != nil {
+		return nil, e.ErrImportProject.AddDesc(fmt.Sprintf("invalid bundle, err: %s", err))
+	}
+	if bundle.Version != ProjectBundleVersion {
+		return nil, e.ErrImportProject.AddDesc(fmt.Sprintf("unsupported bundle version %s", bundle.Version))
+	}
+	if bundle.Project == nil || bundle.Project.ProductName == "" {
+		return nil, e.ErrImportProject.AddDesc("project of the bundle can not be empty")
+	}
+
+	project := bundle.Project
+	projectName := project.ProductName
+	if _, err := templaterepo.NewProductColl().Find(projectName); err == nil {
+		return nil, e.ErrImportProject.AddDesc(fmt.Sprintf("project %s already exists", projectName))
+	}
+
+	// services are added to the project by the service creation below
+	project.Services = nil
+	project.ProductionServices = nil
+	project.ClusterIDs = nil
+	project.Revision = 0
+	project.UpdateBy = userName
+	// the admins of the source installation do not exist here
+	project.Admins = []string{userID}
+	if err := CreateProductTemplate(project, log); err != nil {
+		return nil, e.ErrImportProject.AddErr(err)
+	}
+
+	result := &ProjectBundleImportResult{
+		ProjectName: projectName,
+		Imported:    make([]*ProjectBundleItem, 0),
+		Skipped:     make([]*ProjectBundleItem, 0),
+	}
+	addResult := func(itemType, name string, err error) {
+		if err != nil {
+			log.Warnf("failed to import %s %s of project %s, err: %s", itemType, name, projectName, err)
+			result.Skipped = append(result.Skipped, &ProjectBundleItem{Type: itemType, Name: name, Reason: err.Error()})
+			return
+		}
+		result.Imported = append(result.Imported, &ProjectBundleItem{Type: itemType, Name: name})
+	}
+
+	for _, svc := range bundle.Services {
+		addResult(ProjectBundleItemTypeService, svc.ServiceName, importBundleService(projectName, userName, svc, false, log))
+	}
+	for _, svc := range bundle.ProductionServices {
+		addResult(ProjectBundleItemTypeProductionService, svc.ServiceName, importBundleService(projectName, userName, svc, true, log))
+	}
+	for _, build := range bundle.Builds {
+		addResult(ProjectBundleItemTypeBuild, build.Name, importBundleBuild(projectName, userName, build))
+	}
+	for _, test := range bundle.Tests {
+		addResult(ProjectBundleItemTypeTest, test.Name, importBundleTest(projectName, userName, test))
+	}
+	// workflows refer to the builds, tests and envs, so they are imported after them
+	for _, env := range bundle.Envs {
+		addResult(ProjectBundleItemTypeEnv, env.EnvName, importBundleEnv(project, env, args, userName, requestID, log))
+	}
+	for _, workflow := range bundle.Workflows {
+		workflow.ID = primitive.NilObjectID
+		workflow.Project = projectName
+		workflow.CodeSource = nil
+		workflow.Template = nil
+		addResult(ProjectBundleItemTypeWorkflow, workflow.Name, workflowservice.CreateWorkflowV4(userName, workflow, log))
+	}
+
+	log.Infof("project %s is imported by %s, %d items imported, %d items skipped", projectName, userName, len(result.Imported), len(result.Skipped))
+	return result, nil
+}
+
+// importBundleService imports the yaml of the k8s services, the services of other types refer to the files
+// stored in this installation and need to be created again
+func importBundleService(projectName, userName string, svc *commonmodels.Service, production bool, log *zap.SugaredLogger) error {
+	if svc.Type != setting.K8SDeployType {
+		return fmt.Errorf("service of type %s can not be imported", svc.Type)
+	}
+	_, err := svcService.CreateServiceTemplate(userName, &commonmodels.Service{
+		ProductName:        projectName,
+		ServiceName:        svc.ServiceName,
+		Yaml:               svc.Yaml,
+		Source:             setting.SourceFromZadig,
+		Type:               setting.K8SDeployType,
+		CreateBy:           userName,
+		VariableYaml:       svc.VariableYaml,
+		ServiceVariableKVs: svc.ServiceVariableKVs,
+	}, false, production, log)
+	return err
+}
+
+func importBundleBuild(projectName, userName string, build *commonmodels.Build) error {
+	build.ID = primitive.NilObjectID
+	build.ProductName = projectName
+	build.UpdateBy = userName
+	build.UpdateTime = time.Now().Unix()
+	for _, target := range build.Targets {
+		target.ProductName = projectName
+	}
+	return commonrepo.NewBuildColl().Create(build)
+}
+
+func importBundleTest(projectName, userName string, test *commonmodels.Testing) error {
+	test.ID = primitive.NilObjectID
+	test.ProductName = projectName
+	test.UpdateBy = userName
+	test.UpdateTime = time.Now().Unix()
+	return commonrepo.NewTestingColl().Create(test)
+}
+
+func importBundleEnv(project *template.Product, env *ProjectBundleEnv, args *ProjectBundleImportArgs, userName, requestID string, log *zap.SugaredLogger) error {
+	if !project.IsK8sYamlProduct() {
+		return fmt.Errorf("envs of the project of type %s need to be created manually", project.ProductFeature.GetDeployType())
+	}
+
+	arg := &environmentservice.CreateSingleProductArg{
+		ProductName:     project.ProductName,
+		EnvName:         env.EnvName,
+		Namespace:       env.Namespace,
+		ClusterID:       env.ClusterID,
+		RegistryID:      env.RegistryID,
+		Production:      env.Production,
+		Alias:           env.Alias,
+		GlobalVariables: env.GlobalVariables,
+		Services:        make([][]*environmentservice.ProductK8sServiceCreationInfo, 0),
+	}
+	if args.ClusterID != "" {
+		arg.ClusterID = args.ClusterID
+	}
+	if args.RegistryID != "" {
+		arg.RegistryID = args.RegistryID
+	}
+	for _, group := range env.Services {
+		services := make([]*environmentservice.ProductK8sServiceCreationInfo, 0)
+		for _, svc := range group {
+			services = append(services, &environmentservice.ProductK8sServiceCreationInfo{
+				ProductService: &commonmodels.ProductService{
+					ServiceName: svc.ServiceName,
+					ProductName: project.ProductName,
+					Type:        setting.K8SDeployType,
+					VariableKVs: svc.VariableKVs,
+				},
+			})
+		}
+		arg.Services = append(arg.Services, services)
+	}
+	return environmentservice.CreateYamlProduct(project.ProductName, userName, requestID, []*environmentservice.CreateSingleProductArg{arg}, log)
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/repository"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// SharedService is a service exported by another project which can be referenced by the project
+type SharedService struct {
+	ProjectName    string `json:"project_name"`
+	ServiceName    string `json:"service_name"`
+	Type           string `json:"type"`
+	LatestRevision int64  `json:"latest_revision"`
+	// Referenced means the service is referenced by the project, PinnedRevision is the revision pinned by the reference
+	Referenced     bool  `json:"referenced"`
+	PinnedRevision int64 `json:"pinned_revision"`
+}
+
+// UpdateExportedServices sets the services of the project which can be referenced by the other projects,
+// the services referenced by other projects can not be unexported.
+func UpdateExportedServices(projectName string, services []string, log *zap.SugaredLogger) error {
+	productInfo, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return e.ErrGetProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", projectName, err))
+	}
+	if len(services) > 0 && !productInfo.IsK8sYamlProduct() && !productInfo.IsHelmProduct() {
+		return e.ErrInvalidParam.AddDesc("only the services of k8s yaml and helm projects can be exported")
+	}
+
+	svcMap, err := repository.GetMaxRevisionsServicesMap(projectName, false)
+	if err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to list services of project %s, err: %w", projectName, err))
+	}
+	exported := sets.NewString()
+	for _, service := range services {
+		if _, ok := svcMap[service]; !ok {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s not found in project %s", service, projectName))
+		}
+		exported.Insert(service)
+	}
+
+	for _, service := range productInfo.ExportedServices {
+		if exported.Has(service) {
+			continue
+		}
+		refProjects, err := templaterepo.NewProductColl().ListWithOption(&templaterepo.ProductListOpt{ReferencedService: fmt.Sprintf("%s/%s", projectName, service)})
+		if err != nil {
+			return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to list the projects referencing service %s, err: %w", service, err))
+		}
+		if len(refProjects) > 0 {
+			refProjectNames := make([]string, 0, len(refProjects))
+			for _, refProject := range refProjects {
+				refProjectNames = append(refProjectNames, refProject.ProductName)
+			}
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s is referenced by projects: %s", service, strings.Join(refProjectNames, ",")))
+		}
+	}
+
+	if err := templaterepo.NewProductColl().UpdateExportedServices(projectName, exported.List()); err != nil {
+		log.Errorf("failed to update exported services of project %s, err: %s", projectName, err)
+		return e.ErrUpdateProduct.AddErr(err)
+	}
+	return nil
+}
+
+// ListSharedServices lists the services exported by the other projects of the same deploy type
+func ListSharedServices(projectName string, log *zap.SugaredLogger) ([]*SharedService, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return nil, e.ErrGetProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", projectName, err))
+	}
+	if !productInfo.IsK8sYamlProduct() && !productInfo.IsHelmProduct() {
+		return []*SharedService{}, nil
+	}
+
+	projects, err := templaterepo.NewProductColl().ListWithOption(&templaterepo.ProductListOpt{
+		BasicFacility: productInfo.ProductFeature.BasicFacility,
+		DeployType:    productInfo.ProductFeature.DeployType,
+	})
+	if err != nil {
+		return nil, e.ErrGetProduct.AddErr(fmt.Errorf("failed to list projects, err: %w", err))
+	}
+
+	resp := make([]*SharedService, 0)
+	for _, project := range projects {
+		if project.ProductName == projectName || len(project.ExportedServices) == 0 {
+			continue
+		}
+		svcMap, err := repository.GetMaxRevisionsServicesMap(project.ProductName, false)
+		if err != nil {
+			log.Warnf("failed to list services of project %s, err: %s", project.ProductName, err)
+			continue
+		}
+		for _, serviceName := range project.ExportedServices {
+			svc, ok := svcMap[serviceName]
+			if !ok {
+				continue
+			}
+			sharedService := &SharedService{
+				ProjectName:    project.ProductName,
+				ServiceName:    serviceName,
+				Type:           svc.Type,
+				LatestRevision: svc.Revision,
+			}
+			if ref := productInfo.GetSharedServiceRef(serviceName); ref != nil && ref.ProjectName == project.ProductName {
+				sharedService.Referenced = true
+				sharedService.PinnedRevision = ref.Revision
+			}
+			resp = append(resp, sharedService)
+		}
+	}
+	return resp, nil
+}
+
+// UpdateSharedServiceRefs sets the services of other projects referenced by the project
+func UpdateSharedServiceRefs(projectName string, refs []*template.SharedServiceRef, log *zap.SugaredLogger) error {
+	productInfo, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return e.ErrGetProduct.AddErr(fmt.Errorf("failed to find product %s, err: %w", projectName, err))
+	}
+
+	svcMap, err := repository.GetMaxRevisionsServicesMap(projectName, false)
+	if err != nil {
+		return e.ErrUpdateProduct.AddErr(fmt.Errorf("failed to list services of project %s, err: %w", projectName, err))
+	}
+
+	serviceNames := sets.NewString()
+	for _, ref := range refs {
+		if ref.ProjectName == projectName {
+			return e.ErrInvalidParam.AddDesc("project can not reference its own services")
+		}
+		if _, ok := svcMap[ref.ServiceName]; ok {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s already exists in project %s", ref.ServiceName, projectName))
+		}
+		if serviceNames.Has(ref.ServiceName) {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s is referenced more than once", ref.ServiceName))
+		}
+		serviceNames.Insert(ref.ServiceName)
+
+		ownerProject, err := templaterepo.NewProductColl().Find(ref.ProjectName)
+		if err != nil {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("project %s not found", ref.ProjectName))
+		}
+		if ownerProject.IsK8sYamlProduct() != productInfo.IsK8sYamlProduct() || ownerProject.IsHelmProduct() != productInfo.IsHelmProduct() {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("project %s must be of the same type as the project", ref.ProjectName))
+		}
+		if !sets.NewString(ownerProject.ExportedServices...).Has(ref.ServiceName) {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("service %s is not exported by project %s", ref.ServiceName, ref.ProjectName))
+		}
+		if _, err := repository.QueryTemplateService(&commonrepo.ServiceFindOption{
+			ProductName: ref.ProjectName,
+			ServiceName: ref.ServiceName,
+			Revision:    ref.Revision,
+		}, false); err != nil {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("revision %d of service %s/%s not found", ref.Revision, ref.ProjectName, ref.ServiceName))
+		}
+	}
+
+	if err := templaterepo.NewProductColl().UpdateSharedServiceRefs(projectName, refs); err != nil {
+		log.Errorf("failed to update shared service refs of project %s, err: %s", projectName, err)
+		return e.ErrUpdateProduct.AddErr(err)
+	}
+	return nil
+}
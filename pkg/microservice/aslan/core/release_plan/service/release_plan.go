@@ -851,7 +851,7 @@ func ApproveReleasePlan(c *handler.Context, planID string, req *ApproveRequest)
 		plan.Approval.NativeApproval.ApproveUsers = originApprovalUsers
 	}
 
-	approval, err := approvalservice.GlobalApproveMap.DoApproval(approvalKey, c.UserName, c.UserID, req.Comment, req.Approve)
+	approval, err := approvalservice.GlobalApproveMap.DoApproval(approvalKey, c.UserName, c.UserID, req.Comment, req.Approve, config.ApprovalChannelZadig)
 	if err != nil {
 		return errors.Wrap(err, "do approval")
 	}
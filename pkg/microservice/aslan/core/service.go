@@ -44,6 +44,7 @@ import (
 	releaseplanservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/release_plan/service"
 	sprintservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/sprint_management/service"
 	systemservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
+	vmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/vm/service"
 	workflowservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
 	hubserverconfig "github.com/koderover/zadig/v2/pkg/microservice/hubserver/config"
 	"github.com/koderover/zadig/v2/pkg/microservice/hubserver/core/repository/mongodb"
@@ -126,6 +127,13 @@ func Start(ctx context.Context) {
 	initKlock()
 	initReleasePlanWatcher()
 	initSprintManagementWatcher()
+	initWorkflowScheduleWatcher()
+	initArtifactRetentionWatcher()
+	initDeadAgentJobWatcher()
+	initEnvUpdateWatcher()
+	initCertificateExpiryWatcher()
+	initEnvAutoUpgradeWatcher()
+	initEnvHealthWatcher()
 
 	initService()
 	initDinD()
@@ -186,6 +194,17 @@ func initCron() {
 
 	Scheduler.NewJob(newgoCron.DailyJob(1, newgoCron.NewAtTimes(newgoCron.NewAtTime(4, 0, 0))), newgoCron.NewTask(cleanCacheFiles))
 
+	Scheduler.NewJob(newgoCron.DurationJob(time.Hour), newgoCron.NewTask(func() {
+		if _, err := systemservice.CleanLocalCache(nil, log.SugaredLogger()); err != nil {
+			log.Errorf("[CRONJOB] failed to clean local service cache: %s", err)
+		}
+	}))
+
+	Scheduler.NewJob(newgoCron.DailyJob(1, newgoCron.NewAtTimes(newgoCron.NewAtTime(3, 0, 0))), newgoCron.NewTask(func() {
+		log.Infof("[CRONJOB] running registry retention rules....")
+		systemservice.RunScheduledRegistryRetention(log.SugaredLogger())
+	}))
+
 	Scheduler.Start()
 }
 
@@ -325,6 +344,41 @@ func initSprintManagementWatcher() {
 	go sprintservice.WatchExecutingSprintWorkItemTask()
 }
 
+// initWorkflowScheduleWatcher triggers the timezone aware workflow schedules
+func initWorkflowScheduleWatcher() {
+	go workflowservice.WatchWorkflowSchedules()
+}
+
+// initDeadAgentJobWatcher requeues the vm jobs whose agent died mid-run
+func initDeadAgentJobWatcher() {
+	go vmservice.WatchDeadAgentJobs()
+}
+
+// initEnvUpdateWatcher completes or fails the env updates interrupted by aslan restarts
+func initEnvUpdateWatcher() {
+	go environmentservice.WatchInterruptedEnvUpdates()
+}
+
+// initCertificateExpiryWatcher warns the env owners of the certificates expiring soon
+func initCertificateExpiryWatcher() {
+	go environmentservice.WatchCertificateExpiry()
+}
+
+// initEnvAutoUpgradeWatcher upgrades the services of the envs with auto upgrade enabled
+func initEnvAutoUpgradeWatcher() {
+	go environmentservice.WatchEnvAutoUpgrade()
+}
+
+// initEnvHealthWatcher samples the health score of the envs
+func initEnvHealthWatcher() {
+	go environmentservice.WatchEnvHealth()
+}
+
+// initArtifactRetentionWatcher cleans up the workflow task artifacts by the retention policies
+func initArtifactRetentionWatcher() {
+	go workflowservice.WatchArtifactRetention()
+}
+
 func initDatabaseConnection() {
 	err := gormtool.Open(configbase.MysqlUser(),
 		configbase.MysqlPassword(),
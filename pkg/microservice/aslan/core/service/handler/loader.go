@@ -140,6 +140,81 @@ func LoadServiceTemplate(c *gin.Context) {
 	ctx.RespErr = svcservice.LoadServiceFromCodeHost(ctx.UserName, codehostID, repoOwner, namespace, repoName, repoUUID, branchName, remoteName, args, false, production, ctx.Logger)
 }
 
+// @Summary Bulk import service templates
+// @Description Scan the directory tree of a git repo and create or update the helm and yaml services in bulk, dry run returns the report only
+// @Tags 	service
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	body 		body 		svcservice.BulkImportServiceReq 	true 	"body"
+// @Success 200 		{object} 	svcservice.BulkImportServiceResp
+// @Router /api/aslan/service/loader/import [post]
+func BulkImportServiceTemplate(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	args := new(svcservice.BulkImportServiceReq)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid BulkImportServiceReq json args")
+		return
+	}
+	if args.Repo == "" || args.Branch == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("repo and branch can not be empty")
+		return
+	}
+
+	detail := "项目管理-服务"
+	if args.Production {
+		detail = "项目管理-生产服务"
+	}
+	if !args.DryRun {
+		bs, _ := json.Marshal(args)
+		internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "批量导入", detail, "", string(bs), ctx.Logger)
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if args.Production {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].ProductionService.Create {
+				ctx.UnAuthorized = true
+				return
+			}
+		} else {
+			if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin &&
+				!ctx.Resources.ProjectAuthInfo[projectKey].Service.Create {
+				ctx.UnAuthorized = true
+				return
+			}
+		}
+	}
+
+	if args.Production {
+		if err := commonutil.CheckZadigProfessionalLicense(); err != nil {
+			ctx.RespErr = err
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = svcservice.BulkImportServices(ctx.UserName, projectKey, args, ctx.Logger)
+}
+
 func SyncServiceTemplate(c *gin.Context) {
 	ctx, err := internalhandler.NewContextWithAuthorization(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -84,6 +84,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		loader.POST("/load/:codehostId", LoadServiceTemplate)
 		loader.PUT("/load/:codehostId", SyncServiceTemplate)
 		loader.GET("/validateUpdate/:codehostId", ValidateServiceUpdate)
+		loader.POST("/import", BulkImportServiceTemplate)
 	}
 
 	pm := router.Group("pm")
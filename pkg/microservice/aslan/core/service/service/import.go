@@ -0,0 +1,308 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/repository"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/shared/client/systemconfig"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+// maxImportScanDepth is the maximum depth of directories scanned under the import path
+const maxImportScanDepth = 3
+
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+	ImportActionSkip      = "skip"
+)
+
+// ServiceNameMappingRule maps the directory name to the service name, Pattern is a regular expression matching
+// the whole directory name and Replacement supports the `$1` style references of the pattern.
+type ServiceNameMappingRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+type BulkImportServiceReq struct {
+	CodehostID   int                       `json:"codehost_id"`
+	Owner        string                    `json:"owner"`
+	Namespace    string                    `json:"namespace"`
+	Repo         string                    `json:"repo"`
+	Branch       string                    `json:"branch"`
+	Path         string                    `json:"path"`
+	MappingRules []*ServiceNameMappingRule `json:"mapping_rules"`
+	Visibility   string                    `json:"visibility"`
+	Production   bool                      `json:"production"`
+	DryRun       bool                      `json:"dry_run"`
+}
+
+type BulkImportServiceItem struct {
+	Path        string `json:"path"`
+	Type        string `json:"type"`
+	ServiceName string `json:"service_name"`
+	Action      string `json:"action"`
+	Error       string `json:"error,omitempty"`
+}
+
+type BulkImportServiceResp struct {
+	DryRun bool                     `json:"dry_run"`
+	Items  []*BulkImportServiceItem `json:"items"`
+}
+
+type importCandidate struct {
+	item  *BulkImportServiceItem
+	yamls []string
+}
+
+// BulkImportServices scans the directory tree of the repo and creates or updates the service templates of the project.
+// A directory containing Chart.yaml is imported as a helm service, a directory containing yaml files is imported as a
+// k8s yaml service, other directories are scanned recursively. Only the services matching the project type are imported.
+func BulkImportServices(username, projectName string, args *BulkImportServiceReq, log *zap.SugaredLogger) (*BulkImportServiceResp, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(projectName)
+	if err != nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("project %s not found", projectName))
+	}
+	if !productInfo.IsK8sYamlProduct() && !productInfo.IsHelmProduct() {
+		return nil, e.ErrInvalidParam.AddDesc("bulk import is only supported by k8s yaml and helm projects")
+	}
+
+	rules := make([]*regexp.Regexp, 0, len(args.MappingRules))
+	for _, rule := range args.MappingRules {
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", rule.Pattern))
+		if err != nil {
+			return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("invalid mapping rule %s: %s", rule.Pattern, err))
+		}
+		rules = append(rules, re)
+	}
+
+	ch, err := systemconfig.New().GetCodeHost(args.CodehostID)
+	if err != nil {
+		log.Errorf("Failed to get codehost %d, err: %s", args.CodehostID, err)
+		return nil, e.ErrLoadServiceTemplate.AddDesc(err.Error())
+	}
+	if ch.Type != setting.SourceFromGithub && ch.Type != setting.SourceFromGitlab {
+		return nil, e.ErrLoadServiceTemplate.AddDesc(fmt.Sprintf("bulk import from %s is not supported", ch.Type))
+	}
+	loader, err := getLoader(ch)
+	if err != nil {
+		log.Errorf("Failed to create loader client, err: %s", err)
+		return nil, e.ErrLoadServiceTemplate.AddDesc(err.Error())
+	}
+
+	namespace := args.Namespace
+	if namespace == "" {
+		namespace = args.Owner
+	}
+	importPath := strings.Trim(args.Path, "/")
+
+	candidates := make([]*importCandidate, 0)
+	if err := scanImportCandidates(loader, namespace, args.Repo, args.Branch, importPath, 0, &candidates); err != nil {
+		log.Errorf("Failed to scan path %s of repo %s/%s, err: %s", importPath, namespace, args.Repo, err)
+		return nil, e.ErrLoadServiceTemplate.AddDesc(err.Error())
+	}
+	if len(candidates) == 0 {
+		return nil, e.ErrPreloadServiceTemplate.AddDesc("no helm chart or yaml directory is found under the path")
+	}
+
+	resp := &BulkImportServiceResp{DryRun: args.DryRun}
+	serviceNames := make(map[string]string)
+	for _, candidate := range candidates {
+		item := candidate.item
+		resp.Items = append(resp.Items, item)
+
+		if item.Type == setting.HelmDeployType {
+			item.ServiceName, item.Error = readImportChartName(loader, namespace, args.Repo, args.Branch, item.Path)
+		} else {
+			item.ServiceName = mapServiceName(path.Base(item.Path), rules, args.MappingRules)
+		}
+		if item.Error != "" {
+			item.Action = ImportActionSkip
+			continue
+		}
+
+		if (item.Type == setting.HelmDeployType) != productInfo.IsHelmProduct() {
+			item.Action = ImportActionSkip
+			item.Error = fmt.Sprintf("%s service can not be imported into the project", item.Type)
+			continue
+		}
+		if p, ok := serviceNames[item.ServiceName]; ok {
+			item.Action = ImportActionSkip
+			item.Error = fmt.Sprintf("service name %s conflicts with path %s", item.ServiceName, p)
+			continue
+		}
+		serviceNames[item.ServiceName] = item.Path
+
+		current, err := repository.QueryTemplateService(&commonrepo.ServiceFindOption{
+			ServiceName:   item.ServiceName,
+			ProductName:   projectName,
+			ExcludeStatus: setting.ProductStatusDeleting,
+		}, args.Production)
+		switch {
+		case err != nil:
+			item.Action = ImportActionCreate
+		case item.Type == setting.K8SDeployType && current.Yaml == util.CombineManifests(candidate.yamls):
+			item.Action = ImportActionUnchanged
+		default:
+			item.Action = ImportActionUpdate
+		}
+	}
+
+	if args.DryRun {
+		return resp, nil
+	}
+
+	for _, candidate := range candidates {
+		item := candidate.item
+		if item.Action != ImportActionCreate && item.Action != ImportActionUpdate {
+			continue
+		}
+		if err := importService(username, projectName, ch, loader, namespace, args, candidate, log); err != nil {
+			log.Errorf("Failed to import service %s from path %s, err: %s", item.ServiceName, item.Path, err)
+			item.Error = err.Error()
+		}
+	}
+
+	return resp, nil
+}
+
+func scanImportCandidates(loader yamlLoader, namespace, repo, branch, dir string, depth int, candidates *[]*importCandidate) error {
+	treeNodes, err := loader.GetTree(namespace, repo, dir, branch)
+	if err != nil {
+		return err
+	}
+
+	for _, tn := range treeNodes {
+		if !tn.IsDir && tn.Name == setting.ChartYaml {
+			*candidates = append(*candidates, &importCandidate{item: &BulkImportServiceItem{Path: dir, Type: setting.HelmDeployType}})
+			return nil
+		}
+	}
+
+	if hasYAMLFiles(treeNodes) {
+		yamls, err := loader.GetYAMLContents(namespace, repo, dir, branch, true, true)
+		if err != nil {
+			return err
+		}
+		*candidates = append(*candidates, &importCandidate{
+			item:  &BulkImportServiceItem{Path: dir, Type: setting.K8SDeployType},
+			yamls: yamls,
+		})
+		return nil
+	}
+
+	if depth >= maxImportScanDepth {
+		return nil
+	}
+	folders, _ := getFoldersAndYAMLFiles(treeNodes)
+	for _, f := range folders {
+		if err := scanImportCandidates(loader, namespace, repo, branch, f.FullPath, depth+1, candidates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readImportChartName(loader yamlLoader, namespace, repo, branch, dir string) (string, string) {
+	contents, err := loader.GetYAMLContents(namespace, repo, path.Join(dir, setting.ChartYaml), branch, false, false)
+	if err != nil || len(contents) == 0 {
+		return "", fmt.Sprintf("failed to read %s: %v", setting.ChartYaml, err)
+	}
+	chart := new(Chart)
+	if err := yaml.Unmarshal([]byte(contents[0]), chart); err != nil {
+		return "", fmt.Sprintf("failed to unmarshal %s: %s", setting.ChartYaml, err)
+	}
+	return chart.Name, ""
+}
+
+// mapServiceName returns the service name of the directory by the first matched mapping rule,
+// the directory name is used if no rule is matched
+func mapServiceName(dirName string, rules []*regexp.Regexp, mappingRules []*ServiceNameMappingRule) string {
+	for i, re := range rules {
+		if re.MatchString(dirName) {
+			return re.ReplaceAllString(dirName, mappingRules[i].Replacement)
+		}
+	}
+	return dirName
+}
+
+func importService(username, projectName string, ch *systemconfig.CodeHost, loader yamlLoader, namespace string, args *BulkImportServiceReq, candidate *importCandidate, log *zap.SugaredLogger) error {
+	item := candidate.item
+	if item.Type == setting.HelmDeployType {
+		resp, err := CreateOrUpdateHelmServiceFromGitRepo(projectName, &HelmServiceCreationArgs{
+			HelmLoadSource: HelmLoadSource{Source: LoadFromRepo},
+			Name:           item.ServiceName,
+			CreatedBy:      username,
+			CreateFrom: &CreateFromRepo{
+				CodehostID: ch.ID,
+				Owner:      args.Owner,
+				Namespace:  namespace,
+				Repo:       args.Repo,
+				Branch:     args.Branch,
+				Paths:      []string{item.Path},
+			},
+			Production: args.Production,
+		}, true, log)
+		if err != nil {
+			return err
+		}
+		if len(resp.FailedServices) > 0 {
+			return fmt.Errorf("%s", resp.FailedServices[0].Error)
+		}
+		return nil
+	}
+
+	commit, err := loader.GetLatestRepositoryCommit(namespace, args.Repo, item.Path, args.Branch)
+	if err != nil {
+		return err
+	}
+
+	_, err = CreateServiceTemplate(username, &commonmodels.Service{
+		CodehostID:    ch.ID,
+		RepoName:      args.Repo,
+		RepoOwner:     args.Owner,
+		RepoNamespace: namespace,
+		BranchName:    args.Branch,
+		LoadPath:      item.Path,
+		LoadFromDir:   true,
+		KubeYamls:     candidate.yamls,
+		SrcPath:       fmt.Sprintf("%s/%s/%s/%s/%s/%s", ch.Address, namespace, args.Repo, "tree", args.Branch, item.Path),
+		CreateBy:      username,
+		ServiceName:   item.ServiceName,
+		Type:          setting.K8SDeployType,
+		ProductName:   projectName,
+		Source:        ch.Type,
+		Yaml:          util.CombineManifests(candidate.yamls),
+		Commit:        &commonmodels.Commit{SHA: commit.SHA, Message: commit.Message},
+		Visibility:    args.Visibility,
+	}, true, args.Production, log)
+	return err
+}
@@ -1056,6 +1056,20 @@ func DeleteServiceTemplate(serviceName, serviceType, productName string, product
 		}
 	}
 
+	if !production {
+		refProjects, err := templaterepo.NewProductColl().ListWithOption(&templaterepo.ProductListOpt{ReferencedService: fmt.Sprintf("%s/%s", productName, serviceName)})
+		if err != nil {
+			return e.ErrDeleteTemplate.AddErr(fmt.Errorf("failed to list the projects referencing service %s, err: %w", serviceName, err))
+		}
+		if len(refProjects) > 0 {
+			refProjectNames := make([]string, 0, len(refProjects))
+			for _, refProject := range refProjects {
+				refProjectNames = append(refProjectNames, refProject.ProductName)
+			}
+			return e.ErrDeleteTemplate.AddDesc(fmt.Sprintf("service %s is referenced by projects: %s", serviceName, strings.Join(refProjectNames, ",")))
+		}
+	}
+
 	err := repository.UpdateStatus(serviceName, productName, setting.ProductStatusDeleting, production)
 	if err != nil {
 		errMsg := fmt.Sprintf("[service.UpdateStatus] %s-%s error: %v", serviceName, serviceType, err)
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/stat/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Get Job Usage
+// @Description Get the execution time of the jobs running in the job pods, broken down by month, project and cluster
+// @Tags 	stat
+// @Accept 	json
+// @Produce json
+// @Param 	startMonth	query		string								false	"start month, in the format of 2006-01"
+// @Param 	endMonth	query		string								false	"end month, in the format of 2006-01"
+// @Param 	projects	query		[]string							false	"project names"
+// @Param 	clusterID	query		string								false	"cluster id"
+// @Success 200 		{object} 	service.JobUsageResp
+// @Router /api/aslan/stat/v2/usage/jobs [get]
+func GetJobUsage(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args, err := getJobUsageArgs(c, ctx)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if ctx.UnAuthorized {
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetJobUsage(args, ctx.Logger)
+}
+
+// @Summary Export Job Usage
+// @Description Export the job usage broken down by month, project and cluster in the csv format
+// @Tags 	stat
+// @Accept 	json
+// @Produce text/csv
+// @Param 	startMonth	query		string								false	"start month, in the format of 2006-01"
+// @Param 	endMonth	query		string								false	"end month, in the format of 2006-01"
+// @Param 	projects	query		[]string							false	"project names"
+// @Param 	clusterID	query		string								false	"cluster id"
+// @Success 200
+// @Router /api/aslan/stat/v2/usage/jobs/export [get]
+func ExportJobUsage(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	args, err := getJobUsageArgs(c, ctx)
+	if err != nil || ctx.UnAuthorized {
+		ctx.RespErr = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	data, err := service.ExportJobUsage(args, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Disposition", `attachment; filename="job_usage.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// getJobUsageArgs binds the query args, the usage of all projects is only visible to system admin, other users
+// can only query the projects they are in
+func getJobUsageArgs(c *gin.Context, ctx *internalhandler.Context) (*service.JobUsageArgs, error) {
+	args := new(service.JobUsageArgs)
+	if err := c.ShouldBindQuery(args); err != nil {
+		return nil, e.ErrInvalidParam.AddErr(err)
+	}
+	if err := args.Validate(); err != nil {
+		return nil, e.ErrInvalidParam.AddErr(err)
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		if len(args.Projects) == 0 {
+			ctx.UnAuthorized = true
+			return args, nil
+		}
+		for _, project := range args.Projects {
+			if _, ok := ctx.Resources.ProjectAuthInfo[project]; !ok {
+				ctx.UnAuthorized = true
+				return args, nil
+			}
+		}
+	}
+	return args, nil
+}
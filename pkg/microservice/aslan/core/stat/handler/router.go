@@ -87,6 +87,12 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		aiV2.GET("/requirement/period", GetRequirementDevDepPeriod)
 	}
 
+	usageV2 := v2.Group("usage")
+	{
+		usageV2.GET("/jobs", GetJobUsage)
+		usageV2.GET("/jobs/export", ExportJobUsage)
+	}
+
 	releaseV2 := v2.Group("release")
 	{
 		releaseV2.POST("/monthly", CreateMonthlyReleaseStat)
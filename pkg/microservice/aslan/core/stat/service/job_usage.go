@@ -0,0 +1,171 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type JobUsageArgs struct {
+	// StartMonth and EndMonth are both inclusive, in the format of 2006-01
+	StartMonth string   `json:"startMonth" form:"startMonth"`
+	EndMonth   string   `json:"endMonth"   form:"endMonth"`
+	Projects   []string `json:"projects"   form:"projects"`
+	ClusterID  string   `json:"clusterID"  form:"clusterID"`
+}
+
+func (args *JobUsageArgs) Validate() error {
+	for _, month := range []string{args.StartMonth, args.EndMonth} {
+		if month == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01", month); err != nil {
+			return fmt.Errorf("invalid month %s, the format should be 2006-01", month)
+		}
+	}
+	return nil
+}
+
+type JobUsageItem struct {
+	Month       string  `json:"month"`
+	ProjectName string  `json:"project_name"`
+	ClusterID   string  `json:"cluster_id"`
+	ClusterName string  `json:"cluster_name"`
+	JobCount    int64   `json:"job_count"`
+	Duration    int64   `json:"duration"`
+	Minutes     float64 `json:"minutes"`
+}
+
+type JobUsageMonthly struct {
+	Month    string  `json:"month"`
+	JobCount int64   `json:"job_count"`
+	Duration int64   `json:"duration"`
+	Minutes  float64 `json:"minutes"`
+}
+
+type JobUsageResp struct {
+	// Items is the usage broken down by month, project and cluster
+	Items []*JobUsageItem `json:"items"`
+	// Monthly is the total usage of each month
+	Monthly []*JobUsageMonthly `json:"monthly"`
+}
+
+// GetJobUsage returns the execution time of the jobs running in the job pods, broken down by month, project and cluster
+func GetJobUsage(args *JobUsageArgs, logger *zap.SugaredLogger) (*JobUsageResp, error) {
+	items, err := listJobUsageItems(args)
+	if err != nil {
+		logger.Errorf("failed to get job usage, error: %s", err)
+		return nil, e.ErrGetJobUsage.AddErr(err)
+	}
+
+	resp := &JobUsageResp{
+		Items:   items,
+		Monthly: make([]*JobUsageMonthly, 0),
+	}
+	monthlyMap := make(map[string]*JobUsageMonthly)
+	for _, item := range items {
+		monthly, ok := monthlyMap[item.Month]
+		if !ok {
+			monthly = &JobUsageMonthly{Month: item.Month}
+			monthlyMap[item.Month] = monthly
+			resp.Monthly = append(resp.Monthly, monthly)
+		}
+		monthly.JobCount += item.JobCount
+		monthly.Duration += item.Duration
+	}
+	for _, monthly := range resp.Monthly {
+		monthly.Minutes = secondsToMinutes(monthly.Duration)
+	}
+	return resp, nil
+}
+
+// ExportJobUsage returns the job usage in the csv format
+func ExportJobUsage(args *JobUsageArgs, logger *zap.SugaredLogger) ([]byte, error) {
+	items, err := listJobUsageItems(args)
+	if err != nil {
+		logger.Errorf("failed to get job usage, error: %s", err)
+		return nil, e.ErrExportJobUsage.AddErr(err)
+	}
+
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+	records := [][]string{{"month", "project", "cluster_id", "cluster_name", "job_count", "duration_seconds", "minutes"}}
+	for _, item := range items {
+		records = append(records, []string{
+			item.Month,
+			item.ProjectName,
+			item.ClusterID,
+			item.ClusterName,
+			strconv.FormatInt(item.JobCount, 10),
+			strconv.FormatInt(item.Duration, 10),
+			strconv.FormatFloat(item.Minutes, 'f', 2, 64),
+		})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		logger.Errorf("failed to write job usage csv, error: %s", err)
+		return nil, e.ErrExportJobUsage.AddErr(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func listJobUsageItems(args *JobUsageArgs) ([]*JobUsageItem, error) {
+	stats, err := commonrepo.NewJobUsageRecordColl().GetUsage(&commonrepo.JobUsageQueryOption{
+		ProjectNames: args.Projects,
+		ClusterID:    args.ClusterID,
+		StartMonth:   args.StartMonth,
+		EndMonth:     args.EndMonth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate job usage records, error: %s", err)
+	}
+
+	clusters, err := commonrepo.NewK8SClusterColl().List(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters, error: %s", err)
+	}
+	clusterNames := make(map[string]string)
+	for _, cluster := range clusters {
+		clusterNames[cluster.ID.Hex()] = cluster.Name
+	}
+
+	items := make([]*JobUsageItem, 0, len(stats))
+	for _, stat := range stats {
+		items = append(items, &JobUsageItem{
+			Month:       stat.Month,
+			ProjectName: stat.ProjectName,
+			ClusterID:   stat.ClusterID,
+			ClusterName: clusterNames[stat.ClusterID],
+			JobCount:    stat.JobCount,
+			Duration:    stat.Duration,
+			Minutes:     secondsToMinutes(stat.Duration),
+		})
+	}
+	return items, nil
+}
+
+func secondsToMinutes(seconds int64) float64 {
+	return float64(seconds*100/60) / 100
+}
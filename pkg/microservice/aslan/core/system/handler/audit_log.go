@@ -0,0 +1,149 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Audit Logs
+// @Description List the audit logs of the mutating api calls
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	userID 			query		string		false	"user id"
+// @Param 	username 		query		string		false	"username"
+// @Param 	projectName 	query		string		false	"project name"
+// @Param 	method 			query		string		false	"http method"
+// @Param 	resource 		query		string		false	"resource, e.g. environment, workflow"
+// @Param 	resourceName 	query		string		false	"resource name"
+// @Param 	clientIP 		query		string		false	"client ip"
+// @Param 	failed 			query		bool		false	"only list the failed calls"
+// @Param 	startTime 		query		int			false	"start time"
+// @Param 	endTime 		query		int			false	"end time"
+// @Param 	page 			query		int			false	"page"
+// @Param 	perPage 		query		int			false	"per page"
+// @Success 200 			{object} 	service.ListAuditLogsResp
+// @Router /api/aslan/system/audit/logs [get]
+func ListAuditLogs(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(mongodb.AuditLogArgs)
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if args.PerPage == 0 {
+		args.PerPage = 50
+	}
+	if args.Page == 0 {
+		args.Page = 1
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListAuditLogs(args, ctx.Logger)
+}
+
+// @Summary Get Audit Log Export Policy
+// @Description Get the policy of forwarding the audit logs to the SIEM systems
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Success 200 	{object} 	commonmodels.AuditLogExportPolicy
+// @Router /api/aslan/system/audit/export [get]
+func GetAuditLogExportPolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetAuditLogExportPolicy(ctx.Logger)
+}
+
+// @Summary Update Audit Log Export Policy
+// @Description Update the policy of forwarding the audit logs to the SIEM systems by syslog or webhook
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	body 	body 		commonmodels.AuditLogExportPolicy 	true 	"body"
+// @Success 200
+// @Router /api/aslan/system/audit/export [put]
+func UpdateAuditLogExportPolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.AuditLogExportPolicy)
+	data, err := c.GetRawData()
+	if err == nil {
+		err = json.Unmarshal(data, args)
+	}
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	if before, err := service.GetAuditLogExportPolicy(ctx.Logger); err == nil {
+		before.WebhookSecret = ""
+		internalhandler.SetAuditLogPreviousSettings(c, before)
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "审计日志导出配置", fmt.Sprintf("syslog: %v, webhook: %v", args.SyslogEnabled, args.WebhookEnabled), "", ctx.Logger)
+
+	ctx.RespErr = service.UpdateAuditLogExportPolicy(args, ctx.Logger)
+}
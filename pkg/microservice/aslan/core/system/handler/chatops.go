@@ -0,0 +1,91 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Bind chatops user
+// @Description Bind the IM user of the current user found by email (lark) or phone (dingtalk), the chatops commands sent by the IM user run as the current user
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	body 		body 		service.BindChatOpsUserArgs 	true 	"body"
+// @Success 200 		{object} 	commonmodels.ChatOpsUserBinding
+// @Router /api/aslan/system/chatops/bindings [post]
+func BindChatOpsUser(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.BindChatOpsUserArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if args.IMAppID == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("im_app_id can't be empty")
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.BindChatOpsUser(ctx.UserID, args, ctx.Logger)
+}
+
+// @Summary List chatops user bindings
+// @Description List the IM users bound to the current user
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Success 200 		{array} 	commonmodels.ChatOpsUserBinding
+// @Router /api/aslan/system/chatops/bindings [get]
+func ListChatOpsUserBindings(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.RespErr = service.ListChatOpsUserBindings(ctx.UserID, ctx.Logger)
+}
+
+// @Summary Unbind chatops user
+// @Description Unbind the IM user from the current user
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id 			path		string							true	"binding id"
+// @Success 200
+// @Router /api/aslan/system/chatops/bindings/{id} [delete]
+func UnbindChatOpsUser(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.RespErr = service.UnbindChatOpsUser(ctx.UserID, c.Param("id"), ctx.Logger)
+}
+
+func DingTalkChatOpsHandler(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	body, err := c.GetRawData()
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	ctx.Resp, ctx.RespErr = service.HandleDingTalkChatOpsMessage(c.Param("id"), c.GetHeader("timestamp"), c.GetHeader("sign"), body, ctx.Logger)
+}
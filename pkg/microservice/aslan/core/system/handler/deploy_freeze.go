@@ -0,0 +1,159 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Deploy Freeze Windows
+// @Description List the system deployment freeze windows, the finished ones are omitted unless all is true
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	all 	query		bool									false	"list the finished windows as well"
+// @Success 200 	{array} 	commonmodels.DeployFreezeWindow
+// @Router /api/aslan/system/deploy-freeze [get]
+func ListDeployFreezeWindows(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	all, _ := strconv.ParseBool(c.Query("all"))
+	ctx.Resp, ctx.RespErr = commonservice.ListDeployFreezeWindows(all, ctx.Logger)
+}
+
+// @Summary List Active Deploy Freeze Windows
+// @Description List the deployment freeze windows in effect now
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Success 200 	{array} 	commonmodels.DeployFreezeWindow
+// @Router /api/aslan/system/deploy-freeze/active [get]
+func ListActiveDeployFreezeWindows(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.RespErr = commonservice.ListActiveDeployFreezeWindows(ctx.Logger)
+}
+
+// @Summary Create Deploy Freeze Window
+// @Description Create a system deployment freeze window
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	body 	body 		commonmodels.DeployFreezeWindow 		true 	"body"
+// @Success 200
+// @Router /api/aslan/system/deploy-freeze [post]
+func CreateDeployFreezeWindow(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.DeployFreezeWindow)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "新增", "系统设置-发布冻结窗口", args.Name, "", ctx.Logger)
+
+	ctx.RespErr = commonservice.CreateDeployFreezeWindow(ctx.UserName, args, ctx.Logger)
+}
+
+// @Summary Update Deploy Freeze Window
+// @Description Update a system deployment freeze window
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id 		path		string									true	"window id"
+// @Param 	body 	body 		commonmodels.DeployFreezeWindow 		true 	"body"
+// @Success 200
+// @Router /api/aslan/system/deploy-freeze/{id} [put]
+func UpdateDeployFreezeWindow(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.DeployFreezeWindow)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "系统设置-发布冻结窗口", args.Name, "", ctx.Logger)
+
+	ctx.RespErr = commonservice.UpdateDeployFreezeWindow(c.Param("id"), ctx.UserName, args, ctx.Logger)
+}
+
+// @Summary Delete Deploy Freeze Window
+// @Description Delete a system deployment freeze window
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id 		path		string		true	"window id"
+// @Success 200
+// @Router /api/aslan/system/deploy-freeze/{id} [delete]
+func DeleteDeployFreezeWindow(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "删除", "系统设置-发布冻结窗口", c.Param("id"), "", ctx.Logger)
+
+	ctx.RespErr = commonservice.DeleteDeployFreezeWindow(c.Param("id"), ctx.Logger)
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List dns integrations
+// @Description List dns integrations
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Success 200 		{array} 	commonmodels.DNSIntegration
+// @Router /api/aslan/system/dns/integration [get]
+func ListDNSIntegrations(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.RespErr = service.ListDNSIntegrations()
+}
+
+// @Summary Get a dns integration
+// @Description Get a dns integration
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id			path		string								true	"id"
+// @Success 200 		{object} 	commonmodels.DNSIntegration
+// @Router /api/aslan/system/dns/integration/{id} [get]
+func GetDNSIntegration(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.RespErr = service.GetDNSIntegration(c.Param("id"))
+}
+
+// @Summary Create a dns integration
+// @Description Create a dns integration
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	body 			body 		commonmodels.DNSIntegration 			true 	"body"
+// @Success 200
+// @Router /api/aslan/system/dns/integration [post]
+func CreateDNSIntegration(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.DNSIntegration)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid create dns integration json args")
+		return
+	}
+	args.UpdatedBy = ctx.UserName
+
+	ctx.RespErr = service.CreateDNSIntegration(args)
+}
+
+// @Summary Update a dns integration
+// @Description Update a dns integration
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id				path		string							true	"id"
+// @Param 	body 			body 		commonmodels.DNSIntegration 	true 	"body"
+// @Success 200
+// @Router /api/aslan/system/dns/integration/{id} [put]
+func UpdateDNSIntegration(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.DNSIntegration)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid update dns integration json args")
+		return
+	}
+	args.UpdatedBy = ctx.UserName
+
+	ctx.RespErr = service.UpdateDNSIntegration(c.Param("id"), args)
+}
+
+// @Summary Delete a dns integration
+// @Description Delete a dns integration, the records created by it are kept
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id				path		string							true	"id"
+// @Success 200
+// @Router /api/aslan/system/dns/integration/{id} [delete]
+func DeleteDNSIntegration(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.RespErr = service.DeleteDNSIntegration(c.Param("id"))
+}
@@ -21,9 +21,11 @@ import (
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/lark"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
 	"github.com/koderover/zadig/v2/pkg/setting"
 	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
 	larktool "github.com/koderover/zadig/v2/pkg/tool/lark"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
 )
 
 func GetLarkDepartment(c *gin.Context) {
@@ -66,11 +68,20 @@ func LarkEventHandler(c *gin.Context) {
 		ctx.RespErr = err
 		return
 	}
-	ctx.Resp, ctx.RespErr = lark.EventHandler(
+	resp, err := lark.EventHandler(
 		c.Param("id"),
 		c.GetHeader("X-Lark-Signature"),
 		c.GetHeader("X-Lark-Request-Timestamp"),
 		c.GetHeader("X-Lark-Request-Nonce"), string(body))
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	// lark requires the event to be responded in 3 seconds, so the chatops command runs asynchronously
+	if resp != nil && resp.Message != nil {
+		go service.HandleLarkChatOpsMessage(resp.Message, log.SugaredLogger())
+	}
+	ctx.Resp = resp
 }
 
 type listChatResp struct {
@@ -18,6 +18,7 @@ package handler
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -34,6 +35,13 @@ type CreateLLMIntegrationRequest struct {
 	BaseURL      string       `json:"base_url"`
 	Model        string       `json:"model"`
 	EnableProxy  bool         `json:"enable_proxy"`
+
+	Name                 string   `json:"name"`
+	IsDefault            bool     `json:"is_default"`
+	Features             []string `json:"features"`
+	RateLimit            int      `json:"rate_limit"`
+	PromptTokenPrice     float64  `json:"prompt_token_price"`
+	CompletionTokenPrice float64  `json:"completion_token_price"`
 }
 
 // @Summary Create a llm integration
@@ -178,6 +186,94 @@ func convertLLMArgToModel(args *CreateLLMIntegrationRequest) *commonmodels.LLMIn
 		BaseURL:      args.BaseURL,
 		EnableProxy:  args.EnableProxy,
 		Model:        args.Model,
-		IsDefault:    true,
+		IsDefault:    args.IsDefault,
+
+		Name:                 args.Name,
+		Features:             args.Features,
+		RateLimit:            args.RateLimit,
+		PromptTokenPrice:     args.PromptTokenPrice,
+		CompletionTokenPrice: args.CompletionTokenPrice,
+	}
+}
+
+// @Summary Get llm usage
+// @Description Get the token usage and cost of the AI features by project, the usage of the current month is returned if the time range is not set
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								false	"project name"
+// @Param 	startTime	query		int									false	"start time"
+// @Param 	endTime		query		int									false	"end time"
+// @Success 200 		{array} 	commonmodels.LLMUsageStat
+// @Router /api/aslan/system/llm/usage [get]
+func GetLLMUsage(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	startTime, _ := strconv.ParseInt(c.Query("startTime"), 10, 64)
+	endTime, _ := strconv.ParseInt(c.Query("endTime"), 10, 64)
+	ctx.Resp, ctx.RespErr = service.GetLLMUsage(c.Query("projectName"), startTime, endTime)
+}
+
+// @Summary List llm budgets
+// @Description List the monthly llm cost budgets of the projects
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Success 200 		{array} 	commonmodels.LLMBudget
+// @Router /api/aslan/system/llm/budget [get]
+func ListLLMBudget(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.RespErr = service.ListLLMBudget()
+}
+
+type setLLMBudgetRequest struct {
+	MonthlyBudget   float64  `json:"monthly_budget"`
+	AlertPercentage int      `json:"alert_percentage"`
+	Receivers       []string `json:"receivers"`
+}
+
+// @Summary Set llm budget
+// @Description Set the monthly llm cost budget of the project, the receivers are notified when the cost reaches the alert percentage or the budget
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	projectName		path		string							true	"project name"
+// @Param 	body 			body 		setLLMBudgetRequest 			true 	"body"
+// @Success 200
+// @Router /api/aslan/system/llm/budget/{projectName} [put]
+func SetLLMBudget(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(setLLMBudgetRequest)
+	if err := c.BindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid llm budget json args")
+		return
 	}
+
+	ctx.RespErr = service.SetLLMBudget(&commonmodels.LLMBudget{
+		ProjectName:     c.Param("projectName"),
+		MonthlyBudget:   args.MonthlyBudget,
+		AlertPercentage: args.AlertPercentage,
+		Receivers:       args.Receivers,
+		UpdatedBy:       ctx.UserName,
+	})
+}
+
+// @Summary Delete llm budget
+// @Description Delete the monthly llm cost budget of the project
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	projectName		path		string							true	"project name"
+// @Success 200
+// @Router /api/aslan/system/llm/budget/{projectName} [delete]
+func DeleteLLMBudget(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.RespErr = service.DeleteLLMBudget(c.Param("projectName"))
 }
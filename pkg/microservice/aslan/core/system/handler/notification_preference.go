@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
+	"github.com/koderover/zadig/v2/pkg/shared/client/user"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Get notification preference
+// @Description Get the notification preference of the current user
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Success 200 		{object} 	commonmodels.NotificationPreference
+// @Router /api/aslan/system/notificationPreference [get]
+func GetNotificationPreference(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.RespErr = service.GetNotificationPreference(ctx.UserID, ctx.Logger)
+}
+
+// @Summary Update notification preference
+// @Description Update the notification preference of the current user
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	body 		body 		commonmodels.NotificationPreference 	true 	"body"
+// @Success 200
+// @Router /api/aslan/system/notificationPreference [put]
+func UpdateNotificationPreference(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.NotificationPreference)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	ctx.RespErr = service.UpdateNotificationPreference(ctx.UserID, ctx.UserName, args, ctx.Logger)
+}
+
+// @Summary Get user notification preference
+// @Description Get the notification preference of a user, used by the system admin and the IM bots
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	userID		path		string									true	"user id"
+// @Success 200 		{object} 	commonmodels.NotificationPreference
+// @Router /api/aslan/system/notificationPreference/users/{userID} [get]
+func GetUserNotificationPreference(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetNotificationPreference(c.Param("userID"), ctx.Logger)
+}
+
+// @Summary Update user notification preference
+// @Description Update the notification preference of a user, used by the system admin and the IM bots
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	userID		path		string									true	"user id"
+// @Param 	body 		body 		commonmodels.NotificationPreference 	true 	"body"
+// @Success 200
+// @Router /api/aslan/system/notificationPreference/users/{userID} [put]
+func UpdateUserNotificationPreference(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.NotificationPreference)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	userInfo, err := user.New().GetUserByID(c.Param("userID"))
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(fmt.Sprintf("user %s not found", c.Param("userID")))
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "系统配置-用户通知设置", userInfo.Name, "", ctx.Logger)
+
+	ctx.RespErr = service.UpdateNotificationPreference(c.Param("userID"), userInfo.Name, args, ctx.Logger)
+}
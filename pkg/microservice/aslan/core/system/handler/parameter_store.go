@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List parameters
+// @Description List the parameters of the parameter store, the system level parameters are listed if projectName is empty
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								false	"project name"
+// @Success 200 		{array} 	commonmodels.ParameterStoreItem
+// @Router /api/aslan/system/parameterStore [get]
+func ListParameters(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectName := c.Query("projectName")
+	if !checkParameterStorePermission(ctx, projectName, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListParameters(projectName, ctx.Logger)
+}
+
+// @Summary Create parameter
+// @Description Create a parameter in the parameter store, it is referenced by `{{.store.KEY}}` in the workflows and service yamls
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								false	"project name"
+// @Param 	body 		body 		commonmodels.ParameterStoreItem 	true 	"body"
+// @Success 200
+// @Router /api/aslan/system/parameterStore [post]
+func CreateParameter(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.ParameterStoreItem)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args.ProjectName = c.Query("projectName")
+
+	if !checkParameterStorePermission(ctx, args.ProjectName, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, args.ProjectName, "新建", "参数库", args.Key, "", ctx.Logger)
+
+	ctx.RespErr = service.CreateParameter(ctx.UserName, args, ctx.Logger)
+}
+
+// @Summary Update parameter
+// @Description Update a parameter in the parameter store, the value of the secret parameter is kept if the masked value is passed
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	key			path		string								true	"parameter key"
+// @Param 	projectName	query		string								false	"project name"
+// @Param 	body 		body 		commonmodels.ParameterStoreItem 	true 	"body"
+// @Success 200
+// @Router /api/aslan/system/parameterStore/{key} [put]
+func UpdateParameter(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.ParameterStoreItem)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	projectName := c.Query("projectName")
+
+	if !checkParameterStorePermission(ctx, projectName, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "更新", "参数库", c.Param("key"), "", ctx.Logger)
+
+	ctx.RespErr = service.UpdateParameter(ctx.UserName, projectName, c.Param("key"), args, ctx.Logger)
+}
+
+// @Summary Delete parameter
+// @Description Delete a parameter in the parameter store
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	key			path		string								true	"parameter key"
+// @Param 	projectName	query		string								false	"project name"
+// @Success 200
+// @Router /api/aslan/system/parameterStore/{key} [delete]
+func DeleteParameter(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectName := c.Query("projectName")
+	if !checkParameterStorePermission(ctx, projectName, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "删除", "参数库", c.Param("key"), "", ctx.Logger)
+
+	ctx.RespErr = service.DeleteParameter(ctx.UserName, projectName, c.Param("key"), ctx.Logger)
+}
+
+// @Summary List parameter histories
+// @Description List the change histories of a parameter, the values of the secret parameters are masked
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	key			path		string								true	"parameter key"
+// @Param 	projectName	query		string								false	"project name"
+// @Param 	page_num	query		int									false	"page num"
+// @Param 	page_size	query		int									false	"page size"
+// @Success 200 		{object} 	service.ParameterHistoryResp
+// @Router /api/aslan/system/parameterStore/{key}/history [get]
+func ListParameterHistories(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := &listQuery{}
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	projectName := c.Query("projectName")
+
+	if !checkParameterStorePermission(ctx, projectName, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListParameterHistories(projectName, c.Param("key"), args.PageNum, args.PageSize, ctx.Logger)
+}
+
+// checkParameterStorePermission checks the permission of the parameter store, the system level parameters are
+// readable by everyone and editable by the system admin, the project level ones are managed by the project admin.
+func checkParameterStorePermission(ctx *internalhandler.Context, projectName string, edit bool) bool {
+	if ctx.Resources.IsSystemAdmin {
+		return true
+	}
+	if projectName == "" {
+		return !edit
+	}
+	authInfo, ok := ctx.Resources.ProjectAuthInfo[projectName]
+	if !ok {
+		return false
+	}
+	return !edit || authInfo.IsProjectAdmin
+}
@@ -294,6 +294,101 @@ func DeleteRegistryNamespace(c *gin.Context) {
 	ctx.RespErr = service.DeleteRegistryNamespace(c.Param("id"), ctx.Logger)
 }
 
+// @Summary Rotate Registry Credential
+// @Description Update the access key of the registry and re-create the pull secrets of the environments using it
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id		path		string									true	"registry id"
+// @Param 	body 	body 		service.RotateRegistryCredentialArgs 	true 	"body"
+// @Success 200 	{object} 	commonmodels.RegistryCredentialRotation
+// @Router /api/aslan/system/registry/namespaces/{id}/rotate [post]
+func RotateRegistryCredential(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(service.RotateRegistryCredentialArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "系统设置-Registry-凭证轮换", fmt.Sprintf("registry ID:%s", c.Param("id")), "", ctx.Logger)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.RegistryManagement.Edit {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.RotateRegistryCredential(ctx.UserName, c.Param("id"), args, ctx.Logger)
+}
+
+// @Summary List Registry Credential Rotations
+// @Description List the latest credential rotations of the registry with the progress and the failed environments
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id		path		string									true	"registry id"
+// @Success 200 	{array} 	commonmodels.RegistryCredentialRotation
+// @Router /api/aslan/system/registry/namespaces/{id}/rotations [get]
+func ListRegistryCredentialRotations(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.RegistryManagement.View {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListRegistryCredentialRotations(c.Param("id"))
+}
+
+// @Summary Get Registry Credential Rotation
+// @Description Get the progress and the failed environments of a credential rotation of the registry
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id			path		string									true	"registry id"
+// @Param 	rotationID	path		string									true	"rotation id"
+// @Success 200 		{object} 	commonmodels.RegistryCredentialRotation
+// @Router /api/aslan/system/registry/namespaces/{id}/rotations/{rotationID} [get]
+func GetRegistryCredentialRotation(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.RegistryManagement.View {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetRegistryCredentialRotation(c.Param("id"), c.Param("rotationID"))
+}
+
 func ListAllRepos(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -374,3 +469,242 @@ func ListRepoImages(c *gin.Context) {
 	resp, err := service.GetRepoTags(registryInfo, name, ctx.Logger)
 	ctx.Resp, ctx.RespErr = resp, err
 }
+
+// @Summary List Registry Repositories
+// @Description List the repositories in the namespace of the registry
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id		path		string		true	"registry id"
+// @Success 200 	{array} 	string
+// @Router /api/aslan/system/registry/namespaces/{id}/repos [get]
+func ListRegistryRepositories(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.RegistryManagement.View {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListRegistryRepositories(c.Param("id"), ctx.Logger)
+}
+
+// @Summary List Registry Repository Tags
+// @Description List the tags of the repository with the digest, size and pushed time, the latest pushed first
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id		path		string		true	"registry id"
+// @Param 	repo	query		string		true	"repository name"
+// @Success 200 	{array} 	registry.TagDetail
+// @Router /api/aslan/system/registry/namespaces/{id}/tags [get]
+func ListRegistryRepositoryTags(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	repo := c.Query("repo")
+	if repo == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("repo can not be empty")
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.RegistryManagement.View {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListRegistryRepositoryTags(c.Param("id"), repo, ctx.Logger)
+}
+
+// @Summary List Registry Retention Rules
+// @Description List the tag retention rules of the project
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string		true	"project name"
+// @Success 200 		{array} 	commonmodels.RegistryRetentionRule
+// @Router /api/aslan/system/registry/retention [get]
+func ListRegistryRetentionRules(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListRegistryRetentionRules(projectKey)
+}
+
+// @Summary Update Registry Retention Rule
+// @Description Create or update the tag retention rule of the registry in the project, the tags built by zadig beyond the latest keep_latest ones and older than keep_days are cleaned up daily
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Param 	body 		body 		commonmodels.RegistryRetentionRule 	true 	"body"
+// @Success 200
+// @Router /api/aslan/system/registry/retention [put]
+func UpsertRegistryRetentionRule(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	args := new(commonmodels.RegistryRetentionRule)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "更新", "镜像保留策略", args.RegistryID, "", ctx.Logger)
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = service.UpsertRegistryRetentionRule(projectKey, ctx.UserName, args, ctx.Logger)
+}
+
+// @Summary Delete Registry Retention Rule
+// @Description Delete the tag retention rule of the registry in the project
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	registryID	path		string		true	"registry id"
+// @Param 	projectName	query		string		true	"project name"
+// @Success 200
+// @Router /api/aslan/system/registry/retention/{registryID} [delete]
+func DeleteRegistryRetentionRule(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "删除", "镜像保留策略", c.Param("registryID"), "", ctx.Logger)
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = service.DeleteRegistryRetentionRule(projectKey, c.Param("registryID"))
+}
+
+// @Summary Run Registry Retention Rule
+// @Description Run the tag retention rule of the registry in the project, no tag is deleted in dry run and the report lists the tags to be cleaned up
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	registryID	path		string		true	"registry id"
+// @Param 	projectName	query		string		true	"project name"
+// @Param 	dryRun		query		bool		false	"dry run"
+// @Success 200 		{object} 	service.RegistryRetentionReport
+// @Router /api/aslan/system/registry/retention/{registryID}/run [post]
+func RunRegistryRetentionRule(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+	dryRun := c.Query("dryRun") == "true"
+
+	if !dryRun {
+		internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "执行", "镜像保留策略", c.Param("registryID"), "", ctx.Logger)
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = service.RunRegistryRetentionRule(projectKey, c.Param("registryID"), dryRun, ctx.Logger)
+}
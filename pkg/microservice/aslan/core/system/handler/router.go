@@ -63,6 +63,15 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		registry.GET("/namespaces", ListRegistryNamespaces)
 		registry.POST("/namespaces", CreateRegistryNamespace)
 		registry.PUT("/namespaces/:id", UpdateRegistryNamespace)
+		registry.POST("/namespaces/:id/rotate", RotateRegistryCredential)
+		registry.GET("/namespaces/:id/rotations", ListRegistryCredentialRotations)
+		registry.GET("/namespaces/:id/rotations/:rotationID", GetRegistryCredentialRotation)
+		registry.GET("/namespaces/:id/repos", ListRegistryRepositories)
+		registry.GET("/namespaces/:id/tags", ListRegistryRepositoryTags)
+		registry.GET("/retention", ListRegistryRetentionRules)
+		registry.PUT("/retention", UpsertRegistryRetentionRule)
+		registry.DELETE("/retention/:registryID", DeleteRegistryRetentionRule)
+		registry.POST("/retention/:registryID/run", RunRegistryRetentionRule)
 
 		registry.DELETE("/namespaces/:id", DeleteRegistryNamespace)
 		registry.GET("/release/repos", ListAllRepos)
@@ -88,6 +97,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		cleanCache.GET("/state", CleanCacheState)
 		cleanCache.POST("/cron", SetCron)
 		cleanCache.POST("/sharedStorage", CleanSharedStorage)
+		cleanCache.GET("/localCache", GetLocalCacheUsage)
+		cleanCache.POST("/localCache", CleanLocalCache)
 	}
 
 	// security and privacy settings
@@ -95,6 +106,26 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	{
 		security.POST("", CreateOrUpdateSecuritySettings)
 		security.GET("", GetSecuritySettings)
+		security.GET("/credentialScan", GetCredentialScanPolicy)
+		security.PUT("/credentialScan", UpdateCredentialScanPolicy)
+	}
+
+	// audit logs of the mutating api calls
+	audit := router.Group("audit")
+	{
+		audit.GET("/logs", ListAuditLogs)
+		audit.GET("/export", GetAuditLogExportPolicy)
+		audit.PUT("/export", UpdateAuditLogExportPolicy)
+	}
+
+	// system level deployment freeze calendar
+	deployFreeze := router.Group("deploy-freeze")
+	{
+		deployFreeze.GET("", ListDeployFreezeWindows)
+		deployFreeze.GET("/active", ListActiveDeployFreezeWindows)
+		deployFreeze.POST("", CreateDeployFreezeWindow)
+		deployFreeze.PUT("/:id", UpdateDeployFreezeWindow)
+		deployFreeze.DELETE("/:id", DeleteDeployFreezeWindow)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -393,6 +424,50 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		meego.GET("/:id/projects/:projectID/work_item/:workItemID/transitions", ListAvailableWorkItemTransitions)
 	}
 
+	imageSigningKey := router.Group("imageSigningKey")
+	{
+		imageSigningKey.GET("", ListImageSigningKeys)
+		imageSigningKey = imageSigningKey.Group("", isSystemAdmin)
+		imageSigningKey.GET("/detail", ListImageSigningKeysDetail)
+		imageSigningKey.POST("", CreateImageSigningKey)
+		imageSigningKey.PUT("/:id", UpdateImageSigningKey)
+		imageSigningKey.DELETE("/:id", DeleteImageSigningKey)
+	}
+
+	parameterStore := router.Group("parameterStore")
+	{
+		parameterStore.GET("", ListParameters)
+		parameterStore.POST("", CreateParameter)
+		parameterStore.PUT("/:key", UpdateParameter)
+		parameterStore.DELETE("/:key", DeleteParameter)
+		parameterStore.GET("/:key/history", ListParameterHistories)
+	}
+
+	notificationPreference := router.Group("notificationPreference")
+	{
+		notificationPreference.GET("", GetNotificationPreference)
+		notificationPreference.PUT("", UpdateNotificationPreference)
+		notificationPreference.GET("/users/:userID", GetUserNotificationPreference)
+		notificationPreference.PUT("/users/:userID", UpdateUserNotificationPreference)
+	}
+
+	chatops := router.Group("chatops")
+	{
+		chatops.GET("/bindings", ListChatOpsUserBindings)
+		chatops.POST("/bindings", BindChatOpsUser)
+		chatops.DELETE("/bindings/:id", UnbindChatOpsUser)
+		chatops.POST("/dingtalk/:id", DingTalkChatOpsHandler)
+	}
+
+	statusBadge := router.Group("statusBadge")
+	{
+		statusBadge.GET("", ListStatusBadges)
+		statusBadge.POST("", CreateStatusBadge)
+		statusBadge.DELETE("/:id", DeleteStatusBadge)
+	}
+
+	router.GET("/badge/:token", GetStatusBadge)
+
 	// guanceyun api
 	guanceyun := router.Group("guanceyun")
 	{
@@ -423,6 +498,20 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		llm.GET("/integration/:id", GetLLMIntegration)
 		llm.PUT("/integration/:id", UpdateLLMIntegration)
 		llm.DELETE("/integration/:id", DeleteLLMIntegration)
+		llm.GET("/usage", GetLLMUsage)
+		llm.GET("/budget", ListLLMBudget)
+		llm.PUT("/budget/:projectName", SetLLMBudget)
+		llm.DELETE("/budget/:projectName", DeleteLLMBudget)
+	}
+
+	dns := router.Group("dns")
+	{
+		dns = dns.Group("", isSystemAdmin)
+		dns.GET("/integration", ListDNSIntegrations)
+		dns.POST("/integration", CreateDNSIntegration)
+		dns.GET("/integration/:id", GetDNSIntegration)
+		dns.PUT("/integration/:id", UpdateDNSIntegration)
+		dns.DELETE("/integration/:id", DeleteDNSIntegration)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -23,8 +23,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
 	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
 )
 
@@ -90,3 +92,74 @@ func GetSecuritySettings(c *gin.Context) {
 	}
 	ctx.Resp = resp
 }
+
+// @Summary Get Credential Scan Policy
+// @Description Get the policy of scanning plaintext credentials in workflows and environment configurations
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Success 200 	{object} 	commonmodels.CredentialScanPolicy
+// @Router /api/aslan/system/security/credentialScan [get]
+func GetCredentialScanPolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetCredentialScanPolicy(ctx.Logger)
+}
+
+// @Summary Update Credential Scan Policy
+// @Description Update the policy of scanning plaintext credentials in workflows and environment configurations
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	body 	body 		commonmodels.CredentialScanPolicy 	true 	"body"
+// @Success 200
+// @Router /api/aslan/system/security/credentialScan [put]
+func UpdateCredentialScanPolicy(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.CredentialScanPolicy)
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("update credential scan policy GetRawData err : %s", err)
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("update credential scan policy Unmarshal err : %s", err)
+	}
+	if before, err := service.GetCredentialScanPolicy(ctx.Logger); err == nil {
+		internalhandler.SetAuditLogPreviousSettings(c, before)
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "明文凭证扫描策略", fmt.Sprintf("enabled: %v, block on save: %v", args.Enabled, args.BlockOnSave), string(data), ctx.Logger)
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	ctx.RespErr = service.UpdateCredentialScanPolicy(args, ctx.Logger)
+}
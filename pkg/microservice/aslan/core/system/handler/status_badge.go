@@ -0,0 +1,165 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List status badges
+// @Description List the status badges of the project
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Success 200 		{array} 	commonmodels.StatusBadge
+// @Router /api/aslan/system/statusBadge [get]
+func ListStatusBadges(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+	if !checkStatusBadgePermission(ctx, projectName, false) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListStatusBadges(projectName, ctx.Logger)
+}
+
+// @Summary Create status badge
+// @Description Create a status badge of a workflow or an environment, the badge is accessed by the token without login
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	body 		body 		commonmodels.StatusBadge 			true 	"body"
+// @Success 200 		{object} 	commonmodels.StatusBadge
+// @Router /api/aslan/system/statusBadge [post]
+func CreateStatusBadge(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.StatusBadge)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if args.ProjectName == "" || args.Name == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("project_name and name can't be empty")
+		return
+	}
+	if !checkStatusBadgePermission(ctx, args.ProjectName, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, args.ProjectName, "新建", "状态徽章", fmt.Sprintf("%s:%s", args.Type, args.Name), "", ctx.Logger)
+
+	ctx.Resp, ctx.RespErr = service.CreateStatusBadge(ctx.UserName, args, ctx.Logger)
+}
+
+// @Summary Delete status badge
+// @Description Delete the status badge, the token of the badge is no longer valid
+// @Tags 	system
+// @Accept 	json
+// @Produce json
+// @Param 	id 			path		string							true	"badge id"
+// @Param 	projectName	query		string							true	"project name"
+// @Success 200
+// @Router /api/aslan/system/statusBadge/{id} [delete]
+func DeleteStatusBadge(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+	if !checkStatusBadgePermission(ctx, projectName, true) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "删除", "状态徽章", c.Param("id"), "", ctx.Logger)
+
+	ctx.RespErr = service.DeleteStatusBadge(projectName, c.Param("id"), ctx.Logger)
+}
+
+// @Summary Get status badge
+// @Description Get the SVG status badge by the token, no login is required
+// @Tags 	system
+// @Produce image/svg+xml
+// @Param 	token 		path		string							true	"badge token, the .svg suffix is optional"
+// @Success 200
+// @Router /api/aslan/system/badge/{token} [get]
+func GetStatusBadge(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+
+	svg, err := service.GetStatusBadgeSVG(strings.TrimSuffix(c.Param("token"), ".svg"), ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	// the badges are embedded in READMEs and dashboards, disable the cache so the status is always fresh
+	c.Writer.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "image/svg+xml", svg)
+}
+
+func checkStatusBadgePermission(ctx *internalhandler.Context, projectName string, edit bool) bool {
+	if ctx.Resources.IsSystemAdmin {
+		return true
+	}
+	authInfo, ok := ctx.Resources.ProjectAuthInfo[projectName]
+	if !ok {
+		return false
+	}
+	return !edit || authInfo.IsProjectAdmin
+}
@@ -101,3 +101,48 @@ func CleanSharedStorage(c *gin.Context) {
 
 	ctx.RespErr = service.CleanSharedStorage()
 }
+
+func GetLocalCacheUsage(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.GetLocalCacheUsage()
+}
+
+func CleanLocalCache(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(service.LocalCacheGCArgs)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(args); err != nil {
+			ctx.RespErr = err
+			return
+		}
+	}
+	ctx.Resp, ctx.RespErr = service.CleanLocalCache(args, ctx.Logger)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// AuditLog records a mutating api call, it is written for every call by the middleware while the
+// operation logs are only written by the handlers for the business operations.
+type AuditLog struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"               json:"id,omitempty"`
+	UserID       string             `bson:"user_id"                     json:"user_id"`
+	Username     string             `bson:"username"                    json:"username"`
+	Account      string             `bson:"account"                     json:"account"`
+	IdentityType string             `bson:"identity_type"               json:"identity_type"`
+	ClientIP     string             `bson:"client_ip"                   json:"client_ip"`
+	UserAgent    string             `bson:"user_agent"                  json:"user_agent"`
+	Method       string             `bson:"method"                      json:"method"`
+	// Route is the path template of the api, e.g. /api/environment/environments/:name
+	Route        string `bson:"route"                       json:"route"`
+	RequestURI   string `bson:"request_uri"                 json:"request_uri"`
+	ProjectName  string `bson:"project_name"                json:"project_name"`
+	Resource     string `bson:"resource"                    json:"resource"`
+	ResourceName string `bson:"resource_name"               json:"resource_name"`
+	StatusCode   int    `bson:"status_code"                 json:"status_code"`
+	RequestID    string `bson:"request_id"                  json:"request_id"`
+	// PreviousSettings is the system settings before the change, it's only recorded by the apis updating the
+	// audit log export and the credential scan settings and is empty for the others
+	PreviousSettings string `bson:"previous_settings,omitempty" json:"previous_settings,omitempty"`
+	// RequestBody is the json request body with the sensitive fields masked, the state of the resource after
+	// the change is not recorded
+	RequestBody string `bson:"request_body"                json:"request_body"`
+	// FreezeOverrideReason is the reason given to make production deployments during the deployment freeze
+	FreezeOverrideReason string `bson:"freeze_override_reason,omitempty" json:"freeze_override_reason,omitempty"`
+	CreatedAt            int64  `bson:"created_at"                  json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	models2 "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type AuditLogArgs struct {
+	UserID       string `form:"userID"`
+	Username     string `form:"username"`
+	ProjectName  string `form:"projectName"`
+	Method       string `form:"method"`
+	Resource     string `form:"resource"`
+	ResourceName string `form:"resourceName"`
+	ClientIP     string `form:"clientIP"`
+	// Failed only returns the calls with status code >= 400
+	Failed    bool  `form:"failed"`
+	StartTime int64 `form:"startTime"`
+	EndTime   int64 `form:"endTime"`
+	Page      int   `form:"page"`
+	PerPage   int   `form:"perPage"`
+}
+
+type AuditLogColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewAuditLogColl() *AuditLogColl {
+	name := models2.AuditLog{}.TableName()
+	return &AuditLogColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *AuditLogColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *AuditLogColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "user_id", Value: 1},
+				bson.E{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *AuditLogColl) Insert(args *models2.AuditLog) error {
+	if args == nil {
+		return errors.New("nil audit_log args")
+	}
+
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+func (c *AuditLogColl) Find(args *AuditLogArgs) ([]*models2.AuditLog, int, error) {
+	res := make([]*models2.AuditLog, 0)
+	query := bson.M{}
+	if args.UserID != "" {
+		query["user_id"] = args.UserID
+	}
+	if args.Username != "" {
+		query["username"] = bson.M{"$regex": args.Username}
+	}
+	if args.ProjectName != "" {
+		query["project_name"] = args.ProjectName
+	}
+	if args.Method != "" {
+		query["method"] = args.Method
+	}
+	if args.Resource != "" {
+		query["resource"] = args.Resource
+	}
+	if args.ResourceName != "" {
+		query["resource_name"] = args.ResourceName
+	}
+	if args.ClientIP != "" {
+		query["client_ip"] = args.ClientIP
+	}
+	if args.Failed {
+		query["status_code"] = bson.M{"$gte": 400}
+	}
+	timeQuery := bson.M{}
+	if args.StartTime > 0 {
+		timeQuery["$gte"] = args.StartTime
+	}
+	if args.EndTime > 0 {
+		timeQuery["$lte"] = args.EndTime
+	}
+	if len(timeQuery) > 0 {
+		query["created_at"] = timeQuery
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{"created_at", -1}})
+	if args.Page > 0 && args.PerPage > 0 {
+		opts.SetSkip(int64(args.PerPage * (args.Page - 1))).SetLimit(int64(args.PerPage))
+	}
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := c.CountDocuments(context.TODO(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, int(count), nil
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net/url"
+	"sync"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/httpclient"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const auditLogSignatureHeader = "X-Zadig-Signature"
+
+type ListAuditLogsResp struct {
+	Logs  []*models.AuditLog `json:"logs"`
+	Total int                `json:"total"`
+}
+
+// syslog writers are kept open between the audit logs, they are recreated when the destination changes
+var (
+	auditSyslogMutex  sync.Mutex
+	auditSyslogWriter *syslog.Writer
+	auditSyslogTarget string
+)
+
+// RecordAuditLog saves the audit log and forwards it to the SIEM systems configured in the export policy
+func RecordAuditLog(auditLog *models.AuditLog, logger *zap.SugaredLogger) {
+	if err := mongodb.NewAuditLogColl().Insert(auditLog); err != nil {
+		logger.Errorf("failed to insert audit log of %s %s, error: %s", auditLog.Method, auditLog.RequestURI, err)
+	}
+
+	go exportAuditLog(auditLog)
+}
+
+func ListAuditLogs(args *mongodb.AuditLogArgs, logger *zap.SugaredLogger) (*ListAuditLogsResp, error) {
+	logs, total, err := mongodb.NewAuditLogColl().Find(args)
+	if err != nil {
+		logger.Errorf("failed to list audit logs, error: %s", err)
+		return nil, e.ErrListAuditLog.AddErr(err)
+	}
+	return &ListAuditLogsResp{
+		Logs:  logs,
+		Total: total,
+	}, nil
+}
+
+func GetAuditLogExportPolicy(logger *zap.SugaredLogger) (*commonmodels.AuditLogExportPolicy, error) {
+	systemSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		logger.Errorf("failed to get system settings, error: %s", err)
+		return nil, e.ErrGetAuditLogExportPolicy.AddErr(err)
+	}
+	if systemSetting.AuditLogExport == nil {
+		return &commonmodels.AuditLogExportPolicy{}, nil
+	}
+	return systemSetting.AuditLogExport, nil
+}
+
+func UpdateAuditLogExportPolicy(args *commonmodels.AuditLogExportPolicy, logger *zap.SugaredLogger) error {
+	if args.SyslogEnabled {
+		if args.SyslogNetwork != "udp" && args.SyslogNetwork != "tcp" {
+			return e.ErrUpdateAuditLogExportPolicy.AddDesc("syslog network must be udp or tcp")
+		}
+		if args.SyslogAddress == "" {
+			return e.ErrUpdateAuditLogExportPolicy.AddDesc("syslog address can't be empty")
+		}
+	}
+	if args.WebhookEnabled {
+		if u, err := url.Parse(args.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return e.ErrUpdateAuditLogExportPolicy.AddDesc("invalid webhook url")
+		}
+	}
+
+	err := commonrepo.NewSystemSettingColl().UpdateAuditLogExportPolicy(args)
+	if err != nil {
+		logger.Errorf("failed to update audit log export policy, error: %s", err)
+		return e.ErrUpdateAuditLogExportPolicy.AddErr(err)
+	}
+	return nil
+}
+
+func exportAuditLog(auditLog *models.AuditLog) {
+	logger := log.SugaredLogger().With("service", "exportAuditLog")
+
+	systemSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		logger.Errorf("failed to get system settings, error: %s", err)
+		return
+	}
+	policy := systemSetting.AuditLogExport
+	if policy == nil || (!policy.SyslogEnabled && !policy.WebhookEnabled) {
+		return
+	}
+
+	body, err := json.Marshal(auditLog)
+	if err != nil {
+		logger.Errorf("failed to marshal audit log, error: %s", err)
+		return
+	}
+
+	if policy.SyslogEnabled {
+		if err := writeAuditSyslog(policy.SyslogNetwork, policy.SyslogAddress, string(body)); err != nil {
+			logger.Errorf("failed to send audit log to syslog %s://%s, error: %s", policy.SyslogNetwork, policy.SyslogAddress, err)
+		}
+	}
+
+	if policy.WebhookEnabled {
+		headers := map[string]string{"Content-Type": "application/json"}
+		if policy.WebhookSecret != "" {
+			mac := hmac.New(sha256.New, []byte(policy.WebhookSecret))
+			mac.Write(body)
+			headers[auditLogSignatureHeader] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		}
+		if _, err := httpclient.Post(policy.WebhookURL, httpclient.SetHeaders(headers), httpclient.SetBody(body)); err != nil {
+			logger.Errorf("failed to send audit log to webhook, error: %s", err)
+		}
+	}
+}
+
+func writeAuditSyslog(network, address, message string) error {
+	auditSyslogMutex.Lock()
+	defer auditSyslogMutex.Unlock()
+
+	target := network + "://" + address
+	if auditSyslogWriter == nil || auditSyslogTarget != target {
+		if auditSyslogWriter != nil {
+			_ = auditSyslogWriter.Close()
+			auditSyslogWriter = nil
+		}
+		writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, "zadig-audit")
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog, error: %s", err)
+		}
+		auditSyslogWriter = writer
+		auditSyslogTarget = target
+	}
+
+	if err := auditSyslogWriter.Info(message); err != nil {
+		// the connection is dropped, reconnect on the next audit log
+		_ = auditSyslogWriter.Close()
+		auditSyslogWriter = nil
+		return err
+	}
+	return nil
+}
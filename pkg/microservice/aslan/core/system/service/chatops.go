@@ -0,0 +1,420 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/v2/pkg/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/dingtalk"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/lark"
+	service2 "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	workflowservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/shared/client/user"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/types"
+)
+
+const (
+	chatOpsCommandPrefix = "/zadig"
+	// dingTalkRobotSignTimeout is the max time difference allowed between the dingtalk robot request and the server
+	dingTalkRobotSignTimeout = time.Hour
+)
+
+var chatOpsHelp = []string{
+	"**/zadig run workflow** <workflow> [key=value ...]: 执行工作流，可通过 key=value 指定工作流变量，代码库类型的变量值为分支",
+	"**/zadig env sleep** <env> [project]: 睡眠环境，环境名不唯一时需指定项目",
+	"**/zadig env wakeup** <env> [project]: 唤醒环境，环境名不唯一时需指定项目",
+	"**/zadig help**: 查看帮助",
+}
+
+type BindChatOpsUserArgs struct {
+	IMAppID string `json:"im_app_id"`
+}
+
+// ChatOpsReply is the result of a chatops command, it's rendered as a card in lark and a markdown message in dingtalk
+type ChatOpsReply struct {
+	Title  string
+	Status config.Status
+	Lines  []string
+	URL    string
+}
+
+type DingTalkRobotMessage struct {
+	MsgType       string `json:"msgtype"`
+	SenderStaffID string `json:"senderStaffId"`
+	Text          struct {
+		Content string `json:"content"`
+	} `json:"text"`
+}
+
+type DingTalkRobotReply struct {
+	MsgType  string                 `json:"msgtype"`
+	Markdown *DingTalkRobotMarkdown `json:"markdown"`
+}
+
+type DingTalkRobotMarkdown struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// BindChatOpsUser binds the user of the IM app found by the email (lark) or phone (dingtalk) of the zadig user,
+// so that a user can't bind the chat account of others.
+func BindChatOpsUser(userID string, args *BindChatOpsUserArgs, log *zap.SugaredLogger) (*commonmodels.ChatOpsUserBinding, error) {
+	imApp, err := commonrepo.NewIMAppColl().GetByID(context.Background(), args.IMAppID)
+	if err != nil {
+		log.Errorf("failed to find im app %s, error: %v", args.IMAppID, err)
+		return nil, e.ErrBindChatOpsUser.AddErr(err)
+	}
+
+	userInfo, err := user.New().GetUserByID(userID)
+	if err != nil || userInfo == nil {
+		log.Errorf("failed to find user %s, error: %v", userID, err)
+		return nil, e.ErrBindChatOpsUser.AddDesc("failed to find user info")
+	}
+
+	var chatUserID string
+	switch imApp.Type {
+	case setting.IMLark:
+		if userInfo.Email == "" {
+			return nil, e.ErrBindChatOpsUser.AddDesc("email of the user is required to bind lark user")
+		}
+		chatUserID, err = lark.GetLarkUserID(imApp.ID.Hex(), "email", userInfo.Email, setting.LarkUserOpenID)
+	case setting.IMDingTalk:
+		if userInfo.Phone == "" {
+			return nil, e.ErrBindChatOpsUser.AddDesc("phone of the user is required to bind dingtalk user")
+		}
+		chatUserID, err = dingtalk.GetDingTalkUserIDByMobile(imApp.ID.Hex(), userInfo.Phone)
+	default:
+		return nil, e.ErrBindChatOpsUser.AddDesc(fmt.Sprintf("im app type %s is not supported by chatops", imApp.Type))
+	}
+	if err != nil {
+		log.Errorf("failed to find the %s user of user %s, error: %v", imApp.Type, userInfo.Name, err)
+		return nil, e.ErrBindChatOpsUser.AddErr(err)
+	}
+	if chatUserID == "" {
+		return nil, e.ErrBindChatOpsUser.AddDesc(fmt.Sprintf("%s user of user %s not found", imApp.Type, userInfo.Name))
+	}
+
+	binding := &commonmodels.ChatOpsUserBinding{
+		IMAppID:    imApp.ID.Hex(),
+		IMAppType:  imApp.Type,
+		ChatUserID: chatUserID,
+		UserID:     userID,
+		UserName:   userInfo.Name,
+	}
+	if err := commonrepo.NewChatOpsUserBindingColl().Upsert(binding); err != nil {
+		log.Errorf("failed to bind %s user %s to user %s, error: %v", imApp.Type, chatUserID, userInfo.Name, err)
+		return nil, e.ErrBindChatOpsUser.AddErr(err)
+	}
+	return binding, nil
+}
+
+func ListChatOpsUserBindings(userID string, log *zap.SugaredLogger) ([]*commonmodels.ChatOpsUserBinding, error) {
+	bindings, err := commonrepo.NewChatOpsUserBindingColl().ListByUserID(userID)
+	if err != nil {
+		log.Errorf("failed to list chatops bindings of user %s, error: %v", userID, err)
+		return nil, e.ErrListChatOpsUser.AddErr(err)
+	}
+	return bindings, nil
+}
+
+func UnbindChatOpsUser(userID, id string, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewChatOpsUserBindingColl().Delete(userID, id); err != nil {
+		log.Errorf("failed to delete chatops binding %s of user %s, error: %v", id, userID, err)
+		return e.ErrUnbindChatOpsUser.AddErr(err)
+	}
+	return nil
+}
+
+// HandleLarkChatOpsMessage runs the command in the message and replies a card to the chat
+func HandleLarkChatOpsMessage(msg *lark.MessageEvent, log *zap.SugaredLogger) {
+	reply := executeChatOpsCommand(msg.IMAppID, msg.OpenID, msg.Text, log)
+	if reply == nil {
+		return
+	}
+
+	client, err := lark.GetLarkClientByIMAppID(msg.IMAppID)
+	if err != nil {
+		log.Errorf("failed to get lark client of im app %s, error: %v", msg.IMAppID, err)
+		return
+	}
+
+	card := instantmessage.NewLarkStatusCard(reply.Status, reply.Title, reply.Lines, "点击查看更多信息", reply.URL)
+	content, err := json.Marshal(card)
+	if err != nil {
+		log.Errorf("failed to marshal chatops reply card, error: %v", err)
+		return
+	}
+	if err := client.SendMessage(instantmessage.LarkReceiverTypeChat, instantmessage.LarkMessageTypeCard, msg.ChatID, string(content)); err != nil {
+		log.Errorf("failed to send chatops reply to lark chat %s, error: %v", msg.ChatID, err)
+	}
+}
+
+// HandleDingTalkChatOpsMessage handles the message of dingtalk outgoing robot, the reply is returned in the response
+func HandleDingTalkChatOpsMessage(imAppID, timestamp, sign string, body []byte, log *zap.SugaredLogger) (*DingTalkRobotReply, error) {
+	imApp, err := commonrepo.NewIMAppColl().GetByID(context.Background(), imAppID)
+	if err != nil {
+		log.Errorf("failed to find im app %s, error: %v", imAppID, err)
+		return nil, e.ErrChatOpsCommand.AddErr(err)
+	}
+	if imApp.Type != setting.IMDingTalk {
+		return nil, e.ErrChatOpsCommand.AddDesc(fmt.Sprintf("unexpected im app type %s", imApp.Type))
+	}
+	if err := checkDingTalkRobotSign(timestamp, sign, imApp.DingTalkAppSecret); err != nil {
+		log.Warnf("invalid dingtalk robot request of im app %s: %v", imAppID, err)
+		return nil, e.ErrChatOpsCommand.AddErr(err)
+	}
+
+	msg := &DingTalkRobotMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, e.ErrChatOpsCommand.AddErr(err)
+	}
+	if msg.MsgType != "text" {
+		return nil, nil
+	}
+
+	reply := executeChatOpsCommand(imAppID, msg.SenderStaffID, strings.TrimSpace(msg.Text.Content), log)
+	if reply == nil {
+		return nil, nil
+	}
+
+	text := fmt.Sprintf("### %s\n\n%s", reply.Title, strings.Join(reply.Lines, "\n\n"))
+	if reply.URL != "" {
+		text += fmt.Sprintf("\n\n[点击查看更多信息](%s)", reply.URL)
+	}
+	return &DingTalkRobotReply{
+		MsgType: "markdown",
+		Markdown: &DingTalkRobotMarkdown{
+			Title: reply.Title,
+			Text:  text,
+		},
+	}, nil
+}
+
+// checkDingTalkRobotSign verifies the sign of the dingtalk outgoing robot request: base64(hmac-sha256(timestamp + "\n" + secret))
+func checkDingTalkRobotSign(timestamp, sign, secret string) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "invalid timestamp")
+	}
+	if diff := time.Since(time.UnixMilli(ts)); diff > dingTalkRobotSignTimeout || diff < -dingTalkRobotSignTimeout {
+		return errors.New("request expired")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(sign)) {
+		return errors.New("sign mismatch")
+	}
+	return nil
+}
+
+// executeChatOpsCommand returns nil if the text is not a chatops command
+func executeChatOpsCommand(imAppID, chatUserID, text string, log *zap.SugaredLogger) *ChatOpsReply {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || fields[0] != chatOpsCommandPrefix {
+		return nil
+	}
+	fields = fields[1:]
+
+	if len(fields) == 0 || fields[0] == "help" {
+		return &ChatOpsReply{Title: "Zadig 命令帮助", Status: config.StatusPassed, Lines: chatOpsHelp}
+	}
+
+	binding, err := commonrepo.NewChatOpsUserBindingColl().Find(imAppID, chatUserID)
+	if err != nil {
+		return &ChatOpsReply{
+			Title:  "用户未绑定",
+			Status: config.StatusFailed,
+			Lines:  []string{"请先在 Zadig 个人中心绑定 IM 账号后再执行命令"},
+		}
+	}
+
+	switch {
+	case len(fields) >= 3 && fields[0] == "run" && fields[1] == "workflow":
+		return runChatOpsWorkflow(binding, fields[2], fields[3:], log)
+	case len(fields) >= 3 && fields[0] == "env" && (fields[1] == "sleep" || fields[1] == "wakeup"):
+		projectName := ""
+		if len(fields) > 3 {
+			projectName = fields[3]
+		}
+		return switchChatOpsEnvSleep(binding, projectName, fields[2], fields[1] == "sleep", log)
+	default:
+		return &ChatOpsReply{
+			Title:  "无法识别的命令",
+			Status: config.StatusFailed,
+			Lines:  append([]string{fmt.Sprintf("命令 `%s` 无法识别，支持的命令如下:", text)}, chatOpsHelp...),
+		}
+	}
+}
+
+func runChatOpsWorkflow(binding *commonmodels.ChatOpsUserBinding, workflowName string, args []string, log *zap.SugaredLogger) *ChatOpsReply {
+	title := fmt.Sprintf("执行工作流 %s", workflowName)
+	failed := func(err error) *ChatOpsReply {
+		return &ChatOpsReply{Title: title, Status: config.StatusFailed, Lines: []string{fmt.Sprintf("执行失败: %s", err)}}
+	}
+
+	params := make(map[string]string)
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return failed(fmt.Errorf("invalid param %s, param should be in the format of key=value", arg))
+		}
+		params[kv[0]] = kv[1]
+	}
+
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(workflowName)
+	if err != nil {
+		return failed(fmt.Errorf("workflow %s not found", workflowName))
+	}
+
+	permitted, err := chatOpsPermitted(binding.UserID, workflow.Project, func(authInfo *user.ProjectActions) bool {
+		return authInfo.Workflow != nil && authInfo.Workflow.Execute
+	}, func() (bool, error) {
+		return internalhandler.GetCollaborationModePermission(binding.UserID, workflow.Project, types.ResourceTypeWorkflow, workflow.Name, types.WorkflowActionRun)
+	})
+	if err != nil {
+		return failed(err)
+	}
+	if !permitted {
+		return failed(fmt.Errorf("user %s has no permission to run workflow %s", binding.UserName, workflowName))
+	}
+
+	resp, err := workflowservice.CreateChatOpsWorkflowTask(workflow, binding.UserID, binding.UserName, params, log)
+	if err != nil {
+		log.Errorf("chatops: failed to run workflow %s by user %s, error: %v", workflowName, binding.UserName, err)
+		return failed(err)
+	}
+
+	lines := []string{
+		fmt.Sprintf("**项目名称**: %s", workflow.Project),
+		fmt.Sprintf("**工作流名称**: %s", workflow.DisplayName),
+		fmt.Sprintf("**任务编号**: #%d", resp.TaskID),
+		fmt.Sprintf("**执行用户**: %s", binding.UserName),
+	}
+	for _, arg := range args {
+		lines = append(lines, fmt.Sprintf("**变量**: %s", arg))
+	}
+	return &ChatOpsReply{
+		Title:  title,
+		Status: config.StatusCreated,
+		Lines:  lines,
+		URL: fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d?display_name=%s",
+			configbase.SystemAddress(), workflow.Project, workflow.Name, resp.TaskID, url.PathEscape(workflow.DisplayName)),
+	}
+}
+
+func switchChatOpsEnvSleep(binding *commonmodels.ChatOpsUserBinding, projectName, envName string, sleep bool, log *zap.SugaredLogger) *ChatOpsReply {
+	method := "唤醒"
+	if sleep {
+		method = "睡眠"
+	}
+	title := fmt.Sprintf("%s环境 %s", method, envName)
+	failed := func(err error) *ChatOpsReply {
+		return &ChatOpsReply{Title: title, Status: config.StatusFailed, Lines: []string{fmt.Sprintf("%s失败: %s", method, err)}}
+	}
+
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{Name: projectName, EnvName: envName})
+	if err != nil {
+		return failed(err)
+	}
+	if len(envs) == 0 {
+		return failed(fmt.Errorf("env %s not found", envName))
+	}
+	if len(envs) > 1 {
+		projects := make([]string, 0, len(envs))
+		for _, env := range envs {
+			projects = append(projects, env.ProductName)
+		}
+		return failed(fmt.Errorf("env %s exists in projects %s, please specify the project", envName, strings.Join(projects, ", ")))
+	}
+	env := envs[0]
+
+	permitted, err := chatOpsPermitted(binding.UserID, env.ProductName, func(authInfo *user.ProjectActions) bool {
+		if env.Production {
+			return authInfo.ProductionEnv != nil && authInfo.ProductionEnv.EditConfig
+		}
+		return authInfo.Env != nil && authInfo.Env.EditConfig
+	}, func() (bool, error) {
+		if env.Production {
+			return internalhandler.CheckPermissionGivenByCollaborationMode(binding.UserID, env.ProductName, types.ResourceTypeEnvironment, types.ProductionEnvActionEditConfig)
+		}
+		return internalhandler.CheckPermissionGivenByCollaborationMode(binding.UserID, env.ProductName, types.ResourceTypeEnvironment, types.EnvActionEditConfig)
+	})
+	if err != nil {
+		return failed(err)
+	}
+	if !permitted {
+		return failed(fmt.Errorf("user %s has no permission to %s env %s", binding.UserName, method, envName))
+	}
+
+	if err := service2.EnvSleep(env.ProductName, env.EnvName, sleep, env.Production, log); err != nil {
+		log.Errorf("chatops: failed to %s env %s/%s by user %s, error: %v", method, env.ProductName, env.EnvName, binding.UserName, err)
+		return failed(err)
+	}
+
+	return &ChatOpsReply{
+		Title:  title,
+		Status: config.StatusPassed,
+		Lines: []string{
+			fmt.Sprintf("**项目名称**: %s", env.ProductName),
+			fmt.Sprintf("**环境名称**: %s", env.EnvName),
+			fmt.Sprintf("**执行用户**: %s", binding.UserName),
+		},
+	}
+}
+
+// chatOpsPermitted checks the permission of the zadig user the same way as the handlers, the commands are not
+// sent through the gateway so the authorization info is fetched here.
+func chatOpsPermitted(userID, projectName string, projectCheck func(*user.ProjectActions) bool, collaborationCheck func() (bool, error)) (bool, error) {
+	authInfo, err := user.New().GetUserAuthInfo(userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get authorization info of user: %s", err)
+	}
+	if authInfo.IsSystemAdmin {
+		return true, nil
+	}
+	projectAuthInfo, ok := authInfo.ProjectAuthInfo[projectName]
+	if !ok {
+		return false, nil
+	}
+	if projectAuthInfo.IsProjectAdmin || projectCheck(projectAuthInfo) {
+		return true, nil
+	}
+	permitted, err := collaborationCheck()
+	return err == nil && permitted, nil
+}
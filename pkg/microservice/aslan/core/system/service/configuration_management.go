@@ -20,6 +20,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/setting"
 	"github.com/koderover/zadig/v2/pkg/tool/apollo"
 	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/featureflag"
 )
 
 func ListConfigurationManagement(_type string, log *zap.SugaredLogger) ([]*commonmodels.ConfigurationManagement, error) {
@@ -104,6 +105,8 @@ func ValidateConfigurationManagement(rawData string, log *zap.SugaredLogger) err
 		return validateApolloAuthConfig(getApolloConfigFromRaw(rawData))
 	case setting.SourceFromNacos:
 		return validateNacosAuthConfig(getNacosConfigFromRaw(rawData))
+	case setting.SourceFromLaunchDarkly, setting.SourceFromUnleash, setting.SourceFromFlagsmith:
+		return validateFeatureFlagAuthConfig(getFeatureFlagConfigFromRaw(rawData))
 	default:
 		return e.ErrInvalidParam.AddDesc("invalid type")
 	}
@@ -151,6 +154,17 @@ func validateNacosAuthConfig(config *commonmodels.NacosConfig) error {
 	return nil
 }
 
+func validateFeatureFlagAuthConfig(config *commonmodels.FeatureFlagConfig) error {
+	client, err := featureflag.NewClient(config.Type, config.ServerAddress, config.Token)
+	if err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if err := client.Validate(); err != nil {
+		return e.ErrValidateConfigurationManagement.AddErr(err)
+	}
+	return nil
+}
+
 func getApolloConfigFromRaw(raw string) *commonmodels.ApolloConfig {
 	return &commonmodels.ApolloConfig{
 		ServerAddress: gjson.Get(raw, "server_address").String(),
@@ -170,6 +184,16 @@ func getNacosConfigFromRaw(raw string) *commonmodels.NacosConfig {
 	}
 }
 
+func getFeatureFlagConfigFromRaw(raw string) *commonmodels.FeatureFlagConfig {
+	return &commonmodels.FeatureFlagConfig{
+		Type:          gjson.Get(raw, "type").String(),
+		ServerAddress: gjson.Get(raw, "server_address").String(),
+		FeatureFlagAuthConfig: &commonmodels.FeatureFlagAuthConfig{
+			Token: gjson.Get(raw, "auth_config.token").String(),
+		},
+	}
+}
+
 func marshalConfigurationManagementAuthConfig(management *commonmodels.ConfigurationManagement) error {
 	rawData, err := json.Marshal(management.AuthConfig)
 	if err != nil {
@@ -188,6 +212,10 @@ func marshalConfigurationManagementAuthConfig(management *commonmodels.Configura
 			UserName: gjson.Get(rawJson, "user_name").String(),
 			Password: gjson.Get(rawJson, "password").String(),
 		}
+	case setting.SourceFromLaunchDarkly, setting.SourceFromUnleash, setting.SourceFromFlagsmith:
+		management.AuthConfig = &commonmodels.FeatureFlagAuthConfig{
+			Token: gjson.Get(rawJson, "token").String(),
+		}
 	default:
 		return errors.New("marshal auth config: invalid type")
 	}
@@ -195,7 +223,7 @@ func marshalConfigurationManagementAuthConfig(management *commonmodels.Configura
 }
 
 func validateConfigurationManagementType(management *commonmodels.ConfigurationManagement) error {
-	if management.Type != setting.SourceFromApollo && management.Type != setting.SourceFromNacos {
+	if management.Type != setting.SourceFromApollo && management.Type != setting.SourceFromNacos && !commonmodels.IsFeatureFlagType(management.Type) {
 		return errors.New("invalid type")
 	}
 	return nil
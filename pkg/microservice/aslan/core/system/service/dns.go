@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"text/template"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/dns"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+func ListDNSIntegrations() ([]*commonmodels.DNSIntegration, error) {
+	resp, err := commonrepo.NewDNSIntegrationColl().List()
+	if err != nil {
+		log.Errorf("failed to list dns integrations, err: %s", err)
+		return nil, e.ErrListDNSIntegration.AddErr(err)
+	}
+	for _, integration := range resp {
+		maskDNSIntegrationSecrets(integration)
+	}
+	return resp, nil
+}
+
+func GetDNSIntegration(id string) (*commonmodels.DNSIntegration, error) {
+	resp, err := commonrepo.NewDNSIntegrationColl().GetByID(id)
+	if err != nil {
+		return nil, e.ErrListDNSIntegration.AddErr(err)
+	}
+	maskDNSIntegrationSecrets(resp)
+	return resp, nil
+}
+
+func CreateDNSIntegration(args *commonmodels.DNSIntegration) error {
+	if err := validateDNSIntegration(args); err != nil {
+		return e.ErrCreateDNSIntegration.AddErr(err)
+	}
+
+	if err := commonrepo.NewDNSIntegrationColl().Create(args); err != nil {
+		log.Errorf("failed to create dns integration, err: %s", err)
+		return e.ErrCreateDNSIntegration.AddErr(err)
+	}
+	return nil
+}
+
+// UpdateDNSIntegration updates the integration, the stored secrets are kept if the masked values are passed
+func UpdateDNSIntegration(id string, args *commonmodels.DNSIntegration) error {
+	cur, err := commonrepo.NewDNSIntegrationColl().GetByID(id)
+	if err != nil {
+		return e.ErrUpdateDNSIntegration.AddErr(err)
+	}
+	if args.AccessKeySecret == setting.MaskValue {
+		args.AccessKeySecret = cur.AccessKeySecret
+	}
+	if args.Credentials == setting.MaskValue {
+		args.Credentials = cur.Credentials
+	}
+	if err := validateDNSIntegration(args); err != nil {
+		return e.ErrUpdateDNSIntegration.AddErr(err)
+	}
+
+	if err := commonrepo.NewDNSIntegrationColl().Update(id, args); err != nil {
+		log.Errorf("failed to update dns integration %s, err: %s", id, err)
+		return e.ErrUpdateDNSIntegration.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteDNSIntegration(id string) error {
+	if err := commonrepo.NewDNSIntegrationColl().Delete(id); err != nil {
+		log.Errorf("failed to delete dns integration %s, err: %s", id, err)
+		return e.ErrDeleteDNSIntegration.AddErr(err)
+	}
+	return nil
+}
+
+// maskDNSIntegrationSecrets hides the cloud credentials of the integration in the responses
+func maskDNSIntegrationSecrets(integration *commonmodels.DNSIntegration) {
+	if integration.AccessKeySecret != "" {
+		integration.AccessKeySecret = setting.MaskValue
+	}
+	if integration.Credentials != "" {
+		integration.Credentials = setting.MaskValue
+	}
+}
+
+func validateDNSIntegration(args *commonmodels.DNSIntegration) error {
+	_, err := dns.NewClient(&dns.Config{
+		Provider:        args.Provider,
+		Zone:            args.Zone,
+		AccessKeyID:     args.AccessKeyID,
+		AccessKeySecret: args.AccessKeySecret,
+		Region:          args.Region,
+		ProjectID:       args.GCPProjectID,
+		Credentials:     args.Credentials,
+	})
+	if err != nil {
+		return err
+	}
+
+	projects := make(map[string]bool)
+	for _, rule := range args.Rules {
+		if rule.ProjectName == "" || rule.Target == "" {
+			return fmt.Errorf("project and target of the hostname rule can not be empty")
+		}
+		if projects[rule.ProjectName] {
+			return fmt.Errorf("duplicated hostname rules of project %s", rule.ProjectName)
+		}
+		projects[rule.ProjectName] = true
+		if rule.RecordType != "" && rule.RecordType != dns.RecordTypeA && rule.RecordType != dns.RecordTypeCNAME {
+			return fmt.Errorf("unsupported record type %s", rule.RecordType)
+		}
+		if _, err := template.New("hostname").Parse(rule.HostnameTemplate); err != nil || rule.HostnameTemplate == "" {
+			return fmt.Errorf("invalid hostname template of project %s", rule.ProjectName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/cosign"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// ListImageSigningKeys returns the keys without the secrets if not detail
+func ListImageSigningKeys(detail bool, log *zap.SugaredLogger) ([]*commonmodels.ImageSigningKey, error) {
+	keys, err := commonrepo.NewImageSigningKeyColl().List()
+	if err != nil {
+		log.Errorf("failed to list image signing keys, error: %v", err)
+		return nil, e.ErrListImageSigningKey.AddErr(err)
+	}
+	if !detail {
+		for _, key := range keys {
+			key.PrivateKey = ""
+			key.Password = ""
+			key.IdentityToken = ""
+		}
+	}
+	return keys, nil
+}
+
+func CreateImageSigningKey(username string, args *commonmodels.ImageSigningKey, log *zap.SugaredLogger) error {
+	if err := validateImageSigningKey(args); err != nil {
+		return e.ErrCreateImageSigningKey.AddErr(err)
+	}
+	args.UpdateBy = username
+	if err := commonrepo.NewImageSigningKeyColl().Create(args); err != nil {
+		log.Errorf("failed to create image signing key %s, error: %v", args.Name, err)
+		return e.ErrCreateImageSigningKey.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateImageSigningKey(username, id string, args *commonmodels.ImageSigningKey, log *zap.SugaredLogger) error {
+	if err := validateImageSigningKey(args); err != nil {
+		return e.ErrUpdateImageSigningKey.AddErr(err)
+	}
+	args.UpdateBy = username
+	if err := commonrepo.NewImageSigningKeyColl().Update(id, args); err != nil {
+		log.Errorf("failed to update image signing key %s, error: %v", id, err)
+		return e.ErrUpdateImageSigningKey.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteImageSigningKey(id string, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewImageSigningKeyColl().DeleteByID(id); err != nil {
+		log.Errorf("failed to delete image signing key %s, error: %v", id, err)
+		return e.ErrDeleteImageSigningKey.AddErr(err)
+	}
+	return nil
+}
+
+func validateImageSigningKey(args *commonmodels.ImageSigningKey) error {
+	if args.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch args.Mode {
+	case cosign.ModeKey:
+		if args.PrivateKey == "" || args.PublicKey == "" {
+			return fmt.Errorf("private key and public key are required in %s mode", cosign.ModeKey)
+		}
+	case cosign.ModeKeyless:
+		if args.IdentityToken == "" || args.CertificateIdentity == "" || args.CertificateOIDCIssuer == "" {
+			return fmt.Errorf("identity token, certificate identity and oidc issuer are required in %s mode", cosign.ModeKeyless)
+		}
+	default:
+		return fmt.Errorf("invalid mode: %s", args.Mode)
+	}
+	return nil
+}
@@ -18,7 +18,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
 
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
@@ -70,8 +74,12 @@ func CreateLLMIntegration(ctx context.Context, args *commonmodels.LLMIntegration
 		log.Error(fmtErr)
 		return e.ErrCreateLLMIntegration.AddErr(fmtErr)
 	}
-	if count > 0 {
-		return e.ErrCreateLLMIntegration.AddDesc("llm integration already exists")
+	// the first integration is always the default one
+	if count == 0 {
+		args.IsDefault = true
+	}
+	if err := checkLLMIntegrationName(ctx, "", args.Name); err != nil {
+		return e.ErrCreateLLMIntegration.AddErr(err)
 	}
 
 	if err := commonrepo.NewLLMIntegrationColl().Create(ctx, args); err != nil {
@@ -79,15 +87,126 @@ func CreateLLMIntegration(ctx context.Context, args *commonmodels.LLMIntegration
 		log.Error(fmtErr)
 		return e.ErrCreateLLMIntegration.AddErr(fmtErr)
 	}
+
+	if err := syncLLMIntegrationSelection(ctx, args); err != nil {
+		log.Error(err)
+		return e.ErrCreateLLMIntegration.AddErr(err)
+	}
 	return nil
 }
 
 func UpdateLLMIntegration(ctx context.Context, ID string, args *commonmodels.LLMIntegration) error {
+	if err := checkLLMIntegrationName(ctx, ID, args.Name); err != nil {
+		return e.ErrUpdateLLMIntegration.AddErr(err)
+	}
+
+	// the default integration can only be changed by setting another integration as default
+	if !args.IsDefault {
+		defaultIntegration, err := commonrepo.NewLLMIntegrationColl().FindDefault(ctx)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			fmtErr := fmt.Errorf("find default llm integration err: %w", err)
+			log.Error(fmtErr)
+			return e.ErrUpdateLLMIntegration.AddErr(fmtErr)
+		}
+		if err != nil || defaultIntegration.ID.Hex() == ID {
+			args.IsDefault = true
+		}
+	}
+
 	if err := commonrepo.NewLLMIntegrationColl().Update(ctx, ID, args); err != nil {
 		fmtErr := fmt.Errorf("UpdateLLMIntegration err: %w", err)
 		log.Error(fmtErr)
 		return e.ErrUpdateLLMIntegration.AddErr(fmtErr)
 	}
+
+	if err := syncLLMIntegrationSelection(ctx, args); err != nil {
+		log.Error(err)
+		return e.ErrUpdateLLMIntegration.AddErr(err)
+	}
+	return nil
+}
+
+func checkLLMIntegrationName(ctx context.Context, ID, name string) error {
+	if name == "" {
+		return nil
+	}
+	integration, err := commonrepo.NewLLMIntegrationColl().FindByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return fmt.Errorf("find llm integration %s err: %w", name, err)
+	}
+	if integration.ID.Hex() != ID {
+		return fmt.Errorf("llm integration %s already exists", name)
+	}
+	return nil
+}
+
+// syncLLMIntegrationSelection makes sure there is only one default integration and each feature is served by one
+// integration after the integration is saved
+func syncLLMIntegrationSelection(ctx context.Context, args *commonmodels.LLMIntegration) error {
+	if args.IsDefault {
+		if err := commonrepo.NewLLMIntegrationColl().UnsetDefault(ctx, args.ID); err != nil {
+			return fmt.Errorf("unset default llm integration err: %w", err)
+		}
+	}
+	if err := commonrepo.NewLLMIntegrationColl().PullFeatures(ctx, args.ID, args.Features); err != nil {
+		return fmt.Errorf("remove features from other llm integrations err: %w", err)
+	}
+	return nil
+}
+
+// GetLLMUsage returns the token usage and cost of the AI features by project in [startTime, endTime), the usage of
+// the current month is returned if the time range is not set
+func GetLLMUsage(projectName string, startTime, endTime int64) ([]*commonmodels.LLMUsageStat, error) {
+	if startTime == 0 && endTime == 0 {
+		now := time.Now()
+		startTime = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Unix()
+		endTime = now.Unix() + 1
+	}
+
+	resp, err := commonrepo.NewLLMUsageColl().Stat(projectName, startTime, endTime)
+	if err != nil {
+		fmtErr := fmt.Errorf("GetLLMUsage err: %w", err)
+		log.Error(fmtErr)
+		return nil, e.ErrGetLLMUsage.AddErr(fmtErr)
+	}
+	return resp, nil
+}
+
+func ListLLMBudget() ([]*commonmodels.LLMBudget, error) {
+	resp, err := commonrepo.NewLLMBudgetColl().List()
+	if err != nil {
+		fmtErr := fmt.Errorf("ListLLMBudget err: %w", err)
+		log.Error(fmtErr)
+		return nil, e.ErrListLLMBudget.AddErr(fmtErr)
+	}
+	return resp, nil
+}
+
+func SetLLMBudget(args *commonmodels.LLMBudget) error {
+	if args.MonthlyBudget <= 0 {
+		return e.ErrSetLLMBudget.AddDesc("monthly budget must be greater than 0")
+	}
+	if args.AlertPercentage < 0 || args.AlertPercentage > 100 {
+		return e.ErrSetLLMBudget.AddDesc("alert percentage must be between 0 and 100")
+	}
+
+	if err := commonrepo.NewLLMBudgetColl().Upsert(args); err != nil {
+		fmtErr := fmt.Errorf("SetLLMBudget err: %w", err)
+		log.Error(fmtErr)
+		return e.ErrSetLLMBudget.AddErr(fmtErr)
+	}
+	return nil
+}
+
+func DeleteLLMBudget(projectName string) error {
+	if err := commonrepo.NewLLMBudgetColl().Delete(projectName); err != nil {
+		fmtErr := fmt.Errorf("DeleteLLMBudget err: %w", err)
+		log.Error(fmtErr)
+		return e.ErrSetLLMBudget.AddErr(fmtErr)
+	}
 	return nil
 }
 
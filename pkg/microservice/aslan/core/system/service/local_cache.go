@@ -0,0 +1,255 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	config2 "github.com/koderover/zadig/v2/pkg/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/tool/metrics"
+)
+
+const (
+	// the latest directory is the working copy of the service template and is never collected
+	localCacheLatestRevision = "latest"
+	// delivery charts are stored in the delivery/<revision> directories
+	localCacheDeliveryDir = "delivery"
+	// directories touched recently may still be used by a running deployment
+	localCacheMinAge = time.Hour
+)
+
+var localCacheGCLock sync.Mutex
+
+type LocalCacheGCArgs struct {
+	// MaxAgeDays and MaxSizeMB override the configured thresholds when set
+	MaxAgeDays int   `json:"max_age_days"`
+	MaxSizeMB  int64 `json:"max_size_mb"`
+	DryRun     bool  `json:"dry_run"`
+}
+
+type LocalCacheUsage struct {
+	Type  string `json:"type"`
+	Dirs  int    `json:"dirs"`
+	Bytes int64  `json:"bytes"`
+}
+
+type LocalCacheGCResult struct {
+	Usage          []*LocalCacheUsage `json:"usage"`
+	RemovedDirs    []string           `json:"removed_dirs"`
+	RemovedBytes   int64              `json:"removed_bytes"`
+	RemainingBytes int64              `json:"remaining_bytes"`
+}
+
+type localCacheDir struct {
+	path      string
+	cacheType string
+	size      int64
+	modTime   time.Time
+}
+
+// scanLocalCacheDirs lists the revision directories of services and charts: <data>/<project>/<test|production>/<service>/<revision>
+func scanLocalCacheDirs() ([]*localCacheDir, error) {
+	projects, err := os.ReadDir(config2.DataPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read data path, err: %w", err)
+	}
+
+	ret := make([]*localCacheDir, 0)
+	for _, project := range projects {
+		if !project.IsDir() {
+			continue
+		}
+		for _, cacheType := range []string{"test", "production"} {
+			typePath := filepath.Join(config2.DataPath(), project.Name(), cacheType)
+			services, err := os.ReadDir(typePath)
+			if err != nil {
+				continue
+			}
+			for _, service := range services {
+				if !service.IsDir() {
+					continue
+				}
+				servicePath := filepath.Join(typePath, service.Name())
+				revisions, err := listRevisionDirs(servicePath)
+				if err != nil {
+					return nil, err
+				}
+				for _, revision := range revisions {
+					revision.cacheType = cacheType
+					ret = append(ret, revision)
+				}
+			}
+		}
+	}
+	return ret, nil
+}
+
+func listRevisionDirs(servicePath string) ([]*localCacheDir, error) {
+	entries, err := os.ReadDir(servicePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %s, err: %w", servicePath, err)
+	}
+
+	ret := make([]*localCacheDir, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == localCacheLatestRevision {
+			continue
+		}
+		revisionPath := filepath.Join(servicePath, entry.Name())
+		if entry.Name() == localCacheDeliveryDir {
+			deliveryRevisions, err := listRevisionDirs(revisionPath)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, deliveryRevisions...)
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		size, err := dirSize(revisionPath)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, &localCacheDir{
+			path:    revisionPath,
+			size:    size,
+			modTime: info.ModTime(),
+		})
+	}
+	return ret, nil
+}
+
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate size of %s, err: %w", path, err)
+	}
+	return size, nil
+}
+
+func summarizeLocalCache(dirs []*localCacheDir) []*LocalCacheUsage {
+	usageMap := map[string]*LocalCacheUsage{
+		"test":       {Type: "test"},
+		"production": {Type: "production"},
+	}
+	for _, dir := range dirs {
+		usageMap[dir.cacheType].Dirs++
+		usageMap[dir.cacheType].Bytes += dir.size
+	}
+	ret := []*LocalCacheUsage{usageMap["test"], usageMap["production"]}
+	for _, usage := range ret {
+		metrics.SetLocalCacheUsage(usage.Type, usage.Dirs, usage.Bytes)
+	}
+	return ret
+}
+
+// GetLocalCacheUsage returns the disk usage of the local service and chart caches
+func GetLocalCacheUsage() ([]*LocalCacheUsage, error) {
+	dirs, err := scanLocalCacheDirs()
+	if err != nil {
+		return nil, err
+	}
+	return summarizeLocalCache(dirs), nil
+}
+
+// CleanLocalCache removes the revision caches older than the max age, then the oldest ones until the total size is under the limit.
+// The caches are downloaded again from the object storage or the chart repo when needed.
+func CleanLocalCache(args *LocalCacheGCArgs, log *zap.SugaredLogger) (*LocalCacheGCResult, error) {
+	if args == nil {
+		args = &LocalCacheGCArgs{}
+	}
+	maxAgeDays, maxSizeMB := args.MaxAgeDays, args.MaxSizeMB
+	if maxAgeDays <= 0 {
+		maxAgeDays = config.LocalCacheMaxAgeDays()
+	}
+	if maxSizeMB <= 0 {
+		maxSizeMB = config.LocalCacheMaxSizeMB()
+	}
+
+	localCacheGCLock.Lock()
+	defer localCacheGCLock.Unlock()
+
+	dirs, err := scanLocalCacheDirs()
+	if err != nil {
+		return nil, err
+	}
+	// oldest first
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].modTime.Before(dirs[j].modTime)
+	})
+
+	var totalSize int64
+	for _, dir := range dirs {
+		totalSize += dir.size
+	}
+
+	result := &LocalCacheGCResult{RemovedDirs: make([]string, 0)}
+	maxAge := time.Duration(maxAgeDays) * 24 * time.Hour
+	maxSize := maxSizeMB * 1024 * 1024
+	remaining := make([]*localCacheDir, 0, len(dirs))
+	for _, dir := range dirs {
+		age := time.Since(dir.modTime)
+		if age < localCacheMinAge || (age < maxAge && totalSize <= maxSize) {
+			remaining = append(remaining, dir)
+			continue
+		}
+
+		if !args.DryRun {
+			if err := os.RemoveAll(dir.path); err != nil {
+				log.Errorf("failed to remove local cache %s, err: %s", dir.path, err)
+				remaining = append(remaining, dir)
+				continue
+			}
+		}
+		totalSize -= dir.size
+		result.RemovedBytes += dir.size
+		result.RemovedDirs = append(result.RemovedDirs, dir.path)
+	}
+
+	if args.DryRun {
+		result.Usage = summarizeLocalCache(dirs)
+	} else {
+		result.Usage = summarizeLocalCache(remaining)
+		metrics.RegisterLocalCacheGC(result.RemovedBytes)
+	}
+	result.RemainingBytes = totalSize
+	log.Infof("local cache gc finished, removed %d dirs, freed %d bytes, remaining %d bytes", len(result.RemovedDirs), result.RemovedBytes, totalSize)
+	return result, nil
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notifypref"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// GetNotificationPreference returns the notification preference of the user, an empty preference accepting all notifications is returned if not set
+func GetNotificationPreference(userID string, log *zap.SugaredLogger) (*commonmodels.NotificationPreference, error) {
+	pref, err := commonrepo.NewNotificationPreferenceColl().FindByUserID(userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &commonmodels.NotificationPreference{
+				UserID:        userID,
+				Channels:      []commonmodels.NotificationChannel{},
+				Events:        []commonmodels.NotificationEventType{},
+				MutedProjects: []string{},
+			}, nil
+		}
+		log.Errorf("failed to get notification preference of user %s, error: %v", userID, err)
+		return nil, e.ErrGetNotificationPreference.AddErr(err)
+	}
+	return pref, nil
+}
+
+func UpdateNotificationPreference(userID, userName string, args *commonmodels.NotificationPreference, log *zap.SugaredLogger) error {
+	if err := validateNotificationPreference(args); err != nil {
+		return e.ErrUpdateNotificationPreference.AddErr(err)
+	}
+
+	args.UserID = userID
+	args.UserName = userName
+	if err := commonrepo.NewNotificationPreferenceColl().Upsert(args); err != nil {
+		log.Errorf("failed to update notification preference of user %s, error: %v", userID, err)
+		return e.ErrUpdateNotificationPreference.AddErr(err)
+	}
+	return nil
+}
+
+func validateNotificationPreference(args *commonmodels.NotificationPreference) error {
+	for _, channel := range args.Channels {
+		switch channel {
+		case commonmodels.NotificationChannelInApp, commonmodels.NotificationChannelMail:
+		default:
+			return fmt.Errorf("invalid channel: %s", channel)
+		}
+	}
+	for _, event := range args.Events {
+		switch event {
+		case commonmodels.NotificationEventWorkflow, commonmodels.NotificationEventEnv, commonmodels.NotificationEventSystem:
+		default:
+			return fmt.Errorf("invalid event: %s", event)
+		}
+	}
+	return notifypref.ValidateQuietHours(args.QuietHours)
+}
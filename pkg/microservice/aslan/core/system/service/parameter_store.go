@@ -0,0 +1,162 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/paramstore"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// ListParameters lists the parameters of the project, the system level parameters are listed with an empty project name.
+// The values of the secret parameters are masked.
+func ListParameters(projectName string, log *zap.SugaredLogger) ([]*commonmodels.ParameterStoreItem, error) {
+	items, err := commonrepo.NewParameterStoreColl().List([]string{projectName}, nil)
+	if err != nil {
+		log.Errorf("failed to list parameters of project %q, error: %v", projectName, err)
+		return nil, e.ErrListParameter.AddErr(err)
+	}
+	for _, item := range items {
+		if item.IsSecret {
+			item.Value = setting.MaskValue
+		}
+	}
+	return items, nil
+}
+
+func CreateParameter(username string, args *commonmodels.ParameterStoreItem, log *zap.SugaredLogger) error {
+	if err := validateParameter(args); err != nil {
+		return e.ErrCreateParameter.AddErr(err)
+	}
+	if _, err := commonrepo.NewParameterStoreColl().Get(args.ProjectName, args.Key); err == nil {
+		return e.ErrCreateParameter.AddDesc(fmt.Sprintf("parameter %s already exists", args.Key))
+	}
+
+	args.ID = primitive.NilObjectID
+	args.Usages = nil
+	args.LastUsedTime = 0
+	args.CreatedBy = username
+	args.UpdatedBy = username
+	if err := commonrepo.NewParameterStoreColl().Create(args); err != nil {
+		log.Errorf("failed to create parameter %s of project %q, error: %v", args.Key, args.ProjectName, err)
+		return e.ErrCreateParameter.AddErr(err)
+	}
+	recordParameterHistory(commonmodels.ParameterActionCreate, username, args, log)
+	return nil
+}
+
+// UpdateParameter updates the parameter, the value of the secret parameter is kept if the masked value is passed
+func UpdateParameter(username, projectName, key string, args *commonmodels.ParameterStoreItem, log *zap.SugaredLogger) error {
+	cur, err := commonrepo.NewParameterStoreColl().Get(projectName, key)
+	if err != nil {
+		log.Errorf("failed to get parameter %s of project %q, error: %v", key, projectName, err)
+		return e.ErrGetParameter.AddErr(err)
+	}
+
+	args.ProjectName = projectName
+	args.Key = key
+	if args.Value == setting.MaskValue && cur.IsSecret {
+		args.Value = cur.Value
+	}
+	if err := validateParameter(args); err != nil {
+		return e.ErrUpdateParameter.AddErr(err)
+	}
+
+	args.UpdatedBy = username
+	if err := commonrepo.NewParameterStoreColl().Update(projectName, key, args); err != nil {
+		log.Errorf("failed to update parameter %s of project %q, error: %v", key, projectName, err)
+		return e.ErrUpdateParameter.AddErr(err)
+	}
+	recordParameterHistory(commonmodels.ParameterActionUpdate, username, args, log)
+	return nil
+}
+
+func DeleteParameter(username, projectName, key string, log *zap.SugaredLogger) error {
+	cur, err := commonrepo.NewParameterStoreColl().Get(projectName, key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		log.Errorf("failed to get parameter %s of project %q, error: %v", key, projectName, err)
+		return e.ErrDeleteParameter.AddErr(err)
+	}
+
+	if err := commonrepo.NewParameterStoreColl().Delete(projectName, key); err != nil {
+		log.Errorf("failed to delete parameter %s of project %q, error: %v", key, projectName, err)
+		return e.ErrDeleteParameter.AddErr(err)
+	}
+	recordParameterHistory(commonmodels.ParameterActionDelete, username, cur, log)
+	return nil
+}
+
+type ParameterHistoryResp struct {
+	Total     int64                                 `json:"total"`
+	Histories []*commonmodels.ParameterStoreHistory `json:"histories"`
+}
+
+func ListParameterHistories(projectName, key string, pageNum, pageSize int64, log *zap.SugaredLogger) (*ParameterHistoryResp, error) {
+	histories, total, err := commonrepo.NewParameterStoreHistoryColl().List(projectName, key, pageNum, pageSize)
+	if err != nil {
+		log.Errorf("failed to list histories of parameter %s of project %q, error: %v", key, projectName, err)
+		return nil, e.ErrListParameter.AddErr(err)
+	}
+	return &ParameterHistoryResp{
+		Total:     total,
+		Histories: histories,
+	}, nil
+}
+
+func validateParameter(args *commonmodels.ParameterStoreItem) error {
+	if !paramstore.KeyRegex.MatchString(args.Key) {
+		return fmt.Errorf("invalid key %q, it must start with a letter or underscore and contain only letters, digits and underscores", args.Key)
+	}
+	switch args.Type {
+	case commonmodels.ParameterValueTypeString, commonmodels.ParameterValueTypeNumber, commonmodels.ParameterValueTypeBool, commonmodels.ParameterValueTypeText:
+	case "":
+		args.Type = commonmodels.ParameterValueTypeString
+	default:
+		return fmt.Errorf("invalid type: %s", args.Type)
+	}
+	_, err := paramstore.TypedValue(args)
+	return err
+}
+
+func recordParameterHistory(action, username string, item *commonmodels.ParameterStoreItem, log *zap.SugaredLogger) {
+	history := &commonmodels.ParameterStoreHistory{
+		ProjectName: item.ProjectName,
+		Key:         item.Key,
+		Action:      action,
+		Type:        item.Type,
+		Value:       item.Value,
+		IsSecret:    item.IsSecret,
+		Operator:    username,
+	}
+	if item.IsSecret {
+		history.Value = setting.MaskValue
+	}
+	if err := commonrepo.NewParameterStoreHistoryColl().Create(history); err != nil {
+		log.Warnf("failed to record history of parameter %s of project %q, error: %v", item.Key, item.ProjectName, err)
+	}
+}
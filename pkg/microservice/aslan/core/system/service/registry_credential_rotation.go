@@ -0,0 +1,146 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+const (
+	RegistryCredentialRotationRunning  = "running"
+	RegistryCredentialRotationFinished = "finished"
+
+	registryCredentialRotationListLimit = 20
+)
+
+type RotateRegistryCredentialArgs struct {
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// RotateRegistryCredential updates the access key of the registry and re-creates the default pull secrets in the
+// namespaces of the envs using the registry in background, the progress is recorded in the returned rotation
+func RotateRegistryCredential(username, id string, args *RotateRegistryCredentialArgs, log *zap.SugaredLogger) (*commonmodels.RegistryCredentialRotation, error) {
+	if args.AccessKey == "" || args.SecretKey == "" {
+		return nil, e.ErrInvalidParam.AddDesc("access key and secret key can not be empty")
+	}
+
+	reg, err := commonrepo.NewRegistryNamespaceColl().Find(&commonrepo.FindRegOps{ID: id})
+	if err != nil {
+		return nil, e.ErrFindRegistry.AddErr(err)
+	}
+
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{ExcludeStatus: []string{setting.ProductStatusDeleting}})
+	if err != nil {
+		return nil, e.ErrRotateRegistryCredential.AddErr(fmt.Errorf("failed to list envs, err: %w", err))
+	}
+	// the envs without a registry use the default one
+	affected := make([]*commonmodels.Product, 0)
+	for _, env := range envs {
+		if env.RegistryID == id || (env.RegistryID == "" && reg.IsDefault) {
+			affected = append(affected, env)
+		}
+	}
+
+	reg.AccessKey = args.AccessKey
+	reg.SecretKey = args.SecretKey
+	reg.UpdateBy = username
+	if err := commonrepo.NewRegistryNamespaceColl().Update(id, reg); err != nil {
+		log.Errorf("failed to update the credential of registry %s, err: %s", id, err)
+		return nil, e.ErrRotateRegistryCredential.AddErr(err)
+	}
+	commonutil.InvalidateAWSRegistryCredential(id)
+
+	rotation := &commonmodels.RegistryCredentialRotation{
+		RegistryID: id,
+		Status:     RegistryCredentialRotationRunning,
+		Total:      len(affected),
+		Failures:   make([]*commonmodels.RegistryCredentialRotationFailure, 0),
+		CreatedBy:  username,
+	}
+	if err := commonrepo.NewRegistryCredentialRotationColl().Create(rotation); err != nil {
+		return nil, e.ErrRotateRegistryCredential.AddErr(err)
+	}
+
+	util.Go(func() {
+		propagateRegistryCredential(rotation, affected, log)
+	})
+
+	if err := commonutil.SyncDinDForRegistries(); err != nil {
+		log.Errorf("failed to sync dind for the rotated registry %s, err: %s", id, err)
+	}
+	return rotation, nil
+}
+
+func propagateRegistryCredential(rotation *commonmodels.RegistryCredentialRotation, envs []*commonmodels.Product, log *zap.SugaredLogger) {
+	coll := commonrepo.NewRegistryCredentialRotationColl()
+	for _, env := range envs {
+		err := func() error {
+			kubeClient, err := clientmanager.NewKubeClientManager().GetControllerRuntimeClient(env.ClusterID)
+			if err != nil {
+				return fmt.Errorf("failed to get kube client of cluster %s, err: %w", env.ClusterID, err)
+			}
+			return commonservice.EnsureDefaultRegistrySecret(env.Namespace, env.RegistryID, kubeClient, log)
+		}()
+
+		if err == nil {
+			err = coll.IncSucceeded(rotation.ID)
+		} else {
+			log.Errorf("failed to rotate the registry secret of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+			err = coll.AddFailure(rotation.ID, &commonmodels.RegistryCredentialRotationFailure{
+				ProjectName: env.ProductName,
+				EnvName:     env.EnvName,
+				Production:  env.Production,
+				Namespace:   env.Namespace,
+				Error:       err.Error(),
+			})
+		}
+		if err != nil {
+			log.Errorf("failed to record the progress of registry credential rotation %s, err: %s", rotation.ID.Hex(), err)
+		}
+	}
+
+	if err := coll.Finish(rotation.ID, RegistryCredentialRotationFinished); err != nil {
+		log.Errorf("failed to finish registry credential rotation %s, err: %s", rotation.ID.Hex(), err)
+	}
+}
+
+func ListRegistryCredentialRotations(id string) ([]*commonmodels.RegistryCredentialRotation, error) {
+	return commonrepo.NewRegistryCredentialRotationColl().ListByRegistry(id, registryCredentialRotationListLimit)
+}
+
+func GetRegistryCredentialRotation(id, rotationID string) (*commonmodels.RegistryCredentialRotation, error) {
+	rotation, err := commonrepo.NewRegistryCredentialRotationColl().GetByID(rotationID)
+	if err != nil {
+		return nil, err
+	}
+	if rotation.RegistryID != id {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("rotation %s not found in registry %s", rotationID, id))
+	}
+	return rotation, nil
+}
@@ -0,0 +1,295 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/registry"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+type RegistryRetentionReport struct {
+	DryRun bool                   `json:"dry_run"`
+	Repos  []*RepoRetentionReport `json:"repos"`
+}
+
+type RepoRetentionReport struct {
+	Repo    string                `json:"repo"`
+	Kept    int                   `json:"kept"`
+	Deleted []*registry.TagDetail `json:"deleted"`
+	Errors  []string              `json:"errors"`
+}
+
+func getRegistryBrowser(id string, log *zap.SugaredLogger) (registry.Browser, registry.Endpoint, error) {
+	reg, err := commonrepo.NewRegistryNamespaceColl().Find(&commonrepo.FindRegOps{ID: id})
+	if err != nil {
+		return nil, registry.Endpoint{}, e.ErrFindRegistry.AddErr(err)
+	}
+	// the sdk of ecr takes the access key instead of the docker login credential
+	if reg.RegProvider != config.RegistryTypeAWS {
+		reg, err = commonservice.FindRegistryById(id, true, log)
+		if err != nil {
+			return nil, registry.Endpoint{}, e.ErrFindRegistry.AddErr(err)
+		}
+	}
+
+	var tlsEnabled bool
+	var tlsCert string
+	if reg.AdvancedSetting != nil {
+		tlsEnabled, tlsCert = reg.AdvancedSetting.TLSEnabled, reg.AdvancedSetting.TLSCert
+	}
+	endpoint := registry.Endpoint{
+		Addr:      reg.RegAddr,
+		Ak:        reg.AccessKey,
+		Sk:        reg.SecretKey,
+		Region:    reg.Region,
+		Namespace: reg.Namespace,
+	}
+	return registry.NewBrowser(reg.RegProvider, tlsEnabled, tlsCert), endpoint, nil
+}
+
+func ListRegistryRepositories(id string, log *zap.SugaredLogger) ([]string, error) {
+	browser, endpoint, err := getRegistryBrowser(id, log)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := browser.ListRepositories(endpoint, log)
+	if err != nil {
+		log.Errorf("failed to list repositories of registry %s, err: %s", id, err)
+		return nil, e.ErrListImages.AddErr(err)
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
+
+// ListRegistryRepositoryTags lists the tags of the repository from the latest pushed
+func ListRegistryRepositoryTags(id, repo string, log *zap.SugaredLogger) ([]*registry.TagDetail, error) {
+	browser, endpoint, err := getRegistryBrowser(id, log)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := browser.ListTags(endpoint, repo, log)
+	if err != nil {
+		log.Errorf("failed to list tags of %s in registry %s, err: %s", repo, id, err)
+		return nil, e.ErrListImages.AddErr(err)
+	}
+	sortTagsByPushedTime(tags)
+	return tags, nil
+}
+
+func sortTagsByPushedTime(tags []*registry.TagDetail) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		if tags[i].PushedTime != tags[j].PushedTime {
+			return tags[i].PushedTime > tags[j].PushedTime
+		}
+		// zadig built tags start with the build time
+		return tags[i].Tag > tags[j].Tag
+	})
+}
+
+func ListRegistryRetentionRules(projectName string) ([]*commonmodels.RegistryRetentionRule, error) {
+	return commonrepo.NewRegistryRetentionRuleColl().ListByProject(projectName)
+}
+
+func UpsertRegistryRetentionRule(projectName, userName string, rule *commonmodels.RegistryRetentionRule, log *zap.SugaredLogger) error {
+	if rule.KeepLatest < 0 || rule.KeepDays < 0 {
+		return e.ErrInvalidParam.AddDesc("keep latest and keep days can not be negative")
+	}
+	if rule.KeepLatest == 0 && rule.KeepDays == 0 {
+		return e.ErrInvalidParam.AddDesc("at least one of keep latest and keep days should be set")
+	}
+	if _, err := commonrepo.NewRegistryNamespaceColl().Find(&commonrepo.FindRegOps{ID: rule.RegistryID}); err != nil {
+		return e.ErrFindRegistry.AddErr(err)
+	}
+
+	rule.ProjectName = projectName
+	rule.UpdatedBy = userName
+	if err := commonrepo.NewRegistryRetentionRuleColl().Upsert(rule); err != nil {
+		log.Errorf("failed to update registry retention rule of project %s, err: %s", projectName, err)
+		return err
+	}
+	return nil
+}
+
+func DeleteRegistryRetentionRule(projectName, registryID string) error {
+	return commonrepo.NewRegistryRetentionRuleColl().Delete(projectName, registryID)
+}
+
+// RunRegistryRetentionRule cleans up the expired tags of the rule, nothing is deleted in dry run and the report shows
+// the tags to be deleted
+func RunRegistryRetentionRule(projectName, registryID string, dryRun bool, log *zap.SugaredLogger) (*RegistryRetentionReport, error) {
+	rule, err := commonrepo.NewRegistryRetentionRuleColl().GetByRegistry(projectName, registryID)
+	if err != nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("retention rule of registry %s not found in project %s", registryID, projectName))
+	}
+	return runRegistryRetentionRule(rule, dryRun, log)
+}
+
+// RunScheduledRegistryRetention runs the enabled retention rules, it is called daily
+func RunScheduledRegistryRetention(log *zap.SugaredLogger) {
+	rules, err := commonrepo.NewRegistryRetentionRuleColl().ListEnabled()
+	if err != nil {
+		log.Errorf("failed to list registry retention rules, err: %s", err)
+		return
+	}
+	for _, rule := range rules {
+		if _, err := runRegistryRetentionRule(rule, false, log); err != nil {
+			log.Errorf("failed to run registry retention rule of project %s, registry %s, err: %s", rule.ProjectName, rule.RegistryID, err)
+		}
+	}
+}
+
+func runRegistryRetentionRule(rule *commonmodels.RegistryRetentionRule, dryRun bool, log *zap.SugaredLogger) (*RegistryRetentionReport, error) {
+	browser, endpoint, err := getRegistryBrowser(rule.RegistryID, log)
+	if err != nil {
+		return nil, err
+	}
+	repos := rule.Repos
+	if len(repos) == 0 {
+		repos, err = getProjectImageNames(rule.ProjectName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	inUse, err := getProjectDeployedImages(rule.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RegistryRetentionReport{DryRun: dryRun}
+	now := time.Now()
+	for _, repo := range repos {
+		repoReport := &RepoRetentionReport{Repo: repo, Deleted: make([]*registry.TagDetail, 0), Errors: make([]string, 0)}
+		report.Repos = append(report.Repos, repoReport)
+
+		tags, err := browser.ListTags(endpoint, repo, log)
+		if err != nil {
+			repoReport.Errors = append(repoReport.Errors, err.Error())
+			continue
+		}
+		expired := selectExpiredTags(tags, rule.KeepLatest, rule.KeepDays, inUse[path.Base(repo)], now)
+		repoReport.Kept = len(tags) - len(expired)
+		for _, tag := range expired {
+			if !dryRun {
+				if err := browser.DeleteTag(endpoint, repo, tag, log); err != nil {
+					repoReport.Errors = append(repoReport.Errors, err.Error())
+					repoReport.Kept++
+					continue
+				}
+			}
+			repoReport.Deleted = append(repoReport.Deleted, tag)
+		}
+		if !dryRun && len(repoReport.Deleted) > 0 {
+			log.Infof("%d tags of %s are cleaned up by the retention rule of project %s", len(repoReport.Deleted), repo, rule.ProjectName)
+		}
+	}
+
+	if !dryRun {
+		if err := commonrepo.NewRegistryRetentionRuleColl().UpdateLastRunTime(rule.ProjectName, rule.RegistryID); err != nil {
+			log.Errorf("failed to update the last run time of registry retention rule, err: %s", err)
+		}
+	}
+	return report, nil
+}
+
+// selectExpiredTags returns the zadig built tags beyond the latest keepLatest ones and older than keepDays, the
+// custom tags and the tags in use are never expired
+func selectExpiredTags(tags []*registry.TagDetail, keepLatest, keepDays int, inUse sets.String, now time.Time) []*registry.TagDetail {
+	zadigTags := make([]*registry.TagDetail, 0)
+	for _, tag := range tags {
+		if isZadigBuildTag(tag.Tag) {
+			zadigTags = append(zadigTags, tag)
+		}
+	}
+	sortTagsByPushedTime(zadigTags)
+
+	expired := make([]*registry.TagDetail, 0)
+	deadline := now.AddDate(0, 0, -keepDays).Unix()
+	for i, tag := range zadigTags {
+		if i < keepLatest || inUse.Has(tag.Tag) {
+			continue
+		}
+		if keepDays > 0 && tag.PushedTime >= deadline {
+			continue
+		}
+		expired = append(expired, tag)
+	}
+	return expired
+}
+
+// isZadigBuildTag checks if the tag starts with the build time like the default image tag of zadig builds
+func isZadigBuildTag(tag string) bool {
+	timestamp, _, found := strings.Cut(tag, "-")
+	if !found || len(timestamp) != 14 {
+		return false
+	}
+	_, err := time.Parse("20060102150405", timestamp)
+	return err == nil
+}
+
+func getProjectImageNames(projectName string) ([]string, error) {
+	names := sets.NewString()
+	services, err := commonrepo.NewServiceColl().ListMaxRevisionsByProduct(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services of project %s, err: %w", projectName, err)
+	}
+	productionServices, err := commonrepo.NewProductionServiceColl().ListMaxRevisionsByProduct(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list production services of project %s, err: %w", projectName, err)
+	}
+	for _, svc := range append(services, productionServices...) {
+		for _, container := range svc.Containers {
+			names.Insert(util.GetImageNameFromContainerInfo(container.ImageName, container.Name))
+		}
+	}
+	return names.List(), nil
+}
+
+// getProjectDeployedImages returns the tags of the images deployed in the envs of the project by the image names
+func getProjectDeployedImages(projectName string) (map[string]sets.String, error) {
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{Name: projectName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list envs of project %s, err: %w", projectName, err)
+	}
+	ret := make(map[string]sets.String)
+	for _, env := range envs {
+		for _, svc := range env.GetServiceMap() {
+			for _, container := range svc.Containers {
+				name := util.ExtractImageName(container.Image)
+				if _, ok := ret[name]; !ok {
+					ret[name] = sets.NewString()
+				}
+				ret[name].Insert(commonutil.ExtractImageTag(container.Image))
+			}
+		}
+	}
+	return ret, nil
+}
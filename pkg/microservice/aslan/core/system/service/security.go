@@ -19,7 +19,9 @@ package service
 import (
 	"go.uber.org/zap"
 
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 )
 
 func CreateOrUpdateSecuritySettings(args *SecurityAndPrivacySettings, logger *zap.SugaredLogger) error {
@@ -57,3 +59,24 @@ func GetSecuritySettings(logger *zap.SugaredLogger) (*SecurityAndPrivacySettings
 		ImprovementPlan:     improvementPlan,
 	}, nil
 }
+
+func GetCredentialScanPolicy(logger *zap.SugaredLogger) (*commonmodels.CredentialScanPolicy, error) {
+	systemSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		logger.Errorf("failed to get system settings, error: %s", err)
+		return nil, err
+	}
+	if systemSetting.CredentialScan == nil {
+		return &commonmodels.CredentialScanPolicy{}, nil
+	}
+	return systemSetting.CredentialScan, nil
+}
+
+func UpdateCredentialScanPolicy(args *commonmodels.CredentialScanPolicy, logger *zap.SugaredLogger) error {
+	err := commonrepo.NewSystemSettingColl().UpdateCredentialScanPolicy(args)
+	if err != nil {
+		logger.Errorf("failed to update credential scan policy, error: %s", err)
+		return e.ErrUpdateCredentialScanPolicy.AddErr(err)
+	}
+	return nil
+}
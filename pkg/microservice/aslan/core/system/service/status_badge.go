@@ -0,0 +1,237 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"text/template"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	service2 "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+const (
+	badgeColorGreen  = "#4c1"
+	badgeColorRed    = "#e05d44"
+	badgeColorYellow = "#dfb317"
+	badgeColorBlue   = "#007ec6"
+	badgeColorGrey   = "#9f9f9f"
+
+	statusBadgeTokenLength = 20
+)
+
+var statusBadgeTemplate = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Message}}">` +
+	`<title>{{.Label}}: {{.Message}}</title>` +
+	`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+	`<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>` +
+	`<g clip-path="url(#r)"><rect width="{{.LabelWidth}}" height="20" fill="#555"/><rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/><rect width="{{.Width}}" height="20" fill="url(#s)"/></g>` +
+	`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` +
+	`<text x="{{.LabelX}}" y="14">{{.Label}}</text><text x="{{.MessageX}}" y="14">{{.Message}}</text></g></svg>`))
+
+type statusBadgeSVG struct {
+	Label        string
+	Message      string
+	Color        string
+	Width        int
+	LabelWidth   int
+	MessageWidth int
+	LabelX       int
+	MessageX     int
+}
+
+func CreateStatusBadge(username string, args *commonmodels.StatusBadge, log *zap.SugaredLogger) (*commonmodels.StatusBadge, error) {
+	switch args.Type {
+	case commonmodels.StatusBadgeTypeWorkflow:
+		workflow, err := commonrepo.NewWorkflowV4Coll().Find(args.Name)
+		if err != nil || workflow.Project != args.ProjectName {
+			return nil, e.ErrCreateStatusBadge.AddDesc(fmt.Sprintf("workflow %s not found in project %s", args.Name, args.ProjectName))
+		}
+	case commonmodels.StatusBadgeTypeEnv:
+		_, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: args.ProjectName, EnvName: args.Name})
+		if err != nil {
+			return nil, e.ErrCreateStatusBadge.AddDesc(fmt.Sprintf("env %s not found in project %s", args.Name, args.ProjectName))
+		}
+	default:
+		return nil, e.ErrCreateStatusBadge.AddDesc(fmt.Sprintf("invalid status badge type %s", args.Type))
+	}
+
+	token, err := generateStatusBadgeToken()
+	if err != nil {
+		log.Errorf("failed to generate status badge token, error: %v", err)
+		return nil, e.ErrCreateStatusBadge.AddErr(err)
+	}
+
+	badge := &commonmodels.StatusBadge{
+		Token:       token,
+		ProjectName: args.ProjectName,
+		Type:        args.Type,
+		Name:        args.Name,
+		Label:       args.Label,
+		CreatedBy:   username,
+	}
+	if err := commonrepo.NewStatusBadgeColl().Create(badge); err != nil {
+		log.Errorf("failed to create status badge of %s %s, error: %v", args.Type, args.Name, err)
+		return nil, e.ErrCreateStatusBadge.AddErr(err)
+	}
+	return badge, nil
+}
+
+func ListStatusBadges(projectName string, log *zap.SugaredLogger) ([]*commonmodels.StatusBadge, error) {
+	badges, err := commonrepo.NewStatusBadgeColl().List(projectName)
+	if err != nil {
+		log.Errorf("failed to list status badges of project %s, error: %v", projectName, err)
+		return nil, e.ErrListStatusBadge.AddErr(err)
+	}
+	return badges, nil
+}
+
+func DeleteStatusBadge(projectName, id string, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewStatusBadgeColl().Delete(projectName, id); err != nil {
+		log.Errorf("failed to delete status badge %s of project %s, error: %v", id, projectName, err)
+		return e.ErrDeleteStatusBadge.AddErr(err)
+	}
+	return nil
+}
+
+// GetStatusBadgeSVG renders the badge of the token, the token is the only credential of the request so nothing
+// but the status of the resource bound to the token is exposed.
+func GetStatusBadgeSVG(token string, log *zap.SugaredLogger) ([]byte, error) {
+	badge, err := commonrepo.NewStatusBadgeColl().FindByToken(token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, e.ErrGetStatusBadge.AddDesc("status badge not found")
+		}
+		log.Errorf("failed to find status badge, error: %v", err)
+		return nil, e.ErrGetStatusBadge.AddErr(err)
+	}
+
+	var label, message, color string
+	switch badge.Type {
+	case commonmodels.StatusBadgeTypeWorkflow:
+		label, message, color = getWorkflowBadgeStatus(badge)
+	case commonmodels.StatusBadgeTypeEnv:
+		label, message, color = getEnvBadgeStatus(badge, log)
+	default:
+		return nil, e.ErrGetStatusBadge.AddDesc(fmt.Sprintf("invalid status badge type %s", badge.Type))
+	}
+	if badge.Label != "" {
+		label = badge.Label
+	}
+
+	return renderStatusBadge(label, message, color)
+}
+
+func getWorkflowBadgeStatus(badge *commonmodels.StatusBadge) (string, string, string) {
+	label := badge.Name
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(badge.Name)
+	if err != nil || workflow.Project != badge.ProjectName {
+		return label, "not found", badgeColorGrey
+	}
+	if workflow.DisplayName != "" {
+		label = workflow.DisplayName
+	}
+
+	task, err := commonrepo.NewworkflowTaskv4Coll().GetLatest(badge.Name)
+	if err != nil {
+		return label, "no tasks", badgeColorGrey
+	}
+
+	switch task.Status {
+	case config.StatusPassed:
+		return label, string(task.Status), badgeColorGreen
+	case config.StatusFailed, config.StatusTimeout, config.StatusReject:
+		return label, string(task.Status), badgeColorRed
+	case config.StatusCancelled, config.StatusPause:
+		return label, string(task.Status), badgeColorGrey
+	default:
+		return label, string(task.Status), badgeColorBlue
+	}
+}
+
+func getEnvBadgeStatus(badge *commonmodels.StatusBadge, log *zap.SugaredLogger) (string, string, string) {
+	label := badge.Name
+	env, err := service2.GetProduct(setting.SystemUser, badge.Name, badge.ProjectName, log)
+	if err != nil {
+		return label, "not found", badgeColorGrey
+	}
+
+	switch env.Status {
+	case setting.PodRunning:
+		return label, "healthy", badgeColorGreen
+	case setting.PodUnstable:
+		return label, "unstable", badgeColorYellow
+	case setting.PodCreating, setting.PodUpdating, setting.PodDeleting:
+		return label, env.Status, badgeColorBlue
+	case setting.ProductStatusSleeping:
+		return label, "sleeping", badgeColorGrey
+	default:
+		return label, env.Status, badgeColorRed
+	}
+}
+
+func renderStatusBadge(label, message, color string) ([]byte, error) {
+	labelWidth, messageWidth := badgeTextWidth(label), badgeTextWidth(message)
+	data := &statusBadgeSVG{
+		Label:        html.EscapeString(label),
+		Message:      html.EscapeString(message),
+		Color:        color,
+		Width:        labelWidth + messageWidth,
+		LabelWidth:   labelWidth,
+		MessageWidth: messageWidth,
+		LabelX:       labelWidth / 2,
+		MessageX:     labelWidth + messageWidth/2,
+	}
+
+	buf := new(bytes.Buffer)
+	if err := statusBadgeTemplate.Execute(buf, data); err != nil {
+		return nil, e.ErrGetStatusBadge.AddErr(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// badgeTextWidth estimates the width of the text in the 11px Verdana font, wide characters take about 12px
+func badgeTextWidth(text string) int {
+	width := 10
+	for _, r := range text {
+		if utf8.RuneLen(r) > 1 {
+			width += 12
+		} else {
+			width += 7
+		}
+	}
+	return width
+}
+
+func generateStatusBadgeToken() (string, error) {
+	b := make([]byte, statusBadgeTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
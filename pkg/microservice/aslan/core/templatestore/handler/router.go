@@ -83,6 +83,9 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		workflow.GET("", ListWorkflowTemplate)
 		workflow.GET("/:id", GetWorkflowTemplateByID)
 		workflow.DELETE("/:id", DeleteWorkflowTemplateByID)
+		workflow.POST("/import", ImportWorkflowTemplate)
+		workflow.POST("/:id/instantiate", InstantiateWorkflowTemplate)
+		workflow.GET("/:id/instances", ListWorkflowTemplateInstances)
 	}
 
 	scanning := router.Group("scanning")
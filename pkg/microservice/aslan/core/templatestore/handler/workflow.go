@@ -186,3 +186,119 @@ func DeleteWorkflowTemplateByID(c *gin.Context) {
 
 	ctx.RespErr = templateservice.DeleteWorkflowTemplateByID(c.Param("id"), ctx.Logger)
 }
+
+// @Summary Import Workflow Template
+// @Description Publish the workflow template bundle to the template store, the template of the same name is upgraded if the bundle has a greater version
+// @Tags 	template
+// @Accept 	yaml
+// @Produce json
+// @Param 	body 	body 		templateservice.WorkflowTemplateBundle 	true 	"body"
+// @Success 200
+// @Router /api/aslan/template/workflow/import [post]
+func ImportWorkflowTemplate(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.Template.Create || !ctx.Resources.SystemActions.Template.Edit {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	if err = commonutil.CheckZadigProfessionalLicense(); err != nil {
+		ctx.RespErr = err
+		return
+	}
+
+	args := new(templateservice.WorkflowTemplateBundle)
+	if err := c.ShouldBindYAML(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	ctx.RespErr = templateservice.ImportWorkflowTemplate(ctx.UserName, args, ctx.Logger)
+}
+
+// @Summary Instantiate Workflow Template
+// @Description Create the workflow in the project from the workflow template with the parameter values
+// @Tags 	template
+// @Accept 	json
+// @Produce json
+// @Param 	id		path		string											true	"template id"
+// @Param 	body 	body 		templateservice.InstantiateWorkflowTemplateArgs 	true 	"body"
+// @Success 200
+// @Router /api/aslan/template/workflow/{id}/instantiate [post]
+func InstantiateWorkflowTemplate(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(templateservice.InstantiateWorkflowTemplateArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+	if args.ProjectName == "" || args.WorkflowName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("project_name and workflow_name can not be empty")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, args.ProjectName, "新增", "工作流", args.WorkflowName, "", ctx.Logger)
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[args.ProjectName]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[args.ProjectName].IsProjectAdmin &&
+			!ctx.Resources.ProjectAuthInfo[args.ProjectName].Workflow.Create {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = templateservice.InstantiateWorkflowTemplate(ctx.UserName, c.Param("id"), args, ctx.Logger)
+}
+
+// @Summary List Workflow Template Instances
+// @Description List the workflows instantiated from the workflow template, outdated is true if the workflow is instantiated from an older version
+// @Tags 	template
+// @Accept 	json
+// @Produce json
+// @Param 	id		path		string		true	"template id"
+// @Success 200 	{array} 	templateservice.WorkflowTemplateInstance
+// @Router /api/aslan/template/workflow/{id}/instances [get]
+func ListWorkflowTemplateInstances(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if !ctx.Resources.SystemActions.Template.View {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = templateservice.ListWorkflowTemplateInstances(c.Param("id"), ctx.Logger)
+}
@@ -34,6 +34,7 @@ type WorkflowtemplatePreView struct {
 	Description  string                   `json:"description"`
 	Category     setting.WorkflowCategory `json:"category"`
 	BuildIn      bool                     `json:"build_in"`
+	Version      int                      `json:"version,omitempty"`
 }
 
 type WorkflowTemplateStage struct {
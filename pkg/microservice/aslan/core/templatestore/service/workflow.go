@@ -138,6 +138,7 @@ func ListWorkflowTemplate(category string, excludeBuildIn bool, logger *zap.Suga
 			Description:  template.Description,
 			Category:     template.Category,
 			BuildIn:      template.BuildIn,
+			Version:      template.Version,
 		})
 	}
 	return resp, nil
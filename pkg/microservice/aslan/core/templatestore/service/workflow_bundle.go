@@ -0,0 +1,306 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/notify"
+	workflowservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
+	jobctl "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow/job"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+var templateParamNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// WorkflowTemplateBundle is the importable yaml bundle of a workflow template. The workflow is a yaml string of the
+// params, stages and share storages of the workflow, the parameters are referred as {{.name}} in it.
+type WorkflowTemplateBundle struct {
+	Name          string                                `yaml:"name"          json:"name"`
+	Version       int                                   `yaml:"version"       json:"version"`
+	Category      setting.WorkflowCategory              `yaml:"category"      json:"category"`
+	Description   string                                `yaml:"description"   json:"description"`
+	Documentation string                                `yaml:"documentation" json:"documentation"`
+	Parameters    []*commonmodels.WorkflowTemplateParam `yaml:"parameters"    json:"parameters"`
+	Workflow      string                                `yaml:"workflow"      json:"workflow"`
+}
+
+type workflowTemplateContent struct {
+	Params           []*commonmodels.Param         `yaml:"params"`
+	Stages           []*commonmodels.WorkflowStage `yaml:"stages"`
+	ShareStorages    []*commonmodels.ShareStorage  `yaml:"share_storages"`
+	ConcurrencyLimit int                           `yaml:"concurrency_limit"`
+}
+
+type InstantiateWorkflowTemplateArgs struct {
+	ProjectName  string            `json:"project_name"`
+	WorkflowName string            `json:"workflow_name"`
+	DisplayName  string            `json:"display_name"`
+	Params       map[string]string `json:"params"`
+}
+
+type WorkflowTemplateInstance struct {
+	ProjectName  string `json:"project_name"`
+	WorkflowName string `json:"workflow_name"`
+	DisplayName  string `json:"display_name"`
+	Version      int    `json:"version"`
+	Outdated     bool   `json:"outdated"`
+}
+
+// ImportWorkflowTemplate publishes the bundle to the template store, the template of the same name is upgraded if the
+// bundle has a greater version and the creators of the workflows instantiated from the template are notified
+func ImportWorkflowTemplate(userName string, bundle *WorkflowTemplateBundle, logger *zap.SugaredLogger) error {
+	if bundle.Name == "" {
+		return e.ErrImportWorkflowTemplate.AddDesc("template name can not be empty")
+	}
+	if bundle.Version <= 0 {
+		bundle.Version = 1
+	}
+	if bundle.Category == "" {
+		bundle.Category = setting.CustomWorkflow
+	}
+
+	content, _, err := renderWorkflowTemplateContent(bundle.Workflow, bundle.Parameters, nil)
+	if err != nil {
+		return e.ErrImportWorkflowTemplate.AddErr(err)
+	}
+	template := &commonmodels.WorkflowV4Template{
+		TemplateName:     bundle.Name,
+		Category:         bundle.Category,
+		Params:           content.Params,
+		Stages:           content.Stages,
+		Description:      bundle.Description,
+		ShareStorages:    content.ShareStorages,
+		ConcurrencyLimit: content.ConcurrencyLimit,
+		Version:          bundle.Version,
+		TemplateParams:   bundle.Parameters,
+		Documentation:    bundle.Documentation,
+		Content:          bundle.Workflow,
+		CreatedBy:        userName,
+		UpdatedBy:        userName,
+	}
+	if err := lintWorkflowTemplate(template, logger); err != nil {
+		return err
+	}
+	workflow := &commonmodels.WorkflowV4{
+		Stages: template.Stages,
+	}
+	for _, stage := range template.Stages {
+		for _, job := range stage.Jobs {
+			if err := jobctl.Instantiate(job, workflow); err != nil {
+				logger.Errorf("Failed to instantiate workflow v4 template, error: %v", err)
+				return e.ErrImportWorkflowTemplate.AddErr(err)
+			}
+		}
+	}
+
+	existed, err := commonrepo.NewWorkflowV4TemplateColl().Find(&commonrepo.WorkflowTemplateQueryOption{Name: bundle.Name})
+	if err != nil {
+		if err := commonrepo.NewWorkflowV4TemplateColl().Create(template); err != nil {
+			logger.Errorf("Failed to create workflow template %s, err: %v", template.TemplateName, err)
+			return e.ErrImportWorkflowTemplate.AddErr(err)
+		}
+		return nil
+	}
+
+	if existed.BuildIn {
+		return e.ErrImportWorkflowTemplate.AddDesc(fmt.Sprintf("内置工作流模板 %s 不能被覆盖", bundle.Name))
+	}
+	if bundle.Version <= existed.Version {
+		return e.ErrImportWorkflowTemplate.AddDesc(fmt.Sprintf("版本 %d 需要大于当前版本 %d", bundle.Version, existed.Version))
+	}
+	template.ID = existed.ID
+	template.CreatedBy = existed.CreatedBy
+	template.CreateTime = existed.CreateTime
+	if err := commonrepo.NewWorkflowV4TemplateColl().Update(template); err != nil {
+		logger.Errorf("Failed to update workflow template %s, err: %v", template.TemplateName, err)
+		return e.ErrImportWorkflowTemplate.AddErr(err)
+	}
+
+	go notifyWorkflowTemplateUpgrade(template, logger)
+	return nil
+}
+
+// InstantiateWorkflowTemplate creates the workflow in the project from the template with the parameter values
+func InstantiateWorkflowTemplate(userName, templateID string, args *InstantiateWorkflowTemplateArgs, logger *zap.SugaredLogger) error {
+	template, err := commonrepo.NewWorkflowV4TemplateColl().Find(&commonrepo.WorkflowTemplateQueryOption{ID: templateID})
+	if err != nil {
+		return e.ErrGetWorkflowTemplate.AddErr(err)
+	}
+
+	content := &workflowTemplateContent{
+		Params:           template.Params,
+		Stages:           template.Stages,
+		ShareStorages:    template.ShareStorages,
+		ConcurrencyLimit: template.ConcurrencyLimit,
+	}
+	params := make(map[string]string)
+	if template.Content != "" {
+		content, params, err = renderWorkflowTemplateContent(template.Content, template.TemplateParams, args.Params)
+		if err != nil {
+			return e.ErrInstantiateWorkflowTemplate.AddErr(err)
+		}
+	}
+
+	workflow := &commonmodels.WorkflowV4{
+		Name:             args.WorkflowName,
+		DisplayName:      args.DisplayName,
+		Category:         template.Category,
+		Params:           content.Params,
+		Stages:           content.Stages,
+		Project:          args.ProjectName,
+		Description:      template.Description,
+		ShareStorages:    content.ShareStorages,
+		ConcurrencyLimit: content.ConcurrencyLimit,
+		Template: &commonmodels.WorkflowTemplateRef{
+			ID:      templateID,
+			Name:    template.TemplateName,
+			Version: template.Version,
+			Params:  params,
+		},
+	}
+	if workflow.DisplayName == "" {
+		workflow.DisplayName = workflow.Name
+	}
+	return workflowservice.CreateWorkflowV4(userName, workflow, logger)
+}
+
+func ListWorkflowTemplateInstances(templateID string, logger *zap.SugaredLogger) ([]*WorkflowTemplateInstance, error) {
+	template, err := commonrepo.NewWorkflowV4TemplateColl().Find(&commonrepo.WorkflowTemplateQueryOption{ID: templateID})
+	if err != nil {
+		return nil, e.ErrGetWorkflowTemplate.AddErr(err)
+	}
+	workflows, err := commonrepo.NewWorkflowV4Coll().ListByTemplate(templateID)
+	if err != nil {
+		logger.Errorf("Failed to list workflows of template %s, err: %v", template.TemplateName, err)
+		return nil, e.ErrListWorkflowTemplate.AddErr(err)
+	}
+
+	resp := make([]*WorkflowTemplateInstance, 0)
+	for _, workflow := range workflows {
+		resp = append(resp, &WorkflowTemplateInstance{
+			ProjectName:  workflow.Project,
+			WorkflowName: workflow.Name,
+			DisplayName:  workflow.DisplayName,
+			Version:      workflow.Template.Version,
+			Outdated:     workflow.Template.Version < template.Version,
+		})
+	}
+	return resp, nil
+}
+
+func notifyWorkflowTemplateUpgrade(template *commonmodels.WorkflowV4Template, logger *zap.SugaredLogger) {
+	workflows, err := commonrepo.NewWorkflowV4Coll().ListByTemplate(template.ID.Hex())
+	if err != nil {
+		logger.Errorf("Failed to list workflows of template %s, err: %v", template.TemplateName, err)
+		return
+	}
+
+	outdated := make(map[string][]string)
+	for _, workflow := range workflows {
+		if workflow.Template.Version < template.Version {
+			outdated[workflow.CreatedBy] = append(outdated[workflow.CreatedBy], fmt.Sprintf("%s/%s", workflow.Project, workflow.DisplayName))
+		}
+	}
+	title := fmt.Sprintf("工作流模板 %s 已更新到版本 %d", template.TemplateName, template.Version)
+	for user, names := range outdated {
+		content := fmt.Sprintf("以下工作流基于模板的旧版本创建，请及时更新: %s", strings.Join(names, ", "))
+		notify.SendMessage(user, title, content, "", logger)
+	}
+}
+
+// renderWorkflowTemplateContent renders the workflow of the bundle with the parameter values, the default values are
+// used for the absent parameters. The resolved values of the parameters are returned along with the content.
+func renderWorkflowTemplateContent(workflow string, params []*commonmodels.WorkflowTemplateParam, values map[string]string) (*workflowTemplateContent, map[string]string, error) {
+	resolved := make(map[string]string)
+	for _, param := range params {
+		if !templateParamNameRegex.MatchString(param.Name) {
+			return nil, nil, fmt.Errorf("invalid parameter name %s", param.Name)
+		}
+		if _, ok := resolved[param.Name]; ok {
+			return nil, nil, fmt.Errorf("duplicated parameter %s", param.Name)
+		}
+
+		value, ok := values[param.Name]
+		if !ok || value == "" {
+			value = param.Default
+		}
+		if value == "" && param.Required && values != nil {
+			return nil, nil, fmt.Errorf("parameter %s is required", param.Name)
+		}
+		if value != "" {
+			if err := validateTemplateParamValue(param, value); err != nil {
+				return nil, nil, err
+			}
+		}
+		resolved[param.Name] = value
+	}
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			return nil, nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+
+	tmpl, err := template.New("workflow").Option("missingkey=error").Parse(workflow)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the workflow, err: %w", err)
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, resolved); err != nil {
+		return nil, nil, fmt.Errorf("failed to render the workflow, err: %w", err)
+	}
+	content := new(workflowTemplateContent)
+	if err := yaml.Unmarshal(buf.Bytes(), content); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal the rendered workflow, err: %w", err)
+	}
+	return content, resolved, nil
+}
+
+func validateTemplateParamValue(param *commonmodels.WorkflowTemplateParam, value string) error {
+	switch param.Type {
+	case commonmodels.WorkflowTemplateParamTypeString, "":
+	case commonmodels.WorkflowTemplateParamTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("parameter %s should be a number", param.Name)
+		}
+	case commonmodels.WorkflowTemplateParamTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("parameter %s should be true or false", param.Name)
+		}
+	case commonmodels.WorkflowTemplateParamTypeEnum:
+		for _, option := range param.Options {
+			if option == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("parameter %s should be one of %s", param.Name, strings.Join(param.Options, ", "))
+	default:
+		return fmt.Errorf("unsupported type %s of parameter %s", param.Type, param.Name)
+	}
+	return nil
+}
@@ -29,6 +29,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		vm.PUT("/:vmid/agent/upgrade", UpgradeAgent)
 		vm.GET("/vms", ListVMs)
 		vm.GET("/labels", ListVMLabels)
+		vm.GET("/agent/status", ListAgentStatus)
+		vm.POST("/agent/upgrade", RemoteUpgradeAgents)
 	}
 
 	vmAgent := router.Group("agents")
@@ -23,6 +23,7 @@ import (
 
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/vm/service"
 	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 )
 
 func GetAgentAccessCmd(c *gin.Context) {
@@ -110,6 +111,50 @@ func UpgradeAgent(c *gin.Context) {
 	ctx.Resp, ctx.RespErr = service.UpgradeAgent(c.Param("vmid"), ctx.UserName, ctx.Logger)
 }
 
+type remoteUpgradeAgentsReq struct {
+	IDs []string `json:"ids"`
+}
+
+func RemoteUpgradeAgents(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.Logger.Errorf("failed to generate authorization info for user: %s, error: %s", ctx.UserID, err)
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	req := new(remoteUpgradeAgentsReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	ctx.RespErr = service.RemoteUpgradeAgents(req.IDs, ctx.UserName, ctx.Logger)
+}
+
+func ListAgentStatus(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.Logger.Errorf("failed to generate authorization info for user: %s, error: %s", ctx.UserID, err)
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = service.ListAgentStatus(ctx.Logger)
+}
+
 func ListVMs(c *gin.Context) {
 	ctx, err := internalhandler.NewContextWithAuthorization(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -0,0 +1,221 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	vmmongodb "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/vm"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const (
+	// the agent is regarded as dead if no heartbeat is received during the period,
+	// and the jobs running on it are requeued to the other agents
+	agentDeadTimeout   = 2 * time.Minute
+	maxJobRequeueCount = 3
+)
+
+type AgentStatus struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Label             string `json:"label"`
+	Status            string `json:"status"`
+	Error             string `json:"error"`
+	Platform          string `json:"platform"`
+	Architecture      string `json:"architecture"`
+	IP                string `json:"ip"`
+	AgentVersion      string `json:"agent_version"`
+	LatestVersion     string `json:"latest_version"`
+	Upgrading         bool   `json:"upgrading"`
+	UpgradeVersion    string `json:"upgrade_version"`
+	LastHeartbeatTime int64  `json:"last_heartbeat_time"`
+	HeartbeatTimeout  bool   `json:"heartbeat_timeout"`
+	RunningJobs       int    `json:"running_jobs"`
+}
+
+// ListAgentStatus lists the heartbeat, version and running jobs of the vm agents
+func ListAgentStatus(logger *zap.SugaredLogger) ([]*AgentStatus, error) {
+	vms, err := commonrepo.NewPrivateKeyColl().List(&commonrepo.PrivateKeyArgs{})
+	if err != nil {
+		logger.Errorf("failed to list VMs, error: %s", err)
+		return nil, fmt.Errorf("failed to list VMs, error: %s", err)
+	}
+	// the latest version is only for display
+	latestVersion, err := getZadigAgentVersion()
+	if err != nil {
+		logger.Warnf("failed to get the latest zadig-agent version, error: %s", err)
+	}
+
+	resp := make([]*AgentStatus, 0, len(vms))
+	for _, vm := range vms {
+		if vm.Agent == nil {
+			continue
+		}
+
+		status := &AgentStatus{
+			ID:                vm.ID.Hex(),
+			Name:              vm.Name,
+			Label:             vm.Label,
+			Status:            string(vm.Status),
+			Error:             vm.Error,
+			AgentVersion:      vm.Agent.AgentVersion,
+			LatestVersion:     latestVersion,
+			Upgrading:         vm.Agent.NeedUpdate,
+			UpgradeVersion:    vm.Agent.UpgradeVersion,
+			LastHeartbeatTime: vm.Agent.LastHeartbeatTime,
+		}
+		if vm.Agent.LastHeartbeatTime > 0 {
+			status.HeartbeatTimeout = time.Since(time.Unix(vm.Agent.LastHeartbeatTime, 0)) > time.Duration(setting.AgentDefaultHeartbeatTimeout)*time.Second
+		}
+		if vm.VMInfo != nil {
+			status.IP = vm.VMInfo.IP
+			status.Platform = vm.VMInfo.Platform
+			status.Architecture = vm.VMInfo.Architecture
+		}
+		jobs, err := vmmongodb.NewVMJobColl().ListUnfinishedByVMID(vm.ID.Hex())
+		if err != nil {
+			logger.Errorf("failed to list running jobs of vm %s, error: %s", vm.Name, err)
+		}
+		status.RunningJobs = len(jobs)
+
+		resp = append(resp, status)
+	}
+	return resp, nil
+}
+
+// RemoteUpgradeAgents marks the agents to upgrade to the latest version, the agent downloads the new binary
+// and restarts itself after the running jobs finish when it receives the heartbeat response.
+// All the outdated agents are upgraded if ids is empty.
+func RemoteUpgradeAgents(ids []string, user string, logger *zap.SugaredLogger) error {
+	version, err := getZadigAgentVersion()
+	if err != nil {
+		return e.ErrUpgradeZadigVMAgent.AddErr(fmt.Errorf("failed to get zadig-agent version, error: %s", err))
+	}
+
+	vms := make([]*commonmodels.PrivateKey, 0)
+	if len(ids) == 0 {
+		all, err := commonrepo.NewPrivateKeyColl().List(&commonrepo.PrivateKeyArgs{})
+		if err != nil {
+			return e.ErrUpgradeZadigVMAgent.AddErr(err)
+		}
+		for _, vm := range all {
+			if vm.Agent != nil && vm.Agent.AgentVersion != version {
+				vms = append(vms, vm)
+			}
+		}
+	} else {
+		for _, id := range ids {
+			vm, err := commonrepo.NewPrivateKeyColl().Find(commonrepo.FindPrivateKeyOption{ID: id})
+			if err != nil {
+				return e.ErrUpgradeZadigVMAgent.AddErr(fmt.Errorf("vm %s not exists", id))
+			}
+			if vm.Agent == nil {
+				return e.ErrUpgradeZadigVMAgent.AddErr(fmt.Errorf("vm %s not install zadig-agent", vm.Name))
+			}
+			vms = append(vms, vm)
+		}
+	}
+
+	for _, vm := range vms {
+		if vm.VMInfo == nil {
+			logger.Warnf("skip upgrading vm %s without platform info", vm.Name)
+			continue
+		}
+		vm.Agent.NeedUpdate = true
+		vm.Agent.UpgradeVersion = version
+		vm.UpdateBy = user
+		vm.UpdateTime = time.Now().Unix()
+		if err := commonrepo.NewPrivateKeyColl().Update(vm.ID.Hex(), vm); err != nil {
+			logger.Errorf("failed to upgrade vm agent %s, error: %s", vm.Name, err)
+			return e.ErrUpgradeZadigVMAgent.AddErr(fmt.Errorf("failed to upgrade vm agent %s, error: %s", vm.Name, err))
+		}
+	}
+	return nil
+}
+
+func getAgentDownloadURL(vm *commonmodels.PrivateKey, version string) (string, error) {
+	if vm.VMInfo == nil {
+		return "", fmt.Errorf("vm %s has no platform info", vm.Name)
+	}
+	baseURL, err := getRepoURL()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/zadig-agent-%s-%s-v%s.tar.gz", baseURL, vm.VMInfo.Platform, vm.VMInfo.Architecture, version), nil
+}
+
+// WatchDeadAgentJobs requeues the jobs whose agent died mid-run, so that they can be picked up by the other agents
+func WatchDeadAgentJobs() {
+	logger := log.SugaredLogger().With("service", "WatchDeadAgentJobs")
+	for {
+		time.Sleep(time.Minute)
+
+		lock := cache.NewRedisLockWithExpiry("dead-agent-jobs-watch-lock", time.Minute*5)
+		if err := lock.TryLock(); err != nil {
+			continue
+		}
+		requeueDeadAgentJobs(logger)
+		lock.Unlock()
+	}
+}
+
+func requeueDeadAgentJobs(logger *zap.SugaredLogger) {
+	vms, err := commonrepo.NewPrivateKeyColl().List(&commonrepo.PrivateKeyArgs{})
+	if err != nil {
+		logger.Errorf("failed to list VMs, error: %s", err)
+		return
+	}
+
+	for _, vm := range vms {
+		if vm.Type != setting.NewVMType || vm.Agent == nil || vm.Agent.LastHeartbeatTime == 0 {
+			continue
+		}
+		if time.Since(time.Unix(vm.Agent.LastHeartbeatTime, 0)) < agentDeadTimeout {
+			continue
+		}
+
+		jobs, err := vmmongodb.NewVMJobColl().ListUnfinishedByVMID(vm.ID.Hex())
+		if err != nil {
+			logger.Errorf("failed to list running jobs of vm %s, error: %s", vm.Name, err)
+			continue
+		}
+		for _, job := range jobs {
+			if job.RequeueCount >= maxJobRequeueCount {
+				job.Status = string(config.StatusFailed)
+				job.Error = fmt.Sprintf("agent %s died when running the job, and the job has been requeued %d times", vm.Name, job.RequeueCount)
+			} else {
+				logger.Infof("requeue job %s of workflow %s task %d since agent %s died", job.JobName, job.WorkflowName, job.TaskID, vm.Name)
+				job.Status = string(config.StatusCreated)
+				job.VMID = ""
+				job.RequeueCount++
+			}
+			if err := vmmongodb.NewVMJobColl().Update(job.ID.Hex(), job); err != nil {
+				logger.Errorf("failed to requeue job %s, error: %s", job.ID.Hex(), err)
+			}
+		}
+	}
+}
@@ -18,7 +18,7 @@ package service
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
 	"strings"
 	"time"
@@ -27,8 +27,10 @@ import (
 
 	utilconfig "github.com/koderover/zadig/v2/pkg/config"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	vmmodel "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models/vm"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	vmmongodb "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb/vm"
 	"github.com/koderover/zadig/v2/pkg/tool/cache"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
 	s3tool "github.com/koderover/zadig/v2/pkg/tool/s3"
@@ -69,25 +71,15 @@ func savaVMJobLog(job *vmmodel.VMJob, logContent string, logger *zap.SugaredLogg
 		VMJobStatus.Set(job.ID.Hex())
 	}
 
-	var file string
-	if job != nil && job.LogFile == "" && logContent != "" {
-		file, err = util.CreateVMJobLogFile(job.ID.Hex())
-		if err != nil {
-			return fmt.Errorf("failed to generate tmp file, error: %s", err)
-		}
-		job.LogFile = file
-	} else {
-		file = job.LogFile
-	}
-
+	// the log is appended to the object storage chunk by chunk, so that any aslan replica can serve the log stream
 	if logContent != "" {
-		err = util.WriteFile(file, []byte(logContent), 0644)
-		if err != nil {
-			return fmt.Errorf("failed to write log to file, error: %s", err)
+		if err = uploadVMJobLogChunk(job, logContent); err != nil {
+			logger.Errorf("failed to upload job log chunk to s3, project:%s workflow:%s taskID%d error: %s", job.ProjectName, job.WorkflowName, job.TaskID, err)
+			return fmt.Errorf("failed to upload job log chunk to s3, error: %s", err)
 		}
 	}
 
-	// after the task execution ends, synchronize the logs in the file to s3
+	// after the task execution ends, merge the log chunks into the job log
 	if job.JobFinished() {
 		if err = uploadVMJobLog2S3(job); err != nil {
 			logger.Errorf("failed to upload job log to s3, project:%s workflow:%s taskID%d error: %s", job.ProjectName, job.WorkflowName, job.TaskID, err)
@@ -100,44 +92,113 @@ func savaVMJobLog(job *vmmodel.VMJob, logContent string, logger *zap.SugaredLogg
 	return
 }
 
-func uploadVMJobLog2S3(job *vmmodel.VMJob) error {
+func getVMJobLogStore(job *vmmodel.VMJob) (*commonmodels.S3Storage, *s3tool.Client, error) {
 	store, err := commonrepo.NewS3StorageColl().FindDefault()
 	if err != nil {
-		return fmt.Errorf("failed to get default s3 storage: %s", err)
+		return nil, nil, fmt.Errorf("failed to get default s3 storage: %s", err)
+	}
+	if store.Subfolder != "" {
+		store.Subfolder = fmt.Sprintf("%s/%s/%d/%s", store.Subfolder, strings.ToLower(job.WorkflowName), job.TaskID, "log")
+	} else {
+		store.Subfolder = fmt.Sprintf("%s/%d/%s", strings.ToLower(job.WorkflowName), job.TaskID, "log")
+	}
+	s3client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Region, store.Insecure, store.Provider)
+	if err != nil {
+		return nil, nil, fmt.Errorf("s3 create client error: %v", err)
 	}
+	return store, s3client, nil
+}
 
-	if job.LogFile != "" {
-		if store.Subfolder != "" {
-			store.Subfolder = fmt.Sprintf("%s/%s/%d/%s", store.Subfolder, strings.ToLower(job.WorkflowName), job.TaskID, "log")
-		} else {
-			store.Subfolder = fmt.Sprintf("%s/%d/%s", strings.ToLower(job.WorkflowName), job.TaskID, "log")
-		}
-		s3client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Region, store.Insecure, store.Provider)
+func vmJobLogFileName(job *vmmodel.VMJob) string {
+	return strings.Replace(strings.ToLower(job.JobName), "_", "-", -1)
+}
+
+func vmJobLogChunkKey(subfolder string, job *vmmodel.VMJob, seq int) string {
+	return GetObjectPath(subfolder, fmt.Sprintf("chunks/%s/%08d.log", vmJobLogFileName(job), seq))
+}
+
+func uploadVMJobLogChunk(job *vmmodel.VMJob, logContent string) error {
+	store, s3client, err := getVMJobLogStore(job)
+	if err != nil {
+		return err
+	}
+
+	seq, err := vmmongodb.NewVMJobColl().IncLogChunks(job.ID.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to get log chunk sequence: %s", err)
+	}
+	if err = s3client.UploadContent(store.Bucket, vmJobLogChunkKey(store.Subfolder, job, seq), []byte(logContent)); err != nil {
+		return err
+	}
+	job.LogChunks = seq
+	return nil
+}
+
+// GetVMJobLogChunks returns the log content of the chunks after the given sequence, and the sequence of the last chunk read.
+// The sequence is allocated before the chunk is uploaded, so the chunks failed to upload are missing and skipped.
+func GetVMJobLogChunks(job *vmmodel.VMJob, after int) (string, int, error) {
+	if job.LogChunks <= after {
+		return "", after, nil
+	}
+	store, s3client, err := getVMJobLogStore(job)
+	if err != nil {
+		return "", after, err
+	}
+
+	buf := &strings.Builder{}
+	for seq := after + 1; seq <= job.LogChunks; seq++ {
+		obj, err := s3client.GetFile(store.Bucket, vmJobLogChunkKey(store.Subfolder, job, seq), &s3tool.DownloadOption{RetryNum: 2, IgnoreNotExistError: true})
 		if err != nil {
-			return fmt.Errorf("saveContainerLog s3 create client error: %v", err)
+			return buf.String(), seq - 1, err
+		}
+		if obj == nil {
+			// the last chunk of a running job may be still uploading, read it next time
+			if seq == job.LogChunks && !job.JobFinished() {
+				return buf.String(), seq - 1, nil
+			}
+			continue
 		}
-		fileName := strings.Replace(strings.ToLower(job.JobName), "_", "-", -1)
-		objectKey := GetObjectPath(store.Subfolder, fileName+".log")
-		if err = s3client.Upload(
-			store.Bucket,
-			job.LogFile,
-			objectKey,
-		); err != nil {
-			return fmt.Errorf("saveContainerLog s3 Upload error: %v", err)
+		_, err = io.Copy(buf, obj.Body)
+		obj.Body.Close()
+		if err != nil {
+			return buf.String(), seq - 1, err
 		}
+	}
+	return buf.String(), job.LogChunks, nil
+}
 
-		// remove the log file later
-		util.Go(func() {
-			time.Sleep(5 * time.Second)
-			err = os.Remove(job.LogFile)
-			if err != nil {
-				log.Errorf("Failed to remove vm job log file, error: %v", err)
-			}
-		})
+func uploadVMJobLog2S3(job *vmmodel.VMJob) error {
+	if job.LogChunks == 0 {
+		return nil
+	}
 
-		log.Infof("saveContainerLog s3 upload success, workflowName:%s jobName:%s, taskID:%d", job.WorkflowName, job.JobName, job.TaskID)
+	store, s3client, err := getVMJobLogStore(job)
+	if err != nil {
+		return err
 	}
 
+	content, _, err := GetVMJobLogChunks(job, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read log chunks: %v", err)
+	}
+	objectKey := GetObjectPath(store.Subfolder, vmJobLogFileName(job)+".log")
+	if err = s3client.UploadContent(store.Bucket, objectKey, []byte(content)); err != nil {
+		return fmt.Errorf("saveContainerLog s3 Upload error: %v", err)
+	}
+
+	// remove the log chunks later, the log stream may be still reading them
+	chunks := make([]string, 0, job.LogChunks)
+	for seq := 1; seq <= job.LogChunks; seq++ {
+		chunks = append(chunks, vmJobLogChunkKey(store.Subfolder, job, seq))
+	}
+	util.Go(func() {
+		time.Sleep(time.Minute)
+		if err := s3client.DeleteObjects(store.Bucket, chunks); err != nil {
+			log.Errorf("Failed to remove vm job log chunks, error: %v", err)
+		}
+	})
+
+	log.Infof("saveContainerLog s3 upload success, workflowName:%s jobName:%s, taskID:%d", job.WorkflowName, job.JobName, job.TaskID)
 	return nil
 }
 
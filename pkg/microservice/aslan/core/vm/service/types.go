@@ -114,6 +114,7 @@ type HeartbeatParameters struct {
 	DiskSpace     uint64 `json:"disk_space"`
 	FreeDiskSpace uint64 `json:"free_disk_space"`
 	VMname        string `json:"vm_name"`
+	AgentVersion  string `json:"agent_version"`
 }
 
 type HeartbeatRequest struct {
@@ -125,6 +126,7 @@ type HeartbeatResponse struct {
 	NeedOffline            bool         `json:"need_offline"`
 	NeedUpdateAgentVersion bool         `json:"need_update_agent_version"`
 	AgentVersion           string       `json:"agent_version"`
+	AgentDownloadURL       string       `json:"agent_download_url"`
 	ScheduleWorkflow       bool         `json:"schedule_workflow"`
 	WorkDir                string       `json:"work_dir"`
 	Concurrency            int          `json:"concurrency"`
@@ -480,6 +480,14 @@ func Heartbeat(args *HeartbeatRequest, logger *zap.SugaredLogger) (*HeartbeatRes
 		return nil, fmt.Errorf("zadig server vm %s agent is nil in db", args.Token)
 	}
 	vm.Agent.LastHeartbeatTime = time.Now().Unix()
+	// the upgrade is finished when the agent reports the version it is upgrading to
+	if args.Parameters != nil && args.Parameters.AgentVersion != "" {
+		vm.Agent.AgentVersion = args.Parameters.AgentVersion
+		if vm.Agent.NeedUpdate && vm.Agent.UpgradeVersion == args.Parameters.AgentVersion {
+			vm.Agent.NeedUpdate = false
+			vm.Agent.UpgradeVersion = ""
+		}
+	}
 
 	err = commonrepo.NewPrivateKeyColl().Update(vm.ID.Hex(), vm)
 	if err != nil {
@@ -487,9 +495,15 @@ func Heartbeat(args *HeartbeatRequest, logger *zap.SugaredLogger) (*HeartbeatRes
 		return nil, fmt.Errorf("failed to update vm %s, error: %s", args.Token, err)
 	}
 
-	if vm.Agent.NeedUpdate {
-		resp.NeedUpdateAgentVersion = true
-		resp.AgentVersion = vm.Agent.AgentVersion
+	if vm.Agent.NeedUpdate && vm.Agent.UpgradeVersion != "" {
+		downloadURL, err := getAgentDownloadURL(vm, vm.Agent.UpgradeVersion)
+		if err != nil {
+			logger.Errorf("failed to get the download url of zadig-agent for vm %s, error: %s", vm.Name, err)
+		} else {
+			resp.NeedUpdateAgentVersion = true
+			resp.AgentVersion = vm.Agent.UpgradeVersion
+			resp.AgentDownloadURL = downloadURL
+		}
 	}
 
 	resp.ScheduleWorkflow = vm.ScheduleWorkflow
@@ -611,7 +625,7 @@ type ReportAgentJobResp struct {
 }
 
 func ReportAgentJob(args *ReportJobArgs, logger *zap.SugaredLogger) (*ReportAgentJobResp, error) {
-	_, err := commonrepo.NewPrivateKeyColl().Find(commonrepo.FindPrivateKeyOption{
+	vm, err := commonrepo.NewPrivateKeyColl().Find(commonrepo.FindPrivateKeyOption{
 		Token: args.Token,
 	})
 	if err != nil {
@@ -626,6 +640,14 @@ func ReportAgentJob(args *ReportJobArgs, logger *zap.SugaredLogger) (*ReportAgen
 		return nil, fmt.Errorf("failed to find job %s, error: %s", args.JobID, err)
 	}
 
+	// the job has been requeued to the other agent since the agent is regarded as dead, stop it
+	if job.VMID != vm.ID.Hex() {
+		return &ReportAgentJobResp{
+			JobID:     job.ID.Hex(),
+			JobStatus: string(config.StatusCancelled),
+		}, nil
+	}
+
 	// if job is cancelled or timeout, stop agent job
 	if job.Status == string(config.StatusCancelled) || job.Status == string(config.StatusTimeout) {
 		return &ReportAgentJobResp{
@@ -0,0 +1,208 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/webhook"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Alert Receivers
+// @Description List the Alertmanager receivers of the project
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	projectName	query		string								true	"project name"
+// @Success 200 		{array} 	commonmodels.AlertReceiver
+// @Router /api/aslan/workflow/alertreceiver [get]
+func ListAlertReceivers(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = webhook.ListAlertReceivers(projectKey, ctx.Logger)
+}
+
+// @Summary Create Alert Receiver
+// @Description Create an Alertmanager receiver for the project
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	body 		body 		commonmodels.AlertReceiver 			true 	"body"
+// @Success 200
+// @Router /api/aslan/workflow/alertreceiver [post]
+func CreateAlertReceiver(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	req := new(commonmodels.AlertReceiver)
+	if err := c.ShouldBindJSON(req); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, req.ProjectName, "新建", "告警接收器", req.Name, getBody(c), ctx.Logger)
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[req.ProjectName]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[req.ProjectName].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = webhook.CreateAlertReceiver(ctx.UserName, req, ctx.Logger)
+}
+
+// @Summary Update Alert Receiver
+// @Description Update an Alertmanager receiver of the project
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	id 			path		string								true	"receiver id"
+// @Param 	body 		body 		commonmodels.AlertReceiver 			true 	"body"
+// @Success 200
+// @Router /api/aslan/workflow/alertreceiver/{id} [put]
+func UpdateAlertReceiver(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	req := new(commonmodels.AlertReceiver)
+	if err := c.ShouldBindJSON(req); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, req.ProjectName, "更新", "告警接收器", req.Name, getBody(c), ctx.Logger)
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[req.ProjectName]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[req.ProjectName].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = webhook.UpdateAlertReceiver(ctx.UserName, c.Param("id"), req, ctx.Logger)
+}
+
+// @Summary Delete Alert Receiver
+// @Description Delete an Alertmanager receiver of the project with its alerts
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	id 			path		string								true	"receiver id"
+// @Param 	projectName	query		string								true	"project name"
+// @Success 200
+// @Router /api/aslan/workflow/alertreceiver/{id} [delete]
+func DeleteAlertReceiver(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectKey, "删除", "告警接收器", c.Param("id"), "", ctx.Logger)
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[projectKey]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[projectKey].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.RespErr = webhook.DeleteAlertReceiver(projectKey, c.Param("id"), ctx.Logger)
+}
+
+// AlertmanagerEventHandler receives the webhook of the Alertmanager, the token of the receiver is
+// carried by the token query or the Authorization header
+func AlertmanagerEventHandler(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	body, err := c.GetRawData()
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	ctx.RespErr = webhook.HandleAlertmanagerEvent(c.Param("id"), token, body, ctx.Logger)
+}
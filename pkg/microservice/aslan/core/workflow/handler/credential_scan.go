@@ -0,0 +1,98 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary Scan Workflow Credentials
+// @Description Scan the plaintext credentials embedded in the workflow, the values are masked in the response
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string		true	"workflow name"
+// @Success 200 		{array} 	commonutil.CredentialFinding
+// @Router /api/aslan/workflow/v4/{name}/credentialScan [get]
+func ScanWorkflowV4Credentials(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	w, permitted, err := canEditWorkflowV4(ctx, c.Param("name"))
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = workflow.ScanWorkflowV4Credentials(w.Name, ctx.Logger)
+}
+
+// @Summary Migrate Workflow Credentials
+// @Description Move the plaintext credentials found in the workflow into credential params
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string							true	"workflow name"
+// @Param 	body 		body 		workflow.MigrateCredentialsArgs 	true 	"body"
+// @Success 200 		{object} 	workflow.MigrateCredentialsResp
+// @Router /api/aslan/workflow/v4/{name}/credentialScan/migrate [post]
+func MigrateWorkflowV4Credentials(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(workflow.MigrateCredentialsArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	w, permitted, err := canEditWorkflowV4(ctx, c.Param("name"))
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, w.Project, "迁移", "自定义工作流-明文凭证", w.Name, "", ctx.Logger)
+
+	ctx.Resp, ctx.RespErr = workflow.MigrateWorkflowV4Credentials(w.Name, ctx.UserName, args, ctx.Logger)
+}
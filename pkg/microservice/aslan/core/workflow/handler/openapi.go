@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/koderover/zadig/v2/pkg/types"
 
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	workflowservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
 	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
@@ -56,6 +57,19 @@ func CreateCustomWorkflowTask(c *gin.Context) {
 		}
 	}
 
+	savedWorkflow, err := workflowservice.FindWorkflowV4Raw(args.WorkflowName, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if permitted, err := checkEnvKubeAccessPermission(ctx, savedWorkflow); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	} else if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
 	ctx.Resp, ctx.RespErr = workflowservice.CreateCustomWorkflowTask(ctx.UserName, args, ctx.Logger)
 }
 
@@ -551,7 +565,7 @@ func OpenAPIApproveStage(c *gin.Context) {
 		return
 	}
 
-	ctx.RespErr = workflowservice.ApproveStage(args.WorkflowName, args.StageName, ctx.UserName, ctx.UserID, args.Comment, args.TaskID, args.Approve, ctx.Logger)
+	ctx.RespErr = workflowservice.ApproveStage(args.WorkflowName, args.StageName, ctx.UserName, ctx.UserID, args.Comment, args.TaskID, args.Approve, config.ApprovalChannelOpenAPI, ctx.Logger)
 }
 
 func generalRequestValidate(c *gin.Context) (string, int64, error) {
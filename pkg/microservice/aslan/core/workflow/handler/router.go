@@ -31,6 +31,25 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		webhook.POST("", ProcessWebHook)
 	}
 
+	imagePush := router.Group("imagepush")
+	{
+		imagePush.GET("", ListImagePushTriggers)
+		imagePush.POST("", CreateImagePushTrigger)
+		imagePush.PUT("/:id", UpdateImagePushTrigger)
+		imagePush.DELETE("/:id", DeleteImagePushTrigger)
+		imagePush.GET("/:id/event", ListImagePushEvents)
+		imagePush.POST("/:id/webhook", ImagePushEventHandler)
+	}
+
+	alertReceiver := router.Group("alertreceiver")
+	{
+		alertReceiver.GET("", ListAlertReceivers)
+		alertReceiver.POST("", CreateAlertReceiver)
+		alertReceiver.PUT("/:id", UpdateAlertReceiver)
+		alertReceiver.DELETE("/:id", DeleteAlertReceiver)
+		alertReceiver.POST("/:id/webhook", AlertmanagerEventHandler)
+	}
+
 	build := router.Group("build")
 	{
 		build.GET("/:name/:version/to/subtasks", BuildModuleToSubTasks)
@@ -152,6 +171,12 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		workflowV4.POST("/repo/:jobName", GetWorkflowRepoIndex)
 		workflowV4.GET("/name/:name", FindWorkflowV4)
 		workflowV4.PUT("/:name", UpdateWorkflowV4)
+		workflowV4.PUT("/:name/code", SetWorkflowCodeSource)
+		workflowV4.DELETE("/:name/code", DeleteWorkflowCodeSource)
+		workflowV4.POST("/:name/code/sync", SyncWorkflowFromCode)
+		workflowV4.POST("/:name/code/export", ExportWorkflowToCode)
+		workflowV4.GET("/:name/credentialScan", ScanWorkflowV4Credentials)
+		workflowV4.POST("/:name/credentialScan/migrate", MigrateWorkflowV4Credentials)
 		workflowV4.DELETE("/:name", DeleteWorkflowV4)
 		workflowV4.GET("/preset/:name", GetWorkflowV4Preset)
 		workflowV4.POST("/dynamicVariable/available", GetWorkflowV4DynamicVariableAvailable)
@@ -182,6 +207,19 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		workflowV4.POST("/cron/:workflowName", CreateCronForWorkflowV4)
 		workflowV4.PUT("/cron", UpdateCronForWorkflowV4)
 		workflowV4.DELETE("/cron/:workflowName/trigger/:cronID", DeleteCronForWorkflowV4)
+		workflowV4.GET("/schedule/:workflowName", ListWorkflowSchedules)
+		workflowV4.POST("/schedule/:workflowName", CreateWorkflowSchedule)
+		workflowV4.PUT("/schedule/:workflowName/:id", UpdateWorkflowSchedule)
+		workflowV4.DELETE("/schedule/:workflowName/:id", DeleteWorkflowSchedule)
+		workflowV4.GET("/artifact/retention", GetArtifactRetentionPolicy)
+		workflowV4.PUT("/artifact/retention", UpdateArtifactRetentionPolicy)
+		workflowV4.GET("/artifact/usage", GetArtifactUsage)
+		workflowV4.GET("/queue", ListQueuedWorkflowTasks)
+		workflowV4.POST("/queue/priority", UpdateQueuedTaskPriority)
+		workflowV4.POST("/queue/cancel", CancelQueuedWorkflowTasks)
+		workflowV4.GET("/coverage/trend", GetCoverageTrend)
+		workflowV4.GET("/jobfailure/:workflowName", ListWorkflowJobFailureStats)
+		workflowV4.GET("/kubeaccess", ListJobKubeAccessGrants)
 		workflowV4.POST("/patch", GetPatchParams)
 		workflowV4.GET("/sharestorage", CheckShareStorageEnabled)
 		workflowV4.GET("/all", ListAllAvailableWorkflows)
@@ -205,6 +243,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		taskV4.GET("/filter/workflow/:name", GetWorkflowTaskFilters)
 		taskV4.GET("", ListWorkflowTaskV4ByFilter)
 		taskV4.GET("/workflow/:workflowName/task/:taskID", GetWorkflowTaskV4)
+		taskV4.GET("/workflow/:workflowName/compare", CompareWorkflowTasksV4)
 		taskV4.DELETE("/workflow/:workflowName/task/:taskID", CancelWorkflowTaskV4)
 		taskV4.GET("/clone/workflow/:workflowName/task/:taskID", CloneWorkflowTaskV4)
 		taskV4.GET("/view/workflow/:workflowName/task/:taskID", ViewWorkflowTaskV4)
@@ -217,10 +256,15 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		taskV4.POST("/revert/:workflowName/:jobName/task/:taskID", RevertWorkflowTaskV4Job)
 		taskV4.GET("/revert/:workflowName/:jobName/task/:taskID", GetWorkflowTaskV4JobRevert)
 		taskV4.POST("/approve", ApproveStage)
+		taskV4.POST("/approve/callback", ApproveStageByCallback)
 		taskV4.POST("/handle/error", HandleJobError)
 		taskV4.GET("/workflow/:workflowName/taskId/:taskId/job/:jobName", GetWorkflowV4ArtifactFileContent)
 		taskV4.GET("/workflow/:workflowName/taskId/:taskId/job/:jobName/build", GetWorkflowV4BuildJobArtifactFile)
 		taskV4.PUT("/workflow/:workflowName/taskId/:taskId/remark", UpdateWorkflowV4TaskRemark)
+		taskV4.GET("/workflow/:workflowName/task/:taskID/artifact", ListWorkflowTaskArtifacts)
+		taskV4.GET("/workflow/:workflowName/artifact/:id/download", DownloadWorkflowTaskArtifact)
+		taskV4.GET("/workflow/:workflowName/task/:taskID/coverage", ListWorkflowTaskCoverage)
+		taskV4.GET("/workflow/:workflowName/task/:taskID/job/:jobName/checkpoint", GetWorkflowTaskJobCheckpoints)
 		taskV4.POST("/trigger", CreateWorkflowTaskV4ByBuildInTrigger)
 	}
 
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary List Queued Workflow Tasks
+// @Description List the workflow tasks waiting in the queue grouped by cluster, with the reasons they are not scheduled yet
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Success 200 		{array} 	workflow.ClusterQueuedTasks
+// @Router /api/aslan/workflow/v4/queue [get]
+func ListQueuedWorkflowTasks(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = workflow.ListQueuedWorkflowTasks(ctx.Logger)
+}
+
+type updateQueuedTaskPriorityReq struct {
+	Tasks    []*workflow.QueuedTaskRef `json:"tasks"`
+	Priority int                       `json:"priority"`
+}
+
+// @Summary Update Queued Workflow Task Priority
+// @Description Set the priority of the waiting workflow tasks, the tasks with higher priority are scheduled first
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	body 		body 		updateQueuedTaskPriorityReq 	true 	"body"
+// @Success 200
+// @Router /api/aslan/workflow/v4/queue/priority [post]
+func UpdateQueuedTaskPriority(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	req := new(updateQueuedTaskPriorityReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+	if len(req.Tasks) == 0 {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("tasks can not be empty")
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "工作流任务队列-优先级", "", getBody(c), ctx.Logger)
+
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.RespErr = workflow.UpdateQueuedTaskPriority(req.Tasks, req.Priority, ctx.Logger)
+}
+
+type cancelQueuedWorkflowTasksReq struct {
+	Tasks []*workflow.QueuedTaskRef `json:"tasks"`
+}
+
+// @Summary Cancel Queued Workflow Tasks
+// @Description Cancel the workflow tasks waiting in the queue in bulk
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	body 		body 		cancelQueuedWorkflowTasksReq 	true 	"body"
+// @Success 200
+// @Router /api/aslan/workflow/v4/queue/cancel [post]
+func CancelQueuedWorkflowTasks(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	req := new(cancelQueuedWorkflowTasksReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+	if len(req.Tasks) == 0 {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("tasks can not be empty")
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "取消", "工作流任务队列", "", getBody(c), ctx.Logger)
+
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.RespErr = workflow.CancelQueuedWorkflowTasks(ctx.UserName, req.Tasks, ctx.Logger)
+}
@@ -29,6 +29,7 @@ import (
 
 	"github.com/koderover/zadig/v2/pkg/types"
 
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
 	"github.com/koderover/zadig/v2/pkg/setting"
@@ -96,11 +97,31 @@ func CreateWorkflowTaskV4(c *gin.Context) {
 		}
 	}
 
+	// the kube access of the saved workflow is granted as well, so both are checked against the task creator
+	savedWorkflow, err := workflow.FindWorkflowV4Raw(args.Name, ctx.Logger)
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if permitted, err := checkEnvKubeAccessPermission(ctx, args, savedWorkflow); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	} else if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	freezeOverrideReason := internalhandler.GetFreezeOverrideReason(c)
+	if freezeOverrideReason != "" {
+		internalhandler.SetAuditLogFreezeOverrideReason(c, freezeOverrideReason)
+	}
+
 	ctx.Resp, ctx.RespErr = workflow.CreateWorkflowTaskV4(&workflow.CreateWorkflowTaskV4Args{
-		Name:             ctx.UserName,
-		Account:          ctx.Account,
-		UserID:           ctx.UserID,
-		ApprovalTicketID: ticketID,
+		Name:                 ctx.UserName,
+		Account:              ctx.Account,
+		UserID:               ctx.UserID,
+		ApprovalTicketID:     ticketID,
+		FreezeOverrideReason: freezeOverrideReason,
 	}, args, ctx.Logger)
 }
 
@@ -172,6 +193,172 @@ func ListWorkflowTaskV4ByFilter(c *gin.Context) {
 	ctx.RespErr = err
 }
 
+// @Summary Compare Workflow Tasks V4
+// @Description Compare the parameters, job durations, images and commits of two runs of the workflow
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	workflowName	path		string									true	"workflow name"
+// @Param 	base			query		int										true	"base task id"
+// @Param 	target			query		int										true	"target task id"
+// @Success 200 			{object} 	workflow.WorkflowTaskComparison
+// @Router /api/aslan/workflow/v4/workflowtask/workflow/{workflowName}/compare [get]
+func CompareWorkflowTasksV4(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	baseTaskID, err := strconv.ParseInt(c.Query("base"), 10, 64)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid base task id")
+		return
+	}
+	targetTaskID, err := strconv.ParseInt(c.Query("target"), 10, 64)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid target task id")
+		return
+	}
+
+	w, err := workflow.FindWorkflowV4Raw(c.Param("workflowName"), ctx.Logger)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[w.Project]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[w.Project].IsProjectAdmin &&
+			!ctx.Resources.ProjectAuthInfo[w.Project].Workflow.View {
+			// check if the permission is given by collaboration mode
+			permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, w.Project, types.ResourceTypeWorkflow, w.Name, types.WorkflowActionView)
+			if err != nil || !permitted {
+				ctx.UnAuthorized = true
+				return
+			}
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = workflow.CompareWorkflowTasksV4(w.Name, baseTaskID, targetTaskID, ctx.Logger)
+}
+
+// @Summary List Workflow Job Failure Stats
+// @Description Summarize the failures of the jobs of the workflow, the jobs failed by the infrastructure are marked as flaky
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	workflowName	path		string									true	"workflow name"
+// @Param 	days			query		int										false	"recent days, 30 by default"
+// @Success 200 			{array} 	workflow.WorkflowJobFailureStat
+// @Router /api/aslan/workflow/v4/jobfailure/{workflowName} [get]
+func ListWorkflowJobFailureStats(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	days := 0
+	if c.Query("days") != "" {
+		days, err = strconv.Atoi(c.Query("days"))
+		if err != nil {
+			ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid days")
+			return
+		}
+	}
+
+	w, err := workflow.FindWorkflowV4Raw(c.Param("workflowName"), ctx.Logger)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[w.Project]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[w.Project].IsProjectAdmin &&
+			!ctx.Resources.ProjectAuthInfo[w.Project].Workflow.View {
+			// check if the permission is given by collaboration mode
+			permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, w.Project, types.ResourceTypeWorkflow, w.Name, types.WorkflowActionView)
+			if err != nil || !permitted {
+				ctx.UnAuthorized = true
+				return
+			}
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = workflow.ListWorkflowJobFailureStats(w.Name, days, ctx.Logger)
+}
+
+// @Summary Get Workflow Task Job Checkpoints
+// @Description Get the progress checkpoints reported by the pods of the long-running test job
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	workflowName	path		string									true	"workflow name"
+// @Param 	taskID			path		int										true	"task id"
+// @Param 	jobName			path		string									true	"job name"
+// @Success 200 			{object} 	workflow.JobCheckpointDetail
+// @Router /api/aslan/workflow/v4/workflowtask/workflow/{workflowName}/task/{taskID}/job/{jobName}/checkpoint [get]
+func GetWorkflowTaskJobCheckpoints(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	w, err := workflow.FindWorkflowV4Raw(c.Param("workflowName"), ctx.Logger)
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[w.Project]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+
+		if !ctx.Resources.ProjectAuthInfo[w.Project].IsProjectAdmin &&
+			!ctx.Resources.ProjectAuthInfo[w.Project].Workflow.View {
+			// check if the permission is given by collaboration mode
+			permitted, err := internalhandler.GetCollaborationModePermission(ctx.UserID, w.Project, types.ResourceTypeWorkflow, w.Name, types.WorkflowActionView)
+			if err != nil || !permitted {
+				ctx.UnAuthorized = true
+				return
+			}
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = workflow.GetWorkflowTaskJobCheckpoints(w.Name, taskID, c.Param("jobName"), ctx.Logger)
+}
+
 func GetWorkflowTaskV4(c *gin.Context) {
 	ctx, err := internalhandler.NewContextWithAuthorization(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -766,7 +953,22 @@ func ApproveStage(c *gin.Context) {
 		return
 	}
 
-	ctx.RespErr = workflow.ApproveStage(args.WorkflowName, args.JobName, ctx.UserName, ctx.UserID, args.Comment, args.TaskID, args.Approve, ctx.Logger)
+	ctx.RespErr = workflow.ApproveStage(args.WorkflowName, args.JobName, ctx.UserName, ctx.UserID, args.Comment, args.TaskID, args.Approve, config.ApprovalChannelZadig, ctx.Logger)
+}
+
+// ApproveStageByCallback resolves native approval from external systems, the request is authenticated by
+// the signature header instead of user token
+func ApproveStageByCallback(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	data, err := c.GetRawData()
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	ctx.RespErr = workflow.ApproveStageByCallback(data, c.GetHeader("X-Zadig-Signature"), ctx.Logger)
 }
 
 type HandleJobErrorRequest struct {
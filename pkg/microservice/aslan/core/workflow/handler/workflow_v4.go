@@ -115,6 +115,14 @@ func CreateWorkflowV4(c *gin.Context) {
 		}
 	}
 
+	if permitted, err := checkEnvKubeAccessPermission(ctx, args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	} else if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
 	if err := workflow.CreateWorkflowV4(ctx.UserName, args, ctx.Logger); err != nil {
 		ctx.RespErr = err
 		return
@@ -332,6 +340,14 @@ func UpdateWorkflowV4(c *gin.Context) {
 		}
 	}
 
+	if permitted, err := checkEnvKubeAccessPermission(ctx, args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	} else if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
 	ctx.RespErr = workflow.UpdateWorkflowV4(c.Param("name"), ctx.UserName, args, ctx.Logger)
 }
 
@@ -1072,7 +1088,12 @@ func DeleteGeneralHookForWorkflowV4(c *gin.Context) {
 func GeneralHookEventHandler(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
-	ctx.RespErr = workflow.GeneralHookEventHandler(c.Param("workflowName"), c.Param("hookName"), ctx.Logger)
+	body, err := c.GetRawData()
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	ctx.RespErr = workflow.GeneralHookEventHandler(c.Param("workflowName"), c.Param("hookName"), c.Request.Header, body, ctx.Logger)
 }
 
 func GetCronForWorkflowV4Preset(c *gin.Context) {
@@ -1490,3 +1511,101 @@ func HelmDeployJobMergeImage(c *gin.Context) {
 	}
 	ctx.Resp, ctx.RespErr = workflow.HelmDeployJobMergeImage(ctx, projectName, req.EnvName, req.ServiceName, req.ValuesYaml, images, req.IsProduction, req.UpdateServiceRevision)
 }
+
+// @Summary List Job Kube Access Grants
+// @Description List the audit records of the env kube access granted to the jobs of the project
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	projectName		query		string									true	"project name"
+// @Param 	workflowName	query		string									false	"workflow name"
+// @Param 	envName			query		string									false	"env name"
+// @Param 	pageNum			query		int										false	"page num"
+// @Param 	pageSize		query		int										false	"page size"
+// @Success 200 			{object} 	workflow.ListJobKubeAccessGrantsResp
+// @Router /api/aslan/workflow/v4/kubeaccess [get]
+func ListJobKubeAccessGrants(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(workflow.ListJobKubeAccessGrantsArgs)
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if args.ProjectName == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	// authorization check
+	if !ctx.Resources.IsSystemAdmin {
+		if _, ok := ctx.Resources.ProjectAuthInfo[args.ProjectName]; !ok {
+			ctx.UnAuthorized = true
+			return
+		}
+		if !ctx.Resources.ProjectAuthInfo[args.ProjectName].IsProjectAdmin {
+			ctx.UnAuthorized = true
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = workflow.ListJobKubeAccessGrants(args, ctx.Logger)
+}
+
+// checkEnvKubeAccessPermission checks that the user is allowed to operate the envs the kube access of the workflow
+// jobs are granted to: env view permission for read-only access, env edit permission otherwise since the write access
+// can read the secrets of the namespace.
+func checkEnvKubeAccessPermission(ctx *internalhandler.Context, workflows ...*commonmodels.WorkflowV4) (bool, error) {
+	if ctx.Resources.IsSystemAdmin {
+		return true, nil
+	}
+	for _, w := range workflows {
+		if w == nil {
+			continue
+		}
+		accesses, err := workflow.ListWorkflowEnvKubeAccess(w)
+		if err != nil {
+			return false, err
+		}
+		if len(accesses) == 0 {
+			continue
+		}
+		authInfo, ok := ctx.Resources.ProjectAuthInfo[w.Project]
+		if !ok {
+			return false, nil
+		}
+		if authInfo.IsProjectAdmin {
+			continue
+		}
+		for _, access := range accesses {
+			var permitted bool
+			var action string
+			switch {
+			case access.Production && access.ReadOnly:
+				permitted, action = authInfo.ProductionEnv.View, types.ProductionEnvActionView
+			case access.Production:
+				permitted, action = authInfo.ProductionEnv.EditConfig, types.ProductionEnvActionEditConfig
+			case access.ReadOnly:
+				permitted, action = authInfo.Env.View, types.EnvActionView
+			default:
+				permitted, action = authInfo.Env.EditConfig, types.EnvActionEditConfig
+			}
+			if permitted {
+				continue
+			}
+			// check if the permission is given by collaboration mode
+			permitted, err = internalhandler.GetCollaborationModePermission(ctx.UserID, w.Project, types.ResourceTypeEnvironment, access.EnvName, action)
+			if err != nil || !permitted {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
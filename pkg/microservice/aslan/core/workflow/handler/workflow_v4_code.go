@@ -0,0 +1,194 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// canEditWorkflowV4 finds the project of the workflow and checks whether the user can edit it
+func canEditWorkflowV4(ctx *internalhandler.Context, name string) (*commonmodels.WorkflowV4, bool, error) {
+	w, err := workflow.FindWorkflowV4Raw(name, ctx.Logger)
+	if err != nil {
+		return nil, false, e.ErrFindWorkflow.AddErr(err)
+	}
+	if ctx.Resources.IsSystemAdmin {
+		return w, true, nil
+	}
+	authInfo, ok := ctx.Resources.ProjectAuthInfo[w.Project]
+	if !ok {
+		return w, false, nil
+	}
+	return w, authInfo.IsProjectAdmin || authInfo.Workflow.Edit, nil
+}
+
+// @Summary Set Workflow Code Source
+// @Description Set the git repo file which the workflow is synced from
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string								true	"workflow name"
+// @Param 	body 		body 		commonmodels.WorkflowCodeSource 	true 	"body"
+// @Success 200
+// @Router /api/aslan/workflow/v4/{name}/code [put]
+func SetWorkflowCodeSource(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.WorkflowCodeSource)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	w, permitted, err := canEditWorkflowV4(ctx, c.Param("name"))
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	detail, _ := json.Marshal(args)
+	internalhandler.InsertOperationLog(c, ctx.UserName, w.Project, "更新", "自定义工作流-代码库同步", w.Name, string(detail), ctx.Logger)
+
+	ctx.RespErr = workflow.SetWorkflowCodeSource(w.Name, args, ctx.Logger)
+}
+
+// @Summary Delete Workflow Code Source
+// @Description Stop syncing the workflow from the git repo
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string		true	"workflow name"
+// @Success 200
+// @Router /api/aslan/workflow/v4/{name}/code [delete]
+func DeleteWorkflowCodeSource(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	w, permitted, err := canEditWorkflowV4(ctx, c.Param("name"))
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, w.Project, "删除", "自定义工作流-代码库同步", w.Name, "", ctx.Logger)
+
+	ctx.RespErr = workflow.DeleteWorkflowCodeSource(w.Name, ctx.Logger)
+}
+
+// @Summary Sync Workflow From Code
+// @Description Sync the workflow from the git repo manually
+// @Tags 	workflow
+// @Accept 	json
+// @Produce json
+// @Param 	name		path		string		true	"workflow name"
+// @Success 200
+// @Router /api/aslan/workflow/v4/{name}/code/sync [post]
+func SyncWorkflowFromCode(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	w, permitted, err := canEditWorkflowV4(ctx, c.Param("name"))
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, w.Project, "同步", "自定义工作流-代码库同步", w.Name, "", ctx.Logger)
+
+	ctx.RespErr = workflow.SyncWorkflowFromCode(w.Name, "", ctx.Logger)
+}
+
+// @Summary Export Workflow To Code
+// @Description Open a pull request to the git repo with the edited workflow
+// @Tags 	workflow
+// @Accept 	plain
+// @Produce json
+// @Param 	name		path		string							true	"workflow name"
+// @Param 	body 		body 		commonmodels.WorkflowV4 		true 	"workflow yaml"
+// @Success 200 		{object} 	workflow.WorkflowCodeExportResult
+// @Router /api/aslan/workflow/v4/{name}/code/export [post]
+func ExportWorkflowToCode(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(commonmodels.WorkflowV4)
+	data := getBody(c)
+	if err := yaml.Unmarshal([]byte(data), args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	w, permitted, err := canEditWorkflowV4(ctx, c.Param("name"))
+	if err != nil {
+		ctx.RespErr = err
+		return
+	}
+	if !permitted {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, w.Project, "导出", "自定义工作流-代码库同步", w.Name, data, ctx.Logger)
+
+	ctx.Resp, ctx.RespErr = workflow.ExportWorkflowToCode(w.Name, ctx.UserName, args, ctx.Logger)
+}
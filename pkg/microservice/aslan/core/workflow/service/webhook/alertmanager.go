@@ -0,0 +1,307 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	workflowservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/prometheus"
+)
+
+const (
+	alertParamName    = "alert_name"
+	alertParamEnv     = "env_name"
+	alertParamService = "service_name"
+
+	// the resolved alerts are kept for a week
+	resolvedAlertRetention = 7 * 24 * time.Hour
+)
+
+// defaultAlertServiceLabels are the labels holding the service name set by the common exporters, the labels of the
+// pods are exported by kube-state-metrics with the label_ prefix
+var defaultAlertServiceLabels = []string{"service", "app", "app_kubernetes_io_name", "label_s_service"}
+
+func ListAlertReceivers(projectName string, logger *zap.SugaredLogger) ([]*commonmodels.AlertReceiver, error) {
+	resp, err := commonrepo.NewAlertReceiverColl().List(projectName)
+	if err != nil {
+		logger.Errorf("failed to list alert receivers of project %s, error: %s", projectName, err)
+		return nil, e.ErrListAlertReceiver.AddErr(err)
+	}
+	for _, receiver := range resp {
+		receiver.Token = setting.MaskValue
+	}
+	return resp, nil
+}
+
+type CreateAlertReceiverResp struct {
+	Token string `json:"token"`
+}
+
+// CreateAlertReceiver creates the receiver, a token is generated if not specified. The token is only returned on
+// creation and masked afterwards.
+func CreateAlertReceiver(userName string, receiver *commonmodels.AlertReceiver, logger *zap.SugaredLogger) (*CreateAlertReceiverResp, error) {
+	if err := validateAlertReceiver(receiver); err != nil {
+		return nil, e.ErrCreateAlertReceiver.AddErr(err)
+	}
+	if receiver.Token == "" || receiver.Token == setting.MaskValue {
+		token, err := genWebhookToken()
+		if err != nil {
+			return nil, e.ErrCreateAlertReceiver.AddErr(err)
+		}
+		receiver.Token = token
+	}
+	receiver.CreatedBy = userName
+	receiver.UpdatedBy = userName
+	if err := commonrepo.NewAlertReceiverColl().Create(receiver); err != nil {
+		logger.Errorf("failed to create alert receiver %s, error: %s", receiver.Name, err)
+		return nil, e.ErrCreateAlertReceiver.AddErr(err)
+	}
+	return &CreateAlertReceiverResp{Token: receiver.Token}, nil
+}
+
+func UpdateAlertReceiver(userName, id string, receiver *commonmodels.AlertReceiver, logger *zap.SugaredLogger) error {
+	origin, err := commonrepo.NewAlertReceiverColl().GetByID(id)
+	if err != nil {
+		return e.ErrUpdateAlertReceiver.AddErr(err)
+	}
+	if origin.ProjectName != receiver.ProjectName {
+		return e.ErrUpdateAlertReceiver.AddDesc("project of the receiver can not be changed")
+	}
+	if err := validateAlertReceiver(receiver); err != nil {
+		return e.ErrUpdateAlertReceiver.AddErr(err)
+	}
+	// the masked or empty token keeps the origin one
+	if receiver.Token == "" || receiver.Token == setting.MaskValue {
+		receiver.Token = origin.Token
+	}
+	if receiver.Token == "" {
+		return e.ErrUpdateAlertReceiver.AddDesc("token of the receiver can not be empty")
+	}
+	receiver.ID = origin.ID
+	receiver.CreatedBy = origin.CreatedBy
+	receiver.CreateTime = origin.CreateTime
+	receiver.UpdatedBy = userName
+	if err := commonrepo.NewAlertReceiverColl().Update(receiver); err != nil {
+		logger.Errorf("failed to update alert receiver %s, error: %s", id, err)
+		return e.ErrUpdateAlertReceiver.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteAlertReceiver(projectName, id string, logger *zap.SugaredLogger) error {
+	receiver, err := commonrepo.NewAlertReceiverColl().GetByID(id)
+	if err != nil {
+		return e.ErrDeleteAlertReceiver.AddErr(err)
+	}
+	if receiver.ProjectName != projectName {
+		return e.ErrDeleteAlertReceiver.AddDesc(fmt.Sprintf("receiver %s not found in project %s", id, projectName))
+	}
+	if err := commonrepo.NewAlertReceiverColl().DeleteByID(id); err != nil {
+		logger.Errorf("failed to delete alert receiver %s, error: %s", id, err)
+		return e.ErrDeleteAlertReceiver.AddErr(err)
+	}
+	if err := commonrepo.NewEnvAlertColl().DeleteByReceiver(id); err != nil {
+		logger.Warnf("failed to delete alerts of receiver %s, error: %s", id, err)
+	}
+	return nil
+}
+
+func validateAlertReceiver(receiver *commonmodels.AlertReceiver) error {
+	if receiver.Name == "" || receiver.ProjectName == "" {
+		return fmt.Errorf("name and project of the receiver can not be empty")
+	}
+	for _, remediation := range receiver.Remediations {
+		if remediation.WorkflowArg == nil {
+			return fmt.Errorf("workflow args are required by the remediation")
+		}
+		if remediation.WorkflowArg.Project != receiver.ProjectName {
+			return fmt.Errorf("workflow %s is not in project %s", remediation.WorkflowArg.Name, receiver.ProjectName)
+		}
+		if remediation.Cooldown < 0 {
+			return fmt.Errorf("cooldown of the remediation can not be negative")
+		}
+		remediation.WorkflowName = remediation.WorkflowArg.Name
+	}
+	return nil
+}
+
+// HandleAlertmanagerEvent maps the alerts of the Alertmanager webhook to the envs of the project by the namespace
+// label, and runs the remediation workflows for the alerts starting to fire
+func HandleAlertmanagerEvent(id, token string, body []byte, logger *zap.SugaredLogger) error {
+	receiver, err := commonrepo.NewAlertReceiverColl().GetByID(id)
+	if err != nil {
+		return e.ErrHandleAlertmanagerEvent.AddErr(err)
+	}
+	if receiver.Token == "" || subtle.ConstantTimeCompare([]byte(receiver.Token), []byte(token)) != 1 {
+		return e.ErrUnauthorized.AddDesc("invalid token")
+	}
+	if !receiver.Enabled {
+		logger.Infof("alert receiver %s is disabled, event ignored", receiver.Name)
+		return nil
+	}
+
+	msg, err := prometheus.ParseAlertmanagerMessage(body)
+	if err != nil {
+		return e.ErrHandleAlertmanagerEvent.AddErr(err)
+	}
+
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{Name: receiver.ProjectName})
+	if err != nil {
+		return e.ErrHandleAlertmanagerEvent.AddErr(err)
+	}
+	envMap := make(map[string]*commonmodels.Product)
+	for _, env := range envs {
+		envMap[env.Namespace] = env
+	}
+
+	for _, alert := range msg.Alerts {
+		env, ok := envMap[alert.Labels[prometheus.AlertLabelNamespace]]
+		if !ok {
+			logger.Debugf("alert %s of namespace %s is not mapped to any env of project %s, skipped", alert.Labels[prometheus.AlertLabelName], alert.Labels[prometheus.AlertLabelNamespace], receiver.ProjectName)
+			continue
+		}
+		if err := handleAlert(receiver, env, alert, logger); err != nil {
+			logger.Errorf("failed to handle alert %s of env %s/%s, error: %s", alert.Fingerprint, env.ProductName, env.EnvName, err)
+		}
+	}
+
+	if err := commonrepo.NewEnvAlertColl().DeleteResolvedBefore(time.Now().Add(-resolvedAlertRetention).Unix()); err != nil {
+		logger.Warnf("failed to clean up the resolved alerts, error: %s", err)
+	}
+	return nil
+}
+
+func handleAlert(receiver *commonmodels.AlertReceiver, env *commonmodels.Product, alert *prometheus.Alert, logger *zap.SugaredLogger) error {
+	receiverID := receiver.ID.Hex()
+	previous, err := commonrepo.NewEnvAlertColl().Get(receiverID, alert.Fingerprint)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	record := &commonmodels.EnvAlert{
+		ReceiverID:   receiverID,
+		ProjectName:  env.ProductName,
+		EnvName:      env.EnvName,
+		Production:   env.Production,
+		ServiceName:  getAlertServiceName(receiver, env, alert),
+		Fingerprint:  alert.Fingerprint,
+		AlertName:    alert.Labels[prometheus.AlertLabelName],
+		Severity:     alert.Labels[prometheus.AlertLabelSeverity],
+		Status:       commonmodels.EnvAlertStatusFiring,
+		Labels:       alert.Labels,
+		Annotations:  alert.Annotations,
+		GeneratorURL: alert.GeneratorURL,
+		StartsAt:     alert.StartsAt.Unix(),
+	}
+	if alert.Status == prometheus.AlertStatusResolved {
+		record.Status = commonmodels.EnvAlertStatusResolved
+		record.EndsAt = alert.EndsAt.Unix()
+	}
+	if err := commonrepo.NewEnvAlertColl().Upsert(record); err != nil {
+		return err
+	}
+
+	// the alertmanager resends the firing alerts periodically, the remediation only runs when the alert starts firing
+	if record.Status != commonmodels.EnvAlertStatusFiring || (previous != nil && previous.Status == commonmodels.EnvAlertStatusFiring) {
+		return nil
+	}
+	remediation := getAlertRemediation(receiver, record)
+	if remediation == nil {
+		return nil
+	}
+	if previous != nil && previous.RemediationTime+remediation.Cooldown > time.Now().Unix() {
+		logger.Infof("alert %s of env %s/%s is in the cooldown of the remediation, skipped", record.AlertName, record.ProjectName, record.EnvName)
+		return nil
+	}
+
+	record.RemediationWorkflow = remediation.WorkflowName
+	record.RemediationTime = time.Now().Unix()
+	record.RemediationTaskID, err = runAlertRemediation(remediation, record, logger)
+	if err != nil {
+		logger.Errorf("failed to run remediation workflow %s for alert %s, error: %s", remediation.WorkflowName, record.AlertName, err)
+		record.RemediationError = err.Error()
+	}
+	return commonrepo.NewEnvAlertColl().UpdateRemediation(record)
+}
+
+// getAlertServiceName returns the first value of the service labels which is a service of the env
+func getAlertServiceName(receiver *commonmodels.AlertReceiver, env *commonmodels.Product, alert *prometheus.Alert) string {
+	labels := receiver.ServiceLabels
+	if len(labels) == 0 {
+		labels = defaultAlertServiceLabels
+	}
+	services := env.GetServiceMap()
+	for _, label := range labels {
+		if _, ok := services[alert.Labels[label]]; ok {
+			return alert.Labels[label]
+		}
+	}
+	return ""
+}
+
+func getAlertRemediation(receiver *commonmodels.AlertReceiver, alert *commonmodels.EnvAlert) *commonmodels.AlertRemediation {
+	for _, remediation := range receiver.Remediations {
+		if remediation.AlertName != "" && remediation.AlertName != alert.AlertName {
+			continue
+		}
+		if remediation.Severity != "" && remediation.Severity != alert.Severity {
+			continue
+		}
+		if len(remediation.EnvNames) > 0 {
+			matched := false
+			for _, envName := range remediation.EnvNames {
+				if envName == alert.EnvName {
+					matched = true
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		return remediation
+	}
+	return nil
+}
+
+func runAlertRemediation(remediation *commonmodels.AlertRemediation, alert *commonmodels.EnvAlert, logger *zap.SugaredLogger) (int64, error) {
+	args := remediation.WorkflowArg
+	for _, param := range args.Params {
+		switch param.Name {
+		case alertParamName:
+			param.Value = alert.AlertName
+		case alertParamEnv:
+			param.Value = alert.EnvName
+		case alertParamService:
+			param.Value = alert.ServiceName
+		}
+	}
+	resp, err := workflowservice.CreateWorkflowTaskV4ByBuildInTrigger(setting.AlertmanagerTaskCreator, args, logger)
+	if err != nil {
+		return 0, err
+	}
+	return resp.TaskID, nil
+}
@@ -485,6 +485,12 @@ func ProcessGithubWebHook(payload []byte, req *http.Request, requestID string, l
 		if err = updateServiceTemplateByGithubPush(et, log); err != nil {
 			log.Errorf("updateServiceTemplateByGithubPush failed, error:%v", err)
 		}
+		// sync the workflows managed by code in the pushed branch
+		if branch, isTag := parseGitRef(et.GetRef()); !isTag {
+			if err = workflowservice.SyncWorkflowsByPush(et.GetRepo().GetFullName(), branch, et.GetAfter(), pushEventCommitsFiles(et), log); err != nil {
+				log.Errorf("SyncWorkflowsByPush failed, error:%v", err)
+			}
+		}
 
 		//add webhook user
 		if et.Pusher != nil {
@@ -613,9 +619,19 @@ func updateServiceTemplateByGithubPush(pushEvent *github.PushEvent, log *zap.Sug
 	}
 	svcTmplsMap[true] = productionServiceTmpls
 
+	ref, isTag := parseGitRef(pushEvent.GetRef())
+	repoFullName := pushEvent.GetRepo().GetFullName()
+	if isTag {
+		return updateServicesPinnedToTag(svcTmplsMap, repoFullName, ref, latestCommitID, log)
+	}
+
 	errs := &multierror.Error{}
 	for production, serviceTmpls := range svcTmplsMap {
 		for _, service := range serviceTmpls {
+			if service.GetRepoNamespace()+"/"+service.RepoName != repoFullName || !isServiceTrackingBranch(service, ref) {
+				continue
+			}
+
 			path, err := getServiceSrcPath(service)
 			if err != nil {
 				errs = multierror.Append(errs, err)
@@ -637,6 +653,10 @@ func updateServiceTemplateByGithubPush(pushEvent *github.PushEvent, log *zap.Sug
 				if err != nil {
 					log.Errorf("SyncServiceTemplateFromGithub failed, error: %v", err)
 					errs = multierror.Append(errs, err)
+					continue
+				}
+				if err := updateServicesPinnedToRef(service, ref, production, log); err != nil {
+					errs = multierror.Append(errs, err)
 				}
 			} else {
 				log.Infof("Service template %s from github %s is not affected, no sync", service.ServiceName, service.SrcPath)
@@ -31,6 +31,7 @@ import (
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
 	gitservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/git"
+	workflowservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
 	"github.com/koderover/zadig/v2/pkg/setting"
 	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 )
@@ -90,13 +91,23 @@ func ProcessGitlabHook(payload []byte, req *http.Request, requestID string, log
 		}
 		pathWithNamespace := pushEvent.Project.PathWithNamespace
 		// trigger service template to re-sync from remote repo
-		if err = updateServiceTemplateByPushEvent(changeFiles, pathWithNamespace, log); err != nil {
+		if err = updateServiceTemplateByPushEvent(changeFiles, pathWithNamespace, pushEvent.Ref, log); err != nil {
 			errorList = multierror.Append(errorList, err)
 		}
+		// sync the workflows managed by code in the pushed branch
+		if branch, isTag := parseGitRef(pushEvent.Ref); !isTag {
+			if err = workflowservice.SyncWorkflowsByPush(pathWithNamespace, branch, pushEvent.After, changeFiles, log); err != nil {
+				errorList = multierror.Append(errorList, err)
+			}
+		}
 	case *gitlab.MergeEvent:
 		mergeEvent = event
 	case *gitlab.TagEvent:
 		tagEvent = event
+		// update the envs pinned to the tag
+		if err = updateServiceTemplateByTagEvent(tagEvent, log); err != nil {
+			errorList = multierror.Append(errorList, err)
+		}
 	}
 
 	//触发工作流webhook和测试管理webhook
@@ -260,7 +271,7 @@ type RepositoryInfo struct {
 	VisibilityLevel int    `json:"visibility_level"`
 }
 
-func updateServiceTemplateByPushEvent(diffs []string, pathWithNamespace string, log *zap.SugaredLogger) error {
+func updateServiceTemplateByPushEvent(diffs []string, pathWithNamespace, ref string, log *zap.SugaredLogger) error {
 	log.Infof("EVENT: GITLAB WEBHOOK UPDATING SERVICE TEMPLATE")
 
 	svcTmplsMap := map[bool][]*commonmodels.Service{}
@@ -277,10 +288,11 @@ func updateServiceTemplateByPushEvent(diffs []string, pathWithNamespace string,
 	}
 	svcTmplsMap[true] = productionServiceTmpls
 
+	branch, _ := parseGitRef(ref)
 	errs := &multierror.Error{}
 	for production, serviceTmpls := range svcTmplsMap {
 		for _, service := range serviceTmpls {
-			if service.GetRepoNamespace()+"/"+service.RepoName != pathWithNamespace {
+			if service.GetRepoNamespace()+"/"+service.RepoName != pathWithNamespace || !isServiceTrackingBranch(service, branch) {
 				continue
 			}
 
@@ -305,6 +317,10 @@ func updateServiceTemplateByPushEvent(diffs []string, pathWithNamespace string,
 				if err != nil {
 					log.Errorf("SyncServiceTemplateFromGitlab failed, error: %v", err)
 					errs = multierror.Append(errs, err)
+					continue
+				}
+				if err := updateServicesPinnedToRef(service, branch, production, log); err != nil {
+					errs = multierror.Append(errs, err)
 				}
 			} else {
 				log.Infof("Service template %s from gitlab %s is not affected, no sync", service.ServiceName, service.SrcPath)
@@ -315,6 +331,25 @@ func updateServiceTemplateByPushEvent(diffs []string, pathWithNamespace string,
 	return errs.ErrorOrNil()
 }
 
+func updateServiceTemplateByTagEvent(event *gitlab.TagEvent, log *zap.SugaredLogger) error {
+	svcTmplsMap := map[bool][]*commonmodels.Service{}
+	serviceTmpls, err := GetGitlabTestingServiceTemplates()
+	if err != nil {
+		log.Errorf("Failed to get gitlab testing service templates, error: %v", err)
+		return err
+	}
+	svcTmplsMap[false] = serviceTmpls
+	productionServiceTmpls, err := GetGitlabProductionServiceTemplates()
+	if err != nil {
+		log.Errorf("Failed to get gitlab production service templates, error: %v", err)
+		return err
+	}
+	svcTmplsMap[true] = productionServiceTmpls
+
+	tag, _ := parseGitRef(event.Ref)
+	return updateServicesPinnedToTag(svcTmplsMap, event.Project.PathWithNamespace, tag, event.CheckoutSHA, log)
+}
+
 func GetGitlabTestingServiceTemplates() ([]*commonmodels.Service, error) {
 	opt := &commonrepo.ServiceListOption{
 		Source: setting.SourceFromGitlab,
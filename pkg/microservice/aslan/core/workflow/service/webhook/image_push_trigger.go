@@ -0,0 +1,294 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package webhook
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	environmentservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+	workflowservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/registries"
+)
+
+func ListImagePushTriggers(projectName string, logger *zap.SugaredLogger) ([]*commonmodels.ImagePushTrigger, error) {
+	resp, err := commonrepo.NewImagePushTriggerColl().List(projectName)
+	if err != nil {
+		logger.Errorf("failed to list image push triggers of project %s, error: %s", projectName, err)
+		return nil, e.ErrListImagePushTrigger.AddErr(err)
+	}
+	for _, trigger := range resp {
+		trigger.Token = setting.MaskValue
+	}
+	return resp, nil
+}
+
+type CreateImagePushTriggerResp struct {
+	Token string `json:"token"`
+}
+
+// CreateImagePushTrigger creates the trigger, a token is generated if not specified. The token is only returned on
+// creation and masked afterwards.
+func CreateImagePushTrigger(userName string, trigger *commonmodels.ImagePushTrigger, logger *zap.SugaredLogger) (*CreateImagePushTriggerResp, error) {
+	if err := validateImagePushTrigger(trigger); err != nil {
+		return nil, e.ErrCreateImagePushTrigger.AddErr(err)
+	}
+	if trigger.Token == "" || trigger.Token == setting.MaskValue {
+		token, err := genWebhookToken()
+		if err != nil {
+			return nil, e.ErrCreateImagePushTrigger.AddErr(err)
+		}
+		trigger.Token = token
+	}
+	trigger.CreatedBy = userName
+	trigger.UpdatedBy = userName
+	if err := commonrepo.NewImagePushTriggerColl().Create(trigger); err != nil {
+		logger.Errorf("failed to create image push trigger %s, error: %s", trigger.Name, err)
+		return nil, e.ErrCreateImagePushTrigger.AddErr(err)
+	}
+	return &CreateImagePushTriggerResp{Token: trigger.Token}, nil
+}
+
+// genWebhookToken generates the token required by the public webhooks
+func genWebhookToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func UpdateImagePushTrigger(userName, id string, trigger *commonmodels.ImagePushTrigger, logger *zap.SugaredLogger) error {
+	origin, err := commonrepo.NewImagePushTriggerColl().GetByID(id)
+	if err != nil {
+		return e.ErrUpdateImagePushTrigger.AddErr(err)
+	}
+	if origin.ProjectName != trigger.ProjectName {
+		return e.ErrUpdateImagePushTrigger.AddDesc("project of the trigger can not be changed")
+	}
+	if err := validateImagePushTrigger(trigger); err != nil {
+		return e.ErrUpdateImagePushTrigger.AddErr(err)
+	}
+	// the masked or empty token keeps the origin one
+	if trigger.Token == "" || trigger.Token == setting.MaskValue {
+		trigger.Token = origin.Token
+	}
+	if trigger.Token == "" {
+		return e.ErrUpdateImagePushTrigger.AddDesc("token of the trigger can not be empty")
+	}
+	trigger.ID = origin.ID
+	trigger.CreatedBy = origin.CreatedBy
+	trigger.CreateTime = origin.CreateTime
+	trigger.UpdatedBy = userName
+	if err := commonrepo.NewImagePushTriggerColl().Update(trigger); err != nil {
+		logger.Errorf("failed to update image push trigger %s, error: %s", id, err)
+		return e.ErrUpdateImagePushTrigger.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteImagePushTrigger(projectName, id string, logger *zap.SugaredLogger) error {
+	trigger, err := commonrepo.NewImagePushTriggerColl().GetByID(id)
+	if err != nil {
+		return e.ErrDeleteImagePushTrigger.AddErr(err)
+	}
+	if trigger.ProjectName != projectName {
+		return e.ErrDeleteImagePushTrigger.AddDesc(fmt.Sprintf("trigger %s not found in project %s", id, projectName))
+	}
+	if err := commonrepo.NewImagePushTriggerColl().DeleteByID(id); err != nil {
+		logger.Errorf("failed to delete image push trigger %s, error: %s", id, err)
+		return e.ErrDeleteImagePushTrigger.AddErr(err)
+	}
+	if err := commonrepo.NewImagePushEventColl().DeleteByTrigger(id); err != nil {
+		logger.Warnf("failed to delete events of image push trigger %s, error: %s", id, err)
+	}
+	return nil
+}
+
+func GetImagePushTrigger(id string) (*commonmodels.ImagePushTrigger, error) {
+	return commonrepo.NewImagePushTriggerColl().GetByID(id)
+}
+
+type ListImagePushEventsResp struct {
+	Events []*commonmodels.ImagePushEvent `json:"events"`
+	Total  int64                          `json:"total"`
+}
+
+func ListImagePushEvents(triggerID string, pageNum, pageSize int64, logger *zap.SugaredLogger) (*ListImagePushEventsResp, error) {
+	events, total, err := commonrepo.NewImagePushEventColl().ListByTrigger(triggerID, pageNum, pageSize)
+	if err != nil {
+		logger.Errorf("failed to list events of image push trigger %s, error: %s", triggerID, err)
+		return nil, e.ErrListImagePushTrigger.AddErr(err)
+	}
+	return &ListImagePushEventsResp{Events: events, Total: total}, nil
+}
+
+func validateImagePushTrigger(trigger *commonmodels.ImagePushTrigger) error {
+	if trigger.Name == "" || trigger.ProjectName == "" {
+		return fmt.Errorf("name and project of the trigger can not be empty")
+	}
+	switch trigger.Source {
+	case registries.EventSourceHarbor, registries.EventSourceACR, registries.EventSourceECR:
+	default:
+		return fmt.Errorf("unsupported source %s", trigger.Source)
+	}
+	if trigger.ImageRepo == "" {
+		return fmt.Errorf("image repo can not be empty")
+	}
+	if _, err := regexp.Compile(trigger.TagRegex); err != nil {
+		return fmt.Errorf("invalid tag regex %s: %v", trigger.TagRegex, err)
+	}
+
+	switch trigger.Action {
+	case commonmodels.ImagePushActionUpdateEnv:
+		if len(trigger.EnvNames) == 0 || trigger.ServiceName == "" || trigger.ServiceModule == "" {
+			return fmt.Errorf("envs, service and service module are required to update env")
+		}
+	case commonmodels.ImagePushActionRunWorkflow:
+		if trigger.WorkflowArg == nil {
+			return fmt.Errorf("workflow args are required to run workflow")
+		}
+		if trigger.WorkflowArg.Project != trigger.ProjectName {
+			return fmt.Errorf("workflow %s is not in project %s", trigger.WorkflowArg.Name, trigger.ProjectName)
+		}
+		trigger.WorkflowName = trigger.WorkflowArg.Name
+		if trigger.ImageParam != "" {
+			found := false
+			for _, param := range trigger.WorkflowArg.Params {
+				if param.Name == trigger.ImageParam {
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("param %s not found in workflow %s", trigger.ImageParam, trigger.WorkflowName)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported action %s", trigger.Action)
+	}
+	return nil
+}
+
+// HandleImagePushEvent handles the notification of the registry, each matched image is handled at most once
+func HandleImagePushEvent(id, token string, body []byte, logger *zap.SugaredLogger) error {
+	trigger, err := commonrepo.NewImagePushTriggerColl().GetByID(id)
+	if err != nil {
+		return e.ErrHandleImagePushEvent.AddErr(err)
+	}
+	if trigger.Token == "" || subtle.ConstantTimeCompare([]byte(trigger.Token), []byte(token)) != 1 {
+		return e.ErrUnauthorized.AddDesc("invalid token")
+	}
+	if !trigger.Enabled {
+		logger.Infof("image push trigger %s is disabled, event ignored", trigger.Name)
+		return nil
+	}
+
+	events, err := registries.ParsePushEvents(trigger.Source, body)
+	if err != nil {
+		return e.ErrHandleImagePushEvent.AddErr(err)
+	}
+	tagRegex, err := regexp.Compile(trigger.TagRegex)
+	if err != nil {
+		return e.ErrHandleImagePushEvent.AddErr(err)
+	}
+
+	for _, event := range events {
+		if event.Repo != trigger.ImageRepo || !tagRegex.MatchString(event.Tag) {
+			continue
+		}
+
+		record := &commonmodels.ImagePushEvent{
+			TriggerID:   trigger.ID.Hex(),
+			ProjectName: trigger.ProjectName,
+			Repo:        event.Repo,
+			Tag:         event.Tag,
+			Digest:      event.Digest,
+			Image:       getPushedImage(trigger, event),
+			Action:      trigger.Action,
+			Status:      commonmodels.ImagePushEventStatusProcessing,
+		}
+		if err := commonrepo.NewImagePushEventColl().Create(record); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				logger.Infof("image %s has been handled by trigger %s, skipped", record.Image, trigger.Name)
+				continue
+			}
+			return e.ErrHandleImagePushEvent.AddErr(err)
+		}
+
+		record.Status = commonmodels.ImagePushEventStatusSuccess
+		taskID, err := runImagePushTrigger(trigger, record.Image, logger)
+		if err != nil {
+			logger.Errorf("failed to run image push trigger %s for image %s, error: %s", trigger.Name, record.Image, err)
+			record.Status = commonmodels.ImagePushEventStatusFailed
+			record.Message = err.Error()
+		}
+		record.TaskID = taskID
+		if err := commonrepo.NewImagePushEventColl().UpdateResult(record); err != nil {
+			logger.Errorf("failed to update image push event %s, error: %s", record.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func getPushedImage(trigger *commonmodels.ImagePushTrigger, event *registries.PushEvent) string {
+	image := fmt.Sprintf("%s:%s", event.Repo, event.Tag)
+	if trigger.RegistryHost != "" {
+		image = fmt.Sprintf("%s/%s", strings.TrimSuffix(trigger.RegistryHost, "/"), image)
+	}
+	return image
+}
+
+func runImagePushTrigger(trigger *commonmodels.ImagePushTrigger, image string, logger *zap.SugaredLogger) (int64, error) {
+	switch trigger.Action {
+	case commonmodels.ImagePushActionUpdateEnv:
+		errs := make([]string, 0)
+		for _, envName := range trigger.EnvNames {
+			err := environmentservice.UpdateServiceModuleImage("", setting.ImagePushTaskCreator, trigger.ProjectName, envName, trigger.ServiceName, trigger.ServiceModule, image, trigger.Production, logger)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("env %s: %s", envName, err))
+			}
+		}
+		if len(errs) > 0 {
+			return 0, fmt.Errorf("%s", strings.Join(errs, "; "))
+		}
+		return 0, nil
+	case commonmodels.ImagePushActionRunWorkflow:
+		args := trigger.WorkflowArg
+		for _, param := range args.Params {
+			if param.Name == trigger.ImageParam {
+				param.Value = image
+			}
+		}
+		resp, err := workflowservice.CreateWorkflowTaskV4ByBuildInTrigger(setting.ImagePushTaskCreator, args, logger)
+		if err != nil {
+			return 0, err
+		}
+		return resp.TaskID, nil
+	default:
+		return 0, fmt.Errorf("unsupported action %s", trigger.Action)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	environmentservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/environment/service"
+)
+
+// parseGitRef returns the short name of the pushed ref and whether it is a tag
+func parseGitRef(ref string) (string, bool) {
+	if strings.HasPrefix(ref, "refs/tags/") {
+		return strings.TrimPrefix(ref, "refs/tags/"), true
+	}
+	return strings.TrimPrefix(ref, "refs/heads/"), false
+}
+
+// isServiceTrackingBranch checks whether the service template is loaded from the pushed branch,
+// service templates without a branch are synced by pushes of any branch for compatibility
+func isServiceTrackingBranch(service *commonmodels.Service, branch string) bool {
+	return service.BranchName == "" || service.BranchName == branch
+}
+
+// updateServicesPinnedToTag updates the services of the envs pinned to the tag. Since the service template tracks a single
+// branch, the envs are only updated when the latest revision of the service template is synced from the tagged commit.
+func updateServicesPinnedToTag(svcTmplsMap map[bool][]*commonmodels.Service, pathWithNamespace, tag, commitSHA string, log *zap.SugaredLogger) error {
+	errs := &multierror.Error{}
+	for production, serviceTmpls := range svcTmplsMap {
+		for _, service := range serviceTmpls {
+			if service.GetRepoNamespace()+"/"+service.RepoName != pathWithNamespace {
+				continue
+			}
+			if service.Commit == nil || service.Commit.SHA != commitSHA {
+				log.Infof("Service template %s is not synced from tag %s, no env is updated", service.ServiceName, tag)
+				continue
+			}
+			if err := environmentservice.UpdateServicesPinnedToRef(service.ProductName, service.ServiceName, tag, production, log); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// updateServicesPinnedToRef updates the services of the envs pinned to the branch after the service template is synced
+func updateServicesPinnedToRef(service *commonmodels.Service, ref string, production bool, log *zap.SugaredLogger) error {
+	return environmentservice.UpdateServicesPinnedToRef(service.ProductName, service.ServiceName, ref, production, log)
+}
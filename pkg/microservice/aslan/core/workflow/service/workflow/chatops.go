@@ -0,0 +1,83 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	jobctl "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/workflow/service/workflow/job"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// CreateChatOpsWorkflowTask runs the workflow with its default job configurations, params are set by name
+// and the value of a repo param is used as its branch.
+func CreateChatOpsWorkflowTask(workflow *commonmodels.WorkflowV4, userID, username string, params map[string]string, log *zap.SugaredLogger) (*CreateTaskV4Resp, error) {
+	if workflow.EnableApprovalTicket {
+		return nil, e.ErrCreateTask.AddDesc("workflow need approval ticket to run, which is not supported by chatops right now.")
+	}
+
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if err := jobctl.SetPreset(job, workflow); err != nil {
+				log.Errorf("cannot get workflow %s preset, the error is: %v", workflow.Name, err)
+				return nil, e.ErrFindWorkflow.AddDesc(err.Error())
+			}
+		}
+	}
+
+	if err := fillWorkflowV4(workflow, log); err != nil {
+		return nil, err
+	}
+
+	workflowParamMap := make(map[string]*commonmodels.Param)
+	for _, param := range workflow.Params {
+		workflowParamMap[param.Name] = param
+	}
+
+	for name, value := range params {
+		workflowParam, ok := workflowParamMap[name]
+		if !ok {
+			return nil, fmt.Errorf("param %s not found in workflow %s", name, workflow.Name)
+		}
+		switch workflowParam.ParamsType {
+		case "string", "text":
+			workflowParam.Value = value
+		case "choice":
+			if !sets.NewString(workflowParam.ChoiceOption...).Has(value) {
+				return nil, fmt.Errorf("invalid choice value %s for param %s", value, name)
+			}
+			workflowParam.Value = value
+		case "repo":
+			if workflowParam.Repo == nil {
+				return nil, fmt.Errorf("repo of param %s is not set in workflow %s", name, workflow.Name)
+			}
+			workflowParam.Repo.Branch = value
+			workflowParam.Repo.PRs = nil
+		default:
+			return nil, fmt.Errorf("param %s of type %s can't be set by chatops", name, workflowParam.ParamsType)
+		}
+	}
+
+	return CreateWorkflowTaskV4(&CreateWorkflowTaskV4Args{
+		Name:   username,
+		UserID: userID,
+	}, workflow, log)
+}
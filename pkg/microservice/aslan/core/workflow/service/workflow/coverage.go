@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package workflow
+
+import (
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+const defaultCoverageTrendLimit = 30
+
+type CoverageTrendArgs struct {
+	ProjectName   string `form:"projectName"`
+	ServiceName   string `form:"serviceName"`
+	ServiceModule string `form:"serviceModule"`
+	TestName      string `form:"testName"`
+	Branch        string `form:"branch"`
+	Limit         int64  `form:"limit"`
+}
+
+// GetCoverageTrend returns the coverage records of the merged commits in ascending order of time
+func GetCoverageTrend(args *CoverageTrendArgs, logger *zap.SugaredLogger) ([]*commonmodels.CoverageRecord, error) {
+	if args.Limit <= 0 {
+		args.Limit = defaultCoverageTrendLimit
+	}
+	records, err := commonrepo.NewCoverageRecordColl().List(&commonrepo.CoverageRecordListOption{
+		ProjectName:   args.ProjectName,
+		ServiceName:   args.ServiceName,
+		ServiceModule: args.ServiceModule,
+		TestName:      args.TestName,
+		Branch:        args.Branch,
+		ExcludePR:     true,
+		Limit:         args.Limit,
+	})
+	if err != nil {
+		logger.Errorf("list coverage records of project %s error: %v", args.ProjectName, err)
+		return nil, e.ErrGetTask.AddErr(err)
+	}
+
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+func ListWorkflowTaskCoverage(workflowName string, taskID int64, logger *zap.SugaredLogger) ([]*commonmodels.CoverageRecord, error) {
+	records, err := commonrepo.NewCoverageRecordColl().List(&commonrepo.CoverageRecordListOption{
+		WorkflowName: workflowName,
+		TaskID:       taskID,
+	})
+	if err != nil {
+		logger.Errorf("list coverage records of workflow %s task %d error: %v", workflowName, taskID, err)
+		return nil, e.ErrGetTask.AddErr(err)
+	}
+	return records, nil
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type MigrateCredentialsArgs struct {
+	// IDs are the ids of the findings to migrate, all the findings are migrated if empty
+	IDs []string `json:"ids"`
+}
+
+type MigrateCredentialsResp struct {
+	// Params maps the migrated finding id to the name of the credential param holding the value
+	Params map[string]string `json:"params"`
+}
+
+func ScanWorkflowV4Credentials(name string, logger *zap.SugaredLogger) ([]*commonutil.CredentialFinding, error) {
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(name)
+	if err != nil {
+		logger.Errorf("Failed to find WorkflowV4: %s, the error is: %v", name, err)
+		return nil, e.ErrFindWorkflow.AddErr(err)
+	}
+
+	findings, err := commonutil.ScanCredentialsInObject(workflow)
+	if err != nil {
+		logger.Errorf("failed to scan credentials in workflow %s, error: %s", name, err)
+		return nil, e.ErrScanCredential.AddErr(err)
+	}
+	return findings, nil
+}
+
+// MigrateWorkflowV4Credentials moves the plaintext credentials found in the workflow into credential params,
+// and replaces the plaintext with the reference of the param.
+func MigrateWorkflowV4Credentials(name, user string, args *MigrateCredentialsArgs, logger *zap.SugaredLogger) (*MigrateCredentialsResp, error) {
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(name)
+	if err != nil {
+		logger.Errorf("Failed to find WorkflowV4: %s, the error is: %v", name, err)
+		return nil, e.ErrFindWorkflow.AddErr(err)
+	}
+
+	findings, err := commonutil.ScanCredentialsInObject(workflow)
+	if err != nil {
+		logger.Errorf("failed to scan credentials in workflow %s, error: %s", name, err)
+		return nil, e.ErrScanCredential.AddErr(err)
+	}
+
+	selected := make(map[string]bool)
+	for _, id := range args.IDs {
+		selected[id] = true
+	}
+	// the same value may be found in multiple places, they are migrated into the same param
+	values := make(map[string]string)
+	for _, finding := range findings {
+		if len(selected) > 0 && !selected[finding.ID] {
+			continue
+		}
+		values[finding.ID] = finding.Value
+	}
+	if len(values) == 0 {
+		return nil, e.ErrMigrateCredential.AddDesc("no credential to migrate")
+	}
+
+	existedParams := make(map[string]bool)
+	for _, param := range workflow.Params {
+		existedParams[param.Name] = true
+	}
+	resp := &MigrateCredentialsResp{Params: make(map[string]string)}
+	replacements := make([]string, 0, len(values)*2)
+	newParams := make([]*commonmodels.Param, 0, len(values))
+	for id, value := range values {
+		paramName := "CREDENTIAL_" + strings.ToUpper(id[:8])
+		if existedParams[paramName] {
+			return nil, e.ErrMigrateCredential.AddDesc(fmt.Sprintf("param %s already exists", paramName))
+		}
+		replacements = append(replacements, value, fmt.Sprintf("{{.workflow.params.%s}}", paramName))
+		newParams = append(newParams, &commonmodels.Param{
+			Name:         paramName,
+			Description:  "migrated by credential scan",
+			ParamsType:   "string",
+			Value:        value,
+			IsCredential: true,
+		})
+		resp.Params[id] = paramName
+	}
+
+	data, err := json.Marshal(workflow)
+	if err != nil {
+		return nil, e.ErrMigrateCredential.AddErr(err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, e.ErrMigrateCredential.AddErr(err)
+	}
+	generic = replaceCredentials(generic, strings.NewReplacer(replacements...))
+	if data, err = json.Marshal(generic); err != nil {
+		return nil, e.ErrMigrateCredential.AddErr(err)
+	}
+	migrated := new(commonmodels.WorkflowV4)
+	if err := json.Unmarshal(data, migrated); err != nil {
+		return nil, e.ErrMigrateCredential.AddErr(err)
+	}
+	migrated.Params = append(migrated.Params, newParams...)
+
+	if err := UpdateWorkflowV4(name, user, migrated, logger); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func replaceCredentials(obj interface{}, replacer *strings.Replacer) interface{} {
+	switch value := obj.(type) {
+	case string:
+		return replacer.Replace(value)
+	case []interface{}:
+		for i, item := range value {
+			value[i] = replaceCredentials(item, replacer)
+		}
+	case map[string]interface{}:
+		if isCredential, ok := value["is_credential"].(bool); ok && isCredential {
+			return value
+		}
+		for key, item := range value {
+			value[key] = replaceCredentials(item, replacer)
+		}
+	}
+	return obj
+}
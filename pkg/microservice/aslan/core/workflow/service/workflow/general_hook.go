@@ -0,0 +1,202 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package workflow
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/crypto"
+)
+
+const defaultGeneralHookSignatureHeader = "X-Zadig-Signature"
+
+var jsonPathIndexRegex = regexp.MustCompile(`\[(\d+|\*)\]`)
+
+// toGJSONPath converts a JSONPath expression like $.repository.tags[0].name into gjson syntax
+func toGJSONPath(path string) string {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+	path = jsonPathIndexRegex.ReplaceAllStringFunc(path, func(s string) string {
+		index := strings.Trim(s, "[]")
+		if index == "*" {
+			return ".#"
+		}
+		return "." + index
+	})
+	return strings.TrimPrefix(path, ".")
+}
+
+func validateGeneralHook(hook *commonmodels.GeneralHook) error {
+	for _, filter := range hook.Filters {
+		if filter.JSONPath == "" {
+			return fmt.Errorf("json path of filter can not be empty")
+		}
+		switch filter.Operator {
+		case commonmodels.GeneralHookFilterOperatorEqual, commonmodels.GeneralHookFilterOperatorNotEqual, commonmodels.GeneralHookFilterOperatorExists:
+		case commonmodels.GeneralHookFilterOperatorRegex:
+			if _, err := regexp.Compile(filter.Value); err != nil {
+				return fmt.Errorf("invalid regex %s of filter %s: %v", filter.Value, filter.JSONPath, err)
+			}
+		default:
+			return fmt.Errorf("unsupported filter operator %s", filter.Operator)
+		}
+	}
+
+	params := make(map[string]bool)
+	if hook.WorkflowArg != nil {
+		for _, param := range hook.WorkflowArg.Params {
+			params[param.Name] = true
+		}
+	}
+	for _, mapping := range hook.ParamMappings {
+		if mapping.JSONPath == "" {
+			return fmt.Errorf("json path of param %s can not be empty", mapping.ParamName)
+		}
+		if !params[mapping.ParamName] {
+			return fmt.Errorf("param %s not found in workflow", mapping.ParamName)
+		}
+	}
+	return nil
+}
+
+// encryptGeneralHookSecret encrypts the secret of the hook before it's saved, the masked secret keeps the one of the origin hook
+func encryptGeneralHookSecret(hook, origin *commonmodels.GeneralHook) error {
+	if hook.Secret == setting.MaskValue {
+		hook.Secret = ""
+		if origin != nil {
+			hook.Secret = origin.Secret
+		}
+		return nil
+	}
+	if hook.Secret == "" {
+		return nil
+	}
+	secret, err := crypto.AesEncrypt(hook.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret of general hook %s: %v", hook.Name, err)
+	}
+	hook.Secret = secret
+	return nil
+}
+
+// maskGeneralHookSecrets masks the secrets of the hooks in the responses, the hooks are copied so the saved ones are not changed
+func maskGeneralHookSecrets(hooks []*commonmodels.GeneralHook) []*commonmodels.GeneralHook {
+	resp := make([]*commonmodels.GeneralHook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook == nil || hook.Secret == "" {
+			resp = append(resp, hook)
+			continue
+		}
+		masked := *hook
+		masked.Secret = setting.MaskValue
+		resp = append(resp, &masked)
+	}
+	return resp
+}
+
+// verifyGeneralHookSignature checks the signature of the payload by the decrypted secret of the hook
+func verifyGeneralHookSignature(hook *commonmodels.GeneralHook, header http.Header, body []byte) error {
+	if hook.Secret == "" {
+		return nil
+	}
+	secret, err := crypto.AesDecrypt(hook.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret of general hook %s: %v", hook.Name, err)
+	}
+	return checkGeneralHookSignature(secret, hook.SignatureHeader, header, body)
+}
+
+// checkGeneralHookSignature checks the hex encoded hmac-sha256 of the body, the signature may have a "sha256=" prefix
+func checkGeneralHookSignature(secret, headerName string, header http.Header, body []byte) error {
+	if headerName == "" {
+		headerName = defaultGeneralHookSignatureHeader
+	}
+	signature := strings.TrimPrefix(header.Get(headerName), "sha256=")
+	if signature == "" {
+		return fmt.Errorf("signature header %s not found", headerName)
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// matchGeneralHookFilters returns the first filter which is not matched by the payload
+func matchGeneralHookFilters(filters []*commonmodels.GeneralHookFilter, body []byte) (*commonmodels.GeneralHookFilter, error) {
+	for _, filter := range filters {
+		result := gjson.GetBytes(body, toGJSONPath(filter.JSONPath))
+		matched := false
+		switch filter.Operator {
+		case commonmodels.GeneralHookFilterOperatorExists:
+			matched = result.Exists()
+		case commonmodels.GeneralHookFilterOperatorEqual:
+			matched = result.Exists() && result.String() == filter.Value
+		case commonmodels.GeneralHookFilterOperatorNotEqual:
+			matched = result.String() != filter.Value
+		case commonmodels.GeneralHookFilterOperatorRegex:
+			reg, err := regexp.Compile(filter.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex %s: %v", filter.Value, err)
+			}
+			matched = result.Exists() && reg.MatchString(result.String())
+		default:
+			return nil, fmt.Errorf("unsupported filter operator %s", filter.Operator)
+		}
+		if !matched {
+			return filter, nil
+		}
+	}
+	return nil, nil
+}
+
+// renderGeneralHookParams sets the workflow params from the payload by the param mappings
+func renderGeneralHookParams(hook *commonmodels.GeneralHook, body []byte) error {
+	if hook.WorkflowArg == nil {
+		return nil
+	}
+	for _, mapping := range hook.ParamMappings {
+		result := gjson.GetBytes(body, toGJSONPath(mapping.JSONPath))
+		if !result.Exists() {
+			if mapping.Required {
+				return fmt.Errorf("value of param %s not found by %s", mapping.ParamName, mapping.JSONPath)
+			}
+			continue
+		}
+		for _, param := range hook.WorkflowArg.Params {
+			if param.Name == mapping.ParamName {
+				param.Value = result.String()
+			}
+		}
+	}
+	return nil
+}
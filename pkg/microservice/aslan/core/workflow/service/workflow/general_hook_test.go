@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/setting"
+)
+
+var _ = Describe("Testing general hook", func() {
+
+	payload := []byte(`{"ref":"refs/heads/main","repository":{"name":"zadig"},"commits":[{"id":"abc"}]}`)
+
+	Context("matchGeneralHookFilters", func() {
+		It("should be passed when all filters are matched", func() {
+			filters := []*commonmodels.GeneralHookFilter{
+				{JSONPath: "$.ref", Operator: commonmodels.GeneralHookFilterOperatorEqual, Value: "refs/heads/main"},
+				{JSONPath: "$.repository.name", Operator: commonmodels.GeneralHookFilterOperatorNotEqual, Value: "other"},
+				{JSONPath: "$.ref", Operator: commonmodels.GeneralHookFilterOperatorRegex, Value: "^refs/heads/"},
+				{JSONPath: "$.commits[0].id", Operator: commonmodels.GeneralHookFilterOperatorExists},
+			}
+			filter, err := matchGeneralHookFilters(filters, payload)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(filter).Should(BeNil())
+		})
+		It("should return the filter which is not matched", func() {
+			filters := []*commonmodels.GeneralHookFilter{
+				{JSONPath: "$.ref", Operator: commonmodels.GeneralHookFilterOperatorExists},
+				{JSONPath: "$.ref", Operator: commonmodels.GeneralHookFilterOperatorEqual, Value: "refs/heads/dev"},
+			}
+			filter, err := matchGeneralHookFilters(filters, payload)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(filter).Should(Equal(filters[1]))
+		})
+		It("should not match a missing field by equal", func() {
+			filters := []*commonmodels.GeneralHookFilter{
+				{JSONPath: "$.missing", Operator: commonmodels.GeneralHookFilterOperatorEqual, Value: ""},
+			}
+			filter, err := matchGeneralHookFilters(filters, payload)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(filter).Should(Equal(filters[0]))
+		})
+		It("should raise error for invalid regex", func() {
+			filters := []*commonmodels.GeneralHookFilter{
+				{JSONPath: "$.ref", Operator: commonmodels.GeneralHookFilterOperatorRegex, Value: "("},
+			}
+			_, err := matchGeneralHookFilters(filters, payload)
+			Expect(err).Should(HaveOccurred())
+		})
+		It("should raise error for unsupported operator", func() {
+			filters := []*commonmodels.GeneralHookFilter{
+				{JSONPath: "$.ref", Operator: "gt", Value: "1"},
+			}
+			_, err := matchGeneralHookFilters(filters, payload)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("renderGeneralHookParams", func() {
+		It("should set the params from the payload", func() {
+			hook := &commonmodels.GeneralHook{
+				WorkflowArg: &commonmodels.WorkflowV4{
+					Params: []*commonmodels.Param{{Name: "branch"}, {Name: "repo", Value: "default"}},
+				},
+				ParamMappings: []*commonmodels.GeneralHookParamMapping{
+					{ParamName: "branch", JSONPath: "$.ref"},
+					{ParamName: "repo", JSONPath: "$.missing"},
+				},
+			}
+			Expect(renderGeneralHookParams(hook, payload)).ShouldNot(HaveOccurred())
+			Expect(hook.WorkflowArg.Params[0].Value).Should(Equal("refs/heads/main"))
+			Expect(hook.WorkflowArg.Params[1].Value).Should(Equal("default"))
+		})
+		It("should raise error when a required value is not found", func() {
+			hook := &commonmodels.GeneralHook{
+				WorkflowArg: &commonmodels.WorkflowV4{
+					Params: []*commonmodels.Param{{Name: "branch"}},
+				},
+				ParamMappings: []*commonmodels.GeneralHookParamMapping{
+					{ParamName: "branch", JSONPath: "$.missing", Required: true},
+				},
+			}
+			Expect(renderGeneralHookParams(hook, payload)).Should(HaveOccurred())
+		})
+	})
+
+	Context("checkGeneralHookSignature", func() {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(payload)
+		signature := hex.EncodeToString(mac.Sum(nil))
+
+		It("should be passed for valid signatures", func() {
+			header := http.Header{}
+			header.Set(defaultGeneralHookSignatureHeader, signature)
+			Expect(checkGeneralHookSignature("secret", "", header, payload)).ShouldNot(HaveOccurred())
+			header = http.Header{}
+			header.Set("X-Hub-Signature-256", "sha256="+signature)
+			Expect(checkGeneralHookSignature("secret", "X-Hub-Signature-256", header, payload)).ShouldNot(HaveOccurred())
+		})
+		It("should raise error for missing signature", func() {
+			Expect(checkGeneralHookSignature("secret", "", http.Header{}, payload)).Should(HaveOccurred())
+		})
+		It("should raise error for wrong signature", func() {
+			header := http.Header{}
+			header.Set(defaultGeneralHookSignatureHeader, signature)
+			Expect(checkGeneralHookSignature("other", "", header, payload)).Should(HaveOccurred())
+			header.Set(defaultGeneralHookSignatureHeader, "not-hex")
+			Expect(checkGeneralHookSignature("secret", "", header, payload)).Should(HaveOccurred())
+		})
+	})
+
+	Context("maskGeneralHookSecrets", func() {
+		It("should mask the secrets without changing the hooks", func() {
+			hooks := []*commonmodels.GeneralHook{{Name: "a", Secret: "encrypted"}, {Name: "b"}}
+			masked := maskGeneralHookSecrets(hooks)
+			Expect(masked[0].Secret).Should(Equal(setting.MaskValue))
+			Expect(masked[1].Secret).Should(BeEmpty())
+			Expect(hooks[0].Secret).Should(Equal("encrypted"))
+		})
+		It("should keep the origin secret for the masked value", func() {
+			hook := &commonmodels.GeneralHook{Name: "a", Secret: setting.MaskValue}
+			Expect(encryptGeneralHookSecret(hook, &commonmodels.GeneralHook{Name: "a", Secret: "encrypted"})).ShouldNot(HaveOccurred())
+			Expect(hook.Secret).Should(Equal("encrypted"))
+		})
+	})
+})
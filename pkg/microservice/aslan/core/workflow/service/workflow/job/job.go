@@ -32,6 +32,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/paramstore"
 	"github.com/koderover/zadig/v2/pkg/setting"
 	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
 	"github.com/koderover/zadig/v2/pkg/types"
@@ -42,6 +43,7 @@ const (
 	VMOutputNameRegexString = "^[a-zA-Z0-9_]{1,64}$"
 	OutputNameRegexString   = "^[a-zA-Z0-9_]{1,64}$"
 	JobNameKey              = "job_name"
+	MatrixCellKey           = "matrix_cell"
 )
 
 var (
@@ -135,12 +137,24 @@ func InitJobCtl(job *commonmodels.Job, workflow *commonmodels.WorkflowV4) (JobCt
 		resp = &UpdateEnvIstioConfigJob{job: job, workflow: workflow}
 	case config.JobBlueKing:
 		resp = &BlueKingJob{job: job, workflow: workflow}
+	case config.JobGitHubActions:
+		resp = &GitHubActionsJob{job: job, workflow: workflow}
 	case config.JobApproval:
 		resp = &ApprovalJob{job: job, workflow: workflow}
 	case config.JobNotification:
 		resp = &NotificationJob{job: job, workflow: workflow}
 	case config.JobSAEDeploy:
 		resp = &SAEDeployJob{job: job, workflow: workflow}
+	case config.JobTerraform:
+		resp = &TerraformJob{job: job, workflow: workflow}
+	case config.JobEnvAnalysis:
+		resp = &EnvAnalysisJob{job: job, workflow: workflow}
+	case config.JobCanaryAnalysis:
+		resp = &CanaryAnalysisJob{job: job, workflow: workflow}
+	case config.JobFeatureFlag:
+		resp = &FeatureFlagJob{job: job, workflow: workflow}
+	case config.JobSubWorkflow:
+		resp = &SubWorkflowJob{job: job, workflow: workflow}
 	default:
 		return resp, fmt.Errorf("job type not found %s", job.JobType)
 	}
@@ -266,6 +280,12 @@ func MergeWebhookRepo(workflow *commonmodels.WorkflowV4, repo *types.Repository)
 					return warpJobError(job.Name, err)
 				}
 			}
+			if job.JobType == config.JobTerraform {
+				jobCtl := &TerraformJob{job: job, workflow: workflow}
+				if err := jobCtl.MergeWebhookRepo(repo); err != nil {
+					return warpJobError(job.Name, err)
+				}
+			}
 		}
 	}
 	return nil
@@ -631,10 +651,39 @@ func RenderWorkflowParams(workflow *commonmodels.WorkflowV4, taskID int64, creat
 	if err != nil {
 		return fmt.Errorf("get workflow stage params error: %v", err)
 	}
-	replacedString := renderMultiLineString(string(b), setting.RenderValueTemplate, append(globalParams, stageParams...))
+	storeParams, err := getParameterStoreParams(workflow, string(b))
+	if err != nil {
+		return err
+	}
+	params := append(globalParams, stageParams...)
+	replacedString := renderMultiLineString(string(b), setting.RenderValueTemplate, append(params, storeParams...))
 	return json.Unmarshal([]byte(replacedString), &workflow)
 }
 
+// getParameterStoreParams resolves the parameters of the parameter store referenced in the workflow
+func getParameterStoreParams(workflow *commonmodels.WorkflowV4, content string) ([]*commonmodels.Param, error) {
+	items, err := paramstore.Resolve(workflow.Project, paramstore.ReferencedKeys(content), paramstore.WorkflowUsage(workflow.Project, workflow.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*commonmodels.Param, 0, len(items))
+	for key, item := range items {
+		// the value is rendered into the json of the workflow
+		value, err := util.JsonEscapeString(item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to escape value of parameter %s, err: %w", key, err)
+		}
+		resp = append(resp, &commonmodels.Param{
+			Name:         strings.Join([]string{paramstore.VariableKey, key}, "."),
+			Value:        value,
+			ParamsType:   "string",
+			IsCredential: item.IsSecret,
+		})
+	}
+	return resp, nil
+}
+
 func renderString(value, template string, inputs []*commonmodels.Param) string {
 	for _, input := range inputs {
 		if input.ParamsType == string(commonmodels.MultiSelectType) {
@@ -837,10 +886,14 @@ func getShareStorageDetail(shareStorages []*commonmodels.ShareStorage, shareStor
 		if !ok {
 			continue
 		}
+		subPath := types.GetShareStorageSubPath(workflowName, storageInfo.Name, taskID)
+		if storage.IsWorkflowScoped() {
+			subPath = types.GetShareStorageCacheSubPath(workflowName, storageInfo.Name)
+		}
 		storageDetail := &commonmodels.StorageDetail{
 			Name:      storageInfo.Name,
 			Type:      types.NFSMedium,
-			SubPath:   types.GetShareStorageSubPath(workflowName, storageInfo.Name, taskID),
+			SubPath:   subPath,
 			MountPath: storage.Path,
 		}
 		resp = append(resp, storageDetail)
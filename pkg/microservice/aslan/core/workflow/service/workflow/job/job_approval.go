@@ -23,6 +23,8 @@ import (
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/crypto"
 	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
 	"github.com/samber/lo"
@@ -139,6 +141,9 @@ func (j *ApprovalJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 		approvalUser, _ := util.GeneFlatUsers(nativeApproval.ApproveUsers)
 		nativeApproval.ApproveUsers = approvalUser
 	}
+	if nativeApproval != nil {
+		nativeApproval.CallbackSecret = getSavedApprovalCallbackSecret(j.workflow.Name, j.job.Name)
+	}
 
 	jobSpec := &commonmodels.JobTaskApprovalSpec{
 		Timeout:          j.spec.Timeout,
@@ -329,3 +334,104 @@ func (j *ApprovalJob) getOriginReferedJobSpec(jobName string) (*commonmodels.App
 
 	return nil, fmt.Errorf("approval job %s not found", jobName)
 }
+
+// getJobNativeApproval returns the spec of the approval job or the terraform job and the native approval in it, the
+// spec should be set back to the job if the approval is changed
+func getJobNativeApproval(job *commonmodels.Job) (interface{}, *commonmodels.NativeApproval, error) {
+	switch job.JobType {
+	case config.JobApproval:
+		spec := &commonmodels.ApprovalJobSpec{}
+		if err := commonmodels.IToi(job.Spec, spec); err != nil {
+			return nil, nil, err
+		}
+		return spec, spec.NativeApproval, nil
+	case config.JobTerraform:
+		spec := &commonmodels.TerraformJobSpec{}
+		if err := commonmodels.IToi(job.Spec, spec); err != nil {
+			return nil, nil, err
+		}
+		if spec.ApplyApproval == nil {
+			return spec, nil, nil
+		}
+		return spec, spec.ApplyApproval.NativeApproval, nil
+	default:
+		return nil, nil, nil
+	}
+}
+
+// EncryptApprovalCallbackSecrets encrypts the callback secrets of the native approvals in the workflow before it's
+// saved, the masked secrets keep the ones of the same jobs in the origin workflow
+func EncryptApprovalCallbackSecrets(workflow, origin *commonmodels.WorkflowV4) error {
+	originSecrets := make(map[string]string)
+	if origin != nil {
+		for _, stage := range origin.Stages {
+			for _, job := range stage.Jobs {
+				_, approval, err := getJobNativeApproval(job)
+				if err != nil {
+					return err
+				}
+				if approval != nil {
+					originSecrets[job.Name] = approval.CallbackSecret
+				}
+			}
+		}
+	}
+
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			spec, approval, err := getJobNativeApproval(job)
+			if err != nil {
+				return err
+			}
+			if approval == nil || approval.CallbackSecret == "" {
+				continue
+			}
+			if approval.CallbackSecret == setting.MaskValue {
+				approval.CallbackSecret = originSecrets[job.Name]
+			} else {
+				approval.CallbackSecret, err = crypto.AesEncrypt(approval.CallbackSecret)
+				if err != nil {
+					return fmt.Errorf("failed to encrypt callback secret of job %s: %v", job.Name, err)
+				}
+			}
+			job.Spec = spec
+		}
+	}
+	return nil
+}
+
+// MaskApprovalCallbackSecret masks the callback secret of the native approval of the job in the responses
+func MaskApprovalCallbackSecret(job *commonmodels.Job) error {
+	spec, approval, err := getJobNativeApproval(job)
+	if err != nil {
+		return err
+	}
+	if approval == nil || approval.CallbackSecret == "" {
+		return nil
+	}
+	approval.CallbackSecret = setting.MaskValue
+	job.Spec = spec
+	return nil
+}
+
+// getSavedApprovalCallbackSecret returns the encrypted callback secret of the native approval of the job in the saved
+// workflow, the secret in the args of the task is never used so that the task creator can't set it
+func getSavedApprovalCallbackSecret(workflowName, jobName string) string {
+	workflow, err := mongodb.NewWorkflowV4Coll().Find(workflowName)
+	if err != nil {
+		return ""
+	}
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.Name != jobName {
+				continue
+			}
+			_, approval, err := getJobNativeApproval(job)
+			if err != nil || approval == nil {
+				return ""
+			}
+			return approval.CallbackSecret
+		}
+	}
+	return ""
+}
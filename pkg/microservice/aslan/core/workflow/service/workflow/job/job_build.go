@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"regexp"
 	"strings"
 
 	"go.uber.org/zap"
@@ -423,19 +424,11 @@ func (j *BuildJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 	}
 
 	buildSvc := commonservice.NewBuildService()
-	for jobSubTaskID, build := range j.spec.ServiceAndBuilds {
-		imageTag := commonservice.ReleaseCandidate(build.Repos, taskID, j.workflow.Project, build.ServiceModule, "", build.ImageName, "image")
-
-		image := fmt.Sprintf("%s/%s", registry.RegAddr, imageTag)
-		if len(registry.Namespace) > 0 {
-			image = fmt.Sprintf("%s/%s/%s", registry.RegAddr, registry.Namespace, imageTag)
-		}
-
-		image = strings.TrimPrefix(image, "http://")
-		image = strings.TrimPrefix(image, "https://")
-
-		pkgFile := fmt.Sprintf("%s.tar.gz", commonservice.ReleaseCandidate(build.Repos, taskID, j.workflow.Project, build.ServiceModule, "", build.ImageName, "tar"))
-
+	matrixCells, err := expandBuildMatrix(j.spec.Matrix)
+	if err != nil {
+		return resp, err
+	}
+	for _, build := range j.spec.ServiceAndBuilds {
 		buildInfo, err := buildSvc.GetBuild(build.BuildName, build.ServiceName, build.ServiceModule)
 		if err != nil {
 			return resp, fmt.Errorf("find build: %s error: %v", build.BuildName, err)
@@ -448,327 +441,398 @@ func (j *BuildJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 		if err != nil {
 			return resp, err
 		}
-		outputs := ensureBuildInOutputs(buildInfo.Outputs)
-		jobTaskSpec := &commonmodels.JobTaskFreestyleSpec{}
-		jobTask := &commonmodels.JobTask{
-			JobInfo: map[string]string{
+
+		for cellIndex, cell := range matrixCells {
+			jobSubTaskID := len(resp)
+			imageTag := commonservice.ReleaseCandidate(build.Repos, taskID, j.workflow.Project, build.ServiceModule, "", build.ImageName, "image")
+			pkgName := commonservice.ReleaseCandidate(build.Repos, taskID, j.workflow.Project, build.ServiceModule, "", build.ImageName, "tar")
+			if cell.Name != "" {
+				imageTag = fmt.Sprintf("%s-%s", imageTag, cell.Name)
+				pkgName = fmt.Sprintf("%s-%s", pkgName, cell.Name)
+			}
+
+			image := fmt.Sprintf("%s/%s", registry.RegAddr, imageTag)
+			if len(registry.Namespace) > 0 {
+				image = fmt.Sprintf("%s/%s/%s", registry.RegAddr, registry.Namespace, imageTag)
+			}
+
+			image = strings.TrimPrefix(image, "http://")
+			image = strings.TrimPrefix(image, "https://")
+
+			pkgFile := fmt.Sprintf("%s.tar.gz", pkgName)
+
+			outputs := ensureBuildInOutputs(buildInfo.Outputs)
+			keyParts := []string{build.ServiceName, build.ServiceModule}
+			jobInfo := map[string]string{
 				"service_name":   build.ServiceName,
 				"service_module": build.ServiceModule,
 				JobNameKey:       j.job.Name,
-			},
-			Key:            genJobKey(j.job.Name, build.ServiceName, build.ServiceModule),
-			Name:           GenJobName(j.workflow, j.job.Name, jobSubTaskID),
-			DisplayName:    genJobDisplayName(j.job.Name, build.ServiceName, build.ServiceModule),
-			OriginName:     j.job.Name,
-			JobType:        string(config.JobZadigBuild),
-			Spec:           jobTaskSpec,
-			Timeout:        int64(buildInfo.Timeout),
-			Outputs:        outputs,
-			Infrastructure: buildInfo.Infrastructure,
-			VMLabels:       buildInfo.VMLabels,
-			ErrorPolicy:    j.job.ErrorPolicy,
-		}
-		jobTaskSpec.Properties = commonmodels.JobProperties{
-			Timeout:             int64(buildInfo.Timeout),
-			ResourceRequest:     buildInfo.PreBuild.ResReq,
-			ResReqSpec:          buildInfo.PreBuild.ResReqSpec,
-			CustomEnvs:          renderKeyVals(build.KeyVals, buildInfo.PreBuild.Envs),
-			ClusterID:           buildInfo.PreBuild.ClusterID,
-			StrategyID:          buildInfo.PreBuild.StrategyID,
-			BuildOS:             basicImage.Value,
-			ImageFrom:           buildInfo.PreBuild.ImageFrom,
-			Registries:          registries,
-			ShareStorageDetails: getShareStorageDetail(j.workflow.ShareStorages, build.ShareStorageInfo, j.workflow.Name, taskID),
-			CustomLabels:        buildInfo.PreBuild.CustomLabels,
-			CustomAnnotations:   buildInfo.PreBuild.CustomAnnotations,
-		}
+			}
+			if cell.Name != "" {
+				keyParts = append(keyParts, cell.Name)
+				jobInfo[MatrixCellKey] = cell.Name
+			}
+			jobTaskSpec := &commonmodels.JobTaskFreestyleSpec{}
+			jobTask := &commonmodels.JobTask{
+				JobInfo:        jobInfo,
+				Key:            genJobKey(j.job.Name, keyParts...),
+				Name:           GenJobName(j.workflow, j.job.Name, jobSubTaskID),
+				DisplayName:    genJobDisplayName(j.job.Name, keyParts...),
+				OriginName:     j.job.Name,
+				JobType:        string(config.JobZadigBuild),
+				Spec:           jobTaskSpec,
+				Timeout:        int64(buildInfo.Timeout),
+				Outputs:        outputs,
+				Infrastructure: buildInfo.Infrastructure,
+				VMLabels:       buildInfo.VMLabels,
+				ErrorPolicy:    j.job.ErrorPolicy,
+			}
+			jobTaskSpec.Properties = commonmodels.JobProperties{
+				Timeout:             int64(buildInfo.Timeout),
+				ResourceRequest:     buildInfo.PreBuild.ResReq,
+				ResReqSpec:          buildInfo.PreBuild.ResReqSpec,
+				CustomEnvs:          renderKeyVals(build.KeyVals, buildInfo.PreBuild.Envs),
+				ClusterID:           buildInfo.PreBuild.ClusterID,
+				StrategyID:          buildInfo.PreBuild.StrategyID,
+				BuildOS:             basicImage.Value,
+				ImageFrom:           buildInfo.PreBuild.ImageFrom,
+				Registries:          registries,
+				ShareStorageDetails: getShareStorageDetail(j.workflow.ShareStorages, build.ShareStorageInfo, j.workflow.Name, taskID),
+				CustomLabels:        buildInfo.PreBuild.CustomLabels,
+				CustomAnnotations:   buildInfo.PreBuild.CustomAnnotations,
+				PodTemplate:         buildInfo.PreBuild.PodTemplate,
+			}
 
-		paramEnvs := generateKeyValsFromWorkflowParam(j.workflow.Params)
-		envs := mergeKeyVals(jobTaskSpec.Properties.CustomEnvs, paramEnvs)
+			paramEnvs := generateKeyValsFromWorkflowParam(j.workflow.Params)
+			// matrix values take precedence over build variables and workflow params
+			envs := mergeKeyVals(cell.Envs, mergeKeyVals(jobTaskSpec.Properties.CustomEnvs, paramEnvs))
 
-		jobTaskSpec.Properties.Envs = append(envs, getBuildJobVariables(build, taskID, j.workflow.Project, j.workflow.Name, j.workflow.DisplayName, image, pkgFile, jobTask.Infrastructure, registry, logger)...)
-		jobTaskSpec.Properties.UseHostDockerDaemon = buildInfo.PreBuild.UseHostDockerDaemon
+			jobTaskSpec.Properties.Envs = append(envs, getBuildJobVariables(build, taskID, j.workflow.Project, j.workflow.Name, j.workflow.DisplayName, image, pkgFile, jobTask.Infrastructure, registry, logger)...)
+			jobTaskSpec.Properties.UseHostDockerDaemon = buildInfo.PreBuild.UseHostDockerDaemon
 
-		cacheS3 := &commonmodels.S3Storage{}
-		if jobTask.Infrastructure == setting.JobVMInfrastructure {
-			jobTaskSpec.Properties.CacheEnable = buildInfo.CacheEnable
-			jobTaskSpec.Properties.CacheDirType = buildInfo.CacheDirType
-			jobTaskSpec.Properties.CacheUserDir = buildInfo.CacheUserDir
-		} else {
-			clusterInfo, err := commonrepo.NewK8SClusterColl().Get(buildInfo.PreBuild.ClusterID)
-			if err != nil {
-				return resp, fmt.Errorf("find cluster: %s error: %v", buildInfo.PreBuild.ClusterID, err)
-			}
-
-			if clusterInfo.Cache.MediumType == "" {
-				jobTaskSpec.Properties.CacheEnable = false
-			} else {
-				// set job task cache equal to cluster cache
-				jobTaskSpec.Properties.Cache = clusterInfo.Cache
+			cacheS3 := &commonmodels.S3Storage{}
+			if jobTask.Infrastructure == setting.JobVMInfrastructure {
 				jobTaskSpec.Properties.CacheEnable = buildInfo.CacheEnable
 				jobTaskSpec.Properties.CacheDirType = buildInfo.CacheDirType
 				jobTaskSpec.Properties.CacheUserDir = buildInfo.CacheUserDir
-			}
+			} else {
+				clusterInfo, err := commonrepo.NewK8SClusterColl().Get(buildInfo.PreBuild.ClusterID)
+				if err != nil {
+					return resp, fmt.Errorf("find cluster: %s error: %v", buildInfo.PreBuild.ClusterID, err)
+				}
 
-			if jobTaskSpec.Properties.CacheEnable {
-				jobTaskSpec.Properties.CacheUserDir = commonutil.RenderEnv(jobTaskSpec.Properties.CacheUserDir, jobTaskSpec.Properties.Envs)
-				if jobTaskSpec.Properties.Cache.MediumType == types.NFSMedium {
-					jobTaskSpec.Properties.Cache.NFSProperties.Subpath = commonutil.RenderEnv(jobTaskSpec.Properties.Cache.NFSProperties.Subpath, jobTaskSpec.Properties.Envs)
-				} else if jobTaskSpec.Properties.Cache.MediumType == types.ObjectMedium {
-					cacheS3, err = commonrepo.NewS3StorageColl().Find(jobTaskSpec.Properties.Cache.ObjectProperties.ID)
-					if err != nil {
-						return resp, fmt.Errorf("find cache s3 storage: %s error: %v", jobTaskSpec.Properties.Cache.ObjectProperties.ID, err)
-					}
+				if clusterInfo.Cache.MediumType == "" {
+					jobTaskSpec.Properties.CacheEnable = false
+				} else {
+					// set job task cache equal to cluster cache
+					jobTaskSpec.Properties.Cache = clusterInfo.Cache
+					jobTaskSpec.Properties.CacheEnable = buildInfo.CacheEnable
+					jobTaskSpec.Properties.CacheDirType = buildInfo.CacheDirType
+					jobTaskSpec.Properties.CacheUserDir = buildInfo.CacheUserDir
+				}
+
+				if jobTaskSpec.Properties.CacheEnable {
+					jobTaskSpec.Properties.CacheUserDir = commonutil.RenderEnv(jobTaskSpec.Properties.CacheUserDir, jobTaskSpec.Properties.Envs)
+					if jobTaskSpec.Properties.Cache.MediumType == types.NFSMedium {
+						jobTaskSpec.Properties.Cache.NFSProperties.Subpath = commonutil.RenderEnv(jobTaskSpec.Properties.Cache.NFSProperties.Subpath, jobTaskSpec.Properties.Envs)
+					} else if jobTaskSpec.Properties.Cache.MediumType == types.ObjectMedium {
+						cacheS3, err = commonrepo.NewS3StorageColl().Find(jobTaskSpec.Properties.Cache.ObjectProperties.ID)
+						if err != nil {
+							return resp, fmt.Errorf("find cache s3 storage: %s error: %v", jobTaskSpec.Properties.Cache.ObjectProperties.ID, err)
+						}
 
+					}
 				}
 			}
-		}
-
-		// for other job refer current latest image.
-		build.Image = job.GetJobOutputKey(jobTask.Key, "IMAGE")
-		build.Package = job.GetJobOutputKey(jobTask.Key, "PKG_FILE")
-		log.Infof("BuildJob ToJobs %d: workflow %s service %s, module %s, image %s, package %s",
-			taskID, j.workflow.Name, build.ServiceName, build.ServiceModule, build.Image, build.Package)
-
-		// init tools install step
-		tools := []*step.Tool{}
-		for _, tool := range buildInfo.PreBuild.Installs {
-			tools = append(tools, &step.Tool{
-				Name:    tool.Name,
-				Version: tool.Version,
-			})
-		}
-		toolInstallStep := &commonmodels.StepTask{
-			Name:     fmt.Sprintf("%s-%s", build.ServiceName, "tool-install"),
-			JobName:  jobTask.Name,
-			StepType: config.StepTools,
-			Spec:     step.StepToolInstallSpec{Installs: tools},
-		}
-		jobTaskSpec.Steps = append(jobTaskSpec.Steps, toolInstallStep)
 
-		// init download object cache step
-		if jobTaskSpec.Properties.CacheEnable && jobTaskSpec.Properties.Cache.MediumType == types.ObjectMedium {
-			cacheDir := "/workspace"
-			if jobTaskSpec.Properties.CacheDirType == types.UserDefinedCacheDir {
-				cacheDir = jobTaskSpec.Properties.CacheUserDir
+			// for other job refer current latest image, the first matrix cell is used by default when the build is expanded by matrix.
+			imageOutput, pkgOutput := job.GetJobOutputKey(jobTask.Key, "IMAGE"), job.GetJobOutputKey(jobTask.Key, "PKG_FILE")
+			if cellIndex == 0 {
+				build.Image = imageOutput
+				build.Package = pkgOutput
+				build.MatrixOutputs = nil
+			}
+			if cell.Name != "" {
+				build.MatrixOutputs = append(build.MatrixOutputs, &commonmodels.BuildMatrixOutput{Cell: cell.Name, Image: imageOutput, Package: pkgOutput})
 			}
-			downloadArchiveStep := &commonmodels.StepTask{
-				Name:     fmt.Sprintf("%s-%s", build.ServiceName, "download-archive"),
+			log.Infof("BuildJob ToJobs %d: workflow %s service %s, module %s, image %s, package %s",
+				taskID, j.workflow.Name, build.ServiceName, build.ServiceModule, build.Image, build.Package)
+
+			// init tools install step
+			tools := []*step.Tool{}
+			for _, tool := range buildInfo.PreBuild.Installs {
+				tools = append(tools, &step.Tool{
+					Name:    tool.Name,
+					Version: tool.Version,
+				})
+			}
+			toolInstallStep := &commonmodels.StepTask{
+				Name:     fmt.Sprintf("%s-%s", build.ServiceName, "tool-install"),
 				JobName:  jobTask.Name,
-				StepType: config.StepDownloadArchive,
-				Spec: step.StepDownloadArchiveSpec{
-					UnTar:      true,
-					IgnoreErr:  true,
-					FileName:   setting.BuildOSSCacheFileName,
-					ObjectPath: getBuildJobCacheObjectPath(j.workflow.Name, build.ServiceName, build.ServiceModule),
-					DestDir:    cacheDir,
-					S3:         modelS3toS3(cacheS3),
-				},
+				StepType: config.StepTools,
+				Spec:     step.StepToolInstallSpec{Installs: tools},
 			}
-			jobTaskSpec.Steps = append(jobTaskSpec.Steps, downloadArchiveStep)
-		}
+			jobTaskSpec.Steps = append(jobTaskSpec.Steps, toolInstallStep)
 
-		codehosts, err := codehostrepo.NewCodehostColl().AvailableCodeHost(j.workflow.Project)
-		if err != nil {
-			return resp, fmt.Errorf("find %s project codehost error: %v", j.workflow.Project, err)
-		}
+			// init download object cache step
+			if jobTaskSpec.Properties.CacheEnable && jobTaskSpec.Properties.Cache.MediumType == types.ObjectMedium {
+				cacheDir := "/workspace"
+				if jobTaskSpec.Properties.CacheDirType == types.UserDefinedCacheDir {
+					cacheDir = jobTaskSpec.Properties.CacheUserDir
+				}
+				downloadArchiveStep := &commonmodels.StepTask{
+					Name:     fmt.Sprintf("%s-%s", build.ServiceName, "download-archive"),
+					JobName:  jobTask.Name,
+					StepType: config.StepDownloadArchive,
+					Spec: step.StepDownloadArchiveSpec{
+						UnTar:      true,
+						IgnoreErr:  true,
+						FileName:   setting.BuildOSSCacheFileName,
+						ObjectPath: getBuildJobCacheObjectPath(j.workflow.Name, build.ServiceName, build.ServiceModule),
+						DestDir:    cacheDir,
+						S3:         modelS3toS3(cacheS3),
+					},
+				}
+				jobTaskSpec.Steps = append(jobTaskSpec.Steps, downloadArchiveStep)
+			}
 
-		// init git clone step
-		repos := renderRepos(build.Repos, buildInfo.Repos, jobTaskSpec.Properties.Envs)
-		gitRepos, p4Repos := splitReposByType(repos)
-		gitStep := &commonmodels.StepTask{
-			Name:     build.ServiceName + "-git",
-			JobName:  jobTask.Name,
-			StepType: config.StepGit,
-			Spec: step.StepGitSpec{
-				CodeHosts: codehosts,
-				Repos:     gitRepos,
-			},
-		}
+			codehosts, err := codehostrepo.NewCodehostColl().AvailableCodeHost(j.workflow.Project)
+			if err != nil {
+				return resp, fmt.Errorf("find %s project codehost error: %v", j.workflow.Project, err)
+			}
 
-		jobTaskSpec.Steps = append(jobTaskSpec.Steps, gitStep)
+			// init git clone step
+			repos := renderRepos(build.Repos, buildInfo.Repos, jobTaskSpec.Properties.Envs)
+			gitRepos, p4Repos := splitReposByType(repos)
+			gitStep := &commonmodels.StepTask{
+				Name:     build.ServiceName + "-git",
+				JobName:  jobTask.Name,
+				StepType: config.StepGit,
+				Spec: step.StepGitSpec{
+					CodeHosts: codehosts,
+					Repos:     gitRepos,
+				},
+			}
 
-		p4Step := &commonmodels.StepTask{
-			Name:     build.ServiceName + "-perforce",
-			JobName:  jobTask.Name,
-			StepType: config.StepPerforce,
-			Spec:     step.StepP4Spec{Repos: p4Repos},
-		}
+			jobTaskSpec.Steps = append(jobTaskSpec.Steps, gitStep)
 
-		jobTaskSpec.Steps = append(jobTaskSpec.Steps, p4Step)
-		// init debug before step
-		debugBeforeStep := &commonmodels.StepTask{
-			Name:     build.ServiceName + "-debug_before",
-			JobName:  jobTask.Name,
-			StepType: config.StepDebugBefore,
-		}
-		jobTaskSpec.Steps = append(jobTaskSpec.Steps, debugBeforeStep)
-		// init shell step
-		scripts := []string{}
-		dockerLoginCmd := `docker login -u "$DOCKER_REGISTRY_AK" -p "$DOCKER_REGISTRY_SK" "$DOCKER_REGISTRY_HOST" &> /dev/null`
-		if jobTask.Infrastructure == setting.JobVMInfrastructure {
-			scripts = append(scripts, strings.Split(replaceWrapLine(buildInfo.Scripts), "\n")...)
-		} else {
-			scripts = append([]string{dockerLoginCmd}, strings.Split(replaceWrapLine(buildInfo.Scripts), "\n")...)
-			scripts = append(scripts, outputScript(outputs, jobTask.Infrastructure)...)
-		}
-		scriptStep := &commonmodels.StepTask{
-			JobName: jobTask.Name,
-		}
-		if buildInfo.ScriptType == types.ScriptTypeShell || buildInfo.ScriptType == "" {
-			scriptStep.Name = build.ServiceName + "-shell"
-			scriptStep.StepType = config.StepShell
-			scriptStep.Spec = &step.StepShellSpec{
-				Scripts: scripts,
+			p4Step := &commonmodels.StepTask{
+				Name:     build.ServiceName + "-perforce",
+				JobName:  jobTask.Name,
+				StepType: config.StepPerforce,
+				Spec:     step.StepP4Spec{Repos: p4Repos},
+			}
+
+			jobTaskSpec.Steps = append(jobTaskSpec.Steps, p4Step)
+			// init debug before step
+			debugBeforeStep := &commonmodels.StepTask{
+				Name:     build.ServiceName + "-debug_before",
+				JobName:  jobTask.Name,
+				StepType: config.StepDebugBefore,
 			}
-		} else if buildInfo.ScriptType == types.ScriptTypeBatchFile {
-			scriptStep.Name = build.ServiceName + "-batchfile"
-			scriptStep.StepType = config.StepBatchFile
-			scriptStep.Spec = &step.StepBatchFileSpec{
-				Scripts: scripts,
+			jobTaskSpec.Steps = append(jobTaskSpec.Steps, debugBeforeStep)
+			// init shell step
+			scripts := []string{}
+			dockerLoginCmd := `docker login -u "$DOCKER_REGISTRY_AK" -p "$DOCKER_REGISTRY_SK" "$DOCKER_REGISTRY_HOST" &> /dev/null`
+			if jobTask.Infrastructure == setting.JobVMInfrastructure {
+				scripts = append(scripts, strings.Split(replaceWrapLine(buildInfo.Scripts), "\n")...)
+			} else {
+				scripts = append([]string{dockerLoginCmd}, strings.Split(replaceWrapLine(buildInfo.Scripts), "\n")...)
+				scripts = append(scripts, outputScript(outputs, jobTask.Infrastructure)...)
 			}
-		} else if buildInfo.ScriptType == types.ScriptTypePowerShell {
-			scriptStep.Name = build.ServiceName + "-powershell"
-			scriptStep.StepType = config.StepPowerShell
-			scriptStep.Spec = &step.StepPowerShellSpec{
-				Scripts: scripts,
+			scriptStep := &commonmodels.StepTask{
+				JobName: jobTask.Name,
 			}
-		}
-		jobTaskSpec.Steps = append(jobTaskSpec.Steps, scriptStep)
-		// init debug after step
-		debugAfterStep := &commonmodels.StepTask{
-			Name:     build.ServiceName + "-debug_after",
-			JobName:  jobTask.Name,
-			StepType: config.StepDebugAfter,
-		}
-		jobTaskSpec.Steps = append(jobTaskSpec.Steps, debugAfterStep)
-		// init docker build step
-		if buildInfo.PostBuild != nil && buildInfo.PostBuild.DockerBuild != nil {
-			dockefileContent := ""
-			if buildInfo.PostBuild.DockerBuild.TemplateID != "" {
-				if dockerfileDetail, err := templ.GetDockerfileTemplateDetail(buildInfo.PostBuild.DockerBuild.TemplateID, logger); err == nil {
-					dockefileContent = dockerfileDetail.Content
+			if buildInfo.ScriptType == types.ScriptTypeShell || buildInfo.ScriptType == "" {
+				scriptStep.Name = build.ServiceName + "-shell"
+				scriptStep.StepType = config.StepShell
+				scriptStep.Spec = &step.StepShellSpec{
+					Scripts: scripts,
+				}
+			} else if buildInfo.ScriptType == types.ScriptTypeBatchFile {
+				scriptStep.Name = build.ServiceName + "-batchfile"
+				scriptStep.StepType = config.StepBatchFile
+				scriptStep.Spec = &step.StepBatchFileSpec{
+					Scripts: scripts,
+				}
+			} else if buildInfo.ScriptType == types.ScriptTypePowerShell {
+				scriptStep.Name = build.ServiceName + "-powershell"
+				scriptStep.StepType = config.StepPowerShell
+				scriptStep.Spec = &step.StepPowerShellSpec{
+					Scripts: scripts,
 				}
 			}
-
-			dockerBuildStep := &commonmodels.StepTask{
-				Name:     build.ServiceName + "-docker-build",
+			jobTaskSpec.Steps = append(jobTaskSpec.Steps, scriptStep)
+			// init debug after step
+			debugAfterStep := &commonmodels.StepTask{
+				Name:     build.ServiceName + "-debug_after",
 				JobName:  jobTask.Name,
-				StepType: config.StepDockerBuild,
-				Spec: step.StepDockerBuildSpec{
-					Source:                buildInfo.PostBuild.DockerBuild.Source,
-					WorkDir:               buildInfo.PostBuild.DockerBuild.WorkDir,
-					DockerFile:            buildInfo.PostBuild.DockerBuild.DockerFile,
-					ImageName:             image,
-					ImageReleaseTag:       imageTag,
-					BuildArgs:             buildInfo.PostBuild.DockerBuild.BuildArgs,
-					DockerTemplateContent: dockefileContent,
-					DockerRegistry: &step.DockerRegistry{
-						DockerRegistryID: j.spec.DockerRegistryID,
-						Host:             registry.RegAddr,
-						UserName:         registry.AccessKey,
-						Password:         registry.SecretKey,
-						Namespace:        registry.Namespace,
-					},
-					Repos: repos,
-				},
+				StepType: config.StepDebugAfter,
 			}
-			jobTaskSpec.Steps = append(jobTaskSpec.Steps, dockerBuildStep)
-		}
+			jobTaskSpec.Steps = append(jobTaskSpec.Steps, debugAfterStep)
+			// init docker build step
+			if buildInfo.PostBuild != nil && buildInfo.PostBuild.DockerBuild != nil {
+				dockefileContent := ""
+				if buildInfo.PostBuild.DockerBuild.TemplateID != "" {
+					if dockerfileDetail, err := templ.GetDockerfileTemplateDetail(buildInfo.PostBuild.DockerBuild.TemplateID, logger); err == nil {
+						dockefileContent = dockerfileDetail.Content
+					}
+				}
 
-		// init object cache step
-		if jobTaskSpec.Properties.CacheEnable && jobTaskSpec.Properties.Cache.MediumType == types.ObjectMedium {
-			cacheDir := "/workspace"
-			if jobTaskSpec.Properties.CacheDirType == types.UserDefinedCacheDir {
-				cacheDir = jobTaskSpec.Properties.CacheUserDir
-			}
-			tarArchiveStep := &commonmodels.StepTask{
-				Name:     fmt.Sprintf("%s-%s", build.ServiceName, "tar-archive"),
-				JobName:  jobTask.Name,
-				StepType: config.StepTarArchive,
-				Spec: step.StepTarArchiveSpec{
-					FileName:     setting.BuildOSSCacheFileName,
-					ResultDirs:   []string{"."},
-					AbsResultDir: true,
-					TarDir:       cacheDir,
-					ChangeTarDir: true,
-					S3DestDir:    getBuildJobCacheObjectPath(j.workflow.Name, build.ServiceName, build.ServiceModule),
-					IgnoreErr:    true,
-					S3Storage:    modelS3toS3(cacheS3),
-				},
+				dockerBuildStep := &commonmodels.StepTask{
+					Name:     build.ServiceName + "-docker-build",
+					JobName:  jobTask.Name,
+					StepType: config.StepDockerBuild,
+					Spec: step.StepDockerBuildSpec{
+						Source:                buildInfo.PostBuild.DockerBuild.Source,
+						WorkDir:               buildInfo.PostBuild.DockerBuild.WorkDir,
+						DockerFile:            buildInfo.PostBuild.DockerBuild.DockerFile,
+						ImageName:             image,
+						ImageReleaseTag:       imageTag,
+						BuildArgs:             buildInfo.PostBuild.DockerBuild.BuildArgs,
+						DockerTemplateContent: dockefileContent,
+						DockerRegistry: &step.DockerRegistry{
+							DockerRegistryID: j.spec.DockerRegistryID,
+							Host:             registry.RegAddr,
+							UserName:         registry.AccessKey,
+							Password:         registry.SecretKey,
+							Namespace:        registry.Namespace,
+						},
+						Repos: repos,
+					},
+				}
+				if remoteCache := buildInfo.PostBuild.DockerBuild.RemoteCache; remoteCache != nil && remoteCache.Enabled && jobTask.Infrastructure != setting.JobVMInfrastructure {
+					stepSpec := dockerBuildStep.Spec.(step.StepDockerBuildSpec)
+					stepSpec.RemoteCache, err = getDockerRemoteCache(remoteCache, j.workflow.Project, image)
+					if err != nil {
+						return resp, err
+					}
+					dockerBuildStep.Spec = stepSpec
+					jobTask.Outputs = ensureBuildCacheOutputs(jobTask.Outputs)
+				}
+				jobTaskSpec.Steps = append(jobTaskSpec.Steps, dockerBuildStep)
 			}
-			jobTaskSpec.Steps = append(jobTaskSpec.Steps, tarArchiveStep)
-		}
 
-		// init archive step
-		if buildInfo.PostBuild != nil && buildInfo.PostBuild.FileArchive != nil && buildInfo.PostBuild.FileArchive.FileLocation != "" {
-			uploads := []*step.Upload{
-				{
-					IsFileArchive:       true,
-					Name:                pkgFile,
-					ServiceName:         build.ServiceName,
-					ServiceModule:       build.ServiceModule,
-					JobTaskName:         jobTask.Name,
-					PackageFileLocation: buildInfo.PostBuild.FileArchive.FileLocation,
-					FilePath:            path.Join(buildInfo.PostBuild.FileArchive.FileLocation, pkgFile),
-					DestinationPath:     path.Join(j.workflow.Name, fmt.Sprint(taskID), jobTask.Name, "archive"),
-				},
-			}
-			archiveStep := &commonmodels.StepTask{
-				Name:     build.ServiceName + "-pkgfile-archive",
-				JobName:  jobTask.Name,
-				StepType: config.StepArchive,
-				Spec: step.StepArchiveSpec{
-					UploadDetail: uploads,
-					S3:           modelS3toS3(defaultS3),
-					Repos:        repos,
-				},
+			// init object cache step
+			if jobTaskSpec.Properties.CacheEnable && jobTaskSpec.Properties.Cache.MediumType == types.ObjectMedium {
+				cacheDir := "/workspace"
+				if jobTaskSpec.Properties.CacheDirType == types.UserDefinedCacheDir {
+					cacheDir = jobTaskSpec.Properties.CacheUserDir
+				}
+				tarArchiveStep := &commonmodels.StepTask{
+					Name:     fmt.Sprintf("%s-%s", build.ServiceName, "tar-archive"),
+					JobName:  jobTask.Name,
+					StepType: config.StepTarArchive,
+					Spec: step.StepTarArchiveSpec{
+						FileName:     setting.BuildOSSCacheFileName,
+						ResultDirs:   []string{"."},
+						AbsResultDir: true,
+						TarDir:       cacheDir,
+						ChangeTarDir: true,
+						S3DestDir:    getBuildJobCacheObjectPath(j.workflow.Name, build.ServiceName, build.ServiceModule),
+						IgnoreErr:    true,
+						S3Storage:    modelS3toS3(cacheS3),
+					},
+				}
+				jobTaskSpec.Steps = append(jobTaskSpec.Steps, tarArchiveStep)
 			}
-			jobTaskSpec.Steps = append(jobTaskSpec.Steps, archiveStep)
-		}
 
-		// init object storage step
-		if buildInfo.PostBuild != nil && buildInfo.PostBuild.ObjectStorageUpload != nil && buildInfo.PostBuild.ObjectStorageUpload.Enabled {
-			modelS3, err := commonrepo.NewS3StorageColl().Find(buildInfo.PostBuild.ObjectStorageUpload.ObjectStorageID)
-			if err != nil {
-				return resp, fmt.Errorf("find object storage: %s failed, err: %v", buildInfo.PostBuild.ObjectStorageUpload.ObjectStorageID, err)
+			// init archive step
+			if buildInfo.PostBuild != nil && buildInfo.PostBuild.FileArchive != nil && buildInfo.PostBuild.FileArchive.FileLocation != "" {
+				uploads := []*step.Upload{
+					{
+						IsFileArchive:       true,
+						Name:                pkgFile,
+						ServiceName:         build.ServiceName,
+						ServiceModule:       build.ServiceModule,
+						JobTaskName:         jobTask.Name,
+						PackageFileLocation: buildInfo.PostBuild.FileArchive.FileLocation,
+						FilePath:            path.Join(buildInfo.PostBuild.FileArchive.FileLocation, pkgFile),
+						DestinationPath:     path.Join(j.workflow.Name, fmt.Sprint(taskID), jobTask.Name, "archive"),
+					},
+				}
+				archiveStep := &commonmodels.StepTask{
+					Name:     build.ServiceName + "-pkgfile-archive",
+					JobName:  jobTask.Name,
+					StepType: config.StepArchive,
+					Spec: step.StepArchiveSpec{
+						UploadDetail: uploads,
+						S3:           modelS3toS3(defaultS3),
+						Repos:        repos,
+					},
+				}
+				jobTaskSpec.Steps = append(jobTaskSpec.Steps, archiveStep)
 			}
-			s3 := modelS3toS3(modelS3)
-			s3.Subfolder = ""
-			uploads := []*step.Upload{}
-			for _, detail := range buildInfo.PostBuild.ObjectStorageUpload.UploadDetail {
-				uploads = append(uploads, &step.Upload{
-					FilePath:        detail.FilePath,
-					DestinationPath: detail.DestinationPath,
-				})
+
+			// init object storage step
+			if buildInfo.PostBuild != nil && buildInfo.PostBuild.ObjectStorageUpload != nil && buildInfo.PostBuild.ObjectStorageUpload.Enabled {
+				modelS3, err := commonrepo.NewS3StorageColl().Find(buildInfo.PostBuild.ObjectStorageUpload.ObjectStorageID)
+				if err != nil {
+					return resp, fmt.Errorf("find object storage: %s failed, err: %v", buildInfo.PostBuild.ObjectStorageUpload.ObjectStorageID, err)
+				}
+				s3 := modelS3toS3(modelS3)
+				s3.Subfolder = ""
+				uploads := []*step.Upload{}
+				for _, detail := range buildInfo.PostBuild.ObjectStorageUpload.UploadDetail {
+					uploads = append(uploads, &step.Upload{
+						FilePath:        detail.FilePath,
+						DestinationPath: detail.DestinationPath,
+					})
+				}
+				archiveStep := &commonmodels.StepTask{
+					Name:     build.ServiceName + "-object-storage",
+					JobName:  jobTask.Name,
+					StepType: config.StepArchive,
+					Spec: step.StepArchiveSpec{
+						UploadDetail:    uploads,
+						ObjectStorageID: buildInfo.PostBuild.ObjectStorageUpload.ObjectStorageID,
+						S3:              s3,
+					},
+				}
+				jobTaskSpec.Steps = append(jobTaskSpec.Steps, archiveStep)
 			}
-			archiveStep := &commonmodels.StepTask{
-				Name:     build.ServiceName + "-object-storage",
-				JobName:  jobTask.Name,
-				StepType: config.StepArchive,
-				Spec: step.StepArchiveSpec{
-					UploadDetail:    uploads,
-					ObjectStorageID: buildInfo.PostBuild.ObjectStorageUpload.ObjectStorageID,
-					S3:              s3,
-				},
+
+			// init coverage report step
+			if buildInfo.PostBuild != nil && buildInfo.PostBuild.CoverageReport != nil && buildInfo.PostBuild.CoverageReport.Enabled {
+				coverageStep := &commonmodels.StepTask{
+					Name:     build.ServiceName + "-coverage-report",
+					JobName:  jobTask.Name,
+					StepType: config.StepCoverageReport,
+					Spec: &step.StepCoverageReportSpec{
+						SourceWorkflow:      j.workflow.Name,
+						SourceJobKey:        j.job.Name,
+						JobTaskName:         jobTask.Name,
+						TaskID:              taskID,
+						ProjectName:         j.workflow.Project,
+						ServiceName:         build.ServiceName,
+						ServiceModule:       build.ServiceModule,
+						ReportPath:          buildInfo.PostBuild.CoverageReport.ReportPath,
+						Format:              buildInfo.PostBuild.CoverageReport.Format,
+						RegressionThreshold: buildInfo.PostBuild.CoverageReport.RegressionThreshold,
+						S3DestDir:           path.Join(j.workflow.Name, fmt.Sprint(taskID), jobTask.Name, "coverage"),
+						FileName:            path.Base(buildInfo.PostBuild.CoverageReport.ReportPath),
+						S3Storage:           modelS3toS3(defaultS3),
+						Repos:               repos,
+					},
+				}
+				jobTaskSpec.Steps = append(jobTaskSpec.Steps, coverageStep)
 			}
-			jobTaskSpec.Steps = append(jobTaskSpec.Steps, archiveStep)
-		}
 
-		// init post build shell step
-		if buildInfo.PostBuild != nil && buildInfo.PostBuild.Scripts != "" {
-			scripts := append([]string{dockerLoginCmd}, strings.Split(replaceWrapLine(buildInfo.PostBuild.Scripts), "\n")...)
-			shellStep := &commonmodels.StepTask{
-				Name:     build.ServiceName + "-post-shell",
-				JobName:  jobTask.Name,
-				StepType: config.StepShell,
-				Spec: &step.StepShellSpec{
-					Scripts: scripts,
-				},
+			// init post build shell step
+			if buildInfo.PostBuild != nil && buildInfo.PostBuild.Scripts != "" {
+				scripts := append([]string{dockerLoginCmd}, strings.Split(replaceWrapLine(buildInfo.PostBuild.Scripts), "\n")...)
+				shellStep := &commonmodels.StepTask{
+					Name:     build.ServiceName + "-post-shell",
+					JobName:  jobTask.Name,
+					StepType: config.StepShell,
+					Spec: &step.StepShellSpec{
+						Scripts: scripts,
+					},
+				}
+				jobTaskSpec.Steps = append(jobTaskSpec.Steps, shellStep)
 			}
-			jobTaskSpec.Steps = append(jobTaskSpec.Steps, shellStep)
+			resp = append(resp, jobTask)
 		}
-		resp = append(resp, jobTask)
 	}
 	j.job.Spec = j.spec
 	return resp, nil
@@ -997,7 +1061,121 @@ func (j *BuildJob) LintJob() error {
 		return err
 	}
 
-	return nil
+	return lintBuildMatrix(j.spec.Matrix)
+}
+
+const maxBuildMatrixCells = 64
+
+type buildMatrixCell struct {
+	// Name is the axis values joined by "-", empty if the build is not expanded by matrix
+	Name string
+	Envs []*commonmodels.KeyVal
+}
+
+// expandBuildMatrix returns the cartesian product of the axis values, a single unnamed cell is returned when matrix is empty.
+// The cell names are used in the job keys and image tags, an error is returned if the names of two cells are the same.
+func expandBuildMatrix(axes []*commonmodels.BuildMatrixAxis) ([]*buildMatrixCell, error) {
+	cells := []*buildMatrixCell{{}}
+	for _, axis := range axes {
+		if axis == nil || len(axis.Values) == 0 {
+			continue
+		}
+		expanded := make([]*buildMatrixCell, 0, len(cells)*len(axis.Values))
+		for _, cell := range cells {
+			for _, value := range axis.Values {
+				name := matrixCellNameRegex.ReplaceAllString(value, "-")
+				if cell.Name != "" {
+					name = cell.Name + "-" + name
+				}
+				envs := append([]*commonmodels.KeyVal{}, cell.Envs...)
+				envs = append(envs, &commonmodels.KeyVal{Key: axis.Key, Value: value, Type: commonmodels.StringType})
+				expanded = append(expanded, &buildMatrixCell{Name: name, Envs: envs})
+			}
+		}
+		cells = expanded
+	}
+
+	names := make(map[string]*buildMatrixCell, len(cells))
+	for _, cell := range cells {
+		if existed, ok := names[cell.Name]; ok {
+			return nil, fmt.Errorf("matrix cells %s and %s have the same name %s, please use values differing in letters, digits, '_' or '-'",
+				matrixCellValues(existed), matrixCellValues(cell), cell.Name)
+		}
+		names[cell.Name] = cell
+	}
+	return cells, nil
+}
+
+func matrixCellValues(cell *buildMatrixCell) string {
+	values := make([]string, 0, len(cell.Envs))
+	for _, kv := range cell.Envs {
+		values = append(values, fmt.Sprintf("%s=%s", kv.Key, kv.Value))
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+// getQuotedBuildMatrixCell returns the matrix cell of the quoted job whose outputs are used by the downstream job,
+// the first cell is used if cell is empty. An empty name is returned if the quoted job is not a build expanded by matrix.
+func getQuotedBuildMatrixCell(workflow *commonmodels.WorkflowV4, jobName, cell string) (string, error) {
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.Name != jobName {
+				continue
+			}
+			if job.JobType != config.JobZadigBuild {
+				break
+			}
+			buildSpec := &commonmodels.ZadigBuildJobSpec{}
+			if err := commonmodels.IToi(job.Spec, buildSpec); err != nil {
+				return "", fmt.Errorf("failed to decode build job spec, error: %s", err)
+			}
+			cells, err := expandBuildMatrix(buildSpec.Matrix)
+			if err != nil {
+				return "", err
+			}
+			if cell == "" {
+				return cells[0].Name, nil
+			}
+			for _, c := range cells {
+				if c.Name == cell {
+					return cell, nil
+				}
+			}
+			return "", fmt.Errorf("matrix cell %s not found in build job %s", cell, jobName)
+		}
+	}
+	if cell != "" {
+		return "", fmt.Errorf("matrix cell %s is selected but job %s is not a build expanded by matrix", cell, jobName)
+	}
+	return "", nil
+}
+
+var matrixCellNameRegex = regexp.MustCompile("[^a-zA-Z0-9_-]+")
+
+func lintBuildMatrix(axes []*commonmodels.BuildMatrixAxis) error {
+	cellCount := 1
+	keys := sets.NewString()
+	for _, axis := range axes {
+		if axis == nil {
+			continue
+		}
+		if !OutputNameRegex.MatchString(axis.Key) {
+			return fmt.Errorf("invalid matrix key %s, should match %s", axis.Key, OutputNameRegexString)
+		}
+		if keys.Has(axis.Key) {
+			return fmt.Errorf("duplicated matrix key %s", axis.Key)
+		}
+		keys.Insert(axis.Key)
+		if len(axis.Values) == 0 {
+			return fmt.Errorf("matrix key %s has no values", axis.Key)
+		}
+		cellCount *= len(axis.Values)
+		if cellCount > maxBuildMatrixCells {
+			return fmt.Errorf("build matrix expands to more than %d cells", maxBuildMatrixCells)
+		}
+	}
+	_, err := expandBuildMatrix(axes)
+	return err
 }
 
 func (j *BuildJob) GetOutPuts(log *zap.SugaredLogger) []string {
@@ -1039,6 +1217,9 @@ func (j *BuildJob) GetOutPuts(log *zap.SugaredLogger) []string {
 		outputs = append(outputs, &commonmodels.Output{Name: outputKey})
 	}
 	resp = append(resp, getOutputKey(j.job.Name+".<SERVICE>.<MODULE>", outputs)...)
+	if len(j.spec.Matrix) > 0 {
+		resp = append(resp, getOutputKey(j.job.Name+".<SERVICE>.<MODULE>.<MATRIX_CELL>", outputs)...)
+	}
 	resp = append(resp, "{{.job."+j.job.Name+".<SERVICE>.<MODULE>."+GITURLKEY+"}}")
 	resp = append(resp, "{{.job."+j.job.Name+".<SERVICE>.<MODULE>."+BRANCHKEY+"}}")
 	resp = append(resp, "{{.job."+j.job.Name+".<SERVICE>.<MODULE>."+COMMITIDKEY+"}}")
@@ -1152,6 +1333,64 @@ func ensureBuildInOutputs(outputs []*commonmodels.Output) []*commonmodels.Output
 	return outputs
 }
 
+func ensureBuildCacheOutputs(outputs []*commonmodels.Output) []*commonmodels.Output {
+	keyMap := map[string]struct{}{}
+	for _, output := range outputs {
+		keyMap[output.Name] = struct{}{}
+	}
+	for _, key := range []string{setting.BuildJobOutputKeyCacheHits, setting.BuildJobOutputKeyCacheSteps} {
+		if _, ok := keyMap[key]; !ok {
+			outputs = append(outputs, &commonmodels.Output{Name: key})
+		}
+	}
+	return outputs
+}
+
+// getDockerRemoteCache generates the remote cache of the image, the cache is isolated by project:
+// the registry cache is tagged with the project name and the s3 cache is stored under the project prefix.
+func getDockerRemoteCache(remoteCache *types.DockerRemoteCache, project, image string) (*step.DockerRemoteCache, error) {
+	// image is like host/namespace/name:tag
+	repo := image
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	name := repo[strings.LastIndex(repo, "/")+1:]
+
+	resp := &step.DockerRemoteCache{
+		Type: string(remoteCache.Type),
+		Name: name,
+	}
+	switch remoteCache.Type {
+	case types.DockerRegistryCache:
+		resp.Ref = fmt.Sprintf("%s:buildcache-%s", repo, project)
+	case types.DockerS3Cache:
+		var (
+			storage *commonmodels.S3Storage
+			err     error
+		)
+		if remoteCache.S3StorageID != "" {
+			storage, err = commonrepo.NewS3StorageColl().Find(remoteCache.S3StorageID)
+		} else {
+			storage, err = commonrepo.NewS3StorageColl().FindDefault()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("find build cache s3 storage error: %v", err)
+		}
+		resp.S3 = modelS3toS3(storage)
+		if resp.S3.Region == "" {
+			// buildkit requires the region, any region is accepted by the s3 compatible storage
+			resp.S3.Region = "us-east-1"
+		}
+		resp.Prefix = fmt.Sprintf("zadig-build-cache/%s/", project)
+		if storage.Subfolder != "" {
+			resp.Prefix = fmt.Sprintf("%s/%s", strings.Trim(storage.Subfolder, "/"), resp.Prefix)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported build cache type: %s", remoteCache.Type)
+	}
+	return resp, nil
+}
+
 func getBuildJobCacheObjectPath(workflowName, serviceName, serviceModule string) string {
 	return fmt.Sprintf("%s/cache/%s/%s", workflowName, serviceName, serviceModule)
 }
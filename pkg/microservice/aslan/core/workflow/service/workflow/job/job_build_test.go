@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func matrixCellNames(cells []*buildMatrixCell) []string {
+	names := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		names = append(names, cell.Name)
+	}
+	return names
+}
+
+var _ = Describe("Testing build matrix", func() {
+
+	Context("expandBuildMatrix", func() {
+		It("should return a single unnamed cell for empty matrix", func() {
+			cells, err := expandBuildMatrix(nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cells).To(HaveLen(1))
+			Expect(cells[0].Name).To(BeEmpty())
+			Expect(cells[0].Envs).To(BeEmpty())
+		})
+		It("should expand to the cartesian product of the axis values", func() {
+			cells, err := expandBuildMatrix([]*commonmodels.BuildMatrixAxis{
+				{Key: "GOOS", Values: []string{"linux", "darwin"}},
+				{Key: "GOARCH", Values: []string{"amd64", "arm64"}},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(matrixCellNames(cells)).To(Equal([]string{"linux-amd64", "linux-arm64", "darwin-amd64", "darwin-arm64"}))
+			Expect(cells[1].Envs).To(Equal([]*commonmodels.KeyVal{
+				{Key: "GOOS", Value: "linux", Type: commonmodels.StringType},
+				{Key: "GOARCH", Value: "arm64", Type: commonmodels.StringType},
+			}))
+		})
+		It("should exclude the nil and empty axes", func() {
+			cells, err := expandBuildMatrix([]*commonmodels.BuildMatrixAxis{
+				nil,
+				{Key: "JDK", Values: []string{"8", "17"}},
+				{Key: "EMPTY"},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(matrixCellNames(cells)).To(Equal([]string{"8", "17"}))
+			Expect(cells[0].Envs).To(HaveLen(1))
+		})
+		It("should sanitize the values in cell names", func() {
+			cells, err := expandBuildMatrix([]*commonmodels.BuildMatrixAxis{
+				{Key: "JDK", Values: []string{"openjdk 1.8", "openjdk@17"}},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(matrixCellNames(cells)).To(Equal([]string{"openjdk-1-8", "openjdk-17"}))
+			Expect(cells[0].Envs[0].Value).To(Equal("openjdk 1.8"))
+		})
+		It("should raise error for values with the same sanitized name", func() {
+			_, err := expandBuildMatrix([]*commonmodels.BuildMatrixAxis{
+				{Key: "PLATFORM", Values: []string{"a.b", "a/b"}},
+			})
+			Expect(err).Should(HaveOccurred())
+		})
+		It("should raise error for cells with the same joined name", func() {
+			_, err := expandBuildMatrix([]*commonmodels.BuildMatrixAxis{
+				{Key: "A", Values: []string{"x-y", "x"}},
+				{Key: "B", Values: []string{"z", "y-z"}},
+			})
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("getQuotedBuildMatrixCell", func() {
+		workflow := &commonmodels.WorkflowV4{
+			Stages: []*commonmodels.WorkflowStage{{
+				Jobs: []*commonmodels.Job{
+					{
+						Name:    "build",
+						JobType: config.JobZadigBuild,
+						Spec: &commonmodels.ZadigBuildJobSpec{
+							Matrix: []*commonmodels.BuildMatrixAxis{{Key: "GOARCH", Values: []string{"amd64", "arm64"}}},
+						},
+					},
+					{
+						Name:    "plain-build",
+						JobType: config.JobZadigBuild,
+						Spec:    &commonmodels.ZadigBuildJobSpec{},
+					},
+				},
+			}},
+		}
+		It("should use the first cell by default", func() {
+			cell, err := getQuotedBuildMatrixCell(workflow, "build", "")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cell).To(Equal("amd64"))
+		})
+		It("should use the selected cell", func() {
+			cell, err := getQuotedBuildMatrixCell(workflow, "build", "arm64")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cell).To(Equal("arm64"))
+		})
+		It("should raise error for unknown cells", func() {
+			_, err := getQuotedBuildMatrixCell(workflow, "build", "386")
+			Expect(err).Should(HaveOccurred())
+		})
+		It("should return empty cell for jobs not expanded by matrix", func() {
+			cell, err := getQuotedBuildMatrixCell(workflow, "plain-build", "")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cell).To(BeEmpty())
+			_, err = getQuotedBuildMatrixCell(workflow, "plain-build", "arm64")
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("lintBuildMatrix", func() {
+		It("should be passed for valid matrix", func() {
+			err := lintBuildMatrix([]*commonmodels.BuildMatrixAxis{
+				{Key: "GOOS", Values: []string{"linux", "darwin"}},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+		It("should raise error for duplicated keys", func() {
+			err := lintBuildMatrix([]*commonmodels.BuildMatrixAxis{
+				{Key: "GOOS", Values: []string{"linux"}},
+				{Key: "GOOS", Values: []string{"darwin"}},
+			})
+			Expect(err).Should(HaveOccurred())
+		})
+		It("should raise error for too many cells", func() {
+			values := make([]string, 0, maxBuildMatrixCells+1)
+			for i := 0; i <= maxBuildMatrixCells; i++ {
+				values = append(values, fmt.Sprintf("v%d", i))
+			}
+			err := lintBuildMatrix([]*commonmodels.BuildMatrixAxis{{Key: "V", Values: values}})
+			Expect(err).Should(HaveOccurred())
+		})
+		It("should raise error for cell name collisions", func() {
+			err := lintBuildMatrix([]*commonmodels.BuildMatrixAxis{
+				{Key: "PLATFORM", Values: []string{"a.b", "a/b"}},
+			})
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,208 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type CanaryAnalysisJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.CanaryAnalysisJobSpec
+}
+
+func (j *CanaryAnalysisJob) Instantiate() error {
+	j.spec = &commonmodels.CanaryAnalysisJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *CanaryAnalysisJob) SetPreset() error {
+	j.spec = &commonmodels.CanaryAnalysisJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *CanaryAnalysisJob) SetOptions(approvalTicket *commonmodels.ApprovalTicket) error {
+	return nil
+}
+
+func (j *CanaryAnalysisJob) ClearOptions() error {
+	return nil
+}
+
+func (j *CanaryAnalysisJob) ClearSelectionField() error {
+	return nil
+}
+
+func (j *CanaryAnalysisJob) MergeArgs(args *commonmodels.Job) error {
+	return nil
+}
+
+func (j *CanaryAnalysisJob) UpdateWithLatestSetting() error {
+	return nil
+}
+
+func (j *CanaryAnalysisJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	resp := []*commonmodels.JobTask{}
+
+	j.spec = &commonmodels.CanaryAnalysisJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+
+	var fromJob *commonmodels.Job
+	for _, stage := range j.workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.Name == j.spec.FromJob && (job.JobType == config.JobK8sCanaryDeploy || job.JobType == config.JobK8sBlueGreenDeploy) {
+				fromJob = job
+				break
+			}
+		}
+	}
+	if fromJob == nil {
+		return resp, fmt.Errorf("no canary deploy or blue-green deploy job: %s found, please check workflow configuration", j.spec.FromJob)
+	}
+
+	newTaskSpec := func() *commonmodels.JobTaskCanaryAnalysisSpec {
+		return &commonmodels.JobTaskCanaryAnalysisSpec{
+			ObservabilityID: j.spec.ObservabilityID,
+			Duration:        j.spec.Duration,
+			Interval:        j.spec.Interval,
+			FailureLimit:    j.spec.FailureLimit,
+			Metrics:         j.spec.Metrics,
+			DeployType:      fromJob.JobType,
+		}
+	}
+
+	if fromJob.JobType == config.JobK8sCanaryDeploy {
+		deployJobSpec := &commonmodels.CanaryDeployJobSpec{}
+		if err := commonmodels.IToi(fromJob.Spec, deployJobSpec); err != nil {
+			return resp, err
+		}
+		for jobSubTaskID, target := range deployJobSpec.Targets {
+			if target.WorkloadName == "" {
+				continue
+			}
+			taskSpec := newTaskSpec()
+			taskSpec.ClusterID = deployJobSpec.ClusterID
+			taskSpec.Namespace = deployJobSpec.Namespace
+			taskSpec.K8sServiceName = target.K8sServiceName
+			taskSpec.WorkloadName = target.WorkloadName
+			taskSpec.ContainerName = target.ContainerName
+			taskSpec.Image = target.Image
+			resp = append(resp, &commonmodels.JobTask{
+				Name:        GenJobName(j.workflow, j.job.Name, jobSubTaskID),
+				Key:         genJobKey(j.job.Name, target.K8sServiceName),
+				DisplayName: genJobDisplayName(j.job.Name, target.K8sServiceName),
+				OriginName:  j.job.Name,
+				JobInfo: map[string]string{
+					JobNameKey:         j.job.Name,
+					"k8s_service_name": target.K8sServiceName,
+				},
+				JobType:     string(config.JobCanaryAnalysis),
+				Spec:        taskSpec,
+				ErrorPolicy: j.job.ErrorPolicy,
+			})
+		}
+	} else {
+		deployJobSpec := &commonmodels.BlueGreenDeployV2JobSpec{}
+		if err := commonmodels.IToi(fromJob.Spec, deployJobSpec); err != nil {
+			return resp, err
+		}
+		for jobSubTaskID, target := range deployJobSpec.Services {
+			taskSpec := newTaskSpec()
+			taskSpec.Env = deployJobSpec.Env
+			taskSpec.K8sServiceName = target.GreenServiceName
+			taskSpec.BlueGreenService = target
+			resp = append(resp, &commonmodels.JobTask{
+				Name:        GenJobName(j.workflow, j.job.Name, jobSubTaskID),
+				Key:         genJobKey(j.job.Name, target.ServiceName),
+				DisplayName: genJobDisplayName(j.job.Name, target.ServiceName),
+				OriginName:  j.job.Name,
+				JobInfo: map[string]string{
+					JobNameKey:     j.job.Name,
+					"service_name": target.ServiceName,
+				},
+				JobType:     string(config.JobCanaryAnalysis),
+				Spec:        taskSpec,
+				ErrorPolicy: j.job.ErrorPolicy,
+			})
+		}
+	}
+
+	j.job.Spec = j.spec
+	return resp, nil
+}
+
+func (j *CanaryAnalysisJob) LintJob() error {
+	j.spec = &commonmodels.CanaryAnalysisJobSpec{}
+
+	if err := util.CheckZadigProfessionalLicense(); err != nil {
+		return e.ErrLicenseInvalid.AddDesc("")
+	}
+
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	jobRankMap := getJobRankMap(j.workflow.Stages)
+	deployJobRank, ok := jobRankMap[j.spec.FromJob]
+	if !ok || deployJobRank >= jobRankMap[j.job.Name] {
+		return fmt.Errorf("can not quote job %s in job %s", j.spec.FromJob, j.job.Name)
+	}
+
+	if j.spec.Duration <= 0 || j.spec.Interval <= 0 {
+		return fmt.Errorf("duration and interval of job %s must be positive", j.job.Name)
+	}
+	if j.spec.FailureLimit < 0 {
+		return fmt.Errorf("failure limit of job %s must not be negative", j.job.Name)
+	}
+	if len(j.spec.Metrics) == 0 {
+		return fmt.Errorf("no metric is configured in job %s", j.job.Name)
+	}
+	for _, metric := range j.spec.Metrics {
+		if metric.Query == "" {
+			return fmt.Errorf("query of metric %s is empty", metric.Name)
+		}
+		if metric.ThresholdType != commonmodels.CanaryMetricThresholdAbsolute && metric.ThresholdType != commonmodels.CanaryMetricThresholdRelative {
+			return fmt.Errorf("invalid threshold type %s of metric %s", metric.ThresholdType, metric.Name)
+		}
+	}
+
+	observability, err := commonrepo.NewObservabilityColl().GetByID(context.Background(), j.spec.ObservabilityID)
+	if err != nil {
+		return fmt.Errorf("failed to find observability integration %s, err: %s", j.spec.ObservabilityID, err)
+	}
+	if observability.Type != config.ObservabilityTypePrometheus {
+		return fmt.Errorf("observability integration %s is not prometheus", observability.Name)
+	}
+	return nil
+}
@@ -223,11 +223,20 @@ ServiceOrderLoop:
 		return nil, fmt.Errorf("qutoed service referrece of job %s not found", serviceReferredJob)
 	}
 
+	matrixCell, err := getQuotedBuildMatrixCell(j.workflow, imageReferredJob, j.spec.MatrixCell)
+	if err != nil {
+		return nil, err
+	}
+
 	// then we determine the image for the selected job, use the output for each module is enough
 	for _, svc := range resp {
 		for _, module := range svc.ServiceModules {
-			// generate real job keys
-			key := job.GetJobOutputKey(fmt.Sprintf("%s.%s.%s", imageReferredJob, svc.ServiceName, module.ServiceModule), IMAGEKEY)
+			// generate real job keys, the key of a matrix build job task ends with the cell name
+			keyParts := []string{svc.ServiceName, module.ServiceModule}
+			if matrixCell != "" {
+				keyParts = append(keyParts, matrixCell)
+			}
+			key := job.GetJobOutputKey(genJobKey(imageReferredJob, keyParts...), IMAGEKEY)
 
 			module.Image = key
 		}
@@ -293,6 +302,7 @@ func (j *DeployJob) SetPreset() error {
 					// LatestVariableKVs: svc.LatestVariableKVs,
 					VariableYaml:  svc.VariableYaml,
 					OverrideKVs:   svc.OverrideKVs,
+					ValuesKVs:     svc.ValuesKVs,
 					UpdateConfig:  svc.UpdateConfig,
 					Updatable:     svc.Updatable,
 					AutoSync:      svc.AutoSync,
@@ -494,6 +504,8 @@ func (j *DeployJob) UpdateWithLatestSetting() error {
 	}
 	j.spec.SkipCheckRunStatus = latestSpec.SkipCheckRunStatus
 	j.spec.DeployContents = latestSpec.DeployContents
+	j.spec.ImageSigningKeyID = latestSpec.ImageSigningKeyID
+	j.spec.SmokeCheck = latestSpec.SmokeCheck
 
 	// source is a bit tricky: if the saved args has a source of fromjob, but it has been change to runtime in the config
 	// we need to not only update its source but also set services to empty slice.
@@ -918,6 +930,8 @@ func (j *DeployJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 				Production:         j.spec.Production,
 				DeployContents:     j.spec.DeployContents,
 				Timeout:            timeout,
+				ImageSigningKeyID:  j.spec.ImageSigningKeyID,
+				SmokeCheck:         j.spec.SmokeCheck.GetServiceProbes(serviceName),
 			}
 
 			for _, module := range svc.Modules {
@@ -1072,6 +1086,8 @@ func (j *DeployJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 				ReleaseName:        releaseName,
 				Timeout:            timeout,
 				IsProduction:       j.spec.Production,
+				ImageSigningKeyID:  j.spec.ImageSigningKeyID,
+				SmokeCheck:         j.spec.SmokeCheck.GetServiceProbes(svc.ServiceName),
 			}
 
 			for _, module := range svc.Modules {
@@ -1081,6 +1097,7 @@ func (j *DeployJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 					jobTaskSpec.KeyVals = service.KeyVals
 					jobTaskSpec.VariableYaml = service.VariableYaml
 					jobTaskSpec.UserSuppliedValue = jobTaskSpec.VariableYaml
+					jobTaskSpec.ValuesKVs = service.ValuesKVs
 				}
 
 				jobTaskSpec.ImageAndModules = append(jobTaskSpec.ImageAndModules, &commonmodels.ImageAndServiceModule{
@@ -1160,6 +1177,9 @@ func (j *DeployJob) LintJob() error {
 			}
 		}
 	}
+	if err := lintSmokeCheck(j.spec.SmokeCheck); err != nil {
+		return err
+	}
 	if j.spec.Source != config.SourceFromJob {
 		return nil
 	}
@@ -1171,6 +1191,38 @@ func (j *DeployJob) LintJob() error {
 	return nil
 }
 
+func lintSmokeCheck(smokeCheck *commonmodels.SmokeCheck) error {
+	if smokeCheck == nil || !smokeCheck.Enabled {
+		return nil
+	}
+	if smokeCheck.Window < 0 {
+		return fmt.Errorf("invalid smoke check window: %d", smokeCheck.Window)
+	}
+	for _, probe := range smokeCheck.Probes {
+		if probe.ServiceName == "" {
+			return fmt.Errorf("service of smoke probe %s is not set", probe.Name)
+		}
+		if probe.Port <= 0 || probe.Port > 65535 {
+			return fmt.Errorf("invalid port %d of smoke probe %s", probe.Port, probe.Name)
+		}
+		if probe.Timeout < 0 || probe.Retries < 0 || probe.Interval < 0 {
+			return fmt.Errorf("timeout, retries and interval of smoke probe %s must not be negative", probe.Name)
+		}
+		switch probe.Type {
+		case config.SmokeProbeHTTP:
+			for _, status := range probe.ExpectedStatus {
+				if status < 100 || status > 599 {
+					return fmt.Errorf("invalid expected status %d of smoke probe %s", status, probe.Name)
+				}
+			}
+		case config.SmokeProbeGRPC, config.SmokeProbeTCP:
+		default:
+			return fmt.Errorf("invalid type %s of smoke probe %s", probe.Type, probe.Name)
+		}
+	}
+	return nil
+}
+
 func (j *DeployJob) GetOutPuts(log *zap.SugaredLogger) []string {
 	resp := []string{}
 
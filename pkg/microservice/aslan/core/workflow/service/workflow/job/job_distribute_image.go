@@ -225,6 +225,7 @@ func (j *ImageDistributeJob) UpdateWithLatestSetting() error {
 	j.spec.StrategyID = latestSpec.StrategyID
 	j.spec.EnableTargetImageTagRule = latestSpec.EnableTargetImageTagRule
 	j.spec.TargetImageTagRule = latestSpec.TargetImageTagRule
+	j.spec.ImageSigningKeyID = latestSpec.ImageSigningKeyID
 	j.job.Spec = j.spec
 	return nil
 }
@@ -296,6 +297,19 @@ func (j *ImageDistributeJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, erro
 		SourceRegistry: getRegistry(sourceReg),
 		TargetRegistry: getRegistry(targetReg),
 	}
+	if j.spec.ImageSigningKeyID != "" {
+		signingKey, err := commonrepo.NewImageSigningKeyColl().GetByID(j.spec.ImageSigningKeyID)
+		if err != nil {
+			return resp, fmt.Errorf("failed to find image signing key %s, error: %v", j.spec.ImageSigningKeyID, err)
+		}
+		stepSpec.Sign = &step.ImageSign{
+			Mode:          signingKey.Mode,
+			PrivateKey:    signingKey.PrivateKey,
+			Password:      signingKey.Password,
+			IdentityToken: signingKey.IdentityToken,
+			TlogDisabled:  signingKey.TlogDisabled,
+		}
+	}
 	for _, target := range j.spec.Targets {
 		// for other job refer current latest image.
 		targetKey := strings.Join([]string{j.job.Name, target.ServiceName, target.ServiceModule}, ".")
@@ -458,10 +472,19 @@ serviceLoop:
 		return nil, "", fmt.Errorf("ImageDistributeJob: referred job %s not found", serviceReferredJob)
 	}
 
+	matrixCell, err := getQuotedBuildMatrixCell(j.workflow, imageReferredJob, j.spec.MatrixCell)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// then we determine the image for the selected job, use the output for each module is enough
 	for _, svc := range servicetargets {
-		// generate real job keys
-		key := job.GetJobOutputKey(fmt.Sprintf("%s.%s.%s", imageReferredJob, svc.ServiceName, svc.ServiceModule), IMAGEKEY)
+		// generate real job keys, the key of a matrix build job task ends with the cell name
+		keyParts := []string{svc.ServiceName, svc.ServiceModule}
+		if matrixCell != "" {
+			keyParts = append(keyParts, matrixCell)
+		}
+		key := job.GetJobOutputKey(genJobKey(imageReferredJob, keyParts...), IMAGEKEY)
 
 		svc.SourceImage = key
 	}
@@ -0,0 +1,161 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+type EnvAnalysisJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.EnvAnalysisJobSpec
+}
+
+func (j *EnvAnalysisJob) Instantiate() error {
+	j.spec = &commonmodels.EnvAnalysisJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *EnvAnalysisJob) SetPreset() error {
+	j.spec = &commonmodels.EnvAnalysisJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *EnvAnalysisJob) SetOptions(approvalTicket *commonmodels.ApprovalTicket) error {
+	return nil
+}
+
+func (j *EnvAnalysisJob) ClearOptions() error {
+	return nil
+}
+
+func (j *EnvAnalysisJob) ClearSelectionField() error {
+	return nil
+}
+
+func (j *EnvAnalysisJob) UpdateWithLatestSetting() error {
+	j.spec = &commonmodels.EnvAnalysisJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+
+	latestWorkflow, err := commonrepo.NewWorkflowV4Coll().Find(j.workflow.Name)
+	if err != nil {
+		log.Errorf("Failed to find original workflow to set options, error: %s", err)
+		return err
+	}
+
+	latestSpec := new(commonmodels.EnvAnalysisJobSpec)
+	found := false
+	for _, stage := range latestWorkflow.Stages {
+		if !found {
+			for _, job := range stage.Jobs {
+				if job.Name == j.job.Name && job.JobType == j.job.JobType {
+					if err := commonmodels.IToi(job.Spec, latestSpec); err != nil {
+						return err
+					}
+					found = true
+					break
+				}
+			}
+		} else {
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("failed to find the original workflow: %s", j.workflow.Name)
+	}
+
+	j.spec.ResourceTypes = latestSpec.ResourceTypes
+	j.spec.FailOnProblems = latestSpec.FailOnProblems
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *EnvAnalysisJob) MergeArgs(args *commonmodels.Job) error {
+	j.spec = &commonmodels.EnvAnalysisJobSpec{}
+	if err := commonmodels.IToi(args.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *EnvAnalysisJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	resp := []*commonmodels.JobTask{}
+	j.spec = &commonmodels.EnvAnalysisJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+	j.job.Spec = j.spec
+
+	jobTask := &commonmodels.JobTask{
+		Name:        GenJobName(j.workflow, j.job.Name, 0),
+		Key:         genJobKey(j.job.Name),
+		DisplayName: genJobDisplayName(j.job.Name),
+		OriginName:  j.job.Name,
+		JobType:     string(config.JobEnvAnalysis),
+		JobInfo: map[string]string{
+			JobNameKey: j.job.Name,
+		},
+		Spec: &commonmodels.JobTaskEnvAnalysisSpec{
+			EnvName:        j.spec.EnvName,
+			Production:     j.spec.Production,
+			ResourceTypes:  j.spec.ResourceTypes,
+			ServiceNames:   j.spec.ServiceNames,
+			Workloads:      j.spec.Workloads,
+			FailOnProblems: j.spec.FailOnProblems,
+		},
+		Timeout:     0,
+		ErrorPolicy: j.job.ErrorPolicy,
+	}
+	return []*commonmodels.JobTask{jobTask}, nil
+}
+
+func (j *EnvAnalysisJob) LintJob() error {
+	j.spec = &commonmodels.EnvAnalysisJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+
+	if j.spec.EnvName == "" {
+		return fmt.Errorf("env of job %s is not set", j.job.Name)
+	}
+	for _, workload := range j.spec.Workloads {
+		if !strings.Contains(workload, "/") {
+			return fmt.Errorf("invalid workload %s in job %s, should be in the form of Kind/name", workload, j.job.Name)
+		}
+	}
+	return nil
+}
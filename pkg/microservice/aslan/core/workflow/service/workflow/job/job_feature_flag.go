@@ -0,0 +1,197 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+type FeatureFlagJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.FeatureFlagJobSpec
+}
+
+func (j *FeatureFlagJob) Instantiate() error {
+	j.spec = &commonmodels.FeatureFlagJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *FeatureFlagJob) SetPreset() error {
+	j.spec = &commonmodels.FeatureFlagJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *FeatureFlagJob) SetOptions(approvalTicket *commonmodels.ApprovalTicket) error {
+	return nil
+}
+
+func (j *FeatureFlagJob) ClearOptions() error {
+	return nil
+}
+
+func (j *FeatureFlagJob) ClearSelectionField() error {
+	return nil
+}
+
+func (j *FeatureFlagJob) UpdateWithLatestSetting() error {
+	j.spec = &commonmodels.FeatureFlagJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+
+	latestWorkflow, err := commonrepo.NewWorkflowV4Coll().Find(j.workflow.Name)
+	if err != nil {
+		log.Errorf("Failed to find original workflow to set options, error: %s", err)
+		return err
+	}
+
+	latestSpec := new(commonmodels.FeatureFlagJobSpec)
+	found := false
+	for _, stage := range latestWorkflow.Stages {
+		if !found {
+			for _, job := range stage.Jobs {
+				if job.Name == j.job.Name && job.JobType == j.job.JobType {
+					if err := commonmodels.IToi(job.Spec, latestSpec); err != nil {
+						return err
+					}
+					found = true
+					break
+				}
+			}
+		} else {
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("failed to find the original workflow: %s", j.workflow.Name)
+	}
+
+	j.spec.FeatureFlagID = latestSpec.FeatureFlagID
+	j.spec.ProjectKey = latestSpec.ProjectKey
+	j.spec.EnvMappings = latestSpec.EnvMappings
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *FeatureFlagJob) MergeArgs(args *commonmodels.Job) error {
+	j.spec = &commonmodels.FeatureFlagJobSpec{}
+	if err := commonmodels.IToi(args.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *FeatureFlagJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	resp := []*commonmodels.JobTask{}
+	j.spec = &commonmodels.FeatureFlagJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+	j.job.Spec = j.spec
+	if len(j.spec.Flags) == 0 {
+		return nil, errors.New("feature flag list is empty")
+	}
+
+	flagEnv := ""
+	for _, mapping := range j.spec.EnvMappings {
+		if mapping.EnvName == j.spec.EnvName {
+			flagEnv = mapping.FlagEnv
+			break
+		}
+	}
+	if flagEnv == "" {
+		return nil, fmt.Errorf("env %s is not mapped to any feature flag environment", j.spec.EnvName)
+	}
+
+	flags := make([]*commonmodels.FeatureFlagChange, 0, len(j.spec.Flags))
+	for _, flag := range j.spec.Flags {
+		flags = append(flags, &commonmodels.FeatureFlagChange{
+			Key:     flag.Key,
+			Enabled: flag.Enabled,
+		})
+	}
+	jobTask := &commonmodels.JobTask{
+		Name:        GenJobName(j.workflow, j.job.Name, 0),
+		Key:         genJobKey(j.job.Name),
+		DisplayName: genJobDisplayName(j.job.Name),
+		OriginName:  j.job.Name,
+		JobInfo: map[string]string{
+			JobNameKey: j.job.Name,
+		},
+		JobType: string(config.JobFeatureFlag),
+		Spec: &commonmodels.JobTaskFeatureFlagSpec{
+			FeatureFlagID: j.spec.FeatureFlagID,
+			ProjectKey:    j.spec.ProjectKey,
+			EnvName:       j.spec.EnvName,
+			FlagEnv:       flagEnv,
+			Flags:         flags,
+		},
+		Timeout:     0,
+		ErrorPolicy: j.job.ErrorPolicy,
+	}
+	return []*commonmodels.JobTask{jobTask}, nil
+}
+
+func (j *FeatureFlagJob) LintJob() error {
+	j.spec = &commonmodels.FeatureFlagJobSpec{}
+	if err := util.CheckZadigProfessionalLicense(); err != nil {
+		return e.ErrLicenseInvalid.AddDesc("")
+	}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	info, err := commonrepo.NewConfigurationManagementColl().GetFeatureFlagByID(context.Background(), j.spec.FeatureFlagID)
+	if err != nil {
+		return errors.Errorf("not found feature flag system in mongo, err: %v", err)
+	}
+	if info.Type != setting.SourceFromFlagsmith && j.spec.ProjectKey == "" {
+		return errors.Errorf("project key of job %s is empty", j.job.Name)
+	}
+	for _, mapping := range j.spec.EnvMappings {
+		if mapping.EnvName == "" || mapping.FlagEnv == "" {
+			return errors.Errorf("invalid env mapping of job %s", j.job.Name)
+		}
+	}
+	for _, flag := range j.spec.Flags {
+		if flag.Key == "" {
+			return errors.Errorf("flag key of job %s is empty", j.job.Name)
+		}
+	}
+	return nil
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
 	commonutil "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
 	codehostrepo "github.com/koderover/zadig/v2/pkg/microservice/systemconfig/core/codehost/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
 	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
 	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
@@ -671,6 +672,15 @@ func (j *FreeStyleJob) LintJob() error {
 		}
 	}
 
+	if access := j.spec.Properties.EnvKubeAccess; access != nil && access.Enabled {
+		if access.EnvName == "" {
+			return fmt.Errorf("env of the kube access can not be empty in job %s", j.job.Name)
+		}
+		if j.spec.Properties.Infrastructure == setting.JobVMInfrastructure {
+			return fmt.Errorf("kube access is not supported by vm job %s", j.job.Name)
+		}
+	}
+
 	j.job.Spec = j.spec
 	return checkOutputNames(j.spec.Outputs)
 }
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/shared/client/systemconfig"
+)
+
+type GitHubActionsJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.GitHubActionsJobSpec
+}
+
+func (j *GitHubActionsJob) Instantiate() error {
+	j.spec = &commonmodels.GitHubActionsJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *GitHubActionsJob) SetPreset() error {
+	j.spec = &commonmodels.GitHubActionsJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *GitHubActionsJob) SetOptions(approvalTicket *commonmodels.ApprovalTicket) error {
+	return nil
+}
+
+func (j *GitHubActionsJob) ClearOptions() error {
+	return nil
+}
+
+func (j *GitHubActionsJob) ClearSelectionField() error {
+	return nil
+}
+
+func (j *GitHubActionsJob) UpdateWithLatestSetting() error {
+	return nil
+}
+
+func (j *GitHubActionsJob) MergeArgs(args *commonmodels.Job) error {
+	j.spec = &commonmodels.GitHubActionsJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+
+	argsSpec := &commonmodels.GitHubActionsJobSpec{}
+	if err := commonmodels.IToi(args.Spec, argsSpec); err != nil {
+		return err
+	}
+	// only the ref and the input values can be changed when running the workflow
+	if argsSpec.Ref != "" {
+		j.spec.Ref = argsSpec.Ref
+	}
+	argsInputs := make(map[string]string)
+	for _, input := range argsSpec.Inputs {
+		argsInputs[input.Key] = input.Value
+	}
+	for _, input := range j.spec.Inputs {
+		if value, ok := argsInputs[input.Key]; ok {
+			input.Value = value
+		}
+	}
+
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *GitHubActionsJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	j.spec = &commonmodels.GitHubActionsJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return nil, err
+	}
+
+	resp := make([]*commonmodels.JobTask, 0)
+	resp = append(resp, &commonmodels.JobTask{
+		Name:        GenJobName(j.workflow, j.job.Name, 0),
+		Key:         genJobKey(j.job.Name),
+		DisplayName: genJobDisplayName(j.job.Name),
+		OriginName:  j.job.Name,
+		JobInfo: map[string]string{
+			JobNameKey: j.job.Name,
+		},
+		JobType: string(config.JobGitHubActions),
+		Spec: &commonmodels.JobTaskGitHubActionsSpec{
+			CodeHostID:   j.spec.CodeHostID,
+			RepoOwner:    j.spec.RepoOwner,
+			RepoName:     j.spec.RepoName,
+			WorkflowFile: j.spec.WorkflowFile,
+			Ref:          j.spec.Ref,
+			Inputs:       j.spec.Inputs,
+		},
+		Timeout:     0,
+		ErrorPolicy: j.job.ErrorPolicy,
+	})
+
+	return resp, nil
+}
+
+func (j *GitHubActionsJob) LintJob() error {
+	j.spec = &commonmodels.GitHubActionsJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	if j.spec.RepoOwner == "" || j.spec.RepoName == "" || j.spec.WorkflowFile == "" || j.spec.Ref == "" {
+		return fmt.Errorf("repository, workflow file and ref of github actions job %s are required", j.job.Name)
+	}
+	codehost, err := systemconfig.New().GetCodeHost(j.spec.CodeHostID)
+	if err != nil {
+		return fmt.Errorf("failed to find codehost %d, err: %v", j.spec.CodeHostID, err)
+	}
+	if codehost.Type != setting.SourceFromGithub {
+		return fmt.Errorf("codehost %d is not a github codehost", j.spec.CodeHostID)
+	}
+	return nil
+}
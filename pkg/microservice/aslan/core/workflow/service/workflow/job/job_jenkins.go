@@ -18,6 +18,8 @@ package job
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
@@ -168,6 +170,9 @@ func (j *JenkinsJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 		return nil, errors.New("Jenkins job list is empty")
 	}
 	for _, job := range j.spec.Jobs {
+		if err := validateJenkinsParameters(job.Parameters); err != nil {
+			return nil, fmt.Errorf("invalid parameters of jenkins job %s: %v", job.JobName, err)
+		}
 		resp = append(resp, &commonmodels.JobTask{
 			Name:        GenJobName(j.workflow, j.job.Name, 0),
 			Key:         genJobKey(j.job.Name, job.JobName),
@@ -184,6 +189,7 @@ func (j *JenkinsJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 					JobName:    job.JobName,
 					Parameters: job.Parameters,
 				},
+				PullArtifacts: j.spec.PullArtifacts,
 			},
 			Timeout:     0,
 			ErrorPolicy: j.job.ErrorPolicy,
@@ -203,3 +209,20 @@ func (j *JenkinsJob) LintJob() error {
 	}
 	return nil
 }
+
+// validateJenkinsParameters checks the parameter values against their types before triggering the jenkins build
+func validateJenkinsParameters(parameters []*commonmodels.JenkinsJobParameter) error {
+	for _, parameter := range parameters {
+		switch parameter.Type {
+		case config.ParamTypeBool:
+			if _, err := strconv.ParseBool(parameter.Value); err != nil {
+				return fmt.Errorf("parameter %s requires a bool value, got %q", parameter.Name, parameter.Value)
+			}
+		case config.ParamTypeChoice:
+			if len(parameter.Choices) > 0 && !slices.Contains(parameter.Choices, parameter.Value) {
+				return fmt.Errorf("value %q of parameter %s is not one of the choices %v", parameter.Value, parameter.Name, parameter.Choices)
+			}
+		}
+	}
+	return nil
+}
@@ -128,6 +128,9 @@ func (j *JiraJob) UpdateWithLatestSetting() error {
 		j.spec.IssueType = latestSpec.IssueType
 		j.spec.TargetStatus = latestSpec.TargetStatus
 	}
+	j.spec.IssueSource = latestSpec.IssueSource
+	j.spec.EnvTransitions = latestSpec.EnvTransitions
+	j.spec.Comment = latestSpec.Comment
 
 	j.job.Spec = j.spec
 	return nil
@@ -139,7 +142,8 @@ func (j *JiraJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
 		return resp, err
 	}
-	if len(j.spec.Issues) == 0 {
+	// the issues are extracted from the commit messages when the job runs
+	if len(j.spec.Issues) == 0 && j.spec.IssueSource != commonmodels.JiraIssueSourceCommit {
 		return nil, errors.New("需要指定至少一个 Jira Issue")
 	}
 	j.job.Spec = j.spec
@@ -165,6 +169,10 @@ func (j *JiraJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 			IssueType:    j.spec.IssueType,
 			Issues:       j.spec.Issues,
 			TargetStatus: j.spec.TargetStatus,
+
+			IssueSource:    j.spec.IssueSource,
+			EnvTransitions: j.spec.EnvTransitions,
+			Comment:        j.spec.Comment,
 		},
 		Timeout:     0,
 		ErrorPolicy: j.job.ErrorPolicy,
@@ -186,5 +194,10 @@ func (j *JiraJob) LintJob() error {
 	default:
 		return errors.New("invalid source")
 	}
+	for _, transition := range j.spec.EnvTransitions {
+		if transition.EnvType != commonmodels.JiraEnvTypeTesting && transition.EnvType != commonmodels.JiraEnvTypeProduction {
+			return fmt.Errorf("invalid env type %s", transition.EnvType)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+type SubWorkflowJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.SubWorkflowJobSpec
+}
+
+func (j *SubWorkflowJob) Instantiate() error {
+	j.spec = &commonmodels.SubWorkflowJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *SubWorkflowJob) SetPreset() error {
+	j.spec = &commonmodels.SubWorkflowJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *SubWorkflowJob) SetOptions(approvalTicket *commonmodels.ApprovalTicket) error {
+	return nil
+}
+
+func (j *SubWorkflowJob) ClearOptions() error {
+	return nil
+}
+
+func (j *SubWorkflowJob) ClearSelectionField() error {
+	return nil
+}
+
+func (j *SubWorkflowJob) UpdateWithLatestSetting() error {
+	j.spec = &commonmodels.SubWorkflowJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+
+	latestWorkflow, err := commonrepo.NewWorkflowV4Coll().Find(j.workflow.Name)
+	if err != nil {
+		log.Errorf("Failed to find original workflow to set options, error: %s", err)
+		return err
+	}
+
+	latestSpec := new(commonmodels.SubWorkflowJobSpec)
+	found := false
+	for _, stage := range latestWorkflow.Stages {
+		if !found {
+			for _, job := range stage.Jobs {
+				if job.Name == j.job.Name && job.JobType == j.job.JobType {
+					if err := commonmodels.IToi(job.Spec, latestSpec); err != nil {
+						return err
+					}
+					found = true
+					break
+				}
+			}
+		} else {
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("failed to find the original workflow: %s", j.workflow.Name)
+	}
+
+	// the values of the params still in the latest setting are kept
+	paramMap := make(map[string]*commonmodels.Param)
+	for _, param := range j.spec.Params {
+		paramMap[param.Name] = param
+	}
+	for _, param := range latestSpec.Params {
+		if cur, ok := paramMap[param.Name]; ok && cur.ParamsType == param.ParamsType {
+			param.Value = cur.Value
+			param.ChoiceValue = cur.ChoiceValue
+			param.Repo = cur.Repo
+		}
+	}
+
+	j.spec.ProjectName = latestSpec.ProjectName
+	j.spec.WorkflowName = latestSpec.WorkflowName
+	j.spec.Async = latestSpec.Async
+	j.spec.Params = latestSpec.Params
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *SubWorkflowJob) MergeArgs(args *commonmodels.Job) error {
+	if j.job.Name == args.Name && j.job.JobType == args.JobType {
+		j.spec = &commonmodels.SubWorkflowJobSpec{}
+		if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+			return err
+		}
+
+		argsSpec := &commonmodels.SubWorkflowJobSpec{}
+		if err := commonmodels.IToi(args.Spec, argsSpec); err != nil {
+			return err
+		}
+
+		// only the values of the params are taken from the args, the sub workflow can not be changed when running
+		argsParamMap := make(map[string]*commonmodels.Param)
+		for _, param := range argsSpec.Params {
+			argsParamMap[param.Name] = param
+		}
+		for _, param := range j.spec.Params {
+			if argsParam, ok := argsParamMap[param.Name]; ok {
+				param.Value = argsParam.Value
+				param.ChoiceValue = argsParam.ChoiceValue
+				param.Repo = argsParam.Repo
+			}
+		}
+		j.job.Spec = j.spec
+	}
+	return nil
+}
+
+func (j *SubWorkflowJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	resp := []*commonmodels.JobTask{}
+	j.spec = &commonmodels.SubWorkflowJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+	j.job.Spec = j.spec
+
+	jobTask := &commonmodels.JobTask{
+		Name:        GenJobName(j.workflow, j.job.Name, 0),
+		Key:         genJobKey(j.job.Name),
+		DisplayName: genJobDisplayName(j.job.Name),
+		OriginName:  j.job.Name,
+		JobType:     string(config.JobSubWorkflow),
+		JobInfo: map[string]string{
+			JobNameKey: j.job.Name,
+		},
+		Spec: &commonmodels.JobTaskSubWorkflowSpec{
+			ProjectName:  j.spec.ProjectName,
+			WorkflowName: j.spec.WorkflowName,
+			Params:       j.spec.Params,
+			Async:        j.spec.Async,
+		},
+		Timeout:     0,
+		ErrorPolicy: j.job.ErrorPolicy,
+	}
+	return []*commonmodels.JobTask{jobTask}, nil
+}
+
+func (j *SubWorkflowJob) LintJob() error {
+	j.spec = &commonmodels.SubWorkflowJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+
+	if j.spec.WorkflowName == "" {
+		return fmt.Errorf("sub workflow of job %s is not set", j.job.Name)
+	}
+	subWorkflow, err := commonrepo.NewWorkflowV4Coll().Find(j.spec.WorkflowName)
+	if err != nil {
+		return fmt.Errorf("can't found workflow %s: %v", j.spec.WorkflowName, err)
+	}
+	if j.spec.ProjectName != "" && subWorkflow.Project != j.spec.ProjectName {
+		return fmt.Errorf("workflow %s not found in project %s", j.spec.WorkflowName, j.spec.ProjectName)
+	}
+
+	subParams := sets.NewString()
+	for _, param := range subWorkflow.Params {
+		subParams.Insert(param.Name)
+	}
+	for _, param := range j.spec.Params {
+		if !subParams.Has(param.Name) {
+			return fmt.Errorf("param %s not found in workflow %s", param.Name, subWorkflow.Name)
+		}
+	}
+
+	return checkSubWorkflowLoop(subWorkflow, sets.NewString(j.workflow.Name))
+}
+
+// checkSubWorkflowLoop makes sure the workflow is not called by itself through the sub workflow jobs
+func checkSubWorkflowLoop(workflow *commonmodels.WorkflowV4, callers sets.String) error {
+	if callers.Has(workflow.Name) {
+		return fmt.Errorf("工作流不能循环调用, 工作流名称: %s", workflow.Name)
+	}
+	callers = sets.NewString(append(callers.List(), workflow.Name)...)
+
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.JobType != config.JobSubWorkflow {
+				continue
+			}
+			spec := &commonmodels.SubWorkflowJobSpec{}
+			if err := commonmodels.IToi(job.Spec, spec); err != nil {
+				return err
+			}
+			subWorkflow, err := commonrepo.NewWorkflowV4Coll().Find(spec.WorkflowName)
+			if err != nil {
+				return fmt.Errorf("can't found workflow %s: %v", spec.WorkflowName, err)
+			}
+			if err := checkSubWorkflowLoop(subWorkflow, callers); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
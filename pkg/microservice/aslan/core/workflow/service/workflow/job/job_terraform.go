@@ -0,0 +1,443 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/util"
+	codehostrepo "github.com/koderover/zadig/v2/pkg/microservice/systemconfig/core/codehost/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+	"github.com/koderover/zadig/v2/pkg/types"
+	"github.com/koderover/zadig/v2/pkg/types/step"
+)
+
+const (
+	terraformPlanFile     = "tfplan"
+	terraformPlanTextFile = "tfplan.txt"
+	terraformBackendEnv   = "ZADIG_TF_BACKEND_CONFIG"
+)
+
+type TerraformJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.TerraformJobSpec
+}
+
+func (j *TerraformJob) Instantiate() error {
+	j.spec = &commonmodels.TerraformJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *TerraformJob) SetPreset() error {
+	j.spec = &commonmodels.TerraformJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	if j.spec.Properties != nil && j.spec.Properties.ClusterSource == "" {
+		j.spec.Properties.ClusterSource = "fixed"
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *TerraformJob) SetOptions(approvalTicket *commonmodels.ApprovalTicket) error {
+	return nil
+}
+
+func (j *TerraformJob) ClearOptions() error {
+	return nil
+}
+
+func (j *TerraformJob) ClearSelectionField() error {
+	return nil
+}
+
+func (j *TerraformJob) UpdateWithLatestSetting() error {
+	return nil
+}
+
+func (j *TerraformJob) MergeArgs(args *commonmodels.Job) error {
+	if j.job.Name == args.Name && j.job.JobType == args.JobType {
+		j.spec = &commonmodels.TerraformJobSpec{}
+		if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+			return err
+		}
+		argsSpec := &commonmodels.TerraformJobSpec{}
+		if err := commonmodels.IToi(args.Spec, argsSpec); err != nil {
+			return err
+		}
+		j.spec.Variables = renderKeyVals(argsSpec.Variables, j.spec.Variables)
+		j.spec.Repos = mergeRepos(j.spec.Repos, argsSpec.Repos)
+		if j.spec.Properties != nil && argsSpec.Properties != nil {
+			j.spec.Properties.Envs = renderKeyVals(argsSpec.Properties.Envs, j.spec.Properties.Envs)
+		}
+		j.job.Spec = j.spec
+	}
+	return nil
+}
+
+func (j *TerraformJob) MergeWebhookRepo(webhookRepo *types.Repository) error {
+	j.spec = &commonmodels.TerraformJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.spec.Repos = mergeRepos(j.spec.Repos, []*types.Repository{webhookRepo})
+	j.job.Spec = j.spec
+	return nil
+}
+
+// ToJobs generates a plan job task, and if the action is apply, an optional approval job task
+// and an apply job task. The plan file is passed from plan to apply by the default object storage,
+// so the apply always executes exactly what has been planned and approved.
+func (j *TerraformJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	logger := log.SugaredLogger()
+	j.spec = &commonmodels.TerraformJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return nil, err
+	}
+	j.job.Spec = j.spec
+
+	defaultS3, err := commonrepo.NewS3StorageColl().FindDefault()
+	if err != nil {
+		return nil, fmt.Errorf("find default s3 storage error: %v", err)
+	}
+	registries, err := commonservice.ListRegistryNamespaces("", true, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*commonmodels.JobTask, 0)
+	planTask, err := j.toTerraformJobTask(taskID, 0, config.TerraformActionPlan, defaultS3, registries)
+	if err != nil {
+		return nil, err
+	}
+	resp = append(resp, planTask)
+	if j.spec.Action != config.TerraformActionApply {
+		return resp, nil
+	}
+
+	if j.spec.ApplyApproval != nil && j.spec.ApplyApproval.Enabled {
+		approvalTask, err := j.toApprovalJobTask(1)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, approvalTask)
+	}
+
+	applyTask, err := j.toTerraformJobTask(taskID, 2, config.TerraformActionApply, defaultS3, registries)
+	if err != nil {
+		return nil, err
+	}
+	resp = append(resp, applyTask)
+	return resp, nil
+}
+
+func (j *TerraformJob) toTerraformJobTask(taskID int64, jobSubTaskID int, action config.TerraformAction, defaultS3 *commonmodels.S3Storage, registries []*commonmodels.RegistryNamespace) (*commonmodels.JobTask, error) {
+	jobTaskSpec := &commonmodels.JobTaskFreestyleSpec{
+		Properties: *j.spec.Properties,
+	}
+	jobTask := &commonmodels.JobTask{
+		Name:        GenJobName(j.workflow, j.job.Name, jobSubTaskID),
+		Key:         genJobKey(j.job.Name, string(action)),
+		DisplayName: genJobDisplayName(j.job.Name, string(action)),
+		OriginName:  j.job.Name,
+		JobInfo: map[string]string{
+			JobNameKey:         j.job.Name,
+			"terraform_action": string(action),
+		},
+		JobType:     string(config.JobTerraform),
+		Spec:        jobTaskSpec,
+		Timeout:     j.spec.Properties.Timeout,
+		ErrorPolicy: j.job.ErrorPolicy,
+	}
+
+	basicImage, err := commonrepo.NewBasicImageColl().Find(jobTaskSpec.Properties.ImageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find base image: %s,error :%v", jobTaskSpec.Properties.ImageID, err)
+	}
+	jobTaskSpec.Properties.BuildOS = basicImage.Value
+	jobTaskSpec.Properties.Registries = registries
+	jobTaskSpec.Properties.ShareStorageDetails = getShareStorageDetail(j.workflow.ShareStorages, j.spec.Properties.ShareStorageInfo, j.workflow.Name, taskID)
+
+	paramEnvs := generateKeyValsFromWorkflowParam(j.workflow.Params)
+	envs := mergeKeyVals(jobTaskSpec.Properties.Envs, paramEnvs)
+	envs = append(envs, j.terraformEnvs()...)
+	jobTaskSpec.Properties.CustomEnvs = jobTaskSpec.Properties.Envs
+	jobTaskSpec.Properties.Envs = append(envs, PrepareDefaultWorkflowTaskEnvs(j.workflow.Project, j.workflow.Name, j.workflow.DisplayName, "", taskID)...)
+	jobTaskSpec.Properties.Envs = append(jobTaskSpec.Properties.Envs, getReposVariables(j.spec.Repos)...)
+
+	s3 := modelS3toS3(defaultS3)
+	// the plan file of one task is shared by the plan job and the apply job
+	planObjectPath := path.Join(j.workflow.Name, fmt.Sprint(taskID), j.job.Name, "terraform")
+
+	if j.spec.Version != "" {
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, &commonmodels.StepTask{
+			Name:     j.job.Name + "-tool-install",
+			JobName:  jobTask.Name,
+			StepType: config.StepTools,
+			Spec: step.StepToolInstallSpec{Installs: []*step.Tool{{
+				Name:    string(j.terraformBinary()),
+				Version: j.spec.Version,
+			}}},
+		})
+	}
+
+	codehosts, err := codehostrepo.NewCodehostColl().AvailableCodeHost(j.workflow.Project)
+	if err != nil {
+		return nil, fmt.Errorf("find %s project codehost error: %v", j.workflow.Project, err)
+	}
+	repos := make([]*types.Repository, 0)
+	for _, repo := range j.spec.Repos {
+		if repo.SourceFrom == types.RepoSourceParam {
+			paramRepo, err := findMatchedRepoFromParams(j.workflow.Params, repo.GlobalParamName)
+			if err != nil {
+				return nil, fmt.Errorf("findMatchedRepoFromParams error: %v", err)
+			}
+			repos = append(repos, paramRepo)
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	gitRepos, _ := splitReposByType(repos)
+	jobTaskSpec.Steps = append(jobTaskSpec.Steps, &commonmodels.StepTask{
+		Name:     j.job.Name + "-git",
+		JobName:  jobTask.Name,
+		StepType: config.StepGit,
+		Spec: step.StepGitSpec{
+			CodeHosts: codehosts,
+			Repos:     gitRepos,
+		},
+	})
+
+	if action == config.TerraformActionApply {
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, &commonmodels.StepTask{
+			Name:     j.job.Name + "-download-plan",
+			JobName:  jobTask.Name,
+			StepType: config.StepDownloadArchive,
+			Spec: step.StepDownloadArchiveSpec{
+				FileName:   terraformPlanFile,
+				ObjectPath: path.Join(s3.Subfolder, planObjectPath),
+				DestDir:    j.spec.WorkDir,
+				S3:         s3,
+			},
+		})
+	}
+
+	jobTaskSpec.Steps = append(jobTaskSpec.Steps, &commonmodels.StepTask{
+		Name:     j.job.Name + "-debug_before",
+		JobName:  jobTask.Name,
+		StepType: config.StepDebugBefore,
+	})
+	jobTaskSpec.Steps = append(jobTaskSpec.Steps, &commonmodels.StepTask{
+		Name:     fmt.Sprintf("%s-%s", j.job.Name, action),
+		JobName:  jobTask.Name,
+		StepType: config.StepShell,
+		Spec: &step.StepShellSpec{
+			Scripts: j.terraformScripts(action),
+		},
+	})
+	jobTaskSpec.Steps = append(jobTaskSpec.Steps, &commonmodels.StepTask{
+		Name:     j.job.Name + "-debug_after",
+		JobName:  jobTask.Name,
+		StepType: config.StepDebugAfter,
+	})
+
+	if action == config.TerraformActionPlan {
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, &commonmodels.StepTask{
+			Name:     j.job.Name + "-plan-archive",
+			JobName:  jobTask.Name,
+			StepType: config.StepArchive,
+			Spec: step.StepArchiveSpec{
+				UploadDetail: []*step.Upload{
+					{
+						FilePath:        path.Join(j.spec.WorkDir, terraformPlanFile),
+						DestinationPath: planObjectPath,
+					},
+					{
+						FilePath:        path.Join(j.spec.WorkDir, terraformPlanTextFile),
+						DestinationPath: planObjectPath,
+					},
+				},
+				S3: s3,
+			},
+		})
+	}
+
+	return jobTask, nil
+}
+
+func (j *TerraformJob) toApprovalJobTask(jobSubTaskID int) (*commonmodels.JobTask, error) {
+	approval := j.spec.ApplyApproval
+	nativeApproval := approval.NativeApproval
+	if approval.Type == config.NativeApproval {
+		if nativeApproval == nil {
+			return nil, fmt.Errorf("native approval not found")
+		}
+		approvalUser, _ := util.GeneFlatUsers(nativeApproval.ApproveUsers)
+		nativeApproval.ApproveUsers = approvalUser
+		if len(nativeApproval.ApproveUsers) == 0 {
+			return nil, fmt.Errorf("num of approve-users is 0")
+		}
+		nativeApproval.CallbackSecret = getSavedApprovalCallbackSecret(j.workflow.Name, j.job.Name)
+	}
+
+	return &commonmodels.JobTask{
+		Name:        GenJobName(j.workflow, j.job.Name, jobSubTaskID),
+		Key:         genJobKey(j.job.Name, "approval"),
+		DisplayName: genJobDisplayName(j.job.Name, "approval"),
+		OriginName:  j.job.Name,
+		JobInfo: map[string]string{
+			JobNameKey: j.job.Name,
+		},
+		JobType: string(config.JobApproval),
+		Spec: &commonmodels.JobTaskApprovalSpec{
+			Timeout:          approval.Timeout,
+			Type:             approval.Type,
+			Description:      approval.Description,
+			NativeApproval:   nativeApproval,
+			LarkApproval:     approval.LarkApproval,
+			DingTalkApproval: approval.DingTalkApproval,
+			WorkWXApproval:   approval.WorkWXApproval,
+		},
+		Timeout:     approval.Timeout,
+		ErrorPolicy: j.job.ErrorPolicy,
+	}, nil
+}
+
+func (j *TerraformJob) terraformBinary() config.TerraformBinary {
+	if j.spec.Binary == "" {
+		return config.TerraformBinaryTerraform
+	}
+	return j.spec.Binary
+}
+
+// terraformEnvs passes variables and backend configs by envs, so credentials never show in the scripts
+func (j *TerraformJob) terraformEnvs() []*commonmodels.KeyVal {
+	resp := make([]*commonmodels.KeyVal, 0)
+	resp = append(resp, &commonmodels.KeyVal{Key: "TF_IN_AUTOMATION", Value: "true"})
+	resp = append(resp, &commonmodels.KeyVal{Key: "TF_INPUT", Value: "0"})
+	for _, kv := range j.spec.Variables {
+		value := kv.Value
+		if kv.Type == commonmodels.MultiSelectType {
+			value = strings.Join(kv.ChoiceValue, ",")
+		}
+		resp = append(resp, &commonmodels.KeyVal{
+			Key:          "TF_VAR_" + kv.Key,
+			Value:        value,
+			IsCredential: kv.IsCredential,
+		})
+	}
+	if j.spec.Backend != nil {
+		for i, kv := range j.spec.Backend.Configs {
+			resp = append(resp, &commonmodels.KeyVal{
+				Key:          fmt.Sprintf("%s_%d", terraformBackendEnv, i),
+				Value:        fmt.Sprintf("%s=%s", kv.Key, kv.Value),
+				IsCredential: kv.IsCredential,
+			})
+		}
+	}
+	return resp
+}
+
+func (j *TerraformJob) terraformScripts(action config.TerraformAction) []string {
+	binary := string(j.terraformBinary())
+	initCmd := binary + " init -input=false"
+	if j.spec.Backend != nil {
+		for i := range j.spec.Backend.Configs {
+			initCmd += fmt.Sprintf(` -backend-config="$%s_%d"`, terraformBackendEnv, i)
+		}
+	}
+
+	scripts := []string{
+		"set -e",
+		fmt.Sprintf(`cd "$WORKSPACE/%s"`, strings.TrimPrefix(j.spec.WorkDir, "/")),
+		initCmd,
+	}
+	switch action {
+	case config.TerraformActionPlan:
+		scripts = append(scripts,
+			fmt.Sprintf("%s plan -input=false -out=%s", binary, terraformPlanFile),
+			fmt.Sprintf("%s show -no-color %s > %s", binary, terraformPlanFile, terraformPlanTextFile),
+		)
+	case config.TerraformActionApply:
+		scripts = append(scripts, fmt.Sprintf("%s apply -input=false -auto-approve %s", binary, terraformPlanFile))
+	}
+	return scripts
+}
+
+func (j *TerraformJob) LintJob() error {
+	j.spec = &commonmodels.TerraformJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+
+	if !lo.Contains([]config.TerraformBinary{"", config.TerraformBinaryTerraform, config.TerraformBinaryOpenTofu}, j.spec.Binary) {
+		return fmt.Errorf("unsupported terraform binary: %s", j.spec.Binary)
+	}
+	if !lo.Contains([]config.TerraformAction{config.TerraformActionPlan, config.TerraformActionApply}, j.spec.Action) {
+		return fmt.Errorf("unsupported terraform action: %s", j.spec.Action)
+	}
+	if j.spec.Properties == nil {
+		return fmt.Errorf("terraform job properties not found")
+	}
+	if strings.Contains(j.spec.WorkDir, "..") {
+		return fmt.Errorf("work dir should not contain '..'")
+	}
+	for _, kv := range j.spec.Variables {
+		if !OutputNameRegex.MatchString(kv.Key) {
+			return fmt.Errorf("variable name must match %s", OutputNameRegexString)
+		}
+	}
+
+	if j.spec.Action == config.TerraformActionApply && j.spec.ApplyApproval != nil && j.spec.ApplyApproval.Enabled {
+		switch j.spec.ApplyApproval.Type {
+		case config.NativeApproval:
+		case config.LarkApproval, config.DingTalkApproval, config.WorkWXApproval:
+			if err := util.CheckZadigProfessionalLicense(); err != nil {
+				return e.ErrLicenseInvalid.AddDesc("飞书、钉钉、企业微信审批是专业版功能")
+			}
+		default:
+			return fmt.Errorf("invalid approval type %s", j.spec.ApplyApproval.Type)
+		}
+	}
+
+	// plan, approval and apply run one by one, a parallel stage would run them at the same time.
+	if j.spec.Action == config.TerraformActionApply {
+		for _, stage := range j.workflow.Stages {
+			for _, job := range stage.Jobs {
+				if job.Name == j.job.Name && stage.Parallel {
+					return fmt.Errorf("terraform job %s with apply action can not be in a parallel stage", j.job.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
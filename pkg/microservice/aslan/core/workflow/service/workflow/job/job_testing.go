@@ -484,6 +484,13 @@ func (j *TestingJob) toJobtask(jobSubTaskID int, testing *commonmodels.TestModul
 		ShareStorageDetails: getShareStorageDetail(j.workflow.ShareStorages, testing.ShareStorageInfo, j.workflow.Name, taskID),
 		CustomLabels:        testingInfo.PreTest.CustomLabels,
 		CustomAnnotations:   testingInfo.PreTest.CustomAnnotations,
+		PodTemplate:         testingInfo.PreTest.PodTemplate,
+	}
+	if testingInfo.SoakTest != nil && testingInfo.SoakTest.Enabled {
+		jobTaskSpec.Properties.CheckpointInterval = testingInfo.SoakTest.CheckpointInterval
+		if jobTaskSpec.Properties.CheckpointInterval <= 0 {
+			jobTaskSpec.Properties.CheckpointInterval = 60
+		}
 	}
 
 	cacheS3 := &commonmodels.S3Storage{}
@@ -631,6 +638,32 @@ func (j *TestingJob) toJobtask(jobSubTaskID int, testing *commonmodels.TestModul
 	}
 	jobTaskSpec.Steps = append(jobTaskSpec.Steps, debugAfterStep)
 
+	// init coverage report step
+	if testingInfo.CoverageReport != nil && testingInfo.CoverageReport.Enabled {
+		coverageStep := &commonmodels.StepTask{
+			Name:     testing.Name + "-coverage-report",
+			JobName:  jobTask.Name,
+			StepType: config.StepCoverageReport,
+			Spec: &step.StepCoverageReportSpec{
+				SourceWorkflow:      j.workflow.Name,
+				SourceJobKey:        j.job.Name,
+				JobTaskName:         jobTask.Name,
+				TaskID:              taskID,
+				ProjectName:         j.workflow.Project,
+				ServiceName:         serviceName,
+				ServiceModule:       serviceModule,
+				TestName:            testing.Name,
+				ReportPath:          testingInfo.CoverageReport.ReportPath,
+				Format:              testingInfo.CoverageReport.Format,
+				RegressionThreshold: testingInfo.CoverageReport.RegressionThreshold,
+				S3DestDir:           path.Join(j.workflow.Name, fmt.Sprint(taskID), jobTask.Name, "coverage"),
+				FileName:            path.Base(testingInfo.CoverageReport.ReportPath),
+				Repos:               repos,
+			},
+		}
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, coverageStep)
+	}
+
 	tarDestDir := "/tmp"
 	if testingInfo.ScriptType == types.ScriptTypeBatchFile {
 		tarDestDir = "%TMP%"
@@ -692,6 +725,7 @@ func (j *TestingJob) toJobtask(jobSubTaskID int, testing *commonmodels.TestModul
 				JobTaskName:    jobName,
 				TaskID:         taskID,
 				ReportDir:      testingInfo.TestResultPath,
+				ReportType:     testingInfo.TestResultType,
 				S3DestDir:      path.Join(j.workflow.Name, fmt.Sprint(taskID), jobTask.Name, "junit"),
 				TestName:       testing.Name,
 				TestProject:    testing.ProjectName,
@@ -655,19 +655,42 @@ func (j *VMDeployJob) getOriginReferedJobTargets(jobName string, taskID int) ([]
 				if err := commonmodels.IToi(job.Spec, buildSpec); err != nil {
 					return serviceAndVMDeploys, err
 				}
+				matrixCells, err := expandBuildMatrix(buildSpec.Matrix)
+				if err != nil {
+					return serviceAndVMDeploys, err
+				}
+				matrixCell, err := getQuotedBuildMatrixCell(j.workflow, job.Name, j.spec.MatrixCell)
+				if err != nil {
+					return serviceAndVMDeploys, err
+				}
+				cellIndex := 0
+				for index, cell := range matrixCells {
+					if cell.Name == matrixCell {
+						cellIndex = index
+						break
+					}
+				}
 				for i, build := range buildSpec.ServiceAndBuilds {
+					pkgFile, image := build.Package, build.Image
+					for _, output := range build.MatrixOutputs {
+						if output.Cell == matrixCell {
+							pkgFile, image = output.Package, output.Image
+							break
+						}
+					}
+					// the build is expanded into one job task per matrix cell
 					serviceAndVMDeploys = append(serviceAndVMDeploys, &commonmodels.ServiceAndVMDeploy{
 						ServiceName:   build.ServiceName,
 						ServiceModule: build.ServiceModule,
-						FileName:      build.Package,
-						Image:         build.Image,
+						FileName:      pkgFile,
+						Image:         image,
 						TaskID:        taskID,
 						WorkflowName:  j.workflow.Name,
 						WorkflowType:  config.WorkflowTypeV4,
-						JobTaskName:   GenJobName(j.workflow, job.Name, i),
+						JobTaskName:   GenJobName(j.workflow, job.Name, i*len(matrixCells)+cellIndex),
 					})
 					log.Infof("DeployJob ToJobs getOriginReferedJobTargets: workflow %s service %s, module %s, fileName %s",
-						j.workflow.Name, build.ServiceName, build.ServiceModule, build.Package)
+						j.workflow.Name, build.ServiceName, build.ServiceModule, pkgFile)
 				}
 				return serviceAndVMDeploys, nil
 			}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/types/job"
+)
+
+type JobCheckpointDetail struct {
+	// Merged is the progress of the job merged from all the pods
+	Merged *job.JobCheckpoint                  `json:"merged"`
+	Runs   []*commonmodels.JobCheckpointRecord `json:"runs"`
+}
+
+func GetWorkflowTaskJobCheckpoints(workflowName string, taskID int64, jobName string, logger *zap.SugaredLogger) (*JobCheckpointDetail, error) {
+	records, err := commonrepo.NewJobCheckpointColl().ListByJob(workflowName, taskID, jobName)
+	if err != nil {
+		logger.Errorf("list checkpoints of workflow %s task %d job %s error: %v", workflowName, taskID, jobName, err)
+		return nil, e.ErrGetTask.AddErr(err)
+	}
+
+	checkpoints := make([]*job.JobCheckpoint, 0, len(records))
+	for _, record := range records {
+		checkpoints = append(checkpoints, &record.JobCheckpoint)
+	}
+	return &JobCheckpointDetail{
+		Merged: job.MergeJobCheckpoints(checkpoints),
+		Runs:   records,
+	}, nil
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type ListJobKubeAccessGrantsArgs struct {
+	ProjectName  string `form:"projectName"`
+	WorkflowName string `form:"workflowName"`
+	EnvName      string `form:"envName"`
+	PageNum      int64  `form:"pageNum"`
+	PageSize     int64  `form:"pageSize"`
+}
+
+type ListJobKubeAccessGrantsResp struct {
+	Grants []*commonmodels.JobKubeAccessGrant `json:"grants"`
+	Total  int64                              `json:"total"`
+}
+
+func ListJobKubeAccessGrants(args *ListJobKubeAccessGrantsArgs, logger *zap.SugaredLogger) (*ListJobKubeAccessGrantsResp, error) {
+	grants, total, err := commonrepo.NewJobKubeAccessGrantColl().List(&commonrepo.JobKubeAccessGrantListOption{
+		ProjectName:  args.ProjectName,
+		WorkflowName: args.WorkflowName,
+		EnvName:      args.EnvName,
+		PageNum:      args.PageNum,
+		PageSize:     args.PageSize,
+	})
+	if err != nil {
+		logger.Errorf("list kube access grants of project %s error: %v", args.ProjectName, err)
+		return nil, e.ErrListWorkflow.AddErr(err)
+	}
+	return &ListJobKubeAccessGrantsResp{
+		Grants: grants,
+		Total:  total,
+	}, nil
+}
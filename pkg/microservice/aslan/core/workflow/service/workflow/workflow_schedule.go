@@ -0,0 +1,216 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const (
+	WorkflowScheduleRunStatusTriggered = "triggered"
+	WorkflowScheduleRunStatusSkipped   = "skipped"
+	WorkflowScheduleRunStatusFailed    = "failed"
+)
+
+func ListWorkflowSchedules(projectName, workflowName string, logger *zap.SugaredLogger) ([]*commonmodels.WorkflowSchedule, error) {
+	resp, err := commonrepo.NewWorkflowScheduleColl().List(&commonrepo.WorkflowScheduleListOption{
+		ProjectName:  projectName,
+		WorkflowName: workflowName,
+	})
+	if err != nil {
+		logger.Errorf("list workflow %s schedules error: %v", workflowName, err)
+		return nil, e.ErrGetCronjob.AddErr(err)
+	}
+	return resp, nil
+}
+
+func CreateWorkflowSchedule(userName string, schedule *commonmodels.WorkflowSchedule, logger *zap.SugaredLogger) error {
+	if err := prepareWorkflowSchedule(schedule); err != nil {
+		return e.ErrUpsertCronjob.AddErr(err)
+	}
+	schedule.CreatedBy = userName
+	schedule.UpdatedBy = userName
+	if err := commonrepo.NewWorkflowScheduleColl().Create(schedule); err != nil {
+		logger.Errorf("create workflow %s schedule error: %v", schedule.WorkflowName, err)
+		return e.ErrUpsertCronjob.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateWorkflowSchedule(userName, id string, schedule *commonmodels.WorkflowSchedule, logger *zap.SugaredLogger) error {
+	origin, err := commonrepo.NewWorkflowScheduleColl().GetByID(id)
+	if err != nil {
+		return e.ErrGetCronjob.AddErr(err)
+	}
+	if origin.WorkflowName != schedule.WorkflowName {
+		return e.ErrUpsertCronjob.AddDesc("workflow of the schedule can not be changed")
+	}
+	if err := prepareWorkflowSchedule(schedule); err != nil {
+		return e.ErrUpsertCronjob.AddErr(err)
+	}
+	schedule.ID = origin.ID
+	schedule.CreatedBy = origin.CreatedBy
+	schedule.CreateTime = origin.CreateTime
+	schedule.LastRunTime = origin.LastRunTime
+	schedule.LastTaskID = origin.LastTaskID
+	schedule.LastRunStatus = origin.LastRunStatus
+	schedule.LastRunMessage = origin.LastRunMessage
+	schedule.UpdatedBy = userName
+	if err := commonrepo.NewWorkflowScheduleColl().Update(schedule); err != nil {
+		logger.Errorf("update workflow %s schedule error: %v", schedule.WorkflowName, err)
+		return e.ErrUpsertCronjob.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteWorkflowSchedule(workflowName, id string, logger *zap.SugaredLogger) error {
+	origin, err := commonrepo.NewWorkflowScheduleColl().GetByID(id)
+	if err != nil {
+		return e.ErrGetCronjob.AddErr(err)
+	}
+	if origin.WorkflowName != workflowName {
+		return e.ErrInvalidParam.AddDesc("schedule does not belong to the workflow")
+	}
+	if err := commonrepo.NewWorkflowScheduleColl().DeleteByID(id); err != nil {
+		logger.Errorf("delete workflow %s schedule error: %v", workflowName, err)
+		return e.ErrUpsertCronjob.AddErr(err)
+	}
+	return nil
+}
+
+// prepareWorkflowSchedule validates the schedule and calculates the next run time in its timezone
+func prepareWorkflowSchedule(schedule *commonmodels.WorkflowSchedule) error {
+	if schedule.Name == "" {
+		return fmt.Errorf("schedule name is required")
+	}
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(schedule.WorkflowName)
+	if err != nil {
+		return fmt.Errorf("find workflow %s error: %v", schedule.WorkflowName, err)
+	}
+	if schedule.WorkflowArgs == nil {
+		return fmt.Errorf("workflow args are required")
+	}
+	if schedule.WorkflowArgs.Name != workflow.Name {
+		return fmt.Errorf("workflow args %s do not match workflow %s", schedule.WorkflowArgs.Name, workflow.Name)
+	}
+	schedule.ProjectName = workflow.Project
+	schedule.WorkflowArgs.Project = workflow.Project
+
+	nextRunTime, err := nextWorkflowScheduleTime(schedule.Cron, schedule.Timezone, time.Now())
+	if err != nil {
+		return err
+	}
+	schedule.NextRunTime = nextRunTime
+	return nil
+}
+
+func nextWorkflowScheduleTime(spec, timezone string, from time.Time) (int64, error) {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cron expression %s: %v", spec, err)
+	}
+	loc := time.Local
+	if timezone != "" {
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timezone %s: %v", timezone, err)
+		}
+	}
+	return sched.Next(from.In(loc)).Unix(), nil
+}
+
+// WatchWorkflowSchedules triggers the due workflow schedules, only one aslan instance runs it at a time
+func WatchWorkflowSchedules() {
+	log := log.SugaredLogger().With("service", "WatchWorkflowSchedules")
+	for {
+		time.Sleep(time.Second * 10)
+
+		scheduleLock := cache.NewRedisLockWithExpiry(fmt.Sprint("workflow-schedule-watch-lock"), time.Minute*5)
+		err := scheduleLock.TryLock()
+		if err != nil {
+			continue
+		}
+
+		now := time.Now()
+		list, err := commonrepo.NewWorkflowScheduleColl().ListDue(now.Unix())
+		if err != nil {
+			log.Errorf("list due workflow schedules error: %v", err)
+			scheduleLock.Unlock()
+			continue
+		}
+		for _, schedule := range list {
+			runWorkflowSchedule(schedule, now, log)
+		}
+		scheduleLock.Unlock()
+	}
+}
+
+func runWorkflowSchedule(schedule *commonmodels.WorkflowSchedule, now time.Time, logger *zap.SugaredLogger) {
+	nextRunTime, err := nextWorkflowScheduleTime(schedule.Cron, schedule.Timezone, now)
+	if err != nil {
+		// should not happen since the schedule is validated on saving, disable it to avoid endless retries
+		logger.Errorf("workflow %s schedule %s is invalid: %v", schedule.WorkflowName, schedule.Name, err)
+		schedule.Enabled = false
+		schedule.LastRunStatus = WorkflowScheduleRunStatusFailed
+		schedule.LastRunMessage = err.Error()
+		if err := commonrepo.NewWorkflowScheduleColl().Update(schedule); err != nil {
+			logger.Errorf("disable workflow schedule %s error: %v", schedule.ID.Hex(), err)
+		}
+		return
+	}
+	schedule.NextRunTime = nextRunTime
+	schedule.LastRunTime = now.Unix()
+
+	if schedule.SkipIfRunning {
+		tasks, err := commonrepo.NewworkflowTaskv4Coll().FindTodoTasksByWorkflowName(schedule.WorkflowName)
+		if err != nil {
+			logger.Errorf("find running tasks of workflow %s error: %v", schedule.WorkflowName, err)
+		}
+		if len(tasks) > 0 {
+			schedule.LastRunStatus = WorkflowScheduleRunStatusSkipped
+			schedule.LastRunMessage = fmt.Sprintf("task %d is still running", tasks[0].TaskID)
+			if err := commonrepo.NewWorkflowScheduleColl().UpdateRunResult(schedule); err != nil {
+				logger.Errorf("update workflow schedule %s error: %v", schedule.ID.Hex(), err)
+			}
+			return
+		}
+	}
+
+	resp, err := CreateWorkflowTaskV4ByBuildInTrigger(setting.CronTaskCreator, schedule.WorkflowArgs, logger)
+	if err != nil {
+		logger.Errorf("run workflow %s schedule %s error: %v", schedule.WorkflowName, schedule.Name, err)
+		schedule.LastRunStatus = WorkflowScheduleRunStatusFailed
+		schedule.LastRunMessage = err.Error()
+	} else {
+		schedule.LastTaskID = resp.TaskID
+		schedule.LastRunStatus = WorkflowScheduleRunStatusTriggered
+		schedule.LastRunMessage = ""
+	}
+	if err := commonrepo.NewWorkflowScheduleColl().UpdateRunResult(schedule); err != nil {
+		logger.Errorf("update workflow schedule %s error: %v", schedule.ID.Hex(), err)
+	}
+}
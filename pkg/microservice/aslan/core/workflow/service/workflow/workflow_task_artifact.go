@@ -0,0 +1,256 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+	s3tool "github.com/koderover/zadig/v2/pkg/tool/s3"
+)
+
+const artifactCleanupBatchSize = 500
+
+type ArtifactUsage struct {
+	ProjectName    string `json:"project_name"`
+	TotalSize      int64  `json:"total_size"`
+	Count          int64  `json:"count"`
+	RetentionDays  int    `json:"retention_days"`
+	MaxTotalSizeMB int64  `json:"max_total_size_mb"`
+}
+
+func ListWorkflowTaskArtifacts(workflowName string, taskID int64, jobName string, logger *zap.SugaredLogger) ([]*commonmodels.WorkflowTaskArtifact, error) {
+	resp, err := commonrepo.NewWorkflowTaskArtifactColl().List(&commonrepo.WorkflowTaskArtifactListOption{
+		WorkflowName: workflowName,
+		TaskID:       taskID,
+		JobName:      jobName,
+	})
+	if err != nil {
+		logger.Errorf("list artifacts of workflow %s task %d error: %v", workflowName, taskID, err)
+		return nil, e.ErrGetTask.AddErr(err)
+	}
+	return resp, nil
+}
+
+// DownloadWorkflowTaskArtifact returns the content of the artifact, the caller should close the reader
+func DownloadWorkflowTaskArtifact(workflowName, id string, logger *zap.SugaredLogger) (io.ReadCloser, int64, string, error) {
+	artifact, err := commonrepo.NewWorkflowTaskArtifactColl().GetByID(id)
+	if err != nil {
+		return nil, 0, "", e.ErrInvalidParam.AddDesc(fmt.Sprintf("artifact %s not found", id))
+	}
+	if artifact.WorkflowName != workflowName {
+		return nil, 0, "", e.ErrInvalidParam.AddDesc("artifact does not belong to the workflow")
+	}
+
+	storage, err := commonrepo.NewS3StorageColl().Find(artifact.StorageID)
+	if err != nil {
+		logger.Errorf("find object storage %s error: %v", artifact.StorageID, err)
+		return nil, 0, "", fmt.Errorf("find object storage error: %v", err)
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, storage.Provider)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("create s3 client error: %v", err)
+	}
+	object, err := client.GetFile(storage.Bucket, artifact.ObjectKey, &s3tool.DownloadOption{RetryNum: 2})
+	if err != nil {
+		logger.Errorf("get artifact %s error: %v", artifact.ObjectKey, err)
+		return nil, 0, "", fmt.Errorf("get artifact error: %v", err)
+	}
+	var size int64
+	if object.ContentLength != nil {
+		size = *object.ContentLength
+	}
+	return object.Body, size, artifact.Name, nil
+}
+
+func GetArtifactRetentionPolicy(projectName string) (*commonmodels.ArtifactRetentionPolicy, error) {
+	policy, err := commonrepo.NewArtifactRetentionPolicyColl().Find(projectName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &commonmodels.ArtifactRetentionPolicy{ProjectName: projectName}, nil
+		}
+		return nil, err
+	}
+	return policy, nil
+}
+
+func UpdateArtifactRetentionPolicy(projectName, userName string, policy *commonmodels.ArtifactRetentionPolicy, logger *zap.SugaredLogger) error {
+	if policy.RetentionDays < 0 || policy.MaxTotalSizeMB < 0 {
+		return e.ErrInvalidParam.AddDesc("retention days and max total size can not be negative")
+	}
+	policy.ProjectName = projectName
+	policy.UpdatedBy = userName
+	if err := commonrepo.NewArtifactRetentionPolicyColl().Upsert(policy); err != nil {
+		logger.Errorf("update artifact retention policy of %s error: %v", projectName, err)
+		return err
+	}
+	// apply the new retention days to the existing artifacts
+	if err := commonrepo.NewWorkflowTaskArtifactColl().SetExpireTimeByProject(projectName, policy.RetentionDays); err != nil {
+		logger.Errorf("update artifact expire time of %s error: %v", projectName, err)
+		return err
+	}
+	return nil
+}
+
+// GetArtifactUsage returns the artifact storage usage of the project, or of all projects if projectName is empty
+func GetArtifactUsage(projectName string, logger *zap.SugaredLogger) ([]*ArtifactUsage, error) {
+	usages, err := commonrepo.NewWorkflowTaskArtifactColl().GetUsage(projectName)
+	if err != nil {
+		logger.Errorf("get artifact usage error: %v", err)
+		return nil, err
+	}
+	policies, err := commonrepo.NewArtifactRetentionPolicyColl().List()
+	if err != nil {
+		logger.Errorf("list artifact retention policies error: %v", err)
+		return nil, err
+	}
+	policyMap := make(map[string]*commonmodels.ArtifactRetentionPolicy)
+	for _, policy := range policies {
+		policyMap[policy.ProjectName] = policy
+	}
+
+	resp := make([]*ArtifactUsage, 0)
+	for _, usage := range usages {
+		item := &ArtifactUsage{
+			ProjectName: usage.ProjectName,
+			TotalSize:   usage.TotalSize,
+			Count:       usage.Count,
+		}
+		if policy, ok := policyMap[usage.ProjectName]; ok {
+			item.RetentionDays = policy.RetentionDays
+			item.MaxTotalSizeMB = policy.MaxTotalSizeMB
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+// WatchArtifactRetention removes the expired artifacts and the oldest artifacts of the projects exceeding their quota
+func WatchArtifactRetention() {
+	log := log.SugaredLogger().With("service", "WatchArtifactRetention")
+	for {
+		time.Sleep(time.Minute * 10)
+
+		artifactLock := cache.NewRedisLockWithExpiry(fmt.Sprint("artifact-retention-watch-lock"), time.Minute*30)
+		err := artifactLock.TryLock()
+		if err != nil {
+			continue
+		}
+
+		cleanupExpiredArtifacts(log)
+		cleanupOverQuotaArtifacts(log)
+		artifactLock.Unlock()
+	}
+}
+
+func cleanupExpiredArtifacts(logger *zap.SugaredLogger) {
+	for {
+		artifacts, err := commonrepo.NewWorkflowTaskArtifactColl().ListExpired(time.Now().Unix(), artifactCleanupBatchSize)
+		if err != nil {
+			logger.Errorf("list expired artifacts error: %v", err)
+			return
+		}
+		if len(artifacts) == 0 {
+			return
+		}
+		if err := removeArtifacts(artifacts); err != nil {
+			logger.Errorf("remove expired artifacts error: %v", err)
+			return
+		}
+	}
+}
+
+func cleanupOverQuotaArtifacts(logger *zap.SugaredLogger) {
+	policies, err := commonrepo.NewArtifactRetentionPolicyColl().List()
+	if err != nil {
+		logger.Errorf("list artifact retention policies error: %v", err)
+		return
+	}
+	for _, policy := range policies {
+		if policy.MaxTotalSizeMB <= 0 {
+			continue
+		}
+		usages, err := commonrepo.NewWorkflowTaskArtifactColl().GetUsage(policy.ProjectName)
+		if err != nil || len(usages) == 0 {
+			continue
+		}
+		exceeded := usages[0].TotalSize - policy.MaxTotalSizeMB*1024*1024
+		if exceeded <= 0 {
+			continue
+		}
+
+		artifacts, err := commonrepo.NewWorkflowTaskArtifactColl().List(&commonrepo.WorkflowTaskArtifactListOption{ProjectName: policy.ProjectName})
+		if err != nil {
+			logger.Errorf("list artifacts of %s error: %v", policy.ProjectName, err)
+			continue
+		}
+		// artifacts are sorted by create time, remove the oldest ones first
+		toRemove := make([]*commonmodels.WorkflowTaskArtifact, 0)
+		for _, artifact := range artifacts {
+			if exceeded <= 0 {
+				break
+			}
+			toRemove = append(toRemove, artifact)
+			exceeded -= artifact.Size
+		}
+		if err := removeArtifacts(toRemove); err != nil {
+			logger.Errorf("remove over quota artifacts of %s error: %v", policy.ProjectName, err)
+		}
+	}
+}
+
+func removeArtifacts(artifacts []*commonmodels.WorkflowTaskArtifact) error {
+	keysByStorage := make(map[string][]string)
+	ids := make([]primitive.ObjectID, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		keysByStorage[artifact.StorageID] = append(keysByStorage[artifact.StorageID], artifact.ObjectKey)
+		ids = append(ids, artifact.ID)
+	}
+
+	for storageID, keys := range keysByStorage {
+		storage, err := commonrepo.NewS3StorageColl().Find(storageID)
+		if err != nil {
+			// the storage is removed, only the records are left to be cleaned
+			log.Warnf("find object storage %s error: %v", storageID, err)
+			continue
+		}
+		client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, storage.Provider)
+		if err != nil {
+			return fmt.Errorf("create s3 client error: %v", err)
+		}
+		// s3 allows deleting at most 1000 objects in one request
+		for start := 0; start < len(keys); start += 1000 {
+			end := start + 1000
+			if end > len(keys) {
+				end = len(keys)
+			}
+			if err := client.DeleteObjects(storage.Bucket, keys[start:end]); err != nil {
+				return fmt.Errorf("delete objects from %s error: %v", storage.Bucket, err)
+			}
+		}
+	}
+	return commonrepo.NewWorkflowTaskArtifactColl().DeleteByIDs(ids)
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/workflowcontroller"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type QueuedWorkflowTask struct {
+	ProjectName         string                        `json:"project_name"`
+	WorkflowName        string                        `json:"workflow_name"`
+	WorkflowDisplayName string                        `json:"workflow_display_name"`
+	TaskID              int64                         `json:"task_id"`
+	Type                config.CustomWorkflowTaskType `json:"type"`
+	Status              config.Status                 `json:"status"`
+	TaskCreator         string                        `json:"task_creator"`
+	CreateTime          int64                         `json:"create_time"`
+	Priority            int                           `json:"priority"`
+	// Position is the position of the task in the queue, starting from 1
+	Position        int                                       `json:"position"`
+	BlockingReasons []*workflowcontroller.QueueBlockingReason `json:"blocking_reasons"`
+}
+
+type ClusterQueuedTasks struct {
+	// ClusterID is empty for the tasks without any job running in the job pods
+	ClusterID   string                `json:"cluster_id"`
+	ClusterName string                `json:"cluster_name"`
+	Tasks       []*QueuedWorkflowTask `json:"tasks"`
+}
+
+type QueuedTaskRef struct {
+	WorkflowName string `json:"workflow_name"`
+	TaskID       int64  `json:"task_id"`
+}
+
+// ListQueuedWorkflowTasks returns the tasks waiting in the queue grouped by the clusters their jobs run in, along
+// with the reasons they are not scheduled yet. A task running jobs in multiple clusters shows up in each of them.
+func ListQueuedWorkflowTasks(logger *zap.SugaredLogger) ([]*ClusterQueuedTasks, error) {
+	sysSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		logger.Errorf("get system settings error: %v", err)
+		return nil, e.ErrListWorkflowTaskQueue.AddErr(err)
+	}
+	clusters, err := commonrepo.NewK8SClusterColl().List(nil)
+	if err != nil {
+		logger.Errorf("list clusters error: %v", err)
+		return nil, e.ErrListWorkflowTaskQueue.AddErr(err)
+	}
+	clusterMap := make(map[string]*commonmodels.K8SCluster)
+	for _, cluster := range clusters {
+		clusterMap[cluster.ID.Hex()] = cluster
+	}
+
+	resp := make([]*ClusterQueuedTasks, 0)
+	groupMap := make(map[string]*ClusterQueuedTasks)
+	position := 0
+	for _, queue := range workflowcontroller.PendingTasks() {
+		if queue.Status != config.StatusWaiting && queue.Status != config.StatusBlocked {
+			continue
+		}
+		position++
+		task := &QueuedWorkflowTask{
+			ProjectName:         queue.ProjectName,
+			WorkflowName:        queue.WorkflowName,
+			WorkflowDisplayName: queue.WorkflowDisplayName,
+			TaskID:              queue.TaskID,
+			Type:                queue.Type,
+			Status:              queue.Status,
+			TaskCreator:         queue.TaskCreator,
+			CreateTime:          queue.CreateTime,
+			Priority:            queue.Priority,
+			Position:            position,
+			BlockingReasons:     workflowcontroller.GetWaitingTaskBlockingReasons(queue, int(sysSetting.WorkflowConcurrency)),
+		}
+
+		clusterIDs, err := getWorkflowTaskClusterIDs(queue.WorkflowName, queue.TaskID)
+		if err != nil {
+			logger.Warnf("get clusters of workflow %s task %d error: %v", queue.WorkflowName, queue.TaskID, err)
+		}
+		for _, clusterID := range clusterIDs {
+			if cluster, ok := clusterMap[clusterID]; ok && cluster.Status != setting.Normal {
+				task.BlockingReasons = append(task.BlockingReasons, &workflowcontroller.QueueBlockingReason{
+					Reason:  workflowcontroller.QueueBlockingReasonClusterCapacity,
+					Message: fmt.Sprintf("cluster %s is %s", cluster.Name, cluster.Status),
+				})
+			}
+		}
+		if len(clusterIDs) == 0 {
+			clusterIDs = []string{""}
+		}
+
+		for _, clusterID := range clusterIDs {
+			group, ok := groupMap[clusterID]
+			if !ok {
+				group = &ClusterQueuedTasks{ClusterID: clusterID, Tasks: make([]*QueuedWorkflowTask, 0)}
+				if cluster, ok := clusterMap[clusterID]; ok {
+					group.ClusterName = cluster.Name
+				}
+				groupMap[clusterID] = group
+				resp = append(resp, group)
+			}
+			group.Tasks = append(group.Tasks, task)
+		}
+	}
+	return resp, nil
+}
+
+// getWorkflowTaskClusterIDs returns the clusters of the jobs running in the job pods of the task
+func getWorkflowTaskClusterIDs(workflowName string, taskID int64) ([]string, error) {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterIDs := make([]string, 0)
+	clusterSet := make(map[string]bool)
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			spec := &commonmodels.JobTaskFreestyleSpec{}
+			if err := commonmodels.IToi(job.Spec, spec); err != nil {
+				continue
+			}
+			clusterID := spec.Properties.ClusterID
+			if clusterID == "" || clusterSet[clusterID] {
+				continue
+			}
+			clusterSet[clusterID] = true
+			clusterIDs = append(clusterIDs, clusterID)
+		}
+	}
+	return clusterIDs, nil
+}
+
+// UpdateQueuedTaskPriority sets the priority of the waiting tasks, the tasks with higher priority are scheduled first
+func UpdateQueuedTaskPriority(tasks []*QueuedTaskRef, priority int, logger *zap.SugaredLogger) error {
+	errList := new(multierror.Error)
+	for _, task := range tasks {
+		if err := commonrepo.NewWorkflowQueueColl().UpdatePriority(task.WorkflowName, task.TaskID, priority); err != nil {
+			logger.Errorf("update priority of workflow %s task %d error: %v", task.WorkflowName, task.TaskID, err)
+			errList = multierror.Append(errList, fmt.Errorf("workflow %s task %d is not waiting in the queue", task.WorkflowName, task.TaskID))
+		}
+	}
+	if err := errList.ErrorOrNil(); err != nil {
+		return e.ErrUpdateWorkflowTaskPriority.AddErr(err)
+	}
+	return nil
+}
+
+// CancelQueuedWorkflowTasks cancels the tasks waiting in the queue, the tasks already scheduled are not affected
+func CancelQueuedWorkflowTasks(userName string, tasks []*QueuedTaskRef, logger *zap.SugaredLogger) error {
+	waiting := make(map[string]bool)
+	for _, queue := range workflowcontroller.PendingTasks() {
+		if queue.Status == config.StatusWaiting || queue.Status == config.StatusBlocked {
+			waiting[fmt.Sprintf("%s/%d", queue.WorkflowName, queue.TaskID)] = true
+		}
+	}
+
+	errList := new(multierror.Error)
+	for _, task := range tasks {
+		if !waiting[fmt.Sprintf("%s/%d", task.WorkflowName, task.TaskID)] {
+			errList = multierror.Append(errList, fmt.Errorf("workflow %s task %d is not waiting in the queue", task.WorkflowName, task.TaskID))
+			continue
+		}
+		if err := workflowcontroller.CancelWorkflowTask(userName, task.WorkflowName, task.TaskID, logger); err != nil {
+			errList = multierror.Append(errList, fmt.Errorf("failed to cancel workflow %s task %d: %s", task.WorkflowName, task.TaskID, err))
+		}
+	}
+	if err := errList.ErrorOrNil(); err != nil {
+		return e.ErrCancelQueuedWorkflowTask.AddErr(err)
+	}
+	return nil
+}
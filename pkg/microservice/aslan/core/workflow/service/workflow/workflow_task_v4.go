@@ -18,7 +18,10 @@ package workflow
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -30,6 +33,7 @@ import (
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
 	"gorm.io/gorm/utils"
 	"k8s.io/apimachinery/pkg/util/sets"
 	controllerRuntimeClient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -41,7 +45,9 @@ import (
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
 	commonservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service"
+	approvalservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/approval"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/dingtalk"
+	helmservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/helm"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/instantmessage"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/lark"
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/s3"
@@ -59,6 +65,7 @@ import (
 	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
 	"github.com/koderover/zadig/v2/pkg/tool/cache"
 	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
+	"github.com/koderover/zadig/v2/pkg/tool/crypto"
 	e "github.com/koderover/zadig/v2/pkg/tool/errors"
 	larktool "github.com/koderover/zadig/v2/pkg/tool/lark"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
@@ -98,6 +105,7 @@ type WorkflowTaskPreview struct {
 	Debug               bool                  `bson:"debug"                     json:"debug"`
 	ApprovalTicketID    string                `bson:"approval_ticket_id"        json:"approval_ticket_id"`
 	ApprovalID          string                `bson:"approval_id"               json:"approval_id"`
+	Tags                []string              `bson:"tags"                      json:"tags,omitempty"`
 }
 
 type StageTaskPreview struct {
@@ -107,8 +115,9 @@ type StageTaskPreview struct {
 	EndTime    int64                    `bson:"end_time"      json:"end_time,omitempty"`
 	Parallel   bool                     `bson:"parallel"      json:"parallel"`
 	ManualExec *commonmodels.ManualExec `bson:"manual_exec"      json:"manual_exec"`
+	OnFailure  bool                     `bson:"on_failure"    json:"on_failure"`
 	Jobs       []*JobTaskPreview        `bson:"jobs"          json:"jobs"`
-	Error      string                   `bson:"error" json:"error""`
+	Error      string                   `bson:"error" json:"error"`
 }
 
 type JobTaskPreview struct {
@@ -130,6 +139,9 @@ type JobTaskPreview struct {
 	ErrorHandlerUserID   string                       `bson:"error_handler_user_id"  yaml:"error_handler_user_id" json:"error_handler_user_id"`
 	ErrorHandlerUserName string                       `bson:"error_handler_username"  yaml:"error_handler_username" json:"error_handler_username"`
 	RetryCount           int                          `bson:"retry_count"           yaml:"retry_count"               json:"retry_count"`
+	InfraRetryCount      int                          `bson:"infra_retry_count"     yaml:"infra_retry_count"         json:"infra_retry_count,omitempty"`
+	InfraFailureReason   string                       `bson:"infra_failure_reason"  yaml:"infra_failure_reason"      json:"infra_failure_reason,omitempty"`
+	Checkpoint           *jobspec.JobCheckpoint       `bson:"checkpoint"            yaml:"checkpoint"                json:"checkpoint,omitempty"`
 	// JobInfo contains the fields that make up the job task name, for frontend display
 	JobInfo interface{} `bson:"job_info" json:"job_info"`
 }
@@ -453,6 +465,8 @@ type CreateWorkflowTaskV4Args struct {
 	UserID           string
 	Type             config.CustomWorkflowTaskType
 	ApprovalTicketID string
+	// FreezeOverrideReason is required to deploy to the production envs during the deployment freeze
+	FreezeOverrideReason string
 }
 
 func CreateWorkflowTaskV4ByBuildInTrigger(triggerName string, args *commonmodels.WorkflowV4, log *zap.SugaredLogger) (*CreateTaskV4Resp, error) {
@@ -482,6 +496,9 @@ func CreateWorkflowTaskV4(args *CreateWorkflowTaskV4Args, workflow *commonmodels
 	if err := LintWorkflowV4(workflow, log); err != nil {
 		return resp, err
 	}
+	if err := commonservice.CheckProjectWorkflowTaskQuota(workflow.Project); err != nil {
+		return resp, err
+	}
 
 	var userInfo *types.UserInfo
 	var err error
@@ -596,6 +613,16 @@ func CreateWorkflowTaskV4(args *CreateWorkflowTaskV4Args, workflow *commonmodels
 		return resp, e.ErrCreateTask.AddDesc(err.Error())
 	}
 
+	if err := checkHelmDeployLockedValues(workflow, args.UserID); err != nil {
+		log.Errorf("check locked values keys of workflow %s error: %s", workflow.Name, err)
+		return resp, err
+	}
+
+	if err := checkProductionDeployFreeze(workflow, args.FreezeOverrideReason, log); err != nil {
+		log.Errorf("check deployment freeze of workflow %s error: %s", workflow.Name, err)
+		return resp, err
+	}
+
 	workflowTask.TaskID = nextTaskID
 	workflowTask.TaskCreator = args.Name
 	workflowTask.TaskCreatorID = args.UserID
@@ -617,6 +644,8 @@ func CreateWorkflowTaskV4(args *CreateWorkflowTaskV4Args, workflow *commonmodels
 			Name:       stage.Name,
 			Parallel:   stage.Parallel,
 			ManualExec: stage.ManualExec,
+			Dynamic:    stage.Dynamic,
+			OnFailure:  stage.OnFailure,
 		}
 		for _, job := range stage.Jobs {
 			if jobctl.JobSkiped(job) {
@@ -1512,13 +1541,13 @@ func RevertWorkflowTaskV4Job(ctx *internalhandler.Context, workflowName, jobName
 
 					return nil
 				default:
-					return fmt.Errorf("job of type: %s does not support reverting yet")
+					return fmt.Errorf("job of type: %s does not support reverting yet", job.JobType)
 				}
 			}
 		}
 	}
 
-	return fmt.Errorf("failed to revert job: %s, job not found")
+	return fmt.Errorf("failed to revert job: %s, job not found", jobName)
 }
 
 func GetWorkflowTaskV4JobRevert(workflowName, jobName string, taskID int64, logger *zap.SugaredLogger) (interface{}, error) {
@@ -1814,6 +1843,7 @@ func GetWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredLog
 		Debug:               task.IsDebug,
 		ApprovalTicketID:    task.ApprovalTicketID,
 		ApprovalID:          task.ApprovalID,
+		Tags:                task.Tags,
 	}
 	timeNow := time.Now().Unix()
 	for _, stage := range task.Stages {
@@ -1824,6 +1854,7 @@ func GetWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredLog
 			EndTime:    stage.EndTime,
 			Parallel:   stage.Parallel,
 			ManualExec: stage.ManualExec,
+			OnFailure:  stage.OnFailure,
 			Jobs:       jobsToJobPreviews(stage.Jobs, task.GlobalContext, timeNow, task.ProjectName),
 			Error:      stage.Error,
 		})
@@ -1831,19 +1862,88 @@ func GetWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredLog
 	return resp, nil
 }
 
-func ApproveStage(workflowName, jobName, userName, userID, comment string, taskID int64, approve bool, logger *zap.SugaredLogger) error {
+func ApproveStage(workflowName, jobName, userName, userID, comment string, taskID int64, approve bool, channel config.ApprovalChannel, logger *zap.SugaredLogger) error {
 	if workflowName == "" || jobName == "" || taskID == 0 {
 		errMsg := fmt.Sprintf("can not find approved workflow: %s, taskID: %d,jobName: %s", workflowName, taskID, jobName)
 		logger.Error(errMsg)
 		return e.ErrApproveTask.AddDesc(errMsg)
 	}
-	if err := workflowcontroller.ApproveStage(workflowName, jobName, userName, userID, comment, taskID, approve); err != nil {
+	if err := workflowcontroller.ApproveStage(workflowName, jobName, userName, userID, comment, taskID, approve, channel); err != nil {
 		logger.Error(err)
 		return e.ErrApproveTask.AddErr(err)
 	}
 	return nil
 }
 
+type ApproveCallbackRequest struct {
+	WorkflowName string `json:"workflow_name"`
+	JobName      string `json:"job_name"`
+	TaskID       int64  `json:"task_id"`
+	UserID       string `json:"user_id"`
+	Comment      string `json:"comment"`
+	Approve      bool   `json:"approve"`
+	// Timestamp unix seconds when the request is signed, requests older than 5 minutes are rejected
+	Timestamp int64 `json:"timestamp"`
+}
+
+const approveCallbackWindow = 300 * time.Second
+
+// ApproveStageByCallback resolves native approval by external system, the request body is signed by
+// hex(hmac-sha256(callback_secret, body)) and passed in the signature header
+func ApproveStageByCallback(body []byte, signature string, logger *zap.SugaredLogger) error {
+	args := new(ApproveCallbackRequest)
+	if err := json.Unmarshal(body, args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if args.WorkflowName == "" || args.JobName == "" || args.TaskID == 0 || args.UserID == "" {
+		return e.ErrInvalidParam.AddDesc("workflow_name, job_name, task_id and user_id are required")
+	}
+	if diff := time.Now().Unix() - args.Timestamp; diff > int64(approveCallbackWindow.Seconds()) || diff < -int64(approveCallbackWindow.Seconds()) {
+		return e.ErrInvalidParam.AddDesc("request timestamp expired")
+	}
+
+	approveKey := approvalservice.GenWorkflowApproveKey(args.WorkflowName, args.JobName, args.TaskID)
+	approval, ok := approvalservice.GlobalApproveMap.GetApproval(approveKey)
+	if !ok {
+		return e.ErrApproveTask.AddDesc(fmt.Sprintf("approval of workflow: %s, taskID: %d, jobName: %s not found", args.WorkflowName, args.TaskID, args.JobName))
+	}
+	if approval.CallbackSecret == "" {
+		return e.ErrApproveTask.AddDesc("callback is not enabled for the approval")
+	}
+	secret, err := crypto.AesDecrypt(approval.CallbackSecret)
+	if err != nil {
+		logger.Errorf("failed to decrypt approval callback secret of %s, error: %s", approveKey, err)
+		return e.ErrApproveTask.AddDesc("invalid callback secret of the approval")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(strings.TrimPrefix(signature, "sha256="))) {
+		logger.Warnf("invalid approval callback signature for %s", approveKey)
+		return e.ErrForbidden.AddDesc("invalid signature")
+	}
+	// the signed request is accepted within the window on either side of its timestamp, so the signature
+	// is kept for twice the window to reject the replays of the same request
+	replayKey := fmt.Sprintf("workflow-approve-callback:%s", strings.TrimPrefix(signature, "sha256="))
+	fresh, err := cache.NewRedisCache(config2.RedisCommonCacheTokenDB()).TrySetNX(replayKey, approveKey, 2*approveCallbackWindow)
+	if err != nil {
+		logger.Errorf("failed to record approval callback signature for %s, error: %s", approveKey, err)
+		return e.ErrApproveTask.AddErr(err)
+	}
+	if !fresh {
+		logger.Warnf("replayed approval callback for %s", approveKey)
+		return e.ErrConflict.AddDesc("the callback request has been handled")
+	}
+
+	userName := args.UserID
+	for _, user := range approval.ApproveUsers {
+		if user.UserID == args.UserID {
+			userName = user.UserName
+			break
+		}
+	}
+	return ApproveStage(args.WorkflowName, args.JobName, userName, args.UserID, args.Comment, args.TaskID, args.Approve, config.ApprovalChannelCallback, logger)
+}
+
 func HandleJobError(workflowName, jobName, userID, username string, taskID int64, decision workflowtool.JobErrorDecision, logger *zap.SugaredLogger) error {
 	if workflowName == "" || jobName == "" || taskID == 0 {
 		errMsg := fmt.Sprintf("can not find approved workflow: %s, taskID: %d,jobName: %s", workflowName, taskID, jobName)
@@ -1955,6 +2055,9 @@ func jobsToJobPreviews(jobs []*commonmodels.JobTask, context map[string]string,
 			ErrorHandlerUserID:   job.ErrorHandlerUserID,
 			ErrorHandlerUserName: job.ErrorHandlerUserName,
 			RetryCount:           job.RetryCount,
+			InfraRetryCount:      job.InfraRetryCount,
+			InfraFailureReason:   job.InfraFailureReason,
+			Checkpoint:           job.Checkpoint,
 		}
 		switch job.JobType {
 		case string(config.JobFreestyle):
@@ -2373,6 +2476,113 @@ func jobsToJobPreviews(jobs []*commonmodels.JobTask, context map[string]string,
 	return resp
 }
 
+// checkProductionDeployFreeze rejects the workflow deploying to the production envs during the deployment freeze,
+// unless an override reason is given
+func checkProductionDeployFreeze(workflow *commonmodels.WorkflowV4, overrideReason string, logger *zap.SugaredLogger) error {
+	production := false
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.Skipped {
+				continue
+			}
+			switch job.JobType {
+			case config.JobZadigDeploy:
+				spec := &commonmodels.ZadigDeployJobSpec{}
+				if err := commonmodels.IToi(job.Spec, spec); err != nil {
+					return e.ErrCreateTask.AddErr(err)
+				}
+				production = production || spec.Production
+			case config.JobZadigHelmChartDeploy:
+				spec := &commonmodels.ZadigHelmChartDeployJobSpec{}
+				if err := commonmodels.IToi(job.Spec, spec); err != nil {
+					return e.ErrCreateTask.AddErr(err)
+				}
+				production = production || spec.Production
+			}
+		}
+	}
+	if !production {
+		return nil
+	}
+
+	window, err := commonservice.CheckDeployFreeze(overrideReason)
+	if err != nil {
+		return err
+	}
+	if window != nil {
+		logger.Infof("workflow %s overrides the deployment freeze %s, reason: %s", workflow.Name, window.Name, overrideReason)
+	}
+	return nil
+}
+
+// checkHelmDeployLockedValues rejects the helm deploy jobs changing the locked values keys of the project,
+// unless the task creator is the system admin or the project admin.
+func checkHelmDeployLockedValues(workflow *commonmodels.WorkflowV4, userID string) error {
+	checked := false
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.JobType != config.JobZadigDeploy || job.Skipped {
+				continue
+			}
+			spec := &commonmodels.ZadigDeployJobSpec{}
+			if err := commonmodels.IToi(job.Spec, spec); err != nil {
+				return e.ErrCreateTask.AddErr(err)
+			}
+			if spec.DeployType != setting.HelmDeployType || !slices.Contains(spec.DeployContents, config.DeployConfig) {
+				continue
+			}
+
+			if !checked {
+				if userID != "" {
+					authInfo, err := user.New().GetUserAuthInfo(userID)
+					if err != nil {
+						return e.ErrCreateTask.AddErr(fmt.Errorf("failed to get auth info of user %s, err: %w", userID, err))
+					}
+					if authInfo.IsSystemAdmin {
+						return nil
+					}
+					if projectAuthInfo, ok := authInfo.ProjectAuthInfo[workflow.Project]; ok && projectAuthInfo.IsProjectAdmin {
+						return nil
+					}
+				}
+				checked = true
+			}
+
+			env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+				Name:       workflow.Project,
+				EnvName:    spec.Env,
+				Production: &spec.Production,
+			})
+			if err != nil {
+				return e.ErrCreateTask.AddErr(fmt.Errorf("failed to find env %s, err: %w", spec.Env, err))
+			}
+			renderMap := env.GetChartRenderMap()
+			for _, svc := range spec.Services {
+				if !svc.UpdateConfig {
+					continue
+				}
+				curValues, overrideKVs := "", ""
+				if render, ok := renderMap[svc.ServiceName]; ok {
+					overrideKVs = render.OverrideValues
+					curValues, err = helmservice.MergeServiceOverrideValues(render.GetOverrideYaml(), overrideKVs)
+					if err != nil {
+						return e.ErrCreateTask.AddErr(fmt.Errorf("failed to merge current values of service %s, err: %w", svc.ServiceName, err))
+					}
+				}
+				// the deploy job replaces the override yaml of the service only
+				newValues, err := helmservice.MergeServiceOverrideValues(svc.VariableYaml, overrideKVs)
+				if err != nil {
+					return e.ErrCreateTask.AddErr(fmt.Errorf("failed to merge values of service %s, err: %w", svc.ServiceName, err))
+				}
+				if err := helmservice.CheckLockedValuesKeys(workflow.Project, svc.ServiceName, spec.Production, curValues, newValues); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func setZadigParamRepos(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger) {
 	for _, param := range workflow.Params {
 		if param.ParamsType != "repo" {
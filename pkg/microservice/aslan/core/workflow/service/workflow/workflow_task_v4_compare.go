@@ -0,0 +1,377 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+	"github.com/koderover/zadig/v2/pkg/types"
+	stepspec "github.com/koderover/zadig/v2/pkg/types/step"
+)
+
+const maskedParamValue = "******"
+
+type WorkflowTaskComparison struct {
+	WorkflowName string                  `json:"workflow_name"`
+	Base         *ComparedTaskSummary    `json:"base"`
+	Target       *ComparedTaskSummary    `json:"target"`
+	Params       []*ComparedParam        `json:"params"`
+	Jobs         []*ComparedJob          `json:"jobs"`
+	Images       []*ComparedServiceImage `json:"images"`
+	Commits      []*ComparedCommitRange  `json:"commits"`
+}
+
+type ComparedTaskSummary struct {
+	TaskID      int64         `json:"task_id"`
+	Status      config.Status `json:"status"`
+	TaskCreator string        `json:"task_creator"`
+	CreateTime  int64         `json:"create_time"`
+	Duration    int64         `json:"duration"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// ComparedParam is a workflow parameter or a custom env of a job whose value differs between the runs,
+// the job name is empty for workflow parameters
+type ComparedParam struct {
+	JobName string `json:"job_name,omitempty"`
+	Name    string `json:"name"`
+	Base    string `json:"base"`
+	Target  string `json:"target"`
+}
+
+type ComparedJob struct {
+	Name           string        `json:"name"`
+	DisplayName    string        `json:"display_name"`
+	JobType        string        `json:"job_type"`
+	BaseStatus     config.Status `json:"base_status"`
+	TargetStatus   config.Status `json:"target_status"`
+	BaseDuration   int64         `json:"base_duration"`
+	TargetDuration int64         `json:"target_duration"`
+	// DurationDelta is the target duration minus the base duration in seconds
+	DurationDelta int64  `json:"duration_delta"`
+	TargetError   string `json:"target_error,omitempty"`
+}
+
+type ComparedServiceImage struct {
+	ServiceName   string `json:"service_name"`
+	ServiceModule string `json:"service_module"`
+	BaseImage     string `json:"base_image"`
+	TargetImage   string `json:"target_image"`
+}
+
+type ComparedCommitRange struct {
+	JobName       string `json:"job_name"`
+	ServiceName   string `json:"service_name"`
+	ServiceModule string `json:"service_module"`
+	Source        string `json:"source"`
+	RepoNamespace string `json:"repo_namespace"`
+	RepoName      string `json:"repo_name"`
+	BaseRef       string `json:"base_ref"`
+	TargetRef     string `json:"target_ref"`
+	BaseCommit    string `json:"base_commit"`
+	TargetCommit  string `json:"target_commit"`
+	CompareURL    string `json:"compare_url,omitempty"`
+}
+
+// comparedTaskInfo is the information extracted from a task for comparison
+type comparedTaskInfo struct {
+	params map[string]*ComparedParam
+	jobs   map[string]*commonmodels.JobTask
+	// images and repos are keyed by service/module
+	images map[string]*ComparedServiceImage
+	repos  map[string][]*types.Repository
+	// jobNames keeps the build job of each service/module
+	jobNames  map[string]string
+	jobOrders []string
+}
+
+// CompareWorkflowTasksV4 compares the target run of the workflow with the base run, only the differences are returned
+func CompareWorkflowTasksV4(workflowName string, baseTaskID, targetTaskID int64, logger *zap.SugaredLogger) (*WorkflowTaskComparison, error) {
+	baseTask, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, baseTaskID)
+	if err != nil {
+		logger.Errorf("failed to find task %d of workflow %s, err: %s", baseTaskID, workflowName, err)
+		return nil, e.ErrGetTask.AddErr(err)
+	}
+	targetTask, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, targetTaskID)
+	if err != nil {
+		logger.Errorf("failed to find task %d of workflow %s, err: %s", targetTaskID, workflowName, err)
+		return nil, e.ErrGetTask.AddErr(err)
+	}
+
+	base, target := newComparedTaskInfo(baseTask), newComparedTaskInfo(targetTask)
+	resp := &WorkflowTaskComparison{
+		WorkflowName: workflowName,
+		Base:         newComparedTaskSummary(baseTask),
+		Target:       newComparedTaskSummary(targetTask),
+		Params:       make([]*ComparedParam, 0),
+		Jobs:         make([]*ComparedJob, 0),
+		Images:       make([]*ComparedServiceImage, 0),
+		Commits:      make([]*ComparedCommitRange, 0),
+	}
+
+	for _, key := range unionKeys(base.params, target.params) {
+		baseParam, targetParam := base.params[key], target.params[key]
+		param := &ComparedParam{}
+		if baseParam != nil {
+			param.JobName, param.Name, param.Base = baseParam.JobName, baseParam.Name, baseParam.Base
+		}
+		if targetParam != nil {
+			param.JobName, param.Name, param.Target = targetParam.JobName, targetParam.Name, targetParam.Base
+		}
+		if param.Base != param.Target {
+			resp.Params = append(resp.Params, param)
+		}
+	}
+
+	// jobs are listed in the order of the target run, the jobs only in the base run are appended
+	jobOrders := append([]string{}, target.jobOrders...)
+	for _, name := range base.jobOrders {
+		if _, ok := target.jobs[name]; !ok {
+			jobOrders = append(jobOrders, name)
+		}
+	}
+	for _, name := range jobOrders {
+		job := &ComparedJob{Name: name}
+		if baseJob, ok := base.jobs[name]; ok {
+			job.DisplayName, job.JobType = baseJob.DisplayName, baseJob.JobType
+			job.BaseStatus, job.BaseDuration = baseJob.Status, getJobTaskDuration(baseJob)
+		}
+		if targetJob, ok := target.jobs[name]; ok {
+			job.DisplayName, job.JobType = targetJob.DisplayName, targetJob.JobType
+			job.TargetStatus, job.TargetDuration = targetJob.Status, getJobTaskDuration(targetJob)
+			job.TargetError = targetJob.Error
+		}
+		job.DurationDelta = job.TargetDuration - job.BaseDuration
+		resp.Jobs = append(resp.Jobs, job)
+	}
+
+	for _, key := range unionKeys(base.images, target.images) {
+		image := &ComparedServiceImage{}
+		if baseImage, ok := base.images[key]; ok {
+			image.ServiceName, image.ServiceModule, image.BaseImage = baseImage.ServiceName, baseImage.ServiceModule, baseImage.BaseImage
+		}
+		if targetImage, ok := target.images[key]; ok {
+			image.ServiceName, image.ServiceModule, image.TargetImage = targetImage.ServiceName, targetImage.ServiceModule, targetImage.BaseImage
+		}
+		if image.BaseImage != image.TargetImage {
+			resp.Images = append(resp.Images, image)
+		}
+	}
+
+	for _, key := range unionKeys(base.repos, target.repos) {
+		baseRepos := make(map[string]*types.Repository)
+		for _, repo := range base.repos[key] {
+			baseRepos[repo.GetKey()] = repo
+		}
+		image := target.images[key]
+		if image == nil {
+			image = base.images[key]
+		}
+		for _, repo := range target.repos[key] {
+			baseRepo, ok := baseRepos[repo.GetKey()]
+			if !ok || baseRepo.CommitID == repo.CommitID {
+				continue
+			}
+			commitRange := &ComparedCommitRange{
+				JobName:       target.jobNames[key],
+				Source:        repo.Source,
+				RepoNamespace: repo.GetRepoNamespace(),
+				RepoName:      repo.RepoName,
+				BaseRef:       getRepoRefName(baseRepo),
+				TargetRef:     getRepoRefName(repo),
+				BaseCommit:    baseRepo.CommitID,
+				TargetCommit:  repo.CommitID,
+				CompareURL:    getCommitCompareURL(repo, baseRepo.CommitID, repo.CommitID),
+			}
+			if image != nil {
+				commitRange.ServiceName, commitRange.ServiceModule = image.ServiceName, image.ServiceModule
+			}
+			resp.Commits = append(resp.Commits, commitRange)
+		}
+	}
+
+	return resp, nil
+}
+
+func newComparedTaskSummary(task *commonmodels.WorkflowTask) *ComparedTaskSummary {
+	summary := &ComparedTaskSummary{
+		TaskID:      task.TaskID,
+		Status:      task.Status,
+		TaskCreator: task.TaskCreator,
+		CreateTime:  task.CreateTime,
+		Error:       task.Error,
+	}
+	if task.StartTime > 0 && task.EndTime > task.StartTime {
+		summary.Duration = task.EndTime - task.StartTime
+	}
+	return summary
+}
+
+func newComparedTaskInfo(task *commonmodels.WorkflowTask) *comparedTaskInfo {
+	info := &comparedTaskInfo{
+		params:    make(map[string]*ComparedParam),
+		jobs:      make(map[string]*commonmodels.JobTask),
+		images:    make(map[string]*ComparedServiceImage),
+		repos:     make(map[string][]*types.Repository),
+		jobNames:  make(map[string]string),
+		jobOrders: make([]string, 0),
+	}
+
+	for _, param := range task.Params {
+		info.params[param.Name] = &ComparedParam{Name: param.Name, Base: getComparedParamValue(param.Value, param.Repo, param.IsCredential)}
+	}
+
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			info.jobs[job.Name] = job
+			info.jobOrders = append(info.jobOrders, job.Name)
+
+			switch job.JobType {
+			case string(config.JobZadigBuild), string(config.JobFreestyle), string(config.JobZadigTesting):
+				taskJobSpec := &commonmodels.JobTaskFreestyleSpec{}
+				if err := commonmodels.IToi(job.Spec, taskJobSpec); err != nil {
+					continue
+				}
+				for _, env := range taskJobSpec.Properties.CustomEnvs {
+					value := env.Value
+					if env.IsCredential {
+						value = maskedParamValue
+					}
+					info.params[job.Name+"/"+env.Key] = &ComparedParam{JobName: job.Name, Name: env.Key, Base: value}
+				}
+				if job.JobType != string(config.JobZadigBuild) {
+					continue
+				}
+
+				var serviceName, serviceModule, image string
+				for _, env := range taskJobSpec.Properties.Envs {
+					switch env.Key {
+					case "SERVICE_NAME":
+						serviceName = env.Value
+					case "SERVICE_MODULE":
+						serviceModule = env.Value
+					case "IMAGE":
+						image = env.Value
+					}
+				}
+				key := serviceName + "/" + serviceModule
+				info.jobNames[key] = job.Name
+				if image != "" {
+					info.images[key] = &ComparedServiceImage{ServiceName: serviceName, ServiceModule: serviceModule, BaseImage: image}
+				}
+				for _, step := range taskJobSpec.Steps {
+					if step.StepType != config.StepGit {
+						continue
+					}
+					stepSpec := &stepspec.StepGitSpec{}
+					if err := commonmodels.IToi(step.Spec, stepSpec); err != nil {
+						continue
+					}
+					info.repos[key] = append(info.repos[key], stepSpec.Repos...)
+				}
+			case string(config.JobZadigDeploy):
+				taskJobSpec := &commonmodels.JobTaskDeploySpec{}
+				if err := commonmodels.IToi(job.Spec, taskJobSpec); err != nil {
+					continue
+				}
+				for _, serviceImage := range taskJobSpec.ServiceAndImages {
+					key := taskJobSpec.ServiceName + "/" + serviceImage.ServiceModule
+					if _, ok := info.images[key]; !ok {
+						info.images[key] = &ComparedServiceImage{ServiceName: taskJobSpec.ServiceName, ServiceModule: serviceImage.ServiceModule, BaseImage: serviceImage.Image}
+					}
+				}
+			case string(config.JobZadigHelmDeploy):
+				taskJobSpec := &commonmodels.JobTaskHelmDeploySpec{}
+				if err := commonmodels.IToi(job.Spec, taskJobSpec); err != nil {
+					continue
+				}
+				for _, serviceImage := range taskJobSpec.ImageAndModules {
+					key := taskJobSpec.ServiceName + "/" + serviceImage.ServiceModule
+					if _, ok := info.images[key]; !ok {
+						info.images[key] = &ComparedServiceImage{ServiceName: taskJobSpec.ServiceName, ServiceModule: serviceImage.ServiceModule, BaseImage: serviceImage.Image}
+					}
+				}
+			}
+		}
+	}
+	return info
+}
+
+func getComparedParamValue(value string, repo *types.Repository, isCredential bool) string {
+	if isCredential {
+		return maskedParamValue
+	}
+	if repo != nil {
+		return fmt.Sprintf("%s/%s:%s", repo.GetRepoNamespace(), repo.RepoName, getRepoRefName(repo))
+	}
+	return value
+}
+
+func getJobTaskDuration(job *commonmodels.JobTask) int64 {
+	if job.StartTime == 0 || job.EndTime < job.StartTime {
+		return 0
+	}
+	return job.EndTime - job.StartTime
+}
+
+func getRepoRefName(repo *types.Repository) string {
+	switch {
+	case repo.Tag != "":
+		return repo.Tag
+	case repo.PR > 0:
+		return fmt.Sprintf("%s#%d", repo.Branch, repo.PR)
+	default:
+		return repo.Branch
+	}
+}
+
+// getCommitCompareURL returns the url of the commits between the two runs, only github and gitlab are supported
+func getCommitCompareURL(repo *types.Repository, from, to string) string {
+	if repo.Address == "" || from == "" || to == "" {
+		return ""
+	}
+	switch repo.Source {
+	case setting.SourceFromGithub:
+		return fmt.Sprintf("%s/%s/%s/compare/%s...%s", repo.Address, repo.GetRepoNamespace(), repo.RepoName, from, to)
+	case setting.SourceFromGitlab:
+		return fmt.Sprintf("%s/%s/%s/-/compare/%s...%s", repo.Address, repo.GetRepoNamespace(), repo.RepoName, from, to)
+	default:
+		return ""
+	}
+}
+
+func unionKeys[T any](base, target map[string]T) []string {
+	keys := make([]string, 0, len(base)+len(target))
+	for key := range target {
+		keys = append(keys, key)
+	}
+	for key := range base {
+		if _, ok := target[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/pingcap/tidb/parser"
 	_ "github.com/pingcap/tidb/parser/test_driver"
 	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
@@ -104,6 +105,9 @@ func CreateWorkflowV4(user string, workflow *commonmodels.WorkflowV4, logger *za
 		logger.Errorf("instantiate workflow error: %s", err)
 		return e.ErrUpsertWorkflow.AddErr(err)
 	}
+	if err := jobctl.EncryptApprovalCallbackSecrets(workflow, nil); err != nil {
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
 
 	if _, err := commonrepo.NewWorkflowV4Coll().Create(workflow); err != nil {
 		logger.Errorf("Failed to create workflow v4, the error is: %s", err)
@@ -257,6 +261,13 @@ func UpdateWorkflowV4(name, user string, inputWorkflow *commonmodels.WorkflowV4,
 		logger.Errorf("Failed to find WorkflowV4: %s, the error is: %v", name, err)
 		return e.ErrFindWorkflow.AddErr(err)
 	}
+	if workflow.CodeSource != nil {
+		return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("工作流由代码库 %s/%s 的 %s 管理, 请在代码库中修改", workflow.CodeSource.GetRepoNamespace(), workflow.CodeSource.RepoName, workflow.CodeSource.Path))
+	}
+	return saveWorkflowV4(workflow, user, inputWorkflow, logger)
+}
+
+func saveWorkflowV4(workflow *commonmodels.WorkflowV4, user string, inputWorkflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger) error {
 	if workflow.DisplayName != inputWorkflow.DisplayName {
 		existedWorkflows, _, _ := commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{ProjectName: workflow.Project, DisplayName: inputWorkflow.DisplayName}, 0, 0)
 		if len(existedWorkflows) > 0 {
@@ -276,6 +287,7 @@ func UpdateWorkflowV4(name, user string, inputWorkflow *commonmodels.WorkflowV4,
 	inputWorkflow.GeneralHookCtls = workflow.GeneralHookCtls
 	inputWorkflow.MeegoHookCtls = workflow.MeegoHookCtls
 	inputWorkflow.CustomField = workflow.CustomField
+	inputWorkflow.CodeSource = workflow.CodeSource
 
 	for _, stage := range inputWorkflow.Stages {
 		for _, job := range stage.Jobs {
@@ -285,6 +297,9 @@ func UpdateWorkflowV4(name, user string, inputWorkflow *commonmodels.WorkflowV4,
 			}
 		}
 	}
+	if err := jobctl.EncryptApprovalCallbackSecrets(inputWorkflow, workflow); err != nil {
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
 
 	if err := commonrepo.NewWorkflowV4Coll().Update(
 		workflow.ID.Hex(),
@@ -313,6 +328,27 @@ func FindWorkflowV4(encryptedKey, name string, logger *zap.SugaredLogger) (*comm
 	return workflow, err
 }
 
+// ListWorkflowEnvKubeAccess returns the enabled env kube accesses of the freestyle jobs in the workflow
+func ListWorkflowEnvKubeAccess(workflow *commonmodels.WorkflowV4) ([]*commonmodels.EnvKubeAccess, error) {
+	resp := make([]*commonmodels.EnvKubeAccess, 0)
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.JobType != config.JobFreestyle {
+				continue
+			}
+			spec := &commonmodels.FreestyleJobSpec{}
+			if err := commonmodels.IToiYaml(job.Spec, spec); err != nil {
+				return nil, fmt.Errorf("failed to decode spec of job %s: %v", job.Name, err)
+			}
+			if spec.Properties == nil || spec.Properties.EnvKubeAccess == nil || !spec.Properties.EnvKubeAccess.Enabled {
+				continue
+			}
+			resp = append(resp, spec.Properties.EnvKubeAccess)
+		}
+	}
+	return resp, nil
+}
+
 func FindWorkflowV4Raw(name string, logger *zap.SugaredLogger) (*commonmodels.WorkflowV4, error) {
 	workflow, err := commonrepo.NewWorkflowV4Coll().Find(name)
 	if err != nil {
@@ -336,6 +372,9 @@ func DeleteWorkflowV4(name string, logger *zap.SugaredLogger) error {
 		logger.Errorf("Failed to delete WorkflowV4 task: %s, the error is: %v", name, err)
 		return e.ErrDeleteWorkflow.AddErr(err)
 	}
+	if err := commonrepo.NewWorkflowScheduleColl().DeleteByWorkflowName(name); err != nil {
+		log.Errorf("delete workflow schedules error: %s", err)
+	}
 	if err := commonrepo.NewCounterColl().Delete("WorkflowTaskV4:" + name); err != nil {
 		log.Errorf("Counter.Delete error: %s", err)
 	}
@@ -720,6 +759,7 @@ func ensureWorkflowV4Resp(encryptedKey string, workflow *commonmodels.WorkflowV4
 			return err
 		}
 	}
+	workflow.GeneralHookCtls = maskGeneralHookSecrets(workflow.GeneralHookCtls)
 
 	for _, stage := range workflow.Stages {
 		for _, job := range stage.Jobs {
@@ -746,6 +786,10 @@ func ensureWorkflowV4StageResp(encryptedKey, projectName string, workflowStage *
 }
 
 func ensureWorkflowV4JobResp(job *commonmodels.Job, logger *zap.SugaredLogger, buildMap *sync.Map, buildTemplateMap *sync.Map, encryptedKey, workflowProjectName string) error {
+	if err := jobctl.MaskApprovalCallbackSecret(job); err != nil {
+		logger.Errorf(err.Error())
+		return e.ErrFindWorkflow.AddErr(err)
+	}
 	if job.JobType == config.JobZadigBuild {
 		spec := &commonmodels.ZadigBuildJobSpec{}
 		if err := commonmodels.IToi(job.Spec, spec); err != nil {
@@ -962,6 +1006,8 @@ func ensureWorkflowV4JobResp(job *commonmodels.Job, logger *zap.SugaredLogger, b
 	return nil
 }
 
+var dynamicStageSourceRegex = regexp.MustCompile(`^{{\.job\.([^.]+)\..*output\.[a-zA-Z0-9_]+}}$`)
+
 func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger) error {
 	if workflow.Project == "" {
 		err := fmt.Errorf("project should not be empty")
@@ -1026,6 +1072,19 @@ func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger
 			logger.Errorf("duplicated stage name: %s", stage.Name)
 			return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("duplicated stage name: %s", stage.Name))
 		}
+		if stage.OnFailure && ((stage.ManualExec != nil && stage.ManualExec.Enabled) || (stage.Dynamic != nil && stage.Dynamic.Enabled)) {
+			return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("stage %s: failure handler stage can not be manually executed or dynamic", stage.Name))
+		}
+		if stage.Dynamic != nil && stage.Dynamic.Enabled {
+			// the source job must be in previous stages, which are already in jobNameMap
+			sourceJob := dynamicStageSourceRegex.FindStringSubmatch(stage.Dynamic.Source)
+			if len(sourceJob) < 2 {
+				return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("stage %s: invalid dynamic source %s, should be a job output like {{.job.<job>.output.<name>}}", stage.Name, stage.Dynamic.Source))
+			}
+			if _, ok := jobNameMap[sourceJob[1]]; !ok {
+				return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("stage %s: dynamic source job %s should be in previous stages", stage.Name, sourceJob[1]))
+			}
+		}
 		for _, job := range stage.Jobs {
 			if jobctl.JobSkiped(job) {
 				continue
@@ -1047,7 +1106,8 @@ func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger
 			}
 		}
 	}
-	return nil
+
+	return commonservice.CheckCredentials(fmt.Sprintf("workflow %s", workflow.Name), workflow, logger)
 }
 
 func createLarkApprovalDefinition(workflow *commonmodels.WorkflowV4) error {
@@ -1347,6 +1407,9 @@ func CreateGeneralHookForWorkflowV4(workflowName string, arg *models.GeneralHook
 		logger.Errorf("instantiate hook args error: %s", err)
 		return e.ErrCreateGeneralHook.AddErr(err)
 	}
+	if err := validateGeneralHook(arg); err != nil {
+		return e.ErrCreateGeneralHook.AddErr(err)
+	}
 
 	workflow, err := commonrepo.NewWorkflowV4Coll().Find(workflowName)
 	if err != nil {
@@ -1364,6 +1427,9 @@ func CreateGeneralHookForWorkflowV4(workflowName string, arg *models.GeneralHook
 		logger.Errorf(err.Error())
 		return e.ErrCreateGeneralHook.AddErr(err)
 	}
+	if err := encryptGeneralHookSecret(arg, nil); err != nil {
+		return e.ErrCreateGeneralHook.AddErr(err)
+	}
 	workflow.GeneralHookCtls = append(workflow.GeneralHookCtls, arg)
 	if err := commonrepo.NewWorkflowV4Coll().Update(workflow.ID.Hex(), workflow); err != nil {
 		errMsg := fmt.Sprintf("failed to create general hook for workflow %s, the error is: %v", workflowName, err)
@@ -1432,7 +1498,7 @@ func GetGeneralHookForWorkflowV4Preset(workflowName, hookName string, logger *za
 	gHook.WorkflowArg.MeegoHookCtls = nil
 	gHook.WorkflowArg.GeneralHookCtls = nil
 	gHook.WorkflowArg.HookCtls = nil
-	return gHook, nil
+	return maskGeneralHookSecrets([]*commonmodels.GeneralHook{gHook})[0], nil
 }
 
 func ListGeneralHookForWorkflowV4(workflowName string, logger *zap.SugaredLogger) ([]*models.GeneralHook, error) {
@@ -1441,7 +1507,7 @@ func ListGeneralHookForWorkflowV4(workflowName string, logger *zap.SugaredLogger
 		logger.Errorf("Failed to find WorkflowV4: %s, the error is: %v", workflowName, err)
 		return nil, e.ErrListGeneralHook.AddErr(err)
 	}
-	return workflow.GeneralHookCtls, nil
+	return maskGeneralHookSecrets(workflow.GeneralHookCtls), nil
 }
 
 func UpdateGeneralHookForWorkflowV4(workflowName string, arg *models.GeneralHook, logger *zap.SugaredLogger) error {
@@ -1449,6 +1515,9 @@ func UpdateGeneralHookForWorkflowV4(workflowName string, arg *models.GeneralHook
 		logger.Errorf("instantiate hook args error: %s", err)
 		return e.ErrUpdateGeneralHook.AddErr(err)
 	}
+	if err := validateGeneralHook(arg); err != nil {
+		return e.ErrUpdateGeneralHook.AddErr(err)
+	}
 
 	workflow, err := commonrepo.NewWorkflowV4Coll().Find(workflowName)
 	if err != nil {
@@ -1458,6 +1527,9 @@ func UpdateGeneralHookForWorkflowV4(workflowName string, arg *models.GeneralHook
 	updated := false
 	for i, hook := range workflow.GeneralHookCtls {
 		if hook.Name == arg.Name {
+			if err := encryptGeneralHookSecret(arg, hook); err != nil {
+				return e.ErrUpdateGeneralHook.AddErr(err)
+			}
 			workflow.GeneralHookCtls[i] = arg
 			updated = true
 		}
@@ -1502,7 +1574,7 @@ func DeleteGeneralHookForWorkflowV4(workflowName, hookName string, logger *zap.S
 	return nil
 }
 
-func GeneralHookEventHandler(workflowName, hookName string, logger *zap.SugaredLogger) error {
+func GeneralHookEventHandler(workflowName, hookName string, header http.Header, body []byte, logger *zap.SugaredLogger) error {
 	workflowInfo, err := commonrepo.NewWorkflowV4Coll().Find(workflowName)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to find WorkflowV4: %s, the error is: %v", workflowName, err)
@@ -1526,6 +1598,28 @@ func GeneralHookEventHandler(workflowName, hookName string, logger *zap.SugaredL
 		logger.Error(errMsg)
 		return errors.New(errMsg)
 	}
+	if err := verifyGeneralHookSignature(generalHook, header, body); err != nil {
+		logger.Errorf("HandleGeneralHookEvent: failed to verify signature of hook %s: %s", hookName, err)
+		return e.ErrUnauthorized.AddErr(err)
+	}
+	if len(generalHook.Filters) > 0 || len(generalHook.ParamMappings) > 0 {
+		if len(body) > 0 && !gjson.ValidBytes(body) {
+			return e.ErrInvalidParam.AddDesc("payload is not a valid json")
+		}
+	}
+	unmatched, err := matchGeneralHookFilters(generalHook.Filters, body)
+	if err != nil {
+		logger.Errorf("HandleGeneralHookEvent: failed to match filters of hook %s: %s", hookName, err)
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if unmatched != nil {
+		logger.Infof("HandleGeneralHookEvent: payload does not match filter %s %s %s of hook %s, ignored", unmatched.JSONPath, unmatched.Operator, unmatched.Value, hookName)
+		return nil
+	}
+	if err := renderGeneralHookParams(generalHook, body); err != nil {
+		logger.Errorf("HandleGeneralHookEvent: failed to render params of hook %s: %s", hookName, err)
+		return e.ErrInvalidParam.AddErr(err)
+	}
 	_, err = CreateWorkflowTaskV4ByBuildInTrigger(setting.GeneralHookTaskCreator, generalHook.WorkflowArg, logger)
 	if err != nil {
 		errMsg := fmt.Sprintf("HandleGeneralHookEvent: failed to create workflow task: %s", err)
@@ -2144,6 +2238,19 @@ func getDefaultVars(workflow *commonmodels.WorkflowV4, currentJobName string) []
 	vars = append(vars, fmt.Sprintf(setting.RenderValueTemplate, "workflow.task.creator.id"))
 	vars = append(vars, fmt.Sprintf(setting.RenderValueTemplate, "workflow.task.timestamp"))
 	vars = append(vars, fmt.Sprintf(setting.RenderValueTemplate, "workflow.task.id"))
+	// the failure context is only available to the jobs in the failure handler stages
+	for _, stage := range workflow.Stages {
+		if !stage.OnFailure {
+			continue
+		}
+		for _, j := range stage.Jobs {
+			if j.Name == currentJobName {
+				vars = append(vars, fmt.Sprintf(setting.RenderValueTemplate, "workflow.task.status"))
+				vars = append(vars, fmt.Sprintf(setting.RenderValueTemplate, "workflow.task.failed_jobs"))
+				vars = append(vars, fmt.Sprintf(setting.RenderValueTemplate, "workflow.task.error"))
+			}
+		}
+	}
 	for _, param := range workflow.Params {
 		if param.ParamsType == "repo" {
 			continue
@@ -2919,10 +3026,11 @@ func generateOPAInput(header http.Header, method string, endpoint string) *opa.I
 }
 
 type JenkinsJobParams struct {
-	Name    string           `json:"name"`
-	Default string           `json:"default"`
-	Type    config.ParamType `json:"type"`
-	Choices []string         `json:"choices"`
+	Name        string           `json:"name"`
+	Default     string           `json:"default"`
+	Type        config.ParamType `json:"type"`
+	Choices     []string         `json:"choices"`
+	Description string           `json:"description"`
 }
 
 func GetJenkinsJobParams(id, jobName string) ([]*JenkinsJobParams, error) {
@@ -2948,7 +3056,8 @@ func GetJenkinsJobParams(id, jobName string) ([]*JenkinsJobParams, error) {
 				}
 				return config.ParamTypeString
 			}(),
-			Choices: definition.Choices,
+			Choices:     definition.Choices,
+			Description: definition.Description,
 		})
 	}
 
@@ -0,0 +1,230 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/fs"
+	githubservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/github"
+	gitlabservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/service/gitlab"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/shared/client/systemconfig"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+type WorkflowCodeExportResult struct {
+	URL string `json:"url"`
+}
+
+// SetWorkflowCodeSource moves the definition of the workflow to the yaml file in the git repo, the workflow is synced
+// from the file immediately and then by the push events of the branch
+func SetWorkflowCodeSource(name string, source *commonmodels.WorkflowCodeSource, logger *zap.SugaredLogger) error {
+	if source.RepoName == "" || source.Branch == "" || source.Path == "" {
+		return e.ErrInvalidParam.AddDesc("repo_name, branch and path can not be empty")
+	}
+	if source.Mode == "" {
+		source.Mode = commonmodels.WorkflowCodeSyncModeReadOnly
+	}
+	if source.Mode != commonmodels.WorkflowCodeSyncModeReadOnly && source.Mode != commonmodels.WorkflowCodeSyncModePullRequest {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("invalid sync mode %s", source.Mode))
+	}
+	ch, err := systemconfig.New().GetCodeHost(source.CodehostID)
+	if err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if ch.Type != setting.SourceFromGithub && ch.Type != setting.SourceFromGitlab {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("code host %s is not supported", ch.Type))
+	}
+	source.Path = strings.TrimPrefix(path.Clean(source.Path), "/")
+
+	if _, err := commonrepo.NewWorkflowV4Coll().Find(name); err != nil {
+		return e.ErrFindWorkflow.AddErr(err)
+	}
+	source.LastSyncCommit, source.LastSyncTime, source.SyncError = "", 0, ""
+	if err := commonrepo.NewWorkflowV4Coll().UpdateCodeSource(name, source); err != nil {
+		logger.Errorf("failed to set the code source of workflow %s, err: %s", name, err)
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
+	return SyncWorkflowFromCode(name, "", logger)
+}
+
+// DeleteWorkflowCodeSource stops syncing the workflow from the repo, the workflow can be edited in zadig again
+func DeleteWorkflowCodeSource(name string, logger *zap.SugaredLogger) error {
+	if err := commonrepo.NewWorkflowV4Coll().UpdateCodeSource(name, nil); err != nil {
+		logger.Errorf("failed to delete the code source of workflow %s, err: %s", name, err)
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
+	return nil
+}
+
+// SyncWorkflowFromCode loads the workflow from the yaml file in the repo, the name and the project of the workflow
+// are kept. The result of the sync is recorded in the code source.
+func SyncWorkflowFromCode(name, commitSHA string, logger *zap.SugaredLogger) error {
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(name)
+	if err != nil {
+		return e.ErrFindWorkflow.AddErr(err)
+	}
+	source := workflow.CodeSource
+	if source == nil {
+		return e.ErrSyncWorkflowCode.AddDesc(fmt.Sprintf("workflow %s is not managed by code", name))
+	}
+
+	syncErr := syncWorkflowFromCode(workflow, logger)
+	source.LastSyncTime = time.Now().Unix()
+	source.SyncError = ""
+	if syncErr != nil {
+		source.SyncError = syncErr.Error()
+	} else if commitSHA != "" {
+		source.LastSyncCommit = commitSHA
+	}
+	if err := commonrepo.NewWorkflowV4Coll().UpdateCodeSource(name, source); err != nil {
+		logger.Errorf("failed to update the code source of workflow %s, err: %s", name, err)
+	}
+	if syncErr != nil {
+		logger.Errorf("failed to sync workflow %s from %s/%s/%s, err: %s", name, source.GetRepoNamespace(), source.RepoName, source.Path, syncErr)
+		return e.ErrSyncWorkflowCode.AddErr(syncErr)
+	}
+	return nil
+}
+
+func syncWorkflowFromCode(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger) error {
+	source := workflow.CodeSource
+	content, err := fs.DownloadFileFromSource(&fs.DownloadFromSourceArgs{
+		CodehostID: source.CodehostID,
+		Owner:      source.RepoOwner,
+		Namespace:  source.RepoNamespace,
+		Repo:       source.RepoName,
+		Path:       source.Path,
+		Branch:     source.Branch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download the workflow yaml, err: %w", err)
+	}
+
+	inputWorkflow := new(commonmodels.WorkflowV4)
+	if err := yaml.Unmarshal(content, inputWorkflow); err != nil {
+		return fmt.Errorf("failed to unmarshal the workflow yaml, err: %w", err)
+	}
+	inputWorkflow.Name = workflow.Name
+	inputWorkflow.Project = workflow.Project
+	if inputWorkflow.DisplayName == "" {
+		inputWorkflow.DisplayName = workflow.DisplayName
+	}
+	return saveWorkflowV4(workflow, setting.WebhookTaskCreator, inputWorkflow, logger)
+}
+
+// SyncWorkflowsByPush syncs the workflows whose yaml file is changed by the push to the branch of the repo
+func SyncWorkflowsByPush(pathWithNamespace, branch, commitSHA string, changedFiles []string, logger *zap.SugaredLogger) error {
+	workflows, err := commonrepo.NewWorkflowV4Coll().ListWithCodeSource()
+	if err != nil {
+		logger.Errorf("failed to list the workflows managed by code, err: %s", err)
+		return err
+	}
+
+	errs := &multierror.Error{}
+	for _, workflow := range workflows {
+		source := workflow.CodeSource
+		if source.GetRepoNamespace()+"/"+source.RepoName != pathWithNamespace || source.Branch != branch {
+			continue
+		}
+		for _, file := range changedFiles {
+			if file == source.Path {
+				logger.Infof("syncing workflow %s from %s/%s", workflow.Name, pathWithNamespace, source.Path)
+				if err := SyncWorkflowFromCode(workflow.Name, commitSHA, logger); err != nil {
+					errs = multierror.Append(errs, err)
+				}
+				break
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// ExportWorkflowToCode opens a pull request to the repo with the edited workflow, the workflow in zadig is updated
+// after the pull request is merged
+func ExportWorkflowToCode(name, user string, inputWorkflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger) (*WorkflowCodeExportResult, error) {
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(name)
+	if err != nil {
+		return nil, e.ErrFindWorkflow.AddErr(err)
+	}
+	source := workflow.CodeSource
+	if source == nil {
+		return nil, e.ErrExportWorkflowCode.AddDesc(fmt.Sprintf("workflow %s is not managed by code", name))
+	}
+	if source.Mode != commonmodels.WorkflowCodeSyncModePullRequest {
+		return nil, e.ErrExportWorkflowCode.AddDesc("工作流的同步模式不允许在 Zadig 中修改")
+	}
+
+	inputWorkflow.Name = workflow.Name
+	inputWorkflow.Project = workflow.Project
+	if err := LintWorkflowV4(inputWorkflow, logger); err != nil {
+		return nil, err
+	}
+	content, err := yaml.Marshal(inputWorkflow)
+	if err != nil {
+		return nil, e.ErrExportWorkflowCode.AddErr(err)
+	}
+
+	ch, err := systemconfig.New().GetCodeHost(source.CodehostID)
+	if err != nil {
+		return nil, e.ErrExportWorkflowCode.AddErr(err)
+	}
+	branch := fmt.Sprintf("zadig/workflow-%s-%d", name, time.Now().Unix())
+	message := fmt.Sprintf("Update workflow %s", workflow.DisplayName)
+	description := fmt.Sprintf("Updated by %s in Zadig", user)
+
+	resp := new(WorkflowCodeExportResult)
+	switch ch.Type {
+	case setting.SourceFromGithub:
+		pr, err := githubservice.NewClient(ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy).CommitFileAsPullRequest(context.TODO(), source.GetRepoNamespace(), source.RepoName, source.Branch, branch, source.Path, message, message, description, content)
+		if err != nil {
+			logger.Errorf("failed to open pull request of workflow %s, err: %s", name, err)
+			return nil, e.ErrExportWorkflowCode.AddErr(err)
+		}
+		if pr != nil {
+			resp.URL = pr.GetHTMLURL()
+		}
+	case setting.SourceFromGitlab:
+		client, err := gitlabservice.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+		if err != nil {
+			return nil, e.ErrExportWorkflowCode.AddErr(err)
+		}
+		mr, err := client.CommitFileAsMergeRequest(source.GetRepoNamespace(), source.RepoName, source.Branch, branch, source.Path, message, message, description, content)
+		if err != nil {
+			logger.Errorf("failed to open merge request of workflow %s, err: %s", name, err)
+			return nil, e.ErrExportWorkflowCode.AddErr(err)
+		}
+		if mr != nil {
+			resp.URL = mr.WebURL
+		}
+	default:
+		return nil, e.ErrExportWorkflowCode.AddDesc(fmt.Sprintf("code host %s is not supported", ch.Type))
+	}
+	return resp, nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+const defaultJobFailureStatDays = 30
+
+type WorkflowJobFailureStat struct {
+	JobName string `json:"job_name"`
+	JobType string `json:"job_type"`
+	// Failures counts all the failed runs of the job, InfraFailures counts the ones caused by the infrastructure
+	Failures      int            `json:"failures"`
+	InfraFailures int            `json:"infra_failures"`
+	Recovered     int            `json:"recovered"`
+	Categories    map[string]int `json:"categories"`
+	// Flaky is true if the job failed for the infrastructure or passed after it was requeued
+	Flaky           bool   `json:"flaky"`
+	LastError       string `json:"last_error"`
+	LastFailureTime int64  `json:"last_failure_time"`
+}
+
+// ListWorkflowJobFailureStats summarizes the failures of the jobs of the workflow in the recent days, the flaky jobs come first
+func ListWorkflowJobFailureStats(workflowName string, days int, logger *zap.SugaredLogger) ([]*WorkflowJobFailureStat, error) {
+	if days <= 0 {
+		days = defaultJobFailureStatDays
+	}
+	since := time.Now().AddDate(0, 0, -days).Unix()
+	records, err := commonrepo.NewJobFailureRecordColl().ListByWorkflow(workflowName, since)
+	if err != nil {
+		logger.Errorf("failed to list the job failures of workflow %s, err: %s", workflowName, err)
+		return nil, e.ErrListWorkflow.AddErr(err)
+	}
+
+	statMap := make(map[string]*WorkflowJobFailureStat)
+	resp := make([]*WorkflowJobFailureStat, 0)
+	// records are sorted by the create time in descending order, the first one is the last failure
+	for _, record := range records {
+		stat, ok := statMap[record.JobName]
+		if !ok {
+			stat = &WorkflowJobFailureStat{
+				JobName:         record.JobName,
+				JobType:         record.JobType,
+				Categories:      make(map[string]int),
+				LastError:       record.Error,
+				LastFailureTime: record.CreateTime,
+			}
+			statMap[record.JobName] = stat
+			resp = append(resp, stat)
+		}
+		stat.Failures++
+		stat.Categories[record.Category]++
+		if record.Category != commonmodels.JobFailureCategoryJob {
+			stat.InfraFailures++
+		}
+		if record.Recovered {
+			stat.Recovered++
+		}
+		stat.Flaky = stat.InfraFailures > 0 || stat.Recovered > 0
+	}
+
+	sort.SliceStable(resp, func(i, j int) bool {
+		if resp[i].Flaky != resp[j].Flaky {
+			return resp[i].Flaky
+		}
+		return resp[i].Failures > resp[j].Failures
+	})
+	return resp, nil
+}
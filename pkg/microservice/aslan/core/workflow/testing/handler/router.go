@@ -110,6 +110,16 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		//testTask.DELETE("/productName/:productName/id/:id/pipelines/:name", CancelTestTaskV2)
 	}
 
+	// ---------------------------------------------------------------------------------------
+	// 测试报告趋势接口
+	// ---------------------------------------------------------------------------------------
+	testReportTrend := router.Group("testreport")
+	{
+		testReportTrend.GET("/trend", GetTestReportTrend)
+		testReportTrend.GET("/flaky", ListFlakyTestCases)
+		testReportTrend.GET("/case/log", GetTestCaseLogSegment)
+	}
+
 	// ---------------------------------------------------------------------------------------
 	// Pipeline workspace 管理接口
 	// ---------------------------------------------------------------------------------------
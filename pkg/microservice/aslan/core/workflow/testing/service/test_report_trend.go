@@ -0,0 +1,223 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	logservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/log/service"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+const (
+	defaultTestReportTrendLimit = 30
+	testCaseLogContextLines     = 20
+	testCaseLogMaxLines         = 500
+)
+
+type TestReportTrendItem struct {
+	TaskID     int64   `json:"task_id"`
+	TestName   string  `json:"test_name"`
+	ReportType string  `json:"report_type"`
+	Total      int     `json:"total"`
+	Success    int     `json:"success"`
+	Failed     int     `json:"failed"`
+	Error      int     `json:"error"`
+	Skip       int     `json:"skip"`
+	PassRate   float64 `json:"pass_rate"`
+	TestTime   float64 `json:"test_time"`
+	CreateTime int64   `json:"create_time"`
+}
+
+type FlakyTestCase struct {
+	ClassName        string  `json:"classname"`
+	Name             string  `json:"name"`
+	Runs             int     `json:"runs"`
+	Failures         int     `json:"failures"`
+	Flips            int     `json:"flips"`
+	FailureRate      float64 `json:"failure_rate"`
+	LastFailedTaskID int64   `json:"last_failed_task_id"`
+	LastFailure      string  `json:"last_failure"`
+}
+
+type TestCaseLogSegment struct {
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Found     bool   `json:"found"`
+	Content   string `json:"content"`
+}
+
+// GetTestReportTrend returns the pass rate of each test suite in the latest tasks of the workflow job
+func GetTestReportTrend(workflowName, jobName string, limit int, log *zap.SugaredLogger) ([]*TestReportTrendItem, error) {
+	if limit <= 0 {
+		limit = defaultTestReportTrendLimit
+	}
+	reports, err := commonrepo.NewCustomWorkflowTestReportColl().ListRecentByJob(workflowName, jobName, limit)
+	if err != nil {
+		log.Errorf("failed to list test reports of workflow %s job %s, error: %s", workflowName, jobName, err)
+		return nil, e.ErrGetItReport.AddErr(err)
+	}
+
+	resp := make([]*TestReportTrendItem, 0, len(reports))
+	for _, report := range reports {
+		item := &TestReportTrendItem{
+			TaskID:     report.TaskID,
+			TestName:   report.TestName,
+			ReportType: report.ReportType,
+			Total:      report.TestCaseNum,
+			Success:    report.SuccessCaseNum,
+			Failed:     report.FailedCaseNum,
+			Error:      report.ErrorCaseNum,
+			Skip:       report.SkipCaseNum,
+			TestTime:   report.TestTime,
+			CreateTime: report.CreateTime,
+		}
+		if executed := report.TestCaseNum - report.SkipCaseNum; executed > 0 {
+			item.PassRate = math.Round(float64(report.SuccessCaseNum)/float64(executed)*10000) / 100
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+// ListFlakyTestCases finds the test cases which both passed and failed in the latest tasks of the workflow job,
+// the more times the result flips, the more flaky the case is
+func ListFlakyTestCases(workflowName, jobName string, limit int, log *zap.SugaredLogger) ([]*FlakyTestCase, error) {
+	if limit <= 0 {
+		limit = defaultTestReportTrendLimit
+	}
+	reports, err := commonrepo.NewCustomWorkflowTestReportColl().ListRecentByJob(workflowName, jobName, limit)
+	if err != nil {
+		log.Errorf("failed to list test reports of workflow %s job %s, error: %s", workflowName, jobName, err)
+		return nil, e.ErrGetItReport.AddErr(err)
+	}
+
+	type caseHistory struct {
+		flaky      *FlakyTestCase
+		lastFailed *bool
+		passed     bool
+	}
+	histories := make(map[string]*caseHistory)
+	keys := make([]string, 0)
+	// reports are sorted by task id, so the results of each case are in execution order
+	for _, report := range reports {
+		for _, tc := range report.TestCases {
+			if tc.Skipped != nil {
+				continue
+			}
+			key := tc.ClassName + "." + tc.Name
+			history, ok := histories[key]
+			if !ok {
+				history = &caseHistory{flaky: &FlakyTestCase{ClassName: tc.ClassName, Name: tc.Name}}
+				histories[key] = history
+				keys = append(keys, key)
+			}
+
+			failed := tc.Failure != nil || tc.Error != nil
+			history.flaky.Runs++
+			if failed {
+				history.flaky.Failures++
+				history.flaky.LastFailedTaskID = report.TaskID
+				history.flaky.LastFailure = testCaseFailureMessage(tc)
+			} else {
+				history.passed = true
+			}
+			if history.lastFailed != nil && *history.lastFailed != failed {
+				history.flaky.Flips++
+			}
+			history.lastFailed = &failed
+		}
+	}
+
+	resp := make([]*FlakyTestCase, 0)
+	for _, key := range keys {
+		history := histories[key]
+		if !history.passed || history.flaky.Failures == 0 {
+			continue
+		}
+		history.flaky.FailureRate = math.Round(float64(history.flaky.Failures)/float64(history.flaky.Runs)*10000) / 100
+		resp = append(resp, history.flaky)
+	}
+	sort.SliceStable(resp, func(i, j int) bool {
+		if resp[i].Flips != resp[j].Flips {
+			return resp[i].Flips > resp[j].Flips
+		}
+		return resp[i].FailureRate > resp[j].FailureRate
+	})
+	return resp, nil
+}
+
+// GetTestCaseLogSegment locates the lines mentioning the test case in the job log, so a failure can be
+// linked to the log segment where it happened
+func GetTestCaseLogSegment(workflowName, jobTaskName string, taskID int64, caseName string, log *zap.SugaredLogger) (*TestCaseLogSegment, error) {
+	if caseName == "" {
+		return nil, e.ErrInvalidParam.AddDesc("case name can not be empty")
+	}
+	jobLog, err := logservice.GetWorkflowV4JobContainerLogs(workflowName, jobTaskName, taskID, log)
+	if err != nil {
+		log.Errorf("failed to get log of workflow %s job %s task %d, error: %s", workflowName, jobTaskName, taskID, err)
+		return nil, fmt.Errorf("failed to get job log: %s", err)
+	}
+
+	lines := strings.Split(jobLog, "\n")
+	first, last := -1, -1
+	for i, line := range lines {
+		if strings.Contains(line, caseName) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return &TestCaseLogSegment{Found: false}, nil
+	}
+
+	start := first - testCaseLogContextLines
+	if start < 0 {
+		start = 0
+	}
+	end := last + testCaseLogContextLines
+	if end > len(lines)-1 {
+		end = len(lines) - 1
+	}
+	if end-start+1 > testCaseLogMaxLines {
+		end = start + testCaseLogMaxLines - 1
+	}
+	return &TestCaseLogSegment{
+		StartLine: start + 1,
+		EndLine:   end + 1,
+		Found:     true,
+		Content:   strings.Join(lines[start:end+1], "\n"),
+	}, nil
+}
+
+func testCaseFailureMessage(tc commonmodels.TestCase) string {
+	if tc.Failure != nil {
+		return tc.Failure.Message
+	}
+	if tc.Error != nil {
+		return tc.Error.Message
+	}
+	return ""
+}
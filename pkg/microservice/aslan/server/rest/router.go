@@ -55,7 +55,10 @@ import (
 	connectorHandler "github.com/koderover/zadig/v2/pkg/microservice/systemconfig/core/connector/handler"
 	emailHandler "github.com/koderover/zadig/v2/pkg/microservice/systemconfig/core/email/handler"
 	featuresHandler "github.com/koderover/zadig/v2/pkg/microservice/systemconfig/core/features/handler"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
 	"github.com/koderover/zadig/v2/pkg/tool/metrics"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
 	// Note: have to load docs for swagger to work. See https://blog.csdn.net/weixin_43249914/article/details/103035711
 	// _ "github.com/koderover/zadig/v2/pkg/microservice/aslan/server/rest/doc"
 )
@@ -74,6 +77,17 @@ func init() {
 	metrics.Metrics.MustRegister(metrics.Healthy)
 	metrics.Metrics.MustRegister(metrics.Cluster)
 	metrics.Metrics.MustRegister(metrics.ResponseTime)
+	metrics.Metrics.MustRegister(metrics.BuildCacheSteps)
+	metrics.Metrics.MustRegister(metrics.BuildCacheHits)
+	metrics.Metrics.MustRegister(metrics.LocalCacheBytes)
+	metrics.Metrics.MustRegister(metrics.LocalCacheDirs)
+	metrics.Metrics.MustRegister(metrics.LocalCacheGCRemovedBytes)
+	metrics.Metrics.MustRegister(mongotool.TransactionRetries)
+	metrics.Metrics.MustRegister(mongotool.TransactionAborts)
+	metrics.Metrics.MustRegister(cache.LockWaitSeconds)
+	metrics.Metrics.MustRegister(clientmanager.KubeAPIRequests)
+	metrics.Metrics.MustRegister(clientmanager.KubeAPIThrottledRequests)
+	metrics.Metrics.MustRegister(clientmanager.KubeAPIThrottleSeconds)
 
 	metrics.UpdatePodMetrics()
 }
@@ -166,6 +180,9 @@ func (s *engine) injectRouterGroup(router *gin.RouterGroup) {
 		podexec.GET("/:productName/:podName/:containerName/podExec/:envName", podexecservice.ServeWs)
 		podexec.GET("/production/:productName/:podName/:containerName/podExec/:envName", podexecservice.ServeWs)
 		podexec.GET("/debug/:workflowName/:jobName/task/:taskID", podexecservice.DebugWorkflow)
+		podexec.GET("/ephemeral/:productName/:podName/:envName", podexecservice.ServeEphemeralDebugWs)
+		podexec.GET("/sessions", podexecservice.ListPodExecSessions)
+		podexec.GET("/sessions/:id", podexecservice.GetPodExecSession)
 	}
 
 	// inject picket APIs
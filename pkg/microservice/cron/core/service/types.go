@@ -496,8 +496,11 @@ type WorkflowV4 struct {
 }
 
 type ShareStorage struct {
-	Name string `bson:"name"             json:"name"             yaml:"name"`
-	Path string `bson:"path"             json:"path"             yaml:"path"`
+	Name          string `bson:"name"                     json:"name"                     yaml:"name"`
+	Path          string `bson:"path"                     json:"path"                     yaml:"path"`
+	Scope         string `bson:"scope,omitempty"          json:"scope,omitempty"          yaml:"scope,omitempty"`
+	SizeLimit     int64  `bson:"size_limit,omitempty"     json:"size_limit,omitempty"     yaml:"size_limit,omitempty"`
+	RetentionDays int    `bson:"retention_days,omitempty" json:"retention_days,omitempty" yaml:"retention_days,omitempty"`
 }
 
 type WorkflowStage struct {
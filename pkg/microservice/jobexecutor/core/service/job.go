@@ -33,6 +33,7 @@ import (
 	"github.com/koderover/zadig/v2/pkg/microservice/jobexecutor/core/service/meta"
 	"github.com/koderover/zadig/v2/pkg/microservice/jobexecutor/core/service/step"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
+	"github.com/koderover/zadig/v2/pkg/types"
 	"github.com/koderover/zadig/v2/pkg/types/job"
 )
 
@@ -122,6 +123,10 @@ func (j *Job) getUserEnvs() []string {
 	j.Ctx.Paths = strings.Replace(j.Ctx.Paths, "$HOME", config.Home(), -1)
 	envs = append(envs, fmt.Sprintf("PATH=%s", j.Ctx.Paths))
 	envs = append(envs, fmt.Sprintf("DOCKER_HOST=%s", config.DockerHost()))
+	if j.Ctx.CheckpointInterval > 0 {
+		envs = append(envs, fmt.Sprintf("ZADIG_CHECKPOINT_FILE=%s", job.JobCheckpointFile))
+	}
+	envs = append(envs, fmt.Sprintf("ZADIG_OUTPUT=%s", job.JobOutputFile))
 	envs = append(envs, j.Ctx.Envs...)
 	envs = append(envs, j.Ctx.SecretEnvs...)
 	// @var share output var between steps.
@@ -158,6 +163,69 @@ func (j *Job) AfterRun(ctx context.Context) error {
 	return j.collectJobResult(ctx)
 }
 
+// ReportCheckpoints periodically copies the progress written by long-running tests into the job context configMap,
+// so that the progress can be seen and kept by aslan even if the pod is restarted.
+func (j *Job) ReportCheckpoints(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(j.Ctx.CheckpointInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.UpdateCheckpoint(); err != nil {
+				log.Warnf("failed to report checkpoint: %v", err)
+			}
+		}
+	}
+}
+
+// UpdateCheckpoint writes the current content of the checkpoint file into the job context configMap
+func (j *Job) UpdateCheckpoint() error {
+	if j.Ctx.CheckpointInterval <= 0 {
+		return nil
+	}
+	content, err := os.ReadFile(job.JobCheckpointFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	checkpoint := &job.JobCheckpoint{}
+	if err := json.Unmarshal(content, checkpoint); err != nil {
+		return fmt.Errorf("invalid checkpoint file: %v", err)
+	}
+	checkpoint.RunID, _ = os.Hostname()
+	checkpoint.UpdateTime = time.Now().Unix()
+
+	cm, err := j.ConfigMapUpdater.Get()
+	if err != nil {
+		return err
+	}
+	lastCheckpoint := &job.JobCheckpoint{}
+	if data, ok := cm.Data[types.JobCheckpointKey]; ok {
+		if err := json.Unmarshal([]byte(data), lastCheckpoint); err == nil {
+			// nothing has been changed since last report
+			lastCheckpoint.UpdateTime = checkpoint.UpdateTime
+			if *lastCheckpoint == *checkpoint {
+				return nil
+			}
+		}
+	}
+
+	checkpointBytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[types.JobCheckpointKey] = string(checkpointBytes)
+	return j.ConfigMapUpdater.Update(cm)
+}
+
 // @var collect job output vars, if step return error, job will not collect output vars.
 func (j *Job) collectJobResult(ctx context.Context) error {
 	outputs, err := j.getJobOutputVars(ctx)
@@ -190,5 +258,30 @@ func (j *Job) getJobOutputVars(ctx context.Context) ([]*job.JobOutput, error) {
 		value := strings.Trim(string(fileContents), "\n")
 		outputs = append(outputs, &job.JobOutput{Name: outputName, Value: value})
 	}
+
+	// @var the custom outputs written to $ZADIG_OUTPUT override the declared ones with the same name.
+	fileContents, err := os.ReadFile(job.JobOutputFile)
+	if os.IsNotExist(err) {
+		return outputs, nil
+	} else if err != nil {
+		return outputs, err
+	}
+	customOutputs, err := job.ParseJobOutputs(string(fileContents))
+	if err != nil {
+		return outputs, err
+	}
+	for _, customOutput := range customOutputs {
+		found := false
+		for _, output := range outputs {
+			if output.Name == customOutput.Name {
+				output.Value = customOutput.Value
+				found = true
+				break
+			}
+		}
+		if !found {
+			outputs = append(outputs, customOutput)
+		}
+	}
 	return outputs, nil
 }
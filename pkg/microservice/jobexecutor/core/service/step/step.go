@@ -82,6 +82,11 @@ func RunStep(ctx context.Context, step *meta.Step, workspace, paths string, envs
 		if err != nil {
 			return err
 		}
+	case "coverage_report":
+		stepInstance, err = NewCoverageReportStep(step.Spec, workspace, envs, secretEnvs)
+		if err != nil {
+			return err
+		}
 	case "tar_archive":
 		stepInstance, err = NewTarArchiveStep(step.Spec, workspace, envs, secretEnvs)
 		if err != nil {
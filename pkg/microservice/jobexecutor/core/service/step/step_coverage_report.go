@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package step
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/koderover/zadig/v2/pkg/tool/coverage"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+	"github.com/koderover/zadig/v2/pkg/tool/s3"
+	"github.com/koderover/zadig/v2/pkg/types/step"
+	"github.com/koderover/zadig/v2/pkg/util"
+)
+
+type CoverageReportStep struct {
+	spec       *step.StepCoverageReportSpec
+	envs       []string
+	secretEnvs []string
+	workspace  string
+}
+
+func NewCoverageReportStep(spec interface{}, workspace string, envs, secretEnvs []string) (*CoverageReportStep, error) {
+	coverageReportStep := &CoverageReportStep{workspace: workspace, envs: envs, secretEnvs: secretEnvs}
+	yamlBytes, err := yaml.Marshal(spec)
+	if err != nil {
+		return coverageReportStep, fmt.Errorf("marshal spec %+v failed", spec)
+	}
+	if err := yaml.Unmarshal(yamlBytes, &coverageReportStep.spec); err != nil {
+		return coverageReportStep, fmt.Errorf("unmarshal spec %s to coverage report spec failed", yamlBytes)
+	}
+	return coverageReportStep, nil
+}
+
+func (s *CoverageReportStep) Run(ctx context.Context) error {
+	log.Info("Start parse coverage report.")
+	envMap := util.MakeEnvMap(s.envs, s.secretEnvs)
+	reportPath := filepath.Join(s.workspace, util.ReplaceEnvWithValue(s.spec.ReportPath, envMap))
+
+	summary, err := coverage.ParseFile(reportPath, coverage.Format(s.spec.Format))
+	if err != nil {
+		return fmt.Errorf("failed to parse coverage report: %s", err)
+	}
+	log.Infof("Line coverage: %.2f%% (%d/%d), branch coverage: %.2f%% (%d/%d).",
+		summary.LineRate, summary.LinesCovered, summary.LinesValid, summary.BranchRate, summary.BranchesCovered, summary.BranchesValid)
+
+	if s.spec.S3DestDir != "" && s.spec.FileName != "" && s.spec.S3Storage != nil {
+		client, err := s3.NewClient(s.spec.S3Storage.Endpoint, s.spec.S3Storage.Ak, s.spec.S3Storage.Sk, s.spec.S3Storage.Region, s.spec.S3Storage.Insecure, s.spec.S3Storage.Provider)
+		if err != nil {
+			return fmt.Errorf("failed to create s3 client to upload file, err: %s", err)
+		}
+		destDir := s.spec.S3DestDir
+		if len(s.spec.S3Storage.Subfolder) > 0 {
+			destDir = strings.TrimLeft(path.Join(s.spec.S3Storage.Subfolder, destDir), "/")
+		}
+		if err := client.Upload(s.spec.S3Storage.Bucket, reportPath, path.Join(destDir, s.spec.FileName)); err != nil {
+			return fmt.Errorf("failed to upload coverage report: %s", err)
+		}
+	}
+
+	if s.spec.RegressionThreshold > 0 && s.spec.HasBaseline {
+		drop := s.spec.BaselineLineRate - summary.LineRate
+		log.Infof("Baseline line coverage: %.2f%%, allowed drop: %.2f%%.", s.spec.BaselineLineRate, s.spec.RegressionThreshold)
+		if drop > s.spec.RegressionThreshold {
+			return fmt.Errorf("line coverage dropped %.2f%% from %.2f%% to %.2f%%, exceeds the threshold %.2f%%", drop, s.spec.BaselineLineRate, summary.LineRate, s.spec.RegressionThreshold)
+		}
+	}
+	log.Info("Finish parse coverage report.")
+	return nil
+}
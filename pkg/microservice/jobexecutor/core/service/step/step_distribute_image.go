@@ -29,6 +29,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/tool/cosign"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
 	"github.com/koderover/zadig/v2/pkg/types/step"
 )
@@ -93,7 +94,6 @@ func (s *DistributeImageStep) Run(ctx context.Context) error {
 		if err := errList.ErrorOrNil(); err != nil {
 			return fmt.Errorf("pull target images error: %v", err)
 		}
-		return nil
 	} else {
 		if err := s.loginSourceRegistry(); err != nil {
 			return err
@@ -158,10 +158,35 @@ func (s *DistributeImageStep) Run(ctx context.Context) error {
 
 	}
 
+	if s.spec.Sign != nil {
+		if err := s.signTargetImages(); err != nil {
+			return err
+		}
+	}
+
 	log.Info("Finish distribute images.")
 	return nil
 }
 
+// signTargetImages signs the target images with cosign, the target registry has been logged in
+func (s *DistributeImageStep) signTargetImages() error {
+	log.Infof("Start signing images in %s mode.", s.spec.Sign.Mode)
+	opt := &cosign.SignOption{
+		Mode:          s.spec.Sign.Mode,
+		PrivateKey:    s.spec.Sign.PrivateKey,
+		Password:      s.spec.Sign.Password,
+		IdentityToken: s.spec.Sign.IdentityToken,
+		TlogDisabled:  s.spec.Sign.TlogDisabled,
+	}
+	for _, target := range s.spec.DistributeTarget {
+		if err := cosign.Sign(target.TargetImage, opt); err != nil {
+			return fmt.Errorf("failed to sign image %s: %v", target.TargetImage, err)
+		}
+		log.Infof("sign image [%s] succeed", target.TargetImage)
+	}
+	return nil
+}
+
 func (s *DistributeImageStep) loginSourceRegistry() error {
 	log.Info("Logging in Docker Source Registry.")
 	startTimeDockerLogin := time.Now()
@@ -22,14 +22,20 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/koderover/zadig/v2/pkg/setting"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
+	"github.com/koderover/zadig/v2/pkg/types/job"
 	"github.com/koderover/zadig/v2/pkg/types/step"
 	"github.com/koderover/zadig/v2/pkg/util"
 	"github.com/koderover/zadig/v2/pkg/util/fs"
@@ -37,6 +43,44 @@ import (
 
 const dockerExe = "docker"
 
+var (
+	// buildx plain progress output, e.g. "#8 [builder 2/5] RUN go mod download" and "#8 CACHED"
+	buildStepRegexp  = regexp.MustCompile(`^#(\d+) \[[^\]]*\d+/\d+\]`)
+	cachedStepRegexp = regexp.MustCompile(`^#(\d+) CACHED`)
+)
+
+// buildCacheStats counts the build steps and the steps hit the cache from the buildx output
+type buildCacheStats struct {
+	mu     sync.Mutex
+	steps  map[string]struct{}
+	cached map[string]struct{}
+}
+
+func newBuildCacheStats() *buildCacheStats {
+	return &buildCacheStats{steps: map[string]struct{}{}, cached: map[string]struct{}{}}
+}
+
+func (b *buildCacheStats) parse(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if match := buildStepRegexp.FindStringSubmatch(line); len(match) > 1 {
+		b.steps[match[1]] = struct{}{}
+	} else if match := cachedStepRegexp.FindStringSubmatch(line); len(match) > 1 {
+		b.cached[match[1]] = struct{}{}
+	}
+}
+
+func (b *buildCacheStats) result() (hits, steps int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id := range b.cached {
+		if _, ok := b.steps[id]; ok {
+			hits++
+		}
+	}
+	return hits, len(b.steps)
+}
+
 type DockerBuildStep struct {
 	spec       *step.StepDockerBuildSpec
 	envs       []string
@@ -136,6 +180,7 @@ func (s *DockerBuildStep) runDockerBuild() error {
 	log.Infof("Running Docker Build.")
 	startTimeDockerBuild := time.Now()
 	envs := s.envs
+	cacheStats := newBuildCacheStats()
 	for _, c := range s.dockerCommands() {
 
 		cmdOutReader, err := c.StdoutPipe()
@@ -147,6 +192,7 @@ func (s *DockerBuildStep) runDockerBuild() error {
 		go func() {
 			for outScanner.Scan() {
 				fmt.Printf("%s   %s\n", time.Now().Format(setting.WorkflowTimeFormat), outScanner.Text())
+				cacheStats.parse(outScanner.Text())
 			}
 		}()
 
@@ -159,6 +205,7 @@ func (s *DockerBuildStep) runDockerBuild() error {
 		go func() {
 			for errScanner.Scan() {
 				fmt.Printf("%s   %s\n", time.Now().Format(setting.WorkflowTimeFormat), errScanner.Text())
+				cacheStats.parse(errScanner.Text())
 			}
 		}()
 
@@ -170,15 +217,49 @@ func (s *DockerBuildStep) runDockerBuild() error {
 	}
 	log.Infof("Docker build ended. Duration: %.2f seconds.", time.Since(startTimeDockerBuild).Seconds())
 
+	if s.spec.RemoteCache != nil {
+		hits, steps := cacheStats.result()
+		log.Infof("Build cache hits: %d/%d.", hits, steps)
+		writeBuildCacheOutputs(hits, steps)
+	}
 	return nil
 }
 
+// writeBuildCacheOutputs writes the cache stats to the job outputs, the errors are ignored since it's only for metrics
+func writeBuildCacheOutputs(hits, steps int) {
+	outputs := map[string]int{
+		setting.BuildJobOutputKeyCacheHits:  hits,
+		setting.BuildJobOutputKeyCacheSteps: steps,
+	}
+	for key, value := range outputs {
+		if err := os.WriteFile(filepath.Join(job.JobOutputDir, key), []byte(strconv.Itoa(value)), 0644); err != nil {
+			log.Warnf("failed to write build cache output %s: %v", key, err)
+		}
+	}
+}
+
 func (s *DockerBuildStep) dockerCommands() []*exec.Cmd {
 	cmds := make([]*exec.Cmd, 0)
 	if s.spec.WorkDir == "" {
 		s.spec.WorkDir = "."
 	}
 
+	if s.spec.RemoteCache != nil {
+		// the cache export is not supported by the default docker driver, and buildx pushes the image itself
+		return append(
+			cmds,
+			buildxBuilderCmd(),
+			buildxBuildCmd(
+				s.spec.GetDockerFile(),
+				s.spec.ImageName,
+				s.spec.WorkDir,
+				s.spec.BuildArgs,
+				s.spec.IgnoreCache,
+				s.spec.RemoteCache.CacheArgs(),
+			),
+		)
+	}
+
 	cmds = append(
 		cmds,
 		dockerBuildCmd(
@@ -213,6 +294,28 @@ func dockerBuildCmd(dockerfile, fullImage, ctx, buildArgs string, ignoreCache bo
 	return exec.Command("sh", args...)
 }
 
+func buildxBuilderCmd() *exec.Cmd {
+	builderCommand := fmt.Sprintf("docker buildx inspect %s > /dev/null 2>&1 || docker buildx create --name %s --driver docker-container", setting.BuildCacheBuilderName, setting.BuildCacheBuilderName)
+	return exec.Command("sh", "-c", builderCommand)
+}
+
+func buildxBuildCmd(dockerfile, fullImage, ctx, buildArgs string, ignoreCache bool, cacheArgs []string) *exec.Cmd {
+	dockerCommand := fmt.Sprintf("docker buildx build --builder %s --progress=plain --push", setting.BuildCacheBuilderName)
+	if ignoreCache {
+		dockerCommand += " --no-cache"
+	}
+	// the cache is still exported when it's ignored, so that the next build can use the refreshed cache
+	for _, val := range cacheArgs {
+		dockerCommand = dockerCommand + " '" + val + "'"
+	}
+
+	for _, val := range strings.Fields(buildArgs) {
+		dockerCommand = dockerCommand + " " + val
+	}
+	dockerCommand = dockerCommand + " -t " + fullImage + " -f " + dockerfile + " " + ctx
+	return exec.Command("sh", "-c", dockerCommand)
+}
+
 func dockerPush(fullImage string) *exec.Cmd {
 	args := []string{"-c"}
 	dockerPushCommand := "docker push " + fullImage
@@ -84,6 +84,11 @@ func Execute(ctx context.Context) error {
 		}
 		fmt.Printf("%s   Job Status: %s\n", time.Now().Format(setting.WorkflowTimeFormat), resultMsg)
 
+		// report the final progress before the job result, so that aslan gets it when the job ends
+		if err := j.UpdateCheckpoint(); err != nil {
+			log.Warnf("failed to report checkpoint: %v", err)
+		}
+
 		// set job status and outputs to job context configMap
 		cm, err := j.ConfigMapUpdater.Get()
 		if err != nil {
@@ -106,6 +111,12 @@ func Execute(ctx context.Context) error {
 		afterRunErr error
 	)
 
+	if j.Ctx.CheckpointInterval > 0 {
+		checkpointCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go j.ReportCheckpoints(checkpointCtx)
+	}
+
 	// in order to collect output vars, we don't immediately return err even if runErr is not nil
 	runErr = j.Run(ctx)
 	afterRunErr = j.AfterRun(ctx)
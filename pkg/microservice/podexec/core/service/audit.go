@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+// the recorded input and output of a session are truncated to keep the audit record in a mongo document
+const maxSessionRecordSize = 4 << 20
+
+type sessionRecorder struct {
+	mu        sync.Mutex
+	session   *commonmodels.PodExecSession
+	input     bytes.Buffer
+	output    bytes.Buffer
+	truncated bool
+}
+
+func newSessionRecorder(session *commonmodels.PodExecSession) *sessionRecorder {
+	session.StartTime = time.Now().Unix()
+	return &sessionRecorder{session: session}
+}
+
+func (r *sessionRecorder) record(buf *bytes.Buffer, p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	left := maxSessionRecordSize - r.input.Len() - r.output.Len()
+	if left <= 0 {
+		r.truncated = true
+		return
+	}
+	if len(p) > left {
+		p = p[:left]
+		r.truncated = true
+	}
+	buf.Write(p)
+}
+
+func (r *sessionRecorder) recordInput(p []byte) {
+	r.record(&r.input, p)
+}
+
+func (r *sessionRecorder) recordOutput(p []byte) {
+	r.record(&r.output, p)
+}
+
+func (r *sessionRecorder) recordError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.session.Error = err.Error()
+	}
+}
+
+func (r *sessionRecorder) save() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.session.EndTime = time.Now().Unix()
+	r.session.Input = r.input.String()
+	r.session.Output = r.output.String()
+	r.session.Truncated = r.truncated
+	if err := commonrepo.NewPodExecSessionColl().Create(r.session); err != nil {
+		log.Errorf("failed to save exec session of pod %s/%s by %s, error: %s", r.session.Namespace, r.session.PodName, r.session.UserName, err)
+	}
+}
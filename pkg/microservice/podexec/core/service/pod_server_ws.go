@@ -17,15 +17,22 @@ limitations under the License.
 package service
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/koderover/zadig/v2/pkg/tool/clientmanager"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 
 	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/mongodb"
@@ -63,7 +70,24 @@ func ServeWs(c *gin.Context) {
 	}
 	namespace, clusterID := productInfo.Namespace, productInfo.ClusterID
 
-	pty, err := NewTerminalSession(c.Writer, c.Request, nil)
+	if !internalhandler.CheckEnvDebugPermission(ctx, productName, envName, productInfo.Production) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	pty, err := NewTerminalSession(c.Writer, c.Request, nil, &TerminalSessionOption{
+		Type: Environment,
+		Audit: &commonmodels.PodExecSession{
+			ProjectName:   productName,
+			EnvName:       envName,
+			Production:    productInfo.Production,
+			ClusterID:     clusterID,
+			Namespace:     namespace,
+			PodName:       podName,
+			ContainerName: containerName,
+			UserName:      ctx.UserName,
+		},
+	})
 	if err != nil {
 		log.Errorf("get pty failed: %v", err)
 		ctx.RespErr = e.ErrInternalError.AddDesc(fmt.Sprintf("get pty failed: %v", err))
@@ -91,6 +115,7 @@ func ServeWs(c *gin.Context) {
 		log.Errorf(msg)
 		_, _ = pty.Write([]byte(msg))
 		pty.Done()
+		pty.RecordError(err)
 
 		ctx.RespErr = e.ErrInternalError.AddDesc(fmt.Sprintf("Validate pod error! err: %v", err))
 		return
@@ -102,12 +127,233 @@ func ServeWs(c *gin.Context) {
 		log.Errorf(msg)
 		_, _ = pty.Write([]byte(msg))
 		pty.Done()
+		pty.RecordError(err)
 
 		ctx.RespErr = e.ErrInternalError.AddDesc(fmt.Sprintf("Exec to pod error! err: %v", err))
 		return
 	}
 }
 
+// ServeEphemeralDebugWs injects an ephemeral debug container with the given image into the pod and attaches to it
+func ServeEphemeralDebugWs(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	podName := c.Param("podName")
+	image := c.Query("image")
+	targetContainer := c.Query("targetContainer")
+	if podName == "" || image == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("podName and image can't be empty")
+		return
+	}
+
+	productName := c.Param("productName")
+	envName := c.Param("envName")
+	productInfo, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		ctx.RespErr = e.ErrInternalError.AddDesc(fmt.Sprintf("failed to find product %s/%s, err: %s", productName, envName, err))
+		return
+	}
+	namespace, clusterID := productInfo.Namespace, productInfo.ClusterID
+
+	if !internalhandler.CheckEnvDebugPermission(ctx, productName, envName, productInfo.Production) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	kubeCli, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(clusterID)
+	if err != nil {
+		ctx.RespErr = e.ErrInternalError.AddDesc(fmt.Sprintf("get kubecli err :%v", err))
+		return
+	}
+
+	log.Infof("inject ephemeral debug container with image %s into pod %s/%s by %s", image, namespace, podName, ctx.UserName)
+	containerName, err := createEphemeralDebugContainer(c, kubeCli, namespace, podName, targetContainer, image)
+	if err != nil {
+		ctx.RespErr = e.ErrInternalError.AddDesc(fmt.Sprintf("failed to create ephemeral debug container: %v", err))
+		return
+	}
+
+	pty, err := NewTerminalSession(c.Writer, c.Request, nil, &TerminalSessionOption{
+		Type: Environment,
+		Audit: &commonmodels.PodExecSession{
+			ProjectName:   productName,
+			EnvName:       envName,
+			Production:    productInfo.Production,
+			ClusterID:     clusterID,
+			Namespace:     namespace,
+			PodName:       podName,
+			ContainerName: containerName,
+			DebugImage:    image,
+			UserName:      ctx.UserName,
+		},
+	})
+	if err != nil {
+		log.Errorf("get pty failed: %v", err)
+		ctx.RespErr = e.ErrInternalError.AddDesc(fmt.Sprintf("get pty failed: %v", err))
+		return
+	}
+	defer func() {
+		log.Info("close session.")
+		_ = pty.Close()
+	}()
+
+	err = AttachPod(clusterID, pty, namespace, podName, containerName)
+	if err != nil {
+		msg := fmt.Sprintf("Attach to pod error! err: %v", err)
+		log.Errorf(msg)
+		_, _ = pty.Write([]byte(msg))
+		pty.Done()
+		pty.RecordError(err)
+
+		ctx.RespErr = e.ErrInternalError.AddDesc(fmt.Sprintf("Attach to pod error! err: %v", err))
+		return
+	}
+}
+
+func createEphemeralDebugContainer(ctx context.Context, kubeCli *kubernetes.Clientset, namespace, podName, targetContainer, image string) (string, error) {
+	pod, err := kubeCli.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return "", fmt.Errorf("pod %s is %s, not running", podName, pod.Status.Phase)
+	}
+	if targetContainer != "" {
+		found := false
+		for _, container := range pod.Spec.Containers {
+			if container.Name == targetContainer {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("pod has no container '%s'", targetContainer)
+		}
+	}
+
+	name := fmt.Sprintf("zadig-debug-%s", rand.String(5))
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            name,
+			Image:           image,
+			Command:         []string{"sh"},
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Stdin:           true,
+			TTY:             true,
+		},
+		TargetContainerName: targetContainer,
+	})
+	if _, err := kubeCli.CoreV1().Pods(namespace).UpdateEphemeralContainers(ctx, podName, pod, metav1.UpdateOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("ephemeral containers are not supported by the cluster: %v", err)
+		}
+		return "", err
+	}
+
+	// wait for the debug container to start, the image may need to be pulled
+	err = wait.PollUntilContextTimeout(ctx, time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		pod, err := kubeCli.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name != name {
+				continue
+			}
+			if status.State.Running != nil {
+				return true, nil
+			}
+			if status.State.Terminated != nil {
+				return false, fmt.Errorf("debug container terminated: %s", status.State.Terminated.Reason)
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for debug container %s to run: %v", name, err)
+	}
+	return name, nil
+}
+
+// ListPodExecSessions lists the audit records of the terminal sessions of a project, only for project admins
+func ListPodExecSessions(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	projectKey := c.Query("projectName")
+	if projectKey == "" {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc("projectName can't be empty")
+		return
+	}
+	if !internalhandler.IsProjectAdmin(ctx, projectKey) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := &struct {
+		EnvName  string `form:"envName"`
+		UserName string `form:"userName"`
+		PageNum  int64  `form:"page"`
+		PageSize int64  `form:"perPage"`
+	}{}
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	sessions, total, err := commonrepo.NewPodExecSessionColl().List(&commonrepo.ListPodExecSessionOption{
+		ProjectName: projectKey,
+		EnvName:     args.EnvName,
+		UserName:    args.UserName,
+		PageNum:     args.PageNum,
+		PageSize:    args.PageSize,
+	})
+	if err != nil {
+		ctx.RespErr = e.ErrInternalError.AddErr(err)
+		return
+	}
+	ctx.Resp = map[string]interface{}{
+		"sessions": sessions,
+		"total":    total,
+	}
+}
+
+// GetPodExecSession gets the audit record of a terminal session with the recorded input and output
+func GetPodExecSession(c *gin.Context) {
+	ctx, err := internalhandler.NewContextWithAuthorization(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if err != nil {
+		ctx.RespErr = fmt.Errorf("authorization Info Generation failed: err %s", err)
+		ctx.UnAuthorized = true
+		return
+	}
+
+	session, err := commonrepo.NewPodExecSessionColl().GetByID(c.Param("id"))
+	if err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddDesc(fmt.Sprintf("session %s not found: %s", c.Param("id"), err))
+		return
+	}
+	if !internalhandler.IsProjectAdmin(ctx, session.ProjectName) {
+		ctx.UnAuthorized = true
+		return
+	}
+	ctx.Resp = session
+}
+
 func DebugWorkflow(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
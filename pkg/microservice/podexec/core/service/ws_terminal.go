@@ -33,6 +33,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/remotecommand"
 
+	commonmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/v2/pkg/shared/kube/wrapper"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
 )
@@ -82,11 +83,14 @@ type TerminalSession struct {
 	// SecretEnvs is a list of environment variables that should be hidden from the client.
 	SecretEnvs []string
 	Type       TerminalSessionType
+	recorder   *sessionRecorder
 }
 
 type TerminalSessionOption struct {
 	SecretEnvs []string
 	Type       TerminalSessionType
+	// Audit records the input and output of the session if set
+	Audit *commonmodels.PodExecSession
 }
 
 func NewTerminalSession(w http.ResponseWriter, r *http.Request, responseHeader http.Header, opt ...*TerminalSessionOption) (*TerminalSession, error) {
@@ -103,6 +107,9 @@ func NewTerminalSession(w http.ResponseWriter, r *http.Request, responseHeader h
 	if len(opt) > 0 {
 		session.SecretEnvs = opt[0].SecretEnvs
 		session.Type = opt[0].Type
+		if opt[0].Audit != nil {
+			session.recorder = newSessionRecorder(opt[0].Audit)
+		}
 	}
 	return session, nil
 }
@@ -136,7 +143,11 @@ func (t *TerminalSession) Read(p []byte) (int, error) {
 	}
 	switch msg.Operation {
 	case "stdin":
-		return copy(p, msg.Data), nil
+		n := copy(p, msg.Data)
+		if t.recorder != nil {
+			t.recorder.recordInput(p[:n])
+		}
+		return n, nil
 	case "resize":
 		t.sizeChan <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}
 		return 0, nil
@@ -165,11 +176,24 @@ func (t *TerminalSession) Write(p []byte) (int, error) {
 		log.Errorf("write message err: %v", err)
 		return 0, err
 	}
+	if t.recorder != nil {
+		t.recorder.recordOutput(p)
+	}
 	return len(p), nil
 }
 
+// RecordError records the error of the session in the audit record
+func (t *TerminalSession) RecordError(err error) {
+	if t.recorder != nil {
+		t.recorder.recordError(err)
+	}
+}
+
 // Close close session
 func (t *TerminalSession) Close() error {
+	if t.recorder != nil {
+		t.recorder.save()
+	}
 	return t.wsConn.Close()
 }
 
@@ -241,3 +265,43 @@ func ExecPod(clusterID string, cmd []string, ptyHandler PtyHandler, namespace, p
 	}
 	return nil
 }
+
+// AttachPod attaches to the main process of the container, which is used by the ephemeral debug container
+func AttachPod(clusterID string, ptyHandler PtyHandler, namespace, podName, containerName string) error {
+	kubeClient, err := clientmanager.NewKubeClientManager().GetKubernetesClientSet(clusterID)
+	if err != nil {
+		return err
+	}
+
+	req := kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("attach")
+
+	// stderr is merged into stdout when tty is enabled
+	req.VersionedParams(&corev1.PodAttachOptions{
+		Container: containerName,
+		Stdin:     true,
+		Stdout:    true,
+		TTY:       true,
+	}, scheme.ParameterCodec)
+
+	executor, err := clientmanager.NewKubeClientManager().GetSPDYExecutor(clusterID, req.URL())
+	if err != nil {
+		log.Errorf("NewSPDYExecutor err: %v", err)
+		return err
+	}
+
+	err = executor.Stream(remotecommand.StreamOptions{
+		Stdin:             ptyHandler,
+		Stdout:            ptyHandler,
+		TerminalSizeQueue: ptyHandler,
+		Tty:               true,
+	})
+	if err != nil {
+		log.Errorf("Stream err: %v", err)
+		return err
+	}
+	return nil
+}
@@ -87,7 +87,8 @@ func ThirdPartyLoginEnabled(c *gin.Context) {
 	ctx.Resp = login.ThirdPartyLoginEnabled()
 }
 
-func verifyAndDecode(ctx context.Context, code string) (*login.Claims, error) {
+// verifyAndDecode verifies the id token and returns its claims along with the groups of the user in the identity provider
+func verifyAndDecode(ctx context.Context, code string) (*login.Claims, []string, error) {
 	oidcCtx := oidc.ClientContext(ctx, http.DefaultClient)
 	oauth2Config := &oauth2.Config{
 		ClientID:     config.ClientID(),
@@ -99,33 +100,41 @@ func verifyAndDecode(ctx context.Context, code string) (*login.Claims, error) {
 	var token *oauth2.Token
 	token, err := oauth2Config.Exchange(oidcCtx, code)
 	if err != nil {
-		return nil, e.ErrCallBackUser.AddDesc(fmt.Sprintf("failed to get token: %v", err))
+		return nil, nil, e.ErrCallBackUser.AddDesc(fmt.Sprintf("failed to get token: %v", err))
 	}
 	rawIDToken, ok := token.Extra("id_token").(string)
 	if !ok {
-		return nil, e.ErrCallBackUser.AddDesc("no id_token in token response")
+		return nil, nil, e.ErrCallBackUser.AddDesc("no id_token in token response")
 	}
 	idToken, err := provider().Verifier(&oidc.Config{ClientID: config.ClientID()}).Verify(ctx, rawIDToken)
 	if err != nil {
-		return nil, e.ErrCallBackUser.AddDesc(fmt.Sprintf("failed to verify ID token: %v", err))
+		return nil, nil, e.ErrCallBackUser.AddDesc(fmt.Sprintf("failed to verify ID token: %v", err))
 	}
 	var claimsRaw json.RawMessage
 	if err := idToken.Claims(&claimsRaw); err != nil {
-		return nil, e.ErrCallBackUser.AddDesc(fmt.Sprintf("error decoding ID token claims: %v", err))
+		return nil, nil, e.ErrCallBackUser.AddDesc(fmt.Sprintf("error decoding ID token claims: %v", err))
 	}
 	buff := new(bytes.Buffer)
 	if err := json.Indent(buff, claimsRaw, "", "  "); err != nil {
-		return nil, e.ErrCallBackUser.AddDesc(fmt.Sprintf("error indenting ID token claims: %v", err))
+		return nil, nil, e.ErrCallBackUser.AddDesc(fmt.Sprintf("error indenting ID token claims: %v", err))
 	}
 	var claims login.Claims
 	err = json.Unmarshal(claimsRaw, &claims)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if len(claims.Name) == 0 {
 		claims.Name = claims.PreferredUsername
 	}
-	return &claims, nil
+
+	// groups are kept out of the claims so that they are not signed into the zadig token
+	groupClaims := &struct {
+		Groups []string `json:"groups"`
+	}{}
+	if err := json.Unmarshal(claimsRaw, groupClaims); err != nil {
+		return nil, nil, err
+	}
+	return &claims, groupClaims.Groups, nil
 }
 
 func Callback(c *gin.Context) {
@@ -146,7 +155,7 @@ func Callback(c *gin.Context) {
 		ctx.RespErr = e.ErrCallBackUser.AddDesc(fmt.Sprintf("expected state %q got %q", config.AppState, state))
 		return
 	}
-	claims, err := verifyAndDecode(c.Request.Context(), code)
+	claims, groups, err := verifyAndDecode(c.Request.Context(), code)
 	if err != nil {
 		ctx.RespErr = err
 		return
@@ -166,6 +175,11 @@ func Callback(c *gin.Context) {
 		return
 	}
 
+	// a failed group sync should not block the login, the periodic reconciliation will retry it
+	if err := permission.SyncUserIdPGroups(user.UID, user.Account, claims.FederatedClaims.ConnectorId, groups, ctx.Logger); err != nil {
+		log.Errorf("failed to sync idp groups for user %s, error: %s", user.Account, err)
+	}
+
 	systemSettings, err := aslan.New(configbase.AslanServiceAddress()).GetSystemSecurityAndPrivacySettings()
 	if err != nil {
 		log.Errorf("failed to get system security settings, error: %s", err)
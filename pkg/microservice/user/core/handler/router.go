@@ -57,6 +57,22 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		usergroups.POST("/:id/bulk-delete-users", user.BulkRemoveUserFromUserGroup)
 	}
 
+	serviceAccountTokens := router.Group("/service-account-tokens")
+	{
+		serviceAccountTokens.GET("", user.ListServiceAccountTokens)
+		serviceAccountTokens.POST("", user.CreateServiceAccountToken)
+		serviceAccountTokens.POST("/:uid/revoke", user.RevokeServiceAccountToken)
+	}
+
+	idpGroupMappings := router.Group("/idp-group-mappings")
+	{
+		idpGroupMappings.GET("", user.ListIdPGroupRoleMappings)
+		idpGroupMappings.POST("", user.CreateIdPGroupRoleMapping)
+		idpGroupMappings.POST("/sync", user.SyncIdPGroupRoles)
+		idpGroupMappings.PUT("/:id", user.UpdateIdPGroupRoleMapping)
+		idpGroupMappings.DELETE("/:id", user.DeleteIdPGroupRoleMapping)
+	}
+
 	// =======================================================
 	// User Authorization APIs, internal use ONLY
 	// =======================================================
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package user
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/service/permission"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary 获取身份源用户组与项目角色映射
+// @Description 获取身份源用户组与项目角色映射
+// @Tags 	user
+// @Accept 	json
+// @Produce json
+// @Success 200
+// @Router /api/v1/idp-group-mappings [get]
+func ListIdPGroupRoleMappings(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if !checkSystemAdmin(ctx) {
+		return
+	}
+
+	ctx.Resp, ctx.RespErr = permission.ListIdPGroupRoleMappings(ctx.Logger)
+}
+
+// @Summary 创建身份源用户组与项目角色映射
+// @Description 用户登录时以及定时同步时，身份源用户组中的成员会被授予对应的项目角色
+// @Tags 	user
+// @Accept 	json
+// @Produce json
+// @Param 	body 			body 		models.IdPGroupRoleMapping 	true 	"body"
+// @Success 200
+// @Router /api/v1/idp-group-mappings [post]
+func CreateIdPGroupRoleMapping(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if !checkSystemAdmin(ctx) {
+		return
+	}
+
+	args := new(models.IdPGroupRoleMapping)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	ctx.RespErr = permission.CreateIdPGroupRoleMapping(args, ctx.UserName, ctx.Logger)
+}
+
+// @Summary 更新身份源用户组与项目角色映射
+// @Description 更新身份源用户组与项目角色映射
+// @Tags 	user
+// @Accept 	json
+// @Produce json
+// @Param 	id 				path		string							true	"映射 id"
+// @Param 	body 			body 		models.IdPGroupRoleMapping 	true 	"body"
+// @Success 200
+// @Router /api/v1/idp-group-mappings/{id} [put]
+func UpdateIdPGroupRoleMapping(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if !checkSystemAdmin(ctx) {
+		return
+	}
+
+	args := new(models.IdPGroupRoleMapping)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	ctx.RespErr = permission.UpdateIdPGroupRoleMapping(c.Param("id"), args, ctx.Logger)
+}
+
+// @Summary 删除身份源用户组与项目角色映射
+// @Description 删除映射后，由其授予的项目角色会在下次同步时被移除
+// @Tags 	user
+// @Accept 	json
+// @Produce json
+// @Param 	id 				path		string							true	"映射 id"
+// @Success 200
+// @Router /api/v1/idp-group-mappings/{id} [delete]
+func DeleteIdPGroupRoleMapping(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if !checkSystemAdmin(ctx) {
+		return
+	}
+
+	ctx.RespErr = permission.DeleteIdPGroupRoleMapping(c.Param("id"), ctx.Logger)
+}
+
+// @Summary 同步身份源用户组角色
+// @Description 按用户最近一次登录时的用户组同步项目角色，dryRun 为 true 时只返回变更报告
+// @Tags 	user
+// @Accept 	json
+// @Produce json
+// @Param 	dryRun 			query		bool							false	"是否仅预览"
+// @Success 200 			{object} 	permission.IdPGroupSyncReport
+// @Router /api/v1/idp-group-mappings/sync [post]
+func SyncIdPGroupRoles(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if !checkSystemAdmin(ctx) {
+		return
+	}
+
+	dryRun := false
+	if c.Query("dryRun") != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(c.Query("dryRun"))
+		if err != nil {
+			ctx.RespErr = e.ErrInvalidParam.AddDesc("invalid dryRun")
+			return
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = permission.ReconcileIdPGroupRoles(dryRun, ctx.Logger)
+}
+
+func checkSystemAdmin(ctx *internalhandler.Context) bool {
+	// this is local, so we simply generate user auth info from service
+	err := GenerateUserAuthInfo(ctx)
+	if err != nil {
+		ctx.UnAuthorized = true
+		ctx.RespErr = fmt.Errorf("failed to generate user authorization info, error: %s", err)
+		return false
+	}
+
+	if !ctx.Resources.IsSystemAdmin {
+		ctx.UnAuthorized = true
+		return false
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package user
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/service/permission"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
+	e "github.com/koderover/zadig/v2/pkg/tool/errors"
+)
+
+// @Summary 创建服务账号令牌
+// @Description 创建仅拥有指定项目、资源和操作权限的服务账号令牌，令牌仅在创建时返回一次
+// @Tags 	user
+// @Accept 	json
+// @Produce json
+// @Param 	body 			body 		permission.CreateServiceAccountTokenReq 	true 	"body"
+// @Success 200 			{object} 	permission.CreateServiceAccountTokenResp
+// @Router /api/v1/service-account-tokens [post]
+func CreateServiceAccountToken(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	// this is local, so we simply generate user auth info from service
+	err := GenerateUserAuthInfo(ctx)
+	if err != nil {
+		ctx.UnAuthorized = true
+		ctx.RespErr = fmt.Errorf("failed to generate user authorization info, error: %s", err)
+		return
+	}
+
+	// a service account token can never issue other tokens
+	if permission.IsServiceAccountUID(ctx.UserID) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	args := new(permission.CreateServiceAccountTokenReq)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	if err := args.Validate(); err != nil {
+		ctx.RespErr = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	// authorization checks, a project admin can only issue tokens for the projects it administers
+	if !ctx.Resources.IsSystemAdmin {
+		for _, scope := range args.Scopes {
+			if authInfo, ok := ctx.Resources.ProjectAuthInfo[scope.ProjectName]; !ok || !authInfo.IsProjectAdmin {
+				ctx.UnAuthorized = true
+				return
+			}
+		}
+	}
+
+	ctx.Resp, ctx.RespErr = permission.CreateServiceAccountToken(args, ctx.UserID, ctx.UserName, ctx.Logger)
+}
+
+// @Summary 获取服务账号令牌列表
+// @Description 系统管理员可以看到所有令牌，其他用户只能看到自己创建的令牌
+// @Tags 	user
+// @Accept 	json
+// @Produce json
+// @Success 200
+// @Router /api/v1/service-account-tokens [get]
+func ListServiceAccountTokens(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	// this is local, so we simply generate user auth info from service
+	err := GenerateUserAuthInfo(ctx)
+	if err != nil {
+		ctx.UnAuthorized = true
+		ctx.RespErr = fmt.Errorf("failed to generate user authorization info, error: %s", err)
+		return
+	}
+
+	if permission.IsServiceAccountUID(ctx.UserID) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	creatorUID := ctx.UserID
+	if ctx.Resources.IsSystemAdmin {
+		creatorUID = ""
+	}
+
+	ctx.Resp, ctx.RespErr = permission.ListServiceAccountTokens(creatorUID, ctx.Logger)
+}
+
+// @Summary 吊销服务账号令牌
+// @Description 吊销后令牌立即失效
+// @Tags 	user
+// @Accept 	json
+// @Produce json
+// @Param 	uid 			path		string		true	"令牌 uid"
+// @Success 200
+// @Router /api/v1/service-account-tokens/{uid}/revoke [post]
+func RevokeServiceAccountToken(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	// this is local, so we simply generate user auth info from service
+	err := GenerateUserAuthInfo(ctx)
+	if err != nil {
+		ctx.UnAuthorized = true
+		ctx.RespErr = fmt.Errorf("failed to generate user authorization info, error: %s", err)
+		return
+	}
+
+	if permission.IsServiceAccountUID(ctx.UserID) {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	uid := c.Param("uid")
+	token, err := permission.GetServiceAccountToken(uid)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			ctx.RespErr = e.ErrInvalidParam.AddDesc(fmt.Sprintf("service account token %s not found", uid))
+			return
+		}
+		ctx.RespErr = err
+		return
+	}
+
+	// authorization checks
+	if !ctx.Resources.IsSystemAdmin && token.CreatorUID != ctx.UserID {
+		ctx.UnAuthorized = true
+		return
+	}
+
+	ctx.RespErr = permission.RevokeServiceAccountToken(uid, ctx.UserName, ctx.Logger)
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// IdPGroupRoleMapping grants the project role to the members of the identity provider group
+type IdPGroupRoleMapping struct {
+	ID primitive.ObjectID `bson:"_id,omitempty"        json:"id,omitempty"`
+	// ConnectorID limits the mapping to the groups of one connector, empty means every connector
+	ConnectorID string `bson:"connector_id"         json:"connector_id"`
+	Group       string `bson:"group"                json:"group"`
+	ProjectName string `bson:"project_name"         json:"project_name"`
+	RoleName    string `bson:"role_name"            json:"role_name"`
+	CreatedBy   string `bson:"created_by"           json:"created_by"`
+	CreateTime  int64  `bson:"create_time"          json:"create_time"`
+	UpdateTime  int64  `bson:"update_time"          json:"update_time"`
+}
+
+func (IdPGroupRoleMapping) TableName() string {
+	return "idp_group_role_mapping"
+}
+
+// IdPGroupRoleSyncState records the groups of a user from the last login and the role bindings created by the
+// group sync, only those bindings are removed by the sync so that the manually maintained ones are kept.
+type IdPGroupRoleSyncState struct {
+	ID              primitive.ObjectID       `bson:"_id,omitempty"        json:"id,omitempty"`
+	UID             string                   `bson:"uid"                  json:"uid"`
+	Account         string                   `bson:"account"              json:"account"`
+	ConnectorID     string                   `bson:"connector_id"         json:"connector_id"`
+	Groups          []string                 `bson:"groups"               json:"groups"`
+	ManagedBindings []*IdPManagedRoleBinding `bson:"managed_bindings"     json:"managed_bindings"`
+	SyncedAt        int64                    `bson:"synced_at"            json:"synced_at"`
+}
+
+type IdPManagedRoleBinding struct {
+	ProjectName string `bson:"project_name"         json:"project_name"`
+	RoleName    string `bson:"role_name"            json:"role_name"`
+}
+
+func (IdPGroupRoleSyncState) TableName() string {
+	return "idp_group_role_sync_state"
+}
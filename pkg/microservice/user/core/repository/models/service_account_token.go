@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ServiceAccountToken is a non-human API token that only carries the permissions listed in Scopes.
+type ServiceAccountToken struct {
+	ID          primitive.ObjectID          `bson:"_id,omitempty"    json:"id,omitempty"`
+	UID         string                      `bson:"uid"              json:"uid"`
+	Name        string                      `bson:"name"             json:"name"`
+	Description string                      `bson:"description"      json:"description"`
+	Scopes      []*ServiceAccountTokenScope `bson:"scopes"           json:"scopes"`
+	ExpiresAt   int64                       `bson:"expires_at"       json:"expires_at"`
+	LastUsedAt  int64                       `bson:"last_used_at"     json:"last_used_at"`
+	Revoked     bool                        `bson:"revoked"          json:"revoked"`
+	RevokedBy   string                      `bson:"revoked_by"       json:"revoked_by"`
+	RevokedAt   int64                       `bson:"revoked_at"       json:"revoked_at"`
+	CreatedBy   string                      `bson:"created_by"       json:"created_by"`
+	CreatorUID  string                      `bson:"creator_uid"      json:"creator_uid"`
+	CreateTime  int64                       `bson:"create_time"      json:"create_time"`
+}
+
+// ServiceAccountTokenScope grants Verbs of the Resource type in ProjectName. If Names is empty the verbs apply to the
+// whole project, otherwise only to the named resources of the given Resource type (workflow or environment).
+type ServiceAccountTokenScope struct {
+	ProjectName string   `bson:"project_name" json:"project_name"`
+	Resource    string   `bson:"resource"     json:"resource"`
+	Names       []string `bson:"names"        json:"names"`
+	Verbs       []string `bson:"verbs"        json:"verbs"`
+}
+
+func (ServiceAccountToken) TableName() string {
+	return "service_account_token"
+}
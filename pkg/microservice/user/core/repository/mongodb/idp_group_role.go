@@ -0,0 +1,177 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type IdPGroupRoleMappingColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewIdPGroupRoleMappingColl() *IdPGroupRoleMappingColl {
+	name := models.IdPGroupRoleMapping{}.TableName()
+	return &IdPGroupRoleMappingColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *IdPGroupRoleMappingColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *IdPGroupRoleMappingColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "connector_id", Value: 1},
+			bson.E{Key: "group", Value: 1},
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "role_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *IdPGroupRoleMappingColl) Create(args *models.IdPGroupRoleMapping) error {
+	if args == nil {
+		return errors.New("nil IdPGroupRoleMapping args")
+	}
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+func (c *IdPGroupRoleMappingColl) Update(id string, args *models.IdPGroupRoleMapping) error {
+	if args == nil {
+		return errors.New("nil IdPGroupRoleMapping args")
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	change := bson.M{"$set": bson.M{
+		"connector_id": args.ConnectorID,
+		"group":        args.Group,
+		"project_name": args.ProjectName,
+		"role_name":    args.RoleName,
+		"update_time":  args.UpdateTime,
+	}}
+	_, err = c.UpdateByID(context.TODO(), oid, change)
+	return err
+}
+
+func (c *IdPGroupRoleMappingColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return err
+}
+
+func (c *IdPGroupRoleMappingColl) List() ([]*models.IdPGroupRoleMapping, error) {
+	resp := make([]*models.IdPGroupRoleMapping, 0)
+	opts := options.Find().SetSort(bson.D{{"project_name", 1}, {"group", 1}})
+	cursor, err := c.Find(context.TODO(), bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+type IdPGroupRoleSyncStateColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewIdPGroupRoleSyncStateColl() *IdPGroupRoleSyncStateColl {
+	name := models.IdPGroupRoleSyncState{}.TableName()
+	return &IdPGroupRoleSyncStateColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *IdPGroupRoleSyncStateColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *IdPGroupRoleSyncStateColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "uid", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+// Get returns the sync state of the user, an empty state is returned if the user has never been synced
+func (c *IdPGroupRoleSyncStateColl) Get(uid string) (*models.IdPGroupRoleSyncState, error) {
+	resp := &models.IdPGroupRoleSyncState{}
+	err := c.FindOne(context.TODO(), bson.M{"uid": uid}).Decode(resp)
+	if err == mongo.ErrNoDocuments {
+		return &models.IdPGroupRoleSyncState{UID: uid}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *IdPGroupRoleSyncStateColl) Upsert(args *models.IdPGroupRoleSyncState) error {
+	if args == nil {
+		return errors.New("nil IdPGroupRoleSyncState args")
+	}
+	query := bson.M{"uid": args.UID}
+	change := bson.M{"$set": bson.M{
+		"account":          args.Account,
+		"connector_id":     args.ConnectorID,
+		"groups":           args.Groups,
+		"managed_bindings": args.ManagedBindings,
+		"synced_at":        args.SyncedAt,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *IdPGroupRoleSyncStateColl) List() ([]*models.IdPGroupRoleSyncState, error) {
+	resp := make([]*models.IdPGroupRoleSyncState, 0)
+	cursor, err := c.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository/models"
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+type ServiceAccountTokenColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewServiceAccountTokenColl() *ServiceAccountTokenColl {
+	name := models.ServiceAccountToken{}.TableName()
+	return &ServiceAccountTokenColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *ServiceAccountTokenColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ServiceAccountTokenColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "uid", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "creator_uid", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *ServiceAccountTokenColl) Create(args *models.ServiceAccountToken) error {
+	if args == nil {
+		return errors.New("nil ServiceAccountToken args")
+	}
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+func (c *ServiceAccountTokenColl) FindByUID(uid string) (*models.ServiceAccountToken, error) {
+	resp := &models.ServiceAccountToken{}
+	err := c.FindOne(context.TODO(), bson.M{"uid": uid}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// List lists the tokens created by creatorUID, all tokens are returned if creatorUID is empty.
+func (c *ServiceAccountTokenColl) List(creatorUID string) ([]*models.ServiceAccountToken, error) {
+	resp := make([]*models.ServiceAccountToken, 0)
+	query := bson.M{}
+	if creatorUID != "" {
+		query["creator_uid"] = creatorUID
+	}
+
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}})
+	cursor, err := c.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
+
+func (c *ServiceAccountTokenColl) Revoke(uid, revokedBy string, revokedAt int64) error {
+	query := bson.M{"uid": uid}
+	change := bson.M{"$set": bson.M{
+		"revoked":    true,
+		"revoked_by": revokedBy,
+		"revoked_at": revokedAt,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ServiceAccountTokenColl) UpdateLastUsedAt(uid string, lastUsedAt int64) error {
+	query := bson.M{"uid": uid}
+	change := bson.M{"$set": bson.M{"last_used_at": lastUsedAt}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
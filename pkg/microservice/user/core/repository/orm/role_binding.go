@@ -120,3 +120,12 @@ func DeleteRoleBindingByUID(uid, namespace string, db *gorm.DB) error {
 
 	return nil
 }
+
+// DeleteRoleBindingByRoleIDsAndUID deletes the bindings between the given user and roles
+func DeleteRoleBindingByRoleIDsAndUID(uid string, roleIDs []uint, db *gorm.DB) error {
+	if len(roleIDs) == 0 {
+		return nil
+	}
+
+	return db.Where("uid = ? AND role_id IN ?", uid, roleIDs).Delete(&models.NewRoleBinding{}).Error
+}
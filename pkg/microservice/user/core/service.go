@@ -51,6 +51,9 @@ func Start(_ context.Context) {
 	})
 
 	initDatabase()
+
+	// reconcile the role bindings given by the identity provider groups
+	go permissionservice.WatchIdPGroupRoleSync()
 }
 
 func initDatabase() {
@@ -32,12 +32,17 @@ const (
 	larkWebhookURLRegExp         = `^\/api\/aslan\/system\/lark\/\w+\/webhook$`
 	dingTalkWebhookURLRegExp     = `^\/api\/aslan\/system\/dingtalk\/\w+\/webhook$`
 	workwxWebhookURLRegExp       = `^\/api\/aslan\/system\/workwx\/\w+\/webhook$`
+	dingTalkChatOpsURLRegExp     = `^\/api\/aslan\/system\/chatops\/dingtalk\/\w+$`
+	statusBadgeURLRegExp         = `^\/api\/aslan\/system\/badge\/\w+(\.svg)?$`
 	getClusterAgentYamlURLRegExp = `^\/api\/aslan\/cluster\/agent\/\w+\/agent.yaml$`
 	envWorkloadUrlRegExp         = `^\/api\/aslan\/environment\/environments\/[\w-]+\/check\/workloads\/k8services$`
 	envShareEnableURLRegExp      = `^\/api\/aslan\/environment\/environments\/[\w-]+\/check\/sharenv\/enable\/ready$`
 	envShareDisableURLRegExp     = `^\/api\/aslan\/environment\/environments\/[\w-]+\/check\/sharenv\/disable\/ready$`
 	serviceDeployableURLRegExp   = `^\/api\/aslan\/service\/services\/[\w-]+\/environments\/deployable$`
 	generalWebhookURLRegExp      = `^\/api\/aslan\/workflow\/v4\/generalhook\/[\w-]+\/[^/]+\/webhook$`
+	approveCallbackURLRegExp     = `^\/api\/aslan\/workflow\/v4\/workflowtask\/approve\/callback$`
+	alertmanagerWebhookURLRegExp = `^\/api\/aslan\/workflow\/alertreceiver\/\w+\/webhook$`
+	imagePushWebhookURLRegExp    = `^\/api\/aslan\/workflow\/imagepush\/\w+\/webhook$`
 	codeHostAuthURLRegExp        = `^\/api\/v1\/codehosts\/\w+\/auth$`
 	// workflowTestTaskReportURLRegExp = `^\/api\/aslan\/testing\/report\/workflowv4\/[\w-]+\/id\/\w+\/job\/[^/]+$`
 	// testingTaskReportURLRegExp      = `^\/api\/aslan\/testing\/testtask\/[\w-]+\/\w+\/[^/]+$`
@@ -221,11 +226,31 @@ func IsPublicURL(reqPath, method string) bool {
 		return true
 	}
 
+	match, _ = regexp.MatchString(dingTalkChatOpsURLRegExp, realPath)
+	if match && method == http.MethodPost {
+		return true
+	}
+
+	match, _ = regexp.MatchString(statusBadgeURLRegExp, realPath)
+	if match && method == http.MethodGet {
+		return true
+	}
+
 	match, _ = regexp.MatchString(workwxWebhookURLRegExp, realPath)
 	if match && (method == http.MethodPost || method == http.MethodGet) {
 		return true
 	}
 
+	match, _ = regexp.MatchString(alertmanagerWebhookURLRegExp, realPath)
+	if match && method == http.MethodPost {
+		return true
+	}
+
+	match, _ = regexp.MatchString(imagePushWebhookURLRegExp, realPath)
+	if match && method == http.MethodPost {
+		return true
+	}
+
 	match, _ = regexp.MatchString(getClusterAgentYamlURLRegExp, realPath)
 	if match && method == http.MethodGet {
 		return true
@@ -261,6 +286,11 @@ func IsPublicURL(reqPath, method string) bool {
 		return true
 	}
 
+	match, _ = regexp.MatchString(approveCallbackURLRegExp, realPath)
+	if match && method == http.MethodPost {
+		return true
+	}
+
 	return false
 }
 
@@ -37,6 +37,10 @@ func GetUserAuthInfo(uid string, logger *zap.SugaredLogger) (*AuthorizedResource
 		return generateAdminRoleResource(), nil
 	}
 
+	if IsServiceAccountUID(uid) {
+		return generateServiceAccountAuthInfo(uid)
+	}
+
 	isSystemAdmin, err := checkUserIsSystemAdmin(uid, repository.DB)
 	if err != nil {
 		logger.Errorf("failed to check if the user is system admin for uid: %s, error: %s", uid, err)
@@ -161,6 +165,10 @@ func GetUserAuthInfo(uid string, logger *zap.SugaredLogger) (*AuthorizedResource
 }
 
 func CheckCollaborationModePermission(uid, projectKey, resource, resourceName, action string) (hasPermission bool, err error) {
+	if IsServiceAccountUID(uid) {
+		return checkServiceAccountResourcePermission(uid, projectKey, resource, resourceName, action)
+	}
+
 	hasPermission = false
 	collabInstances, findErr := mongodb.NewCollaborationInstanceColl().FindInstance(uid, projectKey)
 	if findErr != nil {
@@ -188,6 +196,10 @@ func CheckCollaborationModePermission(uid, projectKey, resource, resourceName, a
 }
 
 func CheckPermissionGivenByCollaborationMode(uid, projectKey, resource, action string) (hasPermission bool, err error) {
+	if IsServiceAccountUID(uid) {
+		return checkServiceAccountActionInProject(uid, projectKey, resource, action)
+	}
+
 	hasPermission = false
 	collabInstances, findErr := mongodb.NewCollaborationInstanceColl().FindInstance(uid, projectKey)
 	if findErr != nil {
@@ -221,6 +233,10 @@ func CheckPermissionGivenByCollaborationMode(uid, projectKey, resource, action s
 }
 
 func ListAuthorizedProject(uid string, logger *zap.SugaredLogger) ([]string, error) {
+	if IsServiceAccountUID(uid) {
+		return listServiceAccountProjects(uid, "")
+	}
+
 	tx := repository.DB.Begin(&sql.TxOptions{ReadOnly: true})
 
 	respSet := sets.NewString()
@@ -314,6 +330,10 @@ func ListAuthorizedProject(uid string, logger *zap.SugaredLogger) ([]string, err
 }
 
 func ListAuthorizedProjectByVerb(uid, resource, verb string, logger *zap.SugaredLogger) ([]string, error) {
+	if IsServiceAccountUID(uid) {
+		return listServiceAccountProjects(uid, verb)
+	}
+
 	respSet := sets.NewString()
 
 	tx := repository.DB.Begin(&sql.TxOptions{ReadOnly: true})
@@ -423,6 +443,12 @@ func ListAuthorizedProjectByVerb(uid, resource, verb string, logger *zap.Sugared
 
 // ListAuthorizedWorkflow lists all workflows authorized by collaboration mode
 func ListAuthorizedWorkflow(uid, projectKey string, logger *zap.SugaredLogger) ([]string, []string, error) {
+	if IsServiceAccountUID(uid) {
+		// service account tokens can only be scoped to custom workflows
+		customWorkflows, err := listServiceAccountScopedNames(uid, projectKey, types.ResourceTypeWorkflow, types.WorkflowActionView)
+		return []string{}, customWorkflows, err
+	}
+
 	collaborationInstances, err := mongodb.NewCollaborationInstanceColl().FindInstance(uid, projectKey)
 	if err != nil {
 		logger.Errorf("failed to find user collaboration mode, error: %s", err)
@@ -454,6 +480,15 @@ func ListAuthorizedWorkflow(uid, projectKey string, logger *zap.SugaredLogger) (
 }
 
 func ListAuthorizedEnvs(uid, projectKey string, logger *zap.SugaredLogger) (readEnvList, editEnvList []string, err error) {
+	if IsServiceAccountUID(uid) {
+		readEnvList, err = listServiceAccountScopedNames(uid, projectKey, types.ResourceTypeEnvironment, types.EnvActionView, types.ProductionEnvActionView)
+		if err != nil {
+			return
+		}
+		editEnvList, err = listServiceAccountScopedNames(uid, projectKey, types.ResourceTypeEnvironment, types.EnvActionEditConfig, types.ProductionEnvActionEditConfig)
+		return
+	}
+
 	readEnvList = make([]string, 0)
 	editEnvList = make([]string, 0)
 
@@ -0,0 +1,294 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package permission
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/v2/pkg/config"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository/orm"
+	"github.com/koderover/zadig/v2/pkg/tool/cache"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const idpGroupSyncInterval = time.Hour
+
+type IdPGroupSyncReport struct {
+	DryRun bool                      `json:"dry_run"`
+	Users  []*IdPGroupSyncUserReport `json:"users"`
+}
+
+type IdPGroupSyncUserReport struct {
+	UID     string                          `json:"uid"`
+	Account string                          `json:"account"`
+	Groups  []string                        `json:"groups"`
+	Added   []*models.IdPManagedRoleBinding `json:"added"`
+	Removed []*models.IdPManagedRoleBinding `json:"removed"`
+	Error   string                          `json:"error,omitempty"`
+}
+
+func CreateIdPGroupRoleMapping(args *models.IdPGroupRoleMapping, username string, logger *zap.SugaredLogger) error {
+	if err := validateIdPGroupRoleMapping(args); err != nil {
+		return err
+	}
+
+	args.CreatedBy = username
+	args.CreateTime = time.Now().Unix()
+	args.UpdateTime = args.CreateTime
+	if err := mongodb.NewIdPGroupRoleMappingColl().Create(args); err != nil {
+		logger.Errorf("failed to create idp group role mapping for group %s, error: %s", args.Group, err)
+		return fmt.Errorf("failed to create idp group role mapping, error: %s", err)
+	}
+	return nil
+}
+
+func UpdateIdPGroupRoleMapping(id string, args *models.IdPGroupRoleMapping, logger *zap.SugaredLogger) error {
+	if err := validateIdPGroupRoleMapping(args); err != nil {
+		return err
+	}
+
+	args.UpdateTime = time.Now().Unix()
+	if err := mongodb.NewIdPGroupRoleMappingColl().Update(id, args); err != nil {
+		logger.Errorf("failed to update idp group role mapping %s, error: %s", id, err)
+		return fmt.Errorf("failed to update idp group role mapping, error: %s", err)
+	}
+	return nil
+}
+
+func DeleteIdPGroupRoleMapping(id string, logger *zap.SugaredLogger) error {
+	if err := mongodb.NewIdPGroupRoleMappingColl().Delete(id); err != nil {
+		logger.Errorf("failed to delete idp group role mapping %s, error: %s", id, err)
+		return fmt.Errorf("failed to delete idp group role mapping, error: %s", err)
+	}
+	return nil
+}
+
+func ListIdPGroupRoleMappings(logger *zap.SugaredLogger) ([]*models.IdPGroupRoleMapping, error) {
+	mappings, err := mongodb.NewIdPGroupRoleMappingColl().List()
+	if err != nil {
+		logger.Errorf("failed to list idp group role mappings, error: %s", err)
+		return nil, fmt.Errorf("failed to list idp group role mappings, error: %s", err)
+	}
+	return mappings, nil
+}
+
+func validateIdPGroupRoleMapping(args *models.IdPGroupRoleMapping) error {
+	if args.Group == "" || args.RoleName == "" {
+		return fmt.Errorf("group and role can't be empty")
+	}
+	if args.ProjectName == "" || args.ProjectName == GeneralNamespace {
+		return fmt.Errorf("groups can only be mapped to project roles")
+	}
+
+	role, err := orm.GetRole(args.RoleName, args.ProjectName, repository.DB)
+	if err != nil {
+		return fmt.Errorf("failed to find role %s in project %s, error: %s", args.RoleName, args.ProjectName, err)
+	}
+	if role.ID == 0 {
+		return fmt.Errorf("role %s not found in project %s", args.RoleName, args.ProjectName)
+	}
+	return nil
+}
+
+// SyncUserIdPGroups saves the groups given by the identity provider on login and reconciles the role bindings of the user.
+// The groups are only available when the "groups" scope is configured for the connector.
+func SyncUserIdPGroups(uid, account, connectorID string, groups []string, logger *zap.SugaredLogger) error {
+	state, err := mongodb.NewIdPGroupRoleSyncStateColl().Get(uid)
+	if err != nil {
+		logger.Errorf("failed to find idp group sync state of user %s, error: %s", account, err)
+		return fmt.Errorf("failed to find idp group sync state, error: %s", err)
+	}
+	// users without any group and any synced binding are not tracked at all
+	if len(groups) == 0 && len(state.ManagedBindings) == 0 && state.ID.IsZero() {
+		return nil
+	}
+
+	mappings, err := mongodb.NewIdPGroupRoleMappingColl().List()
+	if err != nil {
+		logger.Errorf("failed to list idp group role mappings, error: %s", err)
+		return fmt.Errorf("failed to list idp group role mappings, error: %s", err)
+	}
+
+	state.Account = account
+	state.ConnectorID = connectorID
+	state.Groups = groups
+	_, err = reconcileUserIdPGroupRoles(state, mappings, false, logger)
+	return err
+}
+
+// ReconcileIdPGroupRoles applies the current group mappings to every synced user by the groups of their last login.
+// Nothing is changed in dry run mode, the report shows what would be changed.
+func ReconcileIdPGroupRoles(dryRun bool, logger *zap.SugaredLogger) (*IdPGroupSyncReport, error) {
+	states, err := mongodb.NewIdPGroupRoleSyncStateColl().List()
+	if err != nil {
+		logger.Errorf("failed to list idp group sync states, error: %s", err)
+		return nil, fmt.Errorf("failed to list idp group sync states, error: %s", err)
+	}
+
+	mappings, err := mongodb.NewIdPGroupRoleMappingColl().List()
+	if err != nil {
+		logger.Errorf("failed to list idp group role mappings, error: %s", err)
+		return nil, fmt.Errorf("failed to list idp group role mappings, error: %s", err)
+	}
+
+	resp := &IdPGroupSyncReport{
+		DryRun: dryRun,
+		Users:  make([]*IdPGroupSyncUserReport, 0),
+	}
+	for _, state := range states {
+		report, err := reconcileUserIdPGroupRoles(state, mappings, dryRun, logger)
+		if err != nil {
+			report.Error = err.Error()
+		}
+		if len(report.Added) > 0 || len(report.Removed) > 0 || report.Error != "" {
+			resp.Users = append(resp.Users, report)
+		}
+	}
+	return resp, nil
+}
+
+// WatchIdPGroupRoleSync periodically reconciles the group mappings, so that the mapping changes take effect
+// without waiting for the users to login again.
+func WatchIdPGroupRoleSync() {
+	logger := log.SugaredLogger().With("service", "WatchIdPGroupRoleSync")
+	for {
+		time.Sleep(idpGroupSyncInterval)
+
+		// the lock outlives the hourly sync by all but a minute, so the groups are synced by a single replica each hour
+		lock := cache.NewRedisLockWithExpiry("idp-group-sync-lock", idpGroupSyncInterval-time.Minute)
+		if err := lock.TryLock(); err != nil {
+			continue
+		}
+		if _, err := ReconcileIdPGroupRoles(false, logger); err != nil {
+			logger.Errorf("failed to reconcile idp group roles, error: %s", err)
+		}
+	}
+}
+
+func reconcileUserIdPGroupRoles(state *models.IdPGroupRoleSyncState, mappings []*models.IdPGroupRoleMapping, dryRun bool, logger *zap.SugaredLogger) (*IdPGroupSyncUserReport, error) {
+	report := &IdPGroupSyncUserReport{
+		UID:     state.UID,
+		Account: state.Account,
+		Groups:  state.Groups,
+		Added:   make([]*models.IdPManagedRoleBinding, 0),
+		Removed: make([]*models.IdPManagedRoleBinding, 0),
+	}
+
+	groupSet := sets.NewString(state.Groups...)
+	desired := make(map[string]*models.IdPManagedRoleBinding)
+	for _, mapping := range mappings {
+		if mapping.ConnectorID != "" && mapping.ConnectorID != state.ConnectorID {
+			continue
+		}
+		if !groupSet.Has(mapping.Group) {
+			continue
+		}
+		desired[mapping.ProjectName+"/"+mapping.RoleName] = &models.IdPManagedRoleBinding{
+			ProjectName: mapping.ProjectName,
+			RoleName:    mapping.RoleName,
+		}
+	}
+
+	managed := make(map[string]*models.IdPManagedRoleBinding)
+	for _, binding := range state.ManagedBindings {
+		managed[binding.ProjectName+"/"+binding.RoleName] = binding
+	}
+
+	newManaged := make([]*models.IdPManagedRoleBinding, 0)
+	addRoleIDs := make([]uint, 0)
+	removeRoleIDs := make([]uint, 0)
+
+	for key, binding := range desired {
+		role, err := orm.GetRole(binding.RoleName, binding.ProjectName, repository.DB)
+		if err != nil {
+			return report, fmt.Errorf("failed to find role %s in project %s, error: %s", binding.RoleName, binding.ProjectName, err)
+		}
+		// the role or the project is deleted after the mapping is created
+		if role.ID == 0 {
+			logger.Warnf("role %s in project %s of the idp group mapping is not found, skipped", binding.RoleName, binding.ProjectName)
+			continue
+		}
+
+		roleBinding, err := orm.GetRoleBinding(role.ID, state.UID, repository.DB)
+		if err != nil {
+			return report, fmt.Errorf("failed to find role binding of role %s for user %s, error: %s", binding.RoleName, state.Account, err)
+		}
+		if roleBinding.ID == 0 {
+			addRoleIDs = append(addRoleIDs, role.ID)
+			report.Added = append(report.Added, binding)
+			newManaged = append(newManaged, binding)
+		} else if _, ok := managed[key]; ok {
+			newManaged = append(newManaged, binding)
+		}
+		// the binding maintained manually is left alone
+	}
+
+	for key, binding := range managed {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		role, err := orm.GetRole(binding.RoleName, binding.ProjectName, repository.DB)
+		if err != nil {
+			return report, fmt.Errorf("failed to find role %s in project %s, error: %s", binding.RoleName, binding.ProjectName, err)
+		}
+		if role.ID == 0 {
+			continue
+		}
+		removeRoleIDs = append(removeRoleIDs, role.ID)
+		report.Removed = append(report.Removed, binding)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if len(addRoleIDs) > 0 || len(removeRoleIDs) > 0 {
+		tx := repository.DB.Begin()
+		if err := orm.BulkCreateRoleBindingForUser(state.UID, addRoleIDs, tx); err != nil {
+			tx.Rollback()
+			logger.Errorf("failed to create idp group role bindings for user %s, error: %s", state.Account, err)
+			return report, fmt.Errorf("failed to create role bindings, error: %s", err)
+		}
+		if err := orm.DeleteRoleBindingByRoleIDsAndUID(state.UID, removeRoleIDs, tx); err != nil {
+			tx.Rollback()
+			logger.Errorf("failed to delete idp group role bindings for user %s, error: %s", state.Account, err)
+			return report, fmt.Errorf("failed to delete role bindings, error: %s", err)
+		}
+		tx.Commit()
+
+		roleCache := cache.NewRedisCache(config.RedisCommonCacheTokenDB())
+		uidRoleKey := fmt.Sprintf(UIDRoleKeyFormat, state.UID)
+		if err := roleCache.Delete(uidRoleKey); err != nil {
+			logger.Warnf("failed to flush user-role cache for key: %s, error: %s", uidRoleKey, err)
+		}
+	}
+
+	state.ManagedBindings = newManaged
+	state.SyncedAt = time.Now().Unix()
+	if err := mongodb.NewIdPGroupRoleSyncStateColl().Upsert(state); err != nil {
+		logger.Errorf("failed to save idp group sync state of user %s, error: %s", state.Account, err)
+		return report, fmt.Errorf("failed to save idp group sync state, error: %s", err)
+	}
+	return report, nil
+}
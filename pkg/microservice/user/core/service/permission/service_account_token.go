@@ -0,0 +1,363 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package permission
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/repository/mongodb"
+	"github.com/koderover/zadig/v2/pkg/microservice/user/core/service/login"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	"github.com/koderover/zadig/v2/pkg/types"
+)
+
+const (
+	// ServiceAccountUIDPrefix is the prefix of the uid carried by service account tokens, user uids never have it.
+	ServiceAccountUIDPrefix = "sa-"
+	// ServiceAccountConnectorID is put into the federated claims of service account tokens as the identity type.
+	ServiceAccountConnectorID = "service_account"
+
+	// the last used time is only written back when it is older than this interval, so that every api call
+	// does not end up with a database write.
+	serviceAccountLastUsedInterval = 60
+
+	// the resource types below can only be granted project wide, workflow, environment and test are in the types package
+	serviceAccountResourceService  = "service"
+	serviceAccountResourceBuild    = "build"
+	serviceAccountResourceScan     = "scan"
+	serviceAccountResourceDelivery = "delivery"
+	serviceAccountResourceSprint   = "sprint"
+)
+
+var (
+	serviceAccountWorkflowVerbs = sets.NewString(
+		types.WorkflowActionView,
+		types.WorkflowActionEdit,
+		types.WorkflowActionRun,
+		types.WorkflowActionDebug,
+	)
+	serviceAccountEnvVerbs = sets.NewString(
+		types.EnvActionView,
+		types.EnvActionEditConfig,
+		types.EnvActionManagePod,
+		types.EnvActionDebug,
+		types.EnvActionSSH,
+		types.ProductionEnvActionView,
+		types.ProductionEnvActionEditConfig,
+		types.ProductionEnvActionManagePod,
+		types.ProductionEnvActionDebug,
+	)
+
+	// serviceAccountProjectVerbs are the verbs that can be granted project wide for each resource type
+	serviceAccountProjectVerbs = map[string]sets.String{
+		types.ResourceTypeWorkflow: sets.NewString(VerbGetWorkflow, VerbCreateWorkflow, VerbEditWorkflow, VerbDeleteWorkflow, VerbRunWorkflow, VerbDebugWorkflow),
+		types.ResourceTypeEnvironment: sets.NewString(
+			VerbGetEnvironment, VerbCreateEnvironment, VerbConfigEnvironment, VerbManageEnvironment, VerbDeleteEnvironment, VerbDebugEnvironmentPod, VerbEnvironmentSSHPM,
+			VerbGetProductionEnv, VerbCreateProductionEnv, VerbConfigProductionEnv, VerbEditProductionEnv, VerbDeleteProductionEnv, VerbDebugProductionEnvPod,
+		),
+		types.ResourceTypeTest: sets.NewString(VerbGetTest, VerbCreateTest, VerbEditTest, VerbDeleteTest, VerbRunTest),
+		serviceAccountResourceService: sets.NewString(
+			VerbGetService, VerbCreateService, VerbEditService, VerbDeleteService,
+			VerbGetProductionService, VerbCreateProductionService, VerbEditProductionService, VerbDeleteProductionService,
+		),
+		serviceAccountResourceBuild:    sets.NewString(VerbGetBuild, VerbCreateBuild, VerbEditBuild, VerbDeleteBuild),
+		serviceAccountResourceScan:     sets.NewString(VerbGetScan, VerbCreateScan, VerbEditScan, VerbDeleteScan, VerbRunScan),
+		serviceAccountResourceDelivery: sets.NewString(VerbGetDelivery, VerbCreateDelivery, VerbDeleteDelivery),
+		serviceAccountResourceSprint: sets.NewString(
+			VerbEditSprintTemplate, VerbGetSprint, VerbCreateSprint, VerbEditSprint, VerbDeleteSprint,
+			VerbCreateSprintWorkItem, VerbEditSprintWorkItem, VerbDeleteSprintWorkItem,
+		),
+	}
+)
+
+type CreateServiceAccountTokenReq struct {
+	Name        string                             `json:"name"`
+	Description string                             `json:"description"`
+	Scopes      []*models.ServiceAccountTokenScope `json:"scopes"`
+	// ExpiresAt is a unix timestamp in seconds, 0 means the token never expires
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+type CreateServiceAccountTokenResp struct {
+	*models.ServiceAccountToken
+	// Token is only returned once on creation and is not stored anywhere.
+	Token string `json:"token"`
+}
+
+func IsServiceAccountUID(uid string) bool {
+	return strings.HasPrefix(uid, ServiceAccountUIDPrefix)
+}
+
+func (args *CreateServiceAccountTokenReq) Validate() error {
+	if args.Name == "" {
+		return fmt.Errorf("token name can't be empty")
+	}
+	if len(args.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	if args.ExpiresAt != 0 && args.ExpiresAt <= time.Now().Unix() {
+		return fmt.Errorf("expiration time must be in the future")
+	}
+
+	for _, scope := range args.Scopes {
+		if scope == nil {
+			return fmt.Errorf("scope can't be empty")
+		}
+		if scope.ProjectName == "" || scope.ProjectName == "*" {
+			return fmt.Errorf("a scope must be bound to a specific project")
+		}
+		if len(scope.Verbs) == 0 {
+			return fmt.Errorf("verbs of the scope in project %s can't be empty", scope.ProjectName)
+		}
+
+		var allowedVerbs sets.String
+		if len(scope.Names) == 0 {
+			verbs, ok := serviceAccountProjectVerbs[scope.Resource]
+			if !ok {
+				return fmt.Errorf("unsupported resource type %s", scope.Resource)
+			}
+			allowedVerbs = verbs
+		} else {
+			switch scope.Resource {
+			case types.ResourceTypeWorkflow:
+				allowedVerbs = serviceAccountWorkflowVerbs
+			case types.ResourceTypeEnvironment:
+				allowedVerbs = serviceAccountEnvVerbs
+			default:
+				return fmt.Errorf("resource type %s does not support scoping by name", scope.Resource)
+			}
+		}
+		for _, verb := range scope.Verbs {
+			if !allowedVerbs.Has(verb) {
+				return fmt.Errorf("verb %s is not supported by resource type %s", verb, scope.Resource)
+			}
+		}
+	}
+	return nil
+}
+
+func CreateServiceAccountToken(args *CreateServiceAccountTokenReq, creatorUID, creatorName string, logger *zap.SugaredLogger) (*CreateServiceAccountTokenResp, error) {
+	id := primitive.NewObjectID()
+	token := &models.ServiceAccountToken{
+		ID:          id,
+		UID:         ServiceAccountUIDPrefix + id.Hex(),
+		Name:        args.Name,
+		Description: args.Description,
+		Scopes:      args.Scopes,
+		ExpiresAt:   args.ExpiresAt,
+		CreatedBy:   creatorName,
+		CreatorUID:  creatorUID,
+		CreateTime:  time.Now().Unix(),
+	}
+
+	expiresAt := args.ExpiresAt
+	if expiresAt == 0 {
+		//24*365*100=876000
+		expiresAt = time.Now().Add(876000 * time.Hour).Unix()
+	}
+
+	tokenString, err := login.CreateToken(&login.Claims{
+		Name:              token.Name,
+		UID:               token.UID,
+		PreferredUsername: token.Name,
+		StandardClaims: jwt.StandardClaims{
+			Audience:  setting.ProductName,
+			ExpiresAt: expiresAt,
+		},
+		FederatedClaims: login.FederatedClaims{
+			ConnectorId: ServiceAccountConnectorID,
+			UserId:      token.UID,
+		},
+	})
+	if err != nil {
+		logger.Errorf("failed to sign service account token %s, error: %s", args.Name, err)
+		return nil, fmt.Errorf("failed to sign service account token, error: %s", err)
+	}
+
+	if err := mongodb.NewServiceAccountTokenColl().Create(token); err != nil {
+		logger.Errorf("failed to save service account token %s, error: %s", args.Name, err)
+		return nil, fmt.Errorf("failed to save service account token, error: %s", err)
+	}
+
+	return &CreateServiceAccountTokenResp{
+		ServiceAccountToken: token,
+		Token:               tokenString,
+	}, nil
+}
+
+// ListServiceAccountTokens lists the tokens created by creatorUID, or every token if creatorUID is empty.
+func ListServiceAccountTokens(creatorUID string, logger *zap.SugaredLogger) ([]*models.ServiceAccountToken, error) {
+	tokens, err := mongodb.NewServiceAccountTokenColl().List(creatorUID)
+	if err != nil {
+		logger.Errorf("failed to list service account tokens, error: %s", err)
+		return nil, fmt.Errorf("failed to list service account tokens, error: %s", err)
+	}
+	return tokens, nil
+}
+
+func GetServiceAccountToken(uid string) (*models.ServiceAccountToken, error) {
+	return mongodb.NewServiceAccountTokenColl().FindByUID(uid)
+}
+
+func RevokeServiceAccountToken(uid, revokedBy string, logger *zap.SugaredLogger) error {
+	if err := mongodb.NewServiceAccountTokenColl().Revoke(uid, revokedBy, time.Now().Unix()); err != nil {
+		logger.Errorf("failed to revoke service account token %s, error: %s", uid, err)
+		return fmt.Errorf("failed to revoke service account token, error: %s", err)
+	}
+	return nil
+}
+
+// ValidateServiceAccountToken checks that the token with the given uid is still usable and records its usage.
+func ValidateServiceAccountToken(uid string) error {
+	token, err := findActiveServiceAccountToken(uid)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	if now-token.LastUsedAt > serviceAccountLastUsedInterval {
+		// failing to record the usage should not block the request
+		_ = mongodb.NewServiceAccountTokenColl().UpdateLastUsedAt(uid, now)
+	}
+	return nil
+}
+
+func findActiveServiceAccountToken(uid string) (*models.ServiceAccountToken, error) {
+	token, err := mongodb.NewServiceAccountTokenColl().FindByUID(uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find service account token %s, error: %s", uid, err)
+	}
+	if token.Revoked {
+		return nil, fmt.Errorf("service account token %s has been revoked", token.Name)
+	}
+	if token.ExpiresAt != 0 && token.ExpiresAt <= time.Now().Unix() {
+		return nil, fmt.Errorf("service account token %s has expired", token.Name)
+	}
+	return token, nil
+}
+
+// generateServiceAccountAuthInfo generates the authorization info of a service account token. Scopes without
+// names are granted project wide, named scopes are answered by the collaboration mode checks below.
+func generateServiceAccountAuthInfo(uid string) (*AuthorizedResources, error) {
+	token, err := findActiveServiceAccountToken(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	projectActionMap := make(map[string]*ProjectActions)
+	for _, scope := range token.Scopes {
+		if _, ok := projectActionMap[scope.ProjectName]; !ok {
+			projectActionMap[scope.ProjectName] = generateDefaultProjectActions()
+		}
+		if len(scope.Names) > 0 {
+			continue
+		}
+		for _, verb := range scope.Verbs {
+			modifyUserProjectAuth(projectActionMap[scope.ProjectName], verb)
+		}
+	}
+
+	projectInfo := make(map[string]ProjectActions)
+	for proj, actions := range projectActionMap {
+		projectInfo[proj] = *actions
+	}
+
+	return &AuthorizedResources{
+		IsSystemAdmin:   false,
+		ProjectAuthInfo: projectInfo,
+		SystemActions:   generateDefaultSystemActions(),
+	}, nil
+}
+
+func checkServiceAccountResourcePermission(uid, projectKey, resource, resourceName, action string) (bool, error) {
+	token, err := findActiveServiceAccountToken(uid)
+	if err != nil {
+		return false, err
+	}
+
+	for _, scope := range token.Scopes {
+		if scope.ProjectName != projectKey || scope.Resource != resource {
+			continue
+		}
+		if sets.NewString(scope.Names...).Has(resourceName) && sets.NewString(scope.Verbs...).Has(action) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func checkServiceAccountActionInProject(uid, projectKey, resource, action string) (bool, error) {
+	token, err := findActiveServiceAccountToken(uid)
+	if err != nil {
+		return false, err
+	}
+
+	for _, scope := range token.Scopes {
+		if scope.ProjectName != projectKey || scope.Resource != resource || len(scope.Names) == 0 {
+			continue
+		}
+		if sets.NewString(scope.Verbs...).Has(action) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// listServiceAccountProjects lists the projects in the scopes of the token, if verb is not empty only
+// the scopes containing the verb are considered.
+func listServiceAccountProjects(uid, verb string) ([]string, error) {
+	token, err := findActiveServiceAccountToken(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	respSet := sets.NewString()
+	for _, scope := range token.Scopes {
+		if verb == "" || sets.NewString(scope.Verbs...).Has(verb) {
+			respSet.Insert(scope.ProjectName)
+		}
+	}
+	return respSet.List(), nil
+}
+
+// listServiceAccountScopedNames lists the names of the resources in the project that the token is scoped to
+// with any of the given verbs.
+func listServiceAccountScopedNames(uid, projectKey, resource string, verbs ...string) ([]string, error) {
+	token, err := findActiveServiceAccountToken(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	respSet := sets.NewString()
+	for _, scope := range token.Scopes {
+		if scope.ProjectName != projectKey || scope.Resource != resource {
+			continue
+		}
+		if sets.NewString(scope.Verbs...).HasAny(verbs...) {
+			respSet.Insert(scope.Names...)
+		}
+	}
+	return respSet.List(), nil
+}
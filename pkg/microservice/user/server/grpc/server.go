@@ -122,8 +122,24 @@ func (s *AuthServer) Check(ctx context.Context, request *ext_authz_v3.CheckReque
 				return resp, nil
 			}
 
-			// if the expiration time is so huge that it is not possible, it is a constant api token, we don't check for the redis.
-			if claims.ExpiresAt-time.Now().Unix() < 8760*60*60 {
+			if permission.IsServiceAccountUID(claims.UID) {
+				// service account tokens are checked against their record so that they can be revoked at any time.
+				if err := permission.ValidateServiceAccountToken(claims.UID); err != nil {
+					resp.Status = &rpc_status.Status{Code: int32(code.Code_UNAUTHENTICATED)}
+					resp.HttpResponse = &ext_authz_v3.CheckResponse_DeniedResponse{DeniedResponse: &ext_authz_v3.DeniedHttpResponse{
+						Status: &typev3.HttpStatus{Code: http.StatusUnauthorized},
+					}}
+					logger.Info("Request Denied",
+						zap.String("path", requestPath),
+						zap.String("method", method),
+						zap.String("body", body),
+						zap.String("reason", "service account token check failed"),
+						zap.String("error", err.Error()),
+					)
+					return resp, nil
+				}
+			} else if claims.ExpiresAt-time.Now().Unix() < 8760*60*60 {
+				// if the expiration time is so huge that it is not possible, it is a constant api token, we don't check for the redis.
 				// check if the given token is removed from the cache
 				token, err := cache.NewRedisCache(config.RedisUserTokenDB()).GetString(claims.UID)
 				if err != nil {
@@ -55,6 +55,7 @@ func (s *engine) injectMiddlewares() {
 	g.Use(ginmiddleware.OperationLogStatus())
 	g.Use(ginmiddleware.Response())
 	g.Use(ginmiddleware.RequestID())
+	g.Use(ginmiddleware.AuditLog())
 	g.Use(ginmiddleware.RequestLog(log.NewFileLogger(config.RequestLogFile())))
 	g.Use(gin.Recovery())
 }
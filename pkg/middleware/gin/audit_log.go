@@ -17,12 +17,30 @@ limitations under the License.
 package gin
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 
+	systemmodels "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/repository/models"
 	systemservice "github.com/koderover/zadig/v2/pkg/microservice/aslan/core/system/service"
+	"github.com/koderover/zadig/v2/pkg/setting"
+	internalhandler "github.com/koderover/zadig/v2/pkg/shared/handler"
 	"github.com/koderover/zadig/v2/pkg/util/ginzap"
 )
 
+const (
+	auditLogMaxBodySize = 4096
+	auditLogMaskedValue = "******"
+)
+
+var auditLogSensitiveKeys = []string{"password", "secret", "private_key", "privatekey", "access_key", "accesskey", "kubeconfig", "kube_config", "private-key", "credential"}
+
 // OperationLogStatus update status of operation if necessary
 func OperationLogStatus() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -43,3 +61,126 @@ func UpdateOperationLogStatus(c *gin.Context) {
 		log.Errorf("UpdateOperation err:%v", err)
 	}
 }
+
+// AuditLog records every mutating api call made by a user into the audit logs
+func AuditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		// only json bodies are recorded so that the file uploads are not buffered
+		var body []byte
+		if c.Request.Body != nil && strings.Contains(c.GetHeader("Content-Type"), "json") {
+			var buf bytes.Buffer
+			body, _ = io.ReadAll(io.TeeReader(c.Request.Body, &buf))
+			c.Request.Body = io.NopCloser(&buf)
+		}
+
+		c.Next()
+
+		ctx := internalhandler.NewContext(c)
+		// calls without a user are made by the other services or the webhooks
+		if ctx.UserID == "" {
+			return
+		}
+
+		projectName := c.Query("projectName")
+		if projectName == "" {
+			projectName = c.Query("projectKey")
+		}
+		resourceName := ""
+		if len(c.Params) > 0 {
+			resourceName = c.Params[len(c.Params)-1].Value
+		}
+
+		systemservice.RecordAuditLog(&systemmodels.AuditLog{
+			UserID:               ctx.UserID,
+			Username:             ctx.UserName,
+			Account:              ctx.Account,
+			IdentityType:         ctx.IdentityType,
+			ClientIP:             c.ClientIP(),
+			UserAgent:            c.Request.UserAgent(),
+			Method:               c.Request.Method,
+			Route:                c.FullPath(),
+			RequestURI:           c.Request.RequestURI,
+			ProjectName:          projectName,
+			Resource:             auditLogResource(c.FullPath()),
+			ResourceName:         resourceName,
+			StatusCode:           c.Writer.Status(),
+			RequestID:            c.GetString(setting.RequestID),
+			PreviousSettings:     c.GetString(setting.AuditLogPreviousSettings),
+			RequestBody:          summarizeAuditLogBody(body),
+			FreezeOverrideReason: c.GetString(setting.AuditLogFreezeOverrideReason),
+			CreatedAt:            time.Now().Unix(),
+		}, ginzap.WithContext(c).Sugar())
+	}
+}
+
+// auditLogResource returns the module of the api, e.g. environment for /api/environment/environments/:name
+func auditLogResource(route string) string {
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	if len(segments) < 2 {
+		return route
+	}
+	if segments[0] == "api" && segments[1] == "v1" && len(segments) > 2 {
+		return segments[2]
+	}
+	return segments[1]
+}
+
+func summarizeAuditLogBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return fmt.Sprintf("[invalid json body, %d bytes]", len(body))
+	}
+	masked, err := json.Marshal(maskAuditLogData(data))
+	if err != nil {
+		return fmt.Sprintf("[json body, %d bytes]", len(body))
+	}
+	if len(masked) > auditLogMaxBodySize {
+		return string(masked[:auditLogMaxBodySize]) + "...(truncated)"
+	}
+	return string(masked)
+}
+
+func maskAuditLogData(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isAuditLogSensitiveKey(key) {
+				v[key] = auditLogMaskedValue
+				continue
+			}
+			v[key] = maskAuditLogData(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = maskAuditLogData(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isAuditLogSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasSuffix(key, "token") {
+		return true
+	}
+	for _, sensitiveKey := range auditLogSensitiveKeys {
+		if strings.Contains(key, sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
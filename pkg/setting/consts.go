@@ -52,6 +52,11 @@ const (
 	ENVDefaultEnvRecycleDay = "DEFAULT_ENV_RECYCLE_DAY"
 	ENVDefaultIngressClass  = "DEFAULT_INGRESS_CLASS"
 
+	ENVLocalCacheMaxAgeDays = "LOCAL_CACHE_MAX_AGE_DAYS"
+	ENVLocalCacheMaxSizeMB  = "LOCAL_CACHE_MAX_SIZE_MB"
+	ENVEnvLockTimeout       = "ENV_LOCK_TIMEOUT_SECONDS"
+	ENVEnvLockExpiry        = "ENV_LOCK_EXPIRY_SECONDS"
+
 	ENVGithubSSHKey    = "GITHUB_SSH_KEY"
 	ENVGithubKnownHost = "GITHUB_KNOWN_HOST"
 
@@ -148,6 +153,7 @@ const (
 	ReplicaSet            = "ReplicaSet"
 	Job                   = "Job"
 	CronJob               = "CronJob"
+	DaemonSet             = "DaemonSet"
 	ClusterRoleBinding    = "ClusterRoleBinding"
 	ServiceAccount        = "ServiceAccount"
 	ClusterRole           = "ClusterRole"
@@ -180,6 +186,9 @@ const (
 	ModifiedByAnnotation            = companyLabel + "/" + "last-modified-by"
 	EditorIDAnnotation              = companyLabel + "/" + "editor-id"
 	LastUpdateTimeAnnotation        = companyLabel + "/" + "last-update-time"
+	// DashboardLinkAnnotationPrefix is the prefix of the workload annotations holding the dashboard urls of the service,
+	// the rest of the key is the name of the link
+	DashboardLinkAnnotationPrefix = companyLabel + "/" + "dashboard."
 
 	JobLabelTaskKey  = "s-task"
 	JobLabelNameKey  = "s-name"
@@ -289,6 +298,10 @@ const (
 	SourceFromApollo = "apollo"
 	// SourceFromNacos is the configuration_management type of nacos
 	SourceFromNacos = "nacos"
+	// SourceFromLaunchDarkly, SourceFromUnleash and SourceFromFlagsmith are the configuration_management types of the feature flag systems
+	SourceFromLaunchDarkly = "launchdarkly"
+	SourceFromUnleash      = "unleash"
+	SourceFromFlagsmith    = "flagsmith"
 
 	ProdENV = "prod"
 	TestENV = "test"
@@ -482,6 +495,10 @@ const (
 	MeegoHookTaskCreator = "meego_hook"
 	// GeneralHookTaskCreator ...
 	GeneralHookTaskCreator = "general_hook"
+	// ImagePushTaskCreator ...
+	ImagePushTaskCreator = "image_push"
+	// AlertmanagerTaskCreator ...
+	AlertmanagerTaskCreator = "alertmanager"
 	// CronTaskCreator ...
 	CronTaskCreator = "timer"
 	// DefaultTaskRevoker ...
@@ -576,6 +593,7 @@ const (
 	TopicItReport     = "task.it.report"
 	TopicNotification = "task.notification"
 	TopicCronjob      = "cronjob"
+	TopicEnvUpdate    = "env.update"
 )
 
 // S3 related constants
@@ -645,6 +663,12 @@ const ProgressFile = "/var/log/job-progress"
 const (
 	ResponseError = "error"
 	ResponseData  = "response"
+	// AuditLogPreviousSettings is the gin context key of the system settings before the change
+	AuditLogPreviousSettings = "auditLogPreviousSettings"
+	// AuditLogFreezeOverrideReason is the gin context key of the reason overriding the deployment freeze
+	AuditLogFreezeOverrideReason = "auditLogFreezeOverrideReason"
+	// FreezeOverrideReasonHeader is the request header carrying the reason overriding the deployment freeze
+	FreezeOverrideReasonHeader = "X-Freeze-Override-Reason"
 )
 
 const ChartTemplatesPath = "charts"
@@ -938,6 +962,13 @@ const (
 	WorkflowScanningJobOutputKeyBranch  = "SonarBranchKey"
 )
 
+const (
+	BuildJobOutputKeyCacheHits  = "BUILD_CACHE_HITS"
+	BuildJobOutputKeyCacheSteps = "BUILD_CACHE_STEPS"
+	// BuildCacheBuilderName is the buildx builder used to import and export the remote cache
+	BuildCacheBuilderName = "zadig-cache-builder"
+)
+
 type NotifyWebHookType string
 
 const (
@@ -115,6 +115,8 @@ type AdvancedConfig struct {
 	ScheduleWorkflow  bool     `json:"schedule_workflow"        bson:"schedule_workflow"`
 	EnableIRSA        bool     `json:"enable_irsa"              bson:"enable_irsa"`
 	IRSARoleARM       string   `json:"irsa_role_arn"            bson:"irsa_role_arn"`
+	KubeAPIQPS        float32  `json:"kube_api_qps"             bson:"kube_api_qps"`
+	KubeAPIBurst      int      `json:"kube_api_burst"           bson:"kube_api_burst"`
 }
 
 func (c *Client) GetClusterInfo(clusterID string) (*ClusterDetail, error) {
@@ -175,6 +175,40 @@ func GetCollaborationModePermission(uid, projectKey, resource, resourceName, act
 	return user.New().CheckUserAuthInfoForCollaborationMode(uid, projectKey, resource, resourceName, action)
 }
 
+// IsProjectAdmin checks if the user of the context is a system admin or an admin of the project
+func IsProjectAdmin(ctx *Context, projectKey string) bool {
+	if ctx.Resources.IsSystemAdmin {
+		return true
+	}
+	authInfo, ok := ctx.Resources.ProjectAuthInfo[projectKey]
+	return ok && authInfo.IsProjectAdmin
+}
+
+// CheckEnvDebugPermission checks the debug pod permission of the env, granted by the role or the collaboration mode.
+// it is shared by the pod debug and port forward features.
+func CheckEnvDebugPermission(ctx *Context, projectKey, envName string, production bool) bool {
+	if ctx.Resources.IsSystemAdmin {
+		return true
+	}
+	authInfo, ok := ctx.Resources.ProjectAuthInfo[projectKey]
+	if !ok {
+		return false
+	}
+	if authInfo.IsProjectAdmin {
+		return true
+	}
+
+	permitted, action := authInfo.Env.DebugPod, types.EnvActionDebug
+	if production {
+		permitted, action = authInfo.ProductionEnv.DebugPod, types.ProductionEnvActionDebug
+	}
+	if permitted {
+		return true
+	}
+	permitted, err := GetCollaborationModePermission(ctx.UserID, projectKey, types.ResourceTypeEnvironment, envName, action)
+	return err == nil && permitted
+}
+
 func ListAuthorizedProjects(uid string) ([]string, bool, error) {
 	if uid == "" {
 		return []string{}, false, errors.New("empty user ID")
@@ -315,6 +349,28 @@ func InsertDetailedOperationLog(c *gin.Context, username, productName, scene, me
 	c.Set("operationLogID", req.ID.Hex())
 }
 
+// SetAuditLogPreviousSettings attaches the system settings before the change to the audit log of the request
+func SetAuditLogPreviousSettings(c *gin.Context, previous interface{}) {
+	data, err := json.Marshal(previous)
+	if err != nil {
+		return
+	}
+	c.Set(setting.AuditLogPreviousSettings, string(data))
+}
+
+// GetFreezeOverrideReason returns the reason overriding the deployment freeze given by the header or the query
+func GetFreezeOverrideReason(c *gin.Context) string {
+	if reason := c.GetHeader(setting.FreezeOverrideReasonHeader); reason != "" {
+		return reason
+	}
+	return c.Query("freezeOverrideReason")
+}
+
+// SetAuditLogFreezeOverrideReason attaches the reason overriding the deployment freeze to the audit log of the request
+func SetAuditLogFreezeOverrideReason(c *gin.Context, reason string) {
+	c.Set(setting.AuditLogFreezeOverrideReason, reason)
+}
+
 // responseHelper recursively finds all nil slice in the given interface,
 // replacing them with empty slices.
 // Drawbacks of this function is listed below to avoid possible misuse.
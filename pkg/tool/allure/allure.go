@@ -0,0 +1,168 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package allure converts allure results into junit report, so that they can be handled
+// in the same way as the junit test reports.
+package allure
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusBroken  = "broken"
+	StatusSkipped = "skipped"
+
+	resultFileSuffix = "-result.json"
+)
+
+type Label struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type StatusDetails struct {
+	Message string `json:"message"`
+	Trace   string `json:"trace"`
+}
+
+// Result is the test result written by allure adapters as <uuid>-result.json
+type Result struct {
+	Name          string         `json:"name"`
+	FullName      string         `json:"fullName"`
+	Status        string         `json:"status"`
+	StatusDetails *StatusDetails `json:"statusDetails"`
+	Start         int64          `json:"start"`
+	Stop          int64          `json:"stop"`
+	Labels        []*Label       `json:"labels"`
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name         `xml:"testsuite"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ReadResults reads all the allure result files in the given directory
+func ReadResults(dir string) ([]*Result, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read allure results dir %s error: %v", dir, err)
+	}
+
+	results := make([]*Result, 0)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), resultFileSuffix) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read allure result %s error: %v", file.Name(), err)
+		}
+		result := new(Result)
+		if err := json.Unmarshal(content, result); err != nil {
+			return nil, fmt.Errorf("unmarshal allure result %s error: %v", file.Name(), err)
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Start < results[j].Start
+	})
+	return results, nil
+}
+
+// ToJunitXML converts the allure results into a junit test suite, failed tests are reported as
+// failures and broken tests are reported as errors
+func ToJunitXML(suiteName string, results []*Result) ([]byte, error) {
+	suite := &junitTestSuite{
+		Name:  suiteName,
+		Cases: make([]*junitTestCase, 0, len(results)),
+	}
+	for _, result := range results {
+		tc := &junitTestCase{
+			Name:      result.Name,
+			ClassName: result.className(),
+		}
+		if result.Stop > result.Start {
+			tc.Time = float64(result.Stop-result.Start) / 1000
+		}
+		suite.Time += tc.Time
+		suite.Tests++
+
+		message := &junitMessage{Type: result.Status}
+		if result.StatusDetails != nil {
+			message.Message = result.StatusDetails.Message
+			message.Text = result.StatusDetails.Trace
+		}
+		switch result.Status {
+		case StatusFailed:
+			tc.Failure = message
+			suite.Failures++
+		case StatusBroken:
+			tc.Error = message
+			suite.Errors++
+		case StatusSkipped:
+			tc.Skipped = &struct{}{}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	content, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
+
+func (r *Result) className() string {
+	for _, label := range r.Labels {
+		if label.Name == "suite" && label.Value != "" {
+			return label.Value
+		}
+	}
+	if r.FullName != "" {
+		return strings.TrimSuffix(strings.TrimSuffix(r.FullName, r.Name), ".")
+	}
+	return ""
+}
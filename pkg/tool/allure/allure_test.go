@@ -0,0 +1,65 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package allure
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToJunitXML(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a-result.json":    `{"name":"testLogin","fullName":"com.example.AuthTest.testLogin","status":"passed","start":1000,"stop":2500}`,
+		"b-result.json":    `{"name":"testLogout","status":"failed","statusDetails":{"message":"expected 200","trace":"at line 10"},"start":3000,"stop":3100,"labels":[{"name":"suite","value":"AuthSuite"}]}`,
+		"c-result.json":    `{"name":"testCrash","status":"broken","start":4000,"stop":4000}`,
+		"d-result.json":    `{"name":"testTodo","status":"skipped","start":5000,"stop":5000}`,
+		"e-container.json": `{"name":"ignored"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := ReadResults(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+
+	content, err := ToJunitXML("allure", results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suite := new(junitTestSuite)
+	if err := xml.Unmarshal(content, suite); err != nil {
+		t.Fatal(err)
+	}
+	if suite.Tests != 4 || suite.Failures != 1 || suite.Errors != 1 || suite.Skipped != 1 {
+		t.Fatalf("unexpected summary: tests %d, failures %d, errors %d, skipped %d", suite.Tests, suite.Failures, suite.Errors, suite.Skipped)
+	}
+	if suite.Cases[0].ClassName != "com.example.AuthTest" || suite.Cases[0].Time != 1.5 {
+		t.Fatalf("unexpected first case: %+v", suite.Cases[0])
+	}
+	if suite.Cases[1].ClassName != "AuthSuite" || suite.Cases[1].Failure == nil || suite.Cases[1].Failure.Message != "expected 200" {
+		t.Fatalf("unexpected failed case: %+v", suite.Cases[1])
+	}
+}
@@ -200,6 +200,22 @@ func (a *Analysis) RunAnalysis(activeFilters []string) {
 	wg.Wait()
 }
 
+// ScopeResults keeps the results of the objects in the scope only, the objects are in the form of Kind/name.
+// A result is in the scope if the object itself or its parent object is in the scope.
+func (a *Analysis) ScopeResults(objects map[string]bool) {
+	scoped := make([]Result, 0)
+	for _, result := range a.Results {
+		name := result.Name
+		if idx := strings.LastIndex(name, "/"); idx >= 0 {
+			name = name[idx+1:]
+		}
+		if objects[result.Kind+"/"+name] || (result.ParentObject != "" && objects[result.ParentObject]) {
+			scoped = append(scoped, result)
+		}
+	}
+	a.Results = scoped
+}
+
 func (a *Analysis) GetAIResults(anonymize bool) error {
 	if len(a.Results) == 0 {
 		return nil
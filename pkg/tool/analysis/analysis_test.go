@@ -279,3 +279,23 @@ func TestAnalysis_MultipleProblemJsonOutput(t *testing.T) {
 
 	require.Equal(t, got, expected)
 }
+
+func TestAnalysis_ScopeResults(t *testing.T) {
+	analysis := Analysis{
+		Results: []Result{
+			{Kind: "Deployment", Name: "default/web"},
+			{Kind: "Pod", Name: "default/web-5d8f7-x2k9p", ParentObject: "Deployment/web"},
+			{Kind: "Pod", Name: "default/api-6c9d8-n7m2q", ParentObject: "Deployment/api"},
+			{Kind: "Service", Name: "default/web"},
+			{Kind: "Service", Name: "default/api"},
+		},
+	}
+
+	analysis.ScopeResults(map[string]bool{"Deployment/web": true, "Service/web": true})
+
+	require.Equal(t, []Result{
+		{Kind: "Deployment", Name: "default/web"},
+		{Kind: "Pod", Name: "default/web-5d8f7-x2k9p", ParentObject: "Deployment/web"},
+		{Kind: "Service", Name: "default/web"},
+	}, analysis.Results)
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/go-redsync/redsync/v4"
 	goredis_v9 "github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/koderover/zadig/v2/pkg/config"
 	"github.com/koderover/zadig/v2/pkg/tool/log"
@@ -29,6 +30,18 @@ import (
 
 var resync *redsync.Redsync
 
+const lockRetryDelay = time.Millisecond * 500
+
+// LockWaitSeconds is the time spent to acquire the redis locks, labeled by the prefix of the lock key
+var LockWaitSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "redis_lock_wait_seconds",
+		Help:    "Time spent waiting to acquire the redis locks",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+	},
+	[]string{"name", "result"},
+)
+
 func init() {
 	resync = redsync.New(goredis_v9.NewPool(NewRedisCache(config.RedisCommonCacheTokenDB()).redisClient))
 }
@@ -41,26 +54,48 @@ type RedisLock struct {
 func NewRedisLock(key string) *RedisLock {
 	return &RedisLock{
 		key:   key,
-		mutex: resync.NewMutex(key, redsync.WithRetryDelay(time.Millisecond*500)),
+		mutex: resync.NewMutex(key, redsync.WithRetryDelay(lockRetryDelay)),
 	}
 }
 
 func NewRedisLockWithExpiry(key string, expiry time.Duration) *RedisLock {
 	return &RedisLock{
-		mutex: resync.NewMutex(key, redsync.WithRetryDelay(time.Millisecond*500), redsync.WithExpiry(expiry)),
+		key:   key,
+		mutex: resync.NewMutex(key, redsync.WithRetryDelay(lockRetryDelay), redsync.WithExpiry(expiry)),
+	}
+}
+
+// NewRedisLockWithTimeout creates a lock which is released automatically after expiry, and Lock gives up after waiting for timeout
+func NewRedisLockWithTimeout(key string, expiry, timeout time.Duration) *RedisLock {
+	tries := int(timeout/lockRetryDelay) + 1
+	return &RedisLock{
+		key:   key,
+		mutex: resync.NewMutex(key, redsync.WithRetryDelay(lockRetryDelay), redsync.WithExpiry(expiry), redsync.WithTries(tries)),
 	}
 }
 
 func (lock *RedisLock) Lock() error {
+	start := time.Now()
 	err := lock.mutex.Lock()
+	result := "acquired"
 	if err != nil {
+		result = "failed"
 		if !strings.Contains(err.Error(), "lock already taken") {
 			log.Errorf("failed to acquire redis lock: %s, err: %s", lock.key, err)
 		}
 	}
+	LockWaitSeconds.WithLabelValues(lockName(lock.key), result).Observe(time.Since(start).Seconds())
 	return err
 }
 
+// lockName keeps the metrics label bounded by dropping the resource identifiers from the key
+func lockName(key string) string {
+	if idx := strings.Index(key, ":"); idx > 0 {
+		return key[:idx]
+	}
+	return key
+}
+
 func (lock *RedisLock) TryLock() error {
 	err := lock.mutex.TryLock()
 	if err != nil {
@@ -71,6 +106,12 @@ func (lock *RedisLock) TryLock() error {
 	return err
 }
 
+// Extend resets the expiry of the lock held by the caller
+func (lock *RedisLock) Extend() error {
+	_, err := lock.mutex.Extend()
+	return err
+}
+
 func (lock *RedisLock) Unlock() error {
 	_, err := lock.mutex.Unlock()
 	return err
@@ -74,6 +74,11 @@ func (c *RedisCache) SetNX(key, val string, ttl time.Duration) error {
 	return err
 }
 
+// TrySetNX sets the key only if it doesn't exist, it returns false if the key already exists
+func (c *RedisCache) TrySetNX(key, val string, ttl time.Duration) (bool, error) {
+	return c.redisClient.SetNX(context.TODO(), key, val, ttl).Result()
+}
+
 func (c *RedisCache) Exists(key string) (bool, error) {
 	exists, err := c.redisClient.Exists(context.TODO(), key).Result()
 	if err != nil {
@@ -30,12 +30,14 @@ import (
 	"github.com/pkg/errors"
 	istioClient "istio.io/client-go/pkg/clientset/versioned"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/httpstream"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
 	metricsV1Beta1 "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	controllerRuntimeClient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -62,6 +64,7 @@ type KubeClientManager struct {
 	kruiseClientMap             sync.Map
 	metricsClientMap            sync.Map
 	istioClientSetMap           sync.Map
+	rateLimiterMap              sync.Map
 
 	informerStopChanMap sync.Map
 	informerFactoryMap  sync.Map
@@ -112,6 +115,7 @@ func (cm *KubeClientManager) GetKubernetesClientSet(clusterID string) (*kubernet
 		if err != nil {
 			return nil, err
 		}
+		cm.setRateLimiter(clusterID, cfg, nil)
 		cli, err := kubernetes.NewForConfig(cfg)
 		if err == nil {
 			cm.kubernetesClientSetMap.Store(clusterID, cli)
@@ -145,6 +149,7 @@ func (cm *KubeClientManager) GetKubernetesClientSet(clusterID string) (*kubernet
 		return nil, fmt.Errorf("failed to create kubeclient: unknown cluster type: %s", clusterInfo.Type)
 	}
 
+	cm.setRateLimiter(clusterID, cfg, clusterInfo.AdvancedConfig)
 	cli, err := kubernetes.NewForConfig(cfg)
 	if err == nil {
 		cm.kubernetesClientSetMap.Store(clusterID, cli)
@@ -166,6 +171,7 @@ func (cm *KubeClientManager) GetKruiseClient(clusterID string) (kruiseclientset.
 		if err != nil {
 			return nil, err
 		}
+		cm.setRateLimiter(clusterID, cfg, nil)
 		cli, err := kruiseclientset.NewForConfig(cfg)
 		if err == nil {
 			cm.kruiseClientMap.Store(clusterID, cli)
@@ -198,6 +204,7 @@ func (cm *KubeClientManager) GetKruiseClient(clusterID string) (kruiseclientset.
 		return nil, fmt.Errorf("failed to create kruise client: unknown cluster type: %s", clusterInfo.Type)
 	}
 
+	cm.setRateLimiter(clusterID, cfg, clusterInfo.AdvancedConfig)
 	cli, err := kruiseclientset.NewForConfig(cfg)
 	if err == nil {
 		cm.kruiseClientMap.Store(clusterID, cli)
@@ -219,6 +226,7 @@ func (cm *KubeClientManager) GetKubernetesMetricsClient(clusterID string) (*metr
 		if err != nil {
 			return nil, err
 		}
+		cm.setRateLimiter(clusterID, cfg, nil)
 		cli, err := metricsV1Beta1.NewForConfig(cfg)
 		if err == nil {
 			cm.metricsClientMap.Store(clusterID, cli)
@@ -252,6 +260,7 @@ func (cm *KubeClientManager) GetKubernetesMetricsClient(clusterID string) (*metr
 		return nil, fmt.Errorf("failed to create kubeclient: unknown cluster type: %s", clusterInfo.Type)
 	}
 
+	cm.setRateLimiter(clusterID, cfg, clusterInfo.AdvancedConfig)
 	cli, err := metricsV1Beta1.NewForConfig(cfg)
 	if err == nil {
 		cm.metricsClientMap.Store(clusterID, cli)
@@ -273,6 +282,7 @@ func (cm *KubeClientManager) GetIstioClientSet(clusterID string) (*istioClient.C
 		if err != nil {
 			return nil, err
 		}
+		cm.setRateLimiter(clusterID, cfg, nil)
 		cli, err := istioClient.NewForConfig(cfg)
 		if err == nil {
 			cm.istioClientSetMap.Store(clusterID, cli)
@@ -306,6 +316,7 @@ func (cm *KubeClientManager) GetIstioClientSet(clusterID string) (*istioClient.C
 		return nil, fmt.Errorf("failed to create kubeclient: unknown cluster type: %s", clusterInfo.Type)
 	}
 
+	cm.setRateLimiter(clusterID, cfg, clusterInfo.AdvancedConfig)
 	cli, err := istioClient.NewForConfig(cfg)
 	if err == nil {
 		cm.istioClientSetMap.Store(clusterID, cli)
@@ -431,12 +442,31 @@ func (cm *KubeClientManager) GetSPDYExecutor(clusterID string, URL *url.URL) (re
 	return remotecommand.NewSPDYExecutor(cfg, http.MethodPost, URL)
 }
 
+// GetSPDYDialer returns the dialer to the streaming subresources such as pods/portforward, it is not singleton either.
+func (cm *KubeClientManager) GetSPDYDialer(clusterID string, URL *url.URL) (httpstream.Dialer, error) {
+	cfg, err := cm.GetRestConfig(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, URL), nil
+}
+
 // GetRestConfig should not be used by other package. TODO: DELETE THIS FUNCTION AND CHANGE THE CALLING FUNCTION
 func (cm *KubeClientManager) GetRestConfig(clusterID string) (*rest.Config, error) {
 	clusterID = handleClusterID(clusterID)
 
 	if clusterID == setting.LocalClusterID {
-		return rest.InClusterConfig()
+		cfg, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, err
+		}
+		cm.setRateLimiter(clusterID, cfg, nil)
+		return cfg, nil
 	}
 
 	clusterInfo, err := aslanClient.New(config.AslanServiceAddress()).GetClusterInfo(clusterID)
@@ -461,6 +491,7 @@ func (cm *KubeClientManager) GetRestConfig(clusterID string) (*rest.Config, erro
 		return nil, fmt.Errorf("failed to create kubeclient: unknown cluster type: %s", clusterInfo.Type)
 	}
 
+	cm.setRateLimiter(clusterID, cfg, clusterInfo.AdvancedConfig)
 	return cfg, err
 }
 
@@ -473,6 +504,7 @@ func (cm *KubeClientManager) Clear(clusterID string) error {
 	cm.kruiseClientMap.Delete(clusterID)
 	cm.metricsClientMap.Delete(clusterID)
 	cm.istioClientSetMap.Delete(clusterID)
+	cm.rateLimiterMap.Delete(clusterID)
 
 	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{
 		ClusterID: clusterID,
@@ -503,7 +535,9 @@ func (cm *KubeClientManager) getControllerRuntimeCluster(clusterID string) (cont
 	}
 
 	if clusterID == setting.LocalClusterID {
-		controllerClient, err := createControllerRuntimeCluster(ctrl.GetConfigOrDie())
+		cfg := ctrl.GetConfigOrDie()
+		cm.setRateLimiter(clusterID, cfg, nil)
+		controllerClient, err := createControllerRuntimeCluster(cfg)
 		if err == nil {
 			go func() {
 				if err := controllerClient.Start(stopContext); err != nil {
@@ -544,6 +578,7 @@ func (cm *KubeClientManager) getControllerRuntimeCluster(clusterID string) (cont
 		return nil, fmt.Errorf("failed to create kubeclient: unknown cluster type: %s", clusterInfo.Type)
 	}
 
+	cm.setRateLimiter(clusterID, cfg, clusterInfo.AdvancedConfig)
 	controllerClient, err := createControllerRuntimeCluster(cfg)
 	if err == nil {
 		go func() {
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clientmanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+
+	aslanClient "github.com/koderover/zadig/v2/pkg/shared/client/aslan"
+)
+
+const (
+	// the api budget shared by all the clients of a cluster, used if it is not configured in the cluster settings
+	defaultKubeAPIQPS   = 50
+	defaultKubeAPIBurst = 100
+
+	// requests waiting longer than this are counted as throttled
+	throttledThreshold = 10 * time.Millisecond
+)
+
+var (
+	KubeAPIRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kube_api_client_requests_total",
+			Help: "Number of the requests passed the client side rate limiter of the cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	KubeAPIThrottledRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kube_api_client_throttled_requests_total",
+			Help: "Number of the requests delayed by the client side rate limiter of the cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	KubeAPIThrottleSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kube_api_client_throttle_seconds",
+			Help:    "Time the requests waited in the client side rate limiter of the cluster",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30},
+		},
+		[]string{"cluster"},
+	)
+)
+
+// meteredRateLimiter records how long the requests wait for the shared budget of the cluster
+type meteredRateLimiter struct {
+	flowcontrol.RateLimiter
+	clusterID string
+}
+
+func (l *meteredRateLimiter) Accept() {
+	start := time.Now()
+	l.RateLimiter.Accept()
+	l.observe(time.Since(start))
+}
+
+func (l *meteredRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	l.observe(time.Since(start))
+	return err
+}
+
+func (l *meteredRateLimiter) observe(wait time.Duration) {
+	KubeAPIRequests.WithLabelValues(l.clusterID).Inc()
+	if wait < throttledThreshold {
+		return
+	}
+	KubeAPIThrottledRequests.WithLabelValues(l.clusterID).Inc()
+	KubeAPIThrottleSeconds.WithLabelValues(l.clusterID).Observe(wait.Seconds())
+}
+
+// setRateLimiter makes all the clients of the cluster share one rate limiter, so that a large env update
+// won't flood the apiserver no matter how many clients it creates.
+func (cm *KubeClientManager) setRateLimiter(clusterID string, cfg *rest.Config, advancedConfig *aslanClient.AdvancedConfig) {
+	if limiter, ok := cm.rateLimiterMap.Load(clusterID); ok {
+		cfg.RateLimiter = limiter.(flowcontrol.RateLimiter)
+		return
+	}
+
+	qps, burst := float32(defaultKubeAPIQPS), defaultKubeAPIBurst
+	if advancedConfig != nil {
+		if advancedConfig.KubeAPIQPS > 0 {
+			qps = advancedConfig.KubeAPIQPS
+		}
+		if advancedConfig.KubeAPIBurst > 0 {
+			burst = advancedConfig.KubeAPIBurst
+		}
+	}
+
+	limiter, _ := cm.rateLimiterMap.LoadOrStore(clusterID, &meteredRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+		clusterID:   clusterID,
+	})
+	cfg.RateLimiter = limiter.(flowcontrol.RateLimiter)
+}
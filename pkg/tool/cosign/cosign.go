@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosign
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const (
+	// ModeKey signs and verifies the image with a cosign key pair
+	ModeKey = "key"
+	// ModeKeyless signs the image with a short-lived certificate issued by fulcio for the OIDC identity
+	ModeKeyless = "keyless"
+
+	binary = "cosign"
+
+	privateKeyEnv = "COSIGN_PRIVATE_KEY"
+	publicKeyEnv  = "COSIGN_PUBLIC_KEY"
+	passwordEnv   = "COSIGN_PASSWORD"
+)
+
+type SignOption struct {
+	Mode       string
+	PrivateKey string
+	Password   string
+	// IdentityToken is the OIDC token used in keyless mode
+	IdentityToken string
+	// TlogDisabled skips uploading the signature to the rekor transparency log, for offline environments
+	TlogDisabled bool
+}
+
+type VerifyOption struct {
+	Mode      string
+	PublicKey string
+	// CertificateIdentity and CertificateOIDCIssuer are required in keyless mode
+	CertificateIdentity   string
+	CertificateOIDCIssuer string
+	TlogDisabled          bool
+	// Registry is used to pull the signature of the image from the private registry
+	Registry *RegistryAuth
+}
+
+type RegistryAuth struct {
+	Host     string
+	Username string
+	Password string
+}
+
+func signArgs(image string, opt *SignOption) ([]string, []string, error) {
+	args := []string{"sign", "--yes"}
+	envs := make([]string, 0)
+	switch opt.Mode {
+	case ModeKey:
+		if opt.PrivateKey == "" {
+			return nil, nil, fmt.Errorf("private key is required in %s mode", ModeKey)
+		}
+		args = append(args, "--key", "env://"+privateKeyEnv)
+		envs = append(envs, privateKeyEnv+"="+opt.PrivateKey, passwordEnv+"="+opt.Password)
+	case ModeKeyless:
+		if opt.IdentityToken == "" {
+			return nil, nil, fmt.Errorf("identity token is required in %s mode", ModeKeyless)
+		}
+		args = append(args, "--identity-token", opt.IdentityToken)
+	default:
+		return nil, nil, fmt.Errorf("unsupported sign mode: %s", opt.Mode)
+	}
+	if opt.TlogDisabled {
+		args = append(args, "--tlog-upload=false")
+	}
+	return append(args, image), envs, nil
+}
+
+func verifyArgs(image string, opt *VerifyOption) ([]string, []string, error) {
+	args := []string{"verify"}
+	envs := make([]string, 0)
+	switch opt.Mode {
+	case ModeKey:
+		if opt.PublicKey == "" {
+			return nil, nil, fmt.Errorf("public key is required in %s mode", ModeKey)
+		}
+		args = append(args, "--key", "env://"+publicKeyEnv)
+		envs = append(envs, publicKeyEnv+"="+opt.PublicKey)
+	case ModeKeyless:
+		if opt.CertificateIdentity == "" || opt.CertificateOIDCIssuer == "" {
+			return nil, nil, fmt.Errorf("certificate identity and oidc issuer are required in %s mode", ModeKeyless)
+		}
+		args = append(args, "--certificate-identity", opt.CertificateIdentity, "--certificate-oidc-issuer", opt.CertificateOIDCIssuer)
+	default:
+		return nil, nil, fmt.Errorf("unsupported verify mode: %s", opt.Mode)
+	}
+	if opt.TlogDisabled {
+		args = append(args, "--insecure-ignore-tlog=true")
+	}
+	return append(args, image), envs, nil
+}
+
+// Sign signs the image and pushes the signature to the registry of the image,
+// the registry should have been logged in by docker.
+func Sign(image string, opt *SignOption) error {
+	args, envs, err := signArgs(image, opt)
+	if err != nil {
+		return err
+	}
+	return run(args, envs)
+}
+
+// Verify returns error if the image is not signed or the signature is invalid
+func Verify(image string, opt *VerifyOption) error {
+	args, envs, err := verifyArgs(image, opt)
+	if err != nil {
+		return err
+	}
+
+	if opt.Registry != nil && opt.Registry.Username != "" {
+		dir, err := os.MkdirTemp("", "cosign-docker-config")
+		if err != nil {
+			return fmt.Errorf("failed to create docker config dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		if err := writeDockerConfig(dir, opt.Registry); err != nil {
+			return err
+		}
+		envs = append(envs, "DOCKER_CONFIG="+dir)
+	}
+	return run(args, envs)
+}
+
+func writeDockerConfig(dir string, registry *RegistryAuth) error {
+	auth := base64.StdEncoding.EncodeToString([]byte(registry.Username + ":" + registry.Password))
+	config := map[string]interface{}{
+		"auths": map[string]interface{}{
+			registry.Host: map[string]string{"auth": auth},
+		},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "config.json"), data, 0600)
+}
+
+func run(args, envs []string) error {
+	if _, err := exec.LookPath(binary); err != nil {
+		return fmt.Errorf("%s is not installed: %v", binary, err)
+	}
+	cmd := exec.Command(binary, args...)
+	cmd.Env = append(os.Environ(), envs...)
+	out := bytes.Buffer{}
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s failed: %v, output: %s", binary, args[0], err, out.String())
+	}
+	return nil
+}
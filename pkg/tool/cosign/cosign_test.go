@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosign
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignArgs(t *testing.T) {
+	args, envs, err := signArgs("reg.io/app:v1", &SignOption{Mode: ModeKey, PrivateKey: "key", Password: "pwd", TlogDisabled: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sign", "--yes", "--key", "env://COSIGN_PRIVATE_KEY", "--tlog-upload=false", "reg.io/app:v1"}, args)
+	assert.Equal(t, []string{"COSIGN_PRIVATE_KEY=key", "COSIGN_PASSWORD=pwd"}, envs)
+
+	args, envs, err = signArgs("reg.io/app:v1", &SignOption{Mode: ModeKeyless, IdentityToken: "token"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sign", "--yes", "--identity-token", "token", "reg.io/app:v1"}, args)
+	assert.Empty(t, envs)
+
+	_, _, err = signArgs("reg.io/app:v1", &SignOption{Mode: ModeKey})
+	assert.Error(t, err)
+	_, _, err = signArgs("reg.io/app:v1", &SignOption{Mode: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestVerifyArgs(t *testing.T) {
+	args, envs, err := verifyArgs("reg.io/app:v1", &VerifyOption{Mode: ModeKey, PublicKey: "pub"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"verify", "--key", "env://COSIGN_PUBLIC_KEY", "reg.io/app:v1"}, args)
+	assert.Equal(t, []string{"COSIGN_PUBLIC_KEY=pub"}, envs)
+
+	args, _, err = verifyArgs("reg.io/app:v1", &VerifyOption{Mode: ModeKeyless, CertificateIdentity: "ci@example.com", CertificateOIDCIssuer: "https://issuer", TlogDisabled: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"verify", "--certificate-identity", "ci@example.com", "--certificate-oidc-issuer", "https://issuer", "--insecure-ignore-tlog=true", "reg.io/app:v1"}, args)
+
+	_, _, err = verifyArgs("reg.io/app:v1", &VerifyOption{Mode: ModeKeyless, CertificateIdentity: "ci@example.com"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package coverage parses the lcov and cobertura coverage reports into a summary.
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatLcov      Format = "lcov"
+	FormatCobertura Format = "cobertura"
+)
+
+type Summary struct {
+	LinesCovered    int64   `bson:"lines_covered"     json:"lines_covered"     yaml:"lines_covered"`
+	LinesValid      int64   `bson:"lines_valid"       json:"lines_valid"       yaml:"lines_valid"`
+	BranchesCovered int64   `bson:"branches_covered"  json:"branches_covered"  yaml:"branches_covered"`
+	BranchesValid   int64   `bson:"branches_valid"    json:"branches_valid"    yaml:"branches_valid"`
+	LineRate        float64 `bson:"line_rate"         json:"line_rate"         yaml:"line_rate"`
+	BranchRate      float64 `bson:"branch_rate"       json:"branch_rate"       yaml:"branch_rate"`
+}
+
+// ParseFile parses the coverage report file in the given format
+func ParseFile(path string, format Format) (*Summary, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read coverage report %s error: %v", path, err)
+	}
+	return Parse(content, format)
+}
+
+func Parse(content []byte, format Format) (*Summary, error) {
+	switch format {
+	case FormatLcov, "":
+		return parseLcov(content)
+	case FormatCobertura:
+		return parseCobertura(content)
+	default:
+		return nil, fmt.Errorf("unsupported coverage format: %s", format)
+	}
+}
+
+// parseLcov sums up the LF/LH/BRF/BRH records of all the source files, the DA/BRDA records
+// are counted instead if a source file has no summary records
+func parseLcov(content []byte) (*Summary, error) {
+	summary := &Summary{}
+	var lf, lh, brf, brh, da, daHit, brda, brdaHit int64
+	var hasLF, hasBRF bool
+
+	flush := func() {
+		if hasLF {
+			summary.LinesValid += lf
+			summary.LinesCovered += lh
+		} else {
+			summary.LinesValid += da
+			summary.LinesCovered += daHit
+		}
+		if hasBRF {
+			summary.BranchesValid += brf
+			summary.BranchesCovered += brh
+		} else {
+			summary.BranchesValid += brda
+			summary.BranchesCovered += brdaHit
+		}
+		lf, lh, brf, brh, da, daHit, brda, brdaHit = 0, 0, 0, 0, 0, 0, 0, 0
+		hasLF, hasBRF = false, false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			if line == "end_of_record" {
+				flush()
+			}
+			continue
+		}
+		switch key {
+		case "LF":
+			lf, hasLF = parseInt(value), true
+		case "LH":
+			lh = parseInt(value)
+		case "BRF":
+			brf, hasBRF = parseInt(value), true
+		case "BRH":
+			brh = parseInt(value)
+		case "DA":
+			// DA:<line number>,<execution count>[,<checksum>]
+			fields := strings.Split(value, ",")
+			if len(fields) >= 2 {
+				da++
+				if parseInt(fields[1]) > 0 {
+					daHit++
+				}
+			}
+		case "BRDA":
+			// BRDA:<line number>,<block number>,<branch number>,<taken>
+			fields := strings.Split(value, ",")
+			if len(fields) == 4 {
+				brda++
+				if fields[3] != "-" && parseInt(fields[3]) > 0 {
+					brdaHit++
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read lcov report error: %v", err)
+	}
+	flush()
+
+	summary.calculateRates()
+	return summary, nil
+}
+
+type coberturaReport struct {
+	XMLName         xml.Name `xml:"coverage"`
+	LineRate        float64  `xml:"line-rate,attr"`
+	BranchRate      float64  `xml:"branch-rate,attr"`
+	LinesCovered    int64    `xml:"lines-covered,attr"`
+	LinesValid      int64    `xml:"lines-valid,attr"`
+	BranchesCovered int64    `xml:"branches-covered,attr"`
+	BranchesValid   int64    `xml:"branches-valid,attr"`
+}
+
+func parseCobertura(content []byte) (*Summary, error) {
+	report := new(coberturaReport)
+	if err := xml.Unmarshal(content, report); err != nil {
+		return nil, fmt.Errorf("unmarshal cobertura report error: %v", err)
+	}
+	summary := &Summary{
+		LinesCovered:    report.LinesCovered,
+		LinesValid:      report.LinesValid,
+		BranchesCovered: report.BranchesCovered,
+		BranchesValid:   report.BranchesValid,
+	}
+	summary.calculateRates()
+	// some generators only report the rates
+	if summary.LinesValid == 0 {
+		summary.LineRate = round(report.LineRate * 100)
+	}
+	if summary.BranchesValid == 0 {
+		summary.BranchRate = round(report.BranchRate * 100)
+	}
+	return summary, nil
+}
+
+// calculateRates calculates the coverage rates in percentage
+func (s *Summary) calculateRates() {
+	if s.LinesValid > 0 {
+		s.LineRate = round(float64(s.LinesCovered) / float64(s.LinesValid) * 100)
+	}
+	if s.BranchesValid > 0 {
+		s.BranchRate = round(float64(s.BranchesCovered) / float64(s.BranchesValid) * 100)
+	}
+}
+
+func parseInt(s string) int64 {
+	i, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return i
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package coverage
+
+import (
+	"testing"
+)
+
+func TestParseLcov(t *testing.T) {
+	content := `TN:
+SF:/src/a.go
+DA:1,1
+DA:2,0
+LF:2
+LH:1
+BRF:2
+BRH:1
+end_of_record
+SF:/src/b.go
+DA:1,3
+DA:2,1
+DA:3,0
+BRDA:3,0,0,1
+BRDA:3,0,1,-
+end_of_record
+`
+	summary, err := Parse([]byte(content), FormatLcov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.LinesValid != 5 || summary.LinesCovered != 3 {
+		t.Fatalf("unexpected lines: %d/%d", summary.LinesCovered, summary.LinesValid)
+	}
+	if summary.BranchesValid != 4 || summary.BranchesCovered != 2 {
+		t.Fatalf("unexpected branches: %d/%d", summary.BranchesCovered, summary.BranchesValid)
+	}
+	if summary.LineRate != 60 || summary.BranchRate != 50 {
+		t.Fatalf("unexpected rates: %v %v", summary.LineRate, summary.BranchRate)
+	}
+}
+
+func TestParseCobertura(t *testing.T) {
+	content := `<?xml version="1.0" ?>
+<coverage line-rate="0.8" branch-rate="0.5" lines-covered="80" lines-valid="100" branches-covered="0" branches-valid="0" version="1.9">
+  <packages/>
+</coverage>`
+	summary, err := Parse([]byte(content), FormatCobertura)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.LineRate != 80 || summary.LinesCovered != 80 {
+		t.Fatalf("unexpected line coverage: %+v", summary)
+	}
+	if summary.BranchRate != 50 {
+		t.Fatalf("unexpected branch rate: %v", summary.BranchRate)
+	}
+
+	if _, err := Parse([]byte(content), "jacoco"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
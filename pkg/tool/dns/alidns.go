@@ -0,0 +1,147 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
+	util "github.com/alibabacloud-go/tea-utils/v2/service"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+const aliDNSEndpoint = "alidns.aliyuncs.com"
+
+type aliDNSClient struct {
+	domain string
+	client *openapi.Client
+}
+
+type aliDNSRecords struct {
+	DomainRecords struct {
+		Record []struct {
+			RecordId string `json:"RecordId"`
+			RR       string `json:"RR"`
+			Type     string `json:"Type"`
+			Value    string `json:"Value"`
+		} `json:"Record"`
+	} `json:"DomainRecords"`
+}
+
+func newAliDNSClient(cfg *Config) (*aliDNSClient, error) {
+	client, err := openapi.NewClient(&openapi.Config{
+		AccessKeyId:     tea.String(cfg.AccessKeyID),
+		AccessKeySecret: tea.String(cfg.AccessKeySecret),
+		Endpoint:        tea.String(aliDNSEndpoint),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alidns client, err: %w", err)
+	}
+	return &aliDNSClient{domain: strings.TrimSuffix(cfg.Zone, "."), client: client}, nil
+}
+
+func (c *aliDNSClient) UpsertRecord(ctx context.Context, record *Record) error {
+	record = normalizeRecord(record)
+	rr, err := c.rr(record.Name)
+	if err != nil {
+		return err
+	}
+
+	existed := new(aliDNSRecords)
+	err = c.call("DescribeSubDomainRecords", map[string]string{
+		"SubDomain": record.Name,
+		"Type":      record.Type,
+	}, existed)
+	if err != nil {
+		return err
+	}
+
+	query := map[string]string{
+		"RR":    rr,
+		"Type":  record.Type,
+		"Value": record.Target,
+		"TTL":   strconv.FormatInt(record.TTL, 10),
+	}
+	for _, r := range existed.DomainRecords.Record {
+		if r.Value == record.Target {
+			return nil
+		}
+		query["RecordId"] = r.RecordId
+		return c.call("UpdateDomainRecord", query, nil)
+	}
+	query["DomainName"] = c.domain
+	return c.call("AddDomainRecord", query, nil)
+}
+
+func (c *aliDNSClient) DeleteRecord(ctx context.Context, record *Record) error {
+	record = normalizeRecord(record)
+	rr, err := c.rr(record.Name)
+	if err != nil {
+		return err
+	}
+	return c.call("DeleteSubDomainRecords", map[string]string{
+		"DomainName": c.domain,
+		"RR":         rr,
+		"Type":       record.Type,
+	}, nil)
+}
+
+// rr returns the host record of the hostname in the domain, e.g. `a.b` for `a.b.example.com`
+func (c *aliDNSClient) rr(hostname string) (string, error) {
+	if hostname == c.domain {
+		return "@", nil
+	}
+	if !strings.HasSuffix(hostname, "."+c.domain) {
+		return "", fmt.Errorf("hostname %s is not in domain %s", hostname, c.domain)
+	}
+	return strings.TrimSuffix(hostname, "."+c.domain), nil
+}
+
+func (c *aliDNSClient) call(action string, query map[string]string, resp interface{}) error {
+	params := &openapi.Params{
+		Action:      tea.String(action),
+		Version:     tea.String("2015-01-09"),
+		Protocol:    tea.String("HTTPS"),
+		Pathname:    tea.String("/"),
+		Method:      tea.String("POST"),
+		AuthType:    tea.String("AK"),
+		Style:       tea.String("RPC"),
+		ReqBodyType: tea.String("json"),
+		BodyType:    tea.String("json"),
+	}
+	request := &openapi.OpenApiRequest{Query: make(map[string]*string)}
+	for k, v := range query {
+		request.Query[k] = tea.String(v)
+	}
+
+	result, err := c.client.CallApi(params, request, &util.RuntimeOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to call alidns %s, err: %w", action, err)
+	}
+	if resp == nil {
+		return nil
+	}
+	body, err := json.Marshal(result["body"])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, resp)
+}
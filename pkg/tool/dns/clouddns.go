@@ -0,0 +1,160 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imroc/req/v3"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	cloudDNSBaseURL = "https://dns.googleapis.com/dns/v1"
+	cloudDNSScope   = "https://www.googleapis.com/auth/ndev.clouddns.readwrite"
+)
+
+type cloudDNSClient struct {
+	projectID   string
+	zone        string
+	tokenSource oauth2.TokenSource
+}
+
+type cloudDNSRecordSet struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	TTL     int64    `json:"ttl"`
+	RRDatas []string `json:"rrdatas"`
+}
+
+type cloudDNSChange struct {
+	Additions []*cloudDNSRecordSet `json:"additions,omitempty"`
+	Deletions []*cloudDNSRecordSet `json:"deletions,omitempty"`
+}
+
+func newCloudDNSClient(cfg *Config) (*cloudDNSClient, error) {
+	creds, err := google.CredentialsFromJSON(context.Background(), []byte(cfg.Credentials), cloudDNSScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account credentials, err: %w", err)
+	}
+	projectID := cfg.ProjectID
+	if projectID == "" {
+		projectID = creds.ProjectID
+	}
+	return &cloudDNSClient{projectID: projectID, zone: cfg.Zone, tokenSource: creds.TokenSource}, nil
+}
+
+func (c *cloudDNSClient) UpsertRecord(ctx context.Context, record *Record) error {
+	record = normalizeRecord(record)
+	existed, err := c.getRecordSet(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	change := &cloudDNSChange{
+		Additions: []*cloudDNSRecordSet{{
+			Name:    record.Name + ".",
+			Type:    record.Type,
+			TTL:     record.TTL,
+			RRDatas: []string{cloudDNSRRData(record)},
+		}},
+	}
+	if existed != nil {
+		if len(existed.RRDatas) == 1 && existed.RRDatas[0] == cloudDNSRRData(record) && existed.TTL == record.TTL {
+			return nil
+		}
+		change.Deletions = []*cloudDNSRecordSet{existed}
+	}
+	return c.change(ctx, change)
+}
+
+func (c *cloudDNSClient) DeleteRecord(ctx context.Context, record *Record) error {
+	record = normalizeRecord(record)
+	existed, err := c.getRecordSet(ctx, record)
+	if err != nil || existed == nil {
+		return err
+	}
+	return c.change(ctx, &cloudDNSChange{Deletions: []*cloudDNSRecordSet{existed}})
+}
+
+func (c *cloudDNSClient) getRecordSet(ctx context.Context, record *Record) (*cloudDNSRecordSet, error) {
+	client, err := c.client()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &struct {
+		RRSets []*cloudDNSRecordSet `json:"rrsets"`
+	}{}
+	_, err = client.R().SetContext(ctx).
+		SetQueryParam("name", record.Name+".").
+		SetQueryParam("type", record.Type).
+		SetSuccessResult(resp).
+		Get(fmt.Sprintf("/projects/%s/managedZones/%s/rrsets", c.projectID, c.zone))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get record set of %s, err: %w", record.Name, err)
+	}
+	if len(resp.RRSets) == 0 {
+		return nil, nil
+	}
+	return resp.RRSets[0], nil
+}
+
+func (c *cloudDNSClient) change(ctx context.Context, change *cloudDNSChange) error {
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.R().SetContext(ctx).
+		SetBody(change).
+		Post(fmt.Sprintf("/projects/%s/managedZones/%s/changes", c.projectID, c.zone))
+	if err != nil {
+		return fmt.Errorf("failed to change record sets, err: %w", err)
+	}
+	return nil
+}
+
+func (c *cloudDNSClient) client() (*req.Client, error) {
+	token, err := c.tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gcp access token, err: %w", err)
+	}
+	return req.C().
+		SetBaseURL(cloudDNSBaseURL).
+		SetCommonBearerAuthToken(token.AccessToken).
+		SetCommonContentType("application/json").
+		OnAfterResponse(func(client *req.Client, resp *req.Response) error {
+			if resp.Err != nil {
+				return nil
+			}
+			if !resp.IsSuccessState() {
+				resp.Err = fmt.Errorf("unexpected status code %d, body: %s", resp.GetStatusCode(), resp.String())
+			}
+			return nil
+		}), nil
+}
+
+// cloudDNSRRData returns the record data, the target of CNAME must be fully qualified
+func cloudDNSRRData(record *Record) string {
+	if record.Type == RecordTypeCNAME && record.Target != "" && record.Target[len(record.Target)-1] != '.' {
+		return record.Target + "."
+	}
+	return record.Target
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type Provider string
+
+const (
+	ProviderRoute53  Provider = "route53"
+	ProviderCloudDNS Provider = "clouddns"
+	ProviderAliDNS   Provider = "alidns"
+)
+
+const (
+	RecordTypeA     = "A"
+	RecordTypeCNAME = "CNAME"
+
+	defaultTTL = 300
+)
+
+type Record struct {
+	// Name is the full hostname of the record without the trailing dot
+	Name   string
+	Type   string
+	Target string
+	TTL    int64
+}
+
+type Config struct {
+	Provider Provider
+	// Zone is the hosted zone ID for route53, the managed zone name for clouddns and the domain name for alidns
+	Zone            string
+	AccessKeyID     string
+	AccessKeySecret string
+	Region          string
+	// ProjectID and Credentials are the GCP project and the service account key in json, only used by clouddns
+	ProjectID   string
+	Credentials string
+}
+
+// IDNSClient manages the records in a zone of the dns provider, both methods are idempotent
+type IDNSClient interface {
+	UpsertRecord(ctx context.Context, record *Record) error
+	DeleteRecord(ctx context.Context, record *Record) error
+}
+
+func NewClient(cfg *Config) (IDNSClient, error) {
+	if cfg.Zone == "" {
+		return nil, fmt.Errorf("zone can not be empty")
+	}
+
+	switch cfg.Provider {
+	case ProviderRoute53:
+		return newRoute53Client(cfg)
+	case ProviderCloudDNS:
+		return newCloudDNSClient(cfg)
+	case ProviderAliDNS:
+		return newAliDNSClient(cfg)
+	default:
+		return nil, fmt.Errorf("dns provider %s not supported", cfg.Provider)
+	}
+}
+
+func normalizeRecord(record *Record) *Record {
+	resp := *record
+	resp.Name = strings.TrimSuffix(strings.ToLower(record.Name), ".")
+	if resp.Type == "" {
+		resp.Type = RecordTypeCNAME
+	}
+	if resp.TTL <= 0 {
+		resp.TTL = defaultTTL
+	}
+	return &resp
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"testing"
+)
+
+func TestNormalizeRecord(t *testing.T) {
+	record := normalizeRecord(&Record{Name: "Svc-Dev.Example.com.", Target: "lb.example.com"})
+	if record.Name != "svc-dev.example.com" || record.Type != RecordTypeCNAME || record.TTL != defaultTTL {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+}
+
+func TestAliDNSRR(t *testing.T) {
+	c := &aliDNSClient{domain: "example.com"}
+	cases := map[string]string{
+		"example.com":         "@",
+		"svc.example.com":     "svc",
+		"a.svc.example.com":   "a.svc",
+		"svc-dev.example.com": "svc-dev",
+	}
+	for hostname, expected := range cases {
+		rr, err := c.rr(hostname)
+		if err != nil || rr != expected {
+			t.Errorf("rr of %s: expected %s, got %s, err: %v", hostname, expected, rr, err)
+		}
+	}
+	if _, err := c.rr("svc.example.org"); err == nil {
+		t.Error("expected error for hostname out of the domain")
+	}
+}
+
+func TestCloudDNSRRData(t *testing.T) {
+	if data := cloudDNSRRData(&Record{Type: RecordTypeCNAME, Target: "lb.example.com"}); data != "lb.example.com." {
+		t.Errorf("unexpected CNAME data: %s", data)
+	}
+	if data := cloudDNSRRData(&Record{Type: RecordTypeA, Target: "1.2.3.4"}); data != "1.2.3.4" {
+		t.Errorf("unexpected A data: %s", data)
+	}
+}
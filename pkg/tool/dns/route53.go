@@ -0,0 +1,103 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+type route53Client struct {
+	zoneID string
+	svc    *route53.Route53
+}
+
+func newRoute53Client(cfg *Config) (*route53Client, error) {
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.AccessKeySecret, ""),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session, err: %w", err)
+	}
+	return &route53Client{zoneID: cfg.Zone, svc: route53.New(sess)}, nil
+}
+
+func (c *route53Client) UpsertRecord(ctx context.Context, record *Record) error {
+	return c.change(ctx, route53.ChangeActionUpsert, normalizeRecord(record))
+}
+
+func (c *route53Client) DeleteRecord(ctx context.Context, record *Record) error {
+	record = normalizeRecord(record)
+	resp, err := c.svc.ListResourceRecordSetsWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(c.zoneID),
+		StartRecordName: aws.String(record.Name),
+		StartRecordType: aws.String(record.Type),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list record sets of %s, err: %w", record.Name, err)
+	}
+	// the record set must be deleted with exactly the same values, the existed one is used
+	for _, set := range resp.ResourceRecordSets {
+		if aws.StringValue(set.Name) != record.Name+"." || aws.StringValue(set.Type) != record.Type {
+			continue
+		}
+		_, err := c.svc.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: aws.String(c.zoneID),
+			ChangeBatch: &route53.ChangeBatch{
+				Changes: []*route53.Change{{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: set}},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete record %s, err: %w", record.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *route53Client) change(ctx context.Context, action string, record *Record) error {
+	_, err := c.svc.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(c.zoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(action),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name:            aws.String(record.Name),
+						Type:            aws.String(record.Type),
+						TTL:             aws.Int64(record.TTL),
+						ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(record.Target)}},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to %s record %s, err: %w", action, record.Name, err)
+	}
+	return nil
+}
@@ -29,6 +29,10 @@ var (
 	ErrForbidden = NewHTTPError(403, "Forbidden")
 	// ErrNotFound ...
 	ErrNotFound = NewHTTPError(404, "Request Not Found")
+	// ErrConflict ...
+	ErrConflict = NewHTTPError(409, "Conflict")
+	// ErrPreconditionRequired ...
+	ErrPreconditionRequired = NewHTTPError(428, "Precondition Required")
 	// ErrInternalError ...
 	ErrInternalError = NewHTTPError(500, "Internal Error")
 
@@ -130,6 +134,10 @@ var (
 	ErrDeleteProductTempl = NewHTTPError(6079, "项目删除检查失败，因为存在正在使用的环境!")
 	// ErrGetProduct ...
 	ErrGetProduct = NewHTTPError(6073, "获取项目失败")
+	// ErrExportProject ...
+	ErrExportProject = NewHTTPError(7262, "导出项目失败")
+	// ErrImportProject ...
+	ErrImportProject = NewHTTPError(7263, "导入项目失败")
 	// ErrListActiveProducts ...
 	ErrListActiveProducts = NewHTTPError(6064, "列出创建,更新,删除中项目失败")
 	// ErrListGroups ...
@@ -234,6 +242,7 @@ var (
 	ErrListSAEApps              = NewHTTPError(6155, "列出SAE应用失败")
 	ErrAddSAEAppToEnv           = NewHTTPError(6156, "添加SAE应用到环境失败")
 	ErrDelSAEAppFromEnv         = NewHTTPError(6156, "从环境删除SAE应用失败")
+	ErrDeleteSvcHasDependents   = NewHTTPError(6157, "删除服务失败，待删除服务被环境中的其他服务依赖")
 
 	//-----------------------------------------------------------------------------------------------
 	// it report APIs Range: 6100 - 6149
@@ -418,6 +427,10 @@ var (
 	ErrBuildJobContainerLogs = NewHTTPError(6261, "查询编译容器日志失败")
 	// ErrTestJobContainerLogs ...
 	ErrTestJobContainerLogs = NewHTTPError(6262, "查询测试容器日志失败")
+	// ErrDownloadLogs ...
+	ErrDownloadLogs = NewHTTPError(6263, "下载日志失败")
+	// ErrDiagnoseJob ...
+	ErrDiagnoseJob = NewHTTPError(6264, "AI诊断任务失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// Registry APIs Range: 6280 - 6299
@@ -426,6 +439,8 @@ var (
 	// ErrListImages ...
 	ErrListImages   = NewHTTPError(6280, "列出镜像失败")
 	ErrFindRegistry = NewHTTPError(6281, "找不到指定的镜像仓库")
+	// ErrRotateRegistryCredential ...
+	ErrRotateRegistryCredential = NewHTTPError(6282, "轮换镜像仓库凭证失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// Insghts APIs Range: 6300 - 6399
@@ -532,6 +547,10 @@ var (
 	ErrFilterWorkflowVars = NewHTTPError(6544, "过滤workflow服务变量失败")
 	// ErrFindWorkflow ...
 	ErrPresetWorkflow = NewHTTPError(6545, "预配置workflow失败")
+	// ErrSyncWorkflowCode ...
+	ErrSyncWorkflowCode = NewHTTPError(6546, "从代码库同步workflow失败")
+	// ErrExportWorkflowCode ...
+	ErrExportWorkflowCode = NewHTTPError(6547, "导出workflow到代码库失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// Directory APIs Range: 6550 - 6560
@@ -795,12 +814,14 @@ var (
 	//-----------------------------------------------------------------------------------------------
 	// workflow template releated errors: 6910-6919
 	//-----------------------------------------------------------------------------------------------
-	ErrCreateWorkflowTemplate = NewHTTPError(6910, "创建工作流模板失败")
-	ErrUpdateWorkflowTemplate = NewHTTPError(6911, "更新工作流模板失败")
-	ErrListWorkflowTemplate   = NewHTTPError(6912, "列出工作流模板失败")
-	ErrGetWorkflowTemplate    = NewHTTPError(6913, "获取工作流模板失败")
-	ErrDeleteWorkflowTemplate = NewHTTPError(6914, "删除工作流模板失败")
-	ErrLintWorkflowTemplate   = NewHTTPError(6915, "检查工作流模板失败")
+	ErrCreateWorkflowTemplate      = NewHTTPError(6910, "创建工作流模板失败")
+	ErrUpdateWorkflowTemplate      = NewHTTPError(6911, "更新工作流模板失败")
+	ErrListWorkflowTemplate        = NewHTTPError(6912, "列出工作流模板失败")
+	ErrGetWorkflowTemplate         = NewHTTPError(6913, "获取工作流模板失败")
+	ErrDeleteWorkflowTemplate      = NewHTTPError(6914, "删除工作流模板失败")
+	ErrLintWorkflowTemplate        = NewHTTPError(6915, "检查工作流模板失败")
+	ErrImportWorkflowTemplate      = NewHTTPError(6916, "导入工作流模板失败")
+	ErrInstantiateWorkflowTemplate = NewHTTPError(6917, "通过工作流模板创建工作流失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// configuration management releated errors: 6920-6929
@@ -888,6 +909,9 @@ var (
 	ErrUpdateLLMIntegration = NewHTTPError(7012, "更新llm集成失败")
 	ErrDeleteLLMIntegration = NewHTTPError(7013, "删除llm集成失败")
 	ErrGetLLMIntegration    = NewHTTPError(7014, "获取llm集成详情失败")
+	ErrGetLLMUsage          = NewHTTPError(7015, "获取llm用量统计失败")
+	ErrListLLMBudget        = NewHTTPError(7016, "获取llm预算列表失败")
+	ErrSetLLMBudget         = NewHTTPError(7017, "设置llm预算失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// observability integration Error Range: 7020 - 7029
@@ -932,6 +956,9 @@ var (
 	ErrSetIstioGrayscaleConfig          = NewHTTPError(7064, "设置Istio灰度失败")
 	ErrGetIstioGrayscalePortalService   = NewHTTPError(7065, "获取Istio灰度入口服务配置失败")
 	ErrSetupIstioGrayscalePortalService = NewHTTPError(7066, "设置Istio灰度入口服务失败")
+	ErrListIstioRouting                 = NewHTTPError(7067, "获取Istio路由配置失败")
+	ErrApplyIstioRouting                = NewHTTPError(7068, "更新Istio路由配置失败")
+	ErrDeleteIstioRouting               = NewHTTPError(7069, "删除Istio路由配置失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// release plan template releated errors: 7070 - 7079
@@ -974,4 +1001,197 @@ var (
 	//-----------------------------------------------------------------------------------------------
 	ErrCreateApprovalTicket = NewHTTPError(7100, "创建预审批单失败")
 	ErrListApprovalTicket   = NewHTTPError(7101, "列出预审批单失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// image push trigger releated errors: 7120 - 7139
+	//-----------------------------------------------------------------------------------------------
+	ErrListImagePushTrigger   = NewHTTPError(7120, "列出镜像推送触发器失败")
+	ErrCreateImagePushTrigger = NewHTTPError(7121, "创建镜像推送触发器失败")
+	ErrUpdateImagePushTrigger = NewHTTPError(7122, "更新镜像推送触发器失败")
+	ErrDeleteImagePushTrigger = NewHTTPError(7123, "删除镜像推送触发器失败")
+	ErrHandleImagePushEvent   = NewHTTPError(7124, "处理镜像推送事件失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env promotion releated errors: 7140 - 7159
+	//-----------------------------------------------------------------------------------------------
+	ErrPreviewEnvPromotion  = NewHTTPError(7140, "预览环境版本晋级失败")
+	ErrCreateEnvPromotion   = NewHTTPError(7141, "创建环境版本晋级失败")
+	ErrListEnvPromotion     = NewHTTPError(7142, "列出环境版本晋级失败")
+	ErrGetEnvPromotion      = NewHTTPError(7143, "获取环境版本晋级失败")
+	ErrApproveEnvPromotion  = NewHTTPError(7144, "审批环境版本晋级失败")
+	ErrRollbackEnvPromotion = NewHTTPError(7145, "回滚环境版本晋级失败")
+	ErrGetEnvChangelog      = NewHTTPError(7146, "获取环境变更日志失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// image signing releated errors: 7160 - 7179
+	//-----------------------------------------------------------------------------------------------
+	ErrListImageSigningKey      = NewHTTPError(7160, "列出镜像签名密钥失败")
+	ErrCreateImageSigningKey    = NewHTTPError(7161, "创建镜像签名密钥失败")
+	ErrUpdateImageSigningKey    = NewHTTPError(7162, "更新镜像签名密钥失败")
+	ErrDeleteImageSigningKey    = NewHTTPError(7163, "删除镜像签名密钥失败")
+	ErrVerifyImageSignature     = NewHTTPError(7164, "镜像签名校验失败")
+	ErrSetEnvImageSigningPolicy = NewHTTPError(7165, "设置环境镜像签名校验失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// port forward releated errors: 7180 - 7189
+	//-----------------------------------------------------------------------------------------------
+	ErrCreatePortForward  = NewHTTPError(7180, "创建端口转发失败")
+	ErrListPortForward    = NewHTTPError(7181, "列出端口转发失败")
+	ErrClosePortForward   = NewHTTPError(7182, "关闭端口转发失败")
+	ErrConnectPortForward = NewHTTPError(7183, "连接端口转发失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env service troubleshooting releated errors: 7190 - 7199
+	//-----------------------------------------------------------------------------------------------
+	ErrGetServiceTimeline = NewHTTPError(7190, "获取服务事件时间线失败")
+	ErrSearchEnvLogs      = NewHTTPError(7191, "搜索环境日志失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// dns releated errors: 7200 - 7209
+	//-----------------------------------------------------------------------------------------------
+	ErrListDNSIntegration   = NewHTTPError(7200, "获取DNS集成列表失败")
+	ErrCreateDNSIntegration = NewHTTPError(7201, "创建DNS集成失败")
+	ErrUpdateDNSIntegration = NewHTTPError(7202, "更新DNS集成失败")
+	ErrDeleteDNSIntegration = NewHTTPError(7203, "删除DNS集成失败")
+	ErrSyncEnvDNSRecords    = NewHTTPError(7204, "同步环境DNS记录失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// traffic mirror releated errors: 7210 - 7219
+	//-----------------------------------------------------------------------------------------------
+	ErrListTrafficMirror   = NewHTTPError(7210, "获取流量镜像列表失败")
+	ErrCreateTrafficMirror = NewHTTPError(7211, "创建流量镜像失败")
+	ErrDeleteTrafficMirror = NewHTTPError(7212, "删除流量镜像失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env debug snapshot releated errors: 7220 - 7229
+	//-----------------------------------------------------------------------------------------------
+	ErrListEnvDebugSnapshot     = NewHTTPError(7220, "获取环境诊断快照列表失败")
+	ErrCreateEnvDebugSnapshot   = NewHTTPError(7221, "创建环境诊断快照失败")
+	ErrDownloadEnvDebugSnapshot = NewHTTPError(7222, "下载环境诊断快照失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env analysis issue releated errors: 7230 - 7239
+	//-----------------------------------------------------------------------------------------------
+	ErrListEnvAnalysisIssue   = NewHTTPError(7230, "获取环境巡检问题工单列表失败")
+	ErrCreateEnvAnalysisIssue = NewHTTPError(7231, "创建环境巡检问题工单失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env resource recommendation releated errors: 7240 - 7249
+	//-----------------------------------------------------------------------------------------------
+	ErrGetResourceRecommendation   = NewHTTPError(7240, "获取资源配置推荐失败")
+	ErrApplyResourceRecommendation = NewHTTPError(7241, "应用资源配置推荐失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env service autoscaler releated errors: 7250 - 7259
+	//-----------------------------------------------------------------------------------------------
+	ErrListServiceAutoscaler   = NewHTTPError(7250, "获取服务弹性伸缩配置失败")
+	ErrUpsertServiceAutoscaler = NewHTTPError(7251, "保存服务弹性伸缩配置失败")
+	ErrDeleteServiceAutoscaler = NewHTTPError(7252, "删除服务弹性伸缩配置失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env workload policy releated errors: 7260 - 7269
+	//-----------------------------------------------------------------------------------------------
+	ErrGetEnvWorkloadPolicy    = NewHTTPError(7260, "获取环境工作负载策略失败")
+	ErrUpdateEnvWorkloadPolicy = NewHTTPError(7261, "更新环境工作负载策略失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// job pod template releated errors: 7270 - 7279
+	//-----------------------------------------------------------------------------------------------
+	ErrListPodTemplate   = NewHTTPError(7270, "获取任务执行环境模板列表失败")
+	ErrGetPodTemplate    = NewHTTPError(7271, "获取任务执行环境模板失败")
+	ErrCreatePodTemplate = NewHTTPError(7272, "创建任务执行环境模板失败")
+	ErrUpdatePodTemplate = NewHTTPError(7273, "更新任务执行环境模板失败")
+	ErrDeletePodTemplate = NewHTTPError(7274, "删除任务执行环境模板失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// credential scan releated errors: 7280 - 7289
+	//-----------------------------------------------------------------------------------------------
+	ErrCredentialDetected         = NewHTTPError(7280, "配置中包含明文凭证")
+	ErrScanCredential             = NewHTTPError(7281, "扫描明文凭证失败")
+	ErrMigrateCredential          = NewHTTPError(7282, "迁移明文凭证失败")
+	ErrUpdateCredentialScanPolicy = NewHTTPError(7283, "更新明文凭证扫描策略失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// parameter store releated errors: 7290 - 7299
+	//-----------------------------------------------------------------------------------------------
+	ErrListParameter    = NewHTTPError(7290, "获取参数列表失败")
+	ErrGetParameter     = NewHTTPError(7291, "获取参数失败")
+	ErrCreateParameter  = NewHTTPError(7292, "创建参数失败")
+	ErrUpdateParameter  = NewHTTPError(7293, "更新参数失败")
+	ErrDeleteParameter  = NewHTTPError(7294, "删除参数失败")
+	ErrResolveParameter = NewHTTPError(7295, "解析参数失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// notification preference releated errors: 7300 - 7309
+	//-----------------------------------------------------------------------------------------------
+	ErrGetNotificationPreference    = NewHTTPError(7300, "获取通知设置失败")
+	ErrUpdateNotificationPreference = NewHTTPError(7301, "更新通知设置失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// chatops releated errors: 7310 - 7319
+	//-----------------------------------------------------------------------------------------------
+	ErrBindChatOpsUser   = NewHTTPError(7310, "绑定 IM 用户失败")
+	ErrListChatOpsUser   = NewHTTPError(7311, "获取 IM 用户绑定失败")
+	ErrUnbindChatOpsUser = NewHTTPError(7312, "解绑 IM 用户失败")
+	ErrChatOpsCommand    = NewHTTPError(7313, "执行 IM 命令失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// status badge releated errors: 7320 - 7329
+	//-----------------------------------------------------------------------------------------------
+	ErrCreateStatusBadge = NewHTTPError(7320, "创建状态徽章失败")
+	ErrListStatusBadge   = NewHTTPError(7321, "获取状态徽章列表失败")
+	ErrDeleteStatusBadge = NewHTTPError(7322, "删除状态徽章失败")
+	ErrGetStatusBadge    = NewHTTPError(7323, "获取状态徽章失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// audit log releated errors: 7330 - 7339
+	//-----------------------------------------------------------------------------------------------
+	ErrListAuditLog               = NewHTTPError(7330, "获取审计日志失败")
+	ErrGetAuditLogExportPolicy    = NewHTTPError(7331, "获取审计日志导出配置失败")
+	ErrUpdateAuditLogExportPolicy = NewHTTPError(7332, "更新审计日志导出配置失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// project quota releated errors: 7340 - 7349
+	//-----------------------------------------------------------------------------------------------
+	ErrProjectQuotaExceeded  = NewHTTPError(7340, "超出项目配额限制")
+	ErrGetProjectQuota       = NewHTTPError(7341, "获取项目配额失败")
+	ErrUpdateProjectQuota    = NewHTTPError(7342, "更新项目配额失败")
+	ErrListProjectQuotaUsage = NewHTTPError(7343, "获取项目配额使用情况失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// job usage releated errors: 7350 - 7359
+	//-----------------------------------------------------------------------------------------------
+	ErrGetJobUsage    = NewHTTPError(7350, "获取任务用量失败")
+	ErrExportJobUsage = NewHTTPError(7351, "导出任务用量失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// workflow task queue releated errors: 7360 - 7369
+	//-----------------------------------------------------------------------------------------------
+	ErrListWorkflowTaskQueue      = NewHTTPError(7360, "获取工作流任务队列失败")
+	ErrUpdateWorkflowTaskPriority = NewHTTPError(7361, "更新工作流任务优先级失败")
+	ErrCancelQueuedWorkflowTask   = NewHTTPError(7362, "取消排队中的工作流任务失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// deploy freeze releated errors: 7370 - 7379
+	//-----------------------------------------------------------------------------------------------
+	ErrDeployFrozen             = NewHTTPError(7370, "生产环境部署冻结中")
+	ErrListDeployFreezeWindow   = NewHTTPError(7371, "获取部署冻结窗口失败")
+	ErrCreateDeployFreezeWindow = NewHTTPError(7372, "创建部署冻结窗口失败")
+	ErrUpdateDeployFreezeWindow = NewHTTPError(7373, "更新部署冻结窗口失败")
+	ErrDeleteDeployFreezeWindow = NewHTTPError(7374, "删除部署冻结窗口失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env health releated errors: 7380 - 7389
+	//-----------------------------------------------------------------------------------------------
+	ErrGetEnvHealth = NewHTTPError(7380, "获取环境健康分失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// alertmanager releated errors: 7390 - 7399
+	//-----------------------------------------------------------------------------------------------
+	ErrListAlertReceiver       = NewHTTPError(7390, "列出告警接收器失败")
+	ErrCreateAlertReceiver     = NewHTTPError(7391, "创建告警接收器失败")
+	ErrUpdateAlertReceiver     = NewHTTPError(7392, "更新告警接收器失败")
+	ErrDeleteAlertReceiver     = NewHTTPError(7393, "删除告警接收器失败")
+	ErrHandleAlertmanagerEvent = NewHTTPError(7394, "处理 Alertmanager 告警失败")
+	ErrListEnvAlerts           = NewHTTPError(7395, "列出环境告警失败")
 )
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2023 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package featureflag
+
+import (
+	"github.com/imroc/req/v3"
+	"github.com/pkg/errors"
+
+	"github.com/koderover/zadig/v2/pkg/setting"
+)
+
+// Client toggles the flags of a feature flag system. The project is ignored by flagsmith, whose environments are
+// identified by the environment keys.
+type Client interface {
+	// Validate checks the connectivity and the token
+	Validate() error
+	GetFlag(project, env, flag string) (bool, error)
+	SetFlag(project, env, flag string, enabled bool) error
+}
+
+func NewClient(_type, address, token string) (Client, error) {
+	switch _type {
+	case setting.SourceFromLaunchDarkly:
+		if address == "" {
+			address = "https://app.launchdarkly.com"
+		}
+		return &launchDarklyClient{Client: newReqClient("Authorization", token), BaseURL: address}, nil
+	case setting.SourceFromUnleash:
+		return &unleashClient{Client: newReqClient("Authorization", token), BaseURL: address}, nil
+	case setting.SourceFromFlagsmith:
+		if address == "" {
+			address = "https://api.flagsmith.com"
+		}
+		return &flagsmithClient{Client: newReqClient("Authorization", "Token "+token), BaseURL: address}, nil
+	default:
+		return nil, errors.Errorf("unsupported feature flag system %s", _type)
+	}
+}
+
+func newReqClient(header, value string) *req.Client {
+	return req.C().
+		SetCommonHeader(header, value).
+		OnAfterResponse(func(client *req.Client, resp *req.Response) error {
+			if resp.Err != nil {
+				resp.Err = errors.Wrapf(resp.Err, "body: %s", resp.String())
+				return nil
+			}
+			if !resp.IsSuccessState() {
+				resp.Err = errors.Errorf("unexpected status code %d, body: %s", resp.GetStatusCode(), resp.String())
+				return nil
+			}
+			return nil
+		})
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2023 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package featureflag
+
+import (
+	"fmt"
+
+	"github.com/imroc/req/v3"
+	"github.com/pkg/errors"
+)
+
+type flagsmithClient struct {
+	*req.Client
+	BaseURL string
+}
+
+type flagsmithFeatureStates struct {
+	Results []*flagsmithFeatureState `json:"results"`
+}
+
+type flagsmithFeatureState struct {
+	ID      int  `json:"id"`
+	Enabled bool `json:"enabled"`
+}
+
+func (c *flagsmithClient) Validate() error {
+	_, err := c.R().Get(c.BaseURL + "/api/v1/organisations/")
+	return err
+}
+
+// getFeatureState returns the environment default state of the feature, env is the key of the environment.
+func (c *flagsmithClient) getFeatureState(env, flag string) (*flagsmithFeatureState, error) {
+	resp := new(flagsmithFeatureStates)
+	_, err := c.R().SetQueryParam("feature_name", flag).SetSuccessResult(resp).
+		Get(fmt.Sprintf("%s/api/v1/environments/%s/featurestates/", c.BaseURL, env))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, errors.Errorf("flag %s not found in environment %s", flag, env)
+	}
+	return resp.Results[0], nil
+}
+
+func (c *flagsmithClient) GetFlag(_, env, flag string) (bool, error) {
+	state, err := c.getFeatureState(env, flag)
+	if err != nil {
+		return false, err
+	}
+	return state.Enabled, nil
+}
+
+func (c *flagsmithClient) SetFlag(_, env, flag string, enabled bool) error {
+	state, err := c.getFeatureState(env, flag)
+	if err != nil {
+		return err
+	}
+	_, err = c.R().SetBody(map[string]bool{"enabled": enabled}).
+		Patch(fmt.Sprintf("%s/api/v1/environments/%s/featurestates/%d/", c.BaseURL, env, state.ID))
+	return err
+}
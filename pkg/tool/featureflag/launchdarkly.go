@@ -0,0 +1,78 @@
+/*
+ * Copyright 2023 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package featureflag
+
+import (
+	"fmt"
+
+	"github.com/imroc/req/v3"
+	"github.com/pkg/errors"
+)
+
+type launchDarklyClient struct {
+	*req.Client
+	BaseURL string
+}
+
+type launchDarklyFlag struct {
+	Environments map[string]struct {
+		On bool `json:"on"`
+	} `json:"environments"`
+}
+
+type launchDarklySemanticPatch struct {
+	EnvironmentKey string                    `json:"environmentKey"`
+	Instructions   []launchDarklyInstruction `json:"instructions"`
+}
+
+type launchDarklyInstruction struct {
+	Kind string `json:"kind"`
+}
+
+func (c *launchDarklyClient) Validate() error {
+	_, err := c.R().SetQueryParam("limit", "1").Get(c.BaseURL + "/api/v2/projects")
+	return err
+}
+
+func (c *launchDarklyClient) GetFlag(project, env, flag string) (bool, error) {
+	resp := new(launchDarklyFlag)
+	_, err := c.R().SetQueryParam("env", env).SetSuccessResult(resp).
+		Get(fmt.Sprintf("%s/api/v2/flags/%s/%s", c.BaseURL, project, flag))
+	if err != nil {
+		return false, err
+	}
+	flagEnv, ok := resp.Environments[env]
+	if !ok {
+		return false, errors.Errorf("environment %s of flag %s not found", env, flag)
+	}
+	return flagEnv.On, nil
+}
+
+func (c *launchDarklyClient) SetFlag(project, env, flag string, enabled bool) error {
+	kind := "turnFlagOff"
+	if enabled {
+		kind = "turnFlagOn"
+	}
+	_, err := c.R().
+		SetContentType("application/json; domain-model=launchdarkly.semanticpatch").
+		SetBody(&launchDarklySemanticPatch{
+			EnvironmentKey: env,
+			Instructions:   []launchDarklyInstruction{{Kind: kind}},
+		}).
+		Patch(fmt.Sprintf("%s/api/v2/flags/%s/%s", c.BaseURL, project, flag))
+	return err
+}
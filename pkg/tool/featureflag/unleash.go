@@ -0,0 +1,65 @@
+/*
+ * Copyright 2023 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package featureflag
+
+import (
+	"fmt"
+
+	"github.com/imroc/req/v3"
+	"github.com/pkg/errors"
+)
+
+type unleashClient struct {
+	*req.Client
+	BaseURL string
+}
+
+type unleashFeature struct {
+	Environments []struct {
+		Name    string `json:"name"`
+		Enabled bool   `json:"enabled"`
+	} `json:"environments"`
+}
+
+func (c *unleashClient) Validate() error {
+	_, err := c.R().Get(c.BaseURL + "/api/admin/projects")
+	return err
+}
+
+func (c *unleashClient) GetFlag(project, env, flag string) (bool, error) {
+	resp := new(unleashFeature)
+	_, err := c.R().SetSuccessResult(resp).
+		Get(fmt.Sprintf("%s/api/admin/projects/%s/features/%s", c.BaseURL, project, flag))
+	if err != nil {
+		return false, err
+	}
+	for _, flagEnv := range resp.Environments {
+		if flagEnv.Name == env {
+			return flagEnv.Enabled, nil
+		}
+	}
+	return false, errors.Errorf("environment %s of flag %s not found", env, flag)
+}
+
+func (c *unleashClient) SetFlag(project, env, flag string, enabled bool) error {
+	action := "off"
+	if enabled {
+		action = "on"
+	}
+	_, err := c.R().Post(fmt.Sprintf("%s/api/admin/projects/%s/features/%s/environments/%s/%s", c.BaseURL, project, flag, env, action))
+	return err
+}
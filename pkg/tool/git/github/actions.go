@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/go-github/v35/github"
+)
+
+// DispatchWorkflow triggers a workflow_dispatch event of the workflow file on the ref
+func (c *Client) DispatchWorkflow(ctx context.Context, owner, repo, workflowFile, ref string, inputs map[string]interface{}) error {
+	return wrapError(c.Actions.CreateWorkflowDispatchEventByFileName(ctx, owner, repo, workflowFile, github.CreateWorkflowDispatchEventRequest{
+		Ref:    ref,
+		Inputs: inputs,
+	}))
+}
+
+// ListDispatchedWorkflowRuns returns the latest runs of the workflow file triggered by workflow_dispatch events on the ref
+func (c *Client) ListDispatchedWorkflowRuns(ctx context.Context, owner, repo, workflowFile, ref string) ([]*github.WorkflowRun, error) {
+	runs, res, err := c.Actions.ListWorkflowRunsByFileName(ctx, owner, repo, workflowFile, &github.ListWorkflowRunsOptions{
+		Branch:      ref,
+		Event:       "workflow_dispatch",
+		ListOptions: github.ListOptions{PerPage: 30},
+	})
+	if err := wrapError(res, err); err != nil {
+		return nil, err
+	}
+	return runs.WorkflowRuns, nil
+}
+
+func (c *Client) GetWorkflowRun(ctx context.Context, owner, repo string, runID int64) (*github.WorkflowRun, error) {
+	run, err := wrap(c.Actions.GetWorkflowRunByID(ctx, owner, repo, runID))
+	if r, ok := run.(*github.WorkflowRun); ok {
+		return r, err
+	}
+
+	return nil, err
+}
+
+func (c *Client) CancelWorkflowRun(ctx context.Context, owner, repo string, runID int64) error {
+	return wrapError(c.Actions.CancelWorkflowRunByID(ctx, owner, repo, runID))
+}
+
+func (c *Client) ListWorkflowRunJobs(ctx context.Context, owner, repo string, runID int64) ([]*github.WorkflowJob, error) {
+	jobs := make([]*github.WorkflowJob, 0)
+	opts := &github.ListWorkflowJobsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		resp, res, err := c.Actions.ListWorkflowJobs(ctx, owner, repo, runID, opts)
+		if err := wrapError(res, err); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, resp.Jobs...)
+		if res.NextPage == 0 {
+			return jobs, nil
+		}
+		opts.Page = res.NextPage
+	}
+}
+
+// GetWorkflowJobLogs downloads the plain text logs of a finished workflow job
+func (c *Client) GetWorkflowJobLogs(ctx context.Context, owner, repo string, jobID int64) (string, error) {
+	u, res, err := c.Actions.GetWorkflowJobLogs(ctx, owner, repo, jobID, true)
+	if err != nil {
+		return "", err
+	}
+	if err := wrapError(res, nil); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download logs of job %d, status: %s", jobID, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
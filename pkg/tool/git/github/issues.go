@@ -0,0 +1,29 @@
+/*
+Copyright 2021 The KodeRover Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v35/github"
+)
+
+func (c *Client) CreateIssue(ctx context.Context, owner string, repo string, issue *github.IssueRequest) (*github.Issue, error) {
+	created, err := wrap(c.Issues.Create(ctx, owner, repo, issue))
+	if i, ok := created.(*github.Issue); ok {
+		return i, err
+	}
+
+	return nil, err
+}
@@ -125,3 +125,51 @@ func (c *Client) ListFiles(ctx context.Context, owner string, repo string, numbe
 
 	return res, err
 }
+
+// CommitFileAsPullRequest commits the file to a new branch created from the base branch and opens a pull request
+// to merge it back to the base branch
+func (c *Client) CommitFileAsPullRequest(ctx context.Context, owner, repo, base, branch, path, message, title, body string, content []byte) (*github.PullRequest, error) {
+	ref, err := wrap(c.Git.GetRef(ctx, owner, repo, "heads/"+base))
+	if err != nil {
+		return nil, err
+	}
+	baseRef, ok := ref.(*github.Reference)
+	if !ok {
+		return nil, nil
+	}
+	newRef := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: baseRef.Object.SHA},
+	}
+	if _, err := wrap(c.Git.CreateRef(ctx, owner, repo, newRef)); err != nil {
+		return nil, err
+	}
+
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.String(message),
+		Content: content,
+		Branch:  github.String(branch),
+	}
+	file, _, _, err := c.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: base})
+	if err == nil && file != nil {
+		opts.SHA = file.SHA
+		_, err = wrap(c.Repositories.UpdateFile(ctx, owner, repo, path, opts))
+	} else {
+		_, err = wrap(c.Repositories.CreateFile(ctx, owner, repo, path, opts))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := wrap(c.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(branch),
+		Base:  github.String(base),
+		Body:  github.String(body),
+	}))
+	if p, ok := pr.(*github.PullRequest); ok {
+		return p, err
+	}
+
+	return nil, err
+}
@@ -79,3 +79,8 @@ func (c *Client) ListCommits(owner, repo, branch string, opts *ListOptions) ([]*
 
 	return cs, nil
 }
+
+func (c *Client) SetCommitStatus(owner, repo, commitSha string, opt *gitlab.SetCommitStatusOptions) error {
+	_, err := wrap(c.Commits.SetCommitStatus(generateProjectName(owner, repo), commitSha, opt))
+	return err
+}
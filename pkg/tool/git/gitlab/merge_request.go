@@ -77,3 +77,41 @@ func (c *Client) ListChangedFiles(event *gitlab.MergeEvent) ([]string, error) {
 //	_, err := wrap(c.Discussions.CreateCommitDiscussion(generateProjectName(owner, repo), commitHash, args))
 //	return err
 //}
+
+// CommitFileAsMergeRequest commits the file to a new branch started from the base branch and opens a merge request
+// to merge it back to the base branch
+func (c *Client) CommitFileAsMergeRequest(owner, repo, base, branch, path, message, title, description string, content []byte) (*gitlab.MergeRequest, error) {
+	project := generateProjectName(owner, repo)
+	action := gitlab.FileUpdate
+	if _, _, err := c.RepositoryFiles.GetFile(project, path, &gitlab.GetFileOptions{Ref: &base}); err != nil {
+		action = gitlab.FileCreate
+	}
+	fileContent := string(content)
+	_, err := wrap(c.Commits.CreateCommit(project, &gitlab.CreateCommitOptions{
+		Branch:        &branch,
+		StartBranch:   &base,
+		CommitMessage: &message,
+		Actions: []*gitlab.CommitActionOptions{
+			{
+				Action:   gitlab.FileAction(action),
+				FilePath: &path,
+				Content:  &fileContent,
+			},
+		},
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	mergeRequest, err := wrap(c.MergeRequests.CreateMergeRequest(project, &gitlab.CreateMergeRequestOptions{
+		Title:        &title,
+		Description:  &description,
+		SourceBranch: &branch,
+		TargetBranch: &base,
+	}))
+	if mr, ok := mergeRequest.(*gitlab.MergeRequest); ok {
+		return mr, err
+	}
+
+	return nil, err
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2023 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package harbor
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/pkg/errors"
+)
+
+const pageSize = 100
+
+type Client struct {
+	*req.Client
+	BaseURL string
+}
+
+// NewClient returns a client of the harbor v2.0 api authenticated by the robot account or the user
+func NewClient(address, username, password string, insecure bool) *Client {
+	client := req.C().
+		SetCommonBasicAuth(username, password).
+		OnAfterResponse(func(client *req.Client, resp *req.Response) error {
+			if resp.Err != nil {
+				resp.Err = errors.Wrapf(resp.Err, "body: %s", resp.String())
+				return nil
+			}
+			if !resp.IsSuccessState() {
+				resp.Err = errors.Errorf("unexpected status code %d, body: %s", resp.GetStatusCode(), resp.String())
+				return nil
+			}
+			return nil
+		})
+	if insecure {
+		client.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	}
+	return &Client{
+		Client:  client,
+		BaseURL: strings.TrimSuffix(address, "/") + "/api/v2.0",
+	}
+}
+
+type Repository struct {
+	// Name is the full name of the repository including the project
+	Name          string `json:"name"`
+	ArtifactCount int64  `json:"artifact_count"`
+}
+
+type Artifact struct {
+	Digest   string    `json:"digest"`
+	Size     int64     `json:"size"`
+	PushTime time.Time `json:"push_time"`
+	Tags     []*Tag    `json:"tags"`
+}
+
+type Tag struct {
+	Name     string    `json:"name"`
+	PushTime time.Time `json:"push_time"`
+}
+
+// ListRepositories lists all the repositories in the project
+func (c *Client) ListRepositories(project string) ([]*Repository, error) {
+	ret := make([]*Repository, 0)
+	for page := 1; ; page++ {
+		repos := make([]*Repository, 0)
+		_, err := c.R().
+			SetQueryParam("page", fmt.Sprintf("%d", page)).
+			SetQueryParam("page_size", fmt.Sprintf("%d", pageSize)).
+			SetSuccessResult(&repos).
+			Get(fmt.Sprintf("%s/projects/%s/repositories", c.BaseURL, url.PathEscape(project)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list repositories of project %s", project)
+		}
+		ret = append(ret, repos...)
+		if len(repos) < pageSize {
+			return ret, nil
+		}
+	}
+}
+
+// ListArtifacts lists all the artifacts with the tags in the repository, the repository name excludes the project
+func (c *Client) ListArtifacts(project, repository string) ([]*Artifact, error) {
+	ret := make([]*Artifact, 0)
+	for page := 1; ; page++ {
+		artifacts := make([]*Artifact, 0)
+		_, err := c.R().
+			SetQueryParam("page", fmt.Sprintf("%d", page)).
+			SetQueryParam("page_size", fmt.Sprintf("%d", pageSize)).
+			SetQueryParam("with_tag", "true").
+			SetSuccessResult(&artifacts).
+			Get(fmt.Sprintf("%s/projects/%s/repositories/%s/artifacts", c.BaseURL, url.PathEscape(project), escapeRepository(repository)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list artifacts of %s/%s", project, repository)
+		}
+		ret = append(ret, artifacts...)
+		if len(artifacts) < pageSize {
+			return ret, nil
+		}
+	}
+}
+
+// DeleteTag removes the tag from the artifact, the artifact is kept and can be garbage collected if it is untagged
+func (c *Client) DeleteTag(project, repository, reference, tag string) error {
+	_, err := c.R().Delete(fmt.Sprintf("%s/projects/%s/repositories/%s/artifacts/%s/tags/%s",
+		c.BaseURL, url.PathEscape(project), escapeRepository(repository), url.PathEscape(reference), url.PathEscape(tag)))
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete tag %s of %s/%s", tag, project, repository)
+	}
+	return nil
+}
+
+// escapeRepository escapes the repository name twice as required by harbor since it may contain slashes
+func escapeRepository(repository string) string {
+	return url.PathEscape(url.PathEscape(repository))
+}
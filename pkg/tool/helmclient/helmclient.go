@@ -477,10 +477,13 @@ func (hClient *HelmClient) getChart(chartName string, chartPathOptions *action.C
 	return helmChart, chartPath, err
 }
 
-func (hClient *HelmClient) installChart(ctx context.Context, spec *hc.ChartSpec) (*release.Release, error) {
+func (hClient *HelmClient) installChart(ctx context.Context, spec *hc.ChartSpec, opts *hc.GenericHelmOptions) (*release.Release, error) {
 	c := hClient.HelmClient
 	install := action.NewInstall(c.ActionConfig)
 	mergeInstallOptions(spec, install)
+	if opts != nil && opts.PostRenderer != nil {
+		install.PostRenderer = opts.PostRenderer
+	}
 
 	if install.Version == "" {
 		install.Version = ">0.0.0-0"
@@ -533,10 +536,13 @@ func (hClient *HelmClient) installChart(ctx context.Context, spec *hc.ChartSpec)
 	return rel, nil
 }
 
-func (hClient *HelmClient) upgradeChart(ctx context.Context, spec *hc.ChartSpec) (*release.Release, error) {
+func (hClient *HelmClient) upgradeChart(ctx context.Context, spec *hc.ChartSpec, opts *hc.GenericHelmOptions) (*release.Release, error) {
 	c := hClient.HelmClient
 	upgrade := action.NewUpgrade(c.ActionConfig)
 	mergeUpgradeOptions(spec, upgrade)
+	if opts != nil && opts.PostRenderer != nil {
+		upgrade.PostRenderer = opts.PostRenderer
+	}
 
 	if upgrade.Version == "" {
 		upgrade.Version = ">0.0.0-0"
@@ -582,12 +588,22 @@ func (hClient *HelmClient) InstallOrUpgradeChart(ctx context.Context, spec *hc.C
 	}
 
 	if install {
-		return hClient.installChart(ctx, spec)
+		return hClient.installChart(ctx, spec, opts)
 	} else {
-		return hClient.upgradeChart(ctx, spec)
+		return hClient.upgradeChart(ctx, spec, opts)
 	}
 }
 
+// RollbackToRevision rolls the release back to the given revision, a revision of 0 means the previous one
+func (hClient *HelmClient) RollbackToRevision(releaseName string, revision, maxHistory int, timeout time.Duration) error {
+	rollback := action.NewRollback(hClient.ActionConfig)
+	rollback.Version = revision
+	rollback.MaxHistory = maxHistory
+	rollback.Timeout = timeout
+	rollback.Wait = timeout > 0
+	return rollback.Run(releaseName)
+}
+
 func (hClient *HelmClient) newGetter(providers getter.Providers, repoUrl string) (getter.Getter, error) {
 	u, err := url.Parse(repoUrl)
 	if err != nil {
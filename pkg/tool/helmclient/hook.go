@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/koderover/zadig/v2/pkg/tool/kube/containerlog"
+)
+
+const (
+	hookLogTailLines = 30
+	// maxHookDiagnosisLength limits the diagnosis attached to the error message
+	maxHookDiagnosisLength = 8192
+)
+
+// DiagnoseHookFailure collects the status, logs and events of the hooks which failed or didn't finish in the last
+// run of the release, an empty string is returned if no hook is found.
+// The release returned by the failed install or upgrade is used if it's not nil, otherwise the latest release is used.
+func (hClient *HelmClient) DiagnoseHookFailure(ctx context.Context, namespace, releaseName string, rel *release.Release) string {
+	if rel == nil {
+		releases, err := hClient.ListReleaseHistory(releaseName, 1)
+		if err != nil || len(releases) == 0 {
+			return ""
+		}
+		releaseutil.Reverse(releases, releaseutil.SortByRevision)
+		rel = releases[0]
+	}
+
+	failedHooks := make([]*release.Hook, 0)
+	for _, hook := range rel.Hooks {
+		if hook.LastRun.Phase == release.HookPhaseFailed || hook.LastRun.Phase == release.HookPhaseRunning {
+			failedHooks = append(failedHooks, hook)
+		}
+	}
+	if len(failedHooks) == 0 {
+		return ""
+	}
+
+	var clientset *kubernetes.Clientset
+	if hClient.RestConfig != nil {
+		clientset, _ = kubernetes.NewForConfig(hClient.RestConfig)
+	}
+
+	buf := &strings.Builder{}
+	for _, hook := range failedHooks {
+		events := make([]string, 0, len(hook.Events))
+		for _, event := range hook.Events {
+			events = append(events, event.String())
+		}
+		fmt.Fprintf(buf, "hook %s/%s [%s] phase: %s\n", hook.Kind, hook.Name, strings.Join(events, ","), hook.LastRun.Phase)
+		if clientset != nil {
+			diagnoseHookResource(ctx, clientset, namespace, hook, buf)
+		}
+	}
+
+	diagnosis := buf.String()
+	if len(diagnosis) > maxHookDiagnosisLength {
+		diagnosis = diagnosis[:maxHookDiagnosisLength] + "\n...(truncated)"
+	}
+	return diagnosis
+}
+
+func getContainerTailLogs(ctx context.Context, clientset *kubernetes.Clientset, namespace, podName, containerName string) string {
+	readCloser, err := containerlog.GetContainerLogStream(ctx, namespace, podName, containerName, false, hookLogTailLines, clientset)
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = readCloser.Close()
+	}()
+
+	logs := &bytes.Buffer{}
+	if _, err := io.Copy(logs, io.LimitReader(readCloser, maxHookDiagnosisLength)); err != nil {
+		return ""
+	}
+	return strings.TrimRight(logs.String(), "\n")
+}
+
+func diagnoseHookResource(ctx context.Context, clientset *kubernetes.Clientset, namespace string, hook *release.Hook, buf *strings.Builder) {
+	involvedObjects := []string{hook.Name}
+	pods := make([]corev1.Pod, 0)
+	switch hook.Kind {
+	case "Job":
+		podList, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "job-name=" + hook.Name})
+		if err != nil {
+			fmt.Fprintf(buf, "  failed to list pods of the hook: %s\n", err)
+			return
+		}
+		pods = podList.Items
+	case "Pod":
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, hook.Name, metav1.GetOptions{})
+		if err != nil {
+			fmt.Fprintf(buf, "  failed to get the hook pod, it may be deleted by the hook delete policy: %s\n", err)
+			return
+		}
+		pods = append(pods, *pod)
+	}
+	if hook.Kind == "Job" && len(pods) == 0 {
+		fmt.Fprintf(buf, "  no pod found for the hook, it may be deleted by the hook delete policy\n")
+	}
+
+	for _, pod := range pods {
+		if pod.Name != hook.Name {
+			involvedObjects = append(involvedObjects, pod.Name)
+		}
+		fmt.Fprintf(buf, "  pod %s phase: %s\n", pod.Name, pod.Status.Phase)
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil {
+				fmt.Fprintf(buf, "  container %s terminated, exit code: %d, reason: %s\n", status.Name, status.State.Terminated.ExitCode, status.State.Terminated.Reason)
+			} else if status.State.Waiting != nil {
+				fmt.Fprintf(buf, "  container %s waiting, reason: %s, message: %s\n", status.Name, status.State.Waiting.Reason, status.State.Waiting.Message)
+			}
+
+			if logs := getContainerTailLogs(ctx, clientset, namespace, pod.Name, status.Name); logs != "" {
+				fmt.Fprintf(buf, "  logs of container %s:\n%s\n", status.Name, logs)
+			}
+		}
+	}
+
+	for _, name := range involvedObjects {
+		eventList, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: "involvedObject.name=" + name})
+		if err != nil {
+			continue
+		}
+		events := eventList.Items
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].LastTimestamp.Before(&events[j].LastTimestamp)
+		})
+		for _, event := range events {
+			if event.Type != corev1.EventTypeWarning {
+				continue
+			}
+			fmt.Fprintf(buf, "  event %s/%s: %s %s\n", event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason, event.Message)
+		}
+	}
+}
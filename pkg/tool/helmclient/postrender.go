@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmclient
+
+import (
+	"bytes"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/postrender"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	kustomizeDir       = "/post-render"
+	kustomizeResources = "all.yaml"
+)
+
+// KustomizePatchSet is the kustomize transformations applied to the rendered manifests of a release
+type KustomizePatchSet struct {
+	CommonLabels      map[string]string
+	CommonAnnotations map[string]string
+	Patches           []*KustomizePatch
+}
+
+// KustomizePatch is a strategic merge patch or a json 6902 patch applied to the resources matching the target,
+// the patch is applied to all the resources in the patch if the target is nil
+type KustomizePatch struct {
+	Patch  string
+	Target *KustomizePatchTarget
+}
+
+type KustomizePatchTarget struct {
+	Group              string
+	Version            string
+	Kind               string
+	Name               string
+	Namespace          string
+	LabelSelector      string
+	AnnotationSelector string
+}
+
+type kustomizePostRenderer struct {
+	kustomization *types.Kustomization
+}
+
+// NewKustomizePostRenderer returns a post renderer applying the patch set to the rendered manifests in memory,
+// the common labels are not added to the selectors since the selectors of the workloads are immutable
+func NewKustomizePostRenderer(patchSet *KustomizePatchSet) (postrender.PostRenderer, error) {
+	kustomization := &types.Kustomization{
+		TypeMeta: types.TypeMeta{
+			APIVersion: types.KustomizationVersion,
+			Kind:       types.KustomizationKind,
+		},
+		Resources:         []string{kustomizeResources},
+		CommonAnnotations: patchSet.CommonAnnotations,
+	}
+	if len(patchSet.CommonLabels) > 0 {
+		kustomization.Labels = []types.Label{{
+			Pairs:            patchSet.CommonLabels,
+			IncludeTemplates: true,
+		}}
+	}
+	for _, patch := range patchSet.Patches {
+		if patch.Patch == "" {
+			return nil, fmt.Errorf("patch content can not be empty")
+		}
+		kustomizePatch := types.Patch{Patch: patch.Patch}
+		if patch.Target != nil {
+			kustomizePatch.Target = &types.Selector{
+				ResId: resid.ResId{
+					Gvk: resid.Gvk{
+						Group:   patch.Target.Group,
+						Version: patch.Target.Version,
+						Kind:    patch.Target.Kind,
+					},
+					Name:      patch.Target.Name,
+					Namespace: patch.Target.Namespace,
+				},
+				LabelSelector:      patch.Target.LabelSelector,
+				AnnotationSelector: patch.Target.AnnotationSelector,
+			}
+		}
+		kustomization.Patches = append(kustomization.Patches, kustomizePatch)
+	}
+	return &kustomizePostRenderer{kustomization: kustomization}, nil
+}
+
+func (r *kustomizePostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	fSys := filesys.MakeFsInMemory()
+	if err := fSys.MkdirAll(kustomizeDir); err != nil {
+		return nil, err
+	}
+	if err := fSys.WriteFile(kustomizeDir+"/"+kustomizeResources, renderedManifests.Bytes()); err != nil {
+		return nil, err
+	}
+	kustomizationBytes, err := yaml.Marshal(r.kustomization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kustomization, err: %w", err)
+	}
+	if err := fSys.WriteFile(kustomizeDir+"/"+"kustomization.yaml", kustomizationBytes); err != nil {
+		return nil, err
+	}
+
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fSys, kustomizeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply post render patches, err: %w", err)
+	}
+	out, err := resMap.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(out), nil
+}
+
+type chainedPostRenderer struct {
+	renderers []postrender.PostRenderer
+}
+
+// NewChainedPostRenderer returns a post renderer running the renderers in order, the nil renderers are skipped and
+// nil is returned if there is no renderer left
+func NewChainedPostRenderer(renderers ...postrender.PostRenderer) postrender.PostRenderer {
+	ret := &chainedPostRenderer{}
+	for _, renderer := range renderers {
+		if renderer != nil {
+			ret.renderers = append(ret.renderers, renderer)
+		}
+	}
+	switch len(ret.renderers) {
+	case 0:
+		return nil
+	case 1:
+		return ret.renderers[0]
+	}
+	return ret
+}
+
+func (r *chainedPostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	var err error
+	for _, renderer := range r.renderers {
+		renderedManifests, err = renderer.Run(renderedManifests)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return renderedManifests, nil
+}
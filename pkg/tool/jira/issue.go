@@ -19,6 +19,7 @@ package jira
 import (
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 
 	"github.com/pkg/errors"
@@ -241,6 +242,33 @@ func (s *IssueService) GetTransitions(key string) ([]*Transition, error) {
 	return list, nil
 }
 
+// Create https://developer.atlassian.com/cloud/jira/platform/rest/v2/api-group-issues/#api-rest-api-2-issue-post
+func (s *IssueService) Create(projectKey, issueType, summary, description string) (*Issue, error) {
+	url := s.client.Host + "/rest/api/2/issue"
+
+	body := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": projectKey},
+			"issuetype":   map[string]string{"name": issueType},
+			"summary":     summary,
+			"description": description,
+		},
+	}
+	resp, err := s.client.R().SetBodyJsonMarshal(body).Post(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetStatusCode()/100 != 2 {
+		return nil, errors.Errorf("get unexpected status code %d, body: %s", resp.GetStatusCode(), resp.String())
+	}
+
+	issue := &Issue{}
+	if err = resp.UnmarshalJson(issue); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+	return issue, nil
+}
+
 func (s *IssueService) AddCommentV3(key, comment, link, linkTitle string) error {
 	url := s.client.Host + "/rest/api/3/issue/" + key + "/comment"
 
@@ -269,6 +297,22 @@ func (s *IssueService) AddCommentV2(key, comment string) error {
 	return nil
 }
 
+var issueKeyRegexp = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-[0-9]+\b`)
+
+// ExtractIssueKeys returns the deduplicated issue keys like "ZADIG-123" mentioned in the text
+func ExtractIssueKeys(text string) []string {
+	keys := make([]string, 0)
+	keySet := make(map[string]struct{})
+	for _, key := range issueKeyRegexp.FindAllString(text, -1) {
+		if _, ok := keySet[key]; ok {
+			continue
+		}
+		keySet[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 //// GetIssuesCountByJQL ...
 //func (s *IssueService) GetIssuesCountByJQL(jql string) (int, error) {
 //	if jql == "" {
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// Forward forwards the data between conn and the port of the pod through the pods/portforward subresource,
+// one connection of the subresource is created for each call. It returns when either side is closed.
+func Forward(dialer httpstream.Dialer, port int, conn io.ReadWriter) error {
+	streamConn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return fmt.Errorf("failed to dial port forward: %v", err)
+	}
+	defer streamConn.Close()
+
+	return forward(streamConn, port, conn)
+}
+
+// Dial returns a connection to the port of the pod through the pods/portforward subresource,
+// the port forward is stopped when the returned connection is closed.
+func Dial(dialer httpstream.Dialer, port int) (net.Conn, error) {
+	streamConn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial port forward: %v", err)
+	}
+
+	local, remote := net.Pipe()
+	conn := &forwardConn{
+		Conn: local,
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer streamConn.Close()
+		defer remote.Close()
+		conn.err = forward(streamConn, port, remote)
+		close(conn.done)
+	}()
+	return conn, nil
+}
+
+type forwardConn struct {
+	net.Conn
+	done chan struct{}
+	err  error
+}
+
+// Read returns the error of the port forward instead of io.EOF if the forwarding failed, e.g. the port is not listened.
+func (c *forwardConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err == io.EOF {
+		<-c.done
+		if c.err != nil {
+			return n, c.err
+		}
+	}
+	return n, err
+}
+
+func forward(streamConn httpstream.Connection, port int, conn io.ReadWriter) error {
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return fmt.Errorf("failed to create error stream: %v", err)
+	}
+	// nothing is written to the error stream
+	errorStream.Close()
+
+	errChan := make(chan error, 3)
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		switch {
+		case err != nil:
+			errChan <- fmt.Errorf("failed to read from error stream: %v", err)
+		case len(message) > 0:
+			errChan <- fmt.Errorf("failed to forward port %d: %s", port, string(message))
+		}
+	}()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return fmt.Errorf("failed to create data stream: %v", err)
+	}
+
+	go func() {
+		_, err := io.Copy(conn, dataStream)
+		errChan <- err
+	}()
+	go func() {
+		// inform the server that nothing more is sent when the local side is closed
+		defer dataStream.Close()
+		if _, err := io.Copy(dataStream, conn); err != nil {
+			errChan <- err
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-streamConn.CloseChan():
+		return nil
+	}
+}
@@ -32,15 +32,21 @@ const (
 	ProviderDeepSeekSiliconCloud Provider = "deepseek_siliconcloud"
 	ProviderAzure                Provider = "azure_openai"
 	ProviderAzureAD              Provider = "azure_ad_openai"
+	// ProviderOpenAICompatible is any service serving the openai api under a custom base url
+	ProviderOpenAICompatible Provider = "openai_compatible"
+	// ProviderVLLM is a self-hosted vLLM server, which serves the openai api
+	ProviderVLLM Provider = "vllm"
 )
 
 var (
-	clients = map[Provider]ILLM{
-		ProviderOpenAI:               &OpenAIClient{},
-		ProviderDeepSeek:             &OpenAIClient{},
-		ProviderDeepSeekSiliconCloud: &OpenAIClient{},
-		ProviderAzure:                &OpenAIClient{},
-		ProviderAzureAD:              &OpenAIClient{},
+	clients = map[Provider]func() ILLM{
+		ProviderOpenAI:               newOpenAIClient,
+		ProviderDeepSeek:             newOpenAIClient,
+		ProviderDeepSeekSiliconCloud: newOpenAIClient,
+		ProviderAzure:                newOpenAIClient,
+		ProviderAzureAD:              newOpenAIClient,
+		ProviderOpenAICompatible:     newOpenAIClient,
+		ProviderVLLM:                 newOpenAIClient,
 	}
 )
 
@@ -52,11 +58,13 @@ type ILLM interface {
 	GetModel() string
 }
 
+// NewClient returns a new client of the provider, a new client is created for each call since the clients of
+// different llm integrations may use the same provider with different configurations
 func NewClient(provider Provider) (ILLM, error) {
-	if c, ok := clients[provider]; !ok {
+	if newFunc, ok := clients[provider]; !ok {
 		return nil, fmt.Errorf("provider %s not supported", provider)
 	} else {
-		return c, nil
+		return newFunc(), nil
 	}
 }
 
@@ -44,6 +44,10 @@ type OpenAIClient struct {
 	apiType string
 }
 
+func newOpenAIClient() ILLM {
+	return &OpenAIClient{}
+}
+
 func (c *OpenAIClient) Configure(config LLMConfig) error {
 	token := config.GetToken()
 	var defaultConfig openai.ClientConfig
@@ -56,7 +60,8 @@ func (c *OpenAIClient) Configure(config LLMConfig) error {
 			c.apiType = string(openai.APITypeAzureAD)
 			defaultConfig.APIType = openai.APITypeAzureAD
 		}
-	} else if strings.HasPrefix(string(config.GetProviderName()), string(ProviderDeepSeek)) {
+	} else if strings.HasPrefix(string(config.GetProviderName()), string(ProviderDeepSeek)) ||
+		config.GetProviderName() == ProviderOpenAICompatible || config.GetProviderName() == ProviderVLLM {
 		c.apiType = string(openai.APITypeOpenAI)
 		defaultConfig = openai.DefaultConfig(token)
 		baseURL := config.GetBaseURL()
@@ -143,6 +148,12 @@ func (c *OpenAIClient) GetCompletion(ctx context.Context, prompt string, options
 	}
 	log.Debugf("ai completion took: %v", time.Since(now))
 
+	if opts.Usage != nil {
+		opts.Usage.PromptTokens = resp.Usage.PromptTokens
+		opts.Usage.CompletionTokens = resp.Usage.CompletionTokens
+		opts.Usage.TotalTokens = resp.Usage.TotalTokens
+	}
+
 	if len(resp.Choices) == 0 {
 		return "", errors.New("no completion choices")
 	}
@@ -14,6 +14,15 @@ type ParamOptions struct {
 	// StopWords is a list of words to stop on.
 	StopWords []string       `json:"stop_words"`
 	LogitBias map[string]int `json:"logit_bias"`
+	// Usage is filled with the token usage of the completion if it is set.
+	Usage *Usage `json:"-"`
+}
+
+// Usage is the token usage of a completion.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 func WithModel(model string) ParamOption {
@@ -46,6 +55,12 @@ func WithLogitBias(logitBias map[string]int) ParamOption {
 	}
 }
 
+func WithUsage(usage *Usage) ParamOption {
+	return func(o *ParamOptions) {
+		o.Usage = usage
+	}
+}
+
 func WithOptions(options ParamOptions) ParamOption {
 	return func(o *ParamOptions) {
 		(*o) = options
@@ -123,6 +123,46 @@ var (
 		},
 		[]string{"method", "handler", "status"},
 	)
+
+	// the hit rate of the build cache is BuildCacheHits / BuildCacheSteps
+	BuildCacheSteps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "build_cache_steps_total",
+			Help: "Number of docker build steps with the remote build cache",
+		},
+		[]string{"project", "cache_type"},
+	)
+
+	BuildCacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "build_cache_hits_total",
+			Help: "Number of docker build steps that hit the remote build cache",
+		},
+		[]string{"project", "cache_type"},
+	)
+
+	LocalCacheBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_cache_bytes",
+			Help: "Disk usage of the local service and chart cache directories",
+		},
+		[]string{"type"},
+	)
+
+	LocalCacheDirs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_cache_dirs",
+			Help: "Number of the local service and chart cache directories",
+		},
+		[]string{"type"},
+	)
+
+	LocalCacheGCRemovedBytes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "local_cache_gc_removed_bytes_total",
+			Help: "Bytes removed by the local cache garbage collection",
+		},
+	)
 )
 
 func SetRunningWorkflows(value int64) {
@@ -138,6 +178,20 @@ func RegisterRequest(startTime int64, method, handler string, status int) {
 	ResponseTime.WithLabelValues(method, handler, fmt.Sprintf("%d", status)).Observe(float64(time.Now().UnixMilli()-startTime) / 1000)
 }
 
+func RegisterBuildCache(project, cacheType string, hits, steps int) {
+	BuildCacheSteps.WithLabelValues(project, cacheType).Add(float64(steps))
+	BuildCacheHits.WithLabelValues(project, cacheType).Add(float64(hits))
+}
+
+func SetLocalCacheUsage(cacheType string, dirs int, bytes int64) {
+	LocalCacheDirs.WithLabelValues(cacheType).Set(float64(dirs))
+	LocalCacheBytes.WithLabelValues(cacheType).Set(float64(bytes))
+}
+
+func RegisterLocalCacheGC(removedBytes int64) {
+	LocalCacheGCRemovedBytes.Add(float64(removedBytes))
+}
+
 func SetCPUUsage(serviceName, podName string, value int64) {
 	// convert to full core
 	CPU.WithLabelValues(serviceName, podName).Set(float64(value) / 1000)
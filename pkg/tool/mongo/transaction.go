@@ -0,0 +1,157 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/koderover/zadig/v2/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/v2/pkg/tool/log"
+)
+
+const (
+	transientTransactionErrorLabel = "TransientTransactionError"
+	unknownCommitResultLabel       = "UnknownTransactionCommitResult"
+	writeConflictCode              = 112
+
+	defaultTransactionAttempts = 5
+	transactionRetryBaseDelay  = 50 * time.Millisecond
+)
+
+var (
+	TransactionRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mongo_transaction_retries_total",
+			Help: "Number of mongodb transactions retried because of transient errors or write conflicts",
+		},
+		[]string{"name"},
+	)
+
+	TransactionAborts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mongo_transaction_aborts_total",
+			Help: "Number of mongodb transactions aborted",
+		},
+		[]string{"name", "reason"},
+	)
+)
+
+type labeledError interface {
+	HasErrorLabel(string) bool
+}
+
+type codedError interface {
+	HasErrorCode(int) bool
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var le labeledError
+	if errors.As(err, &le) {
+		return le.HasErrorLabel(label)
+	}
+	return false
+}
+
+// IsTransientTransactionError reports whether the whole transaction can be retried safely
+func IsTransientTransactionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if hasErrorLabel(err, transientTransactionErrorLabel) {
+		return true
+	}
+	var ce codedError
+	if errors.As(err, &ce) {
+		return ce.HasErrorCode(writeConflictCode)
+	}
+	return false
+}
+
+// WithTransaction runs fn in a transaction and retries it with backoff when the failure is transient,
+// e.g. a write conflict with another transaction. fn may be called more than once, so it should only contain
+// database operations with the given session, side effects on other systems should be done outside.
+func WithTransaction(ctx context.Context, name string, fn func(session mongo.Session) error) error {
+	session := Session()
+	defer session.EndSession(ctx)
+
+	var err error
+	for attempt := 1; attempt <= defaultTransactionAttempts; attempt++ {
+		if attempt > 1 {
+			TransactionRetries.WithLabelValues(name).Inc()
+			time.Sleep(transactionRetryBaseDelay * time.Duration(1<<(attempt-2)))
+		}
+
+		if err = StartTransaction(session); err != nil {
+			return err
+		}
+
+		if err = fn(session); err != nil {
+			if abortErr := AbortTransaction(session); abortErr != nil {
+				log.Errorf("failed to abort transaction %s, err: %s", name, abortErr)
+			}
+			if config.EnableTransaction() && IsTransientTransactionError(err) {
+				TransactionAborts.WithLabelValues(name, "transient").Inc()
+				log.Warnf("transaction %s aborted by transient error, attempt: %d, err: %s", name, attempt, err)
+				continue
+			}
+			TransactionAborts.WithLabelValues(name, "error").Inc()
+			return err
+		}
+
+		if err = commitWithRetry(session, name); err == nil {
+			return nil
+		}
+		if !IsTransientTransactionError(err) {
+			TransactionAborts.WithLabelValues(name, "commit").Inc()
+			return err
+		}
+		TransactionAborts.WithLabelValues(name, "transient").Inc()
+		log.Warnf("transaction %s failed to commit by transient error, attempt: %d, err: %s", name, attempt, err)
+	}
+
+	TransactionAborts.WithLabelValues(name, "exhausted").Inc()
+	return err
+}
+
+// CommitTransactionWithRetry commits the transaction with retries on unknown commit results,
+// it is used by the transactions which can not be rerun as a whole.
+func CommitTransactionWithRetry(session mongo.Session, name string) error {
+	err := commitWithRetry(session, name)
+	if err != nil {
+		TransactionAborts.WithLabelValues(name, "commit").Inc()
+	}
+	return err
+}
+
+// commitWithRetry retries the commit only when the result is unknown, which is safe since commits are idempotent
+func commitWithRetry(session mongo.Session, name string) error {
+	var err error
+	for attempt := 1; attempt <= defaultTransactionAttempts; attempt++ {
+		err = CommitTransaction(session)
+		if err == nil || !hasErrorLabel(err, unknownCommitResultLabel) {
+			return err
+		}
+		TransactionRetries.WithLabelValues(name).Inc()
+		time.Sleep(transactionRetryBaseDelay * time.Duration(attempt))
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongo_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	mongotool "github.com/koderover/zadig/v2/pkg/tool/mongo"
+)
+
+var _ = Describe("Testing transaction", func() {
+
+	DescribeTable("Testing IsTransientTransactionError",
+		func(err error, expected bool) {
+			Expect(mongotool.IsTransientTransactionError(err)).To(Equal(expected))
+		},
+		Entry("nil error", nil, false),
+		Entry("plain error", errors.New("failed"), false),
+		Entry("transient transaction error", mongo.CommandError{Code: 251, Labels: []string{"TransientTransactionError"}}, true),
+		Entry("write conflict", mongo.CommandError{Code: 112, Name: "WriteConflict"}, true),
+		Entry("wrapped write conflict", fmt.Errorf("failed to update: %w", mongo.CommandError{Code: 112}), true),
+		Entry("duplicate key", mongo.CommandError{Code: 11000}, false),
+	)
+})
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2023 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+
+	AlertLabelName      = "alertname"
+	AlertLabelSeverity  = "severity"
+	AlertLabelNamespace = "namespace"
+)
+
+// AlertmanagerMessage is the payload of the webhook receiver of the Alertmanager
+type AlertmanagerMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []*Alert          `json:"alerts"`
+}
+
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// ParseAlertmanagerMessage parses the webhook payload of the Alertmanager, only the version 4 is supported
+func ParseAlertmanagerMessage(body []byte) (*AlertmanagerMessage, error) {
+	msg := new(AlertmanagerMessage)
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, fmt.Errorf("failed to parse alertmanager message: %w", err)
+	}
+	if msg.Version != "" && msg.Version != "4" {
+		return nil, fmt.Errorf("unsupported alertmanager message version %s", msg.Version)
+	}
+	return msg, nil
+}
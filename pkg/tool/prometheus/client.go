@@ -0,0 +1,114 @@
+/*
+ * Copyright 2023 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/imroc/req/v3"
+	"github.com/pkg/errors"
+)
+
+type Client struct {
+	*req.Client
+	BaseURL string
+}
+
+// NewClient returns a client of the prometheus http api, the token is sent as the bearer token if it is not empty
+func NewClient(url, token string) *Client {
+	client := req.C().
+		OnAfterResponse(func(client *req.Client, resp *req.Response) error {
+			if resp.Err != nil {
+				resp.Err = errors.Wrapf(resp.Err, "body: %s", resp.String())
+				return nil
+			}
+			if !resp.IsSuccessState() {
+				resp.Err = errors.Errorf("unexpected status code %d, body: %s", resp.GetStatusCode(), resp.String())
+				return nil
+			}
+			return nil
+		})
+	if token != "" {
+		client.SetCommonBearerAuthToken(token)
+	}
+	return &Client{
+		Client:  client,
+		BaseURL: url,
+	}
+}
+
+type queryResponse struct {
+	Status string    `json:"status"`
+	Error  string    `json:"error"`
+	Data   queryData `json:"data"`
+}
+
+type queryData struct {
+	ResultType string         `json:"resultType"`
+	Result     []vectorResult `json:"result"`
+}
+
+type vectorResult struct {
+	Metric map[string]string `json:"metric"`
+	// Value is the pair of the timestamp and the value in string
+	Value []interface{} `json:"value"`
+}
+
+// Sample is a sample of an instant vector
+type Sample struct {
+	Metric map[string]string
+	Value  float64
+}
+
+// Query evaluates the instant query at the current time, only the vector result is supported
+func (c *Client) Query(query string) ([]*Sample, error) {
+	resp := new(queryResponse)
+	_, err := c.R().SetQueryParam("query", query).SetSuccessResult(resp).
+		Get(c.BaseURL + "/api/v1/query")
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != "success" {
+		return nil, errors.Errorf("query failed: %s", resp.Error)
+	}
+	if resp.Data.ResultType != "vector" {
+		return nil, errors.Errorf("unsupported result type %s", resp.Data.ResultType)
+	}
+
+	samples := make([]*Sample, 0, len(resp.Data.Result))
+	for _, result := range resp.Data.Result {
+		if len(result.Value) != 2 {
+			continue
+		}
+		valueStr, ok := result.Value[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value %s", valueStr)
+		}
+		samples = append(samples, &Sample{Metric: result.Metric, Value: value})
+	}
+	return samples, nil
+}
+
+// BuildInfo checks the connectivity of the prometheus server
+func (c *Client) BuildInfo() error {
+	_, err := c.R().Get(c.BaseURL + "/api/v1/status/buildinfo")
+	return err
+}
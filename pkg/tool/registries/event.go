@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registries
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	EventSourceHarbor = "harbor"
+	EventSourceACR    = "acr"
+	EventSourceECR    = "ecr"
+)
+
+// PushEvent is an image pushed to the registry
+type PushEvent struct {
+	// Repo is the full name of the repository without registry host, e.g. library/nginx
+	Repo   string
+	Tag    string
+	Digest string
+}
+
+type harborEvent struct {
+	Type      string `json:"type"`
+	EventData struct {
+		Resources []struct {
+			Digest string `json:"digest"`
+			Tag    string `json:"tag"`
+		} `json:"resources"`
+		Repository struct {
+			Name         string `json:"name"`
+			Namespace    string `json:"namespace"`
+			RepoFullName string `json:"repo_full_name"`
+		} `json:"repository"`
+	} `json:"event_data"`
+}
+
+type acrEvent struct {
+	PushData struct {
+		Digest string `json:"digest"`
+		Tag    string `json:"tag"`
+	} `json:"push_data"`
+	Repository struct {
+		Name         string `json:"name"`
+		Namespace    string `json:"namespace"`
+		RepoFullName string `json:"repo_full_name"`
+	} `json:"repository"`
+}
+
+type ecrEvent struct {
+	DetailType string `json:"detail-type"`
+	Detail     struct {
+		ActionType     string `json:"action-type"`
+		Result         string `json:"result"`
+		RepositoryName string `json:"repository-name"`
+		ImageDigest    string `json:"image-digest"`
+		ImageTag       string `json:"image-tag"`
+	} `json:"detail"`
+}
+
+// ParsePushEvents parses the notification of the registry, events other than image push are ignored
+func ParsePushEvents(source string, body []byte) ([]*PushEvent, error) {
+	resp := make([]*PushEvent, 0)
+	switch source {
+	case EventSourceHarbor:
+		event := new(harborEvent)
+		if err := json.Unmarshal(body, event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal harbor event: %v", err)
+		}
+		if event.Type != "PUSH_ARTIFACT" && event.Type != "pushImage" {
+			return resp, nil
+		}
+		repo := event.EventData.Repository.RepoFullName
+		if repo == "" {
+			repo = joinRepo(event.EventData.Repository.Namespace, event.EventData.Repository.Name)
+		}
+		for _, res := range event.EventData.Resources {
+			if res.Tag == "" {
+				continue
+			}
+			resp = append(resp, &PushEvent{Repo: repo, Tag: res.Tag, Digest: res.Digest})
+		}
+	case EventSourceACR:
+		event := new(acrEvent)
+		if err := json.Unmarshal(body, event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal acr event: %v", err)
+		}
+		if event.PushData.Tag == "" {
+			return resp, nil
+		}
+		repo := event.Repository.RepoFullName
+		if repo == "" {
+			repo = joinRepo(event.Repository.Namespace, event.Repository.Name)
+		}
+		resp = append(resp, &PushEvent{Repo: repo, Tag: event.PushData.Tag, Digest: event.PushData.Digest})
+	case EventSourceECR:
+		event := new(ecrEvent)
+		if err := json.Unmarshal(body, event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ecr event: %v", err)
+		}
+		if event.Detail.ActionType != "PUSH" || event.Detail.Result != "SUCCESS" || event.Detail.ImageTag == "" {
+			return resp, nil
+		}
+		resp = append(resp, &PushEvent{Repo: event.Detail.RepositoryName, Tag: event.Detail.ImageTag, Digest: event.Detail.ImageDigest})
+	default:
+		return nil, fmt.Errorf("unsupported event source %s", source)
+	}
+	return resp, nil
+}
+
+func joinRepo(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return strings.Join([]string{namespace, name}, "/")
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePushEvents(t *testing.T) {
+	harbor := `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"digest":"sha256:abc","tag":"v1.0.0"}],"repository":{"name":"nginx","namespace":"library","repo_full_name":"library/nginx"}}}`
+	events, err := ParsePushEvents(EventSourceHarbor, []byte(harbor))
+	assert.NoError(t, err)
+	assert.Equal(t, []*PushEvent{{Repo: "library/nginx", Tag: "v1.0.0", Digest: "sha256:abc"}}, events)
+
+	harborDelete := `{"type":"DELETE_ARTIFACT","event_data":{"resources":[{"tag":"v1.0.0"}]}}`
+	events, err = ParsePushEvents(EventSourceHarbor, []byte(harborDelete))
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	acr := `{"push_data":{"digest":"sha256:def","tag":"latest"},"repository":{"name":"app","namespace":"team"}}`
+	events, err = ParsePushEvents(EventSourceACR, []byte(acr))
+	assert.NoError(t, err)
+	assert.Equal(t, []*PushEvent{{Repo: "team/app", Tag: "latest", Digest: "sha256:def"}}, events)
+
+	ecr := `{"detail-type":"ECR Image Action","detail":{"action-type":"PUSH","result":"SUCCESS","repository-name":"team/app","image-digest":"sha256:123","image-tag":"v2"}}`
+	events, err = ParsePushEvents(EventSourceECR, []byte(ecr))
+	assert.NoError(t, err)
+	assert.Equal(t, []*PushEvent{{Repo: "team/app", Tag: "v2", Digest: "sha256:123"}}, events)
+
+	_, err = ParsePushEvents("unknown", []byte(ecr))
+	assert.Error(t, err)
+}
@@ -17,12 +17,14 @@ limitations under the License.
 package s3
 
 import (
+	"bytes"
 	"fmt"
 	"io/fs"
 	"mime"
 	"os"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -236,6 +238,21 @@ func (c *Client) Upload(bucketName, src string, objectKey string) error {
 	return err
 }
 
+// UploadContent uploads the content to the bucket with the specified objectKey
+func (c *Client) UploadContent(bucketName, objectKey string, content []byte) error {
+	input := &s3.PutObjectInput{
+		Body:   bytes.NewReader(content),
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	}
+	mimetype := detectMimetype(objectKey)
+	if mimetype != "" {
+		input.ContentType = &mimetype
+	}
+	_, err := c.PutObject(input)
+	return err
+}
+
 // Upload upload all files in a directory to a S3 path recursively
 func (c *Client) UploadDir(bucketName, srcdir string, s3dir string) error {
 	err := fs.WalkDir(os.DirFS(srcdir), ".", func(p string, d fs.DirEntry, e error) error {
@@ -305,3 +322,35 @@ func (c *Client) ListFiles(bucketName, prefix string, recursive bool) ([]string,
 
 	return ret, nil
 }
+
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ListObjectsInfo lists all the objects with given prefix recursively, including their size and modification time
+func (c *Client) ListObjectsInfo(bucketName, prefix string) ([]*ObjectInfo, error) {
+	ret := make([]*ObjectInfo, 0)
+
+	input := &s3.ListObjectsInput{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	}
+	err := c.ListObjectsPages(input, func(output *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, item := range output.Contents {
+			ret = append(ret, &ObjectInfo{
+				Key:          aws.StringValue(item.Key),
+				Size:         aws.Int64Value(item.Size),
+				LastModified: aws.TimeValue(item.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		log.Errorf("bucket [%s] listing objects with prefix [%v] failed, error: %v", bucketName, prefix, err)
+		return nil, err
+	}
+
+	return ret, nil
+}
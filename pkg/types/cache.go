@@ -53,6 +53,23 @@ type Cache struct {
 	NFSProperties    NFSProperties    `json:"nfs_properties"    bson:"nfs_properties"`
 }
 
+type DockerCacheType string
+
+const (
+	DockerRegistryCache DockerCacheType = "registry"
+	DockerS3Cache       DockerCacheType = "s3"
+)
+
+// DockerRemoteCache imports and exports the buildkit layer cache of docker build from a remote backend,
+// the cache is isolated by project. The registry cache is stored in the image registry of the build job
+// with a dedicated tag.
+type DockerRemoteCache struct {
+	Enabled bool            `json:"enabled"       bson:"enabled"        yaml:"enabled"`
+	Type    DockerCacheType `json:"type"          bson:"type"           yaml:"type"`
+	// S3StorageID is the object storage to store the cache, the default object storage is used if it's empty
+	S3StorageID string `json:"s3_storage_id" bson:"s3_storage_id"  yaml:"s3_storage_id"`
+}
+
 type CacheDirType string
 
 const (
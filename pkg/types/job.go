@@ -26,6 +26,8 @@ const (
 const (
 	JobResultKey  = "job-result"
 	JobOutputsKey = "job-outputs"
+	// JobCheckpointKey keeps the latest progress of long-running test jobs
+	JobCheckpointKey = "job-checkpoint"
 
 	JobDebugStatusKey    = "job-debug-status"
 	JobDebugStatusBefore = "before"
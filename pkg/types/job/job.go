@@ -17,7 +17,9 @@ limitations under the License.
 package job
 
 import (
+	"bufio"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/koderover/zadig/v2/pkg/setting"
@@ -26,13 +28,76 @@ import (
 const (
 	JobOutputDir       = "/zadig/results/"
 	JobTerminationFile = "/zadig/termination"
+	// JobCheckpointFile is where long-running test jobs write their progress in json
+	JobCheckpointFile = "/zadig/checkpoint"
+	// JobOutputFile is where the scripts write their custom outputs as KEY=VALUE lines, it is exposed as $ZADIG_OUTPUT
+	JobOutputFile = "/zadig/outputs"
 )
 
+var outputNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]{1,64}$`)
+
 type JobOutput struct {
 	Name  string `json:"name" bson:"name"`
 	Value string `json:"value" bson:"value"`
 }
 
+// JobCheckpoint is the progress reported by a long-running test job, the case counts
+// only cover the cases run by the pod identified by RunID.
+type JobCheckpoint struct {
+	RunID       string `json:"run_id"       bson:"run_id"`
+	CasesTotal  int    `json:"cases_total"  bson:"cases_total"`
+	CasesRun    int    `json:"cases_run"    bson:"cases_run"`
+	CasesPassed int    `json:"cases_passed" bson:"cases_passed"`
+	CasesFailed int    `json:"cases_failed" bson:"cases_failed"`
+	Message     string `json:"message"      bson:"message"`
+	UpdateTime  int64  `json:"update_time"  bson:"update_time"`
+}
+
+// MergeJobCheckpoints merges the checkpoints of the pods which have run the same job,
+// so that the results of the pods interrupted halfway are not lost.
+func MergeJobCheckpoints(checkpoints []*JobCheckpoint) *JobCheckpoint {
+	merged := &JobCheckpoint{}
+	for _, checkpoint := range checkpoints {
+		if checkpoint.CasesTotal > merged.CasesTotal {
+			merged.CasesTotal = checkpoint.CasesTotal
+		}
+		merged.CasesRun += checkpoint.CasesRun
+		merged.CasesPassed += checkpoint.CasesPassed
+		merged.CasesFailed += checkpoint.CasesFailed
+		if checkpoint.UpdateTime >= merged.UpdateTime {
+			merged.UpdateTime = checkpoint.UpdateTime
+			merged.Message = checkpoint.Message
+		}
+	}
+	return merged
+}
+
 func GetJobOutputKey(key, outputName string) string {
 	return fmt.Sprintf(setting.RenderValueTemplate, strings.Join([]string{"job", key, "output", outputName}, "."))
 }
+
+// ParseJobOutputs parses the KEY=VALUE lines written to the output file, the empty lines and the lines starting
+// with # are ignored. The later value wins if a key is written more than once.
+func ParseJobOutputs(content string) ([]*JobOutput, error) {
+	outputs := make([]*JobOutput, 0)
+	indexes := make(map[string]int)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !outputNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid output line %q, it must be KEY=VALUE and the key must match %s", line, outputNameRegex.String())
+		}
+		if i, ok := indexes[name]; ok {
+			outputs[i].Value = value
+			continue
+		}
+		indexes[name] = len(outputs)
+		outputs = append(outputs, &JobOutput{Name: name, Value: value})
+	}
+	return outputs, scanner.Err()
+}
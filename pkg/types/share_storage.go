@@ -22,7 +22,17 @@ import (
 )
 
 const (
-	pathPrefix = "zadig-share-storage"
+	pathPrefix      = "zadig-share-storage"
+	cachePathPrefix = "zadig-share-cache"
+)
+
+type ShareStorageScope string
+
+const (
+	// ShareStorageScopeTask storage is created for every task and removed when the task is done
+	ShareStorageScopeTask ShareStorageScope = "task"
+	// ShareStorageScopeWorkflow storage is kept between tasks of one workflow, used as dependency cache
+	ShareStorageScopeWorkflow ShareStorageScope = "workflow"
 )
 
 type ShareStorage struct {
@@ -37,3 +47,7 @@ func GetShareStorageSubPath(workflowName, storageName string, taskID int64) stri
 func GetShareStorageSubPathPrefix(workflowName string, taskID int64) string {
 	return path.Join(pathPrefix, fmt.Sprintf("%s-%d", workflowName, taskID))
 }
+
+func GetShareStorageCacheSubPath(workflowName, storageName string) string {
+	return path.Join(cachePathPrefix, workflowName, storageName)
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2024 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package step
+
+import "github.com/koderover/zadig/v2/pkg/types"
+
+type StepCoverageReportSpec struct {
+	SourceWorkflow string `bson:"source_workflow"           json:"source_workflow"          yaml:"source_workflow"`
+	SourceJobKey   string `bson:"source_job_key"            json:"source_job_key"           yaml:"source_job_key"`
+	JobTaskName    string `bson:"job_task_name"             json:"job_task_name"            yaml:"job_task_name"`
+	TaskID         int64  `bson:"task_id"                   json:"task_id"                  yaml:"task_id"`
+	ProjectName    string `bson:"project_name"              json:"project_name"             yaml:"project_name"`
+	ServiceName    string `bson:"service_name"              json:"service_name"             yaml:"service_name"`
+	ServiceModule  string `bson:"service_module"            json:"service_module"           yaml:"service_module"`
+	TestName       string `bson:"test_name"                 json:"test_name"                yaml:"test_name"`
+	// ReportPath is the coverage report file relative to the workspace
+	ReportPath string `bson:"report_path"               json:"report_path"              yaml:"report_path"`
+	// Format is lcov or cobertura
+	Format string `bson:"format"                    json:"format"                   yaml:"format"`
+	// RegressionThreshold is the max allowed drop of line coverage in percentage points compared
+	// with the baseline, the step fails if the coverage drops more than it, 0 means no check
+	RegressionThreshold float64 `bson:"regression_threshold"      json:"regression_threshold"     yaml:"regression_threshold"`
+	// BaselineLineRate is the line coverage of the target branch, filled before the job runs
+	BaselineLineRate float64             `bson:"baseline_line_rate"        json:"baseline_line_rate"       yaml:"baseline_line_rate"`
+	HasBaseline      bool                `bson:"has_baseline"              json:"has_baseline"             yaml:"has_baseline"`
+	S3DestDir        string              `bson:"s3_dest_dir"               json:"s3_dest_dir"              yaml:"s3_dest_dir"`
+	FileName         string              `bson:"file_name"                 json:"file_name"                yaml:"file_name"`
+	S3Storage        *S3                 `bson:"s3_storage"                json:"s3_storage"               yaml:"s3_storage"`
+	Repos            []*types.Repository `bson:"repos"                     json:"repos"                    yaml:"repos"`
+}
@@ -28,6 +28,16 @@ type StepImageDistributeSpec struct {
 	SourceRegistry   *RegistryNamespace           `bson:"source_registry"                json:"source_registry"               yaml:"source_registry"`
 	TargetRegistry   *RegistryNamespace           `bson:"target_registry"                json:"target_registry"               yaml:"target_registry"`
 	DistributeTarget []*DistributeTaskTarget      `bson:"distribute_target"              json:"distribute_target"             yaml:"distribute_target"`
+	// Sign signs the target images with cosign after pushed if not nil
+	Sign *ImageSign `bson:"sign,omitempty" json:"sign,omitempty" yaml:"sign,omitempty"`
+}
+
+type ImageSign struct {
+	Mode          string `bson:"mode"           json:"mode"           yaml:"mode"`
+	PrivateKey    string `bson:"private_key"    json:"private_key"    yaml:"private_key"`
+	Password      string `bson:"password"       json:"password"       yaml:"password"`
+	IdentityToken string `bson:"identity_token" json:"identity_token" yaml:"identity_token"`
+	TlogDisabled  bool   `bson:"tlog_disabled"  json:"tlog_disabled"  yaml:"tlog_disabled"`
 }
 
 type DistributeTaskTarget struct {
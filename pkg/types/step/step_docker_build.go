@@ -36,6 +36,46 @@ type StepDockerBuildSpec struct {
 	IgnoreCache           bool                `bson:"ignore_cache"                        json:"ignore_cache"                           yaml:"ignore_cache"`
 	DockerRegistry        *DockerRegistry     `bson:"docker_registry"                     json:"docker_registry"                        yaml:"docker_registry"`
 	Repos                 []*types.Repository `bson:"repos"                               json:"repos"`
+	// RemoteCache is set when the image is built by buildx with the remote cache
+	RemoteCache *DockerRemoteCache `bson:"remote_cache,omitempty" json:"remote_cache,omitempty" yaml:"remote_cache,omitempty"`
+}
+
+type DockerRemoteCache struct {
+	Type string `bson:"type"                json:"type"                yaml:"type"`
+	// Ref is the cache image for the registry cache
+	Ref string `bson:"ref,omitempty"       json:"ref,omitempty"       yaml:"ref,omitempty"`
+	// S3 and Prefix are used for the s3 cache
+	S3     *S3    `bson:"s3,omitempty"        json:"s3,omitempty"        yaml:"s3,omitempty"`
+	Prefix string `bson:"prefix,omitempty"    json:"prefix,omitempty"    yaml:"prefix,omitempty"`
+	Name   string `bson:"name"                json:"name"                yaml:"name"`
+	// Hits and Steps are collected from the build output after the build
+	Hits  int `bson:"hits"                json:"hits"                yaml:"hits"`
+	Steps int `bson:"steps"               json:"steps"               yaml:"steps"`
+}
+
+// CacheArgs returns the buildx args to import and export the remote cache
+func (c *DockerRemoteCache) CacheArgs() []string {
+	var cache string
+	switch c.Type {
+	case string(types.DockerRegistryCache):
+		cache = fmt.Sprintf("type=registry,ref=%s", c.Ref)
+	case string(types.DockerS3Cache):
+		if c.S3 == nil {
+			return nil
+		}
+		cache = fmt.Sprintf("type=s3,bucket=%s,region=%s,name=%s,prefix=%s,access_key_id=%s,secret_access_key=%s,use_path_style=true",
+			c.S3.Bucket, c.S3.Region, c.Name, c.Prefix, c.S3.Ak, c.S3.Sk)
+		if c.S3.Endpoint != "" {
+			protocol := c.S3.Protocol
+			if protocol == "" {
+				protocol = "https"
+			}
+			cache = fmt.Sprintf("%s,endpoint_url=%s://%s", cache, protocol, c.S3.Endpoint)
+		}
+	default:
+		return nil
+	}
+	return []string{"--cache-from", cache, "--cache-to", cache + ",mode=max"}
 }
 
 type DockerRegistry struct {
@@ -16,6 +16,11 @@ limitations under the License.
 
 package step
 
+const (
+	TestReportTypeJunit  = "junit"
+	TestReportTypeAllure = "allure"
+)
+
 type StepJunitReportSpec struct {
 	SourceWorkflow string `bson:"source_workflow"           json:"source_workflow"                   yaml:"source_workflow"`
 	// no stage name is recorded since the job name is unique, for now (version 2.1.0)
@@ -31,4 +36,6 @@ type StepJunitReportSpec struct {
 	TestName      string `bson:"test_name"                  json:"test_name"                         yaml:"test_name"`
 	TestProject   string `bson:"test_project"               json:"test_project"                      yaml:"test_project"`
 	S3Storage     *S3    `bson:"s3_storage"                 json:"s3_storage"                        yaml:"s3_storage"`
+	// ReportType is the format of the results in report dir, junit xml is used if empty
+	ReportType string `bson:"report_type"                json:"report_type"                       yaml:"report_type"`
 }